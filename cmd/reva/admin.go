@@ -0,0 +1,447 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	collaboration "github.com/cs3org/go-cs3apis/cs3/sharing/collaboration/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/storage/migrate"
+	"github.com/cs3org/reva/pkg/token"
+	"github.com/jedib0t/go-pretty/table"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/metadata"
+)
+
+// adminFlags are the flags of the admin commands, which act on behalf of a
+// user by impersonating them with the token of the logged in admin.
+type adminFlags struct {
+	user *string
+}
+
+func addAdminFlags(cmd *command) *adminFlags {
+	return &adminFlags{
+		user: cmd.String("user", "", "the username of the user to act on behalf of"),
+	}
+}
+
+func (f *adminFlags) reset() {
+	*f.user = ""
+}
+
+// authenticate returns a context carrying a token of the user, obtained with
+// the adminimpersonation auth and the token of the logged in admin.
+func (f *adminFlags) authenticate(client gateway.GatewayAPIClient) (context.Context, *userpb.User, error) {
+	if *f.user == "" {
+		return nil, nil, errors.New("User cannot be empty: use -user flag")
+	}
+	adminToken, err := readToken()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "error reading the token of the admin: run the login command first")
+	}
+
+	res, err := client.Authenticate(context.Background(), &gateway.AuthenticateRequest{
+		Type:         "adminimpersonation",
+		ClientId:     *f.user,
+		ClientSecret: adminToken,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return nil, nil, formatError(res.Status)
+	}
+
+	ctx := token.ContextSetToken(context.Background(), res.Token)
+	ctx = metadata.AppendToOutgoingContext(ctx, token.TokenHeader, res.Token)
+	return ctx, res.User, nil
+}
+
+func adminShareListCommand() *command {
+	cmd := newCommand("admin-share-list")
+	cmd.Description = func() string { return "list the shares created by a user" }
+	cmd.Usage = func() string { return "Usage: admin-share-list -user <username> [-flags]" }
+	admin := addAdminFlags(cmd)
+
+	cmd.ResetFlags = admin.reset
+
+	cmd.Action = func(w ...io.Writer) error {
+		client, err := getClient()
+		if err != nil {
+			return err
+		}
+		ctx, _, err := admin.authenticate(client)
+		if err != nil {
+			return err
+		}
+
+		res, err := client.ListShares(ctx, &collaboration.ListSharesRequest{})
+		if err != nil {
+			return err
+		}
+		if res.Status.Code != rpc.Code_CODE_OK {
+			return formatError(res.Status)
+		}
+
+		if jsonOutput {
+			return printJSON(res.Shares)
+		}
+
+		t := table.NewWriter()
+		t.SetOutputMirror(os.Stdout)
+		t.AppendHeader(table.Row{"#", "ResourceId", "Permissions", "Type", "Grantee.Idp", "Grantee.OpaqueId", "Created", "Updated"})
+		for _, s := range res.Shares {
+			var idp, opaque string
+			if s.Grantee.Type == provider.GranteeType_GRANTEE_TYPE_USER {
+				idp, opaque = s.Grantee.GetUserId().Idp, s.Grantee.GetUserId().OpaqueId
+			} else if s.Grantee.Type == provider.GranteeType_GRANTEE_TYPE_GROUP {
+				idp, opaque = s.Grantee.GetGroupId().Idp, s.Grantee.GetGroupId().OpaqueId
+			}
+			t.AppendRows([]table.Row{
+				{s.Id.OpaqueId, s.ResourceId.String(), s.Permissions.String(), s.Grantee.Type.String(), idp, opaque,
+					time.Unix(int64(s.Ctime.Seconds), 0), time.Unix(int64(s.Mtime.Seconds), 0)},
+			})
+		}
+		t.Render()
+		return nil
+	}
+	return cmd
+}
+
+func adminShareRemoveCommand() *command {
+	cmd := newCommand("admin-share-remove")
+	cmd.Description = func() string { return "revoke a share created by a user" }
+	cmd.Usage = func() string { return "Usage: admin-share-remove -user <username> [-flags] <share_id>" }
+	admin := addAdminFlags(cmd)
+
+	cmd.ResetFlags = admin.reset
+
+	cmd.Action = func(w ...io.Writer) error {
+		if cmd.NArg() < 1 {
+			return errors.New("Invalid arguments: " + cmd.Usage())
+		}
+
+		client, err := getClient()
+		if err != nil {
+			return err
+		}
+		ctx, _, err := admin.authenticate(client)
+		if err != nil {
+			return err
+		}
+
+		res, err := client.RemoveShare(ctx, &collaboration.RemoveShareRequest{
+			Ref: &collaboration.ShareReference{
+				Spec: &collaboration.ShareReference_Id{
+					Id: &collaboration.ShareId{OpaqueId: cmd.Args()[0]},
+				},
+			},
+		})
+		if err != nil {
+			return err
+		}
+		if res.Status.Code != rpc.Code_CODE_OK {
+			return formatError(res.Status)
+		}
+
+		infof("OK\n")
+		return nil
+	}
+	return cmd
+}
+
+func adminSpaceListCommand() *command {
+	cmd := newCommand("admin-space-list")
+	cmd.Description = func() string { return "list the storage spaces owned by a user" }
+	cmd.Usage = func() string { return "Usage: admin-space-list -user <username> [-flags]" }
+	admin := addAdminFlags(cmd)
+	spaceType := cmd.String("type", "", "filter by space type")
+
+	cmd.ResetFlags = func() {
+		admin.reset()
+		*spaceType = ""
+	}
+
+	cmd.Action = func(w ...io.Writer) error {
+		client, err := getClient()
+		if err != nil {
+			return err
+		}
+		ctx, u, err := admin.authenticate(client)
+		if err != nil {
+			return err
+		}
+
+		filters := []*provider.ListStorageSpacesRequest_Filter{
+			{
+				Type: provider.ListStorageSpacesRequest_Filter_TYPE_OWNER,
+				Term: &provider.ListStorageSpacesRequest_Filter_Owner{Owner: u.Id},
+			},
+		}
+		if *spaceType != "" {
+			filters = append(filters, &provider.ListStorageSpacesRequest_Filter{
+				Type: provider.ListStorageSpacesRequest_Filter_TYPE_SPACE_TYPE,
+				Term: &provider.ListStorageSpacesRequest_Filter_SpaceType{SpaceType: *spaceType},
+			})
+		}
+
+		spaces, err := listSpaces(ctx, client, filters)
+		if err != nil {
+			return err
+		}
+
+		if jsonOutput {
+			return printJSON(spaces)
+		}
+
+		t := table.NewWriter()
+		t.SetOutputMirror(os.Stdout)
+		t.AppendHeader(table.Row{"#", "Name", "Type", "Root", "Quota.MaxBytes", "Quota.MaxFiles"})
+		for _, s := range spaces {
+			var maxBytes, maxFiles uint64
+			if s.Quota != nil {
+				maxBytes, maxFiles = s.Quota.QuotaMaxBytes, s.Quota.QuotaMaxFiles
+			}
+			t.AppendRows([]table.Row{
+				{s.Id.GetOpaqueId(), s.Name, s.SpaceType, s.Root.String(), maxBytes, maxFiles},
+			})
+		}
+		t.Render()
+		return nil
+	}
+	return cmd
+}
+
+func adminSpaceUpdateCommand() *command {
+	cmd := newCommand("admin-space-update")
+	cmd.Description = func() string { return "update the name or the quota of a storage space of a user" }
+	cmd.Usage = func() string { return "Usage: admin-space-update -user <username> [-flags] <space_id>" }
+	admin := addAdminFlags(cmd)
+	name := cmd.String("name", "", "the new name of the space")
+	maxBytes := cmd.Int64("quota-max-bytes", -1, "the maximum number of bytes, 0 means unlimited")
+	maxFiles := cmd.Int64("quota-max-files", -1, "the maximum number of files, 0 means unlimited")
+
+	cmd.ResetFlags = func() {
+		admin.reset()
+		*name, *maxBytes, *maxFiles = "", -1, -1
+	}
+
+	cmd.Action = func(w ...io.Writer) error {
+		if cmd.NArg() < 1 {
+			return errors.New("Invalid arguments: " + cmd.Usage())
+		}
+
+		client, err := getClient()
+		if err != nil {
+			return err
+		}
+		ctx, _, err := admin.authenticate(client)
+		if err != nil {
+			return err
+		}
+
+		spaces, err := listSpaces(ctx, client, []*provider.ListStorageSpacesRequest_Filter{
+			{
+				Type: provider.ListStorageSpacesRequest_Filter_TYPE_ID,
+				Term: &provider.ListStorageSpacesRequest_Filter_Id{Id: &provider.StorageSpaceId{OpaqueId: cmd.Args()[0]}},
+			},
+		})
+		if err != nil {
+			return err
+		}
+		if len(spaces) == 0 {
+			return fmt.Errorf("space %s not found", cmd.Args()[0])
+		}
+
+		space := spaces[0]
+		if *name != "" {
+			space.Name = *name
+		}
+		if *maxBytes >= 0 || *maxFiles >= 0 {
+			if space.Quota == nil {
+				space.Quota = &provider.Quota{}
+			}
+			if *maxBytes >= 0 {
+				space.Quota.QuotaMaxBytes = uint64(*maxBytes)
+			}
+			if *maxFiles >= 0 {
+				space.Quota.QuotaMaxFiles = uint64(*maxFiles)
+			}
+		}
+
+		res, err := client.UpdateStorageSpace(ctx, &provider.UpdateStorageSpaceRequest{StorageSpace: space})
+		if err != nil {
+			return err
+		}
+		if res.Status.Code != rpc.Code_CODE_OK {
+			return formatError(res.Status)
+		}
+
+		if jsonOutput {
+			return printJSON(res.StorageSpace)
+		}
+		infof("OK\n")
+		return nil
+	}
+	return cmd
+}
+
+func adminRecyclePurgeCommand() *command {
+	cmd := newCommand("admin-recycle-purge")
+	cmd.Description = func() string { return "purge the recycle bin of a user" }
+	cmd.Usage = func() string { return "Usage: admin-recycle-purge -user <username> [-flags]" }
+	admin := addAdminFlags(cmd)
+
+	cmd.ResetFlags = admin.reset
+
+	cmd.Action = func(w ...io.Writer) error {
+		client, err := getClient()
+		if err != nil {
+			return err
+		}
+		ctx, _, err := admin.authenticate(client)
+		if err != nil {
+			return err
+		}
+
+		home, err := client.GetHome(ctx, &provider.GetHomeRequest{})
+		if err != nil {
+			return err
+		}
+		if home.Status.Code != rpc.Code_CODE_OK {
+			return formatError(home.Status)
+		}
+
+		res, err := client.PurgeRecycle(ctx, &gateway.PurgeRecycleRequest{
+			Ref: &provider.Reference{
+				Spec: &provider.Reference_Path{Path: home.Path},
+			},
+		})
+		if err != nil {
+			return err
+		}
+		if res.Status.Code != rpc.Code_CODE_OK {
+			return formatError(res.Status)
+		}
+
+		infof("OK\n")
+		return nil
+	}
+	return cmd
+}
+
+func adminUserExportCommand() *command {
+	cmd := newCommand("admin-user-export")
+	cmd.Description = func() string { return "export the files, versions, shares and trash metadata of a user" }
+	cmd.Usage = func() string { return "Usage: admin-user-export -user <username> [-flags] <export folder>" }
+	admin := addAdminFlags(cmd)
+
+	cmd.ResetFlags = admin.reset
+
+	cmd.Action = func(w ...io.Writer) error {
+		if cmd.NArg() < 1 {
+			return errors.New("Invalid arguments: " + cmd.Usage())
+		}
+
+		gwc, err := getClient()
+		if err != nil {
+			return err
+		}
+		ctx, u, err := admin.authenticate(gwc)
+		if err != nil {
+			return err
+		}
+
+		dir, err := migrate.Export(ctx, gwc, client, u, cmd.Args()[0])
+		if err != nil {
+			return err
+		}
+
+		infof("exported to %s\n", dir)
+		return nil
+	}
+	return cmd
+}
+
+func adminUserImportCommand() *command {
+	cmd := newCommand("admin-user-import")
+	cmd.Description = func() string { return "import the files and shares of a user exported with admin-user-export" }
+	cmd.Usage = func() string { return "Usage: admin-user-import -user <username> [-flags] <user export folder>" }
+	admin := addAdminFlags(cmd)
+	target := cmd.String("target", "", "the folder to import into, defaults to the home of the user")
+
+	cmd.ResetFlags = func() {
+		admin.reset()
+		*target = ""
+	}
+
+	cmd.Action = func(w ...io.Writer) error {
+		if cmd.NArg() < 1 {
+			return errors.New("Invalid arguments: " + cmd.Usage())
+		}
+
+		gwc, err := getClient()
+		if err != nil {
+			return err
+		}
+		ctx, _, err := admin.authenticate(gwc)
+		if err != nil {
+			return err
+		}
+
+		root := *target
+		if root == "" {
+			home, err := gwc.GetHome(ctx, &provider.GetHomeRequest{})
+			if err != nil {
+				return err
+			}
+			if home.Status.Code != rpc.Code_CODE_OK {
+				return formatError(home.Status)
+			}
+			root = home.Path
+		}
+
+		if err := migrate.ImportUser(ctx, gwc, client, cmd.Args()[0], root); err != nil {
+			return err
+		}
+
+		infof("OK\n")
+		return nil
+	}
+	return cmd
+}
+
+func listSpaces(ctx context.Context, client gateway.GatewayAPIClient, filters []*provider.ListStorageSpacesRequest_Filter) ([]*provider.StorageSpace, error) {
+	res, err := client.ListStorageSpaces(ctx, &provider.ListStorageSpacesRequest{Filters: filters})
+	if err != nil {
+		return nil, err
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return nil, formatError(res.Status)
+	}
+	return res.StorageSpaces, nil
+}
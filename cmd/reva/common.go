@@ -30,8 +30,9 @@ import (
 )
 
 const (
-	viewerPermission string = "viewer"
-	editorPermission string = "editor"
+	viewerPermission  string = "viewer"
+	editorPermission  string = "editor"
+	managerPermission string = "manager"
 )
 
 type config struct {
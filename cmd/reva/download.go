@@ -19,16 +19,23 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/cheggaaa/pb"
 	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
 	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
 	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
 	"github.com/cs3org/reva/internal/http/services/datagateway"
 	"github.com/cs3org/reva/pkg/errtypes"
 	"github.com/cs3org/reva/pkg/rhttp"
@@ -40,8 +47,16 @@ import (
 
 func downloadCommand() *command {
 	cmd := newCommand("download")
-	cmd.Description = func() string { return "download a remote file to the local filesystem" }
+	cmd.Description = func() string { return "download a remote file or folder to the local filesystem" }
 	cmd.Usage = func() string { return "Usage: download [-flags] <remote_file> <local_file>" }
+	recursiveFlag := cmd.Bool("r", false, "download a folder recursively")
+	parallelFlag := cmd.Int("j", 4, "the number of files downloaded in parallel when downloading recursively")
+	segmentsFlag := cmd.Int("s", 1, "the number of segments of a file downloaded in parallel")
+
+	cmd.ResetFlags = func() {
+		*recursiveFlag, *parallelFlag, *segmentsFlag = false, 4, 1
+	}
+
 	cmd.Action = func(w ...io.Writer) error {
 		if cmd.NArg() < 2 {
 			return errors.New("Invalid arguments: " + cmd.Usage())
@@ -70,84 +85,264 @@ func downloadCommand() *command {
 
 		info := res1.Info
 
-		req2 := &provider.InitiateFileDownloadRequest{
-			Ref: &provider.Reference{
-				Spec: &provider.Reference_Path{
-					Path: remote,
-				},
-			},
-		}
-		res, err := client.InitiateFileDownload(ctx, req2)
+		absPath, err := utils.ResolvePath(local)
 		if err != nil {
 			return err
 		}
 
-		if res.Status.Code != rpc.Code_CODE_OK {
-			return formatError(res.Status)
+		d := &downloader{ctx: ctx, gwc: client, segments: *segmentsFlag}
+
+		if info.Type == provider.ResourceType_RESOURCE_TYPE_CONTAINER {
+			if !*recursiveFlag {
+				return errors.New("download: " + remote + " is a folder, use the -r flag to download it recursively")
+			}
+			return d.downloadTree(info, absPath, *parallelFlag)
 		}
 
-		p, err := getDownloadProtocolInfo(res.Protocols, "simple")
+		d.verbose = true
+		d.bar = newProgressBar(int64(info.Size))
+		err = d.download(info, absPath)
+		d.bar.Finish()
+		return err
+	}
+	return cmd
+}
+
+type downloader struct {
+	ctx      context.Context
+	gwc      gateway.GatewayAPIClient
+	bar      *pb.ProgressBar
+	verbose  bool
+	segments int
+}
+
+// downloadTree downloads the remote folder to the local path, creating the
+// folders first and then downloading the files in parallel.
+func (d *downloader) downloadTree(root *provider.ResourceInfo, local string, parallel int) error {
+	var jobs []transferJob
+	var total int64
+	var walk func(info *provider.ResourceInfo, local string) error
+	walk = func(info *provider.ResourceInfo, local string) error {
+		if err := os.MkdirAll(local, 0755); err != nil {
+			return err
+		}
+		res, err := d.gwc.ListContainer(d.ctx, &provider.ListContainerRequest{
+			Ref: &provider.Reference{
+				Spec: &provider.Reference_Path{Path: info.Path},
+			},
+		})
 		if err != nil {
 			return err
 		}
+		if res.Status.Code != rpc.Code_CODE_OK {
+			return formatError(res.Status)
+		}
+		for _, child := range res.Infos {
+			p := filepath.Join(local, path.Base(child.Path))
+			if child.Type == provider.ResourceType_RESOURCE_TYPE_CONTAINER {
+				if err := walk(child, p); err != nil {
+					return err
+				}
+				continue
+			}
+			jobs = append(jobs, transferJob{local: p, remote: child.Path, info: child})
+			total += int64(child.Size)
+		}
+		return nil
+	}
+	if err := walk(root, local); err != nil {
+		return err
+	}
 
-		// TODO(labkode): upload to data server
-		fmt.Printf("Downloading from: %s\n", p.DownloadEndpoint)
+	infof("Downloading %d files (%d bytes)\n", len(jobs), total)
+	d.bar = newProgressBar(total)
+	err := runParallel(jobs, parallel, func(job transferJob) error {
+		return d.download(job.info, job.local)
+	})
+	d.bar.Finish()
+	return err
+}
 
-		content, err := checkDownloadWebdavRef(res.Protocols)
-		if err != nil {
-			if _, ok := err.(errtypes.IsNotSupported); !ok {
-				return err
-			}
+// download downloads the remote file to the local path, verifying its
+// checksum when the storage provides one.
+func (d *downloader) download(info *provider.ResourceInfo, local string) error {
+	req := &provider.InitiateFileDownloadRequest{
+		Ref: &provider.Reference{
+			Spec: &provider.Reference_Path{
+				Path: info.Path,
+			},
+		},
+	}
+	if d.segments > 1 {
+		req.Opaque = &types.Opaque{Map: map[string]*types.OpaqueEntry{
+			"download_segments": {Decoder: "plain", Value: []byte(strconv.Itoa(d.segments))},
+		}}
+	}
+	res, err := d.gwc.InitiateFileDownload(d.ctx, req)
+	if err != nil {
+		return err
+	}
 
-			dataServerURL := p.DownloadEndpoint
-			// TODO(labkode): do a protocol switch
-			httpReq, err := rhttp.NewRequest(ctx, "GET", dataServerURL, nil)
-			if err != nil {
-				return err
-			}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return formatError(res.Status)
+	}
 
-			httpReq.Header.Set(datagateway.TokenTransportHeader, p.Token)
-			httpClient := rhttp.GetHTTPClient(
-				rhttp.Context(ctx),
-				// TODO make insecure configurable
-				rhttp.Insecure(true),
-				// TODO make timeout configurable
-				rhttp.Timeout(time.Duration(24*int64(time.Hour))),
-			)
+	// the gateway splits the file into segments when it supports them
+	if e := res.Opaque.GetMap()["download_segments"]; e != nil {
+		if err := d.downloadSegments(e.Value, local); err != nil {
+			return err
+		}
+		return verifyDownload(info, local)
+	}
 
-			httpRes, err := httpClient.Do(httpReq)
-			if err != nil {
-				return err
-			}
-			defer httpRes.Body.Close()
+	p, err := getDownloadProtocolInfo(res.Protocols, "simple")
+	if err != nil {
+		return err
+	}
 
-			if httpRes.StatusCode != http.StatusOK {
-				return err
-			}
-			content = httpRes.Body
+	if d.verbose {
+		infof("Downloading from: %s\n", p.DownloadEndpoint)
+	}
+
+	content, err := checkDownloadWebdavRef(res.Protocols)
+	if err != nil {
+		if _, ok := err.(errtypes.IsNotSupported); !ok {
+			return err
 		}
 
-		absPath, err := utils.ResolvePath(local)
+		dataServerURL := p.DownloadEndpoint
+		// TODO(labkode): do a protocol switch
+		httpReq, err := rhttp.NewRequest(d.ctx, "GET", dataServerURL, nil)
 		if err != nil {
 			return err
 		}
 
-		bar := pb.New(int(info.Size)).SetUnits(pb.U_BYTES)
-		bar.Start()
-		reader := bar.NewProxyReader(content)
+		httpReq.Header.Set(datagateway.TokenTransportHeader, p.Token)
+		httpClient := rhttp.GetHTTPClient(
+			rhttp.Context(d.ctx),
+			// TODO make insecure configurable
+			rhttp.Insecure(true),
+			// TODO make timeout configurable
+			rhttp.Timeout(time.Duration(24*int64(time.Hour))),
+		)
 
-		fd, err := os.OpenFile(absPath, os.O_CREATE|os.O_WRONLY, 0644)
+		httpRes, err := httpClient.Do(httpReq)
 		if err != nil {
 			return err
 		}
-		if _, err := io.Copy(fd, reader); err != nil {
-			return err
+		defer httpRes.Body.Close()
+
+		if httpRes.StatusCode != http.StatusOK {
+			return errors.New("download: GET request returned " + httpRes.Status)
 		}
-		bar.Finish()
+		content = httpRes.Body
+	}
+
+	fd, err := os.OpenFile(local, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	if _, err := io.Copy(fd, d.bar.NewProxyReader(content)); err != nil {
+		return err
+	}
+	return verifyDownload(info, local)
+}
+
+// downloadSegments downloads the segments of a file in parallel, each one
+// being written at its offset of the local file.
+func (d *downloader) downloadSegments(value []byte, local string) error {
+	var segments []struct {
+		Offset int64  `json:"offset"`
+		Length int64  `json:"length"`
+		URL    string `json:"url"`
+	}
+	if err := json.Unmarshal(value, &segments); err != nil {
+		return err
+	}
+
+	fd, err := os.OpenFile(local, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	httpClient := rhttp.GetHTTPClient(
+		rhttp.Context(d.ctx),
+		// TODO make insecure configurable
+		rhttp.Insecure(true),
+		// TODO make timeout configurable
+		rhttp.Timeout(time.Duration(24*int64(time.Hour))),
+	)
+
+	errs := make(chan error, len(segments))
+	var wg sync.WaitGroup
+	for i := range segments {
+		wg.Add(1)
+		go func(offset, length int64, url string) {
+			defer wg.Done()
+			httpReq, err := rhttp.NewRequest(d.ctx, "GET", url, nil)
+			if err != nil {
+				errs <- err
+				return
+			}
+			httpRes, err := httpClient.Do(httpReq)
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer httpRes.Body.Close()
+			if httpRes.StatusCode != http.StatusPartialContent {
+				errs <- errors.New("download: GET request of segment returned " + httpRes.Status)
+				return
+			}
+			w := &offsetWriter{w: fd, offset: offset}
+			n, err := io.Copy(w, d.bar.NewProxyReader(httpRes.Body))
+			if err != nil {
+				errs <- err
+				return
+			}
+			if n != length {
+				errs <- fmt.Errorf("download: segment at offset %d is %d bytes long, expected %d", offset, n, length)
+			}
+		}(segments[i].Offset, segments[i].Length, segments[i].URL)
+	}
+	wg.Wait()
+	close(errs)
+	return <-errs
+}
+
+// offsetWriter writes sequentially to a file from a given offset.
+type offsetWriter struct {
+	w      io.WriterAt
+	offset int64
+}
+
+func (o *offsetWriter) Write(p []byte) (int, error) {
+	n, err := o.w.WriteAt(p, o.offset)
+	o.offset += int64(n)
+	return n, err
+}
+
+// verifyDownload verifies the checksum of a downloaded file, when the storage
+// provides one.
+func verifyDownload(info *provider.ResourceInfo, local string) error {
+	if info.Checksum == nil {
 		return nil
 	}
-	return cmd
+	// the file is read back from disk, to detect corrupted writes too.
+	rd, err := os.Open(local)
+	if err != nil {
+		return err
+	}
+	defer rd.Close()
+	xs, err := computeXS(info.Checksum.Type, rd)
+	if err != nil {
+		// checksums which cannot be computed locally are not verified.
+		return nil
+	}
+	return verifyChecksum(info, info.Checksum.Type, xs)
 }
 
 func getDownloadProtocolInfo(protocolInfos []*gateway.FileDownloadProtocol, protocol string) (*gateway.FileDownloadProtocol, error) {
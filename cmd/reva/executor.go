@@ -21,7 +21,6 @@ package main
 import (
 	"context"
 	"errors"
-	"fmt"
 	"os"
 	"os/signal"
 	"strings"
@@ -35,10 +34,17 @@ type Executor struct {
 
 // Execute provides execute commands
 func (e *Executor) Execute(s string) {
+	if err := e.Run(s); err != nil {
+		printError(err)
+	}
+}
+
+// Run executes the command, returning its error instead of printing it.
+func (e *Executor) Run(s string) error {
 	s = strings.TrimSpace(s)
 	switch s {
 	case "":
-		return
+		return nil
 	case "exit", "quit":
 		os.Exit(0)
 	}
@@ -49,8 +55,7 @@ func (e *Executor) Execute(s string) {
 	if conf == nil || conf.Host == "" {
 		c, err := readConfig()
 		if err != nil && args[0] != "configure" {
-			fmt.Println("reva is not configured, please pass the -host flag or run the configure command")
-			return
+			return errors.New("reva is not configured, please pass the -host flag or run the configure command")
 		} else if args[0] != "configure" {
 			conf = c
 		}
@@ -60,8 +65,7 @@ func (e *Executor) Execute(s string) {
 	for _, v := range commands {
 		if v.Name == action {
 			if err := v.Parse(args[1:]); err != nil {
-				fmt.Println(err)
-				return
+				return err
 			}
 			defer v.ResetFlags()
 
@@ -92,14 +96,11 @@ func (e *Executor) Execute(s string) {
 				}
 			}()
 
-			if err := executeWithContext(ctx, v); err != nil {
-				fmt.Println(err.Error())
-			}
-			return
+			return executeWithContext(ctx, v)
 		}
 	}
 
-	fmt.Println("Invalid command. Use \"help\" to list the available commands.")
+	return errors.New("Invalid command. Use \"help\" to list the available commands.")
 }
 
 func executeWithContext(ctx context.Context, cmd *command) error {
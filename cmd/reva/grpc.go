@@ -21,7 +21,6 @@ package main
 import (
 	"context"
 	"crypto/tls"
-	"fmt"
 	"log"
 
 	"google.golang.org/grpc/credentials"
@@ -55,17 +54,22 @@ func getClient() (gateway.GatewayAPIClient, error) {
 }
 
 func getConn() (*grpc.ClientConn, error) {
+	return getConnTo(conf.Host)
+}
+
+// getConnTo connects to the given service instead of the gateway.
+func getConnTo(host string) (*grpc.ClientConn, error) {
 	if insecure {
-		return grpc.Dial(conf.Host, grpc.WithInsecure())
+		return grpc.Dial(host, grpc.WithInsecure())
 	}
 
 	// TODO(labkode): if in the future we want client-side certificate validation,
 	// we need to load the client cert here
 	tlsconf := &tls.Config{InsecureSkipVerify: skipverify}
 	creds := credentials.NewTLS(tlsconf)
-	return grpc.Dial(conf.Host, grpc.WithTransportCredentials(creds))
+	return grpc.Dial(host, grpc.WithTransportCredentials(creds))
 }
 
 func formatError(status *rpc.Status) error {
-	return fmt.Errorf("error: code=%+v msg=%q support_trace=%q", status.Code, status.Message, status.Trace)
+	return &statusError{status: status}
 }
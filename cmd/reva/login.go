@@ -35,11 +35,12 @@ import (
 var loginCommand = func() *command {
 	cmd := newCommand("login")
 	cmd.Description = func() string { return "login into the reva server" }
-	cmd.Usage = func() string { return "Usage: login <type>" }
+	cmd.Usage = func() string { return "Usage: login [-flags] <type>" }
 	listFlag := cmd.Bool("list", false, "list available login methods")
+	usernameFlag := cmd.String("username", "", "the username, read along with the password from the REVA_PASSWORD environment variable instead of being prompted for")
 
 	cmd.ResetFlags = func() {
-		*listFlag = false
+		*listFlag, *usernameFlag = false, ""
 	}
 
 	cmd.Action = func(w ...io.Writer) error {
@@ -62,6 +63,9 @@ var loginCommand = func() *command {
 				return formatError(res.Status)
 			}
 
+			if len(w) == 0 && jsonOutput {
+				return printJSON(res.Types)
+			}
 			if len(w) == 0 {
 				fmt.Println("Available login methods:")
 				for _, v := range res.Types {
@@ -81,17 +85,19 @@ var loginCommand = func() *command {
 		}
 
 		authType := cmd.Args()[0]
-		reader := bufio.NewReader(os.Stdin)
-		fmt.Print("username: ")
-		username, err := read(reader)
-		if err != nil {
-			return err
-		}
+		username, password := *usernameFlag, os.Getenv("REVA_PASSWORD")
+		if username == "" {
+			var err error
+			reader := bufio.NewReader(os.Stdin)
+			fmt.Print("username: ")
+			if username, err = read(reader); err != nil {
+				return err
+			}
 
-		fmt.Print("password: ")
-		password, err := readPassword(0)
-		if err != nil {
-			return err
+			fmt.Print("password: ")
+			if password, err = readPassword(0); err != nil {
+				return err
+			}
 		}
 
 		client, err := getClient()
@@ -116,7 +122,7 @@ var loginCommand = func() *command {
 		}
 
 		writeToken(res.Token)
-		fmt.Println("OK")
+		infof("OK\n")
 		return nil
 	}
 	return cmd
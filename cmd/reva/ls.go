@@ -67,6 +67,9 @@ func lsCommand() *command {
 		}
 
 		infos := res.Infos
+		if len(w) == 0 && jsonOutput {
+			return printJSON(infos)
+		}
 		for _, info := range infos {
 			p := info.Path
 			if !*fullFlag {
@@ -34,6 +34,7 @@ var (
 	conf                                   *config
 	host                                   string
 	insecure, skipverify, disableargprompt bool
+	jsonOutput                             bool
 	timeout                                int
 
 	helpCommandOutput string
@@ -47,6 +48,13 @@ var (
 		configureCommand(),
 		loginCommand(),
 		whoamiCommand(),
+		adminShareListCommand(),
+		adminShareRemoveCommand(),
+		adminSpaceListCommand(),
+		adminSpaceUpdateCommand(),
+		adminRecyclePurgeCommand(),
+		adminUserExportCommand(),
+		adminUserImportCommand(),
 		importCommand(),
 		lsCommand(),
 		statCommand(),
@@ -55,6 +63,7 @@ var (
 		rmCommand(),
 		moveCommand(),
 		mkdirCommand(),
+		mountCommand(),
 		ocmFindAcceptedUsersCommand(),
 		ocmInviteGenerateCommand(),
 		ocmInviteForwardCommand(),
@@ -73,12 +82,19 @@ var (
 		recycleListCommand(),
 		recycleRestoreCommand(),
 		recyclePurgeCommand(),
+		revisionsPruneCommand(),
 		shareCreateCommand(),
+		syncCommand(),
 		shareListCommand(),
 		shareRemoveCommand(),
 		shareUpdateCommand(),
 		shareListReceivedCommand(),
 		shareUpdateReceivedCommand(),
+		spaceCreateCommand(),
+		spaceListCommand(),
+		spaceDisableCommand(),
+		spaceRestoreCommand(),
+		spacePurgeCommand(),
 		openInAppCommand(),
 		openFileInAppProviderCommand(),
 		transferCreateCommand(),
@@ -93,6 +109,7 @@ func init() {
 	flag.BoolVar(&insecure, "insecure", false, "disables grpc transport security")
 	flag.BoolVar(&skipverify, "skip-verify", false, "whether to skip verifying the server's certificate chain and host name")
 	flag.BoolVar(&disableargprompt, "disable-arg-prompt", false, "whether to disable prompts for command arguments")
+	flag.BoolVar(&jsonOutput, "json", false, "print the results of the commands as JSON, for scripting")
 	flag.IntVar(&timeout, "timout", -1, "the timeout in seconds for executing the commands, -1 means no timeout")
 	flag.Parse()
 }
@@ -119,9 +136,14 @@ func main() {
 	completer := Completer{DisableArgPrompt: disableargprompt}
 	completer.init()
 
+	// a command given as argument is run non-interactively, exiting with a
+	// code mapped from the status returned by the gateway.
 	if len(flag.Args()) > 0 {
-		executor.Execute(strings.Join(flag.Args(), " "))
-		return
+		err := executor.Run(strings.Join(flag.Args(), " "))
+		if err != nil {
+			printError(err)
+		}
+		os.Exit(exitCode(err))
 	}
 
 	fmt.Printf("reva-cli %s (rev-%s)\n", version, gitCommit)
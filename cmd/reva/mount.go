@@ -0,0 +1,121 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// +build linux darwin
+
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"bazil.org/fuse"
+	"bazil.org/fuse/fs"
+	"github.com/cheggaaa/pb"
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/fusefs"
+	"github.com/pkg/errors"
+)
+
+func mountCommand() *command {
+	cmd := newCommand("mount")
+	cmd.Description = func() string { return "mount the remote namespace as a local FUSE filesystem" }
+	cmd.Usage = func() string { return "Usage: mount [-flags] <remote_path> <mountpoint>" }
+	ttlFlag := cmd.Int("cache-ttl", 5, "the time in seconds the metadata of the remote files is cached")
+
+	cmd.ResetFlags = func() {
+		*ttlFlag = 5
+	}
+
+	cmd.Action = func(w ...io.Writer) error {
+		if cmd.NArg() < 2 {
+			return errors.New("Invalid arguments: " + cmd.Usage())
+		}
+
+		remote, mountpoint := cmd.Args()[0], cmd.Args()[1]
+
+		client, err := getClient()
+		if err != nil {
+			return err
+		}
+
+		c, err := fuse.Mount(mountpoint, fuse.FSName("reva"), fuse.Subtype("revafs"))
+		if err != nil {
+			return err
+		}
+		defer c.Close()
+
+		ctx := getAuthContext()
+		rfs := fusefs.New(client, remote, func() (context.Context, error) { return ctx, nil }, newCLITransfer(client), time.Duration(*ttlFlag)*time.Second)
+		done := make(chan error, 1)
+		go func() {
+			done <- fs.Serve(c, rfs)
+		}()
+
+		<-c.Ready
+		if err := c.MountError; err != nil {
+			return err
+		}
+		infof("%s mounted at %s, interrupt to unmount\n", remote, mountpoint)
+
+		sig := make(chan os.Signal, 1)
+		signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(sig)
+
+		select {
+		case <-sig:
+			if err := fuse.Unmount(mountpoint); err != nil {
+				return err
+			}
+			return <-done
+		case err := <-done:
+			return err
+		}
+	}
+	return cmd
+}
+
+// cliTransfer transfers the files with the downloader and the uploader of
+// the CLI.
+type cliTransfer struct {
+	gwc gateway.GatewayAPIClient
+	bar *pb.ProgressBar
+}
+
+func newCLITransfer(gwc gateway.GatewayAPIClient) *cliTransfer {
+	// the transfers are not shown in a progress bar.
+	bar := pb.New64(0)
+	bar.NotPrint = true
+	return &cliTransfer{gwc: gwc, bar: bar}
+}
+
+func (t *cliTransfer) Download(ctx context.Context, info *provider.ResourceInfo, local string) error {
+	d := &downloader{ctx: ctx, gwc: t.gwc, bar: t.bar}
+	return d.download(info, local)
+}
+
+func (t *cliTransfer) Upload(ctx context.Context, local, remote string) error {
+	u := &uploader{ctx: ctx, gwc: t.gwc, protocol: "tus", xs: "negotiate", bar: t.bar}
+	_, err := u.upload(local, remote)
+	return err
+}
@@ -55,6 +55,10 @@ func ocmFindAcceptedUsersCommand() *command {
 			return formatError(acceptedUsersRes.Status)
 		}
 
+		if len(w) == 0 && jsonOutput {
+			return printJSON(acceptedUsersRes.AcceptedUsers)
+		}
+
 		if len(w) == 0 {
 			t := table.NewWriter()
 			t.SetOutputMirror(os.Stdout)
@@ -20,7 +20,6 @@ package main
 
 import (
 	"errors"
-	"fmt"
 	"io"
 
 	invitepb "github.com/cs3org/go-cs3apis/cs3/ocm/invite/v1beta1"
@@ -30,8 +29,8 @@ import (
 
 func ocmInviteForwardCommand() *command {
 	cmd := newCommand("ocm-invite-forward")
-	cmd.Description = func() string { return "forward ocm invite token" }
-	cmd.Usage = func() string { return "Usage: ocm-invite-forward [-flags] <path>" }
+	cmd.Description = func() string { return "accept an ocm invite token by forwarding it to the provider which generated it" }
+	cmd.Usage = func() string { return "Usage: ocm-invite-forward -token <token> -idp <domain>" }
 	token := cmd.String("token", "", "invite token")
 	idp := cmd.String("idp", "", "the idp of the user who generated the token")
 
@@ -45,7 +44,7 @@ func ocmInviteForwardCommand() *command {
 			return errors.New("token cannot be empty: use -token flag\n" + cmd.Usage())
 		}
 		if *idp == "" {
-			return errors.New("Provider domain cannot be empty: use -provider flag\n" + cmd.Usage())
+			return errors.New("Provider domain cannot be empty: use -idp flag\n" + cmd.Usage())
 		}
 
 		ctx := getAuthContext()
@@ -80,7 +79,7 @@ func ocmInviteForwardCommand() *command {
 		if forwardToken.Status.Code != rpc.Code_CODE_OK {
 			return formatError(forwardToken.Status)
 		}
-		fmt.Println("OK")
+		infof("OK\n")
 		return nil
 	}
 	return cmd
@@ -21,6 +21,7 @@ package main
 import (
 	"fmt"
 	"io"
+	"time"
 
 	invitepb "github.com/cs3org/go-cs3apis/cs3/ocm/invite/v1beta1"
 	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
@@ -45,7 +46,19 @@ func ocmInviteGenerateCommand() *command {
 		if inviteToken.Status.Code != rpc.Code_CODE_OK {
 			return formatError(inviteToken.Status)
 		}
-		fmt.Println(inviteToken)
+
+		if jsonOutput {
+			return printJSON(inviteToken.InviteToken)
+		}
+
+		t := inviteToken.InviteToken
+		fmt.Printf("Token: %s\n", t.Token)
+		if t.Expiration != nil {
+			fmt.Printf("Expires: %s\n", time.Unix(int64(t.Expiration.Seconds), 0))
+		}
+		if t.UserId != nil {
+			fmt.Printf("The invitee can accept it with: ocm-invite-forward -token %s -idp %s\n", t.Token, t.UserId.Idp)
+		}
 		return nil
 	}
 	return cmd
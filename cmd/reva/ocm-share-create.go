@@ -147,6 +147,10 @@ func ocmShareCreateCommand() *command {
 			return formatError(shareRes.Status)
 		}
 
+		if jsonOutput {
+			return printJSON(shareRes.Share)
+		}
+
 		t := table.NewWriter()
 		t.SetOutputMirror(os.Stdout)
 		t.AppendHeader(table.Row{"#", "Owner.Idp", "Owner.OpaqueId", "ResourceId", "Permissions", "Type", "Grantee.Idp", "Grantee.OpaqueId", "Created", "Updated"})
@@ -51,6 +51,10 @@ func ocmShareListReceivedCommand() *command {
 			return formatError(shareRes.Status)
 		}
 
+		if len(w) == 0 && jsonOutput {
+			return printJSON(shareRes.Shares)
+		}
+
 		if len(w) == 0 {
 			t := table.NewWriter()
 			t.SetOutputMirror(os.Stdout)
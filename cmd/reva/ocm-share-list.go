@@ -79,6 +79,10 @@ func ocmShareListCommand() *command {
 			return formatError(shareRes.Status)
 		}
 
+		if len(w) == 0 && jsonOutput {
+			return printJSON(shareRes.Shares)
+		}
+
 		if len(w) == 0 {
 			t := table.NewWriter()
 			t.SetOutputMirror(os.Stdout)
@@ -0,0 +1,140 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	"github.com/cs3org/reva/pkg/utils"
+	"github.com/golang/protobuf/proto"
+)
+
+// Exit codes returned when running a single command, e.g. from a script.
+const (
+	exitOK = iota
+	exitError
+	exitInvalidArgument
+	exitNotFound
+	exitPermissionDenied
+	exitUnauthenticated
+	exitAlreadyExists
+	exitFailedPrecondition
+	exitInsufficientStorage
+	exitUnavailable
+)
+
+var exitCodes = map[rpc.Code]int{
+	rpc.Code_CODE_INVALID_ARGUMENT:     exitInvalidArgument,
+	rpc.Code_CODE_NOT_FOUND:            exitNotFound,
+	rpc.Code_CODE_PERMISSION_DENIED:    exitPermissionDenied,
+	rpc.Code_CODE_UNAUTHENTICATED:      exitUnauthenticated,
+	rpc.Code_CODE_ALREADY_EXISTS:       exitAlreadyExists,
+	rpc.Code_CODE_FAILED_PRECONDITION:  exitFailedPrecondition,
+	rpc.Code_CODE_INSUFFICIENT_STORAGE: exitInsufficientStorage,
+	rpc.Code_CODE_UNAVAILABLE:          exitUnavailable,
+}
+
+// statusError is returned by the commands when the gateway answers with a
+// status other than OK.
+type statusError struct {
+	status *rpc.Status
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("error: code=%+v msg=%q support_trace=%q", e.status.Code, e.status.Message, e.status.Trace)
+}
+
+// exitCode returns the exit code corresponding to the error returned by a
+// command.
+func exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+	var serr *statusError
+	if errors.As(err, &serr) {
+		if code, ok := exitCodes[serr.status.Code]; ok {
+			return code
+		}
+	}
+	return exitError
+}
+
+// printError prints the error returned by a command, as a JSON object on the
+// standard error when the JSON output is enabled.
+func printError(err error) {
+	if !jsonOutput {
+		fmt.Println(err.Error())
+		return
+	}
+
+	res := map[string]interface{}{"error": err.Error()}
+	var serr *statusError
+	if errors.As(err, &serr) {
+		res["code"] = serr.status.Code.String()
+		res["message"] = serr.status.Message
+		res["trace"] = serr.status.Trace
+	}
+	data, _ := json.Marshal(res)
+	fmt.Fprintln(os.Stderr, string(data))
+}
+
+// printJSON prints the given value on the standard output as JSON. Protobuf
+// messages, and slices of them, are marshalled with the protobuf JSON mapping.
+func printJSON(v interface{}) error {
+	data, err := marshalJSON(v)
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+func marshalJSON(v interface{}) ([]byte, error) {
+	if m, ok := v.(proto.Message); ok {
+		return utils.MarshalProtoV1ToJSON(m)
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Slice && rv.Type().Elem().Implements(reflect.TypeOf((*proto.Message)(nil)).Elem()) {
+		list := make([]json.RawMessage, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			data, err := utils.MarshalProtoV1ToJSON(rv.Index(i).Interface().(proto.Message))
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, data)
+		}
+		return json.Marshal(list)
+	}
+
+	return json.Marshal(v)
+}
+
+// infof prints informational messages, which are omitted when the JSON
+// output is enabled so that it can be parsed.
+func infof(format string, a ...interface{}) {
+	if !jsonOutput {
+		fmt.Printf(format, a...)
+	}
+}
@@ -21,16 +21,28 @@ package main
 import (
 	"fmt"
 	"io"
+	"strconv"
+	"time"
 
 	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
 	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
 	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
 )
 
 func recycleListCommand() *command {
 	cmd := newCommand("recycle-list")
 	cmd.Description = func() string { return "list a recycle bin" }
 	cmd.Usage = func() string { return "Usage: recycle-list [-flags] " }
+	limit := cmd.Int("limit", 0, "the maximum number of items to list, 0 means all")
+	token := cmd.String("page-token", "", "the token of the page to list, as printed by the previous listing")
+	pathPrefix := cmd.String("path", "", "only list the items originally below this path")
+	from := cmd.String("from", "", "only list the items deleted after this date (RFC3339)")
+	to := cmd.String("to", "", "only list the items deleted before this date (RFC3339)")
+
+	cmd.ResetFlags = func() {
+		*limit, *token, *pathPrefix, *from, *to = 0, "", "", "", ""
+	}
 
 	cmd.Action = func(w ...io.Writer) error {
 		client, err := getClient()
@@ -52,6 +64,26 @@ func recycleListCommand() *command {
 				},
 			},
 		}
+		opaque := map[string]*types.OpaqueEntry{}
+		if *limit > 0 {
+			opaque["page_size"] = &types.OpaqueEntry{Decoder: "plain", Value: []byte(strconv.Itoa(*limit))}
+		}
+		if *token != "" {
+			opaque["page_token"] = &types.OpaqueEntry{Decoder: "plain", Value: []byte(*token)}
+		}
+		if *pathPrefix != "" {
+			opaque["path_prefix"] = &types.OpaqueEntry{Decoder: "plain", Value: []byte(*pathPrefix)}
+		}
+		if len(opaque) > 0 {
+			req.Opaque = &types.Opaque{Map: opaque}
+		}
+		if req.FromTs, err = parseTimestamp(*from); err != nil {
+			return err
+		}
+		if req.ToTs, err = parseTimestamp(*to); err != nil {
+			return err
+		}
+
 		res, err := client.ListRecycle(ctx, req)
 		if err != nil {
 			return err
@@ -65,7 +97,21 @@ func recycleListCommand() *command {
 		for _, item := range items {
 			fmt.Printf("%+v\n", item)
 		}
+		if e := res.Opaque.GetMap()["next_page_token"]; e != nil {
+			fmt.Printf("next page: -page-token %s\n", e.Value)
+		}
 		return nil
 	}
 	return cmd
 }
+
+func parseTimestamp(v string) (*types.Timestamp, error) {
+	if v == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return nil, err
+	}
+	return &types.Timestamp{Seconds: uint64(t.Unix())}, nil
+}
@@ -0,0 +1,58 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package main
+
+import (
+	"io"
+
+	revisionspb "github.com/cs3org/reva/internal/grpc/services/storageprovider/proto"
+	"github.com/pkg/errors"
+)
+
+func revisionsPruneCommand() *command {
+	cmd := newCommand("revisions-prune")
+	cmd.Description = func() string {
+		return "delete the revisions not kept by the revision retention policy of a storage provider"
+	}
+	cmd.Usage = func() string { return "Usage: revisions-prune <storage provider address>" }
+
+	cmd.Action = func(w ...io.Writer) error {
+		if cmd.NArg() < 1 {
+			return errors.New("Invalid arguments: " + cmd.Usage())
+		}
+
+		conn, err := getConnTo(cmd.Args()[0])
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+
+		res, err := revisionspb.NewRevisionsAdminServiceClient(conn).PruneRevisions(getAuthContext(), &revisionspb.PruneRevisionsRequest{})
+		if err != nil {
+			return err
+		}
+
+		if jsonOutput {
+			return printJSON(res)
+		}
+		infof("deleted %d revisions, reclaimed %d bytes\n", res.Pruned, res.ReclaimedBytes)
+		return nil
+	}
+	return cmd
+}
@@ -39,7 +39,7 @@ func shareCreateCommand() *command {
 	grantType := cmd.String("type", "user", "grantee type (user or group)")
 	grantee := cmd.String("grantee", "", "the grantee")
 	idp := cmd.String("idp", "", "the idp of the grantee, default to same idp as the user triggering the action")
-	rol := cmd.String("rol", "viewer", "the permission for the share (viewer or editor)")
+	rol := cmd.String("rol", "viewer", "the permission for the share (viewer, editor or manager)")
 
 	cmd.ResetFlags = func() {
 		*grantType, *grantee, *idp, *rol = "user", "", "", "viewer"
@@ -121,6 +121,10 @@ func shareCreateCommand() *command {
 			return formatError(shareRes.Status)
 		}
 
+		if jsonOutput {
+			return printJSON(shareRes.Share)
+		}
+
 		t := table.NewWriter()
 		t.SetOutputMirror(os.Stdout)
 		t.AppendHeader(table.Row{"#", "Owner.Idp", "Owner.OpaqueId", "ResourceId", "Permissions", "Type", "Grantee.Idp", "Grantee.OpaqueId", "Created", "Updated"})
@@ -177,6 +181,27 @@ func getSharePerm(p string) (*provider.ResourcePermissions, error) {
 			RestoreFileVersion:   true,
 			Move:                 true,
 		}, nil
+	} else if p == managerPermission {
+		return &provider.ResourcePermissions{
+			AddGrant:             true,
+			CreateContainer:      true,
+			Delete:               true,
+			GetPath:              true,
+			GetQuota:             true,
+			InitiateFileDownload: true,
+			InitiateFileUpload:   true,
+			ListContainer:        true,
+			ListFileVersions:     true,
+			ListGrants:           true,
+			ListRecycle:          true,
+			Move:                 true,
+			PurgeRecycle:         true,
+			RemoveGrant:          true,
+			RestoreFileVersion:   true,
+			RestoreRecycleItem:   true,
+			Stat:                 true,
+			UpdateGrant:          true,
+		}, nil
 	}
 	return nil, errors.New("invalid rol: " + p)
 }
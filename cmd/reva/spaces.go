@@ -0,0 +1,223 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package main
+
+import (
+	"io"
+	"os"
+
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	"github.com/jedib0t/go-pretty/table"
+	"github.com/pkg/errors"
+)
+
+func spaceCreateCommand() *command {
+	cmd := newCommand("space-create")
+	cmd.Description = func() string { return "create a project space managed by the current user" }
+	cmd.Usage = func() string { return "Usage: space-create [-flags] <name>" }
+	maxBytes := cmd.Uint64("quota-max-bytes", 0, "the maximum number of bytes, 0 means unlimited")
+
+	cmd.ResetFlags = func() {
+		*maxBytes = 0
+	}
+
+	cmd.Action = func(w ...io.Writer) error {
+		if cmd.NArg() < 1 {
+			return errors.New("Invalid arguments: " + cmd.Usage())
+		}
+
+		client, err := getClient()
+		if err != nil {
+			return err
+		}
+		ctx := getAuthContext()
+
+		req := &provider.CreateStorageSpaceRequest{
+			Type: "project",
+			Name: cmd.Args()[0],
+		}
+		if *maxBytes > 0 {
+			req.Quota = &provider.Quota{QuotaMaxBytes: *maxBytes}
+		}
+		res, err := client.CreateStorageSpace(ctx, req)
+		if err != nil {
+			return err
+		}
+		if res.Status.Code != rpc.Code_CODE_OK {
+			return formatError(res.Status)
+		}
+
+		if jsonOutput {
+			return printJSON(res.StorageSpace)
+		}
+		infof("created space %s with id %s\n", res.StorageSpace.Name, res.StorageSpace.Id.GetOpaqueId())
+		return nil
+	}
+	return cmd
+}
+
+func spaceListCommand() *command {
+	cmd := newCommand("space-list")
+	cmd.Description = func() string { return "list the project spaces of the current user" }
+	cmd.Usage = func() string { return "Usage: space-list" }
+
+	cmd.Action = func(w ...io.Writer) error {
+		client, err := getClient()
+		if err != nil {
+			return err
+		}
+		ctx := getAuthContext()
+
+		spaces, err := listSpaces(ctx, client, []*provider.ListStorageSpacesRequest_Filter{
+			{
+				Type: provider.ListStorageSpacesRequest_Filter_TYPE_SPACE_TYPE,
+				Term: &provider.ListStorageSpacesRequest_Filter_SpaceType{SpaceType: "project"},
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		if jsonOutput {
+			return printJSON(spaces)
+		}
+
+		t := table.NewWriter()
+		t.SetOutputMirror(os.Stdout)
+		t.AppendHeader(table.Row{"#", "Name", "Quota.MaxBytes", "Disabled"})
+		for _, s := range spaces {
+			var maxBytes uint64
+			if s.Quota != nil {
+				maxBytes = s.Quota.QuotaMaxBytes
+			}
+			var disabled string
+			if e := s.GetOpaque().GetMap()["disabled"]; e != nil {
+				disabled = string(e.Value)
+			}
+			t.AppendRows([]table.Row{
+				{s.Id.GetOpaqueId(), s.Name, maxBytes, disabled},
+			})
+		}
+		t.Render()
+		return nil
+	}
+	return cmd
+}
+
+func spaceDisableCommand() *command {
+	cmd := newCommand("space-disable")
+	cmd.Description = func() string { return "disable a project space, making it inaccessible until restored" }
+	cmd.Usage = func() string { return "Usage: space-disable <space_id>" }
+	cmd.Action = func(w ...io.Writer) error {
+		return deleteSpace(cmd, nil)
+	}
+	return cmd
+}
+
+func spacePurgeCommand() *command {
+	cmd := newCommand("space-purge")
+	cmd.Description = func() string { return "irrevocably delete a disabled project space and its content" }
+	cmd.Usage = func() string { return "Usage: space-purge <space_id>" }
+	cmd.Action = func(w ...io.Writer) error {
+		return deleteSpace(cmd, &types.Opaque{
+			Map: map[string]*types.OpaqueEntry{
+				"purge": {Decoder: "plain", Value: []byte("true")},
+			},
+		})
+	}
+	return cmd
+}
+
+func spaceRestoreCommand() *command {
+	cmd := newCommand("space-restore")
+	cmd.Description = func() string { return "restore a disabled project space" }
+	cmd.Usage = func() string { return "Usage: space-restore <space_id>" }
+
+	cmd.Action = func(w ...io.Writer) error {
+		if cmd.NArg() < 1 {
+			return errors.New("Invalid arguments: " + cmd.Usage())
+		}
+
+		client, err := getClient()
+		if err != nil {
+			return err
+		}
+		ctx := getAuthContext()
+
+		spaces, err := listSpaces(ctx, client, []*provider.ListStorageSpacesRequest_Filter{
+			{
+				Type: provider.ListStorageSpacesRequest_Filter_TYPE_ID,
+				Term: &provider.ListStorageSpacesRequest_Filter_Id{Id: &provider.StorageSpaceId{OpaqueId: cmd.Args()[0]}},
+			},
+		})
+		if err != nil {
+			return err
+		}
+		if len(spaces) == 0 {
+			return errors.New("space not found: " + cmd.Args()[0])
+		}
+
+		res, err := client.UpdateStorageSpace(ctx, &provider.UpdateStorageSpaceRequest{
+			Opaque: &types.Opaque{
+				Map: map[string]*types.OpaqueEntry{
+					"restore": {Decoder: "plain", Value: []byte("true")},
+				},
+			},
+			StorageSpace: &provider.StorageSpace{Id: spaces[0].Id, Root: spaces[0].Root},
+		})
+		if err != nil {
+			return err
+		}
+		if res.Status.Code != rpc.Code_CODE_OK {
+			return formatError(res.Status)
+		}
+
+		infof("OK\n")
+		return nil
+	}
+	return cmd
+}
+
+func deleteSpace(cmd *command, opaque *types.Opaque) error {
+	if cmd.NArg() < 1 {
+		return errors.New("Invalid arguments: " + cmd.Usage())
+	}
+
+	client, err := getClient()
+	if err != nil {
+		return err
+	}
+	ctx := getAuthContext()
+
+	res, err := client.DeleteStorageSpace(ctx, &provider.DeleteStorageSpaceRequest{
+		Opaque: opaque,
+		Id:     &provider.StorageSpaceId{OpaqueId: cmd.Args()[0]},
+	})
+	if err != nil {
+		return err
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return formatError(res.Status)
+	}
+
+	infof("OK\n")
+	return nil
+}
@@ -57,6 +57,9 @@ func statCommand() *command {
 			return formatError(res.Status)
 		}
 
+		if jsonOutput {
+			return printJSON(res.Info)
+		}
 		fmt.Println(res.Info)
 		return nil
 	}
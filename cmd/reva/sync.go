@@ -0,0 +1,378 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// syncStateFile is the file, in the local folder, recording the state of the
+// files after the last sync, to detect on which side they changed since.
+const syncStateFile = ".reva-sync"
+
+type syncState struct {
+	Etag  string `json:"etag"`
+	Size  int64  `json:"size"`
+	Mtime int64  `json:"mtime"`
+}
+
+func syncCommand() *command {
+	cmd := newCommand("sync")
+	cmd.Description = func() string { return "synchronize a local folder with a remote one" }
+	cmd.Usage = func() string { return "Usage: sync [-flags] <local_folder> <remote_folder>" }
+	dryRunFlag := cmd.Bool("dry-run", false, "only print the files which would be transferred")
+	directionFlag := cmd.String("direction", "both", "the direction of the transfers (both, up or down)")
+	parallelFlag := cmd.Int("j", 4, "the number of files transferred in parallel")
+
+	cmd.ResetFlags = func() {
+		*dryRunFlag, *directionFlag, *parallelFlag = false, "both", 4
+	}
+
+	cmd.Action = func(w ...io.Writer) error {
+		if cmd.NArg() < 2 {
+			return errors.New("Invalid arguments: " + cmd.Usage())
+		}
+		up := *directionFlag == "both" || *directionFlag == "up"
+		down := *directionFlag == "both" || *directionFlag == "down"
+		if !up && !down {
+			return errors.New("Invalid direction: " + *directionFlag)
+		}
+
+		local, err := utils.ResolvePath(cmd.Args()[0])
+		if err != nil {
+			return err
+		}
+		remote := cmd.Args()[1]
+
+		client, err := getClient()
+		if err != nil {
+			return err
+		}
+		ctx := getAuthContext()
+
+		state, err := readSyncState(local)
+		if err != nil {
+			return err
+		}
+		locals, err := listLocalTree(local)
+		if err != nil {
+			return err
+		}
+		remotes, err := listRemoteTree(ctx, client, remote)
+		if err != nil {
+			return err
+		}
+
+		s := &syncer{local: local, remote: remote, locals: locals, remotes: remotes, state: state}
+		jobs, err := s.plan(up, down)
+		if err != nil {
+			return err
+		}
+
+		var total int64
+		for _, job := range jobs {
+			rel := s.rel(job.local)
+			if job.upload {
+				total += locals[rel].Size()
+				infof("upload %s\n", rel)
+			} else {
+				total += int64(job.info.Size)
+				infof("download %s\n", rel)
+			}
+		}
+		infof("%d files to transfer\n", len(jobs))
+		if *dryRunFlag {
+			return nil
+		}
+
+		u := &uploader{ctx: ctx, gwc: client, protocol: "tus", xs: "negotiate"}
+		d := &downloader{ctx: ctx, gwc: client}
+		if err := s.createRemoteFolders(u, jobs); err != nil {
+			return err
+		}
+
+		bar := newProgressBar(total)
+		u.bar, d.bar = bar, bar
+		err = runParallel(jobs, *parallelFlag, func(job transferJob) error {
+			info := job.info
+			if job.upload {
+				var err error
+				if info, err = u.upload(job.local, job.remote); err != nil {
+					return err
+				}
+			} else {
+				if err := os.MkdirAll(filepath.Dir(job.local), 0755); err != nil {
+					return err
+				}
+				if err := d.download(info, job.local); err != nil {
+					return err
+				}
+			}
+			return s.record(job.local, info)
+		})
+		bar.Finish()
+
+		// the state is written even after a partial failure, so that the
+		// files already transferred are not compared again.
+		if serr := writeSyncState(local, s.state); serr != nil && err == nil {
+			err = serr
+		}
+		return err
+	}
+	return cmd
+}
+
+type syncer struct {
+	local, remote string
+	locals        map[string]os.FileInfo
+	remotes       map[string]*provider.ResourceInfo
+	mu            sync.Mutex
+	state         map[string]syncState
+}
+
+func (s *syncer) rel(local string) string {
+	rel, _ := filepath.Rel(s.local, local)
+	return filepath.ToSlash(rel)
+}
+
+// plan compares the local and the remote files with their state after the
+// last sync, and returns the transfers needed to synchronize them. Files
+// deleted on one side since the last sync are not transferred again, and
+// when a file changed on both sides the most recently modified copy wins.
+func (s *syncer) plan(up, down bool) ([]transferJob, error) {
+	names := map[string]bool{}
+	for rel := range s.locals {
+		names[rel] = true
+	}
+	for rel := range s.remotes {
+		names[rel] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for rel := range names {
+		sorted = append(sorted, rel)
+	}
+	sort.Strings(sorted)
+
+	var jobs []transferJob
+	for _, rel := range sorted {
+		l, r := s.locals[rel], s.remotes[rel]
+		st, synced := s.state[rel]
+
+		var upload bool
+		switch {
+		case l != nil && r == nil:
+			if synced {
+				delete(s.state, rel)
+				continue
+			}
+			upload = true
+		case l == nil && r != nil:
+			if synced {
+				delete(s.state, rel)
+				continue
+			}
+			upload = false
+		case synced:
+			localChanged := l.Size() != st.Size || l.ModTime().Unix() != st.Mtime
+			remoteChanged := r.Etag != st.Etag
+			switch {
+			case !localChanged && !remoteChanged:
+				continue
+			case localChanged && remoteChanged:
+				upload = l.ModTime().Unix() > int64(r.Mtime.GetSeconds())
+			default:
+				upload = localChanged
+			}
+		default:
+			same, err := sameContent(filepath.Join(s.local, filepath.FromSlash(rel)), l, r)
+			if err != nil {
+				return nil, err
+			}
+			if same {
+				s.state[rel] = syncState{Etag: r.Etag, Size: l.Size(), Mtime: l.ModTime().Unix()}
+				continue
+			}
+			upload = l.ModTime().Unix() > int64(r.Mtime.GetSeconds())
+		}
+
+		if (upload && !up) || (!upload && !down) {
+			continue
+		}
+		jobs = append(jobs, transferJob{
+			local:  filepath.Join(s.local, filepath.FromSlash(rel)),
+			remote: path.Join(s.remote, rel),
+			info:   r,
+			upload: upload,
+		})
+	}
+	return jobs, nil
+}
+
+// sameContent tells whether the local and the remote file have the same
+// content, comparing their checksums when the storage provides one.
+func sameContent(p string, l os.FileInfo, r *provider.ResourceInfo) (bool, error) {
+	if uint64(l.Size()) != r.Size || r.Checksum == nil {
+		return false, nil
+	}
+	fd, err := os.Open(p)
+	if err != nil {
+		return false, err
+	}
+	defer fd.Close()
+	xs, err := computeXS(r.Checksum.Type, fd)
+	if err != nil || xs == "" {
+		return false, nil
+	}
+	return strings.EqualFold(xs, r.Checksum.Sum), nil
+}
+
+func (s *syncer) createRemoteFolders(u *uploader, jobs []transferJob) error {
+	folders := map[string]bool{}
+	for _, job := range jobs {
+		if !job.upload {
+			continue
+		}
+		for p := path.Dir(job.remote); strings.HasPrefix(p, s.remote); p = path.Dir(p) {
+			folders[p] = true
+			if p == s.remote {
+				break
+			}
+		}
+	}
+	sorted := make([]string, 0, len(folders))
+	for p := range folders {
+		sorted = append(sorted, p)
+	}
+	// parents sort before their children.
+	sort.Strings(sorted)
+	for _, p := range sorted {
+		if err := u.createContainer(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *syncer) record(local string, info *provider.ResourceInfo) error {
+	fi, err := os.Stat(local)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state[s.rel(local)] = syncState{Etag: info.Etag, Size: fi.Size(), Mtime: fi.ModTime().Unix()}
+	return nil
+}
+
+func listLocalTree(root string) (map[string]os.FileInfo, error) {
+	files := map[string]os.FileInfo{}
+	if err := os.MkdirAll(root, 0755); err != nil {
+		return nil, err
+	}
+	err := filepath.Walk(root, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !fi.Mode().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		if rel != syncStateFile {
+			files[filepath.ToSlash(rel)] = fi
+		}
+		return nil
+	})
+	return files, err
+}
+
+// listRemoteTree returns the files below the remote folder, by their path
+// relative to it. A missing folder is considered empty.
+func listRemoteTree(ctx context.Context, client gateway.GatewayAPIClient, root string) (map[string]*provider.ResourceInfo, error) {
+	files := map[string]*provider.ResourceInfo{}
+	var walk func(p, rel string) error
+	walk = func(p, rel string) error {
+		res, err := client.ListContainer(ctx, &provider.ListContainerRequest{
+			Ref: &provider.Reference{
+				Spec: &provider.Reference_Path{Path: p},
+			},
+		})
+		if err != nil {
+			return err
+		}
+		if res.Status.Code == rpc.Code_CODE_NOT_FOUND && p == root {
+			return nil
+		}
+		if res.Status.Code != rpc.Code_CODE_OK {
+			return formatError(res.Status)
+		}
+		for _, info := range res.Infos {
+			name := path.Join(rel, path.Base(info.Path))
+			if info.Type == provider.ResourceType_RESOURCE_TYPE_CONTAINER {
+				if err := walk(path.Join(p, path.Base(info.Path)), name); err != nil {
+					return err
+				}
+				continue
+			}
+			files[name] = info
+		}
+		return nil
+	}
+	return files, walk(root, "")
+}
+
+func readSyncState(local string) (map[string]syncState, error) {
+	state := map[string]syncState{}
+	data, err := ioutil.ReadFile(filepath.Join(local, syncStateFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, errors.Wrap(err, "sync: error reading state file")
+	}
+	return state, nil
+}
+
+func writeSyncState(local string, state map[string]syncState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(local, syncStateFile), data, 0600)
+}
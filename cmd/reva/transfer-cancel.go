@@ -20,6 +20,7 @@ package main
 
 import (
 	"errors"
+	"fmt"
 	"io"
 
 	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
@@ -44,7 +45,9 @@ func transferCancelCommand() *command {
 			return err
 		}
 
-		cancelRequest := &datatx.CancelTransferRequest{}
+		cancelRequest := &datatx.CancelTransferRequest{
+			TxId: &datatx.TxId{OpaqueId: *txID},
+		}
 
 		cancelResponse, err := client.CancelTransfer(ctx, cancelRequest)
 		if err != nil {
@@ -54,6 +57,7 @@ func transferCancelCommand() *command {
 			return formatError(cancelResponse.Status)
 		}
 
+		fmt.Printf("%s\t%s\t%s\n", cancelResponse.TxInfo.Id.OpaqueId, cancelResponse.TxInfo.Status, cancelResponse.TxInfo.Description)
 		return nil
 	}
 	return cmd
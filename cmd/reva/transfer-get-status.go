@@ -20,6 +20,7 @@ package main
 
 import (
 	"errors"
+	"fmt"
 	"io"
 
 	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
@@ -44,7 +45,9 @@ func transferGetStatusCommand() *command {
 			return err
 		}
 
-		getStatusRequest := &datatx.GetTransferStatusRequest{}
+		getStatusRequest := &datatx.GetTransferStatusRequest{
+			TxId: &datatx.TxId{OpaqueId: *txID},
+		}
 
 		getStatusResponse, err := client.GetTransferStatus(ctx, getStatusRequest)
 		if err != nil {
@@ -54,6 +57,7 @@ func transferGetStatusCommand() *command {
 			return formatError(getStatusResponse.Status)
 		}
 
+		fmt.Printf("%s\t%s\t%s\n", getStatusResponse.TxInfo.Id.OpaqueId, getStatusResponse.TxInfo.Status, getStatusResponse.TxInfo.Description)
 		return nil
 	}
 	return cmd
@@ -0,0 +1,118 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/cheggaaa/pb"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/pkg/errors"
+)
+
+// transferJob is a file to be transferred by a recursive upload or download.
+type transferJob struct {
+	local, remote string
+	info          *provider.ResourceInfo
+	upload        bool
+}
+
+// runParallel runs fn on every job using the given number of workers and
+// returns an error listing the jobs which failed, if any.
+func runParallel(jobs []transferJob, workers int, fn func(transferJob) error) error {
+	if workers < 1 {
+		workers = 1
+	}
+
+	ch := make(chan transferJob)
+	var mu sync.Mutex
+	var failed []string
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range ch {
+				if err := fn(job); err != nil {
+					mu.Lock()
+					failed = append(failed, fmt.Sprintf("%s: %v", job.local, err))
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for _, job := range jobs {
+		ch <- job
+	}
+	close(ch)
+	wg.Wait()
+
+	if len(failed) > 0 {
+		return errors.Errorf("%d of %d files could not be transferred:\n%s", len(failed), len(jobs), strings.Join(failed, "\n"))
+	}
+	return nil
+}
+
+func newProgressBar(total int64) *pb.ProgressBar {
+	bar := pb.New64(total).SetUnits(pb.U_BYTES)
+	bar.NotPrint = jsonOutput
+	return bar.Start()
+}
+
+// progressReader reports the bytes read to a progress bar. The data read
+// again after seeking back, e.g. when resuming an upload, is only reported
+// once.
+type progressReader struct {
+	io.ReadSeeker
+	bar           *pb.ProgressBar
+	pos, reported int64
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.ReadSeeker.Read(p)
+	r.pos += int64(n)
+	if r.pos > r.reported {
+		r.bar.Add64(r.pos - r.reported)
+		r.reported = r.pos
+	}
+	return n, err
+}
+
+func (r *progressReader) Seek(offset int64, whence int) (int64, error) {
+	pos, err := r.ReadSeeker.Seek(offset, whence)
+	if err == nil {
+		r.pos = pos
+	}
+	return pos, err
+}
+
+// verifyChecksum checks that the checksum computed locally matches the one
+// of the remote resource, when the storage provides one of the same type.
+func verifyChecksum(info *provider.ResourceInfo, t provider.ResourceChecksumType, xs string) error {
+	if xs == "" || info.Checksum == nil || info.Checksum.Type != t {
+		return nil
+	}
+	if !strings.EqualFold(info.Checksum.Sum, xs) {
+		return errors.Errorf("checksum mismatch for %s: local %s:%s, remote %s:%s", info.Path, t, xs, t, info.Checksum.Sum)
+	}
+	return nil
+}
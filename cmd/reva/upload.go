@@ -19,14 +19,18 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"math"
 	"net/http"
 	"os"
+	"path"
 	"path/filepath"
 	"strconv"
+	"time"
 
+	"github.com/cheggaaa/pb"
 	"github.com/cs3org/reva/internal/http/services/datagateway"
 	"github.com/pkg/errors"
 
@@ -49,13 +53,15 @@ import (
 
 func uploadCommand() *command {
 	cmd := newCommand("upload")
-	cmd.Description = func() string { return "upload a local file to the remote server" }
+	cmd.Description = func() string { return "upload a local file or folder to the remote server" }
 	cmd.Usage = func() string { return "Usage: upload [-flags] <file_name> <remote_target>" }
 	protocolFlag := cmd.String("protocol", "tus", "the protocol to be used for uploads")
 	xsFlag := cmd.String("xs", "negotiate", "compute checksum")
+	recursiveFlag := cmd.Bool("r", false, "upload a folder recursively")
+	parallelFlag := cmd.Int("j", 4, "the number of files uploaded in parallel when uploading recursively")
 
 	cmd.ResetFlags = func() {
-		*protocolFlag, *xsFlag = "tus", "negotiate"
+		*protocolFlag, *xsFlag, *recursiveFlag, *parallelFlag = "tus", "negotiate", false, 4
 	}
 
 	cmd.Action = func(w ...io.Writer) error {
@@ -73,167 +79,302 @@ func uploadCommand() *command {
 			return err
 		}
 
-		fd, err := os.Open(absPath)
+		md, err := os.Stat(absPath)
 		if err != nil {
 			return err
 		}
-		defer fd.Close()
-
-		md, err := fd.Stat()
-		if err != nil {
-			return err
-		}
-
-		fmt.Printf("Local file size: %d bytes\n", md.Size())
 
 		gwc, err := getClient()
 		if err != nil {
 			return err
 		}
 
-		req := &provider.InitiateFileUploadRequest{
-			Ref: &provider.Reference{
-				Spec: &provider.Reference_Path{
-					Path: target,
-				},
-			},
-			Opaque: &typespb.Opaque{
-				Map: map[string]*typespb.OpaqueEntry{
-					"Upload-Length": {
-						Decoder: "plain",
-						Value:   []byte(strconv.FormatInt(md.Size(), 10)),
-					},
-				},
-			},
+		u := &uploader{ctx: ctx, gwc: gwc, protocol: *protocolFlag, xs: *xsFlag}
+
+		if md.IsDir() {
+			if !*recursiveFlag {
+				return errors.New("upload: " + fn + " is a folder, use the -r flag to upload it recursively")
+			}
+			return u.uploadTree(absPath, target, *parallelFlag)
 		}
 
-		res, err := gwc.InitiateFileUpload(ctx, req)
+		infof("Local file size: %d bytes\n", md.Size())
+		u.verbose = true
+		u.bar = newProgressBar(md.Size())
+		info, err := u.upload(absPath, target)
+		u.bar.Finish()
 		if err != nil {
 			return err
 		}
 
-		if res.Status.Code != rpc.Code_CODE_OK {
-			return formatError(res.Status)
+		if jsonOutput {
+			return printJSON(info)
 		}
 
-		if err = checkUploadWebdavRef(res.Protocols, md, fd); err != nil {
-			if _, ok := err.(errtypes.IsNotSupported); !ok {
-				return err
-			}
-		} else {
-			return nil
-		}
+		fmt.Printf("File uploaded: %s:%s %d %s\n", info.Id.StorageId, info.Id.OpaqueId, info.Size, info.Path)
 
-		p, err := getUploadProtocolInfo(res.Protocols, *protocolFlag)
-		if err != nil {
-			return err
-		}
+		return nil
+	}
+	return cmd
+}
 
-		fmt.Printf("Data server: %s\n", p.UploadEndpoint)
-		fmt.Printf("Allowed checksums: %+v\n", p.AvailableChecksums)
+// maxUploadRetries is the number of times an interrupted TUS upload is
+// resumed before giving up.
+const maxUploadRetries = 3
+
+type uploader struct {
+	ctx      context.Context
+	gwc      gateway.GatewayAPIClient
+	protocol string
+	xs       string
+	bar      *pb.ProgressBar
+	verbose  bool
+}
+
+func (u *uploader) logf(format string, a ...interface{}) {
+	if u.verbose {
+		infof(format, a...)
+	}
+}
 
-		xsType, err := guessXS(*xsFlag, p.AvailableChecksums)
+// uploadTree uploads the local folder to the remote target, creating the
+// folders first and then uploading the files in parallel.
+func (u *uploader) uploadTree(root, target string, parallel int) error {
+	var dirs []string
+	var jobs []transferJob
+	var total int64
+	err := filepath.Walk(root, func(p string, fi os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
-		fmt.Printf("Checksum selected: %s\n", xsType)
-
-		xs, err := computeXS(xsType, fd)
+		rel, err := filepath.Rel(root, p)
 		if err != nil {
 			return err
 		}
+		remote := path.Join(target, filepath.ToSlash(rel))
+		switch {
+		case fi.IsDir():
+			dirs = append(dirs, remote)
+		case fi.Mode().IsRegular():
+			jobs = append(jobs, transferJob{local: p, remote: remote})
+			total += fi.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
 
-		fmt.Printf("Local XS: %s:%s\n", xsType, xs)
-		// seek back reader to 0
-		if _, err := fd.Seek(0, 0); err != nil {
+	for _, d := range dirs {
+		if err := u.createContainer(d); err != nil {
 			return err
 		}
+	}
 
-		dataServerURL := p.UploadEndpoint
+	infof("Uploading %d files (%d bytes) in %d folders\n", len(jobs), total, len(dirs))
+	u.bar = newProgressBar(total)
+	err = runParallel(jobs, parallel, func(job transferJob) error {
+		_, err := u.upload(job.local, job.remote)
+		return err
+	})
+	u.bar.Finish()
+	return err
+}
 
-		if *protocolFlag == "simple" {
-			httpReq, err := rhttp.NewRequest(ctx, "PUT", dataServerURL, fd)
-			if err != nil {
-				return err
-			}
+func (u *uploader) createContainer(p string) error {
+	res, err := u.gwc.CreateContainer(u.ctx, &provider.CreateContainerRequest{
+		Ref: &provider.Reference{
+			Spec: &provider.Reference_Path{Path: p},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if res.Status.Code != rpc.Code_CODE_OK && res.Status.Code != rpc.Code_CODE_ALREADY_EXISTS {
+		return formatError(res.Status)
+	}
+	return nil
+}
 
-			httpReq.Header.Set(datagateway.TokenTransportHeader, p.Token)
-			q := httpReq.URL.Query()
-			q.Add("xs", xs)
-			q.Add("xs_type", storageprovider.GRPC2PKGXS(xsType).String())
-			httpReq.URL.RawQuery = q.Encode()
+// upload uploads the local file to the remote target and returns the
+// metadata of the uploaded file, after verifying its checksum.
+func (u *uploader) upload(fn, target string) (*provider.ResourceInfo, error) {
+	fd, err := os.Open(fn)
+	if err != nil {
+		return nil, err
+	}
+	defer fd.Close()
 
-			httpRes, err := client.Do(httpReq)
-			if err != nil {
-				return err
-			}
-			defer httpRes.Body.Close()
-			if httpRes.StatusCode != http.StatusOK {
-				return errors.New("upload: PUT request returned " + httpRes.Status)
-			}
-		} else {
-			// create the tus client.
-			c := tus.DefaultConfig()
-			c.Resume = true
-			c.HttpClient = client
-			c.Store, err = memorystore.NewMemoryStore()
-			if err != nil {
-				return err
-			}
-			if token, ok := tokenpkg.ContextGetToken(ctx); ok {
-				c.Header.Add(tokenpkg.TokenHeader, token)
-			}
-			c.Header.Add(datagateway.TokenTransportHeader, p.Token)
-			tusc, err := tus.NewClient(dataServerURL, c)
-			if err != nil {
-				return err
-			}
+	md, err := fd.Stat()
+	if err != nil {
+		return nil, err
+	}
 
-			metadata := map[string]string{
-				"filename": filepath.Base(target),
-				"dir":      filepath.Dir(target),
-				"checksum": fmt.Sprintf("%s %s", storageprovider.GRPC2PKGXS(xsType).String(), xs),
-			}
+	req := &provider.InitiateFileUploadRequest{
+		Ref: &provider.Reference{
+			Spec: &provider.Reference_Path{
+				Path: target,
+			},
+		},
+		Opaque: &typespb.Opaque{
+			Map: map[string]*typespb.OpaqueEntry{
+				"Upload-Length": {
+					Decoder: "plain",
+					Value:   []byte(strconv.FormatInt(md.Size(), 10)),
+				},
+			},
+		},
+	}
 
-			fingerprint := fmt.Sprintf("%s-%d-%s-%s", md.Name(), md.Size(), md.ModTime(), xs)
+	res, err := u.gwc.InitiateFileUpload(u.ctx, req)
+	if err != nil {
+		return nil, err
+	}
 
-			// create an upload from a file.
-			upload := tus.NewUpload(fd, md.Size(), metadata, fingerprint)
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return nil, formatError(res.Status)
+	}
 
-			// create the uploader.
-			c.Store.Set(upload.Fingerprint, dataServerURL)
-			uploader := tus.NewUploader(tusc, dataServerURL, upload, 0)
+	reader := &progressReader{ReadSeeker: fd, bar: u.bar}
 
-			// start the uploading process.
-			err = uploader.Upload()
-			if err != nil {
-				return err
-			}
+	xsType := provider.ResourceChecksumType_RESOURCE_CHECKSUM_TYPE_UNSET
+	var xs string
+	if err = checkUploadWebdavRef(res.Protocols, md, reader); err != nil {
+		if _, ok := err.(errtypes.IsNotSupported); !ok {
+			return nil, err
 		}
 
-		req2 := &provider.StatRequest{
-			Ref: &provider.Reference{
-				Spec: &provider.Reference_Path{
-					Path: target,
-				},
-			},
+		if xsType, xs, err = u.uploadToDataServer(res.Protocols, fd, reader, md, target); err != nil {
+			return nil, err
 		}
-		res2, err := gwc.Stat(ctx, req2)
+	}
+
+	req2 := &provider.StatRequest{
+		Ref: &provider.Reference{
+			Spec: &provider.Reference_Path{
+				Path: target,
+			},
+		},
+	}
+	res2, err := u.gwc.Stat(u.ctx, req2)
+	if err != nil {
+		return nil, err
+	}
+
+	if res2.Status.Code != rpc.Code_CODE_OK {
+		return nil, formatError(res2.Status)
+	}
+
+	if err := verifyChecksum(res2.Info, xsType, xs); err != nil {
+		return nil, err
+	}
+	return res2.Info, nil
+}
+
+// uploadToDataServer uploads the file to the data server with the protocol
+// of the uploader and returns the checksum it computed.
+func (u *uploader) uploadToDataServer(protocols []*gateway.FileUploadProtocol, fd *os.File, reader io.ReadSeeker, md os.FileInfo, target string) (provider.ResourceChecksumType, string, error) {
+	invalid := provider.ResourceChecksumType_RESOURCE_CHECKSUM_TYPE_INVALID
+
+	p, err := getUploadProtocolInfo(protocols, u.protocol)
+	if err != nil {
+		return invalid, "", err
+	}
+
+	u.logf("Data server: %s\n", p.UploadEndpoint)
+	u.logf("Allowed checksums: %+v\n", p.AvailableChecksums)
+
+	xsType, err := guessXS(u.xs, p.AvailableChecksums)
+	if err != nil {
+		return invalid, "", err
+	}
+	u.logf("Checksum selected: %s\n", xsType)
+
+	xs, err := computeXS(xsType, fd)
+	if err != nil {
+		return invalid, "", err
+	}
+
+	u.logf("Local XS: %s:%s\n", xsType, xs)
+	// seek back reader to 0
+	if _, err := reader.Seek(0, 0); err != nil {
+		return invalid, "", err
+	}
+
+	dataServerURL := p.UploadEndpoint
+
+	if u.protocol == "simple" {
+		httpReq, err := rhttp.NewRequest(u.ctx, "PUT", dataServerURL, reader)
 		if err != nil {
-			return err
+			return invalid, "", err
 		}
 
-		if res2.Status.Code != rpc.Code_CODE_OK {
-			return formatError(res2.Status)
+		httpReq.Header.Set(datagateway.TokenTransportHeader, p.Token)
+		q := httpReq.URL.Query()
+		q.Add("xs", xs)
+		q.Add("xs_type", storageprovider.GRPC2PKGXS(xsType).String())
+		httpReq.URL.RawQuery = q.Encode()
+
+		httpRes, err := client.Do(httpReq)
+		if err != nil {
+			return invalid, "", err
+		}
+		defer httpRes.Body.Close()
+		if httpRes.StatusCode != http.StatusOK {
+			return invalid, "", errors.New("upload: PUT request returned " + httpRes.Status)
 		}
+		return xsType, xs, nil
+	}
+
+	// create the tus client.
+	c := tus.DefaultConfig()
+	c.Resume = true
+	c.HttpClient = client
+	c.Store, err = memorystore.NewMemoryStore()
+	if err != nil {
+		return invalid, "", err
+	}
+	if token, ok := tokenpkg.ContextGetToken(u.ctx); ok {
+		c.Header.Add(tokenpkg.TokenHeader, token)
+	}
+	c.Header.Add(datagateway.TokenTransportHeader, p.Token)
+	tusc, err := tus.NewClient(dataServerURL, c)
+	if err != nil {
+		return invalid, "", err
+	}
 
-		info := res2.Info
+	metadata := map[string]string{
+		"filename": filepath.Base(target),
+		"dir":      filepath.Dir(target),
+		"checksum": fmt.Sprintf("%s %s", storageprovider.GRPC2PKGXS(xsType).String(), xs),
+	}
 
-		fmt.Printf("File uploaded: %s:%s %d %s\n", info.Id.StorageId, info.Id.OpaqueId, info.Size, info.Path)
+	fingerprint := fmt.Sprintf("%s-%d-%s-%s", md.Name(), md.Size(), md.ModTime(), xs)
 
-		return nil
+	// create an upload from a file.
+	upload := tus.NewUpload(reader, md.Size(), metadata, fingerprint)
+
+	// create the uploader.
+	c.Store.Set(upload.Fingerprint, dataServerURL)
+	tusUploader := tus.NewUploader(tusc, dataServerURL, upload, 0)
+
+	// start the uploading process, resuming it from the offset known to the
+	// data server when it gets interrupted.
+	for attempt := 1; ; attempt++ {
+		err = tusUploader.Upload()
+		if err == nil {
+			break
+		}
+		if attempt > maxUploadRetries {
+			return invalid, "", err
+		}
+		u.logf("Upload of %s interrupted, resuming: %v\n", target, err)
+		time.Sleep(time.Duration(attempt) * time.Second)
+		if tusUploader, err = tusc.ResumeUpload(upload); err != nil {
+			return invalid, "", err
+		}
 	}
-	return cmd
+	return xsType, xs, nil
 }
 
 func getUploadProtocolInfo(protocolInfos []*gateway.FileUploadProtocol, protocol string) (*gateway.FileUploadProtocol, error) {
@@ -245,7 +386,7 @@ func getUploadProtocolInfo(protocolInfos []*gateway.FileUploadProtocol, protocol
 	return nil, errtypes.NotFound(protocol)
 }
 
-func checkUploadWebdavRef(protocols []*gateway.FileUploadProtocol, md os.FileInfo, fd *os.File) error {
+func checkUploadWebdavRef(protocols []*gateway.FileUploadProtocol, md os.FileInfo, fd io.Reader) error {
 	p, err := getUploadProtocolInfo(protocols, "simple")
 	if err != nil {
 		return err
@@ -287,7 +428,6 @@ func checkUploadWebdavRef(protocols []*gateway.FileUploadProtocol, md os.FileInf
 		return err
 	}
 
-	fmt.Println("File uploaded")
 	return nil
 }
 
@@ -23,10 +23,12 @@ import (
 	"io/ioutil"
 
 	"github.com/BurntSushi/toml"
+	"github.com/cs3org/reva/pkg/secrets"
 	"github.com/pkg/errors"
 )
 
-// Read reads the configuration from the reader.
+// Read reads the configuration from the reader. The references to secrets in
+// the values, e.g. ${env:JWT_SECRET}, are resolved.
 func Read(r io.Reader) (map[string]interface{}, error) {
 	data, err := ioutil.ReadAll(r)
 	if err != nil {
@@ -41,5 +43,10 @@ func Read(r io.Reader) (map[string]interface{}, error) {
 		return nil, err
 	}
 
+	// resolve the references to the secrets held outside of the file.
+	if err := secrets.Resolve(v); err != nil {
+		return nil, errors.Wrap(err, "config: error resolving secrets")
+	}
+
 	return v, nil
 }
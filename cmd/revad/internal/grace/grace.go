@@ -27,6 +27,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
@@ -44,6 +45,13 @@ type Watcher struct {
 	ss        map[string]Server
 	pidFile   string
 	childPIDs []int
+	// gracePeriod is the time given to the servers to drain the in-flight
+	// requests on a graceful shutdown.
+	gracePeriod time.Duration
+	hooks       []func()
+	// mu protects the listeners and the servers, replaced when a server is
+	// restarted.
+	mu sync.Mutex
 }
 
 // Option represent an option.
@@ -63,13 +71,31 @@ func WithPIDFile(fn string) Option {
 	}
 }
 
+// WithGracePeriod sets the time given to the servers to drain the in-flight
+// requests, e.g. uploads and downloads, on a graceful shutdown before they
+// are stopped abruptly.
+func WithGracePeriod(d time.Duration) Option {
+	return func(w *Watcher) {
+		w.gracePeriod = d
+	}
+}
+
+// WithShutdownHook adds a function to be run once the servers are stopped,
+// before the process exits.
+func WithShutdownHook(f func()) Option {
+	return func(w *Watcher) {
+		w.hooks = append(w.hooks, f)
+	}
+}
+
 // NewWatcher creates a Watcher.
 func NewWatcher(opts ...Option) *Watcher {
 	w := &Watcher{
-		log:      zerolog.Nop(),
-		graceful: os.Getenv("GRACEFUL") == "true",
-		ppid:     os.Getppid(),
-		ss:       map[string]Server{},
+		log:         zerolog.Nop(),
+		graceful:    os.Getenv("GRACEFUL") == "true",
+		ppid:        os.Getppid(),
+		ss:          map[string]Server{},
+		gracePeriod: 10 * time.Second,
 	}
 
 	for _, opt := range opts {
@@ -188,6 +214,8 @@ func newListener(network, addr string) (net.Listener, error) {
 
 // GetListeners return grpc listener first and http listener second.
 func (w *Watcher) GetListeners(servers map[string]Server) (map[string]net.Listener, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
 	w.ss = servers
 	lns := map[string]net.Listener{}
 	if w.graceful {
@@ -248,6 +276,38 @@ func (w *Watcher) GetListeners(servers map[string]Server) (map[string]net.Listen
 	return lns, nil
 }
 
+// Relisten replaces the server of the given name, e.g. after a failure, and
+// returns a new listener on its address, the previous one being closed. The
+// new listener is the one passed to the child on a hot-reload.
+func (w *Watcher) Relisten(name string, s Server) (net.Listener, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if prev, ok := w.lns[name]; ok {
+		_ = prev.Close()
+	}
+	ln, err := newListener(s.Network(), s.Address())
+	if err != nil {
+		return nil, err
+	}
+	if w.lns == nil {
+		w.lns = map[string]net.Listener{}
+	}
+	w.lns[name] = ln
+	w.ss[name] = s
+	return ln, nil
+}
+
+// servers returns the current servers.
+func (w *Watcher) servers() []Server {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	ss := make([]Server, 0, len(w.ss))
+	for _, s := range w.ss {
+		ss = append(ss, s)
+	}
+	return ss
+}
+
 // Server is the interface that servers like HTTP or gRPC
 // servers need to implement.
 type Server interface {
@@ -260,7 +320,7 @@ type Server interface {
 // TrapSignals captures the OS signal.
 func (w *Watcher) TrapSignals() {
 	signalCh := make(chan os.Signal, 1024)
-	signal.Notify(signalCh, syscall.SIGHUP, syscall.SIGINT, syscall.SIGQUIT)
+	signal.Notify(signalCh, syscall.SIGHUP, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGTERM)
 	for {
 		s := <-signalCh
 		w.log.Info().Msgf("%v signal received", s)
@@ -270,8 +330,9 @@ func (w *Watcher) TrapSignals() {
 			w.log.Info().Msg("preparing for a hot-reload, forking child process...")
 
 			// Fork a child process.
-			listeners := w.lns
-			p, err := forkChild(listeners)
+			w.mu.Lock()
+			p, err := forkChild(w.lns)
+			w.mu.Unlock()
 			if err != nil {
 				w.log.Error().Err(err).Msgf("unable to fork child process")
 			} else {
@@ -279,52 +340,77 @@ func (w *Watcher) TrapSignals() {
 				w.childPIDs = append(w.childPIDs, p.Pid)
 			}
 
-		case syscall.SIGQUIT:
-			w.log.Info().Msg("preparing for a graceful shutdown with deadline of 10 seconds")
-			go func() {
-				count := 10
-				ticker := time.NewTicker(time.Second)
-				for ; true; <-ticker.C {
-					w.log.Info().Msgf("shutting down in %d seconds", count-1)
-					count--
-					if count <= 0 {
-						w.log.Info().Msg("deadline reached before draining active conns, hard stopping ...")
-						for _, s := range w.ss {
-							err := s.Stop()
-							if err != nil {
-								w.log.Error().Err(err).Msg("error stopping server")
-							}
-							w.log.Info().Msgf("fd to %s:%s abruptly closed", s.Network(), s.Address())
-						}
-						w.Exit(1)
-					}
-				}
-			}()
-			for _, s := range w.ss {
-				w.log.Info().Msgf("fd to %s:%s gracefully closed ", s.Network(), s.Address())
-				err := s.GracefulStop()
-				if err != nil {
-					w.log.Error().Err(err).Msg("error stopping server")
-					w.log.Info().Msg("exit with error code 1")
-					w.Exit(1)
-				}
-			}
-			w.log.Info().Msg("exit with error code 0")
-			w.Exit(0)
-		case syscall.SIGINT, syscall.SIGTERM:
+		case syscall.SIGQUIT, syscall.SIGTERM:
+			w.gracefulShutdown()
+		case syscall.SIGINT:
 			w.log.Info().Msg("preparing for hard shutdown, aborting all conns")
-			for _, s := range w.ss {
-				w.log.Info().Msgf("fd to %s:%s abruptly closed", s.Network(), s.Address())
-				err := s.Stop()
-				if err != nil {
-					w.log.Error().Err(err).Msg("error stopping server")
-				}
-			}
+			w.stop()
+			w.runHooks()
 			w.Exit(0)
 		}
 	}
 }
 
+// gracefulShutdown stops the servers from accepting new requests and waits for
+// the in-flight ones to complete, up to the grace period, before running the
+// shutdown hooks and exiting.
+func (w *Watcher) gracefulShutdown() {
+	w.log.Info().Msgf("preparing for a graceful shutdown with deadline of %s", w.gracePeriod)
+
+	ss := w.servers()
+	var wg sync.WaitGroup
+	errc := make(chan error, len(ss))
+	for _, s := range ss {
+		wg.Add(1)
+		go func(s Server) {
+			defer wg.Done()
+			w.log.Info().Msgf("fd to %s:%s gracefully closed ", s.Network(), s.Address())
+			if err := s.GracefulStop(); err != nil {
+				w.log.Error().Err(err).Msg("error stopping server")
+				errc <- err
+			}
+		}(s)
+	}
+	drained := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(drained)
+	}()
+
+	code := 0
+	select {
+	case <-drained:
+		w.log.Info().Msg("active conns drained")
+		if len(errc) > 0 {
+			code = 1
+		}
+	case <-time.After(w.gracePeriod):
+		w.log.Info().Msg("deadline reached before draining active conns, hard stopping ...")
+		w.stop()
+		code = 1
+	}
+
+	w.runHooks()
+	w.log.Info().Msgf("exit with error code %d", code)
+	w.Exit(code)
+}
+
+// stop stops the servers abruptly.
+func (w *Watcher) stop() {
+	for _, s := range w.servers() {
+		w.log.Info().Msgf("fd to %s:%s abruptly closed", s.Network(), s.Address())
+		if err := s.Stop(); err != nil {
+			w.log.Error().Err(err).Msg("error stopping server")
+		}
+	}
+}
+
+func (w *Watcher) runHooks() {
+	for _, h := range w.hooks {
+		h()
+	}
+}
+
 func getListenerFile(ln net.Listener) (*os.File, error) {
 	switch t := ln.(type) {
 	case *net.TCPListener:
@@ -38,7 +38,7 @@ import (
 
 var (
 	versionFlag = flag.Bool("version", false, "show version and exit")
-	testFlag    = flag.Bool("t", false, "test configuration and exit")
+	testFlag    = flag.Bool("t", false, "test configuration and exit, same as the check-config command")
 	signalFlag  = flag.String("s", "", "send signal to a master process: stop, quit, reload")
 	configFlag  = flag.String("c", "/etc/revad/revad.toml", "set configuration file")
 	pidFlag     = flag.String("p", "", "pid file. If empty defaults to a random file in the OS temporary directory")
@@ -61,7 +61,8 @@ func main() {
 	handleVersionFlag()
 	handleSignalFlag()
 
-	confs, err := getConfigs()
+	files := getConfigFiles()
+	confs, err := readConfigs(files)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error reading the configuration file(s): %s\n", err.Error())
 		os.Exit(1)
@@ -76,8 +77,8 @@ func main() {
 	}
 
 	// if test flag is true we exit as this flag only tests for valid configurations.
-	if *testFlag {
-		os.Exit(0)
+	if *testFlag || flag.Arg(0) == "check-config" {
+		checkConfigs(files, confs)
 	}
 
 	runConfigs(confs)
@@ -135,13 +136,14 @@ func handleSignalFlag() {
 	}
 }
 
-func getConfigs() ([]map[string]interface{}, error) {
+func getConfigFiles() []string {
 	var confs []string
 	// give priority to read from dev-dir
 	if *dirFlag != "" {
 		cfgs, err := getConfigsFromDir(*dirFlag)
 		if err != nil {
-			return nil, err
+			fmt.Fprintf(os.Stderr, "error reading the configuration directory: %s\n", err.Error())
+			os.Exit(1)
 		}
 		confs = append(confs, cfgs...)
 	} else {
@@ -154,12 +156,25 @@ func getConfigs() ([]map[string]interface{}, error) {
 		os.Exit(1)
 	}
 
-	configs, err := readConfigs(confs)
-	if err != nil {
-		return nil, err
-	}
+	return confs
+}
 
-	return configs, nil
+// checkConfigs validates the configurations, printing the issues found, and
+// exits with 1 if any of them is invalid.
+func checkConfigs(files []string, confs []map[string]interface{}) {
+	code := 0
+	for i, conf := range confs {
+		for _, issue := range runtime.CheckConfig(conf, true) {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", files[i], issue)
+			if !issue.Warning {
+				code = 1
+			}
+		}
+	}
+	if code == 0 {
+		fmt.Fprintf(os.Stderr, "configuration is valid\n")
+	}
+	os.Exit(code)
 }
 
 func getConfigsFromDir(dir string) (confs []string, err error) {
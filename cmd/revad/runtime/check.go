@@ -0,0 +1,296 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package runtime
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	appauthregistry "github.com/cs3org/reva/pkg/appauth/manager/registry"
+	authregistry "github.com/cs3org/reva/pkg/auth/manager/registry"
+	authregistryregistry "github.com/cs3org/reva/pkg/auth/registry/registry"
+	groupregistry "github.com/cs3org/reva/pkg/group/manager/registry"
+	ocminviteregistry "github.com/cs3org/reva/pkg/ocm/invite/manager/registry"
+	ocmauthorizerregistry "github.com/cs3org/reva/pkg/ocm/provider/authorizer/registry"
+	ocmshareregistry "github.com/cs3org/reva/pkg/ocm/share/manager/registry"
+	publicshareregistry "github.com/cs3org/reva/pkg/publicshare/manager/registry"
+	"github.com/cs3org/reva/pkg/rgrpc"
+	"github.com/cs3org/reva/pkg/rhttp/global"
+	settingsregistry "github.com/cs3org/reva/pkg/settings/manager/registry"
+	shareregistry "github.com/cs3org/reva/pkg/share/manager/registry"
+	"github.com/cs3org/reva/pkg/sharedconf"
+	fsregistry "github.com/cs3org/reva/pkg/storage/fs/registry"
+	storageregistryregistry "github.com/cs3org/reva/pkg/storage/registry/registry"
+	userregistry "github.com/cs3org/reva/pkg/user/manager/registry"
+	webhookregistry "github.com/cs3org/reva/pkg/webhook/manager/registry"
+	"github.com/mitchellh/mapstructure"
+)
+
+// Issue is a problem found in the configuration.
+type Issue struct {
+	// Path locates the offending value, e.g. grpc.services.storageprovider.driver.
+	Path string
+	Msg  string
+	// Warning is set for the issues which do not prevent revad from starting,
+	// e.g. unknown keys, which are ignored.
+	Warning bool
+}
+
+func (i Issue) String() string {
+	level := "error"
+	if i.Warning {
+		level = "warning"
+	}
+	return fmt.Sprintf("%s: %s: %s", level, i.Path, i.Msg)
+}
+
+// driverCheck tells which key of the configuration of a service selects a
+// driver, and whether a driver is registered under a given name.
+type driverCheck struct {
+	key    string
+	exists func(name string) bool
+}
+
+var grpcDrivers = map[string]driverCheck{
+	"applicationauth":       {"driver", func(n string) bool { _, ok := appauthregistry.NewFuncs[n]; return ok }},
+	"authprovider":          {"auth_manager", func(n string) bool { _, ok := authregistry.NewFuncs[n]; return ok }},
+	"authregistry":          {"driver", func(n string) bool { _, ok := authregistryregistry.NewFuncs[n]; return ok }},
+	"groupprovider":         {"driver", func(n string) bool { _, ok := groupregistry.NewFuncs[n]; return ok }},
+	"ocmcore":               {"driver", func(n string) bool { _, ok := ocmshareregistry.NewFuncs[n]; return ok }},
+	"ocminvitemanager":      {"driver", func(n string) bool { _, ok := ocminviteregistry.NewFuncs[n]; return ok }},
+	"ocmproviderauthorizer": {"driver", func(n string) bool { _, ok := ocmauthorizerregistry.NewFuncs[n]; return ok }},
+	"ocmshareprovider":      {"driver", func(n string) bool { _, ok := ocmshareregistry.NewFuncs[n]; return ok }},
+	"preferences":           {"driver", func(n string) bool { _, ok := settingsregistry.NewFuncs[n]; return ok }},
+	"publicshareprovider":   {"driver", func(n string) bool { _, ok := publicshareregistry.NewFuncs[n]; return ok }},
+	"storageprovider":       {"driver", func(n string) bool { _, ok := fsregistry.NewFuncs[n]; return ok }},
+	"storageregistry":       {"driver", func(n string) bool { _, ok := storageregistryregistry.NewFuncs[n]; return ok }},
+	"userprovider":          {"driver", func(n string) bool { _, ok := userregistry.NewFuncs[n]; return ok }},
+	"usershareprovider":     {"driver", func(n string) bool { _, ok := shareregistry.NewFuncs[n]; return ok }},
+}
+
+var httpDrivers = map[string]driverCheck{
+	"dataprovider": {"driver", func(n string) bool { _, ok := fsregistry.NewFuncs[n]; return ok }},
+	"webhooks":     {"driver", func(n string) bool { _, ok := webhookregistry.NewFuncs[n]; return ok }},
+}
+
+// required lists the keys which must be set in the configuration of the
+// services, as they have no default.
+var required = map[string][]string{
+	"http.services.debug": {"token"},
+}
+
+// CheckConfig validates the configuration: the types of the core, log and
+// shared sections, the unknown keys, the services, interceptors, middlewares
+// and drivers referred to, the required keys, and the addresses of the gRPC
+// services referring to this process. If reachability is set, the URLs of the
+// data gateway and data servers are also queried.
+func CheckConfig(mainConf map[string]interface{}, reachability bool) []Issue {
+	issues := []Issue{}
+	add := func(path, msg string, warning bool) {
+		issues = append(issues, Issue{Path: path, Msg: msg, Warning: warning})
+	}
+
+	for k := range mainConf {
+		switch k {
+		case "core", "log", "shared", "grpc", "http", "registry":
+		default:
+			add(k, "unknown section", true)
+		}
+	}
+
+	issues = append(issues, checkStruct("core", mainConf["core"], &coreConf{})...)
+	issues = append(issues, checkStruct("log", mainConf["log"], &logConf{})...)
+	unused, err := sharedconf.Check(mainConf["shared"])
+	issues = append(issues, decodeIssues("shared", unused, err)...)
+
+	grpcAddr := ""
+	if grpcConf, ok := section(mainConf, "grpc", add); ok {
+		grpcAddr, _ = grpcConf["address"].(string)
+		checkServices("grpc", grpcConf, add, func(name string) bool { _, ok := rgrpc.Services[name]; return ok }, grpcDrivers)
+		interceptors, _ := grpcConf["interceptors"].(map[string]interface{})
+		for name := range interceptors {
+			_, unary := rgrpc.UnaryInterceptors[name]
+			_, stream := rgrpc.StreamInterceptors[name]
+			if !unary && !stream && name != "auth" {
+				add("grpc.interceptors."+name, "interceptor does not exist", false)
+			}
+		}
+	}
+	if httpConf, ok := section(mainConf, "http", add); ok {
+		checkServices("http", httpConf, add, func(name string) bool { _, ok := global.Services[name]; return ok }, httpDrivers)
+		middlewares, _ := httpConf["middlewares"].(map[string]interface{})
+		for name := range middlewares {
+			if _, ok := global.NewMiddlewares[name]; !ok && name != "auth" && name != "providerauthorizer" {
+				add("http.middlewares."+name, "middleware does not exist", false)
+			}
+		}
+	}
+
+	// the addresses of the services the gateway relies on, when pointing to
+	// this process, require the services to be enabled here.
+	if gw, ok := service(mainConf, "grpc", "gateway"); ok && grpcAddr != "" {
+		for k, v := range gw {
+			addr, ok := v.(string)
+			if !ok || !strings.HasSuffix(k, "svc") || addr != grpcAddr {
+				continue
+			}
+			name := strings.TrimSuffix(k, "svc")
+			if _, ok := service(mainConf, "grpc", name); !ok {
+				add("grpc.services.gateway."+k, fmt.Sprintf("points to this process at %s, but the %s service is not enabled", addr, name), false)
+			}
+		}
+	}
+
+	if reachability {
+		for path, u := range dataURLs(mainConf) {
+			if err := reachable(u); err != nil {
+				add(path, fmt.Sprintf("%s is not reachable: %v", u, err), true)
+			}
+		}
+	}
+
+	sort.SliceStable(issues, func(i, j int) bool { return issues[i].Path < issues[j].Path })
+	return issues
+}
+
+func checkServices(server string, conf map[string]interface{}, add func(string, string, bool), exists func(string) bool, drivers map[string]driverCheck) {
+	services, ok := conf["services"].(map[string]interface{})
+	if !ok {
+		if _, set := conf["services"]; set {
+			add(server+".services", "must be a table", false)
+		}
+		return
+	}
+	for name, v := range services {
+		path := server + ".services." + name
+		if !exists(name) {
+			add(path, "service does not exist", false)
+			continue
+		}
+		svc, ok := v.(map[string]interface{})
+		if !ok {
+			add(path, "must be a table", false)
+			continue
+		}
+		for _, k := range required[path] {
+			if s, _ := svc[k].(string); s == "" {
+				add(path+"."+k, "is required", false)
+			}
+		}
+		if _, ok := svc["drivers"]; ok {
+			if _, ok := svc["drivers"].(map[string]interface{}); !ok {
+				add(path+".drivers", "must be a table", false)
+			}
+		}
+		d, ok := drivers[name]
+		if !ok {
+			continue
+		}
+		dv, set := svc[d.key]
+		if !set {
+			continue
+		}
+		driver, ok := dv.(string)
+		if !ok {
+			add(path+"."+d.key, "must be a string", false)
+		} else if !d.exists(driver) {
+			add(path+"."+d.key, fmt.Sprintf("driver %q does not exist", driver), false)
+		}
+	}
+}
+
+// checkStruct decodes the configuration of a section into its struct,
+// reporting the type errors and the unknown keys.
+func checkStruct(path string, v, result interface{}) []Issue {
+	var md mapstructure.Metadata
+	dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{Metadata: &md, Result: result})
+	if err != nil {
+		return []Issue{{Path: path, Msg: err.Error()}}
+	}
+	err = dec.Decode(v)
+	return decodeIssues(path, md.Unused, err)
+}
+
+func decodeIssues(path string, unused []string, err error) []Issue {
+	issues := []Issue{}
+	for _, k := range unused {
+		issues = append(issues, Issue{Path: path + "." + k, Msg: "unknown key", Warning: true})
+	}
+	if me, ok := err.(*mapstructure.Error); ok {
+		for _, e := range me.Errors {
+			issues = append(issues, Issue{Path: path, Msg: e})
+		}
+	} else if err != nil {
+		issues = append(issues, Issue{Path: path, Msg: err.Error()})
+	}
+	return issues
+}
+
+func section(mainConf map[string]interface{}, name string, add func(string, string, bool)) (map[string]interface{}, bool) {
+	v, ok := mainConf[name]
+	if !ok {
+		return nil, false
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		add(name, "must be a table", false)
+	}
+	return m, ok
+}
+
+func service(mainConf map[string]interface{}, server, name string) (map[string]interface{}, bool) {
+	s, _ := mainConf[server].(map[string]interface{})
+	services, _ := s["services"].(map[string]interface{})
+	svc, ok := services[name].(map[string]interface{})
+	return svc, ok
+}
+
+// dataURLs returns the URLs of the data gateway and data servers, by path.
+func dataURLs(mainConf map[string]interface{}) map[string]string {
+	urls := map[string]string{}
+	if shared, ok := mainConf["shared"].(map[string]interface{}); ok {
+		if u, _ := shared["datagateway"].(string); u != "" {
+			urls["shared.datagateway"] = u
+		}
+	}
+	if gw, ok := service(mainConf, "grpc", "gateway"); ok {
+		if u, _ := gw["datagateway"].(string); u != "" {
+			urls["grpc.services.gateway.datagateway"] = u
+		}
+	}
+	if sp, ok := service(mainConf, "grpc", "storageprovider"); ok {
+		if u, _ := sp["data_server_url"].(string); u != "" {
+			urls["grpc.services.storageprovider.data_server_url"] = u
+		}
+	}
+	return urls
+}
+
+func reachable(u string) error {
+	c := &http.Client{Timeout: 5 * time.Second}
+	res, err := c.Head(u)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	return nil
+}
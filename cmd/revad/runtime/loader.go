@@ -27,20 +27,40 @@ import (
 	_ "github.com/cs3org/reva/internal/http/interceptors/auth/tokenwriter/loader"
 	_ "github.com/cs3org/reva/internal/http/interceptors/loader"
 	_ "github.com/cs3org/reva/internal/http/services/loader"
+	_ "github.com/cs3org/reva/pkg/activity/manager/loader"
 	_ "github.com/cs3org/reva/pkg/appauth/manager/loader"
+	_ "github.com/cs3org/reva/pkg/audit/manager/loader"
 	_ "github.com/cs3org/reva/pkg/auth/manager/loader"
 	_ "github.com/cs3org/reva/pkg/auth/registry/loader"
+	_ "github.com/cs3org/reva/pkg/cache/driver/loader"
 	_ "github.com/cs3org/reva/pkg/cbox/loader"
+	_ "github.com/cs3org/reva/pkg/changelog/manager/loader"
+	_ "github.com/cs3org/reva/pkg/comments/manager/loader"
+	_ "github.com/cs3org/reva/pkg/datatx/manager/loader"
+	_ "github.com/cs3org/reva/pkg/dlock/driver/loader"
+	_ "github.com/cs3org/reva/pkg/events/driver/loader"
+	_ "github.com/cs3org/reva/pkg/favorite/manager/loader"
 	_ "github.com/cs3org/reva/pkg/group/manager/loader"
+	_ "github.com/cs3org/reva/pkg/guest/manager/loader"
+	_ "github.com/cs3org/reva/pkg/idalloc/manager/loader"
+	_ "github.com/cs3org/reva/pkg/maintenance/manager/loader"
 	_ "github.com/cs3org/reva/pkg/metrics/driver/loader"
+	_ "github.com/cs3org/reva/pkg/notification/manager/loader"
 	_ "github.com/cs3org/reva/pkg/ocm/invite/manager/loader"
 	_ "github.com/cs3org/reva/pkg/ocm/provider/authorizer/loader"
 	_ "github.com/cs3org/reva/pkg/ocm/share/manager/loader"
+	_ "github.com/cs3org/reva/pkg/permission/manager/loader"
+	_ "github.com/cs3org/reva/pkg/postprocessing/step/loader"
 	_ "github.com/cs3org/reva/pkg/publicshare/manager/loader"
+	_ "github.com/cs3org/reva/pkg/publish/archive/loader"
+	_ "github.com/cs3org/reva/pkg/publish/manager/loader"
 	_ "github.com/cs3org/reva/pkg/rhttp/datatx/manager/loader"
+	_ "github.com/cs3org/reva/pkg/search/index/loader"
+	_ "github.com/cs3org/reva/pkg/settings/manager/loader"
 	_ "github.com/cs3org/reva/pkg/share/manager/loader"
 	_ "github.com/cs3org/reva/pkg/storage/fs/loader"
 	_ "github.com/cs3org/reva/pkg/storage/registry/loader"
 	_ "github.com/cs3org/reva/pkg/token/manager/loader"
 	_ "github.com/cs3org/reva/pkg/user/manager/loader"
+	_ "github.com/cs3org/reva/pkg/webhook/manager/loader"
 )
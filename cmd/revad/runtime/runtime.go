@@ -27,24 +27,24 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/cs3org/reva/pkg/registry"
 	"github.com/cs3org/reva/pkg/registry/memory"
 
 	"github.com/cs3org/reva/pkg/utils"
 
-	"contrib.go.opencensus.io/exporter/jaeger"
 	"github.com/cs3org/reva/cmd/revad/internal/grace"
+	"github.com/cs3org/reva/pkg/health"
 	"github.com/cs3org/reva/pkg/logger"
 	"github.com/cs3org/reva/pkg/rgrpc"
 	"github.com/cs3org/reva/pkg/rhttp"
 	"github.com/cs3org/reva/pkg/sharedconf"
+	"github.com/cs3org/reva/pkg/tenant"
+	rtrace "github.com/cs3org/reva/pkg/trace"
 	"github.com/mitchellh/mapstructure"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
-	"go.opencensus.io/plugin/ocgrpc"
-	"go.opencensus.io/plugin/ochttp"
-	"go.opencensus.io/stats/view"
-	"go.opencensus.io/trace"
 )
 
 // Run runs a reva server with the given config file and pid file.
@@ -57,25 +57,30 @@ func Run(mainConf map[string]interface{}, pidFile, logLevel string) {
 // RunWithOptions runs a reva server with the given config file, pid file and options.
 func RunWithOptions(mainConf map[string]interface{}, pidFile string, opts ...Option) {
 	options := newOptions(opts...)
+	checkConfOrDie(mainConf, options.Logger)
 	parseSharedConfOrDie(mainConf["shared"])
+	sharedconf.SetMainConf(mainConf)
 	coreConf := parseCoreConfOrDie(mainConf["core"])
 
 	// TODO: one can pass the options from the config file to registry.New() and initialize a registry based upon config files.
+	var registered []registry.Service
 	if options.Registry != nil {
 		utils.GlobalRegistry = options.Registry
 	} else if _, ok := mainConf["registry"]; ok {
 		for _, services := range mainConf["registry"].(map[string]interface{}) {
 			for sName, nodes := range services.(map[string]interface{}) {
 				for _, instance := range nodes.([]interface{}) {
-					if err := utils.GlobalRegistry.Add(memory.NewService(sName, instance.(map[string]interface{})["nodes"].([]interface{}))); err != nil {
+					svc := memory.NewService(sName, instance.(map[string]interface{})["nodes"].([]interface{}))
+					if err := utils.GlobalRegistry.Add(svc); err != nil {
 						panic(err)
 					}
+					registered = append(registered, svc)
 				}
 			}
 		}
 	}
 
-	run(mainConf, coreConf, options.Logger, pidFile)
+	run(mainConf, coreConf, options.Logger, pidFile, registered)
 }
 
 type coreConf struct {
@@ -84,9 +89,19 @@ type coreConf struct {
 	TracingEndpoint    string `mapstructure:"tracing_endpoint"`
 	TracingCollector   string `mapstructure:"tracing_collector"`
 	TracingServiceName string `mapstructure:"tracing_service_name"`
+	// TracingExporter is either otlp or jaeger, the default.
+	TracingExporter    string  `mapstructure:"tracing_exporter"`
+	TracingInsecure    bool    `mapstructure:"tracing_insecure"`
+	TracingSampleRatio float64 `mapstructure:"tracing_sample_ratio"`
+	// GracePeriod is the time in seconds given to the servers to drain the
+	// in-flight requests when shutting down gracefully.
+	GracePeriod int `mapstructure:"grace_period"`
+	// MaxRestartBackoff is the maximum time in seconds to wait before
+	// restarting a failed server.
+	MaxRestartBackoff int `mapstructure:"max_restart_backoff"`
 }
 
-func run(mainConf map[string]interface{}, coreConf *coreConf, logger *zerolog.Logger, filename string) {
+func run(mainConf map[string]interface{}, coreConf *coreConf, logger *zerolog.Logger, filename string, registered []registry.Service) {
 	host, _ := os.Hostname()
 	logger.Info().Msgf("host info: %s", host)
 
@@ -95,13 +110,13 @@ func run(mainConf map[string]interface{}, coreConf *coreConf, logger *zerolog.Lo
 	initCPUCount(coreConf, logger)
 
 	servers := initServers(mainConf, logger)
-	watcher, err := initWatcher(logger, filename)
+	watcher, err := initWatcher(logger, filename, coreConf, registered)
 	if err != nil {
 		log.Panic(err)
 	}
 	listeners := initListeners(watcher, servers, logger)
 
-	start(mainConf, servers, listeners, logger, watcher)
+	start(mainConf, coreConf, servers, listeners, logger, watcher)
 }
 
 func initListeners(watcher *grace.Watcher, servers map[string]grace.Server, log *zerolog.Logger) map[string]net.Listener {
@@ -113,8 +128,20 @@ func initListeners(watcher *grace.Watcher, servers map[string]grace.Server, log
 	return listeners
 }
 
-func initWatcher(log *zerolog.Logger, filename string) (*grace.Watcher, error) {
-	watcher, err := handlePIDFlag(log, filename)
+func initWatcher(log *zerolog.Logger, filename string, coreConf *coreConf, registered []registry.Service) (*grace.Watcher, error) {
+	var opts []grace.Option
+	if coreConf.GracePeriod > 0 {
+		opts = append(opts, grace.WithGracePeriod(time.Duration(coreConf.GracePeriod)*time.Second))
+	}
+	// deregister the services once the in-flight requests are drained.
+	opts = append(opts, grace.WithShutdownHook(func() {
+		for _, svc := range registered {
+			if err := utils.GlobalRegistry.Remove(svc); err != nil {
+				log.Error().Err(err).Msgf("error deregistering service %s", svc.Name())
+			}
+		}
+	}))
+	watcher, err := handlePIDFlag(log, filename, opts...)
 	// TODO(labkode): maybe pidfile can be created later on? like once a server is going to be created?
 	if err != nil {
 		log.Error().Err(err).Msg("error creating grace watcher")
@@ -152,8 +179,17 @@ func initServers(mainConf map[string]interface{}, log *zerolog.Logger) map[strin
 }
 
 func initTracing(conf *coreConf, log *zerolog.Logger) {
-	if err := setupOpenCensus(conf); err != nil {
-		log.Error().Err(err).Msg("error configuring open census stats and tracing")
+	err := rtrace.Init(&rtrace.Options{
+		Enabled:     conf.TracingEnabled,
+		Exporter:    conf.TracingExporter,
+		Endpoint:    conf.TracingEndpoint,
+		Collector:   conf.TracingCollector,
+		ServiceName: conf.TracingServiceName,
+		Insecure:    conf.TracingInsecure,
+		SampleRatio: conf.TracingSampleRatio,
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("error configuring tracing")
 		os.Exit(1)
 	}
 }
@@ -177,8 +213,7 @@ func initLogger(conf *logConf) *zerolog.Logger {
 	return log
 }
 
-func handlePIDFlag(l *zerolog.Logger, pidFile string) (*grace.Watcher, error) {
-	var opts []grace.Option
+func handlePIDFlag(l *zerolog.Logger, pidFile string, opts ...grace.Option) (*grace.Watcher, error) {
 	opts = append(opts, grace.WithPIDFile(pidFile))
 	opts = append(opts, grace.WithLogger(l.With().Str("pkg", "grace").Logger()))
 	w := grace.NewWatcher(opts...)
@@ -190,22 +225,17 @@ func handlePIDFlag(l *zerolog.Logger, pidFile string) (*grace.Watcher, error) {
 	return w, nil
 }
 
-func start(mainConf map[string]interface{}, servers map[string]grace.Server, listeners map[string]net.Listener, log *zerolog.Logger, watcher *grace.Watcher) {
+func start(mainConf map[string]interface{}, coreConf *coreConf, servers map[string]grace.Server, listeners map[string]net.Listener, log *zerolog.Logger, watcher *grace.Watcher) {
+	maxBackoff := time.Duration(coreConf.MaxRestartBackoff) * time.Second
 	if isEnabledHTTP(mainConf) {
-		go func() {
-			if err := servers["http"].(*rhttp.Server).Start(listeners["http"]); err != nil {
-				log.Error().Err(err).Msg("error starting the http server")
-				watcher.Exit(1)
-			}
-		}()
+		health.SetState("http", health.Starting, nil)
+		newServer := func() (startable, error) { return getHTTPServer(mainConf["http"], log) }
+		go supervise("http", servers["http"].(*rhttp.Server), listeners["http"], newServer, watcher, maxBackoff, log)
 	}
 	if isEnabledGRPC(mainConf) {
-		go func() {
-			if err := servers["grpc"].(*rgrpc.Server).Start(listeners["grpc"]); err != nil {
-				log.Error().Err(err).Msg("error starting the grpc server")
-				watcher.Exit(1)
-			}
-		}()
+		health.SetState("grpc", health.Starting, nil)
+		newServer := func() (startable, error) { return getGRPCServer(mainConf["grpc"], log) }
+		go supervise("grpc", servers["grpc"].(*rgrpc.Server), listeners["grpc"], newServer, watcher, maxBackoff, log)
 	}
 	watcher.TrapSignals()
 }
@@ -219,6 +249,11 @@ func newLogger(conf *logConf) (*zerolog.Logger, error) {
 
 	var opts []logger.Option
 	opts = append(opts, logger.WithLevel(conf.Level))
+	for svc, lvl := range conf.Services {
+		if err := logger.SetServiceLevel(svc, lvl); err != nil {
+			return nil, err
+		}
+	}
 
 	w, err := getWriter(conf.Output)
 	if err != nil {
@@ -270,39 +305,6 @@ func getHTTPServer(conf interface{}, l *zerolog.Logger) (*rhttp.Server, error) {
 	return s, nil
 }
 
-func setupOpenCensus(conf *coreConf) error {
-	if err := view.Register(ochttp.DefaultServerViews...); err != nil {
-		return err
-	}
-
-	if err := view.Register(ocgrpc.DefaultServerViews...); err != nil {
-		return err
-	}
-
-	if !conf.TracingEnabled {
-		return nil
-	}
-
-	if conf.TracingServiceName == "" {
-		conf.TracingServiceName = "revad"
-	}
-
-	je, err := jaeger.NewExporter(jaeger.Options{
-		AgentEndpoint:     conf.TracingEndpoint,
-		CollectorEndpoint: conf.TracingCollector,
-		ServiceName:       conf.TracingServiceName,
-	})
-
-	if err != nil {
-		return err
-	}
-
-	// register it as a trace exporter
-	trace.RegisterExporter(je)
-	trace.ApplyConfig(trace.Config{DefaultSampler: trace.AlwaysSample()})
-	return nil
-}
-
 //  adjustCPU parses string cpu and sets GOMAXPROCS
 // according to its value. It accepts either
 // a number (e.g. 3) or a percent (e.g. 50%).
@@ -349,14 +351,36 @@ func parseCoreConfOrDie(v interface{}) *coreConf {
 		fmt.Fprintf(os.Stderr, "error decoding core config: %s\n", err.Error())
 		os.Exit(1)
 	}
+	if c.MaxRestartBackoff <= 0 {
+		c.MaxRestartBackoff = 60
+	}
 	return c
 }
 
+func checkConfOrDie(mainConf map[string]interface{}, log *zerolog.Logger) {
+	failed := false
+	for _, i := range CheckConfig(mainConf, false) {
+		if i.Warning {
+			log.Warn().Str("path", i.Path).Msg(i.Msg)
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "invalid configuration: %s\n", i)
+		failed = true
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
 func parseSharedConfOrDie(v interface{}) {
 	if err := sharedconf.Decode(v); err != nil {
 		fmt.Fprintf(os.Stderr, "error decoding shared config: %s\n", err.Error())
 		os.Exit(1)
 	}
+	if err := tenant.Load(sharedconf.GetTenants()); err != nil {
+		fmt.Fprintf(os.Stderr, "error loading the tenants: %s\n", err.Error())
+		os.Exit(1)
+	}
 }
 
 func parseLogConfOrDie(v interface{}, logLevel string) *logConf {
@@ -383,6 +407,9 @@ type logConf struct {
 	Output string `mapstructure:"output"`
 	Mode   string `mapstructure:"mode"`
 	Level  string `mapstructure:"level"`
+	// Services overrides the level of the logs of the requests handled by
+	// the given services.
+	Services map[string]string `mapstructure:"services"`
 }
 
 func isEnabledHTTP(conf map[string]interface{}) bool {
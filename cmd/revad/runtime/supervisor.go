@@ -0,0 +1,112 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package runtime
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/cs3org/reva/cmd/revad/internal/grace"
+	"github.com/cs3org/reva/pkg/health"
+	"github.com/rs/zerolog"
+)
+
+const minRestartBackoff = time.Second
+
+type startable interface {
+	grace.Server
+	Start(ln net.Listener) error
+}
+
+// relistener hands out the listeners of the restarted servers, see
+// grace.Watcher.
+type relistener interface {
+	Relisten(name string, s grace.Server) (net.Listener, error)
+}
+
+// supervise runs the server, restarting it with an exponential backoff when
+// it fails, e.g. because one of its services could not be created, instead of
+// taking down the whole process. The state of the server is reported to the
+// health service.
+//
+// A server cannot be started again once it failed, the gRPC one refusing to
+// register its services twice, so each restart creates a new server with
+// newServer, serving on a new listener obtained from the watcher.
+func supervise(name string, s startable, ln net.Listener, newServer func() (startable, error), w relistener, maxBackoff time.Duration, log *zerolog.Logger) {
+	backoff := minRestartBackoff
+	for {
+		health.SetState(name, health.Running, nil)
+		started := time.Now()
+		err := startServer(s, ln)
+		if err == nil {
+			health.SetState(name, health.Stopped, nil)
+			return
+		}
+
+		// a server which ran for longer than the maximum backoff is
+		// considered to have recovered.
+		if time.Since(started) > maxBackoff {
+			backoff = minRestartBackoff
+		}
+		// closes the services of the failed server.
+		_ = s.Stop()
+
+		for {
+			health.SetState(name, health.Restarting, err)
+			log.Error().Err(err).Msgf("%s server failed, restarting in %s", name, backoff)
+			time.Sleep(backoff)
+			backoff = nextBackoff(backoff, maxBackoff)
+
+			if s, ln, err = restart(name, newServer, w); err == nil {
+				break
+			}
+		}
+	}
+}
+
+// restart creates a new server and its listener.
+func restart(name string, newServer func() (startable, error), w relistener) (startable, net.Listener, error) {
+	s, err := newServer()
+	if err != nil {
+		return nil, nil, err
+	}
+	ln, err := w.Relisten(name, s)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s, ln, nil
+}
+
+func nextBackoff(backoff, max time.Duration) time.Duration {
+	backoff *= 2
+	if backoff > max {
+		return max
+	}
+	return backoff
+}
+
+func startServer(s startable, ln net.Listener) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return s.Start(ln)
+}
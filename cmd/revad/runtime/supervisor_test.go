@@ -0,0 +1,148 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package runtime
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/cs3org/reva/cmd/revad/internal/grace"
+	"github.com/cs3org/reva/pkg/health"
+	"github.com/cs3org/reva/pkg/rgrpc"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc"
+	grpchealth "google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+type healthService struct {
+	*grpchealth.Server
+	closed bool
+}
+
+func (s *healthService) Register(ss *grpc.Server) { healthpb.RegisterHealthServer(ss, s) }
+
+func (s *healthService) UnprotectedEndpoints() []string {
+	return []string{"/grpc.health.v1.Health/Check"}
+}
+
+func (s *healthService) Close() error {
+	s.closed = true
+	return nil
+}
+
+// relistenFunc hands out the listeners of the restarted servers.
+type relistenFunc func(name string, s grace.Server) (net.Listener, error)
+
+func (f relistenFunc) Relisten(name string, s grace.Server) (net.Listener, error) {
+	return f(name, s)
+}
+
+func TestSuperviseRestart(t *testing.T) {
+	services := []*healthService{}
+	rgrpc.Register("test-health", func(map[string]interface{}, *grpc.Server) (rgrpc.Service, error) {
+		svc := &healthService{Server: grpchealth.NewServer()}
+		services = append(services, svc)
+		return svc, nil
+	})
+	conf := map[string]interface{}{
+		"address":  "127.0.0.1:0",
+		"services": map[string]interface{}{"test-health": map[string]interface{}{}},
+		"interceptors": map[string]interface{}{
+			"auth": map[string]interface{}{
+				"token_managers": map[string]interface{}{"jwt": map[string]interface{}{"secret": "changemeplease"}},
+			},
+		},
+	}
+	log := zerolog.Nop()
+	newServer := func() (startable, error) { return getGRPCServer(conf, &log) }
+
+	first, err := newServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the first server fails to serve once its services are registered.
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = closed.Close()
+
+	var restarted grace.Server
+	listeners := make(chan net.Listener, 1)
+	w := relistenFunc(func(name string, s grace.Server) (net.Listener, error) {
+		if name != "grpc" {
+			t.Errorf("relistening for the %s server", name)
+		}
+		restarted = s
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err == nil {
+			listeners <- ln
+		}
+		return ln, err
+	})
+
+	done := make(chan struct{})
+	go func() {
+		supervise("grpc", first, closed, newServer, w, time.Minute, &log)
+		close(done)
+	}()
+
+	var ln net.Listener
+	select {
+	case ln = <-listeners:
+	case <-time.After(10 * time.Second):
+		t.Fatal("the server was not restarted")
+	}
+	if restarted == first {
+		t.Fatal("the failed server was started again")
+	}
+
+	conn, err := grpc.Dial(ln.Addr().String(), grpc.WithInsecure(), grpc.WithBlock())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	res, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status != healthpb.HealthCheckResponse_SERVING {
+		t.Errorf("the restarted server reports %s", res.Status)
+	}
+	if len(services) != 2 || !services[0].closed || services[1].closed {
+		t.Errorf("the services of the failed server must be closed and the new ones open")
+	}
+
+	_ = restarted.GracefulStop()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("the supervision did not end with the server")
+	}
+	for _, s := range health.Statuses() {
+		if s.Name == "grpc" && (s.State != health.Stopped || s.Restarts != 1) {
+			t.Errorf("the server is %s after %d restarts, wanted %s after 1", s.State, s.Restarts, health.Stopped)
+		}
+	}
+}
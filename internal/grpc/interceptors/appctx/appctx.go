@@ -20,18 +20,23 @@ package appctx
 
 import (
 	"context"
+	"strings"
 
 	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/logger"
 	"github.com/rs/zerolog"
-	"go.opencensus.io/trace"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
 )
 
 // NewUnary returns a new unary interceptor that creates the application context.
-func NewUnary(log zerolog.Logger) grpc.UnaryServerInterceptor {
+// The services map the full names of the gRPC services to the names of the
+// reva services registering them, which the log levels are set for.
+func NewUnary(log zerolog.Logger, services map[string]string) grpc.UnaryServerInterceptor {
 	interceptor := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
-		span := trace.FromContext(ctx)
-		sub := log.With().Str("traceid", span.SpanContext().TraceID.String()).Logger()
+		traceID := trace.SpanContextFromContext(ctx).TraceID()
+		sub := logger.ForService(log, serviceName(info.FullMethod, services))
+		sub = sub.With().Str("traceid", traceID.String()).Logger()
 		ctx = appctx.WithLogger(ctx, &sub)
 		res, err := handler(ctx, req)
 		return res, err
@@ -41,10 +46,11 @@ func NewUnary(log zerolog.Logger) grpc.UnaryServerInterceptor {
 
 // NewStream returns a new server stream interceptor
 // that creates the application context.
-func NewStream(log zerolog.Logger) grpc.StreamServerInterceptor {
+func NewStream(log zerolog.Logger, services map[string]string) grpc.StreamServerInterceptor {
 	interceptor := func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
-		span := trace.FromContext(ss.Context())
-		sub := log.With().Str("traceid", span.SpanContext().TraceID.String()).Logger()
+		traceID := trace.SpanContextFromContext(ss.Context()).TraceID()
+		sub := logger.ForService(log, serviceName(info.FullMethod, services))
+		sub = sub.With().Str("traceid", traceID.String()).Logger()
 		ctx := appctx.WithLogger(ss.Context(), &sub)
 		wrapped := newWrappedServerStream(ctx, ss)
 		err := handler(srv, wrapped)
@@ -53,6 +59,17 @@ func NewStream(log zerolog.Logger) grpc.StreamServerInterceptor {
 	return interceptor
 }
 
+// serviceName returns the name of the reva service handling the given method,
+// e.g. /cs3.gateway.v1beta1.GatewayAPI/Stat, or the name of the gRPC service
+// if unknown.
+func serviceName(fullMethod string, services map[string]string) string {
+	svc := strings.SplitN(strings.TrimPrefix(fullMethod, "/"), "/", 2)[0]
+	if name, ok := services[svc]; ok {
+		return name
+	}
+	return svc
+}
+
 func newWrappedServerStream(ctx context.Context, ss grpc.ServerStream) *wrappedServerStream {
 	return &wrappedServerStream{ServerStream: ss, newCtx: ctx}
 }
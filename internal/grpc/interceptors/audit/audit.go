@@ -0,0 +1,222 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package audit
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	collaboration "github.com/cs3org/go-cs3apis/cs3/sharing/collaboration/v1beta1"
+	link "github.com/cs3org/go-cs3apis/cs3/sharing/link/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/audit"
+	"github.com/cs3org/reva/pkg/audit/manager/registry"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/rgrpc"
+	ctxpkg "github.com/cs3org/reva/pkg/user"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultPriority = 50
+)
+
+// defaultActions are the security-relevant methods recorded by default.
+var defaultActions = []string{
+	"Authenticate",
+	"AddGrant", "UpdateGrant", "RemoveGrant", "DenyGrant",
+	"CreateShare", "UpdateShare", "RemoveShare", "UpdateReceivedShare",
+	"CreatePublicShare", "UpdatePublicShare", "RemovePublicShare",
+	"CreateOCMShare", "UpdateOCMShare", "RemoveOCMShare",
+	"Delete", "PurgeRecycle",
+}
+
+func init() {
+	rgrpc.RegisterUnaryInterceptor("audit", NewUnary)
+}
+
+type config struct {
+	Priority int    `mapstructure:"priority"`
+	Driver   string `mapstructure:"driver"`
+	// Drivers holds the configuration of the audit loggers.
+	Drivers map[string]map[string]interface{} `mapstructure:"drivers"`
+	// Actions is the list of the names of the recorded methods, for example
+	// CreateShare. If empty, the security-relevant methods are recorded.
+	Actions []string `mapstructure:"actions"`
+}
+
+func parseConfig(m map[string]interface{}) (*config, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, errors.Wrap(err, "audit: error decoding conf")
+	}
+	if c.Priority == 0 {
+		c.Priority = defaultPriority
+	}
+	if c.Driver == "" {
+		c.Driver = "file"
+	}
+	if len(c.Actions) == 0 {
+		c.Actions = defaultActions
+	}
+	return c, nil
+}
+
+// NewUnary returns a unary interceptor recording the security-relevant calls
+// in the audit log, along with their outcome.
+func NewUnary(m map[string]interface{}) (grpc.UnaryServerInterceptor, int, error) {
+	c, err := parseConfig(m)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	f, ok := registry.NewFuncs[c.Driver]
+	if !ok {
+		return nil, 0, errtypes.NotFound("audit: driver not found: " + c.Driver)
+	}
+	l, err := f(c.Drivers[c.Driver])
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "audit: error creating audit logger")
+	}
+
+	actions := map[string]bool{}
+	for _, a := range c.Actions {
+		actions[a] = true
+	}
+
+	interceptor := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		action := info.FullMethod[strings.LastIndex(info.FullMethod, "/")+1:]
+		if !actions[action] {
+			return handler(ctx, req)
+		}
+
+		res, err := handler(ctx, req)
+
+		e := newEntry(ctx, action, req, res, err)
+		if werr := l.Write(ctx, e); werr != nil {
+			appctx.GetLogger(ctx).Error().Err(werr).Str("action", action).Msg("audit: error writing audit log")
+		}
+		return res, err
+	}
+	return interceptor, c.Priority, nil
+}
+
+func newEntry(ctx context.Context, action string, req, res interface{}, err error) *audit.Entry {
+	e := &audit.Entry{
+		Time:     time.Now(),
+		Action:   action,
+		Resource: resource(req),
+		Details:  map[string]string{},
+	}
+
+	if u, ok := ctxpkg.ContextGetUser(ctx); ok {
+		e.Actor = userKey(u.Id)
+	} else if r, ok := res.(interface{ GetUser() *userpb.User }); ok && r.GetUser() != nil {
+		// the user is not in the context of the calls authenticating it
+		e.Actor = userKey(r.GetUser().Id)
+	}
+	if id, ok := ctxpkg.ContextGetImpersonator(ctx); ok {
+		e.Impersonator = userKey(id)
+	}
+
+	if r, ok := req.(interface {
+		GetType() string
+		GetClientId() string
+	}); ok {
+		e.Details["auth_type"] = r.GetType()
+		e.Details["client_id"] = r.GetClientId()
+	}
+	if r, ok := req.(interface{ GetGrant() *provider.Grant }); ok && r.GetGrant() != nil {
+		e.Details["grantee"] = grantee(r.GetGrant().Grantee)
+	}
+	if r, ok := req.(interface {
+		GetGrant() *collaboration.ShareGrant
+	}); ok && r.GetGrant() != nil {
+		e.Details["grantee"] = grantee(r.GetGrant().Grantee)
+	}
+
+	switch {
+	case err != nil:
+		e.Outcome = audit.Failure
+		if c := status.Code(err); c == codes.PermissionDenied || c == codes.Unauthenticated {
+			e.Outcome = audit.Denied
+		}
+		e.Details["error"] = err.Error()
+	default:
+		e.Outcome = audit.Success
+		if r, ok := res.(interface{ GetStatus() *rpc.Status }); ok && r.GetStatus() != nil {
+			code := r.GetStatus().Code
+			switch code {
+			case rpc.Code_CODE_OK:
+			case rpc.Code_CODE_PERMISSION_DENIED, rpc.Code_CODE_UNAUTHENTICATED:
+				e.Outcome = audit.Denied
+			default:
+				e.Outcome = audit.Failure
+			}
+			e.Details["code"] = code.String()
+		}
+	}
+	return e
+}
+
+func resource(req interface{}) string {
+	switch r := req.(type) {
+	case interface{ GetRef() *provider.Reference }:
+		if id := r.GetRef().GetId(); id != nil {
+			return id.StorageId + ":" + id.OpaqueId
+		}
+		return r.GetRef().GetPath()
+	case interface{ GetResourceInfo() *provider.ResourceInfo }:
+		return r.GetResourceInfo().GetPath()
+	case interface {
+		GetRef() *collaboration.ShareReference
+	}:
+		return "share:" + r.GetRef().GetId().GetOpaqueId()
+	case interface {
+		GetRef() *link.PublicShareReference
+	}:
+		if t := r.GetRef().GetToken(); t != "" {
+			return "publicshare:" + t
+		}
+		return "publicshare:" + r.GetRef().GetId().GetOpaqueId()
+	}
+	return ""
+}
+
+func grantee(g *provider.Grantee) string {
+	switch {
+	case g.GetUserId() != nil:
+		return "user:" + userKey(g.GetUserId())
+	case g.GetGroupId() != nil:
+		return "group:" + g.GetGroupId().Idp + "!" + g.GetGroupId().OpaqueId
+	}
+	return ""
+}
+
+func userKey(id *userpb.UserId) string {
+	return id.GetIdp() + "!" + id.GetOpaqueId()
+}
@@ -20,8 +20,10 @@ package auth
 
 import (
 	"context"
+	"path"
 	"strings"
 
+	authpb "github.com/cs3org/go-cs3apis/cs3/auth/provider/v1beta1"
 	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
 	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
 	link "github.com/cs3org/go-cs3apis/cs3/sharing/link/v1beta1"
@@ -98,9 +100,11 @@ func NewUnary(m map[string]interface{}, unprotected []string) (grpc.UnaryServerI
 			// to decide the storage provider.
 			tkn, ok := token.ContextGetToken(ctx)
 			if ok {
-				u, err := dismantleToken(ctx, tkn, req, tokenManager, conf.GatewayAddr)
+				u, tokenScope, err := dismantleToken(ctx, tkn, req, tokenManager, conf.GatewayAddr)
 				if err == nil {
 					ctx = user.ContextSetUser(ctx, u)
+					appctx.WithLoggerField(ctx, "userid", u.Id.GetOpaqueId())
+					ctx = tagImpersonation(ctx, info.FullMethod, u, tokenScope)
 				}
 			}
 			return handler(ctx, req)
@@ -116,21 +120,22 @@ func NewUnary(m map[string]interface{}, unprotected []string) (grpc.UnaryServerI
 		}
 
 		// validate the token and ensure access to the resource is allowed
-		u, err := dismantleToken(ctx, tkn, req, tokenManager, conf.GatewayAddr)
+		u, tokenScope, err := dismantleToken(ctx, tkn, req, tokenManager, conf.GatewayAddr)
 		if err != nil {
 			log.Warn().Err(err).Msg("access token is invalid")
 			return nil, status.Errorf(codes.Unauthenticated, "auth: core access token is invalid")
 		}
 
-		// store user and core access token in context.
+		// store user in context. The token is not recorded, as the spans
+		// are exported to the tracing backend.
 		span.AddAttributes(
 			trace.StringAttribute("id.idp", u.Id.Idp),
 			trace.StringAttribute("id.opaque_id", u.Id.OpaqueId),
-			trace.StringAttribute("username", u.Username),
-			trace.StringAttribute("token", tkn))
-		span.AddAttributes(trace.StringAttribute("user", u.String()), trace.StringAttribute("token", tkn))
+			trace.StringAttribute("username", u.Username))
 
 		ctx = user.ContextSetUser(ctx, u)
+		appctx.WithLoggerField(ctx, "userid", u.Id.GetOpaqueId())
+		ctx = tagImpersonation(ctx, info.FullMethod, u, tokenScope)
 		return handler(ctx, req)
 	}
 	return interceptor, nil
@@ -169,9 +174,11 @@ func NewStream(m map[string]interface{}, unprotected []string) (grpc.StreamServe
 			// to decide the storage provider.
 			tkn, ok := token.ContextGetToken(ctx)
 			if ok {
-				u, err := dismantleToken(ctx, tkn, ss, tokenManager, conf.GatewayAddr)
+				u, tokenScope, err := dismantleToken(ctx, tkn, ss, tokenManager, conf.GatewayAddr)
 				if err == nil {
 					ctx = user.ContextSetUser(ctx, u)
+					appctx.WithLoggerField(ctx, "userid", u.Id.GetOpaqueId())
+					ctx = tagImpersonation(ctx, info.FullMethod, u, tokenScope)
 					ss = newWrappedServerStream(ctx, ss)
 				}
 			}
@@ -187,7 +194,7 @@ func NewStream(m map[string]interface{}, unprotected []string) (grpc.StreamServe
 		}
 
 		// validate the token and ensure access to the resource is allowed
-		u, err := dismantleToken(ctx, tkn, ss, tokenManager, conf.GatewayAddr)
+		u, tokenScope, err := dismantleToken(ctx, tkn, ss, tokenManager, conf.GatewayAddr)
 		if err != nil {
 			log.Warn().Err(err).Msg("access token is invalid")
 			return status.Errorf(codes.Unauthenticated, "auth: core access token is invalid")
@@ -195,6 +202,8 @@ func NewStream(m map[string]interface{}, unprotected []string) (grpc.StreamServe
 
 		// store user and core access token in context.
 		ctx = user.ContextSetUser(ctx, u)
+		appctx.WithLoggerField(ctx, "userid", u.Id.GetOpaqueId())
+		ctx = tagImpersonation(ctx, info.FullMethod, u, tokenScope)
 		wrapped := newWrappedServerStream(ctx, ss)
 		return handler(srv, wrapped)
 	}
@@ -214,20 +223,38 @@ func (ss *wrappedServerStream) Context() context.Context {
 	return ss.newCtx
 }
 
-func dismantleToken(ctx context.Context, tkn string, req interface{}, mgr token.Manager, gatewayAddr string) (*userpb.User, error) {
+// tagImpersonation enriches the context logger of calls made with an
+// impersonation token with both identities, and records the call.
+func tagImpersonation(ctx context.Context, method string, u *userpb.User, tokenScope map[string]*authpb.Scope) context.Context {
+	impersonator, ok := scope.GetImpersonator(tokenScope)
+	if !ok {
+		return ctx
+	}
+
+	log := appctx.GetLogger(ctx).With().
+		Interface("user_id", u.Id).
+		Interface("impersonator_id", impersonator).
+		Logger()
+	log.Info().Str("method", method).Msg("impersonated call")
+
+	ctx = appctx.WithLogger(ctx, &log)
+	return user.ContextSetImpersonator(ctx, impersonator)
+}
+
+func dismantleToken(ctx context.Context, tkn string, req interface{}, mgr token.Manager, gatewayAddr string) (*userpb.User, map[string]*authpb.Scope, error) {
 	log := appctx.GetLogger(ctx)
 	u, tokenScope, err := mgr.DismantleToken(ctx, tkn)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
 	// Check if access to the resource is in the scope of the token
 	ok, err := scope.VerifyScope(tokenScope, req)
 	if err != nil {
-		return nil, errtypes.InternalError("error verifying scope of access token")
+		return nil, nil, errtypes.InternalError("error verifying scope of access token")
 	}
 	if ok {
-		return u, nil
+		return u, tokenScope, nil
 	}
 
 	// Check if req is of type *provider.Reference_Path
@@ -251,12 +278,12 @@ func dismantleToken(ctx context.Context, tkn string, req interface{}, mgr token.
 			}
 			err = utils.UnmarshalJSONToProtoV1(publicShareScope, &share)
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 
 			client, err := pool.GetGatewayServiceClient(gatewayAddr)
 			if err != nil {
-				return nil, err
+				return nil, nil, err
 			}
 
 			// Since the public share is obtained from the scope, the current token
@@ -269,18 +296,20 @@ func dismantleToken(ctx context.Context, tkn string, req interface{}, mgr token.
 
 			statResponse, err := client.Stat(ctx, statReq)
 			if err != nil || statResponse.Status.Code != rpc.Code_CODE_OK {
-				return nil, err
+				return nil, nil, err
 			}
 
-			if strings.HasPrefix(ref.GetPath(), statResponse.Info.Path) {
+			// compare whole path segments, otherwise a link to /spaces/proj
+			// would give access to /spaces/project too
+			if p, root := path.Clean(ref.GetPath()), path.Clean(statResponse.Info.Path); p == root || strings.HasPrefix(p, root+"/") {
 				// The path corresponds to the resource to which the token has access.
 				// We allow access to it.
-				return u, nil
+				return u, tokenScope, nil
 			}
 		}
 	}
 
-	return nil, err
+	return nil, nil, err
 }
 
 func extractRef(req interface{}) (*provider.Reference, bool) {
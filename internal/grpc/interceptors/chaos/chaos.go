@@ -0,0 +1,164 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package chaos
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/rgrpc"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultPriority = 200
+)
+
+func init() {
+	rgrpc.RegisterUnaryInterceptor("chaos", NewUnary)
+	rgrpc.RegisterStreamInterceptor("chaos", NewStream)
+}
+
+type config struct {
+	Priority int `mapstructure:"priority"`
+	// Methods is the list of full method name prefixes the faults are
+	// injected into. If empty, every call is affected.
+	Methods []string `mapstructure:"methods"`
+	// Latency is the delay in milliseconds added to the calls, to which a
+	// random duration up to Jitter milliseconds is added.
+	Latency           int     `mapstructure:"latency"`
+	Jitter            int     `mapstructure:"jitter"`
+	LatencyPercentage float64 `mapstructure:"latency_percentage"`
+	// ErrorCode is the gRPC code of the errors returned instead of handling
+	// the calls, e.g. UNAVAILABLE.
+	ErrorCode       string  `mapstructure:"error_code"`
+	ErrorPercentage float64 `mapstructure:"error_percentage"`
+}
+
+func parseConfig(m map[string]interface{}) (*config, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, errors.Wrap(err, "chaos: error decoding conf")
+	}
+	if c.Priority == 0 {
+		c.Priority = defaultPriority
+	}
+	if c.ErrorCode == "" {
+		c.ErrorCode = "UNAVAILABLE"
+	}
+	return c, nil
+}
+
+type interceptor struct {
+	conf *config
+	code codes.Code
+}
+
+func newInterceptor(m map[string]interface{}) (*interceptor, error) {
+	conf, err := parseConfig(m)
+	if err != nil {
+		return nil, err
+	}
+	var code codes.Code
+	if err := code.UnmarshalJSON([]byte(`"` + strings.ToUpper(conf.ErrorCode) + `"`)); err != nil {
+		return nil, errors.Wrap(err, "chaos: invalid error code")
+	}
+	return &interceptor{conf: conf, code: code}, nil
+}
+
+// NewUnary returns a unary interceptor delaying a percentage of the calls and
+// failing another, to test the resilience of the clients in staging
+// environments. It must not be enabled in production.
+func NewUnary(m map[string]interface{}) (grpc.UnaryServerInterceptor, int, error) {
+	i, err := newInterceptor(m)
+	if err != nil {
+		return nil, 0, err
+	}
+	interceptor := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := i.inject(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+	return interceptor, i.conf.Priority, nil
+}
+
+// NewStream returns a stream interceptor delaying a percentage of the calls
+// and failing another, to test the resilience of the clients in staging
+// environments. It must not be enabled in production.
+func NewStream(m map[string]interface{}) (grpc.StreamServerInterceptor, int, error) {
+	i, err := newInterceptor(m)
+	if err != nil {
+		return nil, 0, err
+	}
+	interceptor := func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := i.inject(ss.Context(), info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+	return interceptor, i.conf.Priority, nil
+}
+
+// inject delays the call and returns the error to fail it with, depending on
+// the configured percentages.
+func (i *interceptor) inject(ctx context.Context, method string) error {
+	if !i.matches(method) {
+		return nil
+	}
+	log := appctx.GetLogger(ctx)
+
+	if rand.Float64()*100 < i.conf.LatencyPercentage {
+		d := time.Duration(i.conf.Latency) * time.Millisecond
+		if i.conf.Jitter > 0 {
+			d += time.Duration(rand.Intn(i.conf.Jitter)) * time.Millisecond
+		}
+		log.Debug().Str("method", method).Dur("latency", d).Msg("chaos: injecting latency")
+		select {
+		case <-time.After(d):
+		case <-ctx.Done():
+			return status.Error(codes.Canceled, ctx.Err().Error())
+		}
+	}
+
+	if rand.Float64()*100 < i.conf.ErrorPercentage {
+		log.Debug().Str("method", method).Str("code", i.code.String()).Msg("chaos: injecting error")
+		return status.Errorf(i.code, "chaos: injected error")
+	}
+	return nil
+}
+
+func (i *interceptor) matches(method string) bool {
+	if len(i.conf.Methods) == 0 {
+		return true
+	}
+	for _, m := range i.conf.Methods {
+		if strings.HasPrefix(method, m) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,131 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package ipfilter
+
+import (
+	"context"
+	"strings"
+
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/ipfilter"
+	"github.com/cs3org/reva/pkg/rgrpc"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultPriority = 20
+)
+
+func init() {
+	rgrpc.RegisterUnaryInterceptor("ipfilter", NewUnary)
+	rgrpc.RegisterStreamInterceptor("ipfilter", NewStream)
+}
+
+type config struct {
+	ipfilter.Config `mapstructure:",squash"`
+	Priority        int `mapstructure:"priority"`
+	// Methods is the list of full method name prefixes the rules apply to,
+	// for example /revad.adminprovider. If empty, every call is filtered.
+	Methods []string `mapstructure:"methods"`
+}
+
+type interceptor struct {
+	conf   *config
+	filter *ipfilter.Filter
+}
+
+func newInterceptor(m map[string]interface{}) (*interceptor, error) {
+	conf := &config{}
+	if err := mapstructure.Decode(m, conf); err != nil {
+		return nil, errors.Wrap(err, "ipfilter: error decoding conf")
+	}
+	if conf.Priority == 0 {
+		conf.Priority = defaultPriority
+	}
+	filter, err := ipfilter.New(&conf.Config)
+	if err != nil {
+		return nil, err
+	}
+	return &interceptor{conf: conf, filter: filter}, nil
+}
+
+// NewUnary returns a unary interceptor rejecting the calls from the addresses
+// not allowed with PERMISSION_DENIED.
+func NewUnary(m map[string]interface{}) (grpc.UnaryServerInterceptor, int, error) {
+	i, err := newInterceptor(m)
+	if err != nil {
+		return nil, 0, err
+	}
+	interceptor := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := i.check(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+	return interceptor, i.conf.Priority, nil
+}
+
+// NewStream returns a stream interceptor rejecting the calls from the
+// addresses not allowed with PERMISSION_DENIED.
+func NewStream(m map[string]interface{}) (grpc.StreamServerInterceptor, int, error) {
+	i, err := newInterceptor(m)
+	if err != nil {
+		return nil, 0, err
+	}
+	interceptor := func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := i.check(ss.Context(), info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+	return interceptor, i.conf.Priority, nil
+}
+
+func (i *interceptor) check(ctx context.Context, method string) error {
+	if !i.matches(method) {
+		return nil
+	}
+	var addr string
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		addr = p.Addr.String()
+	}
+	if err := i.filter.Check(ipfilter.ParseHost(addr)); err != nil {
+		log := appctx.GetLogger(ctx)
+		log.Warn().Err(err).Str("method", method).Msg("ipfilter: call refused")
+		return status.Error(codes.PermissionDenied, "address not allowed")
+	}
+	return nil
+}
+
+func (i *interceptor) matches(method string) bool {
+	if len(i.conf.Methods) == 0 {
+		return true
+	}
+	for _, m := range i.conf.Methods {
+		if strings.HasPrefix(method, m) {
+			return true
+		}
+	}
+	return false
+}
@@ -18,4 +18,13 @@
 
 package loader
 
-// Add your own.
+import (
+	// Load core gRPC interceptors.
+	_ "github.com/cs3org/reva/internal/grpc/interceptors/audit"
+	_ "github.com/cs3org/reva/internal/grpc/interceptors/chaos"
+	_ "github.com/cs3org/reva/internal/grpc/interceptors/ipfilter"
+	_ "github.com/cs3org/reva/internal/grpc/interceptors/maintenance"
+	_ "github.com/cs3org/reva/internal/grpc/interceptors/metrics"
+	_ "github.com/cs3org/reva/internal/grpc/interceptors/ratelimit"
+	// Add your own.
+)
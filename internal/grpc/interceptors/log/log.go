@@ -22,6 +22,8 @@ import (
 	"context"
 	"time"
 
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	"github.com/cs3org/reva/pkg/appctx"
 	"github.com/rs/zerolog"
 	"google.golang.org/grpc"
@@ -32,25 +34,16 @@ import (
 )
 
 // NewUnary returns a new unary interceptor
-// that logs grpc calls.
+// that logs grpc calls. Besides the fields set on the logger of the request,
+// i.e. the trace id, the service and the user id, the entries record the
+// method, the resource concerned by the call, its duration and its status.
 func NewUnary() grpc.UnaryServerInterceptor {
 	interceptor := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
 		start := time.Now()
 		res, err := handler(ctx, req)
 		code := status.Code(err)
 		end := time.Now()
-		diff := end.Sub(start).Nanoseconds()
-		var fromAddress, userAgent string
-		if p, ok := peer.FromContext(ctx); ok {
-			fromAddress = p.Addr.Network() + "://" + p.Addr.String()
-		}
-		if md, ok := metadata.FromIncomingContext(ctx); ok {
-			if vals, ok := md["user-agent"]; ok {
-				if len(vals) > 0 && vals[0] != "" {
-					userAgent = vals[0]
-				}
-			}
-		}
+		fromAddress, userAgent := client(ctx)
 
 		log := appctx.GetLogger(ctx)
 		var event *zerolog.Event
@@ -62,10 +55,13 @@ func NewUnary() grpc.UnaryServerInterceptor {
 
 		event.Str("user-agent", userAgent).
 			Str("from", fromAddress).
-			Str("uri", info.FullMethod).
+			Str("method", info.FullMethod).
+			Str("ref", reference(req)).
 			Str("start", start.Format("02/Jan/2006:15:04:05 -0700")).
-			Str("end", end.Format("02/Jan/2006:15:04:05 -0700")).Int("time_ns", int(diff)).
+			Str("end", end.Format("02/Jan/2006:15:04:05 -0700")).
+			Dur("duration", end.Sub(start)).
 			Str("code", code.String()).
+			Str("status", rpcStatus(res)).
 			Msg("unary")
 
 		return res, err
@@ -81,18 +77,7 @@ func NewStream() grpc.StreamServerInterceptor {
 		err := handler(srv, ss)
 		end := time.Now()
 		code := status.Code(err)
-		diff := end.Sub(start).Nanoseconds()
-		var fromAddress, userAgent string
-		if p, ok := peer.FromContext(ss.Context()); ok {
-			fromAddress = p.Addr.Network() + "://" + p.Addr.String()
-		}
-		if md, ok := metadata.FromIncomingContext(ss.Context()); ok {
-			if vals, ok := md["user-agent"]; ok {
-				if len(vals) > 0 && vals[0] != "" {
-					userAgent = vals[0]
-				}
-			}
-		}
+		fromAddress, userAgent := client(ss.Context())
 
 		log := appctx.GetLogger(ss.Context())
 		var event *zerolog.Event
@@ -104,9 +89,10 @@ func NewStream() grpc.StreamServerInterceptor {
 
 		event.Str("user-agent", userAgent).
 			Str("from", fromAddress).
-			Str("uri", info.FullMethod).
+			Str("method", info.FullMethod).
 			Str("start", start.Format("02/Jan/2006:15:04:05 -0700")).
-			Str("end", end.Format("02/Jan/2006:15:04:05 -0700")).Int("time_ns", int(diff)).
+			Str("end", end.Format("02/Jan/2006:15:04:05 -0700")).
+			Dur("duration", end.Sub(start)).
 			Str("code", code.String()).
 			Msg("stream")
 
@@ -114,3 +100,40 @@ func NewStream() grpc.StreamServerInterceptor {
 	}
 	return interceptor
 }
+
+// client returns the address and the user agent of the caller.
+func client(ctx context.Context) (string, string) {
+	var fromAddress, userAgent string
+	if p, ok := peer.FromContext(ctx); ok {
+		fromAddress = p.Addr.Network() + "://" + p.Addr.String()
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if vals, ok := md["user-agent"]; ok {
+			if len(vals) > 0 && vals[0] != "" {
+				userAgent = vals[0]
+			}
+		}
+	}
+	return fromAddress, userAgent
+}
+
+// reference returns the resource the request refers to, if any.
+func reference(req interface{}) string {
+	r, ok := req.(interface{ GetRef() *provider.Reference })
+	if !ok {
+		return ""
+	}
+	if id := r.GetRef().GetId(); id != nil {
+		return id.StorageId + ":" + id.OpaqueId
+	}
+	return r.GetRef().GetPath()
+}
+
+// rpcStatus returns the CS3 status code of the response, which can differ
+// from the gRPC one as the errors are mostly reported in the response.
+func rpcStatus(res interface{}) string {
+	if r, ok := res.(interface{ GetStatus() *rpc.Status }); ok && r.GetStatus() != nil {
+		return r.GetStatus().Code.String()
+	}
+	return ""
+}
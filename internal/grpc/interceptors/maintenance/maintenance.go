@@ -0,0 +1,126 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package maintenance
+
+import (
+	"context"
+
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/maintenance"
+	"github.com/cs3org/reva/pkg/maintenance/manager/registry"
+	"github.com/cs3org/reva/pkg/rgrpc"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultPriority = 100
+)
+
+func init() {
+	rgrpc.RegisterUnaryInterceptor("maintenance", NewUnary)
+	rgrpc.RegisterStreamInterceptor("maintenance", NewStream)
+}
+
+type config struct {
+	Priority int                               `mapstructure:"priority"`
+	Driver   string                            `mapstructure:"driver"`
+	Drivers  map[string]map[string]interface{} `mapstructure:"drivers"`
+}
+
+func parseConfig(m map[string]interface{}) (*config, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, errors.Wrap(err, "maintenance: error decoding conf")
+	}
+	if c.Priority == 0 {
+		c.Priority = defaultPriority
+	}
+	if c.Driver == "" {
+		c.Driver = "memory"
+	}
+	return c, nil
+}
+
+func newManager(m map[string]interface{}) (maintenance.Manager, int, error) {
+	c, err := parseConfig(m)
+	if err != nil {
+		return nil, 0, err
+	}
+	f, ok := registry.NewFuncs[c.Driver]
+	if !ok {
+		return nil, 0, errors.New("maintenance: driver not found: " + c.Driver)
+	}
+	mgr, err := f(c.Drivers[c.Driver])
+	if err != nil {
+		return nil, 0, err
+	}
+	return mgr, c.Priority, nil
+}
+
+// NewUnary returns a unary interceptor refusing the mutating calls with
+// UNAVAILABLE while the deployment is in maintenance.
+func NewUnary(m map[string]interface{}) (grpc.UnaryServerInterceptor, int, error) {
+	mgr, prio, err := newManager(m)
+	if err != nil {
+		return nil, 0, err
+	}
+	interceptor := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := check(ctx, mgr, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+	return interceptor, prio, nil
+}
+
+// NewStream returns a stream interceptor refusing the mutating calls with
+// UNAVAILABLE while the deployment is in maintenance.
+func NewStream(m map[string]interface{}) (grpc.StreamServerInterceptor, int, error) {
+	mgr, prio, err := newManager(m)
+	if err != nil {
+		return nil, 0, err
+	}
+	interceptor := func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := check(ss.Context(), mgr, info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+	return interceptor, prio, nil
+}
+
+func check(ctx context.Context, mgr maintenance.Manager, method string) error {
+	if !maintenance.IsMutating(method) {
+		return nil
+	}
+	modes, err := mgr.GetModes(ctx)
+	if err != nil {
+		// do not make the deployment read-only when the modes cannot be read
+		appctx.GetLogger(ctx).Error().Err(err).Msg("maintenance: error getting maintenance modes")
+		return nil
+	}
+	if mode, ok := modes[maintenance.Global]; ok {
+		return status.Errorf(codes.Unavailable, "the service is in maintenance, only reads are allowed: %s", mode.Message)
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package metrics
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	"github.com/cs3org/reva/pkg/rgrpc"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultPriority = 10
+)
+
+var (
+	serviceKey = tag.MustNewKey("grpc_service")
+	methodKey  = tag.MustNewKey("grpc_method")
+	// codeKey is the CS3 status code of the response, or the gRPC code of the
+	// error if the call failed.
+	codeKey = tag.MustNewKey("code")
+
+	requests = stats.Int64("grpc_server_requests", "Number of gRPC calls handled", stats.UnitDimensionless)
+	errs     = stats.Int64("grpc_server_errors", "Number of gRPC calls which did not succeed", stats.UnitDimensionless)
+	latency  = stats.Float64("grpc_server_latency", "Time spent handling the gRPC calls", stats.UnitMilliseconds)
+	inFlight = stats.Int64("grpc_server_in_flight", "Number of gRPC calls being handled", stats.UnitDimensionless)
+
+	// latencyBuckets are the bounds in milliseconds of the latency histograms.
+	latencyBuckets = []float64{1, 2, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000, 30000}
+
+	views = []*view.View{
+		{Name: "grpc_server_requests_total", Measure: requests, Aggregation: view.Count(), TagKeys: []tag.Key{serviceKey, methodKey, codeKey}},
+		{Name: "grpc_server_errors_total", Measure: errs, Aggregation: view.Count(), TagKeys: []tag.Key{serviceKey, methodKey, codeKey}},
+		{Name: "grpc_server_latency_ms", Measure: latency, Aggregation: view.Distribution(latencyBuckets...), TagKeys: []tag.Key{serviceKey, methodKey}},
+		{Name: "grpc_server_in_flight", Measure: inFlight, Aggregation: view.LastValue(), TagKeys: []tag.Key{serviceKey, methodKey}},
+	}
+)
+
+func init() {
+	rgrpc.RegisterUnaryInterceptor("metrics", NewUnary)
+	rgrpc.RegisterStreamInterceptor("metrics", NewStream)
+}
+
+type config struct {
+	Priority int `mapstructure:"priority"`
+}
+
+var registerViews sync.Once
+
+func parseConfig(m map[string]interface{}) (*config, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, errors.Wrap(err, "metrics: error decoding conf")
+	}
+	if c.Priority == 0 {
+		c.Priority = defaultPriority
+	}
+
+	var err error
+	registerViews.Do(func() {
+		err = view.Register(views...)
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "metrics: error registering views")
+	}
+	return c, nil
+}
+
+// NewUnary returns a unary interceptor recording the number, the latency and
+// the outcome of the calls, per service and method.
+func NewUnary(m map[string]interface{}) (grpc.UnaryServerInterceptor, int, error) {
+	c, err := parseConfig(m)
+	if err != nil {
+		return nil, 0, err
+	}
+	interceptor := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		done := start(ctx, info.FullMethod)
+		res, err := handler(ctx, req)
+		done(code(res, err))
+		return res, err
+	}
+	return interceptor, c.Priority, nil
+}
+
+// NewStream returns a stream interceptor recording the number, the duration
+// and the outcome of the streams, per service and method.
+func NewStream(m map[string]interface{}) (grpc.StreamServerInterceptor, int, error) {
+	c, err := parseConfig(m)
+	if err != nil {
+		return nil, 0, err
+	}
+	interceptor := func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		done := start(ss.Context(), info.FullMethod)
+		err := handler(srv, ss)
+		done(code(nil, err))
+		return err
+	}
+	return interceptor, c.Priority, nil
+}
+
+// calls holds the number of calls being handled per full method name.
+var calls sync.Map
+
+// start records the beginning of a call, and returns the function recording
+// its end with the given code.
+func start(ctx context.Context, fullMethod string) func(code string) {
+	service, method := split(fullMethod)
+	ctx, _ = tag.New(ctx, tag.Upsert(serviceKey, service), tag.Upsert(methodKey, method))
+
+	v, _ := calls.LoadOrStore(fullMethod, new(int64))
+	n := v.(*int64)
+	stats.Record(ctx, inFlight.M(atomic.AddInt64(n, 1)))
+
+	t := time.Now()
+	return func(code string) {
+		stats.Record(ctx, inFlight.M(atomic.AddInt64(n, -1)), latency.M(float64(time.Since(t))/float64(time.Millisecond)))
+
+		ctx, _ := tag.New(ctx, tag.Upsert(codeKey, code))
+		stats.Record(ctx, requests.M(1))
+		if code != rpc.Code_CODE_OK.String() {
+			stats.Record(ctx, errs.M(1))
+		}
+	}
+}
+
+// code returns the CS3 status code of the response or, if the call failed,
+// the gRPC code of the error.
+func code(res interface{}, err error) string {
+	if err != nil {
+		return status.Code(err).String()
+	}
+	if r, ok := res.(interface{ GetStatus() *rpc.Status }); ok && r.GetStatus() != nil {
+		return r.GetStatus().Code.String()
+	}
+	return rpc.Code_CODE_OK.String()
+}
+
+// split splits a full method name, e.g. /cs3.gateway.v1beta1.GatewayAPI/Stat,
+// into the service and the method names.
+func split(fullMethod string) (string, string) {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+	if i := strings.LastIndex(fullMethod, "/"); i >= 0 {
+		return fullMethod[:i], fullMethod[i+1:]
+	}
+	return "unknown", fullMethod
+}
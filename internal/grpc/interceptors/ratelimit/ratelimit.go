@@ -0,0 +1,170 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/ratelimit"
+	"github.com/cs3org/reva/pkg/rgrpc"
+	ctxpkg "github.com/cs3org/reva/pkg/user"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultPriority = 100
+)
+
+func init() {
+	rgrpc.RegisterUnaryInterceptor("ratelimit", NewUnary)
+	rgrpc.RegisterStreamInterceptor("ratelimit", NewStream)
+}
+
+type config struct {
+	ratelimit.Config `mapstructure:",squash"`
+	Priority         int `mapstructure:"priority"`
+	// Methods is the list of full method name prefixes the limits apply to,
+	// for example /cs3.gateway.v1beta1.GatewayAPI/Authenticate. If empty,
+	// every call is limited.
+	Methods []string `mapstructure:"methods"`
+}
+
+func parseConfig(m map[string]interface{}) (*config, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, errors.Wrap(err, "ratelimit: error decoding conf")
+	}
+	c.Init()
+	if c.Priority == 0 {
+		c.Priority = defaultPriority
+	}
+	return c, nil
+}
+
+type interceptor struct {
+	conf    *config
+	limiter ratelimit.Limiter
+}
+
+func newInterceptor(m map[string]interface{}) (*interceptor, error) {
+	conf, err := parseConfig(m)
+	if err != nil {
+		return nil, err
+	}
+	return &interceptor{conf: conf, limiter: ratelimit.New(&conf.Config)}, nil
+}
+
+// NewUnary returns a unary interceptor rejecting the calls exceeding the
+// configured rate with RESOURCE_EXHAUSTED.
+func NewUnary(m map[string]interface{}) (grpc.UnaryServerInterceptor, int, error) {
+	i, err := newInterceptor(m)
+	if err != nil {
+		return nil, 0, err
+	}
+	interceptor := func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := i.check(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+	return interceptor, i.conf.Priority, nil
+}
+
+// NewStream returns a stream interceptor rejecting the calls exceeding the
+// configured rate with RESOURCE_EXHAUSTED.
+func NewStream(m map[string]interface{}) (grpc.StreamServerInterceptor, int, error) {
+	i, err := newInterceptor(m)
+	if err != nil {
+		return nil, 0, err
+	}
+	interceptor := func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := i.check(ss.Context(), info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+	return interceptor, i.conf.Priority, nil
+}
+
+func (i *interceptor) check(ctx context.Context, method string) error {
+	if !i.matches(method) {
+		return nil
+	}
+
+	log := appctx.GetLogger(ctx)
+	key := i.key(ctx)
+
+	ok, retry, err := i.limiter.Allow(ctx, key)
+	if err != nil {
+		// do not lock users out when the limiter is not available
+		log.Error().Err(err).Msg("ratelimit: error checking rate limit")
+		return nil
+	}
+	if !ok {
+		log.Warn().Str("key", key).Str("method", method).Msg("ratelimit: rate limit exceeded")
+		secs := retryAfterSeconds(retry)
+		_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", fmt.Sprintf("%d", secs)))
+		return status.Errorf(codes.ResourceExhausted, "rate limit exceeded, retry after %d seconds", secs)
+	}
+	return nil
+}
+
+func (i *interceptor) matches(method string) bool {
+	if len(i.conf.Methods) == 0 {
+		return true
+	}
+	for _, m := range i.conf.Methods {
+		if strings.HasPrefix(method, m) {
+			return true
+		}
+	}
+	return false
+}
+
+func (i *interceptor) key(ctx context.Context) string {
+	if i.conf.PerUser {
+		if u, ok := ctxpkg.ContextGetUser(ctx); ok && u.Id != nil {
+			return "user:" + u.Id.Idp + "!" + u.Id.OpaqueId
+		}
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		host, _, err := net.SplitHostPort(p.Addr.String())
+		if err != nil {
+			host = p.Addr.String()
+		}
+		return "ip:" + host
+	}
+	return "ip:unknown"
+}
+
+func retryAfterSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
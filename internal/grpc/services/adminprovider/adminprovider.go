@@ -0,0 +1,422 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package adminprovider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	registry "github.com/cs3org/go-cs3apis/cs3/storage/registry/v1beta1"
+	adminpb "github.com/cs3org/reva/internal/grpc/services/adminprovider/proto"
+	searchpb "github.com/cs3org/reva/internal/grpc/services/search/proto"
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/maintenance"
+	maintenanceregistry "github.com/cs3org/reva/pkg/maintenance/manager/registry"
+	"github.com/cs3org/reva/pkg/permission"
+	permregistry "github.com/cs3org/reva/pkg/permission/manager/registry"
+	"github.com/cs3org/reva/pkg/rgrpc"
+	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
+	"github.com/cs3org/reva/pkg/rhttp"
+	"github.com/cs3org/reva/pkg/sharedconf"
+	"github.com/cs3org/reva/pkg/sysinfo"
+	"github.com/cs3org/reva/pkg/user"
+	userregistry "github.com/cs3org/reva/pkg/user/manager/registry"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func init() {
+	rgrpc.Register("adminprovider", New)
+}
+
+type config struct {
+	GatewaySvc         string `mapstructure:"gatewaysvc"`
+	StorageRegistrySvc string `mapstructure:"storageregistrysvc"`
+	// SearchSvc is the address of the search service, used to reindex the
+	// files of the users.
+	SearchSvc   string                            `mapstructure:"searchsvc"`
+	UserDriver  string                            `mapstructure:"user_driver"`
+	UserDrivers map[string]map[string]interface{} `mapstructure:"user_drivers"`
+	// PermissionDriver, if set, is used to check whether the users hold the
+	// system.admin capability. Otherwise the Admins and AdminGroups are used.
+	PermissionDriver  string                            `mapstructure:"permission_driver"`
+	PermissionDrivers map[string]map[string]interface{} `mapstructure:"permission_drivers"`
+	Admins            []string                          `mapstructure:"admins"`
+	AdminGroups       []string                          `mapstructure:"admin_groups"`
+	// SysinfoEndpoints maps the names of the other reva services to the URL
+	// of their sysinfo endpoint, which is queried for their version.
+	SysinfoEndpoints map[string]string `mapstructure:"sysinfo_endpoints"`
+	Insecure         bool              `mapstructure:"insecure"`
+	// MaintenanceDriver is the store of the maintenance modes, which must be
+	// shared with the gateway and the ocdav service.
+	MaintenanceDriver  string                            `mapstructure:"maintenance_driver"`
+	MaintenanceDrivers map[string]map[string]interface{} `mapstructure:"maintenance_drivers"`
+}
+
+func (c *config) init() {
+	if c.UserDriver == "" {
+		c.UserDriver = "json"
+	}
+	if c.MaintenanceDriver == "" {
+		c.MaintenanceDriver = "memory"
+	}
+	c.GatewaySvc = sharedconf.GetGatewaySVC(c.GatewaySvc)
+	if c.StorageRegistrySvc == "" {
+		c.StorageRegistrySvc = c.GatewaySvc
+	}
+}
+
+type service struct {
+	conf        *config
+	users       user.Manager
+	pm          permission.Manager
+	maintenance maintenance.Manager
+	client      *http.Client
+}
+
+// New returns a new AdminAPIServer, exposing the operational tasks of the
+// admins in a single place. The tasks are forwarded to the services owning
+// the data, which check again that the user is an admin.
+func New(m map[string]interface{}, ss *grpc.Server) (rgrpc.Service, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		err = errors.Wrap(err, "adminprovider: error decoding conf")
+		return nil, err
+	}
+	c.init()
+
+	f, ok := userregistry.NewFuncs[c.UserDriver]
+	if !ok {
+		return nil, errtypes.NotFound("adminprovider: user driver not found: " + c.UserDriver)
+	}
+	users, err := f(c.UserDrivers[c.UserDriver])
+	if err != nil {
+		return nil, err
+	}
+
+	pm, err := permregistry.New(c.PermissionDriver, c.PermissionDrivers)
+	if err != nil {
+		return nil, errors.Wrap(err, "adminprovider: error creating permission manager")
+	}
+
+	mf, ok := maintenanceregistry.NewFuncs[c.MaintenanceDriver]
+	if !ok {
+		return nil, errtypes.NotFound("adminprovider: maintenance driver not found: " + c.MaintenanceDriver)
+	}
+	mm, err := mf(c.MaintenanceDrivers[c.MaintenanceDriver])
+	if err != nil {
+		return nil, err
+	}
+
+	return &service{
+		conf:        c,
+		users:       users,
+		pm:          pm,
+		maintenance: mm,
+		client: rhttp.GetHTTPClient(
+			rhttp.Timeout(5*time.Second),
+			rhttp.Insecure(c.Insecure),
+		),
+	}, nil
+}
+
+func (s *service) Close() error {
+	return nil
+}
+
+func (s *service) UnprotectedEndpoints() []string {
+	return []string{}
+}
+
+func (s *service) Register(ss *grpc.Server) {
+	adminpb.RegisterAdminAPIServer(ss, s)
+}
+
+func (s *service) ListUsers(ctx context.Context, req *adminpb.ListUsersRequest) (*adminpb.ListUsersResponse, error) {
+	if !s.isAdmin(ctx) {
+		return nil, status.Error(codes.PermissionDenied, "adminprovider: not allowed to list the users")
+	}
+	users, err := s.users.FindUsers(ctx, req.Query)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	res := &adminpb.ListUsersResponse{}
+	for _, u := range users {
+		res.Users = append(res.Users, &adminpb.User{
+			OpaqueId:    u.Id.GetOpaqueId(),
+			Idp:         u.Id.GetIdp(),
+			Username:    u.Username,
+			DisplayName: u.DisplayName,
+			Mail:        u.Mail,
+		})
+	}
+	return res, nil
+}
+
+func (s *service) DisableUser(ctx context.Context, req *adminpb.DisableUserRequest) (*adminpb.DisableUserResponse, error) {
+	if !s.isAdmin(ctx) {
+		return nil, status.Error(codes.PermissionDenied, "adminprovider: not allowed to disable users")
+	}
+	if req.OpaqueId == "" {
+		return nil, status.Error(codes.InvalidArgument, "adminprovider: missing user")
+	}
+	pm, ok := s.users.(user.ProvisioningManager)
+	if !ok {
+		return nil, status.Error(codes.Unimplemented, "adminprovider: the user driver does not support disabling users")
+	}
+	if err := pm.DeleteUser(ctx, &userpb.UserId{OpaqueId: req.OpaqueId, Idp: req.Idp}); err != nil {
+		return nil, toStatus(err)
+	}
+	appctx.GetLogger(ctx).Info().Str("user", req.OpaqueId).Msg("adminprovider: disabled user")
+	return &adminpb.DisableUserResponse{}, nil
+}
+
+func (s *service) RecalculateTreeSize(ctx context.Context, req *adminpb.RecalculateTreeSizeRequest) (*adminpb.RecalculateTreeSizeResponse, error) {
+	if !s.isAdmin(ctx) {
+		return nil, status.Error(codes.PermissionDenied, "adminprovider: not allowed to recalculate tree sizes")
+	}
+	if req.StorageId == "" || req.SpaceId == "" {
+		return nil, status.Error(codes.InvalidArgument, "adminprovider: missing space")
+	}
+	c, err := s.storageAdminClient(ctx, req.StorageId, req.SpaceId)
+	if err != nil {
+		return nil, err
+	}
+	return c.RecalculateTreeSize(ctx, req)
+}
+
+func (s *service) PurgeTrash(ctx context.Context, req *adminpb.PurgeTrashRequest) (*adminpb.PurgeTrashResponse, error) {
+	if !s.isAdmin(ctx) {
+		return nil, status.Error(codes.PermissionDenied, "adminprovider: not allowed to purge the recycle bins")
+	}
+	if req.StorageId == "" {
+		return nil, status.Error(codes.InvalidArgument, "adminprovider: missing storage")
+	}
+	if req.OlderThanDays < 0 {
+		return nil, status.Error(codes.InvalidArgument, "adminprovider: negative age")
+	}
+	c, err := s.storageAdminClient(ctx, req.StorageId, req.StorageId)
+	if err != nil {
+		return nil, err
+	}
+	return c.PurgeTrash(ctx, req)
+}
+
+func (s *service) InvalidateCaches(ctx context.Context, req *adminpb.InvalidateCachesRequest) (*adminpb.InvalidateCachesResponse, error) {
+	if !s.isAdmin(ctx) {
+		return nil, status.Error(codes.PermissionDenied, "adminprovider: not allowed to invalidate the caches")
+	}
+	c, err := pool.GetCacheAdminClient(s.conf.GatewaySvc)
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	return c.InvalidateCaches(ctx, req)
+}
+
+func (s *service) ReindexSearch(ctx context.Context, req *adminpb.ReindexSearchRequest) (*adminpb.ReindexSearchResponse, error) {
+	if !s.isAdmin(ctx) {
+		return nil, status.Error(codes.PermissionDenied, "adminprovider: not allowed to reindex the search")
+	}
+	if s.conf.SearchSvc == "" {
+		return nil, status.Error(codes.Unimplemented, "adminprovider: no search service configured")
+	}
+	c, err := pool.GetSearchClient(s.conf.SearchSvc)
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	res, err := c.Reindex(ctx, &searchpb.ReindexRequest{OpaqueId: req.OpaqueId, Idp: req.Idp})
+	if err != nil {
+		return nil, err
+	}
+	return &adminpb.ReindexSearchResponse{Indexed: res.Indexed}, nil
+}
+
+// GetVersions returns the version of this service, and of the services
+// listed in the sysinfo endpoints. The services which cannot be reached are
+// reported with an error rather than failing the whole request.
+func (s *service) GetVersions(ctx context.Context, req *adminpb.GetVersionsRequest) (*adminpb.GetVersionsResponse, error) {
+	if !s.isAdmin(ctx) {
+		return nil, status.Error(codes.PermissionDenied, "adminprovider: not allowed to query the versions")
+	}
+	res := &adminpb.GetVersionsResponse{
+		Versions: []*adminpb.ServiceVersion{serviceVersion("adminprovider", sysinfo.SysInfo)},
+	}
+
+	names := make([]string, 0, len(s.conf.SysinfoEndpoints))
+	for name := range s.conf.SysinfoEndpoints {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		info, err := s.getSysinfo(ctx, s.conf.SysinfoEndpoints[name])
+		if err != nil {
+			res.Versions = append(res.Versions, &adminpb.ServiceVersion{Name: name, Error: err.Error()})
+			continue
+		}
+		res.Versions = append(res.Versions, serviceVersion(name, info))
+	}
+	return res, nil
+}
+
+// SetMaintenance starts or ends a maintenance. The address of the storage
+// provider is stored along with its id, for the gateway to recognize it when
+// it is resolved from a path.
+func (s *service) SetMaintenance(ctx context.Context, req *adminpb.SetMaintenanceRequest) (*adminpb.SetMaintenanceResponse, error) {
+	if !s.isAdmin(ctx) {
+		return nil, status.Error(codes.PermissionDenied, "adminprovider: not allowed to set the maintenance")
+	}
+	key := req.StorageId
+	if key == "" {
+		key = maintenance.Global
+	}
+	if !req.Enabled {
+		if err := s.maintenance.SetMode(ctx, key, nil); err != nil {
+			return nil, toStatus(err)
+		}
+		appctx.GetLogger(ctx).Info().Str("storage", key).Msg("adminprovider: ended maintenance")
+		return &adminpb.SetMaintenanceResponse{}, nil
+	}
+
+	mode := &maintenance.Mode{Message: req.Message, Since: time.Now()}
+	if key != maintenance.Global {
+		p, err := s.findStorageProvider(ctx, req.StorageId, req.StorageId)
+		if err != nil {
+			return nil, err
+		}
+		mode.Address = p.Address
+	}
+	if err := s.maintenance.SetMode(ctx, key, mode); err != nil {
+		return nil, toStatus(err)
+	}
+	appctx.GetLogger(ctx).Info().Str("storage", key).Msg("adminprovider: started maintenance")
+	return &adminpb.SetMaintenanceResponse{}, nil
+}
+
+func (s *service) GetMaintenance(ctx context.Context, req *adminpb.GetMaintenanceRequest) (*adminpb.GetMaintenanceResponse, error) {
+	if !s.isAdmin(ctx) {
+		return nil, status.Error(codes.PermissionDenied, "adminprovider: not allowed to query the maintenance")
+	}
+	modes, err := s.maintenance.GetModes(ctx)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	res := &adminpb.GetMaintenanceResponse{}
+	for id, m := range modes {
+		res.Modes = append(res.Modes, &adminpb.MaintenanceMode{StorageId: id, Message: m.Message, Since: m.Since.Unix()})
+	}
+	sort.Slice(res.Modes, func(i, j int) bool { return res.Modes[i].StorageId < res.Modes[j].StorageId })
+	return res, nil
+}
+
+func (s *service) getSysinfo(ctx context.Context, endpoint string) (*sysinfo.SystemInformation, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", res.StatusCode)
+	}
+	info := &sysinfo.SystemInformation{}
+	if err := json.NewDecoder(res.Body).Decode(info); err != nil {
+		return nil, err
+	}
+	return info, nil
+}
+
+func serviceVersion(name string, info *sysinfo.SystemInformation) *adminpb.ServiceVersion {
+	v := &adminpb.ServiceVersion{Name: name}
+	if info == nil || info.Reva == nil {
+		v.Error = "no version information"
+		return v
+	}
+	v.Version = info.Reva.Version
+	v.BuildDate = info.Reva.BuildDate
+	v.GitCommit = info.Reva.GitCommit
+	v.GoVersion = info.Reva.GoVersion
+	return v
+}
+
+// storageAdminClient returns the client of the storage provider serving the
+// space, found with the storage registry.
+func (s *service) storageAdminClient(ctx context.Context, storageID, spaceID string) (adminpb.StorageAdminAPIClient, error) {
+	p, err := s.findStorageProvider(ctx, storageID, spaceID)
+	if err != nil {
+		return nil, err
+	}
+	return pool.GetStorageAdminClient(p.Address)
+}
+
+func (s *service) findStorageProvider(ctx context.Context, storageID, spaceID string) (*registry.ProviderInfo, error) {
+	c, err := pool.GetStorageRegistryClient(s.conf.StorageRegistrySvc)
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	res, err := c.GetStorageProviders(ctx, &registry.GetStorageProvidersRequest{
+		Ref: &provider.Reference{Spec: &provider.Reference_Id{Id: &provider.ResourceId{StorageId: storageID, OpaqueId: spaceID}}},
+	})
+	if err != nil {
+		return nil, status.Error(codes.Unavailable, err.Error())
+	}
+	if res.Status.Code != rpc.Code_CODE_OK || len(res.Providers) == 0 {
+		return nil, status.Error(codes.NotFound, "adminprovider: storage provider not found for "+storageID)
+	}
+	return res.Providers[0], nil
+}
+
+func (s *service) isAdmin(ctx context.Context) bool {
+	u, ok := user.ContextGetUser(ctx)
+	if !ok {
+		return false
+	}
+	allowed, err := permission.IsAdmin(ctx, s.pm, u, s.conf.Admins, s.conf.AdminGroups)
+	if err != nil {
+		appctx.GetLogger(ctx).Error().Err(err).Msg("adminprovider: error checking permission")
+	}
+	return allowed
+}
+
+func toStatus(err error) error {
+	switch err.(type) {
+	case errtypes.IsNotFound:
+		return status.Error(codes.NotFound, err.Error())
+	case errtypes.IsBadRequest:
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errtypes.IsNotSupported:
+		return status.Error(codes.Unimplemented, err.Error())
+	case errtypes.IsPermissionDenied:
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
@@ -0,0 +1,183 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package adminprovider
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	registry "github.com/cs3org/go-cs3apis/cs3/storage/registry/v1beta1"
+	adminpb "github.com/cs3org/reva/internal/grpc/services/adminprovider/proto"
+	"github.com/cs3org/reva/pkg/permission"
+	"github.com/cs3org/reva/pkg/permission/manager/static"
+	"github.com/cs3org/reva/pkg/user"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestIsAdmin(t *testing.T) {
+	lists := &config{Admins: []string{"admin"}, AdminGroups: []string{"support"}}
+	pm, err := static.New(map[string]interface{}{
+		"roles": map[string][]string{"operator": {permission.Administer}, "manager": {permission.ManageSpaces}, "root": {permission.All}},
+		"assignments": map[string]interface{}{
+			"users": map[string][]string{"operator": {"operator"}, "manager": {"manager"}, "root": {"root"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		pm   permission.Manager
+		user *userpb.User
+		want bool
+	}{
+		{"listed admin", nil, &userpb.User{Username: "admin"}, true},
+		{"member of an admin group", nil, &userpb.User{Username: "marie", Groups: []string{"physics", "support"}}, true},
+		{"other user", nil, &userpb.User{Username: "einstein", Groups: []string{"physics"}}, false},
+		{"no user", nil, nil, false},
+		{"capability granted", pm, &userpb.User{Username: "operator"}, true},
+		{"all the capabilities granted", pm, &userpb.User{Username: "root"}, true},
+		{"other capability granted", pm, &userpb.User{Username: "manager"}, false},
+		{"listed admin without the capability", pm, &userpb.User{Username: "admin"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &service{conf: lists, pm: tt.pm}
+			ctx := context.Background()
+			if tt.user != nil {
+				ctx = user.ContextSetUser(ctx, tt.user)
+			}
+			if got := s.isAdmin(ctx); got != tt.want {
+				t.Errorf("isAdmin() = %v, wanted %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotAdminDenied(t *testing.T) {
+	s := &service{conf: &config{Admins: []string{"admin"}}}
+	ctx := user.ContextSetUser(context.Background(), &userpb.User{Username: "einstein"})
+
+	calls := map[string]func() error{
+		"ListUsers": func() error {
+			_, err := s.ListUsers(ctx, &adminpb.ListUsersRequest{})
+			return err
+		},
+		"DisableUser": func() error {
+			_, err := s.DisableUser(ctx, &adminpb.DisableUserRequest{OpaqueId: "marie"})
+			return err
+		},
+		"RecalculateTreeSize": func() error {
+			_, err := s.RecalculateTreeSize(ctx, &adminpb.RecalculateTreeSizeRequest{StorageId: "storage", SpaceId: "space"})
+			return err
+		},
+		"PurgeTrash": func() error {
+			_, err := s.PurgeTrash(ctx, &adminpb.PurgeTrashRequest{StorageId: "storage"})
+			return err
+		},
+		"InvalidateCaches": func() error {
+			_, err := s.InvalidateCaches(ctx, &adminpb.InvalidateCachesRequest{})
+			return err
+		},
+		"ReindexSearch": func() error {
+			_, err := s.ReindexSearch(ctx, &adminpb.ReindexSearchRequest{OpaqueId: "marie"})
+			return err
+		},
+		"GetVersions": func() error {
+			_, err := s.GetVersions(ctx, &adminpb.GetVersionsRequest{})
+			return err
+		},
+		"SetMaintenance": func() error {
+			_, err := s.SetMaintenance(ctx, &adminpb.SetMaintenanceRequest{Enabled: true})
+			return err
+		},
+		"GetMaintenance": func() error {
+			_, err := s.GetMaintenance(ctx, &adminpb.GetMaintenanceRequest{})
+			return err
+		},
+	}
+	for name, call := range calls {
+		t.Run(name, func(t *testing.T) {
+			if code := status.Code(call()); code != codes.PermissionDenied {
+				t.Errorf("got %s, wanted %s", code, codes.PermissionDenied)
+			}
+		})
+	}
+}
+
+type storageRegistry struct {
+	registry.UnimplementedRegistryAPIServer
+	providers map[string]*registry.ProviderInfo
+}
+
+func (r *storageRegistry) GetStorageProviders(ctx context.Context, req *registry.GetStorageProvidersRequest) (*registry.GetStorageProvidersResponse, error) {
+	id := req.Ref.GetId()
+	if id.GetStorageId() == "unavailable" {
+		return nil, status.Error(codes.Unavailable, "registry unavailable")
+	}
+	p, ok := r.providers[id.GetStorageId()+"!"+id.GetOpaqueId()]
+	if !ok {
+		return &registry.GetStorageProvidersResponse{Status: &rpc.Status{Code: rpc.Code_CODE_NOT_FOUND}}, nil
+	}
+	return &registry.GetStorageProvidersResponse{Status: &rpc.Status{Code: rpc.Code_CODE_OK}, Providers: []*registry.ProviderInfo{p}}, nil
+}
+
+func TestFindStorageProvider(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	registry.RegisterRegistryAPIServer(srv, &storageRegistry{providers: map[string]*registry.ProviderInfo{
+		"home!home":    {Address: "home:9000"},
+		"projects!foo": {Address: "projects:9000"},
+	}})
+	go func() { _ = srv.Serve(l) }()
+	defer srv.Stop()
+
+	s := &service{conf: &config{StorageRegistrySvc: l.Addr().String()}}
+	tests := []struct {
+		storage, space string
+		address        string
+		code           codes.Code
+	}{
+		{"home", "home", "home:9000", codes.OK},
+		{"projects", "foo", "projects:9000", codes.OK},
+		{"projects", "bar", "", codes.NotFound},
+		{"unknown", "unknown", "", codes.NotFound},
+		{"unavailable", "unavailable", "", codes.Unavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.storage+"/"+tt.space, func(t *testing.T) {
+			p, err := s.findStorageProvider(context.Background(), tt.storage, tt.space)
+			if code := status.Code(err); code != tt.code {
+				t.Fatalf("got %s, wanted %s", code, tt.code)
+			}
+			if err == nil && p.Address != tt.address {
+				t.Errorf("got the provider %s, wanted %s", p.Address, tt.address)
+			}
+		})
+	}
+}
@@ -21,12 +21,15 @@ package authprovider
 import (
 	"context"
 	"fmt"
+	"time"
 
 	provider "github.com/cs3org/go-cs3apis/cs3/auth/provider/v1beta1"
 	"github.com/cs3org/reva/pkg/appctx"
 	"github.com/cs3org/reva/pkg/auth"
 	"github.com/cs3org/reva/pkg/auth/manager/registry"
 	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/events"
+	eventsregistry "github.com/cs3org/reva/pkg/events/driver/registry"
 	"github.com/cs3org/reva/pkg/rgrpc"
 	"github.com/cs3org/reva/pkg/rgrpc/status"
 	"github.com/mitchellh/mapstructure"
@@ -41,6 +44,8 @@ func init() {
 type config struct {
 	AuthManager  string                            `mapstructure:"auth_manager"`
 	AuthManagers map[string]map[string]interface{} `mapstructure:"auth_managers"`
+	// Events configures the bus the UserLoggedIn events are published to.
+	Events map[string]interface{} `mapstructure:"events"`
 }
 
 func (c *config) init() {
@@ -52,6 +57,7 @@ func (c *config) init() {
 type service struct {
 	authmgr auth.Manager
 	conf    *config
+	stream  events.Stream
 }
 
 func parseConfig(m map[string]interface{}) (*config, error) {
@@ -86,7 +92,12 @@ func New(m map[string]interface{}, ss *grpc.Server) (rgrpc.Service, error) {
 		return nil, err
 	}
 
-	svc := &service{conf: c, authmgr: authManager}
+	stream, err := eventsregistry.NewStream(c.Events)
+	if err != nil {
+		return nil, err
+	}
+
+	svc := &service{conf: c, authmgr: authManager, stream: stream}
 
 	return svc, nil
 }
@@ -112,6 +123,14 @@ func (s *service) Authenticate(ctx context.Context, req *provider.AuthenticateRe
 	switch v := err.(type) {
 	case nil:
 		log.Info().Msgf("user %s authenticated", u.String())
+		if err := events.Publish(ctx, s.stream, events.UserLoggedIn{
+			UserID:   u.Id,
+			Username: u.Username,
+			AuthType: s.conf.AuthManager,
+			Time:     time.Now(),
+		}); err != nil {
+			log.Error().Err(err).Msg("authsvc: error publishing event")
+		}
 		return &provider.AuthenticateResponse{
 			Status:     status.NewOK(ctx),
 			User:       u,
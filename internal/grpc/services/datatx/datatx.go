@@ -22,6 +22,8 @@ import (
 	"context"
 
 	datatx "github.com/cs3org/go-cs3apis/cs3/tx/v1beta1"
+	txdriver "github.com/cs3org/reva/pkg/datatx"
+	"github.com/cs3org/reva/pkg/datatx/manager/registry"
 	"github.com/cs3org/reva/pkg/errtypes"
 	"github.com/cs3org/reva/pkg/rgrpc"
 	"github.com/cs3org/reva/pkg/rgrpc/status"
@@ -35,13 +37,19 @@ func init() {
 }
 
 type config struct {
+	Driver  string                            `mapstructure:"driver"`
+	Drivers map[string]map[string]interface{} `mapstructure:"drivers"`
 }
 
 type service struct {
 	conf *config
+	mgr  txdriver.Manager
 }
 
 func (c *config) init() {
+	if c.Driver == "" {
+		c.Driver = "rclone"
+	}
 }
 
 func (s *service) Register(ss *grpc.Server) {
@@ -57,6 +65,13 @@ func parseConfig(m map[string]interface{}) (*config, error) {
 	return c, nil
 }
 
+func getManager(c *config) (txdriver.Manager, error) {
+	if f, ok := registry.NewFuncs[c.Driver]; ok {
+		return f(c.Drivers[c.Driver])
+	}
+	return nil, errtypes.NotFound("driver not found: " + c.Driver)
+}
+
 // New creates a new datatx svc
 func New(m map[string]interface{}, ss *grpc.Server) (rgrpc.Service, error) {
 
@@ -66,8 +81,14 @@ func New(m map[string]interface{}, ss *grpc.Server) (rgrpc.Service, error) {
 	}
 	c.init()
 
+	mgr, err := getManager(c)
+	if err != nil {
+		return nil, err
+	}
+
 	service := &service{
 		conf: c,
+		mgr:  mgr,
 	}
 
 	return service, nil
@@ -81,20 +102,60 @@ func (s *service) UnprotectedEndpoints() []string {
 	return []string{}
 }
 
+// CreateTransfer starts a transfer. The CS3 API carries the target URIs of
+// the source and the destination in the opaque, in the src_target_uri and
+// dest_target_uri entries; the operation entry set to move removes the
+// source once it is transferred.
 func (s *service) CreateTransfer(ctx context.Context, req *datatx.CreateTransferRequest) (*datatx.CreateTransferResponse, error) {
+	src, dst := opaqueValue(req, "src_target_uri"), opaqueValue(req, "dest_target_uri")
+	if src == "" || dst == "" {
+		err := errtypes.BadRequest("src_target_uri and dest_target_uri are required")
+		return &datatx.CreateTransferResponse{
+			Status: status.NewInvalidArg(ctx, err.Error()),
+		}, nil
+	}
+
+	info, err := s.mgr.CreateTransfer(ctx, src, dst, opaqueValue(req, "operation") == "move")
+	if err != nil {
+		return &datatx.CreateTransferResponse{
+			Status: status.NewStatusFromErrType(ctx, "error creating transfer", err),
+		}, nil
+	}
 	return &datatx.CreateTransferResponse{
-		Status: status.NewUnimplemented(ctx, errtypes.NotSupported("CreateTransfer not implemented"), "CreateTransfer not implemented"),
+		Status: status.NewOK(ctx),
+		TxInfo: info,
 	}, nil
 }
 
-func (s *service) GetTransferStatus(ctx context.Context, in *datatx.GetTransferStatusRequest) (*datatx.GetTransferStatusResponse, error) {
+func (s *service) GetTransferStatus(ctx context.Context, req *datatx.GetTransferStatusRequest) (*datatx.GetTransferStatusResponse, error) {
+	info, err := s.mgr.GetTransferStatus(ctx, req.GetTxId().GetOpaqueId())
+	if err != nil {
+		return &datatx.GetTransferStatusResponse{
+			Status: status.NewStatusFromErrType(ctx, "error getting transfer status", err),
+		}, nil
+	}
 	return &datatx.GetTransferStatusResponse{
-		Status: status.NewUnimplemented(ctx, errtypes.NotSupported("GetTransferStatus not implemented"), "GetTransferStatus not implemented"),
+		Status: status.NewOK(ctx),
+		TxInfo: info,
 	}, nil
 }
 
-func (s *service) CancelTransfer(ctx context.Context, in *datatx.CancelTransferRequest) (*datatx.CancelTransferResponse, error) {
+func (s *service) CancelTransfer(ctx context.Context, req *datatx.CancelTransferRequest) (*datatx.CancelTransferResponse, error) {
+	info, err := s.mgr.CancelTransfer(ctx, req.GetTxId().GetOpaqueId())
+	if err != nil {
+		return &datatx.CancelTransferResponse{
+			Status: status.NewStatusFromErrType(ctx, "error cancelling transfer", err),
+		}, nil
+	}
 	return &datatx.CancelTransferResponse{
-		Status: status.NewUnimplemented(ctx, errtypes.NotSupported("CancelTransfer not implemented"), "CancelTransfer not implemented"),
+		Status: status.NewOK(ctx),
+		TxInfo: info,
 	}, nil
 }
+
+func opaqueValue(req *datatx.CreateTransferRequest, key string) string {
+	if e, ok := req.GetOpaque().GetMap()[key]; ok && e.Decoder == "plain" {
+		return string(e.Value)
+	}
+	return ""
+}
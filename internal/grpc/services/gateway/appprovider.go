@@ -37,6 +37,7 @@ import (
 	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
 	"github.com/cs3org/reva/pkg/token"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/metadata"
@@ -266,13 +267,17 @@ func getGRPCConfig(opaque *typespb.Opaque) (bool, bool) {
 }
 
 func getConn(host string, insecure, skipverify bool) (*grpc.ClientConn, error) {
+	interceptors := []grpc.DialOption{
+		grpc.WithUnaryInterceptor(otelgrpc.UnaryClientInterceptor()),
+		grpc.WithStreamInterceptor(otelgrpc.StreamClientInterceptor()),
+	}
 	if insecure {
-		return grpc.Dial(host, grpc.WithInsecure())
+		return grpc.Dial(host, append(interceptors, grpc.WithInsecure())...)
 	}
 
 	// TODO(labkode): if in the future we want client-side certificate validation,
 	// we need to load the client cert here
 	tlsconf := &tls.Config{InsecureSkipVerify: skipverify}
 	creds := credentials.NewTLS(tlsconf)
-	return grpc.Dial(host, grpc.WithTransportCredentials(creds))
+	return grpc.Dial(host, append(interceptors, grpc.WithTransportCredentials(creds))...)
 }
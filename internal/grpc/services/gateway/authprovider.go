@@ -29,6 +29,7 @@ import (
 	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
 	storageprovider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/auth/scope"
 	"github.com/cs3org/reva/pkg/errtypes"
 	"github.com/cs3org/reva/pkg/rgrpc/status"
 	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
@@ -102,7 +103,10 @@ func (s *svc) Authenticate(ctx context.Context, req *gateway.AuthenticateRequest
 		return res, nil
 	}
 
-	if scope, ok := res.TokenScope["user"]; s.c.DisableHomeCreationOnLogin || !ok || scope.Role != authpb.Role_ROLE_OWNER {
+	// the impersonation tokens act on behalf of existing users, whose home is
+	// not created nor are they provisioned by the impersonator.
+	_, impersonated := scope.GetImpersonator(res.TokenScope)
+	if scope, ok := res.TokenScope["user"]; s.c.DisableHomeCreationOnLogin || impersonated || !ok || scope.Role != authpb.Role_ROLE_OWNER {
 		gwRes := &gateway.AuthenticateResponse{
 			Status: status.NewOK(ctx),
 			User:   res.User,
@@ -134,6 +138,10 @@ func (s *svc) Authenticate(ctx context.Context, req *gateway.AuthenticateRequest
 		}, nil
 	}
 
+	if s.c.Provisioning.Enabled {
+		s.provision(ctx, res.User)
+	}
+
 	gwRes := &gateway.AuthenticateResponse{
 		Status: status.NewOK(ctx),
 		User:   res.User,
@@ -0,0 +1,50 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package gateway
+
+import (
+	"context"
+
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/internal/grpc/services/storageprovider"
+	batchpb "github.com/cs3org/reva/internal/grpc/services/storageprovider/proto"
+	"github.com/cs3org/reva/pkg/rgrpc/status"
+)
+
+// StatMany stats the resources as Stat does, e.g. resolving the shares in
+// the share folder, saving the clients a round trip per resource.
+func (s *svc) StatMany(ctx context.Context, req *batchpb.StatManyRequest) (*batchpb.StatManyResponse, error) {
+	return storageprovider.StatMany(ctx, req, s.Stat)
+}
+
+// AddGrantBatch adds the grants on the storage providers of the resources,
+// which check that the user is allowed to.
+func (s *svc) AddGrantBatch(ctx context.Context, req *batchpb.AddGrantBatchRequest) (*batchpb.AddGrantBatchResponse, error) {
+	return storageprovider.AddGrantBatch(ctx, req, s.addGrantOnProvider)
+}
+
+func (s *svc) addGrantOnProvider(ctx context.Context, req *provider.AddGrantRequest) (*provider.AddGrantResponse, error) {
+	c, err := s.find(ctx, req.Ref)
+	if err != nil {
+		return &provider.AddGrantResponse{
+			Status: status.NewStatusFromErrType(ctx, "error finding storage provider", err),
+		}, nil
+	}
+	return c.AddGrant(ctx, req)
+}
@@ -0,0 +1,53 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package gateway
+
+import (
+	"context"
+
+	adminpb "github.com/cs3org/reva/internal/grpc/services/adminprovider/proto"
+	"github.com/cs3org/reva/pkg/permission"
+	userpkg "github.com/cs3org/reva/pkg/user"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+)
+
+// InvalidateCaches empties the caches of the gateway, e.g. after the etags
+// of a storage provider were changed behind its back.
+func (s *svc) InvalidateCaches(ctx context.Context, req *adminpb.InvalidateCachesRequest) (*adminpb.InvalidateCachesResponse, error) {
+	if !s.isAdmin(ctx) {
+		return nil, grpcstatus.Error(codes.PermissionDenied, "not allowed to invalidate the caches")
+	}
+	if err := s.etagCache.Purge(ctx); err != nil {
+		return nil, grpcstatus.Error(codes.Internal, err.Error())
+	}
+	if err := s.providerCache.Purge(ctx); err != nil {
+		return nil, grpcstatus.Error(codes.Internal, err.Error())
+	}
+	return &adminpb.InvalidateCachesResponse{Caches: []string{"etag", "providers"}}, nil
+}
+
+func (s *svc) isAdmin(ctx context.Context) bool {
+	u, ok := userpkg.ContextGetUser(ctx)
+	if !ok {
+		return false
+	}
+	allowed, _ := permission.IsAdmin(ctx, s.permissions, u, s.c.Admins, s.c.AdminGroups)
+	return allowed
+}
@@ -0,0 +1,66 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package gateway
+
+import (
+	"context"
+	"testing"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/pkg/permission"
+	"github.com/cs3org/reva/pkg/permission/manager/static"
+	userpkg "github.com/cs3org/reva/pkg/user"
+)
+
+func TestIsAdmin(t *testing.T) {
+	pm, err := static.New(map[string]interface{}{
+		"assignments": map[string]interface{}{
+			"users": map[string][]string{"alice": {permission.RoleAdmin}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &config{Admins: []string{"bob"}}
+
+	tests := []struct {
+		name  string
+		pm    permission.Manager
+		user  string
+		admin bool
+	}{
+		{"admin role", pm, "alice", true},
+		// the permission driver replaces the lists of admins
+		{"listed admin with a driver", pm, "bob", false},
+		{"listed admin", nil, "bob", true},
+		{"user", nil, "alice", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &svc{c: c, permissions: tt.pm}
+			u := &userpb.User{Id: &userpb.UserId{OpaqueId: tt.user}, Username: tt.user}
+			if admin := s.isAdmin(userpkg.ContextSetUser(context.Background(), u)); admin != tt.admin {
+				t.Errorf("got %v, wanted %v", admin, tt.admin)
+			}
+		})
+	}
+	if (&svc{c: c}).isAdmin(context.Background()) {
+		t.Error("an anonymous request is admin")
+	}
+}
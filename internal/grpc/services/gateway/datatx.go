@@ -21,9 +21,15 @@ package gateway
 import (
 	"context"
 
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	ocm "github.com/cs3org/go-cs3apis/cs3/sharing/ocm/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	datatx "github.com/cs3org/go-cs3apis/cs3/tx/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	txdriver "github.com/cs3org/reva/pkg/datatx"
 	"github.com/cs3org/reva/pkg/rgrpc/status"
 	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
+	"github.com/cs3org/reva/pkg/token"
 	"github.com/pkg/errors"
 )
 
@@ -77,3 +83,98 @@ func (s *svc) CancelTransfer(ctx context.Context, req *datatx.CancelTransferRequ
 
 	return res, nil
 }
+
+// newTransferRequest returns the request transferring the resource at the
+// source target URI to the destination one, operation being copy or move.
+func newTransferRequest(src, dst, operation string) *datatx.CreateTransferRequest {
+	return &datatx.CreateTransferRequest{
+		Opaque: &types.Opaque{
+			Map: map[string]*types.OpaqueEntry{
+				"src_target_uri":  {Decoder: "plain", Value: []byte(src)},
+				"dest_target_uri": {Decoder: "plain", Value: []byte(dst)},
+				"operation":       {Decoder: "plain", Value: []byte(operation)},
+			},
+		},
+	}
+}
+
+// createOCMTransfer transfers the data of a share of type transfer from the
+// site of the sharer to the folder of the recipient.
+func (s *svc) createOCMTransfer(ctx context.Context, share *ocm.Share, shareToken, dst string) (*rpc.Status, error) {
+	webdavEP, err := s.getWebdavEndpoint(ctx, share.Creator.Idp)
+	if err != nil {
+		return status.NewInternal(ctx, err, "error getting webdav endpoint of the sharer"), nil
+	}
+	srcURI, err := txdriver.TargetURI(webdavEP, share.Name, shareToken)
+	if err != nil {
+		return status.NewInternal(ctx, err, "error building transfer source"), nil
+	}
+	tkn, ok := token.ContextGetToken(ctx)
+	if !ok {
+		return status.NewUnauthenticated(ctx, errors.New("token not found in context"), "error building transfer destination"), nil
+	}
+	dstURI, err := txdriver.TargetURI(s.c.DataTxWebdavEndpoint, dst, tkn)
+	if err != nil {
+		return status.NewInternal(ctx, err, "error building transfer destination"), nil
+	}
+
+	res, err := s.CreateTransfer(ctx, newTransferRequest(srcURI, dstURI, "copy"))
+	if err != nil {
+		return status.NewInternal(ctx, err, "error creating transfer"), nil
+	}
+	return res.Status, nil
+}
+
+// crossStorageMove moves a resource to another storage provider with a data
+// transfer. The move completes asynchronously, the id of the transfer is
+// returned in the transfer_id entry of the opaque of the response.
+func (s *svc) crossStorageMove(ctx context.Context, req *provider.MoveRequest) (*provider.MoveResponse, error) {
+	src := req.Source.GetPath()
+	if src == "" {
+		statRes, err := s.Stat(ctx, &provider.StatRequest{Ref: req.Source})
+		if err != nil {
+			return nil, errors.Wrap(err, "gateway: error calling Stat")
+		}
+		if statRes.Status.Code != rpc.Code_CODE_OK {
+			return &provider.MoveResponse{Status: statRes.Status}, nil
+		}
+		src = statRes.Info.Path
+	}
+	dst := req.Destination.GetPath()
+	if dst == "" {
+		return &provider.MoveResponse{
+			Status: status.NewInvalidArg(ctx, "the destination of a cross storage move must be a path"),
+		}, nil
+	}
+
+	tkn, ok := token.ContextGetToken(ctx)
+	if !ok {
+		return &provider.MoveResponse{
+			Status: status.NewUnauthenticated(ctx, errors.New("token not found in context"), "error moving across storages"),
+		}, nil
+	}
+	srcURI, err := txdriver.TargetURI(s.c.DataTxWebdavEndpoint, src, tkn)
+	if err != nil {
+		return &provider.MoveResponse{Status: status.NewInternal(ctx, err, "error building transfer source")}, nil
+	}
+	dstURI, err := txdriver.TargetURI(s.c.DataTxWebdavEndpoint, dst, tkn)
+	if err != nil {
+		return &provider.MoveResponse{Status: status.NewInternal(ctx, err, "error building transfer destination")}, nil
+	}
+
+	res, err := s.CreateTransfer(ctx, newTransferRequest(srcURI, dstURI, "move"))
+	if err != nil {
+		return nil, errors.Wrap(err, "gateway: error calling CreateTransfer")
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return &provider.MoveResponse{Status: res.Status}, nil
+	}
+	return &provider.MoveResponse{
+		Status: status.NewOK(ctx),
+		Opaque: &types.Opaque{
+			Map: map[string]*types.OpaqueEntry{
+				"transfer_id": {Decoder: "plain", Value: []byte(res.TxInfo.GetId().GetOpaqueId())},
+			},
+		},
+	}, nil
+}
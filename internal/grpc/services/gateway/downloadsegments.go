@@ -0,0 +1,107 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package gateway
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strconv"
+
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/pkg/errors"
+)
+
+// downloadSegmentsKey is the opaque entry of the download requests holding
+// the number of segments the client wants to download in parallel. The
+// response carries the segments, as JSON, in the same entry.
+const downloadSegmentsKey = "download_segments"
+
+// downloadSegment is a byte range of a file, downloadable from its own URL
+// of the data gateway.
+type downloadSegment struct {
+	Offset int64  `json:"offset"`
+	Length int64  `json:"length"`
+	URL    string `json:"url"`
+}
+
+// addDownloadSegments splits the file to download into segments of equal
+// size, each with a transfer token restricted to its range, and adds them to
+// the opaque of the response.
+func (s *svc) addDownloadSegments(ctx context.Context, c provider.ProviderAPIClient, req *provider.InitiateFileDownloadRequest, target string, opaque *types.Opaque) (*types.Opaque, error) {
+	n, err := strconv.Atoi(string(req.Opaque.Map[downloadSegmentsKey].Value))
+	if err != nil || n < 1 {
+		return nil, errtypes.BadRequest("invalid number of download segments")
+	}
+	if n > s.c.MaxDownloadSegments {
+		n = s.c.MaxDownloadSegments
+	}
+
+	statRes, err := c.Stat(ctx, &provider.StatRequest{Ref: req.Ref})
+	if err != nil {
+		return nil, errors.Wrap(err, "gateway: error stating file to download")
+	}
+	if statRes.Status.Code != rpc.Code_CODE_OK {
+		return nil, errtypes.InternalError(statRes.Status.Message)
+	}
+
+	segments := []downloadSegment{}
+	for _, seg := range splitSegments(int64(statRes.Info.Size), n) {
+		tkn, err := s.signRange(ctx, target, fmt.Sprintf("bytes=%d-%d", seg.Offset, seg.Offset+seg.Length-1))
+		if err != nil {
+			return nil, err
+		}
+		u := s.dataGatewayURL
+		u.Path = path.Join(u.Path, tkn)
+		seg.URL = u.String()
+		segments = append(segments, seg)
+	}
+	value, err := json.Marshal(segments)
+	if err != nil {
+		return nil, err
+	}
+
+	if opaque == nil {
+		opaque = &types.Opaque{}
+	}
+	if opaque.Map == nil {
+		opaque.Map = map[string]*types.OpaqueEntry{}
+	}
+	opaque.Map[downloadSegmentsKey] = &types.OpaqueEntry{Decoder: "json", Value: value}
+	return opaque, nil
+}
+
+// splitSegments splits size bytes into at most n segments of equal size,
+// the last one being shorter.
+func splitSegments(size int64, n int) []downloadSegment {
+	segSize := (size + int64(n) - 1) / int64(n)
+	var segments []downloadSegment
+	for offset := int64(0); offset < size; offset += segSize {
+		length := segSize
+		if offset+length > size {
+			length = size - offset
+		}
+		segments = append(segments, downloadSegment{Offset: offset, Length: length})
+	}
+	return segments
+}
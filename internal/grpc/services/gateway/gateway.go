@@ -22,12 +22,25 @@ import (
 	"fmt"
 	"net/url"
 	"strings"
+	"sync"
 	"time"
 
 	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	adminpb "github.com/cs3org/reva/internal/grpc/services/adminprovider/proto"
+	ocmcorepb "github.com/cs3org/reva/internal/grpc/services/ocmcore/proto"
+	lockpb "github.com/cs3org/reva/internal/grpc/services/storageprovider/proto"
 
-	"github.com/ReneKroon/ttlcache/v2"
+	"github.com/cs3org/reva/pkg/cache"
+	cacheregistry "github.com/cs3org/reva/pkg/cache/driver/registry"
+	"github.com/cs3org/reva/pkg/dlock"
+	dlockregistry "github.com/cs3org/reva/pkg/dlock/driver/registry"
 	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/events"
+	eventsregistry "github.com/cs3org/reva/pkg/events/driver/registry"
+	"github.com/cs3org/reva/pkg/maintenance"
+	maintenanceregistry "github.com/cs3org/reva/pkg/maintenance/manager/registry"
+	"github.com/cs3org/reva/pkg/permission"
+	permregistry "github.com/cs3org/reva/pkg/permission/manager/registry"
 	"github.com/cs3org/reva/pkg/rgrpc"
 	"github.com/cs3org/reva/pkg/sharedconf"
 	"github.com/cs3org/reva/pkg/token"
@@ -69,6 +82,49 @@ type config struct {
 	HomeMapping         string                            `mapstructure:"home_mapping"`
 	TokenManagers       map[string]map[string]interface{} `mapstructure:"token_managers"`
 	EtagCacheTTL        int                               `mapstructure:"etag_cache_ttl"`
+	// ProviderCacheTTL is the number of seconds the storage providers
+	// resolved for a reference are cached, 0 disabling the cache.
+	ProviderCacheTTL int `mapstructure:"provider_cache_ttl"`
+	// Cache configures the driver of the etag, provider and idempotency
+	// caches, e.g. redis for them to be shared by the replicas of the
+	// gateway.
+	Cache map[string]interface{} `mapstructure:"cache"`
+	// MaintenanceDriver is the store of the maintenance modes of the storage
+	// providers, the gateway refusing the mutating calls to the ones in
+	// maintenance.
+	MaintenanceDriver  string                            `mapstructure:"maintenance_driver"`
+	MaintenanceDrivers map[string]map[string]interface{} `mapstructure:"maintenance_drivers"`
+	// IdempotencyTTL is the number of seconds the responses of the requests
+	// carrying an idempotency key are kept to be replayed, a negative value
+	// disabling the replays.
+	IdempotencyTTL int `mapstructure:"idempotency_ttl"`
+	// Locks configures the locks serializing the retries of a request with
+	// the same idempotency key, e.g. redis for them to be shared by the
+	// replicas of the gateway.
+	Locks map[string]interface{} `mapstructure:"locks"`
+	// DataTxWebdavEndpoint is the WebDAV endpoint exposing the namespace of
+	// the gateway, used as the destination of the data transfers, e.g. an
+	// ocdav service with the / files namespace. When it is set, the shares
+	// of type transfer are transferred to the Data-Transfers folder.
+	DataTxWebdavEndpoint string `mapstructure:"datatx_webdav_endpoint"`
+	// CrossStorageMove makes the moves across storage providers run as data
+	// transfers, requiring DataTxWebdavEndpoint.
+	CrossStorageMove bool `mapstructure:"cross_storage_move"`
+	// MaxDownloadSegments caps the number of segments a download can be
+	// split into when the client asks for segmented download URLs.
+	MaxDownloadSegments int `mapstructure:"max_download_segments"`
+	// Admins and AdminGroups are the users, and the groups of users, allowed
+	// to invalidate the caches of the gateway, when no PermissionDriver
+	// grants the system.admin capability.
+	Admins      []string `mapstructure:"admins"`
+	AdminGroups []string `mapstructure:"admin_groups"`
+	// PermissionDriver resolves the roles of the users, for the admin tasks
+	// and the provisioning of the users.
+	PermissionDriver  string                            `mapstructure:"permission_driver"`
+	PermissionDrivers map[string]map[string]interface{} `mapstructure:"permission_drivers"`
+	// Provisioning configures the provisioning of the users at their first
+	// login.
+	Provisioning provisioningConfig `mapstructure:"provisioning"`
 }
 
 // sets defaults
@@ -87,6 +143,16 @@ func (c *config) init() {
 		c.TokenManager = "jwt"
 	}
 
+	if c.IdempotencyTTL == 0 {
+		c.IdempotencyTTL = 86400
+	}
+
+	c.Provisioning.init()
+
+	if c.MaintenanceDriver == "" {
+		c.MaintenanceDriver = "memory"
+	}
+
 	// if services address are not specified we used the shared conf
 	// for the gatewaysvc to have dev setups very quickly.
 	c.AuthRegistryEndpoint = sharedconf.GetGatewaySVC(c.AuthRegistryEndpoint)
@@ -113,13 +179,27 @@ func (c *config) init() {
 	if c.TransferExpires == 0 {
 		c.TransferExpires = 10
 	}
+
+	if c.MaxDownloadSegments == 0 {
+		c.MaxDownloadSegments = 16
+	}
 }
 
 type svc struct {
 	c              *config
 	dataGatewayURL url.URL
 	tokenmgr       token.Manager
-	etagCache      *ttlcache.Cache `mapstructure:"etag_cache"`
+	etagCache      cache.Cache
+	providerCache  *cache.ProviderCache
+	maintenance    maintenance.Manager
+	idempotency    cache.Cache
+	// idempotencyLocks are the locks of the idempotency keys in use.
+	idempotencyLocks dlock.Locker
+	permissions      permission.Manager
+	stream           events.Stream
+	// quotaSynced holds the times of the last synchronizations of the
+	// identity quotas, by user id.
+	quotaSynced sync.Map
 }
 
 // New creates a new gateway svc that acts as a proxy for any grpc operation.
@@ -144,15 +224,51 @@ func New(m map[string]interface{}, ss *grpc.Server) (rgrpc.Service, error) {
 		return nil, err
 	}
 
-	etagCache := ttlcache.NewCache()
-	_ = etagCache.SetTTL(time.Duration(c.EtagCacheTTL) * time.Second)
-	etagCache.SkipTTLExtensionOnHit(true)
+	mf, ok := maintenanceregistry.NewFuncs[c.MaintenanceDriver]
+	if !ok {
+		return nil, errtypes.NotFound("gateway: maintenance driver not found: " + c.MaintenanceDriver)
+	}
+	mm, err := mf(c.MaintenanceDrivers[c.MaintenanceDriver])
+	if err != nil {
+		return nil, err
+	}
+
+	etagCache, err := cacheregistry.NewCache("etag", c.Cache)
+	if err != nil {
+		return nil, err
+	}
+	providerCache, err := cacheregistry.NewCache("providers", c.Cache)
+	if err != nil {
+		return nil, err
+	}
+	idempotencyCache, err := cacheregistry.NewCache("idempotency", c.Cache)
+	if err != nil {
+		return nil, err
+	}
+	idempotencyLocks, err := dlockregistry.NewLocker(c.Locks)
+	if err != nil {
+		return nil, err
+	}
 
 	s := &svc{
-		c:              c,
-		dataGatewayURL: *u,
-		tokenmgr:       tokenManager,
-		etagCache:      etagCache,
+		c:                c,
+		dataGatewayURL:   *u,
+		tokenmgr:         tokenManager,
+		etagCache:        etagCache,
+		providerCache:    cache.NewProviderCache(providerCache, time.Duration(c.ProviderCacheTTL)*time.Second),
+		maintenance:      mm,
+		idempotency:      idempotencyCache,
+		idempotencyLocks: idempotencyLocks,
+	}
+
+	if s.permissions, err = permregistry.New(c.PermissionDriver, c.PermissionDrivers); err != nil {
+		return nil, errors.Wrap(err, "gateway: error creating permission manager")
+	}
+
+	if c.Provisioning.Enabled {
+		if s.stream, err = eventsregistry.NewStream(c.Provisioning.Events); err != nil {
+			return nil, errors.Wrap(err, "gateway: error creating events stream")
+		}
 	}
 
 	return s, nil
@@ -160,15 +276,19 @@ func New(m map[string]interface{}, ss *grpc.Server) (rgrpc.Service, error) {
 
 func (s *svc) Register(ss *grpc.Server) {
 	gateway.RegisterGatewayAPIServer(ss, s)
+	lockpb.RegisterLockAPIServer(ss, s)
+	lockpb.RegisterStreamAPIServer(ss, s)
+	lockpb.RegisterBatchAPIServer(ss, s)
+	adminpb.RegisterCacheAdminAPIServer(ss, s)
+	ocmcorepb.RegisterOCMNotificationsAPIServer(ss, s)
 }
 
 func (s *svc) Close() error {
-	s.etagCache.Close()
 	return nil
 }
 
 func (s *svc) UnprotectedEndpoints() []string {
-	return []string{"/cs3.gateway.v1beta1.GatewayAPI"}
+	return []string{"/cs3.gateway.v1beta1.GatewayAPI", "/revad.ocmcore.OCMNotificationsAPI"}
 }
 
 func parseConfig(m map[string]interface{}) (*config, error) {
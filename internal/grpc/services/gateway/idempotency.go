@@ -0,0 +1,221 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package gateway
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	collaboration "github.com/cs3org/go-cs3apis/cs3/sharing/collaboration/v1beta1"
+	link "github.com/cs3org/go-cs3apis/cs3/sharing/link/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/dlock"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/idempotency"
+	"github.com/cs3org/reva/pkg/rgrpc/status"
+	userpkg "github.com/cs3org/reva/pkg/user"
+	"github.com/cs3org/reva/pkg/utils"
+	"github.com/golang/protobuf/proto"
+)
+
+// idempotencyLockTTL bounds the time a call holds the lock of its idempotency
+// key, the retries waiting for it to complete.
+const idempotencyLockTTL = 5 * time.Minute
+
+// The mutating calls below can carry an idempotency key, their successful
+// responses being replayed when the same user retries the same request with
+// the same key, e.g. after a timeout. The uploads are not replayed, as their
+// responses hold upload URLs which cannot be used twice.
+
+func (s *svc) CreateContainer(ctx context.Context, req *provider.CreateContainerRequest) (*provider.CreateContainerResponse, error) {
+	call, err := s.startIdempotentCall(ctx, "CreateContainer", req)
+	if err != nil {
+		return &provider.CreateContainerResponse{Status: status.NewStatusFromErrType(ctx, "CreateContainer", err)}, nil
+	}
+	defer call.end(ctx)
+	if res := (&provider.CreateContainerResponse{}); call.replay(ctx, res) {
+		return res, nil
+	}
+	res, err := s.doCreateContainer(ctx, req)
+	call.record(ctx, res, err)
+	return res, err
+}
+
+func (s *svc) Move(ctx context.Context, req *provider.MoveRequest) (*provider.MoveResponse, error) {
+	call, err := s.startIdempotentCall(ctx, "Move", req)
+	if err != nil {
+		return &provider.MoveResponse{Status: status.NewStatusFromErrType(ctx, "Move", err)}, nil
+	}
+	defer call.end(ctx)
+	if res := (&provider.MoveResponse{}); call.replay(ctx, res) {
+		return res, nil
+	}
+	res, err := s.doMove(ctx, req)
+	call.record(ctx, res, err)
+	return res, err
+}
+
+func (s *svc) CreateShare(ctx context.Context, req *collaboration.CreateShareRequest) (*collaboration.CreateShareResponse, error) {
+	call, err := s.startIdempotentCall(ctx, "CreateShare", req)
+	if err != nil {
+		return &collaboration.CreateShareResponse{Status: status.NewStatusFromErrType(ctx, "CreateShare", err)}, nil
+	}
+	defer call.end(ctx)
+	if res := (&collaboration.CreateShareResponse{}); call.replay(ctx, res) {
+		return res, nil
+	}
+	res, err := s.createShare(ctx, req)
+	call.record(ctx, res, err)
+	return res, err
+}
+
+func (s *svc) CreatePublicShare(ctx context.Context, req *link.CreatePublicShareRequest) (*link.CreatePublicShareResponse, error) {
+	call, err := s.startIdempotentCall(ctx, "CreatePublicShare", req)
+	if err != nil {
+		return &link.CreatePublicShareResponse{Status: status.NewStatusFromErrType(ctx, "CreatePublicShare", err)}, nil
+	}
+	defer call.end(ctx)
+	if res := (&link.CreatePublicShareResponse{}); call.replay(ctx, res) {
+		return res, nil
+	}
+	res, err := s.createPublicShare(ctx, req)
+	call.record(ctx, res, err)
+	return res, err
+}
+
+// idempotentCall is a call carrying an idempotency key. The nil call, for the
+// calls without key, runs them as usual.
+type idempotentCall struct {
+	s    *svc
+	key  string
+	hash string
+	lock dlock.Lock
+	// cached is the response recorded by a previous call with the same key.
+	cached json.RawMessage
+}
+
+// cachedResponse is the response of a call recorded under its key, with the
+// hash of the request it answered.
+type cachedResponse struct {
+	Hash     string          `json:"hash"`
+	Response json.RawMessage `json:"response"`
+}
+
+// startIdempotentCall locks the idempotency key of the call, if any, waiting
+// for a concurrent call with the same key to complete, and looks up the
+// response recorded under it. The key is shared by the requests of a user to a
+// method: reusing it for another request is refused with a
+// errtypes.PreconditionFailed error.
+func (s *svc) startIdempotentCall(ctx context.Context, method string, req proto.Message) (*idempotentCall, error) {
+	if s.c.IdempotencyTTL < 0 {
+		return nil, nil
+	}
+	k := idempotency.GetKey(ctx)
+	if k == "" {
+		return nil, nil
+	}
+	u, ok := userpkg.ContextGetUser(ctx)
+	if !ok {
+		return nil, nil
+	}
+	buf := proto.NewBuffer(nil)
+	buf.SetDeterministic(true)
+	if err := buf.Marshal(req); err != nil {
+		return nil, nil
+	}
+	rh := sha256.Sum256(buf.Bytes())
+
+	h := sha256.New()
+	for _, v := range []string{u.Id.GetIdp(), u.Id.GetOpaqueId(), method, k} {
+		_, _ = h.Write([]byte(v))
+		_, _ = h.Write([]byte{0})
+	}
+	c := &idempotentCall{s: s, key: hex.EncodeToString(h.Sum(nil)), hash: hex.EncodeToString(rh[:])}
+
+	lock, err := dlock.Acquire(ctx, s.idempotencyLocks, "idempotency:"+c.key, idempotencyLockTTL)
+	if err != nil {
+		return nil, errtypes.Unavailable("error locking idempotency key: " + err.Error())
+	}
+	c.lock = lock
+
+	b, err := s.idempotency.Get(ctx, c.key)
+	if err != nil {
+		return c, nil
+	}
+	var cached cachedResponse
+	if err := json.Unmarshal(b, &cached); err != nil {
+		return c, nil
+	}
+	if cached.Hash != c.hash {
+		c.end(ctx)
+		return nil, errtypes.PreconditionFailed("idempotency key already used for another request")
+	}
+	c.cached = cached.Response
+	return c, nil
+}
+
+// replay fills res with the response recorded under the key, if any.
+func (c *idempotentCall) replay(ctx context.Context, res proto.Message) bool {
+	if c == nil || c.cached == nil {
+		return false
+	}
+	if err := utils.UnmarshalJSONToProtoV1(c.cached, res); err != nil {
+		return false
+	}
+	appctx.GetLogger(ctx).Info().Msg("gateway: replaying response of idempotent request")
+	return true
+}
+
+// record caches the response under the key when the call succeeded, the
+// failed calls being run again on retries.
+func (c *idempotentCall) record(ctx context.Context, res interface {
+	proto.Message
+	GetStatus() *rpc.Status
+}, err error) {
+	if c == nil || err != nil || res.GetStatus().GetCode() != rpc.Code_CODE_OK {
+		return
+	}
+	r, err := utils.MarshalProtoV1ToJSON(res)
+	if err != nil {
+		return
+	}
+	b, err := json.Marshal(&cachedResponse{Hash: c.hash, Response: r})
+	if err != nil {
+		return
+	}
+	if err := c.s.idempotency.Set(ctx, c.key, b, time.Duration(c.s.c.IdempotencyTTL)*time.Second); err != nil {
+		appctx.GetLogger(ctx).Error().Err(err).Msg("gateway: error caching response of idempotent request")
+	}
+}
+
+// end releases the key for the retries waiting for the call.
+func (c *idempotentCall) end(ctx context.Context) {
+	if c == nil || c.lock == nil {
+		return
+	}
+	if err := c.lock.Unlock(ctx); err != nil {
+		appctx.GetLogger(ctx).Error().Err(err).Msg("gateway: error unlocking idempotency key")
+	}
+	c.lock = nil
+}
@@ -0,0 +1,128 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+package gateway
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	cachememory "github.com/cs3org/reva/pkg/cache/driver/memory"
+	dlockmemory "github.com/cs3org/reva/pkg/dlock/driver/memory"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/idempotency"
+	"github.com/cs3org/reva/pkg/rgrpc/status"
+	userpkg "github.com/cs3org/reva/pkg/user"
+	"google.golang.org/grpc/metadata"
+)
+
+func newIdempotencyService(t *testing.T) *svc {
+	c, err := cachememory.New("idempotency", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := dlockmemory.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &svc{c: &config{IdempotencyTTL: 60}, idempotency: c, idempotencyLocks: l}
+}
+
+func idempotencyContext(key string) context.Context {
+	ctx := userpkg.ContextSetUser(context.Background(), &userpb.User{Id: &userpb.UserId{Idp: "localhost", OpaqueId: "einstein"}})
+	if key == "" {
+		return ctx
+	}
+	return metadata.NewIncomingContext(ctx, metadata.Pairs(idempotency.MetadataKey, key))
+}
+
+func mkcol(path string) *provider.CreateContainerRequest {
+	return &provider.CreateContainerRequest{Ref: &provider.Reference{Spec: &provider.Reference_Path{Path: path}}}
+}
+
+func TestIdempotentCall(t *testing.T) {
+	s := newIdempotencyService(t)
+
+	// without key the calls are not recorded
+	ctx := idempotencyContext("")
+	call, err := s.startIdempotentCall(ctx, "CreateContainer", mkcol("/a"))
+	if call != nil || err != nil {
+		t.Fatalf("expected no idempotent call without key, got %v, %v", call, err)
+	}
+
+	ctx = idempotencyContext("k1")
+	call, err = s.startIdempotentCall(ctx, "CreateContainer", mkcol("/a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if call.replay(ctx, &provider.CreateContainerResponse{}) {
+		t.Fatal("expected nothing to replay for the first call")
+	}
+	call.record(ctx, &provider.CreateContainerResponse{Status: status.NewOK(ctx)}, nil)
+
+	// a concurrent retry waits for the first call and replays its response
+	replayed := make(chan bool)
+	go func() {
+		retry, err := s.startIdempotentCall(ctx, "CreateContainer", mkcol("/a"))
+		if err != nil {
+			replayed <- false
+			return
+		}
+		defer retry.end(ctx)
+		replayed <- retry.replay(ctx, &provider.CreateContainerResponse{})
+	}()
+	select {
+	case <-replayed:
+		t.Fatal("expected the retry to wait for the first call")
+	case <-time.After(100 * time.Millisecond):
+	}
+	call.end(ctx)
+	select {
+	case ok := <-replayed:
+		if !ok {
+			t.Fatal("expected the retry to replay the response")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the retry is still waiting")
+	}
+
+	// the key cannot be reused for another request
+	if _, err := s.startIdempotentCall(ctx, "CreateContainer", mkcol("/b")); err == nil {
+		t.Fatal("expected a key reused for another request to be refused")
+	} else if _, ok := err.(errtypes.IsPreconditionFailed); !ok {
+		t.Fatalf("expected a precondition failed error, got %v", err)
+	}
+
+	// but it can for another method, and the failed calls are run again
+	call, err = s.startIdempotentCall(ctx, "Move", &provider.MoveRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	call.record(ctx, &provider.MoveResponse{Status: status.NewNotFound(ctx, "")}, nil)
+	call.end(ctx)
+	call, err = s.startIdempotentCall(ctx, "Move", &provider.MoveRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer call.end(ctx)
+	if call.replay(ctx, &provider.MoveResponse{}) {
+		t.Fatal("expected a failed call not to be replayed")
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package gateway
+
+import (
+	"context"
+
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/internal/grpc/services/storageprovider"
+	lockpb "github.com/cs3org/reva/internal/grpc/services/storageprovider/proto"
+	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+)
+
+// SetLock forwards the request to the storage provider of the resource.
+func (s *svc) SetLock(ctx context.Context, req *lockpb.LockRequest) (*lockpb.LockResponse, error) {
+	c, req, err := s.findLockProvider(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return c.SetLock(ctx, req)
+}
+
+// GetLock forwards the request to the storage provider of the resource.
+func (s *svc) GetLock(ctx context.Context, req *lockpb.LockRequest) (*lockpb.LockResponse, error) {
+	c, req, err := s.findLockProvider(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return c.GetLock(ctx, req)
+}
+
+// RefreshLock forwards the request to the storage provider of the resource.
+func (s *svc) RefreshLock(ctx context.Context, req *lockpb.LockRequest) (*lockpb.LockResponse, error) {
+	c, req, err := s.findLockProvider(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return c.RefreshLock(ctx, req)
+}
+
+// Unlock forwards the request to the storage provider of the resource.
+func (s *svc) Unlock(ctx context.Context, req *lockpb.LockRequest) (*lockpb.LockResponse, error) {
+	c, req, err := s.findLockProvider(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return c.Unlock(ctx, req)
+}
+
+// findLockProvider resolves the resource with a stat, which follows the
+// shares, and returns the lock client of its storage provider along with
+// the request referencing the resource by id.
+func (s *svc) findLockProvider(ctx context.Context, req *lockpb.LockRequest) (lockpb.LockAPIClient, *lockpb.LockRequest, error) {
+	statRes, err := s.Stat(ctx, &provider.StatRequest{Ref: storageprovider.RefFromProto(req.Ref)})
+	if err != nil {
+		return nil, nil, err
+	}
+	switch statRes.Status.Code {
+	case rpc.Code_CODE_OK:
+	case rpc.Code_CODE_NOT_FOUND:
+		return nil, nil, grpcstatus.Error(codes.NotFound, statRes.Status.Message)
+	case rpc.Code_CODE_PERMISSION_DENIED:
+		return nil, nil, grpcstatus.Error(codes.PermissionDenied, statRes.Status.Message)
+	default:
+		return nil, nil, grpcstatus.Error(codes.Internal, statRes.Status.Message)
+	}
+
+	id := statRes.Info.Id
+	providers, err := s.findProviders(ctx, &provider.Reference{Spec: &provider.Reference_Id{Id: id}})
+	if err != nil {
+		return nil, nil, grpcstatus.Error(codes.NotFound, err.Error())
+	}
+	c, err := pool.GetLockClient(providers[0].Address)
+	if err != nil {
+		return nil, nil, grpcstatus.Error(codes.Internal, err.Error())
+	}
+	return c, &lockpb.LockRequest{
+		Ref:  &lockpb.Reference{StorageId: id.StorageId, OpaqueId: id.OpaqueId},
+		Lock: req.Lock,
+	}, nil
+}
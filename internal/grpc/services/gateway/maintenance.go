@@ -0,0 +1,54 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package gateway
+
+import (
+	"context"
+
+	registry "github.com/cs3org/go-cs3apis/cs3/storage/registry/v1beta1"
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/maintenance"
+	"google.golang.org/grpc"
+)
+
+// checkMaintenance refuses the mutating calls to the storage provider when
+// it is in maintenance. The provider is recognized by its id when it was
+// resolved from an id, and by its address otherwise.
+func (s *svc) checkMaintenance(ctx context.Context, p *registry.ProviderInfo) error {
+	method, ok := grpc.Method(ctx)
+	if !ok || !maintenance.IsMutating(method) {
+		return nil
+	}
+	modes, err := s.maintenance.GetModes(ctx)
+	if err != nil {
+		// do not make the storage read-only when the modes cannot be read
+		appctx.GetLogger(ctx).Error().Err(err).Msg("gateway: error getting maintenance modes")
+		return nil
+	}
+	for id, mode := range modes {
+		if id == maintenance.Global {
+			continue
+		}
+		if (p.ProviderId != "" && id == p.ProviderId) || (mode.Address != "" && mode.Address == p.Address) {
+			return errtypes.Unavailable("the storage is in maintenance, only reads are allowed: " + mode.Message)
+		}
+	}
+	return nil
+}
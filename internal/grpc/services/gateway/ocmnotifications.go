@@ -0,0 +1,91 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package gateway
+
+import (
+	"context"
+	"time"
+
+	ocmprovider "github.com/cs3org/go-cs3apis/cs3/ocm/provider/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	ocm "github.com/cs3org/go-cs3apis/cs3/sharing/ocm/v1beta1"
+	ocmcorepb "github.com/cs3org/reva/internal/grpc/services/ocmcore/proto"
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/conversions"
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/errtypes"
+	ocmshare "github.com/cs3org/reva/pkg/ocm/share"
+	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
+	"github.com/cs3org/reva/pkg/rhttp"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+)
+
+// ProcessNotification forwards the notification to the ocm core.
+func (s *svc) ProcessNotification(ctx context.Context, req *ocmcorepb.NotificationRequest) (*ocmcorepb.NotificationResponse, error) {
+	c, err := pool.GetOCMNotificationsClient(s.c.OCMCoreEndpoint)
+	if err != nil {
+		return nil, grpcstatus.Error(codes.Internal, err.Error())
+	}
+	return c.ProcessNotification(ctx, req)
+}
+
+// notifyOCMShare sends the notification about the share to the provider of
+// the other user of the share: the grantee when we are the provider of the
+// owner, the owner otherwise. The failures are only logged, as the other
+// provider is not required to implement the notifications.
+func (s *svc) notifyOCMShare(ctx context.Context, typ string, share *ocm.Share) {
+	log := appctx.GetLogger(ctx)
+
+	n := &ocmshare.Notification{
+		NotificationType: typ,
+		ResourceType:     "file",
+		ProviderID:       share.ResourceId.GetStorageId() + ":" + share.ResourceId.GetOpaqueId(),
+		Notification: &ocmshare.NotificationDetails{
+			Owner:     share.Owner.GetOpaqueId(),
+			ShareWith: share.Grantee.GetUserId().GetOpaqueId(),
+		},
+	}
+	domain := share.Grantee.GetUserId().GetIdp()
+	n.Notification.MeshProvider = share.Owner.GetIdp()
+	if typ == ocmshare.NotificationShareAccepted || typ == ocmshare.NotificationShareDeclined {
+		domain, n.Notification.MeshProvider = n.Notification.MeshProvider, domain
+	}
+	if e, ok := share.Grantee.GetOpaque().GetMap()[ocmshare.SharedSecretOpaqueKey]; ok {
+		n.Notification.SharedSecret = string(e.Value)
+	}
+	if typ == ocmshare.NotificationPermissionsChanged {
+		n.Notification.Permissions = int(conversions.RoleFromResourcePermissions(share.Permissions.GetPermissions()).OCSPermissions())
+	}
+
+	if err := s.sendOCMNotification(ctx, domain, n); err != nil {
+		log.Warn().Err(err).Str("type", typ).Str("domain", domain).Msg("gateway: error sending ocm notification")
+	}
+}
+
+func (s *svc) sendOCMNotification(ctx context.Context, domain string, n *ocmshare.Notification) error {
+	res, err := s.GetInfoByDomain(ctx, &ocmprovider.GetInfoByDomainRequest{Domain: domain})
+	if err != nil {
+		return err
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return errtypes.NotFound("gateway: mesh provider not found: " + domain)
+	}
+	client := rhttp.GetHTTPClient(rhttp.Context(ctx), rhttp.Timeout(5*time.Second))
+	return ocmshare.SendNotification(ctx, client, res.ProviderInfo, n)
+}
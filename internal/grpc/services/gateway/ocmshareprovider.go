@@ -28,6 +28,7 @@ import (
 	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	"github.com/cs3org/reva/pkg/appctx"
 	"github.com/cs3org/reva/pkg/errtypes"
+	ocmshare "github.com/cs3org/reva/pkg/ocm/share"
 	"github.com/cs3org/reva/pkg/rgrpc/status"
 	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
 	"github.com/pkg/errors"
@@ -76,31 +77,32 @@ func (s *svc) RemoveOCMShare(ctx context.Context, req *ocm.RemoveOCMShareRequest
 		}, nil
 	}
 
-	// if we need to commit the share, we need the resource it points to.
-	var share *ocm.Share
-	if s.c.CommitShareToStorageGrant {
-		getShareReq := &ocm.GetOCMShareRequest{
-			Ref: req.Ref,
-		}
-		getShareRes, err := c.GetOCMShare(ctx, getShareReq)
-		if err != nil {
-			return nil, errors.Wrap(err, "gateway: error calling GetShare")
-		}
+	// we need the share to notify its grantee and, if we need to commit the
+	// share, the resource it points to.
+	getShareReq := &ocm.GetOCMShareRequest{
+		Ref: req.Ref,
+	}
+	getShareRes, err := c.GetOCMShare(ctx, getShareReq)
+	if err != nil {
+		return nil, errors.Wrap(err, "gateway: error calling GetShare")
+	}
 
-		if getShareRes.Status.Code != rpc.Code_CODE_OK {
-			res := &ocm.RemoveOCMShareResponse{
-				Status: status.NewInternal(ctx, status.NewErrorFromCode(getShareRes.Status.Code, "gateway"),
-					"error getting share when committing to the storage"),
-			}
-			return res, nil
+	if getShareRes.Status.Code != rpc.Code_CODE_OK {
+		res := &ocm.RemoveOCMShareResponse{
+			Status: status.NewInternal(ctx, status.NewErrorFromCode(getShareRes.Status.Code, "gateway"),
+				"error getting share"),
 		}
-		share = getShareRes.Share
+		return res, nil
 	}
+	share := getShareRes.Share
 
 	res, err := c.RemoveOCMShare(ctx, req)
 	if err != nil {
 		return nil, errors.Wrap(err, "gateway: error calling RemoveShare")
 	}
+	if res.Status.Code == rpc.Code_CODE_OK {
+		s.notifyOCMShare(ctx, ocmshare.NotificationShareUnshared, share)
+	}
 
 	// if we don't need to commit we return earlier
 	if !s.c.CommitShareToStorageGrant && !s.c.CommitShareToStorageRef {
@@ -179,6 +181,13 @@ func (s *svc) UpdateOCMShare(ctx context.Context, req *ocm.UpdateOCMShareRequest
 		return nil, errors.Wrap(err, "gateway: error calling UpdateShare")
 	}
 
+	if res.Status.Code == rpc.Code_CODE_OK && req.Field.GetPermissions() != nil {
+		getShareRes, err := c.GetOCMShare(ctx, &ocm.GetOCMShareRequest{Ref: req.Ref})
+		if err == nil && getShareRes.Status.Code == rpc.Code_CODE_OK {
+			s.notifyOCMShare(ctx, ocmshare.NotificationPermissionsChanged, getShareRes.Share)
+		}
+	}
+
 	return res, nil
 }
 
@@ -219,6 +228,23 @@ func (s *svc) UpdateReceivedOCMShare(ctx context.Context, req *ocm.UpdateReceive
 		}, nil
 	}
 
+	// the owner is notified of the acceptance or the refusal of the share
+	if res.Status.Code == rpc.Code_CODE_OK {
+		typ := ""
+		switch req.Field.GetState() {
+		case ocm.ShareState_SHARE_STATE_ACCEPTED:
+			typ = ocmshare.NotificationShareAccepted
+		case ocm.ShareState_SHARE_STATE_REJECTED:
+			typ = ocmshare.NotificationShareDeclined
+		}
+		if typ != "" {
+			getShareRes, err := c.GetReceivedOCMShare(ctx, &ocm.GetReceivedOCMShareRequest{Ref: req.Ref})
+			if err == nil && getShareRes.Status.Code == rpc.Code_CODE_OK {
+				s.notifyOCMShare(ctx, typ, getShareRes.Share.Share)
+			}
+		}
+	}
+
 	// if we don't need to create/delete references then we return early.
 	if !s.c.CommitShareToStorageGrant && !s.c.CommitShareToStorageRef {
 		return res, nil
@@ -332,6 +358,9 @@ func (s *svc) createOCMReference(ctx context.Context, share *ocm.Share) (*rpc.St
 		}
 
 		refPath = path.Join(homeRes.Path, s.c.DataTransfersFolder, path.Base(share.Name))
+		if s.c.DataTxWebdavEndpoint != "" {
+			return s.createOCMTransfer(ctx, share, token, refPath)
+		}
 		targetURI = fmt.Sprintf("datatx://%s@%s?name=%s", token, share.Creator.Idp, share.Name)
 	} else {
 		// reference path is the home path + some name on the corresponding
@@ -340,7 +369,8 @@ func (s *svc) createOCMReference(ctx context.Context, share *ocm.Share) (*rpc.St
 		// from the main request.
 		refPath = path.Join(homeRes.Path, s.c.ShareFolder, path.Base(share.Name))
 		// webdav is the scheme, token@host the opaque part and the share name the query of the URL.
-		targetURI = fmt.Sprintf("webdav://%s@%s?name=%s", token, share.Creator.Idp, share.Name)
+		// The id of the share is used to enforce its protection when accessing it.
+		targetURI = fmt.Sprintf("webdav://%s@%s?name=%s&share_id=%s", token, share.Creator.Idp, share.Name, share.Id.OpaqueId)
 	}
 
 	log.Info().Msg("mount path will be:" + refPath)
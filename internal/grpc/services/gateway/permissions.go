@@ -0,0 +1,43 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+package gateway
+
+import (
+	"context"
+
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/permission"
+	userpkg "github.com/cs3org/reva/pkg/user"
+)
+
+// hasCapability tells whether the user has been granted the capability by the
+// permission driver. Without a permission driver every user has it.
+func (s *svc) hasCapability(ctx context.Context, capability string) bool {
+	if s.permissions == nil {
+		return true
+	}
+	u, ok := userpkg.ContextGetUser(ctx)
+	if !ok {
+		return false
+	}
+	allowed, err := permission.CheckPermission(ctx, s.permissions, u, capability)
+	if err != nil {
+		appctx.GetLogger(ctx).Error().Err(err).Str("capability", capability).Msg("gateway: error checking permission")
+	}
+	return allowed
+}
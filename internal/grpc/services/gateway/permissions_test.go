@@ -0,0 +1,103 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+package gateway
+
+import (
+	"context"
+	"testing"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	collaboration "github.com/cs3org/go-cs3apis/cs3/sharing/collaboration/v1beta1"
+	link "github.com/cs3org/go-cs3apis/cs3/sharing/link/v1beta1"
+	"github.com/cs3org/reva/pkg/permission"
+	"github.com/cs3org/reva/pkg/permission/manager/static"
+	userpkg "github.com/cs3org/reva/pkg/user"
+)
+
+func TestShareCapabilities(t *testing.T) {
+	pm, err := static.New(map[string]interface{}{
+		"assignments": map[string]interface{}{
+			"users": map[string][]string{
+				"alice": {permission.RoleUser},
+				"guest": {permission.RoleGuest},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		pm      permission.Manager
+		user    string
+		allowed bool
+	}{
+		{"user role", pm, "alice", true},
+		{"guest role", pm, "guest", false},
+		// the users without a role get the default user role
+		{"no role", pm, "bob", true},
+		// without a permission driver everybody may share
+		{"no driver", nil, "guest", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &svc{c: &config{}, permissions: tt.pm}
+			u := &userpb.User{Id: &userpb.UserId{OpaqueId: tt.user}, Username: tt.user}
+			ctx := userpkg.ContextSetUser(context.Background(), u)
+			for _, c := range []string{permission.CreateShare, permission.CreatePublicLink} {
+				if allowed := s.hasCapability(ctx, c); allowed != tt.allowed {
+					t.Errorf("%s: got %v, wanted %v", c, allowed, tt.allowed)
+				}
+			}
+		})
+	}
+}
+
+func TestCreateShareDenied(t *testing.T) {
+	pm, err := static.New(map[string]interface{}{
+		"assignments": map[string]interface{}{
+			"users": map[string][]string{"guest": {permission.RoleGuest}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &svc{c: &config{}, permissions: pm}
+	u := &userpb.User{Id: &userpb.UserId{OpaqueId: "guest"}, Username: "guest"}
+	ctx := userpkg.ContextSetUser(context.Background(), u)
+
+	// the providers are not configured: the requests must be denied before
+	// reaching them.
+	res, err := s.createShare(ctx, &collaboration.CreateShareRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Status.Code != rpc.Code_CODE_PERMISSION_DENIED {
+		t.Errorf("CreateShare: got %v, wanted %v", res.Status.Code, rpc.Code_CODE_PERMISSION_DENIED)
+	}
+
+	pres, err := s.createPublicShare(ctx, &link.CreatePublicShareRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pres.Status.Code != rpc.Code_CODE_PERMISSION_DENIED {
+		t.Errorf("CreatePublicShare: got %v, wanted %v", pres.Status.Code, rpc.Code_CODE_PERMISSION_DENIED)
+	}
+}
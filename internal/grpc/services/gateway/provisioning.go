@@ -0,0 +1,338 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package gateway
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+
+	grouppb "github.com/cs3org/go-cs3apis/cs3/identity/group/v1beta1"
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	preferences "github.com/cs3org/go-cs3apis/cs3/preferences/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/auth/scope"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/events"
+	tokenpkg "github.com/cs3org/reva/pkg/token"
+	userpkg "github.com/cs3org/reva/pkg/user"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/metadata"
+)
+
+// provisioningKey is the preference recording the provisioning steps done
+// for a user, separated by commas.
+const provisioningKey = "provisioning.done"
+
+// defaultQuotaRole is used for the users without a role having a quota.
+const defaultQuotaRole = "default"
+
+// Steps of the provisioning, run in this order.
+const (
+	stepQuota         = "quota"
+	stepNotifications = "notifications"
+	stepEvent         = "event"
+)
+
+// provisioningConfig configures the provisioning of the users at their first
+// login, on top of the creation of their home.
+type provisioningConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Quotas maps the roles to the quota in bytes of the homes of their
+	// users. The default entry is used for the users without a role having
+	// one.
+	Quotas map[string]uint64 `mapstructure:"quotas"`
+	// IdentityQuota makes the quotas set on the users and their groups in
+	// the identity management system, and exposed by the user and group
+	// providers, take precedence over the ones of the roles. The quota of the
+	// user comes first, then the largest of the ones of its groups.
+	IdentityQuota bool `mapstructure:"identity_quota"`
+	// QuotaRefreshInterval is the minimum number of seconds between two
+	// synchronizations of the identity quota of a user, done at login.
+	QuotaRefreshInterval int `mapstructure:"quota_refresh_interval"`
+	// QuotaAdmin is the username of the user the quotas are set on behalf of,
+	// who must be allowed to manage the storage spaces.
+	QuotaAdmin string `mapstructure:"quota_admin"`
+	// Notifications are the preferences set for the new users, e.g.
+	// notifications.share_received = "true".
+	Notifications map[string]string `mapstructure:"notifications"`
+	// Retries is the number of times a failed step is run again before
+	// giving up until the next login, RetryDelay the number of milliseconds
+	// between two attempts.
+	Retries    int `mapstructure:"retries"`
+	RetryDelay int `mapstructure:"retry_delay"`
+	// Events configures the bus the UserProvisioned events are published to.
+	Events map[string]interface{} `mapstructure:"events"`
+}
+
+func (c *provisioningConfig) init() {
+	if c.Retries == 0 {
+		c.Retries = 2
+	}
+	if c.RetryDelay == 0 {
+		c.RetryDelay = 100
+	}
+	if c.QuotaRefreshInterval == 0 {
+		c.QuotaRefreshInterval = 3600
+	}
+}
+
+// provision runs the provisioning steps which have not been done yet for the
+// user. The failed steps are logged and run again at the next login.
+func (s *svc) provision(ctx context.Context, u *userpb.User) {
+	log := appctx.GetLogger(ctx)
+
+	done, err := s.provisionedSteps(ctx)
+	if err != nil {
+		log.Error().Err(err).Str("user", u.Username).Msg("gateway: error getting the provisioning state, skipping")
+		return
+	}
+
+	// the steps with a due function are run again when it tells so
+	steps := []struct {
+		name string
+		run  func(context.Context, *userpb.User) error
+		due  func(*userpb.User) bool
+	}{
+		{stepQuota, s.provisionQuota, s.quotaRefreshDue},
+		{stepNotifications, s.provisionNotifications, nil},
+		{stepEvent, s.provisionEvent, nil},
+	}
+	changed := false
+	for _, step := range steps {
+		if done[step.name] && (step.due == nil || !step.due(u)) {
+			continue
+		}
+		if err := s.retry(ctx, func() error { return step.run(ctx, u) }); err != nil {
+			log.Error().Err(err).Str("user", u.Username).Str("step", step.name).Msg("gateway: error provisioning user, retrying at next login")
+			// the later steps depend on the user being fully provisioned
+			break
+		}
+		if !done[step.name] {
+			done[step.name] = true
+			changed = true
+		}
+	}
+
+	if changed {
+		if err := s.setProvisionedSteps(ctx, done); err != nil {
+			log.Error().Err(err).Str("user", u.Username).Msg("gateway: error saving the provisioning state")
+		}
+	}
+}
+
+func (s *svc) retry(ctx context.Context, f func() error) error {
+	var err error
+	for i := 0; i <= s.c.Provisioning.Retries; i++ {
+		if i > 0 {
+			select {
+			case <-time.After(time.Duration(s.c.Provisioning.RetryDelay) * time.Millisecond):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		if err = f(); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+func (s *svc) provisionedSteps(ctx context.Context) (map[string]bool, error) {
+	res, err := s.GetKey(ctx, &preferences.GetKeyRequest{Key: provisioningKey})
+	if err != nil {
+		return nil, err
+	}
+	done := map[string]bool{}
+	switch res.Status.Code {
+	case rpc.Code_CODE_OK:
+		for _, step := range strings.Split(res.Val, ",") {
+			if step != "" {
+				done[step] = true
+			}
+		}
+	case rpc.Code_CODE_NOT_FOUND:
+	default:
+		return nil, errtypes.InternalError(res.Status.Message)
+	}
+	return done, nil
+}
+
+func (s *svc) setProvisionedSteps(ctx context.Context, done map[string]bool) error {
+	steps := make([]string, 0, len(done))
+	for step := range done {
+		steps = append(steps, step)
+	}
+	sort.Strings(steps)
+	return s.setKey(ctx, provisioningKey, strings.Join(steps, ","))
+}
+
+func (s *svc) setKey(ctx context.Context, key, value string) error {
+	res, err := s.SetKey(ctx, &preferences.SetKeyRequest{Key: key, Val: value})
+	if err != nil {
+		return err
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return errtypes.InternalError(res.Status.Message)
+	}
+	return nil
+}
+
+// provisionQuota sets the quota of the user on its home.
+func (s *svc) provisionQuota(ctx context.Context, u *userpb.User) error {
+	quota, ok, err := s.getQuota(ctx, u)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return nil
+	}
+
+	homeRes, err := s.GetHome(ctx, &provider.GetHomeRequest{})
+	if err != nil {
+		return err
+	}
+	if homeRes.Status.Code != rpc.Code_CODE_OK {
+		return errtypes.InternalError(homeRes.Status.Message)
+	}
+	statRes, err := s.Stat(ctx, &provider.StatRequest{Ref: &provider.Reference{Spec: &provider.Reference_Path{Path: homeRes.Path}}})
+	if err != nil {
+		return err
+	}
+	if statRes.Status.Code != rpc.Code_CODE_OK {
+		return errtypes.InternalError(statRes.Status.Message)
+	}
+
+	adminCtx, err := s.quotaAdminContext(ctx)
+	if err != nil {
+		return err
+	}
+	res, err := s.UpdateStorageSpace(adminCtx, &provider.UpdateStorageSpaceRequest{
+		StorageSpace: &provider.StorageSpace{
+			Root:  statRes.Info.Id,
+			Quota: &provider.Quota{QuotaMaxBytes: quota},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return errtypes.InternalError(res.Status.Message)
+	}
+	s.quotaSynced.Store(u.Id.String(), time.Now())
+	return nil
+}
+
+// getQuota returns the quota of the user: its identity quota if enabled,
+// else the quota of its role.
+func (s *svc) getQuota(ctx context.Context, u *userpb.User) (uint64, bool, error) {
+	if s.c.Provisioning.IdentityQuota {
+		if q, ok := userpkg.GetQuota(u.Opaque); ok {
+			return q, true, nil
+		}
+		var quota uint64
+		found := false
+		for _, g := range u.Groups {
+			res, err := s.GetGroupByClaim(ctx, &grouppb.GetGroupByClaimRequest{Claim: "group_name", Value: g})
+			if err != nil {
+				return 0, false, err
+			}
+			if res.Status.Code != rpc.Code_CODE_OK {
+				continue
+			}
+			if q, ok := userpkg.GetQuota(res.Group.Opaque); ok && q >= quota {
+				quota, found = q, true
+			}
+		}
+		if found {
+			return quota, true, nil
+		}
+	}
+
+	quota, ok := s.c.Provisioning.Quotas[defaultQuotaRole]
+	if s.permissions != nil {
+		roles, err := s.permissions.GetRoles(ctx, u)
+		if err != nil {
+			return 0, false, err
+		}
+		for _, r := range roles {
+			if q, found := s.c.Provisioning.Quotas[r]; found {
+				return q, true, nil
+			}
+		}
+	}
+	return quota, ok, nil
+}
+
+// quotaRefreshDue tells whether the identity quota of the user has to be
+// synchronized again.
+func (s *svc) quotaRefreshDue(u *userpb.User) bool {
+	if !s.c.Provisioning.IdentityQuota {
+		return false
+	}
+	t, ok := s.quotaSynced.Load(u.Id.String())
+	return !ok || time.Since(t.(time.Time)) > time.Duration(s.c.Provisioning.QuotaRefreshInterval)*time.Second
+}
+
+// quotaAdminContext returns a context carrying a token minted for the quota
+// admin.
+func (s *svc) quotaAdminContext(ctx context.Context) (context.Context, error) {
+	if s.c.Provisioning.QuotaAdmin == "" {
+		return nil, errtypes.BadRequest("gateway: quotas configured without quota admin")
+	}
+	res, err := s.GetUserByClaim(ctx, &userpb.GetUserByClaimRequest{Claim: "username", Value: s.c.Provisioning.QuotaAdmin})
+	if err != nil {
+		return nil, err
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return nil, errtypes.NotFound("gateway: quota admin " + s.c.Provisioning.QuotaAdmin)
+	}
+
+	scopes, err := scope.GetOwnerScope()
+	if err != nil {
+		return nil, err
+	}
+	tkn, err := s.tokenmgr.MintToken(ctx, res.User, scopes)
+	if err != nil {
+		return nil, errors.Wrap(err, "gateway: error minting token")
+	}
+	ctx = tokenpkg.ContextSetToken(ctx, tkn)
+	return metadata.NewOutgoingContext(ctx, metadata.Pairs(tokenpkg.TokenHeader, tkn)), nil
+}
+
+// provisionNotifications subscribes the user to the default notifications.
+func (s *svc) provisionNotifications(ctx context.Context, u *userpb.User) error {
+	for k, v := range s.c.Provisioning.Notifications {
+		if err := s.setKey(ctx, k, v); err != nil {
+			return errors.Wrap(err, "error setting "+k)
+		}
+	}
+	return nil
+}
+
+func (s *svc) provisionEvent(ctx context.Context, u *userpb.User) error {
+	return events.Publish(ctx, s.stream, events.UserProvisioned{
+		UserID:   u.Id,
+		Username: u.Username,
+		Time:     time.Now(),
+	})
+}
@@ -0,0 +1,156 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package gateway
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	grouppb "github.com/cs3org/go-cs3apis/cs3/identity/group/v1beta1"
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/permission/manager/static"
+	userpkg "github.com/cs3org/reva/pkg/user"
+	"google.golang.org/grpc"
+)
+
+// groupProvider serves groups whose quota is their name's entry in quotas.
+type groupProvider struct {
+	grouppb.UnimplementedGroupAPIServer
+	quotas map[string]string
+}
+
+func (p *groupProvider) GetGroupByClaim(ctx context.Context, req *grouppb.GetGroupByClaimRequest) (*grouppb.GetGroupByClaimResponse, error) {
+	q, ok := p.quotas[req.Value]
+	if !ok {
+		return &grouppb.GetGroupByClaimResponse{Status: &rpc.Status{Code: rpc.Code_CODE_NOT_FOUND}}, nil
+	}
+	g := &grouppb.Group{GroupName: req.Value}
+	if q != "" {
+		g.Opaque = &types.Opaque{Map: map[string]*types.OpaqueEntry{userpkg.QuotaOpaqueKey: userpkg.QuotaEntry(q)}}
+	}
+	return &grouppb.GetGroupByClaimResponse{Status: &rpc.Status{Code: rpc.Code_CODE_OK}, Group: g}, nil
+}
+
+func TestProvisioningQuota(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	grouppb.RegisterGroupAPIServer(srv, &groupProvider{quotas: map[string]string{
+		"physics":   "2000",
+		"chemistry": "3000",
+		"sailing":   "",
+	}})
+	go func() { _ = srv.Serve(l) }()
+	defer srv.Stop()
+
+	withQuota := func(username, quota string, groups ...string) *userpb.User {
+		u := &userpb.User{Id: &userpb.UserId{OpaqueId: username}, Username: username, Groups: groups}
+		if quota != "" {
+			u.Opaque = &types.Opaque{Map: map[string]*types.OpaqueEntry{userpkg.QuotaOpaqueKey: userpkg.QuotaEntry(quota)}}
+		}
+		return u
+	}
+	quotas := map[string]uint64{"default": 100, "staff": 500}
+	perms, err := static.New(map[string]interface{}{
+		"assignments": map[string]interface{}{
+			"users": map[string][]string{"marie": {"staff"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		identity bool
+		user     *userpb.User
+		quota    uint64
+		ok       bool
+	}{
+		{"identity quota of the user", true, withQuota("einstein", "1000", "physics"), 1000, true},
+		{"largest quota of the groups", true, withQuota("einstein", "", "physics", "chemistry", "sailing"), 3000, true},
+		{"unknown group", true, withQuota("einstein", "", "unknown", "physics"), 2000, true},
+		{"invalid identity quota", true, withQuota("einstein", "lots", "physics"), 2000, true},
+		{"groups without quota", true, withQuota("marie", "", "sailing"), 500, true},
+		{"identity quota disabled", false, withQuota("einstein", "1000", "physics"), 100, true},
+		{"quota of the role", false, withQuota("marie", "1000"), 500, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &config{GroupProviderEndpoint: l.Addr().String()}
+			c.Provisioning.IdentityQuota = tt.identity
+			c.Provisioning.Quotas = quotas
+			s := &svc{c: c, permissions: perms}
+
+			quota, ok, err := s.getQuota(context.Background(), tt.user)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if quota != tt.quota || ok != tt.ok {
+				t.Errorf("got %d, %v, wanted %d, %v", quota, ok, tt.quota, tt.ok)
+			}
+		})
+	}
+
+	t.Run("no quota configured", func(t *testing.T) {
+		s := &svc{c: &config{}}
+		if _, ok, _ := s.getQuota(context.Background(), withQuota("einstein", "1000")); ok {
+			t.Error("a quota was found without quotas nor identity quotas")
+		}
+	})
+}
+
+func TestQuotaRefreshDue(t *testing.T) {
+	u := &userpb.User{Id: &userpb.UserId{OpaqueId: "einstein"}}
+	c := &config{}
+	c.Provisioning.QuotaRefreshInterval = 3600
+	s := &svc{c: c}
+
+	if s.quotaRefreshDue(u) {
+		t.Error("the refresh of the quota is due without identity quotas")
+	}
+	c.Provisioning.IdentityQuota = true
+	if !s.quotaRefreshDue(u) {
+		t.Error("the refresh of a quota never synchronized is not due")
+	}
+	s.quotaSynced.Store(u.Id.String(), time.Now())
+	if s.quotaRefreshDue(u) {
+		t.Error("the refresh of a quota synchronized just now is due")
+	}
+	s.quotaSynced.Store(u.Id.String(), time.Now().Add(-2*time.Hour))
+	if !s.quotaRefreshDue(u) {
+		t.Error("the refresh of an outdated quota is not due")
+	}
+}
+
+func TestQuotaAdminContextRequiresAdmin(t *testing.T) {
+	s := &svc{c: &config{}}
+	if _, err := s.quotaAdminContext(context.Background()); err == nil {
+		t.Fatal("the quotas were set without quota admin")
+	} else if _, ok := err.(errtypes.IsBadRequest); !ok {
+		t.Fatalf("got %v, wanted a bad request", err)
+	}
+}
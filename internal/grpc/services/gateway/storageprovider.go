@@ -20,9 +20,14 @@ package gateway
 
 import (
 	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io"
 	"net/url"
 	"path"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -35,7 +40,10 @@ import (
 	"github.com/cs3org/reva/pkg/errtypes"
 	"github.com/cs3org/reva/pkg/rgrpc/status"
 	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
+	"github.com/cs3org/reva/pkg/storage"
 	"github.com/cs3org/reva/pkg/storage/utils/etag"
+	"github.com/cs3org/reva/pkg/tags"
+	userpkg "github.com/cs3org/reva/pkg/user"
 	"github.com/cs3org/reva/pkg/utils"
 	"github.com/dgrijalva/jwt-go"
 	"github.com/google/uuid"
@@ -46,9 +54,16 @@ import (
 type transferClaims struct {
 	jwt.StandardClaims
 	Target string `json:"target"`
+	// Range restricts the transfer to a byte range of the target, in the
+	// format of the HTTP Range header.
+	Range string `json:"range,omitempty"`
 }
 
-func (s *svc) sign(_ context.Context, target string) (string, error) {
+func (s *svc) sign(ctx context.Context, target string) (string, error) {
+	return s.signRange(ctx, target, "")
+}
+
+func (s *svc) signRange(_ context.Context, target, byteRange string) (string, error) {
 	// Tus sends a separate request to the datagateway service for every chunk.
 	// For large files, this can take a long time, so we extend the expiration
 	// for 10 minutes. TODO: Make this configurable.
@@ -60,6 +75,7 @@ func (s *svc) sign(_ context.Context, target string) (string, error) {
 			IssuedAt:  time.Now().Unix(),
 		},
 		Target: target,
+		Range:  byteRange,
 	}
 
 	t := jwt.NewWithClaims(jwt.GetSigningMethod("HS256"), claims)
@@ -95,8 +111,7 @@ func (s *svc) CreateHome(ctx context.Context, req *provider.CreateHomeRequest) (
 
 func (s *svc) CreateStorageSpace(ctx context.Context, req *provider.CreateStorageSpaceRequest) (*provider.CreateStorageSpaceResponse, error) {
 	log := appctx.GetLogger(ctx)
-	// TODO: needs to be fixed
-	c, err := s.findByPath(ctx, req.Type)
+	c, err := s.findSpacesProvider(ctx, nil)
 	if err != nil {
 		return &provider.CreateStorageSpaceResponse{
 			Status: status.NewStatusFromErrType(ctx, "error finding path", err),
@@ -115,16 +130,7 @@ func (s *svc) CreateStorageSpace(ctx context.Context, req *provider.CreateStorag
 
 func (s *svc) ListStorageSpaces(ctx context.Context, req *provider.ListStorageSpacesRequest) (*provider.ListStorageSpacesResponse, error) {
 	log := appctx.GetLogger(ctx)
-	// TODO: needs to be fixed
-	var id *provider.StorageSpaceId
-	for _, f := range req.Filters {
-		if f.Type == provider.ListStorageSpacesRequest_Filter_TYPE_ID {
-			id = f.GetId()
-		}
-	}
-	c, err := s.findByID(ctx, &provider.ResourceId{
-		OpaqueId: id.OpaqueId,
-	})
+	c, err := s.findSpacesProvider(ctx, nil)
 	if err != nil {
 		return &provider.ListStorageSpacesResponse{
 			Status: status.NewStatusFromErrType(ctx, "error finding path", err),
@@ -143,8 +149,7 @@ func (s *svc) ListStorageSpaces(ctx context.Context, req *provider.ListStorageSp
 
 func (s *svc) UpdateStorageSpace(ctx context.Context, req *provider.UpdateStorageSpaceRequest) (*provider.UpdateStorageSpaceResponse, error) {
 	log := appctx.GetLogger(ctx)
-	// TODO: needs to be fixed
-	c, err := s.findByID(ctx, req.StorageSpace.Root)
+	c, err := s.findSpacesProvider(ctx, req.StorageSpace.GetRoot())
 	if err != nil {
 		return &provider.UpdateStorageSpaceResponse{
 			Status: status.NewStatusFromErrType(ctx, "error finding ID", err),
@@ -163,10 +168,7 @@ func (s *svc) UpdateStorageSpace(ctx context.Context, req *provider.UpdateStorag
 
 func (s *svc) DeleteStorageSpace(ctx context.Context, req *provider.DeleteStorageSpaceRequest) (*provider.DeleteStorageSpaceResponse, error) {
 	log := appctx.GetLogger(ctx)
-	// TODO: needs to be fixed
-	c, err := s.findByID(ctx, &provider.ResourceId{
-		OpaqueId: req.Id.OpaqueId,
-	})
+	c, err := s.findSpacesProvider(ctx, nil)
 	if err != nil {
 		return &provider.DeleteStorageSpaceResponse{
 			Status: status.NewStatusFromErrType(ctx, "error finding path", err),
@@ -183,6 +185,15 @@ func (s *svc) DeleteStorageSpace(ctx context.Context, req *provider.DeleteStorag
 	return res, nil
 }
 
+// findSpacesProvider returns the provider of the space root if it is known, or
+// else the provider of the home of the user, which hosts its project spaces.
+func (s *svc) findSpacesProvider(ctx context.Context, root *provider.ResourceId) (provider.ProviderAPIClient, error) {
+	if root.GetStorageId() != "" {
+		return s.findByID(ctx, root)
+	}
+	return s.findByPath(ctx, s.getHome(ctx))
+}
+
 func (s *svc) GetHome(ctx context.Context, _ *provider.GetHomeRequest) (*provider.GetHomeResponse, error) {
 	home := s.getHome(ctx)
 	homeRes := &provider.GetHomeResponse{Path: home, Status: status.NewOK(ctx)}
@@ -407,6 +418,14 @@ func (s *svc) initiateFileDownload(ctx context.Context, req *provider.InitiateFi
 
 			protocols[p].DownloadEndpoint = s.c.DataGatewayEndpoint
 			protocols[p].Token = token
+
+			if protocols[p].Protocol == "simple" && storageRes.Status.GetCode() == rpc.Code_CODE_OK && req.Opaque.GetMap()[downloadSegmentsKey] != nil {
+				if storageRes.Opaque, err = s.addDownloadSegments(ctx, c, req, target, storageRes.Opaque); err != nil {
+					return &gateway.InitiateFileDownloadResponse{
+						Status: status.NewStatusFromErrType(ctx, "error creating download segments", err),
+					}, nil
+				}
+			}
 		}
 	}
 
@@ -660,7 +679,7 @@ func (s *svc) GetPath(ctx context.Context, req *provider.GetPathRequest) (*provi
 	}, nil
 }
 
-func (s *svc) CreateContainer(ctx context.Context, req *provider.CreateContainerRequest) (*provider.CreateContainerResponse, error) {
+func (s *svc) doCreateContainer(ctx context.Context, req *provider.CreateContainerRequest) (*provider.CreateContainerResponse, error) {
 	log := appctx.GetLogger(ctx)
 	p, st := s.getPath(ctx, req.Ref)
 	if st.Code != rpc.Code_CODE_OK {
@@ -873,7 +892,7 @@ func (s *svc) delete(ctx context.Context, req *provider.DeleteRequest) (*provide
 	return res, nil
 }
 
-func (s *svc) Move(ctx context.Context, req *provider.MoveRequest) (*provider.MoveResponse, error) {
+func (s *svc) doMove(ctx context.Context, req *provider.MoveRequest) (*provider.MoveResponse, error) {
 	log := appctx.GetLogger(ctx)
 	p, st := s.getPath(ctx, req.Source)
 	if st.Code != rpc.Code_CODE_OK {
@@ -990,8 +1009,12 @@ func (s *svc) move(ctx context.Context, req *provider.MoveRequest) (*provider.Mo
 	}
 	srcP, dstP := srcList[0], dstList[0]
 
-	// if providers are not the same we do not implement cross storage copy yet.
+	// if providers are not the same we do not implement cross storage copy yet,
+	// unless the data transfers are used for it.
 	if srcP.Address != dstP.Address {
+		if s.c.CrossStorageMove && s.c.DataTxWebdavEndpoint != "" {
+			return s.crossStorageMove(ctx, req)
+		}
 		res := &provider.MoveResponse{
 			Status: status.NewUnimplemented(ctx, nil, "gateway: cross storage copy not yet implemented"),
 		}
@@ -1001,7 +1024,7 @@ func (s *svc) move(ctx context.Context, req *provider.MoveRequest) (*provider.Mo
 	c, err := s.getStorageProviderClient(ctx, srcP)
 	if err != nil {
 		return &provider.MoveResponse{
-			Status: status.NewInternal(ctx, err, "error connecting to storage provider="+srcP.Address),
+			Status: status.NewStatusFromErrType(ctx, "error connecting to storage provider="+srcP.Address, err),
 		}, nil
 	}
 
@@ -1079,19 +1102,9 @@ func (s *svc) statHome(ctx context.Context) (*provider.StatResponse, error) {
 		}, nil
 	}
 
-	if etagIface, err := s.etagCache.Get(statRes.Info.Owner.OpaqueId + ":" + statRes.Info.Path); err == nil {
-		resMtime := utils.TSToTime(statRes.Info.Mtime)
-		resEtag := etagIface.(etagWithTS)
-		// Use the updated etag if the home folder has been modified
-		if resMtime.Before(resEtag.Timestamp) {
-			statRes.Info.Etag = resEtag.Etag
-		}
-	} else {
-		statRes.Info.Etag = etag.GenerateEtagFromResources(statRes.Info, []*provider.ResourceInfo{statSharedFolder.Info})
-		if s.c.EtagCacheTTL > 0 {
-			_ = s.etagCache.Set(statRes.Info.Owner.OpaqueId+":"+statRes.Info.Path, etagWithTS{statRes.Info.Etag, time.Now()})
-		}
-	}
+	// the home etag changes whenever the shares folder etag does, so that the
+	// clients syncing the home notice the changes in the received shares
+	statRes.Info.Etag = s.aggregateEtag(ctx, statRes.Info, []*provider.ResourceInfo{statSharedFolder.Info})
 
 	return statRes, nil
 }
@@ -1128,21 +1141,50 @@ func (s *svc) statSharesFolder(ctx context.Context) (*provider.StatResponse, err
 		}, nil
 	}
 
-	if etagIface, err := s.etagCache.Get(statRes.Info.Owner.OpaqueId + ":" + statRes.Info.Path); err == nil {
-		resMtime := utils.TSToTime(statRes.Info.Mtime)
-		resEtag := etagIface.(etagWithTS)
-		// Use the updated etag if the shares folder has been modified, i.e., a new
-		// reference has been created.
-		if resMtime.Before(resEtag.Timestamp) {
-			statRes.Info.Etag = resEtag.Etag
+	// the listing holds the resolved share targets, whose etags change with
+	// any change inside the shares
+	statRes.Info.Etag = s.aggregateEtag(ctx, statRes.Info, lsRes.Infos)
+	return statRes, nil
+}
+
+// aggregateEtag returns the etag of a virtual folder like the home or the
+// shares folder, derived from the etags of its children. The etag is cached
+// along with a digest of the children etags, so that it stays stable until
+// either the folder itself or one of its children changes. Comparing the
+// mtime alone would hide the changes inside the received shares, which do
+// not touch the mount points.
+func (s *svc) aggregateEtag(ctx context.Context, root *provider.ResourceInfo, children []*provider.ResourceInfo) string {
+	key := root.Owner.OpaqueId + ":" + root.Path
+	sources := etagSources(children)
+	if b, err := s.etagCache.Get(ctx, key); err == nil {
+		var resEtag etagWithTS
+		if json.Unmarshal(b, &resEtag) == nil && resEtag.Sources == sources && utils.TSToTime(root.Mtime).Before(resEtag.Timestamp) {
+			return resEtag.Etag
 		}
-	} else {
-		statRes.Info.Etag = etag.GenerateEtagFromResources(statRes.Info, lsRes.Infos)
-		if s.c.EtagCacheTTL > 0 {
-			_ = s.etagCache.Set(statRes.Info.Owner.OpaqueId+":"+statRes.Info.Path, etagWithTS{statRes.Info.Etag, time.Now()})
+	}
+
+	e := etag.GenerateEtagFromResources(root, children)
+	if s.c.EtagCacheTTL > 0 {
+		if b, err := json.Marshal(etagWithTS{Etag: e, Sources: sources, Timestamp: time.Now()}); err == nil {
+			_ = s.etagCache.Set(ctx, key, b, time.Duration(s.c.EtagCacheTTL)*time.Second)
 		}
 	}
-	return statRes, nil
+	return e
+}
+
+// etagSources digests the paths and etags of the resources, independently
+// of their order.
+func etagSources(resources []*provider.ResourceInfo) string {
+	entries := make([]string, 0, len(resources))
+	for _, r := range resources {
+		entries = append(entries, r.GetPath()+"\x00"+r.GetEtag())
+	}
+	sort.Strings(entries)
+	h := md5.New()
+	for _, e := range entries {
+		_, _ = io.WriteString(h, e+"\n")
+	}
+	return hex.EncodeToString(h.Sum(nil))
 }
 
 func (s *svc) stat(ctx context.Context, req *provider.StatRequest) (*provider.StatResponse, error) {
@@ -1617,8 +1659,36 @@ func (s *svc) listContainerOnProvider(ctx context.Context, req *provider.ListCon
 	*res = r.Infos
 }
 
+// listByTag lists the resources with a tag in the subtree of the reference,
+// which is looked up by the storage provider of the space it belongs to.
+func (s *svc) listByTag(ctx context.Context, req *provider.ListContainerRequest) (*provider.ListContainerResponse, error) {
+	providers, err := s.findProviders(ctx, req.Ref)
+	if err != nil {
+		return &provider.ListContainerResponse{
+			Status: status.NewStatusFromErrType(ctx, "listByTag ref: "+req.Ref.String(), err),
+		}, nil
+	}
+
+	c, err := s.getStorageProviderClient(ctx, providers[0])
+	if err != nil {
+		return &provider.ListContainerResponse{
+			Status: status.NewInternal(ctx, err, "error connecting to storage provider="+providers[0].Address),
+		}, nil
+	}
+
+	res, err := c.ListContainer(ctx, req)
+	if err != nil {
+		return nil, errors.Wrap(err, "gateway: error calling ListContainer")
+	}
+	return res, nil
+}
+
 func (s *svc) ListContainer(ctx context.Context, req *provider.ListContainerRequest) (*provider.ListContainerResponse, error) {
 	log := appctx.GetLogger(ctx)
+	if req.Opaque.GetMap()[tags.ListOpaqueKey] != nil {
+		return s.listByTag(ctx, req)
+	}
+
 	p, st := s.getPath(ctx, req.Ref, req.ArbitraryMetadataKeys...)
 	if st.Code != rpc.Code_CODE_OK {
 		return &provider.ListContainerResponse{
@@ -1924,12 +1994,36 @@ func (s *svc) ListFileVersions(ctx context.Context, req *provider.ListFileVersio
 }
 
 func (s *svc) RestoreFileVersion(ctx context.Context, req *provider.RestoreFileVersionRequest) (*provider.RestoreFileVersionResponse, error) {
-	c, err := s.find(ctx, req.Ref)
+	srcList, err := s.findProviders(ctx, req.Ref)
 	if err != nil {
 		return &provider.RestoreFileVersionResponse{
 			Status: status.NewStatusFromErrType(ctx, "RestoreFileVersion ref="+req.Ref.String(), err),
 		}, nil
 	}
+	srcP := srcList[0]
+
+	// the version can be restored to another location of the same storage
+	if e := req.Opaque.GetMap()["restore_path"]; e != nil {
+		dst := &provider.Reference{Spec: &provider.Reference_Path{Path: string(e.Value)}}
+		dstList, err := s.findProviders(ctx, dst)
+		if err != nil {
+			return &provider.RestoreFileVersionResponse{
+				Status: status.NewStatusFromErrType(ctx, "RestoreFileVersion dst="+dst.String(), err),
+			}, nil
+		}
+		if srcP.Address != dstList[0].Address {
+			return &provider.RestoreFileVersionResponse{
+				Status: status.NewUnimplemented(ctx, nil, "gateway: cross storage restore not yet implemented"),
+			}, nil
+		}
+	}
+
+	c, err := s.getStorageProviderClient(ctx, srcP)
+	if err != nil {
+		return &provider.RestoreFileVersionResponse{
+			Status: status.NewStatusFromErrType(ctx, "error connecting to storage provider="+srcP.Address, err),
+		}, nil
+	}
 
 	res, err := c.RestoreFileVersion(ctx, req)
 	if err != nil {
@@ -1948,7 +2042,7 @@ func (s *svc) ListRecycle(ctx context.Context, req *gateway.ListRecycleRequest)
 	c, err := s.find(ctx, req.GetRef())
 	if err != nil {
 		return &provider.ListRecycleResponse{
-			Status: status.NewStatusFromErrType(ctx, "ListFileVersions ref="+req.Ref.String(), err),
+			Status: status.NewStatusFromErrType(ctx, "ListRecycle ref="+req.Ref.String(), err),
 		}, nil
 	}
 
@@ -2007,9 +2101,12 @@ func (s *svc) GetQuota(ctx context.Context, req *gateway.GetQuotaRequest) (*prov
 		}, nil
 	}
 
+	opaque, err := storage.QuotaRefOpaque(req.GetOpaque(), req.GetRef())
+	if err != nil {
+		return nil, errors.Wrap(err, "gateway: error encoding the reference")
+	}
 	res, err := c.GetQuota(ctx, &provider.GetQuotaRequest{
-		Opaque: req.GetOpaque(),
-		// Ref:    req.GetRef(), // TODO send which storage space ... or root
+		Opaque: opaque,
 	})
 	if err != nil {
 		return nil, errors.Wrap(err, "gateway: error calling GetQuota")
@@ -2043,7 +2140,11 @@ func (s *svc) find(ctx context.Context, ref *provider.Reference) (provider.Provi
 	return s.getStorageProviderClient(ctx, p[0])
 }
 
-func (s *svc) getStorageProviderClient(_ context.Context, p *registry.ProviderInfo) (provider.ProviderAPIClient, error) {
+func (s *svc) getStorageProviderClient(ctx context.Context, p *registry.ProviderInfo) (provider.ProviderAPIClient, error) {
+	if err := s.checkMaintenance(ctx, p); err != nil {
+		return nil, err
+	}
+
 	c, err := pool.GetStorageProviderServiceClient(p.Address)
 	if err != nil {
 		err = errors.Wrap(err, "gateway: error getting a storage provider client")
@@ -2054,6 +2155,18 @@ func (s *svc) getStorageProviderClient(_ context.Context, p *registry.ProviderIn
 }
 
 func (s *svc) findProviders(ctx context.Context, ref *provider.Reference) ([]*registry.ProviderInfo, error) {
+	// the providers of the home depend on the user, and the ones of any
+	// reference on the tenant of the user
+	key := ref.String()
+	if u, ok := userpkg.ContextGetUser(ctx); ok {
+		key = u.Id.GetIdp() + ":" + u.Id.GetOpaqueId() + ":" + key
+	}
+	if s.c.ProviderCacheTTL > 0 {
+		if providers, ok := s.providerCache.Get(ctx, key); ok {
+			return providers, nil
+		}
+	}
+
 	c, err := pool.GetStorageRegistryClient(s.c.StorageRegistryEndpoint)
 	if err != nil {
 		return nil, errors.Wrap(err, "gateway: error getting storage registry client")
@@ -2086,10 +2199,14 @@ func (s *svc) findProviders(ctx context.Context, ref *provider.Reference) ([]*re
 		return nil, errtypes.NotFound("gateway: provider is nil")
 	}
 
+	if s.c.ProviderCacheTTL > 0 {
+		_ = s.providerCache.Set(ctx, key, res.Providers)
+	}
 	return res.Providers, nil
 }
 
 type etagWithTS struct {
 	Etag      string
+	Sources   string
 	Timestamp time.Time
 }
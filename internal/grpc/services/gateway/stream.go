@@ -0,0 +1,133 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package gateway
+
+import (
+	"context"
+	"io"
+
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/internal/grpc/services/storageprovider"
+	streampb "github.com/cs3org/reva/internal/grpc/services/storageprovider/proto"
+	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+)
+
+// Download forwards the stream of the storage provider of the file.
+func (s *svc) Download(req *streampb.DownloadRequest, stream streampb.StreamAPI_DownloadServer) error {
+	ctx := stream.Context()
+	c, ref, err := s.findStreamProvider(ctx, req.Ref, false)
+	if err != nil {
+		return err
+	}
+	res, err := c.Download(ctx, &streampb.DownloadRequest{Ref: ref})
+	if err != nil {
+		return err
+	}
+	for {
+		msg, err := res.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := stream.Send(msg); err != nil {
+			return err
+		}
+	}
+}
+
+// Upload forwards the stream to the storage provider of the file, or of its
+// parent folder if the file does not exist yet.
+func (s *svc) Upload(stream streampb.StreamAPI_UploadServer) error {
+	ctx := stream.Context()
+	msg, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	c, ref, err := s.findStreamProvider(ctx, msg.Ref, true)
+	if err != nil {
+		return err
+	}
+	up, err := c.Upload(ctx)
+	if err != nil {
+		return err
+	}
+	msg.Ref = ref
+	for {
+		if err := up.Send(msg); err != nil {
+			// the provider closed the stream, its status is returned below
+			if err == io.EOF {
+				break
+			}
+			return err
+		}
+		msg, err = stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	res, err := up.CloseAndRecv()
+	if err != nil {
+		return err
+	}
+	return stream.SendAndClose(res)
+}
+
+// findStreamProvider resolves the file like findLockProvider and returns the
+// stream client of its storage provider along with the reference of the file
+// by id. When create is set and the file does not exist, the provider is
+// looked up by path and the reference is left unchanged.
+func (s *svc) findStreamProvider(ctx context.Context, ref *streampb.Reference, create bool) (streampb.StreamAPIClient, *streampb.Reference, error) {
+	cs3Ref := storageprovider.RefFromProto(ref)
+	statRes, err := s.Stat(ctx, &provider.StatRequest{Ref: cs3Ref})
+	if err != nil {
+		return nil, nil, err
+	}
+	switch statRes.Status.Code {
+	case rpc.Code_CODE_OK:
+		id := statRes.Info.Id
+		cs3Ref = &provider.Reference{Spec: &provider.Reference_Id{Id: id}}
+		ref = &streampb.Reference{StorageId: id.StorageId, OpaqueId: id.OpaqueId}
+	case rpc.Code_CODE_NOT_FOUND:
+		if !create || ref.GetOpaqueId() != "" {
+			return nil, nil, grpcstatus.Error(codes.NotFound, statRes.Status.Message)
+		}
+	case rpc.Code_CODE_PERMISSION_DENIED:
+		return nil, nil, grpcstatus.Error(codes.PermissionDenied, statRes.Status.Message)
+	default:
+		return nil, nil, grpcstatus.Error(codes.Internal, statRes.Status.Message)
+	}
+
+	providers, err := s.findProviders(ctx, cs3Ref)
+	if err != nil {
+		return nil, nil, grpcstatus.Error(codes.NotFound, err.Error())
+	}
+	c, err := pool.GetStreamClient(providers[0].Address)
+	if err != nil {
+		return nil, nil, grpcstatus.Error(codes.Internal, err.Error())
+	}
+	return c, ref, nil
+}
@@ -24,20 +24,38 @@ import (
 	"net/url"
 	"path"
 	"strings"
+	"time"
 
 	ocmprovider "github.com/cs3org/go-cs3apis/cs3/ocm/provider/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	ocm "github.com/cs3org/go-cs3apis/cs3/sharing/ocm/v1beta1"
 	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
 	"github.com/cs3org/reva/pkg/errtypes"
+	ocmshare "github.com/cs3org/reva/pkg/ocm/share"
+	"github.com/cs3org/reva/pkg/rgrpc/status"
 	"github.com/cs3org/reva/pkg/token"
 	"github.com/pkg/errors"
 	"github.com/studio-b12/gowebdav"
 )
 
+// ocmSharedSecretHeader carries the shared secret of the protected OCM shares
+const ocmSharedSecretHeader = "X-OCM-Shared-Secret"
+
 type webdavEndpoint struct {
 	filePath string
 	endpoint string
 	token    string
+	secret   string
+}
+
+func newWebdavClient(webdavEP string, ep *webdavEndpoint) *gowebdav.Client {
+	c := gowebdav.NewClient(webdavEP, "", "")
+	c.SetHeader(token.TokenHeader, ep.token)
+	if ep.secret != "" {
+		c.SetHeader(ocmSharedSecretHeader, ep.secret)
+	}
+	return c
 }
 
 func (s *svc) webdavRefStat(ctx context.Context, targetURL string, nameQueries ...string) (*provider.ResourceInfo, error) {
@@ -55,8 +73,7 @@ func (s *svc) webdavRefStat(ctx context.Context, targetURL string, nameQueries .
 		return nil, err
 	}
 
-	c := gowebdav.NewClient(webdavEP, "", "")
-	c.SetHeader(token.TokenHeader, ep.token)
+	c := newWebdavClient(webdavEP, ep)
 
 	// TODO(ishank011): We need to call PROPFIND ourselves as we need to retrieve
 	// ownloud-specific fields to get the resource ID and permissions.
@@ -82,8 +99,7 @@ func (s *svc) webdavRefLs(ctx context.Context, targetURL string, nameQueries ...
 		return nil, err
 	}
 
-	c := gowebdav.NewClient(webdavEP, "", "")
-	c.SetHeader(token.TokenHeader, ep.token)
+	c := newWebdavClient(webdavEP, ep)
 
 	// TODO(ishank011): We need to call PROPFIND ourselves as we need to retrieve
 	// ownloud-specific fields to get the resource ID and permissions.
@@ -115,8 +131,7 @@ func (s *svc) webdavRefMkdir(ctx context.Context, targetURL string, nameQueries
 		return err
 	}
 
-	c := gowebdav.NewClient(webdavEP, "", "")
-	c.SetHeader(token.TokenHeader, ep.token)
+	c := newWebdavClient(webdavEP, ep)
 
 	err = c.Mkdir(ep.filePath, 0700)
 	if err != nil {
@@ -148,8 +163,7 @@ func (s *svc) webdavRefMove(ctx context.Context, targetURL, src, destination str
 		return err
 	}
 
-	c := gowebdav.NewClient(srcWebdavEP, "", "")
-	c.SetHeader(token.TokenHeader, srcEP.token)
+	c := newWebdavClient(srcWebdavEP, srcEP)
 
 	err = c.Rename(srcEP.filePath, destEP.filePath, true)
 	if err != nil {
@@ -173,8 +187,7 @@ func (s *svc) webdavRefDelete(ctx context.Context, targetURL string, nameQueries
 		return err
 	}
 
-	c := gowebdav.NewClient(webdavEP, "", "")
-	c.SetHeader(token.TokenHeader, ep.token)
+	c := newWebdavClient(webdavEP, ep)
 
 	err = c.Remove(ep.filePath)
 	if err != nil {
@@ -208,6 +221,10 @@ func (s *svc) webdavRefTransferEndpoint(ctx context.Context, targetURL string, n
 				Decoder: "plain",
 				Value:   []byte(ep.token),
 			},
+			"webdav-shared-secret": {
+				Decoder: "plain",
+				Value:   []byte(ep.secret),
+			},
 		},
 	}, nil
 }
@@ -230,11 +247,46 @@ func (s *svc) extractEndpointInfo(ctx context.Context, targetURL string) (*webda
 		return nil, errors.Wrap(err, "gateway: error parsing target resource name")
 	}
 
-	return &webdavEndpoint{
+	ep := &webdavEndpoint{
 		filePath: m["name"][0],
 		endpoint: uri.Host,
 		token:    uri.User.String(),
-	}, nil
+	}
+	if id := m.Get("share_id"); id != "" {
+		if err := s.resolveOCMShareProtection(ctx, id, ep); err != nil {
+			return nil, err
+		}
+	}
+	return ep, nil
+}
+
+// resolveOCMShareProtection enforces the expiration of the received OCM
+// share and sets the current token and shared secret of the endpoint, which
+// the owner can rotate after the share has been accepted.
+func (s *svc) resolveOCMShareProtection(ctx context.Context, id string, ep *webdavEndpoint) error {
+	res, err := s.GetReceivedOCMShare(ctx, &ocm.GetReceivedOCMShareRequest{
+		Ref: &ocm.ShareReference{
+			Spec: &ocm.ShareReference_Id{Id: &ocm.ShareId{OpaqueId: id}},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return status.NewErrorFromCode(res.Status.Code, "gateway")
+	}
+
+	o := res.Share.GetShare().GetGrantee().GetOpaque()
+	if ocmshare.Expired(o, time.Now()) {
+		return errtypes.PermissionDenied("gateway: the ocm share " + id + " has expired")
+	}
+	if e, ok := o.GetMap()["token"]; ok {
+		ep.token = string(e.Value)
+	}
+	if e, ok := o.GetMap()[ocmshare.SharedSecretOpaqueKey]; ok {
+		ep.secret = string(e.Value)
+	}
+	return nil
 }
 
 func (s *svc) getWebdavEndpoint(ctx context.Context, domain string) (string, error) {
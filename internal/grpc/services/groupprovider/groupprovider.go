@@ -26,6 +26,8 @@ import (
 	"github.com/cs3org/reva/pkg/errtypes"
 	"github.com/cs3org/reva/pkg/group"
 	"github.com/cs3org/reva/pkg/group/manager/registry"
+	"github.com/cs3org/reva/pkg/idalloc"
+	idallocregistry "github.com/cs3org/reva/pkg/idalloc/manager/registry"
 	"github.com/cs3org/reva/pkg/rgrpc"
 	"github.com/cs3org/reva/pkg/rgrpc/status"
 	"github.com/mitchellh/mapstructure"
@@ -40,6 +42,9 @@ func init() {
 type config struct {
 	Driver  string                            `mapstructure:"driver"`
 	Drivers map[string]map[string]interface{} `mapstructure:"drivers"`
+	// IDAllocator allocates the gid numbers of the groups lacking them.
+	IDAllocator  string                            `mapstructure:"id_allocator"`
+	IDAllocators map[string]map[string]interface{} `mapstructure:"id_allocators"`
 }
 
 func (c *config) init() {
@@ -59,11 +64,25 @@ func parseConfig(m map[string]interface{}) (*config, error) {
 }
 
 func getDriver(c *config) (group.Manager, error) {
-	if f, ok := registry.NewFuncs[c.Driver]; ok {
-		return f(c.Drivers[c.Driver])
+	f, ok := registry.NewFuncs[c.Driver]
+	if !ok {
+		return nil, errtypes.NotFound(fmt.Sprintf("driver %s not found for group manager", c.Driver))
 	}
 
-	return nil, errtypes.NotFound(fmt.Sprintf("driver %s not found for group manager", c.Driver))
+	mgr, err := f(c.Drivers[c.Driver])
+	if err != nil || c.IDAllocator == "" {
+		return mgr, err
+	}
+
+	af, ok := idallocregistry.NewFuncs[c.IDAllocator]
+	if !ok {
+		return nil, errtypes.NotFound(fmt.Sprintf("id allocator %s not found", c.IDAllocator))
+	}
+	a, err := af(c.IDAllocators[c.IDAllocator])
+	if err != nil {
+		return nil, err
+	}
+	return idalloc.NewGroupManager(mgr, a), nil
 }
 
 // New returns a new GroupProviderServiceServer.
@@ -20,6 +20,7 @@ package loader
 
 import (
 	// Load core gRPC services.
+	_ "github.com/cs3org/reva/internal/grpc/services/adminprovider"
 	_ "github.com/cs3org/reva/internal/grpc/services/applicationauth"
 	_ "github.com/cs3org/reva/internal/grpc/services/appprovider"
 	_ "github.com/cs3org/reva/internal/grpc/services/appregistry"
@@ -36,6 +37,8 @@ import (
 	_ "github.com/cs3org/reva/internal/grpc/services/preferences"
 	_ "github.com/cs3org/reva/internal/grpc/services/publicshareprovider"
 	_ "github.com/cs3org/reva/internal/grpc/services/publicstorageprovider"
+	_ "github.com/cs3org/reva/internal/grpc/services/search"
+	_ "github.com/cs3org/reva/internal/grpc/services/sharesstorageprovider"
 	_ "github.com/cs3org/reva/internal/grpc/services/storageprovider"
 	_ "github.com/cs3org/reva/internal/grpc/services/storageregistry"
 	_ "github.com/cs3org/reva/internal/grpc/services/userprovider"
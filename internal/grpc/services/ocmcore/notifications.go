@@ -0,0 +1,115 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package ocmcore
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	ocm "github.com/cs3org/go-cs3apis/cs3/sharing/ocm/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	ocmcorepb "github.com/cs3org/reva/internal/grpc/services/ocmcore/proto"
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/events"
+	"github.com/cs3org/reva/pkg/ocm/share"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+)
+
+// ProcessNotification applies the notification of another provider to the
+// share and notifies our user of the share.
+func (s *service) ProcessNotification(ctx context.Context, req *ocmcorepb.NotificationRequest) (*ocmcorepb.NotificationResponse, error) {
+	owner := &userpb.UserId{Idp: req.OwnerIdp, OpaqueId: req.OwnerOpaqueId}
+	grantee := &userpb.UserId{Idp: req.GranteeIdp, OpaqueId: req.GranteeOpaqueId}
+	key := &ocm.ShareKey{
+		Owner:      owner,
+		ResourceId: &provider.ResourceId{StorageId: req.StorageId, OpaqueId: req.OpaqueId},
+		Grantee: &provider.Grantee{
+			Type: provider.GranteeType_GRANTEE_TYPE_USER,
+			Id:   &provider.Grantee_UserId{UserId: grantee},
+		},
+	}
+
+	var (
+		sh   *ocm.Share
+		user = grantee
+		err  error
+	)
+	switch req.Type {
+	case share.NotificationShareAccepted, share.NotificationShareDeclined:
+		state := ocm.ShareState_SHARE_STATE_ACCEPTED
+		if req.Type == share.NotificationShareDeclined {
+			state = ocm.ShareState_SHARE_STATE_REJECTED
+		}
+		sh, err = s.sm.SetShareState(ctx, key, req.SharedSecret, state)
+		user = owner
+	case share.NotificationShareUnshared:
+		var rs *ocm.ReceivedShare
+		if rs, err = s.sm.RemoveReceivedShare(ctx, key, req.SharedSecret); err == nil {
+			sh = rs.Share
+		}
+	case share.NotificationPermissionsChanged:
+		var perms *provider.ResourcePermissions
+		if err := json.Unmarshal(req.Permissions, &perms); err != nil || perms == nil {
+			return nil, grpcstatus.Error(codes.InvalidArgument, "ocmcore: invalid permissions")
+		}
+		var rs *ocm.ReceivedShare
+		if rs, err = s.sm.UpdateReceivedSharePermissions(ctx, key, req.SharedSecret, &ocm.SharePermissions{Permissions: perms}); err == nil {
+			sh = rs.Share
+		}
+	default:
+		return nil, grpcstatus.Error(codes.InvalidArgument, "ocmcore: unsupported notification type "+req.Type)
+	}
+	if err != nil {
+		return nil, notificationError(err)
+	}
+
+	remote := owner
+	if user == owner {
+		remote = grantee
+	}
+	if err := events.Publish(ctx, s.stream, events.OCMShareNotified{
+		NotificationType: req.Type,
+		ShareID:          sh.Id.GetOpaqueId(),
+		User:             user,
+		RemoteUser:       remote,
+		ResourceName:     sh.Name,
+		Time:             time.Now(),
+	}); err != nil {
+		appctx.GetLogger(ctx).Error().Err(err).Msg("error publishing event")
+	}
+
+	return &ocmcorepb.NotificationResponse{ShareId: sh.Id.GetOpaqueId()}, nil
+}
+
+func notificationError(err error) error {
+	switch errors.Cause(err).(type) {
+	case errtypes.IsNotFound:
+		return grpcstatus.Error(codes.NotFound, err.Error())
+	case errtypes.IsPermissionDenied:
+		return grpcstatus.Error(codes.PermissionDenied, err.Error())
+	case errtypes.IsBadRequest:
+		return grpcstatus.Error(codes.InvalidArgument, err.Error())
+	}
+	return grpcstatus.Error(codes.Internal, err.Error())
+}
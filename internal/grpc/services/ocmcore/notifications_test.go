@@ -0,0 +1,167 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package ocmcore
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	ocm "github.com/cs3org/go-cs3apis/cs3/sharing/ocm/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	ocmcorepb "github.com/cs3org/reva/internal/grpc/services/ocmcore/proto"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/events"
+	"github.com/cs3org/reva/pkg/ocm/share"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// shareManager holds a single share, protected by a shared secret.
+type shareManager struct {
+	share.Manager
+	share   *ocm.Share
+	state   ocm.ShareState
+	perms   *ocm.SharePermissions
+	removed bool
+}
+
+func (m *shareManager) find(key *ocm.ShareKey, secret string) error {
+	if key.Owner.OpaqueId != m.share.Owner.OpaqueId || key.ResourceId.OpaqueId != m.share.ResourceId.OpaqueId ||
+		key.Grantee.GetUserId().GetOpaqueId() != m.share.Grantee.GetUserId().GetOpaqueId() {
+		return errtypes.NotFound(key.String())
+	}
+	return share.CheckSecret(m.share.Grantee, secret)
+}
+
+func (m *shareManager) SetShareState(ctx context.Context, key *ocm.ShareKey, secret string, state ocm.ShareState) (*ocm.Share, error) {
+	if err := m.find(key, secret); err != nil {
+		return nil, err
+	}
+	m.state = state
+	return m.share, nil
+}
+
+func (m *shareManager) UpdateReceivedSharePermissions(ctx context.Context, key *ocm.ShareKey, secret string, p *ocm.SharePermissions) (*ocm.ReceivedShare, error) {
+	if err := m.find(key, secret); err != nil {
+		return nil, err
+	}
+	m.perms = p
+	return &ocm.ReceivedShare{Share: m.share}, nil
+}
+
+func (m *shareManager) RemoveReceivedShare(ctx context.Context, key *ocm.ShareKey, secret string) (*ocm.ReceivedShare, error) {
+	if err := m.find(key, secret); err != nil {
+		return nil, err
+	}
+	m.removed = true
+	return &ocm.ReceivedShare{Share: m.share}, nil
+}
+
+type stream struct {
+	published []*events.Message
+}
+
+func (s *stream) Publish(ctx context.Context, msg *events.Message) error {
+	s.published = append(s.published, msg)
+	return nil
+}
+
+func (s *stream) Consume(ctx context.Context, group string) (<-chan *events.Message, error) {
+	return nil, nil
+}
+
+func TestProcessNotification(t *testing.T) {
+	perms, _ := json.Marshal(&provider.ResourcePermissions{Stat: true})
+	notification := func(typ, owner, secret string) *ocmcorepb.NotificationRequest {
+		return &ocmcorepb.NotificationRequest{
+			Type:            typ,
+			StorageId:       "storage",
+			OpaqueId:        "file",
+			OwnerIdp:        "cernbox.cern.ch",
+			OwnerOpaqueId:   owner,
+			GranteeIdp:      "cesnet.cz",
+			GranteeOpaqueId: "marie",
+			SharedSecret:    secret,
+			Permissions:     perms,
+		}
+	}
+	invalidPerms := notification(share.NotificationPermissionsChanged, "einstein", "s3cret")
+	invalidPerms.Permissions = []byte("null")
+
+	tests := []struct {
+		name string
+		req  *ocmcorepb.NotificationRequest
+		code codes.Code
+		user string
+	}{
+		{"accepted", notification(share.NotificationShareAccepted, "einstein", "s3cret"), codes.OK, "einstein"},
+		{"declined", notification(share.NotificationShareDeclined, "einstein", "s3cret"), codes.OK, "einstein"},
+		{"unshared", notification(share.NotificationShareUnshared, "einstein", "s3cret"), codes.OK, "marie"},
+		{"permissions changed", notification(share.NotificationPermissionsChanged, "einstein", "s3cret"), codes.OK, "marie"},
+		{"wrong secret", notification(share.NotificationShareAccepted, "einstein", "guess"), codes.PermissionDenied, ""},
+		{"missing secret", notification(share.NotificationShareUnshared, "einstein", ""), codes.PermissionDenied, ""},
+		{"unknown share", notification(share.NotificationShareAccepted, "richard", "s3cret"), codes.NotFound, ""},
+		{"invalid permissions", invalidPerms, codes.InvalidArgument, ""},
+		{"unsupported type", notification("SHARE_EXPIRED", "einstein", "s3cret"), codes.InvalidArgument, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			grantee := &provider.Grantee{
+				Type: provider.GranteeType_GRANTEE_TYPE_USER,
+				Id:   &provider.Grantee_UserId{UserId: &userpb.UserId{Idp: "cesnet.cz", OpaqueId: "marie"}},
+			}
+			share.SetProtection(grantee, map[string]*types.OpaqueEntry{
+				share.SharedSecretOpaqueKey: {Decoder: "plain", Value: []byte("s3cret")},
+			})
+			sm := &shareManager{share: &ocm.Share{
+				Id:         &ocm.ShareId{OpaqueId: "share"},
+				ResourceId: &provider.ResourceId{StorageId: "storage", OpaqueId: "file"},
+				Owner:      &userpb.UserId{Idp: "cernbox.cern.ch", OpaqueId: "einstein"},
+				Grantee:    grantee,
+			}}
+			st := &stream{}
+			s := &service{sm: sm, stream: st}
+
+			_, err := s.ProcessNotification(context.Background(), tt.req)
+			if code := status.Code(err); code != tt.code {
+				t.Fatalf("got %s, wanted %s", code, tt.code)
+			}
+			if tt.code != codes.OK {
+				if len(st.published) != 0 || sm.state != ocm.ShareState_SHARE_STATE_INVALID || sm.perms != nil || sm.removed {
+					t.Error("the refused notification changed the share")
+				}
+				return
+			}
+
+			if len(st.published) != 1 {
+				t.Fatalf("got %d events, wanted 1", len(st.published))
+			}
+			var ev events.OCMShareNotified
+			if err := json.Unmarshal(st.published[0].Data, &ev); err != nil {
+				t.Fatal(err)
+			}
+			if ev.User.OpaqueId != tt.user {
+				t.Errorf("notified %s, wanted %s", ev.User.OpaqueId, tt.user)
+			}
+		})
+	}
+}
@@ -27,7 +27,10 @@ import (
 	ocmcore "github.com/cs3org/go-cs3apis/cs3/ocm/core/v1beta1"
 	ocm "github.com/cs3org/go-cs3apis/cs3/sharing/ocm/v1beta1"
 	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	ocmcorepb "github.com/cs3org/reva/internal/grpc/services/ocmcore/proto"
 	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/events"
+	eventsregistry "github.com/cs3org/reva/pkg/events/driver/registry"
 	"github.com/cs3org/reva/pkg/ocm/share"
 	"github.com/cs3org/reva/pkg/ocm/share/manager/registry"
 	"github.com/cs3org/reva/pkg/rgrpc"
@@ -44,11 +47,15 @@ func init() {
 type config struct {
 	Driver  string                            `mapstructure:"driver"`
 	Drivers map[string]map[string]interface{} `mapstructure:"drivers"`
+	// Events configures the bus the notifications received from the other
+	// providers are published to.
+	Events map[string]interface{} `mapstructure:"events"`
 }
 
 type service struct {
-	conf *config
-	sm   share.Manager
+	conf   *config
+	sm     share.Manager
+	stream events.Stream
 }
 
 func (c *config) init() {
@@ -59,6 +66,7 @@ func (c *config) init() {
 
 func (s *service) Register(ss *grpc.Server) {
 	ocmcore.RegisterOcmCoreAPIServer(ss, s)
+	ocmcorepb.RegisterOCMNotificationsAPIServer(ss, s)
 }
 
 func getShareManager(c *config) (share.Manager, error) {
@@ -91,9 +99,15 @@ func New(m map[string]interface{}, ss *grpc.Server) (rgrpc.Service, error) {
 		return nil, err
 	}
 
+	stream, err := eventsregistry.NewStream(c.Events)
+	if err != nil {
+		return nil, err
+	}
+
 	service := &service{
-		conf: c,
-		sm:   sm,
+		conf:   c,
+		sm:     sm,
+		stream: stream,
 	}
 
 	return service, nil
@@ -104,7 +118,10 @@ func (s *service) Close() error {
 }
 
 func (s *service) UnprotectedEndpoints() []string {
-	return []string{"/cs3.ocm.core.v1beta1.OcmCoreAPI/CreateOCMCoreShare"}
+	return []string{
+		"/cs3.ocm.core.v1beta1.OcmCoreAPI/CreateOCMCoreShare",
+		"/revad.ocmcore.OCMNotificationsAPI/ProcessNotification",
+	}
 }
 
 func (s *service) CreateOCMCoreShare(ctx context.Context, req *ocmcore.CreateOCMCoreShareRequest) (*ocmcore.CreateOCMCoreShareResponse, error) {
@@ -171,6 +188,7 @@ func (s *service) CreateOCMCoreShare(ctx context.Context, req *ocmcore.CreateOCM
 			Permissions: resourcePermissions,
 		},
 	}
+	share.SetProtection(grant.Grantee, share.Protection(req.Protocol.Opaque))
 
 	var shareType ocm.Share_ShareType
 	switch req.Protocol.Name {
@@ -161,6 +161,9 @@ func (s *service) CreateOCMShare(ctx context.Context, req *ocm.CreateOCMShareReq
 		}
 	}
 
+	// the optional expiration and shared secret are sent to the recipient
+	share.SetProtection(req.Grant.Grantee, share.Protection(req.Opaque))
+
 	share, err := s.sm.Share(ctx, req.ResourceId, req.Grant, name, req.RecipientMeshProvider, permissions, nil, "", sharetype)
 	if err != nil {
 		return &ocm.CreateOCMShareResponse{
@@ -20,15 +20,18 @@ package preferences
 
 import (
 	"context"
-	"sync"
 
 	"google.golang.org/grpc"
 
 	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
 	preferences "github.com/cs3org/go-cs3apis/cs3/preferences/v1beta1"
+	"github.com/cs3org/reva/pkg/errtypes"
 	"github.com/cs3org/reva/pkg/rgrpc"
 	"github.com/cs3org/reva/pkg/rgrpc/status"
+	"github.com/cs3org/reva/pkg/settings"
+	"github.com/cs3org/reva/pkg/settings/manager/registry"
 	"github.com/cs3org/reva/pkg/user"
+	"github.com/mitchellh/mapstructure"
 	"github.com/pkg/errors"
 )
 
@@ -40,17 +43,67 @@ func init() {
 	rgrpc.Register("preferences", New)
 }
 
-// m maps user to map of user preferences.
-// m = map[userToken]map[key]value
-var m = make(map[string]map[string]string)
+type config struct {
+	Driver  string                            `mapstructure:"driver"`
+	Drivers map[string]map[string]interface{} `mapstructure:"drivers"`
+	// Bundles are additional bundles of settings defined by the administrators.
+	Bundles []*settings.Bundle `mapstructure:"bundles"`
+	// Forced maps keys to values enforced by the administrators,
+	// which the users cannot change.
+	Forced map[string]string `mapstructure:"forced"`
+}
 
-var mutex = &sync.Mutex{}
+func (c *config) init() {
+	if c.Driver == "" {
+		c.Driver = "memory"
+	}
+}
 
-type service struct{}
+type service struct {
+	conf *config
+	sm   settings.Manager
+}
+
+func parseConfig(m map[string]interface{}) (*config, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		err = errors.Wrap(err, "error decoding conf")
+		return nil, err
+	}
+	c.init()
+	return c, nil
+}
 
 // New returns a new PreferencesServiceServer
 func New(m map[string]interface{}, ss *grpc.Server) (rgrpc.Service, error) {
-	service := &service{}
+	c, err := parseConfig(m)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, b := range c.Bundles {
+		if err := settings.RegisterBundle(b); err != nil {
+			return nil, err
+		}
+	}
+	for k, v := range c.Forced {
+		if st, ok := settings.Lookup(k); ok {
+			if err := st.Validate(v); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	f, ok := registry.NewFuncs[c.Driver]
+	if !ok {
+		return nil, errtypes.NotFound("preferences: driver not found: " + c.Driver)
+	}
+	sm, err := f(c.Drivers[c.Driver])
+	if err != nil {
+		return nil, err
+	}
+
+	service := &service{conf: c, sm: sm}
 	return service, nil
 }
 
@@ -87,15 +140,25 @@ func (s *service) SetKey(ctx context.Context, req *preferences.SetKeyRequest) (*
 		}, err
 	}
 
-	name := u.Username
+	if _, ok := s.conf.Forced[key]; ok {
+		return &preferences.SetKeyResponse{
+			Status: status.NewPermissionDenied(ctx, nil, "the value of "+key+" is enforced"),
+		}, nil
+	}
 
-	mutex.Lock()
-	defer mutex.Unlock()
-	if len(m[name]) == 0 {
-		m[name] = map[string]string{key: value}
-	} else {
-		usersettings := m[name]
-		usersettings[key] = value
+	// the keys which are not part of a bundle are stored as they are
+	if st, ok := settings.Lookup(key); ok {
+		if err := st.Validate(value); err != nil {
+			return &preferences.SetKeyResponse{
+				Status: status.NewInvalidArg(ctx, err.Error()),
+			}, nil
+		}
+	}
+
+	if err := s.sm.SetValue(ctx, u.Id, key, value); err != nil {
+		return &preferences.SetKeyResponse{
+			Status: status.NewInternal(ctx, err, "error setting key"),
+		}, nil
 	}
 
 	return &preferences.SetKeyResponse{
@@ -113,17 +176,31 @@ func (s *service) GetKey(ctx context.Context, req *preferences.GetKeyRequest) (*
 		}, err
 	}
 
-	name := u.Username
+	if value, ok := s.conf.Forced[key]; ok {
+		return &preferences.GetKeyResponse{
+			Status: status.NewOK(ctx),
+			Val:    value,
+		}, nil
+	}
 
-	mutex.Lock()
-	defer mutex.Unlock()
-	if len(m[name]) != 0 {
-		if value, ok := m[name][key]; ok {
+	value, err := s.sm.GetValue(ctx, u.Id, key)
+	switch err.(type) {
+	case nil:
+		return &preferences.GetKeyResponse{
+			Status: status.NewOK(ctx),
+			Val:    value,
+		}, nil
+	case errtypes.IsNotFound:
+		if st, ok := settings.Lookup(key); ok {
 			return &preferences.GetKeyResponse{
 				Status: status.NewOK(ctx),
-				Val:    value,
+				Val:    st.Default,
 			}, nil
 		}
+	default:
+		return &preferences.GetKeyResponse{
+			Status: status.NewInternal(ctx, err, "error getting key"),
+		}, nil
 	}
 
 	res := &preferences.GetKeyResponse{
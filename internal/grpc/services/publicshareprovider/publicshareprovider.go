@@ -20,11 +20,14 @@ package publicshareprovider
 
 import (
 	"context"
+	"sync"
 
 	link "github.com/cs3org/go-cs3apis/cs3/sharing/link/v1beta1"
 	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	"github.com/cs3org/reva/pkg/appctx"
 	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/jobs"
+	"github.com/cs3org/reva/pkg/logger"
 	"github.com/cs3org/reva/pkg/publicshare"
 	"github.com/cs3org/reva/pkg/publicshare/manager/registry"
 	"github.com/cs3org/reva/pkg/rgrpc"
@@ -42,6 +45,15 @@ func init() {
 type config struct {
 	Driver  string                            `mapstructure:"driver"`
 	Drivers map[string]map[string]interface{} `mapstructure:"drivers"`
+	// ShareExpirySchedule, if set, is the schedule at which the expired
+	// shares are deleted, e.g. "*/5 * * * *" or "@every 1m".
+	ShareExpirySchedule string `mapstructure:"share_expiry_schedule"`
+	// JobLeaseDir is a directory shared by the replicas of the service, used
+	// to elect the replica deleting the expired shares.
+	JobLeaseDir string `mapstructure:"job_lease_dir"`
+	// JobLocks configures the locks shared by the replicas of the service,
+	// which take precedence over the JobLeaseDir.
+	JobLocks map[string]interface{} `mapstructure:"job_locks"`
 }
 
 func (c *config) init() {
@@ -53,6 +65,8 @@ func (c *config) init() {
 type service struct {
 	conf *config
 	sm   publicshare.Manager
+	stop chan struct{}
+	wg   sync.WaitGroup
 }
 
 func getShareManager(c *config) (publicshare.Manager, error) {
@@ -64,6 +78,8 @@ func getShareManager(c *config) (publicshare.Manager, error) {
 
 // TODO(labkode): add ctx to Close.
 func (s *service) Close() error {
+	close(s.stop)
+	s.wg.Wait()
 	return nil
 }
 func (s *service) UnprotectedEndpoints() []string {
@@ -101,11 +117,53 @@ func New(m map[string]interface{}, ss *grpc.Server) (rgrpc.Service, error) {
 	service := &service{
 		conf: c,
 		sm:   sm,
+		stop: make(chan struct{}),
+	}
+
+	if c.ShareExpirySchedule != "" {
+		if err := service.scheduleShareExpiry(); err != nil {
+			return nil, err
+		}
 	}
 
 	return service, nil
 }
 
+// scheduleShareExpiry periodically deletes the expired shares.
+func (s *service) scheduleShareExpiry() error {
+	p, ok := s.sm.(publicshare.ExpiredSharesPurger)
+	if !ok {
+		return errtypes.NotSupported("publicshareprovider: the driver " + s.conf.Driver + " does not support purging the expired shares")
+	}
+
+	elector, err := jobs.NewElector(s.conf.JobLocks, s.conf.JobLeaseDir, "publicshareprovider")
+	if err != nil {
+		return errors.Wrap(err, "publicshareprovider: error creating job elector")
+	}
+	log := logger.New()
+	sched, err := jobs.NewScheduler(elector, log)
+	if err != nil {
+		return err
+	}
+	err = sched.Register("share_expiry", s.conf.ShareExpirySchedule, func(ctx context.Context) error {
+		purged, err := p.PurgeExpiredShares(ctx)
+		if purged > 0 {
+			log.Info().Int("shares", purged).Msg("publicshareprovider: deleted expired shares")
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		sched.Run(s.stop)
+	}()
+	return nil
+}
+
 func (s *service) CreatePublicShare(ctx context.Context, req *link.CreatePublicShareRequest) (*link.CreatePublicShareResponse, error) {
 	log := appctx.GetLogger(ctx)
 	log.Info().Str("publicshareprovider", "create").Msg("create public share")
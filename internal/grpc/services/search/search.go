@@ -0,0 +1,238 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package search
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/internal/grpc/services/search/proto"
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/logger"
+	"github.com/cs3org/reva/pkg/permission"
+	permregistry "github.com/cs3org/reva/pkg/permission/manager/registry"
+	"github.com/cs3org/reva/pkg/rgrpc"
+	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
+	"github.com/cs3org/reva/pkg/search"
+	"github.com/cs3org/reva/pkg/search/index/registry"
+	"github.com/cs3org/reva/pkg/sharedconf"
+	ctxpkg "github.com/cs3org/reva/pkg/user"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultLimit = 50
+	maxLimit     = 1000
+	// maxPages is the number of pages of results fetched from the index
+	// to fill the response with matches the user has access to.
+	maxPages = 10
+)
+
+func init() {
+	rgrpc.Register("search", New)
+}
+
+type config struct {
+	GatewaySvc string                            `mapstructure:"gatewaysvc"`
+	Index      string                            `mapstructure:"index"`
+	Indexes    map[string]map[string]interface{} `mapstructure:"indexes"`
+	// Indexer configures the indexing of the uploaded files.
+	Indexer map[string]interface{} `mapstructure:"indexer"`
+	// Admins and AdminGroups are the users, and the groups of users, allowed
+	// to reindex the files of the users, when no PermissionDriver grants the
+	// system.admin capability.
+	Admins            []string                          `mapstructure:"admins"`
+	AdminGroups       []string                          `mapstructure:"admin_groups"`
+	PermissionDriver  string                            `mapstructure:"permission_driver"`
+	PermissionDrivers map[string]map[string]interface{} `mapstructure:"permission_drivers"`
+}
+
+func (c *config) init() {
+	if c.Index == "" {
+		c.Index = "bleve"
+	}
+	c.GatewaySvc = sharedconf.GetGatewaySVC(c.GatewaySvc)
+}
+
+type service struct {
+	conf    *config
+	index   search.Index
+	indexer *search.Indexer
+	pm      permission.Manager
+	stop    chan struct{}
+}
+
+// New returns a new SearchServiceServer, which indexes the files as they
+// are uploaded and trims the results to the files the user has access to.
+// It can be tested like this:
+// prototool grpc --address 0.0.0.0:9999 --method 'revad.search.SearchService/Search' --data '{"query": "report"}'
+func New(m map[string]interface{}, ss *grpc.Server) (rgrpc.Service, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		err = errors.Wrap(err, "search: error decoding conf")
+		return nil, err
+	}
+	c.init()
+
+	f, ok := registry.NewFuncs[c.Index]
+	if !ok {
+		return nil, errtypes.NotFound("search: index not found: " + c.Index)
+	}
+	index, err := f(c.Indexes[c.Index])
+	if err != nil {
+		return nil, err
+	}
+
+	ic, err := search.ParseIndexerConfig(c.Indexer)
+	if err != nil {
+		return nil, err
+	}
+	indexer, err := search.NewIndexer(ic, index, logger.New())
+	if err != nil {
+		return nil, err
+	}
+
+	pm, err := permregistry.New(c.PermissionDriver, c.PermissionDrivers)
+	if err != nil {
+		return nil, errors.Wrap(err, "search: error creating permission manager")
+	}
+
+	s := &service{conf: c, index: index, indexer: indexer, pm: pm, stop: make(chan struct{})}
+	go indexer.Run(s.stop)
+	return s, nil
+}
+
+func (s *service) Close() error {
+	close(s.stop)
+	if c, ok := s.index.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+func (s *service) UnprotectedEndpoints() []string {
+	return []string{}
+}
+
+func (s *service) Register(ss *grpc.Server) {
+	proto.RegisterSearchServiceServer(ss, s)
+}
+
+func (s *service) Search(ctx context.Context, req *proto.SearchRequest) (*proto.SearchResponse, error) {
+	if strings.TrimSpace(req.Query) == "" && len(req.Metadata) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "search: empty query")
+	}
+	limit := int(req.Limit)
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+
+	client, err := pool.GetGatewayServiceClient(s.conf.GatewaySvc)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	// the index does not know about permissions: the matches are statted on
+	// behalf of the user, and the ones they cannot access are dropped
+	res := &proto.SearchResponse{Matches: []*proto.Match{}}
+	q := &search.Query{Term: req.Query, Metadata: req.Metadata, Limit: limit}
+	for page := 0; page < maxPages && len(res.Matches) < limit; page++ {
+		matches, err := s.index.Search(ctx, q)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		for _, m := range matches {
+			ri, ok := s.stat(ctx, client, m)
+			if !ok {
+				continue
+			}
+			res.Matches = append(res.Matches, &proto.Match{
+				StorageId: ri.Id.StorageId,
+				OpaqueId:  ri.Id.OpaqueId,
+				Path:      ri.Path,
+				MimeType:  ri.MimeType,
+				Size:      ri.Size,
+				Mtime:     ri.Mtime.GetSeconds(),
+				Metadata:  ri.GetArbitraryMetadata().GetMetadata(),
+				Score:     m.Score,
+			})
+			if len(res.Matches) == limit {
+				break
+			}
+		}
+		if len(matches) < q.Limit {
+			break
+		}
+		q.Offset += len(matches)
+	}
+	return res, nil
+}
+
+func (s *service) stat(ctx context.Context, client gateway.GatewayAPIClient, m *search.Match) (*provider.ResourceInfo, bool) {
+	res, err := client.Stat(ctx, &provider.StatRequest{
+		Ref:                   &provider.Reference{Spec: &provider.Reference_Id{Id: m.ResourceID}},
+		ArbitraryMetadataKeys: []string{"*"},
+	})
+	if err != nil {
+		appctx.GetLogger(ctx).Error().Err(err).Str("id", m.ID()).Msg("search: error statting match")
+		return nil, false
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return nil, false
+	}
+	return res.Info, true
+}
+
+// Reindex lets the admins index again the files of a user, e.g. after the
+// index was lost or the indexing of the content was enabled.
+func (s *service) Reindex(ctx context.Context, req *proto.ReindexRequest) (*proto.ReindexResponse, error) {
+	if !s.isAdmin(ctx) {
+		return nil, status.Error(codes.PermissionDenied, "search: not allowed to reindex")
+	}
+	if req.OpaqueId == "" {
+		return nil, status.Error(codes.InvalidArgument, "search: missing user")
+	}
+	indexed, err := s.indexer.Reindex(ctx, &userpb.UserId{OpaqueId: req.OpaqueId, Idp: req.Idp})
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &proto.ReindexResponse{Indexed: uint64(indexed)}, nil
+}
+
+func (s *service) isAdmin(ctx context.Context) bool {
+	u, ok := ctxpkg.ContextGetUser(ctx)
+	if !ok {
+		return false
+	}
+	allowed, _ := permission.IsAdmin(ctx, s.pm, u, s.conf.Admins, s.conf.AdminGroups)
+	return allowed
+}
@@ -0,0 +1,152 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package sharesstorageprovider
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/pkg/errors"
+)
+
+// mountPoints keeps the names under which the received shares of the users
+// are mounted, by user and share id. The names are persisted to a JSON file
+// when one is configured.
+type mountPoints struct {
+	sync.Mutex // concurrent access to the file
+	file       string
+	names      map[string]map[string]string
+}
+
+func loadMountPoints(file string) (*mountPoints, error) {
+	m := &mountPoints{file: file, names: map[string]map[string]string{}}
+	if file == "" {
+		return m, nil
+	}
+
+	data, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading the file: "+file)
+	}
+	if len(data) == 0 {
+		return m, nil
+	}
+	if err := json.Unmarshal(data, &m.names); err != nil {
+		return nil, errors.Wrap(err, "error decoding data from json")
+	}
+	return m, nil
+}
+
+func (m *mountPoints) save() error {
+	if m.file == "" {
+		return nil
+	}
+	data, err := json.Marshal(m.names)
+	if err != nil {
+		return errors.Wrap(err, "error encoding to json")
+	}
+	if err := ioutil.WriteFile(m.file, data, 0644); err != nil {
+		return errors.Wrap(err, "error writing to file: "+m.file)
+	}
+	return nil
+}
+
+// resolve returns the mount point names of the shares of the user, naming
+// the shares mounted for the first time after the default name returned by
+// defaultName. The names are kept unique by suffixing the default names
+// already taken.
+func (m *mountPoints) resolve(user string, shareIDs []string, defaultName func(shareID string) (string, error)) (map[string]string, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	names := m.names[user]
+	if names == nil {
+		names = map[string]string{}
+	}
+	taken := map[string]bool{}
+	for _, n := range names {
+		taken[n] = true
+	}
+
+	changed := false
+	res := make(map[string]string, len(shareIDs))
+	for _, id := range shareIDs {
+		if n, ok := names[id]; ok {
+			res[id] = n
+			continue
+		}
+		base, err := defaultName(id)
+		if err != nil {
+			return nil, err
+		}
+		n := base
+		for i := 2; taken[n]; i++ {
+			n = fmt.Sprintf("%s (%d)", base, i)
+		}
+		names[id] = n
+		taken[n] = true
+		res[id] = n
+		changed = true
+	}
+
+	if changed {
+		m.names[user] = names
+		if err := m.save(); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// rename changes the name of the mount point of a share, provided that no
+// other share of the user is mounted under the new name.
+func (m *mountPoints) rename(user, shareID, name string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	for id, n := range m.names[user] {
+		if n == name && id != shareID {
+			return errtypes.AlreadyExists("a share is already mounted as " + name)
+		}
+	}
+	if m.names[user] == nil {
+		m.names[user] = map[string]string{}
+	}
+	m.names[user][shareID] = name
+	return m.save()
+}
+
+// remove forgets the mount point of a share, e.g. once it has been rejected.
+func (m *mountPoints) remove(user, shareID string) error {
+	m.Lock()
+	defer m.Unlock()
+
+	if _, ok := m.names[user][shareID]; !ok {
+		return nil
+	}
+	delete(m.names[user], shareID)
+	return m.save()
+}
@@ -0,0 +1,64 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package sharesstorageprovider
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/cs3org/reva/pkg/errtypes"
+)
+
+func TestMountPoints(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "mount-points.json")
+	m, err := loadMountPoints(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defaultName := func(id string) (string, error) { return "Documents", nil }
+	names, err := m.resolve("einstein", []string{"1", "2"}, defaultName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names["1"] != "Documents" || names["2"] != "Documents (2)" {
+		t.Fatalf("unexpected mount points %v", names)
+	}
+
+	if err := m.rename("einstein", "2", "Documents"); err == nil {
+		t.Fatal("expected renaming to a taken name to fail")
+	} else if _, ok := err.(errtypes.IsAlreadyExists); !ok {
+		t.Fatalf("expected an already exists error, got %v", err)
+	}
+	if err := m.rename("einstein", "2", "Papers"); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := loadMountPoints(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names, err = reloaded.resolve("einstein", []string{"1", "2"}, defaultName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if names["1"] != "Documents" || names["2"] != "Papers" {
+		t.Fatalf("unexpected mount points after reload %v", names)
+	}
+}
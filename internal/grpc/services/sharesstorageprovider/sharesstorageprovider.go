@@ -0,0 +1,816 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package sharesstorageprovider
+
+import (
+	"context"
+	"path"
+	"sort"
+	"strings"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	collaboration "github.com/cs3org/go-cs3apis/cs3/sharing/collaboration/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/rgrpc"
+	"github.com/cs3org/reva/pkg/rgrpc/status"
+	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
+	"github.com/cs3org/reva/pkg/sharedconf"
+	"github.com/cs3org/reva/pkg/storage/utils/etag"
+	"github.com/cs3org/reva/pkg/user"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"go.opencensus.io/trace"
+	"google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	gstatus "google.golang.org/grpc/status"
+)
+
+const spaceTypeShare = "share"
+
+func init() {
+	rgrpc.Register("sharesstorageprovider", New)
+}
+
+type config struct {
+	MountPath       string `mapstructure:"mount_path"`
+	MountID         string `mapstructure:"mount_id"`
+	GatewayAddr     string `mapstructure:"gateway_addr"`
+	MountPointsFile string `mapstructure:"mount_points_file"`
+}
+
+func (c *config) init() {
+	if c.MountPath == "" {
+		c.MountPath = "/shares"
+	}
+	if c.MountID == "" {
+		c.MountID = "a0ca6a90-a365-4782-871e-d44447bbc668"
+	}
+	c.GatewayAddr = sharedconf.GetGatewaySVC(c.GatewayAddr)
+}
+
+// service presents the accepted shares received by the user as the folders
+// of a single virtual storage, under the names of their mount points,
+// instead of references inside the home of the user. The requests on the
+// resources inside the shares are forwarded to the gateway.
+type service struct {
+	conf        *config
+	gateway     gateway.GatewayAPIClient
+	mountPoints *mountPoints
+}
+
+// mount is an accepted received share and the name it is mounted under.
+type mount struct {
+	name  string
+	share *collaboration.ReceivedShare
+}
+
+func (s *service) Close() error {
+	return nil
+}
+
+func (s *service) UnprotectedEndpoints() []string {
+	return []string{}
+}
+
+func (s *service) Register(ss *grpc.Server) {
+	provider.RegisterProviderAPIServer(ss, s)
+}
+
+func parseConfig(m map[string]interface{}) (*config, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		err = errors.Wrap(err, "error decoding conf")
+		return nil, err
+	}
+	return c, nil
+}
+
+// New creates a new shares storage provider service.
+func New(m map[string]interface{}, ss *grpc.Server) (rgrpc.Service, error) {
+	c, err := parseConfig(m)
+	if err != nil {
+		return nil, err
+	}
+	c.init()
+
+	gw, err := pool.GetGatewayServiceClient(c.GatewayAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	mp, err := loadMountPoints(c.MountPointsFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return &service{
+		conf:        c,
+		gateway:     gw,
+		mountPoints: mp,
+	}, nil
+}
+
+func (s *service) Stat(ctx context.Context, req *provider.StatRequest) (*provider.StatResponse, error) {
+	ctx, span := trace.StartSpan(ctx, "Stat")
+	defer span.End()
+
+	name, relativePath, m, st, err := s.resolve(ctx, req.Ref)
+	switch {
+	case err != nil:
+		return &provider.StatResponse{
+			Status: status.NewStatusFromErrType(ctx, "sharesstorageprovider: error resolving ref "+req.Ref.String(), err),
+		}, nil
+	case st != nil:
+		return &provider.StatResponse{Status: st}, nil
+	case name == "":
+		info, st, err := s.statRoot(ctx)
+		if err != nil {
+			return &provider.StatResponse{
+				Status: status.NewInternal(ctx, err, "sharesstorageprovider: error stating the shares"),
+			}, nil
+		}
+		return &provider.StatResponse{Status: st, Info: info}, nil
+	case m == nil:
+		return &provider.StatResponse{Status: status.NewNotFound(ctx, "sharesstorageprovider: no share mounted as "+name)}, nil
+	}
+
+	ref, st, err := s.targetRef(ctx, m, relativePath)
+	switch {
+	case err != nil:
+		return nil, err
+	case st != nil:
+		return &provider.StatResponse{Status: st}, nil
+	}
+	res, err := s.gateway.Stat(ctx, &provider.StatRequest{Ref: ref, ArbitraryMetadataKeys: req.ArbitraryMetadataKeys})
+	if err != nil {
+		return &provider.StatResponse{
+			Status: status.NewInternal(ctx, err, "gateway: error calling Stat for ref:"+req.Ref.String()),
+		}, nil
+	}
+	if res.Info != nil {
+		s.rewriteInfo(res.Info, m, relativePath)
+	}
+	return res, nil
+}
+
+func (s *service) ListContainerStream(req *provider.ListContainerStreamRequest, ss provider.ProviderAPI_ListContainerStreamServer) error {
+	return gstatus.Errorf(codes.Unimplemented, "method not implemented")
+}
+
+func (s *service) ListContainer(ctx context.Context, req *provider.ListContainerRequest) (*provider.ListContainerResponse, error) {
+	ctx, span := trace.StartSpan(ctx, "ListContainer")
+	defer span.End()
+
+	name, relativePath, m, st, err := s.resolve(ctx, req.Ref)
+	switch {
+	case err != nil:
+		return &provider.ListContainerResponse{
+			Status: status.NewStatusFromErrType(ctx, "sharesstorageprovider: error resolving ref "+req.Ref.String(), err),
+		}, nil
+	case st != nil:
+		return &provider.ListContainerResponse{Status: st}, nil
+	case name == "":
+		infos, st, err := s.statMounts(ctx)
+		if err != nil {
+			return &provider.ListContainerResponse{
+				Status: status.NewInternal(ctx, err, "sharesstorageprovider: error listing the shares"),
+			}, nil
+		}
+		return &provider.ListContainerResponse{Status: st, Infos: infos}, nil
+	case m == nil:
+		return &provider.ListContainerResponse{Status: status.NewNotFound(ctx, "sharesstorageprovider: no share mounted as "+name)}, nil
+	}
+
+	ref, st, err := s.targetRef(ctx, m, relativePath)
+	switch {
+	case err != nil:
+		return nil, err
+	case st != nil:
+		return &provider.ListContainerResponse{Status: st}, nil
+	}
+	res, err := s.gateway.ListContainer(ctx, &provider.ListContainerRequest{Ref: ref, ArbitraryMetadataKeys: req.ArbitraryMetadataKeys})
+	if err != nil {
+		return &provider.ListContainerResponse{
+			Status: status.NewInternal(ctx, err, "gateway: error calling ListContainer for ref:"+req.Ref.String()),
+		}, nil
+	}
+	for _, info := range res.Infos {
+		s.rewriteInfo(info, m, path.Join(relativePath, path.Base(info.Path)))
+	}
+	return res, nil
+}
+
+func (s *service) CreateContainer(ctx context.Context, req *provider.CreateContainerRequest) (*provider.CreateContainerResponse, error) {
+	ref, st, err := s.forwardRef(ctx, req.Ref, "CreateContainer")
+	switch {
+	case err != nil:
+		return nil, err
+	case st != nil:
+		return &provider.CreateContainerResponse{Status: st}, nil
+	}
+	res, err := s.gateway.CreateContainer(ctx, &provider.CreateContainerRequest{Ref: ref})
+	if err != nil {
+		return &provider.CreateContainerResponse{
+			Status: status.NewInternal(ctx, err, "gateway: error calling CreateContainer for ref:"+req.Ref.String()),
+		}, nil
+	}
+	return res, nil
+}
+
+// Delete removes a resource inside a share. Deleting the mount point of a
+// share rejects the share instead.
+func (s *service) Delete(ctx context.Context, req *provider.DeleteRequest) (*provider.DeleteResponse, error) {
+	name, relativePath, m, st, err := s.resolve(ctx, req.Ref)
+	switch {
+	case err != nil:
+		return &provider.DeleteResponse{
+			Status: status.NewStatusFromErrType(ctx, "sharesstorageprovider: error resolving ref "+req.Ref.String(), err),
+		}, nil
+	case st != nil:
+		return &provider.DeleteResponse{Status: st}, nil
+	case name == "":
+		return &provider.DeleteResponse{Status: status.NewPermissionDenied(ctx, nil, "sharesstorageprovider: the shares folder cannot be deleted")}, nil
+	case m == nil:
+		return &provider.DeleteResponse{Status: status.NewNotFound(ctx, "sharesstorageprovider: no share mounted as "+name)}, nil
+	case relativePath == "":
+		return &provider.DeleteResponse{Status: s.rejectShare(ctx, m)}, nil
+	}
+
+	ref, st, err := s.targetRef(ctx, m, relativePath)
+	switch {
+	case err != nil:
+		return nil, err
+	case st != nil:
+		return &provider.DeleteResponse{Status: st}, nil
+	}
+	res, err := s.gateway.Delete(ctx, &provider.DeleteRequest{Ref: ref})
+	if err != nil {
+		return &provider.DeleteResponse{
+			Status: status.NewInternal(ctx, err, "gateway: error calling Delete for ref:"+req.Ref.String()),
+		}, nil
+	}
+	return res, nil
+}
+
+// Move moves resources within a share. Moving the mount point of a share
+// renames the mount point.
+func (s *service) Move(ctx context.Context, req *provider.MoveRequest) (*provider.MoveResponse, error) {
+	ctx, span := trace.StartSpan(ctx, "Move")
+	defer span.End()
+
+	span.AddAttributes(
+		trace.StringAttribute("source", req.Source.String()),
+		trace.StringAttribute("destination", req.Destination.String()),
+	)
+
+	srcName, srcPath, srcMount, st, err := s.resolve(ctx, req.Source)
+	switch {
+	case err != nil:
+		return &provider.MoveResponse{
+			Status: status.NewStatusFromErrType(ctx, "sharesstorageprovider: error resolving ref "+req.Source.String(), err),
+		}, nil
+	case st != nil:
+		return &provider.MoveResponse{Status: st}, nil
+	case srcName == "":
+		return &provider.MoveResponse{Status: status.NewPermissionDenied(ctx, nil, "sharesstorageprovider: the shares folder cannot be moved")}, nil
+	case srcMount == nil:
+		return &provider.MoveResponse{Status: status.NewNotFound(ctx, "sharesstorageprovider: no share mounted as "+srcName)}, nil
+	}
+
+	dstName, dstPath, dstMount, st, err := s.resolve(ctx, req.Destination)
+	switch {
+	case err != nil:
+		return &provider.MoveResponse{
+			Status: status.NewStatusFromErrType(ctx, "sharesstorageprovider: error resolving ref "+req.Destination.String(), err),
+		}, nil
+	case st != nil:
+		return &provider.MoveResponse{Status: st}, nil
+	case dstName == "":
+		return &provider.MoveResponse{Status: status.NewInvalidArg(ctx, "sharesstorageprovider: invalid destination "+req.Destination.String())}, nil
+	}
+
+	if srcPath == "" {
+		if dstPath != "" {
+			return &provider.MoveResponse{Status: status.NewInvalidArg(ctx, "sharesstorageprovider: a share can only be renamed")}, nil
+		}
+		return &provider.MoveResponse{Status: s.renameMount(ctx, srcMount, dstName)}, nil
+	}
+	if dstMount != srcMount || dstPath == "" {
+		// there is no way to move resources between the storages of the shares
+		return &provider.MoveResponse{Status: status.NewInvalidArg(ctx, "sharesstorageprovider: resources can only be moved within a share")}, nil
+	}
+
+	srcRef, st, err := s.targetRef(ctx, srcMount, srcPath)
+	switch {
+	case err != nil:
+		return nil, err
+	case st != nil:
+		return &provider.MoveResponse{Status: st}, nil
+	}
+	dstRef, st, err := s.targetRef(ctx, dstMount, dstPath)
+	switch {
+	case err != nil:
+		return nil, err
+	case st != nil:
+		return &provider.MoveResponse{Status: st}, nil
+	}
+
+	res, err := s.gateway.Move(ctx, &provider.MoveRequest{Source: srcRef, Destination: dstRef})
+	if err != nil {
+		return &provider.MoveResponse{
+			Status: status.NewInternal(ctx, err, "gateway: error calling Move for source ref "+req.Source.String()+" to destination ref "+req.Destination.String()),
+		}, nil
+	}
+	return res, nil
+}
+
+func (s *service) InitiateFileDownload(ctx context.Context, req *provider.InitiateFileDownloadRequest) (*provider.InitiateFileDownloadResponse, error) {
+	ref, st, err := s.forwardRef(ctx, req.Ref, "InitiateFileDownload")
+	switch {
+	case err != nil:
+		return nil, err
+	case st != nil:
+		return &provider.InitiateFileDownloadResponse{Status: st}, nil
+	}
+
+	dRes, err := s.gateway.InitiateFileDownload(ctx, &provider.InitiateFileDownloadRequest{Ref: ref})
+	if err != nil {
+		return &provider.InitiateFileDownloadResponse{
+			Status: status.NewInternal(ctx, err, "gateway: error calling InitiateFileDownload"),
+		}, nil
+	}
+	if dRes.Status.Code != rpc.Code_CODE_OK {
+		return &provider.InitiateFileDownloadResponse{
+			Status: dRes.Status,
+		}, nil
+	}
+
+	protocols := make([]*provider.FileDownloadProtocol, 0, len(dRes.Protocols))
+	for p := range dRes.Protocols {
+		if !strings.HasSuffix(dRes.Protocols[p].DownloadEndpoint, "/") {
+			dRes.Protocols[p].DownloadEndpoint += "/"
+		}
+		dRes.Protocols[p].DownloadEndpoint += dRes.Protocols[p].Token
+
+		protocols = append(protocols, &provider.FileDownloadProtocol{
+			Opaque:           dRes.Protocols[p].Opaque,
+			Protocol:         dRes.Protocols[p].Protocol,
+			DownloadEndpoint: dRes.Protocols[p].DownloadEndpoint,
+			Expose:           true, // the gateway already has encoded the download endpoint
+		})
+	}
+
+	return &provider.InitiateFileDownloadResponse{
+		Status:    dRes.Status,
+		Protocols: protocols,
+	}, nil
+}
+
+func (s *service) InitiateFileUpload(ctx context.Context, req *provider.InitiateFileUploadRequest) (*provider.InitiateFileUploadResponse, error) {
+	ref, st, err := s.forwardRef(ctx, req.Ref, "InitiateFileUpload")
+	switch {
+	case err != nil:
+		return nil, err
+	case st != nil:
+		return &provider.InitiateFileUploadResponse{Status: st}, nil
+	}
+
+	uRes, err := s.gateway.InitiateFileUpload(ctx, &provider.InitiateFileUploadRequest{Ref: ref, Opaque: req.Opaque})
+	if err != nil {
+		return &provider.InitiateFileUploadResponse{
+			Status: status.NewInternal(ctx, err, "gateway: error calling InitiateFileUpload"),
+		}, nil
+	}
+	if uRes.Status.Code != rpc.Code_CODE_OK {
+		return &provider.InitiateFileUploadResponse{
+			Status: uRes.Status,
+		}, nil
+	}
+
+	protocols := make([]*provider.FileUploadProtocol, 0, len(uRes.Protocols))
+	for p := range uRes.Protocols {
+		if !strings.HasSuffix(uRes.Protocols[p].UploadEndpoint, "/") {
+			uRes.Protocols[p].UploadEndpoint += "/"
+		}
+		uRes.Protocols[p].UploadEndpoint += uRes.Protocols[p].Token
+
+		protocols = append(protocols, &provider.FileUploadProtocol{
+			Opaque:             uRes.Protocols[p].Opaque,
+			Protocol:           uRes.Protocols[p].Protocol,
+			UploadEndpoint:     uRes.Protocols[p].UploadEndpoint,
+			AvailableChecksums: uRes.Protocols[p].AvailableChecksums,
+			Expose:             true, // the gateway already has encoded the upload endpoint
+		})
+	}
+
+	return &provider.InitiateFileUploadResponse{
+		Status:    uRes.Status,
+		Protocols: protocols,
+	}, nil
+}
+
+// ListStorageSpaces lists the single space holding the shares of the user.
+func (s *service) ListStorageSpaces(ctx context.Context, req *provider.ListStorageSpacesRequest) (*provider.ListStorageSpacesResponse, error) {
+	for _, f := range req.Filters {
+		switch f.Type {
+		case provider.ListStorageSpacesRequest_Filter_TYPE_ID:
+			if f.GetId().GetOpaqueId() != s.conf.MountID {
+				return &provider.ListStorageSpacesResponse{Status: status.NewOK(ctx)}, nil
+			}
+		case provider.ListStorageSpacesRequest_Filter_TYPE_SPACE_TYPE:
+			if f.GetSpaceType() != spaceTypeShare {
+				return &provider.ListStorageSpacesResponse{Status: status.NewOK(ctx)}, nil
+			}
+		}
+	}
+
+	info, st, err := s.statRoot(ctx)
+	switch {
+	case err != nil:
+		return &provider.ListStorageSpacesResponse{
+			Status: status.NewInternal(ctx, err, "sharesstorageprovider: error stating the shares"),
+		}, nil
+	case st.Code != rpc.Code_CODE_OK:
+		return &provider.ListStorageSpacesResponse{Status: st}, nil
+	}
+
+	space := &provider.StorageSpace{
+		Id:        &provider.StorageSpaceId{OpaqueId: s.conf.MountID},
+		Root:      info.Id,
+		Name:      path.Base(s.conf.MountPath),
+		SpaceType: spaceTypeShare,
+		Mtime:     info.Mtime,
+	}
+	if u, ok := user.ContextGetUser(ctx); ok {
+		space.Owner = u
+	}
+	return &provider.ListStorageSpacesResponse{
+		Status:        status.NewOK(ctx),
+		StorageSpaces: []*provider.StorageSpace{space},
+	}, nil
+}
+
+// resolve splits a path reference into the name of the mount point and the
+// path relative to the shared resource, and looks up the share mounted
+// under the name. The name is empty for the root of the storage, the share
+// nil when no share is mounted under the name.
+func (s *service) resolve(ctx context.Context, ref *provider.Reference) (string, string, *mount, *rpc.Status, error) {
+	if ref.GetPath() == "" {
+		return "", "", nil, nil, errtypes.BadRequest("need path based ref: got " + ref.String())
+	}
+	fn := ref.GetPath()
+	if fn != s.conf.MountPath && !strings.HasPrefix(fn, s.conf.MountPath+"/") {
+		return "", "", nil, nil, errtypes.BadRequest("path " + fn + " does not belong to this storage provider mount path " + s.conf.MountPath)
+	}
+
+	parts := strings.SplitN(strings.Trim(strings.TrimPrefix(fn, s.conf.MountPath), "/"), "/", 2)
+	name := parts[0]
+	if name == "" {
+		return "", "", nil, nil, nil
+	}
+	relativePath := ""
+	if len(parts) > 1 {
+		relativePath = path.Clean(parts[1])
+		if relativePath == "." {
+			relativePath = ""
+		}
+	}
+
+	mounts, st, err := s.listMounts(ctx)
+	if err != nil || st != nil {
+		return "", "", nil, st, err
+	}
+	for _, m := range mounts {
+		if m.name == name {
+			return name, relativePath, m, nil, nil
+		}
+	}
+	return name, relativePath, nil, nil, nil
+}
+
+// forwardRef translates the reference of a resource inside a share into
+// the reference of the resource in the storage of the share.
+func (s *service) forwardRef(ctx context.Context, ref *provider.Reference, op string) (*provider.Reference, *rpc.Status, error) {
+	name, relativePath, m, st, err := s.resolve(ctx, ref)
+	switch {
+	case err != nil:
+		return nil, status.NewStatusFromErrType(ctx, "sharesstorageprovider: error resolving ref "+ref.String(), err), nil
+	case st != nil:
+		return nil, st, nil
+	case name == "":
+		return nil, status.NewPermissionDenied(ctx, nil, "sharesstorageprovider: "+op+" is not allowed on the shares folder"), nil
+	case m == nil:
+		if op == "CreateContainer" || op == "InitiateFileUpload" {
+			return nil, status.NewPermissionDenied(ctx, nil, "sharesstorageprovider: resources can only be created inside the shares"), nil
+		}
+		return nil, status.NewNotFound(ctx, "sharesstorageprovider: no share mounted as "+name), nil
+	}
+	return s.targetRef(ctx, m, relativePath)
+}
+
+// targetRef returns the reference to the path relative to the resource of
+// a share.
+func (s *service) targetRef(ctx context.Context, m *mount, relativePath string) (*provider.Reference, *rpc.Status, error) {
+	if relativePath == "" {
+		return &provider.Reference{
+			Spec: &provider.Reference_Id{Id: m.share.Share.ResourceId},
+		}, nil, nil
+	}
+
+	pathRes, err := s.gateway.GetPath(ctx, &provider.GetPathRequest{ResourceId: m.share.Share.ResourceId})
+	switch {
+	case err != nil:
+		return nil, nil, err
+	case pathRes.Status.Code != rpc.Code_CODE_OK:
+		return nil, pathRes.Status, nil
+	}
+	return &provider.Reference{
+		Spec: &provider.Reference_Path{Path: path.Join(pathRes.Path, relativePath)},
+	}, nil, nil
+}
+
+// listMounts returns the accepted shares received by the user along with
+// their mount points, sorted by name.
+func (s *service) listMounts(ctx context.Context) ([]*mount, *rpc.Status, error) {
+	u, ok := user.ContextGetUser(ctx)
+	if !ok {
+		return nil, nil, errtypes.UserRequired("sharesstorageprovider: user not found in context")
+	}
+
+	lsRes, err := s.gateway.ListReceivedShares(ctx, &collaboration.ListReceivedSharesRequest{})
+	switch {
+	case err != nil:
+		return nil, nil, errors.Wrap(err, "sharesstorageprovider: error calling ListReceivedShares")
+	case lsRes.Status.Code != rpc.Code_CODE_OK:
+		return nil, lsRes.Status, nil
+	}
+
+	shares := map[string]*collaboration.ReceivedShare{}
+	ids := []string{}
+	for _, rs := range lsRes.Shares {
+		if rs.State != collaboration.ShareState_SHARE_STATE_ACCEPTED {
+			continue
+		}
+		id := rs.Share.Id.GetOpaqueId()
+		shares[id] = rs
+		ids = append(ids, id)
+	}
+	// name the oldest shares first, so that they keep the plain names
+	sort.SliceStable(ids, func(i, j int) bool {
+		return tsBefore(shares[ids[i]].Share.GetCtime(), shares[ids[j]].Share.GetCtime())
+	})
+
+	names, err := s.mountPoints.resolve(u.Id.GetOpaqueId(), ids, func(id string) (string, error) {
+		statRes, err := s.gateway.Stat(ctx, &provider.StatRequest{
+			Ref: &provider.Reference{Spec: &provider.Reference_Id{Id: shares[id].Share.ResourceId}},
+		})
+		switch {
+		case err != nil:
+			return "", err
+		case statRes.Status.Code != rpc.Code_CODE_OK:
+			return "", status.NewErrorFromCode(statRes.Status.Code, "sharesstorageprovider")
+		}
+		return path.Base(statRes.Info.Path), nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mounts := make([]*mount, 0, len(ids))
+	for _, id := range ids {
+		mounts = append(mounts, &mount{name: names[id], share: shares[id]})
+	}
+	sort.Slice(mounts, func(i, j int) bool { return mounts[i].name < mounts[j].name })
+	return mounts, nil, nil
+}
+
+// statMounts stats the resources of the shares, skipping the ones which
+// cannot be stated, e.g. because they have been deleted.
+func (s *service) statMounts(ctx context.Context) ([]*provider.ResourceInfo, *rpc.Status, error) {
+	mounts, st, err := s.listMounts(ctx)
+	if err != nil || st != nil {
+		return nil, st, err
+	}
+
+	log := appctx.GetLogger(ctx)
+	infos := make([]*provider.ResourceInfo, 0, len(mounts))
+	for _, m := range mounts {
+		statRes, err := s.gateway.Stat(ctx, &provider.StatRequest{
+			Ref: &provider.Reference{Spec: &provider.Reference_Id{Id: m.share.Share.ResourceId}},
+		})
+		if err != nil || statRes.Status.Code != rpc.Code_CODE_OK {
+			log.Debug().Err(err).Interface("share", m.share.Share.Id).Msg("sharesstorageprovider: skipping share that cannot be stated")
+			continue
+		}
+		s.rewriteInfo(statRes.Info, m, "")
+		infos = append(infos, statRes.Info)
+	}
+	return infos, status.NewOK(ctx), nil
+}
+
+// statRoot returns the info of the root of the storage, whose etag and
+// mtime are derived from the resources of the shares.
+func (s *service) statRoot(ctx context.Context) (*provider.ResourceInfo, *rpc.Status, error) {
+	infos, st, err := s.statMounts(ctx)
+	if err != nil || st.Code != rpc.Code_CODE_OK {
+		return nil, st, err
+	}
+
+	info := &provider.ResourceInfo{
+		Type:     provider.ResourceType_RESOURCE_TYPE_CONTAINER,
+		Id:       &provider.ResourceId{StorageId: s.conf.MountID, OpaqueId: s.conf.MountID},
+		Path:     s.conf.MountPath,
+		MimeType: "httpd/unix-directory",
+		Etag:     etag.GenerateEtagFromResources(nil, infos),
+		Mtime:    &types.Timestamp{},
+		PermissionSet: &provider.ResourcePermissions{
+			GetPath:       true,
+			ListContainer: true,
+			Stat:          true,
+		},
+	}
+	if u, ok := user.ContextGetUser(ctx); ok {
+		info.Owner = u.Id
+	}
+	for _, i := range infos {
+		info.Size += i.Size
+		if tsBefore(info.Mtime, i.Mtime) {
+			info.Mtime = i.Mtime
+		}
+	}
+	return info, st, nil
+}
+
+// rewriteInfo presents the info of a resource inside a share as a resource
+// of the storage, restricted to the permissions granted by the share.
+func (s *service) rewriteInfo(info *provider.ResourceInfo, m *mount, relativePath string) {
+	info.Path = path.Join(s.conf.MountPath, m.name, relativePath)
+	if info.PermissionSet != nil && m.share.Share.GetPermissions().GetPermissions() != nil {
+		filterPermissions(info.PermissionSet, m.share.Share.Permissions.Permissions)
+	}
+}
+
+func (s *service) renameMount(ctx context.Context, m *mount, name string) *rpc.Status {
+	u := user.ContextMustGetUser(ctx)
+	if err := s.mountPoints.rename(u.Id.GetOpaqueId(), m.share.Share.Id.GetOpaqueId(), name); err != nil {
+		return status.NewStatusFromErrType(ctx, "sharesstorageprovider: error renaming share", err)
+	}
+	return status.NewOK(ctx)
+}
+
+func (s *service) rejectShare(ctx context.Context, m *mount) *rpc.Status {
+	res, err := s.gateway.UpdateReceivedShare(ctx, &collaboration.UpdateReceivedShareRequest{
+		Ref: &collaboration.ShareReference{
+			Spec: &collaboration.ShareReference_Id{Id: m.share.Share.Id},
+		},
+		Field: &collaboration.UpdateReceivedShareRequest_UpdateField{
+			Field: &collaboration.UpdateReceivedShareRequest_UpdateField_State{
+				State: collaboration.ShareState_SHARE_STATE_REJECTED,
+			},
+		},
+	})
+	switch {
+	case err != nil:
+		return status.NewInternal(ctx, err, "gateway: error calling UpdateReceivedShare")
+	case res.Status.Code != rpc.Code_CODE_OK:
+		return res.Status
+	}
+
+	u := user.ContextMustGetUser(ctx)
+	if err := s.mountPoints.remove(u.Id.GetOpaqueId(), m.share.Share.Id.GetOpaqueId()); err != nil {
+		appctx.GetLogger(ctx).Error().Err(err).Msg("sharesstorageprovider: error removing mount point")
+	}
+	return status.NewOK(ctx)
+}
+
+func tsBefore(a, b *types.Timestamp) bool {
+	if a.GetSeconds() != b.GetSeconds() {
+		return a.GetSeconds() < b.GetSeconds()
+	}
+	return a.GetNanos() < b.GetNanos()
+}
+
+func filterPermissions(l *provider.ResourcePermissions, r *provider.ResourcePermissions) {
+	l.AddGrant = l.AddGrant && r.AddGrant
+	l.CreateContainer = l.CreateContainer && r.CreateContainer
+	l.Delete = l.Delete && r.Delete
+	l.GetPath = l.GetPath && r.GetPath
+	l.GetQuota = l.GetQuota && r.GetQuota
+	l.InitiateFileDownload = l.InitiateFileDownload && r.InitiateFileDownload
+	l.InitiateFileUpload = l.InitiateFileUpload && r.InitiateFileUpload
+	l.ListContainer = l.ListContainer && r.ListContainer
+	l.ListFileVersions = l.ListFileVersions && r.ListFileVersions
+	l.ListGrants = l.ListGrants && r.ListGrants
+	l.ListRecycle = l.ListRecycle && r.ListRecycle
+	l.Move = l.Move && r.Move
+	l.PurgeRecycle = l.PurgeRecycle && r.PurgeRecycle
+	l.RemoveGrant = l.RemoveGrant && r.RemoveGrant
+	l.RestoreFileVersion = l.RestoreFileVersion && r.RestoreFileVersion
+	l.RestoreRecycleItem = l.RestoreRecycleItem && r.RestoreRecycleItem
+	l.Stat = l.Stat && r.Stat
+	l.UpdateGrant = l.UpdateGrant && r.UpdateGrant
+}
+
+func (s *service) SetArbitraryMetadata(ctx context.Context, req *provider.SetArbitraryMetadataRequest) (*provider.SetArbitraryMetadataResponse, error) {
+	return nil, gstatus.Errorf(codes.Unimplemented, "method not implemented")
+}
+
+func (s *service) UnsetArbitraryMetadata(ctx context.Context, req *provider.UnsetArbitraryMetadataRequest) (*provider.UnsetArbitraryMetadataResponse, error) {
+	return nil, gstatus.Errorf(codes.Unimplemented, "method not implemented")
+}
+
+func (s *service) GetPath(ctx context.Context, req *provider.GetPathRequest) (*provider.GetPathResponse, error) {
+	return nil, gstatus.Errorf(codes.Unimplemented, "method not implemented")
+}
+
+func (s *service) GetHome(ctx context.Context, req *provider.GetHomeRequest) (*provider.GetHomeResponse, error) {
+	return nil, gstatus.Errorf(codes.Unimplemented, "method not implemented")
+}
+
+func (s *service) CreateHome(ctx context.Context, req *provider.CreateHomeRequest) (*provider.CreateHomeResponse, error) {
+	return nil, gstatus.Errorf(codes.Unimplemented, "method not implemented")
+}
+
+func (s *service) CreateStorageSpace(ctx context.Context, req *provider.CreateStorageSpaceRequest) (*provider.CreateStorageSpaceResponse, error) {
+	return nil, gstatus.Errorf(codes.Unimplemented, "method not implemented")
+}
+
+func (s *service) UpdateStorageSpace(ctx context.Context, req *provider.UpdateStorageSpaceRequest) (*provider.UpdateStorageSpaceResponse, error) {
+	return nil, gstatus.Errorf(codes.Unimplemented, "method not implemented")
+}
+
+func (s *service) DeleteStorageSpace(ctx context.Context, req *provider.DeleteStorageSpaceRequest) (*provider.DeleteStorageSpaceResponse, error) {
+	return nil, gstatus.Errorf(codes.Unimplemented, "method not implemented")
+}
+
+func (s *service) ListFileVersions(ctx context.Context, req *provider.ListFileVersionsRequest) (*provider.ListFileVersionsResponse, error) {
+	return nil, gstatus.Errorf(codes.Unimplemented, "method not implemented")
+}
+
+func (s *service) RestoreFileVersion(ctx context.Context, req *provider.RestoreFileVersionRequest) (*provider.RestoreFileVersionResponse, error) {
+	return nil, gstatus.Errorf(codes.Unimplemented, "method not implemented")
+}
+
+func (s *service) ListRecycleStream(req *provider.ListRecycleStreamRequest, ss provider.ProviderAPI_ListRecycleStreamServer) error {
+	return gstatus.Errorf(codes.Unimplemented, "method not implemented")
+}
+
+func (s *service) ListRecycle(ctx context.Context, req *provider.ListRecycleRequest) (*provider.ListRecycleResponse, error) {
+	return nil, gstatus.Errorf(codes.Unimplemented, "method not implemented")
+}
+
+func (s *service) RestoreRecycleItem(ctx context.Context, req *provider.RestoreRecycleItemRequest) (*provider.RestoreRecycleItemResponse, error) {
+	return nil, gstatus.Errorf(codes.Unimplemented, "method not implemented")
+}
+
+func (s *service) PurgeRecycle(ctx context.Context, req *provider.PurgeRecycleRequest) (*provider.PurgeRecycleResponse, error) {
+	return nil, gstatus.Errorf(codes.Unimplemented, "method not implemented")
+}
+
+func (s *service) ListGrants(ctx context.Context, req *provider.ListGrantsRequest) (*provider.ListGrantsResponse, error) {
+	return nil, gstatus.Errorf(codes.Unimplemented, "method not implemented")
+}
+
+func (s *service) AddGrant(ctx context.Context, req *provider.AddGrantRequest) (*provider.AddGrantResponse, error) {
+	return nil, gstatus.Errorf(codes.Unimplemented, "method not implemented")
+}
+
+func (s *service) CreateReference(ctx context.Context, req *provider.CreateReferenceRequest) (*provider.CreateReferenceResponse, error) {
+	return nil, gstatus.Errorf(codes.Unimplemented, "method not implemented")
+}
+
+func (s *service) CreateSymlink(ctx context.Context, req *provider.CreateSymlinkRequest) (*provider.CreateSymlinkResponse, error) {
+	return nil, gstatus.Errorf(codes.Unimplemented, "method not implemented")
+}
+
+func (s *service) UpdateGrant(ctx context.Context, req *provider.UpdateGrantRequest) (*provider.UpdateGrantResponse, error) {
+	return nil, gstatus.Errorf(codes.Unimplemented, "method not implemented")
+}
+
+func (s *service) RemoveGrant(ctx context.Context, req *provider.RemoveGrantRequest) (*provider.RemoveGrantResponse, error) {
+	return nil, gstatus.Errorf(codes.Unimplemented, "method not implemented")
+}
+
+func (s *service) GetQuota(ctx context.Context, req *provider.GetQuotaRequest) (*provider.GetQuotaResponse, error) {
+	return nil, gstatus.Errorf(codes.Unimplemented, "method not implemented")
+}
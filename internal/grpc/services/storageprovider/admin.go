@@ -0,0 +1,107 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package storageprovider
+
+import (
+	"context"
+	"time"
+
+	adminpb "github.com/cs3org/reva/internal/grpc/services/adminprovider/proto"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/permission"
+	"github.com/cs3org/reva/pkg/storage"
+	ctxpkg "github.com/cs3org/reva/pkg/user"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+)
+
+// RecalculateTreeSize lets the admins fix the tree size of a space.
+func (s *service) RecalculateTreeSize(ctx context.Context, req *adminpb.RecalculateTreeSizeRequest) (*adminpb.RecalculateTreeSizeResponse, error) {
+	if !s.isAdmin(ctx) {
+		return nil, grpcstatus.Error(codes.PermissionDenied, "not allowed to recalculate tree sizes")
+	}
+	tr, ok := s.storage.(storage.TreeSizeRecalculator)
+	if !ok {
+		return nil, grpcstatus.Error(codes.Unimplemented, "the storage driver does not account tree sizes")
+	}
+	size, err := tr.RecalculateTreeSize(ctx, req.SpaceId)
+	if err != nil {
+		return nil, adminError(err)
+	}
+	s.log.Info().Str("space", req.SpaceId).Uint64("size", size).Msg("storageprovider: recalculated tree size")
+	return &adminpb.RecalculateTreeSizeResponse{Size: size}, nil
+}
+
+// PurgeTrash lets the admins purge the recycle bins on demand, regardless of
+// the retention policy.
+func (s *service) PurgeTrash(ctx context.Context, req *adminpb.PurgeTrashRequest) (*adminpb.PurgeTrashResponse, error) {
+	if !s.isAdmin(ctx) {
+		return nil, grpcstatus.Error(codes.PermissionDenied, "not allowed to purge the recycle bins")
+	}
+	rp, ok := s.storage.(storage.RecyclePurger)
+	if !ok {
+		return nil, grpcstatus.Error(codes.Unimplemented, "the storage driver does not support purging recycle bins")
+	}
+
+	bins := []string{req.RecycleBinId}
+	if req.RecycleBinId == "" {
+		var err error
+		if bins, err = rp.ListRecycleBins(ctx); err != nil {
+			return nil, adminError(err)
+		}
+	}
+	before := time.Now().AddDate(0, 0, -int(req.OlderThanDays))
+	res := &adminpb.PurgeTrashResponse{}
+	for _, id := range bins {
+		items, bytes, err := rp.PurgeRecycleBin(ctx, id, before)
+		res.Purged += uint64(items)
+		res.ReclaimedBytes += bytes
+		if err != nil {
+			return nil, adminError(err)
+		}
+	}
+	s.log.Info().Int("recycle_bins", len(bins)).Uint64("items", res.Purged).Uint64("bytes", res.ReclaimedBytes).Msg("storageprovider: purged recycle bins")
+	return res, nil
+}
+
+// isAdmin checks the users running the admin tasks the way the adminprovider
+// does, rather than against the space admins.
+func (s *service) isAdmin(ctx context.Context) bool {
+	u, ok := ctxpkg.ContextGetUser(ctx)
+	if !ok {
+		return false
+	}
+	allowed, err := permission.IsAdmin(ctx, s.pm, u, s.conf.Admins, s.conf.AdminGroups)
+	if err != nil {
+		s.log.Error().Err(err).Msg("storageprovider: error checking permission")
+	}
+	return allowed
+}
+
+func adminError(err error) error {
+	switch err.(type) {
+	case errtypes.IsNotFound:
+		return grpcstatus.Error(codes.NotFound, err.Error())
+	case errtypes.IsBadRequest:
+		return grpcstatus.Error(codes.InvalidArgument, err.Error())
+	case errtypes.IsNotSupported:
+		return grpcstatus.Error(codes.Unimplemented, err.Error())
+	}
+	return grpcstatus.Error(codes.Internal, err.Error())
+}
@@ -0,0 +1,115 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package storageprovider
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	adminpb "github.com/cs3org/reva/internal/grpc/services/adminprovider/proto"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/storage"
+	ctxpkg "github.com/cs3org/reva/pkg/user"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type adminFS struct {
+	storage.FS
+	sizes  map[string]uint64
+	bins   map[string]int
+	before time.Time
+}
+
+func (fs *adminFS) RecalculateTreeSize(ctx context.Context, id string) (uint64, error) {
+	size, ok := fs.sizes[id]
+	if !ok {
+		return 0, errtypes.NotFound(id)
+	}
+	return size, nil
+}
+
+func (fs *adminFS) ListRecycleBins(ctx context.Context) ([]string, error) {
+	ids := []string{}
+	for id := range fs.bins {
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func (fs *adminFS) PurgeRecycleBin(ctx context.Context, id string, before time.Time) (int, uint64, error) {
+	items, ok := fs.bins[id]
+	if !ok {
+		return 0, 0, errtypes.NotFound(id)
+	}
+	fs.before = before
+	return items, uint64(items) * 10, nil
+}
+
+func TestAdminTasks(t *testing.T) {
+	log := zerolog.Nop()
+	admin := ctxpkg.ContextSetUser(context.Background(), &userpb.User{Username: "admin"})
+	spaceAdmin := ctxpkg.ContextSetUser(context.Background(), &userpb.User{Username: "manager"})
+	conf := &config{Admins: []string{"admin"}, SpaceAdmins: []string{"manager"}}
+	fs := &adminFS{sizes: map[string]uint64{"space": 42}, bins: map[string]int{"einstein": 2, "marie": 3}}
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		fs     storage.FS
+		treeID string
+		binID  string
+		size   uint64
+		purged uint64
+		code   codes.Code
+	}{
+		{"admin", admin, fs, "space", "", 42, 5, codes.OK},
+		{"one recycle bin", admin, fs, "space", "marie", 42, 3, codes.OK},
+		{"unknown space and recycle bin", admin, fs, "other", "other", 0, 0, codes.NotFound},
+		{"space admin", spaceAdmin, fs, "space", "", 0, 0, codes.PermissionDenied},
+		{"no user", context.Background(), fs, "space", "", 0, 0, codes.PermissionDenied},
+		{"unsupported driver", admin, struct{ storage.FS }{}, "space", "", 0, 0, codes.Unimplemented},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &service{conf: conf, storage: tt.fs, log: &log}
+
+			size, err := s.RecalculateTreeSize(tt.ctx, &adminpb.RecalculateTreeSizeRequest{SpaceId: tt.treeID})
+			if code := status.Code(err); code != tt.code {
+				t.Errorf("RecalculateTreeSize: got %s, wanted %s", code, tt.code)
+			} else if err == nil && size.Size != tt.size {
+				t.Errorf("RecalculateTreeSize: got %d, wanted %d", size.Size, tt.size)
+			}
+
+			purged, err := s.PurgeTrash(tt.ctx, &adminpb.PurgeTrashRequest{RecycleBinId: tt.binID, OlderThanDays: 7})
+			if code := status.Code(err); code != tt.code {
+				t.Errorf("PurgeTrash: got %s, wanted %s", code, tt.code)
+			} else if err == nil && (purged.Purged != tt.purged || purged.ReclaimedBytes != tt.purged*10) {
+				t.Errorf("PurgeTrash: got %d items and %d bytes, wanted %d items", purged.Purged, purged.ReclaimedBytes, tt.purged)
+			}
+		})
+	}
+
+	if age := time.Since(fs.before); age < 7*24*time.Hour || age > 8*24*time.Hour {
+		t.Errorf("the items deleted %s ago were purged, wanted 7 days", age)
+	}
+}
@@ -0,0 +1,111 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package storageprovider
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	batchpb "github.com/cs3org/reva/internal/grpc/services/storageprovider/proto"
+	"github.com/cs3org/reva/pkg/rgrpc/status"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+)
+
+// MaxBatchSize is the maximum number of resources of a batch call.
+const MaxBatchSize = 1000
+
+// batchConcurrency is the number of resources of a batch handled at once.
+const batchConcurrency = 16
+
+// StatMany stats the resources with stat, which is the Stat of the storage
+// provider or of the gateway.
+func StatMany(ctx context.Context, req *batchpb.StatManyRequest, stat func(context.Context, *provider.StatRequest) (*provider.StatResponse, error)) (*batchpb.StatManyResponse, error) {
+	if len(req.Refs) > MaxBatchSize {
+		return nil, grpcstatus.Error(codes.InvalidArgument, "too many resources, the maximum is "+strconv.Itoa(MaxBatchSize))
+	}
+	results := make([]*batchpb.StatResult, len(req.Refs))
+	runBatch(len(req.Refs), func(i int) {
+		res, err := stat(ctx, &provider.StatRequest{Ref: req.Refs[i], ArbitraryMetadataKeys: req.ArbitraryMetadataKeys})
+		switch {
+		case err != nil:
+			results[i] = &batchpb.StatResult{Status: status.NewInternal(ctx, err, "error stating "+req.Refs[i].String())}
+		case res.Status.Code != rpc.Code_CODE_OK:
+			results[i] = &batchpb.StatResult{Status: res.Status}
+		default:
+			results[i] = &batchpb.StatResult{Status: res.Status, Info: res.Info}
+		}
+	})
+	return &batchpb.StatManyResponse{Results: results}, nil
+}
+
+// AddGrantBatch adds the grants with addGrant, which is the AddGrant of the
+// storage provider or of the gateway.
+func AddGrantBatch(ctx context.Context, req *batchpb.AddGrantBatchRequest, addGrant func(context.Context, *provider.AddGrantRequest) (*provider.AddGrantResponse, error)) (*batchpb.AddGrantBatchResponse, error) {
+	if len(req.Items) > MaxBatchSize {
+		return nil, grpcstatus.Error(codes.InvalidArgument, "too many grants, the maximum is "+strconv.Itoa(MaxBatchSize))
+	}
+	statuses := make([]*rpc.Status, len(req.Items))
+	runBatch(len(req.Items), func(i int) {
+		item := req.Items[i]
+		if item.Grant.GetGrantee() == nil {
+			statuses[i] = status.NewInvalidArg(ctx, "missing grantee")
+			return
+		}
+		res, err := addGrant(ctx, &provider.AddGrantRequest{Ref: item.Ref, Grant: item.Grant})
+		if err != nil {
+			statuses[i] = status.NewInternal(ctx, err, "error adding grant to "+item.Ref.String())
+			return
+		}
+		statuses[i] = res.Status
+	})
+	return &batchpb.AddGrantBatchResponse{Statuses: statuses}, nil
+}
+
+// runBatch calls f for the indexes from 0 to n-1, batchConcurrency of them
+// at a time.
+func runBatch(n int, f func(i int)) {
+	sem := make(chan struct{}, batchConcurrency)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			f(i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// StatMany returns the metadata of the resources of the storage provider.
+func (s *service) StatMany(ctx context.Context, req *batchpb.StatManyRequest) (*batchpb.StatManyResponse, error) {
+	return StatMany(ctx, req, s.Stat)
+}
+
+// AddGrantBatch adds the grants to the resources of the storage provider.
+func (s *service) AddGrantBatch(ctx context.Context, req *batchpb.AddGrantBatchRequest) (*batchpb.AddGrantBatchResponse, error) {
+	return AddGrantBatch(ctx, req, s.AddGrant)
+}
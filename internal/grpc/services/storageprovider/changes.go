@@ -0,0 +1,63 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+package storageprovider
+
+import (
+	"context"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/events"
+	ctxpkg "github.com/cs3org/reva/pkg/user"
+)
+
+// The storage provider publishes the changes of the resources to the events
+// bus when one is configured, from which the change logs of the users are
+// materialized. The resources are stated to resolve their id, owner and path
+// in the global namespace, as the requests may only carry one of them.
+
+// statChanged returns the resource a change is about to be published for, or
+// nil when the changes are not published.
+func (s *service) statChanged(ctx context.Context, ref *provider.Reference) *provider.ResourceInfo {
+	if s.stream == nil {
+		return nil
+	}
+	md, err := s.storage.GetMD(ctx, ref, []string{})
+	if err != nil {
+		appctx.GetLogger(ctx).Debug().Err(err).Interface("ref", ref).Msg("storageprovider: change not published, error stating resource")
+		return nil
+	}
+	if err := s.wrap(ctx, md); err != nil {
+		return nil
+	}
+	return md
+}
+
+func (s *service) publishChange(ctx context.Context, ev interface{}) {
+	if err := events.Publish(ctx, s.stream, ev); err != nil {
+		appctx.GetLogger(ctx).Error().Err(err).Msg("storageprovider: error publishing change")
+	}
+}
+
+func executant(ctx context.Context) *userpb.UserId {
+	if u, ok := ctxpkg.ContextGetUser(ctx); ok {
+		return u.Id
+	}
+	return nil
+}
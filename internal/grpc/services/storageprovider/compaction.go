@@ -0,0 +1,170 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package storageprovider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	revisionspb "github.com/cs3org/reva/internal/grpc/services/storageprovider/proto"
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/permission"
+	"github.com/cs3org/reva/pkg/storage"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/tag"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+)
+
+// revisionPolicy tells which revisions of a file are kept, the others being
+// deleted by the compaction. A revision is kept when any rule keeps it.
+type revisionPolicy struct {
+	// KeepLast keeps the given number of most recent revisions.
+	KeepLast int `mapstructure:"keep_last"`
+	// KeepDaily keeps the most recent revision of each of the given number of
+	// last days.
+	KeepDaily int `mapstructure:"keep_daily"`
+	// KeepWeekly keeps the most recent revision of each of the given number
+	// of last weeks.
+	KeepWeekly int `mapstructure:"keep_weekly"`
+	// KeepMonthly keeps the most recent revision of each of the given number
+	// of last months.
+	KeepMonthly int `mapstructure:"keep_monthly"`
+}
+
+func (p *revisionPolicy) enabled() bool {
+	return p.KeepLast > 0 || p.KeepDaily > 0 || p.KeepWeekly > 0 || p.KeepMonthly > 0
+}
+
+// expired returns the revisions not kept by the policy.
+func (p *revisionPolicy) expired(revisions []*provider.FileVersion, now time.Time) []*provider.FileVersion {
+	sorted := make([]*provider.FileVersion, len(revisions))
+	copy(sorted, revisions)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Mtime > sorted[j].Mtime })
+
+	// each rule keeps the most recent revision of its periods
+	type rule struct {
+		since  time.Time
+		period func(t time.Time) string
+		seen   map[string]bool
+	}
+	rules := []*rule{}
+	if p.KeepDaily > 0 {
+		rules = append(rules, &rule{
+			since:  now.AddDate(0, 0, -p.KeepDaily),
+			period: func(t time.Time) string { return t.Format("2006-01-02") },
+			seen:   map[string]bool{},
+		})
+	}
+	if p.KeepWeekly > 0 {
+		rules = append(rules, &rule{
+			since: now.AddDate(0, 0, -7*p.KeepWeekly),
+			period: func(t time.Time) string {
+				y, w := t.ISOWeek()
+				return fmt.Sprintf("%d-%d", y, w)
+			},
+			seen: map[string]bool{},
+		})
+	}
+	if p.KeepMonthly > 0 {
+		rules = append(rules, &rule{
+			since:  now.AddDate(0, -p.KeepMonthly, 0),
+			period: func(t time.Time) string { return t.Format("2006-01") },
+			seen:   map[string]bool{},
+		})
+	}
+
+	expired := []*provider.FileVersion{}
+	for i, rev := range sorted {
+		keep := i < p.KeepLast
+		t := time.Unix(int64(rev.Mtime), 0).UTC()
+		for _, r := range rules {
+			if t.Before(r.since) {
+				continue
+			}
+			if period := r.period(t); !r.seen[period] {
+				r.seen[period] = true
+				keep = true
+			}
+		}
+		if !keep {
+			expired = append(expired, rev)
+		}
+	}
+	return expired
+}
+
+// compactRevisions deletes the revisions not kept by the revision retention
+// policy and returns the number of deleted revisions and reclaimed bytes.
+func (s *service) compactRevisions(ctx context.Context, rp storage.RevisionPruner) (uint64, uint64, error) {
+	s.compactionMu.Lock()
+	defer s.compactionMu.Unlock()
+
+	log := appctx.GetLogger(ctx)
+	now := time.Now()
+	var pruned, reclaimed uint64
+	err := rp.WalkRevisions(ctx, func(revisions []*provider.FileVersion) error {
+		for _, rev := range s.conf.RevisionRetention.expired(revisions, now) {
+			if err := rp.DeleteRevision(ctx, rev.Key); err != nil {
+				if _, ok := err.(errtypes.IsNotFound); !ok {
+					log.Error().Err(err).Str("revision", rev.Key).Msg("storageprovider: error deleting revision")
+				}
+				continue
+			}
+			pruned++
+			reclaimed += rev.Size
+		}
+		return nil
+	})
+
+	if pruned > 0 {
+		if tctx, terr := tag.New(ctx, tag.Upsert(mountKey, s.mountID)); terr == nil {
+			stats.Record(tctx, prunedRevisions.M(int64(pruned)), revisionReclaimedBytes.M(int64(reclaimed)))
+		}
+		log.Info().Uint64("revisions", pruned).Uint64("bytes", reclaimed).Msg("storageprovider: deleted expired revisions")
+	}
+	return pruned, reclaimed, err
+}
+
+// PruneRevisions lets the admins compact the revisions on demand.
+func (s *service) PruneRevisions(ctx context.Context, req *revisionspb.PruneRevisionsRequest) (*revisionspb.PruneRevisionsResponse, error) {
+	if !s.hasCapability(ctx, permission.ManageRevisions) {
+		return nil, grpcstatus.Error(codes.PermissionDenied, "not allowed to prune revisions")
+	}
+	if !s.conf.RevisionRetention.enabled() {
+		return nil, grpcstatus.Error(codes.FailedPrecondition, "no revision retention policy configured")
+	}
+	rp, ok := s.storage.(storage.RevisionPruner)
+	if !ok {
+		return nil, grpcstatus.Error(codes.Unimplemented, "the storage driver does not support deleting revisions")
+	}
+
+	pruned, reclaimed, err := s.compactRevisions(ctx, rp)
+	if err != nil {
+		if _, ok := err.(errtypes.IsNotSupported); ok {
+			return nil, grpcstatus.Error(codes.Unimplemented, err.Error())
+		}
+		return nil, grpcstatus.Error(codes.Internal, err.Error())
+	}
+	return &revisionspb.PruneRevisionsResponse{Pruned: pruned, ReclaimedBytes: reclaimed}, nil
+}
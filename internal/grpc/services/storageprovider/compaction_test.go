@@ -0,0 +1,74 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package storageprovider
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+)
+
+func TestRevisionPolicyExpired(t *testing.T) {
+	now := time.Date(2021, 6, 30, 12, 0, 0, 0, time.UTC)
+	rev := func(key string, age time.Duration) *provider.FileVersion {
+		return &provider.FileVersion{Key: key, Mtime: uint64(now.Add(-age).Unix())}
+	}
+	day := 24 * time.Hour
+	revisions := []*provider.FileVersion{
+		rev("1h", time.Hour),
+		rev("2h", 2*time.Hour),
+		rev("1d", day),
+		rev("1d1h", day+time.Hour),
+		rev("3d", 3*day),
+		rev("40d", 40*day),
+		rev("70d", 70*day),
+	}
+
+	tests := []struct {
+		name    string
+		policy  revisionPolicy
+		expired []string
+	}{
+		{"keep last", revisionPolicy{KeepLast: 2}, []string{"1d", "1d1h", "3d", "40d", "70d"}},
+		{"keep daily", revisionPolicy{KeepDaily: 2}, []string{"2h", "1d1h", "3d", "40d", "70d"}},
+		{"keep monthly", revisionPolicy{KeepMonthly: 2}, []string{"2h", "1d", "1d1h", "3d", "70d"}},
+		{"combined", revisionPolicy{KeepLast: 1, KeepDaily: 7, KeepMonthly: 3}, []string{"2h", "1d1h"}},
+	}
+	for _, tt := range tests {
+		var expired []string
+		for _, r := range tt.policy.expired(revisions, now) {
+			expired = append(expired, r.Key)
+		}
+		sort.Strings(expired)
+		want := append([]string{}, tt.expired...)
+		sort.Strings(want)
+		if len(expired) != len(want) {
+			t.Errorf("%s: expired %v, want %v", tt.name, expired, want)
+			continue
+		}
+		for i := range want {
+			if expired[i] != want[i] {
+				t.Errorf("%s: expired %v, want %v", tt.name, expired, want)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package storageprovider
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/cs3org/reva/pkg/storage"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+var (
+	mountKey = tag.MustNewKey("mount_id")
+
+	purgedItems    = stats.Int64("recycle_purged_items", "Number of recycle bin items purged by the retention janitor", stats.UnitDimensionless)
+	reclaimedBytes = stats.Int64("recycle_reclaimed_bytes", "Number of bytes reclaimed by the retention janitor", stats.UnitBytes)
+
+	prunedRevisions        = stats.Int64("revisions_pruned", "Number of revisions deleted by the revision compaction", stats.UnitDimensionless)
+	revisionReclaimedBytes = stats.Int64("revisions_reclaimed_bytes", "Number of bytes reclaimed by the revision compaction", stats.UnitBytes)
+
+	janitorViews = []*view.View{
+		{Name: "recycle_purged_items_total", Measure: purgedItems, Aggregation: view.Sum(), TagKeys: []tag.Key{mountKey}},
+		{Name: "recycle_reclaimed_bytes_total", Measure: reclaimedBytes, Aggregation: view.Sum(), TagKeys: []tag.Key{mountKey}},
+		{Name: "revisions_pruned_total", Measure: prunedRevisions, Aggregation: view.Sum(), TagKeys: []tag.Key{mountKey}},
+		{Name: "revisions_reclaimed_bytes_total", Measure: revisionReclaimedBytes, Aggregation: view.Sum(), TagKeys: []tag.Key{mountKey}},
+	}
+
+	registerJanitorViews sync.Once
+)
+
+// retentionEnabled tells whether the items of some recycle bin expire.
+func (c *config) retentionEnabled() bool {
+	if c.RecycleRetention > 0 {
+		return true
+	}
+	for _, days := range c.RecycleRetentionSpaces {
+		if days > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// recycleRetention returns the number of days the items of the given recycle
+// bin are kept, 0 meaning forever.
+func (c *config) recycleRetention(id string) int {
+	if days, ok := c.RecycleRetentionSpaces[id]; ok {
+		return days
+	}
+	return c.RecycleRetention
+}
+
+// purgeRecycleBins purges the expired items of the recycle bins.
+func (s *service) purgeRecycleBins(ctx context.Context, rp storage.RecyclePurger) error {
+	bins, err := rp.ListRecycleBins(ctx)
+	if err != nil {
+		return err
+	}
+
+	ctx, err = tag.New(ctx, tag.Upsert(mountKey, s.mountID))
+	if err != nil {
+		return err
+	}
+
+	var purgeErr error
+	now := time.Now()
+	for _, id := range bins {
+		days := s.conf.recycleRetention(id)
+		if days <= 0 {
+			continue
+		}
+		items, bytes, err := rp.PurgeRecycleBin(ctx, id, now.AddDate(0, 0, -days))
+		if items > 0 {
+			stats.Record(ctx, purgedItems.M(int64(items)), reclaimedBytes.M(int64(bytes)))
+			s.log.Info().Str("recycle_bin", id).Int("items", items).Uint64("bytes", bytes).Msg("storageprovider: purged expired recycle items")
+		}
+		if err != nil {
+			s.log.Error().Err(err).Str("recycle_bin", id).Msg("storageprovider: error purging recycle bin")
+			purgeErr = err
+		}
+	}
+	return purgeErr
+}
+
+// collectBlobs deletes the deduplicated blobs which are not referenced
+// anymore.
+func (s *service) collectBlobs(ctx context.Context, bd storage.BlobDeduplicator) error {
+	collected, err := bd.CollectBlobs(ctx)
+	if collected > 0 {
+		s.log.Info().Int("blobs", collected).Msg("storageprovider: deleted unreferenced blobs")
+	}
+	return err
+}
+
+// tierBlobs moves the content which was not accessed to the cold storage.
+func (s *service) tierBlobs(ctx context.Context, bt storage.BlobTierer) error {
+	moved, err := bt.TierBlobs(ctx)
+	if moved > 0 {
+		s.log.Info().Int("blobs", moved).Msg("storageprovider: moved blobs to the cold storage")
+	}
+	return err
+}
+
+// purgeUploads deletes the uploads the clients gave up on.
+func (s *service) purgeUploads(ctx context.Context, up storage.UploadPurger) error {
+	purged, err := up.PurgeExpiredUploads(ctx, time.Now().Add(-time.Duration(s.conf.UploadExpiration)*time.Second))
+	if purged > 0 {
+		s.log.Info().Int("uploads", purged).Msg("storageprovider: deleted expired uploads")
+	}
+	return err
+}
@@ -0,0 +1,162 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package storageprovider
+
+import (
+	"context"
+	"time"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	lockpb "github.com/cs3org/reva/internal/grpc/services/storageprovider/proto"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/storage"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+)
+
+// SetLock locks a resource, unless it is locked with another lock id.
+func (s *service) SetLock(ctx context.Context, req *lockpb.LockRequest) (*lockpb.LockResponse, error) {
+	locker, ref, err := s.lockerFor(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := locker.SetLock(ctx, ref, LockFromProto(req.Lock)); err != nil {
+		return nil, lockError(err)
+	}
+	return getLock(ctx, locker, ref)
+}
+
+// GetLock returns the lock of a resource.
+func (s *service) GetLock(ctx context.Context, req *lockpb.LockRequest) (*lockpb.LockResponse, error) {
+	locker, ref, err := s.lockerFor(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return getLock(ctx, locker, ref)
+}
+
+// RefreshLock replaces the lock of a resource, which must have the same lock id.
+func (s *service) RefreshLock(ctx context.Context, req *lockpb.LockRequest) (*lockpb.LockResponse, error) {
+	locker, ref, err := s.lockerFor(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := locker.RefreshLock(ctx, ref, LockFromProto(req.Lock)); err != nil {
+		return nil, lockError(err)
+	}
+	return getLock(ctx, locker, ref)
+}
+
+// Unlock removes the lock of a resource, which must have the same lock id.
+func (s *service) Unlock(ctx context.Context, req *lockpb.LockRequest) (*lockpb.LockResponse, error) {
+	locker, ref, err := s.lockerFor(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := locker.Unlock(ctx, ref, LockFromProto(req.Lock)); err != nil {
+		return nil, lockError(err)
+	}
+	return &lockpb.LockResponse{}, nil
+}
+
+func (s *service) lockerFor(ctx context.Context, req *lockpb.LockRequest) (storage.Locker, *provider.Reference, error) {
+	locker, ok := s.storage.(storage.Locker)
+	if !ok {
+		return nil, nil, grpcstatus.Error(codes.Unimplemented, "the storage driver does not support locks")
+	}
+	ref, err := s.unwrap(ctx, RefFromProto(req.Ref))
+	if err != nil {
+		return nil, nil, grpcstatus.Error(codes.InvalidArgument, err.Error())
+	}
+	return locker, ref, nil
+}
+
+func getLock(ctx context.Context, locker storage.Locker, ref *provider.Reference) (*lockpb.LockResponse, error) {
+	l, err := locker.GetLock(ctx, ref)
+	if err != nil {
+		return nil, lockError(err)
+	}
+	return &lockpb.LockResponse{Lock: LockToProto(l)}, nil
+}
+
+func lockError(err error) error {
+	switch errors.Cause(err).(type) {
+	case errtypes.IsNotFound:
+		return grpcstatus.Error(codes.NotFound, err.Error())
+	case errtypes.IsPermissionDenied:
+		return grpcstatus.Error(codes.PermissionDenied, err.Error())
+	case errtypes.IsLocked:
+		return grpcstatus.Error(codes.FailedPrecondition, err.Error())
+	case errtypes.IsBadRequest:
+		return grpcstatus.Error(codes.InvalidArgument, err.Error())
+	case errtypes.IsNotSupported:
+		return grpcstatus.Error(codes.Unimplemented, err.Error())
+	}
+	return grpcstatus.Error(codes.Internal, err.Error())
+}
+
+// RefFromProto returns the CS3 reference of a LockAPI reference.
+func RefFromProto(ref *lockpb.Reference) *provider.Reference {
+	if ref.GetOpaqueId() != "" {
+		return &provider.Reference{Spec: &provider.Reference_Id{Id: &provider.ResourceId{
+			StorageId: ref.GetStorageId(),
+			OpaqueId:  ref.GetOpaqueId(),
+		}}}
+	}
+	return &provider.Reference{Spec: &provider.Reference_Path{Path: ref.GetPath()}}
+}
+
+// LockFromProto converts a LockAPI lock, returning nil if it is unset.
+func LockFromProto(l *lockpb.Lock) *storage.Lock {
+	if l == nil {
+		return nil
+	}
+	lock := &storage.Lock{
+		LockID:  l.LockId,
+		Type:    l.Type,
+		AppName: l.AppName,
+	}
+	if l.UserOpaqueId != "" {
+		lock.User = &userpb.UserId{Idp: l.UserIdp, OpaqueId: l.UserOpaqueId}
+	}
+	if l.Expiration > 0 {
+		lock.Expiration = time.Unix(int64(l.Expiration), 0)
+	}
+	return lock
+}
+
+// LockToProto converts a lock to a LockAPI lock, returning nil if it is unset.
+func LockToProto(l *storage.Lock) *lockpb.Lock {
+	if l == nil {
+		return nil
+	}
+	lock := &lockpb.Lock{
+		LockId:       l.LockID,
+		Type:         l.Type,
+		UserIdp:      l.User.GetIdp(),
+		UserOpaqueId: l.User.GetOpaqueId(),
+		AppName:      l.AppName,
+	}
+	if !l.Expiration.IsZero() {
+		lock.Expiration = uint64(l.Expiration.Unix())
+	}
+	return lock
+}
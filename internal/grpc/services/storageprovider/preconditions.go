@@ -0,0 +1,171 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package storageprovider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash/fnv"
+	"sort"
+	"sync"
+
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/golang/protobuf/proto"
+)
+
+// The changes of the arbitrary metadata and of the grants of a resource can
+// carry, in their if_match opaque entry, the version of the metadata or of
+// the grants they were computed from, and are refused when the resource
+// changed in the meantime. The versions are hashes of the metadata and of
+// the grants, returned by Stat and ListGrants, rather than the etag, which
+// not all the drivers change on such updates.
+const (
+	ifMatchKey         = "if_match"
+	metadataVersionKey = "metadata_version"
+	grantsVersionKey   = "grants_version"
+)
+
+// ifMatch returns the version expected by the request, if any.
+func ifMatch(o *types.Opaque) (string, bool) {
+	e, ok := o.GetMap()[ifMatchKey]
+	if !ok || e.Decoder != "plain" {
+		return "", false
+	}
+	return string(e.Value), true
+}
+
+// setVersion adds the version to the opaque, which is created if needed. An
+// empty version, when it could not be computed, is not added.
+func setVersion(o *types.Opaque, key, version string) *types.Opaque {
+	if version == "" {
+		return o
+	}
+	if o == nil {
+		o = &types.Opaque{}
+	}
+	if o.Map == nil {
+		o.Map = map[string]*types.OpaqueEntry{}
+	}
+	o.Map[key] = &types.OpaqueEntry{Decoder: "plain", Value: []byte(version)}
+	return o
+}
+
+func metadataVersion(md *provider.ArbitraryMetadata) string {
+	m := md.GetMetadata()
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	h := sha256.New()
+	for _, k := range keys {
+		_, _ = h.Write([]byte(k))
+		_, _ = h.Write([]byte{0})
+		_, _ = h.Write([]byte(m[k]))
+		_, _ = h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func grantsVersion(grants []*provider.Grant) (string, error) {
+	encoded := make([]string, 0, len(grants))
+	for _, g := range grants {
+		buf := proto.NewBuffer(nil)
+		buf.SetDeterministic(true)
+		if err := buf.Marshal(g); err != nil {
+			return "", err
+		}
+		encoded = append(encoded, string(buf.Bytes()))
+	}
+	sort.Strings(encoded)
+	h := sha256.New()
+	for _, e := range encoded {
+		_, _ = h.Write([]byte(e))
+		_, _ = h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// lockResource serializes the changes of the metadata and of the grants of
+// a resource, for another change not to sneak in between the check of the
+// version and the change. It returns the function releasing the lock.
+//
+// The locks only serialize the calls handled by this process: the replicas
+// of the storage provider serving the same storage do not see each other's
+// locks, and the drivers are left to protect their own writes.
+func (s *service) lockResource(ctx context.Context, ref *provider.Reference) func() {
+	mu := s.resourceMutex(ctx, ref)
+	mu.Lock()
+	return mu.Unlock
+}
+
+// resourceMutex returns the mutex of the resource the reference points to.
+// The mutexes are keyed on the id of the resource, for a path and an id
+// reference to the same resource to share one, and on the reference itself
+// when the resource cannot be resolved, in which case the change will most
+// likely fail anyway.
+func (s *service) resourceMutex(ctx context.Context, ref *provider.Reference) *sync.Mutex {
+	key := ref.String()
+	id := ref.GetId()
+	if id == nil {
+		if md, err := s.storage.GetMD(ctx, ref, nil); err == nil {
+			id = md.Id
+		}
+	}
+	if id != nil {
+		key = id.StorageId + "!" + id.OpaqueId
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+	return &s.versionMu[h.Sum32()%uint32(len(s.versionMu))]
+}
+
+// checkMetadataVersion returns the current version of the metadata, and
+// fails with errtypes.PreconditionFailed when it is not the expected one.
+func (s *service) checkMetadataVersion(ctx context.Context, ref *provider.Reference, expected string) (string, error) {
+	md, err := s.storage.GetMD(ctx, ref, nil)
+	if err != nil {
+		return "", err
+	}
+	current := metadataVersion(md.ArbitraryMetadata)
+	if current != expected {
+		return current, errtypes.PreconditionFailed("the metadata changed, current version is " + current)
+	}
+	return current, nil
+}
+
+// checkGrantsVersion returns the current version of the grants, and fails
+// with errtypes.PreconditionFailed when it is not the expected one.
+func (s *service) checkGrantsVersion(ctx context.Context, ref *provider.Reference, expected string) (string, error) {
+	grants, err := s.storage.ListGrants(ctx, ref)
+	if err != nil {
+		return "", err
+	}
+	current, err := grantsVersion(grants)
+	if err != nil {
+		return "", err
+	}
+	if current != expected {
+		return current, errtypes.PreconditionFailed("the grants changed, current version is " + current)
+	}
+	return current, nil
+}
@@ -0,0 +1,100 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package storageprovider
+
+import (
+	"context"
+	"testing"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/storage"
+)
+
+func TestGrantsVersion(t *testing.T) {
+	grant := func(user string, stat bool) *provider.Grant {
+		return &provider.Grant{
+			Grantee: &provider.Grantee{
+				Type: provider.GranteeType_GRANTEE_TYPE_USER,
+				Id:   &provider.Grantee_UserId{UserId: &userpb.UserId{OpaqueId: user}},
+			},
+			Permissions: &provider.ResourcePermissions{Stat: stat},
+		}
+	}
+	a, b := grant("einstein", true), grant("marie", true)
+
+	v1, err := grantsVersion([]*provider.Grant{a, b})
+	if err != nil {
+		t.Fatal(err)
+	}
+	v2, _ := grantsVersion([]*provider.Grant{b, a})
+	if v1 != v2 {
+		t.Error("the version depends on the order of the grants")
+	}
+	v3, _ := grantsVersion([]*provider.Grant{a, grant("marie", false)})
+	if v1 == v3 {
+		t.Error("the version does not change with the permissions")
+	}
+}
+
+func TestMetadataVersion(t *testing.T) {
+	v1 := metadataVersion(&provider.ArbitraryMetadata{Metadata: map[string]string{"a": "b", "c": "d"}})
+	v2 := metadataVersion(&provider.ArbitraryMetadata{Metadata: map[string]string{"c": "d", "a": "b"}})
+	if v1 != v2 {
+		t.Error("the version is not stable")
+	}
+	// the separators keep the keys and values apart
+	v3 := metadataVersion(&provider.ArbitraryMetadata{Metadata: map[string]string{"ab": "", "c": "d"}})
+	if v1 == v3 {
+		t.Error("different metadata have the same version")
+	}
+	if metadataVersion(nil) == v1 {
+		t.Error("empty metadata has the version of non empty metadata")
+	}
+}
+
+type statFS struct {
+	storage.FS
+	ids map[string]*provider.ResourceId
+}
+
+func (fs statFS) GetMD(_ context.Context, ref *provider.Reference, _ []string) (*provider.ResourceInfo, error) {
+	if id, ok := fs.ids[ref.GetPath()]; ok {
+		return &provider.ResourceInfo{Id: id}, nil
+	}
+	return nil, errtypes.NotFound(ref.GetPath())
+}
+
+func TestResourceMutex(t *testing.T) {
+	id := &provider.ResourceId{StorageId: "storage", OpaqueId: "file"}
+	s := &service{storage: statFS{ids: map[string]*provider.ResourceId{"/file": id}}}
+	ctx := context.Background()
+
+	byID := s.resourceMutex(ctx, &provider.Reference{Spec: &provider.Reference_Id{Id: id}})
+	byPath := s.resourceMutex(ctx, &provider.Reference{Spec: &provider.Reference_Path{Path: "/file"}})
+	if byID != byPath {
+		t.Error("the path and the id of a resource do not share the lock")
+	}
+	// an unknown resource falls back to its reference
+	missing := &provider.Reference{Spec: &provider.Reference_Path{Path: "/missing"}}
+	if s.resourceMutex(ctx, missing) != s.resourceMutex(ctx, missing) {
+		t.Error("the lock of an unresolved reference is not stable")
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package storageprovider
+
+import (
+	"context"
+	"fmt"
+
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/permission"
+	"github.com/cs3org/reva/pkg/storage"
+	ctxpkg "github.com/cs3org/reva/pkg/user"
+)
+
+// getQuota returns the quota of the storage space holding the resource, or
+// the quota of the storage when the driver does not manage it per space.
+func (s *service) getQuota(ctx context.Context, ref *provider.Reference) (uint64, uint64, error) {
+	if qm, ok := s.storage.(storage.QuotaManager); ok && ref != nil {
+		total, used, err := qm.GetSpaceQuota(ctx, ref)
+		if _, notSupported := err.(errtypes.IsNotSupported); !notSupported {
+			return total, used, err
+		}
+	}
+	return s.storage.GetQuota(ctx)
+}
+
+// checkQuota checks that the upload of the given length fits in the quota of
+// the storage space. Drivers unable to report a quota are not checked.
+func (s *service) checkQuota(ctx context.Context, ref *provider.Reference, uploadLength int64) error {
+	if uploadLength <= 0 {
+		return nil
+	}
+	total, used, err := s.getQuota(ctx, ref)
+	if err != nil {
+		appctx.GetLogger(ctx).Debug().Err(err).Msg("storageprovider: quota not checked")
+		return nil
+	}
+	if total > 0 && used+uint64(uploadLength) > total {
+		return errtypes.InsufficientStorage(fmt.Sprintf("quota exceeded: %d bytes used of %d, %d requested", used, total, uploadLength))
+	}
+	return nil
+}
+
+// hasCapability tells whether the user has been granted the capability, or
+// is one of the space admins when no permission driver is configured.
+func (s *service) hasCapability(ctx context.Context, capability string) bool {
+	u, ok := ctxpkg.ContextGetUser(ctx)
+	if !ok {
+		return false
+	}
+	var allowed bool
+	var err error
+	if s.pm != nil {
+		allowed, err = permission.CheckPermission(ctx, s.pm, u, capability)
+	} else {
+		allowed, err = permission.IsAdmin(ctx, nil, u, s.conf.SpaceAdmins, s.conf.SpaceAdminGroups)
+	}
+	if err != nil {
+		appctx.GetLogger(ctx).Error().Err(err).Msg("storageprovider: error checking permission")
+	}
+	return allowed
+}
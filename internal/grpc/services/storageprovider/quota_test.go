@@ -0,0 +1,152 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package storageprovider
+
+import (
+	"context"
+	"testing"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/permission"
+	"github.com/cs3org/reva/pkg/permission/manager/static"
+	"github.com/cs3org/reva/pkg/storage"
+	ctxpkg "github.com/cs3org/reva/pkg/user"
+)
+
+// quotaFS keeps the quota of the spaces, keyed on their root, the storage
+// having a quota of its own.
+type quotaFS struct {
+	storage.FS
+	quotas map[string]uint64
+}
+
+func (fs *quotaFS) GetQuota(ctx context.Context) (uint64, uint64, error) {
+	return 1000, 100, nil
+}
+
+func (fs *quotaFS) GetSpaceQuota(ctx context.Context, ref *provider.Reference) (uint64, uint64, error) {
+	q, ok := fs.quotas[ref.GetId().GetOpaqueId()]
+	if !ok {
+		return 0, 0, errtypes.NotFound(ref.String())
+	}
+	return q, 10, nil
+}
+
+func (fs *quotaFS) SetSpaceQuota(ctx context.Context, ref *provider.Reference, maxBytes uint64) error {
+	fs.quotas[ref.GetId().GetOpaqueId()] = maxBytes
+	return nil
+}
+
+func TestUpdateSpaceQuota(t *testing.T) {
+	conf := &config{SpaceAdmins: []string{"manager"}, SpaceAdminGroups: []string{"managers"}}
+	pm, err := static.New(map[string]interface{}{
+		"roles": map[string][]string{"operator": {permission.ManageSpaces}, "manager": {permission.CreateSpace}},
+		"assignments": map[string]interface{}{
+			"users": map[string][]string{"operator": {"operator"}, "manager": {"manager"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		pm   permission.Manager
+		fs   storage.FS
+		user *userpb.User
+		code rpc.Code
+	}{
+		{"space admin", nil, &quotaFS{quotas: map[string]uint64{}}, &userpb.User{Username: "manager"}, rpc.Code_CODE_OK},
+		{"member of a space admin group", nil, &quotaFS{quotas: map[string]uint64{}}, &userpb.User{Username: "marie", Groups: []string{"managers"}}, rpc.Code_CODE_OK},
+		{"other user", nil, &quotaFS{quotas: map[string]uint64{}}, &userpb.User{Username: "einstein"}, rpc.Code_CODE_PERMISSION_DENIED},
+		{"anonymous", nil, &quotaFS{quotas: map[string]uint64{}}, nil, rpc.Code_CODE_PERMISSION_DENIED},
+		{"capability granted", pm, &quotaFS{quotas: map[string]uint64{}}, &userpb.User{Username: "operator"}, rpc.Code_CODE_OK},
+		{"space admin without the capability", pm, &quotaFS{quotas: map[string]uint64{}}, &userpb.User{Username: "manager"}, rpc.Code_CODE_PERMISSION_DENIED},
+		{"driver without space quotas", nil, struct{ storage.FS }{}, &userpb.User{Username: "manager"}, rpc.Code_CODE_UNIMPLEMENTED},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &service{conf: conf, pm: tt.pm, storage: tt.fs}
+			ctx := context.Background()
+			if tt.user != nil {
+				ctx = ctxpkg.ContextSetUser(ctx, tt.user)
+			}
+			res, err := s.UpdateStorageSpace(ctx, &provider.UpdateStorageSpaceRequest{
+				StorageSpace: &provider.StorageSpace{
+					Root:  &provider.ResourceId{StorageId: "storage", OpaqueId: "space"},
+					Quota: &provider.Quota{QuotaMaxBytes: 500},
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Status.Code != tt.code {
+				t.Errorf("got %s, wanted %s", res.Status.Code, tt.code)
+			}
+			if fs, ok := tt.fs.(*quotaFS); ok {
+				if _, set := fs.quotas["space"]; set != (tt.code == rpc.Code_CODE_OK) {
+					t.Errorf("the quota was set: %v, wanted %v", set, tt.code == rpc.Code_CODE_OK)
+				}
+			}
+		})
+	}
+}
+
+func TestGetQuota(t *testing.T) {
+	s := &service{conf: &config{}, storage: &quotaFS{quotas: map[string]uint64{"space": 500}}}
+	ctx := context.Background()
+	space := &provider.Reference{Spec: &provider.Reference_Id{Id: &provider.ResourceId{StorageId: "storage", OpaqueId: "space"}}}
+	other := &provider.Reference{Spec: &provider.Reference_Id{Id: &provider.ResourceId{StorageId: "storage", OpaqueId: "other"}}}
+
+	tests := []struct {
+		name  string
+		ref   *provider.Reference
+		code  rpc.Code
+		total uint64
+	}{
+		{"storage", nil, rpc.Code_CODE_OK, 1000},
+		{"space", space, rpc.Code_CODE_OK, 500},
+		{"unknown space", other, rpc.Code_CODE_NOT_FOUND, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, err := storage.QuotaRefOpaque(nil, tt.ref)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := s.GetQuota(ctx, &provider.GetQuotaRequest{Opaque: o})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Status.Code != tt.code || res.TotalBytes != tt.total {
+				t.Errorf("got %s with %d bytes, wanted %s with %d bytes", res.Status.Code, res.TotalBytes, tt.code, tt.total)
+			}
+		})
+	}
+
+	if err := s.checkQuota(ctx, space, 490); err != nil {
+		t.Errorf("an upload fitting in the quota was refused: %v", err)
+	}
+	if _, ok := s.checkQuota(ctx, space, 491).(errtypes.InsufficientStorage); !ok {
+		t.Error("an upload exceeding the quota was accepted")
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package storageprovider
+
+import (
+	"context"
+	"encoding/base64"
+	"sort"
+	"strconv"
+	"strings"
+
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/storage"
+	"github.com/cs3org/reva/pkg/utils"
+)
+
+// The recycle bin listings are paginated and filtered by path with the
+// following opaque entries. The deletion time range is given by the FromTs and
+// ToTs of the request.
+const (
+	recyclePageSizeKey      = "page_size"
+	recyclePageTokenKey     = "page_token"
+	recyclePathPrefixKey    = "path_prefix"
+	recycleNextPageTokenKey = "next_page_token"
+)
+
+func opaqueString(o *types.Opaque, key string) string {
+	if o == nil || o.Map[key] == nil {
+		return ""
+	}
+	return string(o.Map[key].Value)
+}
+
+func recycleFilter(req *provider.ListRecycleRequest) *storage.RecycleFilter {
+	f := &storage.RecycleFilter{
+		PathPrefix: opaqueString(req.Opaque, recyclePathPrefixKey),
+	}
+	if req.FromTs != nil {
+		f.From = utils.TSToTime(req.FromTs)
+	}
+	if req.ToTs != nil {
+		f.To = utils.TSToTime(req.ToTs)
+	}
+	return f
+}
+
+// listRecycle lists the recycle items matching the filter, letting the driver
+// filter them when it supports it.
+func (s *service) listRecycle(ctx context.Context, f *storage.RecycleFilter) ([]*provider.RecycleItem, error) {
+	if rf, ok := s.storage.(storage.RecycleFilterer); ok {
+		items, err := rf.ListRecycleFiltered(ctx, f)
+		if _, unsupported := err.(errtypes.IsNotSupported); !unsupported {
+			return items, err
+		}
+	}
+
+	items, err := s.storage.ListRecycle(ctx)
+	if err != nil {
+		return nil, err
+	}
+	filtered := items[:0]
+	for _, item := range items {
+		if item.DeletionTime == nil {
+			if !f.From.IsZero() || !f.To.IsZero() {
+				continue
+			}
+		} else if !f.MatchTime(utils.TSToTime(item.DeletionTime)) {
+			continue
+		}
+		if f.MatchPath(item.Path) {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered, nil
+}
+
+// recycleCursor identifies the last item of a page. The items are sorted by
+// descending deletion time, then by key, so that the following pages stay
+// consistent while items are deleted, restored or purged.
+type recycleCursor struct {
+	seconds uint64
+	key     string
+}
+
+func cursorOf(item *provider.RecycleItem) recycleCursor {
+	return recycleCursor{seconds: item.GetDeletionTime().GetSeconds(), key: item.Key}
+}
+
+func (c recycleCursor) before(o recycleCursor) bool {
+	if c.seconds != o.seconds {
+		return c.seconds > o.seconds
+	}
+	return c.key < o.key
+}
+
+func (c recycleCursor) encode() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatUint(c.seconds, 10) + ":" + c.key))
+}
+
+func decodeCursor(token string) (recycleCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return recycleCursor{}, errtypes.BadRequest("invalid page token")
+	}
+	parts := strings.SplitN(string(b), ":", 2)
+	if len(parts) != 2 {
+		return recycleCursor{}, errtypes.BadRequest("invalid page token")
+	}
+	seconds, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil {
+		return recycleCursor{}, errtypes.BadRequest("invalid page token")
+	}
+	return recycleCursor{seconds: seconds, key: parts[1]}, nil
+}
+
+// paginateRecycle returns the page of items following the token and the token
+// of the next page, empty when it is the last one. A page size of 0 returns
+// all the items.
+func paginateRecycle(items []*provider.RecycleItem, pageSize int, token string) ([]*provider.RecycleItem, string, error) {
+	if pageSize <= 0 && token == "" {
+		return items, "", nil
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return cursorOf(items[i]).before(cursorOf(items[j]))
+	})
+
+	if token != "" {
+		c, err := decodeCursor(token)
+		if err != nil {
+			return nil, "", err
+		}
+		items = items[sort.Search(len(items), func(i int) bool {
+			return c.before(cursorOf(items[i]))
+		}):]
+	}
+
+	if pageSize <= 0 || len(items) <= pageSize {
+		return items, "", nil
+	}
+	return items[:pageSize], cursorOf(items[pageSize-1]).encode(), nil
+}
@@ -0,0 +1,171 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package storageprovider
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strconv"
+	"time"
+
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/permission"
+)
+
+// Retention policies are stored as arbitrary metadata of the spaces or folders
+// they apply to. The files below them cannot be deleted or modified until they
+// are older than the retention period, nor at all while a hold is placed.
+const (
+	retentionDaysKey = "reva.retention.days"
+	retentionHoldKey = "reva.retention.hold"
+)
+
+var retentionKeys = []string{retentionDaysKey, retentionHoldKey}
+
+type retentionPolicy struct {
+	days int
+	hold bool
+}
+
+// merge adds the policy set on the resource, the longest period winning
+func (p *retentionPolicy) merge(ri *provider.ResourceInfo) {
+	md := ri.GetArbitraryMetadata().GetMetadata()
+	if days, err := strconv.Atoi(md[retentionDaysKey]); err == nil && days > p.days {
+		p.days = days
+	}
+	if md[retentionHoldKey] == "true" {
+		p.hold = true
+	}
+}
+
+// retentionPolicyOf returns the resource and the retention policy applying to
+// it, which combines the policies set on the resource and on its ancestors.
+// The ancestors the user cannot access, e.g. above a received share, are not
+// taken into account.
+func (s *service) retentionPolicyOf(ctx context.Context, ref *provider.Reference) (*provider.ResourceInfo, *retentionPolicy, error) {
+	ri, err := s.storage.GetMD(ctx, ref, retentionKeys)
+	if err != nil {
+		return nil, nil, err
+	}
+	p := &retentionPolicy{}
+	p.merge(ri)
+
+	for fn := ri.Path; fn != "/" && fn != "." && fn != ""; {
+		fn = path.Dir(fn)
+		pi, err := s.storage.GetMD(ctx, &provider.Reference{Spec: &provider.Reference_Path{Path: fn}}, retentionKeys)
+		if err != nil {
+			break
+		}
+		p.merge(pi)
+	}
+	return ri, p, nil
+}
+
+// checkRetention returns an error if a retention policy forbids to delete or
+// modify the resource. Folders can only be deleted or moved once empty, so that
+// no file escapes the policy.
+func (s *service) checkRetention(ctx context.Context, ref *provider.Reference) error {
+	if !s.conf.EnableRetention {
+		return nil
+	}
+
+	ri, p, err := s.retentionPolicyOf(ctx, ref)
+	if err != nil {
+		if _, ok := err.(errtypes.IsNotFound); ok {
+			// new files are not retained yet
+			return nil
+		}
+		return err
+	}
+
+	switch {
+	case p.hold:
+		return errtypes.PermissionDenied("retention: " + ri.Path + " is on hold")
+	case p.days == 0:
+		return nil
+	case ri.Type == provider.ResourceType_RESOURCE_TYPE_CONTAINER:
+		children, err := s.storage.ListFolder(ctx, ref, nil)
+		if err != nil {
+			return err
+		}
+		if len(children) > 0 {
+			return errtypes.PermissionDenied("retention: " + ri.Path + " is not empty")
+		}
+		return nil
+	}
+
+	until := time.Unix(int64(ri.GetMtime().GetSeconds()), 0).AddDate(0, 0, p.days)
+	if time.Now().Before(until) {
+		return errtypes.PermissionDenied(fmt.Sprintf("retention: %s is retained until %s", ri.Path, until.UTC().Format(time.RFC3339)))
+	}
+	return nil
+}
+
+// checkSetRetention checks that the user is allowed to set the retention
+// metadata. Retention periods can be extended but never shortened.
+func (s *service) checkSetRetention(ctx context.Context, ref *provider.Reference, md map[string]string) error {
+	if !s.conf.EnableRetention {
+		return nil
+	}
+	days, setDays := md[retentionDaysKey]
+	_, setHold := md[retentionHoldKey]
+	if !setDays && !setHold {
+		return nil
+	}
+	if !s.hasCapability(ctx, permission.ManageRetention) {
+		return errtypes.PermissionDenied("retention: not allowed to manage retention policies")
+	}
+	if !setDays {
+		return nil
+	}
+
+	n, err := strconv.Atoi(days)
+	if err != nil || n < 0 {
+		return errtypes.BadRequest("retention: invalid number of days " + days)
+	}
+	ri, err := s.storage.GetMD(ctx, ref, []string{retentionDaysKey})
+	if err != nil {
+		return err
+	}
+	if current, err := strconv.Atoi(ri.GetArbitraryMetadata().GetMetadata()[retentionDaysKey]); err == nil && n < current {
+		return errtypes.PermissionDenied("retention: retention periods cannot be shortened")
+	}
+	return nil
+}
+
+// checkUnsetRetention checks that the user is allowed to unset the retention
+// metadata. Holds can be released, retention periods never removed.
+func (s *service) checkUnsetRetention(ctx context.Context, keys []string) error {
+	if !s.conf.EnableRetention {
+		return nil
+	}
+	for _, k := range keys {
+		switch k {
+		case retentionDaysKey:
+			return errtypes.PermissionDenied("retention: retention periods cannot be removed")
+		case retentionHoldKey:
+			if !s.hasCapability(ctx, permission.ManageRetention) {
+				return errtypes.PermissionDenied("retention: not allowed to release holds")
+			}
+		}
+	}
+	return nil
+}
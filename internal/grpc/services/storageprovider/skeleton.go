@@ -0,0 +1,286 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package storageprovider
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	grouppb "github.com/cs3org/go-cs3apis/cs3/identity/group/v1beta1"
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/storage"
+	ctxpkg "github.com/cs3org/reva/pkg/user"
+	"github.com/pkg/errors"
+)
+
+// skeletonMetadataKey is set on the homes once their skeleton has been
+// provisioned, to the name of the skeleton.
+const skeletonMetadataKey = "reva.skeleton"
+
+// defaultSkeleton is used for the users without a role having a skeleton.
+const defaultSkeleton = "default"
+
+// skeleton is the content provisioned in a home when it is created.
+type skeleton struct {
+	// TemplateDir is a local folder whose content is copied to the home.
+	TemplateDir string `mapstructure:"template_dir"`
+	// Files are written to the home, by path relative to it. They are meant
+	// for the READMEs which do not belong to a shared template folder.
+	Files map[string]string `mapstructure:"files"`
+	// Quota is the number of bytes the home is limited to, 0 leaving the
+	// quota of the driver.
+	Quota uint64 `mapstructure:"quota"`
+	// Grants are the default shares of the provisioned folders.
+	Grants []*skeletonGrant `mapstructure:"grants"`
+}
+
+// skeletonGrant shares a path of the home with a user or a group.
+type skeletonGrant struct {
+	// Path is relative to the home.
+	Path string `mapstructure:"path"`
+	// UserIdp and User identify the user grantee.
+	UserIdp string `mapstructure:"user_idp"`
+	User    string `mapstructure:"user"`
+	// Group is the opaque id of the group grantee.
+	Group string `mapstructure:"group"`
+	// Permissions are either viewer or editor.
+	Permissions string `mapstructure:"permissions"`
+}
+
+func (g *skeletonGrant) grant() (*provider.Grant, error) {
+	var grantee *provider.Grantee
+	switch {
+	case g.User != "":
+		grantee = &provider.Grantee{
+			Type: provider.GranteeType_GRANTEE_TYPE_USER,
+			Id:   &provider.Grantee_UserId{UserId: &userpb.UserId{Idp: g.UserIdp, OpaqueId: g.User}},
+		}
+	case g.Group != "":
+		grantee = &provider.Grantee{
+			Type: provider.GranteeType_GRANTEE_TYPE_GROUP,
+			Id:   &provider.Grantee_GroupId{GroupId: &grouppb.GroupId{OpaqueId: g.Group}},
+		}
+	default:
+		return nil, errtypes.BadRequest("skeleton: grant of " + g.Path + " without grantee")
+	}
+
+	perms := &provider.ResourcePermissions{
+		GetPath:              true,
+		GetQuota:             true,
+		InitiateFileDownload: true,
+		ListContainer:        true,
+		ListFileVersions:     true,
+		ListRecycle:          true,
+		Stat:                 true,
+	}
+	switch g.Permissions {
+	case "", "viewer":
+	case "editor":
+		perms.CreateContainer = true
+		perms.Delete = true
+		perms.InitiateFileUpload = true
+		perms.Move = true
+		perms.RestoreFileVersion = true
+		perms.RestoreRecycleItem = true
+	default:
+		return nil, errtypes.BadRequest("skeleton: unknown permissions " + g.Permissions)
+	}
+	return &provider.Grant{Grantee: grantee, Permissions: perms}, nil
+}
+
+// getSkeleton returns the skeleton of the first role of the user having
+// one, or the default skeleton.
+func (s *service) getSkeleton(ctx context.Context, u *userpb.User) (string, *skeleton) {
+	if s.pm != nil {
+		roles, err := s.pm.GetRoles(ctx, u)
+		if err != nil {
+			appctx.GetLogger(ctx).Error().Err(err).Msg("storageprovider: error getting the roles, using the default skeleton")
+		}
+		for _, r := range roles {
+			if sk, ok := s.conf.Skeletons[r]; ok {
+				return r, sk
+			}
+		}
+	}
+	return defaultSkeleton, s.conf.Skeletons[defaultSkeleton]
+}
+
+// provisionHome provisions the skeleton of the user in the home, unless it
+// has already been. The provisioning is undone when it fails, so that it is
+// tried again at the next creation of the home.
+func (s *service) provisionHome(ctx context.Context) error {
+	u, ok := ctxpkg.ContextGetUser(ctx)
+	if !ok {
+		return errtypes.UserRequired("skeleton: no user in context")
+	}
+	name, sk := s.getSkeleton(ctx, u)
+	if sk == nil {
+		return nil
+	}
+
+	home := &provider.Reference{Spec: &provider.Reference_Path{Path: "/"}}
+	if provisioned, err := s.isProvisioned(ctx, home); err != nil || provisioned {
+		return err
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(u.GetId().String()))
+	mu := &s.skeletonMu[h.Sum32()%uint32(len(s.skeletonMu))]
+	mu.Lock()
+	defer mu.Unlock()
+
+	// another login may have provisioned the home in the meantime
+	if provisioned, err := s.isProvisioned(ctx, home); err != nil || provisioned {
+		return err
+	}
+
+	p := &provisioning{fs: s.storage}
+	if err := p.run(ctx, home, sk, name); err != nil {
+		p.rollback(ctx)
+		return errors.Wrap(err, "skeleton: error provisioning "+name)
+	}
+	return nil
+}
+
+func (s *service) isProvisioned(ctx context.Context, home *provider.Reference) (bool, error) {
+	md, err := s.storage.GetMD(ctx, home, []string{skeletonMetadataKey})
+	if err != nil {
+		return false, err
+	}
+	_, ok := md.GetArbitraryMetadata().GetMetadata()[skeletonMetadataKey]
+	return ok, nil
+}
+
+// provisioning keeps track of what has been created in the home, to undo
+// it when a later step fails.
+type provisioning struct {
+	fs      storage.FS
+	created []*provider.Reference
+	granted []*provider.Grant
+	grantOn []*provider.Reference
+}
+
+func (p *provisioning) run(ctx context.Context, home *provider.Reference, sk *skeleton, name string) error {
+	if sk.TemplateDir != "" {
+		if err := p.copyTemplate(ctx, sk.TemplateDir); err != nil {
+			return err
+		}
+	}
+
+	for fn, content := range sk.Files {
+		if err := p.upload(ctx, path.Join("/", fn), ioutil.NopCloser(strings.NewReader(content))); err != nil {
+			return err
+		}
+	}
+
+	for _, g := range sk.Grants {
+		grant, err := g.grant()
+		if err != nil {
+			return err
+		}
+		ref := &provider.Reference{Spec: &provider.Reference_Path{Path: path.Join("/", g.Path)}}
+		if err := p.fs.AddGrant(ctx, ref, grant); err != nil {
+			return errors.Wrap(err, "error adding grant on "+g.Path)
+		}
+		p.granted = append(p.granted, grant)
+		p.grantOn = append(p.grantOn, ref)
+	}
+
+	if sk.Quota > 0 {
+		qm, ok := p.fs.(storage.QuotaManager)
+		if !ok {
+			return errtypes.NotSupported("skeleton: the driver does not manage the quota of the homes")
+		}
+		if err := qm.SetSpaceQuota(ctx, home, sk.Quota); err != nil {
+			return errors.Wrap(err, "error setting quota")
+		}
+	}
+
+	md := &provider.ArbitraryMetadata{Metadata: map[string]string{skeletonMetadataKey: name}}
+	return p.fs.SetArbitraryMetadata(ctx, home, md)
+}
+
+func (p *provisioning) copyTemplate(ctx context.Context, dir string) error {
+	return filepath.Walk(dir, func(fp string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, fp)
+		if err != nil || rel == "." {
+			return err
+		}
+		fn := path.Join("/", filepath.ToSlash(rel))
+
+		if info.IsDir() {
+			if err := p.fs.CreateDir(ctx, fn); err != nil {
+				if _, exists := errors.Cause(err).(errtypes.IsAlreadyExists); exists {
+					return nil
+				}
+				return errors.Wrap(err, "error creating "+fn)
+			}
+			p.created = append(p.created, &provider.Reference{Spec: &provider.Reference_Path{Path: fn}})
+			return nil
+		}
+
+		f, err := os.Open(fp)
+		if err != nil {
+			return err
+		}
+		return p.upload(ctx, fn, f)
+	})
+}
+
+func (p *provisioning) upload(ctx context.Context, fn string, r io.ReadCloser) error {
+	ref := &provider.Reference{Spec: &provider.Reference_Path{Path: fn}}
+	if _, err := p.fs.GetMD(ctx, ref, nil); err == nil {
+		// the users keep their own version of the file
+		r.Close()
+		return nil
+	}
+	if err := p.fs.Upload(ctx, ref, r); err != nil {
+		return errors.Wrap(err, fmt.Sprintf("error uploading %s", fn))
+	}
+	p.created = append(p.created, ref)
+	return nil
+}
+
+// rollback undoes the provisioning in reverse order, as far as possible.
+func (p *provisioning) rollback(ctx context.Context) {
+	log := appctx.GetLogger(ctx)
+	for i := len(p.granted) - 1; i >= 0; i-- {
+		if err := p.fs.RemoveGrant(ctx, p.grantOn[i], p.granted[i]); err != nil {
+			log.Error().Err(err).Interface("ref", p.grantOn[i]).Msg("storageprovider: error removing skeleton grant")
+		}
+	}
+	for i := len(p.created) - 1; i >= 0; i-- {
+		if err := p.fs.Delete(ctx, p.created[i]); err != nil {
+			log.Error().Err(err).Interface("ref", p.created[i]).Msg("storageprovider: error deleting skeleton content")
+		}
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package storageprovider
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/storage/fs/local"
+	ruser "github.com/cs3org/reva/pkg/user"
+	"github.com/cs3org/reva/tests/helpers"
+)
+
+func TestProvisionHome(t *testing.T) {
+	root, err := helpers.TempDir("reva-unit-tests-*-root")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	tpl, err := helpers.TempDir("reva-unit-tests-*-skeleton")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tpl)
+	if err := os.MkdirAll(filepath.Join(tpl, "Documents"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(tpl, "Documents", "welcome.txt"), []byte("welcome"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fs, err := local.New(map[string]interface{}{"root": root})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &service{
+		conf: &config{Skeletons: map[string]*skeleton{
+			defaultSkeleton: {
+				TemplateDir: tpl,
+				Files:       map[string]string{"README.md": "read me"},
+			},
+		}},
+		storage: fs,
+	}
+	ctx := ruser.ContextSetUser(context.Background(), &userpb.User{
+		Id:       &userpb.UserId{Idp: "idp", OpaqueId: "userid"},
+		Username: "username",
+	})
+
+	if err := s.provisionHome(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for _, fn := range []string{"/Documents", "/Documents/welcome.txt", "/README.md"} {
+		if _, err := fs.GetMD(ctx, &provider.Reference{Spec: &provider.Reference_Path{Path: fn}}, nil); err != nil {
+			t.Errorf("%s not provisioned: %v", fn, err)
+		}
+	}
+
+	// the skeleton is provisioned only once
+	readme := &provider.Reference{Spec: &provider.Reference_Path{Path: "/README.md"}}
+	if err := fs.Delete(ctx, readme); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.provisionHome(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := fs.GetMD(ctx, readme, nil); err == nil {
+		t.Error("the skeleton was provisioned twice")
+	}
+}
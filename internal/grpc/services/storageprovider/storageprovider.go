@@ -27,19 +27,36 @@ import (
 	"path"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
 	// link "github.com/cs3org/go-cs3apis/cs3/sharing/link/v1beta1"
 	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	adminpb "github.com/cs3org/reva/internal/grpc/services/adminprovider/proto"
+	revisionspb "github.com/cs3org/reva/internal/grpc/services/storageprovider/proto"
 	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/cache"
+	cacheregistry "github.com/cs3org/reva/pkg/cache/driver/registry"
 	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/events"
+	eventsregistry "github.com/cs3org/reva/pkg/events/driver/registry"
+	"github.com/cs3org/reva/pkg/jobs"
+	"github.com/cs3org/reva/pkg/logger"
 	"github.com/cs3org/reva/pkg/mime"
+	"github.com/cs3org/reva/pkg/permission"
+	permregistry "github.com/cs3org/reva/pkg/permission/manager/registry"
 	"github.com/cs3org/reva/pkg/rgrpc"
 	"github.com/cs3org/reva/pkg/rgrpc/status"
 	"github.com/cs3org/reva/pkg/storage"
 	"github.com/cs3org/reva/pkg/storage/fs/registry"
+	fsmetrics "github.com/cs3org/reva/pkg/storage/utils/metrics"
+	"github.com/cs3org/reva/pkg/tags"
 	"github.com/mitchellh/mapstructure"
 	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"go.opencensus.io/stats/view"
 	"go.opencensus.io/trace"
 	"google.golang.org/grpc"
 )
@@ -58,6 +75,52 @@ type config struct {
 	ExposeDataServer bool                              `mapstructure:"expose_data_server" docs:"false;Whether to expose data server."` // if true the client will be able to upload/download directly to it
 	AvailableXS      map[string]uint32                 `mapstructure:"available_checksums" docs:"nil;List of available checksums."`
 	MimeTypes        map[string]string                 `mapstructure:"mimetypes" docs:"nil;List of supported mime types and corresponding file extensions."`
+	SlowThreshold    int                               `mapstructure:"slow_operation_threshold" docs:"0;The duration in milliseconds above which the storage driver operations are logged. 0 disables the logging."`
+	SpaceAdmins      []string                          `mapstructure:"space_admins" docs:"nil;The usernames allowed to create project spaces, to change the quota of the storage spaces, to manage the retention policies and to prune the revisions."`
+	SpaceAdminGroups []string                          `mapstructure:"space_admin_groups" docs:"nil;The groups whose members are allowed to create project spaces, to change the quota of the storage spaces, to manage the retention policies and to prune the revisions."`
+	Admins           []string                          `mapstructure:"admins" docs:"nil;The usernames allowed to run the admin tasks, recalculating the tree sizes and purging the recycle bins, when no permission driver is set. They must match the admins of the adminprovider."`
+	AdminGroups      []string                          `mapstructure:"admin_groups" docs:"nil;The groups whose members are allowed to run the admin tasks when no permission driver is set. They must match the admin groups of the adminprovider."`
+	EnableRetention  bool                              `mapstructure:"enable_retention" docs:"false;Whether to enforce the retention policies set on the spaces and folders."`
+	RecycleRetention int                               `mapstructure:"recycle_retention" docs:"0;The number of days the recycle bin items are kept before being purged. 0 keeps them forever."`
+	// RecycleRetentionSpaces overrides the recycle retention for the recycle
+	// bins of the given users or spaces, 0 keeping the items forever.
+	RecycleRetentionSpaces map[string]int `mapstructure:"recycle_retention_spaces"`
+	RecyclePurgeInterval   int            `mapstructure:"recycle_purge_interval" docs:"3600;The interval in seconds between two purges of the expired recycle bin items."`
+	// RevisionRetention tells which revisions are kept by the compaction,
+	// which is disabled when no rule is set.
+	RevisionRetention          revisionPolicy `mapstructure:"revision_retention"`
+	RevisionCompactionInterval int            `mapstructure:"revision_compaction_interval" docs:"86400;The interval in seconds between two compactions of the revisions."`
+	// PermissionDriver, if set, is used to check whether the users are allowed
+	// to create and manage the storage spaces, the retention policies and the
+	// revisions instead of the lists of admins.
+	PermissionDriver  string                            `mapstructure:"permission_driver"`
+	PermissionDrivers map[string]map[string]interface{} `mapstructure:"permission_drivers"`
+	StreamMaxSize     int64                             `mapstructure:"stream_max_size" docs:"0;The size in bytes up to which the files can be downloaded and uploaded over gRPC streams, without the data provider. 0 disables the streams."`
+	BlobGCInterval    int                               `mapstructure:"blob_gc_interval" docs:"0;The interval in seconds between two collections of the deduplicated blobs which are not referenced anymore. 0 disables the collection."`
+	TieringInterval   int                               `mapstructure:"tiering_interval" docs:"0;The interval in seconds between two moves of the content which was not accessed to the cold storage. 0 disables the tiering."`
+	UploadExpiration  int                               `mapstructure:"upload_expiration" docs:"0;The number of seconds after which the uploads which are not written to are deleted. 0 keeps them."`
+	UploadGCInterval  int                               `mapstructure:"upload_gc_interval" docs:"3600;The interval in seconds between two deletions of the expired uploads."`
+	// JobSchedules overrides the intervals of the periodic jobs with cron
+	// expressions, e.g. "0 3 * * *". The jobs are recycle_purge,
+	// revision_compaction, blob_gc, tiering and upload_gc.
+	JobSchedules map[string]string `mapstructure:"job_schedules"`
+	JobLeaseDir  string            `mapstructure:"job_lease_dir" docs:";A directory shared by the replicas of the storage provider, used to elect the replica running each occurrence of the periodic jobs. Every replica runs them when unset."`
+	// JobLocks configures the locks shared by the replicas of the storage
+	// provider, which elect the replica running each occurrence of the
+	// periodic jobs. They take precedence over the JobLeaseDir.
+	JobLocks map[string]interface{} `mapstructure:"job_locks"`
+	// PermissionCacheTTL is the number of seconds the capabilities granted
+	// to the roles are cached, 0 disabling the cache.
+	PermissionCacheTTL int `mapstructure:"permission_cache_ttl"`
+	// PermissionCache configures the driver of the capability cache.
+	PermissionCache map[string]interface{} `mapstructure:"permission_cache"`
+	// Skeletons configures the content provisioned once in the homes when
+	// they are created, by role. The default skeleton is used for the users
+	// without a role having one. The driver must manage the homes.
+	Skeletons map[string]*skeleton `mapstructure:"skeletons"`
+	// Events configures the bus the changes of the resources are published
+	// to, for the change logs of the users. They are not published without it.
+	Events map[string]interface{} `mapstructure:"events"`
 }
 
 func (c *config) init() {
@@ -90,6 +153,31 @@ func (c *config) init() {
 	if len(c.AvailableXS) == 0 {
 		c.AvailableXS = map[string]uint32{"md5": 100, "unset": 1000}
 	}
+
+	if c.RecyclePurgeInterval == 0 {
+		c.RecyclePurgeInterval = 3600
+	}
+
+	if c.RevisionCompactionInterval == 0 {
+		c.RevisionCompactionInterval = 86400
+	}
+
+	if c.UploadGCInterval == 0 {
+		c.UploadGCInterval = 3600
+	}
+}
+
+// jobSchedule returns the schedule of a periodic job, which runs at the
+// given interval in seconds unless a cron expression is configured. It is
+// empty when the job is disabled.
+func (c *config) jobSchedule(name string, interval int) string {
+	if spec, ok := c.JobSchedules[name]; ok {
+		return spec
+	}
+	if interval <= 0 {
+		return ""
+	}
+	return fmt.Sprintf("@every %ds", interval)
 }
 
 type service struct {
@@ -99,9 +187,20 @@ type service struct {
 	tmpFolder          string
 	dataServerURL      *url.URL
 	availableXS        []*provider.ResourceChecksumPriority
+	pm                 permission.Manager
+	log                *zerolog.Logger
+	stop               chan struct{}
+	wg                 sync.WaitGroup
+	compactionMu       sync.Mutex
+	versionMu          [64]sync.Mutex
+	skeletonMu         [64]sync.Mutex
+	// stream is the bus the changes are published to, if any
+	stream events.Publisher
 }
 
 func (s *service) Close() error {
+	close(s.stop)
+	s.wg.Wait()
 	return s.storage.Shutdown(context.Background())
 }
 
@@ -109,6 +208,11 @@ func (s *service) UnprotectedEndpoints() []string { return []string{} }
 
 func (s *service) Register(ss *grpc.Server) {
 	provider.RegisterProviderAPIServer(ss, s)
+	revisionspb.RegisterRevisionsAdminServiceServer(ss, s)
+	revisionspb.RegisterLockAPIServer(ss, s)
+	revisionspb.RegisterStreamAPIServer(ss, s)
+	revisionspb.RegisterBatchAPIServer(ss, s)
+	adminpb.RegisterStorageAdminAPIServer(ss, s)
 }
 
 func parseXSTypes(xsTypes map[string]uint32) ([]*provider.ResourceChecksumPriority, error) {
@@ -176,6 +280,24 @@ func New(m map[string]interface{}, ss *grpc.Server) (rgrpc.Service, error) {
 
 	registerMimeTypes(c.MimeTypes)
 
+	var pm permission.Manager
+	if c.PermissionDriver != "" {
+		f, ok := permregistry.NewFuncs[c.PermissionDriver]
+		if !ok {
+			return nil, errtypes.NotFound("storageprovider: permission driver does not exist: " + c.PermissionDriver)
+		}
+		if pm, err = f(c.PermissionDrivers[c.PermissionDriver]); err != nil {
+			return nil, errors.Wrap(err, "storageprovider: error creating permission manager")
+		}
+		if c.PermissionCacheTTL > 0 {
+			pc, err := cacheregistry.NewCache("capabilities", c.PermissionCache)
+			if err != nil {
+				return nil, errors.Wrap(err, "storageprovider: error creating capability cache")
+			}
+			pm = permission.NewCachedManager(pm, cache.NewCapabilityCache(pc, time.Duration(c.PermissionCacheTTL)*time.Second))
+		}
+	}
+
 	service := &service{
 		conf:          c,
 		storage:       fs,
@@ -184,6 +306,107 @@ func New(m map[string]interface{}, ss *grpc.Server) (rgrpc.Service, error) {
 		mountID:       mountID,
 		dataServerURL: u,
 		availableXS:   xsTypes,
+		pm:            pm,
+		log:           logger.New(),
+		stop:          make(chan struct{}),
+	}
+
+	if len(c.Events) > 0 {
+		if service.stream, err = eventsregistry.NewStream(c.Events); err != nil {
+			return nil, errors.Wrap(err, "storageprovider: error creating events stream")
+		}
+	}
+
+	if c.retentionEnabled() || c.RevisionRetention.enabled() {
+		registerJanitorViews.Do(func() {
+			err = view.Register(janitorViews...)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	elector, err := jobs.NewElector(c.JobLocks, c.JobLeaseDir, mountID)
+	if err != nil {
+		return nil, errors.Wrap(err, "storageprovider: error creating job elector")
+	}
+	sched, err := jobs.NewScheduler(elector, service.log)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.retentionEnabled() {
+		rp, ok := fs.(storage.RecyclePurger)
+		if !ok {
+			return nil, errtypes.NotSupported("storageprovider: the driver " + c.Driver + " does not support the recycle retention")
+		}
+		err := sched.Register("recycle_purge", c.jobSchedule("recycle_purge", c.RecyclePurgeInterval), func(ctx context.Context) error {
+			return service.purgeRecycleBins(ctx, rp)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if c.RevisionRetention.enabled() {
+		rp, ok := fs.(storage.RevisionPruner)
+		if !ok {
+			return nil, errtypes.NotSupported("storageprovider: the driver " + c.Driver + " does not support the revision retention")
+		}
+		err := sched.Register("revision_compaction", c.jobSchedule("revision_compaction", c.RevisionCompactionInterval), func(ctx context.Context) error {
+			_, _, err := service.compactRevisions(ctx, rp)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if spec := c.jobSchedule("blob_gc", c.BlobGCInterval); spec != "" {
+		bd, ok := fs.(storage.BlobDeduplicator)
+		if !ok {
+			return nil, errtypes.NotSupported("storageprovider: the driver " + c.Driver + " does not support the deduplication")
+		}
+		err := sched.Register("blob_gc", spec, func(ctx context.Context) error {
+			return service.collectBlobs(ctx, bd)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if spec := c.jobSchedule("tiering", c.TieringInterval); spec != "" {
+		bt, ok := fs.(storage.BlobTierer)
+		if !ok {
+			return nil, errtypes.NotSupported("storageprovider: the driver " + c.Driver + " does not support the tiering")
+		}
+		err := sched.Register("tiering", spec, func(ctx context.Context) error {
+			return service.tierBlobs(ctx, bt)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if c.UploadExpiration > 0 {
+		up, ok := fs.(storage.UploadPurger)
+		if !ok {
+			return nil, errtypes.NotSupported("storageprovider: the driver " + c.Driver + " does not support the expiration of the uploads")
+		}
+		err := sched.Register("upload_gc", c.jobSchedule("upload_gc", c.UploadGCInterval), func(ctx context.Context) error {
+			return service.purgeUploads(ctx, up)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !sched.Empty() {
+		service.wg.Add(1)
+		go func() {
+			defer service.wg.Done()
+			sched.Run(service.stop)
+		}()
 	}
 
 	return service, nil
@@ -204,6 +427,22 @@ func (s *service) SetArbitraryMetadata(ctx context.Context, req *provider.SetArb
 		}, nil
 	}
 
+	if err := s.checkSetRetention(ctx, newRef, req.ArbitraryMetadata.GetMetadata()); err != nil {
+		return &provider.SetArbitraryMetadataResponse{
+			Status: status.NewStatusFromErrType(ctx, "error setting arbitrary metadata", err),
+		}, nil
+	}
+
+	defer s.lockResource(ctx, newRef)()
+	if expected, ok := ifMatch(req.Opaque); ok {
+		if current, err := s.checkMetadataVersion(ctx, newRef, expected); err != nil {
+			return &provider.SetArbitraryMetadataResponse{
+				Status: status.NewStatusFromErrType(ctx, "error setting arbitrary metadata", err),
+				Opaque: setVersion(nil, metadataVersionKey, current),
+			}, nil
+		}
+	}
+
 	if err := s.storage.SetArbitraryMetadata(ctx, newRef, req.ArbitraryMetadata); err != nil {
 		var st *rpc.Status
 		switch err.(type) {
@@ -234,6 +473,22 @@ func (s *service) UnsetArbitraryMetadata(ctx context.Context, req *provider.Unse
 		}, nil
 	}
 
+	if err := s.checkUnsetRetention(ctx, req.ArbitraryMetadataKeys); err != nil {
+		return &provider.UnsetArbitraryMetadataResponse{
+			Status: status.NewStatusFromErrType(ctx, "error unsetting arbitrary metadata", err),
+		}, nil
+	}
+
+	defer s.lockResource(ctx, newRef)()
+	if expected, ok := ifMatch(req.Opaque); ok {
+		if current, err := s.checkMetadataVersion(ctx, newRef, expected); err != nil {
+			return &provider.UnsetArbitraryMetadataResponse{
+				Status: status.NewStatusFromErrType(ctx, "error unsetting arbitrary metadata", err),
+				Opaque: setVersion(nil, metadataVersionKey, current),
+			}, nil
+		}
+	}
+
 	if err := s.storage.UnsetArbitraryMetadata(ctx, newRef, req.ArbitraryMetadataKeys); err != nil {
 		var st *rpc.Status
 		switch err.(type) {
@@ -324,6 +579,18 @@ func (s *service) InitiateFileUpload(ctx context.Context, req *provider.Initiate
 			metadata["mtime"] = string(req.Opaque.Map["X-OC-Mtime"].Value)
 		}
 	}
+	if err := s.checkRetention(ctx, newRef); err != nil {
+		return &provider.InitiateFileUploadResponse{
+			Status: status.NewStatusFromErrType(ctx, "error initiating upload", err),
+		}, nil
+	}
+
+	if err := s.checkQuota(ctx, newRef, uploadLength); err != nil {
+		return &provider.InitiateFileUploadResponse{
+			Status: status.NewInsufficientStorage(ctx, err, "insufficient storage"),
+		}, nil
+	}
+
 	uploadIDs, err := s.storage.InitiateUpload(ctx, newRef, uploadLength, metadata)
 	if err != nil {
 		var st *rpc.Status
@@ -345,6 +612,8 @@ func (s *service) InitiateFileUpload(ctx context.Context, req *provider.Initiate
 			st = status.NewPermissionDenied(ctx, err, "permission denied")
 		case errtypes.InsufficientStorage:
 			st = status.NewInsufficientStorage(ctx, err, "insufficient storage")
+		case errtypes.IsLocked:
+			st = &rpc.Status{Code: rpc.Code_CODE_FAILED_PRECONDITION, Message: err.Error()}
 		default:
 			st = status.NewInternal(ctx, err, "error getting upload id: "+req.Ref.String())
 		}
@@ -416,33 +685,241 @@ func (s *service) CreateHome(ctx context.Context, req *provider.CreateHomeReques
 		}, nil
 	}
 
+	if err := s.provisionHome(ctx); err != nil {
+		log.Err(err).Msg("storageprovider: error provisioning the home")
+		return &provider.CreateHomeResponse{
+			Status: status.NewInternal(ctx, err, "error provisioning home"),
+		}, nil
+	}
+
 	res := &provider.CreateHomeResponse{
 		Status: status.NewOK(ctx),
 	}
 	return res, nil
 }
 
+// CreateStorageSpace creates a project space, restricted to the users allowed
+// to create spaces. The creator becomes the manager of the space.
 func (s *service) CreateStorageSpace(ctx context.Context, req *provider.CreateStorageSpaceRequest) (*provider.CreateStorageSpaceResponse, error) {
+	sm, ok := s.storage.(storage.SpacesManager)
+	if !ok {
+		return &provider.CreateStorageSpaceResponse{
+			Status: status.NewUnimplemented(ctx, errtypes.NotSupported("CreateStorageSpace not implemented"), "CreateStorageSpace not implemented"),
+		}, nil
+	}
+
+	if !s.hasCapability(ctx, permission.CreateSpace) {
+		return &provider.CreateStorageSpaceResponse{
+			Status: status.NewPermissionDenied(ctx, errtypes.PermissionDenied("spaces"), "not allowed to create spaces"),
+		}, nil
+	}
+	if req.Quota != nil && !s.hasCapability(ctx, permission.ManageSpaces) {
+		return &provider.CreateStorageSpaceResponse{
+			Status: status.NewPermissionDenied(ctx, errtypes.PermissionDenied("quota"), "not allowed to set the quota"),
+		}, nil
+	}
+
+	space, err := sm.CreateStorageSpace(ctx, req)
+	if err != nil {
+		return &provider.CreateStorageSpaceResponse{
+			Status: status.NewStatusFromErrType(ctx, "error creating space", err),
+		}, nil
+	}
+	space.Root.StorageId = s.mountID
+
 	return &provider.CreateStorageSpaceResponse{
-		Status: status.NewUnimplemented(ctx, errtypes.NotSupported("CreateStorageSpace not implemented"), "CreateStorageSpace not implemented"),
+		Status:       status.NewOK(ctx),
+		StorageSpace: space,
 	}, nil
 }
 
 func (s *service) ListStorageSpaces(ctx context.Context, req *provider.ListStorageSpacesRequest) (*provider.ListStorageSpacesResponse, error) {
+	sm, ok := s.storage.(storage.SpacesManager)
+	if !ok {
+		return &provider.ListStorageSpacesResponse{
+			Status: status.NewUnimplemented(ctx, errtypes.NotSupported("ListStorageSpaces not implemented"), "ListStorageSpaces not implemented"),
+		}, nil
+	}
+
+	spaces, err := sm.ListStorageSpaces(ctx, req.Filters)
+	if err != nil {
+		return &provider.ListStorageSpacesResponse{
+			Status: status.NewStatusFromErrType(ctx, "error listing spaces", err),
+		}, nil
+	}
+	for _, space := range spaces {
+		space.Root.StorageId = s.mountID
+	}
+
 	return &provider.ListStorageSpacesResponse{
-		Status: status.NewUnimplemented(ctx, errtypes.NotSupported("ListStorageSpaces not implemented"), "ListStorageSpaces not implemented"),
+		Status:        status.NewOK(ctx),
+		StorageSpaces: spaces,
 	}, nil
 }
 
+// UpdateStorageSpace changes the name and the quota of the storage space, or
+// restores it when the "restore" opaque entry is set. The "dedup" opaque
+// entry of the space enables or disables the deduplication of its content,
+// the "tiering_days" one sets its tiering policy and the "encryption" one
+// manages the keys of its encryption.
+// Changing the quota is restricted to the users allowed to manage spaces, the
+// rest to the managers of the space.
 func (s *service) UpdateStorageSpace(ctx context.Context, req *provider.UpdateStorageSpaceRequest) (*provider.UpdateStorageSpaceResponse, error) {
+	space := req.StorageSpace
+	if space == nil || space.Root == nil {
+		return &provider.UpdateStorageSpaceResponse{
+			Status: status.NewInvalidArg(ctx, "missing space root"),
+		}, nil
+	}
+
+	var err error
+	sm, isSpacesManager := s.storage.(storage.SpacesManager)
+	switch {
+	case req.Opaque != nil && req.Opaque.Map["restore"] != nil:
+		if !isSpacesManager {
+			err = errtypes.NotSupported("RestoreStorageSpace")
+			break
+		}
+		err = sm.RestoreStorageSpace(ctx, space.Root.OpaqueId)
+	case space.Name != "" && isSpacesManager:
+		err = sm.RenameStorageSpace(ctx, space.Root.OpaqueId, space.Name)
+	}
+	if e := space.GetOpaque().GetMap()["dedup"]; err == nil && e != nil {
+		if bd, ok := s.storage.(storage.BlobDeduplicator); ok {
+			err = bd.SetSpaceDedup(ctx, space.Root.OpaqueId, string(e.Value) == "true")
+		} else {
+			err = errtypes.NotSupported("SetSpaceDedup")
+		}
+	}
+	if e := space.GetOpaque().GetMap()["tiering_days"]; err == nil && e != nil {
+		err = s.setSpaceTiering(ctx, space.Root.OpaqueId, string(e.Value))
+	}
+	if e := space.GetOpaque().GetMap()["encryption"]; err == nil && e != nil {
+		err = s.updateSpaceEncryption(ctx, space, string(e.Value))
+	}
+	if err != nil {
+		return &provider.UpdateStorageSpaceResponse{
+			Status: status.NewStatusFromErrType(ctx, "error updating space", err),
+		}, nil
+	}
+
+	if space.Quota != nil {
+		if st := s.setSpaceQuota(ctx, space); st != nil {
+			return &provider.UpdateStorageSpaceResponse{Status: st}, nil
+		}
+	} else if !isSpacesManager {
+		return &provider.UpdateStorageSpaceResponse{
+			Status: status.NewUnimplemented(ctx, errtypes.NotSupported("UpdateStorageSpace not implemented"), "UpdateStorageSpace not implemented"),
+		}, nil
+	}
+
 	return &provider.UpdateStorageSpaceResponse{
-		Status: status.NewUnimplemented(ctx, errtypes.NotSupported("UpdateStorageSpace not implemented"), "UpdateStorageSpace not implemented"),
+		Status:       status.NewOK(ctx),
+		StorageSpace: space,
 	}, nil
 }
 
+// updateSpaceEncryption runs the encryption action of the space, reading
+// the passphrases from its opaque entries and removing them so that they are
+// not sent back
+func (s *service) updateSpaceEncryption(ctx context.Context, space *provider.StorageSpace, action string) error {
+	se, ok := s.storage.(storage.SpaceEncrypter)
+	if !ok {
+		return errtypes.NotSupported("SpaceEncrypter")
+	}
+	m := space.Opaque.Map
+	var passphrase, newPassphrase string
+	if e := m["passphrase"]; e != nil {
+		passphrase = string(e.Value)
+	}
+	if e := m["new_passphrase"]; e != nil {
+		newPassphrase = string(e.Value)
+	}
+	delete(m, "passphrase")
+	delete(m, "new_passphrase")
+
+	id := space.Root.OpaqueId
+	switch action {
+	case "enable":
+		return se.EnableSpaceEncryption(ctx, id, passphrase)
+	case "unlock":
+		return se.UnlockSpace(ctx, id, passphrase)
+	case "lock":
+		return se.LockSpace(ctx, id)
+	case "rotate":
+		return se.RotateSpaceKey(ctx, id)
+	case "passphrase":
+		return se.ChangeSpacePassphrase(ctx, id, passphrase, newPassphrase)
+	default:
+		return errtypes.BadRequest("unknown encryption action: " + action)
+	}
+}
+
+// setSpaceTiering sets the tiering policy of the space
+func (s *service) setSpaceTiering(ctx context.Context, id, value string) error {
+	bt, ok := s.storage.(storage.BlobTierer)
+	if !ok {
+		return errtypes.NotSupported("SetSpaceTiering")
+	}
+	days, err := strconv.Atoi(value)
+	if err != nil {
+		return errtypes.BadRequest("invalid tiering days: " + value)
+	}
+	return bt.SetSpaceTiering(ctx, id, days)
+}
+
+// setSpaceQuota sets the quota of the space and returns the status of the
+// failure, if any
+func (s *service) setSpaceQuota(ctx context.Context, space *provider.StorageSpace) *rpc.Status {
+	qm, ok := s.storage.(storage.QuotaManager)
+	if !ok {
+		return status.NewUnimplemented(ctx, errtypes.NotSupported("SetSpaceQuota"), "quota not supported")
+	}
+
+	if !s.hasCapability(ctx, permission.ManageSpaces) {
+		return status.NewPermissionDenied(ctx, errtypes.PermissionDenied("quota"), "not allowed to change the quota")
+	}
+
+	ref, err := s.unwrap(ctx, &provider.Reference{Spec: &provider.Reference_Id{Id: space.Root}})
+	if err != nil {
+		return status.NewInternal(ctx, err, "error unwrapping path")
+	}
+
+	if err := qm.SetSpaceQuota(ctx, ref, space.Quota.QuotaMaxBytes); err != nil {
+		return status.NewStatusFromErrType(ctx, "error setting quota", err)
+	}
+	return nil
+}
+
+// DeleteStorageSpace disables the storage space, or purges it when the
+// "purge" opaque entry is set. Only disabled spaces can be purged.
 func (s *service) DeleteStorageSpace(ctx context.Context, req *provider.DeleteStorageSpaceRequest) (*provider.DeleteStorageSpaceResponse, error) {
+	sm, ok := s.storage.(storage.SpacesManager)
+	if !ok {
+		return &provider.DeleteStorageSpaceResponse{
+			Status: status.NewUnimplemented(ctx, errtypes.NotSupported("DeleteStorageSpace not implemented"), "DeleteStorageSpace not implemented"),
+		}, nil
+	}
+	if req.Id == nil {
+		return &provider.DeleteStorageSpaceResponse{
+			Status: status.NewInvalidArg(ctx, "missing space id"),
+		}, nil
+	}
+
+	var err error
+	if req.Opaque != nil && req.Opaque.Map["purge"] != nil {
+		err = sm.PurgeStorageSpace(ctx, req.Id.OpaqueId)
+	} else {
+		err = sm.DisableStorageSpace(ctx, req.Id.OpaqueId)
+	}
+	if err != nil {
+		return &provider.DeleteStorageSpaceResponse{
+			Status: status.NewStatusFromErrType(ctx, "error deleting space", err),
+		}, nil
+	}
+
 	return &provider.DeleteStorageSpaceResponse{
-		Status: status.NewUnimplemented(ctx, errtypes.NotSupported("DeleteStorageSpace not implemented"), "DeleteStorageSpace not implemented"),
+		Status: status.NewOK(ctx),
 	}, nil
 }
 
@@ -471,6 +948,16 @@ func (s *service) CreateContainer(ctx context.Context, req *provider.CreateConta
 		}, nil
 	}
 
+	if md := s.statChanged(ctx, newRef); md != nil {
+		s.publishChange(ctx, events.ContainerCreated{
+			Executant:  executant(ctx),
+			Owner:      md.Owner,
+			ResourceID: md.Id,
+			Path:       md.Path,
+			Time:       time.Now(),
+		})
+	}
+
 	res := &provider.CreateContainerResponse{
 		Status: status.NewOK(ctx),
 	}
@@ -490,6 +977,13 @@ func (s *service) Delete(ctx context.Context, req *provider.DeleteRequest) (*pro
 		}, nil
 	}
 
+	if err := s.checkRetention(ctx, newRef); err != nil {
+		return &provider.DeleteResponse{
+			Status: status.NewStatusFromErrType(ctx, "error deleting file", err),
+		}, nil
+	}
+
+	md := s.statChanged(ctx, newRef)
 	if err := s.storage.Delete(ctx, newRef); err != nil {
 		var st *rpc.Status
 		switch err.(type) {
@@ -505,6 +999,16 @@ func (s *service) Delete(ctx context.Context, req *provider.DeleteRequest) (*pro
 		}, nil
 	}
 
+	if md != nil {
+		s.publishChange(ctx, events.ItemTrashed{
+			Executant:  executant(ctx),
+			Owner:      md.Owner,
+			ResourceID: md.Id,
+			Path:       md.Path,
+			Time:       time.Now(),
+		})
+	}
+
 	res := &provider.DeleteResponse{
 		Status: status.NewOK(ctx),
 	}
@@ -525,6 +1029,13 @@ func (s *service) Move(ctx context.Context, req *provider.MoveRequest) (*provide
 		}, nil
 	}
 
+	if err := s.checkRetention(ctx, sourceRef); err != nil {
+		return &provider.MoveResponse{
+			Status: status.NewStatusFromErrType(ctx, "error moving", err),
+		}, nil
+	}
+
+	src := s.statChanged(ctx, sourceRef)
 	if err := s.storage.Move(ctx, sourceRef, targetRef); err != nil {
 		var st *rpc.Status
 		switch err.(type) {
@@ -540,6 +1051,19 @@ func (s *service) Move(ctx context.Context, req *provider.MoveRequest) (*provide
 		}, nil
 	}
 
+	if src != nil {
+		if md := s.statChanged(ctx, targetRef); md != nil {
+			s.publishChange(ctx, events.ItemMoved{
+				Executant:  executant(ctx),
+				Owner:      md.Owner,
+				ResourceID: md.Id,
+				OldPath:    src.Path,
+				Path:       md.Path,
+				Time:       time.Now(),
+			})
+		}
+	}
+
 	res := &provider.MoveResponse{
 		Status: status.NewOK(ctx),
 	}
@@ -582,6 +1106,10 @@ func (s *service) Stat(ctx context.Context, req *provider.StatRequest) (*provide
 			Status: status.NewInternal(ctx, err, "error wrapping path"),
 		}, nil
 	}
+	if len(req.ArbitraryMetadataKeys) == 0 {
+		// the version only covers the whole metadata
+		md.Opaque = setVersion(md.Opaque, metadataVersionKey, metadataVersion(md.ArbitraryMetadata))
+	}
 	res := &provider.StatResponse{
 		Status: status.NewOK(ctx),
 		Info:   md,
@@ -658,7 +1186,12 @@ func (s *service) ListContainer(ctx context.Context, req *provider.ListContainer
 		}, nil
 	}
 
-	mds, err := s.storage.ListFolder(ctx, newRef, req.ArbitraryMetadataKeys)
+	var mds []*provider.ResourceInfo
+	if tag := opaqueString(req.Opaque, tags.ListOpaqueKey); tag != "" {
+		mds, err = s.listByTag(ctx, newRef, tag, req.ArbitraryMetadataKeys)
+	} else {
+		mds, err = s.storage.ListFolder(ctx, newRef, req.ArbitraryMetadataKeys)
+	}
 	if err != nil {
 		var st *rpc.Status
 		switch err.(type) {
@@ -729,6 +1262,16 @@ func (s *service) RestoreFileVersion(ctx context.Context, req *provider.RestoreF
 		}, nil
 	}
 
+	if restorePath := opaqueString(req.Opaque, restorePathKey); restorePath != "" {
+		return s.restoreFileVersionTo(ctx, newRef, req.Key, restorePath)
+	}
+
+	if err := s.checkRetention(ctx, newRef); err != nil {
+		return &provider.RestoreFileVersionResponse{
+			Status: status.NewStatusFromErrType(ctx, "error restoring version", err),
+		}, nil
+	}
+
 	if err := s.storage.RestoreRevision(ctx, newRef, req.Key); err != nil {
 		var st *rpc.Status
 		switch err.(type) {
@@ -750,6 +1293,37 @@ func (s *service) RestoreFileVersion(ctx context.Context, req *provider.RestoreF
 	return res, nil
 }
 
+// restorePathKey is the opaque entry of the RestoreFileVersionRequest giving
+// the path to restore the version to, instead of replacing the current one.
+const restorePathKey = "restore_path"
+
+// restoreFileVersionTo restores the revision as a new file at the given path,
+// which must belong to the same storage provider.
+func (s *service) restoreFileVersionTo(ctx context.Context, ref *provider.Reference, key, restorePath string) (*provider.RestoreFileVersionResponse, error) {
+	rr, ok := s.storage.(storage.RevisionRestorer)
+	if !ok {
+		return &provider.RestoreFileVersionResponse{
+			Status: status.NewUnimplemented(ctx, nil, "restoring versions to another location is not supported by the storage driver"),
+		}, nil
+	}
+
+	target, err := s.unwrap(ctx, &provider.Reference{Spec: &provider.Reference_Path{Path: restorePath}})
+	if err != nil {
+		return &provider.RestoreFileVersionResponse{
+			Status: status.NewInvalidArg(ctx, "restore path is outside of the storage: "+restorePath),
+		}, nil
+	}
+
+	if err := rr.RestoreRevisionTo(ctx, ref, key, target); err != nil {
+		return &provider.RestoreFileVersionResponse{
+			Status: status.NewStatusFromErrType(ctx, "error restoring version to "+restorePath, err),
+		}, nil
+	}
+	return &provider.RestoreFileVersionResponse{
+		Status: status.NewOK(ctx),
+	}, nil
+}
+
 func (s *service) ListRecycleStream(req *provider.ListRecycleStreamRequest, ss provider.ProviderAPI_ListRecycleStreamServer) error {
 	ctx := ss.Context()
 	log := appctx.GetLogger(ctx)
@@ -790,8 +1364,22 @@ func (s *service) ListRecycleStream(req *provider.ListRecycleStreamRequest, ss p
 }
 
 func (s *service) ListRecycle(ctx context.Context, req *provider.ListRecycleRequest) (*provider.ListRecycleResponse, error) {
-	items, err := s.storage.ListRecycle(ctx)
+	var pageSize int
+	if v := opaqueString(req.Opaque, recyclePageSizeKey); v != "" {
+		var err error
+		if pageSize, err = strconv.Atoi(v); err != nil || pageSize < 0 {
+			return &provider.ListRecycleResponse{
+				Status: status.NewInvalidArg(ctx, "invalid page size"),
+			}, nil
+		}
+	}
+
+	items, err := s.listRecycle(ctx, recycleFilter(req))
 	// TODO(labkode): CRITICAL: fill recycle info with storage provider.
+	var next string
+	if err == nil {
+		items, next, err = paginateRecycle(items, pageSize, opaqueString(req.Opaque, recyclePageTokenKey))
+	}
 	if err != nil {
 		var st *rpc.Status
 		switch err.(type) {
@@ -799,6 +1387,8 @@ func (s *service) ListRecycle(ctx context.Context, req *provider.ListRecycleRequ
 			st = status.NewNotFound(ctx, "path not found when listing recycle")
 		case errtypes.PermissionDenied:
 			st = status.NewPermissionDenied(ctx, err, "permission denied")
+		case errtypes.BadRequest:
+			st = status.NewInvalidArg(ctx, err.Error())
 		default:
 			st = status.NewInternal(ctx, err, "error listing recycle")
 		}
@@ -811,6 +1401,13 @@ func (s *service) ListRecycle(ctx context.Context, req *provider.ListRecycleRequ
 		Status:       status.NewOK(ctx),
 		RecycleItems: items,
 	}
+	if next != "" {
+		res.Opaque = &types.Opaque{
+			Map: map[string]*types.OpaqueEntry{
+				recycleNextPageTokenKey: {Decoder: "plain", Value: []byte(next)},
+			},
+		}
+	}
 	return res, nil
 }
 
@@ -900,9 +1497,16 @@ func (s *service) ListGrants(ctx context.Context, req *provider.ListGrantsReques
 		}, nil
 	}
 
+	version, err := grantsVersion(grants)
+	if err != nil {
+		return &provider.ListGrantsResponse{
+			Status: status.NewInternal(ctx, err, "error computing grants version"),
+		}, nil
+	}
 	res := &provider.ListGrantsResponse{
 		Status: status.NewOK(ctx),
 		Grants: grants,
+		Opaque: setVersion(nil, grantsVersionKey, version),
 	}
 	return res, nil
 }
@@ -922,6 +1526,16 @@ func (s *service) AddGrant(ctx context.Context, req *provider.AddGrantRequest) (
 		}, nil
 	}
 
+	defer s.lockResource(ctx, newRef)()
+	if expected, ok := ifMatch(req.Opaque); ok {
+		if current, err := s.checkGrantsVersion(ctx, newRef, expected); err != nil {
+			return &provider.AddGrantResponse{
+				Status: status.NewStatusFromErrType(ctx, "error setting grants", err),
+				Opaque: setVersion(nil, grantsVersionKey, current),
+			}, nil
+		}
+	}
+
 	err = s.storage.AddGrant(ctx, newRef, req.Grant)
 	if err != nil {
 		var st *rpc.Status
@@ -959,6 +1573,16 @@ func (s *service) UpdateGrant(ctx context.Context, req *provider.UpdateGrantRequ
 		}, nil
 	}
 
+	defer s.lockResource(ctx, newRef)()
+	if expected, ok := ifMatch(req.Opaque); ok {
+		if current, err := s.checkGrantsVersion(ctx, newRef, expected); err != nil {
+			return &provider.UpdateGrantResponse{
+				Status: status.NewStatusFromErrType(ctx, "error updating grant", err),
+				Opaque: setVersion(nil, grantsVersionKey, current),
+			}, nil
+		}
+	}
+
 	if err := s.storage.UpdateGrant(ctx, newRef, req.Grant); err != nil {
 		var st *rpc.Status
 		switch err.(type) {
@@ -995,6 +1619,16 @@ func (s *service) RemoveGrant(ctx context.Context, req *provider.RemoveGrantRequ
 		}, nil
 	}
 
+	defer s.lockResource(ctx, newRef)()
+	if expected, ok := ifMatch(req.Opaque); ok {
+		if current, err := s.checkGrantsVersion(ctx, newRef, expected); err != nil {
+			return &provider.RemoveGrantResponse{
+				Status: status.NewStatusFromErrType(ctx, "error removing grant", err),
+				Opaque: setVersion(nil, grantsVersionKey, current),
+			}, nil
+		}
+	}
+
 	if err := s.storage.RemoveGrant(ctx, newRef, req.Grant); err != nil {
 		var st *rpc.Status
 		switch err.(type) {
@@ -1069,7 +1703,21 @@ func (s *service) CreateSymlink(ctx context.Context, req *provider.CreateSymlink
 }
 
 func (s *service) GetQuota(ctx context.Context, req *provider.GetQuotaRequest) (*provider.GetQuotaResponse, error) {
-	total, used, err := s.storage.GetQuota(ctx)
+	ref, err := storage.QuotaRefFromOpaque(req.Opaque)
+	if err != nil {
+		return &provider.GetQuotaResponse{
+			Status: status.NewInvalidArg(ctx, "invalid reference in the opaque"),
+		}, nil
+	}
+	if ref != nil {
+		if ref, err = s.unwrap(ctx, ref); err != nil {
+			return &provider.GetQuotaResponse{
+				Status: status.NewInternal(ctx, err, "error unwrapping path"),
+			}, nil
+		}
+	}
+
+	total, used, err := s.getQuota(ctx, ref)
 	if err != nil {
 		var st *rpc.Status
 		switch err.(type) {
@@ -1095,7 +1743,14 @@ func (s *service) GetQuota(ctx context.Context, req *provider.GetQuotaRequest) (
 
 func getFS(c *config) (storage.FS, error) {
 	if f, ok := registry.NewFuncs[c.Driver]; ok {
-		return f(c.Drivers[c.Driver])
+		fs, err := f(c.Drivers[c.Driver])
+		if err != nil {
+			return nil, err
+		}
+		return fsmetrics.New(c.Driver, fs, fsmetrics.Options{
+			SlowThreshold: time.Duration(c.SlowThreshold) * time.Millisecond,
+			Endpoint:      fsmetrics.Endpoint(c.Drivers[c.Driver]),
+		})
 	}
 	return nil, errtypes.NotFound("driver not found: " + c.Driver)
 }
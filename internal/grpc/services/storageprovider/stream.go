@@ -0,0 +1,153 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package storageprovider
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	streampb "github.com/cs3org/reva/internal/grpc/services/storageprovider/proto"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
+)
+
+// streamChunkSize is the size of the data chunks sent by Download.
+const streamChunkSize = 64 * 1024
+
+// Download streams the content of a file no larger than the stream
+// threshold, sending its size and etag in the first message.
+func (s *service) Download(req *streampb.DownloadRequest, stream streampb.StreamAPI_DownloadServer) error {
+	ctx := stream.Context()
+	if s.conf.StreamMaxSize <= 0 {
+		return grpcstatus.Error(codes.Unimplemented, "streaming is disabled")
+	}
+	ref, err := s.unwrap(ctx, RefFromProto(req.Ref))
+	if err != nil {
+		return grpcstatus.Error(codes.InvalidArgument, err.Error())
+	}
+	md, err := s.storage.GetMD(ctx, ref, nil)
+	if err != nil {
+		return lockError(err)
+	}
+	if md.Type != provider.ResourceType_RESOURCE_TYPE_FILE {
+		return grpcstatus.Error(codes.InvalidArgument, "not a file")
+	}
+	if int64(md.Size) > s.conf.StreamMaxSize {
+		return grpcstatus.Error(codes.FailedPrecondition, "the file is too large to be streamed")
+	}
+
+	r, err := s.storage.Download(ctx, ref)
+	if err != nil {
+		return lockError(err)
+	}
+	defer r.Close()
+
+	res := &streampb.DownloadResponse{Size: md.Size, Etag: md.Etag}
+	buf := make([]byte, streamChunkSize)
+	for first := true; ; first = false {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 || first {
+			res.Data = buf[:n]
+			if err := stream.Send(res); err != nil {
+				return err
+			}
+			res = &streampb.DownloadResponse{}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil
+		}
+		if err != nil {
+			return grpcstatus.Error(codes.Internal, err.Error())
+		}
+	}
+}
+
+// Upload replaces the content of a file with the chunks of the stream. The
+// file is buffered in memory, which is bounded by the stream threshold, and
+// written with the same checks as the uploads of the data provider.
+func (s *service) Upload(stream streampb.StreamAPI_UploadServer) error {
+	ctx := stream.Context()
+	if s.conf.StreamMaxSize <= 0 {
+		return grpcstatus.Error(codes.Unimplemented, "streaming is disabled")
+	}
+	req, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	size := int64(req.Size)
+	if size > s.conf.StreamMaxSize {
+		return grpcstatus.Error(codes.FailedPrecondition, "the file is too large to be streamed")
+	}
+	ref, err := s.unwrap(ctx, RefFromProto(req.Ref))
+	if err != nil {
+		return grpcstatus.Error(codes.InvalidArgument, err.Error())
+	}
+	if ref.GetPath() == "/" {
+		return grpcstatus.Error(codes.InvalidArgument, "can't upload to mount path")
+	}
+	if err := s.checkRetention(ctx, ref); err != nil {
+		return lockError(err)
+	}
+	if err := s.checkQuota(ctx, ref, size); err != nil {
+		return grpcstatus.Error(codes.ResourceExhausted, err.Error())
+	}
+
+	var buf bytes.Buffer
+	for {
+		if int64(buf.Len()+len(req.Data)) > size {
+			return grpcstatus.Error(codes.InvalidArgument, "the stream is larger than the announced size")
+		}
+		buf.Write(req.Data)
+		req, err = stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if int64(buf.Len()) != size {
+		return grpcstatus.Error(codes.InvalidArgument, "the stream is smaller than the announced size")
+	}
+
+	ids, err := s.storage.InitiateUpload(ctx, ref, size, nil)
+	if err != nil {
+		return lockError(err)
+	}
+	id, ok := ids["simple"]
+	if !ok {
+		return grpcstatus.Error(codes.Unimplemented, "the storage driver does not support simple uploads")
+	}
+	err = s.storage.Upload(ctx, &provider.Reference{Spec: &provider.Reference_Path{Path: id}}, ioutil.NopCloser(&buf))
+	if err != nil {
+		if _, ok := err.(errtypes.IsInsufficientStorage); ok {
+			return grpcstatus.Error(codes.ResourceExhausted, err.Error())
+		}
+		return lockError(err)
+	}
+
+	md, err := s.storage.GetMD(ctx, ref, nil)
+	if err != nil {
+		return lockError(err)
+	}
+	return stream.SendAndClose(&streampb.UploadResponse{Etag: md.Etag})
+}
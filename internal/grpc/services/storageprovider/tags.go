@@ -0,0 +1,56 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package storageprovider
+
+import (
+	"context"
+
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/tags"
+)
+
+// listByTag returns the resources of the subtree of ref having the tag. The
+// folders of the subtree which cannot be listed are skipped.
+func (s *service) listByTag(ctx context.Context, ref *provider.Reference, tag string, mdKeys []string) ([]*provider.ResourceInfo, error) {
+	log := appctx.GetLogger(ctx)
+	keys := append([]string{tags.MetadataKey}, mdKeys...)
+
+	matches := []*provider.ResourceInfo{}
+	queue := []*provider.Reference{ref}
+	for i := 0; i < len(queue); i++ {
+		mds, err := s.storage.ListFolder(ctx, queue[i], keys)
+		if err != nil {
+			if i == 0 {
+				return nil, err
+			}
+			log.Debug().Err(err).Interface("ref", queue[i]).Msg("storageprovider: skipping folder when listing by tag")
+			continue
+		}
+		for _, md := range mds {
+			if tags.Has(md.GetArbitraryMetadata().GetMetadata()[tags.MetadataKey], tag) {
+				matches = append(matches, md)
+			}
+			if md.Type == provider.ResourceType_RESOURCE_TYPE_CONTAINER {
+				queue = append(queue, &provider.Reference{Spec: &provider.Reference_Id{Id: md.Id}})
+			}
+		}
+	}
+	return matches, nil
+}
@@ -21,13 +21,21 @@ package userprovider
 import (
 	"context"
 	"fmt"
+	"time"
 
 	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/pkg/cache"
+	cacheregistry "github.com/cs3org/reva/pkg/cache/driver/registry"
 	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/guest"
+	guestregistry "github.com/cs3org/reva/pkg/guest/manager/registry"
+	"github.com/cs3org/reva/pkg/idalloc"
+	idallocregistry "github.com/cs3org/reva/pkg/idalloc/manager/registry"
 	"github.com/cs3org/reva/pkg/rgrpc"
 	"github.com/cs3org/reva/pkg/rgrpc/status"
 	"github.com/cs3org/reva/pkg/user"
 	"github.com/cs3org/reva/pkg/user/manager/registry"
+	"github.com/cs3org/reva/pkg/user/mapping"
 	"github.com/mitchellh/mapstructure"
 	"github.com/pkg/errors"
 	"google.golang.org/grpc"
@@ -40,6 +48,20 @@ func init() {
 type config struct {
 	Driver  string                            `mapstructure:"driver"`
 	Drivers map[string]map[string]interface{} `mapstructure:"drivers"`
+	// Mapping normalizes the attributes of the users returned by the driver.
+	Mapping map[string]interface{} `mapstructure:"mapping"`
+	// IDAllocator allocates the uid and gid numbers of the users lacking them.
+	IDAllocator  string                            `mapstructure:"id_allocator"`
+	IDAllocators map[string]map[string]interface{} `mapstructure:"id_allocators"`
+	// CacheTTL is the number of seconds the users are cached, 0 disabling
+	// the cache.
+	CacheTTL int `mapstructure:"cache_ttl"`
+	// Cache configures the driver of the user cache, e.g. redis for it to be
+	// shared by the replicas of the service.
+	Cache map[string]interface{} `mapstructure:"cache"`
+	// GuestManager resolves the guest accounts created for external sharees.
+	GuestManager  string                            `mapstructure:"guest_manager"`
+	GuestManagers map[string]map[string]interface{} `mapstructure:"guest_managers"`
 }
 
 func (c *config) init() {
@@ -59,11 +81,50 @@ func parseConfig(m map[string]interface{}) (*config, error) {
 }
 
 func getDriver(c *config) (user.Manager, error) {
-	if f, ok := registry.NewFuncs[c.Driver]; ok {
-		return f(c.Drivers[c.Driver])
+	f, ok := registry.NewFuncs[c.Driver]
+	if !ok {
+		return nil, errtypes.NotFound(fmt.Sprintf("driver %s not found for user manager", c.Driver))
 	}
 
-	return nil, errtypes.NotFound(fmt.Sprintf("driver %s not found for user manager", c.Driver))
+	mgr, err := f(c.Drivers[c.Driver])
+	if err != nil {
+		return nil, err
+	}
+
+	if len(c.Mapping) > 0 {
+		mc, err := mapping.ParseConfig(c.Mapping)
+		if err != nil {
+			return nil, err
+		}
+		if mgr, err = mapping.New(mc, mgr); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.IDAllocator != "" {
+		f, ok := idallocregistry.NewFuncs[c.IDAllocator]
+		if !ok {
+			return nil, errtypes.NotFound(fmt.Sprintf("id allocator %s not found", c.IDAllocator))
+		}
+		a, err := f(c.IDAllocators[c.IDAllocator])
+		if err != nil {
+			return nil, err
+		}
+		mgr = idalloc.NewUserManager(mgr, a)
+	}
+
+	if c.GuestManager != "" {
+		f, ok := guestregistry.NewFuncs[c.GuestManager]
+		if !ok {
+			return nil, errtypes.NotFound(fmt.Sprintf("guest manager %s not found", c.GuestManager))
+		}
+		g, err := f(c.GuestManagers[c.GuestManager])
+		if err != nil {
+			return nil, err
+		}
+		mgr = guest.NewUserManager(mgr, g)
+	}
+	return mgr, nil
 }
 
 // New returns a new UserProviderServiceServer.
@@ -80,11 +141,20 @@ func New(m map[string]interface{}, ss *grpc.Server) (rgrpc.Service, error) {
 
 	svc := &service{usermgr: userManager}
 
+	if c.CacheTTL > 0 {
+		uc, err := cacheregistry.NewCache("users", c.Cache)
+		if err != nil {
+			return nil, err
+		}
+		svc.cache = cache.NewUserCache(uc, time.Duration(c.CacheTTL)*time.Second)
+	}
+
 	return svc, nil
 }
 
 type service struct {
 	usermgr user.Manager
+	cache   *cache.UserCache
 }
 
 func (s *service) Close() error {
@@ -100,6 +170,16 @@ func (s *service) Register(ss *grpc.Server) {
 }
 
 func (s *service) GetUser(ctx context.Context, req *userpb.GetUserRequest) (*userpb.GetUserResponse, error) {
+	key := req.UserId.GetIdp() + "!" + req.UserId.GetOpaqueId()
+	if s.cache != nil {
+		if user, ok := s.cache.Get(ctx, key); ok {
+			return &userpb.GetUserResponse{
+				Status: status.NewOK(ctx),
+				User:   user,
+			}, nil
+		}
+	}
+
 	user, err := s.usermgr.GetUser(ctx, req.UserId)
 	if err != nil {
 		// TODO(labkode): check for not found.
@@ -110,6 +190,10 @@ func (s *service) GetUser(ctx context.Context, req *userpb.GetUserRequest) (*use
 		return res, nil
 	}
 
+	if s.cache != nil {
+		_ = s.cache.Set(ctx, key, user)
+	}
+
 	res := &userpb.GetUserResponse{
 		Status: status.NewOK(ctx),
 		User:   user,
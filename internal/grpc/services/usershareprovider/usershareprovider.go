@@ -20,16 +20,21 @@ package usershareprovider
 
 import (
 	"context"
+	"path"
+	"time"
 
 	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
 	collaboration "github.com/cs3org/go-cs3apis/cs3/sharing/collaboration/v1beta1"
 	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	"github.com/cs3org/reva/pkg/appctx"
 	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/events"
+	eventsregistry "github.com/cs3org/reva/pkg/events/driver/registry"
 	"github.com/cs3org/reva/pkg/rgrpc"
 	"github.com/cs3org/reva/pkg/rgrpc/status"
 	"github.com/cs3org/reva/pkg/share"
 	"github.com/cs3org/reva/pkg/share/manager/registry"
+	"github.com/cs3org/reva/pkg/tenant"
 	"github.com/cs3org/reva/pkg/user"
 	"github.com/mitchellh/mapstructure"
 	"github.com/pkg/errors"
@@ -43,6 +48,8 @@ func init() {
 type config struct {
 	Driver  string                            `mapstructure:"driver"`
 	Drivers map[string]map[string]interface{} `mapstructure:"drivers"`
+	// Events configures the bus the ShareCreated events are published to.
+	Events map[string]interface{} `mapstructure:"events"`
 }
 
 func (c *config) init() {
@@ -54,6 +61,10 @@ func (c *config) init() {
 type service struct {
 	conf *config
 	sm   share.Manager
+	// tenantManagers holds the share managers of the tenants storing their
+	// shares apart, by tenant id.
+	tenantManagers map[string]share.Manager
+	stream         events.Stream
 }
 
 func getShareManager(c *config) (share.Manager, error) {
@@ -100,14 +111,45 @@ func New(m map[string]interface{}, ss *grpc.Server) (rgrpc.Service, error) {
 		return nil, err
 	}
 
+	tenantManagers := map[string]share.Manager{}
+	for _, t := range tenant.All() {
+		if t.Shares == nil {
+			continue
+		}
+		f, ok := registry.NewFuncs[c.Driver]
+		if !ok {
+			return nil, errtypes.NotFound("driver not found: " + c.Driver)
+		}
+		if tenantManagers[t.ID], err = f(t.Shares); err != nil {
+			return nil, errors.Wrap(err, "error creating share manager of tenant "+t.ID)
+		}
+	}
+
+	stream, err := eventsregistry.NewStream(c.Events)
+	if err != nil {
+		return nil, err
+	}
+
 	service := &service{
-		conf: c,
-		sm:   sm,
+		conf:           c,
+		sm:             sm,
+		tenantManagers: tenantManagers,
+		stream:         stream,
 	}
 
 	return service, nil
 }
 
+// manager returns the share manager of the tenant of the context.
+func (s *service) manager(ctx context.Context) share.Manager {
+	if t, ok := tenant.ContextGetTenant(ctx); ok {
+		if sm, ok := s.tenantManagers[t.ID]; ok {
+			return sm
+		}
+	}
+	return s.sm
+}
+
 func (s *service) CreateShare(ctx context.Context, req *collaboration.CreateShareRequest) (*collaboration.CreateShareResponse, error) {
 	u := user.ContextMustGetUser(ctx)
 	if req.Grant.Grantee.Type == provider.GranteeType_GRANTEE_TYPE_USER && req.Grant.Grantee.GetUserId().Idp == "" {
@@ -115,13 +157,25 @@ func (s *service) CreateShare(ctx context.Context, req *collaboration.CreateShar
 		g := &userpb.UserId{OpaqueId: req.Grant.Grantee.GetUserId().OpaqueId, Idp: u.Id.Idp}
 		req.Grant.Grantee.Id = &provider.Grantee_UserId{UserId: g}
 	}
-	share, err := s.sm.Share(ctx, req.ResourceInfo, req.Grant)
+	share, err := s.manager(ctx).Share(ctx, req.ResourceInfo, req.Grant)
 	if err != nil {
 		return &collaboration.CreateShareResponse{
 			Status: status.NewInternal(ctx, err, "error creating share"),
 		}, nil
 	}
 
+	if err := events.Publish(ctx, s.stream, events.ShareCreated{
+		ShareID:        share.GetId().GetOpaqueId(),
+		Sharer:         u.Id,
+		GranteeUserID:  req.Grant.Grantee.GetUserId(),
+		GranteeGroupID: req.Grant.Grantee.GetGroupId(),
+		ResourceID:     req.ResourceInfo.GetId(),
+		ResourceName:   path.Base(req.ResourceInfo.GetPath()),
+		Time:           time.Now(),
+	}); err != nil {
+		appctx.GetLogger(ctx).Error().Err(err).Msg("error publishing event")
+	}
+
 	res := &collaboration.CreateShareResponse{
 		Status: status.NewOK(ctx),
 		Share:  share,
@@ -130,7 +184,7 @@ func (s *service) CreateShare(ctx context.Context, req *collaboration.CreateShar
 }
 
 func (s *service) RemoveShare(ctx context.Context, req *collaboration.RemoveShareRequest) (*collaboration.RemoveShareResponse, error) {
-	err := s.sm.Unshare(ctx, req.Ref)
+	err := s.manager(ctx).Unshare(ctx, req.Ref)
 	if err != nil {
 		return &collaboration.RemoveShareResponse{
 			Status: status.NewInternal(ctx, err, "error removing share"),
@@ -143,7 +197,7 @@ func (s *service) RemoveShare(ctx context.Context, req *collaboration.RemoveShar
 }
 
 func (s *service) GetShare(ctx context.Context, req *collaboration.GetShareRequest) (*collaboration.GetShareResponse, error) {
-	share, err := s.sm.GetShare(ctx, req.Ref)
+	share, err := s.manager(ctx).GetShare(ctx, req.Ref)
 	if err != nil {
 		return &collaboration.GetShareResponse{
 			Status: status.NewInternal(ctx, err, "error getting share"),
@@ -157,7 +211,7 @@ func (s *service) GetShare(ctx context.Context, req *collaboration.GetShareReque
 }
 
 func (s *service) ListShares(ctx context.Context, req *collaboration.ListSharesRequest) (*collaboration.ListSharesResponse, error) {
-	shares, err := s.sm.ListShares(ctx, req.Filters) // TODO(labkode): add filter to share manager
+	shares, err := s.manager(ctx).ListShares(ctx, req.Filters) // TODO(labkode): add filter to share manager
 	if err != nil {
 		return &collaboration.ListSharesResponse{
 			Status: status.NewInternal(ctx, err, "error listing shares"),
@@ -172,7 +226,7 @@ func (s *service) ListShares(ctx context.Context, req *collaboration.ListSharesR
 }
 
 func (s *service) UpdateShare(ctx context.Context, req *collaboration.UpdateShareRequest) (*collaboration.UpdateShareResponse, error) {
-	share, err := s.sm.UpdateShare(ctx, req.Ref, req.Field.GetPermissions()) // TODO(labkode): check what to update
+	share, err := s.manager(ctx).UpdateShare(ctx, req.Ref, req.Field.GetPermissions()) // TODO(labkode): check what to update
 	if err != nil {
 		return &collaboration.UpdateShareResponse{
 			Status: status.NewInternal(ctx, err, "error updating share"),
@@ -187,7 +241,7 @@ func (s *service) UpdateShare(ctx context.Context, req *collaboration.UpdateShar
 }
 
 func (s *service) ListReceivedShares(ctx context.Context, req *collaboration.ListReceivedSharesRequest) (*collaboration.ListReceivedSharesResponse, error) {
-	shares, err := s.sm.ListReceivedShares(ctx) // TODO(labkode): check what to update
+	shares, err := s.manager(ctx).ListReceivedShares(ctx) // TODO(labkode): check what to update
 	if err != nil {
 		return &collaboration.ListReceivedSharesResponse{
 			Status: status.NewInternal(ctx, err, "error listing received shares"),
@@ -204,7 +258,7 @@ func (s *service) ListReceivedShares(ctx context.Context, req *collaboration.Lis
 func (s *service) GetReceivedShare(ctx context.Context, req *collaboration.GetReceivedShareRequest) (*collaboration.GetReceivedShareResponse, error) {
 	log := appctx.GetLogger(ctx)
 
-	share, err := s.sm.GetReceivedShare(ctx, req.Ref)
+	share, err := s.manager(ctx).GetReceivedShare(ctx, req.Ref)
 	if err != nil {
 		log.Err(err).Msg("error getting received share")
 		return &collaboration.GetReceivedShareResponse{
@@ -220,7 +274,7 @@ func (s *service) GetReceivedShare(ctx context.Context, req *collaboration.GetRe
 }
 
 func (s *service) UpdateReceivedShare(ctx context.Context, req *collaboration.UpdateReceivedShareRequest) (*collaboration.UpdateReceivedShareResponse, error) {
-	share, err := s.sm.UpdateReceivedShare(ctx, req.Ref, req.Field) // TODO(labkode): check what to update
+	share, err := s.manager(ctx).UpdateReceivedShare(ctx, req.Ref, req.Field) // TODO(labkode): check what to update
 	if err != nil {
 		return &collaboration.UpdateReceivedShareResponse{
 			Status: status.NewInternal(ctx, err, "error updating received share"),
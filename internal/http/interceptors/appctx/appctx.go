@@ -25,30 +25,48 @@ import (
 	"net/http"
 
 	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/logger"
+	"github.com/cs3org/reva/pkg/rhttp/router"
 	"github.com/rs/zerolog"
-	"go.opencensus.io/trace"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // New returns a new HTTP middleware that stores the log
-// in the context with request ID information.
-func New(log zerolog.Logger) func(http.Handler) http.Handler {
+// in the context with request ID information. The services map the prefixes
+// of the services to their names, which the log levels are set for.
+func New(log zerolog.Logger, services map[string]string) func(http.Handler) http.Handler {
 	chain := func(h http.Handler) http.Handler {
-		return handler(log, h)
+		return handler(log, services, h)
 	}
 	return chain
 }
 
-func handler(log zerolog.Logger, h http.Handler) http.Handler {
+func handler(log zerolog.Logger, services map[string]string, h http.Handler) http.Handler {
 
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		ctx := r.Context()
 
 		// trace is set on the httpserver.go file as the outermost wrapper handler.
-		span := trace.FromContext(ctx)
-		sub := log.With().Str("traceid", span.SpanContext().TraceID.String()).Logger()
+		traceID := trace.SpanContextFromContext(ctx).TraceID()
+		sub := log
+		if svc, ok := serviceName(r.URL.Path, services); ok {
+			sub = logger.ForService(log, svc)
+		}
+		sub = sub.With().Str("traceid", traceID.String()).Logger()
 		ctx = appctx.WithLogger(ctx, &sub)
 
 		r = r.WithContext(ctx)
 		h.ServeHTTP(w, r)
 	})
 }
+
+// serviceName returns the name of the service the path is routed to.
+func serviceName(p string, services map[string]string) (string, bool) {
+	head, _ := router.ShiftPath(p)
+	if name, ok := services[head]; ok {
+		return name, true
+	}
+	// when a service is exposed at the root.
+	name, ok := services[""]
+	return name, ok
+}
@@ -34,8 +34,10 @@ import (
 	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
 	"github.com/cs3org/reva/pkg/rhttp/global"
 	"github.com/cs3org/reva/pkg/sharedconf"
+	"github.com/cs3org/reva/pkg/tenant"
 	"github.com/cs3org/reva/pkg/token"
 	tokenmgr "github.com/cs3org/reva/pkg/token/manager/registry"
+	rtrace "github.com/cs3org/reva/pkg/trace"
 	"github.com/cs3org/reva/pkg/user"
 	"github.com/cs3org/reva/pkg/utils"
 	"github.com/mitchellh/mapstructure"
@@ -43,6 +45,10 @@ import (
 	"google.golang.org/grpc/metadata"
 )
 
+// defaultPriority places the authentication after the middlewares
+// identifying, counting and filtering the clients, and before the others.
+const defaultPriority = 30
+
 type config struct {
 	Priority   int    `mapstructure:"priority"`
 	GatewaySvc string `mapstructure:"gatewaysvc"`
@@ -69,15 +75,19 @@ func parseConfig(m map[string]interface{}) (*config, error) {
 }
 
 // New returns a new middleware with defined priority.
-func New(m map[string]interface{}, unprotected []string) (global.Middleware, error) {
+func New(m map[string]interface{}, unprotected []string) (global.Middleware, int, error) {
 	conf, err := parseConfig(m)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	conf.GatewaySvc = sharedconf.GetGatewaySVC(conf.GatewaySvc)
 
 	// set defaults
+	if conf.Priority == 0 {
+		conf.Priority = defaultPriority
+	}
+
 	if conf.TokenStrategy == "" {
 		conf.TokenStrategy = "header"
 	}
@@ -102,44 +112,44 @@ func New(m map[string]interface{}, unprotected []string) (global.Middleware, err
 	for i, key := range conf.CredentialChain {
 		f, ok := registry.NewCredentialFuncs[conf.CredentialChain[i]]
 		if !ok {
-			return nil, fmt.Errorf("credential strategy not found: %s", conf.CredentialChain[i])
+			return nil, 0, fmt.Errorf("credential strategy not found: %s", conf.CredentialChain[i])
 		}
 
 		credStrategy, err := f(conf.CredentialStrategies[conf.CredentialChain[i]])
 		if err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 		credChain[key] = credStrategy
 	}
 
 	g, ok := tokenregistry.NewTokenFuncs[conf.TokenStrategy]
 	if !ok {
-		return nil, fmt.Errorf("token strategy not found: %s", conf.TokenStrategy)
+		return nil, 0, fmt.Errorf("token strategy not found: %s", conf.TokenStrategy)
 	}
 
 	tokenStrategy, err := g(conf.TokenStrategies[conf.TokenStrategy])
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	h, ok := tokenmgr.NewFuncs[conf.TokenManager]
 	if !ok {
-		return nil, fmt.Errorf("token manager not found: %s", conf.TokenStrategy)
+		return nil, 0, fmt.Errorf("token manager not found: %s", conf.TokenStrategy)
 	}
 
 	tokenManager, err := h(conf.TokenManagers[conf.TokenManager])
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	i, ok := tokenwriterregistry.NewTokenFuncs[conf.TokenWriter]
 	if !ok {
-		return nil, fmt.Errorf("token writer not found: %s", conf.TokenWriter)
+		return nil, 0, fmt.Errorf("token writer not found: %s", conf.TokenWriter)
 	}
 
 	tokenWriter, err := i(conf.TokenWriters[conf.TokenWriter])
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 
 	chain := func(h http.Handler) http.Handler {
@@ -155,6 +165,13 @@ func New(m map[string]interface{}, unprotected []string) (global.Middleware, err
 
 			log := appctx.GetLogger(ctx)
 
+			// the tenant served at the host name the request was sent to
+			t, hostTenant := tenant.ByHost(r.Host)
+			if hostTenant {
+				ctx = tenant.ContextSetTenant(ctx, t)
+				r = r.WithContext(ctx)
+			}
+
 			// skip auth for urls set in the config.
 			// TODO(labkode): maybe use method:url to bypass auth.
 			if utils.Skip(r.URL.Path, unprotected) {
@@ -252,16 +269,38 @@ func New(m map[string]interface{}, unprotected []string) (global.Middleware, err
 				return
 			}
 
+			// the users of the other tenants are refused at the host names of a tenant
+			if hostTenant {
+				if err := t.CheckUser(u.Id); err != nil {
+					log.Warn().Err(err).Str("host", r.Host).Msg("user refused by tenant")
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+			}
+
 			// store user and core access token in context.
 			ctx = user.ContextSetUser(ctx, u)
+			appctx.WithLoggerField(ctx, "userid", u.Id.GetOpaqueId())
 			ctx = token.ContextSetToken(ctx, tkn)
+			rtrace.SetUserAttributes(ctx, u)
+
+			// calls made on behalf of another user are tagged with both identities.
+			if impersonator, ok := scope.GetImpersonator(tokenScope); ok {
+				l := log.With().
+					Interface("user_id", u.Id).
+					Interface("impersonator_id", impersonator).
+					Logger()
+				l.Info().Str("method", r.Method).Str("path", r.URL.Path).Msg("impersonated request")
+				ctx = appctx.WithLogger(ctx, &l)
+				ctx = user.ContextSetImpersonator(ctx, impersonator)
+			}
 			ctx = metadata.AppendToOutgoingContext(ctx, token.TokenHeader, tkn) // TODO(jfd): hardcoded metadata key. use  PerRPCCredentials?
 
 			r = r.WithContext(ctx)
 			h.ServeHTTP(w, r)
 		})
 	}
-	return chain, nil
+	return chain, conf.Priority, nil
 }
 
 // getCredsForUserAgent returns the WWW Authenticate challenges keys to use given an http request
@@ -0,0 +1,85 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package clientcredentials
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+
+	"github.com/cs3org/reva/internal/http/interceptors/auth/credential/registry"
+	"github.com/cs3org/reva/pkg/auth"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	registry.Register("clientcredentials", New)
+}
+
+type config struct {
+	// TokenEndpoint is the path the clients post their grants to.
+	TokenEndpoint string `mapstructure:"token_endpoint"`
+}
+
+type strategy struct {
+	c *config
+}
+
+// New returns a new auth strategy that checks for OAuth2 client credentials
+// sent along a client_credentials grant.
+// See https://tools.ietf.org/html/rfc6749#section-4.4
+func New(m map[string]interface{}) (auth.CredentialStrategy, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, errors.Wrap(err, "error decoding conf")
+	}
+	if c.TokenEndpoint == "" {
+		c.TokenEndpoint = "/token"
+	}
+	return &strategy{c: c}, nil
+}
+
+func (s *strategy) GetCredentials(w http.ResponseWriter, r *http.Request) (*auth.Credentials, error) {
+	// the body of the other requests must be left untouched for their handlers
+	if r.Method != http.MethodPost || r.URL.Path != s.c.TokenEndpoint {
+		return nil, fmt.Errorf("not a request to the token endpoint")
+	}
+	if ct, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || ct != "application/x-www-form-urlencoded" {
+		return nil, fmt.Errorf("token request is not form encoded")
+	}
+	if r.PostFormValue("grant_type") != "client_credentials" {
+		return nil, fmt.Errorf("no client credentials grant provided")
+	}
+
+	// clients should authenticate with basic auth, but may send
+	// their credentials in the request body as well.
+	id, secret, ok := r.BasicAuth()
+	if !ok {
+		id, secret = r.PostFormValue("client_id"), r.PostFormValue("client_secret")
+	}
+	if id == "" || secret == "" {
+		return nil, fmt.Errorf("no client credentials provided")
+	}
+	return &auth.Credentials{Type: "clientcredentials", ClientID: id, ClientSecret: secret}, nil
+}
+
+func (s *strategy) AddWWWAuthenticate(w http.ResponseWriter, r *http.Request, realm string) {
+	// the basic strategy already adds the challenge for the client authentication
+}
@@ -0,0 +1,110 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package bodylimit
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/rhttp/global"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultPriority = 50
+)
+
+func init() {
+	global.RegisterMiddleware("bodylimit", New)
+}
+
+type config struct {
+	Priority int `mapstructure:"priority"`
+	// MaxBodySize is the maximum size in bytes of the request bodies.
+	MaxBodySize int64 `mapstructure:"max_body_size"`
+	// Prefixes is the list of URL path prefixes the limit applies to, for
+	// example /ocs or /remote.php. If empty, every request is limited.
+	Prefixes []string `mapstructure:"prefixes"`
+	// UploadPrefixes is the list of URL path prefixes of the TUS upload
+	// endpoints, whose chunks are not limited, their size being bound by the
+	// upload length declared at their creation. Defaults to the data gateway
+	// and the data provider.
+	UploadPrefixes []string `mapstructure:"upload_prefixes"`
+}
+
+// New returns a middleware rejecting the request bodies larger than the
+// configured size with 413 Request Entity Too Large.
+func New(m map[string]interface{}) (global.Middleware, int, error) {
+	conf := &config{}
+	if err := mapstructure.Decode(m, conf); err != nil {
+		return nil, 0, errors.Wrap(err, "bodylimit: error decoding conf")
+	}
+	if conf.Priority == 0 {
+		conf.Priority = defaultPriority
+	}
+	if conf.UploadPrefixes == nil {
+		conf.UploadPrefixes = []string{"/datagateway", "/data"}
+	}
+	if conf.MaxBodySize <= 0 {
+		return nil, 0, errors.New("bodylimit: max_body_size must be configured")
+	}
+
+	mw := func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !conf.matches(r.URL.Path) || conf.isTUSChunk(r) {
+				h.ServeHTTP(w, r)
+				return
+			}
+			if r.ContentLength > conf.MaxBodySize {
+				log := appctx.GetLogger(r.Context())
+				log.Warn().Int64("length", r.ContentLength).Str("path", r.URL.Path).Msg("bodylimit: request body too large")
+				w.WriteHeader(http.StatusRequestEntityTooLarge)
+				return
+			}
+			// the bodies without length, sent in chunks, fail once read
+			// beyond the limit.
+			r.Body = http.MaxBytesReader(w, r.Body, conf.MaxBodySize)
+			h.ServeHTTP(w, r)
+		})
+	}
+	return mw, conf.Priority, nil
+}
+
+func (c *config) isTUSChunk(r *http.Request) bool {
+	return r.Method == http.MethodPatch && r.Header.Get("Content-Type") == "application/offset+octet-stream" &&
+		hasPrefix(r.URL.Path, c.UploadPrefixes)
+}
+
+func (c *config) matches(path string) bool {
+	return len(c.Prefixes) == 0 || hasPrefix(path, c.Prefixes)
+}
+
+// hasPrefix returns whether the path is below one of the prefixes, matched on
+// whole path segments.
+func hasPrefix(path string, prefixes []string) bool {
+	for _, p := range prefixes {
+		p = strings.TrimSuffix(p, "/")
+		if path == p || strings.HasPrefix(path, p+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+package bodylimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	mw, _, err := New(map[string]interface{}{"max_body_size": 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		large    bool
+		expected int
+	}{
+		{"small body", http.MethodPut, "/remote.php/webdav/a", false, http.StatusOK},
+		{"large body", http.MethodPut, "/remote.php/webdav/a", true, http.StatusRequestEntityTooLarge},
+		{"tus chunk to the data gateway", http.MethodPatch, "/datagateway/tkn", true, http.StatusOK},
+		{"tus chunk to the data provider", http.MethodPatch, "/data/tus/id", true, http.StatusOK},
+		{"tus chunk elsewhere", http.MethodPatch, "/ocs/v1.php/apps", true, http.StatusRequestEntityTooLarge},
+		{"tus chunk to a longer segment", http.MethodPatch, "/database", true, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := "ab"
+			if tt.large {
+				body = "abcdefgh"
+			}
+			r := httptest.NewRequest(tt.method, tt.path, strings.NewReader(body))
+			if tt.method == http.MethodPatch {
+				r.Header.Set("Content-Type", "application/offset+octet-stream")
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != tt.expected {
+				t.Errorf("%s %s: got %d, expected %d", tt.method, tt.path, w.Code, tt.expected)
+			}
+		})
+	}
+}
@@ -102,6 +102,7 @@ func New(m map[string]interface{}) (global.Middleware, int, error) {
 			"Upload-Checksum",
 			"Upload-Offset",
 			"X-HTTP-Method-Override",
+			"Idempotency-Key",
 		}
 	}
 
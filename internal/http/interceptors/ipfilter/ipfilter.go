@@ -0,0 +1,115 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package ipfilter
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/ipfilter"
+	"github.com/cs3org/reva/pkg/rhttp/global"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultPriority = 20
+)
+
+func init() {
+	global.RegisterMiddleware("ipfilter", New)
+}
+
+type config struct {
+	ipfilter.Config `mapstructure:",squash"`
+	Priority        int `mapstructure:"priority"`
+	// Prefixes is the list of URL path prefixes the rules apply to, for
+	// example /admin. If empty, every request is filtered.
+	Prefixes []string `mapstructure:"prefixes"`
+	// RealIPHeader is the header set by a trusted reverse proxy carrying
+	// the address of the client, for example X-Forwarded-For.
+	RealIPHeader string `mapstructure:"real_ip_header"`
+	// TrustedProxies is the list of networks or addresses of the reverse
+	// proxies allowed to set the RealIPHeader.
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+
+	proxies *ipfilter.Proxies
+}
+
+// New returns a middleware rejecting the requests from the addresses not
+// allowed with 403 Forbidden.
+func New(m map[string]interface{}) (global.Middleware, int, error) {
+	conf := &config{}
+	if err := mapstructure.Decode(m, conf); err != nil {
+		return nil, 0, errors.Wrap(err, "ipfilter: error decoding conf")
+	}
+	if conf.Priority == 0 {
+		conf.Priority = defaultPriority
+	}
+	filter, err := ipfilter.New(&conf.Config)
+	if err != nil {
+		return nil, 0, err
+	}
+	if conf.RealIPHeader != "" && len(conf.TrustedProxies) == 0 {
+		return nil, 0, errors.New("ipfilter: real_ip_header needs the trusted_proxies")
+	}
+	if conf.proxies, err = ipfilter.NewProxies(conf.TrustedProxies); err != nil {
+		return nil, 0, err
+	}
+
+	mw := func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !conf.matches(r.URL.Path) {
+				h.ServeHTTP(w, r)
+				return
+			}
+			if err := filter.Check(conf.clientIP(r)); err != nil {
+				log := appctx.GetLogger(r.Context())
+				log.Warn().Err(err).Str("path", r.URL.Path).Msg("ipfilter: request refused")
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			h.ServeHTTP(w, r)
+		})
+	}
+	return mw, conf.Priority, nil
+}
+
+func (c *config) matches(path string) bool {
+	if len(c.Prefixes) == 0 {
+		return true
+	}
+	for _, p := range c.Prefixes {
+		// /admin matches /admin and /admin/users, but not /administrator
+		p = strings.TrimSuffix(p, "/")
+		if path == p || strings.HasPrefix(path, p+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *config) clientIP(r *http.Request) net.IP {
+	if c.RealIPHeader == "" {
+		return ipfilter.ParseHost(r.RemoteAddr)
+	}
+	return c.proxies.ClientIP(r.RemoteAddr, strings.Join(r.Header.Values(c.RealIPHeader), ","))
+}
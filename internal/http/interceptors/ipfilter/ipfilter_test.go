@@ -0,0 +1,71 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+package ipfilter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	mw, _, err := New(map[string]interface{}{
+		"prefixes":        []string{"/admin"},
+		"allow":           []string{"137.138.0.0/16"},
+		"real_ip_header":  "X-Forwarded-For",
+		"trusted_proxies": []string{"10.0.0.1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		name       string
+		path       string
+		remoteAddr string
+		header     string
+		expected   int
+	}{
+		{"allowed", "/admin/users", "137.138.1.1:1234", "", http.StatusOK},
+		{"refused", "/admin", "8.8.8.8:1234", "", http.StatusForbidden},
+		{"other path", "/ocs", "8.8.8.8:1234", "", http.StatusOK},
+		{"longer segment", "/administrator", "8.8.8.8:1234", "", http.StatusOK},
+		{"trusted proxy", "/admin", "10.0.0.1:1234", "137.138.1.1", http.StatusOK},
+		{"untrusted proxy", "/admin", "8.8.8.8:1234", "137.138.1.1", http.StatusForbidden},
+		{"spoofed header", "/admin", "10.0.0.1:1234", "137.138.1.1, 8.8.8.8", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.header != "" {
+				r.Header.Set("X-Forwarded-For", tt.header)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != tt.expected {
+				t.Errorf("%s from %s: got %d, expected %d", tt.path, tt.remoteAddr, w.Code, tt.expected)
+			}
+		})
+	}
+
+	if _, _, err := New(map[string]interface{}{"real_ip_header": "X-Forwarded-For"}); err == nil {
+		t.Error("expected an error without trusted proxies")
+	}
+}
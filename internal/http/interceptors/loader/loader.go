@@ -20,7 +20,12 @@ package loader
 
 import (
 	// Load core HTTP middlewares.
+	_ "github.com/cs3org/reva/internal/http/interceptors/bodylimit"
 	_ "github.com/cs3org/reva/internal/http/interceptors/cors"
+	_ "github.com/cs3org/reva/internal/http/interceptors/ipfilter"
+	_ "github.com/cs3org/reva/internal/http/interceptors/metrics"
 	_ "github.com/cs3org/reva/internal/http/interceptors/providerauthorizer"
+	_ "github.com/cs3org/reva/internal/http/interceptors/ratelimit"
+	_ "github.com/cs3org/reva/internal/http/interceptors/requestid"
 	// Add your own middleware.
 )
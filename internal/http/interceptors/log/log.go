@@ -31,6 +31,9 @@ import (
 )
 
 // New returns a new HTTP middleware that logs HTTP requests and responses.
+// Besides the fields set on the logger of the request, i.e. the trace id, the
+// service and the user id, the entries record the method, the uri, the
+// duration and the status of the requests.
 // TODO(labkode): maybe log to another file?
 func New() func(http.Handler) http.Handler {
 	return handler
@@ -92,8 +95,6 @@ func writeLog(log *zerolog.Logger, req *http.Request, url url.URL, ts time.Time,
 		uri = url.RequestURI()
 	}
 
-	diff := end.Sub(ts).Nanoseconds()
-
 	var event *zerolog.Event
 	switch {
 	case status < 400:
@@ -108,7 +109,8 @@ func writeLog(log *zerolog.Logger, req *http.Request, url url.URL, ts time.Time,
 		Str("uri", uri).Str("url", u).Str("proto", req.Proto).Int("status", status).
 		Int("size", size).
 		Str("start", ts.Format("02/Jan/2006:15:04:05 -0700")).
-		Str("end", end.Format("02/Jan/2006:15:04:05 -0700")).Int("time_ns", int(diff)).
+		Str("end", end.Format("02/Jan/2006:15:04:05 -0700")).
+		Dur("duration", end.Sub(ts)).
 		Msg("http")
 }
 
@@ -0,0 +1,157 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package metrics
+
+import (
+	"bufio"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cs3org/reva/pkg/rhttp/global"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+const (
+	defaultPriority = 10
+)
+
+var (
+	serviceKey = tag.MustNewKey("http_service")
+	methodKey  = tag.MustNewKey("http_method")
+	statusKey  = tag.MustNewKey("http_status")
+
+	requests = stats.Int64("http_server_requests", "Number of HTTP requests handled", stats.UnitDimensionless)
+	errs     = stats.Int64("http_server_errors", "Number of HTTP requests answered with an error status", stats.UnitDimensionless)
+	latency  = stats.Float64("http_server_latency", "Time spent handling the HTTP requests", stats.UnitMilliseconds)
+	inFlight = stats.Int64("http_server_in_flight", "Number of HTTP requests being handled", stats.UnitDimensionless)
+
+	// latencyBuckets are the bounds in milliseconds of the latency histograms.
+	latencyBuckets = []float64{1, 2, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000, 30000}
+
+	views = []*view.View{
+		{Name: "http_server_requests_total", Measure: requests, Aggregation: view.Count(), TagKeys: []tag.Key{serviceKey, methodKey, statusKey}},
+		{Name: "http_server_errors_total", Measure: errs, Aggregation: view.Count(), TagKeys: []tag.Key{serviceKey, methodKey, statusKey}},
+		{Name: "http_server_latency_ms", Measure: latency, Aggregation: view.Distribution(latencyBuckets...), TagKeys: []tag.Key{serviceKey, methodKey}},
+		{Name: "http_server_in_flight", Measure: inFlight, Aggregation: view.LastValue(), TagKeys: []tag.Key{serviceKey}},
+	}
+)
+
+func init() {
+	global.RegisterMiddleware("metrics", New)
+}
+
+type config struct {
+	Priority int `mapstructure:"priority"`
+}
+
+var registerViews sync.Once
+
+// New returns a middleware recording the number, the latency and the status
+// of the requests, per service and method. The service of a request is the
+// first segment of its path, or unknown for the requests answered with 404
+// Not Found, so that arbitrary paths do not create new series.
+func New(m map[string]interface{}) (global.Middleware, int, error) {
+	conf := &config{}
+	if err := mapstructure.Decode(m, conf); err != nil {
+		return nil, 0, errors.Wrap(err, "metrics: error decoding conf")
+	}
+	if conf.Priority == 0 {
+		conf.Priority = defaultPriority
+	}
+
+	var err error
+	registerViews.Do(func() {
+		err = view.Register(views...)
+	})
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "metrics: error registering views")
+	}
+
+	var active int64
+	mw := func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			service := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)[0]
+
+			stats.Record(ctx, inFlight.M(atomic.AddInt64(&active, 1)))
+			t := time.Now()
+			sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+			h.ServeHTTP(sw, r)
+			stats.Record(ctx, inFlight.M(atomic.AddInt64(&active, -1)))
+
+			if sw.status == http.StatusNotFound {
+				service = "unknown"
+			}
+			ctx, _ = tag.New(ctx,
+				tag.Upsert(serviceKey, service),
+				tag.Upsert(methodKey, r.Method),
+				tag.Upsert(statusKey, strconv.Itoa(sw.status)),
+			)
+			stats.Record(ctx, requests.M(1), latency.M(float64(time.Since(t))/float64(time.Millisecond)))
+			if sw.status >= 500 {
+				stats.Record(ctx, errs.M(1))
+			}
+		})
+	}
+
+	return mw, conf.Priority, nil
+}
+
+// statusWriter records the status of the response.
+type statusWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.status = code
+		w.wroteHeader = true
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("metrics: response writer does not support hijacking")
+	}
+	return h.Hijack()
+}
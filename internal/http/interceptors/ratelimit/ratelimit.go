@@ -0,0 +1,155 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/ipfilter"
+	"github.com/cs3org/reva/pkg/ratelimit"
+	"github.com/cs3org/reva/pkg/rhttp/global"
+	ctxpkg "github.com/cs3org/reva/pkg/user"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+const (
+	// defaultPriority places the limits by client address before the
+	// authentication, for the failed logins to be limited too.
+	defaultPriority = 25
+	// defaultUserPriority places the limits by user after the
+	// authentication, which identifies the user.
+	defaultUserPriority = 100
+)
+
+func init() {
+	global.RegisterMiddleware("ratelimit", New)
+}
+
+type config struct {
+	ratelimit.Config `mapstructure:",squash"`
+	Priority         int `mapstructure:"priority"`
+	// Prefixes is the list of URL path prefixes the limits apply to,
+	// for example /ocs, /remote.php or the login endpoints. If empty,
+	// every request is limited.
+	Prefixes []string `mapstructure:"prefixes"`
+	// RealIPHeader is the header set by a trusted reverse proxy carrying
+	// the address of the client, for example X-Forwarded-For.
+	RealIPHeader string `mapstructure:"real_ip_header"`
+	// TrustedProxies is the list of networks or addresses of the reverse
+	// proxies allowed to set the RealIPHeader.
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+
+	proxies *ipfilter.Proxies
+}
+
+// New returns a middleware rejecting the requests exceeding the configured rate
+// with 429 Too Many Requests.
+func New(m map[string]interface{}) (global.Middleware, int, error) {
+	conf := &config{}
+	if err := mapstructure.Decode(m, conf); err != nil {
+		return nil, 0, errors.Wrap(err, "ratelimit: error decoding conf")
+	}
+	conf.Init()
+	if conf.Priority == 0 {
+		conf.Priority = defaultPriority
+		if conf.PerUser {
+			conf.Priority = defaultUserPriority
+		}
+	}
+	if conf.RealIPHeader != "" && len(conf.TrustedProxies) == 0 {
+		return nil, 0, errors.New("ratelimit: real_ip_header needs the trusted_proxies")
+	}
+	var err error
+	if conf.proxies, err = ipfilter.NewProxies(conf.TrustedProxies); err != nil {
+		return nil, 0, err
+	}
+
+	limiter := ratelimit.New(&conf.Config)
+
+	mw := func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !conf.matches(r.URL.Path) {
+				h.ServeHTTP(w, r)
+				return
+			}
+
+			ctx := r.Context()
+			log := appctx.GetLogger(ctx)
+			key := conf.key(r)
+
+			ok, retry, err := limiter.Allow(ctx, key)
+			if err != nil {
+				// do not lock users out when the limiter is not available
+				log.Error().Err(err).Msg("ratelimit: error checking rate limit")
+				h.ServeHTTP(w, r)
+				return
+			}
+			if !ok {
+				log.Warn().Str("key", key).Str("path", r.URL.Path).Msg("ratelimit: rate limit exceeded")
+				w.Header().Set("Retry-After", fmt.Sprintf("%d", int(math.Ceil(retry.Seconds()))))
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			h.ServeHTTP(w, r)
+		})
+	}
+
+	return mw, conf.Priority, nil
+}
+
+func (c *config) matches(path string) bool {
+	if len(c.Prefixes) == 0 {
+		return true
+	}
+	for _, p := range c.Prefixes {
+		if strings.HasPrefix(path, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *config) key(r *http.Request) string {
+	if c.PerUser {
+		if u, ok := ctxpkg.ContextGetUser(r.Context()); ok && u.Id != nil {
+			return "user:" + u.Id.Idp + "!" + u.Id.OpaqueId
+		}
+	}
+	if c.RealIPHeader == "" {
+		return "ip:" + remoteHost(r.RemoteAddr)
+	}
+	if ip := c.proxies.ClientIP(r.RemoteAddr, strings.Join(r.Header.Values(c.RealIPHeader), ",")); ip != nil {
+		return "ip:" + ip.String()
+	}
+	return "ip:" + remoteHost(r.RemoteAddr)
+}
+
+func remoteHost(addr string) string {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
@@ -0,0 +1,92 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestClientAddress(t *testing.T) {
+	mw, _, err := New(map[string]interface{}{
+		"rate":            0.01,
+		"burst":           1,
+		"real_ip_header":  "X-Forwarded-For",
+		"trusted_proxies": []string{"10.0.0.1"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	do := func(remoteAddr, header string) int {
+		r := httptest.NewRequest(http.MethodPost, "/index.php/login", nil)
+		r.RemoteAddr = remoteAddr
+		if header != "" {
+			r.Header.Set("X-Forwarded-For", header)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	// a client cannot escape the limit by setting the header itself
+	if code := do("8.8.8.8:1234", "1.1.1.1"); code != http.StatusOK {
+		t.Fatalf("first request got %d", code)
+	}
+	if code := do("8.8.8.8:1234", "2.2.2.2"); code != http.StatusTooManyRequests {
+		t.Errorf("spoofed header got %d, wanted %d", code, http.StatusTooManyRequests)
+	}
+
+	// behind the trusted proxy, the right-most untrusted hop is the client
+	if code := do("10.0.0.1:1234", "3.3.3.3"); code != http.StatusOK {
+		t.Fatalf("proxied request got %d", code)
+	}
+	if code := do("10.0.0.1:1234", "4.4.4.4, 3.3.3.3"); code != http.StatusTooManyRequests {
+		t.Errorf("hop added by the client got %d, wanted %d", code, http.StatusTooManyRequests)
+	}
+	if code := do("10.0.0.1:1234", "5.5.5.5"); code != http.StatusOK {
+		t.Errorf("other client behind the proxy got %d", code)
+	}
+}
+
+func TestConfig(t *testing.T) {
+	tests := []struct {
+		name     string
+		conf     map[string]interface{}
+		priority int
+		err      bool
+	}{
+		{"by address", map[string]interface{}{}, defaultPriority, false},
+		{"by user", map[string]interface{}{"per_user": true}, defaultUserPriority, false},
+		{"configured", map[string]interface{}{"priority": 60}, 60, false},
+		{"untrusted header", map[string]interface{}{"real_ip_header": "X-Forwarded-For"}, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, prio, err := New(tt.conf)
+			if (err != nil) != tt.err {
+				t.Fatalf("got error %v, wanted an error: %v", err, tt.err)
+			}
+			if prio != tt.priority {
+				t.Errorf("got priority %d, wanted %d", prio, tt.priority)
+			}
+		})
+	}
+}
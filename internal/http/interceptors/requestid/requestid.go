@@ -0,0 +1,78 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package requestid
+
+import (
+	"net/http"
+
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/rhttp/global"
+	"github.com/google/uuid"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+const (
+	defaultPriority = 5
+	defaultHeader   = "X-Request-Id"
+)
+
+func init() {
+	global.RegisterMiddleware("requestid", New)
+}
+
+type config struct {
+	Priority int `mapstructure:"priority"`
+	// Header is the header carrying the id of the request, set by the
+	// clients or by a reverse proxy.
+	Header string `mapstructure:"header"`
+}
+
+// New returns a middleware making sure every request has an id, generating
+// one if the client did not send it. The id is added to the logs of the
+// request and returned in the response headers.
+func New(m map[string]interface{}) (global.Middleware, int, error) {
+	conf := &config{}
+	if err := mapstructure.Decode(m, conf); err != nil {
+		return nil, 0, errors.Wrap(err, "requestid: error decoding conf")
+	}
+	if conf.Priority == 0 {
+		conf.Priority = defaultPriority
+	}
+	if conf.Header == "" {
+		conf.Header = defaultHeader
+	}
+
+	mw := func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get(conf.Header)
+			if id == "" {
+				id = uuid.New().String()
+				r.Header.Set(conf.Header, id)
+			}
+			w.Header().Set(conf.Header, id)
+
+			ctx := r.Context()
+			log := appctx.GetLogger(ctx).With().Str("requestid", id).Logger()
+			r = r.WithContext(appctx.WithLogger(ctx, &log))
+			h.ServeHTTP(w, r)
+		})
+	}
+	return mw, conf.Priority, nil
+}
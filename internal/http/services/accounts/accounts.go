@@ -0,0 +1,342 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package accounts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/permission"
+	permregistry "github.com/cs3org/reva/pkg/permission/manager/registry"
+	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
+	"github.com/cs3org/reva/pkg/rhttp/global"
+	"github.com/cs3org/reva/pkg/rhttp/router"
+	"github.com/cs3org/reva/pkg/sharedconf"
+	"github.com/cs3org/reva/pkg/token"
+	tokenregistry "github.com/cs3org/reva/pkg/token/manager/registry"
+	"github.com/cs3org/reva/pkg/token/revocation"
+	ctxpkg "github.com/cs3org/reva/pkg/user"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+func init() {
+	global.Register("accounts", New)
+}
+
+const (
+	sharePolicyRemove   = "remove"
+	sharePolicyTransfer = "transfer"
+)
+
+type config struct {
+	Prefix        string                            `mapstructure:"prefix"`
+	GatewaySvc    string                            `mapstructure:"gatewaysvc"`
+	TokenManager  string                            `mapstructure:"token_manager"`
+	TokenManagers map[string]map[string]interface{} `mapstructure:"token_managers"`
+	// RevocationFile is the token revocation list, which must be shared with
+	// the token managers verifying the tokens.
+	RevocationFile string `mapstructure:"revocation_file"`
+	// SharePolicy is either remove, to remove the shares and public links of
+	// the user, or transfer, to share the shared resources with a successor.
+	SharePolicy string `mapstructure:"share_policy"`
+	// GracePeriod is the time in hours after which the space of the user is deleted.
+	GracePeriod int `mapstructure:"grace_period"`
+	// JobsFile holds the scheduled space cleanups.
+	JobsFile string `mapstructure:"jobs_file"`
+	// CleanupInterval is the time in seconds between two runs of the scheduled cleanups.
+	CleanupInterval int      `mapstructure:"cleanup_interval"`
+	Admins          []string `mapstructure:"admins"`
+	AdminGroups     []string `mapstructure:"admin_groups"`
+	// PermissionDriver, if set, is used to check whether the users are allowed
+	// to deprovision accounts instead of the lists of admins.
+	PermissionDriver  string                            `mapstructure:"permission_driver"`
+	PermissionDrivers map[string]map[string]interface{} `mapstructure:"permission_drivers"`
+}
+
+func (c *config) init() {
+	if c.Prefix == "" {
+		c.Prefix = "accounts"
+	}
+	if c.TokenManager == "" {
+		c.TokenManager = "jwt"
+	}
+	if c.SharePolicy == "" {
+		c.SharePolicy = sharePolicyRemove
+	}
+	if c.GracePeriod == 0 {
+		c.GracePeriod = 30 * 24
+	}
+	if c.JobsFile == "" {
+		c.JobsFile = "/var/tmp/reva/deprovisioning.json"
+	}
+	if c.RevocationFile == "" {
+		c.RevocationFile = "/var/tmp/reva/revoked-tokens.json"
+	}
+	if c.CleanupInterval == 0 {
+		c.CleanupInterval = 3600
+	}
+	c.GatewaySvc = sharedconf.GetGatewaySVC(c.GatewaySvc)
+}
+
+type svc struct {
+	conf         *config
+	log          *zerolog.Logger
+	tokenManager token.Manager
+	pm           permission.Manager
+	revoked      *revocation.List
+	jobs         *jobs
+	stop         chan struct{}
+	wg           sync.WaitGroup
+}
+
+// New returns a service handling the lifecycle of the user accounts.
+// When an account is deprovisioned, its tokens are revoked, its shares are
+// removed or transferred, and its space is deleted after a grace period.
+func New(m map[string]interface{}, log *zerolog.Logger) (global.Service, error) {
+	conf := &config{}
+	if err := mapstructure.Decode(m, conf); err != nil {
+		return nil, err
+	}
+	conf.init()
+
+	if conf.SharePolicy != sharePolicyRemove && conf.SharePolicy != sharePolicyTransfer {
+		return nil, errors.New("accounts: invalid share policy " + conf.SharePolicy)
+	}
+
+	f, ok := tokenregistry.NewFuncs[conf.TokenManager]
+	if !ok {
+		return nil, errtypes.NotFound("accounts: token manager does not exist: " + conf.TokenManager)
+	}
+	tm, err := f(conf.TokenManagers[conf.TokenManager])
+	if err != nil {
+		return nil, errors.Wrap(err, "accounts: error creating token manager")
+	}
+
+	var pm permission.Manager
+	if conf.PermissionDriver != "" {
+		f, ok := permregistry.NewFuncs[conf.PermissionDriver]
+		if !ok {
+			return nil, errtypes.NotFound("accounts: permission driver does not exist: " + conf.PermissionDriver)
+		}
+		if pm, err = f(conf.PermissionDrivers[conf.PermissionDriver]); err != nil {
+			return nil, errors.Wrap(err, "accounts: error creating permission manager")
+		}
+	}
+
+	s := &svc{
+		conf:         conf,
+		log:          log,
+		tokenManager: tm,
+		pm:           pm,
+		revoked:      revocation.New(conf.RevocationFile),
+		jobs:         newJobs(conf.JobsFile),
+		stop:         make(chan struct{}),
+	}
+
+	s.wg.Add(1)
+	go s.runCleanups()
+
+	return s, nil
+}
+
+// Close performs cleanup.
+func (s *svc) Close() error {
+	close(s.stop)
+	s.wg.Wait()
+	return nil
+}
+
+func (s *svc) Prefix() string {
+	return s.conf.Prefix
+}
+
+func (s *svc) Unprotected() []string {
+	return []string{}
+}
+
+func (s *svc) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var head string
+		head, r.URL.Path = router.ShiftPath(r.URL.Path)
+
+		if !s.isAdmin(r.Context()) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		switch {
+		case head == "deprovision" && r.Method == http.MethodPost:
+			s.handleDeprovision(w, r)
+		case head == "deprovision" && r.Method == http.MethodDelete:
+			s.handleCancel(w, r)
+		case head == "deprovision" && r.Method == http.MethodGet:
+			s.handleList(w, r)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+}
+
+func (s *svc) isAdmin(ctx context.Context) bool {
+	u, ok := ctxpkg.ContextGetUser(ctx)
+	if !ok {
+		return false
+	}
+	var allowed bool
+	var err error
+	if s.pm != nil {
+		allowed, err = permission.CheckPermission(ctx, s.pm, u, permission.DeprovisionUsers)
+	} else {
+		allowed, err = permission.IsAdmin(ctx, nil, u, s.conf.Admins, s.conf.AdminGroups)
+	}
+	if err != nil {
+		appctx.GetLogger(ctx).Error().Err(err).Msg("accounts: error checking permission")
+	}
+	return allowed
+}
+
+func (s *svc) getUser(ctx context.Context, username string) (*userpb.User, error) {
+	client, err := pool.GetGatewayServiceClient(s.conf.GatewaySvc)
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.GetUserByClaim(ctx, &userpb.GetUserByClaimRequest{
+		Claim: "username",
+		Value: username,
+	})
+	switch {
+	case err != nil:
+		return nil, err
+	case res.Status.Code == rpc.Code_CODE_NOT_FOUND:
+		return nil, errtypes.NotFound(username)
+	case res.Status.Code != rpc.Code_CODE_OK:
+		return nil, errtypes.InternalError(res.Status.Message)
+	}
+	return res.User, nil
+}
+
+func (s *svc) handleDeprovision(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := appctx.GetLogger(ctx)
+
+	username := r.FormValue("username")
+	if username == "" {
+		http.Error(w, "missing username", http.StatusBadRequest)
+		return
+	}
+
+	// the user might already have been disabled in the user provider,
+	// in which case the caller has to provide its id
+	var u *userpb.User
+	if id := r.FormValue("user_id"); id != "" {
+		u = &userpb.User{
+			Id:       &userpb.UserId{Idp: r.FormValue("idp"), OpaqueId: id},
+			Username: username,
+		}
+	} else {
+		var err error
+		if u, err = s.getUser(ctx, username); err != nil {
+			log.Error().Err(err).Str("username", username).Msg("accounts: error getting user")
+			writeError(w, err)
+			return
+		}
+	}
+
+	var successor *userpb.User
+	if s.conf.SharePolicy == sharePolicyTransfer {
+		name := r.FormValue("successor")
+		if name == "" {
+			http.Error(w, "missing successor", http.StatusBadRequest)
+			return
+		}
+		var err error
+		if successor, err = s.getUser(ctx, name); err != nil {
+			log.Error().Err(err).Str("username", name).Msg("accounts: error getting successor")
+			writeError(w, err)
+			return
+		}
+	}
+
+	job, err := s.deprovision(ctx, u, successor)
+	if err != nil {
+		log.Error().Err(err).Str("username", username).Msg("accounts: error deprovisioning user")
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(job)
+}
+
+func (s *svc) handleCancel(w http.ResponseWriter, r *http.Request) {
+	username := r.FormValue("username")
+	job, err := s.jobs.remove(username)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	emit(r.Context(), "cleanup_cancelled", job.UserID, nil)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *svc) handleList(w http.ResponseWriter, r *http.Request) {
+	list, err := s.jobs.list()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(list)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	switch err.(type) {
+	case errtypes.IsNotFound:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errtypes.IsBadRequest:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, fmt.Sprintf("internal error: %v", err), http.StatusInternalServerError)
+	}
+}
+
+// emit records an audit event for a step of the lifecycle of an account.
+func emit(ctx context.Context, step string, uid *userpb.UserId, err error) {
+	log := appctx.GetLogger(ctx)
+	ev := log.Info()
+	if err != nil {
+		ev = log.Error().Err(err)
+	}
+	ev.Str("event", "account.deprovisioning."+step).
+		Str("idp", uid.GetIdp()).
+		Str("user_id", uid.GetOpaqueId()).
+		Time("time", time.Now()).
+		Msg("accounts: deprovisioning step")
+}
@@ -0,0 +1,99 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package accounts
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/pkg/permission"
+	"github.com/cs3org/reva/pkg/permission/manager/static"
+	ctxpkg "github.com/cs3org/reva/pkg/user"
+)
+
+func TestHandlerAuthorization(t *testing.T) {
+	admin := &userpb.User{Username: "admin"}
+	helpdesk := &userpb.User{Username: "marie", Groups: []string{"helpdesk"}}
+	user := &userpb.User{Username: "einstein"}
+	pm, err := static.New(map[string]interface{}{
+		"roles": map[string][]string{"operator": {permission.DeprovisionUsers}, "admin": {permission.ManageUsers}},
+		"assignments": map[string]interface{}{
+			"users": map[string][]string{"operator": {"operator"}, "admin": {"admin"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		pm     permission.Manager
+		user   *userpb.User
+		method string
+		url    string
+		code   int
+	}{
+		{"anonymous", nil, nil, http.MethodGet, "/deprovision", http.StatusForbidden},
+		{"not an admin", nil, user, http.MethodGet, "/deprovision", http.StatusForbidden},
+		{"not an admin deprovisioning", nil, user, http.MethodPost, "/deprovision?username=marie", http.StatusForbidden},
+		{"not an admin cancelling", nil, user, http.MethodDelete, "/deprovision?username=einstein", http.StatusForbidden},
+		{"admin", nil, admin, http.MethodGet, "/deprovision", http.StatusOK},
+		{"member of an admin group", nil, helpdesk, http.MethodGet, "/deprovision", http.StatusOK},
+		{"capability granted", pm, &userpb.User{Username: "operator"}, http.MethodGet, "/deprovision", http.StatusOK},
+		{"listed admin without the capability", pm, admin, http.MethodGet, "/deprovision", http.StatusForbidden},
+		{"missing username", nil, admin, http.MethodPost, "/deprovision", http.StatusBadRequest},
+		{"unknown cleanup", nil, admin, http.MethodDelete, "/deprovision?username=marie", http.StatusNotFound},
+		{"cancelled cleanup", nil, admin, http.MethodDelete, "/deprovision?username=einstein", http.StatusNoContent},
+		{"unsupported method", nil, admin, http.MethodPut, "/deprovision", http.StatusNotFound},
+		{"unknown path", nil, admin, http.MethodGet, "/users", http.StatusNotFound},
+	}
+
+	file := filepath.Join(t.TempDir(), "jobs.json")
+	j := newJobs(file)
+	if err := j.add(&job{UserID: &userpb.UserId{OpaqueId: "einstein"}, Username: "einstein", Due: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &svc{
+				conf: &config{Admins: []string{"admin"}, AdminGroups: []string{"helpdesk"}},
+				pm:   tt.pm,
+				jobs: j,
+			}
+			r := httptest.NewRequest(tt.method, tt.url, nil)
+			if tt.user != nil {
+				r = r.WithContext(ctxpkg.ContextSetUser(r.Context(), tt.user))
+			}
+			w := httptest.NewRecorder()
+			s.Handler().ServeHTTP(w, r)
+			if w.Code != tt.code {
+				t.Errorf("got status %d, wanted %d", w.Code, tt.code)
+			}
+		})
+	}
+
+	if list, _ := j.list(); len(list) != 0 {
+		t.Errorf("the cleanup of einstein was not cancelled: %v", list)
+	}
+}
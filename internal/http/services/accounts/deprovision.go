@@ -0,0 +1,323 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package accounts
+
+import (
+	"context"
+	"time"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	collaboration "github.com/cs3org/go-cs3apis/cs3/sharing/collaboration/v1beta1"
+	link "github.com/cs3org/go-cs3apis/cs3/sharing/link/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/auth/scope"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
+	"github.com/cs3org/reva/pkg/token"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/metadata"
+)
+
+// deprovision runs the deprovisioning steps for the given user. The shares of
+// the user are removed or transferred to the successor, the tokens of the user
+// are revoked and the cleanup of its space is scheduled.
+func (s *svc) deprovision(ctx context.Context, u, successor *userpb.User) (*job, error) {
+	emit(ctx, "started", u.Id, nil)
+
+	client, err := pool.GetGatewayServiceClient(s.conf.GatewaySvc)
+	if err != nil {
+		return nil, err
+	}
+
+	// the shares must be handled before the tokens of the user are revoked,
+	// as the calls are made on behalf of the user.
+	userCtx, err := s.userContext(ctx, u)
+	if err != nil {
+		emit(ctx, "failed", u.Id, err)
+		return nil, err
+	}
+
+	if successor != nil {
+		err = s.transferShares(userCtx, client, successor)
+		emit(ctx, "shares_transferred", u.Id, err)
+	} else {
+		err = s.removeShares(userCtx, client)
+		emit(ctx, "shares_removed", u.Id, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.removePublicShares(userCtx, client)
+	emit(ctx, "public_shares_removed", u.Id, err)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.revoked.Revoke(u.Id)
+	emit(ctx, "tokens_revoked", u.Id, err)
+	if err != nil {
+		return nil, err
+	}
+
+	j := &job{
+		UserID:   u.Id,
+		Username: u.Username,
+		Due:      time.Now().Add(time.Duration(s.conf.GracePeriod) * time.Hour),
+	}
+	err = s.jobs.add(j)
+	emit(ctx, "cleanup_scheduled", u.Id, err)
+	if err != nil {
+		return nil, err
+	}
+
+	return j, nil
+}
+
+// userContext returns a context carrying a token minted for the given user.
+func (s *svc) userContext(ctx context.Context, u *userpb.User) (context.Context, error) {
+	scopes, err := scope.GetOwnerScope()
+	if err != nil {
+		return nil, err
+	}
+	tkn, err := s.tokenManager.MintToken(ctx, u, scopes)
+	if err != nil {
+		return nil, errors.Wrap(err, "accounts: error minting token")
+	}
+	ctx = token.ContextSetToken(ctx, tkn)
+	return metadata.AppendToOutgoingContext(ctx, token.TokenHeader, tkn), nil
+}
+
+func (s *svc) listShares(ctx context.Context, client gateway.GatewayAPIClient) ([]*collaboration.Share, error) {
+	res, err := client.ListShares(ctx, &collaboration.ListSharesRequest{})
+	if err != nil {
+		return nil, err
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return nil, errtypes.InternalError(res.Status.Message)
+	}
+	return res.Shares, nil
+}
+
+func (s *svc) removeShares(ctx context.Context, client gateway.GatewayAPIClient) error {
+	shares, err := s.listShares(ctx, client)
+	if err != nil {
+		return err
+	}
+	for _, share := range shares {
+		res, err := client.RemoveShare(ctx, &collaboration.RemoveShareRequest{
+			Ref: &collaboration.ShareReference{
+				Spec: &collaboration.ShareReference_Id{Id: share.Id},
+			},
+		})
+		if err != nil {
+			return err
+		}
+		if res.Status.Code != rpc.Code_CODE_OK && res.Status.Code != rpc.Code_CODE_NOT_FOUND {
+			return errtypes.InternalError(res.Status.Message)
+		}
+	}
+	return nil
+}
+
+// transferShares shares every resource shared by the user with the successor,
+// with the widest permissions granted on it. The original shares are kept
+// until the space of the user is cleaned up.
+func (s *svc) transferShares(ctx context.Context, client gateway.GatewayAPIClient, successor *userpb.User) error {
+	log := appctx.GetLogger(ctx)
+
+	shares, err := s.listShares(ctx, client)
+	if err != nil {
+		return err
+	}
+
+	type transfer struct {
+		id          *provider.ResourceId
+		permissions *provider.ResourcePermissions
+	}
+	transfers := map[string]*transfer{}
+	for _, share := range shares {
+		if share.Grantee.GetUserId().GetOpaqueId() == successor.Id.OpaqueId {
+			continue
+		}
+		key := share.ResourceId.StorageId + "!" + share.ResourceId.OpaqueId
+		t, ok := transfers[key]
+		if !ok {
+			t = &transfer{id: share.ResourceId, permissions: &provider.ResourcePermissions{}}
+			transfers[key] = t
+		}
+		mergePermissions(t.permissions, share.Permissions.GetPermissions())
+	}
+
+	for _, t := range transfers {
+		statRes, err := client.Stat(ctx, &provider.StatRequest{
+			Ref: &provider.Reference{
+				Spec: &provider.Reference_Id{Id: t.id},
+			},
+		})
+		if err != nil {
+			return err
+		}
+		if statRes.Status.Code != rpc.Code_CODE_OK {
+			log.Warn().Interface("resource_id", t.id).Str("status", statRes.Status.Code.String()).Msg("accounts: skipping transfer of resource")
+			continue
+		}
+
+		res, err := client.CreateShare(ctx, &collaboration.CreateShareRequest{
+			ResourceInfo: statRes.Info,
+			Grant: &collaboration.ShareGrant{
+				Grantee: &provider.Grantee{
+					Type: provider.GranteeType_GRANTEE_TYPE_USER,
+					Id:   &provider.Grantee_UserId{UserId: successor.Id},
+				},
+				Permissions: &collaboration.SharePermissions{Permissions: t.permissions},
+			},
+		})
+		if err != nil {
+			return err
+		}
+		if res.Status.Code != rpc.Code_CODE_OK && res.Status.Code != rpc.Code_CODE_ALREADY_EXISTS {
+			return errtypes.InternalError(res.Status.Message)
+		}
+	}
+	return nil
+}
+
+func mergePermissions(dst, src *provider.ResourcePermissions) {
+	if src == nil {
+		return
+	}
+	dst.AddGrant = dst.AddGrant || src.AddGrant
+	dst.CreateContainer = dst.CreateContainer || src.CreateContainer
+	dst.Delete = dst.Delete || src.Delete
+	dst.GetPath = dst.GetPath || src.GetPath
+	dst.GetQuota = dst.GetQuota || src.GetQuota
+	dst.InitiateFileDownload = dst.InitiateFileDownload || src.InitiateFileDownload
+	dst.InitiateFileUpload = dst.InitiateFileUpload || src.InitiateFileUpload
+	dst.ListContainer = dst.ListContainer || src.ListContainer
+	dst.ListFileVersions = dst.ListFileVersions || src.ListFileVersions
+	dst.ListGrants = dst.ListGrants || src.ListGrants
+	dst.ListRecycle = dst.ListRecycle || src.ListRecycle
+	dst.Move = dst.Move || src.Move
+	dst.PurgeRecycle = dst.PurgeRecycle || src.PurgeRecycle
+	dst.RemoveGrant = dst.RemoveGrant || src.RemoveGrant
+	dst.RestoreFileVersion = dst.RestoreFileVersion || src.RestoreFileVersion
+	dst.RestoreRecycleItem = dst.RestoreRecycleItem || src.RestoreRecycleItem
+	dst.Stat = dst.Stat || src.Stat
+	dst.UpdateGrant = dst.UpdateGrant || src.UpdateGrant
+}
+
+func (s *svc) removePublicShares(ctx context.Context, client gateway.GatewayAPIClient) error {
+	res, err := client.ListPublicShares(ctx, &link.ListPublicSharesRequest{})
+	if err != nil {
+		return err
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return errtypes.InternalError(res.Status.Message)
+	}
+	for _, share := range res.Share {
+		rmRes, err := client.RemovePublicShare(ctx, &link.RemovePublicShareRequest{
+			Ref: &link.PublicShareReference{
+				Spec: &link.PublicShareReference_Id{Id: share.Id},
+			},
+		})
+		if err != nil {
+			return err
+		}
+		if rmRes.Status.Code != rpc.Code_CODE_OK && rmRes.Status.Code != rpc.Code_CODE_NOT_FOUND {
+			return errtypes.InternalError(rmRes.Status.Message)
+		}
+	}
+	return nil
+}
+
+// runCleanups periodically deletes the spaces of the users whose grace
+// period has expired.
+func (s *svc) runCleanups() {
+	defer s.wg.Done()
+	ticker := time.NewTicker(time.Duration(s.conf.CleanupInterval) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+			s.cleanup()
+		}
+	}
+}
+
+func (s *svc) cleanup() {
+	ctx := appctx.WithLogger(context.Background(), s.log)
+
+	due, err := s.jobs.due(time.Now())
+	if err != nil {
+		s.log.Error().Err(err).Msg("accounts: error reading scheduled cleanups")
+		return
+	}
+	for _, j := range due {
+		err := s.deleteSpace(ctx, j)
+		emit(ctx, "space_deleted", j.UserID, err)
+		if err != nil {
+			continue
+		}
+		if _, err := s.jobs.remove(j.Username); err != nil {
+			s.log.Error().Err(err).Str("username", j.Username).Msg("accounts: error removing cleanup job")
+		}
+	}
+}
+
+func (s *svc) deleteSpace(ctx context.Context, j *job) error {
+	client, err := pool.GetGatewayServiceClient(s.conf.GatewaySvc)
+	if err != nil {
+		return err
+	}
+
+	// the tokens of the user have been revoked, so the token minted here must
+	// be issued after the revocation to be accepted.
+	u := &userpb.User{Id: j.UserID, Username: j.Username}
+	ctx, err = s.userContext(ctx, u)
+	if err != nil {
+		return err
+	}
+
+	homeRes, err := client.GetHome(ctx, &provider.GetHomeRequest{})
+	if err != nil {
+		return err
+	}
+	if homeRes.Status.Code != rpc.Code_CODE_OK {
+		return errtypes.InternalError(homeRes.Status.Message)
+	}
+
+	res, err := client.Delete(ctx, &provider.DeleteRequest{
+		Ref: &provider.Reference{
+			Spec: &provider.Reference_Path{Path: homeRes.Path},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if res.Status.Code != rpc.Code_CODE_OK && res.Status.Code != rpc.Code_CODE_NOT_FOUND {
+		return errtypes.InternalError(res.Status.Message)
+	}
+	return nil
+}
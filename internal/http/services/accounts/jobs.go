@@ -0,0 +1,129 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package accounts
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/pkg/errors"
+)
+
+// job is a scheduled cleanup of the space of a deprovisioned user.
+type job struct {
+	UserID   *userpb.UserId `json:"user_id"`
+	Username string         `json:"username"`
+	Due      time.Time      `json:"due"`
+}
+
+// jobs stores the scheduled cleanups in a file, so that they survive restarts.
+type jobs struct {
+	sync.Mutex
+	file string
+}
+
+func newJobs(file string) *jobs {
+	return &jobs{file: file}
+}
+
+func (j *jobs) read() (map[string]*job, error) {
+	list := map[string]*job{}
+	data, err := ioutil.ReadFile(j.file)
+	if os.IsNotExist(err) {
+		return list, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, errors.Wrap(err, "accounts: error decoding jobs file")
+	}
+	return list, nil
+}
+
+func (j *jobs) write(list map[string]*job) error {
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(j.file), 0700); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(j.file, data, 0600)
+}
+
+func (j *jobs) add(n *job) error {
+	j.Lock()
+	defer j.Unlock()
+	list, err := j.read()
+	if err != nil {
+		return err
+	}
+	list[n.Username] = n
+	return j.write(list)
+}
+
+func (j *jobs) remove(username string) (*job, error) {
+	j.Lock()
+	defer j.Unlock()
+	list, err := j.read()
+	if err != nil {
+		return nil, err
+	}
+	n, ok := list[username]
+	if !ok {
+		return nil, errtypes.NotFound(username)
+	}
+	delete(list, username)
+	return n, j.write(list)
+}
+
+func (j *jobs) list() ([]*job, error) {
+	j.Lock()
+	defer j.Unlock()
+	list, err := j.read()
+	if err != nil {
+		return nil, err
+	}
+	res := make([]*job, 0, len(list))
+	for _, n := range list {
+		res = append(res, n)
+	}
+	return res, nil
+}
+
+func (j *jobs) due(now time.Time) ([]*job, error) {
+	all, err := j.list()
+	if err != nil {
+		return nil, err
+	}
+	var res []*job
+	for _, n := range all {
+		if !n.Due.After(now) {
+			res = append(res, n)
+		}
+	}
+	return res, nil
+}
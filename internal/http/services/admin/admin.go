@@ -0,0 +1,185 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	adminpb "github.com/cs3org/reva/internal/grpc/services/adminprovider/proto"
+	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
+	"github.com/cs3org/reva/pkg/rhttp/global"
+	"github.com/cs3org/reva/pkg/rhttp/router"
+	"github.com/cs3org/reva/pkg/sharedconf"
+	"github.com/mitchellh/mapstructure"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func init() {
+	global.Register("admin", New)
+}
+
+type config struct {
+	Prefix string `mapstructure:"prefix"`
+	// AdminProviderSvc is the address of the adminprovider service, which
+	// runs the tasks and checks that the user is an admin.
+	AdminProviderSvc string `mapstructure:"adminprovidersvc"`
+}
+
+func (c *config) init() {
+	if c.Prefix == "" {
+		c.Prefix = "admin"
+	}
+	c.AdminProviderSvc = sharedconf.GetGatewaySVC(c.AdminProviderSvc)
+}
+
+type svc struct {
+	conf *config
+}
+
+// New returns a service exposing the operational tasks of the admins over
+// HTTP:
+//
+//	GET  /users?query=<query>
+//	POST /users/<idp>/<opaque id>/disable
+//	POST /spaces/<storage id>/<space id>/treesize
+//	POST /trash/<storage id>/purge?recycle_bin=<id>&older_than_days=<days>
+//	POST /caches/invalidate
+//	POST /search/reindex/<idp>/<opaque id>
+//	GET  /versions
+//	GET  /maintenance
+//	POST /maintenance?storage_id=<storage id>&message=<message>
+//	DELETE /maintenance?storage_id=<storage id>
+//
+// The maintenance without storage id is the one of the whole deployment.
+func New(m map[string]interface{}, log *zerolog.Logger) (global.Service, error) {
+	conf := &config{}
+	if err := mapstructure.Decode(m, conf); err != nil {
+		return nil, err
+	}
+	conf.init()
+	return &svc{conf: conf}, nil
+}
+
+// Close performs cleanup.
+func (s *svc) Close() error {
+	return nil
+}
+
+func (s *svc) Prefix() string {
+	return s.conf.Prefix
+}
+
+func (s *svc) Unprotected() []string {
+	return []string{}
+}
+
+func (s *svc) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		c, err := pool.GetAdminClient(s.conf.AdminProviderSvc)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		var head string
+		head, r.URL.Path = router.ShiftPath(r.URL.Path)
+		segments := []string{}
+		for p := r.URL.Path; p != "/"; {
+			var seg string
+			seg, p = router.ShiftPath(p)
+			segments = append(segments, seg)
+		}
+		query := r.URL.Query()
+
+		var res interface{}
+		switch {
+		case head == "users" && len(segments) == 0 && r.Method == http.MethodGet:
+			res, err = c.ListUsers(ctx, &adminpb.ListUsersRequest{Query: query.Get("query")})
+		case head == "users" && len(segments) == 3 && segments[2] == "disable" && r.Method == http.MethodPost:
+			res, err = c.DisableUser(ctx, &adminpb.DisableUserRequest{Idp: segments[0], OpaqueId: segments[1]})
+		case head == "spaces" && len(segments) == 3 && segments[2] == "treesize" && r.Method == http.MethodPost:
+			res, err = c.RecalculateTreeSize(ctx, &adminpb.RecalculateTreeSizeRequest{StorageId: segments[0], SpaceId: segments[1]})
+		case head == "trash" && len(segments) == 2 && segments[1] == "purge" && r.Method == http.MethodPost:
+			var days int
+			if d := query.Get("older_than_days"); d != "" {
+				if days, err = strconv.Atoi(d); err != nil {
+					http.Error(w, "invalid older_than_days", http.StatusBadRequest)
+					return
+				}
+			}
+			res, err = c.PurgeTrash(ctx, &adminpb.PurgeTrashRequest{
+				StorageId:     segments[0],
+				RecycleBinId:  query.Get("recycle_bin"),
+				OlderThanDays: int32(days),
+			})
+		case head == "caches" && len(segments) == 1 && segments[0] == "invalidate" && r.Method == http.MethodPost:
+			res, err = c.InvalidateCaches(ctx, &adminpb.InvalidateCachesRequest{})
+		case head == "search" && len(segments) == 3 && segments[0] == "reindex" && r.Method == http.MethodPost:
+			res, err = c.ReindexSearch(ctx, &adminpb.ReindexSearchRequest{Idp: segments[1], OpaqueId: segments[2]})
+		case head == "versions" && len(segments) == 0 && r.Method == http.MethodGet:
+			res, err = c.GetVersions(ctx, &adminpb.GetVersionsRequest{})
+		case head == "maintenance" && len(segments) == 0 && r.Method == http.MethodGet:
+			res, err = c.GetMaintenance(ctx, &adminpb.GetMaintenanceRequest{})
+		case head == "maintenance" && len(segments) == 0 && (r.Method == http.MethodPost || r.Method == http.MethodDelete):
+			res, err = c.SetMaintenance(ctx, &adminpb.SetMaintenanceRequest{
+				StorageId: query.Get("storage_id"),
+				Enabled:   r.Method == http.MethodPost,
+				Message:   query.Get("message"),
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, res)
+	})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	switch status.Code(err) {
+	case codes.NotFound:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case codes.InvalidArgument:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case codes.PermissionDenied:
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case codes.Unauthenticated:
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+	case codes.Unimplemented:
+		http.Error(w, err.Error(), http.StatusNotImplemented)
+	case codes.Unavailable:
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package admin
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	adminpb "github.com/cs3org/reva/internal/grpc/services/adminprovider/proto"
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// adminAPI records the last request it received.
+type adminAPI struct {
+	adminpb.UnimplementedAdminAPIServer
+	mu   sync.Mutex
+	last proto.Message
+}
+
+func (a *adminAPI) record(req proto.Message) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.last = req
+}
+
+func (a *adminAPI) ListUsers(ctx context.Context, req *adminpb.ListUsersRequest) (*adminpb.ListUsersResponse, error) {
+	a.record(req)
+	return &adminpb.ListUsersResponse{}, nil
+}
+
+func (a *adminAPI) DisableUser(ctx context.Context, req *adminpb.DisableUserRequest) (*adminpb.DisableUserResponse, error) {
+	a.record(req)
+	switch req.OpaqueId {
+	case "forbidden":
+		return nil, status.Error(codes.PermissionDenied, "not an admin")
+	case "missing":
+		return nil, status.Error(codes.NotFound, "no such user")
+	}
+	return &adminpb.DisableUserResponse{}, nil
+}
+
+func (a *adminAPI) RecalculateTreeSize(ctx context.Context, req *adminpb.RecalculateTreeSizeRequest) (*adminpb.RecalculateTreeSizeResponse, error) {
+	a.record(req)
+	return &adminpb.RecalculateTreeSizeResponse{}, nil
+}
+
+func (a *adminAPI) PurgeTrash(ctx context.Context, req *adminpb.PurgeTrashRequest) (*adminpb.PurgeTrashResponse, error) {
+	a.record(req)
+	return &adminpb.PurgeTrashResponse{}, nil
+}
+
+func (a *adminAPI) InvalidateCaches(ctx context.Context, req *adminpb.InvalidateCachesRequest) (*adminpb.InvalidateCachesResponse, error) {
+	a.record(req)
+	return &adminpb.InvalidateCachesResponse{}, nil
+}
+
+func (a *adminAPI) ReindexSearch(ctx context.Context, req *adminpb.ReindexSearchRequest) (*adminpb.ReindexSearchResponse, error) {
+	a.record(req)
+	return &adminpb.ReindexSearchResponse{}, nil
+}
+
+func (a *adminAPI) GetVersions(ctx context.Context, req *adminpb.GetVersionsRequest) (*adminpb.GetVersionsResponse, error) {
+	a.record(req)
+	return &adminpb.GetVersionsResponse{}, nil
+}
+
+func (a *adminAPI) SetMaintenance(ctx context.Context, req *adminpb.SetMaintenanceRequest) (*adminpb.SetMaintenanceResponse, error) {
+	a.record(req)
+	return &adminpb.SetMaintenanceResponse{}, nil
+}
+
+func (a *adminAPI) GetMaintenance(ctx context.Context, req *adminpb.GetMaintenanceRequest) (*adminpb.GetMaintenanceResponse, error) {
+	a.record(req)
+	return &adminpb.GetMaintenanceResponse{}, nil
+}
+
+func TestRouting(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	api := &adminAPI{}
+	srv := grpc.NewServer()
+	adminpb.RegisterAdminAPIServer(srv, api)
+	go func() { _ = srv.Serve(l) }()
+	defer srv.Stop()
+
+	s, err := New(map[string]interface{}{"adminprovidersvc": l.Addr().String()}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := s.Handler()
+
+	tests := []struct {
+		method, url string
+		code        int
+		req         proto.Message
+	}{
+		{http.MethodGet, "/users?query=ein", http.StatusOK, &adminpb.ListUsersRequest{Query: "ein"}},
+		{http.MethodPost, "/users/idp/einstein/disable", http.StatusOK, &adminpb.DisableUserRequest{Idp: "idp", OpaqueId: "einstein"}},
+		{http.MethodPost, "/users/idp/forbidden/disable", http.StatusForbidden, &adminpb.DisableUserRequest{Idp: "idp", OpaqueId: "forbidden"}},
+		{http.MethodPost, "/users/idp/missing/disable", http.StatusNotFound, &adminpb.DisableUserRequest{Idp: "idp", OpaqueId: "missing"}},
+		{http.MethodPost, "/spaces/storage/space/treesize", http.StatusOK, &adminpb.RecalculateTreeSizeRequest{StorageId: "storage", SpaceId: "space"}},
+		{http.MethodPost, "/trash/storage/purge?recycle_bin=einstein&older_than_days=30", http.StatusOK, &adminpb.PurgeTrashRequest{StorageId: "storage", RecycleBinId: "einstein", OlderThanDays: 30}},
+		{http.MethodPost, "/trash/storage/purge?older_than_days=month", http.StatusBadRequest, nil},
+		{http.MethodPost, "/caches/invalidate", http.StatusOK, &adminpb.InvalidateCachesRequest{}},
+		{http.MethodPost, "/search/reindex/idp/einstein", http.StatusOK, &adminpb.ReindexSearchRequest{Idp: "idp", OpaqueId: "einstein"}},
+		{http.MethodGet, "/versions", http.StatusOK, &adminpb.GetVersionsRequest{}},
+		{http.MethodGet, "/maintenance", http.StatusOK, &adminpb.GetMaintenanceRequest{}},
+		{http.MethodPost, "/maintenance?storage_id=storage&message=upgrade", http.StatusOK, &adminpb.SetMaintenanceRequest{StorageId: "storage", Enabled: true, Message: "upgrade"}},
+		{http.MethodDelete, "/maintenance", http.StatusOK, &adminpb.SetMaintenanceRequest{}},
+
+		// the tasks changing the state cannot be triggered with a GET
+		{http.MethodGet, "/users/idp/einstein/disable", http.StatusNotFound, nil},
+		{http.MethodGet, "/trash/storage/purge", http.StatusNotFound, nil},
+		{http.MethodGet, "/caches/invalidate", http.StatusNotFound, nil},
+		{http.MethodPost, "/users", http.StatusNotFound, nil},
+		{http.MethodPost, "/users/einstein/disable", http.StatusNotFound, nil},
+		{http.MethodPost, "/spaces/storage/treesize", http.StatusNotFound, nil},
+		{http.MethodGet, "/unknown", http.StatusNotFound, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.url, func(t *testing.T) {
+			api.record(nil)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(tt.method, tt.url, nil))
+			if w.Code != tt.code {
+				t.Errorf("got status %d, wanted %d", w.Code, tt.code)
+			}
+			api.mu.Lock()
+			defer api.mu.Unlock()
+			switch {
+			case tt.req == nil && api.last != nil:
+				t.Errorf("the request was forwarded as %v", api.last)
+			case tt.req != nil && !proto.Equal(api.last, tt.req):
+				t.Errorf("the request was forwarded as %v, wanted %v", api.last, tt.req)
+			}
+		})
+	}
+}
@@ -0,0 +1,326 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package dataexport
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/cs3org/reva/pkg/activity"
+	"github.com/cs3org/reva/pkg/activity/manager/registry"
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
+	"github.com/cs3org/reva/pkg/rhttp/global"
+	"github.com/cs3org/reva/pkg/rhttp/router"
+	"github.com/cs3org/reva/pkg/sharedconf"
+	"github.com/cs3org/reva/pkg/token"
+	ctxpkg "github.com/cs3org/reva/pkg/user"
+	"github.com/google/uuid"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/metadata"
+)
+
+func init() {
+	global.Register("dataexport", New)
+}
+
+type config struct {
+	Prefix     string `mapstructure:"prefix"`
+	GatewaySvc string `mapstructure:"gatewaysvc"`
+	// ExportDir is the folder the archives are written to.
+	ExportDir string `mapstructure:"export_dir"`
+	// Expiration is the number of seconds after which the archives are
+	// removed.
+	Expiration int `mapstructure:"expiration"`
+	// ActivityDriver, if set, configures the activity manager the timelines
+	// of the users are exported from.
+	ActivityDriver  string                            `mapstructure:"activity_driver"`
+	ActivityDrivers map[string]map[string]interface{} `mapstructure:"activity_drivers"`
+}
+
+func (c *config) init() {
+	if c.Prefix == "" {
+		c.Prefix = "data-export"
+	}
+	if c.ExportDir == "" {
+		c.ExportDir = filepath.Join(os.TempDir(), "reva-data-exports")
+	}
+	if c.Expiration == 0 {
+		c.Expiration = 7 * 24 * 60 * 60
+	}
+	c.GatewaySvc = sharedconf.GetGatewaySVC(c.GatewaySvc)
+}
+
+type svc struct {
+	conf *config
+	am   activity.Manager
+	log  *zerolog.Logger
+
+	mu   sync.Mutex
+	jobs map[string]*Job
+}
+
+// New returns a service allowing the users to export their personal data
+// into an archive. The archive is generated in the background, the users
+// poll the job for its progress and download the archive once it is done.
+func New(m map[string]interface{}, log *zerolog.Logger) (global.Service, error) {
+	conf := &config{}
+	if err := mapstructure.Decode(m, conf); err != nil {
+		return nil, err
+	}
+	conf.init()
+
+	if err := os.MkdirAll(conf.ExportDir, 0700); err != nil {
+		return nil, errors.Wrap(err, "dataexport: error creating export dir")
+	}
+
+	var am activity.Manager
+	if conf.ActivityDriver != "" {
+		f, ok := registry.NewFuncs[conf.ActivityDriver]
+		if !ok {
+			return nil, errtypes.NotFound("dataexport: activity driver not found: " + conf.ActivityDriver)
+		}
+		var err error
+		if am, err = f(conf.ActivityDrivers[conf.ActivityDriver]); err != nil {
+			return nil, errors.Wrap(err, "dataexport: error creating activity manager")
+		}
+	}
+
+	return &svc{
+		conf: conf,
+		am:   am,
+		log:  log,
+		jobs: map[string]*Job{},
+	}, nil
+}
+
+// Close performs cleanup.
+func (s *svc) Close() error {
+	return nil
+}
+
+func (s *svc) Prefix() string {
+	return s.conf.Prefix
+}
+
+func (s *svc) Unprotected() []string {
+	return []string{}
+}
+
+// Handler serves POST / to start an export, GET / to list the exports of the
+// user, GET /<id> to get the progress of an export, GET /<id>/download to
+// download the archive and DELETE /<id> to remove it.
+func (s *svc) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.purgeExpired()
+
+		var id, action string
+		id, r.URL.Path = router.ShiftPath(r.URL.Path)
+		action, _ = router.ShiftPath(r.URL.Path)
+
+		switch {
+		case id == "" && r.Method == http.MethodPost:
+			s.handleCreate(w, r)
+		case id == "" && r.Method == http.MethodGet:
+			s.handleList(w, r)
+		case id != "" && action == "" && r.Method == http.MethodGet:
+			if j := s.getJob(r.Context(), id); j != nil {
+				writeJSON(w, http.StatusOK, j.snapshot())
+				return
+			}
+			w.WriteHeader(http.StatusNotFound)
+		case id != "" && action == "download" && r.Method == http.MethodGet:
+			s.handleDownload(w, r, id)
+		case id != "" && action == "" && r.Method == http.MethodDelete:
+			j := s.getJob(r.Context(), id)
+			if j == nil {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			if st := j.snapshot().State; st == statePending || st == stateRunning {
+				http.Error(w, "the export is running", http.StatusConflict)
+				return
+			}
+			s.removeJob(j)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+}
+
+// handleCreate starts an export, unless one is already running for the user.
+func (s *svc) handleCreate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	u := ctxpkg.ContextMustGetUser(ctx)
+	tkn, ok := token.ContextGetToken(ctx)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	s.mu.Lock()
+	for _, j := range s.jobs {
+		if !sameUser(j, u.Id.Idp, u.Id.OpaqueId) {
+			continue
+		}
+		if st := j.snapshot().State; st == statePending || st == stateRunning {
+			s.mu.Unlock()
+			writeJSON(w, http.StatusOK, j.snapshot())
+			return
+		}
+	}
+	id := uuid.New().String()
+	j := &Job{
+		ID:      id,
+		State:   statePending,
+		Created: time.Now(),
+		user:    u.Id,
+		file:    filepath.Join(s.conf.ExportDir, id+".zip"),
+	}
+	s.jobs[id] = j
+	s.mu.Unlock()
+
+	client, err := pool.GetGatewayServiceClient(s.conf.GatewaySvc)
+	if err != nil {
+		s.fail(j, err)
+		writeJSON(w, http.StatusInternalServerError, j.snapshot())
+		return
+	}
+
+	// the export outlives the request, so it runs with a context of its own
+	jobCtx := token.ContextSetToken(context.Background(), tkn)
+	jobCtx = metadata.AppendToOutgoingContext(jobCtx, token.TokenHeader, tkn)
+	jobCtx = ctxpkg.ContextSetUser(jobCtx, u)
+	jobCtx = appctx.WithLogger(jobCtx, s.log)
+
+	e := &exporter{client: client, am: s.am, user: u}
+	go func() {
+		if err := e.run(jobCtx, j); err != nil {
+			s.fail(j, err)
+		}
+	}()
+
+	w.Header().Set("Location", path.Join("/", s.conf.Prefix, id))
+	writeJSON(w, http.StatusAccepted, j.snapshot())
+}
+
+func (s *svc) handleList(w http.ResponseWriter, r *http.Request) {
+	u := ctxpkg.ContextMustGetUser(r.Context())
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := []*Job{}
+	for _, j := range s.jobs {
+		if sameUser(j, u.Id.Idp, u.Id.OpaqueId) {
+			list = append(list, j.snapshot())
+		}
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *svc) handleDownload(w http.ResponseWriter, r *http.Request, id string) {
+	j := s.getJob(r.Context(), id)
+	if j == nil {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	snapshot := j.snapshot()
+	if snapshot.State != stateDone {
+		http.Error(w, "the export is "+snapshot.State, http.StatusConflict)
+		return
+	}
+
+	fd, err := os.Open(j.file)
+	if err != nil {
+		appctx.GetLogger(r.Context()).Error().Err(err).Str("export", id).Msg("dataexport: error opening archive")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	defer fd.Close()
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", "attachment; filename=\"data-export-"+snapshot.Created.UTC().Format("2006-01-02")+".zip\"")
+	http.ServeContent(w, r, "", snapshot.Finished, fd)
+}
+
+// getJob returns the job of the user with the given id, or nil.
+func (s *svc) getJob(ctx context.Context, id string) *Job {
+	u := ctxpkg.ContextMustGetUser(ctx)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, ok := s.jobs[id]
+	if !ok || !sameUser(j, u.Id.Idp, u.Id.OpaqueId) {
+		return nil
+	}
+	return j
+}
+
+func (s *svc) fail(j *Job, err error) {
+	s.log.Error().Err(err).Str("export", j.ID).Msg("dataexport: export failed")
+	j.update(func(j *Job) {
+		j.State = stateFailed
+		j.Error = err.Error()
+		j.Finished = time.Now()
+	})
+	_ = os.Remove(j.file)
+}
+
+func (s *svc) removeJob(j *Job) {
+	s.mu.Lock()
+	delete(s.jobs, j.ID)
+	s.mu.Unlock()
+	if err := os.Remove(j.file); err != nil && !os.IsNotExist(err) {
+		s.log.Error().Err(err).Str("export", j.ID).Msg("dataexport: error removing archive")
+	}
+}
+
+// purgeExpired removes the exports finished for longer than the expiration.
+func (s *svc) purgeExpired() {
+	limit := time.Now().Add(-time.Duration(s.conf.Expiration) * time.Second)
+	s.mu.Lock()
+	expired := []*Job{}
+	for _, j := range s.jobs {
+		if f := j.snapshot().Finished; !f.IsZero() && f.Before(limit) {
+			expired = append(expired, j)
+		}
+	}
+	s.mu.Unlock()
+	for _, j := range expired {
+		s.removeJob(j)
+	}
+}
+
+func sameUser(j *Job, idp, opaqueID string) bool {
+	return j.user.GetIdp() == idp && j.user.GetOpaqueId() == opaqueID
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,301 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package dataexport
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	collaboration "github.com/cs3org/go-cs3apis/cs3/sharing/collaboration/v1beta1"
+	link "github.com/cs3org/go-cs3apis/cs3/sharing/link/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/activity"
+	"github.com/cs3org/reva/pkg/utils"
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+)
+
+// States of the jobs.
+const (
+	statePending = "pending"
+	stateRunning = "running"
+	stateDone    = "done"
+	stateFailed  = "failed"
+)
+
+// activityPageSize is the number of activities fetched at once.
+const activityPageSize = 200
+
+// Job is an export of the personal data of a user.
+type Job struct {
+	ID string `json:"id"`
+	// State is one of pending, running, done and failed.
+	State string `json:"state"`
+	// Progress is the percentage of the export done, Step the part of the
+	// data being exported.
+	Progress int       `json:"progress"`
+	Step     string    `json:"step,omitempty"`
+	Error    string    `json:"error,omitempty"`
+	Size     int64     `json:"size,omitempty"`
+	Created  time.Time `json:"created"`
+	Finished time.Time `json:"finished,omitempty"`
+
+	user *userpb.UserId
+	file string
+	mu   sync.Mutex
+}
+
+// snapshot returns a copy of the job which can be encoded while the export
+// runs.
+func (j *Job) snapshot() *Job {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return &Job{
+		ID:       j.ID,
+		State:    j.State,
+		Progress: j.Progress,
+		Step:     j.Step,
+		Error:    j.Error,
+		Size:     j.Size,
+		Created:  j.Created,
+		Finished: j.Finished,
+	}
+}
+
+func (j *Job) update(f func(j *Job)) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	f(j)
+}
+
+// exportFile is a file of the archive.
+type exportFile struct {
+	name   string
+	export func(e *exporter, ctx context.Context) (interface{}, error)
+}
+
+// exportFiles are the files of the archive, in the order they are written.
+var exportFiles = []exportFile{
+	{"profile.json", (*exporter).profile},
+	{"shares_given.json", (*exporter).sharesGiven},
+	{"shares_received.json", (*exporter).sharesReceived},
+	{"activity.json", (*exporter).activities},
+	{"files.json", (*exporter).files},
+}
+
+type exporter struct {
+	client gateway.GatewayAPIClient
+	am     activity.Manager
+	user   *userpb.User
+}
+
+// run writes the archive of the job, reporting the progress after each file.
+func (e *exporter) run(ctx context.Context, j *Job) error {
+	j.update(func(j *Job) { j.State = stateRunning })
+
+	fd, err := os.Create(j.file)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	zw := zip.NewWriter(fd)
+	for i, f := range exportFiles {
+		j.update(func(j *Job) { j.Step = f.name })
+
+		data, err := f.export(e, ctx)
+		if err != nil {
+			return errors.Wrap(err, "error exporting "+f.name)
+		}
+		w, err := zw.Create(f.name)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(data); err != nil {
+			return err
+		}
+
+		j.update(func(j *Job) { j.Progress = (i + 1) * 100 / len(exportFiles) })
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	info, err := fd.Stat()
+	if err != nil {
+		return err
+	}
+	j.update(func(j *Job) {
+		j.State = stateDone
+		j.Step = ""
+		j.Size = info.Size()
+		j.Finished = time.Now()
+	})
+	return nil
+}
+
+func (e *exporter) profile(ctx context.Context) (interface{}, error) {
+	return map[string]interface{}{
+		"id":           e.user.Id,
+		"username":     e.user.Username,
+		"display_name": e.user.DisplayName,
+		"mail":         e.user.Mail,
+		"groups":       e.user.Groups,
+	}, nil
+}
+
+func (e *exporter) sharesGiven(ctx context.Context) (interface{}, error) {
+	res, err := e.client.ListShares(ctx, &collaboration.ListSharesRequest{})
+	if err != nil {
+		return nil, err
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return nil, errors.New(res.Status.Message)
+	}
+	pRes, err := e.client.ListPublicShares(ctx, &link.ListPublicSharesRequest{})
+	if err != nil {
+		return nil, err
+	}
+	if pRes.Status.Code != rpc.Code_CODE_OK {
+		return nil, errors.New(pRes.Status.Message)
+	}
+	shares, err := protoJSON(&collaboration.ListSharesResponse{Shares: res.Shares})
+	if err != nil {
+		return nil, err
+	}
+	links, err := protoJSON(&link.ListPublicSharesResponse{Share: pRes.Share})
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"shares":       shares,
+		"public_links": links,
+	}, nil
+}
+
+func (e *exporter) sharesReceived(ctx context.Context) (interface{}, error) {
+	res, err := e.client.ListReceivedShares(ctx, &collaboration.ListReceivedSharesRequest{})
+	if err != nil {
+		return nil, err
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return nil, errors.New(res.Status.Message)
+	}
+	return protoJSON(&collaboration.ListReceivedSharesResponse{Shares: res.Shares})
+}
+
+// protoJSON encodes a response holding the listed messages, so that they
+// are encoded with the names of the protobuf fields.
+func protoJSON(m proto.Message) (json.RawMessage, error) {
+	b, err := utils.MarshalProtoV1ToJSON(m)
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(b), nil
+}
+
+// activities returns the whole timeline of the user, or nothing if no
+// activity manager is configured.
+func (e *exporter) activities(ctx context.Context) (interface{}, error) {
+	list := []*activity.Activity{}
+	if e.am == nil {
+		return list, nil
+	}
+	f := &activity.Filter{Limit: activityPageSize}
+	for {
+		page, err := e.am.List(ctx, e.user.Id, f)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, page...)
+		if len(page) < f.Limit {
+			return list, nil
+		}
+		f.Since = page[len(page)-1].ID
+	}
+}
+
+// fileEntry is a resource of the listing of the files of the user.
+type fileEntry struct {
+	Path  string    `json:"path"`
+	Type  string    `json:"type"`
+	Size  uint64    `json:"size"`
+	Mtime time.Time `json:"mtime"`
+	Etag  string    `json:"etag"`
+}
+
+// files lists the files in the home of the user recursively.
+func (e *exporter) files(ctx context.Context) (interface{}, error) {
+	home, err := e.client.GetHome(ctx, &provider.GetHomeRequest{})
+	if err != nil {
+		return nil, err
+	}
+	if home.Status.Code != rpc.Code_CODE_OK {
+		return nil, errors.New(home.Status.Message)
+	}
+
+	list := []*fileEntry{}
+	queue := []string{home.Path}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		res, err := e.client.ListContainer(ctx, &provider.ListContainerRequest{
+			Ref: &provider.Reference{
+				Spec: &provider.Reference_Path{Path: p},
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if res.Status.Code != rpc.Code_CODE_OK {
+			return nil, errors.New(res.Status.Message)
+		}
+		for _, info := range res.Infos {
+			entry := &fileEntry{
+				Path: info.Path,
+				Type: "file",
+				Size: info.Size,
+				Etag: info.Etag,
+			}
+			if info.Mtime != nil {
+				entry.Mtime = utils.TSToTime(info.Mtime).UTC()
+			}
+			switch info.Type {
+			case provider.ResourceType_RESOURCE_TYPE_CONTAINER:
+				entry.Type = "folder"
+				queue = append(queue, info.Path)
+			case provider.ResourceType_RESOURCE_TYPE_FILE:
+			default:
+				// references to the received shares, which are exported with them
+				continue
+			}
+			list = append(list, entry)
+		}
+	}
+	return list, nil
+}
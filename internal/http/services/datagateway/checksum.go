@@ -0,0 +1,173 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package datagateway
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/hex"
+	"hash"
+	"hash/adler32"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/events"
+	"github.com/pkg/errors"
+)
+
+var errChecksumMismatch = errors.New("datagateway: checksum mismatch")
+
+// checksum is a checksum declared by a client or by a data provider.
+type checksum struct {
+	algorithm string
+	sum       string
+}
+
+// declaredChecksum returns the checksum declared in the Upload-Checksum
+// header, formatted as '[algorithm] [checksum]', or in the OC-Checksum
+// header, formatted as '[ALGORITHM]:[checksum]'. It returns nil when none
+// of them is set or the algorithm is not supported.
+func declaredChecksum(h http.Header) *checksum {
+	var parts []string
+	if v := h.Get("Upload-Checksum"); v != "" {
+		parts = strings.SplitN(v, " ", 2)
+	} else if v := h.Get("OC-Checksum"); v != "" {
+		parts = strings.SplitN(v, ":", 2)
+	}
+	if len(parts) != 2 {
+		return nil
+	}
+	xs := &checksum{algorithm: strings.ToLower(parts[0]), sum: strings.ToLower(parts[1])}
+	if xs.newHash() == nil {
+		return nil
+	}
+	return xs
+}
+
+func (xs *checksum) newHash() hash.Hash {
+	switch xs.algorithm {
+	case "sha1":
+		return sha1.New()
+	case "md5":
+		return md5.New()
+	case "adler32":
+		return adler32.New()
+	}
+	return nil
+}
+
+// spoolVerified writes the body of an upload to a temporary file, which is
+// only returned if the checksum of its content matches the declared one.
+// The caller has to remove the file.
+func (s *svc) spoolVerified(ctx context.Context, body io.Reader, xs *checksum, target string) (*os.File, error) {
+	f, err := ioutil.TempFile("", "datagateway-upload-")
+	if err != nil {
+		return nil, err
+	}
+	h := xs.newHash()
+	if _, err = io.Copy(f, io.TeeReader(body, h)); err == nil {
+		err = s.verifyChecksum(ctx, "upload", target, xs, h)
+	}
+	if err == nil {
+		_, err = f.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return nil, err
+	}
+	return f, nil
+}
+
+// copyVerified copies the body of a download to the client, holding back its
+// last byte until the checksum of the transferred bytes has been verified.
+// On a mismatch the connection is aborted, so that the client does not get
+// a complete response.
+func (s *svc) copyVerified(ctx context.Context, w io.Writer, body io.Reader, xs *checksum, target string) (int64, error) {
+	h := xs.newHash()
+	hw := &holdbackWriter{w: w}
+	c, err := io.Copy(hw, io.TeeReader(body, h))
+	if err != nil {
+		return c, err
+	}
+	if err := s.verifyChecksum(ctx, "download", target, xs, h); err != nil {
+		panic(http.ErrAbortHandler)
+	}
+	return c, hw.flush()
+}
+
+// verifyChecksum compares the computed checksum with the declared one,
+// publishing a TransferChecksumMismatch event when they differ.
+func (s *svc) verifyChecksum(ctx context.Context, direction, target string, xs *checksum, h hash.Hash) error {
+	computed := hex.EncodeToString(h.Sum(nil))
+	if computed == xs.sum {
+		return nil
+	}
+	log := appctx.GetLogger(ctx)
+	log.Error().Str("direction", direction).Str("target", target).Str("algorithm", xs.algorithm).
+		Str("expected", xs.sum).Str("computed", computed).Msg("checksum mismatch")
+	ev := events.TransferChecksumMismatch{
+		Direction: direction,
+		Target:    target,
+		Algorithm: xs.algorithm,
+		Expected:  xs.sum,
+		Computed:  computed,
+		Time:      time.Now(),
+	}
+	if err := events.Publish(ctx, s.publisher, ev); err != nil {
+		log.Error().Err(err).Msg("error publishing event")
+	}
+	return errChecksumMismatch
+}
+
+// holdbackWriter writes everything but the last byte written to it, until
+// it is flushed.
+type holdbackWriter struct {
+	w    io.Writer
+	last []byte
+}
+
+func (hw *holdbackWriter) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if err := hw.flush(); err != nil {
+		return 0, err
+	}
+	if _, err := hw.w.Write(p[:len(p)-1]); err != nil {
+		return 0, err
+	}
+	hw.last = []byte{p[len(p)-1]}
+	return len(p), nil
+}
+
+func (hw *holdbackWriter) flush() error {
+	if hw.last == nil {
+		return nil
+	}
+	_, err := hw.w.Write(hw.last)
+	hw.last = nil
+	return err
+}
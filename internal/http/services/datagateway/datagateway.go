@@ -23,12 +23,15 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"os"
 	"path"
 	"strconv"
 	"time"
 
 	"github.com/cs3org/reva/pkg/appctx"
 	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/events"
+	eventsregistry "github.com/cs3org/reva/pkg/events/driver/registry"
 	"github.com/cs3org/reva/pkg/rhttp"
 	"github.com/cs3org/reva/pkg/rhttp/global"
 	"github.com/cs3org/reva/pkg/sharedconf"
@@ -51,12 +54,23 @@ func init() {
 type transferClaims struct {
 	jwt.StandardClaims
 	Target string `json:"target"`
+	// Range restricts the transfer to a byte range of the target, in the
+	// format of the HTTP Range header. It is set for the segments of the
+	// parallel downloads.
+	Range string `json:"range,omitempty"`
 }
 type config struct {
 	Prefix               string `mapstructure:"prefix"`
 	TransferSharedSecret string `mapstructure:"transfer_shared_secret"`
 	Timeout              int64  `mapstructure:"timeout"`
 	Insecure             bool   `mapstructure:"insecure"`
+	// VerifyChecksums makes the data gateway verify the bytes it transfers
+	// against the checksums declared by the clients on uploads and by the
+	// data providers on downloads, failing the transfers on a mismatch.
+	VerifyChecksums bool `mapstructure:"verify_checksums"`
+	// Events configures the bus the TransferChecksumMismatch events are
+	// published to.
+	Events map[string]interface{} `mapstructure:"events"`
 }
 
 func (c *config) init() {
@@ -68,9 +82,10 @@ func (c *config) init() {
 }
 
 type svc struct {
-	conf    *config
-	handler http.Handler
-	client  *http.Client
+	conf      *config
+	handler   http.Handler
+	client    *http.Client
+	publisher events.Publisher
 }
 
 // New returns a new datagateway
@@ -82,12 +97,18 @@ func New(m map[string]interface{}, log *zerolog.Logger) (global.Service, error)
 
 	conf.init()
 
+	stream, err := eventsregistry.NewStream(conf.Events)
+	if err != nil {
+		return nil, err
+	}
+
 	s := &svc{
 		conf: conf,
 		client: rhttp.GetHTTPClient(
 			rhttp.Timeout(time.Duration(conf.Timeout*int64(time.Second))),
 			rhttp.Insecure(conf.Insecure),
 		),
+		publisher: stream,
 	}
 	s.setHandler()
 	return s, nil
@@ -118,6 +139,7 @@ func (s *svc) setHandler() {
 			s.doHead(w, r)
 			return
 		case "GET":
+			addCorsHeader(w)
 			s.doGet(w, r)
 			return
 		case "PUT":
@@ -136,8 +158,9 @@ func (s *svc) setHandler() {
 func addCorsHeader(res http.ResponseWriter) {
 	headers := res.Header()
 	headers.Set("Access-Control-Allow-Origin", "*")
-	headers.Set("Access-Control-Allow-Headers", "Content-Type, Origin, Authorization")
+	headers.Set("Access-Control-Allow-Headers", "Content-Type, Origin, Authorization, Range")
 	headers.Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS, HEAD")
+	headers.Set("Access-Control-Expose-Headers", "Accept-Ranges, Content-Range, Content-Length")
 }
 
 func (s *svc) verify(ctx context.Context, r *http.Request) (*transferClaims, error) {
@@ -186,6 +209,10 @@ func (s *svc) doHead(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	httpReq.Header = r.Header
+	if claims.Range != "" {
+		// the segments of a download only give access to their range
+		httpReq.Header.Set("Range", claims.Range)
+	}
 
 	httpRes, err := httpClient.Do(httpReq)
 	if err != nil {
@@ -227,6 +254,10 @@ func (s *svc) doGet(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	httpReq.Header = r.Header
+	if claims.Range != "" {
+		// the segments of a download only give access to their range
+		httpReq.Header.Set("Range", claims.Range)
+	}
 
 	httpRes, err := httpClient.Do(httpReq)
 	if err != nil {
@@ -244,7 +275,15 @@ func (s *svc) doGet(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var c int64
-	c, err = io.Copy(w, httpRes.Body)
+	var xs *checksum
+	if s.conf.VerifyChecksums && httpRes.StatusCode == http.StatusOK {
+		xs = declaredChecksum(httpRes.Header)
+	}
+	if xs != nil {
+		c, err = s.copyVerified(ctx, w, httpRes.Body, xs, claims.Target)
+	} else {
+		c, err = io.Copy(w, httpRes.Body)
+	}
 	if err != nil {
 		log.Error().Err(err).Msg("error writing body after headers were sent")
 	}
@@ -283,16 +322,40 @@ func (s *svc) doPut(w http.ResponseWriter, r *http.Request) {
 	targetURL.RawQuery = r.URL.RawQuery
 	target = targetURL.String()
 
+	var body io.Reader = r.Body
+	var size int64
+	if xs := declaredChecksum(r.Header); s.conf.VerifyChecksums && xs != nil {
+		f, err := s.spoolVerified(ctx, r.Body, xs, claims.Target)
+		if err != nil {
+			if err == errChecksumMismatch {
+				w.WriteHeader(errtypes.StatusChecksumMismatch)
+				return
+			}
+			log.Err(err).Msg("datagateway: error spooling upload")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		defer os.Remove(f.Name())
+		defer f.Close()
+		if info, err := f.Stat(); err == nil {
+			size = info.Size()
+		}
+		body = f
+	}
+
 	log.Debug().Str("target", claims.Target).Msg("sending request to internal data server")
 
 	httpClient := s.client
-	httpReq, err := rhttp.NewRequest(ctx, "PUT", target, r.Body)
+	httpReq, err := rhttp.NewRequest(ctx, "PUT", target, body)
 	if err != nil {
 		log.Err(err).Msg("wrong request")
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
 	httpReq.Header = r.Header
+	if size > 0 {
+		httpReq.ContentLength = size
+	}
 
 	httpRes, err := httpClient.Do(httpReq)
 	if err != nil {
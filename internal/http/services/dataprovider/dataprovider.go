@@ -21,13 +21,17 @@ package dataprovider
 import (
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/events"
+	eventsregistry "github.com/cs3org/reva/pkg/events/driver/registry"
 	datatxregistry "github.com/cs3org/reva/pkg/rhttp/datatx/manager/registry"
 	"github.com/cs3org/reva/pkg/rhttp/global"
 	"github.com/cs3org/reva/pkg/rhttp/router"
 	"github.com/cs3org/reva/pkg/storage"
 	"github.com/cs3org/reva/pkg/storage/fs/registry"
+	fsmetrics "github.com/cs3org/reva/pkg/storage/utils/metrics"
 	"github.com/mitchellh/mapstructure"
 	"github.com/rs/zerolog"
 )
@@ -43,6 +47,15 @@ type config struct {
 	DataTXs  map[string]map[string]interface{} `mapstructure:"data_txs" docs:"url:pkg/rhttp/datatx/manager/simple/simple.go;The configuration for the data tx protocols"`
 	Timeout  int64                             `mapstructure:"timeout"`
 	Insecure bool                              `mapstructure:"insecure"`
+	// SlowThreshold is the duration in milliseconds above which the storage
+	// driver operations are logged. 0 disables the logging.
+	SlowThreshold int `mapstructure:"slow_operation_threshold"`
+	// Events configures the bus the FileUploaded events are published to.
+	Events map[string]interface{} `mapstructure:"events"`
+	// MountPath is the path the storage is mounted at in the global
+	// namespace, published along with the paths of the uploaded files
+	// unless the data tx protocols configure their own.
+	MountPath string `mapstructure:"mount_path"`
 }
 
 func (c *config) init() {
@@ -75,7 +88,12 @@ func New(m map[string]interface{}, log *zerolog.Logger) (global.Service, error)
 		return nil, err
 	}
 
-	dataTXs, err := getDataTXs(conf, fs)
+	stream, err := eventsregistry.NewStream(conf.Events)
+	if err != nil {
+		return nil, err
+	}
+
+	dataTXs, err := getDataTXs(conf, fs, stream)
 	if err != nil {
 		return nil, err
 	}
@@ -92,12 +110,19 @@ func New(m map[string]interface{}, log *zerolog.Logger) (global.Service, error)
 
 func getFS(c *config) (storage.FS, error) {
 	if f, ok := registry.NewFuncs[c.Driver]; ok {
-		return f(c.Drivers[c.Driver])
+		fs, err := f(c.Drivers[c.Driver])
+		if err != nil {
+			return nil, err
+		}
+		return fsmetrics.New(c.Driver, fs, fsmetrics.Options{
+			SlowThreshold: time.Duration(c.SlowThreshold) * time.Millisecond,
+			Endpoint:      fsmetrics.Endpoint(c.Drivers[c.Driver]),
+		})
 	}
 	return nil, fmt.Errorf("driver not found: %s", c.Driver)
 }
 
-func getDataTXs(c *config, fs storage.FS) (map[string]http.Handler, error) {
+func getDataTXs(c *config, fs storage.FS, publisher events.Publisher) (map[string]http.Handler, error) {
 	if c.DataTXs == nil {
 		c.DataTXs = make(map[string]map[string]interface{})
 	}
@@ -108,8 +133,15 @@ func getDataTXs(c *config, fs storage.FS) (map[string]http.Handler, error) {
 
 	txs := make(map[string]http.Handler)
 	for t := range c.DataTXs {
+		if _, ok := c.DataTXs[t]["mount_path"]; !ok && c.MountPath != "" {
+			m := map[string]interface{}{"mount_path": c.MountPath}
+			for k, v := range c.DataTXs[t] {
+				m[k] = v
+			}
+			c.DataTXs[t] = m
+		}
 		if f, ok := datatxregistry.NewFuncs[t]; ok {
-			if tx, err := f(c.DataTXs[t]); err == nil {
+			if tx, err := f(c.DataTXs[t], publisher); err == nil {
 				if handler, err := tx.Handler(fs); err == nil {
 					txs[t] = handler
 				}
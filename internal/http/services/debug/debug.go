@@ -0,0 +1,189 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package debug
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	rpprof "runtime/pprof"
+	"strings"
+
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/rhttp/global"
+	"github.com/cs3org/reva/pkg/rhttp/router"
+	"github.com/cs3org/reva/pkg/sharedconf"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+func init() {
+	global.Register("debug", New)
+}
+
+// TokenHeader is the header carrying the token granting access to the
+// debug endpoints.
+const TokenHeader = "X-Debug-Token"
+
+// redacted is the value replacing the secrets in the configuration.
+const redacted = "REDACTED"
+
+// secretKeys are the substrings identifying the configuration keys whose
+// values are redacted.
+var secretKeys = []string{"secret", "password", "passwd", "token", "key", "credential", "dsn"}
+
+type config struct {
+	Prefix string `mapstructure:"prefix"`
+	// Token is the dedicated admin token to be sent in the X-Debug-Token
+	// header to access the endpoints.
+	Token string `mapstructure:"token"`
+}
+
+func (c *config) init() {
+	if c.Prefix == "" {
+		c.Prefix = "debug"
+	}
+}
+
+type svc struct {
+	conf *config
+}
+
+// New returns a service exposing diagnostics of the running process: the
+// pprof profiles under /pprof, the expvar variables under /vars, the
+// configuration with the secrets redacted under /config and the stacks of
+// all the goroutines under /goroutines.
+func New(m map[string]interface{}, log *zerolog.Logger) (global.Service, error) {
+	conf := &config{}
+	if err := mapstructure.Decode(m, conf); err != nil {
+		return nil, err
+	}
+	conf.init()
+	if conf.Token == "" {
+		return nil, errors.New("debug: a token is required to protect the endpoints")
+	}
+	return &svc{conf: conf}, nil
+}
+
+// Close performs cleanup.
+func (s *svc) Close() error {
+	return nil
+}
+
+func (s *svc) Prefix() string {
+	return s.conf.Prefix
+}
+
+// Unprotected returns all the endpoints, as the requests are authorized with
+// the dedicated token rather than with the users' credentials.
+func (s *svc) Unprotected() []string {
+	return []string{"/"}
+}
+
+func (s *svc) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tkn := r.Header.Get(TokenHeader)
+		if subtle.ConstantTimeCompare([]byte(tkn), []byte(s.conf.Token)) != 1 {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var head string
+		head, r.URL.Path = router.ShiftPath(r.URL.Path)
+
+		switch head {
+		case "pprof":
+			s.handlePprof(w, r)
+		case "vars":
+			expvar.Handler().ServeHTTP(w, r)
+		case "config":
+			w.Header().Set("Content-Type", "application/json")
+			if err := json.NewEncoder(w).Encode(redact(sharedconf.GetMainConf())); err != nil {
+				appctx.GetLogger(r.Context()).Error().Err(err).Msg("debug: error encoding config")
+			}
+		case "goroutines":
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			_ = rpprof.Lookup("goroutine").WriteTo(w, 2)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+}
+
+func (s *svc) handlePprof(w http.ResponseWriter, r *http.Request) {
+	switch name := strings.TrimPrefix(r.URL.Path, "/"); name {
+	case "cmdline":
+		pprof.Cmdline(w, r)
+	case "profile":
+		pprof.Profile(w, r)
+	case "symbol":
+		pprof.Symbol(w, r)
+	case "trace":
+		pprof.Trace(w, r)
+	default:
+		// the index serves the named profiles, e.g. heap, relative to the
+		// standard path.
+		r.URL.Path = "/debug/pprof/" + name
+		pprof.Index(w, r)
+	}
+}
+
+// redact returns a copy of the configuration where the values of the keys
+// which look like secrets are replaced.
+func redact(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		c := make(map[string]interface{}, len(t))
+		for k, v := range t {
+			if isSecret(k) {
+				c[k] = redacted
+			} else {
+				c[k] = redact(v)
+			}
+		}
+		return c
+	case []map[string]interface{}:
+		c := make([]interface{}, 0, len(t))
+		for _, v := range t {
+			c = append(c, redact(v))
+		}
+		return c
+	case []interface{}:
+		c := make([]interface{}, 0, len(t))
+		for _, v := range t {
+			c = append(c, redact(v))
+		}
+		return c
+	default:
+		return v
+	}
+}
+
+func isSecret(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range secretKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,64 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package debug
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRedact(t *testing.T) {
+	conf := map[string]interface{}{
+		"shared": map[string]interface{}{
+			"jwt_secret": "changemeplease",
+			"gatewaysvc": "localhost:19000",
+		},
+		"grpc": map[string]interface{}{
+			"services": map[string]interface{}{
+				"userprovider": map[string]interface{}{
+					"drivers": []map[string]interface{}{
+						{"bind_password": "secret", "hostname": "localhost"},
+					},
+				},
+			},
+		},
+	}
+	expected := map[string]interface{}{
+		"shared": map[string]interface{}{
+			"jwt_secret": redacted,
+			"gatewaysvc": "localhost:19000",
+		},
+		"grpc": map[string]interface{}{
+			"services": map[string]interface{}{
+				"userprovider": map[string]interface{}{
+					"drivers": []interface{}{
+						map[string]interface{}{"bind_password": redacted, "hostname": "localhost"},
+					},
+				},
+			},
+		},
+	}
+
+	if got := redact(conf); !reflect.DeepEqual(got, expected) {
+		t.Errorf("redact() = %v, want %v", got, expected)
+	}
+	if conf["shared"].(map[string]interface{})["jwt_secret"] != "changemeplease" {
+		t.Error("redact() modified the original configuration")
+	}
+}
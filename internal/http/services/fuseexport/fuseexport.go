@@ -0,0 +1,357 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package fuseexport
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/events"
+	eventsregistry "github.com/cs3org/reva/pkg/events/driver/registry"
+	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
+	"github.com/cs3org/reva/pkg/rhttp"
+	"github.com/cs3org/reva/pkg/rhttp/global"
+	"github.com/cs3org/reva/pkg/sharedconf"
+	"github.com/cs3org/reva/pkg/token"
+	ctxpkg "github.com/cs3org/reva/pkg/user"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/metadata"
+)
+
+func init() {
+	global.Register("fuseexport", New)
+}
+
+type mountConfig struct {
+	// User is the username of the user the namespace is exported for.
+	User string `mapstructure:"user"`
+	// Path is the exported folder, e.g. the folder of a project. It defaults
+	// to the home of the user.
+	Path       string `mapstructure:"path"`
+	Mountpoint string `mapstructure:"mountpoint"`
+	// UID and GID own the files of the mount, defaulting to the ones of the
+	// reva process.
+	UID int `mapstructure:"uid"`
+	GID int `mapstructure:"gid"`
+}
+
+type config struct {
+	Prefix     string `mapstructure:"prefix"`
+	GatewaySvc string `mapstructure:"gatewaysvc"`
+	// MachineAuthAPIKey is the API key of the machine auth provider, used to
+	// obtain the tokens of the users the namespaces are exported for.
+	MachineAuthAPIKey string         `mapstructure:"machine_auth_apikey"`
+	Mounts            []*mountConfig `mapstructure:"mounts"`
+	// CacheTTL is the time in seconds the metadata of the files is cached.
+	CacheTTL int `mapstructure:"cache_ttl"`
+	// TokenRefresh is the time in seconds after which the tokens of the
+	// users are renewed. It must be lower than the expiration of the tokens.
+	TokenRefresh int `mapstructure:"token_refresh"`
+	// AllowOther lets the users other than the one running reva access the
+	// mounts.
+	AllowOther bool `mapstructure:"allow_other"`
+	// ConsumerGroup is the group the events invalidating the caches are
+	// consumed in. Every instance of the service needs a group of its own.
+	ConsumerGroup string                 `mapstructure:"consumer_group"`
+	Events        map[string]interface{} `mapstructure:"events"`
+	Timeout       int64                  `mapstructure:"timeout"`
+	Insecure      bool                   `mapstructure:"insecure"`
+}
+
+func (c *config) init() {
+	if c.Prefix == "" {
+		c.Prefix = "fuse-export"
+	}
+	if c.CacheTTL == 0 {
+		c.CacheTTL = 5
+	}
+	if c.TokenRefresh == 0 {
+		c.TokenRefresh = 15 * 60
+	}
+	if c.ConsumerGroup == "" {
+		hostname, _ := os.Hostname()
+		c.ConsumerGroup = "fuseexport-" + hostname
+	}
+	c.GatewaySvc = sharedconf.GetGatewaySVC(c.GatewaySvc)
+}
+
+// invalidator drops the cached metadata of the resources of a mount.
+type invalidator interface {
+	Invalidate(paths ...string)
+	InvalidateResource(id *provider.ResourceId) bool
+}
+
+// mount is a namespace exported on a local mountpoint.
+type mount struct {
+	conf *mountConfig
+	// root is the remote folder of the mount.
+	root    string
+	fs      invalidator
+	unmount func() error
+
+	mu        sync.Mutex
+	token     string
+	user      *userpb.User
+	refreshed time.Time
+	err       error
+}
+
+// mountStatus is the state of a mount reported by the service.
+type mountStatus struct {
+	User       string `json:"user"`
+	Path       string `json:"path"`
+	Mountpoint string `json:"mountpoint"`
+	Mounted    bool   `json:"mounted"`
+	Error      string `json:"error,omitempty"`
+}
+
+type svc struct {
+	conf   *config
+	gwc    gateway.GatewayAPIClient
+	client *http.Client
+	stream events.Stream
+	log    *zerolog.Logger
+	mounts []*mount
+	stop   chan struct{}
+}
+
+// New returns a service exporting the namespaces of users or projects on
+// local FUSE mounts, e.g. to make them available to the jobs of an HPC
+// cluster. The mounts act on behalf of their users, whose tokens are renewed
+// periodically, and the caches of the mounts are invalidated when files are
+// uploaded.
+func New(m map[string]interface{}, log *zerolog.Logger) (global.Service, error) {
+	conf := &config{}
+	if err := mapstructure.Decode(m, conf); err != nil {
+		return nil, err
+	}
+	conf.init()
+
+	if conf.MachineAuthAPIKey == "" {
+		return nil, errors.New("fuseexport: machine_auth_apikey must be set")
+	}
+
+	gwc, err := pool.GetGatewayServiceClient(conf.GatewaySvc)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := eventsregistry.NewStream(conf.Events)
+	if err != nil {
+		return nil, errors.Wrap(err, "fuseexport: error creating events stream")
+	}
+
+	s := &svc{
+		conf: conf,
+		gwc:  gwc,
+		client: rhttp.GetHTTPClient(
+			rhttp.Timeout(time.Duration(conf.Timeout*int64(time.Second))),
+			rhttp.Insecure(conf.Insecure),
+		),
+		stream: stream,
+		log:    log,
+		stop:   make(chan struct{}),
+	}
+
+	for _, mc := range conf.Mounts {
+		if mc.User == "" || mc.Mountpoint == "" {
+			return nil, errors.New("fuseexport: the mounts need a user and a mountpoint")
+		}
+		mnt := &mount{conf: mc}
+		if err := s.mount(mnt); err != nil {
+			// the other mounts are still served, the failure is reported
+			// by the status of the mount.
+			log.Error().Err(err).Str("user", mc.User).Str("mountpoint", mc.Mountpoint).Msg("fuseexport: error mounting namespace")
+			mnt.err = err
+		}
+		s.mounts = append(s.mounts, mnt)
+	}
+
+	go s.invalidate()
+
+	return s, nil
+}
+
+// Close unmounts the namespaces.
+func (s *svc) Close() error {
+	close(s.stop)
+	var errs []string
+	for _, m := range s.mounts {
+		if m.unmount == nil {
+			continue
+		}
+		if err := m.unmount(); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+	if len(errs) > 0 {
+		return errors.New("fuseexport: error unmounting: " + strings.Join(errs, ", "))
+	}
+	return nil
+}
+
+func (s *svc) Prefix() string {
+	return s.conf.Prefix
+}
+
+func (s *svc) Unprotected() []string {
+	return []string{}
+}
+
+// Handler serves GET / with the status of the mounts of the user.
+func (s *svc) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || strings.Trim(r.URL.Path, "/") != "" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		u := ctxpkg.ContextMustGetUser(r.Context())
+
+		res := []*mountStatus{}
+		for _, m := range s.mounts {
+			if m.conf.User != u.Username {
+				continue
+			}
+			st := &mountStatus{
+				User:       m.conf.User,
+				Path:       m.root,
+				Mountpoint: m.conf.Mountpoint,
+				Mounted:    m.err == nil,
+			}
+			if m.err != nil {
+				st.Error = m.err.Error()
+			}
+			res = append(res, st)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(res); err != nil {
+			appctx.GetLogger(r.Context()).Error().Err(err).Msg("fuseexport: error writing response")
+		}
+	})
+}
+
+// context returns a context carrying a token of the user of the mount,
+// authenticating the user again when the token is due for renewal.
+func (s *svc) context(m *mount) (context.Context, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.token == "" || time.Since(m.refreshed) > time.Duration(s.conf.TokenRefresh)*time.Second {
+		res, err := s.gwc.Authenticate(context.Background(), &gateway.AuthenticateRequest{
+			Type:         "machine",
+			ClientId:     m.conf.User,
+			ClientSecret: s.conf.MachineAuthAPIKey,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if res.Status.Code != rpc.Code_CODE_OK {
+			return nil, errors.New("fuseexport: error authenticating " + m.conf.User + ": " + res.Status.Message)
+		}
+		m.token, m.user, m.refreshed = res.Token, res.User, time.Now()
+	}
+
+	ctx := appctx.WithLogger(context.Background(), s.log)
+	ctx = ctxpkg.ContextSetUser(ctx, m.user)
+	ctx = token.ContextSetToken(ctx, m.token)
+	return metadata.AppendToOutgoingContext(ctx, token.TokenHeader, m.token), nil
+}
+
+// resolveRoot returns the exported folder of the mount, the home of the user
+// if none is configured.
+func (s *svc) resolveRoot(m *mount) (string, error) {
+	if m.conf.Path != "" {
+		return m.conf.Path, nil
+	}
+	ctx, err := s.context(m)
+	if err != nil {
+		return "", err
+	}
+	res, err := s.gwc.GetHome(ctx, &provider.GetHomeRequest{})
+	if err != nil {
+		return "", err
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return "", errors.New("fuseexport: error getting home of " + m.conf.User + ": " + res.Status.Message)
+	}
+	return res.Path, nil
+}
+
+// invalidate drops the cached metadata of the uploaded files from the
+// mounts, until the service is closed.
+func (s *svc) invalidate() {
+	ctx, cancel := context.WithCancel(appctx.WithLogger(context.Background(), s.log))
+	defer cancel()
+	go func() {
+		<-s.stop
+		cancel()
+	}()
+
+	evs, err := events.Consume(ctx, s.stream, s.conf.ConsumerGroup, events.FileUploaded{})
+	if err != nil {
+		s.log.Error().Err(err).Msg("fuseexport: error consuming events")
+		return
+	}
+	for e := range evs {
+		ev, ok := e.(events.FileUploaded)
+		if !ok || ev.ResourceID == nil {
+			continue
+		}
+		for _, m := range s.mounts {
+			if m.fs == nil || m.fs.InvalidateResource(ev.ResourceID) {
+				continue
+			}
+			// the file is new to the mount, its folder has to be listed
+			// again if it is in the mount.
+			s.invalidateNew(m, ev.ResourceID)
+		}
+	}
+}
+
+func (s *svc) invalidateNew(m *mount, id *provider.ResourceId) {
+	ctx, err := s.context(m)
+	if err != nil {
+		s.log.Error().Err(err).Str("user", m.conf.User).Msg("fuseexport: error authenticating user")
+		return
+	}
+	res, err := s.gwc.Stat(ctx, &provider.StatRequest{
+		Ref: &provider.Reference{Spec: &provider.Reference_Id{Id: id}},
+	})
+	if err != nil || res.Status.Code != rpc.Code_CODE_OK {
+		// the file is not visible to the user of the mount.
+		return
+	}
+	p := res.Info.Path
+	if p == m.root || strings.HasPrefix(p, strings.TrimSuffix(m.root, "/")+"/") {
+		m.fs.Invalidate(p, path.Dir(p))
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// +build linux darwin
+
+package fuseexport
+
+import (
+	"context"
+	"time"
+
+	"bazil.org/fuse"
+	"bazil.org/fuse/fs"
+	"github.com/cs3org/reva/pkg/fusefs"
+)
+
+// mount mounts the namespace and serves it in the background.
+func (s *svc) mount(m *mount) error {
+	root, err := s.resolveRoot(m)
+	if err != nil {
+		return err
+	}
+	m.root = root
+
+	options := []fuse.MountOption{fuse.FSName("reva"), fuse.Subtype("revafs")}
+	if s.conf.AllowOther {
+		options = append(options, fuse.AllowOther())
+	}
+	c, err := fuse.Mount(m.conf.Mountpoint, options...)
+	if err != nil {
+		return err
+	}
+
+	rfs := fusefs.New(s.gwc, root, func() (context.Context, error) { return s.context(m) }, fusefs.NewSimpleTransferer(s.gwc, s.client), time.Duration(s.conf.CacheTTL)*time.Second)
+	if m.conf.UID != 0 || m.conf.GID != 0 {
+		rfs.SetOwner(uint32(m.conf.UID), uint32(m.conf.GID))
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := fs.Serve(c, rfs); err != nil {
+			s.log.Error().Err(err).Str("mountpoint", m.conf.Mountpoint).Msg("fuseexport: error serving mount")
+		}
+	}()
+
+	<-c.Ready
+	if err := c.MountError; err != nil {
+		c.Close()
+		return err
+	}
+
+	m.fs = rfs
+	m.unmount = func() error {
+		if err := fuse.Unmount(m.conf.Mountpoint); err != nil {
+			return err
+		}
+		<-done
+		return c.Close()
+	}
+	s.log.Info().Str("user", m.conf.User).Str("path", root).Str("mountpoint", m.conf.Mountpoint).Msg("fuseexport: namespace mounted")
+	return nil
+}
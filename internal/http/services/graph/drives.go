@@ -0,0 +1,194 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package graph
+
+import (
+	"encoding/base64"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
+)
+
+// drive is a storage space, see
+// https://docs.microsoft.com/en-us/graph/api/resources/drive
+type drive struct {
+	ID                   string       `json:"id"`
+	Name                 string       `json:"name"`
+	DriveType            string       `json:"driveType"`
+	Owner                *identitySet `json:"owner,omitempty"`
+	Quota                *quota       `json:"quota,omitempty"`
+	LastModifiedDateTime *time.Time   `json:"lastModifiedDateTime,omitempty"`
+	Root                 *driveItem   `json:"root,omitempty"`
+}
+
+type identitySet struct {
+	User *identity `json:"user,omitempty"`
+}
+
+type identity struct {
+	ID          string `json:"id"`
+	DisplayName string `json:"displayName,omitempty"`
+}
+
+type quota struct {
+	Total uint64 `json:"total,omitempty"`
+}
+
+func newDrive(space *provider.StorageSpace) *drive {
+	d := &drive{
+		ID:        space.Id.GetOpaqueId(),
+		Name:      space.Name,
+		DriveType: space.SpaceType,
+	}
+	if space.Owner != nil {
+		d.Owner = &identitySet{User: &identity{
+			ID:          space.Owner.GetId().GetOpaqueId(),
+			DisplayName: space.Owner.DisplayName,
+		}}
+	}
+	if space.Quota != nil && space.Quota.QuotaMaxBytes > 0 {
+		d.Quota = &quota{Total: space.Quota.QuotaMaxBytes}
+	}
+	if space.Mtime != nil {
+		t := time.Unix(int64(space.Mtime.Seconds), int64(space.Mtime.Nanos)).UTC()
+		d.LastModifiedDateTime = &t
+	}
+	if space.Root != nil {
+		d.Root = &driveItem{ID: wrapResourceID(space.Root)}
+	}
+	return d
+}
+
+func listSpaces(r *http.Request, client gateway.GatewayAPIClient, filters []*provider.ListStorageSpacesRequest_Filter) ([]*provider.StorageSpace, *rpc.Status, error) {
+	res, err := client.ListStorageSpaces(r.Context(), &provider.ListStorageSpacesRequest{Filters: filters})
+	if err != nil {
+		return nil, nil, err
+	}
+	return res.StorageSpaces, res.Status, nil
+}
+
+// getSpace returns the space of the drive, a not found status if the user
+// has no access to it.
+func getSpace(r *http.Request, client gateway.GatewayAPIClient, driveID string) (*provider.StorageSpace, *rpc.Status, error) {
+	spaces, st, err := listSpaces(r, client, []*provider.ListStorageSpacesRequest_Filter{
+		{
+			Type: provider.ListStorageSpacesRequest_Filter_TYPE_ID,
+			Term: &provider.ListStorageSpacesRequest_Filter_Id{Id: &provider.StorageSpaceId{OpaqueId: driveID}},
+		},
+	})
+	if err != nil || st.Code != rpc.Code_CODE_OK {
+		return nil, st, err
+	}
+	for _, space := range spaces {
+		if space.Id.GetOpaqueId() == driveID {
+			return space, st, nil
+		}
+	}
+	return nil, &rpc.Status{Code: rpc.Code_CODE_NOT_FOUND, Message: "drive not found"}, nil
+}
+
+func (s *svc) handleListDrives(w http.ResponseWriter, r *http.Request) {
+	size, ok := s.pageSize(r)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalidRequest", "invalid $top")
+		return
+	}
+	skip, ok := decodeSkip(r.URL.Query().Get("$skiptoken"))
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalidRequest", "invalid $skiptoken")
+		return
+	}
+
+	client, err := pool.GetGatewayServiceClient(s.conf.GatewaySvc)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+	spaces, st, err := listSpaces(r, client, nil)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+	if st.Code != rpc.Code_CODE_OK {
+		writeStatusError(w, r, st)
+		return
+	}
+	sort.Slice(spaces, func(i, j int) bool {
+		if spaces[i].Name != spaces[j].Name {
+			return spaces[i].Name < spaces[j].Name
+		}
+		return spaces[i].Id.GetOpaqueId() < spaces[j].Id.GetOpaqueId()
+	})
+
+	drives := []*drive{}
+	for i := skip; i < len(spaces) && i < skip+size; i++ {
+		drives = append(drives, newDrive(spaces[i]))
+	}
+	res := &collection{Value: drives}
+	if skip+size < len(spaces) {
+		q := r.URL.Query()
+		q.Set("$skiptoken", encodeSkip(skip+size))
+		res.NextLink = link(r, q)
+	}
+	writeJSON(w, r, res)
+}
+
+func (s *svc) handleGetDrive(w http.ResponseWriter, r *http.Request, driveID string) {
+	client, err := pool.GetGatewayServiceClient(s.conf.GatewaySvc)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+	space, st, err := getSpace(r, client, driveID)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+	if st.Code != rpc.Code_CODE_OK {
+		writeStatusError(w, r, st)
+		return
+	}
+	writeJSON(w, r, newDrive(space))
+}
+
+// encodeSkip returns the $skiptoken of the page starting at the entry skip.
+func encodeSkip(skip int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(skip)))
+}
+
+func decodeSkip(token string) (int, bool) {
+	if token == "" {
+		return 0, true
+	}
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, false
+	}
+	skip, err := strconv.Atoi(string(b))
+	if err != nil || skip < 0 {
+		return 0, false
+	}
+	return skip, true
+}
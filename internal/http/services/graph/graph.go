@@ -0,0 +1,259 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package graph
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/rhttp/global"
+	"github.com/cs3org/reva/pkg/rhttp/router"
+	"github.com/cs3org/reva/pkg/sharedconf"
+	"github.com/mitchellh/mapstructure"
+	"github.com/rs/zerolog"
+)
+
+func init() {
+	global.Register("graph", New)
+}
+
+const (
+	defaultPageSize = 200
+	maxPageSize     = 1000
+)
+
+type config struct {
+	Prefix     string `mapstructure:"prefix"`
+	GatewaySvc string `mapstructure:"gatewaysvc"`
+	// PageSize is the number of entries of the collections returned when the
+	// clients do not ask for a size with $top.
+	PageSize int `mapstructure:"page_size"`
+}
+
+func (c *config) init() {
+	if c.Prefix == "" {
+		c.Prefix = "graph"
+	}
+	if c.PageSize == 0 {
+		c.PageSize = defaultPageSize
+	}
+	c.GatewaySvc = sharedconf.GetGatewaySVC(c.GatewaySvc)
+}
+
+type svc struct {
+	conf *config
+}
+
+// New returns a service exposing the storage spaces of the users as the
+// drives of an MS Graph like API, see
+// https://docs.microsoft.com/en-us/graph/api/resources/drive
+func New(m map[string]interface{}, log *zerolog.Logger) (global.Service, error) {
+	conf := &config{}
+	if err := mapstructure.Decode(m, conf); err != nil {
+		return nil, err
+	}
+	conf.init()
+	return &svc{conf: conf}, nil
+}
+
+// Close performs cleanup.
+func (s *svc) Close() error {
+	return nil
+}
+
+func (s *svc) Prefix() string {
+	return s.conf.Prefix
+}
+
+func (s *svc) Unprotected() []string {
+	return []string{}
+}
+
+// Handler serves GET /v1.0/me/drives to list the drives of the user,
+// GET /v1.0/drives/<drive> to get a drive, and GET /v1.0/drives/<drive>/root
+// or GET /v1.0/drives/<drive>/items/<item> to get an item of a drive, followed
+// by /children to list the children of the item or /delta to list the changes
+// below the item.
+func (s *svc) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, "notSupported", "the method is not supported")
+			return
+		}
+
+		var version, head string
+		version, r.URL.Path = router.ShiftPath(r.URL.Path)
+		if version != "v1.0" {
+			writeError(w, http.StatusNotFound, "itemNotFound", "unknown API version")
+			return
+		}
+
+		head, r.URL.Path = router.ShiftPath(r.URL.Path)
+		switch head {
+		case "me":
+			if strings.Trim(r.URL.Path, "/") != "drives" {
+				writeError(w, http.StatusNotFound, "itemNotFound", "unknown resource")
+				return
+			}
+			s.handleListDrives(w, r)
+		case "drives":
+			var driveID string
+			driveID, r.URL.Path = router.ShiftPath(r.URL.Path)
+			if driveID == "" {
+				writeError(w, http.StatusNotFound, "itemNotFound", "unknown resource")
+				return
+			}
+			s.handleDrive(w, r, driveID)
+		default:
+			writeError(w, http.StatusNotFound, "itemNotFound", "unknown resource")
+		}
+	})
+}
+
+func (s *svc) handleDrive(w http.ResponseWriter, r *http.Request, driveID string) {
+	var head, itemID, action string
+	head, r.URL.Path = router.ShiftPath(r.URL.Path)
+	switch head {
+	case "":
+		s.handleGetDrive(w, r, driveID)
+		return
+	case "root":
+	case "items":
+		itemID, r.URL.Path = router.ShiftPath(r.URL.Path)
+		if itemID == "" {
+			writeError(w, http.StatusNotFound, "itemNotFound", "unknown resource")
+			return
+		}
+	default:
+		writeError(w, http.StatusNotFound, "itemNotFound", "unknown resource")
+		return
+	}
+
+	action, _ = router.ShiftPath(r.URL.Path)
+	switch action {
+	case "":
+		s.handleGetItem(w, r, driveID, itemID)
+	case "children":
+		s.handleListChildren(w, r, driveID, itemID)
+	case "delta":
+		s.handleDelta(w, r, driveID, itemID)
+	default:
+		writeError(w, http.StatusNotFound, "itemNotFound", "unknown resource")
+	}
+}
+
+// collection is a page of the entries of a collection.
+type collection struct {
+	Value     interface{} `json:"value"`
+	NextLink  string      `json:"@odata.nextLink,omitempty"`
+	DeltaLink string      `json:"@odata.deltaLink,omitempty"`
+}
+
+// pageSize returns the size of the pages asked for with $top.
+func (s *svc) pageSize(r *http.Request) (int, bool) {
+	top := r.URL.Query().Get("$top")
+	if top == "" {
+		return s.conf.PageSize, true
+	}
+	n, err := strconv.Atoi(top)
+	if err != nil || n < 1 {
+		return 0, false
+	}
+	if n > maxPageSize {
+		n = maxPageSize
+	}
+	return n, true
+}
+
+// link returns the URL of the resource of the request with the given query,
+// for the next pages of the collections.
+func link(r *http.Request, query url.Values) string {
+	u, err := url.ParseRequestURI(r.RequestURI)
+	if err != nil {
+		return ""
+	}
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// wrapResourceID encodes a resource id as ocdav does for the file ids.
+func wrapResourceID(id *provider.ResourceId) string {
+	return base64.URLEncoding.EncodeToString([]byte(id.GetStorageId() + ":" + id.GetOpaqueId()))
+}
+
+func unwrapResourceID(s string) *provider.ResourceId {
+	b, err := base64.URLEncoding.DecodeString(s)
+	if err != nil {
+		return nil
+	}
+	parts := strings.SplitN(string(b), ":", 2)
+	if len(parts) != 2 {
+		return nil
+	}
+	return &provider.ResourceId{StorageId: parts[0], OpaqueId: parts[1]}
+}
+
+type graphError struct {
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	e := &graphError{}
+	e.Error.Code, e.Error.Message = code, message
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(e)
+}
+
+// writeStatusError writes the error matching the status of a CS3 response.
+func writeStatusError(w http.ResponseWriter, r *http.Request, st *rpc.Status) {
+	switch st.Code {
+	case rpc.Code_CODE_NOT_FOUND:
+		writeError(w, http.StatusNotFound, "itemNotFound", st.Message)
+	case rpc.Code_CODE_PERMISSION_DENIED:
+		writeError(w, http.StatusForbidden, "accessDenied", st.Message)
+	case rpc.Code_CODE_INVALID_ARGUMENT:
+		writeError(w, http.StatusBadRequest, "invalidRequest", st.Message)
+	default:
+		appctx.GetLogger(r.Context()).Error().Str("status", st.Message).Msg("graph: error calling gateway")
+		writeError(w, http.StatusInternalServerError, "generalException", st.Message)
+	}
+}
+
+func writeInternalError(w http.ResponseWriter, r *http.Request, err error) {
+	appctx.GetLogger(r.Context()).Error().Err(err).Msg("graph: error handling request")
+	writeError(w, http.StatusInternalServerError, "generalException", "an internal error occurred")
+}
+
+func writeJSON(w http.ResponseWriter, r *http.Request, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		appctx.GetLogger(r.Context()).Error().Err(err).Msg("graph: error writing response")
+	}
+}
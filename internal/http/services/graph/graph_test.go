@@ -0,0 +1,75 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package graph
+
+import (
+	"testing"
+
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+)
+
+func TestTokens(t *testing.T) {
+	skip, ok := decodeSkip(encodeSkip(42))
+	if !ok || skip != 42 {
+		t.Fatalf("expected 42, got %d", skip)
+	}
+	if _, ok := decodeSkip("not a token"); ok {
+		t.Fatal("expected an invalid $skiptoken to be rejected")
+	}
+
+	st, ok := decodeDeltaState(encodeDeltaState(&deltaState{Since: 1, Until: 2, Skip: 3}))
+	if !ok || st.Since != 1 || st.Until != 2 || st.Skip != 3 {
+		t.Fatalf("unexpected delta state %+v", st)
+	}
+
+	id := &provider.ResourceId{StorageId: "storage", OpaqueId: "opaque:id"}
+	if got := unwrapResourceID(wrapResourceID(id)); got.StorageId != id.StorageId || got.OpaqueId != id.OpaqueId {
+		t.Fatalf("expected %v, got %v", id, got)
+	}
+}
+
+func TestDriveItem(t *testing.T) {
+	root := &item{
+		driveID:  "drive",
+		rootPath: "/projects/p",
+		info: &provider.ResourceInfo{
+			Id:   &provider.ResourceId{StorageId: "s", OpaqueId: "root"},
+			Path: "/projects/p",
+			Type: provider.ResourceType_RESOURCE_TYPE_CONTAINER,
+		},
+	}
+	d := root.driveItem()
+	if d.Root == nil || d.Folder == nil || d.ParentReference != nil {
+		t.Fatalf("unexpected root item %+v", d)
+	}
+
+	child := root.child(&provider.ResourceInfo{
+		Id:       &provider.ResourceId{StorageId: "s", OpaqueId: "file"},
+		Path:     "/projects/p/docs/a.txt",
+		Type:     provider.ResourceType_RESOURCE_TYPE_FILE,
+		MimeType: "text/plain",
+	})
+	d = child.driveItem()
+	if d.Name != "a.txt" || d.File == nil || d.File.MimeType != "text/plain" {
+		t.Fatalf("unexpected file item %+v", d)
+	}
+	if d.ParentReference.Path != "/drive/root:/docs" || d.ParentReference.DriveID != "drive" {
+		t.Fatalf("unexpected parent reference %+v", d.ParentReference)
+	}
+}
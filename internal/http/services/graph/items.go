@@ -0,0 +1,362 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package graph
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
+)
+
+// driveItem is a file or a folder of a drive, see
+// https://docs.microsoft.com/en-us/graph/api/resources/driveitem
+type driveItem struct {
+	ID                   string         `json:"id"`
+	Name                 string         `json:"name,omitempty"`
+	Size                 uint64         `json:"size"`
+	ETag                 string         `json:"eTag,omitempty"`
+	LastModifiedDateTime *time.Time     `json:"lastModifiedDateTime,omitempty"`
+	ParentReference      *itemReference `json:"parentReference,omitempty"`
+	Folder               *struct{}      `json:"folder,omitempty"`
+	File                 *file          `json:"file,omitempty"`
+	Root                 *struct{}      `json:"root,omitempty"`
+}
+
+type itemReference struct {
+	DriveID string `json:"driveId"`
+	ID      string `json:"id,omitempty"`
+	// Path is the path of the parent in the drive, e.g. /drive/root:/folder
+	Path string `json:"path,omitempty"`
+}
+
+type file struct {
+	MimeType string `json:"mimeType,omitempty"`
+}
+
+// item is a resource of a drive.
+type item struct {
+	driveID  string
+	rootPath string
+	info     *provider.ResourceInfo
+}
+
+func (i *item) isRoot() bool {
+	return i.info.Path == i.rootPath
+}
+
+func (i *item) driveItem() *driveItem {
+	d := &driveItem{
+		ID:   wrapResourceID(i.info.Id),
+		Name: path.Base(i.info.Path),
+		Size: i.info.Size,
+		ETag: i.info.Etag,
+	}
+	if i.info.Mtime != nil {
+		t := time.Unix(int64(i.info.Mtime.Seconds), int64(i.info.Mtime.Nanos)).UTC()
+		d.LastModifiedDateTime = &t
+	}
+	if i.info.Type == provider.ResourceType_RESOURCE_TYPE_CONTAINER {
+		d.Folder = &struct{}{}
+	} else {
+		d.File = &file{MimeType: i.info.MimeType}
+	}
+	if i.isRoot() {
+		d.Name = "root"
+		d.Root = &struct{}{}
+		return d
+	}
+	rel := strings.TrimPrefix(path.Dir(i.info.Path), i.rootPath)
+	d.ParentReference = &itemReference{
+		DriveID: i.driveID,
+		Path:    "/drive/root:" + rel,
+	}
+	return d
+}
+
+// child returns the item of a child of the folder.
+func (i *item) child(info *provider.ResourceInfo) *item {
+	return &item{driveID: i.driveID, rootPath: i.rootPath, info: info}
+}
+
+func stat(r *http.Request, client gateway.GatewayAPIClient, id *provider.ResourceId) (*provider.ResourceInfo, *rpc.Status, error) {
+	res, err := client.Stat(r.Context(), &provider.StatRequest{
+		Ref: &provider.Reference{Spec: &provider.Reference_Id{Id: id}},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return res.Info, res.Status, nil
+}
+
+// getItem returns the item of the drive, its root if itemID is empty. Items
+// which are not below the root of the drive are not found.
+func getItem(r *http.Request, client gateway.GatewayAPIClient, driveID, itemID string) (*item, *rpc.Status, error) {
+	space, st, err := getSpace(r, client, driveID)
+	if err != nil || st.Code != rpc.Code_CODE_OK {
+		return nil, st, err
+	}
+	root, st, err := stat(r, client, space.Root)
+	if err != nil || st.Code != rpc.Code_CODE_OK {
+		return nil, st, err
+	}
+	i := &item{driveID: driveID, rootPath: root.Path, info: root}
+	if itemID == "" {
+		return i, st, nil
+	}
+
+	id := unwrapResourceID(itemID)
+	if id == nil {
+		return nil, &rpc.Status{Code: rpc.Code_CODE_INVALID_ARGUMENT, Message: "invalid item id"}, nil
+	}
+	info, st, err := stat(r, client, id)
+	if err != nil || st.Code != rpc.Code_CODE_OK {
+		return nil, st, err
+	}
+	if info.Path != root.Path && !strings.HasPrefix(info.Path, strings.TrimSuffix(root.Path, "/")+"/") {
+		return nil, &rpc.Status{Code: rpc.Code_CODE_NOT_FOUND, Message: "item not found"}, nil
+	}
+	return i.child(info), st, nil
+}
+
+func listContainer(r *http.Request, client gateway.GatewayAPIClient, id *provider.ResourceId) ([]*provider.ResourceInfo, *rpc.Status, error) {
+	res, err := client.ListContainer(r.Context(), &provider.ListContainerRequest{
+		Ref: &provider.Reference{Spec: &provider.Reference_Id{Id: id}},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return res.Infos, res.Status, nil
+}
+
+func (s *svc) handleGetItem(w http.ResponseWriter, r *http.Request, driveID, itemID string) {
+	client, err := pool.GetGatewayServiceClient(s.conf.GatewaySvc)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+	i, st, err := getItem(r, client, driveID, itemID)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+	if st.Code != rpc.Code_CODE_OK {
+		writeStatusError(w, r, st)
+		return
+	}
+	writeJSON(w, r, i.driveItem())
+}
+
+func (s *svc) handleListChildren(w http.ResponseWriter, r *http.Request, driveID, itemID string) {
+	size, ok := s.pageSize(r)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalidRequest", "invalid $top")
+		return
+	}
+	skip, ok := decodeSkip(r.URL.Query().Get("$skiptoken"))
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalidRequest", "invalid $skiptoken")
+		return
+	}
+
+	client, err := pool.GetGatewayServiceClient(s.conf.GatewaySvc)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+	i, st, err := getItem(r, client, driveID, itemID)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+	if st.Code != rpc.Code_CODE_OK {
+		writeStatusError(w, r, st)
+		return
+	}
+	if i.info.Type != provider.ResourceType_RESOURCE_TYPE_CONTAINER {
+		writeError(w, http.StatusBadRequest, "invalidRequest", "the item is not a folder")
+		return
+	}
+
+	infos, st, err := listContainer(r, client, i.info.Id)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+	if st.Code != rpc.Code_CODE_OK {
+		writeStatusError(w, r, st)
+		return
+	}
+	sort.Slice(infos, func(a, b int) bool { return infos[a].Path < infos[b].Path })
+
+	parentID := wrapResourceID(i.info.Id)
+	children := []*driveItem{}
+	for n := skip; n < len(infos) && n < skip+size; n++ {
+		d := i.child(infos[n]).driveItem()
+		d.ParentReference.ID = parentID
+		children = append(children, d)
+	}
+	res := &collection{Value: children}
+	if skip+size < len(infos) {
+		q := r.URL.Query()
+		q.Set("$skiptoken", encodeSkip(skip+size))
+		res.NextLink = link(r, q)
+	}
+	writeJSON(w, r, res)
+}
+
+// deltaState is the state of a delta query. The changes are the items
+// modified after Since, Until being the time the query started at and the
+// Since of the next query. Skip is the position of the page in the changes.
+type deltaState struct {
+	Since int64 `json:"since"`
+	Until int64 `json:"until"`
+	Skip  int   `json:"skip,omitempty"`
+}
+
+func encodeDeltaState(st *deltaState) string {
+	b, _ := json.Marshal(st)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeDeltaState(token string) (*deltaState, bool) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, false
+	}
+	st := &deltaState{}
+	if err := json.Unmarshal(b, st); err != nil {
+		return nil, false
+	}
+	return st, true
+}
+
+// handleDelta lists the items below the item modified since the previous
+// query, all the items for the first query. The token of the next query is
+// returned in the @odata.deltaLink of the last page. The deleted items are
+// not reported, as the storages do not keep track of them.
+func (s *svc) handleDelta(w http.ResponseWriter, r *http.Request, driveID, itemID string) {
+	size, ok := s.pageSize(r)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalidRequest", "invalid $top")
+		return
+	}
+
+	q := r.URL.Query()
+	now := time.Now().UnixNano()
+	ds := &deltaState{Until: now}
+	switch {
+	case q.Get("$skiptoken") != "":
+		if ds, ok = decodeDeltaState(q.Get("$skiptoken")); !ok {
+			writeError(w, http.StatusBadRequest, "invalidRequest", "invalid $skiptoken")
+			return
+		}
+	case q.Get("token") == "latest":
+		// the client only asks for a token to track the changes from now on
+		ds.Since = now
+	case q.Get("token") != "":
+		prev, ok := decodeDeltaState(q.Get("token"))
+		if !ok {
+			writeError(w, http.StatusBadRequest, "invalidRequest", "invalid token")
+			return
+		}
+		ds.Since = prev.Until
+	}
+
+	client, err := pool.GetGatewayServiceClient(s.conf.GatewaySvc)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+	i, st, err := getItem(r, client, driveID, itemID)
+	if err != nil {
+		writeInternalError(w, r, err)
+		return
+	}
+	if st.Code != rpc.Code_CODE_OK {
+		writeStatusError(w, r, st)
+		return
+	}
+
+	var changes []*item
+	if ds.Since < now {
+		if changes, st, err = walkChanges(r, client, i, ds.Since); err != nil {
+			writeInternalError(w, r, err)
+			return
+		}
+		if st.Code != rpc.Code_CODE_OK {
+			writeStatusError(w, r, st)
+			return
+		}
+	}
+	sort.Slice(changes, func(a, b int) bool { return changes[a].info.Path < changes[b].info.Path })
+
+	page := []*driveItem{}
+	for n := ds.Skip; n < len(changes) && n < ds.Skip+size; n++ {
+		page = append(page, changes[n].driveItem())
+	}
+
+	q.Del("$skiptoken")
+	q.Del("token")
+	res := &collection{Value: page}
+	if ds.Skip+size < len(changes) {
+		q.Set("$skiptoken", encodeDeltaState(&deltaState{Since: ds.Since, Until: ds.Until, Skip: ds.Skip + size}))
+		res.NextLink = link(r, q)
+	} else {
+		q.Set("token", encodeDeltaState(&deltaState{Until: ds.Until}))
+		res.DeltaLink = link(r, q)
+	}
+	writeJSON(w, r, res)
+}
+
+// walkChanges returns the item and the items below it modified after since.
+func walkChanges(r *http.Request, client gateway.GatewayAPIClient, i *item, since int64) ([]*item, *rpc.Status, error) {
+	var changes []*item
+	if mtime(i.info) > since {
+		changes = append(changes, i)
+	}
+	if i.info.Type != provider.ResourceType_RESOURCE_TYPE_CONTAINER {
+		return changes, &rpc.Status{Code: rpc.Code_CODE_OK}, nil
+	}
+
+	infos, st, err := listContainer(r, client, i.info.Id)
+	if err != nil || st.Code != rpc.Code_CODE_OK {
+		return nil, st, err
+	}
+	for _, info := range infos {
+		children, st, err := walkChanges(r, client, i.child(info), since)
+		if err != nil || st.Code != rpc.Code_CODE_OK {
+			return nil, st, err
+		}
+		changes = append(changes, children...)
+	}
+	return changes, &rpc.Status{Code: rpc.Code_CODE_OK}, nil
+}
+
+func mtime(info *provider.ResourceInfo) int64 {
+	return time.Unix(int64(info.Mtime.GetSeconds()), int64(info.Mtime.GetNanos())).UnixNano()
+}
@@ -0,0 +1,160 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package guests
+
+import (
+	"html/template"
+	"net/http"
+
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/guest"
+	"github.com/cs3org/reva/pkg/guest/manager/registry"
+	"github.com/cs3org/reva/pkg/rhttp/global"
+	"github.com/cs3org/reva/pkg/rhttp/router"
+	"github.com/mitchellh/mapstructure"
+	"github.com/rs/zerolog"
+)
+
+func init() {
+	global.Register("guests", New)
+}
+
+type config struct {
+	Prefix  string                            `mapstructure:"prefix"`
+	Driver  string                            `mapstructure:"driver"`
+	Drivers map[string]map[string]interface{} `mapstructure:"drivers"`
+}
+
+func (c *config) init() {
+	if c.Prefix == "" {
+		c.Prefix = "guests"
+	}
+	if c.Driver == "" {
+		c.Driver = "json"
+	}
+}
+
+type svc struct {
+	conf *config
+	gm   guest.Manager
+}
+
+const activationForm = `<!DOCTYPE html>
+<html>
+<head><title>Activate your guest account</title></head>
+<body>
+{{if .Message}}<p>{{.Message}}</p>{{end}}
+{{if .Token}}
+<form method="POST">
+<input type="hidden" name="token" value="{{.Token}}">
+<label>Password <input type="password" name="password"></label>
+<button type="submit">Activate</button>
+</form>
+{{end}}
+</body>
+</html>
+`
+
+var activationTemplate = template.Must(template.New("activation").Parse(activationForm))
+
+// New returns a new guests service, which lets external sharees activate the
+// guest accounts created for them.
+func New(m map[string]interface{}, log *zerolog.Logger) (global.Service, error) {
+	conf := &config{}
+	if err := mapstructure.Decode(m, conf); err != nil {
+		return nil, err
+	}
+	conf.init()
+
+	f, ok := registry.NewFuncs[conf.Driver]
+	if !ok {
+		return nil, errtypes.NotFound("guests: driver not found: " + conf.Driver)
+	}
+	gm, err := f(conf.Drivers[conf.Driver])
+	if err != nil {
+		return nil, err
+	}
+
+	return &svc{conf: conf, gm: gm}, nil
+}
+
+// Close performs cleanup.
+func (s *svc) Close() error {
+	return nil
+}
+
+func (s *svc) Prefix() string {
+	return s.conf.Prefix
+}
+
+func (s *svc) Unprotected() []string {
+	return []string{"/activate"}
+}
+
+func (s *svc) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var head string
+		head, r.URL.Path = router.ShiftPath(r.URL.Path)
+
+		switch head {
+		case "activate":
+			s.handleActivate(w, r)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+}
+
+func (s *svc) handleActivate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := appctx.GetLogger(ctx)
+
+	switch r.Method {
+	case http.MethodGet:
+		s.renderActivation(w, r, http.StatusOK, r.URL.Query().Get("token"), "")
+	case http.MethodPost:
+		token := r.FormValue("token")
+		u, err := s.gm.ActivateGuest(ctx, token, r.FormValue("password"))
+		if err != nil {
+			log.Warn().Err(err).Msg("guests: error activating guest account")
+			switch err.(type) {
+			case errtypes.IsBadRequest:
+				s.renderActivation(w, r, http.StatusBadRequest, token, "Please choose a password.")
+			case errtypes.IsNotFound, errtypes.IsPermissionDenied:
+				s.renderActivation(w, r, http.StatusForbidden, "", "The activation link is invalid or has expired.")
+			default:
+				s.renderActivation(w, r, http.StatusInternalServerError, "", "The account could not be activated.")
+			}
+			return
+		}
+		log.Info().Str("guest", u.Username).Msg("guests: guest account activated")
+		s.renderActivation(w, r, http.StatusOK, "", "Your account has been activated, you can now log in with your email address.")
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *svc) renderActivation(w http.ResponseWriter, r *http.Request, code int, token, msg string) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(code)
+	if err := activationTemplate.Execute(w, map[string]string{"Token": token, "Message": msg}); err != nil {
+		appctx.GetLogger(r.Context()).Err(err).Msg("guests: error writing response")
+	}
+}
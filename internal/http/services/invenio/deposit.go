@@ -0,0 +1,202 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package invenio
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"path"
+	"sync"
+	"time"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/internal/http/services/datagateway"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/invenio"
+	"github.com/cs3org/reva/pkg/rhttp"
+	"github.com/pkg/errors"
+)
+
+const (
+	statePending = "pending"
+	stateRunning = "running"
+	stateDone    = "done"
+	stateFailed  = "failed"
+)
+
+// Deposit is the upload of a folder to the repository.
+type Deposit struct {
+	ID   string `json:"id"`
+	Path string `json:"path"`
+	// State is one of pending, running, done and failed.
+	State string `json:"state"`
+	Error string `json:"error,omitempty"`
+	// Files is the number of files of the folder, Uploaded the number of
+	// files uploaded so far.
+	Files    int `json:"files"`
+	Uploaded int `json:"uploaded"`
+	// Record is the id of the record in the repository.
+	Record   string    `json:"record,omitempty"`
+	DOI      string    `json:"doi,omitempty"`
+	URL      string    `json:"url,omitempty"`
+	Created  time.Time `json:"created"`
+	Finished time.Time `json:"finished,omitempty"`
+
+	user *userpb.UserId
+	mu   sync.Mutex
+}
+
+// snapshot returns a copy of the deposit which can be encoded while the
+// upload runs.
+func (d *Deposit) snapshot() *Deposit {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return &Deposit{
+		ID:       d.ID,
+		Path:     d.Path,
+		State:    d.State,
+		Error:    d.Error,
+		Files:    d.Files,
+		Uploaded: d.Uploaded,
+		Record:   d.Record,
+		DOI:      d.DOI,
+		URL:      d.URL,
+		Created:  d.Created,
+		Finished: d.Finished,
+	}
+}
+
+func (d *Deposit) update(f func(d *Deposit)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	f(d)
+}
+
+// depositor uploads the folders to the repository on behalf of a user.
+type depositor struct {
+	gwc  gateway.GatewayAPIClient
+	repo invenio.Client
+	// client downloads the files from the data gateway.
+	client      *http.Client
+	metadataKey string
+}
+
+// run creates a record with the metadata, uploads the files of the folder,
+// publishes the record and stores its DOI in the metadata of the folder.
+func (d *depositor) run(ctx context.Context, dep *Deposit, metadata json.RawMessage) error {
+	dep.update(func(dep *Deposit) { dep.State = stateRunning })
+
+	ref := &provider.Reference{Spec: &provider.Reference_Path{Path: dep.Path}}
+	res, err := d.gwc.ListContainer(ctx, &provider.ListContainerRequest{Ref: ref})
+	if err != nil {
+		return err
+	}
+	switch res.Status.Code {
+	case rpc.Code_CODE_OK:
+	case rpc.Code_CODE_NOT_FOUND:
+		return errtypes.NotFound(dep.Path)
+	default:
+		return errors.New("invenio: error listing " + dep.Path + ": " + res.Status.Message)
+	}
+	// the records of the repositories hold a flat list of files
+	for _, info := range res.Infos {
+		if info.Type == provider.ResourceType_RESOURCE_TYPE_CONTAINER {
+			return errtypes.NotSupported("invenio: the folder contains the folder " + path.Base(info.Path))
+		}
+	}
+	if len(res.Infos) == 0 {
+		return errtypes.BadRequest("invenio: the folder is empty")
+	}
+	dep.update(func(dep *Deposit) { dep.Files = len(res.Infos) })
+
+	record, err := d.repo.Create(ctx, metadata)
+	if err != nil {
+		return err
+	}
+	dep.update(func(dep *Deposit) { dep.Record, dep.URL = record.ID, record.URL })
+
+	for _, info := range res.Infos {
+		if err := d.upload(ctx, record, info); err != nil {
+			return errors.Wrap(err, "invenio: error uploading "+info.Path)
+		}
+		dep.update(func(dep *Deposit) { dep.Uploaded++ })
+	}
+
+	if err := d.repo.Publish(ctx, record); err != nil {
+		return err
+	}
+	dep.update(func(dep *Deposit) { dep.DOI, dep.URL = record.DOI, record.URL })
+
+	sres, err := d.gwc.SetArbitraryMetadata(ctx, &provider.SetArbitraryMetadataRequest{
+		Ref: ref,
+		ArbitraryMetadata: &provider.ArbitraryMetadata{
+			Metadata: map[string]string{d.metadataKey: record.DOI},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if sres.Status.Code != rpc.Code_CODE_OK {
+		return errors.New("invenio: error storing the DOI of " + dep.Path + ": " + sres.Status.Message)
+	}
+
+	dep.update(func(dep *Deposit) {
+		dep.State = stateDone
+		dep.Finished = time.Now()
+	})
+	return nil
+}
+
+func (d *depositor) upload(ctx context.Context, record *invenio.Deposition, info *provider.ResourceInfo) error {
+	res, err := d.gwc.InitiateFileDownload(ctx, &provider.InitiateFileDownloadRequest{
+		Ref: &provider.Reference{Spec: &provider.Reference_Path{Path: info.Path}},
+	})
+	if err != nil {
+		return err
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return errors.New(res.Status.Message)
+	}
+	var ep, tkn string
+	for _, p := range res.Protocols {
+		if p.Protocol == "simple" {
+			ep, tkn = p.DownloadEndpoint, p.Token
+		}
+	}
+
+	req, err := rhttp.NewRequest(ctx, http.MethodGet, ep, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(datagateway.TokenTransportHeader, tkn)
+	httpRes, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer httpRes.Body.Close()
+	if httpRes.StatusCode != http.StatusOK {
+		return errors.New("error downloading file: " + httpRes.Status)
+	}
+
+	return d.repo.Upload(ctx, record, path.Base(info.Path), httpRes.Body, int64(info.Size))
+}
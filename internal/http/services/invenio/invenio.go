@@ -0,0 +1,385 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package invenio
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ReneKroon/ttlcache/v2"
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/invenio"
+	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
+	"github.com/cs3org/reva/pkg/rhttp"
+	"github.com/cs3org/reva/pkg/rhttp/global"
+	"github.com/cs3org/reva/pkg/rhttp/router"
+	"github.com/cs3org/reva/pkg/sharedconf"
+	"github.com/cs3org/reva/pkg/token"
+	ctxpkg "github.com/cs3org/reva/pkg/user"
+	"github.com/cs3org/reva/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"golang.org/x/oauth2"
+	"google.golang.org/grpc/metadata"
+)
+
+func init() {
+	global.Register("invenio", New)
+}
+
+type config struct {
+	Prefix     string `mapstructure:"prefix"`
+	GatewaySvc string `mapstructure:"gatewaysvc"`
+	// URL is the URL of the repository, Flavor its API, either zenodo or
+	// rdm for InvenioRDM.
+	URL    string `mapstructure:"url"`
+	Flavor string `mapstructure:"flavor"`
+	// ClientID and ClientSecret identify reva as an OAuth application of the
+	// repository, RedirectURL is the URL of the callback of the service.
+	ClientID     string   `mapstructure:"client_id"`
+	ClientSecret string   `mapstructure:"client_secret"`
+	RedirectURL  string   `mapstructure:"redirect_url"`
+	Scopes       []string `mapstructure:"scopes"`
+	// TokensFile is the file the OAuth tokens of the users are stored in.
+	TokensFile string `mapstructure:"tokens_file"`
+	// MetadataKey is the key of the arbitrary metadata the DOI of the
+	// published folders is stored in.
+	MetadataKey string `mapstructure:"metadata_key"`
+	Timeout     int64  `mapstructure:"timeout"`
+	Insecure    bool   `mapstructure:"insecure"`
+}
+
+func (c *config) init() {
+	if c.Prefix == "" {
+		c.Prefix = "invenio"
+	}
+	if c.URL == "" {
+		c.URL = "https://zenodo.org"
+	}
+	c.URL = strings.TrimSuffix(c.URL, "/")
+	if c.Flavor == "" {
+		c.Flavor = invenio.FlavorZenodo
+	}
+	if len(c.Scopes) == 0 {
+		c.Scopes = []string{"deposit:write", "deposit:actions"}
+	}
+	if c.TokensFile == "" {
+		c.TokensFile = "/var/tmp/reva/invenio-tokens.json"
+	}
+	if c.MetadataKey == "" {
+		c.MetadataKey = "doi"
+	}
+	c.GatewaySvc = sharedconf.GetGatewaySVC(c.GatewaySvc)
+}
+
+type svc struct {
+	conf   *config
+	oauth  *oauth2.Config
+	client *http.Client
+	tokens *tokenStore
+	// states maps the states of the pending OAuth authorizations to the
+	// users.
+	states *ttlcache.Cache
+	log    *zerolog.Logger
+
+	mu       sync.Mutex
+	deposits map[string]*Deposit
+}
+
+// New returns a service depositing folders of the users to Zenodo or an
+// InvenioRDM repository. The users first connect their account of the
+// repository through OAuth, then deposit folders with the metadata of the
+// records. The files are uploaded in the background, and the DOI of the
+// published records are stored in the metadata of the folders.
+func New(m map[string]interface{}, log *zerolog.Logger) (global.Service, error) {
+	conf := &config{}
+	if err := mapstructure.Decode(m, conf); err != nil {
+		return nil, err
+	}
+	conf.init()
+	if conf.ClientID == "" || conf.ClientSecret == "" || conf.RedirectURL == "" {
+		return nil, errors.New("invenio: client_id, client_secret and redirect_url must be set")
+	}
+	if _, err := invenio.New(conf.Flavor, conf.URL, nil); err != nil {
+		return nil, err
+	}
+
+	tokens, err := newTokenStore(conf.TokensFile)
+	if err != nil {
+		return nil, err
+	}
+
+	states := ttlcache.NewCache()
+	_ = states.SetTTL(10 * time.Minute)
+
+	return &svc{
+		conf: conf,
+		oauth: &oauth2.Config{
+			ClientID:     conf.ClientID,
+			ClientSecret: conf.ClientSecret,
+			RedirectURL:  conf.RedirectURL,
+			Scopes:       conf.Scopes,
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  conf.URL + "/oauth/authorize",
+				TokenURL: conf.URL + "/oauth/token",
+			},
+		},
+		client: rhttp.GetHTTPClient(
+			rhttp.Timeout(time.Duration(conf.Timeout*int64(time.Second))),
+			rhttp.Insecure(conf.Insecure),
+		),
+		tokens:   tokens,
+		states:   states,
+		log:      log,
+		deposits: map[string]*Deposit{},
+	}, nil
+}
+
+// Close performs cleanup.
+func (s *svc) Close() error {
+	return s.states.Close()
+}
+
+func (s *svc) Prefix() string {
+	return s.conf.Prefix
+}
+
+func (s *svc) Unprotected() []string {
+	// the repository redirects the browser of the user to the callback
+	return []string{"/oauth/callback"}
+}
+
+// Handler serves GET /oauth to know whether the account of the user is
+// connected, GET /oauth/authorize to connect it, DELETE /oauth to disconnect
+// it, POST /deposits to deposit a folder, GET /deposits to list the deposits
+// of the user and GET /deposits/<id> to get the progress of a deposit.
+func (s *svc) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var head, tail string
+		head, r.URL.Path = router.ShiftPath(r.URL.Path)
+		tail, _ = router.ShiftPath(r.URL.Path)
+
+		switch {
+		case head == "oauth" && tail == "" && r.Method == http.MethodGet:
+			u := ctxpkg.ContextMustGetUser(r.Context())
+			writeJSON(w, http.StatusOK, map[string]bool{"connected": s.tokens.get(u.Id) != nil})
+		case head == "oauth" && tail == "" && r.Method == http.MethodDelete:
+			s.handleDisconnect(w, r)
+		case head == "oauth" && tail == "authorize" && r.Method == http.MethodGet:
+			s.handleAuthorize(w, r)
+		case head == "oauth" && tail == "callback" && r.Method == http.MethodGet:
+			s.handleCallback(w, r)
+		case head == "deposits" && tail == "" && r.Method == http.MethodPost:
+			s.handleDeposit(w, r)
+		case head == "deposits" && tail == "" && r.Method == http.MethodGet:
+			s.handleList(w, r)
+		case head == "deposits" && tail != "" && r.Method == http.MethodGet:
+			if d := s.getDeposit(r.Context(), tail); d != nil {
+				writeJSON(w, http.StatusOK, d.snapshot())
+				return
+			}
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+}
+
+// handleAuthorize redirects the user to the repository to grant reva access
+// to their account.
+func (s *svc) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	u := ctxpkg.ContextMustGetUser(r.Context())
+	state := uuid.New().String()
+	if err := s.states.Set(state, u.Id); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, s.oauth.AuthCodeURL(state, oauth2.AccessTypeOffline), http.StatusFound)
+}
+
+// handleCallback exchanges the authorization code for a token, for the user
+// who started the authorization.
+func (s *svc) handleCallback(w http.ResponseWriter, r *http.Request) {
+	log := appctx.GetLogger(r.Context())
+	q := r.URL.Query()
+	if e := q.Get("error"); e != "" {
+		http.Error(w, "the authorization failed: "+e, http.StatusForbidden)
+		return
+	}
+
+	state := q.Get("state")
+	v, err := s.states.Get(state)
+	if err != nil {
+		http.Error(w, "unknown or expired authorization", http.StatusBadRequest)
+		return
+	}
+	_ = s.states.Remove(state)
+	uid := v.(*userpb.UserId)
+
+	ctx := context.WithValue(r.Context(), oauth2.HTTPClient, s.client)
+	tkn, err := s.oauth.Exchange(ctx, q.Get("code"))
+	if err != nil {
+		log.Error().Err(err).Msg("invenio: error exchanging authorization code")
+		http.Error(w, "error obtaining the token", http.StatusBadGateway)
+		return
+	}
+	if err := s.tokens.set(uid, tkn); err != nil {
+		log.Error().Err(err).Msg("invenio: error storing token")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write([]byte("The account is connected, this window can be closed.\n"))
+}
+
+func (s *svc) handleDisconnect(w http.ResponseWriter, r *http.Request) {
+	u := ctxpkg.ContextMustGetUser(r.Context())
+	if err := s.tokens.set(u.Id, nil); err != nil {
+		appctx.GetLogger(r.Context()).Error().Err(err).Msg("invenio: error removing token")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type depositRequest struct {
+	Path string `json:"path"`
+	// Metadata are the metadata of the record, as expected by the
+	// repository.
+	Metadata json.RawMessage `json:"metadata"`
+}
+
+// handleDeposit starts the deposit of a folder.
+func (s *svc) handleDeposit(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	u := ctxpkg.ContextMustGetUser(ctx)
+	tkn, ok := token.ContextGetToken(ctx)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	oauthToken := s.tokens.get(u.Id)
+	if oauthToken == nil {
+		http.Error(w, "the account of the repository is not connected", http.StatusPreconditionFailed)
+		return
+	}
+
+	req := &depositRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Path == "" || len(req.Metadata) == 0 {
+		http.Error(w, "path and metadata are required", http.StatusBadRequest)
+		return
+	}
+
+	gwc, err := pool.GetGatewayServiceClient(s.conf.GatewaySvc)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	d := &Deposit{
+		ID:      uuid.New().String(),
+		Path:    path.Clean(req.Path),
+		State:   statePending,
+		Created: time.Now(),
+		user:    u.Id,
+	}
+	s.mu.Lock()
+	s.deposits[d.ID] = d
+	s.mu.Unlock()
+
+	// the deposit outlives the request, so it runs with a context of its own
+	jobCtx := token.ContextSetToken(context.Background(), tkn)
+	jobCtx = metadata.AppendToOutgoingContext(jobCtx, token.TokenHeader, tkn)
+	jobCtx = ctxpkg.ContextSetUser(jobCtx, u)
+	jobCtx = appctx.WithLogger(jobCtx, s.log)
+
+	ts := s.oauth.TokenSource(context.WithValue(jobCtx, oauth2.HTTPClient, s.client), oauthToken)
+	repo, _ := invenio.New(s.conf.Flavor, s.conf.URL, &http.Client{
+		Transport: &oauth2.Transport{Source: ts, Base: s.client.Transport},
+		Timeout:   s.client.Timeout,
+	})
+	dep := &depositor{gwc: gwc, repo: repo, client: s.client, metadataKey: s.conf.MetadataKey}
+
+	go func() {
+		if err := dep.run(jobCtx, d, req.Metadata); err != nil {
+			s.fail(d, err)
+		}
+		// keep the token refreshed during the deposit
+		if t, err := ts.Token(); err == nil && t.AccessToken != oauthToken.AccessToken {
+			if err := s.tokens.set(u.Id, t); err != nil {
+				s.log.Error().Err(err).Msg("invenio: error storing refreshed token")
+			}
+		}
+	}()
+
+	w.Header().Set("Location", path.Join("/", s.conf.Prefix, "deposits", d.ID))
+	writeJSON(w, http.StatusAccepted, d.snapshot())
+}
+
+func (s *svc) handleList(w http.ResponseWriter, r *http.Request) {
+	u := ctxpkg.ContextMustGetUser(r.Context())
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := []*Deposit{}
+	for _, d := range s.deposits {
+		if utils.UserEqual(d.user, u.Id) {
+			list = append(list, d.snapshot())
+		}
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *svc) getDeposit(ctx context.Context, id string) *Deposit {
+	u := ctxpkg.ContextMustGetUser(ctx)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, ok := s.deposits[id]
+	if !ok || !utils.UserEqual(d.user, u.Id) {
+		return nil
+	}
+	return d
+}
+
+func (s *svc) fail(d *Deposit, err error) {
+	s.log.Error().Err(err).Str("deposit", d.ID).Msg("invenio: deposit failed")
+	d.update(func(d *Deposit) {
+		d.State = stateFailed
+		d.Error = err.Error()
+		d.Finished = time.Now()
+	})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
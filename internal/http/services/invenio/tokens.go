@@ -0,0 +1,83 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package invenio
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+)
+
+// tokenStore persists the OAuth tokens of the users in a JSON file.
+type tokenStore struct {
+	mu     sync.Mutex
+	file   string
+	tokens map[string]*oauth2.Token
+}
+
+func newTokenStore(file string) (*tokenStore, error) {
+	s := &tokenStore{file: file, tokens: map[string]*oauth2.Token{}}
+	data, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &s.tokens); err != nil {
+		return nil, errors.Wrap(err, "invenio: error decoding tokens")
+	}
+	return s, nil
+}
+
+func tokenKey(u *userpb.UserId) string {
+	return u.Idp + "!" + u.OpaqueId
+}
+
+func (s *tokenStore) get(u *userpb.UserId) *oauth2.Token {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tokens[tokenKey(u)]
+}
+
+// set stores the token of the user, removing it if it is nil.
+func (s *tokenStore) set(u *userpb.UserId, t *oauth2.Token) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if t == nil {
+		delete(s.tokens, tokenKey(u))
+	} else {
+		s.tokens[tokenKey(u)] = t
+	}
+
+	data, err := json.Marshal(s.tokens)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.file), 0700); err != nil {
+		return err
+	}
+	return errors.Wrap(ioutil.WriteFile(s.file, data, 0600), "invenio: error writing tokens")
+}
@@ -20,9 +20,19 @@ package loader
 
 import (
 	// Load core HTTP services
+	_ "github.com/cs3org/reva/internal/http/services/accounts"
+	_ "github.com/cs3org/reva/internal/http/services/admin"
+	_ "github.com/cs3org/reva/internal/http/services/dataexport"
 	_ "github.com/cs3org/reva/internal/http/services/datagateway"
 	_ "github.com/cs3org/reva/internal/http/services/dataprovider"
+	_ "github.com/cs3org/reva/internal/http/services/debug"
+	_ "github.com/cs3org/reva/internal/http/services/fuseexport"
+	_ "github.com/cs3org/reva/internal/http/services/graph"
+	_ "github.com/cs3org/reva/internal/http/services/guests"
+	_ "github.com/cs3org/reva/internal/http/services/health"
 	_ "github.com/cs3org/reva/internal/http/services/helloworld"
+	_ "github.com/cs3org/reva/internal/http/services/invenio"
+	_ "github.com/cs3org/reva/internal/http/services/loglevel"
 	_ "github.com/cs3org/reva/internal/http/services/mentix"
 	_ "github.com/cs3org/reva/internal/http/services/meshdirectory"
 	_ "github.com/cs3org/reva/internal/http/services/metrics"
@@ -30,9 +40,18 @@ import (
 	_ "github.com/cs3org/reva/internal/http/services/oidcprovider"
 	_ "github.com/cs3org/reva/internal/http/services/owncloud/ocdav"
 	_ "github.com/cs3org/reva/internal/http/services/owncloud/ocs"
+	_ "github.com/cs3org/reva/internal/http/services/permissions"
+	_ "github.com/cs3org/reva/internal/http/services/postprocessing"
 	_ "github.com/cs3org/reva/internal/http/services/prometheus"
+	_ "github.com/cs3org/reva/internal/http/services/publications"
+	_ "github.com/cs3org/reva/internal/http/services/restgateway"
+	_ "github.com/cs3org/reva/internal/http/services/s3"
+	_ "github.com/cs3org/reva/internal/http/services/scim"
 	_ "github.com/cs3org/reva/internal/http/services/siteacc"
+	_ "github.com/cs3org/reva/internal/http/services/sse"
 	_ "github.com/cs3org/reva/internal/http/services/sysinfo"
+	_ "github.com/cs3org/reva/internal/http/services/theme"
+	_ "github.com/cs3org/reva/internal/http/services/webhooks"
 	_ "github.com/cs3org/reva/internal/http/services/wellknown"
 	// Add your own service here
 )
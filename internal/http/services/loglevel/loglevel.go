@@ -0,0 +1,131 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package loglevel
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/logger"
+	"github.com/cs3org/reva/pkg/permission"
+	permregistry "github.com/cs3org/reva/pkg/permission/manager/registry"
+	"github.com/cs3org/reva/pkg/rhttp/global"
+	"github.com/cs3org/reva/pkg/rhttp/router"
+	ctxpkg "github.com/cs3org/reva/pkg/user"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+func init() {
+	global.Register("loglevel", New)
+}
+
+type config struct {
+	Prefix string `mapstructure:"prefix"`
+	// Admins and AdminGroups are the users, and the groups of users, allowed
+	// to change the log levels, when no PermissionDriver grants the
+	// system.admin capability.
+	Admins            []string                          `mapstructure:"admins"`
+	AdminGroups       []string                          `mapstructure:"admin_groups"`
+	PermissionDriver  string                            `mapstructure:"permission_driver"`
+	PermissionDrivers map[string]map[string]interface{} `mapstructure:"permission_drivers"`
+}
+
+func (c *config) init() {
+	if c.Prefix == "" {
+		c.Prefix = "loglevel"
+	}
+}
+
+type svc struct {
+	conf *config
+	pm   permission.Manager
+}
+
+// New returns a debug service allowing the administrators to change the log
+// level of the requests handled by a given service at runtime. GET / lists
+// the overridden levels, PUT /{service}?level={level} overrides the level of
+// the service and DELETE /{service} restores the configured one.
+func New(m map[string]interface{}, log *zerolog.Logger) (global.Service, error) {
+	conf := &config{}
+	if err := mapstructure.Decode(m, conf); err != nil {
+		return nil, err
+	}
+	conf.init()
+	pm, err := permregistry.New(conf.PermissionDriver, conf.PermissionDrivers)
+	if err != nil {
+		return nil, errors.Wrap(err, "loglevel: error creating permission manager")
+	}
+	return &svc{conf: conf, pm: pm}, nil
+}
+
+// Close performs cleanup.
+func (s *svc) Close() error {
+	return nil
+}
+
+func (s *svc) Prefix() string {
+	return s.conf.Prefix
+}
+
+func (s *svc) Unprotected() []string {
+	return []string{}
+}
+
+func (s *svc) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.isAdmin(r) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		service, _ := router.ShiftPath(r.URL.Path)
+
+		switch {
+		case service == "" && r.Method == http.MethodGet:
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(logger.ServiceLevels())
+		case service != "" && r.Method == http.MethodPut:
+			if err := logger.SetServiceLevel(service, r.URL.Query().Get("level")); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		case service != "" && r.Method == http.MethodDelete:
+			logger.ResetServiceLevel(service)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+}
+
+func (s *svc) isAdmin(r *http.Request) bool {
+	u, ok := ctxpkg.ContextGetUser(r.Context())
+	if !ok {
+		return false
+	}
+	allowed, err := permission.IsAdmin(r.Context(), s.pm, u, s.conf.Admins, s.conf.AdminGroups)
+	if err != nil {
+		appctx.GetLogger(r.Context()).Error().Err(err).Msg("loglevel: error checking the permissions")
+	}
+	return allowed
+}
@@ -19,27 +19,165 @@
 package ocmd
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	ocmprovider "github.com/cs3org/go-cs3apis/cs3/ocm/provider/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	ocmcorepb "github.com/cs3org/reva/internal/grpc/services/ocmcore/proto"
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/conversions"
 	"github.com/cs3org/reva/pkg/appctx"
-	"github.com/cs3org/reva/pkg/rhttp/router"
+	"github.com/cs3org/reva/pkg/ocm/share"
+	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
+	"github.com/cs3org/reva/pkg/utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type notificationsHandler struct {
+	gatewayAddr string
 }
 
 func (h *notificationsHandler) init(c *Config) {
+	h.gatewayAddr = c.GatewaySvc
 }
 
 func (h *notificationsHandler) Handler() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		log := appctx.GetLogger(r.Context())
 
-		var head string
-		head, r.URL.Path = router.ShiftPath(r.URL.Path)
+		switch r.Method {
+		case http.MethodPost:
+			h.processNotification(w, r)
+		default:
+			WriteError(w, r, APIErrorInvalidParameter, "Only POST method is allowed", nil)
+		}
+	})
+}
+
+// processNotification applies the notification sent by another provider about
+// a share between one of its users and one of ours.
+func (h *notificationsHandler) processNotification(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := appctx.GetLogger(ctx)
 
-		log.Debug().Str("head", head).Str("tail", r.URL.Path).Msg("http routing")
+	var n share.Notification
+	if err := json.NewDecoder(r.Body).Decode(&n); err != nil {
+		WriteError(w, r, APIErrorInvalidParameter, "invalid notification", nil)
+		return
+	}
+	d := n.Notification
+	if n.NotificationType == "" || d == nil || d.Owner == "" || d.ShareWith == "" || d.MeshProvider == "" {
+		WriteError(w, r, APIErrorInvalidParameter, "missing notification details", nil)
+		return
+	}
+	resource := strings.SplitN(n.ProviderID, ":", 2)
+	if len(resource) != 2 {
+		WriteError(w, r, APIErrorInvalidParameter, "invalid providerId", nil)
+		return
+	}
 
-		w.WriteHeader(http.StatusOK)
+	gatewayClient, err := pool.GetGatewayServiceClient(h.gatewayAddr)
+	if err != nil {
+		WriteError(w, r, APIErrorServerError, "error getting gateway grpc client", err)
+		return
+	}
+
+	clientIP, err := utils.GetClientIP(r)
+	if err != nil {
+		WriteError(w, r, APIErrorServerError, fmt.Sprintf("error retrieving client IP from request: %s", r.RemoteAddr), err)
+		return
+	}
+	providerAllowedResp, err := gatewayClient.IsProviderAllowed(ctx, &ocmprovider.IsProviderAllowedRequest{
+		Provider: &ocmprovider.ProviderInfo{
+			Domain:   d.MeshProvider,
+			Services: []*ocmprovider.Service{{Host: clientIP}},
+		},
 	})
+	if err != nil {
+		WriteError(w, r, APIErrorServerError, "error sending a grpc is provider allowed request", err)
+		return
+	}
+	if providerAllowedResp.Status.Code != rpc.Code_CODE_OK {
+		WriteError(w, r, APIErrorUnauthenticated, "provider not authorized", errors.New(providerAllowedResp.Status.Message))
+		return
+	}
+
+	req := &ocmcorepb.NotificationRequest{
+		Type:            n.NotificationType,
+		StorageId:       resource[0],
+		OpaqueId:        resource[1],
+		OwnerIdp:        d.MeshProvider,
+		OwnerOpaqueId:   d.Owner,
+		GranteeIdp:      d.MeshProvider,
+		GranteeOpaqueId: d.ShareWith,
+		SharedSecret:    d.SharedSecret,
+	}
+
+	// the user of the other provider is identified by its domain, ours
+	// needs to be looked up
+	local := d.ShareWith
+	if n.NotificationType == share.NotificationShareAccepted || n.NotificationType == share.NotificationShareDeclined {
+		local = d.Owner
+	}
+	userRes, err := gatewayClient.GetUser(ctx, &userpb.GetUserRequest{
+		UserId: &userpb.UserId{OpaqueId: local},
+	})
+	if err != nil {
+		WriteError(w, r, APIErrorServerError, "error searching user", err)
+		return
+	}
+	if userRes.Status.Code != rpc.Code_CODE_OK {
+		WriteError(w, r, APIErrorNotFound, "user not found", errors.New(userRes.Status.Message))
+		return
+	}
+	if local == d.Owner {
+		req.OwnerIdp = userRes.User.Id.Idp
+	} else {
+		req.GranteeIdp = userRes.User.Id.Idp
+	}
+
+	if n.NotificationType == share.NotificationPermissionsChanged {
+		permissions, err := conversions.NewPermissions(d.Permissions)
+		if err != nil {
+			WriteError(w, r, APIErrorInvalidParameter, err.Error(), nil)
+			return
+		}
+		role := conversions.RoleFromOCSPermissions(permissions)
+		if req.Permissions, err = json.Marshal(role.CS3ResourcePermissions()); err != nil {
+			WriteError(w, r, APIErrorServerError, "could not encode role", nil)
+			return
+		}
+	}
+
+	c, err := pool.GetOCMNotificationsClient(h.gatewayAddr)
+	if err != nil {
+		WriteError(w, r, APIErrorServerError, "error getting ocm notifications grpc client", err)
+		return
+	}
+	if _, err := c.ProcessNotification(ctx, req); err != nil {
+		switch status.Code(err) {
+		case codes.NotFound:
+			WriteError(w, r, APIErrorNotFound, "share not found", nil)
+		case codes.PermissionDenied:
+			WriteError(w, r, APIErrorUnauthenticated, "invalid shared secret", nil)
+		case codes.InvalidArgument:
+			WriteError(w, r, APIErrorInvalidParameter, status.Convert(err).Message(), nil)
+		default:
+			WriteError(w, r, APIErrorServerError, "error processing notification", err)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if _, err := w.Write([]byte("{}")); err != nil {
+		log.Error().Err(err).Msg("error writing response")
+		return
+	}
+
+	log.Info().Str("type", n.NotificationType).Msg("Notification processed.")
 }
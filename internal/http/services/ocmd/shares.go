@@ -23,6 +23,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
 	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
@@ -32,6 +33,7 @@ import (
 	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
 	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/conversions"
 	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/ocm/share"
 	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
 	"github.com/cs3org/reva/pkg/utils"
 )
@@ -155,6 +157,28 @@ func (h *sharesHandler) createShare(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	protocolOpaque := map[string]*types.OpaqueEntry{
+		"permissions": {
+			Decoder: "json",
+			Value:   val,
+		},
+		"token": {
+			Decoder: "plain",
+			Value:   []byte(token),
+		},
+	}
+	// the optional protection of the share, enforced when accessing it
+	if exp, ok := options["expiration"].(string); ok && exp != "" {
+		if _, err := strconv.ParseInt(exp, 10, 64); err != nil {
+			WriteError(w, r, APIErrorInvalidParameter, "protocol: invalid expiration", nil)
+			return
+		}
+		protocolOpaque[share.ExpirationOpaqueKey] = &types.OpaqueEntry{Decoder: "plain", Value: []byte(exp)}
+	}
+	if secret, ok := options["sharedSecret"].(string); ok && secret != "" {
+		protocolOpaque[share.SharedSecretOpaqueKey] = &types.OpaqueEntry{Decoder: "plain", Value: []byte(secret)}
+	}
+
 	ownerID := &userpb.UserId{
 		OpaqueId: owner,
 		Idp:      meshProvider,
@@ -167,16 +191,7 @@ func (h *sharesHandler) createShare(w http.ResponseWriter, r *http.Request) {
 		Protocol: &ocmcore.Protocol{
 			Name: protocolDecoded["name"].(string),
 			Opaque: &types.Opaque{
-				Map: map[string]*types.OpaqueEntry{
-					"permissions": {
-						Decoder: "json",
-						Value:   val,
-					},
-					"token": {
-						Decoder: "plain",
-						Value:   []byte(token),
-					},
-				},
+				Map: protocolOpaque,
 			},
 		},
 	}
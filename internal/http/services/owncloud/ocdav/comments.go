@@ -0,0 +1,543 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package ocdav
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/comments"
+	commentsregistry "github.com/cs3org/reva/pkg/comments/manager/registry"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/events"
+	eventsregistry "github.com/cs3org/reva/pkg/events/driver/registry"
+	"github.com/cs3org/reva/pkg/rhttp/router"
+	ctxuser "github.com/cs3org/reva/pkg/user"
+	"github.com/rs/zerolog"
+)
+
+// maxCommentLength is the maximum number of characters of a comment, as
+// enforced by oc10.
+const maxCommentLength = 1000
+
+// CommentsHandler handles the comments requests
+type CommentsHandler struct {
+	manager comments.Manager
+	stream  events.Publisher
+}
+
+func (h *CommentsHandler) init(c *Config) error {
+	f, ok := commentsregistry.NewFuncs[c.CommentsDriver]
+	if !ok {
+		return errtypes.NotFound("ocdav: comments driver not found: " + c.CommentsDriver)
+	}
+	m, err := f(c.CommentsDrivers[c.CommentsDriver])
+	if err != nil {
+		return err
+	}
+	stream, err := eventsregistry.NewStream(c.Events)
+	if err != nil {
+		return err
+	}
+	h.manager = m
+	h.stream = stream
+	return nil
+}
+
+// Handler handles requests
+// the comments of a file can be listed with a REPORT or a PROPFIND to
+// /remote.php/dav/comments/files/<fileid>, and a comment is added with a
+// POST to the same url. A comment is identified by its id, eg.
+// /remote.php/dav/comments/files/<fileid>/42
+func (h *CommentsHandler) Handler(s *svc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		var objectType, id string
+		objectType, r.URL.Path = router.ShiftPath(r.URL.Path)
+		id, r.URL.Path = router.ShiftPath(r.URL.Path)
+		rid := unwrap(id)
+		if objectType != comments.ObjectTypeFiles || rid == nil {
+			http.Error(w, "404 Not Found", http.StatusNotFound)
+			return
+		}
+
+		if r.Method == http.MethodOptions {
+			s.handleOptions(w, r, "comments")
+			return
+		}
+
+		sublog := appctx.GetLogger(ctx).With().Interface("resourceid", rid).Logger()
+
+		// only the users who can access the file can access its comments
+		info, ok := h.statResource(w, r, s, rid, &sublog)
+		if !ok {
+			return
+		}
+
+		// baseURI is encoded as part of the response payload in href field
+		baseURI := path.Join(ctx.Value(ctxKeyBaseURI).(string), objectType, id)
+		ctx = context.WithValue(ctx, ctxKeyBaseURI, baseURI)
+		r = r.WithContext(ctx)
+
+		var key string
+		key, r.URL.Path = router.ShiftPath(r.URL.Path)
+		if key == "" {
+			switch r.Method {
+			case http.MethodPost:
+				h.doAdd(w, r, s, info, &sublog)
+			case "REPORT":
+				h.doFilter(w, r, s, info, &sublog)
+			case "PROPFIND":
+				h.doList(w, r, s, info, &sublog)
+			default:
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+			return
+		}
+
+		cid, err := strconv.ParseInt(key, 10, 64)
+		if err != nil {
+			http.Error(w, "404 Not Found", http.StatusNotFound)
+			return
+		}
+		c, err := h.manager.Get(ctx, cid)
+		if _, isNotFound := err.(errtypes.NotFound); isNotFound || (err == nil && c.ObjectID != wrapResourceID(info.Id)) {
+			http.Error(w, "404 Not Found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			sublog.Error().Err(err).Msg("error getting comment")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		switch r.Method {
+		case "PROPFIND":
+			h.writeComments(w, r, s, []*comments.Comment{c}, false, &sublog)
+		case "PROPPATCH":
+			h.doUpdate(w, r, s, info, c, &sublog)
+		case http.MethodDelete:
+			h.doDelete(w, r, c, &sublog)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+}
+
+func (h *CommentsHandler) statResource(w http.ResponseWriter, r *http.Request, s *svc, rid *provider.ResourceId, log *zerolog.Logger) (*provider.ResourceInfo, bool) {
+	client, err := s.getClient()
+	if err != nil {
+		log.Error().Err(err).Msg("error getting grpc client")
+		w.WriteHeader(http.StatusInternalServerError)
+		return nil, false
+	}
+	res, err := client.Stat(r.Context(), &provider.StatRequest{
+		Ref: &provider.Reference{Spec: &provider.Reference_Id{Id: rid}},
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("error sending a grpc stat request")
+		w.WriteHeader(http.StatusInternalServerError)
+		return nil, false
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		HandleErrorStatus(log, w, res.Status)
+		return nil, false
+	}
+	return res.Info, true
+}
+
+// newComment is the body of the requests adding a comment.
+type newComment struct {
+	ActorType string `json:"actorType"`
+	Verb      string `json:"verb"`
+	Message   string `json:"message"`
+}
+
+func (h *CommentsHandler) doAdd(w http.ResponseWriter, r *http.Request, s *svc, info *provider.ResourceInfo, log *zerolog.Logger) {
+	ctx := r.Context()
+	u, ok := ctxuser.ContextGetUser(ctx)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var body newComment
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		log.Debug().Err(err).Msg("error decoding comment")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if (body.Verb != "" && body.Verb != comments.VerbComment) || (body.ActorType != "" && body.ActorType != "users") {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if !validCommentMessage(body.Message) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	client, err := s.getClient()
+	if err != nil {
+		log.Error().Err(err).Msg("error getting grpc client")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	mentions, mentioned := resolveMentions(ctx, client, body.Message, u, log)
+
+	c := &comments.Comment{
+		ObjectType:    comments.ObjectTypeFiles,
+		ObjectID:      wrapResourceID(info.Id),
+		Actor:         u.Id,
+		ActorUsername: u.Username,
+		Verb:          comments.VerbComment,
+		Message:       body.Message,
+		Mentions:      mentions,
+		CreationTime:  time.Now(),
+	}
+	if err := h.manager.Add(ctx, c); err != nil {
+		log.Error().Err(err).Msg("error adding comment")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	h.publish(ctx, c, info, mentioned, log)
+
+	w.Header().Set("Content-Location", path.Join(ctx.Value(ctxKeyBaseURI).(string), strconv.FormatInt(c.ID, 10)))
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (h *CommentsHandler) doUpdate(w http.ResponseWriter, r *http.Request, s *svc, info *provider.ResourceInfo, c *comments.Comment, log *zerolog.Logger) {
+	ctx := r.Context()
+	u, ok := ctxuser.ContextGetUser(ctx)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if !isCommentAuthor(u, c) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
+	pp, status, err := readProppatch(r.Body)
+	if err != nil {
+		log.Debug().Err(err).Msg("error reading proppatch")
+		w.WriteHeader(status)
+		return
+	}
+	var message *string
+	for _, patch := range pp {
+		for _, p := range patch.Props {
+			if p.XMLName.Space != _nsOwncloud || p.XMLName.Local != "message" || patch.Remove {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			var m string
+			if err := xml.Unmarshal([]byte("<message>"+string(p.InnerXML)+"</message>"), &m); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			message = &m
+		}
+	}
+	if message == nil || !validCommentMessage(*message) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	client, err := s.getClient()
+	if err != nil {
+		log.Error().Err(err).Msg("error getting grpc client")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	mentions, mentioned := resolveMentions(ctx, client, *message, u, log)
+	if err := h.manager.Update(ctx, c.ID, *message, mentions); err != nil {
+		log.Error().Err(err).Msg("error updating comment")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// only the users who were not mentioned before are notified
+	previous := map[string]bool{}
+	for _, m := range c.Mentions {
+		previous[m] = true
+	}
+	newlyMentioned := []*userpb.UserId{}
+	for i, m := range mentions {
+		if !previous[m] {
+			newlyMentioned = append(newlyMentioned, mentioned[i])
+		}
+	}
+	h.publish(ctx, c, info, newlyMentioned, log)
+
+	name := xml.Name{Space: _nsOwncloud, Local: "message"}
+	propRes, err := s.formatProppatchResponse(ctx, []xml.Name{name}, nil, path.Join(ctx.Value(ctxKeyBaseURI).(string), strconv.FormatInt(c.ID, 10)))
+	if err != nil {
+		log.Error().Err(err).Msg("error formatting proppatch response")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusMultiStatus)
+	if _, err := w.Write([]byte(propRes)); err != nil {
+		log.Err(err).Msg("error writing response")
+	}
+}
+
+func (h *CommentsHandler) doDelete(w http.ResponseWriter, r *http.Request, c *comments.Comment, log *zerolog.Logger) {
+	ctx := r.Context()
+	u, ok := ctxuser.ContextGetUser(ctx)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	if !isCommentAuthor(u, c) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	if err := h.manager.Delete(ctx, c.ID); err != nil {
+		log.Error().Err(err).Msg("error deleting comment")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// doList lists all the comments of the file, if the depth allows it.
+func (h *CommentsHandler) doList(w http.ResponseWriter, r *http.Request, s *svc, info *provider.ResourceInfo, log *zerolog.Logger) {
+	list := []*comments.Comment{}
+	if r.Header.Get("Depth") != "0" {
+		var err error
+		list, err = h.manager.List(r.Context(), &comments.Filter{
+			ObjectType: comments.ObjectTypeFiles,
+			ObjectID:   wrapResourceID(info.Id),
+		})
+		if err != nil {
+			log.Error().Err(err).Msg("error listing comments")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+	}
+	h.writeComments(w, r, s, list, true, log)
+}
+
+// http://owncloud.org/ns filter-comments, as sent by the web clients
+type filterComments struct {
+	XMLName  xml.Name `xml:"http://owncloud.org/ns filter-comments"`
+	Limit    int      `xml:"http://owncloud.org/ns limit"`
+	Offset   int      `xml:"http://owncloud.org/ns offset"`
+	Datetime string   `xml:"http://owncloud.org/ns datetime"`
+}
+
+// doFilter lists a page of the comments of the file, most recent first.
+func (h *CommentsHandler) doFilter(w http.ResponseWriter, r *http.Request, s *svc, info *provider.ResourceInfo, log *zerolog.Logger) {
+	var fc filterComments
+	if err := xml.NewDecoder(r.Body).Decode(&fc); err != nil && err != io.EOF {
+		log.Debug().Err(err).Msg("error reading report")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	f := &comments.Filter{
+		ObjectType: comments.ObjectTypeFiles,
+		ObjectID:   wrapResourceID(info.Id),
+		Limit:      fc.Limit,
+		Offset:     fc.Offset,
+	}
+	if fc.Datetime != "" {
+		t, err := parseCommentsDatetime(fc.Datetime)
+		if err != nil {
+			log.Debug().Err(err).Msg("error parsing datetime")
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.Before = t
+	}
+
+	list, err := h.manager.List(r.Context(), f)
+	if err != nil {
+		log.Error().Err(err).Msg("error listing comments")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	h.writeComments(w, r, s, list, false, log)
+}
+
+func parseCommentsDatetime(v string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, time.RFC1123Z, RFC1123} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t, nil
+		}
+	}
+	return time.Parse(time.RFC1123, v)
+}
+
+// writeComments writes the multistatus response of the comments, optionally
+// preceded by the collection of the comments of the file. All the
+// properties of the comments are returned, whatever the request asked for.
+func (h *CommentsHandler) writeComments(w http.ResponseWriter, r *http.Request, s *svc, list []*comments.Comment, collection bool, log *zerolog.Logger) {
+	ctx := r.Context()
+	baseURI := ctx.Value(ctxKeyBaseURI).(string)
+
+	responses := make([]*responseXML, 0, len(list)+1)
+	if collection {
+		responses = append(responses, &responseXML{
+			Href: encodePath(baseURI) + "/",
+			Propstat: []propstatXML{{
+				Status: "HTTP/1.1 200 OK",
+				Prop: []*propertyXML{
+					s.newPropRaw("d:resourcetype", "<d:collection/>"),
+					s.newProp("oc:count", strconv.Itoa(len(list))),
+				},
+			}},
+		})
+	}
+
+	names := newDisplayNames(s, log)
+	for _, c := range list {
+		var mentions strings.Builder
+		for _, m := range c.Mentions {
+			mentions.WriteString("<oc:mention><oc:mentionType>users</oc:mentionType><oc:mentionId>")
+			mentions.Write(s.xmlEscaped(m))
+			mentions.WriteString("</oc:mentionId><oc:mentionDisplayName>")
+			mentions.Write(s.xmlEscaped(names.get(ctx, m)))
+			mentions.WriteString("</oc:mentionDisplayName></oc:mention>")
+		}
+		responses = append(responses, &responseXML{
+			Href: encodePath(path.Join(baseURI, strconv.FormatInt(c.ID, 10))),
+			Propstat: []propstatXML{{
+				Status: "HTTP/1.1 200 OK",
+				Prop: []*propertyXML{
+					s.newProp("oc:id", strconv.FormatInt(c.ID, 10)),
+					s.newProp("oc:verb", c.Verb),
+					s.newProp("oc:actorType", "users"),
+					s.newProp("oc:actorId", c.ActorUsername),
+					s.newProp("oc:actorDisplayName", names.get(ctx, c.ActorUsername)),
+					s.newProp("oc:creationDateTime", c.CreationTime.UTC().Format(RFC1123)),
+					s.newProp("oc:objectType", c.ObjectType),
+					s.newProp("oc:objectId", c.ObjectID),
+					s.newProp("oc:message", c.Message),
+					s.newPropRaw("oc:mentions", mentions.String()),
+				},
+			}},
+		})
+	}
+
+	responsesXML, err := xml.Marshal(&responses)
+	if err != nil {
+		log.Error().Err(err).Msg("error formatting comments")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	msg := `<?xml version="1.0" encoding="utf-8"?><d:multistatus xmlns:d="DAV:" `
+	msg += `xmlns:s="http://sabredav.org/ns" xmlns:oc="http://owncloud.org/ns">`
+	msg += string(responsesXML) + `</d:multistatus>`
+
+	w.Header().Set("DAV", "1, 3, extended-mkcol")
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusMultiStatus)
+	if _, err := w.Write([]byte(msg)); err != nil {
+		log.Err(err).Msg("error writing response")
+	}
+}
+
+func (h *CommentsHandler) publish(ctx context.Context, c *comments.Comment, info *provider.ResourceInfo, mentioned []*userpb.UserId, log *zerolog.Logger) {
+	if err := events.Publish(ctx, h.stream, events.CommentCreated{
+		CommentID:    strconv.FormatInt(c.ID, 10),
+		Author:       c.Actor,
+		ResourceID:   info.Id,
+		ResourceName: path.Base(info.Path),
+		Mentioned:    mentioned,
+		Time:         time.Now(),
+	}); err != nil {
+		log.Error().Err(err).Msg("error publishing comment event")
+	}
+}
+
+func validCommentMessage(m string) bool {
+	return strings.TrimSpace(m) != "" && utf8.RuneCountInString(m) <= maxCommentLength
+}
+
+func isCommentAuthor(u *userpb.User, c *comments.Comment) bool {
+	return c.Actor != nil && u.Id.GetOpaqueId() == c.Actor.OpaqueId && u.Id.GetIdp() == c.Actor.Idp
+}
+
+// resolveMentions returns the usernames mentioned in the message which
+// belong to existing users other than the author, along with their ids.
+func resolveMentions(ctx context.Context, client gateway.GatewayAPIClient, message string, author *userpb.User, log *zerolog.Logger) ([]string, []*userpb.UserId) {
+	usernames := []string{}
+	ids := []*userpb.UserId{}
+	for _, username := range comments.ParseMentions(message) {
+		if username == author.Username {
+			continue
+		}
+		res, err := client.GetUserByClaim(ctx, &userpb.GetUserByClaimRequest{Claim: "username", Value: username})
+		if err != nil {
+			log.Error().Err(err).Str("username", username).Msg("error looking up mentioned user")
+			continue
+		}
+		if res.Status.Code != rpc.Code_CODE_OK {
+			continue
+		}
+		usernames = append(usernames, username)
+		ids = append(ids, res.User.Id)
+	}
+	return usernames, ids
+}
+
+// displayNames looks up the display names of the users, falling back to
+// their usernames.
+type displayNames struct {
+	s     *svc
+	log   *zerolog.Logger
+	names map[string]string
+}
+
+func newDisplayNames(s *svc, log *zerolog.Logger) *displayNames {
+	return &displayNames{s: s, log: log, names: map[string]string{}}
+}
+
+func (d *displayNames) get(ctx context.Context, username string) string {
+	if name, ok := d.names[username]; ok {
+		return name
+	}
+	d.names[username] = username
+	client, err := d.s.getClient()
+	if err != nil {
+		d.log.Error().Err(err).Msg("error getting grpc client")
+		return username
+	}
+	res, err := client.GetUserByClaim(ctx, &userpb.GetUserByClaimRequest{Claim: "username", Value: username})
+	if err == nil && res.Status.Code == rpc.Code_CODE_OK && res.User.DisplayName != "" {
+		d.names[username] = res.User.DisplayName
+	}
+	return d.names[username]
+}
@@ -0,0 +1,185 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package ocdav
+
+import (
+	"context"
+	"flag"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocdav/gatewayfake"
+	_ "github.com/cs3org/reva/pkg/changelog/manager/memory"
+	_ "github.com/cs3org/reva/pkg/comments/manager/memory"
+	_ "github.com/cs3org/reva/pkg/events/driver/memory"
+	_ "github.com/cs3org/reva/pkg/favorite/manager/memory"
+	_ "github.com/cs3org/reva/pkg/maintenance/manager/memory"
+	"github.com/cs3org/reva/pkg/rgrpc/status"
+	ctxuser "github.com/cs3org/reva/pkg/user"
+	"github.com/stretchr/testify/mock"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+var einstein = &userpb.User{
+	Id:       &userpb.UserId{Idp: "idp", OpaqueId: "einstein-id"},
+	Username: "einstein",
+}
+
+// newTestService returns the service talking to the mock of the gateway,
+// whose expectations are asserted at the end of the test.
+func newTestService(t *testing.T) (*svc, *gatewayfake.GatewayAPIClient) {
+	s, err := New(map[string]interface{}{"webdav_namespace": "/home"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := gatewayfake.NewGatewayAPIClient(t)
+	s.(*svc).gatewayClient = c
+	return s.(*svc), c
+}
+
+// byPath matches the requests referencing the resource by the path.
+func byPath(p string) interface{} {
+	return mock.MatchedBy(func(req interface{ GetRef() *provider.Reference }) bool {
+		return req.GetRef().GetPath() == p
+	})
+}
+
+// byID matches the requests referencing the resource by the id.
+func byID(storageID, opaqueID string) interface{} {
+	return mock.MatchedBy(func(req interface{ GetRef() *provider.Reference }) bool {
+		id := req.GetRef().GetId()
+		return id.GetStorageId() == storageID && id.GetOpaqueId() == opaqueID
+	})
+}
+
+func serve(s *svc, method, target, body string, header map[string]string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	for k, v := range header {
+		r.Header.Set(k, v)
+	}
+	r = r.WithContext(ctxuser.ContextSetUser(context.Background(), einstein))
+	w := httptest.NewRecorder()
+	s.Handler().ServeHTTP(w, r)
+	return w
+}
+
+// assertGolden compares the body with the golden file, which the tests
+// rewrite when run with -update.
+func assertGolden(t *testing.T, name string, body []byte) {
+	t.Helper()
+	golden := filepath.Join("testdata", name)
+	if *update {
+		if err := ioutil.WriteFile(golden, append(body, '\n'), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected, err := ioutil.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(body); got != strings.TrimSuffix(string(expected), "\n") {
+		t.Errorf("the body differs from %s:\n%s", golden, got)
+	}
+}
+
+func photos() []*provider.ResourceInfo {
+	return []*provider.ResourceInfo{
+		{
+			Type: provider.ResourceType_RESOURCE_TYPE_CONTAINER,
+			Id:   &provider.ResourceId{StorageId: "storage-1", OpaqueId: "photos"},
+			Path: "/home/Photos",
+			Etag: `"e1"`,
+		},
+		{
+			Type: provider.ResourceType_RESOURCE_TYPE_FILE,
+			Id:   &provider.ResourceId{StorageId: "storage-1", OpaqueId: "moon"},
+			Path: "/home/Photos/moon.jpg",
+			Etag: `"e2"`,
+			Size: 1024,
+		},
+		{
+			Type: provider.ResourceType_RESOURCE_TYPE_CONTAINER,
+			Id:   &provider.ResourceId{StorageId: "storage-1", OpaqueId: "sub"},
+			Path: "/home/Photos/Sub",
+			Etag: `"e3"`,
+		},
+	}
+}
+
+const propfindBody = `<?xml version="1.0"?>
+<d:propfind xmlns:d="DAV:" xmlns:oc="http://owncloud.org/ns">
+	<d:prop><d:resourcetype/><d:getetag/><d:getcontentlength/><oc:fileid/><oc:favorite/></d:prop>
+</d:propfind>`
+
+func TestPropfind(t *testing.T) {
+	s, c := newTestService(t)
+	infos := photos()
+	c.On("Stat", mock.Anything, byPath("/home/Photos")).Return(&provider.StatResponse{Status: status.NewOK(context.Background()), Info: infos[0]}, nil)
+	c.On("ListContainer", mock.Anything, byPath("/home/Photos")).Return(&provider.ListContainerResponse{Status: status.NewOK(context.Background()), Infos: infos[1:]}, nil)
+	if err := s.favorites.SetFavorite(context.Background(), einstein.Id, infos[1].Id); err != nil {
+		t.Fatal(err)
+	}
+
+	w := serve(s, "PROPFIND", "/remote.php/dav/files/einstein/Photos", propfindBody, map[string]string{"Depth": "1"})
+	if w.Code != http.StatusMultiStatus {
+		t.Fatalf("PROPFIND returned %d, expected %d", w.Code, http.StatusMultiStatus)
+	}
+	assertGolden(t, "propfind_depth1.xml", w.Body.Bytes())
+}
+
+func TestPropfindNotFound(t *testing.T) {
+	s, c := newTestService(t)
+	c.On("Stat", mock.Anything, byPath("/home/missing")).Return(&provider.StatResponse{Status: status.NewNotFound(context.Background(), "not found")}, nil)
+
+	w := serve(s, "PROPFIND", "/remote.php/dav/files/einstein/missing", propfindBody, map[string]string{"Depth": "0"})
+	if w.Code != http.StatusNotFound {
+		t.Errorf("PROPFIND returned %d, expected %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestReportFavorites(t *testing.T) {
+	s, c := newTestService(t)
+	moon := photos()[1]
+	deleted := &provider.ResourceId{StorageId: "storage-1", OpaqueId: "deleted"}
+	c.On("Stat", mock.Anything, byID("storage-1", "moon")).Return(&provider.StatResponse{Status: status.NewOK(context.Background()), Info: moon}, nil)
+	c.On("Stat", mock.Anything, byID("storage-1", "deleted")).Return(&provider.StatResponse{Status: status.NewNotFound(context.Background(), "not found")}, nil)
+	for _, rid := range []*provider.ResourceId{moon.Id, deleted} {
+		if err := s.favorites.SetFavorite(context.Background(), einstein.Id, rid); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	body := `<?xml version="1.0"?>
+<oc:filter-files xmlns:d="DAV:" xmlns:oc="http://owncloud.org/ns">
+	<d:prop><d:getetag/><oc:fileid/><oc:favorite/></d:prop>
+	<oc:filter-rules><oc:favorite>1</oc:favorite></oc:filter-rules>
+</oc:filter-files>`
+	w := serve(s, "REPORT", "/remote.php/dav/files/einstein", body, nil)
+	if w.Code != http.StatusMultiStatus {
+		t.Fatalf("REPORT returned %d, expected %d", w.Code, http.StatusMultiStatus)
+	}
+	assertGolden(t, "report_favorites.xml", w.Body.Bytes())
+}
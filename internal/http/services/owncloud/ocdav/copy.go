@@ -31,6 +31,7 @@ import (
 	typespb "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
 	"github.com/cs3org/reva/internal/http/services/datagateway"
 	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/errtypes"
 	"github.com/cs3org/reva/pkg/rhttp"
 	"go.opencensus.io/trace"
 )
@@ -150,6 +151,11 @@ func (s *svc) handleCopy(w http.ResponseWriter, r *http.Request, ns string) {
 	}
 
 	err = s.descend(ctx, client, srcStatRes.Info, dst, depth == "infinity")
+	if _, ok := err.(errtypes.IsInsufficientStorage); ok && s.c.StrictRFC {
+		sublog.Debug().Err(err).Msg("insufficient storage")
+		w.WriteHeader(http.StatusInsufficientStorage)
+		return
+	}
 	if err != nil {
 		sublog.Error().Err(err).Str("depth", depth).Msg("error descending directory")
 		w.WriteHeader(http.StatusInternalServerError)
@@ -169,6 +175,9 @@ func (s *svc) descend(ctx context.Context, client gateway.GatewayAPIClient, src
 			},
 		}
 		createRes, err := client.CreateContainer(ctx, createReq)
+		if err == nil && createRes.Status.Code == rpc.Code_CODE_INSUFFICIENT_STORAGE {
+			return errtypes.InsufficientStorage(dst)
+		}
 		if err != nil || createRes.Status.Code != rpc.Code_CODE_OK {
 			return err
 		}
@@ -250,6 +259,9 @@ func (s *svc) descend(ctx context.Context, client gateway.GatewayAPIClient, src
 			return err
 		}
 
+		if uRes.Status.Code == rpc.Code_CODE_INSUFFICIENT_STORAGE {
+			return errtypes.InsufficientStorage(dst)
+		}
 		if uRes.Status.Code != rpc.Code_CODE_OK {
 			return fmt.Errorf("status code %d", uRes.Status.Code)
 		}
@@ -292,8 +304,11 @@ func (s *svc) descend(ctx context.Context, client gateway.GatewayAPIClient, src
 				return err
 			}
 			defer httpUploadRes.Body.Close()
+			if httpUploadRes.StatusCode == http.StatusInsufficientStorage {
+				return errtypes.InsufficientStorage(dst)
+			}
 			if httpUploadRes.StatusCode != http.StatusOK {
-				return err
+				return fmt.Errorf("status code %d", httpUploadRes.StatusCode)
 			}
 		}
 	}
@@ -30,7 +30,6 @@ import (
 	rpcv1beta1 "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
 	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	"github.com/cs3org/reva/pkg/appctx"
-	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
 	"github.com/cs3org/reva/pkg/rhttp/router"
 	tokenpkg "github.com/cs3org/reva/pkg/token"
 	"github.com/cs3org/reva/pkg/user"
@@ -43,6 +42,8 @@ type tokenStatInfoKey struct{}
 // DavHandler routes to the different sub handlers
 type DavHandler struct {
 	AvatarsHandler      *AvatarsHandler
+	CommentsHandler     *CommentsHandler
+	DeltaHandler        *DeltaHandler
 	FilesHandler        *WebDavHandler
 	FilesHomeHandler    *WebDavHandler
 	MetaHandler         *MetaHandler
@@ -56,6 +57,14 @@ func (h *DavHandler) init(c *Config) error {
 	if err := h.AvatarsHandler.init(c); err != nil {
 		return err
 	}
+	h.CommentsHandler = new(CommentsHandler)
+	if err := h.CommentsHandler.init(c); err != nil {
+		return err
+	}
+	h.DeltaHandler = new(DeltaHandler)
+	if err := h.DeltaHandler.init(c); err != nil {
+		return err
+	}
 	h.FilesHandler = new(WebDavHandler)
 	if err := h.FilesHandler.init(c.FilesNamespace, false); err != nil {
 		return err
@@ -129,6 +138,13 @@ func (h *DavHandler) Handler(s *svc) http.Handler {
 		switch head {
 		case "avatars":
 			h.AvatarsHandler.Handler(s).ServeHTTP(w, r)
+		case "comments":
+			base := path.Join(ctx.Value(ctxKeyBaseURI).(string), "comments")
+			ctx = context.WithValue(ctx, ctxKeyBaseURI, base)
+			r = r.WithContext(ctx)
+			h.CommentsHandler.Handler(s).ServeHTTP(w, r)
+		case "delta":
+			h.DeltaHandler.Handler(s).ServeHTTP(w, r)
 		case "files":
 			var requestUserID string
 			var oldPath = r.URL.Path
@@ -166,7 +182,7 @@ func (h *DavHandler) Handler(s *svc) http.Handler {
 		case "public-files":
 			base := path.Join(ctx.Value(ctxKeyBaseURI).(string), "public-files")
 			ctx = context.WithValue(ctx, ctxKeyBaseURI, base)
-			c, err := pool.GetGatewayServiceClient(s.c.GatewaySvc)
+			c, err := s.getClient()
 			if err != nil {
 				w.WriteHeader(http.StatusNotFound)
 			}
@@ -239,6 +255,11 @@ func (h *DavHandler) Handler(s *svc) http.Handler {
 				r = r.WithContext(ctx)
 				h.PublicFileHandler.Handler(s).ServeHTTP(w, r)
 			} else {
+				if code := checkPublicFolderRequest(r, base, token, sRes.Info.PermissionSet); code != 0 {
+					log.Debug().Str("token", token).Str("method", r.Method).Int("status", code).Msg("request refused on public folder")
+					w.WriteHeader(code)
+					return
+				}
 				h.PublicFolderHandler.Handler(s).ServeHTTP(w, r)
 			}
 
@@ -24,6 +24,7 @@ import (
 
 	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
 	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	lockpb "github.com/cs3org/reva/internal/grpc/services/storageprovider/proto"
 	"github.com/cs3org/reva/pkg/appctx"
 	"go.opencensus.io/trace"
 )
@@ -44,6 +45,10 @@ func (s *svc) handleDelete(w http.ResponseWriter, r *http.Request, ns string) {
 		return
 	}
 
+	if !s.checkLock(w, r, &lockpb.Reference{Path: fn}, &sublog) {
+		return
+	}
+
 	ref := &provider.Reference{
 		Spec: &provider.Reference_Path{Path: fn},
 	}
@@ -0,0 +1,312 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+package ocdav
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	collaboration "github.com/cs3org/go-cs3apis/cs3/sharing/collaboration/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/changelog"
+	changelogregistry "github.com/cs3org/reva/pkg/changelog/manager/registry"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/storage/utils/templates"
+	ctxuser "github.com/cs3org/reva/pkg/user"
+	"github.com/cs3org/reva/pkg/utils"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	defaultDeltaLimit = 500
+	maxDeltaLimit     = 5000
+)
+
+// DeltaHandler serves the changes made to the namespace of the users since a
+// cursor, so that the clients do not have to walk the whole tree to
+// synchronize.
+type DeltaHandler struct {
+	manager changelog.Manager
+	// stop stops the materializer, if any
+	stop chan struct{}
+}
+
+type deltaResponse struct {
+	*changelog.Delta
+	// Cursor is the cursor to pass to get the next changes.
+	Cursor string `json:"cursor"`
+	// HasMore tells that more changes are available after the cursor.
+	HasMore bool `json:"has_more"`
+}
+
+func (h *DeltaHandler) init(c *Config) error {
+	f, ok := changelogregistry.NewFuncs[c.ChangelogDriver]
+	if !ok {
+		return errtypes.NotFound("ocdav: change log driver not found: " + c.ChangelogDriver)
+	}
+	m, err := f(c.ChangelogDrivers[c.ChangelogDriver])
+	if err != nil {
+		return err
+	}
+	h.manager = m
+
+	if len(c.Changelog) > 0 {
+		mc, err := changelog.ParseMaterializerConfig(c.Changelog)
+		if err != nil {
+			return err
+		}
+		mt, err := changelog.NewMaterializer(mc, m, &log.Logger)
+		if err != nil {
+			return err
+		}
+		h.stop = make(chan struct{})
+		go mt.Run(h.stop)
+	}
+	return nil
+}
+
+// close stops the materializer, so that it does not outlive the service.
+func (h *DeltaHandler) close() {
+	if h.stop != nil {
+		close(h.stop)
+		h.stop = nil
+	}
+}
+
+// Handler handles requests
+// the changes are listed with a GET to /remote.php/dav/delta?cursor=<cursor>,
+// the cursor being the one returned by the previous call. Without cursor,
+// only the current cursor is returned, the clients having to walk the tree
+// once. A 410 tells that the changes since the cursor are not known anymore,
+// and that the clients have to walk the tree again. The paths are the ones of
+// the files endpoint, the changes of the resources shared with the user being
+// reported under the share folder.
+func (h *DeltaHandler) Handler(s *svc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		log := appctx.GetLogger(ctx)
+		if r.Method != http.MethodGet || (r.URL.Path != "/" && r.URL.Path != "") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		u, ok := ctxuser.ContextGetUser(ctx)
+		if !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		q := r.URL.Query()
+		limit := defaultDeltaLimit
+		if l := q.Get("limit"); l != "" {
+			var err error
+			if limit, err = strconv.Atoi(l); err != nil || limit <= 0 {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			if limit > maxDeltaLimit {
+				limit = maxDeltaLimit
+			}
+		}
+
+		v, err := h.view(ctx, s, u)
+		if err != nil {
+			log.Error().Err(err).Msg("error listing the received shares")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		changes, cursor, err := h.manager.Since(ctx, v.owners(), q.Get("cursor"), limit)
+		switch err.(type) {
+		case nil:
+		case errtypes.BadRequest:
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		default:
+			if err == changelog.ErrCursorExpired {
+				w.WriteHeader(http.StatusGone)
+				return
+			}
+			log.Error().Err(err).Msg("error listing changes")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		b, err := json.Marshal(&deltaResponse{
+			Delta:   changelog.Compact(v.apply(changes)),
+			Cursor:  cursor,
+			HasMore: len(changes) == limit,
+		})
+		if err != nil {
+			log.Error().Err(err).Msg("error encoding delta")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := w.Write(b); err != nil {
+			log.Error().Err(err).Msg("error writing delta")
+		}
+	})
+}
+
+// view returns the view of the user on the changes, made of its namespace
+// and of the resources shared with it and accepted.
+func (h *DeltaHandler) view(ctx context.Context, s *svc, u *userpb.User) (*deltaView, error) {
+	v := &deltaView{user: u.Id, namespace: templates.WithUser(u, s.c.FilesNamespace)}
+
+	client, err := s.getClient()
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.ListReceivedShares(ctx, &collaboration.ListReceivedSharesRequest{})
+	if err != nil {
+		return nil, err
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return nil, errtypes.InternalError(res.Status.Message)
+	}
+	for _, rs := range res.Shares {
+		if rs.State != collaboration.ShareState_SHARE_STATE_ACCEPTED {
+			continue
+		}
+		ref := &provider.Reference{Spec: &provider.Reference_Id{Id: rs.Share.ResourceId}}
+		st, err := client.Stat(ctx, &provider.StatRequest{Ref: ref})
+		if err != nil || st.Status.Code != rpc.Code_CODE_OK {
+			// the resource may have been deleted since
+			appctx.GetLogger(ctx).Debug().Err(err).Interface("share", rs.Share.Id).Msg("delta: error stating shared resource")
+			continue
+		}
+		v.shares = append(v.shares, &sharedRoot{
+			owner:     rs.Share.Owner,
+			path:      st.Info.Path,
+			mountPath: path.Join("/", s.c.ShareFolder, path.Base(st.Info.Path)),
+		})
+	}
+	return v, nil
+}
+
+// sharedRoot is a resource shared with the user, whose changes are read from
+// the log of its owner.
+type sharedRoot struct {
+	owner *userpb.UserId
+	// path is the path of the resource in the global namespace
+	path string
+	// mountPath is the path the resource is visible at to the user
+	mountPath string
+}
+
+// deltaView maps the paths of the changes in the global namespace to the ones
+// visible to the user, and leaves out the changes the user cannot see.
+type deltaView struct {
+	user      *userpb.UserId
+	namespace string
+	shares    []*sharedRoot
+}
+
+// owners returns the users whose logs hold the changes visible to the user.
+func (v *deltaView) owners() []*userpb.UserId {
+	owners := []*userpb.UserId{v.user}
+	for _, s := range v.shares {
+		seen := false
+		for _, o := range owners {
+			if utils.UserEqual(o, s.owner) {
+				seen = true
+				break
+			}
+		}
+		if !seen {
+			owners = append(owners, s.owner)
+		}
+	}
+	return owners
+}
+
+// visible returns the path visible to the user of the resource of the owner.
+func (v *deltaView) visible(owner *userpb.UserId, p string) (string, bool) {
+	if p == "" {
+		return "", false
+	}
+	if utils.UserEqual(owner, v.user) {
+		if rel, ok := under(p, v.namespace); ok {
+			return rel, true
+		}
+	}
+	for _, s := range v.shares {
+		if !utils.UserEqual(owner, s.owner) {
+			continue
+		}
+		if rel, ok := under(p, s.path); ok {
+			return path.Join(s.mountPath, rel), true
+		}
+	}
+	return "", false
+}
+
+// apply returns the changes visible to the user, with their visible paths. The
+// moves between a visible and an invisible location are reported as the
+// creation or the deletion of the resource.
+func (v *deltaView) apply(changes []*changelog.Change) []*changelog.Change {
+	visible := make([]*changelog.Change, 0, len(changes))
+	for _, c := range changes {
+		p, ok := v.visible(c.Owner, c.Path)
+		if c.Type != changelog.Moved {
+			if ok {
+				vc := *c
+				vc.Path = p
+				visible = append(visible, &vc)
+			}
+			continue
+		}
+		old, oldOk := v.visible(c.Owner, c.OldPath)
+		vc := *c
+		switch {
+		case ok && oldOk:
+			vc.Path, vc.OldPath = p, old
+		case ok:
+			vc.Type, vc.Path, vc.OldPath = changelog.Created, p, ""
+		case oldOk:
+			vc.Type, vc.Path, vc.OldPath = changelog.Deleted, old, ""
+		default:
+			continue
+		}
+		visible = append(visible, &vc)
+	}
+	return visible
+}
+
+// under returns the path of p relative to root, if p is root or below it.
+func under(p, root string) (string, bool) {
+	root = path.Clean("/" + root)
+	p = path.Clean("/" + p)
+	if p == root {
+		return "/", true
+	}
+	if root == "/" {
+		return p, true
+	}
+	if strings.HasPrefix(p, root+"/") {
+		return strings.TrimPrefix(p, root), true
+	}
+	return "", false
+}
@@ -0,0 +1,88 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+package ocdav
+
+import (
+	"reflect"
+	"testing"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/pkg/changelog"
+)
+
+func TestDeltaView(t *testing.T) {
+	einstein := &userpb.UserId{Idp: "idp", OpaqueId: "einstein"}
+	marie := &userpb.UserId{Idp: "idp", OpaqueId: "marie"}
+	v := &deltaView{
+		user:      einstein,
+		namespace: "/users/einstein",
+		shares: []*sharedRoot{
+			{owner: marie, path: "/users/marie/Projects", mountPath: "/MyShares/Projects"},
+		},
+	}
+
+	if owners := v.owners(); len(owners) != 2 || owners[1] != marie {
+		t.Fatalf("unexpected owners %v", owners)
+	}
+
+	changes := []*changelog.Change{
+		{Type: changelog.Modified, Owner: einstein, Path: "/users/einstein/notes.txt"},
+		{Type: changelog.Created, Owner: marie, Path: "/users/marie/Projects/reva"},
+		// not shared with einstein
+		{Type: changelog.Created, Owner: marie, Path: "/users/marie/Private/diary.txt"},
+		// the paths of the logs of the others are not those of einstein
+		{Type: changelog.Created, Owner: marie, Path: "/users/einstein/other.txt"},
+		{Type: changelog.Moved, Owner: marie, OldPath: "/users/marie/Projects/a", Path: "/users/marie/Projects/b"},
+		{Type: changelog.Moved, Owner: marie, OldPath: "/users/marie/Projects/c", Path: "/users/marie/Private/c"},
+		{Type: changelog.Moved, Owner: marie, OldPath: "/users/marie/Private/d", Path: "/users/marie/Projects/d"},
+		{Type: changelog.Deleted, Owner: einstein, Path: "/users/einstein"},
+	}
+	want := []*changelog.Change{
+		{Type: changelog.Modified, Owner: einstein, Path: "/notes.txt"},
+		{Type: changelog.Created, Owner: marie, Path: "/MyShares/Projects/reva"},
+		{Type: changelog.Moved, Owner: marie, OldPath: "/MyShares/Projects/a", Path: "/MyShares/Projects/b"},
+		{Type: changelog.Deleted, Owner: marie, Path: "/MyShares/Projects/c"},
+		{Type: changelog.Created, Owner: marie, Path: "/MyShares/Projects/d"},
+		{Type: changelog.Deleted, Owner: einstein, Path: "/"},
+	}
+	if got := v.apply(changes); !reflect.DeepEqual(got, want) {
+		for i := range got {
+			t.Logf("%+v", got[i])
+		}
+		t.Fatal("unexpected visible changes")
+	}
+}
+
+func TestUnder(t *testing.T) {
+	tests := []struct {
+		p, root, rel string
+		ok           bool
+	}{
+		{"/home/a/b", "/home", "/a/b", true},
+		{"/home", "/home", "/", true},
+		{"/home/", "/home", "/", true},
+		{"/homework", "/home", "", false},
+		{"/a", "/", "/a", true},
+		{"/other", "/home", "", false},
+	}
+	for _, tt := range tests {
+		if rel, ok := under(tt.p, tt.root); rel != tt.rel || ok != tt.ok {
+			t.Errorf("under(%q, %q): got %q, %v, wanted %q, %v", tt.p, tt.root, rel, ok, tt.rel, tt.ok)
+		}
+	}
+}
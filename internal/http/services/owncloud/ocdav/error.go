@@ -96,6 +96,12 @@ func HandleErrorStatus(log *zerolog.Logger, w http.ResponseWriter, s *rpc.Status
 	case rpc.Code_CODE_INSUFFICIENT_STORAGE:
 		log.Debug().Interface("status", s).Msg("insufficient storage")
 		w.WriteHeader(http.StatusInsufficientStorage)
+	case rpc.Code_CODE_UNAVAILABLE:
+		log.Debug().Interface("status", s).Msg("unavailable")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	case rpc.Code_CODE_FAILED_PRECONDITION:
+		log.Debug().Interface("status", s).Msg("precondition failed")
+		w.WriteHeader(http.StatusPreconditionFailed)
 	default:
 		log.Error().Interface("status", s).Msg("grpc request failed")
 		w.WriteHeader(http.StatusInternalServerError)
@@ -34,6 +34,7 @@ import (
 	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
 	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/postprocessing"
 	"github.com/cs3org/reva/pkg/rhttp"
 	"github.com/cs3org/reva/pkg/utils"
 )
@@ -58,6 +59,7 @@ func (s *svc) handleGet(w http.ResponseWriter, r *http.Request, ns string) {
 		Ref: &provider.Reference{
 			Spec: &provider.Reference_Path{Path: fn},
 		},
+		ArbitraryMetadataKeys: []string{postprocessing.StatusKey},
 	}
 	sRes, err := client.Stat(ctx, sReq)
 	if err != nil {
@@ -78,6 +80,16 @@ func (s *svc) handleGet(w http.ResponseWriter, r *http.Request, ns string) {
 		return
 	}
 
+	switch postprocessing.Status(info.GetArbitraryMetadata().GetMetadata()[postprocessing.StatusKey]) {
+	case postprocessing.StatusProcessing:
+		w.WriteHeader(http.StatusTooEarly)
+		return
+	case postprocessing.StatusRejected:
+		sublog.Debug().Msg("file was rejected by the post-processing")
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+
 	dReq := &provider.InitiateFileDownloadRequest{
 		Ref: &provider.Reference{
 			Spec: &provider.Reference_Path{Path: fn},
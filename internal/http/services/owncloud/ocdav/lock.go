@@ -19,33 +19,305 @@
 package ocdav
 
 import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
 	"net/http"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
+	lockpb "github.com/cs3org/reva/internal/grpc/services/storageprovider/proto"
 	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
+	"github.com/cs3org/reva/pkg/storage"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
 )
 
-// TODO(jfd) implement lock
+const (
+	// defaultLockTimeout is the duration of the locks whose clients do not
+	// ask for one, as in oc10.
+	defaultLockTimeout = 30 * time.Minute
+	// maxLockTimeout caps the duration of the locks, so that the locks of
+	// the clients which went away eventually expire.
+	maxLockTimeout = 24 * time.Hour
+)
+
+// http://www.webdav.org/specs/rfc4918.html#ELEMENT_lockinfo
+type lockInfo struct {
+	XMLName   xml.Name  `xml:"DAV: lockinfo"`
+	Exclusive *struct{} `xml:"DAV: lockscope>exclusive"`
+	Shared    *struct{} `xml:"DAV: lockscope>shared"`
+	Owner     struct {
+		InnerXML string `xml:",innerxml"`
+	} `xml:"DAV: owner"`
+}
+
+// handleLock locks the resource or, when the request has no body, refreshes
+// the lock whose token is submitted in the If header. The locks are kept by
+// the storage providers, so that they are shared with the applications.
 func (s *svc) handleLock(w http.ResponseWriter, r *http.Request, ns string) {
-	log := appctx.GetLogger(r.Context())
-	xml := `<?xml version="1.0" encoding="utf-8"?>
-	<prop xmlns="DAV:">
-		<lockdiscovery>
-			<activelock>
-				<allprop/>
-				<timeout>Second-604800</timeout>
-				<depth>Infinity</depth>
-				<locktoken>
-				<href>opaquelocktoken:00000000-0000-0000-0000-000000000000</href>
-				</locktoken>
-			</activelock>
-		</lockdiscovery>
-	</prop>`
-
-	w.Header().Set("Content-Type", "text/xml; charset=\"utf-8\"")
-	w.Header().Set("Lock-Token",
-		"opaquelocktoken:00000000-0000-0000-0000-000000000000")
-	_, err := w.Write([]byte(xml))
+	ctx := r.Context()
+	fn := path.Join(ns, r.URL.Path)
+
+	sublog := appctx.GetLogger(ctx).With().Str("path", fn).Logger()
+
+	client, err := pool.GetLockClient(s.c.GatewaySvc)
+	if err != nil {
+		sublog.Error().Err(err).Msg("error getting grpc client")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	timeout, err := parseLockTimeout(r.Header.Get("Timeout"))
+	if err != nil {
+		sublog.Debug().Err(err).Msg("invalid timeout")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var li lockInfo
+	refresh := false
+	if err := xml.NewDecoder(r.Body).Decode(&li); err == io.EOF {
+		refresh = true
+	} else if err != nil {
+		sublog.Debug().Err(err).Msg("error reading lockinfo")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	ref := &lockpb.Reference{Path: fn}
+	var res *lockpb.LockResponse
+	if refresh {
+		res, err = refreshLock(ctx, client, ref, r.Header.Get("If"), timeout)
+	} else {
+		l := &lockpb.Lock{
+			LockId:     "opaquelocktoken:" + uuid.New().String(),
+			Type:       storage.LockTypeExclusive,
+			Expiration: uint64(time.Now().Add(timeout).Unix()),
+		}
+		if li.Shared != nil {
+			l.Type = storage.LockTypeShared
+		}
+		res, err = client.SetLock(ctx, &lockpb.LockRequest{Ref: ref, Lock: l})
+	}
+	if refresh && s.c.StrictRFC && grpcstatus.Code(err) == codes.FailedPrecondition {
+		// the refresh does not submit the token of the lock
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return
+	}
+	if err != nil {
+		handleLockError(&sublog, w, err)
+		return
+	}
+
+	if !refresh {
+		w.Header().Set("Lock-Token", "<"+res.Lock.GetLockId()+">")
+	}
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write([]byte(formatLockDiscovery(res.Lock, li.Owner.InnerXML, fn))); err != nil {
+		sublog.Err(err).Msg("error writing response")
+	}
+}
+
+func refreshLock(ctx context.Context, client lockpb.LockAPIClient, ref *lockpb.Reference, ifHeader string, timeout time.Duration) (*lockpb.LockResponse, error) {
+	res, err := client.GetLock(ctx, &lockpb.LockRequest{Ref: ref})
+	if err != nil {
+		return nil, err
+	}
+	if res.Lock == nil || !submitsLockToken(ifHeader, res.Lock.LockId) {
+		return nil, grpcstatus.Error(codes.FailedPrecondition, "the lock token is not submitted")
+	}
+	l := res.Lock
+	l.Expiration = uint64(time.Now().Add(timeout).Unix())
+	return client.RefreshLock(ctx, &lockpb.LockRequest{Ref: ref, Lock: l})
+}
+
+// handleUnlock removes the lock whose token is given in the Lock-Token header.
+func (s *svc) handleUnlock(w http.ResponseWriter, r *http.Request, ns string) {
+	ctx := r.Context()
+	fn := path.Join(ns, r.URL.Path)
+
+	sublog := appctx.GetLogger(ctx).With().Str("path", fn).Logger()
+
+	token := strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(r.Header.Get("Lock-Token")), "<"), ">")
+	if token == "" {
+		sublog.Debug().Msg("missing lock token")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	client, err := pool.GetLockClient(s.c.GatewaySvc)
 	if err != nil {
-		log.Err(err).Msg("error writing response")
+		sublog.Error().Err(err).Msg("error getting grpc client")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	_, err = client.Unlock(ctx, &lockpb.LockRequest{
+		Ref:  &lockpb.Reference{Path: fn},
+		Lock: &lockpb.Lock{LockId: token},
+	})
+	if grpcstatus.Code(err) == codes.FailedPrecondition {
+		// the token does not match the lock of the resource
+		w.WriteHeader(http.StatusConflict)
+		return
+	}
+	if err != nil {
+		handleLockError(&sublog, w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// checkLock tells whether the request may modify the resource, which it may
+// unless the resource is locked and the request does not submit the lock
+// token in its If header. In strict mode the request may not either when the
+// state tokens of its If header do not match the lock, if any. It writes the
+// response otherwise.
+func (s *svc) checkLock(w http.ResponseWriter, r *http.Request, ref *lockpb.Reference, log *zerolog.Logger) bool {
+	client, err := pool.GetLockClient(s.c.GatewaySvc)
+	if err != nil {
+		log.Error().Err(err).Msg("error getting grpc client")
+		w.WriteHeader(http.StatusInternalServerError)
+		return false
+	}
+	res, err := client.GetLock(r.Context(), &lockpb.LockRequest{Ref: ref})
+	switch grpcstatus.Code(err) {
+	case codes.OK:
+	case codes.NotFound, codes.Unimplemented:
+		// new resources and storages without locks cannot be locked
+		res = &lockpb.LockResponse{}
+	default:
+		log.Error().Err(err).Msg("error getting lock")
+		w.WriteHeader(http.StatusInternalServerError)
+		return false
+	}
+	ifHeader := r.Header.Get("If")
+	if s.c.StrictRFC && ifHeader != "" && !evalIfHeader(ifHeader, res.Lock.GetLockId()) {
+		log.Debug().Str("if", ifHeader).Msg("the state tokens do not match the lock")
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return false
+	}
+	if res.Lock == nil || submitsLockToken(ifHeader, res.Lock.LockId) {
+		return true
+	}
+	log.Debug().Str("lock", res.Lock.AppName).Msg("resource is locked")
+	w.WriteHeader(http.StatusLocked)
+	return false
+}
+
+// submitsLockToken tells whether the If header lists the lock token. The
+// conditions of the header are not evaluated.
+func submitsLockToken(ifHeader, token string) bool {
+	return token != "" && strings.Contains(ifHeader, "<"+token+">")
+}
+
+var (
+	ifListRegex      = regexp.MustCompile(`\(([^)]*)\)`)
+	ifConditionRegex = regexp.MustCompile(`(Not\s+)?(<[^>]*>|\[[^\]]*\])`)
+)
+
+// evalIfHeader evaluates the state tokens of the If header against the lock
+// token of the resource, see https://tools.ietf.org/html/rfc4918#section-10.4.
+// The header holds when one of its lists holds, a list holding when all its
+// conditions do. The entity tags are not evaluated and the resource tags
+// are ignored, the conditions applying to the resource of the request.
+func evalIfHeader(ifHeader, token string) bool {
+	for _, list := range ifListRegex.FindAllStringSubmatch(ifHeader, -1) {
+		holds := true
+		for _, c := range ifConditionRegex.FindAllStringSubmatch(list[1], -1) {
+			if strings.HasPrefix(c[2], "[") {
+				continue
+			}
+			matches := token != "" && c[2] == "<"+token+">"
+			if matches == (c[1] != "") {
+				holds = false
+				break
+			}
+		}
+		if holds {
+			return true
+		}
+	}
+	return false
+}
+
+var lockTimeoutRegex = regexp.MustCompile(`^Second-(\d+)$`)
+
+// parseLockTimeout returns the duration of the lock asked for by the
+// Timeout header, which lists the timeouts by order of preference.
+func parseLockTimeout(header string) (time.Duration, error) {
+	if header == "" {
+		return defaultLockTimeout, nil
+	}
+	for _, t := range strings.Split(header, ",") {
+		t = strings.TrimSpace(t)
+		if t == "Infinite" {
+			return maxLockTimeout, nil
+		}
+		if m := lockTimeoutRegex.FindStringSubmatch(t); m != nil {
+			seconds, err := strconv.ParseInt(m[1], 10, 64)
+			if err != nil {
+				return 0, err
+			}
+			timeout := time.Duration(seconds) * time.Second
+			if timeout > maxLockTimeout || timeout <= 0 {
+				timeout = maxLockTimeout
+			}
+			return timeout, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid timeout %q", header)
+}
+
+func formatLockDiscovery(l *lockpb.Lock, owner, fn string) string {
+	scope := "<d:exclusive/>"
+	if l.GetType() == storage.LockTypeShared {
+		scope = "<d:shared/>"
+	}
+	timeout := "Infinite"
+	if l.GetExpiration() > 0 {
+		remaining := time.Until(time.Unix(int64(l.Expiration), 0)).Round(time.Second)
+		timeout = fmt.Sprintf("Second-%d", int64(remaining/time.Second))
+	}
+
+	var b strings.Builder
+	b.WriteString(`<?xml version="1.0" encoding="utf-8"?><d:prop xmlns:d="DAV:"><d:lockdiscovery><d:activelock>`)
+	b.WriteString(`<d:locktype><d:write/></d:locktype><d:lockscope>` + scope + `</d:lockscope>`)
+	b.WriteString(`<d:depth>0</d:depth>`)
+	if owner != "" {
+		b.WriteString(`<d:owner>` + owner + `</d:owner>`)
+	}
+	b.WriteString(`<d:timeout>` + timeout + `</d:timeout>`)
+	b.WriteString(`<d:locktoken><d:href>`)
+	_ = xml.EscapeText(&b, []byte(l.GetLockId()))
+	b.WriteString(`</d:href></d:locktoken><d:lockroot><d:href>`)
+	_ = xml.EscapeText(&b, []byte(encodePath(fn)))
+	b.WriteString(`</d:href></d:lockroot></d:activelock></d:lockdiscovery></d:prop>`)
+	return b.String()
+}
+
+func handleLockError(log *zerolog.Logger, w http.ResponseWriter, err error) {
+	switch grpcstatus.Code(err) {
+	case codes.FailedPrecondition:
+		w.WriteHeader(http.StatusLocked)
+	case codes.NotFound:
+		w.WriteHeader(http.StatusNotFound)
+	case codes.PermissionDenied:
+		w.WriteHeader(http.StatusForbidden)
+	case codes.InvalidArgument:
+		w.WriteHeader(http.StatusBadRequest)
+	case codes.Unimplemented:
+		w.WriteHeader(http.StatusNotImplemented)
+	default:
+		log.Error().Err(err).Msg("error handling lock")
+		w.WriteHeader(http.StatusInternalServerError)
 	}
 }
@@ -0,0 +1,45 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package ocdav
+
+import "testing"
+
+func TestEvalIfHeader(t *testing.T) {
+	const token = "opaquelocktoken:a515cfa4-5da4-22e1-f5b5-00a0451e6bf7"
+	tests := []struct {
+		header string
+		token  string
+		holds  bool
+	}{
+		{"(<" + token + ">)", token, true},
+		{"(<" + token + ">)", "", false},
+		{"(<opaquelocktoken:other>)", token, false},
+		{"(<DAV:no-lock>)", "", false},
+		{"(Not <DAV:no-lock>)", "", true},
+		{"(<DAV:no-lock>) (<" + token + ">)", token, true},
+		{`(<` + token + `> ["etag"])`, token, true},
+		{`<http://example.org/file> (<` + token + `>)`, token, true},
+		{"(Not <" + token + ">)", token, false},
+	}
+	for _, tt := range tests {
+		if holds := evalIfHeader(tt.header, tt.token); holds != tt.holds {
+			t.Errorf("evalIfHeader(%q, %q) = %v, expected %v", tt.header, tt.token, holds, tt.holds)
+		}
+	}
+}
@@ -18,6 +18,8 @@
 
 package ocdav
 
+// go:generate mockery --srcpkg github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1 --name GatewayAPIClient --output gatewayfake --outpkg gatewayfake
+
 import (
 	"context"
 	"encoding/base64"
@@ -34,6 +36,12 @@ import (
 	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
 	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/favorite"
+	favoriteregistry "github.com/cs3org/reva/pkg/favorite/manager/registry"
+	"github.com/cs3org/reva/pkg/idempotency"
+	"github.com/cs3org/reva/pkg/maintenance"
+	maintenanceregistry "github.com/cs3org/reva/pkg/maintenance/manager/registry"
 	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
 	"github.com/cs3org/reva/pkg/rhttp"
 	"github.com/cs3org/reva/pkg/rhttp/global"
@@ -73,11 +81,61 @@ type Config struct {
 	Timeout         int64  `mapstructure:"timeout"`
 	Insecure        bool   `mapstructure:"insecure"`
 	PublicURL       string `mapstructure:"public_url"`
+	// FavoriteStorageDriver is the store of the favorites of the users.
+	FavoriteStorageDriver  string                            `mapstructure:"favorite_storage_driver"`
+	FavoriteStorageDrivers map[string]map[string]interface{} `mapstructure:"favorite_storage_drivers"`
+	// CommentsDriver is the store of the comments on the files.
+	CommentsDriver  string                            `mapstructure:"comments_driver"`
+	CommentsDrivers map[string]map[string]interface{} `mapstructure:"comments_drivers"`
+	// Events configures the bus the comment events are published to.
+	Events map[string]interface{} `mapstructure:"events"`
+	// ProductName is the name of the product reported by status.php, which
+	// the clients show to the users.
+	ProductName string `mapstructure:"product_name"`
+	// MaintenanceDriver is the store of the maintenance modes, the global
+	// one being reported by status.php.
+	MaintenanceDriver  string                            `mapstructure:"maintenance_driver"`
+	MaintenanceDrivers map[string]map[string]interface{} `mapstructure:"maintenance_drivers"`
+	// StrictRFC follows RFC 4918 where the ownCloud clients expect the
+	// behavior of oc10, as needed to pass the litmus test suite: the
+	// insufficient storage of a COPY is reported with a 507, the If headers
+	// of the write requests are evaluated against the locks and the unknown
+	// properties are reported in their own namespace.
+	StrictRFC bool `mapstructure:"strict_rfc"`
+	// ChangelogDriver is the store of the change logs of the users, served
+	// by the delta endpoint.
+	ChangelogDriver  string                            `mapstructure:"changelog_driver"`
+	ChangelogDrivers map[string]map[string]interface{} `mapstructure:"changelog_drivers"`
+	// Changelog configures the materialization of the change logs from the
+	// events published by the storage providers. The change logs stay empty
+	// without it.
+	Changelog map[string]interface{} `mapstructure:"changelog"`
+	// ShareFolder is the folder of the home of the users holding the
+	// resources shared with them, as configured in the gateway.
+	ShareFolder string `mapstructure:"share_folder"`
 }
 
 func (c *Config) init() {
 	// note: default c.Prefix is an empty string
 	c.GatewaySvc = sharedconf.GetGatewaySVC(c.GatewaySvc)
+	if c.FavoriteStorageDriver == "" {
+		c.FavoriteStorageDriver = "memory"
+	}
+	if c.ProductName == "" {
+		c.ProductName = "ownCloud"
+	}
+	if c.CommentsDriver == "" {
+		c.CommentsDriver = "memory"
+	}
+	if c.MaintenanceDriver == "" {
+		c.MaintenanceDriver = "memory"
+	}
+	if c.ChangelogDriver == "" {
+		c.ChangelogDriver = "memory"
+	}
+	if c.ShareFolder == "" {
+		c.ShareFolder = "MyShares"
+	}
 }
 
 type svc struct {
@@ -85,6 +143,11 @@ type svc struct {
 	webDavHandler *WebDavHandler
 	davHandler    *DavHandler
 	client        *http.Client
+	favorites     favorite.Manager
+	maintenance   maintenance.Manager
+	// gatewayClient replaces the client of the gateway service, e.g. with
+	// a fake in the tests.
+	gatewayClient gateway.GatewayAPIClient
 }
 
 // New returns a new ocdav
@@ -96,6 +159,24 @@ func New(m map[string]interface{}, log *zerolog.Logger) (global.Service, error)
 
 	conf.init()
 
+	f, ok := favoriteregistry.NewFuncs[conf.FavoriteStorageDriver]
+	if !ok {
+		return nil, errtypes.NotFound("ocdav: favorite storage driver not found: " + conf.FavoriteStorageDriver)
+	}
+	favorites, err := f(conf.FavoriteStorageDrivers[conf.FavoriteStorageDriver])
+	if err != nil {
+		return nil, err
+	}
+
+	mf, ok := maintenanceregistry.NewFuncs[conf.MaintenanceDriver]
+	if !ok {
+		return nil, errtypes.NotFound("ocdav: maintenance driver not found: " + conf.MaintenanceDriver)
+	}
+	mm, err := mf(conf.MaintenanceDrivers[conf.MaintenanceDriver])
+	if err != nil {
+		return nil, err
+	}
+
 	s := &svc{
 		c:             conf,
 		webDavHandler: new(WebDavHandler),
@@ -104,6 +185,8 @@ func New(m map[string]interface{}, log *zerolog.Logger) (global.Service, error)
 			rhttp.Timeout(time.Duration(conf.Timeout*int64(time.Second))),
 			rhttp.Insecure(conf.Insecure),
 		),
+		favorites:   favorites,
+		maintenance: mm,
 	}
 	// initialize handlers and set default configs
 	if err := s.webDavHandler.init(conf.WebdavNamespace, true); err != nil {
@@ -120,6 +203,9 @@ func (s *svc) Prefix() string {
 }
 
 func (s *svc) Close() error {
+	if s.davHandler.DeltaHandler != nil {
+		s.davHandler.DeltaHandler.close()
+	}
 	return nil
 }
 
@@ -133,10 +219,18 @@ func (s *svc) Handler() http.Handler {
 		log := appctx.GetLogger(ctx)
 
 		addAccessHeaders(w, r)
+		// the gateway refuses a key reused for another request, so it is only
+		// forwarded for the methods making a single mutating call, e.g. not
+		// for the recursive copies.
+		if r.Method == "MKCOL" || r.Method == "MOVE" {
+			r = idempotency.ForwardKey(r)
+			ctx = r.Context()
+		}
 
 		// TODO(jfd): do we need this?
 		// fake litmus testing for empty namespace: see https://github.com/golang/net/blob/e514e69ffb8bc3c76a71ae40de0118d794855992/webdav/litmus_test_server.go#L58-L89
-		if r.Header.Get("X-Litmus") == "props: 3 (propfind_invalid2)" {
+		// the strict mode rejects the empty namespaces of all the requests
+		if !s.c.StrictRFC && r.Header.Get("X-Litmus") == "props: 3 (propfind_invalid2)" {
 			http.Error(w, "400 Bad Request", http.StatusBadRequest)
 			return
 		}
@@ -187,6 +281,9 @@ func (s *svc) Handler() http.Handler {
 }
 
 func (s *svc) getClient() (gateway.GatewayAPIClient, error) {
+	if s.gatewayClient != nil {
+		return s.gatewayClient, nil
+	}
 	return pool.GetGatewayServiceClient(s.c.GatewaySvc)
 }
 
@@ -34,13 +34,17 @@ import (
 
 	"go.opencensus.io/trace"
 
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
 	userv1beta1 "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
 	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
 	link "github.com/cs3org/go-cs3apis/cs3/sharing/link/v1beta1"
 	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
 	"github.com/cs3org/reva/internal/grpc/services/storageprovider"
 	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/conversions"
 	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/favorite"
+	"github.com/cs3org/reva/pkg/postprocessing"
 	ctxuser "github.com/cs3org/reva/pkg/user"
 	"github.com/cs3org/reva/pkg/utils"
 )
@@ -87,6 +91,11 @@ func (s *svc) handlePropfind(w http.ResponseWriter, r *http.Request, ns string)
 		w.WriteHeader(status)
 		return
 	}
+	if s.c.StrictRFC && hasEmptyNamespace(&pf) {
+		sublog.Debug().Msg("propfind request with a property in the empty namespace")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
 
 	client, err := s.getClient()
 	if err != nil {
@@ -113,6 +122,7 @@ func (s *svc) handlePropfind(w http.ResponseWriter, r *http.Request, ns string)
 	ref := &provider.Reference{
 		Spec: &provider.Reference_Path{Path: fn},
 	}
+	quotaRef := ref
 	req := &provider.StatRequest{
 		Ref:                   ref,
 		ArbitraryMetadataKeys: metadataKeys,
@@ -194,6 +204,13 @@ func (s *svc) handlePropfind(w http.ResponseWriter, r *http.Request, ns string)
 		}
 	}
 
+	if requestsQuota(&pf) {
+		s.setQuotaAvailable(ctx, client, quotaRef, infos)
+	}
+	if requestsFavorite(&pf) {
+		s.setFavorites(ctx, infos)
+	}
+
 	propRes, err := s.formatPropfind(ctx, &pf, infos, ns)
 	if err != nil {
 		sublog.Error().Err(err).Msg("error formatting propfind")
@@ -222,6 +239,92 @@ func (s *svc) handlePropfind(w http.ResponseWriter, r *http.Request, ns string)
 	}
 }
 
+func requestsQuota(pf *propfindXML) bool {
+	for i := range pf.Prop {
+		if pf.Prop[i].Space == _nsDav && pf.Prop[i].Local == "quota-available-bytes" {
+			return true
+		}
+	}
+	return false
+}
+
+// setQuotaAvailable stores the remaining quota of the storage space holding the
+// resource in the opaque of the containers, unless the storage already did.
+func (s *svc) setQuotaAvailable(ctx context.Context, client gateway.GatewayAPIClient, ref *provider.Reference, infos []*provider.ResourceInfo) {
+	res, err := client.GetQuota(ctx, &gateway.GetQuotaRequest{Ref: ref})
+	if err != nil || res.Status.Code != rpc.Code_CODE_OK || res.TotalBytes == 0 {
+		appctx.GetLogger(ctx).Debug().Err(err).Msg("error getting quota")
+		return
+	}
+	var available uint64
+	if res.TotalBytes > res.UsedBytes {
+		available = res.TotalBytes - res.UsedBytes
+	}
+
+	for _, info := range infos {
+		if info.Type != provider.ResourceType_RESOURCE_TYPE_CONTAINER {
+			continue
+		}
+		if info.Opaque == nil {
+			info.Opaque = &types.Opaque{}
+		}
+		if info.Opaque.Map == nil {
+			info.Opaque.Map = map[string]*types.OpaqueEntry{}
+		}
+		if _, ok := info.Opaque.Map["quota"]; ok {
+			continue
+		}
+		info.Opaque.Map["quota"] = &types.OpaqueEntry{
+			Decoder: "plain",
+			Value:   []byte(strconv.FormatUint(available, 10)),
+		}
+	}
+}
+
+func requestsFavorite(pf *propfindXML) bool {
+	if pf.Allprop != nil {
+		return true
+	}
+	for i := range pf.Prop {
+		if pf.Prop[i].Space == _nsOwncloud && pf.Prop[i].Local == "favorite" {
+			return true
+		}
+	}
+	return false
+}
+
+// setFavorites sets the favorite flags of the resources from the favorites
+// of the logged in user, in place of the ones stored with the resources.
+func (s *svc) setFavorites(ctx context.Context, infos []*provider.ResourceInfo) {
+	u, ok := ctxuser.ContextGetUser(ctx)
+	if !ok {
+		return
+	}
+	favs, err := s.favorites.ListFavorites(ctx, u.Id)
+	if err != nil {
+		appctx.GetLogger(ctx).Error().Err(err).Msg("error listing favorites")
+		return
+	}
+	set := make(map[string]bool, len(favs))
+	for _, rid := range favs {
+		set[favorite.ResourceKey(rid)] = true
+	}
+
+	for _, info := range infos {
+		if info.ArbitraryMetadata == nil {
+			info.ArbitraryMetadata = &provider.ArbitraryMetadata{}
+		}
+		if info.ArbitraryMetadata.Metadata == nil {
+			info.ArbitraryMetadata.Metadata = map[string]string{}
+		}
+		if info.Id != nil && set[favorite.ResourceKey(info.Id)] {
+			info.ArbitraryMetadata.Metadata[_propOcFavorite] = "1"
+		} else {
+			delete(info.ArbitraryMetadata.Metadata, _propOcFavorite)
+		}
+	}
+}
+
 func requiresExplicitFetching(n *xml.Name) bool {
 	switch n.Space {
 	case _nsDav:
@@ -235,7 +338,7 @@ func requiresExplicitFetching(n *xml.Name) bool {
 		}
 	case _nsOwncloud:
 		switch n.Local {
-		case "favorite", "share-types", "checksums", "size":
+		case "favorite", "share-types", "checksums", "size", "processing":
 			return true
 		default:
 			return false
@@ -246,6 +349,17 @@ func requiresExplicitFetching(n *xml.Name) bool {
 	return true
 }
 
+// hasEmptyNamespace tells whether the propfind request asks for properties
+// without namespace, which RFC 4918 does not allow.
+func hasEmptyNamespace(pf *propfindXML) bool {
+	for _, n := range pf.Prop {
+		if n.Space == "" {
+			return true
+		}
+	}
+	return false
+}
+
 // from https://github.com/golang/net/blob/e514e69ffb8bc3c76a71ae40de0118d794855992/webdav/xml.go#L178-L205
 func readPropfind(r io.Reader) (pf propfindXML, status int, err error) {
 	c := countingReader{r: r}
@@ -311,6 +425,17 @@ func (s *svc) newPropNS(namespace string, local string, val string) *propertyXML
 	}
 }
 
+// newUnknownProp returns the property reported as not found. The ownCloud
+// clients expect the prefix the property is given by default, which is not
+// the one of its namespace for the ocs properties, the strict mode declaring
+// the namespace of the property.
+func (s *svc) newUnknownProp(n xml.Name, prefix string) *propertyXML {
+	if s.c.StrictRFC {
+		return s.newPropNS(n.Space, n.Local, "")
+	}
+	return s.newProp(prefix+n.Local, "")
+}
+
 // TODO properly use the space
 func (s *svc) newProp(key, val string) *propertyXML {
 	return &propertyXML{
@@ -650,6 +775,13 @@ func (s *svc) mdToPropResponse(ctx context.Context, pf *propfindXML, md *provide
 					} else {
 						propstatNotFound.Prop = append(propstatNotFound.Prop, s.newProp("oc:"+pf.Prop[i].Local, ""))
 					}
+				case "processing":
+					// the status of the post-processing of the uploaded file
+					if st, ok := md.GetArbitraryMetadata().GetMetadata()[postprocessing.StatusKey]; ok {
+						propstatOK.Prop = append(propstatOK.Prop, s.newProp("oc:processing", st))
+					} else {
+						propstatNotFound.Prop = append(propstatNotFound.Prop, s.newProp("oc:processing", ""))
+					}
 				case "owner-display-name": // phoenix only
 					if md.Owner != nil {
 						if isCurrentUserOwner(ctx, md.Owner) {
@@ -696,7 +828,7 @@ func (s *svc) mdToPropResponse(ctx context.Context, pf *propfindXML, md *provide
 					// TODO(jfd): double check the client behavior with reva on backup restore
 					fallthrough
 				default:
-					propstatNotFound.Prop = append(propstatNotFound.Prop, s.newProp("oc:"+pf.Prop[i].Local, ""))
+					propstatNotFound.Prop = append(propstatNotFound.Prop, s.newUnknownProp(pf.Prop[i], "oc:"))
 				}
 			case _nsDav:
 				switch pf.Prop[i].Local {
@@ -757,7 +889,7 @@ func (s *svc) mdToPropResponse(ctx context.Context, pf *propfindXML, md *provide
 						propstatNotFound.Prop = append(propstatNotFound.Prop, s.newProp("d:quota-available-bytes", ""))
 					}
 				default:
-					propstatNotFound.Prop = append(propstatNotFound.Prop, s.newProp("d:"+pf.Prop[i].Local, ""))
+					propstatNotFound.Prop = append(propstatNotFound.Prop, s.newUnknownProp(pf.Prop[i], "d:"))
 				}
 			case _nsOCS:
 				switch pf.Prop[i].Local {
@@ -779,7 +911,7 @@ func (s *svc) mdToPropResponse(ctx context.Context, pf *propfindXML, md *provide
 						propstatOK.Prop = append(propstatOK.Prop, s.newPropNS(pf.Prop[i].Space, pf.Prop[i].Local, strconv.FormatUint(uint64(perms), 10)))
 					}
 				default:
-					propstatNotFound.Prop = append(propstatNotFound.Prop, s.newProp("d:"+pf.Prop[i].Local, ""))
+					propstatNotFound.Prop = append(propstatNotFound.Prop, s.newUnknownProp(pf.Prop[i], "d:"))
 				}
 			default:
 				// handle custom properties
@@ -832,6 +964,8 @@ func metadataKeyOf(n *xml.Name) string {
 	switch {
 	case n.Space == _nsDav && n.Local == "quota-available-bytes":
 		return "quota"
+	case n.Space == _nsOwncloud && n.Local == "processing":
+		return postprocessing.StatusKey
 	default:
 		return fmt.Sprintf("%s/%s", n.Space, n.Local)
 	}
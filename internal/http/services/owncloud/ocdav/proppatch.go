@@ -32,6 +32,8 @@ import (
 	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
 	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/errtypes"
+	ctxuser "github.com/cs3org/reva/pkg/user"
 	"github.com/pkg/errors"
 )
 
@@ -111,6 +113,21 @@ func (s *svc) handleProppatch(w http.ResponseWriter, r *http.Request, ns string)
 					remove = true
 				}
 			}
+			// the favorites are specific to the user and kept in the favorites
+			// store rather than with the resource
+			if key == _propOcFavorite {
+				if err := s.updateFavorite(ctx, statRes.Info.Id, !remove); err != nil {
+					sublog.Error().Err(err).Msg("error updating favorite")
+					w.WriteHeader(http.StatusInternalServerError)
+					return
+				}
+				if remove {
+					removedProps = append(removedProps, propNameXML)
+				} else {
+					acceptedProps = append(acceptedProps, propNameXML)
+				}
+				continue
+			}
 			// Webdav spec requires the operations to be executed in the order
 			// specified in the PROPPATCH request
 			// http://www.webdav.org/specs/rfc2518.html#rfc.section.8.2
@@ -213,6 +230,17 @@ func (s *svc) formatProppatchResponse(ctx context.Context, acceptedProps []xml.N
 	return msg, nil
 }
 
+func (s *svc) updateFavorite(ctx context.Context, rid *provider.ResourceId, set bool) error {
+	u, ok := ctxuser.ContextGetUser(ctx)
+	if !ok {
+		return errtypes.UserRequired("ocdav: favorites require a user")
+	}
+	if set {
+		return s.favorites.SetFavorite(ctx, u.Id, rid)
+	}
+	return s.favorites.UnsetFavorite(ctx, u.Id, rid)
+}
+
 func (s *svc) isBooleanProperty(prop string) bool {
 	// TODO add other properties we know to be boolean?
 	return prop == _propOcFavorite
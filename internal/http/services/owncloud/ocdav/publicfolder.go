@@ -0,0 +1,88 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package ocdav
+
+import (
+	"net/http"
+
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/rhttp/router"
+)
+
+// checkPublicFolderRequest checks that a request on a folder shared by link
+// stays in the shared subtree and is allowed by the role of the link, given
+// by the permissions of the shared folder. It returns the status of the
+// refused requests, or 0.
+func checkPublicFolderRequest(r *http.Request, baseURI, token string, perms *provider.ResourcePermissions) int {
+	_, rel := router.ShiftPath(r.URL.Path)
+	atRoot := rel == "/"
+
+	switch r.Method {
+	case "MOVE", "COPY":
+		dst, err := extractDestination(r.Header.Get("Destination"), baseURI)
+		if err != nil {
+			return http.StatusBadRequest
+		}
+		// the destination must be in the same link, and cannot replace the
+		// shared folder.
+		dstToken, dstRel := router.ShiftPath(dst)
+		if dstToken != token || dstRel == "/" {
+			return http.StatusForbidden
+		}
+	}
+	if atRoot && (r.Method == http.MethodDelete || r.Method == "MOVE") {
+		return http.StatusForbidden
+	}
+
+	if !publicFolderMethodAllowed(r.Method, perms) {
+		return http.StatusForbidden
+	}
+	return 0
+}
+
+// publicFolderMethodAllowed returns whether the role of a link allows the
+// method. The methods not listed are refused.
+func publicFolderMethodAllowed(method string, perms *provider.ResourcePermissions) bool {
+	if method == http.MethodOptions {
+		return true
+	}
+	if perms == nil {
+		return false
+	}
+	switch method {
+	// the read methods of the viewers
+	case http.MethodGet:
+		return perms.InitiateFileDownload
+	case http.MethodHead, "PROPFIND", "REPORT":
+		return perms.Stat
+
+	// the write methods of the editors and uploaders
+	case http.MethodPut, http.MethodPost, "PROPPATCH", "LOCK", "UNLOCK":
+		return perms.InitiateFileUpload
+	case "MKCOL":
+		return perms.CreateContainer
+	case http.MethodDelete:
+		return perms.Delete
+	case "MOVE":
+		return perms.Move
+	case "COPY":
+		return perms.InitiateFileDownload && perms.InitiateFileUpload
+	}
+	return false
+}
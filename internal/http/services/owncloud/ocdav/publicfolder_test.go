@@ -0,0 +1,68 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package ocdav
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+)
+
+func TestCheckPublicFolderRequest(t *testing.T) {
+	const base = "/remote.php/dav/public-files"
+	viewer := &provider.ResourcePermissions{Stat: true, ListContainer: true, InitiateFileDownload: true}
+	editor := &provider.ResourcePermissions{Stat: true, ListContainer: true, InitiateFileDownload: true,
+		InitiateFileUpload: true, CreateContainer: true, Delete: true, Move: true}
+	tests := []struct {
+		method, path, dst string
+		perms             *provider.ResourcePermissions
+		code              int
+	}{
+		{"PROPFIND", "/tkn/a/b", "", viewer, 0},
+		{http.MethodGet, "/tkn/a/file.txt", "", viewer, 0},
+		{http.MethodPut, "/tkn/a/file.txt", "", viewer, http.StatusForbidden},
+		{http.MethodPut, "/tkn/a/file.txt", "", editor, 0},
+		{"MKCOL", "/tkn/a/c", "", editor, 0},
+		{http.MethodDelete, "/tkn/a", "", editor, 0},
+		{http.MethodDelete, "/tkn/", "", editor, http.StatusForbidden},
+		{"MOVE", "/tkn/a", base + "/tkn/b", editor, 0},
+		{"MOVE", "/tkn/a", base + "/tkn/b", viewer, http.StatusForbidden},
+		{"MOVE", "/tkn/a", base + "/other/b", editor, http.StatusForbidden},
+		{"MOVE", "/tkn/a", base + "/tkn/../../b", editor, http.StatusForbidden},
+		{"MOVE", "/tkn", base + "/tkn/b", editor, http.StatusForbidden},
+		{"COPY", "/tkn/a", base + "/tkn", editor, http.StatusForbidden},
+		{"COPY", "/tkn/a", "/elsewhere/b", editor, http.StatusBadRequest},
+		{http.MethodOptions, "/tkn/a", "", nil, 0},
+		{"PROPFIND", "/tkn/a", "", nil, http.StatusForbidden},
+		{http.MethodPatch, "/tkn/a/file.txt", "", editor, http.StatusForbidden},
+		{"ACL", "/tkn/a", "", editor, http.StatusForbidden},
+		{"SEARCH", "/tkn/a", "", viewer, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest(tt.method, tt.path, nil)
+		if tt.dst != "" {
+			r.Header.Set("Destination", "https://cloud.example.org"+tt.dst)
+		}
+		if code := checkPublicFolderRequest(r, base, "tkn", tt.perms); code != tt.code {
+			t.Errorf("%s %s -> %s: got status %d, expected %d", tt.method, tt.path, tt.dst, code, tt.code)
+		}
+	}
+}
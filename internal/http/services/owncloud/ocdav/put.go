@@ -29,6 +29,7 @@ import (
 	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
 	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	typespb "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	lockpb "github.com/cs3org/reva/internal/grpc/services/storageprovider/proto"
 	"github.com/cs3org/reva/internal/http/services/datagateway"
 	"github.com/cs3org/reva/pkg/appctx"
 	"github.com/cs3org/reva/pkg/errtypes"
@@ -187,6 +188,11 @@ func (s *svc) handlePutHelper(w http.ResponseWriter, r *http.Request, content io
 				return
 			}
 		}
+
+		lockRef := &lockpb.Reference{StorageId: info.Id.GetStorageId(), OpaqueId: info.Id.GetOpaqueId()}
+		if !s.checkLock(w, r, lockRef, &sublog) {
+			return
+		}
 	}
 
 	opaqueMap := map[string]*typespb.OpaqueEntry{
@@ -268,6 +274,10 @@ func (s *svc) handlePutHelper(w http.ResponseWriter, r *http.Request, content io
 			return
 		}
 		httpReq.Header.Set(datagateway.TokenTransportHeader, token)
+		if e, ok := opaqueMap["Upload-Checksum"]; ok {
+			// lets the data gateway verify the transferred bytes
+			httpReq.Header.Set("Upload-Checksum", string(e.Value))
+		}
 
 		httpRes, err := s.client.Do(httpReq)
 		if err != nil {
@@ -23,7 +23,10 @@ import (
 	"io"
 	"net/http"
 
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	"github.com/cs3org/reva/pkg/appctx"
+	ctxuser "github.com/cs3org/reva/pkg/user"
 )
 
 func (s *svc) handleReport(w http.ResponseWriter, r *http.Request, ns string) {
@@ -41,6 +44,10 @@ func (s *svc) handleReport(w http.ResponseWriter, r *http.Request, ns string) {
 		s.doSearchFiles(w, r, rep.SearchFiles)
 		return
 	}
+	if rep.FilterFiles != nil {
+		s.doFilterFiles(w, r, rep.FilterFiles, ns)
+		return
+	}
 
 	// TODO(jfd): implement report
 
@@ -59,9 +66,81 @@ func (s *svc) doSearchFiles(w http.ResponseWriter, r *http.Request, sf *reportSe
 	w.WriteHeader(http.StatusNotImplemented)
 }
 
+// doFilterFiles lists the favorites of the user. The other filter rules
+// are not supported.
+func (s *svc) doFilterFiles(w http.ResponseWriter, r *http.Request, ff *reportFilterFiles, ns string) {
+	ctx := r.Context()
+	log := appctx.GetLogger(ctx)
+
+	if !ff.Rules.Favorite {
+		w.WriteHeader(http.StatusNotImplemented)
+		return
+	}
+
+	u, ok := ctxuser.ContextGetUser(ctx)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	client, err := s.getClient()
+	if err != nil {
+		log.Error().Err(err).Msg("error getting grpc client")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	favs, err := s.favorites.ListFavorites(ctx, u.Id)
+	if err != nil {
+		log.Error().Err(err).Msg("error listing favorites")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	pf := &propfindXML{Prop: ff.Prop}
+	metadataKeys := []string{}
+	for i := range pf.Prop {
+		if requiresExplicitFetching(&pf.Prop[i]) {
+			metadataKeys = append(metadataKeys, metadataKeyOf(&pf.Prop[i]))
+		}
+	}
+
+	infos := make([]*provider.ResourceInfo, 0, len(favs))
+	for _, rid := range favs {
+		res, err := client.Stat(ctx, &provider.StatRequest{
+			Ref:                   &provider.Reference{Spec: &provider.Reference_Id{Id: rid}},
+			ArbitraryMetadataKeys: metadataKeys,
+		})
+		if err != nil {
+			log.Error().Err(err).Msg("error sending a grpc stat request")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		// the favorites which were deleted or are no longer accessible are
+		// left out
+		if res.Status.Code != rpc.Code_CODE_OK {
+			continue
+		}
+		infos = append(infos, res.Info)
+	}
+	s.setFavorites(ctx, infos)
+
+	propRes, err := s.formatPropfind(ctx, pf, infos, ns)
+	if err != nil {
+		log.Error().Err(err).Msg("error formatting propfind")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("DAV", "1, 3, extended-mkcol")
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusMultiStatus)
+	if _, err := w.Write([]byte(propRes)); err != nil {
+		log.Err(err).Msg("error writing response")
+	}
+}
+
 type report struct {
 	SearchFiles *reportSearchFiles
-	// FilterFiles TODO add this for tag based search
+	FilterFiles *reportFilterFiles
 }
 type reportSearchFiles struct {
 	XMLName xml.Name                `xml:"search-files"`
@@ -75,6 +154,15 @@ type reportSearchFilesSearch struct {
 	Offset  int    `xml:"offset"`
 }
 
+type reportFilterFiles struct {
+	XMLName xml.Name               `xml:"filter-files"`
+	Prop    propfindProps          `xml:"DAV: prop"`
+	Rules   reportFilterFilesRules `xml:"filter-rules"`
+}
+type reportFilterFilesRules struct {
+	Favorite bool `xml:"favorite"`
+}
+
 func readReport(r io.Reader) (rep *report, status int, err error) {
 	decoder := xml.NewDecoder(r)
 	rep = &report{}
@@ -96,6 +184,13 @@ func readReport(r io.Reader) (rep *report, status int, err error) {
 					return nil, http.StatusBadRequest, err
 				}
 				rep.SearchFiles = &repSF
+			} else if v.Name.Local == "filter-files" {
+				var repFF reportFilterFiles
+				err = decoder.DecodeElement(&repFF, &v)
+				if err != nil {
+					return nil, http.StatusBadRequest, err
+				}
+				rep.FilterFiles = &repFF
 			}
 		}
 	}
@@ -24,6 +24,7 @@ import (
 
 	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/data"
 	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/maintenance"
 )
 
 func (s *svc) doStatus(w http.ResponseWriter, r *http.Request) {
@@ -35,7 +36,10 @@ func (s *svc) doStatus(w http.ResponseWriter, r *http.Request) {
 		Version:        "10.0.9.5", // TODO(jfd) make build/config determined
 		VersionString:  "10.0.9",
 		Edition:        "community",
-		ProductName:    "ownCloud",
+		ProductName:    s.c.ProductName,
+	}
+	if s.inMaintenance(r) {
+		status.Maintenance = true
 	}
 
 	statusJSON, err := json.MarshalIndent(status, "", "    ")
@@ -50,3 +54,15 @@ func (s *svc) doStatus(w http.ResponseWriter, r *http.Request) {
 		log.Err(err).Msg("error writing response")
 	}
 }
+
+// inMaintenance tells whether the whole deployment is in maintenance, the
+// clients then pausing their synchronization.
+func (s *svc) inMaintenance(r *http.Request) bool {
+	modes, err := s.maintenance.GetModes(r.Context())
+	if err != nil {
+		appctx.GetLogger(r.Context()).Error().Err(err).Msg("error getting maintenance modes")
+		return false
+	}
+	_, ok := modes[maintenance.Global]
+	return ok
+}
@@ -33,8 +33,8 @@ import (
 	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
 	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
 	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
 	"github.com/cs3org/reva/pkg/appctx"
-	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
 	"github.com/cs3org/reva/pkg/rhttp/router"
 	ctxuser "github.com/cs3org/reva/pkg/user"
 	"github.com/cs3org/reva/pkg/utils"
@@ -156,7 +156,7 @@ func (h *TrashbinHandler) listTrashbin(w http.ResponseWriter, r *http.Request, s
 		return
 	}
 
-	gc, err := pool.GetGatewayServiceClient(s.c.GatewaySvc)
+	gc, err := s.getClient()
 	if err != nil {
 		// TODO(jfd) how do we make the user aware that some storages are not available?
 		// opaque response property? Or a list of errors?
@@ -179,13 +179,18 @@ func (h *TrashbinHandler) listTrashbin(w http.ResponseWriter, r *http.Request, s
 
 	// ask gateway for recycle items
 	// TODO(labkode): add Reference to ListRecycleRequest
-	getRecycleRes, err := gc.ListRecycle(ctx, &gateway.ListRecycleRequest{
-		Ref: &provider.Reference{
-			Spec: &provider.Reference_Path{
-				Path: getHomeRes.Path,
-			},
+	listReq, err := trashbinListRequest(r)
+	if err != nil {
+		sublog.Debug().Err(err).Msg("invalid trashbin listing parameters")
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	listReq.Ref = &provider.Reference{
+		Spec: &provider.Reference_Path{
+			Path: getHomeRes.Path,
 		},
-	})
+	}
+	getRecycleRes, err := gc.ListRecycle(ctx, listReq)
 
 	if err != nil {
 		sublog.Error().Err(err).Msg("error calling ListRecycle")
@@ -206,6 +211,9 @@ func (h *TrashbinHandler) listTrashbin(w http.ResponseWriter, r *http.Request, s
 	}
 	w.Header().Set("DAV", "1, 3, extended-mkcol")
 	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	if e := getRecycleRes.Opaque.GetMap()["next_page_token"]; e != nil {
+		w.Header().Set("OC-Next-Page-Token", string(e.Value))
+	}
 	w.WriteHeader(http.StatusMultiStatus)
 	_, err = w.Write([]byte(propRes))
 	if err != nil {
@@ -214,6 +222,51 @@ func (h *TrashbinHandler) listTrashbin(w http.ResponseWriter, r *http.Request, s
 	}
 }
 
+// trashbinListRequest builds the listing request from the query parameters:
+// limit and page_token paginate the listing, from and to bound the deletion
+// time as unix timestamps and path only keeps the items originally below it.
+func trashbinListRequest(r *http.Request) (*gateway.ListRecycleRequest, error) {
+	q := r.URL.Query()
+	req := &gateway.ListRecycleRequest{}
+
+	opaque := map[string]*types.OpaqueEntry{}
+	if v := q.Get("limit"); v != "" {
+		if n, err := strconv.ParseUint(v, 10, 32); err != nil || n == 0 {
+			return nil, fmt.Errorf("invalid limit %q", v)
+		}
+		opaque["page_size"] = &types.OpaqueEntry{Decoder: "plain", Value: []byte(v)}
+	}
+	if v := q.Get("page_token"); v != "" {
+		opaque["page_token"] = &types.OpaqueEntry{Decoder: "plain", Value: []byte(v)}
+	}
+	if v := q.Get("path"); v != "" {
+		opaque["path_prefix"] = &types.OpaqueEntry{Decoder: "plain", Value: []byte(path.Join("/", v))}
+	}
+	if len(opaque) > 0 {
+		req.Opaque = &types.Opaque{Map: opaque}
+	}
+
+	var err error
+	if req.FromTs, err = queryTimestamp(q.Get("from")); err != nil {
+		return nil, err
+	}
+	if req.ToTs, err = queryTimestamp(q.Get("to")); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func queryTimestamp(v string) (*types.Timestamp, error) {
+	if v == "" {
+		return nil, nil
+	}
+	seconds, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timestamp %q", v)
+	}
+	return &types.Timestamp{Seconds: seconds}, nil
+}
+
 func (h *TrashbinHandler) formatTrashPropfind(ctx context.Context, s *svc, u *userpb.User, pf *propfindXML, items []*provider.RecycleItem) (string, error) {
 	responses := make([]*responseXML, 0, len(items)+1)
 	// add trashbin dir . entry
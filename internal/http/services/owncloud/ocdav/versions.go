@@ -28,14 +28,17 @@ import (
 	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
 	"github.com/cs3org/reva/pkg/appctx"
 	"github.com/cs3org/reva/pkg/rhttp/router"
+	ctxuser "github.com/cs3org/reva/pkg/user"
 	"go.opencensus.io/trace"
 )
 
 // VersionsHandler handles version requests
 type VersionsHandler struct {
+	namespace string
 }
 
 func (h *VersionsHandler) init(c *Config) error {
+	h.namespace = c.WebdavNamespace
 	return nil
 }
 
@@ -51,6 +54,9 @@ func (h *VersionsHandler) Handler(s *svc, rid *provider.ResourceId) http.Handler
 			return
 		}
 
+		// versions can be restored to the files of the current user
+		filesBaseURI := path.Join(path.Dir(ctx.Value(ctxKeyBaseURI).(string)), "files")
+
 		// baseURI is encoded as part of the response payload in href field
 		baseURI := path.Join(ctx.Value(ctxKeyBaseURI).(string), wrapResourceID(rid))
 		ctx = context.WithValue(ctx, ctxKeyBaseURI, baseURI)
@@ -69,8 +75,7 @@ func (h *VersionsHandler) Handler(s *svc, rid *provider.ResourceId) http.Handler
 		if key != "" && r.Method == "COPY" {
 			// TODO(jfd) it seems we cannot directly GET version content with cs3 ...
 			// TODO(jfd) cs3api has no delete file version call
-			// TODO(jfd) restore version to given Destination, but cs3api has no destination
-			h.doRestore(w, r, s, rid, key)
+			h.doRestore(w, r, s, rid, key, filesBaseURI)
 			return
 		}
 
@@ -178,13 +183,25 @@ func (h *VersionsHandler) doListVersions(w http.ResponseWriter, r *http.Request,
 
 }
 
-func (h *VersionsHandler) doRestore(w http.ResponseWriter, r *http.Request, s *svc, rid *provider.ResourceId, key string) {
+// doRestore restores the version in place, or as a new file when the
+// destination is in the files of the current user, eg.
+// /remote.php/dav/files/<username>/path/to/restored.txt
+func (h *VersionsHandler) doRestore(w http.ResponseWriter, r *http.Request, s *svc, rid *provider.ResourceId, key, filesBaseURI string) {
 	ctx := r.Context()
 	ctx, span := trace.StartSpan(ctx, "restore")
 	defer span.End()
 
 	sublog := appctx.GetLogger(ctx).With().Interface("resourceid", rid).Str("key", key).Logger()
 
+	var dst string
+	if u, ok := ctxuser.ContextGetUser(ctx); ok {
+		// other destinations, like the restore folder of the ownCloud clients, restore in place
+		if p, err := extractDestination(r.Header.Get("Destination"), path.Join(filesBaseURI, u.Username)); err == nil && path.Clean("/"+p) != "/" {
+			dst = path.Join(applyLayout(ctx, h.namespace, true, ""), p)
+			sublog = sublog.With().Str("dst", dst).Logger()
+		}
+	}
+
 	client, err := s.getClient()
 	if err != nil {
 		sublog.Error().Err(err).Msg("error getting grpc client")
@@ -198,6 +215,13 @@ func (h *VersionsHandler) doRestore(w http.ResponseWriter, r *http.Request, s *s
 		},
 		Key: key,
 	}
+	if dst != "" {
+		req.Opaque = &types.Opaque{
+			Map: map[string]*types.OpaqueEntry{
+				"restore_path": {Decoder: "plain", Value: []byte(dst)},
+			},
+		}
+	}
 
 	res, err := client.RestoreFileVersion(ctx, req)
 	if err != nil {
@@ -205,9 +229,18 @@ func (h *VersionsHandler) doRestore(w http.ResponseWriter, r *http.Request, s *s
 		w.WriteHeader(http.StatusInternalServerError)
 		return
 	}
+	if dst != "" && res.Status.Code == rpc.Code_CODE_ALREADY_EXISTS {
+		// existing files are not overwritten, see https://tools.ietf.org/html/rfc4918#section-9.8.5
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return
+	}
 	if res.Status.Code != rpc.Code_CODE_OK {
 		HandleErrorStatus(&sublog, w, res.Status)
 		return
 	}
+	if dst != "" {
+		w.WriteHeader(http.StatusCreated)
+		return
+	}
 	w.WriteHeader(http.StatusNoContent)
 }
@@ -21,6 +21,7 @@ package config
 import (
 	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/data"
 	"github.com/cs3org/reva/pkg/sharedconf"
+	"github.com/cs3org/reva/pkg/smtpclient"
 )
 
 // Config holds the config options that need to be passed down to all ocs handlers
@@ -38,6 +39,39 @@ type Config struct {
 	CacheWarmupDrivers      map[string]map[string]interface{} `mapstructure:"cache_warmup_drivers"`
 	ResourceInfoCacheSize   int                               `mapstructure:"resource_info_cache_size"`
 	ResourceInfoCacheTTL    int                               `mapstructure:"resource_info_cache_ttl"`
+	GuestManager            string                            `mapstructure:"guest_manager"`
+	GuestManagers           map[string]map[string]interface{} `mapstructure:"guest_managers"`
+	GuestActivationURL      string                            `mapstructure:"guest_activation_url"`
+	// PermissionDriver, if set, is used to check whether the users hold the
+	// guests.invite capability before creating guest accounts for them.
+	PermissionDriver     string                            `mapstructure:"permission_driver"`
+	PermissionDrivers    map[string]map[string]interface{} `mapstructure:"permission_drivers"`
+	SMTPCredentials      *smtpclient.SMTPCredentials       `mapstructure:"smtp_credentials"`
+	NotificationManager  string                            `mapstructure:"notification_manager"`
+	NotificationManagers map[string]map[string]interface{} `mapstructure:"notification_managers"`
+	// Notifications configures the dispatcher delivering the notifications of
+	// the events consumed from the events bus. If empty, no dispatcher is run.
+	Notifications    map[string]interface{}            `mapstructure:"notifications"`
+	ActivityManager  string                            `mapstructure:"activity_manager"`
+	ActivityManagers map[string]map[string]interface{} `mapstructure:"activity_managers"`
+	// Activities configures the materializer adding the events consumed from
+	// the events bus to the timelines of the users. If empty, none is run.
+	Activities map[string]interface{} `mapstructure:"activities"`
+	// ResourceInfoCache configures the driver of the resource info cache, by
+	// default kept in memory with up to ResourceInfoCacheSize entries.
+	ResourceInfoCache map[string]interface{} `mapstructure:"resource_info_cache"`
+	// CapabilitiesProbeTTL is the number of seconds the features probed to
+	// compute the capabilities advertised to a user are cached. A negative
+	// value disables the probing, the configured capabilities being
+	// advertised as is.
+	CapabilitiesProbeTTL int `mapstructure:"capabilities_probe_ttl"`
+	// Provisioning configures the subset of the ownCloud provisioning API
+	// writing to the user and group managers. If empty, it is disabled.
+	Provisioning map[string]interface{} `mapstructure:"provisioning"`
+	// TenantCapabilities holds the capabilities advertised to the clients of
+	// the tenants overriding some, by tenant id. They are computed from the
+	// configuration by the service.
+	TenantCapabilities map[string]data.CapabilitiesData `mapstructure:"-"`
 }
 
 // Init sets sane defaults
@@ -62,9 +96,30 @@ func (c *Config) Init() {
 		c.AdditionalInfoAttribute = "{{.Mail}}"
 	}
 
+	if c.NotificationManager == "" {
+		c.NotificationManager = "memory"
+	}
+
+	if c.ActivityManager == "" {
+		c.ActivityManager = "memory"
+	}
+
 	if c.ResourceInfoCacheSize == 0 {
 		c.ResourceInfoCacheSize = 1000000
 	}
 
+	if c.CapabilitiesProbeTTL == 0 {
+		c.CapabilitiesProbeTTL = 60
+	}
+
+	if c.ResourceInfoCache == nil {
+		c.ResourceInfoCache = map[string]interface{}{
+			"driver": "memory",
+			"drivers": map[string]map[string]interface{}{
+				"memory": {"max_entries": c.ResourceInfoCacheSize},
+			},
+		}
+	}
+
 	c.GatewaySvc = sharedconf.GetGatewaySVC(c.GatewaySvc)
 }
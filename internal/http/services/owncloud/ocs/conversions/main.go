@@ -49,6 +49,10 @@ const (
 	// ShareTypeGroup represents a group share
 	ShareTypeGroup ShareType = 1
 
+	// ShareTypeEmail represents a share with an external email address,
+	// backed by a guest account
+	ShareTypeEmail ShareType = 4
+
 	// ShareTypeFederatedCloudShare represents a federated share
 	ShareTypeFederatedCloudShare ShareType = 6
 )
@@ -46,6 +46,8 @@ const (
 	RoleFileEditor string = "file-editor"
 	// RoleCoowner grants owner permissions on a resource
 	RoleCoowner string = "coowner"
+	// RoleManager grants the permissions to manage a project space, including its members
+	RoleManager string = "manager"
 	// RoleUploader FIXME: uploader role with only write permission can use InitiateFileUpload, not anything else
 	RoleUploader string = "uploader"
 )
@@ -127,6 +129,8 @@ func RoleFromName(name string) *Role {
 		return NewFileEditorRole()
 	case RoleCoowner:
 		return NewCoownerRole()
+	case RoleManager:
+		return NewManagerRole()
 	case RoleUploader:
 		return NewUploaderRole()
 	}
@@ -258,6 +262,14 @@ func NewCoownerRole() *Role {
 	}
 }
 
+// NewManagerRole creates a manager role, which has the same permissions as a
+// coowner. Granted at the root of a project space it allows to manage the space.
+func NewManagerRole() *Role {
+	r := NewCoownerRole()
+	r.Name = RoleManager
+	return r
+}
+
 // NewUploaderRole creates an uploader role
 func NewUploaderRole() *Role {
 	return &Role{
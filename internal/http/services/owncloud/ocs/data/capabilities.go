@@ -56,6 +56,7 @@ type Capabilities struct {
 	Dav           *CapabilitiesDav           `json:"dav" xml:"dav"`
 	FilesSharing  *CapabilitiesFilesSharing  `json:"files_sharing" xml:"files_sharing" mapstructure:"files_sharing"`
 	Notifications *CapabilitiesNotifications `json:"notifications" xml:"notifications"`
+	Theming       *CapabilitiesTheming       `json:"theming,omitempty" xml:"theming,omitempty" mapstructure:"theming"`
 }
 
 // CapabilitiesCore holds webdav config
@@ -78,6 +79,20 @@ type Status struct {
 	Hostname       string  `json:"hostname,omitempty" xml:"hostname,omitempty"`
 }
 
+// CapabilitiesTheming holds the branding of the deployment, applied by the
+// clients. The logo, background and favicon are URLs, e.g. of the assets
+// served by the theme service.
+type CapabilitiesTheming struct {
+	Name       string `json:"name" xml:"name"`
+	URL        string `json:"url" xml:"url"`
+	Slogan     string `json:"slogan" xml:"slogan"`
+	Color      string `json:"color" xml:"color"`
+	ColorText  string `json:"color-text" xml:"color-text" mapstructure:"color_text"`
+	Logo       string `json:"logo" xml:"logo"`
+	Background string `json:"background" xml:"background"`
+	Favicon    string `json:"favicon" xml:"favicon"`
+}
+
 // CapabilitiesChecksums holds available hashes
 type CapabilitiesChecksums struct {
 	SupportedTypes      []string `json:"supportedTypes" xml:"supportedTypes>element" mapstructure:"supported_types"`
@@ -100,6 +115,8 @@ type CapabilitiesFiles struct {
 	Undelete         ocsBool                      `json:"undelete" xml:"undelete"`
 	Versioning       ocsBool                      `json:"versioning" xml:"versioning"`
 	Favorites        ocsBool                      `json:"favorites" xml:"favorites"`
+	Retention        ocsBool                      `json:"retention" xml:"retention"`
+	AppProviders     ocsBool                      `json:"app_providers_enabled" xml:"app_providers_enabled"`
 	BlacklistedFiles []string                     `json:"blacklisted_files" xml:"blacklisted_files>element" mapstructure:"blacklisted_files"`
 	TusSupport       *CapabilitiesFilesTusSupport `json:"tus_support" xml:"tus_support" mapstructure:"tus_support"`
 }
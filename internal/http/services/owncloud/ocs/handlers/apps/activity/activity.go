@@ -0,0 +1,218 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package activity
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/config"
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/response"
+	"github.com/cs3org/reva/pkg/activity"
+	"github.com/cs3org/reva/pkg/activity/manager/registry"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
+	"github.com/cs3org/reva/pkg/rhttp/router"
+	"github.com/cs3org/reva/pkg/user"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	defaultLimit = 50
+	maxLimit     = 200
+)
+
+// Handler serves the timelines of the users
+type Handler struct {
+	am          activity.Manager
+	gatewayAddr string
+}
+
+// ActivityData is the representation of an activity in the API
+type ActivityData struct {
+	ActivityID   int64  `json:"activity_id" xml:"activity_id"`
+	App          string `json:"app" xml:"app"`
+	Type         string `json:"type" xml:"type"`
+	User         string `json:"user" xml:"user"`
+	AffectedUser string `json:"affecteduser" xml:"affecteduser"`
+	Subject      string `json:"subject" xml:"subject"`
+	ObjectType   string `json:"object_type" xml:"object_type"`
+	ObjectID     string `json:"object_id" xml:"object_id"`
+	ObjectName   string `json:"object_name" xml:"object_name"`
+	Datetime     string `json:"datetime" xml:"datetime"`
+}
+
+// Init initializes this and any contained handlers
+func (h *Handler) Init(c *config.Config) error {
+	f, ok := registry.NewFuncs[c.ActivityManager]
+	if !ok {
+		return errtypes.NotFound("activity: manager not found: " + c.ActivityManager)
+	}
+	am, err := f(c.ActivityManagers[c.ActivityManager])
+	if err != nil {
+		return err
+	}
+	h.am = am
+	h.gatewayAddr = c.GatewaySvc
+
+	if len(c.Activities) > 0 {
+		mc, err := activity.ParseMaterializerConfig(c.Activities)
+		if err != nil {
+			return err
+		}
+		mt, err := activity.NewMaterializer(mc, am, &log.Logger)
+		if err != nil {
+			return err
+		}
+		go mt.Run(nil)
+	}
+	return nil
+}
+
+// ServeHTTP serves /activity, with the optional filters all and filter. The
+// latter restricts the activities to the resource given by the object_type
+// and object_id parameters.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var head, filter string
+	head, r.URL.Path = router.ShiftPath(r.URL.Path)
+	filter, _ = router.ShiftPath(r.URL.Path)
+	if head != "activity" || r.Method != http.MethodGet {
+		response.WriteOCSError(w, r, response.MetaNotFound.StatusCode, "Not found", nil)
+		return
+	}
+
+	q := r.URL.Query()
+	f := &activity.Filter{Limit: defaultLimit}
+	switch filter {
+	case "", "all":
+	case "filter":
+		f.ObjectType = q.Get("object_type")
+		f.ObjectID = q.Get("object_id")
+		if f.ObjectType == "" || f.ObjectID == "" {
+			response.WriteOCSError(w, r, response.MetaBadRequest.StatusCode, "missing object_type or object_id", nil)
+			return
+		}
+	default:
+		response.WriteOCSError(w, r, response.MetaNotFound.StatusCode, "unknown filter", nil)
+		return
+	}
+	if s := q.Get("since"); s != "" {
+		since, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			response.WriteOCSError(w, r, response.MetaBadRequest.StatusCode, "invalid since", nil)
+			return
+		}
+		f.Since = since
+	}
+	if l := q.Get("limit"); l != "" {
+		limit, err := strconv.Atoi(l)
+		if err != nil || limit <= 0 {
+			response.WriteOCSError(w, r, response.MetaBadRequest.StatusCode, "invalid limit", nil)
+			return
+		}
+		if limit > maxLimit {
+			limit = maxLimit
+		}
+		f.Limit = limit
+	}
+
+	ctx := r.Context()
+	u := user.ContextMustGetUser(ctx)
+	list, err := h.am.List(ctx, u.Id, f)
+	if err != nil {
+		response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "error listing activities", err)
+		return
+	}
+
+	names := map[string]string{}
+	data := make([]*ActivityData, 0, len(list))
+	for _, a := range list {
+		actor := h.username(ctx, names, u, a.Actor)
+		data = append(data, &ActivityData{
+			ActivityID:   a.ID,
+			App:          "reva",
+			Type:         a.Type,
+			User:         actor,
+			AffectedUser: u.Username,
+			Subject:      subject(a, actor),
+			ObjectType:   a.ObjectType,
+			ObjectID:     a.ObjectID,
+			ObjectName:   a.ObjectName,
+			Datetime:     a.Time.UTC().Format(time.RFC3339),
+		})
+	}
+
+	// link to the next page, as the activity app does
+	if len(list) == f.Limit {
+		last := strconv.FormatInt(list[len(list)-1].ID, 10)
+		w.Header().Set("X-Activity-Last-Given", last)
+		if next, err := url.Parse(r.RequestURI); err == nil {
+			params := next.Query()
+			params.Set("since", last)
+			next.RawQuery = params.Encode()
+			w.Header().Set("Link", fmt.Sprintf("<%s>; rel=\"next\"", next.String()))
+		}
+	}
+	response.WriteOCSSuccess(w, r, data)
+}
+
+// username resolves the username of the actor of an activity, caching the
+// results in names for the duration of the request.
+func (h *Handler) username(ctx context.Context, names map[string]string, u *userpb.User, actor *userpb.UserId) string {
+	if actor == nil {
+		return ""
+	}
+	if actor.Idp == u.Id.Idp && actor.OpaqueId == u.Id.OpaqueId {
+		return u.Username
+	}
+	key := actor.Idp + "!" + actor.OpaqueId
+	if name, ok := names[key]; ok {
+		return name
+	}
+
+	name := actor.OpaqueId
+	if client, err := pool.GetGatewayServiceClient(h.gatewayAddr); err == nil {
+		res, err := client.GetUser(ctx, &userpb.GetUserRequest{UserId: actor})
+		if err == nil && res.Status.Code == rpc.Code_CODE_OK {
+			name = res.User.Username
+		}
+	}
+	names[key] = name
+	return name
+}
+
+func subject(a *activity.Activity, actor string) string {
+	switch a.Type {
+	case activity.FileUploaded:
+		return fmt.Sprintf("You uploaded %s", a.ObjectName)
+	case activity.ShareCreated:
+		return fmt.Sprintf("You shared %s", a.ObjectName)
+	case activity.ShareReceived:
+		return fmt.Sprintf("%s shared %s with you", actor, a.ObjectName)
+	case activity.LinkExpired:
+		return fmt.Sprintf("Your public link to %s has expired", a.ObjectName)
+	}
+	return a.ObjectName
+}
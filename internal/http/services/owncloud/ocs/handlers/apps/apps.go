@@ -22,8 +22,10 @@ import (
 	"net/http"
 
 	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/config"
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/handlers/apps/activity"
 	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/handlers/apps/notifications"
 	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/handlers/apps/sharing"
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/handlers/apps/systemtags"
 	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/response"
 	"github.com/cs3org/reva/pkg/rhttp/router"
 )
@@ -32,12 +34,25 @@ import (
 type Handler struct {
 	SharingHandler       *sharing.Handler
 	NotificationsHandler *notifications.Handler
+	ActivityHandler      *activity.Handler
+	SystemTagsHandler    *systemtags.Handler
 }
 
 // Init initializes this and any contained handlers
 func (h *Handler) Init(c *config.Config) error {
 	h.SharingHandler = new(sharing.Handler)
 	h.NotificationsHandler = new(notifications.Handler)
+	if err := h.NotificationsHandler.Init(c); err != nil {
+		return err
+	}
+	h.ActivityHandler = new(activity.Handler)
+	if err := h.ActivityHandler.Init(c); err != nil {
+		return err
+	}
+	h.SystemTagsHandler = new(systemtags.Handler)
+	if err := h.SystemTagsHandler.Init(c); err != nil {
+		return err
+	}
 	return h.SharingHandler.Init(c)
 }
 
@@ -60,12 +75,32 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		head, r.URL.Path = router.ShiftPath(r.URL.Path)
 		if head == "api" {
 			head, r.URL.Path = router.ShiftPath(r.URL.Path)
-			if head == "v1" {
+			if head == "v1" || head == "v2" {
 				h.NotificationsHandler.ServeHTTP(w, r)
 				return
 			}
 		}
 		response.WriteOCSError(w, r, response.MetaNotFound.StatusCode, "Not found", nil)
+	case "activity":
+		head, r.URL.Path = router.ShiftPath(r.URL.Path)
+		if head == "api" {
+			head, r.URL.Path = router.ShiftPath(r.URL.Path)
+			if head == "v2" {
+				h.ActivityHandler.ServeHTTP(w, r)
+				return
+			}
+		}
+		response.WriteOCSError(w, r, response.MetaNotFound.StatusCode, "Not found", nil)
+	case "systemtags":
+		head, r.URL.Path = router.ShiftPath(r.URL.Path)
+		if head == "api" {
+			head, r.URL.Path = router.ShiftPath(r.URL.Path)
+			if head == "v1" {
+				h.SystemTagsHandler.ServeHTTP(w, r)
+				return
+			}
+		}
+		response.WriteOCSError(w, r, response.MetaNotFound.StatusCode, "Not found", nil)
 	default:
 		response.WriteOCSError(w, r, response.MetaNotFound.StatusCode, "Not found", nil)
 	}
@@ -20,22 +20,184 @@ package notifications
 
 import (
 	"net/http"
+	"time"
 
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/config"
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/response"
 	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/notification"
+	"github.com/cs3org/reva/pkg/notification/manager/registry"
 	"github.com/cs3org/reva/pkg/rhttp/router"
+	"github.com/cs3org/reva/pkg/user"
+	"github.com/rs/zerolog/log"
 )
 
-// Handler placeholder for notifications
+// Handler serves the notifications of the users
 type Handler struct {
+	nm notification.Manager
 }
 
-func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	log := appctx.GetLogger(r.Context())
+// NotificationData is the representation of a notification in the API
+type NotificationData struct {
+	NotificationID string `json:"notification_id" xml:"notification_id"`
+	App            string `json:"app" xml:"app"`
+	User           string `json:"user" xml:"user"`
+	Datetime       string `json:"datetime" xml:"datetime"`
+	ObjectType     string `json:"object_type" xml:"object_type"`
+	ObjectID       string `json:"object_id" xml:"object_id"`
+	Subject        string `json:"subject" xml:"subject"`
+	Message        string `json:"message" xml:"message"`
+	Actor          string `json:"actor,omitempty" xml:"actor,omitempty"`
+	Read           bool   `json:"read" xml:"read"`
+}
+
+// Init initializes this and any contained handlers
+func (h *Handler) Init(c *config.Config) error {
+	f, ok := registry.NewFuncs[c.NotificationManager]
+	if !ok {
+		return errtypes.NotFound("notifications: manager not found: " + c.NotificationManager)
+	}
+	nm, err := f(c.NotificationManagers[c.NotificationManager])
+	if err != nil {
+		return err
+	}
+	h.nm = nm
 
+	// the events published in this process are dispatched only if configured,
+	// so that a single instance delivers them
+	if len(c.Notifications) > 0 {
+		dc, err := notification.ParseDispatcherConfig(c.Notifications)
+		if err != nil {
+			return err
+		}
+		if dc.SMTPCredentials == nil {
+			dc.SMTPCredentials = c.SMTPCredentials
+		}
+		if dc.GatewaySvc == "" {
+			dc.GatewaySvc = c.GatewaySvc
+		}
+		d, err := notification.NewDispatcher(dc, nm, &log.Logger)
+		if err != nil {
+			return err
+		}
+		go d.Run(nil)
+	}
+	return nil
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	var head string
 	head, r.URL.Path = router.ShiftPath(r.URL.Path)
+	if head != "notifications" {
+		response.WriteOCSError(w, r, response.MetaNotFound.StatusCode, "Not found", nil)
+		return
+	}
+
+	var id, action string
+	id, r.URL.Path = router.ShiftPath(r.URL.Path)
+	action, _ = router.ShiftPath(r.URL.Path)
+
+	switch {
+	case id == "" && r.Method == http.MethodGet:
+		h.list(w, r)
+	case id == "" && r.Method == http.MethodDelete:
+		h.deleteAll(w, r)
+	case id != "" && action == "" && r.Method == http.MethodGet:
+		h.get(w, r, id)
+	case id != "" && action == "" && r.Method == http.MethodDelete:
+		h.delete(w, r, id)
+	case id != "" && action == "read" && r.Method == http.MethodPost:
+		h.setRead(w, r, id, true)
+	case id != "" && action == "unread" && r.Method == http.MethodPost:
+		h.setRead(w, r, id, false)
+	default:
+		response.WriteOCSError(w, r, response.MetaNotFound.StatusCode, "Not found", nil)
+	}
+}
+
+func toData(username string, n *notification.Notification) *NotificationData {
+	return &NotificationData{
+		NotificationID: n.ID,
+		App:            "reva",
+		User:           username,
+		Datetime:       n.Time.UTC().Format(time.RFC3339),
+		ObjectType:     n.Type,
+		ObjectID:       n.Resource,
+		Subject:        n.Subject,
+		Message:        n.Message,
+		Actor:          n.Actor,
+		Read:           n.Read,
+	}
+}
 
-	log.Debug().Str("head", head).Str("tail", r.URL.Path).Msg("http routing")
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	if _, ok := err.(errtypes.IsNotFound); ok {
+		response.WriteOCSError(w, r, response.MetaNotFound.StatusCode, "notification not found", nil)
+		return
+	}
+	response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "error accessing notifications", err)
+}
+
+func (h *Handler) list(w http.ResponseWriter, r *http.Request) {
+	u := user.ContextMustGetUser(r.Context())
+	list, err := h.nm.List(r.Context(), u.Id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	unread := r.URL.Query().Get("unread") == "true"
+	data := make([]*NotificationData, 0, len(list))
+	for _, n := range list {
+		if unread && n.Read {
+			continue
+		}
+		data = append(data, toData(u.Username, n))
+	}
+	response.WriteOCSSuccess(w, r, data)
+}
+
+func (h *Handler) get(w http.ResponseWriter, r *http.Request, id string) {
+	u := user.ContextMustGetUser(r.Context())
+	n, err := h.nm.Get(r.Context(), u.Id, id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	response.WriteOCSSuccess(w, r, toData(u.Username, n))
+}
+
+func (h *Handler) setRead(w http.ResponseWriter, r *http.Request, id string, read bool) {
+	u := user.ContextMustGetUser(r.Context())
+	if err := h.nm.SetRead(r.Context(), u.Id, id, read); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	response.WriteOCSSuccess(w, r, nil)
+}
+
+func (h *Handler) delete(w http.ResponseWriter, r *http.Request, id string) {
+	u := user.ContextMustGetUser(r.Context())
+	if err := h.nm.Delete(r.Context(), u.Id, id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	response.WriteOCSSuccess(w, r, nil)
+}
 
-	w.WriteHeader(http.StatusOK)
+func (h *Handler) deleteAll(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	u := user.ContextMustGetUser(ctx)
+	list, err := h.nm.List(ctx, u.Id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	for _, n := range list {
+		if err := h.nm.Delete(ctx, u.Id, n.ID); err != nil {
+			appctx.GetLogger(ctx).Error().Err(err).Str("id", n.ID).Msg("error deleting notification")
+		}
+	}
+	response.WriteOCSSuccess(w, r, nil)
 }
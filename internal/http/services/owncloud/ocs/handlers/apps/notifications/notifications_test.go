@@ -0,0 +1,106 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package notifications
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/response"
+	"github.com/cs3org/reva/pkg/notification"
+	"github.com/cs3org/reva/pkg/notification/manager/memory"
+	"github.com/cs3org/reva/pkg/user"
+)
+
+func TestHandlerIsolatesTheUsers(t *testing.T) {
+	ctx := context.Background()
+	einstein := &userpb.User{Id: &userpb.UserId{OpaqueId: "einstein"}, Username: "einstein"}
+	marie := &userpb.User{Id: &userpb.UserId{OpaqueId: "marie"}, Username: "marie"}
+
+	nm, err := memory.New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = nm.Add(ctx, einstein.Id, &notification.Notification{ID: "einstein-1", Time: time.Now()})
+	_ = nm.Add(ctx, marie.Id, &notification.Notification{ID: "marie-1", Time: time.Now()})
+	_ = nm.Add(ctx, marie.Id, &notification.Notification{ID: "marie-2", Time: time.Now()})
+	h := &Handler{nm: nm}
+
+	tests := []struct {
+		method, url string
+		code        int
+		items       int
+	}{
+		{http.MethodGet, "/notifications", 100, 2},
+		{http.MethodGet, "/notifications/marie-1", 100, 0},
+		{http.MethodPost, "/notifications/marie-1/read", 100, 0},
+		{http.MethodGet, "/notifications?unread=true", 100, 1},
+		{http.MethodGet, "/notifications/einstein-1", 998, 0},
+		{http.MethodPost, "/notifications/einstein-1/read", 998, 0},
+		{http.MethodDelete, "/notifications/einstein-1", 998, 0},
+		{http.MethodPost, "/notifications/marie-2/archive", 998, 0},
+		{http.MethodPut, "/notifications/marie-2", 998, 0},
+		{http.MethodGet, "/activities", 998, 0},
+		{http.MethodDelete, "/notifications", 100, 0},
+		{http.MethodGet, "/notifications", 100, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.url, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.url, nil)
+			q := r.URL.Query()
+			q.Set("format", "json")
+			r.URL.RawQuery = q.Encode()
+			r = r.WithContext(user.ContextSetUser(r.Context(), marie))
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			var res struct {
+				OCS struct {
+					Meta response.Meta       `json:"meta"`
+					Data []*NotificationData `json:"data"`
+				} `json:"ocs"`
+			}
+			_ = json.Unmarshal(w.Body.Bytes(), &res)
+			if res.OCS.Meta.StatusCode != tt.code {
+				t.Errorf("got status %d, wanted %d", res.OCS.Meta.StatusCode, tt.code)
+			}
+			if tt.items > 0 && len(res.OCS.Data) != tt.items {
+				t.Errorf("got %d notifications, wanted %d", len(res.OCS.Data), tt.items)
+			}
+			for _, n := range res.OCS.Data {
+				if n.User != "marie" || n.NotificationID == "einstein-1" {
+					t.Errorf("marie got the notification %s of %s", n.NotificationID, n.User)
+				}
+			}
+		})
+	}
+
+	n, err := nm.Get(ctx, einstein.Id, "einstein-1")
+	if err != nil {
+		t.Fatal("marie deleted the notifications of einstein")
+	}
+	if n.Read {
+		t.Error("marie marked the notifications of einstein as read")
+	}
+}
@@ -0,0 +1,156 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package shares
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"text/template"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	collaboration "github.com/cs3org/go-cs3apis/cs3/sharing/collaboration/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/conversions"
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/response"
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/permission"
+	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
+	"github.com/cs3org/reva/pkg/user"
+)
+
+const guestActivationMail = `Hello,
+
+{{.Inviter}} has shared "{{.Resource}}" with you.
+
+To access it, activate your guest account by choosing a password at:
+{{.Link}}
+`
+
+var guestActivationTemplate = template.Must(template.New("guestActivation").Parse(guestActivationMail))
+
+func (h *Handler) createGuestShare(w http.ResponseWriter, r *http.Request, statInfo *provider.ResourceInfo, role *conversions.Role, roleVal []byte) {
+	ctx := r.Context()
+	log := appctx.GetLogger(ctx)
+
+	if h.guestManager == nil {
+		response.WriteOCSError(w, r, response.MetaBadRequest.StatusCode, "sharing with guests is not enabled", nil)
+		return
+	}
+	if !h.canInviteGuests(r) {
+		response.WriteOCSError(w, r, response.MetaUnauthorized.StatusCode, "not allowed to invite guests", nil)
+		return
+	}
+
+	c, err := pool.GetGatewayServiceClient(h.gatewayAddr)
+	if err != nil {
+		response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "error getting grpc gateway client", err)
+		return
+	}
+
+	shareWith := r.FormValue("shareWith")
+	if shareWith == "" {
+		response.WriteOCSError(w, r, response.MetaBadRequest.StatusCode, "missing shareWith", nil)
+		return
+	}
+
+	g, err := h.guestManager.InviteGuest(ctx, shareWith)
+	if err != nil {
+		response.WriteOCSError(w, r, response.MetaBadRequest.StatusCode, "error creating guest account", err)
+		return
+	}
+
+	createShareReq := &collaboration.CreateShareRequest{
+		Opaque: &types.Opaque{
+			Map: map[string]*types.OpaqueEntry{
+				"role": {
+					Decoder: "json",
+					Value:   roleVal,
+				},
+			},
+		},
+		ResourceInfo: statInfo,
+		Grant: &collaboration.ShareGrant{
+			Grantee: &provider.Grantee{
+				Type: provider.GranteeType_GRANTEE_TYPE_USER,
+				Id:   &provider.Grantee_UserId{UserId: g.User.GetId()},
+			},
+			Permissions: &collaboration.SharePermissions{
+				Permissions: role.CS3ResourcePermissions(),
+			},
+		},
+	}
+
+	if share := h.createCs3Share(ctx, w, r, c, createShareReq, statInfo); share == nil || g.IsActive() {
+		return
+	}
+
+	if err := h.sendGuestActivation(r, g.User, g.ActivationToken, statInfo); err != nil {
+		// the share exists, the guest can still be invited again later on
+		log.Error().Err(err).Str("guest", g.User.Mail).Msg("error sending guest activation mail")
+	}
+}
+
+// canInviteGuests tells whether the user has been granted the guests.invite
+// capability. Without a permission driver every user has it.
+func (h *Handler) canInviteGuests(r *http.Request) bool {
+	if h.permissions == nil {
+		return true
+	}
+	ctx := r.Context()
+	u, ok := user.ContextGetUser(ctx)
+	if !ok {
+		return false
+	}
+	allowed, err := permission.CheckPermission(ctx, h.permissions, u, permission.InviteGuests)
+	if err != nil {
+		appctx.GetLogger(ctx).Error().Err(err).Msg("error checking permission")
+	}
+	return allowed
+}
+
+func (h *Handler) sendGuestActivation(r *http.Request, guest *userpb.User, token string, statInfo *provider.ResourceInfo) error {
+	if h.smtpCredentials == nil {
+		appctx.GetLogger(r.Context()).Warn().Str("guest", guest.Mail).Msg("no smtp credentials configured, not sending guest activation mail")
+		return nil
+	}
+
+	link, err := url.Parse(h.guestActivationURL)
+	if err != nil {
+		return err
+	}
+	q := link.Query()
+	q.Set("token", token)
+	link.RawQuery = q.Encode()
+
+	inviter := user.ContextMustGetUser(r.Context())
+	var body bytes.Buffer
+	err = guestActivationTemplate.Execute(&body, map[string]string{
+		"Inviter":  inviter.DisplayName,
+		"Resource": statInfo.Path,
+		"Link":     link.String(),
+	})
+	if err != nil {
+		return err
+	}
+
+	return h.smtpCredentials.SendMail(guest.Mail, inviter.DisplayName+" shared a resource with you", body.String())
+}
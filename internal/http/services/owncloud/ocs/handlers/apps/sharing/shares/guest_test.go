@@ -0,0 +1,81 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+package shares
+
+import (
+	"context"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/response"
+	"github.com/cs3org/reva/pkg/guest"
+	"github.com/cs3org/reva/pkg/permission"
+	"github.com/cs3org/reva/pkg/permission/manager/static"
+	"github.com/cs3org/reva/pkg/user"
+)
+
+type invitations struct {
+	guest.Manager
+	invited []string
+}
+
+func (m *invitations) InviteGuest(ctx context.Context, mail string) (*guest.Guest, error) {
+	m.invited = append(m.invited, mail)
+	return nil, context.Canceled
+}
+
+func TestCreateGuestShareDenied(t *testing.T) {
+	pm, err := static.New(map[string]interface{}{
+		"assignments": map[string]interface{}{
+			"users": map[string][]string{
+				"alice": {permission.RoleUser},
+				"guest": {permission.RoleGuest},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	gm := &invitations{}
+	h := &Handler{guestManager: gm, permissions: pm}
+
+	form := url.Values{"shareWith": {"someone@example.org"}}
+	r := httptest.NewRequest("POST", "/shares?format=json", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	u := &userpb.User{Id: &userpb.UserId{OpaqueId: "guest"}, Username: "guest"}
+	r = r.WithContext(user.ContextSetUser(r.Context(), u))
+	w := httptest.NewRecorder()
+
+	h.createGuestShare(w, r, &provider.ResourceInfo{}, nil, nil)
+
+	if len(gm.invited) != 0 {
+		t.Errorf("a guest was invited by a user without the %s capability", permission.InviteGuests)
+	}
+	if !strings.Contains(w.Body.String(), `"statuscode":997`) {
+		t.Errorf("got %s, wanted the status code %d", w.Body.String(), response.MetaUnauthorized.StatusCode)
+	}
+
+	u = &userpb.User{Id: &userpb.UserId{OpaqueId: "alice"}, Username: "alice"}
+	if !h.canInviteGuests(r.WithContext(user.ContextSetUser(r.Context(), u))) {
+		t.Errorf("the user role is not allowed to invite guests")
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package shares
+
+import (
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	collaboration "github.com/cs3org/go-cs3apis/cs3/sharing/collaboration/v1beta1"
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/conversions"
+	"github.com/pkg/errors"
+)
+
+const (
+	sortByMtime = "mtime"
+	sortByName  = "name"
+)
+
+// listOptions are the pagination and sorting parameters of the share
+// listings:
+//
+//	limit=<n>&offset=<n>&sort_by=mtime|name&sort_order=asc|desc
+//
+// A limit of 0 means no limit.
+type listOptions struct {
+	limit  int
+	offset int
+	sortBy string
+	desc   bool
+}
+
+func parseListOptions(r *http.Request) (*listOptions, error) {
+	opts := &listOptions{}
+	var err error
+	if l := r.FormValue("limit"); l != "" {
+		if opts.limit, err = strconv.Atoi(l); err != nil || opts.limit < 0 {
+			return nil, errors.New("invalid limit")
+		}
+	}
+	if o := r.FormValue("offset"); o != "" {
+		if opts.offset, err = strconv.Atoi(o); err != nil || opts.offset < 0 {
+			return nil, errors.New("invalid offset")
+		}
+	}
+
+	switch s := r.FormValue("sort_by"); s {
+	case "":
+		// pages need a stable order
+		if opts.paginated() {
+			opts.sortBy = sortByMtime
+		}
+	case sortByMtime, sortByName:
+		opts.sortBy = s
+	default:
+		return nil, errors.New("invalid sort_by, must be mtime or name")
+	}
+
+	switch o := r.FormValue("sort_order"); o {
+	case "", "asc":
+	case "desc":
+		opts.desc = true
+	default:
+		return nil, errors.New("invalid sort_order, must be asc or desc")
+	}
+	return opts, nil
+}
+
+func (o *listOptions) paginated() bool {
+	return o.limit > 0 || o.offset > 0
+}
+
+// page returns the bounds of the requested page in a list of n elements.
+func (o *listOptions) page(n int) (int, int) {
+	start := o.offset
+	if start > n {
+		start = n
+	}
+	end := n
+	if o.limit > 0 && start+o.limit < n {
+		end = start + o.limit
+	}
+	return start, end
+}
+
+// sortReceivedShares sorts the received shares by their mtime. This happens
+// before stating the resources, so that only the requested page is stated.
+func sortReceivedShares(shares []*collaboration.ReceivedShare, desc bool) {
+	sort.SliceStable(shares, func(i, j int) bool {
+		a, b := shares[i].GetShare().GetMtime(), shares[j].GetShare().GetMtime()
+		if desc {
+			a, b = b, a
+		}
+		if a.GetSeconds() != b.GetSeconds() {
+			return a.GetSeconds() < b.GetSeconds()
+		}
+		return a.GetNanos() < b.GetNanos()
+	})
+}
+
+func sortShareData(shares []*conversions.ShareData, opts *listOptions) {
+	if opts.sortBy == "" {
+		return
+	}
+	sort.SliceStable(shares, func(i, j int) bool {
+		a, b := shares[i], shares[j]
+		if opts.desc {
+			a, b = b, a
+		}
+		if opts.sortBy == sortByName {
+			return strings.ToLower(path.Base(a.FileTarget)) < strings.ToLower(path.Base(b.FileTarget))
+		}
+		return a.STime < b.STime
+	})
+}
+
+// getStateFilters parses a comma separated list of states, eg. state=0,1.
+// A nil result means that all the states are listed.
+func getStateFilters(s string) []collaboration.ShareState {
+	var states []collaboration.ShareState
+	for _, f := range strings.Split(s, ",") {
+		state := getStateFilter(strings.TrimSpace(f))
+		if state == ocsStateUnknown {
+			return nil
+		}
+		states = append(states, state)
+	}
+	return states
+}
+
+func matchesStates(state collaboration.ShareState, states []collaboration.ShareState) bool {
+	if states == nil {
+		return true
+	}
+	for _, s := range states {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
 	collaboration "github.com/cs3org/go-cs3apis/cs3/sharing/collaboration/v1beta1"
@@ -38,7 +39,34 @@ import (
 	"github.com/pkg/errors"
 )
 
-func (h *Handler) createPublicLinkShare(w http.ResponseWriter, r *http.Request, statInfo *provider.ResourceInfo) {
+// publicLinkRoles are the roles that can be given to public links
+var publicLinkRoles = []string{conversions.RoleViewer, conversions.RoleUploader, conversions.RoleEditor}
+
+func isPublicLinkRole(name string) bool {
+	for _, r := range publicLinkRoles {
+		if r == name {
+			return true
+		}
+	}
+	return false
+}
+
+// parseSpaceRef parses the reference of the storage space a public link is
+// rooted in, in the form <storage id>!<space id>. The id of a space is the
+// one of its root.
+func parseSpaceRef(ref string) (*provider.ResourceId, error) {
+	parts := strings.SplitN(ref, "!", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return nil, errors.New("space_ref must be of the form <storage id>!<space id>")
+	}
+	return &provider.ResourceId{StorageId: parts[0], OpaqueId: parts[1]}, nil
+}
+
+// createPublicLinkShare creates a public link with the given role, which has
+// already been checked against the permissions of the user on the resource.
+// Without an explicit role the legacy permissions and publicUpload arguments
+// are used.
+func (h *Handler) createPublicLinkShare(w http.ResponseWriter, r *http.Request, statInfo *provider.ResourceInfo, role *conversions.Role) {
 	ctx := r.Context()
 	log := appctx.GetLogger(ctx)
 
@@ -54,8 +82,10 @@ func (h *Handler) createPublicLinkShare(w http.ResponseWriter, r *http.Request,
 		return
 	}
 
-	newPermissions, err := permissionFromRequest(r, h)
-	if err != nil {
+	var newPermissions *provider.ResourcePermissions
+	if r.FormValue("role") != "" {
+		newPermissions = role.CS3ResourcePermissions()
+	} else if newPermissions, err = permissionFromRequest(r, h); err != nil {
 		response.WriteOCSError(w, r, response.MetaBadRequest.StatusCode, "Could not read permission from request", err)
 		return
 	}
@@ -32,6 +32,7 @@ import (
 
 	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/conversions"
 	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/response"
+	"github.com/cs3org/reva/pkg/ocm/share"
 	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
 )
 
@@ -102,6 +103,25 @@ func (h *Handler) createFederatedCloudShare(w http.ResponseWriter, r *http.Reque
 		RecipientMeshProvider: providerInfoResp.ProviderInfo,
 	}
 
+	// the optional protection of the share, enforced by the recipient's site
+	if expireDate := r.FormValue("expireDate"); expireDate != "" {
+		expiration, err := conversions.ParseTimestamp(expireDate)
+		if err != nil {
+			response.WriteOCSError(w, r, response.MetaBadRequest.StatusCode, "invalid datetime format", err)
+			return
+		}
+		createShareReq.Opaque.Map[share.ExpirationOpaqueKey] = &types.OpaqueEntry{
+			Decoder: "plain",
+			Value:   []byte(strconv.FormatUint(expiration.Seconds, 10)),
+		}
+	}
+	if password := r.FormValue("password"); password != "" {
+		createShareReq.Opaque.Map[share.SharedSecretOpaqueKey] = &types.OpaqueEntry{
+			Decoder: "plain",
+			Value:   []byte(password),
+		}
+	}
+
 	createShareResponse, err := c.CreateOCMShare(ctx, createShareReq)
 	if err != nil {
 		response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "error sending a grpc create ocm share request", err)
@@ -42,16 +42,22 @@ import (
 	"github.com/rs/zerolog/log"
 
 	"github.com/ReneKroon/ttlcache/v2"
-	"github.com/bluele/gcache"
 	"github.com/cs3org/reva/internal/http/services/owncloud/ocdav"
 	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/config"
 	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/conversions"
 	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/response"
 	"github.com/cs3org/reva/pkg/appctx"
+	cachepkg "github.com/cs3org/reva/pkg/cache"
+	cacheregistry "github.com/cs3org/reva/pkg/cache/driver/registry"
+	"github.com/cs3org/reva/pkg/guest"
+	guestregistry "github.com/cs3org/reva/pkg/guest/manager/registry"
+	"github.com/cs3org/reva/pkg/permission"
+	permregistry "github.com/cs3org/reva/pkg/permission/manager/registry"
 	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
 	"github.com/cs3org/reva/pkg/rhttp/router"
 	"github.com/cs3org/reva/pkg/share/cache"
 	"github.com/cs3org/reva/pkg/share/cache/registry"
+	"github.com/cs3org/reva/pkg/smtpclient"
 	"github.com/pkg/errors"
 )
 
@@ -63,8 +69,12 @@ type Handler struct {
 	homeNamespace          string
 	additionalInfoTemplate *template.Template
 	userIdentifierCache    *ttlcache.Cache
-	resourceInfoCache      gcache.Cache
+	resourceInfoCache      *cachepkg.StatCache
 	resourceInfoCacheTTL   time.Duration
+	guestManager           guest.Manager
+	guestActivationURL     string
+	smtpCredentials        *smtpclient.SMTPCredentials
+	permissions            permission.Manager
 }
 
 // we only cache the minimal set of data instead of the full user metadata
@@ -81,14 +91,25 @@ func getCacheWarmupManager(c *config.Config) (cache.Warmup, error) {
 	return nil, fmt.Errorf("driver not found: %s", c.CacheWarmupDriver)
 }
 
+func getGuestManager(c *config.Config) (guest.Manager, error) {
+	if f, ok := guestregistry.NewFuncs[c.GuestManager]; ok {
+		return f(c.GuestManagers[c.GuestManager])
+	}
+	return nil, fmt.Errorf("driver not found: %s", c.GuestManager)
+}
+
 // Init initializes this and any contained handlers
 func (h *Handler) Init(c *config.Config) error {
 	h.gatewayAddr = c.GatewaySvc
 	h.publicURL = c.Config.Host
 	h.sharePrefix = c.SharePrefix
 	h.homeNamespace = c.HomeNamespace
-	h.resourceInfoCache = gcache.New(c.ResourceInfoCacheSize).LFU().Build()
 	h.resourceInfoCacheTTL = time.Second * time.Duration(c.ResourceInfoCacheTTL)
+	ric, err := cacheregistry.NewCache("resource_info", c.ResourceInfoCache)
+	if err != nil {
+		return err
+	}
+	h.resourceInfoCache = cachepkg.NewStatCache(ric, h.resourceInfoCacheTTL)
 
 	h.additionalInfoTemplate, _ = template.New("additionalInfo").Parse(c.AdditionalInfoAttribute)
 
@@ -102,6 +123,21 @@ func (h *Handler) Init(c *config.Config) error {
 		}
 	}
 
+	if c.GuestManager != "" {
+		gm, err := getGuestManager(c)
+		if err != nil {
+			return err
+		}
+		h.guestManager = gm
+		h.guestActivationURL = c.GuestActivationURL
+		if c.SMTPCredentials != nil {
+			h.smtpCredentials = smtpclient.NewSMTPCredentials(c.SMTPCredentials)
+		}
+		if h.permissions, err = permregistry.New(c.PermissionDriver, c.PermissionDrivers); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -112,7 +148,7 @@ func (h *Handler) startCacheWarmup(c cache.Warmup) {
 	}
 	for _, r := range infos {
 		key := wrapResourceID(r.Id)
-		_ = h.resourceInfoCache.SetWithExpire(key, r, time.Second*h.resourceInfoCacheTTL)
+		_ = h.resourceInfoCache.Set(context.Background(), key, r)
 	}
 }
 
@@ -224,7 +260,22 @@ func (h *Handler) createShare(w http.ResponseWriter, r *http.Request) {
 		},
 	}
 
-	sublog := appctx.GetLogger(ctx).With().Str("path", fn).Logger()
+	// public links can be rooted in a whole storage space instead
+	spaceRef := r.FormValue("space_ref")
+	if spaceRef != "" {
+		if shareType != int(conversions.ShareTypePublicLink) {
+			response.WriteOCSError(w, r, response.MetaBadRequest.StatusCode, "only public links can be rooted in a space", nil)
+			return
+		}
+		root, err := parseSpaceRef(spaceRef)
+		if err != nil {
+			response.WriteOCSError(w, r, response.MetaBadRequest.StatusCode, err.Error(), err)
+			return
+		}
+		statReq.Ref = &provider.Reference{Spec: &provider.Reference_Id{Id: root}}
+	}
+
+	sublog := appctx.GetLogger(ctx).With().Str("path", fn).Str("space_ref", spaceRef).Logger()
 
 	statRes, err := client.Stat(ctx, &statReq)
 	if err != nil {
@@ -256,15 +307,24 @@ func (h *Handler) createShare(w http.ResponseWriter, r *http.Request) {
 			h.createGroupShare(w, r, statRes.Info, role, val)
 		}
 	case int(conversions.ShareTypePublicLink):
+		if reqRole := r.FormValue("role"); reqRole != "" && !isPublicLinkRole(reqRole) {
+			response.WriteOCSError(w, r, response.MetaBadRequest.StatusCode, "public links can only be viewer, uploader or editor", nil)
+			return
+		}
 		// public links default to read only
-		if _, _, err := h.extractPermissions(w, r, statRes.Info, conversions.NewViewerRole()); err == nil {
-			h.createPublicLinkShare(w, r, statRes.Info)
+		if role, _, err := h.extractPermissions(w, r, statRes.Info, conversions.NewViewerRole()); err == nil {
+			h.createPublicLinkShare(w, r, statRes.Info, role)
 		}
 	case int(conversions.ShareTypeFederatedCloudShare):
 		// federated shares default to read only
 		if role, val, err := h.extractPermissions(w, r, statRes.Info, conversions.NewViewerRole()); err == nil {
 			h.createFederatedCloudShare(w, r, statRes.Info, role, val)
 		}
+	case int(conversions.ShareTypeEmail):
+		// shares with external guests default to read only
+		if role, val, err := h.extractPermissions(w, r, statRes.Info, conversions.NewViewerRole()); err == nil {
+			h.createGuestShare(w, r, statRes.Info, role, val)
+		}
 	default:
 		response.WriteOCSError(w, r, response.MetaBadRequest.StatusCode, "unknown share type", nil)
 	}
@@ -563,8 +623,14 @@ const (
 )
 
 func (h *Handler) listSharesWithMe(w http.ResponseWriter, r *http.Request) {
-	// which pending state to list
-	stateFilter := getStateFilter(r.FormValue("state"))
+	// which pending states to list
+	states := getStateFilters(r.FormValue("state"))
+
+	opts, err := parseListOptions(r)
+	if err != nil {
+		response.WriteOCSError(w, r, response.MetaBadRequest.StatusCode, err.Error(), err)
+		return
+	}
 
 	client, err := pool.GetGatewayServiceClient(h.gatewayAddr)
 	if err != nil {
@@ -615,21 +681,36 @@ func (h *Handler) listSharesWithMe(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	shares := make([]*conversions.ShareData, 0, len(lrsRes.GetShares()))
-
-	// TODO(refs) filter out "invalid" shares
+	received := make([]*collaboration.ReceivedShare, 0, len(lrsRes.GetShares()))
 	for _, rs := range lrsRes.GetShares() {
-		if stateFilter != ocsStateUnknown && rs.GetState() != stateFilter {
+		if !matchesStates(rs.GetState(), states) {
+			continue
+		}
+		// check if the shared resource matches the path resource
+		if pinfo != nil && (rs.Share.ResourceId.StorageId != pinfo.GetId().StorageId ||
+			rs.Share.ResourceId.OpaqueId != pinfo.GetId().OpaqueId) {
 			continue
 		}
+		received = append(received, rs)
+	}
+
+	// the names are only known after stating the resources, otherwise we
+	// only stat the shares of the requested page
+	pageBeforeStat := opts.sortBy != sortByName
+	if pageBeforeStat {
+		if opts.sortBy == sortByMtime {
+			sortReceivedShares(received, opts.desc)
+		}
+		start, end := opts.page(len(received))
+		received = received[start:end]
+	}
+
+	shares := make([]*conversions.ShareData, 0, len(received))
+
+	// TODO(refs) filter out "invalid" shares
+	for _, rs := range received {
 		var info *provider.ResourceInfo
 		if pinfo != nil {
-			// check if the shared resource matches the path resource
-			if rs.Share.ResourceId.StorageId != pinfo.GetId().StorageId ||
-				rs.Share.ResourceId.OpaqueId != pinfo.GetId().OpaqueId {
-				// try next share
-				continue
-			}
 			// we can reuse the stat info
 			info = pinfo
 		} else {
@@ -664,12 +745,24 @@ func (h *Handler) listSharesWithMe(w http.ResponseWriter, r *http.Request) {
 		shares = append(shares, data)
 	}
 
+	if !pageBeforeStat {
+		sortShareData(shares, opts)
+		start, end := opts.page(len(shares))
+		shares = shares[start:end]
+	}
+
 	response.WriteOCSSuccess(w, r, shares)
 }
 
 func (h *Handler) listSharesWithOthers(w http.ResponseWriter, r *http.Request) {
 	shares := make([]*conversions.ShareData, 0)
 
+	opts, err := parseListOptions(r)
+	if err != nil {
+		response.WriteOCSError(w, r, response.MetaBadRequest.StatusCode, err.Error(), err)
+		return
+	}
+
 	filters := []*collaboration.ListSharesRequest_Filter{}
 	linkFilters := []*link.ListPublicSharesRequest_Filter{}
 	var e error
@@ -704,6 +797,10 @@ func (h *Handler) listSharesWithOthers(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	sortShareData(shares, opts)
+	start, end := opts.page(len(shares))
+	shares = shares[start:end]
+
 	response.WriteOCSSuccess(w, r, shares)
 }
 
@@ -964,9 +1061,9 @@ func (h *Handler) getResourceInfo(ctx context.Context, client gateway.GatewayAPI
 
 	var pinfo *provider.ResourceInfo
 	var status *rpc.Status
-	if infoIf, err := h.resourceInfoCache.Get(key); h.resourceInfoCacheTTL > 0 && err == nil {
+	if info, ok := h.resourceInfoCache.Get(ctx, key); h.resourceInfoCacheTTL > 0 && ok {
 		logger.Debug().Msgf("cache hit for resource %+v", key)
-		pinfo = infoIf.(*provider.ResourceInfo)
+		pinfo = info
 		status = &rpc.Status{Code: rpc.Code_CODE_OK}
 	} else {
 		statReq := &provider.StatRequest{
@@ -985,40 +1082,43 @@ func (h *Handler) getResourceInfo(ctx context.Context, client gateway.GatewayAPI
 		pinfo = statRes.GetInfo()
 		status = statRes.Status
 		if h.resourceInfoCacheTTL > 0 {
-			_ = h.resourceInfoCache.SetWithExpire(key, pinfo, h.resourceInfoCacheTTL)
+			_ = h.resourceInfoCache.Set(ctx, key, pinfo)
 		}
 	}
 
 	return pinfo, status, nil
 }
 
-func (h *Handler) createCs3Share(ctx context.Context, w http.ResponseWriter, r *http.Request, client gateway.GatewayAPIClient, req *collaboration.CreateShareRequest, info *provider.ResourceInfo) {
+// createCs3Share creates the share and writes the OCS response. It returns the
+// created share, or nil if the creation failed.
+func (h *Handler) createCs3Share(ctx context.Context, w http.ResponseWriter, r *http.Request, client gateway.GatewayAPIClient, req *collaboration.CreateShareRequest, info *provider.ResourceInfo) *collaboration.Share {
 	createShareResponse, err := client.CreateShare(ctx, req)
 	if err != nil {
 		response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "error sending a grpc create share request", err)
-		return
+		return nil
 	}
 	if createShareResponse.Status.Code != rpc.Code_CODE_OK {
 		if createShareResponse.Status.Code == rpc.Code_CODE_NOT_FOUND {
 			response.WriteOCSError(w, r, response.MetaNotFound.StatusCode, "not found", nil)
-			return
+			return nil
 		}
 		response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "grpc create share request failed", err)
-		return
+		return nil
 	}
 	s, err := conversions.CS3Share2ShareData(ctx, createShareResponse.Share)
 	if err != nil {
 		response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "error mapping share data", err)
-		return
+		return nil
 	}
 	err = h.addFileInfo(ctx, s, info)
 	if err != nil {
 		response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "error adding fileinfo to share", err)
-		return
+		return nil
 	}
 	h.mapUserIds(ctx, client, s)
 
 	response.WriteOCSSuccess(w, r, s)
+	return createShareResponse.Share
 }
 
 func mapState(state collaboration.ShareState) int {
@@ -70,3 +70,72 @@ func TestMapState(t *testing.T) {
 		}
 	}
 }
+
+func TestGetStateFilters(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []collaboration.ShareState
+	}{
+		{"all", nil},
+		{"0,all", nil},
+		{"", []collaboration.ShareState{collaboration.ShareState_SHARE_STATE_ACCEPTED}},
+		{"0, 1", []collaboration.ShareState{collaboration.ShareState_SHARE_STATE_ACCEPTED, collaboration.ShareState_SHARE_STATE_PENDING}},
+	}
+
+	for _, tt := range tests {
+		states := getStateFilters(tt.input)
+		if len(states) != len(tt.expected) {
+			t.Fatalf("getStateFilters(\"%s\") returned %v instead of expected %v", tt.input, states, tt.expected)
+		}
+		for i := range states {
+			if states[i] != tt.expected[i] {
+				t.Errorf("getStateFilters(\"%s\") returned %v instead of expected %v", tt.input, states, tt.expected)
+			}
+		}
+	}
+}
+
+func TestListOptionsPage(t *testing.T) {
+	tests := []struct {
+		opts       listOptions
+		n          int
+		start, end int
+	}{
+		{listOptions{}, 5, 0, 5},
+		{listOptions{limit: 2}, 5, 0, 2},
+		{listOptions{limit: 2, offset: 4}, 5, 4, 5},
+		{listOptions{offset: 7}, 5, 5, 5},
+	}
+
+	for _, tt := range tests {
+		start, end := tt.opts.page(tt.n)
+		if start != tt.start || end != tt.end {
+			t.Errorf("page(%d) with %+v returned [%d:%d] instead of expected [%d:%d]", tt.n, tt.opts, start, end, tt.start, tt.end)
+		}
+	}
+}
+
+func TestParseSpaceRef(t *testing.T) {
+	tests := []struct {
+		input     string
+		storageID string
+		opaqueID  string
+		err       bool
+	}{
+		{"storage!space", "storage", "space", false},
+		{"storage!space!with!bangs", "storage", "space!with!bangs", false},
+		{"storage", "", "", true},
+		{"!space", "", "", true},
+		{"storage!", "", "", true},
+	}
+
+	for _, tt := range tests {
+		id, err := parseSpaceRef(tt.input)
+		if (err != nil) != tt.err {
+			t.Fatalf("parseSpaceRef(\"%s\") returned error %v", tt.input, err)
+		}
+		if err == nil && (id.StorageId != tt.storageID || id.OpaqueId != tt.opaqueID) {
+			t.Errorf("parseSpaceRef(\"%s\") returned %v instead of expected %s!%s", tt.input, id, tt.storageID, tt.opaqueID)
+		}
+	}
+}
@@ -0,0 +1,307 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package systemtags
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/config"
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/response"
+	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
+	"github.com/cs3org/reva/pkg/rhttp/router"
+	"github.com/cs3org/reva/pkg/tags"
+	"github.com/cs3org/reva/pkg/utils"
+)
+
+// Handler serves the tags of the resources, mirroring the systemtags of
+// ownCloud. The tags are user-defined and identified by their names.
+type Handler struct {
+	gatewayAddr string
+}
+
+// TagData is the representation of a tag in the API
+type TagData struct {
+	ID             string `json:"id" xml:"id"`
+	Name           string `json:"name" xml:"name"`
+	UserVisible    bool   `json:"user-visible" xml:"user-visible"`
+	UserAssignable bool   `json:"user-assignable" xml:"user-assignable"`
+}
+
+// FileData is the representation of a tagged resource in the API
+type FileData struct {
+	FileID   string `json:"file_id" xml:"file_id"`
+	Path     string `json:"path" xml:"path"`
+	Name     string `json:"name" xml:"name"`
+	MimeType string `json:"mimetype" xml:"mimetype"`
+	Size     uint64 `json:"size" xml:"size"`
+	Mtime    string `json:"mtime" xml:"mtime"`
+}
+
+// Init initializes this and any contained handlers
+func (h *Handler) Init(c *config.Config) error {
+	h.gatewayAddr = c.GatewaySvc
+	return nil
+}
+
+// ServeHTTP serves the tags of a resource under files/<fileid>, which are
+// added with PUT and removed with DELETE on files/<fileid>/<tag>, and the
+// resources with a tag under tags/<tag>/files. The latter are looked up in the
+// subtree given by the path or space_id parameter, the home of the user by
+// default.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var head, id, tag string
+	head, r.URL.Path = router.ShiftPath(r.URL.Path)
+	switch head {
+	case "files":
+		id, r.URL.Path = router.ShiftPath(r.URL.Path)
+		tag, _ = router.ShiftPath(r.URL.Path)
+		rid := unwrap(id)
+		if rid == nil {
+			response.WriteOCSError(w, r, response.MetaBadRequest.StatusCode, "invalid file id", nil)
+			return
+		}
+		ref := &provider.Reference{Spec: &provider.Reference_Id{Id: rid}}
+		switch {
+		case r.Method == http.MethodGet && tag == "":
+			h.listTags(w, r, ref)
+		case r.Method == http.MethodPut && tag != "":
+			h.updateTags(w, r, ref, tag, tags.Add)
+		case r.Method == http.MethodDelete && tag != "":
+			h.updateTags(w, r, ref, tag, tags.Remove)
+		default:
+			response.WriteOCSError(w, r, response.MetaNotFound.StatusCode, "Not found", nil)
+		}
+	case "tags":
+		tag, r.URL.Path = router.ShiftPath(r.URL.Path)
+		head, _ = router.ShiftPath(r.URL.Path)
+		if tag == "" || head != "files" || r.Method != http.MethodGet {
+			response.WriteOCSError(w, r, response.MetaNotFound.StatusCode, "Not found", nil)
+			return
+		}
+		h.listFiles(w, r, tag)
+	default:
+		response.WriteOCSError(w, r, response.MetaNotFound.StatusCode, "Not found", nil)
+	}
+}
+
+func (h *Handler) listTags(w http.ResponseWriter, r *http.Request, ref *provider.Reference) {
+	ctx := r.Context()
+	client, err := pool.GetGatewayServiceClient(h.gatewayAddr)
+	if err != nil {
+		response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "error getting gateway client", err)
+		return
+	}
+	v, st, err := getTags(ctx, client, ref)
+	if err != nil {
+		response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "error sending a grpc stat request", err)
+		return
+	}
+	if st.Code != rpc.Code_CODE_OK {
+		writeStatusError(w, r, st)
+		return
+	}
+
+	data := []*TagData{}
+	for _, t := range tags.Parse(v) {
+		data = append(data, newTagData(t))
+	}
+	response.WriteOCSSuccess(w, r, data)
+}
+
+func (h *Handler) updateTags(w http.ResponseWriter, r *http.Request, ref *provider.Reference, tag string, update func(string, string) (string, bool)) {
+	if err := tags.Validate(tag); err != nil {
+		response.WriteOCSError(w, r, response.MetaBadRequest.StatusCode, err.Error(), nil)
+		return
+	}
+
+	ctx := r.Context()
+	client, err := pool.GetGatewayServiceClient(h.gatewayAddr)
+	if err != nil {
+		response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "error getting gateway client", err)
+		return
+	}
+	v, st, err := getTags(ctx, client, ref)
+	if err != nil {
+		response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "error sending a grpc stat request", err)
+		return
+	}
+	if st.Code != rpc.Code_CODE_OK {
+		writeStatusError(w, r, st)
+		return
+	}
+
+	v, changed := update(v, tag)
+	if !changed {
+		response.WriteOCSSuccess(w, r, newTagData(tag))
+		return
+	}
+
+	if v == "" {
+		res, err := client.UnsetArbitraryMetadata(ctx, &provider.UnsetArbitraryMetadataRequest{
+			Ref:                   ref,
+			ArbitraryMetadataKeys: []string{tags.MetadataKey},
+		})
+		if err != nil {
+			response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "error sending a grpc unset metadata request", err)
+			return
+		}
+		st = res.Status
+	} else {
+		res, err := client.SetArbitraryMetadata(ctx, &provider.SetArbitraryMetadataRequest{
+			Ref: ref,
+			ArbitraryMetadata: &provider.ArbitraryMetadata{
+				Metadata: map[string]string{tags.MetadataKey: v},
+			},
+		})
+		if err != nil {
+			response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "error sending a grpc set metadata request", err)
+			return
+		}
+		st = res.Status
+	}
+	if st.Code != rpc.Code_CODE_OK {
+		writeStatusError(w, r, st)
+		return
+	}
+	response.WriteOCSSuccess(w, r, newTagData(tag))
+}
+
+func (h *Handler) listFiles(w http.ResponseWriter, r *http.Request, tag string) {
+	ctx := r.Context()
+	client, err := pool.GetGatewayServiceClient(h.gatewayAddr)
+	if err != nil {
+		response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "error getting gateway client", err)
+		return
+	}
+
+	q := r.URL.Query()
+	var ref *provider.Reference
+	switch {
+	case q.Get("space_id") != "":
+		rid := unwrap(q.Get("space_id"))
+		if rid == nil {
+			response.WriteOCSError(w, r, response.MetaBadRequest.StatusCode, "invalid space id", nil)
+			return
+		}
+		ref = &provider.Reference{Spec: &provider.Reference_Id{Id: rid}}
+	case q.Get("path") != "":
+		ref = &provider.Reference{Spec: &provider.Reference_Path{Path: path.Clean("/" + q.Get("path"))}}
+	default:
+		res, err := client.GetHome(ctx, &provider.GetHomeRequest{})
+		if err != nil {
+			response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "error sending a grpc get home request", err)
+			return
+		}
+		if res.Status.Code != rpc.Code_CODE_OK {
+			writeStatusError(w, r, res.Status)
+			return
+		}
+		ref = &provider.Reference{Spec: &provider.Reference_Path{Path: res.Path}}
+	}
+
+	res, err := client.ListContainer(ctx, &provider.ListContainerRequest{
+		Ref: ref,
+		Opaque: &types.Opaque{
+			Map: map[string]*types.OpaqueEntry{
+				tags.ListOpaqueKey: {Decoder: "plain", Value: []byte(tag)},
+			},
+		},
+	})
+	if err != nil {
+		response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "error sending a grpc list container request", err)
+		return
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		writeStatusError(w, r, res.Status)
+		return
+	}
+
+	data := make([]*FileData, 0, len(res.Infos))
+	for _, ri := range res.Infos {
+		fd := &FileData{
+			FileID:   wrap(ri.Id),
+			Path:     ri.Path,
+			Name:     path.Base(ri.Path),
+			MimeType: ri.MimeType,
+			Size:     ri.Size,
+		}
+		if ri.Mtime != nil {
+			fd.Mtime = utils.TSToTime(ri.Mtime).UTC().Format(time.RFC3339)
+		}
+		data = append(data, fd)
+	}
+	response.WriteOCSSuccess(w, r, data)
+}
+
+// getTags returns the metadata value of the tags of the resource.
+func getTags(ctx context.Context, client gateway.GatewayAPIClient, ref *provider.Reference) (string, *rpc.Status, error) {
+	res, err := client.Stat(ctx, &provider.StatRequest{
+		Ref:                   ref,
+		ArbitraryMetadataKeys: []string{tags.MetadataKey},
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return "", res.Status, nil
+	}
+	return res.Info.GetArbitraryMetadata().GetMetadata()[tags.MetadataKey], res.Status, nil
+}
+
+func writeStatusError(w http.ResponseWriter, r *http.Request, st *rpc.Status) {
+	switch st.Code {
+	case rpc.Code_CODE_NOT_FOUND, rpc.Code_CODE_PERMISSION_DENIED:
+		// do not disclose the existence of the resources the user cannot access
+		response.WriteOCSError(w, r, response.MetaNotFound.StatusCode, "not found", nil)
+	case rpc.Code_CODE_INVALID_ARGUMENT:
+		response.WriteOCSError(w, r, response.MetaBadRequest.StatusCode, st.Message, nil)
+	default:
+		response.WriteOCSError(w, r, response.MetaServerError.StatusCode, st.Message, nil)
+	}
+}
+
+func newTagData(tag string) *TagData {
+	return &TagData{ID: tag, Name: tag, UserVisible: true, UserAssignable: true}
+}
+
+func wrap(r *provider.ResourceId) string {
+	return base64.URLEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", r.StorageId, r.OpaqueId)))
+}
+
+func unwrap(rid string) *provider.ResourceId {
+	decoded, err := base64.URLEncoding.DecodeString(rid)
+	if err != nil {
+		return nil
+	}
+	parts := strings.SplitN(string(decoded), ":", 2)
+	if len(parts) != 2 {
+		return nil
+	}
+	return &provider.ResourceId{StorageId: parts[0], OpaqueId: parts[1]}
+}
@@ -20,10 +20,15 @@ package capabilities
 
 import (
 	"net/http"
+	"time"
 
+	"github.com/ReneKroon/ttlcache/v2"
 	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/config"
 	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/data"
 	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/response"
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/tenant"
+	ctxpkg "github.com/cs3org/reva/pkg/user"
 )
 
 // Handler renders the capability endpoint
@@ -31,6 +36,11 @@ type Handler struct {
 	c                     data.CapabilitiesData
 	defaultUploadProtocol string
 	userAgentChunkingMap  map[string]string
+	gatewayAddr           string
+	probeEnabled          bool
+	featureCache          *ttlcache.Cache
+	// tenants holds the handlers of the tenants with their own capabilities
+	tenants map[string]*Handler
 }
 
 // Init initializes this and any contained handlers
@@ -38,6 +48,21 @@ func (h *Handler) Init(c *config.Config) {
 	h.c = c.Capabilities
 	h.defaultUploadProtocol = c.DefaultUploadProtocol
 	h.userAgentChunkingMap = c.UserAgentChunkingMap
+	h.gatewayAddr = c.GatewaySvc
+	h.tenants = map[string]*Handler{}
+	for id, caps := range c.TenantCapabilities {
+		tc := *c
+		tc.Capabilities = caps
+		tc.TenantCapabilities = nil
+		th := &Handler{}
+		th.Init(&tc)
+		h.tenants[id] = th
+	}
+	h.probeEnabled = c.CapabilitiesProbeTTL > 0
+	if h.probeEnabled {
+		h.featureCache = ttlcache.NewCache()
+		_ = h.featureCache.SetTTL(time.Duration(c.CapabilitiesProbeTTL) * time.Second)
+	}
 
 	// capabilities
 	if h.c.Capabilities == nil {
@@ -72,6 +97,9 @@ func (h *Handler) Init(c *config.Config) {
 	if h.c.Capabilities.Core.Status.Edition == "" {
 		h.c.Capabilities.Core.Status.Edition = "community" // TODO make build determined
 	}
+	if h.c.Capabilities.Core.Status.ProductName == "" && h.c.Capabilities.Theming != nil {
+		h.c.Capabilities.Core.Status.ProductName = h.c.Capabilities.Theming.Name
+	}
 	if h.c.Capabilities.Core.Status.ProductName == "" {
 		h.c.Capabilities.Core.Status.ProductName = "reva" // TODO make build determined
 	}
@@ -216,10 +244,29 @@ func (h *Handler) Init(c *config.Config) {
 
 }
 
-// Handler renders the capabilities
+// Handler renders the capabilities. The file capabilities of the
+// authenticated users are adjusted to what their home storage and the
+// services of the deployment actually support, unless the probing is
+// disabled.
 func (h *Handler) Handler() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if t, ok := tenant.ContextGetTenant(r.Context()); ok {
+			if th, ok := h.tenants[t.ID]; ok {
+				th.Handler().ServeHTTP(w, r)
+				return
+			}
+		}
 		c := h.getCapabilitiesForUserAgent(r.UserAgent())
+		if u, ok := ctxpkg.ContextGetUser(r.Context()); ok && h.probeEnabled && c.Capabilities.Files != nil {
+			f, err := h.getFeatures(r.Context(), u)
+			if err != nil {
+				// better the configured capabilities than none
+				appctx.GetLogger(r.Context()).Error().Err(err).Msg("error probing capabilities")
+			} else {
+				c = clone(c)
+				applyFeatures(&c, f)
+			}
+		}
 		response.WriteOCSSuccess(w, r, c)
 	})
 }
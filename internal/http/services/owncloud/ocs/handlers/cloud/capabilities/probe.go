@@ -0,0 +1,165 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package capabilities
+
+import (
+	"context"
+
+	appregistry "github.com/cs3org/go-cs3apis/cs3/app/registry/v1beta1"
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/data"
+	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
+	"github.com/pkg/errors"
+)
+
+// features are the capabilities of the storage of a user and of the
+// services of the deployment, which the advertised ones are derived from.
+type features struct {
+	Tus        bool
+	Trashbin   bool
+	Versioning bool
+	Apps       bool
+}
+
+// getFeatures returns the features available to the user, probed at most
+// once per probe TTL.
+func (h *Handler) getFeatures(ctx context.Context, u *userpb.User) (*features, error) {
+	key := u.Id.GetIdp() + "!" + u.Id.GetOpaqueId()
+	if f, err := h.featureCache.Get(key); err == nil {
+		return f.(*features), nil
+	}
+	f, err := h.probe(ctx)
+	if err != nil {
+		return nil, err
+	}
+	_ = h.featureCache.Set(key, f)
+	return f, nil
+}
+
+// probe asks the home storage of the user what it supports. Only the calls
+// reported as not implemented disable a feature, the other failures are
+// taken as the feature being available but failing for another reason.
+func (h *Handler) probe(ctx context.Context) (*features, error) {
+	client, err := pool.GetGatewayServiceClient(h.gatewayAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	homeRes, err := client.GetHome(ctx, &provider.GetHomeRequest{})
+	if err != nil {
+		return nil, err
+	}
+	if homeRes.Status.Code != rpc.Code_CODE_OK {
+		return nil, errors.New("capabilities: error getting home: " + homeRes.Status.Message)
+	}
+	home := &provider.Reference{Spec: &provider.Reference_Path{Path: homeRes.Path}}
+
+	statRes, err := client.Stat(ctx, &provider.StatRequest{Ref: home})
+	if err != nil {
+		return nil, err
+	}
+	if statRes.Status.Code != rpc.Code_CODE_OK {
+		return nil, errors.New("capabilities: error statting home: " + statRes.Status.Message)
+	}
+
+	f := &features{Tus: true}
+	// the storage providers not supporting tus flag the folders, as
+	// advertised to the clients by the PROPFIND responses
+	if statRes.Info.Opaque != nil {
+		if _, ok := statRes.Info.Opaque.Map["disable_tus"]; ok {
+			f.Tus = false
+		}
+	}
+
+	recycleRes, err := client.ListRecycle(ctx, &gateway.ListRecycleRequest{
+		Ref: home,
+		Opaque: &types.Opaque{Map: map[string]*types.OpaqueEntry{
+			"page_size": {Decoder: "plain", Value: []byte("1")},
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	f.Trashbin = recycleRes.Status.Code != rpc.Code_CODE_UNIMPLEMENTED
+
+	versionsRes, err := client.ListFileVersions(ctx, &provider.ListFileVersionsRequest{Ref: home})
+	if err != nil {
+		return nil, err
+	}
+	f.Versioning = versionsRes.Status.Code != rpc.Code_CODE_UNIMPLEMENTED
+
+	appsRes, err := client.ListAppProviders(ctx, &appregistry.ListAppProvidersRequest{})
+	if err != nil {
+		return nil, err
+	}
+	f.Apps = appsRes.Status.Code == rpc.Code_CODE_OK && len(appsRes.Providers) > 0
+
+	return f, nil
+}
+
+// applyFeatures adjusts the capabilities to the features, the ones missing
+// being neither advertised nor configurable.
+func applyFeatures(c *data.CapabilitiesData, f *features) {
+	files := c.Capabilities.Files
+	if !f.Tus {
+		files.TusSupport = nil
+	}
+
+	files.Undelete = false
+	if f.Trashbin {
+		files.Undelete = true
+	}
+	if c.Capabilities.Dav != nil && !f.Trashbin {
+		c.Capabilities.Dav.Trashbin = ""
+	}
+
+	files.Versioning = false
+	if f.Versioning {
+		files.Versioning = true
+	}
+
+	files.AppProviders = false
+	if f.Apps {
+		files.AppProviders = true
+	}
+}
+
+// clone copies the capabilities modified per request, so that the ones
+// shared by the requests are left untouched.
+func clone(c data.CapabilitiesData) data.CapabilitiesData {
+	caps := *c.Capabilities
+	c.Capabilities = &caps
+	if caps.Files != nil {
+		files := *caps.Files
+		caps.Files = &files
+		if files.TusSupport != nil {
+			tus := *files.TusSupport
+			files.TusSupport = &tus
+		}
+	}
+	if caps.Dav != nil {
+		dav := *caps.Dav
+		caps.Dav = &dav
+	}
+	return c
+}
@@ -38,7 +38,7 @@ func (h *Handler) getCapabilitiesForUserAgent(userAgent string) data.Capabilitie
 			// we could also use a regexp for pattern matching
 			if strings.Contains(userAgent, k) {
 				// Creating a copy of the capabilities struct is less expensive than taking a lock
-				c := h.c
+				c := clone(h.c)
 				setCapabilitiesForChunkProtocol(chunkProtocol(v), &c)
 				return c
 			}
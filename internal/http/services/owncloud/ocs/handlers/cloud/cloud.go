@@ -23,6 +23,7 @@ import (
 
 	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/config"
 	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/handlers/cloud/capabilities"
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/handlers/cloud/provisioning"
 	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/handlers/cloud/user"
 	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/handlers/cloud/users"
 	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/response"
@@ -34,6 +35,8 @@ type Handler struct {
 	UserHandler         *user.Handler
 	UsersHandler        *users.Handler
 	CapabilitiesHandler *capabilities.Handler
+	// ProvisioningHandler is nil unless the provisioning API is configured
+	ProvisioningHandler *provisioning.Handler
 }
 
 // Init initializes this and any contained handlers
@@ -41,6 +44,12 @@ func (h *Handler) Init(c *config.Config) error {
 	h.UserHandler = new(user.Handler)
 	h.CapabilitiesHandler = new(capabilities.Handler)
 	h.CapabilitiesHandler.Init(c)
+	if len(c.Provisioning) > 0 {
+		h.ProvisioningHandler = new(provisioning.Handler)
+		if err := h.ProvisioningHandler.Init(c); err != nil {
+			return err
+		}
+	}
 	h.UsersHandler = new(users.Handler)
 	return h.UsersHandler.Init(c)
 }
@@ -56,7 +65,17 @@ func (h *Handler) Handler() http.Handler {
 		case "user":
 			h.UserHandler.ServeHTTP(w, r)
 		case "users":
+			if h.ProvisioningHandler != nil && h.ProvisioningHandler.HandlesUsers(r) {
+				h.ProvisioningHandler.HandleUsers(w, r)
+				return
+			}
 			h.UsersHandler.ServeHTTP(w, r)
+		case "groups":
+			if h.ProvisioningHandler == nil {
+				response.WriteOCSError(w, r, response.MetaNotFound.StatusCode, "Not found", nil)
+				return
+			}
+			h.ProvisioningHandler.HandleGroups(w, r)
 		default:
 			response.WriteOCSError(w, r, response.MetaNotFound.StatusCode, "Not found", nil)
 		}
@@ -0,0 +1,488 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package provisioning
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	grouppb "github.com/cs3org/go-cs3apis/cs3/identity/group/v1beta1"
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/config"
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/response"
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/group"
+	groupregistry "github.com/cs3org/reva/pkg/group/manager/registry"
+	"github.com/cs3org/reva/pkg/permission"
+	permregistry "github.com/cs3org/reva/pkg/permission/manager/registry"
+	"github.com/cs3org/reva/pkg/rhttp/router"
+	"github.com/cs3org/reva/pkg/user"
+	userregistry "github.com/cs3org/reva/pkg/user/manager/registry"
+	"github.com/google/uuid"
+	"github.com/mitchellh/mapstructure"
+)
+
+// ocsStatusExists is the status code of the provisioning API when the user
+// or the group to create already exists.
+const ocsStatusExists = 102
+
+type provisioningConfig struct {
+	// Idp is the identity provider of the provisioned users and groups.
+	Idp          string                            `mapstructure:"idp"`
+	UserDriver   string                            `mapstructure:"user_driver"`
+	UserDrivers  map[string]map[string]interface{} `mapstructure:"user_drivers"`
+	GroupDriver  string                            `mapstructure:"group_driver"`
+	GroupDrivers map[string]map[string]interface{} `mapstructure:"group_drivers"`
+	// PermissionDriver, if set, is used to check whether the users hold the
+	// system.admin capability. Otherwise the Admins and AdminGroups are used.
+	PermissionDriver  string                            `mapstructure:"permission_driver"`
+	PermissionDrivers map[string]map[string]interface{} `mapstructure:"permission_drivers"`
+	Admins            []string                          `mapstructure:"admins"`
+	AdminGroups       []string                          `mapstructure:"admin_groups"`
+}
+
+func (c *provisioningConfig) init() {
+	if c.UserDriver == "" {
+		c.UserDriver = "json"
+	}
+	if c.GroupDriver == "" {
+		c.GroupDriver = "json"
+	}
+}
+
+// Handler implements the subset of the ownCloud provisioning API used by the
+// admin tooling on top of writable user and group managers:
+//
+//	GET    /cloud/users?search=<query>
+//	POST   /cloud/users                    userid, email, displayname, groups[]
+//	PUT    /cloud/users/<userid>           key=email|displayname|quota, value
+//	PUT    /cloud/users/<userid>/disable
+//	GET    /cloud/users/<userid>/groups
+//	POST   /cloud/users/<userid>/groups    groupid
+//	DELETE /cloud/users/<userid>/groups    groupid
+//	GET    /cloud/groups?search=<query>
+//	POST   /cloud/groups                   groupid
+//	GET    /cloud/groups/<groupid>
+//	DELETE /cloud/groups/<groupid>
+//
+// The passwords are not part of the users, they are managed by the
+// authentication providers.
+type Handler struct {
+	c      *provisioningConfig
+	users  user.ProvisioningManager
+	groups group.ProvisioningManager
+	pm     permission.Manager
+}
+
+// Init initializes this and any contained handlers
+func (h *Handler) Init(c *config.Config) error {
+	h.c = &provisioningConfig{}
+	if err := mapstructure.Decode(c.Provisioning, h.c); err != nil {
+		return err
+	}
+	h.c.init()
+
+	f, ok := userregistry.NewFuncs[h.c.UserDriver]
+	if !ok {
+		return errtypes.NotFound(fmt.Sprintf("driver %s not found for user manager", h.c.UserDriver))
+	}
+	um, err := f(h.c.UserDrivers[h.c.UserDriver])
+	if err != nil {
+		return err
+	}
+	if h.users, ok = um.(user.ProvisioningManager); !ok {
+		return errtypes.NotSupported(fmt.Sprintf("ocs: user manager %s does not support provisioning", h.c.UserDriver))
+	}
+
+	g, ok := groupregistry.NewFuncs[h.c.GroupDriver]
+	if !ok {
+		return errtypes.NotFound(fmt.Sprintf("driver %s not found for group manager", h.c.GroupDriver))
+	}
+	gm, err := g(h.c.GroupDrivers[h.c.GroupDriver])
+	if err != nil {
+		return err
+	}
+	if h.groups, ok = gm.(group.ProvisioningManager); !ok {
+		return errtypes.NotSupported(fmt.Sprintf("ocs: group manager %s does not support provisioning", h.c.GroupDriver))
+	}
+
+	if h.c.PermissionDriver != "" {
+		p, ok := permregistry.NewFuncs[h.c.PermissionDriver]
+		if !ok {
+			return errtypes.NotFound("ocs: permission driver not found: " + h.c.PermissionDriver)
+		}
+		if h.pm, err = p(h.c.PermissionDrivers[h.c.PermissionDriver]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HandlesUsers tells whether a request to /cloud/users is part of the
+// provisioning API. The user info of /cloud/users/<userid> is not.
+func (h *Handler) HandlesUsers(r *http.Request) bool {
+	userid, tail := router.ShiftPath(r.URL.Path)
+	return !(r.Method == http.MethodGet && userid != "" && tail == "/")
+}
+
+// HandleUsers serves the /cloud/users endpoints.
+func (h *Handler) HandleUsers(w http.ResponseWriter, r *http.Request) {
+	var userid, head string
+	userid, r.URL.Path = router.ShiftPath(r.URL.Path)
+	head, _ = router.ShiftPath(r.URL.Path)
+
+	// the users can list their own groups
+	if !h.isAdmin(r) && !(head == "groups" && r.Method == http.MethodGet && h.isSelf(r, userid)) {
+		response.WriteOCSError(w, r, response.MetaUnauthorized.StatusCode, "not allowed to provision users", nil)
+		return
+	}
+
+	switch {
+	case userid == "" && r.Method == http.MethodGet:
+		h.listUsers(w, r)
+	case userid == "" && r.Method == http.MethodPost:
+		h.createUser(w, r)
+	case userid != "" && head == "" && r.Method == http.MethodPut:
+		h.editUser(w, r, userid)
+	case userid != "" && head == "disable" && r.Method == http.MethodPut:
+		h.disableUser(w, r, userid)
+	case userid != "" && head == "groups" && r.Method == http.MethodGet:
+		h.listUserGroups(w, r, userid)
+	case userid != "" && head == "groups" && (r.Method == http.MethodPost || r.Method == http.MethodDelete):
+		h.editUserGroups(w, r, userid)
+	default:
+		response.WriteOCSError(w, r, response.MetaNotFound.StatusCode, "Not found", nil)
+	}
+}
+
+// HandleGroups serves the /cloud/groups endpoints.
+func (h *Handler) HandleGroups(w http.ResponseWriter, r *http.Request) {
+	if !h.isAdmin(r) {
+		response.WriteOCSError(w, r, response.MetaUnauthorized.StatusCode, "not allowed to provision groups", nil)
+		return
+	}
+
+	groupid, _ := router.ShiftPath(r.URL.Path)
+	switch {
+	case groupid == "" && r.Method == http.MethodGet:
+		h.listGroups(w, r)
+	case groupid == "" && r.Method == http.MethodPost:
+		h.createGroup(w, r)
+	case groupid != "" && r.Method == http.MethodGet:
+		h.listGroupMembers(w, r, groupid)
+	case groupid != "" && r.Method == http.MethodDelete:
+		h.deleteGroup(w, r, groupid)
+	default:
+		response.WriteOCSError(w, r, response.MetaNotFound.StatusCode, "Not found", nil)
+	}
+}
+
+func (h *Handler) isSelf(r *http.Request, userid string) bool {
+	u, ok := user.ContextGetUser(r.Context())
+	return ok && u.Username == userid
+}
+
+func (h *Handler) isAdmin(r *http.Request) bool {
+	ctx := r.Context()
+	u, ok := user.ContextGetUser(ctx)
+	if !ok {
+		return false
+	}
+	allowed, err := permission.IsAdmin(ctx, h.pm, u, h.c.Admins, h.c.AdminGroups)
+	if err != nil {
+		appctx.GetLogger(ctx).Error().Err(err).Msg("ocs: error checking permission")
+	}
+	return allowed
+}
+
+// Users holds the user ids of a listing
+type Users struct {
+	Users []string `json:"users" xml:"users>element"`
+}
+
+// Groups holds the group ids of a listing
+type Groups struct {
+	Groups []string `json:"groups" xml:"groups>element"`
+}
+
+func (h *Handler) listUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := h.users.FindUsers(r.Context(), r.FormValue("search"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	res := &Users{Users: make([]string, 0, len(users))}
+	for _, u := range users {
+		res.Users = append(res.Users, u.Username)
+	}
+	response.WriteOCSSuccess(w, r, res)
+}
+
+func (h *Handler) createUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if err := r.ParseForm(); err != nil {
+		response.WriteOCSError(w, r, response.MetaBadRequest.StatusCode, "invalid form", err)
+		return
+	}
+	userid := r.FormValue("userid")
+	if userid == "" {
+		response.WriteOCSError(w, r, response.MetaBadRequest.StatusCode, "missing userid", nil)
+		return
+	}
+	displayName := r.FormValue("displayname")
+	if displayName == "" {
+		displayName = userid
+	}
+
+	u, err := h.users.CreateUser(ctx, &userpb.User{
+		Id:          &userpb.UserId{Idp: h.c.Idp, OpaqueId: uuid.New().String()},
+		Username:    userid,
+		Mail:        r.FormValue("email"),
+		DisplayName: displayName,
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	for _, g := range r.Form["groups[]"] {
+		if err := h.groups.AddMembers(ctx, h.groupID(g), []*userpb.UserId{u.Id}); err != nil {
+			writeError(w, r, err)
+			return
+		}
+	}
+	response.WriteOCSSuccess(w, r, nil)
+}
+
+func (h *Handler) editUser(w http.ResponseWriter, r *http.Request, userid string) {
+	ctx := r.Context()
+	u, err := h.users.GetUserByClaim(ctx, "username", userid)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	value := r.FormValue("value")
+	switch r.FormValue("key") {
+	case "email":
+		u.Mail = value
+	case "displayname", "display":
+		u.DisplayName = value
+	case "quota":
+		if err := setQuota(u, value); err != nil {
+			response.WriteOCSError(w, r, response.MetaBadRequest.StatusCode, err.Error(), err)
+			return
+		}
+	default:
+		response.WriteOCSError(w, r, response.MetaBadRequest.StatusCode, "unsupported key", nil)
+		return
+	}
+
+	if _, err := h.users.UpdateUser(ctx, u); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	response.WriteOCSSuccess(w, r, nil)
+}
+
+// setQuota sets the quota picked up by the storage providers. The values
+// none and default remove it, so that the default quota applies.
+func setQuota(u *userpb.User, value string) error {
+	if value == "none" || value == "default" {
+		delete(u.GetOpaque().GetMap(), user.QuotaOpaqueKey)
+		return nil
+	}
+	q, err := parseQuota(value)
+	if err != nil {
+		return err
+	}
+	if u.Opaque == nil {
+		u.Opaque = &types.Opaque{}
+	}
+	if u.Opaque.Map == nil {
+		u.Opaque.Map = map[string]*types.OpaqueEntry{}
+	}
+	u.Opaque.Map[user.QuotaOpaqueKey] = user.QuotaEntry(strconv.FormatUint(q, 10))
+	return nil
+}
+
+var quotaUnits = map[string]uint64{
+	"":   1,
+	"b":  1,
+	"kb": 1 << 10,
+	"mb": 1 << 20,
+	"gb": 1 << 30,
+	"tb": 1 << 40,
+}
+
+// parseQuota parses the quotas as sent by ownCloud, eg. 1073741824 or 5 GB.
+func parseQuota(value string) (uint64, error) {
+	v := strings.ToLower(strings.TrimSpace(value))
+	i := strings.IndexFunc(v, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if i == -1 {
+		i = len(v)
+	}
+	unit, ok := quotaUnits[strings.TrimSpace(v[i:])]
+	if !ok {
+		return 0, errtypes.BadRequest("invalid quota unit: " + value)
+	}
+	n, err := strconv.ParseFloat(v[:i], 64)
+	if err != nil || n < 0 {
+		return 0, errtypes.BadRequest("invalid quota: " + value)
+	}
+	return uint64(n * float64(unit)), nil
+}
+
+func (h *Handler) disableUser(w http.ResponseWriter, r *http.Request, userid string) {
+	ctx := r.Context()
+	u, err := h.users.GetUserByClaim(ctx, "username", userid)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := h.users.DeleteUser(ctx, u.Id); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	appctx.GetLogger(ctx).Info().Str("user", userid).Msg("ocs: disabled user")
+	response.WriteOCSSuccess(w, r, nil)
+}
+
+func (h *Handler) listUserGroups(w http.ResponseWriter, r *http.Request, userid string) {
+	ctx := r.Context()
+	u, err := h.users.GetUserByClaim(ctx, "username", userid)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	groups, err := h.users.GetUserGroups(ctx, u.Id)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if groups == nil {
+		groups = []string{}
+	}
+	response.WriteOCSSuccess(w, r, &Groups{Groups: groups})
+}
+
+func (h *Handler) editUserGroups(w http.ResponseWriter, r *http.Request, userid string) {
+	ctx := r.Context()
+	groupid := r.FormValue("groupid")
+	if groupid == "" {
+		response.WriteOCSError(w, r, response.MetaBadRequest.StatusCode, "missing groupid", nil)
+		return
+	}
+	u, err := h.users.GetUserByClaim(ctx, "username", userid)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	members := []*userpb.UserId{u.Id}
+	if r.Method == http.MethodPost {
+		err = h.groups.AddMembers(ctx, h.groupID(groupid), members)
+	} else {
+		err = h.groups.RemoveMembers(ctx, h.groupID(groupid), members)
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	response.WriteOCSSuccess(w, r, nil)
+}
+
+func (h *Handler) groupID(groupid string) *grouppb.GroupId {
+	return &grouppb.GroupId{Idp: h.c.Idp, OpaqueId: groupid}
+}
+
+func (h *Handler) listGroups(w http.ResponseWriter, r *http.Request) {
+	groups, err := h.groups.FindGroups(r.Context(), r.FormValue("search"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	res := &Groups{Groups: make([]string, 0, len(groups))}
+	for _, g := range groups {
+		res.Groups = append(res.Groups, g.GroupName)
+	}
+	response.WriteOCSSuccess(w, r, res)
+}
+
+func (h *Handler) createGroup(w http.ResponseWriter, r *http.Request) {
+	groupid := r.FormValue("groupid")
+	if groupid == "" {
+		response.WriteOCSError(w, r, response.MetaBadRequest.StatusCode, "missing groupid", nil)
+		return
+	}
+	_, err := h.groups.CreateGroup(r.Context(), &grouppb.Group{
+		Id:          h.groupID(groupid),
+		GroupName:   groupid,
+		DisplayName: groupid,
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	response.WriteOCSSuccess(w, r, nil)
+}
+
+func (h *Handler) listGroupMembers(w http.ResponseWriter, r *http.Request, groupid string) {
+	ctx := r.Context()
+	members, err := h.groups.GetMembers(ctx, h.groupID(groupid))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	res := &Users{Users: make([]string, 0, len(members))}
+	for _, m := range members {
+		u, err := h.users.GetUser(ctx, m)
+		if err != nil {
+			// disabled users are still members of their groups
+			continue
+		}
+		res.Users = append(res.Users, u.Username)
+	}
+	response.WriteOCSSuccess(w, r, res)
+}
+
+func (h *Handler) deleteGroup(w http.ResponseWriter, r *http.Request, groupid string) {
+	if err := h.groups.DeleteGroup(r.Context(), h.groupID(groupid)); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	response.WriteOCSSuccess(w, r, nil)
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	switch err.(type) {
+	case errtypes.IsNotFound:
+		response.WriteOCSError(w, r, response.MetaNotFound.StatusCode, err.Error(), nil)
+	case errtypes.IsAlreadyExists:
+		response.WriteOCSError(w, r, ocsStatusExists, err.Error(), nil)
+	case errtypes.IsBadRequest:
+		response.WriteOCSError(w, r, response.MetaBadRequest.StatusCode, err.Error(), nil)
+	default:
+		response.WriteOCSError(w, r, response.MetaServerError.StatusCode, "error provisioning", err)
+	}
+}
@@ -0,0 +1,44 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package provisioning
+
+import "testing"
+
+func TestParseQuota(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected uint64
+		err      bool
+	}{
+		{"1073741824", 1073741824, false},
+		{"5 GB", 5 << 30, false},
+		{"1.5MB", 3 << 19, false},
+		{"10 kb", 10 << 10, false},
+		{"5 PB", 0, true},
+		{"-1", 0, true},
+		{"", 0, true},
+	}
+
+	for _, tt := range tests {
+		q, err := parseQuota(tt.input)
+		if (err != nil) != tt.err || q != tt.expected {
+			t.Errorf("parseQuota(\"%s\") returned %d, %v instead of expected %d", tt.input, q, err, tt.expected)
+		}
+	}
+}
@@ -22,10 +22,13 @@ import (
 	"net/http"
 
 	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/config"
+	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/data"
 	"github.com/cs3org/reva/internal/http/services/owncloud/ocs/response"
 	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/idempotency"
 	"github.com/cs3org/reva/pkg/rhttp/global"
 	"github.com/cs3org/reva/pkg/rhttp/router"
+	"github.com/cs3org/reva/pkg/tenant"
 	"github.com/mitchellh/mapstructure"
 	"github.com/rs/zerolog"
 )
@@ -53,6 +56,22 @@ func New(m map[string]interface{}, log *zerolog.Logger) (global.Service, error)
 
 	conf.Init()
 
+	// the tenants get the configured capabilities with their overrides on top
+	conf.TenantCapabilities = map[string]data.CapabilitiesData{}
+	for _, t := range tenant.All() {
+		if len(t.Capabilities) == 0 {
+			continue
+		}
+		caps := data.CapabilitiesData{}
+		if err := mapstructure.Decode(m["capabilities"], &caps); err != nil {
+			return nil, err
+		}
+		if err := mapstructure.Decode(t.Capabilities, &caps); err != nil {
+			return nil, err
+		}
+		conf.TenantCapabilities[t.ID] = caps
+	}
+
 	s := &svc{
 		c:         conf,
 		V1Handler: new(V1Handler),
@@ -81,6 +100,7 @@ func (s *svc) Unprotected() []string {
 func (s *svc) Handler() http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		log := appctx.GetLogger(r.Context())
+		r = idempotency.ForwardKey(r)
 
 		var head string
 		head, r.URL.Path = router.ShiftPath(r.URL.Path)
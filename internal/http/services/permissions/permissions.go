@@ -0,0 +1,169 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package permissions
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/permission"
+	"github.com/cs3org/reva/pkg/permission/manager/registry"
+	"github.com/cs3org/reva/pkg/rhttp/global"
+	"github.com/cs3org/reva/pkg/rhttp/router"
+	ctxpkg "github.com/cs3org/reva/pkg/user"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+func init() {
+	global.Register("permissions", New)
+}
+
+type config struct {
+	Prefix  string                            `mapstructure:"prefix"`
+	Driver  string                            `mapstructure:"driver"`
+	Drivers map[string]map[string]interface{} `mapstructure:"drivers"`
+}
+
+func (c *config) init() {
+	if c.Prefix == "" {
+		c.Prefix = "permissions"
+	}
+	if c.Driver == "" {
+		c.Driver = "static"
+	}
+}
+
+type svc struct {
+	conf *config
+	pm   permission.Manager
+}
+
+// New returns a service exposing the roles and capabilities of the
+// authenticated user, to be queried by the frontends.
+func New(m map[string]interface{}, log *zerolog.Logger) (global.Service, error) {
+	conf := &config{}
+	if err := mapstructure.Decode(m, conf); err != nil {
+		return nil, errors.Wrap(err, "permissions: error decoding conf")
+	}
+	conf.init()
+
+	f, ok := registry.NewFuncs[conf.Driver]
+	if !ok {
+		return nil, errtypes.NotFound("permissions: driver not found: " + conf.Driver)
+	}
+	pm, err := f(conf.Drivers[conf.Driver])
+	if err != nil {
+		return nil, err
+	}
+
+	return &svc{conf: conf, pm: pm}, nil
+}
+
+// Close performs cleanup.
+func (s *svc) Close() error {
+	return nil
+}
+
+func (s *svc) Prefix() string {
+	return s.conf.Prefix
+}
+
+func (s *svc) Unprotected() []string {
+	return []string{}
+}
+
+func (s *svc) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var head string
+		head, r.URL.Path = router.ShiftPath(r.URL.Path)
+
+		if r.Method != http.MethodGet {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		switch head {
+		case "":
+			s.handleGet(w, r)
+		case "check":
+			s.handleCheck(w, r)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+}
+
+// handleGet returns the roles and capabilities of the user.
+func (s *svc) handleGet(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := appctx.GetLogger(ctx)
+	u := ctxpkg.ContextMustGetUser(ctx)
+
+	roles, err := s.pm.GetRoles(ctx, u)
+	if err != nil {
+		log.Error().Err(err).Msg("permissions: error getting roles")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	capabilities, err := permission.GetUserCapabilities(ctx, s.pm, u)
+	if err != nil {
+		log.Error().Err(err).Msg("permissions: error getting capabilities")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"roles":        roles,
+		"capabilities": capabilities,
+	})
+}
+
+// handleCheck returns whether the user has been granted a capability.
+func (s *svc) handleCheck(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := appctx.GetLogger(ctx)
+	u := ctxpkg.ContextMustGetUser(ctx)
+
+	capability := r.URL.Query().Get("capability")
+	if capability == "" {
+		http.Error(w, "missing capability", http.StatusBadRequest)
+		return
+	}
+
+	allowed, err := permission.CheckPermission(ctx, s.pm, u, capability)
+	if err != nil {
+		log.Error().Err(err).Msg("permissions: error checking permission")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, map[string]interface{}{
+		"capability": capability,
+		"allowed":    allowed,
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,179 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package postprocessing
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/postprocessing"
+	"github.com/cs3org/reva/pkg/postprocessing/step/registry"
+	"github.com/cs3org/reva/pkg/rhttp/global"
+	"github.com/cs3org/reva/pkg/rhttp/router"
+	ctxpkg "github.com/cs3org/reva/pkg/user"
+	"github.com/cs3org/reva/pkg/utils"
+	"github.com/mitchellh/mapstructure"
+	"github.com/rs/zerolog"
+)
+
+func init() {
+	global.Register("postprocessing", New)
+}
+
+type config struct {
+	Prefix string `mapstructure:"prefix"`
+	// Pipeline configures the steps the uploads go through.
+	Pipeline map[string]interface{} `mapstructure:"pipeline"`
+}
+
+func (c *config) init() {
+	if c.Prefix == "" {
+		c.Prefix = "postprocessing"
+	}
+}
+
+type svc struct {
+	conf     *config
+	pipeline *postprocessing.Pipeline
+	stop     chan struct{}
+}
+
+// New returns a service running the post-processing of the uploads, and
+// allowing the users to follow the post-processing of their files.
+func New(m map[string]interface{}, log *zerolog.Logger) (global.Service, error) {
+	conf := &config{}
+	if err := mapstructure.Decode(m, conf); err != nil {
+		return nil, err
+	}
+	conf.init()
+
+	pc, err := postprocessing.ParsePipelineConfig(conf.Pipeline)
+	if err != nil {
+		return nil, err
+	}
+	p, err := postprocessing.NewPipeline(pc, newStep, log)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &svc{
+		conf:     conf,
+		pipeline: p,
+		stop:     make(chan struct{}),
+	}
+	go p.Run(s.stop)
+
+	return s, nil
+}
+
+// newStep creates a step with the driver of the same name.
+func newStep(name string, m map[string]interface{}) (postprocessing.Step, error) {
+	f, ok := registry.NewFuncs[name]
+	if !ok {
+		return nil, errtypes.NotFound("postprocessing: step not found: " + name)
+	}
+	return f(m)
+}
+
+// Close performs cleanup.
+func (s *svc) Close() error {
+	close(s.stop)
+	return nil
+}
+
+func (s *svc) Prefix() string {
+	return s.conf.Prefix
+}
+
+func (s *svc) Unprotected() []string {
+	return []string{}
+}
+
+func (s *svc) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u, ok := ctxpkg.ContextGetUser(r.Context())
+		if !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var head, id, action string
+		head, r.URL.Path = router.ShiftPath(r.URL.Path)
+		id, r.URL.Path = router.ShiftPath(r.URL.Path)
+		action, _ = router.ShiftPath(r.URL.Path)
+		if head != "jobs" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		if id == "" {
+			if r.Method != http.MethodGet {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			jobs := s.pipeline.ListJobs(u.Id)
+			if jobs == nil {
+				jobs = []*postprocessing.Job{}
+			}
+			writeJSON(w, http.StatusOK, jobs)
+			return
+		}
+
+		j, err := s.pipeline.GetJob(id)
+		if err == nil && !utils.UserEqual(j.Executant, u.Id) {
+			err = errtypes.NotFound("postprocessing: job not found: " + id)
+		}
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+
+		switch {
+		case action == "" && r.Method == http.MethodGet:
+			writeJSON(w, http.StatusOK, j)
+		case action == "retry" && r.Method == http.MethodPost:
+			if err := s.pipeline.Retry(id); err != nil {
+				writeError(w, err)
+				return
+			}
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	switch err.(type) {
+	case errtypes.IsNotFound:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errtypes.IsBadRequest:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, fmt.Sprintf("internal error: %v", err), http.StatusInternalServerError)
+	}
+}
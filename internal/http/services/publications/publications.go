@@ -0,0 +1,247 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package publications
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/publish"
+	archiveregistry "github.com/cs3org/reva/pkg/publish/archive/registry"
+	"github.com/cs3org/reva/pkg/publish/manager/registry"
+	"github.com/cs3org/reva/pkg/rhttp"
+	"github.com/cs3org/reva/pkg/rhttp/global"
+	"github.com/cs3org/reva/pkg/rhttp/router"
+	"github.com/cs3org/reva/pkg/sharedconf"
+	"github.com/cs3org/reva/pkg/token"
+	ctxpkg "github.com/cs3org/reva/pkg/user"
+	"github.com/cs3org/reva/pkg/utils"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/metadata"
+)
+
+func init() {
+	global.Register("publications", New)
+}
+
+type config struct {
+	Prefix     string                            `mapstructure:"prefix"`
+	GatewaySvc string                            `mapstructure:"gatewaysvc"`
+	Driver     string                            `mapstructure:"driver"`
+	Drivers    map[string]map[string]interface{} `mapstructure:"drivers"`
+	// Archive is the archival storage the folders are copied to.
+	Archive  string                            `mapstructure:"archive"`
+	Archives map[string]map[string]interface{} `mapstructure:"archives"`
+	// IdentifierPrefix is prepended to the ids of the publications to build
+	// their persistent identifiers.
+	IdentifierPrefix string `mapstructure:"identifier_prefix"`
+	Timeout          int64  `mapstructure:"timeout"`
+	Insecure         bool   `mapstructure:"insecure"`
+}
+
+func (c *config) init() {
+	if c.Prefix == "" {
+		c.Prefix = "publications"
+	}
+	if c.Driver == "" {
+		c.Driver = "json"
+	}
+	if c.IdentifierPrefix == "" {
+		c.IdentifierPrefix = "urn:uuid:"
+	}
+	c.GatewaySvc = sharedconf.GetGatewaySVC(c.GatewaySvc)
+}
+
+type svc struct {
+	conf      *config
+	m         publish.Manager
+	publisher *publish.Publisher
+	log       *zerolog.Logger
+}
+
+// New returns a service publishing folders to an archival storage. A
+// publication copies a snapshot of the folder to the archive, along with a
+// manifest recording its provenance, and is identified by a persistent
+// identifier.
+func New(m map[string]interface{}, log *zerolog.Logger) (global.Service, error) {
+	conf := &config{}
+	if err := mapstructure.Decode(m, conf); err != nil {
+		return nil, err
+	}
+	conf.init()
+
+	f, ok := registry.NewFuncs[conf.Driver]
+	if !ok {
+		return nil, errtypes.NotFound("publications: driver not found: " + conf.Driver)
+	}
+	mgr, err := f(conf.Drivers[conf.Driver])
+	if err != nil {
+		return nil, errors.Wrap(err, "publications: error creating publication manager")
+	}
+
+	af, ok := archiveregistry.NewFuncs[conf.Archive]
+	if !ok {
+		return nil, errtypes.NotFound("publications: archive not found: " + conf.Archive)
+	}
+	archive, err := af(conf.Archives[conf.Archive])
+	if err != nil {
+		return nil, errors.Wrap(err, "publications: error creating archive")
+	}
+
+	client := rhttp.GetHTTPClient(
+		rhttp.Timeout(time.Duration(conf.Timeout*int64(time.Second))),
+		rhttp.Insecure(conf.Insecure),
+	)
+
+	return &svc{
+		conf:      conf,
+		m:         mgr,
+		publisher: publish.NewPublisher(mgr, conf.Archive, archive, conf.GatewaySvc, conf.IdentifierPrefix, client),
+		log:       log,
+	}, nil
+}
+
+// Close performs cleanup.
+func (s *svc) Close() error {
+	return nil
+}
+
+func (s *svc) Prefix() string {
+	return s.conf.Prefix
+}
+
+func (s *svc) Unprotected() []string {
+	return []string{}
+}
+
+// Handler serves POST / to publish a folder, GET / to list the publications
+// of the user and GET /<id> to get a publication.
+func (s *svc) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var id string
+		id, r.URL.Path = router.ShiftPath(r.URL.Path)
+
+		switch {
+		case id == "" && r.Method == http.MethodPost:
+			s.handleCreate(w, r)
+		case id == "" && r.Method == http.MethodGet:
+			s.handleList(w, r)
+		case id != "" && r.Method == http.MethodGet:
+			s.handleGet(w, r, id)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+}
+
+type createRequest struct {
+	Path        string `json:"path"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+// handleCreate starts the publication of a folder. The folder is copied in
+// the background, the users poll the publication for its state.
+func (s *svc) handleCreate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	log := appctx.GetLogger(ctx)
+	u := ctxpkg.ContextMustGetUser(ctx)
+	tkn, ok := token.ContextGetToken(ctx)
+	if !ok {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	req := &createRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Path == "" || req.Title == "" {
+		http.Error(w, "path and title are required", http.StatusBadRequest)
+		return
+	}
+
+	p, err := s.publisher.Create(ctx, u.Id, path.Clean(req.Path), req.Title, req.Description)
+	if err != nil {
+		log.Error().Err(err).Msg("publications: error storing publication")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Location", path.Join("/", s.conf.Prefix, p.ID))
+	writeJSON(w, http.StatusAccepted, p)
+
+	// the publication outlives the request, so it runs with a context of its own
+	jobCtx := token.ContextSetToken(context.Background(), tkn)
+	jobCtx = metadata.AppendToOutgoingContext(jobCtx, token.TokenHeader, tkn)
+	jobCtx = ctxpkg.ContextSetUser(jobCtx, u)
+	jobCtx = appctx.WithLogger(jobCtx, s.log)
+
+	go func() {
+		if err := s.publisher.Publish(jobCtx, p); err != nil {
+			s.log.Error().Err(err).Str("publication", p.ID).Msg("publications: error publishing folder")
+		}
+	}()
+}
+
+func (s *svc) handleList(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	u := ctxpkg.ContextMustGetUser(ctx)
+	list, err := s.m.ListPublications(ctx, u.Id)
+	if err != nil {
+		appctx.GetLogger(ctx).Error().Err(err).Msg("publications: error listing publications")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+func (s *svc) handleGet(w http.ResponseWriter, r *http.Request, id string) {
+	ctx := r.Context()
+	u := ctxpkg.ContextMustGetUser(ctx)
+	p, err := s.m.GetPublication(ctx, id)
+	switch err.(type) {
+	case nil:
+	case errtypes.IsNotFound:
+		w.WriteHeader(http.StatusNotFound)
+		return
+	default:
+		appctx.GetLogger(ctx).Error().Err(err).Str("publication", id).Msg("publications: error getting publication")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	if !utils.UserEqual(p.Owner, u.Id) {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	writeJSON(w, http.StatusOK, p)
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
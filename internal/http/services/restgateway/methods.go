@@ -0,0 +1,233 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package restgateway
+
+import (
+	"context"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	collaboration "github.com/cs3org/go-cs3apis/cs3/sharing/collaboration/v1beta1"
+	link "github.com/cs3org/go-cs3apis/cs3/sharing/link/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/golang/protobuf/proto"
+)
+
+// method maps an RPC of the gateway to POST /<name>.
+type method struct {
+	name string
+	// req returns an empty request of the RPC, the body is decoded into.
+	req  func() proto.Message
+	res  func() proto.Message
+	call func(ctx context.Context, c gateway.GatewayAPIClient, req proto.Message) (proto.Message, error)
+}
+
+// methods are the RPCs exposed by the service.
+var methods = []*method{
+	// storage
+	{
+		name: "GetHome",
+		req:  func() proto.Message { return &provider.GetHomeRequest{} },
+		res:  func() proto.Message { return &provider.GetHomeResponse{} },
+		call: func(ctx context.Context, c gateway.GatewayAPIClient, req proto.Message) (proto.Message, error) {
+			return c.GetHome(ctx, req.(*provider.GetHomeRequest))
+		},
+	},
+	{
+		name: "Stat",
+		req:  func() proto.Message { return &provider.StatRequest{} },
+		res:  func() proto.Message { return &provider.StatResponse{} },
+		call: func(ctx context.Context, c gateway.GatewayAPIClient, req proto.Message) (proto.Message, error) {
+			return c.Stat(ctx, req.(*provider.StatRequest))
+		},
+	},
+	{
+		name: "ListContainer",
+		req:  func() proto.Message { return &provider.ListContainerRequest{} },
+		res:  func() proto.Message { return &provider.ListContainerResponse{} },
+		call: func(ctx context.Context, c gateway.GatewayAPIClient, req proto.Message) (proto.Message, error) {
+			return c.ListContainer(ctx, req.(*provider.ListContainerRequest))
+		},
+	},
+	{
+		name: "CreateContainer",
+		req:  func() proto.Message { return &provider.CreateContainerRequest{} },
+		res:  func() proto.Message { return &provider.CreateContainerResponse{} },
+		call: func(ctx context.Context, c gateway.GatewayAPIClient, req proto.Message) (proto.Message, error) {
+			return c.CreateContainer(ctx, req.(*provider.CreateContainerRequest))
+		},
+	},
+	{
+		name: "Delete",
+		req:  func() proto.Message { return &provider.DeleteRequest{} },
+		res:  func() proto.Message { return &provider.DeleteResponse{} },
+		call: func(ctx context.Context, c gateway.GatewayAPIClient, req proto.Message) (proto.Message, error) {
+			return c.Delete(ctx, req.(*provider.DeleteRequest))
+		},
+	},
+	{
+		name: "Move",
+		req:  func() proto.Message { return &provider.MoveRequest{} },
+		res:  func() proto.Message { return &provider.MoveResponse{} },
+		call: func(ctx context.Context, c gateway.GatewayAPIClient, req proto.Message) (proto.Message, error) {
+			return c.Move(ctx, req.(*provider.MoveRequest))
+		},
+	},
+
+	// user and group shares
+	{
+		name: "CreateShare",
+		req:  func() proto.Message { return &collaboration.CreateShareRequest{} },
+		res:  func() proto.Message { return &collaboration.CreateShareResponse{} },
+		call: func(ctx context.Context, c gateway.GatewayAPIClient, req proto.Message) (proto.Message, error) {
+			return c.CreateShare(ctx, req.(*collaboration.CreateShareRequest))
+		},
+	},
+	{
+		name: "GetShare",
+		req:  func() proto.Message { return &collaboration.GetShareRequest{} },
+		res:  func() proto.Message { return &collaboration.GetShareResponse{} },
+		call: func(ctx context.Context, c gateway.GatewayAPIClient, req proto.Message) (proto.Message, error) {
+			return c.GetShare(ctx, req.(*collaboration.GetShareRequest))
+		},
+	},
+	{
+		name: "ListShares",
+		req:  func() proto.Message { return &collaboration.ListSharesRequest{} },
+		res:  func() proto.Message { return &collaboration.ListSharesResponse{} },
+		call: func(ctx context.Context, c gateway.GatewayAPIClient, req proto.Message) (proto.Message, error) {
+			return c.ListShares(ctx, req.(*collaboration.ListSharesRequest))
+		},
+	},
+	{
+		name: "UpdateShare",
+		req:  func() proto.Message { return &collaboration.UpdateShareRequest{} },
+		res:  func() proto.Message { return &collaboration.UpdateShareResponse{} },
+		call: func(ctx context.Context, c gateway.GatewayAPIClient, req proto.Message) (proto.Message, error) {
+			return c.UpdateShare(ctx, req.(*collaboration.UpdateShareRequest))
+		},
+	},
+	{
+		name: "RemoveShare",
+		req:  func() proto.Message { return &collaboration.RemoveShareRequest{} },
+		res:  func() proto.Message { return &collaboration.RemoveShareResponse{} },
+		call: func(ctx context.Context, c gateway.GatewayAPIClient, req proto.Message) (proto.Message, error) {
+			return c.RemoveShare(ctx, req.(*collaboration.RemoveShareRequest))
+		},
+	},
+	{
+		name: "ListReceivedShares",
+		req:  func() proto.Message { return &collaboration.ListReceivedSharesRequest{} },
+		res:  func() proto.Message { return &collaboration.ListReceivedSharesResponse{} },
+		call: func(ctx context.Context, c gateway.GatewayAPIClient, req proto.Message) (proto.Message, error) {
+			return c.ListReceivedShares(ctx, req.(*collaboration.ListReceivedSharesRequest))
+		},
+	},
+	{
+		name: "GetReceivedShare",
+		req:  func() proto.Message { return &collaboration.GetReceivedShareRequest{} },
+		res:  func() proto.Message { return &collaboration.GetReceivedShareResponse{} },
+		call: func(ctx context.Context, c gateway.GatewayAPIClient, req proto.Message) (proto.Message, error) {
+			return c.GetReceivedShare(ctx, req.(*collaboration.GetReceivedShareRequest))
+		},
+	},
+	{
+		name: "UpdateReceivedShare",
+		req:  func() proto.Message { return &collaboration.UpdateReceivedShareRequest{} },
+		res:  func() proto.Message { return &collaboration.UpdateReceivedShareResponse{} },
+		call: func(ctx context.Context, c gateway.GatewayAPIClient, req proto.Message) (proto.Message, error) {
+			return c.UpdateReceivedShare(ctx, req.(*collaboration.UpdateReceivedShareRequest))
+		},
+	},
+
+	// public links
+	{
+		name: "CreatePublicShare",
+		req:  func() proto.Message { return &link.CreatePublicShareRequest{} },
+		res:  func() proto.Message { return &link.CreatePublicShareResponse{} },
+		call: func(ctx context.Context, c gateway.GatewayAPIClient, req proto.Message) (proto.Message, error) {
+			return c.CreatePublicShare(ctx, req.(*link.CreatePublicShareRequest))
+		},
+	},
+	{
+		name: "GetPublicShare",
+		req:  func() proto.Message { return &link.GetPublicShareRequest{} },
+		res:  func() proto.Message { return &link.GetPublicShareResponse{} },
+		call: func(ctx context.Context, c gateway.GatewayAPIClient, req proto.Message) (proto.Message, error) {
+			return c.GetPublicShare(ctx, req.(*link.GetPublicShareRequest))
+		},
+	},
+	{
+		name: "ListPublicShares",
+		req:  func() proto.Message { return &link.ListPublicSharesRequest{} },
+		res:  func() proto.Message { return &link.ListPublicSharesResponse{} },
+		call: func(ctx context.Context, c gateway.GatewayAPIClient, req proto.Message) (proto.Message, error) {
+			return c.ListPublicShares(ctx, req.(*link.ListPublicSharesRequest))
+		},
+	},
+	{
+		name: "UpdatePublicShare",
+		req:  func() proto.Message { return &link.UpdatePublicShareRequest{} },
+		res:  func() proto.Message { return &link.UpdatePublicShareResponse{} },
+		call: func(ctx context.Context, c gateway.GatewayAPIClient, req proto.Message) (proto.Message, error) {
+			return c.UpdatePublicShare(ctx, req.(*link.UpdatePublicShareRequest))
+		},
+	},
+	{
+		name: "RemovePublicShare",
+		req:  func() proto.Message { return &link.RemovePublicShareRequest{} },
+		res:  func() proto.Message { return &link.RemovePublicShareResponse{} },
+		call: func(ctx context.Context, c gateway.GatewayAPIClient, req proto.Message) (proto.Message, error) {
+			return c.RemovePublicShare(ctx, req.(*link.RemovePublicShareRequest))
+		},
+	},
+
+	// spaces
+	{
+		name: "CreateStorageSpace",
+		req:  func() proto.Message { return &provider.CreateStorageSpaceRequest{} },
+		res:  func() proto.Message { return &provider.CreateStorageSpaceResponse{} },
+		call: func(ctx context.Context, c gateway.GatewayAPIClient, req proto.Message) (proto.Message, error) {
+			return c.CreateStorageSpace(ctx, req.(*provider.CreateStorageSpaceRequest))
+		},
+	},
+	{
+		name: "ListStorageSpaces",
+		req:  func() proto.Message { return &provider.ListStorageSpacesRequest{} },
+		res:  func() proto.Message { return &provider.ListStorageSpacesResponse{} },
+		call: func(ctx context.Context, c gateway.GatewayAPIClient, req proto.Message) (proto.Message, error) {
+			return c.ListStorageSpaces(ctx, req.(*provider.ListStorageSpacesRequest))
+		},
+	},
+	{
+		name: "UpdateStorageSpace",
+		req:  func() proto.Message { return &provider.UpdateStorageSpaceRequest{} },
+		res:  func() proto.Message { return &provider.UpdateStorageSpaceResponse{} },
+		call: func(ctx context.Context, c gateway.GatewayAPIClient, req proto.Message) (proto.Message, error) {
+			return c.UpdateStorageSpace(ctx, req.(*provider.UpdateStorageSpaceRequest))
+		},
+	},
+	{
+		name: "DeleteStorageSpace",
+		req:  func() proto.Message { return &provider.DeleteStorageSpaceRequest{} },
+		res:  func() proto.Message { return &provider.DeleteStorageSpaceResponse{} },
+		call: func(ctx context.Context, c gateway.GatewayAPIClient, req proto.Message) (proto.Message, error) {
+			return c.DeleteStorageSpace(ctx, req.(*provider.DeleteStorageSpaceRequest))
+		},
+	},
+}
@@ -0,0 +1,138 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package restgateway
+
+import (
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// schemaBuilder builds the OpenAPI schemas of the protobuf messages, as
+// encoded by protojson.
+type schemaBuilder struct {
+	schemas map[string]interface{}
+}
+
+// ref returns a reference to the schema of the message, adding the schema
+// and the ones of the messages it contains to the components.
+func (b *schemaBuilder) ref(md protoreflect.MessageDescriptor) map[string]interface{} {
+	name := string(md.FullName())
+	if _, ok := b.schemas[name]; !ok {
+		// the placeholder stops the recursion of the messages containing
+		// themselves.
+		b.schemas[name] = nil
+		b.schemas[name] = b.message(md)
+	}
+	return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+}
+
+func (b *schemaBuilder) message(md protoreflect.MessageDescriptor) map[string]interface{} {
+	props := map[string]interface{}{}
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		props[fd.JSONName()] = b.field(fd)
+	}
+	return map[string]interface{}{"type": "object", "properties": props}
+}
+
+func (b *schemaBuilder) field(fd protoreflect.FieldDescriptor) map[string]interface{} {
+	switch {
+	case fd.IsMap():
+		return map[string]interface{}{"type": "object", "additionalProperties": b.value(fd.MapValue())}
+	case fd.IsList():
+		return map[string]interface{}{"type": "array", "items": b.value(fd)}
+	default:
+		return b.value(fd)
+	}
+}
+
+func (b *schemaBuilder) value(fd protoreflect.FieldDescriptor) map[string]interface{} {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return map[string]interface{}{"type": "boolean"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return map[string]interface{}{"type": "integer", "format": "int32"}
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return map[string]interface{}{"type": "integer", "format": "int64", "minimum": 0}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind, protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		// protojson encodes the 64 bit integers as strings
+		return map[string]interface{}{"type": "string", "format": "int64"}
+	case protoreflect.FloatKind:
+		return map[string]interface{}{"type": "number", "format": "float"}
+	case protoreflect.DoubleKind:
+		return map[string]interface{}{"type": "number", "format": "double"}
+	case protoreflect.BytesKind:
+		return map[string]interface{}{"type": "string", "format": "byte"}
+	case protoreflect.EnumKind:
+		values := fd.Enum().Values()
+		names := make([]string, 0, values.Len())
+		for i := 0; i < values.Len(); i++ {
+			names = append(names, string(values.Get(i).Name()))
+		}
+		return map[string]interface{}{"type": "string", "enum": names}
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		return b.ref(fd.Message())
+	default:
+		return map[string]interface{}{"type": "string"}
+	}
+}
+
+func descriptor(m proto.Message) protoreflect.MessageDescriptor {
+	return proto.MessageV2(m).ProtoReflect().Descriptor()
+}
+
+// openAPI returns the OpenAPI description of the methods served under the
+// prefix.
+func openAPI(prefix string) map[string]interface{} {
+	b := &schemaBuilder{schemas: map[string]interface{}{}}
+	paths := map[string]interface{}{}
+	for _, m := range methods {
+		paths["/"+m.name] = map[string]interface{}{
+			"post": map[string]interface{}{
+				"operationId": m.name,
+				"requestBody": map[string]interface{}{
+					"required": true,
+					"content": map[string]interface{}{
+						"application/json": map[string]interface{}{"schema": b.ref(descriptor(m.req()))},
+					},
+				},
+				"responses": map[string]interface{}{
+					"default": map[string]interface{}{
+						"description": "The response of the RPC. The HTTP status reflects the status of the response.",
+						"content": map[string]interface{}{
+							"application/json": map[string]interface{}{"schema": b.ref(descriptor(m.res()))},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "CS3 APIs gateway",
+			"version": "v1beta1",
+		},
+		"servers":    []interface{}{map[string]interface{}{"url": "/" + prefix}},
+		"paths":      paths,
+		"components": map[string]interface{}{"schemas": b.schemas},
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package restgateway
+
+import (
+	"testing"
+)
+
+func TestOpenAPI(t *testing.T) {
+	spec := openAPI("cs3")
+
+	paths := spec["paths"].(map[string]interface{})
+	if len(paths) != len(methods) {
+		t.Fatalf("expected %d paths, got %d", len(methods), len(paths))
+	}
+	if _, ok := paths["/Stat"]; !ok {
+		t.Fatal("expected a path for Stat")
+	}
+
+	schemas := spec["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+	req, ok := schemas["cs3.storage.provider.v1beta1.StatRequest"].(map[string]interface{})
+	if !ok {
+		t.Fatal("expected a schema for StatRequest")
+	}
+	props := req["properties"].(map[string]interface{})
+	if _, ok := props["ref"]; !ok {
+		t.Fatalf("expected the ref of StatRequest to be described, got %v", props)
+	}
+	// the schemas of the nested messages are described too
+	if _, ok := schemas["cs3.storage.provider.v1beta1.Reference"]; !ok {
+		t.Fatal("expected a schema for Reference")
+	}
+}
@@ -0,0 +1,204 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package restgateway
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
+	"github.com/cs3org/reva/pkg/rhttp/global"
+	"github.com/cs3org/reva/pkg/sharedconf"
+	"github.com/cs3org/reva/pkg/utils"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+func init() {
+	global.Register("restgateway", New)
+}
+
+// maxBodySize is the maximum size of the requests.
+const maxBodySize = 1 << 20
+
+type config struct {
+	Prefix     string `mapstructure:"prefix"`
+	GatewaySvc string `mapstructure:"gatewaysvc"`
+}
+
+func (c *config) init() {
+	if c.Prefix == "" {
+		c.Prefix = "cs3"
+	}
+	c.GatewaySvc = sharedconf.GetGatewaySVC(c.GatewaySvc)
+}
+
+type svc struct {
+	conf    *config
+	methods map[string]*method
+	spec    []byte
+}
+
+// New returns a service mapping the main RPCs of the gateway to REST, so that
+// they can be called without gRPC tooling: POST /<method> takes the request
+// and returns the response of the RPC, both encoded with protojson. The
+// OpenAPI description of the methods is served at GET /openapi.json.
+func New(m map[string]interface{}, log *zerolog.Logger) (global.Service, error) {
+	conf := &config{}
+	if err := mapstructure.Decode(m, conf); err != nil {
+		return nil, err
+	}
+	conf.init()
+
+	spec, err := json.Marshal(openAPI(conf.Prefix))
+	if err != nil {
+		return nil, errors.Wrap(err, "restgateway: error generating the OpenAPI description")
+	}
+
+	s := &svc{
+		conf:    conf,
+		methods: map[string]*method{},
+		spec:    spec,
+	}
+	for _, m := range methods {
+		s.methods[m.name] = m
+	}
+	return s, nil
+}
+
+// Close performs cleanup.
+func (s *svc) Close() error {
+	return nil
+}
+
+func (s *svc) Prefix() string {
+	return s.conf.Prefix
+}
+
+func (s *svc) Unprotected() []string {
+	return []string{"/openapi.json"}
+}
+
+func (s *svc) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.Trim(r.URL.Path, "/")
+		if name == "openapi.json" && r.Method == http.MethodGet {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write(s.spec)
+			return
+		}
+
+		m, ok := s.methods[name]
+		if !ok {
+			writeError(w, http.StatusNotFound, "unknown method "+name)
+			return
+		}
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			writeError(w, http.StatusMethodNotAllowed, "the methods must be called with POST")
+			return
+		}
+		s.call(w, r, m)
+	})
+}
+
+func (s *svc) call(w http.ResponseWriter, r *http.Request, m *method) {
+	ctx := r.Context()
+	log := appctx.GetLogger(ctx)
+
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "error reading request: "+err.Error())
+		return
+	}
+	req := m.req()
+	if len(body) > 0 {
+		if err := utils.UnmarshalJSONToProtoV1(body, req); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid request: "+err.Error())
+			return
+		}
+	}
+
+	client, err := pool.GetGatewayServiceClient(s.conf.GatewaySvc)
+	if err != nil {
+		log.Error().Err(err).Msg("restgateway: error getting gateway client")
+		writeError(w, http.StatusInternalServerError, "error getting gateway client")
+		return
+	}
+	res, err := m.call(ctx, client, req)
+	if err != nil {
+		log.Error().Err(err).Str("method", m.name).Msg("restgateway: error calling gateway")
+		writeError(w, http.StatusBadGateway, "error calling gateway")
+		return
+	}
+
+	b, err := utils.MarshalProtoV1ToJSON(res)
+	if err != nil {
+		log.Error().Err(err).Str("method", m.name).Msg("restgateway: error encoding response")
+		writeError(w, http.StatusInternalServerError, "error encoding response")
+		return
+	}
+
+	status := http.StatusOK
+	if st, ok := res.(interface{ GetStatus() *rpc.Status }); ok {
+		status = httpStatus(st.GetStatus())
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if _, err := w.Write(b); err != nil {
+		log.Error().Err(err).Msg("restgateway: error writing response")
+	}
+}
+
+// httpStatus maps the status of a CS3 response to an HTTP status.
+func httpStatus(st *rpc.Status) int {
+	switch st.GetCode() {
+	case rpc.Code_CODE_OK:
+		return http.StatusOK
+	case rpc.Code_CODE_INVALID_ARGUMENT:
+		return http.StatusBadRequest
+	case rpc.Code_CODE_UNAUTHENTICATED:
+		return http.StatusUnauthorized
+	case rpc.Code_CODE_PERMISSION_DENIED:
+		return http.StatusForbidden
+	case rpc.Code_CODE_NOT_FOUND:
+		return http.StatusNotFound
+	case rpc.Code_CODE_ALREADY_EXISTS:
+		return http.StatusConflict
+	case rpc.Code_CODE_FAILED_PRECONDITION:
+		return http.StatusPreconditionFailed
+	case rpc.Code_CODE_UNIMPLEMENTED:
+		return http.StatusNotImplemented
+	case rpc.Code_CODE_UNAVAILABLE:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"message": message})
+}
@@ -0,0 +1,83 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package s3
+
+import (
+	"encoding/xml"
+	"net/http"
+
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	"github.com/cs3org/reva/pkg/appctx"
+)
+
+// s3Error is an error returned to the clients as described in
+// https://docs.aws.amazon.com/AmazonS3/latest/API/ErrorResponses.html
+type s3Error struct {
+	XMLName  xml.Name `xml:"Error"`
+	Code     string   `xml:"Code"`
+	Message  string   `xml:"Message"`
+	Resource string   `xml:"Resource,omitempty"`
+	status   int
+}
+
+func (e *s3Error) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+var (
+	errMethodNotAllowed = &s3Error{Code: "MethodNotAllowed", Message: "The specified method is not allowed against this resource.", status: http.StatusMethodNotAllowed}
+	errNotImplemented   = &s3Error{Code: "NotImplemented", Message: "A header or query you provided implies functionality that is not implemented.", status: http.StatusNotImplemented}
+	errNoSuchBucket     = &s3Error{Code: "NoSuchBucket", Message: "The specified bucket does not exist.", status: http.StatusNotFound}
+	errNoSuchKey        = &s3Error{Code: "NoSuchKey", Message: "The specified key does not exist.", status: http.StatusNotFound}
+	errNoSuchUpload     = &s3Error{Code: "NoSuchUpload", Message: "The specified multipart upload does not exist.", status: http.StatusNotFound}
+	errAccessDenied     = &s3Error{Code: "AccessDenied", Message: "Access Denied", status: http.StatusForbidden}
+	errInternal         = &s3Error{Code: "InternalError", Message: "We encountered an internal error. Please try again.", status: http.StatusInternalServerError}
+)
+
+// statusError converts a CS3 status into the matching S3 error, notFound
+// being returned for the resources which do not exist.
+func statusError(st *rpc.Status, notFound *s3Error) error {
+	switch st.Code {
+	case rpc.Code_CODE_NOT_FOUND:
+		return notFound
+	case rpc.Code_CODE_PERMISSION_DENIED:
+		return errAccessDenied
+	case rpc.Code_CODE_FAILED_PRECONDITION:
+		return &s3Error{Code: "PreconditionFailed", Message: st.Message, status: http.StatusPreconditionFailed}
+	default:
+		return &s3Error{Code: "InternalError", Message: st.Message, status: http.StatusInternalServerError}
+	}
+}
+
+// writeError writes err, logging the errors which are not S3 errors and
+// hiding them behind an InternalError.
+func (s *svc) writeError(w http.ResponseWriter, r *http.Request, err error) {
+	e, ok := err.(*s3Error)
+	if !ok {
+		appctx.GetLogger(r.Context()).Error().Err(err).Str("path", r.URL.Path).Msg("s3: error handling request")
+		e = errInternal
+	}
+	res := *e
+	res.Resource = r.URL.Path
+	if r.Method == http.MethodHead {
+		w.WriteHeader(res.status)
+		return
+	}
+	s.writeXML(w, r, res.status, &res)
+}
@@ -0,0 +1,287 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package s3
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	ctxpkg "github.com/cs3org/reva/pkg/user"
+	"github.com/google/uuid"
+)
+
+const maxPartNumber = 10000
+
+// multipartUpload is the metadata of a multipart upload, kept in the folder
+// of the upload next to the parts.
+type multipartUpload struct {
+	Bucket    string    `json:"bucket"`
+	Key       string    `json:"key"`
+	Owner     string    `json:"owner"`
+	Initiated time.Time `json:"initiated"`
+}
+
+type initiateMultipartUploadResult struct {
+	XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+	Xmlns    string   `xml:"xmlns,attr"`
+	Bucket   string   `xml:"Bucket"`
+	Key      string   `xml:"Key"`
+	UploadID string   `xml:"UploadId"`
+}
+
+type completePart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+type completeMultipartUpload struct {
+	XMLName xml.Name       `xml:"CompleteMultipartUpload"`
+	Parts   []completePart `xml:"Part"`
+}
+
+type completeMultipartUploadResult struct {
+	XMLName  xml.Name `xml:"CompleteMultipartUploadResult"`
+	Xmlns    string   `xml:"xmlns,attr"`
+	Location string   `xml:"Location"`
+	Bucket   string   `xml:"Bucket"`
+	Key      string   `xml:"Key"`
+	ETag     string   `xml:"ETag"`
+}
+
+func (s *svc) uploadDir(uploadID string) string {
+	return filepath.Join(s.conf.MultipartDir, uploadID)
+}
+
+// getUpload returns the metadata of the upload, if it was initiated by the
+// user of the request for the same bucket and key.
+func (s *svc) getUpload(r *http.Request, bucket, key, uploadID string) (*multipartUpload, error) {
+	if _, err := uuid.Parse(uploadID); err != nil {
+		return nil, errNoSuchUpload
+	}
+	b, err := ioutil.ReadFile(filepath.Join(s.uploadDir(uploadID), "upload.json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errNoSuchUpload
+		}
+		return nil, err
+	}
+	upload := &multipartUpload{}
+	if err := json.Unmarshal(b, upload); err != nil {
+		return nil, err
+	}
+	u := ctxpkg.ContextMustGetUser(r.Context())
+	if upload.Owner != u.Username || upload.Bucket != bucket || upload.Key != key {
+		return nil, errNoSuchUpload
+	}
+	return upload, nil
+}
+
+func (s *svc) handleCreateMultipartUpload(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	ctx := r.Context()
+	client, home, err := s.getClient(ctx)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if err := statBucket(ctx, client, home, bucket); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	uploadID := uuid.New().String()
+	b, err := json.Marshal(&multipartUpload{
+		Bucket:    bucket,
+		Key:       key,
+		Owner:     ctxpkg.ContextMustGetUser(ctx).Username,
+		Initiated: time.Now(),
+	})
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if err := os.Mkdir(s.uploadDir(uploadID), 0700); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if err := ioutil.WriteFile(filepath.Join(s.uploadDir(uploadID), "upload.json"), b, 0600); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	s.writeXML(w, r, http.StatusOK, &initiateMultipartUploadResult{
+		Xmlns:    s3Namespace,
+		Bucket:   bucket,
+		Key:      key,
+		UploadID: uploadID,
+	})
+}
+
+// handleUploadPart stores the part in the folder of the upload, its md5 sum
+// being the etag of the part.
+func (s *svc) handleUploadPart(w http.ResponseWriter, r *http.Request, bucket, key, uploadID string) {
+	n, err := strconv.Atoi(r.URL.Query().Get("partNumber"))
+	if err != nil || n < 1 || n > maxPartNumber {
+		s.writeError(w, r, &s3Error{Code: "InvalidArgument", Message: "Part number must be an integer between 1 and 10000, inclusive.", status: http.StatusBadRequest})
+		return
+	}
+	if _, err := s.getUpload(r, bucket, key, uploadID); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	dir := s.uploadDir(uploadID)
+	f, err := ioutil.TempFile(dir, "part-")
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	defer os.Remove(f.Name())
+
+	h := md5.New()
+	_, err = io.Copy(io.MultiWriter(f, h), r.Body)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	sum := hex.EncodeToString(h.Sum(nil))
+
+	part := filepath.Join(dir, strconv.Itoa(n))
+	if err := ioutil.WriteFile(part+".md5", []byte(sum), 0600); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if err := os.Rename(f.Name(), part); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	w.Header().Set("ETag", quoteETag(sum))
+	w.WriteHeader(http.StatusOK)
+}
+
+// handleCompleteMultipartUpload concatenates the parts listed in the request
+// and uploads the result to the storage.
+func (s *svc) handleCompleteMultipartUpload(w http.ResponseWriter, r *http.Request, bucket, key, uploadID string) {
+	ctx := r.Context()
+	if _, err := s.getUpload(r, bucket, key, uploadID); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	req := &completeMultipartUpload{}
+	if err := xml.NewDecoder(r.Body).Decode(req); err != nil || len(req.Parts) == 0 {
+		s.writeError(w, r, &s3Error{Code: "MalformedXML", Message: "The XML you provided was not well-formed or did not validate against our published schema.", status: http.StatusBadRequest})
+		return
+	}
+
+	dir := s.uploadDir(uploadID)
+	invalidPart := &s3Error{Code: "InvalidPart", Message: "One or more of the specified parts could not be found or the specified entity tag might not have matched the part's entity tag.", status: http.StatusBadRequest}
+
+	var (
+		readers []io.Reader
+		length  int64
+		sums    []byte
+	)
+	for i, p := range req.Parts {
+		if i > 0 && p.PartNumber <= req.Parts[i-1].PartNumber {
+			s.writeError(w, r, &s3Error{Code: "InvalidPartOrder", Message: "The list of parts was not in ascending order.", status: http.StatusBadRequest})
+			return
+		}
+		part := filepath.Join(dir, strconv.Itoa(p.PartNumber))
+		sum, err := ioutil.ReadFile(part + ".md5")
+		if err != nil || quoteETag(string(sum)) != quoteETag(p.ETag) {
+			s.writeError(w, r, invalidPart)
+			return
+		}
+		f, err := os.Open(part)
+		if err != nil {
+			s.writeError(w, r, invalidPart)
+			return
+		}
+		defer f.Close()
+		fi, err := f.Stat()
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+		b, err := hex.DecodeString(string(sum))
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+		readers = append(readers, f)
+		length += fi.Size()
+		sums = append(sums, b...)
+	}
+
+	client, home, err := s.getClient(ctx)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if err := mkdirAll(ctx, client, home, bucket, path.Dir(key)); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if err := s.upload(ctx, client, resolve(home, bucket, key), io.MultiReader(readers...), length); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		s.log.Error().Err(err).Str("upload", uploadID).Msg("s3: error removing multipart upload")
+	}
+
+	// the etag of the multipart uploads is the md5 sum of the md5 sums of
+	// the parts followed by the number of parts
+	etag := md5.Sum(sums)
+	s.writeXML(w, r, http.StatusOK, &completeMultipartUploadResult{
+		Xmlns:    s3Namespace,
+		Location: "/" + strings.Trim(s.conf.Prefix, "/") + "/" + bucket + "/" + key,
+		Bucket:   bucket,
+		Key:      key,
+		ETag:     quoteETag(hex.EncodeToString(etag[:]) + "-" + strconv.Itoa(len(req.Parts))),
+	})
+}
+
+func (s *svc) handleAbortMultipartUpload(w http.ResponseWriter, r *http.Request, bucket, key, uploadID string) {
+	if _, err := s.getUpload(r, bucket, key, uploadID); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if err := os.RemoveAll(s.uploadDir(uploadID)); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
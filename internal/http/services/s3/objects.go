@@ -0,0 +1,684 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package s3
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	typespb "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	"github.com/cs3org/reva/internal/http/services/datagateway"
+	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
+	"github.com/cs3org/reva/pkg/rhttp"
+	ctxpkg "github.com/cs3org/reva/pkg/user"
+	"github.com/cs3org/reva/pkg/utils"
+)
+
+const (
+	s3Namespace    = "http://s3.amazonaws.com/doc/2006-03-01/"
+	timeFormat     = "2006-01-02T15:04:05.000Z"
+	defaultMaxKeys = 1000
+)
+
+type owner struct {
+	ID          string `xml:"ID"`
+	DisplayName string `xml:"DisplayName"`
+}
+
+type bucketEntry struct {
+	Name         string `xml:"Name"`
+	CreationDate string `xml:"CreationDate"`
+}
+
+type listAllMyBucketsResult struct {
+	XMLName xml.Name      `xml:"ListAllMyBucketsResult"`
+	Xmlns   string        `xml:"xmlns,attr"`
+	Owner   owner         `xml:"Owner"`
+	Buckets []bucketEntry `xml:"Buckets>Bucket"`
+}
+
+type locationConstraint struct {
+	XMLName xml.Name `xml:"LocationConstraint"`
+	Xmlns   string   `xml:"xmlns,attr"`
+}
+
+type object struct {
+	Key          string `xml:"Key"`
+	LastModified string `xml:"LastModified"`
+	ETag         string `xml:"ETag"`
+	Size         uint64 `xml:"Size"`
+	StorageClass string `xml:"StorageClass"`
+}
+
+type commonPrefix struct {
+	Prefix string `xml:"Prefix"`
+}
+
+type listBucketResult struct {
+	XMLName               xml.Name       `xml:"ListBucketResult"`
+	Xmlns                 string         `xml:"xmlns,attr"`
+	Name                  string         `xml:"Name"`
+	Prefix                string         `xml:"Prefix"`
+	Delimiter             string         `xml:"Delimiter,omitempty"`
+	MaxKeys               int            `xml:"MaxKeys"`
+	EncodingType          string         `xml:"EncodingType,omitempty"`
+	IsTruncated           bool           `xml:"IsTruncated"`
+	Marker                *string        `xml:"Marker"`
+	NextMarker            string         `xml:"NextMarker,omitempty"`
+	KeyCount              *int           `xml:"KeyCount"`
+	ContinuationToken     string         `xml:"ContinuationToken,omitempty"`
+	NextContinuationToken string         `xml:"NextContinuationToken,omitempty"`
+	StartAfter            string         `xml:"StartAfter,omitempty"`
+	Contents              []object       `xml:"Contents"`
+	CommonPrefixes        []commonPrefix `xml:"CommonPrefixes"`
+}
+
+func (s *svc) getClient(ctx context.Context) (gateway.GatewayAPIClient, string, error) {
+	client, err := pool.GetGatewayServiceClient(s.conf.GatewaySvc)
+	if err != nil {
+		return nil, "", err
+	}
+	res, err := client.GetHome(ctx, &provider.GetHomeRequest{})
+	if err != nil {
+		return nil, "", err
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return nil, "", statusError(res.Status, errAccessDenied)
+	}
+	return client, res.Path, nil
+}
+
+func stat(ctx context.Context, client gateway.GatewayAPIClient, fn string, notFound *s3Error) (*provider.ResourceInfo, error) {
+	res, err := client.Stat(ctx, &provider.StatRequest{
+		Ref: &provider.Reference{Spec: &provider.Reference_Path{Path: fn}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return nil, statusError(res.Status, notFound)
+	}
+	return res.Info, nil
+}
+
+func listContainer(ctx context.Context, client gateway.GatewayAPIClient, fn string, notFound *s3Error) ([]*provider.ResourceInfo, error) {
+	res, err := client.ListContainer(ctx, &provider.ListContainerRequest{
+		Ref: &provider.Reference{Spec: &provider.Reference_Path{Path: fn}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return nil, statusError(res.Status, notFound)
+	}
+	return res.Infos, nil
+}
+
+// statBucket returns an error if the bucket does not exist.
+func statBucket(ctx context.Context, client gateway.GatewayAPIClient, home, bucket string) error {
+	info, err := stat(ctx, client, resolve(home, bucket, ""), errNoSuchBucket)
+	if err != nil {
+		return err
+	}
+	if info.Type != provider.ResourceType_RESOURCE_TYPE_CONTAINER {
+		return errNoSuchBucket
+	}
+	return nil
+}
+
+// mkdirAll creates the folders of dir under the bucket which do not exist.
+func mkdirAll(ctx context.Context, client gateway.GatewayAPIClient, home, bucket, dir string) error {
+	p := resolve(home, bucket, "")
+	for _, segment := range strings.Split(strings.Trim(dir, "/"), "/") {
+		if segment == "" {
+			continue
+		}
+		p = path.Join(p, segment)
+		res, err := client.CreateContainer(ctx, &provider.CreateContainerRequest{
+			Ref: &provider.Reference{Spec: &provider.Reference_Path{Path: p}},
+		})
+		if err != nil {
+			return err
+		}
+		if res.Status.Code != rpc.Code_CODE_OK && res.Status.Code != rpc.Code_CODE_ALREADY_EXISTS {
+			return statusError(res.Status, errNoSuchBucket)
+		}
+	}
+	return nil
+}
+
+func (s *svc) upload(ctx context.Context, client gateway.GatewayAPIClient, fn string, body io.Reader, length int64) error {
+	res, err := client.InitiateFileUpload(ctx, &provider.InitiateFileUploadRequest{
+		Ref: &provider.Reference{Spec: &provider.Reference_Path{Path: fn}},
+		Opaque: &typespb.Opaque{Map: map[string]*typespb.OpaqueEntry{
+			"Upload-Length": {
+				Decoder: "plain",
+				Value:   []byte(strconv.FormatInt(length, 10)),
+			},
+		}},
+	})
+	if err != nil {
+		return err
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return statusError(res.Status, errNoSuchBucket)
+	}
+
+	var ep, tkn string
+	for _, p := range res.Protocols {
+		if p.Protocol == "simple" {
+			ep, tkn = p.UploadEndpoint, p.Token
+		}
+	}
+
+	httpReq, err := rhttp.NewRequest(ctx, http.MethodPut, ep, body)
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set(datagateway.TokenTransportHeader, tkn)
+	httpReq.ContentLength = length
+
+	httpRes, err := s.client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer httpRes.Body.Close()
+	if httpRes.StatusCode != http.StatusOK {
+		return &s3Error{Code: "InternalError", Message: "error uploading to the data server: " + httpRes.Status, status: http.StatusInternalServerError}
+	}
+	return nil
+}
+
+func (s *svc) handleListBuckets(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	client, home, err := s.getClient(ctx)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	infos, err := listContainer(ctx, client, home, errAccessDenied)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	u := ctxpkg.ContextMustGetUser(ctx)
+	res := &listAllMyBucketsResult{
+		Xmlns:   s3Namespace,
+		Owner:   owner{ID: u.Id.OpaqueId, DisplayName: u.DisplayName},
+		Buckets: []bucketEntry{},
+	}
+	for _, info := range infos {
+		if info.Type != provider.ResourceType_RESOURCE_TYPE_CONTAINER {
+			continue
+		}
+		res.Buckets = append(res.Buckets, bucketEntry{
+			Name:         path.Base(info.Path),
+			CreationDate: formatTime(info.Mtime),
+		})
+	}
+	sort.Slice(res.Buckets, func(i, j int) bool { return res.Buckets[i].Name < res.Buckets[j].Name })
+	s.writeXML(w, r, http.StatusOK, res)
+}
+
+func (s *svc) handleHeadBucket(w http.ResponseWriter, r *http.Request, bucket string) {
+	ctx := r.Context()
+	client, home, err := s.getClient(ctx)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if err := statBucket(ctx, client, home, bucket); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *svc) handleCreateBucket(w http.ResponseWriter, r *http.Request, bucket string) {
+	ctx := r.Context()
+	client, home, err := s.getClient(ctx)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	res, err := client.CreateContainer(ctx, &provider.CreateContainerRequest{
+		Ref: &provider.Reference{Spec: &provider.Reference_Path{Path: resolve(home, bucket, "")}},
+	})
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	switch res.Status.Code {
+	case rpc.Code_CODE_OK:
+	case rpc.Code_CODE_ALREADY_EXISTS:
+		s.writeError(w, r, &s3Error{Code: "BucketAlreadyOwnedByYou", Message: "The bucket you tried to create already exists, and you own it.", status: http.StatusConflict})
+		return
+	default:
+		s.writeError(w, r, statusError(res.Status, errAccessDenied))
+		return
+	}
+	w.Header().Set("Location", "/"+bucket)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *svc) handleDeleteBucket(w http.ResponseWriter, r *http.Request, bucket string) {
+	ctx := r.Context()
+	client, home, err := s.getClient(ctx)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if err := statBucket(ctx, client, home, bucket); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	infos, err := listContainer(ctx, client, resolve(home, bucket, ""), errNoSuchBucket)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if len(infos) > 0 {
+		s.writeError(w, r, &s3Error{Code: "BucketNotEmpty", Message: "The bucket you tried to delete is not empty.", status: http.StatusConflict})
+		return
+	}
+	res, err := client.Delete(ctx, &provider.DeleteRequest{
+		Ref: &provider.Reference{Spec: &provider.Reference_Path{Path: resolve(home, bucket, "")}},
+	})
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		s.writeError(w, r, statusError(res.Status, errNoSuchBucket))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listEntry is a key of a bucket. The folders are listed with a trailing
+// slash when they are empty or when they are not walked into.
+type listEntry struct {
+	key  string
+	info *provider.ResourceInfo
+}
+
+// walk lists the keys of the folder dir of the bucket starting with prefix.
+// When the delimiter is a slash, the folders below the prefix are not walked
+// into, as their keys would be rolled up into a common prefix anyway.
+func walk(ctx context.Context, client gateway.GatewayAPIClient, root, dir, prefix, delimiter string) ([]listEntry, error) {
+	infos, err := listContainer(ctx, client, path.Join(root, dir), errNoSuchBucket)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []listEntry
+	for _, info := range infos {
+		key := path.Join(dir, path.Base(info.Path))
+		if info.Type != provider.ResourceType_RESOURCE_TYPE_CONTAINER {
+			if strings.HasPrefix(key, prefix) {
+				entries = append(entries, listEntry{key: key, info: info})
+			}
+			continue
+		}
+
+		key += "/"
+		switch {
+		case strings.HasPrefix(prefix, key):
+			// the prefix points inside the folder
+		case !strings.HasPrefix(key, prefix):
+			continue
+		case delimiter == "/":
+			entries = append(entries, listEntry{key: key, info: info})
+			continue
+		}
+
+		children, err := walk(ctx, client, root, key, prefix, delimiter)
+		if err != nil {
+			return nil, err
+		}
+		if len(children) == 0 && strings.HasPrefix(key, prefix) {
+			children = append(children, listEntry{key: key, info: info})
+		}
+		entries = append(entries, children...)
+	}
+	return entries, nil
+}
+
+// handleListObjects implements both the version 1 and 2 of ListObjects.
+func (s *svc) handleListObjects(w http.ResponseWriter, r *http.Request, bucket string) {
+	ctx := r.Context()
+	q := r.URL.Query()
+	v2 := q.Get("list-type") == "2"
+
+	prefix, delimiter := q.Get("prefix"), q.Get("delimiter")
+	maxKeys := defaultMaxKeys
+	if mk := q.Get("max-keys"); mk != "" {
+		i, err := strconv.Atoi(mk)
+		if err != nil || i < 0 {
+			s.writeError(w, r, &s3Error{Code: "InvalidArgument", Message: "Invalid max-keys.", status: http.StatusBadRequest})
+			return
+		}
+		if i < maxKeys {
+			maxKeys = i
+		}
+	}
+
+	res := &listBucketResult{
+		Xmlns:        s3Namespace,
+		Name:         bucket,
+		Prefix:       prefix,
+		Delimiter:    delimiter,
+		MaxKeys:      maxKeys,
+		EncodingType: q.Get("encoding-type"),
+	}
+
+	var marker string
+	if v2 {
+		res.StartAfter = q.Get("start-after")
+		marker = res.StartAfter
+		if ct := q.Get("continuation-token"); ct != "" {
+			b, err := base64.StdEncoding.DecodeString(ct)
+			if err != nil {
+				s.writeError(w, r, &s3Error{Code: "InvalidArgument", Message: "The continuation token provided is incorrect.", status: http.StatusBadRequest})
+				return
+			}
+			res.ContinuationToken = ct
+			marker = string(b)
+		}
+	} else {
+		marker = q.Get("marker")
+		res.Marker = &marker
+	}
+
+	client, home, err := s.getClient(ctx)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if err := statBucket(ctx, client, home, bucket); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	entries, err := walk(ctx, client, resolve(home, bucket, ""), "", prefix, delimiter)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].key < entries[j].key })
+
+	var last string
+	count := 0
+	for _, e := range entries {
+		if e.key <= marker {
+			continue
+		}
+		// the keys rolled up in the common prefix the previous page ended on
+		if delimiter != "" && len(marker) > len(prefix) && strings.HasSuffix(marker, delimiter) && strings.HasPrefix(e.key, marker) {
+			continue
+		}
+
+		cp := ""
+		if delimiter != "" {
+			if i := strings.Index(e.key[len(prefix):], delimiter); i >= 0 {
+				cp = e.key[:len(prefix)+i+len(delimiter)]
+			}
+		}
+		if cp != "" && cp == last {
+			continue
+		}
+
+		if count == maxKeys {
+			res.IsTruncated = true
+			break
+		}
+		count++
+
+		if cp != "" {
+			last = cp
+			res.CommonPrefixes = append(res.CommonPrefixes, commonPrefix{Prefix: s.encodeKey(res, cp)})
+			continue
+		}
+		last = e.key
+		res.Contents = append(res.Contents, object{
+			Key:          s.encodeKey(res, e.key),
+			LastModified: formatTime(e.info.Mtime),
+			ETag:         quoteETag(e.info.Etag),
+			Size:         e.info.Size,
+			StorageClass: "STANDARD",
+		})
+	}
+
+	if res.IsTruncated {
+		if v2 {
+			res.NextContinuationToken = base64.StdEncoding.EncodeToString([]byte(last))
+		} else {
+			res.NextMarker = last
+		}
+	}
+	if v2 {
+		res.KeyCount = &count
+	}
+	if res.EncodingType == "url" {
+		res.Prefix = s.encodeKey(res, res.Prefix)
+		res.Delimiter = s.encodeKey(res, res.Delimiter)
+		res.NextMarker = s.encodeKey(res, res.NextMarker)
+		res.StartAfter = s.encodeKey(res, res.StartAfter)
+	}
+	s.writeXML(w, r, http.StatusOK, res)
+}
+
+// encodeKey URL encodes the key if the client asked for it, as the keys
+// can contain characters which are not valid in XML.
+func (s *svc) encodeKey(res *listBucketResult, key string) string {
+	if res.EncodingType != "url" {
+		return key
+	}
+	segments := strings.Split(key, "/")
+	for i := range segments {
+		segments[i] = uriEncode(segments[i])
+	}
+	return strings.Join(segments, "/")
+}
+
+func (s *svc) handleGetObject(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	ctx := r.Context()
+	client, home, err := s.getClient(ctx)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	fn := resolve(home, bucket, key)
+	info, err := stat(ctx, client, fn, errNoSuchKey)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if info.Type == provider.ResourceType_RESOURCE_TYPE_CONTAINER {
+		s.writeError(w, r, errNoSuchKey)
+		return
+	}
+
+	etag := quoteETag(info.Etag)
+	if m := r.Header.Get("If-Match"); m != "" && m != "*" && quoteETag(m) != etag {
+		s.writeError(w, r, &s3Error{Code: "PreconditionFailed", Message: "At least one of the pre-conditions you specified did not hold.", status: http.StatusPreconditionFailed})
+		return
+	}
+	if m := r.Header.Get("If-None-Match"); m != "" && (m == "*" || quoteETag(m) == etag) {
+		w.Header().Set("ETag", etag)
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Last-Modified", utils.TSToTime(info.Mtime).UTC().Format(http.TimeFormat))
+	w.Header().Set("Content-Type", info.MimeType)
+	w.Header().Set("Accept-Ranges", "bytes")
+
+	if r.Method == http.MethodHead {
+		w.Header().Set("Content-Length", strconv.FormatUint(info.Size, 10))
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	dRes, err := client.InitiateFileDownload(ctx, &provider.InitiateFileDownloadRequest{
+		Ref: &provider.Reference{Spec: &provider.Reference_Path{Path: fn}},
+	})
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if dRes.Status.Code != rpc.Code_CODE_OK {
+		s.writeError(w, r, statusError(dRes.Status, errNoSuchKey))
+		return
+	}
+
+	var ep, tkn string
+	for _, p := range dRes.Protocols {
+		if p.Protocol == "simple" {
+			ep, tkn = p.DownloadEndpoint, p.Token
+		}
+	}
+
+	httpReq, err := rhttp.NewRequest(ctx, http.MethodGet, ep, nil)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	httpReq.Header.Set(datagateway.TokenTransportHeader, tkn)
+	if rng := r.Header.Get("Range"); rng != "" {
+		httpReq.Header.Set("Range", rng)
+	}
+
+	httpRes, err := s.client.Do(httpReq)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	defer httpRes.Body.Close()
+
+	switch httpRes.StatusCode {
+	case http.StatusOK:
+		w.Header().Set("Content-Length", strconv.FormatUint(info.Size, 10))
+	case http.StatusPartialContent:
+		w.Header().Set("Content-Range", httpRes.Header.Get("Content-Range"))
+		w.Header().Set("Content-Length", httpRes.Header.Get("Content-Length"))
+	case http.StatusRequestedRangeNotSatisfiable:
+		s.writeError(w, r, &s3Error{Code: "InvalidRange", Message: "The requested range is not satisfiable.", status: http.StatusRequestedRangeNotSatisfiable})
+		return
+	default:
+		s.writeError(w, r, &s3Error{Code: "InternalError", Message: "error downloading from the data server: " + httpRes.Status, status: http.StatusInternalServerError})
+		return
+	}
+	w.WriteHeader(httpRes.StatusCode)
+	if _, err := io.Copy(w, httpRes.Body); err != nil {
+		s.log.Error().Err(err).Str("path", fn).Msg("s3: error copying data to response")
+	}
+}
+
+func (s *svc) handlePutObject(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	ctx := r.Context()
+	if r.Header.Get("X-Amz-Copy-Source") != "" {
+		s.writeError(w, r, errNotImplemented)
+		return
+	}
+	client, home, err := s.getClient(ctx)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	if err := statBucket(ctx, client, home, bucket); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	// keys with a trailing slash are the folders
+	if strings.HasSuffix(key, "/") {
+		if err := mkdirAll(ctx, client, home, bucket, key); err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	if r.ContentLength < 0 {
+		s.writeError(w, r, &s3Error{Code: "MissingContentLength", Message: "You must provide the Content-Length HTTP header.", status: http.StatusLengthRequired})
+		return
+	}
+	if err := mkdirAll(ctx, client, home, bucket, path.Dir(key)); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	fn := resolve(home, bucket, key)
+	if err := s.upload(ctx, client, fn, r.Body, r.ContentLength); err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+
+	info, err := stat(ctx, client, fn, errNoSuchKey)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	w.Header().Set("ETag", quoteETag(info.Etag))
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *svc) handleDeleteObject(w http.ResponseWriter, r *http.Request, bucket, key string) {
+	ctx := r.Context()
+	client, home, err := s.getClient(ctx)
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	res, err := client.Delete(ctx, &provider.DeleteRequest{
+		Ref: &provider.Reference{Spec: &provider.Reference_Path{Path: resolve(home, bucket, key)}},
+	})
+	if err != nil {
+		s.writeError(w, r, err)
+		return
+	}
+	// deleting a key which does not exist succeeds in S3
+	if res.Status.Code != rpc.Code_CODE_OK && res.Status.Code != rpc.Code_CODE_NOT_FOUND {
+		s.writeError(w, r, statusError(res.Status, errNoSuchKey))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func formatTime(ts *typespb.Timestamp) string {
+	return time.Unix(int64(ts.GetSeconds()), int64(ts.GetNanos())).UTC().Format(timeFormat)
+}
+
+// quoteETag returns the etag between double quotes, as S3 does.
+func quoteETag(etag string) string {
+	return "\"" + strings.Trim(etag, "\"") + "\""
+}
@@ -0,0 +1,301 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package s3
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ReneKroon/ttlcache/v2"
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
+	"github.com/cs3org/reva/pkg/rhttp"
+	"github.com/cs3org/reva/pkg/rhttp/global"
+	"github.com/cs3org/reva/pkg/sharedconf"
+	"github.com/cs3org/reva/pkg/token"
+	ctxpkg "github.com/cs3org/reva/pkg/user"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/metadata"
+)
+
+func init() {
+	global.Register("s3", New)
+}
+
+type credential struct {
+	SecretKey string `mapstructure:"secret_key"`
+	Username  string `mapstructure:"username"`
+}
+
+type config struct {
+	Prefix     string `mapstructure:"prefix"`
+	GatewaySvc string `mapstructure:"gatewaysvc"`
+	// Credentials maps the access keys to their secret keys and to the
+	// users they act on behalf of.
+	Credentials map[string]*credential `mapstructure:"credentials"`
+	// MachineAuthAPIKey is the API key of the machine auth provider, used to
+	// obtain a token of the user once the signature of a request is verified.
+	MachineAuthAPIKey string `mapstructure:"machine_auth_apikey"`
+	// MultipartDir is the folder the parts of the multipart uploads are kept
+	// in until the uploads are completed.
+	MultipartDir string `mapstructure:"multipart_dir"`
+	Timeout      int64  `mapstructure:"timeout"`
+	Insecure     bool   `mapstructure:"insecure"`
+}
+
+func (c *config) init() {
+	if c.Prefix == "" {
+		c.Prefix = "s3"
+	}
+	if c.MultipartDir == "" {
+		c.MultipartDir = filepath.Join(os.TempDir(), "reva-s3-multipart")
+	}
+	c.GatewaySvc = sharedconf.GetGatewaySVC(c.GatewaySvc)
+}
+
+type svc struct {
+	conf   *config
+	client *http.Client
+	tokens *ttlcache.Cache
+	log    *zerolog.Logger
+}
+
+type userToken struct {
+	token string
+	user  *userpb.User
+}
+
+// New returns a service exposing the home of the users through a subset of
+// the S3 API. The top level folders of the home are the buckets, requests
+// must be signed with AWS signature version 4 and use path style addressing.
+func New(m map[string]interface{}, log *zerolog.Logger) (global.Service, error) {
+	conf := &config{}
+	if err := mapstructure.Decode(m, conf); err != nil {
+		return nil, err
+	}
+	conf.init()
+
+	if conf.MachineAuthAPIKey == "" {
+		return nil, errors.New("s3: machine_auth_apikey must be set")
+	}
+	if err := os.MkdirAll(conf.MultipartDir, 0700); err != nil {
+		return nil, errors.Wrap(err, "s3: error creating multipart dir")
+	}
+
+	tokens := ttlcache.NewCache()
+	_ = tokens.SetTTL(time.Minute)
+
+	return &svc{
+		conf: conf,
+		client: rhttp.GetHTTPClient(
+			rhttp.Timeout(time.Duration(conf.Timeout*int64(time.Second))),
+			rhttp.Insecure(conf.Insecure),
+		),
+		tokens: tokens,
+		log:    log,
+	}, nil
+}
+
+// Close performs cleanup.
+func (s *svc) Close() error {
+	return s.tokens.Close()
+}
+
+func (s *svc) Prefix() string {
+	return s.conf.Prefix
+}
+
+// Unprotected returns all the paths of the service, the requests are
+// authenticated with their signature instead of the reva tokens.
+func (s *svc) Unprotected() []string {
+	return []string{"/"}
+}
+
+func (s *svc) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, err := s.authenticate(r)
+		if err != nil {
+			s.writeError(w, r, err)
+			return
+		}
+		r = r.WithContext(ctx)
+
+		bucket, key := splitPath(r.URL.Path)
+		q := r.URL.Query()
+		_, uploads := q["uploads"]
+		uploadID := q.Get("uploadId")
+
+		switch {
+		case bucket == "" && r.Method == http.MethodGet:
+			s.handleListBuckets(w, r)
+		case bucket == "":
+			s.writeError(w, r, errMethodNotAllowed)
+		case key == "":
+			s.handleBucket(w, r, bucket)
+		case uploads && r.Method == http.MethodPost:
+			s.handleCreateMultipartUpload(w, r, bucket, key)
+		case uploadID != "" && r.Method == http.MethodPut:
+			s.handleUploadPart(w, r, bucket, key, uploadID)
+		case uploadID != "" && r.Method == http.MethodPost:
+			s.handleCompleteMultipartUpload(w, r, bucket, key, uploadID)
+		case uploadID != "" && r.Method == http.MethodDelete:
+			s.handleAbortMultipartUpload(w, r, bucket, key, uploadID)
+		case uploadID != "":
+			s.writeError(w, r, errNotImplemented)
+		case r.Method == http.MethodGet || r.Method == http.MethodHead:
+			s.handleGetObject(w, r, bucket, key)
+		case r.Method == http.MethodPut:
+			s.handlePutObject(w, r, bucket, key)
+		case r.Method == http.MethodDelete:
+			s.handleDeleteObject(w, r, bucket, key)
+		default:
+			s.writeError(w, r, errMethodNotAllowed)
+		}
+	})
+}
+
+func (s *svc) handleBucket(w http.ResponseWriter, r *http.Request, bucket string) {
+	q := r.URL.Query()
+	if _, ok := q["location"]; ok && r.Method == http.MethodGet {
+		s.writeXML(w, r, http.StatusOK, &locationConstraint{Xmlns: s3Namespace})
+		return
+	}
+	// the other sub resources of the buckets (acl, policy, versioning...)
+	// are not supported
+	for _, sub := range []string{"acl", "cors", "lifecycle", "policy", "tagging", "uploads", "versioning", "versions", "website"} {
+		if _, ok := q[sub]; ok {
+			s.writeError(w, r, errNotImplemented)
+			return
+		}
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		s.handleListObjects(w, r, bucket)
+	case http.MethodHead:
+		s.handleHeadBucket(w, r, bucket)
+	case http.MethodPut:
+		s.handleCreateBucket(w, r, bucket)
+	case http.MethodDelete:
+		s.handleDeleteBucket(w, r, bucket)
+	default:
+		s.writeError(w, r, errMethodNotAllowed)
+	}
+}
+
+// authenticate verifies the signature of the request and returns a context
+// carrying the user owning the access key and a token of theirs.
+func (s *svc) authenticate(r *http.Request) (context.Context, error) {
+	header := r.Header.Get("Authorization")
+	if header == "" {
+		return nil, &s3Error{Code: "AccessDenied", Message: "Anonymous access is not supported.", status: http.StatusForbidden}
+	}
+	a, err := parseSigV4Auth(header)
+	if err != nil {
+		return nil, &s3Error{Code: "AuthorizationHeaderMalformed", Message: err.Error(), status: http.StatusBadRequest}
+	}
+	if strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		return nil, &s3Error{Code: "NotImplemented", Message: "Chunked payload signing is not supported.", status: http.StatusNotImplemented}
+	}
+
+	cred, ok := s.conf.Credentials[a.accessKey]
+	if !ok {
+		return nil, &s3Error{Code: "InvalidAccessKeyId", Message: "The access key does not exist.", status: http.StatusForbidden}
+	}
+	if err := verifySigV4(r, a, cred.SecretKey, time.Now()); err != nil {
+		return nil, &s3Error{Code: "SignatureDoesNotMatch", Message: err.Error(), status: http.StatusForbidden}
+	}
+
+	ut, err := s.getUserToken(r.Context(), cred.Username)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := ctxpkg.ContextSetUser(r.Context(), ut.user)
+	ctx = token.ContextSetToken(ctx, ut.token)
+	ctx = metadata.AppendToOutgoingContext(ctx, token.TokenHeader, ut.token)
+	return ctx, nil
+}
+
+func (s *svc) getUserToken(ctx context.Context, username string) (*userToken, error) {
+	if v, err := s.tokens.Get(username); err == nil {
+		return v.(*userToken), nil
+	}
+
+	client, err := pool.GetGatewayServiceClient(s.conf.GatewaySvc)
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.Authenticate(ctx, &gateway.AuthenticateRequest{
+		Type:         "machine",
+		ClientId:     username,
+		ClientSecret: s.conf.MachineAuthAPIKey,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		appctx.GetLogger(ctx).Error().Str("username", username).Str("status", res.Status.Message).Msg("s3: error authenticating user")
+		return nil, &s3Error{Code: "InvalidAccessKeyId", Message: "The user of the access key cannot be authenticated.", status: http.StatusForbidden}
+	}
+
+	ut := &userToken{token: res.Token, user: res.User}
+	_ = s.tokens.Set(username, ut)
+	return ut, nil
+}
+
+// splitPath splits /bucket/some/key into the bucket and the key.
+func splitPath(p string) (string, string) {
+	p = strings.TrimPrefix(p, "/")
+	parts := strings.SplitN(p, "/", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
+
+// resolve returns the path in the home of the user of a bucket or an object.
+func resolve(home, bucket, key string) string {
+	return path.Join(home, bucket, key)
+}
+
+func (s *svc) writeXML(w http.ResponseWriter, r *http.Request, status int, v interface{}) {
+	b, err := xml.Marshal(v)
+	if err != nil {
+		appctx.GetLogger(r.Context()).Error().Err(err).Msg("s3: error marshaling response")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	if _, err := w.Write(append([]byte(xml.Header), b...)); err != nil {
+		appctx.GetLogger(r.Context()).Error().Err(err).Msg("s3: error writing response")
+	}
+}
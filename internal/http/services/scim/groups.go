@@ -0,0 +1,257 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package scim
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+
+	grouppb "github.com/cs3org/go-cs3apis/cs3/identity/group/v1beta1"
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/rhttp/router"
+	"github.com/google/uuid"
+)
+
+func (s *svc) handleGroups(w http.ResponseWriter, r *http.Request) {
+	id, _ := router.ShiftPath(r.URL.Path)
+	switch {
+	case id == "" && r.Method == http.MethodGet:
+		s.listGroups(w, r)
+	case id == "" && r.Method == http.MethodPost:
+		s.createGroup(w, r)
+	case id != "" && r.Method == http.MethodGet:
+		s.getGroup(w, r, id)
+	case id != "" && r.Method == http.MethodPut:
+		s.replaceGroup(w, r, id)
+	case id != "" && r.Method == http.MethodPatch:
+		s.patchGroup(w, r, id)
+	case id != "" && r.Method == http.MethodDelete:
+		s.deleteGroup(w, r, id)
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, "", "method not allowed")
+	}
+}
+
+func (s *svc) groupID(id string) *grouppb.GroupId {
+	return &grouppb.GroupId{Idp: s.conf.Idp, OpaqueId: id}
+}
+
+func (s *svc) listGroups(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	attr, value, err := parseFilter(r.URL.Query().Get("filter"))
+	if err != nil {
+		handleError(w, r, err)
+		return
+	}
+
+	var groups []*grouppb.Group
+	switch attr {
+	case "":
+		groups, err = s.groups.FindGroups(ctx, "")
+	case "id":
+		var g *grouppb.Group
+		if g, err = s.groups.GetGroup(ctx, s.groupID(value)); err == nil {
+			groups = []*grouppb.Group{g}
+		}
+	case "displayName":
+		var g *grouppb.Group
+		if g, err = s.groups.GetGroupByClaim(ctx, "group_name", value); err == nil {
+			groups = []*grouppb.Group{g}
+		}
+	default:
+		err = errtypes.BadRequest("unsupported filter attribute: " + attr)
+	}
+
+	if _, ok := err.(errtypes.IsNotFound); ok {
+		groups, err = nil, nil
+	}
+	if err != nil {
+		handleError(w, r, err)
+		return
+	}
+
+	resources := make([]interface{}, 0, len(groups))
+	for _, g := range groups {
+		resources = append(resources, s.toSCIMGroup(g))
+	}
+	writeJSON(w, r, http.StatusOK, paginate(r, resources))
+}
+
+func (s *svc) getGroup(w http.ResponseWriter, r *http.Request, id string) {
+	g, err := s.groups.GetGroup(r.Context(), s.groupID(id))
+	if err != nil {
+		handleError(w, r, err)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, s.toSCIMGroup(g))
+}
+
+func (s *svc) createGroup(w http.ResponseWriter, r *http.Request) {
+	sg := &scimGroup{}
+	if err := json.NewDecoder(r.Body).Decode(sg); err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalidSyntax", err.Error())
+		return
+	}
+	if sg.DisplayName == "" {
+		writeError(w, r, http.StatusBadRequest, "invalidValue", "displayName is required")
+		return
+	}
+
+	g, err := s.groups.CreateGroup(r.Context(), s.fromSCIMGroup(sg, uuid.New().String()))
+	if err != nil {
+		handleError(w, r, err)
+		return
+	}
+
+	res := s.toSCIMGroup(g)
+	w.Header().Set("Location", res.Meta.Location)
+	writeJSON(w, r, http.StatusCreated, res)
+}
+
+func (s *svc) replaceGroup(w http.ResponseWriter, r *http.Request, id string) {
+	ctx := r.Context()
+	sg := &scimGroup{}
+	if err := json.NewDecoder(r.Body).Decode(sg); err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalidSyntax", err.Error())
+		return
+	}
+
+	current, err := s.groups.GetGroup(ctx, s.groupID(id))
+	if err != nil {
+		handleError(w, r, err)
+		return
+	}
+
+	g := s.fromSCIMGroup(sg, id)
+	g.Id = current.Id
+	g.GidNumber = current.GidNumber
+	g.Mail = current.Mail
+	if g.GroupName == "" {
+		g.GroupName = current.GroupName
+	}
+
+	if g, err = s.groups.UpdateGroup(ctx, g); err != nil {
+		handleError(w, r, err)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, s.toSCIMGroup(g))
+}
+
+// memberFilterRegex matches the paths selecting a single member of a group,
+// e.g. members[value eq "4c510ada-c86b-4815-8820-42cdf82c3d51"].
+var memberFilterRegex = regexp.MustCompile(`^members\[\s*value\s+eq\s+"([^"]*)"\s*\]$`)
+
+func (s *svc) patchGroup(w http.ResponseWriter, r *http.Request, id string) {
+	ctx := r.Context()
+	patch := &patchOp{}
+	if err := json.NewDecoder(r.Body).Decode(patch); err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalidSyntax", err.Error())
+		return
+	}
+
+	gid := s.groupID(id)
+	for _, op := range patch.Operations {
+		if err := s.applyGroupOperation(r, gid, op); err != nil {
+			handleError(w, r, err)
+			return
+		}
+	}
+
+	g, err := s.groups.GetGroup(ctx, gid)
+	if err != nil {
+		handleError(w, r, err)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, s.toSCIMGroup(g))
+}
+
+func (s *svc) applyGroupOperation(r *http.Request, gid *grouppb.GroupId, op patchOperation) error {
+	ctx := r.Context()
+
+	var members []multiValue
+	decodeMembers := func() error {
+		if err := json.Unmarshal(op.Value, &members); err != nil {
+			return errtypes.BadRequest(err.Error())
+		}
+		return nil
+	}
+
+	switch o := strings.ToLower(op.Op); {
+	case o == "add" && op.Path == "members":
+		if err := decodeMembers(); err != nil {
+			return err
+		}
+		return s.groups.AddMembers(ctx, gid, s.toUserIDs(members))
+
+	case o == "remove" && op.Path == "members":
+		if len(op.Value) == 0 {
+			current, err := s.groups.GetMembers(ctx, gid)
+			if err != nil {
+				return err
+			}
+			return s.groups.RemoveMembers(ctx, gid, current)
+		}
+		if err := decodeMembers(); err != nil {
+			return err
+		}
+		return s.groups.RemoveMembers(ctx, gid, s.toUserIDs(members))
+
+	case o == "remove" && memberFilterRegex.MatchString(op.Path):
+		m := memberFilterRegex.FindStringSubmatch(op.Path)
+		return s.groups.RemoveMembers(ctx, gid, []*userpb.UserId{{Idp: s.conf.Idp, OpaqueId: m[1]}})
+
+	case (o == "replace" || o == "add") && (op.Path == "" || op.Path == "displayName" || op.Path == "members"):
+		g, err := s.groups.GetGroup(ctx, gid)
+		if err != nil {
+			return err
+		}
+		sg := s.toSCIMGroup(g)
+		switch op.Path {
+		case "":
+			err = json.Unmarshal(op.Value, sg)
+		case "displayName":
+			err = json.Unmarshal(op.Value, &sg.DisplayName)
+		case "members":
+			sg.Members = nil
+			err = json.Unmarshal(op.Value, &sg.Members)
+		}
+		if err != nil {
+			return errtypes.BadRequest(err.Error())
+		}
+		updated := s.fromSCIMGroup(sg, gid.OpaqueId)
+		updated.Id = g.Id
+		updated.GidNumber = g.GidNumber
+		updated.Mail = g.Mail
+		_, err = s.groups.UpdateGroup(ctx, updated)
+		return err
+	}
+
+	return errtypes.BadRequest("unsupported operation on groups: " + op.Op + " " + op.Path)
+}
+
+func (s *svc) deleteGroup(w http.ResponseWriter, r *http.Request, id string) {
+	if err := s.groups.DeleteGroup(r.Context(), s.groupID(id)); err != nil {
+		handleError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
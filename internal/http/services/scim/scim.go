@@ -0,0 +1,248 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package scim
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/group"
+	groupregistry "github.com/cs3org/reva/pkg/group/manager/registry"
+	"github.com/cs3org/reva/pkg/rhttp/global"
+	"github.com/cs3org/reva/pkg/rhttp/router"
+	"github.com/cs3org/reva/pkg/user"
+	userregistry "github.com/cs3org/reva/pkg/user/manager/registry"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+func init() {
+	global.Register("scim", New)
+}
+
+type config struct {
+	Prefix string `mapstructure:"prefix"`
+	// Token is the bearer token identity management systems authenticate with.
+	Token string `mapstructure:"token"`
+	// Idp is the identity provider of the provisioned users and groups.
+	Idp string `mapstructure:"idp"`
+	// BaseURL is the public URL of the service, for example
+	// https://cernbox.cern.ch/scim, used in the resource locations.
+	BaseURL      string                            `mapstructure:"base_url"`
+	UserDriver   string                            `mapstructure:"user_driver"`
+	UserDrivers  map[string]map[string]interface{} `mapstructure:"user_drivers"`
+	GroupDriver  string                            `mapstructure:"group_driver"`
+	GroupDrivers map[string]map[string]interface{} `mapstructure:"group_drivers"`
+}
+
+func (c *config) init() {
+	if c.Prefix == "" {
+		c.Prefix = "scim"
+	}
+	if c.UserDriver == "" {
+		c.UserDriver = "json"
+	}
+	if c.GroupDriver == "" {
+		c.GroupDriver = "json"
+	}
+	c.BaseURL = strings.TrimSuffix(c.BaseURL, "/")
+}
+
+type svc struct {
+	conf   *config
+	users  user.ProvisioningManager
+	groups group.ProvisioningManager
+}
+
+// New returns a new SCIM 2.0 service allowing identity management systems
+// to provision users and groups in a writable user and group backend.
+func New(m map[string]interface{}, log *zerolog.Logger) (global.Service, error) {
+	conf := &config{}
+	if err := mapstructure.Decode(m, conf); err != nil {
+		return nil, err
+	}
+	conf.init()
+
+	if conf.Token == "" {
+		return nil, errors.New("scim: a token must be configured")
+	}
+
+	users, err := getUserManager(conf)
+	if err != nil {
+		return nil, err
+	}
+	groups, err := getGroupManager(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	return &svc{conf: conf, users: users, groups: groups}, nil
+}
+
+func getUserManager(c *config) (user.ProvisioningManager, error) {
+	f, ok := userregistry.NewFuncs[c.UserDriver]
+	if !ok {
+		return nil, errtypes.NotFound(fmt.Sprintf("driver %s not found for user manager", c.UserDriver))
+	}
+	mgr, err := f(c.UserDrivers[c.UserDriver])
+	if err != nil {
+		return nil, err
+	}
+	p, ok := mgr.(user.ProvisioningManager)
+	if !ok {
+		return nil, errtypes.NotSupported(fmt.Sprintf("scim: user manager %s does not support provisioning", c.UserDriver))
+	}
+	return p, nil
+}
+
+func getGroupManager(c *config) (group.ProvisioningManager, error) {
+	f, ok := groupregistry.NewFuncs[c.GroupDriver]
+	if !ok {
+		return nil, errtypes.NotFound(fmt.Sprintf("driver %s not found for group manager", c.GroupDriver))
+	}
+	mgr, err := f(c.GroupDrivers[c.GroupDriver])
+	if err != nil {
+		return nil, err
+	}
+	p, ok := mgr.(group.ProvisioningManager)
+	if !ok {
+		return nil, errtypes.NotSupported(fmt.Sprintf("scim: group manager %s does not support provisioning", c.GroupDriver))
+	}
+	return p, nil
+}
+
+// Close performs cleanup.
+func (s *svc) Close() error {
+	return nil
+}
+
+func (s *svc) Prefix() string {
+	return s.conf.Prefix
+}
+
+// Unprotected returns all the endpoints: SCIM clients are not reva users,
+// they authenticate with the configured bearer token instead.
+func (s *svc) Unprotected() []string {
+	return []string{"/"}
+}
+
+func (s *svc) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.authorized(r) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="scim"`)
+			writeError(w, r, http.StatusUnauthorized, "", "invalid token")
+			return
+		}
+
+		var version, head string
+		version, r.URL.Path = router.ShiftPath(r.URL.Path)
+		if version != "v2" {
+			writeError(w, r, http.StatusNotFound, "", "unsupported version")
+			return
+		}
+		head, r.URL.Path = router.ShiftPath(r.URL.Path)
+		switch head {
+		case "Users":
+			s.handleUsers(w, r)
+		case "Groups":
+			s.handleGroups(w, r)
+		case "ServiceProviderConfig":
+			s.handleServiceProviderConfig(w, r)
+		default:
+			writeError(w, r, http.StatusNotFound, "", "unknown endpoint")
+		}
+	})
+}
+
+func (s *svc) authorized(r *http.Request) bool {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return false
+	}
+	token := strings.TrimPrefix(auth, "Bearer ")
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.conf.Token)) == 1
+}
+
+func (s *svc) handleServiceProviderConfig(w http.ResponseWriter, r *http.Request) {
+	supported := func(b bool) map[string]bool { return map[string]bool{"supported": b} }
+	writeJSON(w, r, http.StatusOK, map[string]interface{}{
+		"schemas":        []string{schemaSPConfig},
+		"patch":          supported(true),
+		"bulk":           map[string]interface{}{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         map[string]interface{}{"supported": true, "maxResults": 1000},
+		"changePassword": supported(false),
+		"sort":           supported(false),
+		"etag":           supported(false),
+		"authenticationSchemes": []map[string]string{{
+			"type": "oauthbearertoken",
+			"name": "OAuth Bearer Token",
+		}},
+	})
+}
+
+// filterRegex matches the simple equality filters sent by the identity
+// management systems to look up a resource, e.g. userName eq "einstein".
+var filterRegex = regexp.MustCompile(`^\s*(\w+(?:\.\w+)?)\s+eq\s+"([^"]*)"\s*$`)
+
+func parseFilter(filter string) (string, string, error) {
+	if filter == "" {
+		return "", "", nil
+	}
+	m := filterRegex.FindStringSubmatch(filter)
+	if m == nil {
+		return "", "", errtypes.BadRequest("unsupported filter: " + filter)
+	}
+	return m[1], m[2], nil
+}
+
+// paginate returns the page of the resources requested with the
+// startIndex and count query parameters.
+func paginate(r *http.Request, resources []interface{}) *listResponse {
+	start, err := strconv.Atoi(r.URL.Query().Get("startIndex"))
+	if err != nil || start < 1 {
+		start = 1
+	}
+	count, err := strconv.Atoi(r.URL.Query().Get("count"))
+	if err != nil || count < 0 {
+		count = len(resources)
+	}
+
+	page := []interface{}{}
+	if start <= len(resources) {
+		end := start - 1 + count
+		if end > len(resources) {
+			end = len(resources)
+		}
+		page = resources[start-1 : end]
+	}
+
+	return &listResponse{
+		Schemas:      []string{schemaListResponse},
+		TotalResults: len(resources),
+		StartIndex:   start,
+		ItemsPerPage: len(page),
+		Resources:    page,
+	}
+}
@@ -0,0 +1,224 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package scim
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	grouppb "github.com/cs3org/go-cs3apis/cs3/identity/group/v1beta1"
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/errtypes"
+)
+
+// The SCIM 2.0 schemas, see https://tools.ietf.org/html/rfc7643
+const (
+	schemaUser         = "urn:ietf:params:scim:schemas:core:2.0:User"
+	schemaGroup        = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	schemaListResponse = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	schemaPatchOp      = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	schemaError        = "urn:ietf:params:scim:api:messages:2.0:Error"
+	schemaSPConfig     = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+
+	contentType = "application/scim+json"
+)
+
+type meta struct {
+	ResourceType string `json:"resourceType"`
+	Location     string `json:"location,omitempty"`
+}
+
+type name struct {
+	Formatted string `json:"formatted,omitempty"`
+}
+
+type multiValue struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+}
+
+type scimUser struct {
+	Schemas     []string     `json:"schemas"`
+	ID          string       `json:"id,omitempty"`
+	ExternalID  string       `json:"externalId,omitempty"`
+	UserName    string       `json:"userName"`
+	Name        *name        `json:"name,omitempty"`
+	DisplayName string       `json:"displayName,omitempty"`
+	Emails      []multiValue `json:"emails,omitempty"`
+	Groups      []multiValue `json:"groups,omitempty"`
+	Active      *bool        `json:"active,omitempty"`
+	Meta        *meta        `json:"meta,omitempty"`
+}
+
+type scimGroup struct {
+	Schemas     []string     `json:"schemas"`
+	ID          string       `json:"id,omitempty"`
+	ExternalID  string       `json:"externalId,omitempty"`
+	DisplayName string       `json:"displayName"`
+	Members     []multiValue `json:"members,omitempty"`
+	Meta        *meta        `json:"meta,omitempty"`
+}
+
+type listResponse struct {
+	Schemas      []string      `json:"schemas"`
+	TotalResults int           `json:"totalResults"`
+	StartIndex   int           `json:"startIndex"`
+	ItemsPerPage int           `json:"itemsPerPage"`
+	Resources    []interface{} `json:"Resources"`
+}
+
+type patchOp struct {
+	Schemas    []string         `json:"schemas"`
+	Operations []patchOperation `json:"Operations"`
+}
+
+type patchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+type scimError struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+}
+
+func (s *svc) toSCIMUser(u *userpb.User) *scimUser {
+	active := true
+	su := &scimUser{
+		Schemas:     []string{schemaUser},
+		ID:          u.Id.OpaqueId,
+		UserName:    u.Username,
+		DisplayName: u.DisplayName,
+		Active:      &active,
+		Meta: &meta{
+			ResourceType: "User",
+			Location:     s.conf.BaseURL + "/v2/Users/" + u.Id.OpaqueId,
+		},
+	}
+	if u.DisplayName != "" {
+		su.Name = &name{Formatted: u.DisplayName}
+	}
+	if u.Mail != "" {
+		su.Emails = []multiValue{{Value: u.Mail, Primary: true}}
+	}
+	for _, g := range u.Groups {
+		su.Groups = append(su.Groups, multiValue{Value: g})
+	}
+	return su
+}
+
+func (s *svc) fromSCIMUser(su *scimUser, id string) *userpb.User {
+	u := &userpb.User{
+		Id: &userpb.UserId{
+			Idp:      s.conf.Idp,
+			OpaqueId: id,
+		},
+		Username:    su.UserName,
+		DisplayName: su.DisplayName,
+	}
+	if u.DisplayName == "" && su.Name != nil {
+		u.DisplayName = su.Name.Formatted
+	}
+	for _, e := range su.Emails {
+		if u.Mail == "" || e.Primary {
+			u.Mail = e.Value
+		}
+	}
+	return u
+}
+
+func (s *svc) toSCIMGroup(g *grouppb.Group) *scimGroup {
+	sg := &scimGroup{
+		Schemas:     []string{schemaGroup},
+		ID:          g.Id.OpaqueId,
+		DisplayName: g.DisplayName,
+		Meta: &meta{
+			ResourceType: "Group",
+			Location:     s.conf.BaseURL + "/v2/Groups/" + g.Id.OpaqueId,
+		},
+	}
+	if sg.DisplayName == "" {
+		sg.DisplayName = g.GroupName
+	}
+	for _, m := range g.Members {
+		sg.Members = append(sg.Members, multiValue{Value: m.OpaqueId})
+	}
+	return sg
+}
+
+func (s *svc) fromSCIMGroup(sg *scimGroup, id string) *grouppb.Group {
+	g := &grouppb.Group{
+		Id: &grouppb.GroupId{
+			Idp:      s.conf.Idp,
+			OpaqueId: id,
+		},
+		GroupName:   sg.DisplayName,
+		DisplayName: sg.DisplayName,
+	}
+	g.Members = s.toUserIDs(sg.Members)
+	return g
+}
+
+func (s *svc) toUserIDs(members []multiValue) []*userpb.UserId {
+	ids := make([]*userpb.UserId, 0, len(members))
+	for _, m := range members {
+		ids = append(ids, &userpb.UserId{Idp: s.conf.Idp, OpaqueId: m.Value})
+	}
+	return ids
+}
+
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, v interface{}) {
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		appctx.GetLogger(r.Context()).Error().Err(err).Msg("scim: error writing response")
+	}
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, status int, scimType, detail string) {
+	writeJSON(w, r, status, &scimError{
+		Schemas:  []string{schemaError},
+		Status:   strconv.Itoa(status),
+		ScimType: scimType,
+		Detail:   detail,
+	})
+}
+
+// handleError maps the errors returned by the managers to SCIM errors.
+func handleError(w http.ResponseWriter, r *http.Request, err error) {
+	switch err.(type) {
+	case errtypes.IsNotFound:
+		writeError(w, r, http.StatusNotFound, "", err.Error())
+	case errtypes.IsAlreadyExists:
+		writeError(w, r, http.StatusConflict, "uniqueness", err.Error())
+	case errtypes.IsBadRequest:
+		writeError(w, r, http.StatusBadRequest, "invalidValue", err.Error())
+	case errtypes.IsNotSupported:
+		writeError(w, r, http.StatusNotImplemented, "", err.Error())
+	default:
+		appctx.GetLogger(r.Context()).Error().Err(err).Msg("scim: internal error")
+		writeError(w, r, http.StatusInternalServerError, "", err.Error())
+	}
+}
@@ -0,0 +1,242 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package scim
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/rhttp/router"
+	"github.com/google/uuid"
+)
+
+func (s *svc) handleUsers(w http.ResponseWriter, r *http.Request) {
+	id, _ := router.ShiftPath(r.URL.Path)
+	switch {
+	case id == "" && r.Method == http.MethodGet:
+		s.listUsers(w, r)
+	case id == "" && r.Method == http.MethodPost:
+		s.createUser(w, r)
+	case id != "" && r.Method == http.MethodGet:
+		s.getUser(w, r, id)
+	case id != "" && r.Method == http.MethodPut:
+		s.replaceUser(w, r, id)
+	case id != "" && r.Method == http.MethodPatch:
+		s.patchUser(w, r, id)
+	case id != "" && r.Method == http.MethodDelete:
+		s.deleteUser(w, r, id)
+	default:
+		writeError(w, r, http.StatusMethodNotAllowed, "", "method not allowed")
+	}
+}
+
+func (s *svc) userID(id string) *userpb.UserId {
+	return &userpb.UserId{Idp: s.conf.Idp, OpaqueId: id}
+}
+
+func (s *svc) listUsers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	attr, value, err := parseFilter(r.URL.Query().Get("filter"))
+	if err != nil {
+		handleError(w, r, err)
+		return
+	}
+
+	var users []*userpb.User
+	switch attr {
+	case "":
+		users, err = s.users.FindUsers(ctx, "")
+	case "id":
+		var u *userpb.User
+		if u, err = s.users.GetUser(ctx, s.userID(value)); err == nil {
+			users = []*userpb.User{u}
+		}
+	case "userName", "emails", "emails.value":
+		claim := "username"
+		if attr != "userName" {
+			claim = "mail"
+		}
+		var u *userpb.User
+		if u, err = s.users.GetUserByClaim(ctx, claim, value); err == nil {
+			users = []*userpb.User{u}
+		}
+	default:
+		err = errtypes.BadRequest("unsupported filter attribute: " + attr)
+	}
+
+	if _, ok := err.(errtypes.IsNotFound); ok {
+		users, err = nil, nil
+	}
+	if err != nil {
+		handleError(w, r, err)
+		return
+	}
+
+	resources := make([]interface{}, 0, len(users))
+	for _, u := range users {
+		resources = append(resources, s.toSCIMUser(u))
+	}
+	writeJSON(w, r, http.StatusOK, paginate(r, resources))
+}
+
+func (s *svc) getUser(w http.ResponseWriter, r *http.Request, id string) {
+	u, err := s.users.GetUser(r.Context(), s.userID(id))
+	if err != nil {
+		handleError(w, r, err)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, s.toSCIMUser(u))
+}
+
+func (s *svc) createUser(w http.ResponseWriter, r *http.Request) {
+	su := &scimUser{}
+	if err := json.NewDecoder(r.Body).Decode(su); err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalidSyntax", err.Error())
+		return
+	}
+	if su.UserName == "" {
+		writeError(w, r, http.StatusBadRequest, "invalidValue", "userName is required")
+		return
+	}
+
+	u, err := s.users.CreateUser(r.Context(), s.fromSCIMUser(su, uuid.New().String()))
+	if err != nil {
+		handleError(w, r, err)
+		return
+	}
+
+	res := s.toSCIMUser(u)
+	w.Header().Set("Location", res.Meta.Location)
+	writeJSON(w, r, http.StatusCreated, res)
+}
+
+func (s *svc) replaceUser(w http.ResponseWriter, r *http.Request, id string) {
+	su := &scimUser{}
+	if err := json.NewDecoder(r.Body).Decode(su); err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalidSyntax", err.Error())
+		return
+	}
+	s.updateUser(w, r, id, su)
+}
+
+func (s *svc) patchUser(w http.ResponseWriter, r *http.Request, id string) {
+	patch := &patchOp{}
+	if err := json.NewDecoder(r.Body).Decode(patch); err != nil {
+		writeError(w, r, http.StatusBadRequest, "invalidSyntax", err.Error())
+		return
+	}
+
+	u, err := s.users.GetUser(r.Context(), s.userID(id))
+	if err != nil {
+		handleError(w, r, err)
+		return
+	}
+	su := s.toSCIMUser(u)
+
+	for _, op := range patch.Operations {
+		if err := applyUserOperation(su, op); err != nil {
+			handleError(w, r, err)
+			return
+		}
+	}
+	s.updateUser(w, r, id, su)
+}
+
+// applyUserOperation applies an add or replace patch operation to the
+// attributes of the user which are stored by reva.
+func applyUserOperation(su *scimUser, op patchOperation) error {
+	switch strings.ToLower(op.Op) {
+	case "add", "replace":
+	default:
+		return errtypes.BadRequest("unsupported operation on users: " + op.Op)
+	}
+
+	if op.Path == "" {
+		// the value holds the attributes to replace
+		return json.Unmarshal(op.Value, su)
+	}
+
+	var err error
+	switch op.Path {
+	case "active":
+		err = json.Unmarshal(op.Value, &su.Active)
+	case "userName":
+		err = json.Unmarshal(op.Value, &su.UserName)
+	case "displayName":
+		err = json.Unmarshal(op.Value, &su.DisplayName)
+	case "name.formatted":
+		su.Name = &name{}
+		err = json.Unmarshal(op.Value, &su.Name.Formatted)
+	case "emails":
+		err = json.Unmarshal(op.Value, &su.Emails)
+	default:
+		// attributes not stored by reva are ignored
+	}
+	if err != nil {
+		return errtypes.BadRequest(err.Error())
+	}
+	return nil
+}
+
+func (s *svc) updateUser(w http.ResponseWriter, r *http.Request, id string, su *scimUser) {
+	ctx := r.Context()
+	current, err := s.users.GetUser(ctx, s.userID(id))
+	if err != nil {
+		handleError(w, r, err)
+		return
+	}
+
+	if su.Active != nil && !*su.Active {
+		// deprovisioned users are disabled, the user manager keeps their record
+		if err := s.users.DeleteUser(ctx, current.Id); err != nil {
+			handleError(w, r, err)
+			return
+		}
+		res := s.toSCIMUser(current)
+		res.Active = su.Active
+		writeJSON(w, r, http.StatusOK, res)
+		return
+	}
+
+	u := s.fromSCIMUser(su, id)
+	u.Id = current.Id
+	u.Groups = current.Groups
+	u.Opaque = current.Opaque
+	if u.Username == "" {
+		u.Username = current.Username
+	}
+
+	u, err = s.users.UpdateUser(ctx, u)
+	if err != nil {
+		handleError(w, r, err)
+		return
+	}
+	writeJSON(w, r, http.StatusOK, s.toSCIMUser(u))
+}
+
+func (s *svc) deleteUser(w http.ResponseWriter, r *http.Request, id string) {
+	if err := s.users.DeleteUser(r.Context(), s.userID(id)); err != nil {
+		handleError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,208 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package sse
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/events"
+)
+
+// statTimeout bounds the resolution of a changed file for a user.
+const statTimeout = 10 * time.Second
+
+// subscriber is a client connected to the service.
+type subscriber struct {
+	user *userpb.User
+	// ctx is the context of the request of the client, carrying the token
+	// the changed files are resolved with.
+	ctx           context.Context
+	notifications chan *notification
+	overflow      chan struct{}
+	once          sync.Once
+}
+
+type notification struct {
+	event string
+	data  []byte
+}
+
+type fileChanged struct {
+	Path      string    `json:"path"`
+	StorageID string    `json:"storage_id"`
+	OpaqueID  string    `json:"opaque_id"`
+	Time      time.Time `json:"time"`
+}
+
+type shareReceived struct {
+	ShareID      string    `json:"share_id"`
+	Sharer       string    `json:"sharer"`
+	StorageID    string    `json:"storage_id"`
+	OpaqueID     string    `json:"opaque_id"`
+	ResourceName string    `json:"resource_name"`
+	Time         time.Time `json:"time"`
+}
+
+func userKey(u *userpb.UserId) string {
+	return u.GetIdp() + "!" + u.GetOpaqueId()
+}
+
+func (s *svc) subscribe(sub *subscriber) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subscribers[sub] = struct{}{}
+}
+
+func (s *svc) unsubscribe(sub *subscriber) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.subscribers, sub)
+}
+
+// send queues the notification for the subscriber, which is disconnected
+// when its queue is full.
+func (s *svc) send(sub *subscriber, n *notification) {
+	select {
+	case sub.notifications <- n:
+	default:
+		sub.once.Do(func() { close(sub.overflow) })
+	}
+}
+
+// byUser returns the subscribers grouped by user.
+func (s *svc) byUser() map[string][]*subscriber {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	users := map[string][]*subscriber{}
+	for sub := range s.subscribers {
+		k := userKey(sub.user.Id)
+		users[k] = append(users[k], sub)
+	}
+	return users
+}
+
+// dispatch notifies the subscribers of the events, until the service is
+// closed.
+func (s *svc) dispatch() {
+	ctx, cancel := context.WithCancel(appctx.WithLogger(context.Background(), s.log))
+	defer cancel()
+	go func() {
+		<-s.stop
+		cancel()
+	}()
+
+	evs, err := events.Consume(ctx, s.stream, s.conf.ConsumerGroup, events.FileUploaded{}, events.ShareCreated{})
+	if err != nil {
+		s.log.Error().Err(err).Msg("sse: error consuming events")
+		return
+	}
+	for e := range evs {
+		switch ev := e.(type) {
+		case events.FileUploaded:
+			s.fileChanged(ev)
+		case events.ShareCreated:
+			s.shareReceived(ev)
+		}
+	}
+}
+
+// fileChanged notifies the users who can access the uploaded file, resolving
+// it once per user with the token of one of their clients, so that each of
+// them gets the path of the file in their own namespace.
+func (s *svc) fileChanged(ev events.FileUploaded) {
+	if ev.ResourceID == nil {
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, subs := range s.byUser() {
+		wg.Add(1)
+		go func(subs []*subscriber) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(subs[0].ctx, statTimeout)
+			defer cancel()
+			res, err := s.gwc.Stat(ctx, &provider.StatRequest{
+				Ref: &provider.Reference{Spec: &provider.Reference_Id{Id: ev.ResourceID}},
+			})
+			if err != nil || res.Status.Code != rpc.Code_CODE_OK {
+				// the file is not visible to the user
+				return
+			}
+			data, err := json.Marshal(fileChanged{
+				Path:      res.Info.Path,
+				StorageID: ev.ResourceID.StorageId,
+				OpaqueID:  ev.ResourceID.OpaqueId,
+				Time:      ev.Time,
+			})
+			if err != nil {
+				return
+			}
+			for _, sub := range subs {
+				s.send(sub, &notification{event: "file-changed", data: data})
+			}
+		}(subs)
+	}
+	wg.Wait()
+}
+
+// shareReceived notifies the grantee of the share, or the members of the
+// grantee group.
+func (s *svc) shareReceived(ev events.ShareCreated) {
+	data, err := json.Marshal(shareReceived{
+		ShareID:      ev.ShareID,
+		Sharer:       ev.Sharer.GetOpaqueId(),
+		StorageID:    ev.ResourceID.GetStorageId(),
+		OpaqueID:     ev.ResourceID.GetOpaqueId(),
+		ResourceName: ev.ResourceName,
+		Time:         ev.Time,
+	})
+	if err != nil {
+		return
+	}
+	n := &notification{event: "share-received", data: data}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for sub := range s.subscribers {
+		if isGrantee(sub.user, ev) {
+			s.send(sub, n)
+		}
+	}
+}
+
+func isGrantee(u *userpb.User, ev events.ShareCreated) bool {
+	if ev.GranteeUserID != nil {
+		return userKey(ev.GranteeUserID) == userKey(u.Id)
+	}
+	if ev.GranteeGroupID != nil {
+		for _, g := range u.Groups {
+			if g == ev.GranteeGroupID.OpaqueId {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,199 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package sse
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/events"
+	eventsregistry "github.com/cs3org/reva/pkg/events/driver/registry"
+	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
+	"github.com/cs3org/reva/pkg/rhttp/global"
+	"github.com/cs3org/reva/pkg/sharedconf"
+	ctxpkg "github.com/cs3org/reva/pkg/user"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+func init() {
+	global.Register("sse", New)
+}
+
+type config struct {
+	Prefix     string `mapstructure:"prefix"`
+	GatewaySvc string `mapstructure:"gatewaysvc"`
+	// ConsumerGroup is the group the events are consumed in. Every instance
+	// of the service needs a group of its own, as the clients connected to
+	// each instance are notified of all the events.
+	ConsumerGroup string                 `mapstructure:"consumer_group"`
+	Events        map[string]interface{} `mapstructure:"events"`
+	// KeepAlive is the interval in seconds between two comments sent to
+	// the idle clients, so that the proxies do not close the connections.
+	KeepAlive int `mapstructure:"keepalive"`
+	// BufferSize is the number of notifications queued for a client. The
+	// clients falling further behind are disconnected, and are expected to
+	// reconnect and to synchronize again.
+	BufferSize int `mapstructure:"buffer_size"`
+}
+
+func (c *config) init() {
+	if c.Prefix == "" {
+		c.Prefix = "sse"
+	}
+	if c.ConsumerGroup == "" {
+		hostname, _ := os.Hostname()
+		c.ConsumerGroup = "sse-" + hostname
+	}
+	if c.KeepAlive == 0 {
+		c.KeepAlive = 30
+	}
+	if c.BufferSize == 0 {
+		c.BufferSize = 64
+	}
+	c.GatewaySvc = sharedconf.GetGatewaySVC(c.GatewaySvc)
+}
+
+type svc struct {
+	conf   *config
+	gwc    gateway.GatewayAPIClient
+	stream events.Stream
+	log    *zerolog.Logger
+	stop   chan struct{}
+
+	mu          sync.Mutex
+	subscribers map[*subscriber]struct{}
+}
+
+// New returns a service streaming the changes relevant to the users as
+// server-sent events, derived from the events bus, so that the clients can
+// react to them instead of polling:
+//
+//	GET /
+//
+// The events are file-changed, when a file the user has access to was
+// uploaded, and share-received, when a resource was shared with the user or
+// one of their groups.
+func New(m map[string]interface{}, log *zerolog.Logger) (global.Service, error) {
+	conf := &config{}
+	if err := mapstructure.Decode(m, conf); err != nil {
+		return nil, err
+	}
+	conf.init()
+
+	gwc, err := pool.GetGatewayServiceClient(conf.GatewaySvc)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := eventsregistry.NewStream(conf.Events)
+	if err != nil {
+		return nil, errors.Wrap(err, "sse: error creating events stream")
+	}
+
+	s := &svc{
+		conf:        conf,
+		gwc:         gwc,
+		stream:      stream,
+		log:         log,
+		stop:        make(chan struct{}),
+		subscribers: map[*subscriber]struct{}{},
+	}
+	go s.dispatch()
+
+	return s, nil
+}
+
+// Close disconnects the clients.
+func (s *svc) Close() error {
+	close(s.stop)
+	return nil
+}
+
+func (s *svc) Prefix() string {
+	return s.conf.Prefix
+}
+
+func (s *svc) Unprotected() []string {
+	return []string{}
+}
+
+func (s *svc) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || strings.Trim(r.URL.Path, "/") != "" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming not supported", http.StatusInternalServerError)
+			return
+		}
+		ctx := r.Context()
+		log := appctx.GetLogger(ctx)
+
+		sub := &subscriber{
+			user:          ctxpkg.ContextMustGetUser(ctx),
+			ctx:           ctx,
+			notifications: make(chan *notification, s.conf.BufferSize),
+			overflow:      make(chan struct{}),
+		}
+		s.subscribe(sub)
+		defer s.unsubscribe(sub)
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		// keep nginx from buffering the stream
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		keepalive := time.NewTicker(time.Duration(s.conf.KeepAlive) * time.Second)
+		defer keepalive.Stop()
+
+		for {
+			select {
+			case n := <-sub.notifications:
+				if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", n.event, n.data); err != nil {
+					log.Debug().Err(err).Msg("sse: error writing event")
+					return
+				}
+			case <-keepalive.C:
+				if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+					return
+				}
+			case <-sub.overflow:
+				log.Debug().Str("user", sub.user.Username).Msg("sse: client too slow, disconnecting")
+				return
+			case <-ctx.Done():
+				return
+			case <-s.stop:
+				return
+			}
+			flusher.Flush()
+		}
+	})
+}
@@ -0,0 +1,118 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package theme
+
+import (
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+
+	"github.com/cs3org/reva/pkg/rhttp/global"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+func init() {
+	global.Register("theme", New)
+}
+
+type config struct {
+	Prefix string `mapstructure:"prefix"`
+	// AssetsDir is the directory holding the assets of the theme, e.g. the
+	// logos, backgrounds and favicons referenced by the capabilities.
+	AssetsDir string `mapstructure:"assets_dir"`
+	// MaxAge is the number of seconds the clients may cache the assets.
+	MaxAge int `mapstructure:"max_age"`
+}
+
+func (c *config) init() {
+	if c.Prefix == "" {
+		c.Prefix = "themes"
+	}
+	if c.MaxAge == 0 {
+		c.MaxAge = 3600
+	}
+}
+
+type svc struct {
+	conf *config
+}
+
+// New returns a service serving the assets of the theme of the deployment,
+// without authentication, as the clients show them before the users log in.
+func New(m map[string]interface{}, log *zerolog.Logger) (global.Service, error) {
+	conf := &config{}
+	if err := mapstructure.Decode(m, conf); err != nil {
+		return nil, err
+	}
+	conf.init()
+
+	if conf.AssetsDir == "" {
+		return nil, errors.New("theme: assets_dir must be set")
+	}
+	if fi, err := os.Stat(conf.AssetsDir); err != nil || !fi.IsDir() {
+		return nil, errors.New("theme: assets_dir is not a directory: " + conf.AssetsDir)
+	}
+
+	return &svc{conf: conf}, nil
+}
+
+// Close performs cleanup.
+func (s *svc) Close() error {
+	return nil
+}
+
+func (s *svc) Prefix() string {
+	return s.conf.Prefix
+}
+
+func (s *svc) Unprotected() []string {
+	return []string{"/"}
+}
+
+// Handler serves the files of the assets directory. The directories are not
+// listed.
+func (s *svc) Handler() http.Handler {
+	dir := http.Dir(s.conf.AssetsDir)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+
+		name := path.Clean("/" + r.URL.Path)
+		f, err := dir.Open(name)
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		defer f.Close()
+
+		fi, err := f.Stat()
+		if err != nil || fi.IsDir() {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(s.conf.MaxAge))
+		http.ServeContent(w, r, fi.Name(), fi.ModTime(), f)
+	})
+}
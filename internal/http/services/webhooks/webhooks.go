@@ -0,0 +1,306 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/permission"
+	permregistry "github.com/cs3org/reva/pkg/permission/manager/registry"
+	"github.com/cs3org/reva/pkg/rhttp/global"
+	"github.com/cs3org/reva/pkg/rhttp/router"
+	ctxpkg "github.com/cs3org/reva/pkg/user"
+	"github.com/cs3org/reva/pkg/webhook"
+	"github.com/cs3org/reva/pkg/webhook/manager/registry"
+	"github.com/google/uuid"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+func init() {
+	global.Register("webhooks", New)
+}
+
+type config struct {
+	Prefix  string                            `mapstructure:"prefix"`
+	Driver  string                            `mapstructure:"driver"`
+	Drivers map[string]map[string]interface{} `mapstructure:"drivers"`
+	// Dispatcher configures the delivery of the events.
+	Dispatcher  map[string]interface{} `mapstructure:"dispatcher"`
+	Admins      []string               `mapstructure:"admins"`
+	AdminGroups []string               `mapstructure:"admin_groups"`
+	// PermissionDriver, if set, is used to check whether the users are allowed
+	// to manage the webhooks instead of the lists of admins.
+	PermissionDriver  string                            `mapstructure:"permission_driver"`
+	PermissionDrivers map[string]map[string]interface{} `mapstructure:"permission_drivers"`
+}
+
+func (c *config) init() {
+	if c.Prefix == "" {
+		c.Prefix = "webhooks"
+	}
+	if c.Driver == "" {
+		c.Driver = "json"
+	}
+}
+
+type svc struct {
+	conf       *config
+	m          webhook.Manager
+	pm         permission.Manager
+	dispatcher *webhook.Dispatcher
+	stop       chan struct{}
+}
+
+// New returns a service allowing the administrators to register webhooks,
+// which the events published to the events bus are delivered to.
+func New(m map[string]interface{}, log *zerolog.Logger) (global.Service, error) {
+	conf := &config{}
+	if err := mapstructure.Decode(m, conf); err != nil {
+		return nil, err
+	}
+	conf.init()
+
+	f, ok := registry.NewFuncs[conf.Driver]
+	if !ok {
+		return nil, errtypes.NotFound("webhooks: driver not found: " + conf.Driver)
+	}
+	wm, err := f(conf.Drivers[conf.Driver])
+	if err != nil {
+		return nil, errors.Wrap(err, "webhooks: error creating webhook manager")
+	}
+
+	var pm permission.Manager
+	if conf.PermissionDriver != "" {
+		f, ok := permregistry.NewFuncs[conf.PermissionDriver]
+		if !ok {
+			return nil, errtypes.NotFound("webhooks: permission driver does not exist: " + conf.PermissionDriver)
+		}
+		if pm, err = f(conf.PermissionDrivers[conf.PermissionDriver]); err != nil {
+			return nil, errors.Wrap(err, "webhooks: error creating permission manager")
+		}
+	}
+
+	dc, err := webhook.ParseDispatcherConfig(conf.Dispatcher)
+	if err != nil {
+		return nil, err
+	}
+	d, err := webhook.NewDispatcher(dc, wm, log)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &svc{
+		conf:       conf,
+		m:          wm,
+		pm:         pm,
+		dispatcher: d,
+		stop:       make(chan struct{}),
+	}
+	go d.Run(s.stop)
+
+	return s, nil
+}
+
+// Close performs cleanup.
+func (s *svc) Close() error {
+	close(s.stop)
+	return nil
+}
+
+func (s *svc) Prefix() string {
+	return s.conf.Prefix
+}
+
+func (s *svc) Unprotected() []string {
+	return []string{}
+}
+
+func (s *svc) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.isAdmin(r.Context()) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		var head string
+		head, r.URL.Path = router.ShiftPath(r.URL.Path)
+
+		switch {
+		case head == "" && r.Method == http.MethodGet:
+			s.handleList(w, r)
+		case head == "" && r.Method == http.MethodPost:
+			s.handleCreate(w, r)
+		case head == "deadletters":
+			s.handleDeadLetters(w, r)
+		case head != "" && r.Method == http.MethodDelete:
+			if err := s.m.DeleteWebhook(r.Context(), head); err != nil {
+				writeError(w, err)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+}
+
+func (s *svc) handleDeadLetters(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var id, action string
+	id, r.URL.Path = router.ShiftPath(r.URL.Path)
+	action, _ = router.ShiftPath(r.URL.Path)
+
+	switch {
+	case id == "" && r.Method == http.MethodGet:
+		list, err := s.m.ListDeadLetters(ctx)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, list)
+	case id != "" && action == "" && r.Method == http.MethodDelete:
+		if err := s.m.DeleteDeadLetter(ctx, id); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case id != "" && action == "retry" && r.Method == http.MethodPost:
+		s.handleRetry(w, r, id)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// handleRetry delivers a dead letter again, and removes it from the queue if
+// the delivery succeeds.
+func (s *svc) handleRetry(w http.ResponseWriter, r *http.Request, id string) {
+	ctx := r.Context()
+
+	dl, err := s.m.GetDeadLetter(ctx, id)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	wh, err := s.m.GetWebhook(ctx, dl.WebhookID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if err := s.dispatcher.Deliver(ctx, wh, dl.Payload); err != nil {
+		dl.Attempts++
+		dl.LastError = err.Error()
+		dl.Time = time.Now()
+		if err := s.m.AddDeadLetter(ctx, dl); err != nil {
+			appctx.GetLogger(ctx).Error().Err(err).Str("delivery", id).Msg("webhooks: error updating dead letter")
+		}
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	if err := s.m.DeleteDeadLetter(ctx, id); err != nil {
+		writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *svc) handleList(w http.ResponseWriter, r *http.Request) {
+	list, err := s.m.ListWebhooks(r.Context())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	res := make([]*webhook.Webhook, 0, len(list))
+	for _, wh := range list {
+		res = append(res, withoutSecret(wh))
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (s *svc) handleCreate(w http.ResponseWriter, r *http.Request) {
+	wh := &webhook.Webhook{}
+	if err := json.NewDecoder(r.Body).Decode(wh); err != nil {
+		http.Error(w, "invalid body", http.StatusBadRequest)
+		return
+	}
+	if u, err := url.Parse(wh.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		http.Error(w, "invalid url", http.StatusBadRequest)
+		return
+	}
+
+	wh.ID = uuid.New().String()
+	wh.Created = time.Now()
+	if err := s.m.AddWebhook(r.Context(), wh); err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, withoutSecret(wh))
+}
+
+// withoutSecret returns a copy of the webhook which can be sent to the clients.
+func withoutSecret(wh *webhook.Webhook) *webhook.Webhook {
+	c := *wh
+	c.Secret = ""
+	return &c
+}
+
+func (s *svc) isAdmin(ctx context.Context) bool {
+	u, ok := ctxpkg.ContextGetUser(ctx)
+	if !ok {
+		return false
+	}
+	var allowed bool
+	var err error
+	if s.pm != nil {
+		// the permission driver may grant the management of the webhooks
+		// without granting the administration of the whole system.
+		allowed, err = permission.CheckPermission(ctx, s.pm, u, permission.ManageWebhooks)
+	} else {
+		allowed, err = permission.IsAdmin(ctx, nil, u, s.conf.Admins, s.conf.AdminGroups)
+	}
+	if err != nil {
+		appctx.GetLogger(ctx).Error().Err(err).Msg("webhooks: error checking permission")
+	}
+	return allowed
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	switch err.(type) {
+	case errtypes.IsNotFound:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		http.Error(w, fmt.Sprintf("internal error: %v", err), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package activity keeps the timeline of the actions concerning each user and
+// the resources they have access to, as materialized from the events bus.
+package activity
+
+import (
+	"context"
+	"time"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+)
+
+// Types of the activities.
+const (
+	FileUploaded  = "file_uploaded"
+	ShareCreated  = "share_created"
+	ShareReceived = "share_received"
+	LinkExpired   = "link_expired"
+)
+
+// ObjectTypeFiles is the type of the objects of the activities concerning
+// files and folders.
+const ObjectTypeFiles = "files"
+
+// Activity is an entry of the timeline of a user.
+type Activity struct {
+	// ID is assigned by the manager, and increases with the time the
+	// activities are added.
+	ID    int64          `json:"id"`
+	Type  string         `json:"type"`
+	Actor *userpb.UserId `json:"actor,omitempty"`
+	// ObjectType, ObjectID and ObjectName describe the resource concerned by
+	// the activity. ObjectID is empty if the id of the resource is not known.
+	ObjectType string    `json:"object_type"`
+	ObjectID   string    `json:"object_id,omitempty"`
+	ObjectName string    `json:"object_name"`
+	Time       time.Time `json:"time"`
+}
+
+// Filter restricts the activities returned by List.
+type Filter struct {
+	// ObjectType and ObjectID, if set, restrict the activities to the ones
+	// concerning the given resource.
+	ObjectType string
+	ObjectID   string
+	// Since, if set, restricts the activities to the ones older than the
+	// activity with this id, to fetch the next page of the timeline.
+	Since int64
+	// Limit is the maximum number of activities returned.
+	Limit int
+}
+
+// Matches returns whether the activity satisfies the resource constraints of
+// the filter.
+func (f *Filter) Matches(a *Activity) bool {
+	if f.ObjectType != "" && f.ObjectType != a.ObjectType {
+		return false
+	}
+	if f.ObjectID != "" && f.ObjectID != a.ObjectID {
+		return false
+	}
+	return f.Since <= 0 || a.ID < f.Since
+}
+
+// Manager is the interface to implement to store the timelines of the users.
+type Manager interface {
+	// Add adds the activity to the timeline of the user, and sets its id.
+	Add(ctx context.Context, uid *userpb.UserId, a *Activity) error
+	// List returns the activities of the user matching the filter, most
+	// recent first.
+	List(ctx context.Context, uid *userpb.UserId, f *Filter) ([]*Activity, error)
+	// Purge removes the activities older than the given time.
+	Purge(ctx context.Context, before time.Time) error
+}
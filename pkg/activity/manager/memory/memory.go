@@ -0,0 +1,92 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/pkg/activity"
+	"github.com/cs3org/reva/pkg/activity/manager/registry"
+)
+
+func init() {
+	registry.Register("memory", New)
+}
+
+type manager struct {
+	sync.Mutex
+	lastID int64
+	// activities holds the timelines of the users, oldest first.
+	activities map[string][]*activity.Activity
+}
+
+// New returns an activity manager keeping the timelines in memory.
+func New(m map[string]interface{}) (activity.Manager, error) {
+	return &manager{activities: map[string][]*activity.Activity{}}, nil
+}
+
+func userKey(uid *userpb.UserId) string {
+	return uid.GetIdp() + "!" + uid.GetOpaqueId()
+}
+
+func (m *manager) Add(ctx context.Context, uid *userpb.UserId, a *activity.Activity) error {
+	m.Lock()
+	defer m.Unlock()
+	m.lastID++
+	a.ID = m.lastID
+	key := userKey(uid)
+	m.activities[key] = append(m.activities[key], a)
+	return nil
+}
+
+func (m *manager) List(ctx context.Context, uid *userpb.UserId, f *activity.Filter) ([]*activity.Activity, error) {
+	m.Lock()
+	defer m.Unlock()
+	timeline := m.activities[userKey(uid)]
+	list := []*activity.Activity{}
+	for i := len(timeline) - 1; i >= 0; i-- {
+		if f.Limit > 0 && len(list) == f.Limit {
+			break
+		}
+		if f.Matches(timeline[i]) {
+			list = append(list, timeline[i])
+		}
+	}
+	return list, nil
+}
+
+func (m *manager) Purge(ctx context.Context, before time.Time) error {
+	m.Lock()
+	defer m.Unlock()
+	for key, timeline := range m.activities {
+		i := 0
+		for i < len(timeline) && timeline[i].Time.Before(before) {
+			i++
+		}
+		if i == len(timeline) {
+			delete(m.activities, key)
+		} else {
+			m.activities[key] = timeline[i:]
+		}
+	}
+	return nil
+}
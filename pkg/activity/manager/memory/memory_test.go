@@ -0,0 +1,59 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/pkg/activity"
+)
+
+func TestListAndPurge(t *testing.T) {
+	ctx := context.Background()
+	uid := &userpb.UserId{Idp: "idp", OpaqueId: "einstein"}
+	m, _ := New(nil)
+
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		a := &activity.Activity{Type: activity.FileUploaded, ObjectName: "file", Time: now.Add(time.Duration(i) * time.Hour)}
+		if err := m.Add(ctx, uid, a); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	page, _ := m.List(ctx, uid, &activity.Filter{Limit: 2})
+	if len(page) != 2 || page[0].ID != 5 || page[1].ID != 4 {
+		t.Fatalf("unexpected first page %+v", page)
+	}
+	page, _ = m.List(ctx, uid, &activity.Filter{Limit: 2, Since: page[1].ID})
+	if len(page) != 2 || page[0].ID != 3 {
+		t.Fatalf("unexpected second page %+v", page)
+	}
+
+	if err := m.Purge(ctx, now.Add(90*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	all, _ := m.List(ctx, uid, &activity.Filter{})
+	if len(all) != 3 {
+		t.Fatalf("expected 3 activities after purge, got %d", len(all))
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/pkg/activity"
+	"github.com/cs3org/reva/pkg/activity/manager/registry"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+
+	// Provides mysql drivers
+	_ "github.com/go-sql-driver/mysql"
+)
+
+func init() {
+	registry.Register("sql", New)
+}
+
+// The manager expects the following table:
+//
+//   activities(id, user_idp, user_id, type, actor_idp, actor_id, object_type, object_id, object_name, time)
+//
+// where id is auto-incremented, and the activities are looked up by
+// user_idp, user_id and id.
+
+type config struct {
+	DbUsername string `mapstructure:"db_username"`
+	DbPassword string `mapstructure:"db_password"`
+	DbHost     string `mapstructure:"db_host"`
+	DbPort     int    `mapstructure:"db_port"`
+	DbName     string `mapstructure:"db_name"`
+}
+
+func (c *config) init() {
+	if c.DbPort == 0 {
+		c.DbPort = 3306
+	}
+}
+
+type manager struct {
+	db *sql.DB
+}
+
+func parseConfig(m map[string]interface{}) (*config, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		err = errors.Wrap(err, "error decoding conf")
+		return nil, err
+	}
+	c.init()
+	return c, nil
+}
+
+// New returns an activity manager storing the timelines in a SQL database.
+func New(m map[string]interface{}) (activity.Manager, error) {
+	c, err := parseConfig(m)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?parseTime=true", c.DbUsername, c.DbPassword, c.DbHost, c.DbPort, c.DbName))
+	if err != nil {
+		return nil, errors.Wrap(err, "sql: error opening connection to the database")
+	}
+
+	return &manager{db: db}, nil
+}
+
+func (m *manager) Add(ctx context.Context, uid *userpb.UserId, a *activity.Activity) error {
+	query := "INSERT INTO activities(user_idp, user_id, type, actor_idp, actor_id, object_type, object_id, object_name, time) VALUES(?,?,?,?,?,?,?,?,?)"
+	res, err := m.db.ExecContext(ctx, query, uid.Idp, uid.OpaqueId, a.Type, a.Actor.GetIdp(), a.Actor.GetOpaqueId(), a.ObjectType, a.ObjectID, a.ObjectName, a.Time.UTC())
+	if err != nil {
+		return err
+	}
+	a.ID, err = res.LastInsertId()
+	return err
+}
+
+func (m *manager) List(ctx context.Context, uid *userpb.UserId, f *activity.Filter) ([]*activity.Activity, error) {
+	query := "SELECT id, type, actor_idp, actor_id, object_type, object_id, object_name, time FROM activities WHERE user_idp=? AND user_id=?"
+	params := []interface{}{uid.Idp, uid.OpaqueId}
+	if f.ObjectType != "" {
+		query += " AND object_type=?"
+		params = append(params, f.ObjectType)
+	}
+	if f.ObjectID != "" {
+		query += " AND object_id=?"
+		params = append(params, f.ObjectID)
+	}
+	if f.Since > 0 {
+		query += " AND id<?"
+		params = append(params, f.Since)
+	}
+	query += " ORDER BY id DESC"
+	if f.Limit > 0 {
+		query += " LIMIT ?"
+		params = append(params, f.Limit)
+	}
+
+	rows, err := m.db.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []*activity.Activity{}
+	for rows.Next() {
+		a := &activity.Activity{}
+		var actorIdp, actorID string
+		if err := rows.Scan(&a.ID, &a.Type, &actorIdp, &actorID, &a.ObjectType, &a.ObjectID, &a.ObjectName, &a.Time); err != nil {
+			return nil, err
+		}
+		if actorID != "" {
+			a.Actor = &userpb.UserId{Idp: actorIdp, OpaqueId: actorID}
+		}
+		list = append(list, a)
+	}
+	return list, rows.Err()
+}
+
+func (m *manager) Purge(ctx context.Context, before time.Time) error {
+	_, err := m.db.ExecContext(ctx, "DELETE FROM activities WHERE time<?", before.UTC())
+	return err
+}
@@ -0,0 +1,172 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package activity
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"path"
+	"time"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/events"
+	eventsregistry "github.com/cs3org/reva/pkg/events/driver/registry"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+// MaterializerConfig is the configuration of the materializer.
+type MaterializerConfig struct {
+	// Events configures the bus the events are consumed from.
+	Events map[string]interface{} `mapstructure:"events"`
+	// Retention is the number of days the activities are kept for.
+	Retention int `mapstructure:"retention"`
+	// PurgeInterval is the time in seconds between two purges of the
+	// expired activities.
+	PurgeInterval int `mapstructure:"purge_interval"`
+}
+
+// ParseMaterializerConfig decodes the configuration of the materializer from a map.
+func ParseMaterializerConfig(m map[string]interface{}) (*MaterializerConfig, error) {
+	c := &MaterializerConfig{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, errors.Wrap(err, "activity: error decoding conf")
+	}
+	if c.Retention == 0 {
+		c.Retention = 90
+	}
+	if c.PurgeInterval == 0 {
+		c.PurgeInterval = 3600
+	}
+	return c, nil
+}
+
+// Materializer adds the events consumed from the bus to the timelines of the
+// users concerned.
+type Materializer struct {
+	c      *MaterializerConfig
+	m      Manager
+	stream events.Consumer
+	log    *zerolog.Logger
+}
+
+// NewMaterializer returns a materializer storing the activities in m.
+func NewMaterializer(c *MaterializerConfig, m Manager, log *zerolog.Logger) (*Materializer, error) {
+	stream, err := eventsregistry.NewStream(c.Events)
+	if err != nil {
+		return nil, errors.Wrap(err, "activity: error creating events stream")
+	}
+	return &Materializer{c: c, m: m, stream: stream, log: log}, nil
+}
+
+// Run materializes the events consumed from the bus and purges the expired
+// activities until stop is closed.
+func (mt *Materializer) Run(stop <-chan struct{}) {
+	ctx, cancel := context.WithCancel(appctx.WithLogger(context.Background(), mt.log))
+	defer cancel()
+
+	evs, err := events.Consume(ctx, mt.stream, "activities", events.ShareCreated{}, events.FileUploaded{}, events.LinkExpired{})
+	if err != nil {
+		mt.log.Error().Err(err).Msg("activity: error consuming events")
+		return
+	}
+
+	ticker := time.NewTicker(time.Duration(mt.c.PurgeInterval) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			before := time.Now().AddDate(0, 0, -mt.c.Retention)
+			if err := mt.m.Purge(ctx, before); err != nil {
+				mt.log.Error().Err(err).Msg("activity: error purging activities")
+			}
+		case e, ok := <-evs:
+			if !ok {
+				return
+			}
+			mt.materialize(ctx, e)
+		}
+	}
+}
+
+func (mt *Materializer) materialize(ctx context.Context, e interface{}) {
+	switch e := e.(type) {
+	case events.FileUploaded:
+		mt.add(ctx, e.Executant, &Activity{
+			Type:       FileUploaded,
+			Actor:      e.Executant,
+			ObjectType: ObjectTypeFiles,
+			ObjectID:   wrapResourceID(e.ResourceID),
+			ObjectName: path.Base(e.Path),
+			Time:       e.Time,
+		})
+	case events.ShareCreated:
+		mt.add(ctx, e.Sharer, &Activity{
+			Type:       ShareCreated,
+			Actor:      e.Sharer,
+			ObjectType: ObjectTypeFiles,
+			ObjectID:   wrapResourceID(e.ResourceID),
+			ObjectName: e.ResourceName,
+			Time:       e.Time,
+		})
+		// the shares with groups only appear in the timeline of the sharer,
+		// as the members of the groups are not resolved
+		if e.GranteeUserID != nil {
+			mt.add(ctx, e.GranteeUserID, &Activity{
+				Type:       ShareReceived,
+				Actor:      e.Sharer,
+				ObjectType: ObjectTypeFiles,
+				ObjectID:   wrapResourceID(e.ResourceID),
+				ObjectName: e.ResourceName,
+				Time:       e.Time,
+			})
+		}
+	case events.LinkExpired:
+		mt.add(ctx, e.Creator, &Activity{
+			Type:       LinkExpired,
+			ObjectType: ObjectTypeFiles,
+			ObjectName: e.Name,
+			Time:       e.Time,
+		})
+	}
+}
+
+func (mt *Materializer) add(ctx context.Context, uid *userpb.UserId, a *Activity) {
+	if uid == nil {
+		return
+	}
+	if err := mt.m.Add(ctx, uid, a); err != nil {
+		mt.log.Error().Err(err).Str("type", a.Type).Msg("activity: error adding activity")
+	}
+}
+
+// wrapResourceID encodes the id of the resource as the file ids of the
+// ownCloud APIs.
+func wrapResourceID(r *provider.ResourceId) string {
+	if r == nil {
+		return ""
+	}
+	return base64.URLEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", r.StorageId, r.OpaqueId)))
+}
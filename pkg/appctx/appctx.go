@@ -34,3 +34,13 @@ func WithLogger(ctx context.Context, l *zerolog.Logger) context.Context {
 func GetLogger(ctx context.Context) *zerolog.Logger {
 	return zerolog.Ctx(ctx)
 }
+
+// WithLoggerField adds the given field to the logger associated with the
+// given context. Unlike WithLogger, the logger is updated in place, so that
+// the field is also recorded by the middlewares which stored the logger,
+// e.g. the user authenticated further down the chain.
+func WithLoggerField(ctx context.Context, key, val string) {
+	GetLogger(ctx).UpdateContext(func(c zerolog.Context) zerolog.Context {
+		return c.Str(key, val)
+	})
+}
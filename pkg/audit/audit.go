@@ -0,0 +1,100 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package audit records the security-relevant actions performed by the users
+// in an append-only log. Each entry carries the hash of the previous one, so
+// that the removal or the modification of an entry breaks the chain and can
+// be detected with Verify.
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Outcomes of the audited actions.
+const (
+	Success = "success"
+	Failure = "failure"
+	Denied  = "denied"
+)
+
+// Entry is a record of the audit log.
+type Entry struct {
+	Time time.Time `json:"time"`
+	// Action is the name of the audited action, e.g. CreateShare.
+	Action string `json:"action"`
+	// Actor is the user who performed the action, as idp!opaque_id.
+	Actor string `json:"actor,omitempty"`
+	// Impersonator is the user acting on behalf of the actor, if any.
+	Impersonator string `json:"impersonator,omitempty"`
+	// Resource is the resource concerned by the action, if any.
+	Resource string            `json:"resource,omitempty"`
+	Outcome  string            `json:"outcome"`
+	Details  map[string]string `json:"details,omitempty"`
+	// PrevHash is the hash of the previous entry of the log.
+	PrevHash string `json:"prev_hash"`
+	// Hash is the hash of the entry, covering PrevHash.
+	Hash string `json:"hash"`
+}
+
+// Logger is the interface to implement to store the audit log.
+type Logger interface {
+	// Write appends the entry to the log, after chaining it to the last one.
+	Write(ctx context.Context, e *Entry) error
+}
+
+// Sum computes the hash of the entry, which covers every field but Hash.
+func Sum(e *Entry) string {
+	c := *e
+	c.Hash = ""
+	// the fields of a struct are always encoded in the same order and the
+	// keys of a map are sorted, so the encoding is stable.
+	data, _ := json.Marshal(&c)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Chain links the entry to the previous one and computes its hash. The time
+// of the entry is normalized to UTC microseconds, so that the hash can still
+// be verified once the entry is read back from backends with a lower
+// precision.
+func Chain(prevHash string, e *Entry) {
+	e.Time = e.Time.UTC().Truncate(time.Microsecond)
+	e.PrevHash = prevHash
+	e.Hash = Sum(e)
+}
+
+// Verify checks the chain of the given entries, in the order they were
+// written, and returns the index of the first entry which was tampered with,
+// or -1 if the chain is intact.
+func Verify(entries []*Entry) (int, error) {
+	for i, e := range entries {
+		if Sum(e) != e.Hash {
+			return i, fmt.Errorf("audit: hash mismatch in entry %d", i)
+		}
+		if i > 0 && e.PrevHash != entries[i-1].Hash {
+			return i, fmt.Errorf("audit: entry %d is not chained to the previous one", i)
+		}
+	}
+	return -1, nil
+}
@@ -0,0 +1,49 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package audit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	var entries []*Entry
+	prev := ""
+	for _, action := range []string{"Authenticate", "CreateShare", "Delete"} {
+		e := &Entry{Time: time.Now(), Action: action, Actor: "idp!einstein", Outcome: Success}
+		Chain(prev, e)
+		prev = e.Hash
+		entries = append(entries, e)
+	}
+
+	if i, err := Verify(entries); i != -1 || err != nil {
+		t.Fatalf("intact chain reported as tampered at %d: %v", i, err)
+	}
+
+	entries[1].Actor = "idp!marie"
+	if i, _ := Verify(entries); i != 1 {
+		t.Fatalf("modified entry detected at %d, expected 1", i)
+	}
+
+	entries = append(entries[:1], entries[2:]...)
+	if i, _ := Verify(entries); i != 1 {
+		t.Fatalf("removed entry detected at %d, expected 1", i)
+	}
+}
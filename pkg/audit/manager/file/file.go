@@ -0,0 +1,139 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package file
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/cs3org/reva/pkg/audit"
+	"github.com/cs3org/reva/pkg/audit/manager/registry"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	registry.Register("file", New)
+}
+
+type config struct {
+	// File is the path of the log, which holds an entry per line.
+	File string `mapstructure:"file"`
+}
+
+func (c *config) init() {
+	if c.File == "" {
+		c.File = "/var/log/reva/audit.log"
+	}
+}
+
+type logger struct {
+	sync.Mutex
+	f        *os.File
+	lastHash string
+}
+
+func parseConfig(m map[string]interface{}) (*config, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		err = errors.Wrap(err, "error decoding conf")
+		return nil, err
+	}
+	c.init()
+	return c, nil
+}
+
+// New returns an audit logger appending the entries as JSON lines to a file.
+func New(m map[string]interface{}) (audit.Logger, error) {
+	c, err := parseConfig(m)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.File), 0700); err != nil {
+		return nil, errors.Wrap(err, "audit: error creating log directory")
+	}
+	lastHash, err := readLastHash(c.File)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(c.File, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, errors.Wrap(err, "audit: error opening log")
+	}
+
+	return &logger{f: f, lastHash: lastHash}, nil
+}
+
+// readLastHash returns the hash of the last entry of the log, so that the
+// chain is continued across restarts.
+func readLastHash(file string) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", errors.Wrap(err, "audit: error opening log")
+	}
+	defer f.Close()
+
+	var last []byte
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) > 0 {
+			last = append(last[:0], scanner.Bytes()...)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", errors.Wrap(err, "audit: error reading log")
+	}
+	if last == nil {
+		return "", nil
+	}
+
+	e := &audit.Entry{}
+	if err := json.Unmarshal(last, e); err != nil {
+		return "", errors.Wrap(err, "audit: error decoding last entry of the log")
+	}
+	return e.Hash, nil
+}
+
+func (l *logger) Write(ctx context.Context, e *audit.Entry) error {
+	l.Lock()
+	defer l.Unlock()
+
+	audit.Chain(l.lastHash, e)
+	data, err := json.Marshal(e)
+	if err != nil {
+		return errors.Wrap(err, "audit: error encoding entry")
+	}
+	if _, err := l.f.Write(append(data, '\n')); err != nil {
+		return errors.Wrap(err, "audit: error writing entry")
+	}
+	if err := l.f.Sync(); err != nil {
+		return errors.Wrap(err, "audit: error syncing log")
+	}
+	l.lastHash = e.Hash
+	return nil
+}
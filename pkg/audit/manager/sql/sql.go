@@ -0,0 +1,116 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/cs3org/reva/pkg/audit"
+	"github.com/cs3org/reva/pkg/audit/manager/registry"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+
+	// Provides mysql drivers
+	_ "github.com/go-sql-driver/mysql"
+)
+
+func init() {
+	registry.Register("sql", New)
+}
+
+// The logger expects the following table:
+//
+//   audit_log(id, time, action, actor, impersonator, resource, outcome, details, prev_hash, hash)
+//
+// where id is auto-incremented, time is a DATETIME(6) and details holds the
+// JSON encoding of the details of the entry. The services sharing the table
+// extend the same chain.
+
+type config struct {
+	DbUsername string `mapstructure:"db_username"`
+	DbPassword string `mapstructure:"db_password"`
+	DbHost     string `mapstructure:"db_host"`
+	DbPort     int    `mapstructure:"db_port"`
+	DbName     string `mapstructure:"db_name"`
+}
+
+func (c *config) init() {
+	if c.DbPort == 0 {
+		c.DbPort = 3306
+	}
+}
+
+type logger struct {
+	db *sql.DB
+}
+
+func parseConfig(m map[string]interface{}) (*config, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		err = errors.Wrap(err, "error decoding conf")
+		return nil, err
+	}
+	c.init()
+	return c, nil
+}
+
+// New returns an audit logger storing the entries in a SQL database.
+func New(m map[string]interface{}) (audit.Logger, error) {
+	c, err := parseConfig(m)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s:%d)/%s", c.DbUsername, c.DbPassword, c.DbHost, c.DbPort, c.DbName))
+	if err != nil {
+		return nil, errors.Wrap(err, "sql: error opening connection to the database")
+	}
+
+	return &logger{db: db}, nil
+}
+
+func (l *logger) Write(ctx context.Context, e *audit.Entry) error {
+	details, err := json.Marshal(e.Details)
+	if err != nil {
+		return errors.Wrap(err, "audit: error encoding details")
+	}
+
+	tx, err := l.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// lock the last entry, so that concurrent writers do not fork the chain
+	var prevHash string
+	err = tx.QueryRowContext(ctx, "SELECT hash FROM audit_log ORDER BY id DESC LIMIT 1 FOR UPDATE").Scan(&prevHash)
+	if err != nil && err != sql.ErrNoRows {
+		return err
+	}
+
+	audit.Chain(prevHash, e)
+	query := "INSERT INTO audit_log(time, action, actor, impersonator, resource, outcome, details, prev_hash, hash) VALUES(?,?,?,?,?,?,?,?,?)"
+	if _, err := tx.ExecContext(ctx, query, e.Time, e.Action, e.Actor, e.Impersonator, e.Resource, e.Outcome, string(details), e.PrevHash, e.Hash); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
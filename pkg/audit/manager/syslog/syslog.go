@@ -0,0 +1,97 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package syslog
+
+import (
+	"context"
+	"encoding/json"
+	"log/syslog"
+	"sync"
+
+	"github.com/cs3org/reva/pkg/audit"
+	"github.com/cs3org/reva/pkg/audit/manager/registry"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	registry.Register("syslog", New)
+}
+
+type config struct {
+	// Network and Address are the ones of the syslog server, e.g. udp and
+	// localhost:514. If empty, the local syslog daemon is used.
+	Network string `mapstructure:"network"`
+	Address string `mapstructure:"address"`
+	Tag     string `mapstructure:"tag"`
+}
+
+func (c *config) init() {
+	if c.Tag == "" {
+		c.Tag = "reva-audit"
+	}
+}
+
+type logger struct {
+	sync.Mutex
+	w        *syslog.Writer
+	lastHash string
+}
+
+func parseConfig(m map[string]interface{}) (*config, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		err = errors.Wrap(err, "error decoding conf")
+		return nil, err
+	}
+	c.init()
+	return c, nil
+}
+
+// New returns an audit logger sending the entries as JSON messages to syslog,
+// with the auth facility. As the log cannot be read back, the chain starts
+// over every time the service is restarted.
+func New(m map[string]interface{}) (audit.Logger, error) {
+	c, err := parseConfig(m)
+	if err != nil {
+		return nil, err
+	}
+
+	w, err := syslog.Dial(c.Network, c.Address, syslog.LOG_AUTH|syslog.LOG_NOTICE, c.Tag)
+	if err != nil {
+		return nil, errors.Wrap(err, "audit: error connecting to syslog")
+	}
+	return &logger{w: w}, nil
+}
+
+func (l *logger) Write(ctx context.Context, e *audit.Entry) error {
+	l.Lock()
+	defer l.Unlock()
+
+	audit.Chain(l.lastHash, e)
+	data, err := json.Marshal(e)
+	if err != nil {
+		return errors.Wrap(err, "audit: error encoding entry")
+	}
+	if err := l.w.Notice(string(data)); err != nil {
+		return errors.Wrap(err, "audit: error sending entry to syslog")
+	}
+	l.lastHash = e.Hash
+	return nil
+}
@@ -0,0 +1,179 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package adminimpersonation
+
+import (
+	"context"
+	"fmt"
+
+	authpb "github.com/cs3org/go-cs3apis/cs3/auth/provider/v1beta1"
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/auth"
+	"github.com/cs3org/reva/pkg/auth/manager/registry"
+	"github.com/cs3org/reva/pkg/auth/scope"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/permission"
+	permregistry "github.com/cs3org/reva/pkg/permission/manager/registry"
+	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
+	"github.com/cs3org/reva/pkg/sharedconf"
+	"github.com/cs3org/reva/pkg/token"
+	tokenregistry "github.com/cs3org/reva/pkg/token/manager/registry"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	registry.Register("adminimpersonation", New)
+}
+
+type config struct {
+	GatewayAddr   string                            `mapstructure:"gateway_addr"`
+	TokenManager  string                            `mapstructure:"token_manager"`
+	TokenManagers map[string]map[string]interface{} `mapstructure:"token_managers"`
+	// Admins is the list of usernames allowed to impersonate other users.
+	Admins []string `mapstructure:"admins"`
+	// AdminGroups is the list of groups whose members are allowed to impersonate other users.
+	AdminGroups []string `mapstructure:"admin_groups"`
+	// PermissionDriver, if set, is used to check whether the users are allowed
+	// to impersonate other users instead of the lists of admins.
+	PermissionDriver  string                            `mapstructure:"permission_driver"`
+	PermissionDrivers map[string]map[string]interface{} `mapstructure:"permission_drivers"`
+}
+
+func (c *config) init() {
+	if c.TokenManager == "" {
+		c.TokenManager = "jwt"
+	}
+	c.GatewayAddr = sharedconf.GetGatewaySVC(c.GatewayAddr)
+}
+
+type manager struct {
+	c            *config
+	tokenManager token.Manager
+	pm           permission.Manager
+}
+
+func parseConfig(m map[string]interface{}) (*config, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		err = errors.Wrap(err, "error decoding conf")
+		return nil, err
+	}
+	return c, nil
+}
+
+// New returns an auth manager that allows administrators to obtain a token
+// acting as another user. The client id is the username of the user to
+// impersonate and the client secret is the access token of the administrator.
+func New(m map[string]interface{}) (auth.Manager, error) {
+	c, err := parseConfig(m)
+	if err != nil {
+		return nil, err
+	}
+	c.init()
+
+	f, ok := tokenregistry.NewFuncs[c.TokenManager]
+	if !ok {
+		return nil, errtypes.NotFound("adminimpersonation: token manager does not exist: " + c.TokenManager)
+	}
+	tm, err := f(c.TokenManagers[c.TokenManager])
+	if err != nil {
+		return nil, errors.Wrap(err, "adminimpersonation: error creating token manager")
+	}
+
+	var pm permission.Manager
+	if c.PermissionDriver != "" {
+		f, ok := permregistry.NewFuncs[c.PermissionDriver]
+		if !ok {
+			return nil, errtypes.NotFound("adminimpersonation: permission driver does not exist: " + c.PermissionDriver)
+		}
+		if pm, err = f(c.PermissionDrivers[c.PermissionDriver]); err != nil {
+			return nil, errors.Wrap(err, "adminimpersonation: error creating permission manager")
+		}
+	}
+
+	return &manager{c: c, tokenManager: tm, pm: pm}, nil
+}
+
+func (m *manager) Authenticate(ctx context.Context, username, adminToken string) (*userpb.User, map[string]*authpb.Scope, error) {
+	log := appctx.GetLogger(ctx)
+
+	admin, adminScope, err := m.tokenManager.DismantleToken(ctx, adminToken)
+	if err != nil {
+		return nil, nil, errtypes.InvalidCredentials("adminimpersonation: invalid admin token")
+	}
+
+	// impersonation tokens cannot be used to impersonate somebody else,
+	// and neither can tokens with a restricted scope.
+	if _, ok := scope.GetImpersonator(adminScope); ok {
+		return nil, nil, errtypes.PermissionDenied("adminimpersonation: chained impersonation is not allowed")
+	}
+	if s, ok := adminScope["user"]; !ok || s.Role != authpb.Role_ROLE_OWNER {
+		return nil, nil, errtypes.PermissionDenied("adminimpersonation: admin token does not have owner scope")
+	}
+
+	allowed, err := m.isAdmin(ctx, admin)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !allowed {
+		return nil, nil, errtypes.PermissionDenied(fmt.Sprintf("adminimpersonation: user %s is not allowed to impersonate", admin.Username))
+	}
+
+	gtw, err := pool.GetGatewayServiceClient(m.c.GatewayAddr)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	res, err := gtw.GetUserByClaim(ctx, &userpb.GetUserByClaimRequest{
+		Claim: "username",
+		Value: username,
+	})
+	switch {
+	case err != nil:
+		return nil, nil, err
+	case res.Status.Code == rpc.Code_CODE_NOT_FOUND:
+		return nil, nil, errtypes.NotFound(res.Status.Message)
+	case res.Status.Code != rpc.Code_CODE_OK:
+		return nil, nil, errtypes.InternalError(res.Status.Message)
+	}
+
+	scopes, err := scope.GetImpersonationScope(admin.Id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	log.Info().
+		Str("impersonator", admin.Username).
+		Interface("impersonator_id", admin.Id).
+		Str("user", res.User.Username).
+		Interface("user_id", res.User.Id).
+		Msg("adminimpersonation: issuing impersonation token")
+
+	return res.User, scopes, nil
+}
+
+func (m *manager) isAdmin(ctx context.Context, u *userpb.User) (bool, error) {
+	if m.pm != nil {
+		return permission.CheckPermission(ctx, m.pm, u, permission.ImpersonateUsers)
+	}
+	return permission.IsAdmin(ctx, nil, u, m.c.Admins, m.c.AdminGroups)
+}
@@ -0,0 +1,125 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package adminimpersonation
+
+import (
+	"context"
+	"testing"
+
+	authpb "github.com/cs3org/go-cs3apis/cs3/auth/provider/v1beta1"
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/auth/scope"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/token/manager/jwt"
+)
+
+var tokenManagers = map[string]interface{}{
+	"jwt": map[string]interface{}{"secret": "changemeplease"},
+}
+
+func TestAuthenticateScopes(t *testing.T) {
+	ctx := context.Background()
+	m, err := New(map[string]interface{}{
+		"gateway_addr":   "localhost:19000",
+		"token_managers": tokenManagers,
+		"admins":         []string{"admin"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tm, err := jwt.New(tokenManagers["jwt"].(map[string]interface{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	admin := &userpb.User{Id: &userpb.UserId{OpaqueId: "admin-id"}, Username: "admin"}
+	owner, _ := scope.GetOwnerScope()
+	impersonation, _ := scope.GetImpersonationScope(&userpb.UserId{OpaqueId: "other-admin-id"})
+	resource, _ := scope.GetResourceInfoScope(&provider.ResourceInfo{Path: "/home/admin"}, authpb.Role_ROLE_OWNER)
+	viewer, _ := scope.GetOwnerScope()
+	viewer["user"].Role = authpb.Role_ROLE_VIEWER
+
+	tests := []struct {
+		name  string
+		scope map[string]*authpb.Scope
+	}{
+		{"impersonation token", impersonation},
+		{"resource token", resource},
+		{"viewer token", viewer},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tkn, err := tm.MintToken(ctx, admin, tt.scope)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, _, err := m.Authenticate(ctx, "einstein", tkn); err == nil {
+				t.Fatal("the token was allowed to impersonate")
+			} else if _, ok := err.(errtypes.PermissionDenied); !ok {
+				t.Fatalf("got %v, wanted a permission denied error", err)
+			}
+		})
+	}
+
+	t.Run("not an admin", func(t *testing.T) {
+		tkn, err := tm.MintToken(ctx, &userpb.User{Id: &userpb.UserId{OpaqueId: "einstein-id"}, Username: "einstein"}, owner)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := m.Authenticate(ctx, "marie", tkn); err == nil {
+			t.Fatal("a user who is not an admin was allowed to impersonate")
+		} else if _, ok := err.(errtypes.PermissionDenied); !ok {
+			t.Fatalf("got %v, wanted a permission denied error", err)
+		}
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		if _, _, err := m.Authenticate(ctx, "einstein", "invalid"); err == nil {
+			t.Fatal("an invalid token was allowed to impersonate")
+		} else if _, ok := err.(errtypes.InvalidCredentials); !ok {
+			t.Fatalf("got %v, wanted an invalid credentials error", err)
+		}
+	})
+}
+
+func TestIsAdmin(t *testing.T) {
+	m := &manager{c: &config{Admins: []string{"admin"}, AdminGroups: []string{"support"}}}
+	tests := []struct {
+		name string
+		user *userpb.User
+		want bool
+	}{
+		{"listed user", &userpb.User{Username: "admin"}, true},
+		{"member of an admin group", &userpb.User{Username: "marie", Groups: []string{"physics", "support"}}, true},
+		{"other user", &userpb.User{Username: "einstein", Groups: []string{"physics"}}, false},
+		{"group named like an admin", &userpb.User{Username: "support"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := m.isAdmin(context.Background(), tt.user)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("isAdmin(%s) = %v, wanted %v", tt.user.Username, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package clientcredentials
+
+import (
+	"context"
+	"crypto/subtle"
+	"strings"
+
+	authpb "github.com/cs3org/go-cs3apis/cs3/auth/provider/v1beta1"
+	user "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	"github.com/cs3org/reva/pkg/auth"
+	"github.com/cs3org/reva/pkg/auth/manager/registry"
+	"github.com/cs3org/reva/pkg/auth/scope"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
+	"github.com/cs3org/reva/pkg/sharedconf"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func init() {
+	registry.Register("clientcredentials", New)
+}
+
+// client is an OAuth2 client allowed to obtain tokens.
+type client struct {
+	// Secret is the secret of the client, either in clear or as a bcrypt hash.
+	Secret string `mapstructure:"secret"`
+	// Username is the service account the client acts as. If empty, the
+	// client acts as a machine user named after the client id.
+	Username string `mapstructure:"username"`
+	// Paths restricts the HTTP endpoints and the storage paths the client
+	// can reach.
+	Paths []string `mapstructure:"paths"`
+}
+
+type config struct {
+	GatewayAddr string             `mapstructure:"gateway_addr"`
+	Idp         string             `mapstructure:"idp"`
+	Clients     map[string]*client `mapstructure:"clients"`
+}
+
+func (c *config) init() {
+	if c.Idp == "" {
+		c.Idp = "machine"
+	}
+	c.GatewayAddr = sharedconf.GetGatewaySVC(c.GatewayAddr)
+}
+
+type manager struct {
+	c *config
+}
+
+func parseConfig(m map[string]interface{}) (*config, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		err = errors.Wrap(err, "error decoding conf")
+		return nil, err
+	}
+	return c, nil
+}
+
+// New returns an auth manager authenticating OAuth2 clients with the client
+// credentials flow. The tokens it issues carry a machine scope.
+func New(m map[string]interface{}) (auth.Manager, error) {
+	c, err := parseConfig(m)
+	if err != nil {
+		return nil, err
+	}
+	c.init()
+	return &manager{c: c}, nil
+}
+
+func (m *manager) Authenticate(ctx context.Context, clientID, clientSecret string) (*user.User, map[string]*authpb.Scope, error) {
+	cl, ok := m.c.Clients[clientID]
+	if !ok || !checkSecret(cl.Secret, clientSecret) {
+		return nil, nil, errtypes.InvalidCredentials(clientID)
+	}
+
+	u, err := m.getUser(ctx, clientID, cl)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	scopes, err := scope.GetMachineScope(clientID, cl.Paths)
+	if err != nil {
+		return nil, nil, err
+	}
+	return u, scopes, nil
+}
+
+func (m *manager) getUser(ctx context.Context, clientID string, cl *client) (*user.User, error) {
+	if cl.Username == "" {
+		return &user.User{
+			Id: &user.UserId{
+				Idp:      m.c.Idp,
+				OpaqueId: clientID,
+			},
+			Username:    clientID,
+			DisplayName: clientID,
+		}, nil
+	}
+
+	gtw, err := pool.GetGatewayServiceClient(m.c.GatewayAddr)
+	if err != nil {
+		return nil, err
+	}
+	res, err := gtw.GetUserByClaim(ctx, &user.GetUserByClaimRequest{
+		Claim: "username",
+		Value: cl.Username,
+	})
+	switch {
+	case err != nil:
+		return nil, err
+	case res.Status.Code == rpc.Code_CODE_NOT_FOUND:
+		return nil, errtypes.NotFound(res.Status.Message)
+	case res.Status.Code != rpc.Code_CODE_OK:
+		return nil, errtypes.InternalError(res.Status.Message)
+	}
+	return res.User, nil
+}
+
+func checkSecret(expected, secret string) bool {
+	if strings.HasPrefix(expected, "$2") {
+		return bcrypt.CompareHashAndPassword([]byte(expected), []byte(secret)) == nil
+	}
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(secret)) == 1
+}
@@ -0,0 +1,96 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package guest
+
+import (
+	"context"
+
+	authpb "github.com/cs3org/go-cs3apis/cs3/auth/provider/v1beta1"
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/pkg/auth"
+	"github.com/cs3org/reva/pkg/auth/manager/registry"
+	"github.com/cs3org/reva/pkg/auth/scope"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/guest"
+	guestregistry "github.com/cs3org/reva/pkg/guest/manager/registry"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	registry.Register("guest", New)
+}
+
+type config struct {
+	Driver  string                            `mapstructure:"driver"`
+	Drivers map[string]map[string]interface{} `mapstructure:"drivers"`
+}
+
+func (c *config) init() {
+	if c.Driver == "" {
+		c.Driver = "json"
+	}
+}
+
+type manager struct {
+	gm guest.Manager
+}
+
+func parseConfig(m map[string]interface{}) (*config, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		err = errors.Wrap(err, "error decoding conf")
+		return nil, err
+	}
+	return c, nil
+}
+
+// New returns an auth manager that authenticates activated guest accounts
+// with their email address and password.
+func New(m map[string]interface{}) (auth.Manager, error) {
+	c, err := parseConfig(m)
+	if err != nil {
+		return nil, err
+	}
+	c.init()
+
+	f, ok := guestregistry.NewFuncs[c.Driver]
+	if !ok {
+		return nil, errtypes.NotFound("guest: driver not found: " + c.Driver)
+	}
+	gm, err := f(c.Drivers[c.Driver])
+	if err != nil {
+		return nil, err
+	}
+
+	return &manager{gm: gm}, nil
+}
+
+func (m *manager) Authenticate(ctx context.Context, mail, password string) (*userpb.User, map[string]*authpb.Scope, error) {
+	u, err := m.gm.Authenticate(ctx, mail, password)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s, err := scope.GetGuestScope(u.Id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return u, s, nil
+}
@@ -20,7 +20,10 @@ package loader
 
 import (
 	// Load core authentication managers.
+	_ "github.com/cs3org/reva/pkg/auth/manager/adminimpersonation"
+	_ "github.com/cs3org/reva/pkg/auth/manager/clientcredentials"
 	_ "github.com/cs3org/reva/pkg/auth/manager/demo"
+	_ "github.com/cs3org/reva/pkg/auth/manager/guest"
 	_ "github.com/cs3org/reva/pkg/auth/manager/impersonator"
 	_ "github.com/cs3org/reva/pkg/auth/manager/json"
 	_ "github.com/cs3org/reva/pkg/auth/manager/ldap"
@@ -0,0 +1,121 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package oidc
+
+import (
+	"context"
+	"time"
+
+	"github.com/ReneKroon/ttlcache/v2"
+	user "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+)
+
+// userAttributes holds the attributes of a user looked up in the user provider
+// which complement the claims obtained from the OIDC provider.
+type userAttributes struct {
+	DisplayName string
+	Groups      []string
+	Opaque      map[string]*types.OpaqueEntry
+}
+
+func newUserCache(ttl int) *ttlcache.Cache {
+	if ttl <= 0 {
+		return nil
+	}
+	c := ttlcache.NewCache()
+	_ = c.SetTTL(time.Duration(ttl) * time.Second)
+	c.SkipTTLExtensionOnHit(true)
+	return c
+}
+
+// getUserAttributes returns the attributes of the user from the user provider,
+// caching them so that verifying a token does not trigger a lookup in the
+// backend (LDAP, REST, ...) on every request.
+func (am *mgr) getUserAttributes(ctx context.Context, uid *user.UserId) (*userAttributes, error) {
+	key := uid.Idp + "!" + uid.OpaqueId
+	if am.userCache != nil {
+		if v, err := am.userCache.Get(key); err == nil {
+			return v.(*userAttributes), nil
+		}
+	}
+
+	gwc, err := pool.GetGatewayServiceClient(am.c.GatewaySvc)
+	if err != nil {
+		return nil, errors.Wrap(err, "oidc: error getting gateway grpc client")
+	}
+
+	attrs := &userAttributes{}
+	getUserResp, err := gwc.GetUser(ctx, &user.GetUserRequest{UserId: uid})
+	if err != nil {
+		return nil, errors.Wrap(err, "oidc: error getting user")
+	}
+
+	switch getUserResp.Status.Code {
+	case rpc.Code_CODE_OK:
+		u := getUserResp.User
+		attrs.DisplayName = u.DisplayName
+		attrs.Groups = u.Groups
+		if u.Opaque != nil {
+			attrs.Opaque = u.Opaque.Map
+		}
+	case rpc.Code_CODE_NOT_FOUND:
+		// the user provider might only know about the groups of the user
+		getGroupsResp, err := gwc.GetUserGroups(ctx, &user.GetUserGroupsRequest{
+			UserId: uid,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "oidc: error getting user groups")
+		}
+		if getGroupsResp.Status.Code != rpc.Code_CODE_OK {
+			return nil, errors.New("oidc: grpc getting user groups failed: " + getGroupsResp.Status.Message)
+		}
+		attrs.Groups = getGroupsResp.Groups
+	default:
+		return nil, errors.New("oidc: grpc getting user failed: " + getUserResp.Status.Message)
+	}
+
+	if am.userCache != nil {
+		_ = am.userCache.Set(key, attrs)
+	}
+	return attrs, nil
+}
+
+// enrich completes the user built from the OIDC claims with the attributes
+// known by the user provider. Claims take precedence over the opaque entries
+// of the provider, the display name of the provider over the one in the claims.
+// The attributes are shared by the cache, the user gets copies of them.
+func (attrs *userAttributes) enrich(u *user.User) {
+	u.Groups = append([]string(nil), attrs.Groups...)
+	if attrs.DisplayName != "" {
+		u.DisplayName = attrs.DisplayName
+	}
+	if u.Opaque == nil {
+		u.Opaque = &types.Opaque{Map: map[string]*types.OpaqueEntry{}}
+	}
+	for k, v := range attrs.Opaque {
+		if _, ok := u.Opaque.Map[k]; !ok {
+			u.Opaque.Map[k] = proto.Clone(v).(*types.OpaqueEntry)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package oidc
+
+import (
+	"testing"
+
+	user "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+)
+
+func TestEnrichCopiesTheAttributes(t *testing.T) {
+	attrs := &userAttributes{
+		Groups: []string{"physics"},
+		Opaque: map[string]*types.OpaqueEntry{"quota": {Decoder: "plain", Value: []byte("10")}},
+	}
+
+	u := &user.User{}
+	attrs.enrich(u)
+	u.Groups[0] = "admins"
+	u.Opaque.Map["quota"].Value = []byte("0")
+
+	if attrs.Groups[0] != "physics" {
+		t.Error("changing the groups of the user changed the cached attributes")
+	}
+	if string(attrs.Opaque["quota"].Value) != "10" {
+		t.Error("changing the opaque of the user changed the cached attributes")
+	}
+}
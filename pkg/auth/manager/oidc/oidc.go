@@ -25,15 +25,14 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/ReneKroon/ttlcache/v2"
 	oidc "github.com/coreos/go-oidc"
 	authpb "github.com/cs3org/go-cs3apis/cs3/auth/provider/v1beta1"
 	user "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
-	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
 	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
 	"github.com/cs3org/reva/pkg/auth"
 	"github.com/cs3org/reva/pkg/auth/manager/registry"
 	"github.com/cs3org/reva/pkg/auth/scope"
-	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
 	"github.com/cs3org/reva/pkg/rhttp"
 	"github.com/cs3org/reva/pkg/sharedconf"
 	"github.com/mitchellh/mapstructure"
@@ -47,17 +46,19 @@ func init() {
 }
 
 type mgr struct {
-	provider *oidc.Provider // cached on first request
-	c        *config
+	provider  *oidc.Provider // cached on first request
+	c         *config
+	userCache *ttlcache.Cache
 }
 
 type config struct {
-	Insecure   bool   `mapstructure:"insecure" docs:"false;Whether to skip certificate checks when sending requests."`
-	Issuer     string `mapstructure:"issuer" docs:";The issuer of the OIDC token."`
-	IDClaim    string `mapstructure:"id_claim" docs:"sub;The claim containing the ID of the user."`
-	UIDClaim   string `mapstructure:"uid_claim" docs:";The claim containing the UID of the user."`
-	GIDClaim   string `mapstructure:"gid_claim" docs:";The claim containing the GID of the user."`
-	GatewaySvc string `mapstructure:"gatewaysvc" docs:";The endpoint at which the GRPC gateway is exposed."`
+	Insecure     bool   `mapstructure:"insecure" docs:"false;Whether to skip certificate checks when sending requests."`
+	Issuer       string `mapstructure:"issuer" docs:";The issuer of the OIDC token."`
+	IDClaim      string `mapstructure:"id_claim" docs:"sub;The claim containing the ID of the user."`
+	UIDClaim     string `mapstructure:"uid_claim" docs:";The claim containing the UID of the user."`
+	GIDClaim     string `mapstructure:"gid_claim" docs:";The claim containing the GID of the user."`
+	GatewaySvc   string `mapstructure:"gatewaysvc" docs:";The endpoint at which the GRPC gateway is exposed."`
+	UserCacheTTL int    `mapstructure:"user_cache_ttl" docs:"60;The time in seconds for which the user information obtained from the user provider is cached. Set to a negative value to disable the cache."`
 }
 
 func (c *config) init() {
@@ -66,6 +67,10 @@ func (c *config) init() {
 		c.IDClaim = "sub"
 	}
 
+	if c.UserCacheTTL == 0 {
+		c.UserCacheTTL = 60
+	}
+
 	c.GatewaySvc = sharedconf.GetGatewaySVC(c.GatewaySvc)
 }
 
@@ -86,7 +91,7 @@ func New(m map[string]interface{}) (auth.Manager, error) {
 	}
 	c.init()
 
-	return &mgr{c: c}, nil
+	return &mgr{c: c, userCache: newUserCache(c.UserCacheTTL)}, nil
 }
 
 // the clientID it would be empty as we only need to validate the clientSecret variable
@@ -157,20 +162,6 @@ func (am *mgr) Authenticate(ctx context.Context, clientID, clientSecret string)
 		OpaqueId: claims[am.c.IDClaim].(string), // a stable non reassignable id
 		Idp:      claims["issuer"].(string),     // in the scope of this issuer
 	}
-	gwc, err := pool.GetGatewayServiceClient(am.c.GatewaySvc)
-	if err != nil {
-		return nil, nil, errors.Wrap(err, "oidc: error getting gateway grpc client")
-	}
-	getGroupsResp, err := gwc.GetUserGroups(ctx, &user.GetUserGroupsRequest{
-		UserId: userID,
-	})
-	if err != nil {
-		return nil, nil, errors.Wrap(err, "oidc: error getting user groups")
-	}
-	if getGroupsResp.Status.Code != rpc.Code_CODE_OK {
-		return nil, nil, errors.Wrap(err, "oidc: grpc getting user groups failed")
-	}
-
 	u := &user.User{
 		Id:       userID,
 		Username: claims["preferred_username"].(string),
@@ -178,13 +169,18 @@ func (am *mgr) Authenticate(ctx context.Context, clientID, clientSecret string)
 		// to the admin to choose what claim provides the groups.
 		// TODO(labkode) ... use all claims from oidc?
 		// TODO(labkode): do like K8s does it: https://github.com/kubernetes/kubernetes/blob/master/staging/src/k8s.io/apiserver/plugin/pkg/authenticator/token/oidc/oidc.go
-		Groups:       getGroupsResp.Groups,
 		Mail:         claims["email"].(string),
 		MailVerified: claims["email_verified"].(bool),
 		DisplayName:  claims["name"].(string),
 		Opaque:       opaqueObj,
 	}
 
+	attrs, err := am.getUserAttributes(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	attrs.enrich(u)
+
 	scope, err := scope.GetOwnerScope()
 	if err != nil {
 		return nil, nil, err
@@ -0,0 +1,109 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package scope
+
+import (
+	"fmt"
+	"strings"
+
+	authpb "github.com/cs3org/go-cs3apis/cs3/auth/provider/v1beta1"
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	collaboration "github.com/cs3org/go-cs3apis/cs3/sharing/collaboration/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	registry "github.com/cs3org/go-cs3apis/cs3/storage/registry/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/utils"
+)
+
+func guestScope(scope *authpb.Scope, resource interface{}) (bool, error) {
+	switch v := resource.(type) {
+	// Guests have no home of their own: the storage providers only let them
+	// access the resources whose grants were added by the shares they received.
+	case *registry.GetStorageProvidersRequest:
+		return true, nil
+	case *provider.StatRequest:
+		return true, nil
+	case *provider.ListContainerRequest:
+		return true, nil
+	case *provider.InitiateFileDownloadRequest:
+		return true, nil
+	case *provider.CreateContainerRequest:
+		return true, nil
+	case *provider.DeleteRequest:
+		return true, nil
+	case *provider.MoveRequest:
+		return true, nil
+	case *provider.InitiateFileUploadRequest:
+		return true, nil
+
+	case *collaboration.ListReceivedSharesRequest:
+		return true, nil
+	case *collaboration.GetReceivedShareRequest:
+		return true, nil
+	case *collaboration.UpdateReceivedShareRequest:
+		return true, nil
+
+	case string:
+		return checkGuestPath(v), nil
+	}
+
+	return false, errtypes.InternalError(fmt.Sprintf("resource type assertion failed: %+v", resource))
+}
+
+func checkGuestPath(path string) bool {
+	paths := []string{
+		"/dataprovider",
+		"/data",
+		"/remote.php/webdav",
+		"/remote.php/dav/files",
+		"/webdav",
+		"/dav/files",
+		"/ocs/v1.php/cloud/user",
+		"/ocs/v2.php/cloud/user",
+		"/ocs/v1.php/cloud/capabilities",
+		"/ocs/v2.php/cloud/capabilities",
+		"/ocs/v1.php/apps/files_sharing/api/v1/shares",
+		"/ocs/v2.php/apps/files_sharing/api/v1/shares",
+	}
+	for _, p := range paths {
+		if strings.HasPrefix(path, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetGuestScope returns the restricted scope assigned to guest accounts, which
+// only allows access to the resources shared with them.
+func GetGuestScope(uid *userpb.UserId) (map[string]*authpb.Scope, error) {
+	val, err := utils.MarshalProtoV1ToJSON(uid)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]*authpb.Scope{
+		"guest": &authpb.Scope{
+			Resource: &types.OpaqueEntry{
+				Decoder: "json",
+				Value:   val,
+			},
+			Role: authpb.Role_ROLE_VIEWER,
+		},
+	}, nil
+}
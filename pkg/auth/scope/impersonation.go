@@ -0,0 +1,69 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package scope
+
+import (
+	authpb "github.com/cs3org/go-cs3apis/cs3/auth/provider/v1beta1"
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	"github.com/cs3org/reva/pkg/utils"
+)
+
+// impersonatorKey is the key of the scope entry recording the user who
+// obtained a token on behalf of the token owner.
+const impersonatorKey = "impersonator"
+
+// GetImpersonationScope returns the scope to be attached to a token minted for
+// a user on behalf of the given impersonator. The token grants the same access
+// as an owner token, and additionally carries the impersonator identity so that
+// every call made with it can be attributed to both users.
+func GetImpersonationScope(impersonator *userpb.UserId) (map[string]*authpb.Scope, error) {
+	scopes, err := GetOwnerScope()
+	if err != nil {
+		return nil, err
+	}
+
+	val, err := utils.MarshalProtoV1ToJSON(impersonator)
+	if err != nil {
+		return nil, err
+	}
+	scopes[impersonatorKey] = &authpb.Scope{
+		Resource: &types.OpaqueEntry{
+			Decoder: "json",
+			Value:   val,
+		},
+		Role: authpb.Role_ROLE_OWNER,
+	}
+	return scopes, nil
+}
+
+// GetImpersonator returns the id of the user who minted the token carrying the
+// given scope on behalf of its owner, if the token was obtained through
+// impersonation.
+func GetImpersonator(scopes map[string]*authpb.Scope) (*userpb.UserId, bool) {
+	s, ok := scopes[impersonatorKey]
+	if !ok || s.Resource == nil {
+		return nil, false
+	}
+	var uid userpb.UserId
+	if err := utils.UnmarshalJSONToProtoV1(s.Resource.Value, &uid); err != nil {
+		return nil, false
+	}
+	return &uid, true
+}
@@ -0,0 +1,137 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package scope
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	authpb "github.com/cs3org/go-cs3apis/cs3/auth/provider/v1beta1"
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	registry "github.com/cs3org/go-cs3apis/cs3/storage/registry/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	"github.com/cs3org/reva/pkg/errtypes"
+)
+
+// machineKey is the key of the scope granted to tokens issued to services
+// authenticating with the OAuth2 client credentials flow.
+const machineKey = "machine"
+
+// MachineResource is the resource of a machine scope.
+type MachineResource struct {
+	// ClientID is the OAuth2 client the token was issued to.
+	ClientID string `json:"client_id"`
+	// Paths restricts the HTTP endpoints and the storage paths the client
+	// can reach. If empty, the client can reach every endpoint and resource.
+	Paths []string `json:"paths,omitempty"`
+}
+
+func machineScope(scope *authpb.Scope, resource interface{}) (bool, error) {
+	var m MachineResource
+	if err := json.Unmarshal(scope.Resource.Value, &m); err != nil {
+		return false, err
+	}
+
+	switch v := resource.(type) {
+	case string:
+		return checkMachinePath(&m, v), nil
+
+	// The gRPC calls made on behalf of the client by the HTTP services it can
+	// reach. Anything else is denied.
+	case *registry.GetStorageProvidersRequest:
+		return checkMachineRef(&m, v.GetRef()), nil
+	case *provider.GetHomeRequest:
+		return true, nil
+	case *provider.StatRequest:
+		return checkMachineRef(&m, v.GetRef()), nil
+	case *provider.ListContainerRequest:
+		return checkMachineRef(&m, v.GetRef()), nil
+	case *provider.InitiateFileDownloadRequest:
+		return checkMachineRef(&m, v.GetRef()), nil
+	case *provider.CreateContainerRequest:
+		return checkMachineRef(&m, v.GetRef()), nil
+	case *provider.DeleteRequest:
+		return checkMachineRef(&m, v.GetRef()), nil
+	case *provider.MoveRequest:
+		return checkMachineRef(&m, v.GetSource()) && checkMachineRef(&m, v.GetDestination()), nil
+	case *provider.InitiateFileUploadRequest:
+		return checkMachineRef(&m, v.GetRef()), nil
+	case *userpb.GetUserRequest:
+		return true, nil
+	}
+
+	return false, errtypes.InternalError(fmt.Sprintf("resource type assertion failed: %+v", resource))
+}
+
+func checkMachinePath(m *MachineResource, path string) bool {
+	if len(m.Paths) == 0 {
+		return true
+	}
+	for _, p := range m.Paths {
+		if strings.HasPrefix(path, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func checkMachineRef(m *MachineResource, ref *provider.Reference) bool {
+	if len(m.Paths) == 0 {
+		return true
+	}
+	// the references by id cannot be matched against the paths
+	if ref.GetId() != nil {
+		return false
+	}
+	return checkMachinePath(m, ref.GetPath())
+}
+
+// GetMachineScope returns the scope of a token issued to the given OAuth2
+// client, restricted to the given HTTP path prefixes.
+func GetMachineScope(clientID string, paths []string) (map[string]*authpb.Scope, error) {
+	val, err := json.Marshal(&MachineResource{ClientID: clientID, Paths: paths})
+	if err != nil {
+		return nil, err
+	}
+	return map[string]*authpb.Scope{
+		machineKey: &authpb.Scope{
+			Resource: &types.OpaqueEntry{
+				Decoder: "json",
+				Value:   val,
+			},
+			Role: authpb.Role_ROLE_OWNER,
+		},
+	}, nil
+}
+
+// GetMachineClientID returns the OAuth2 client a token carrying the given
+// scope was issued to, if it was obtained through the client credentials flow.
+func GetMachineClientID(scopes map[string]*authpb.Scope) (string, bool) {
+	s, ok := scopes[machineKey]
+	if !ok || s.Resource == nil {
+		return "", false
+	}
+	var m MachineResource
+	if err := json.Unmarshal(s.Resource.Value, &m); err != nil {
+		return "", false
+	}
+	return m.ClientID, true
+}
@@ -31,6 +31,8 @@ var supportedScopes = map[string]Verifier{
 	"user":         userScope,
 	"publicshare":  publicshareScope,
 	"resourceinfo": resourceinfoScope,
+	"guest":        guestScope,
+	"machine":      machineScope,
 }
 
 // VerifyScope is the function to be called when dismantling tokens to check if
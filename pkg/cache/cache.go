@@ -0,0 +1,40 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package cache provides caches which can be shared by the replicas of the
+// services, and typed caches for the values commonly cached by them.
+package cache
+
+import (
+	"context"
+	"time"
+)
+
+// Cache stores values for a limited time.
+type Cache interface {
+	// Get returns the value of the key, or an errtypes.NotFound error when
+	// the key is not cached.
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Set caches the value of the key for ttl, 0 meaning until it is
+	// evicted.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete removes the key from the cache.
+	Delete(ctx context.Context, key string) error
+	// Purge removes all the keys from the cache.
+	Purge(ctx context.Context) error
+}
@@ -16,13 +16,11 @@
 // granted to it by virtue of its status as an Intergovernmental Organization
 // or submit itself to any jurisdiction.
 
-package ocdav
+package loader
 
 import (
-	"net/http"
+	// Load core cache drivers.
+	_ "github.com/cs3org/reva/pkg/cache/driver/memory"
+	_ "github.com/cs3org/reva/pkg/cache/driver/redis"
+	// Add your own here
 )
-
-// TODO(jfd): implement unlock
-func (s *svc) handleUnlock(w http.ResponseWriter, r *http.Request, ns string) {
-	w.WriteHeader(http.StatusNotImplemented)
-}
@@ -0,0 +1,97 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package memory
+
+import (
+	"context"
+	"time"
+
+	"github.com/cs3org/reva/pkg/cache"
+	"github.com/cs3org/reva/pkg/cache/driver/registry"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/dgraph-io/ristretto"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	registry.Register("memory", New)
+}
+
+type config struct {
+	// MaxEntries is the number of entries above which the least valuable
+	// ones are evicted.
+	MaxEntries int64 `mapstructure:"max_entries"`
+}
+
+func (c *config) init() {
+	if c.MaxEntries == 0 {
+		c.MaxEntries = 100000
+	}
+}
+
+type memory struct {
+	cache *ristretto.Cache
+}
+
+// New returns a cache keeping the values in the memory of the process,
+// which is not shared with the other replicas of the service.
+func New(namespace string, m map[string]interface{}) (cache.Cache, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, errors.Wrap(err, "error decoding conf")
+	}
+	c.init()
+
+	rc, err := ristretto.NewCache(&ristretto.Config{
+		// ristretto recommends tracking ten times the number of entries
+		NumCounters: 10 * c.MaxEntries,
+		MaxCost:     c.MaxEntries,
+		BufferItems: 64,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "cache: error creating cache")
+	}
+	return &memory{cache: rc}, nil
+}
+
+func (m *memory) Get(ctx context.Context, key string) ([]byte, error) {
+	v, ok := m.cache.Get(key)
+	if !ok {
+		return nil, errtypes.NotFound(key)
+	}
+	return v.([]byte), nil
+}
+
+func (m *memory) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	m.cache.SetWithTTL(key, value, 1, ttl)
+	// the writes are buffered, wait for them so that they can be read
+	m.cache.Wait()
+	return nil
+}
+
+func (m *memory) Delete(ctx context.Context, key string) error {
+	m.cache.Del(key)
+	return nil
+}
+
+func (m *memory) Purge(ctx context.Context) error {
+	m.cache.Clear()
+	return nil
+}
@@ -0,0 +1,171 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/cs3org/reva/pkg/cache"
+	"github.com/cs3org/reva/pkg/cache/driver/registry"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/gomodule/redigo/redis"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	registry.Register("redis", New)
+}
+
+type config struct {
+	Address  string `mapstructure:"address"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	// Prefix is prepended to the namespace of the cache to build the keys.
+	Prefix string `mapstructure:"prefix"`
+}
+
+func (c *config) init() {
+	if c.Address == "" {
+		c.Address = "localhost:6379"
+	}
+	if c.Prefix == "" {
+		c.Prefix = "reva:cache:"
+	}
+}
+
+type redisCache struct {
+	pool   *redis.Pool
+	prefix string
+}
+
+// New returns a cache keeping the values in redis, shared by the replicas
+// of the service.
+func New(namespace string, m map[string]interface{}) (cache.Cache, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, errors.Wrap(err, "error decoding conf")
+	}
+	c.init()
+
+	return &redisCache{
+		pool:   newRedisPool(c.Address, c.Username, c.Password),
+		prefix: c.Prefix + namespace + ":",
+	}, nil
+}
+
+func (r *redisCache) Get(ctx context.Context, key string) ([]byte, error) {
+	conn, err := r.pool.GetContext(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "cache: error getting redis connection")
+	}
+	defer conn.Close()
+
+	v, err := redis.Bytes(conn.Do("GET", r.prefix+key))
+	if err != nil {
+		if err == redis.ErrNil {
+			return nil, errtypes.NotFound(key)
+		}
+		return nil, errors.Wrap(err, "cache: error getting key")
+	}
+	return v, nil
+}
+
+func (r *redisCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	conn, err := r.pool.GetContext(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cache: error getting redis connection")
+	}
+	defer conn.Close()
+
+	args := []interface{}{r.prefix + key, value}
+	if ttl > 0 {
+		args = append(args, "PX", ttl.Milliseconds())
+	}
+	if _, err := conn.Do("SET", args...); err != nil {
+		return errors.Wrap(err, "cache: error setting key")
+	}
+	return nil
+}
+
+func (r *redisCache) Delete(ctx context.Context, key string) error {
+	conn, err := r.pool.GetContext(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cache: error getting redis connection")
+	}
+	defer conn.Close()
+
+	if _, err := conn.Do("DEL", r.prefix+key); err != nil {
+		return errors.Wrap(err, "cache: error deleting key")
+	}
+	return nil
+}
+
+// Purge deletes the keys of the namespace, scanning them by batches so as
+// not to block the server.
+func (r *redisCache) Purge(ctx context.Context) error {
+	conn, err := r.pool.GetContext(ctx)
+	if err != nil {
+		return errors.Wrap(err, "cache: error getting redis connection")
+	}
+	defer conn.Close()
+
+	cursor := 0
+	for {
+		values, err := redis.Values(conn.Do("SCAN", cursor, "MATCH", r.prefix+"*", "COUNT", 1000))
+		if err != nil {
+			return errors.Wrap(err, "cache: error scanning keys")
+		}
+		var keys []interface{}
+		if _, err := redis.Scan(values, &cursor, &keys); err != nil {
+			return errors.Wrap(err, "cache: error scanning keys")
+		}
+		if len(keys) > 0 {
+			if _, err := conn.Do("DEL", keys...); err != nil {
+				return errors.Wrap(err, "cache: error deleting keys")
+			}
+		}
+		if cursor == 0 {
+			return nil
+		}
+	}
+}
+
+func newRedisPool(address, username, password string) *redis.Pool {
+	return &redis.Pool{
+		MaxIdle:     50,
+		MaxActive:   1000,
+		IdleTimeout: 240 * time.Second,
+		Dial: func() (redis.Conn, error) {
+			opts := []redis.DialOption{}
+			if username != "" {
+				opts = append(opts, redis.DialUsername(username))
+			}
+			if password != "" {
+				opts = append(opts, redis.DialPassword(password))
+			}
+			return redis.Dial("tcp", address, opts...)
+		},
+		TestOnBorrow: func(c redis.Conn, t time.Time) error {
+			_, err := c.Do("PING")
+			return err
+		},
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package registry
+
+import (
+	"github.com/cs3org/reva/pkg/cache"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+// NewFunc is the function that cache drivers
+// should register at init time.
+type NewFunc func(namespace string, m map[string]interface{}) (cache.Cache, error)
+
+// NewFuncs is a map containing all the registered cache drivers.
+var NewFuncs = map[string]NewFunc{}
+
+// Register registers a new cache driver new function.
+// Not safe for concurrent use. Safe for use from package init.
+func Register(name string, f NewFunc) {
+	NewFuncs[name] = f
+}
+
+type config struct {
+	Driver  string                            `mapstructure:"driver"`
+	Drivers map[string]map[string]interface{} `mapstructure:"drivers"`
+}
+
+// NewCache returns the cache configured in the cache section of the
+// configuration of a service. The namespace separates the caches of the
+// services sharing a driver, e.g. "etag" or "providers". Without
+// configuration, the values are cached in the memory of the process.
+func NewCache(namespace string, m map[string]interface{}) (cache.Cache, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, errors.Wrap(err, "cache: error decoding conf")
+	}
+	if c.Driver == "" {
+		c.Driver = "memory"
+	}
+
+	f, ok := NewFuncs[c.Driver]
+	if !ok {
+		return nil, errtypes.NotFound("cache: driver not found: " + c.Driver)
+	}
+	return f(namespace, c.Drivers[c.Driver])
+}
@@ -0,0 +1,173 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	registry "github.com/cs3org/go-cs3apis/cs3/storage/registry/v1beta1"
+	"github.com/cs3org/reva/pkg/utils"
+	"github.com/golang/protobuf/proto"
+)
+
+// The typed caches store the messages in their JSON encoding, and treat the
+// entries which cannot be decoded, e.g. after an upgrade, as missing.
+
+func getProto(ctx context.Context, c Cache, key string, m proto.Message) bool {
+	b, err := c.Get(ctx, key)
+	if err != nil {
+		return false
+	}
+	return utils.UnmarshalJSONToProtoV1(b, m) == nil
+}
+
+func setProto(ctx context.Context, c Cache, key string, m proto.Message, ttl time.Duration) error {
+	b, err := utils.MarshalProtoV1ToJSON(m)
+	if err != nil {
+		return err
+	}
+	return c.Set(ctx, key, b, ttl)
+}
+
+// StatCache caches the metadata of the resources.
+type StatCache struct {
+	c   Cache
+	ttl time.Duration
+}
+
+// NewStatCache returns a StatCache keeping the entries for ttl.
+func NewStatCache(c Cache, ttl time.Duration) *StatCache {
+	return &StatCache{c: c, ttl: ttl}
+}
+
+// Get returns the cached metadata of the key.
+func (s *StatCache) Get(ctx context.Context, key string) (*provider.ResourceInfo, bool) {
+	info := &provider.ResourceInfo{}
+	if !getProto(ctx, s.c, key, info) {
+		return nil, false
+	}
+	return info, true
+}
+
+// Set caches the metadata of the key.
+func (s *StatCache) Set(ctx context.Context, key string, info *provider.ResourceInfo) error {
+	return setProto(ctx, s.c, key, info, s.ttl)
+}
+
+// Delete removes the metadata of the key.
+func (s *StatCache) Delete(ctx context.Context, key string) error {
+	return s.c.Delete(ctx, key)
+}
+
+// UserCache caches the users.
+type UserCache struct {
+	c   Cache
+	ttl time.Duration
+}
+
+// NewUserCache returns a UserCache keeping the entries for ttl.
+func NewUserCache(c Cache, ttl time.Duration) *UserCache {
+	return &UserCache{c: c, ttl: ttl}
+}
+
+// Get returns the cached user of the key.
+func (u *UserCache) Get(ctx context.Context, key string) (*userpb.User, bool) {
+	user := &userpb.User{}
+	if !getProto(ctx, u.c, key, user) {
+		return nil, false
+	}
+	return user, true
+}
+
+// Set caches the user of the key.
+func (u *UserCache) Set(ctx context.Context, key string, user *userpb.User) error {
+	return setProto(ctx, u.c, key, user, u.ttl)
+}
+
+// Delete removes the user of the key.
+func (u *UserCache) Delete(ctx context.Context, key string) error {
+	return u.c.Delete(ctx, key)
+}
+
+// ProviderCache caches the storage providers serving the references.
+type ProviderCache struct {
+	c   Cache
+	ttl time.Duration
+}
+
+// NewProviderCache returns a ProviderCache keeping the entries for ttl.
+func NewProviderCache(c Cache, ttl time.Duration) *ProviderCache {
+	return &ProviderCache{c: c, ttl: ttl}
+}
+
+// Get returns the cached providers of the key.
+func (p *ProviderCache) Get(ctx context.Context, key string) ([]*registry.ProviderInfo, bool) {
+	res := &registry.GetStorageProvidersResponse{}
+	if !getProto(ctx, p.c, key, res) {
+		return nil, false
+	}
+	return res.Providers, true
+}
+
+// Set caches the providers of the key.
+func (p *ProviderCache) Set(ctx context.Context, key string, providers []*registry.ProviderInfo) error {
+	return setProto(ctx, p.c, key, &registry.GetStorageProvidersResponse{Providers: providers}, p.ttl)
+}
+
+// Purge removes all the cached providers.
+func (p *ProviderCache) Purge(ctx context.Context) error {
+	return p.c.Purge(ctx)
+}
+
+// CapabilityCache caches the capabilities granted to the roles.
+type CapabilityCache struct {
+	c   Cache
+	ttl time.Duration
+}
+
+// NewCapabilityCache returns a CapabilityCache keeping the entries for ttl.
+func NewCapabilityCache(c Cache, ttl time.Duration) *CapabilityCache {
+	return &CapabilityCache{c: c, ttl: ttl}
+}
+
+// Get returns the cached capabilities of the key.
+func (cc *CapabilityCache) Get(ctx context.Context, key string) ([]string, bool) {
+	b, err := cc.c.Get(ctx, key)
+	if err != nil {
+		return nil, false
+	}
+	var caps []string
+	if err := json.Unmarshal(b, &caps); err != nil {
+		return nil, false
+	}
+	return caps, true
+}
+
+// Set caches the capabilities of the key.
+func (cc *CapabilityCache) Set(ctx context.Context, key string, caps []string) error {
+	b, err := json.Marshal(caps)
+	if err != nil {
+		return err
+	}
+	return cc.c.Set(ctx, key, b, cc.ttl)
+}
@@ -33,6 +33,7 @@ const (
 	groupPrefix           = "group:"
 	groupMembersPrefix    = "members:"
 	groupInternalIDPrefix = "internal:"
+	groupNonMemberPrefix  = "nonmember:"
 )
 
 func initRedisPool(address, username, password string) *redis.Pool {
@@ -171,3 +172,12 @@ func (m *manager) cacheGroupMembers(gid *grouppb.GroupId, members []*userpb.User
 	}
 	return nil
 }
+
+func (m *manager) isCachedNonMember(gid *grouppb.GroupId, uid *userpb.UserId) bool {
+	_, err := m.getVal(groupPrefix + groupNonMemberPrefix + gid.OpaqueId + ":" + uid.OpaqueId)
+	return err == nil
+}
+
+func (m *manager) cacheNonMember(gid *grouppb.GroupId, uid *userpb.UserId) error {
+	return m.setVal(groupPrefix+groupNonMemberPrefix+gid.OpaqueId+":"+uid.OpaqueId, "1", m.conf.NonMemberCacheExpiration*60)
+}
@@ -59,6 +59,8 @@ type config struct {
 	RedisPassword string `mapstructure:"redis_password" docs:""`
 	// The time in minutes for which the members of a group would be cached
 	GroupMembersCacheExpiration int `mapstructure:"group_members_cache_expiration" docs:"5"`
+	// The time in minutes for which a negative membership check would be cached
+	NonMemberCacheExpiration int `mapstructure:"non_member_cache_expiration" docs:"1"`
 	// The OIDC Provider
 	IDProvider string `mapstructure:"id_provider" docs:"http://cernbox.cern.ch"`
 	// Base API Endpoint
@@ -78,6 +80,9 @@ func (c *config) init() {
 	if c.GroupMembersCacheExpiration == 0 {
 		c.GroupMembersCacheExpiration = 5
 	}
+	if c.NonMemberCacheExpiration == 0 {
+		c.NonMemberCacheExpiration = 1
+	}
 	if c.RedisAddress == "" {
 		c.RedisAddress = ":6379"
 	}
@@ -318,6 +323,7 @@ func (m *manager) GetMembers(ctx context.Context, gid *grouppb.GroupId) ([]*user
 	if err != nil {
 		return nil, err
 	}
+	// the precomputed member identities include the members of the nested groups
 	url := fmt.Sprintf("%s/Group/%s/memberidentities/precomputed", m.conf.APIBaseURL, internalID)
 	userData, err := m.apiTokenManager.SendAPIGetRequest(ctx, url, false)
 	if err != nil {
@@ -343,6 +349,10 @@ func (m *manager) GetMembers(ctx context.Context, gid *grouppb.GroupId) ([]*user
 }
 
 func (m *manager) HasMember(ctx context.Context, gid *grouppb.GroupId, uid *userpb.UserId) (bool, error) {
+	if m.isCachedNonMember(gid, uid) {
+		return false, nil
+	}
+
 	groupMemers, err := m.GetMembers(ctx, gid)
 	if err != nil {
 		return false, err
@@ -353,5 +363,10 @@ func (m *manager) HasMember(ctx context.Context, gid *grouppb.GroupId, uid *user
 			return true, nil
 		}
 	}
+
+	if err = m.cacheNonMember(gid, uid); err != nil {
+		log := appctx.GetLogger(ctx)
+		log.Error().Err(err).Msg("rest: error caching non member")
+	}
 	return false, nil
 }
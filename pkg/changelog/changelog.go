@@ -0,0 +1,175 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+// Package changelog keeps the log of the changes made to the namespace of each
+// user, as materialized from the events published by the storage providers,
+// so that the clients can fetch the changes made since their last
+// synchronization instead of walking the whole tree again.
+package changelog
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+)
+
+// Types of the changes.
+const (
+	Created  = "created"
+	Modified = "modified"
+	Deleted  = "deleted"
+	Moved    = "moved"
+)
+
+// ErrCursorExpired is returned for the cursors whose changes are not all
+// known anymore, e.g. because they were purged. The clients have to walk the
+// whole tree again and start over from the current cursor.
+var ErrCursorExpired = errors.New("changelog: cursor expired")
+
+// Change is an entry of the change log of a user.
+type Change struct {
+	Type string `json:"type"`
+	// Owner is the user whose log holds the change, set by the managers when
+	// listing the changes.
+	Owner *userpb.UserId `json:"-"`
+	// ResourceID is the id of the resource in the format of the file ids of
+	// the ownCloud APIs, empty if it is not known.
+	ResourceID string `json:"resource_id,omitempty"`
+	// Path is the path of the resource in the global namespace.
+	Path string `json:"path"`
+	// OldPath is the path the resource was moved from.
+	OldPath string    `json:"old_path,omitempty"`
+	Time    time.Time `json:"time"`
+}
+
+// Manager is the interface to implement to store the change logs of the
+// users.
+type Manager interface {
+	// Add appends the change to the log of the user.
+	Add(ctx context.Context, uid *userpb.UserId, c *Change) error
+	// Since returns the changes of the users made after the cursor, oldest
+	// first and at most limit of them, along with the cursor of the last
+	// returned change. The cursors are shared by the logs of all the users,
+	// so that the logs of the owners of the resources shared with a user can
+	// be read along with the own one. An empty cursor returns no change but
+	// the current cursor. ErrCursorExpired is returned for the cursors whose
+	// changes have been lost.
+	Since(ctx context.Context, uids []*userpb.UserId, cursor string, limit int) ([]*Change, string, error)
+	// Purge removes the changes older than the given time.
+	Purge(ctx context.Context, before time.Time) error
+}
+
+// Item is a resource of a delta.
+type Item struct {
+	ID   string `json:"id,omitempty"`
+	Path string `json:"path"`
+}
+
+// Delta is the compact form of a list of changes, holding the final state of
+// each changed resource. A moved resource is reported as deleted at its old
+// path and created or modified at its new one, so the deletions are to be
+// applied first. The uploads are reported as modifications, as the storage
+// providers do not tell whether the file existed before.
+type Delta struct {
+	Created  []*Item `json:"created"`
+	Modified []*Item `json:"modified"`
+	Deleted  []*Item `json:"deleted"`
+}
+
+// pathKeyPrefix prefixes the paths of the resources whose id is not known
+const pathKeyPrefix = "path:"
+
+// state is the state of a resource while compacting the changes
+type state struct {
+	typ  string
+	path string
+	// oldPath is the path the resource had before the changes, if they
+	// moved it
+	oldPath string
+}
+
+// Compact folds the changes, oldest first, into a delta.
+func Compact(changes []*Change) *Delta {
+	states := map[string]*state{}
+	keys := []string{}
+	for _, c := range changes {
+		key := c.ResourceID
+		if key == "" {
+			key = pathKeyPrefix + c.Path
+		}
+		st, ok := states[key]
+		if !ok {
+			st = &state{typ: Modified}
+			states[key] = st
+			keys = append(keys, key)
+		}
+		switch c.Type {
+		case Created:
+			st.typ, st.path = Created, c.Path
+		case Modified:
+			if st.typ == Deleted {
+				st.typ = Modified
+			}
+			st.path = c.Path
+		case Moved:
+			if st.typ != Created && st.oldPath == "" {
+				st.oldPath = c.OldPath
+			}
+			st.path = c.Path
+		case Deleted:
+			if st.typ == Created {
+				// nothing to report for a resource which did not exist
+				delete(states, key)
+				continue
+			}
+			if st.oldPath != "" {
+				st.path, st.oldPath = st.oldPath, ""
+			} else {
+				st.path = c.Path
+			}
+			st.typ = Deleted
+		}
+	}
+
+	d := &Delta{Created: []*Item{}, Modified: []*Item{}, Deleted: []*Item{}}
+	for _, key := range keys {
+		st, ok := states[key]
+		if !ok {
+			continue
+		}
+		var id string
+		if !strings.HasPrefix(key, pathKeyPrefix) {
+			id = key
+		}
+		if st.oldPath != "" && st.oldPath != st.path {
+			d.Deleted = append(d.Deleted, &Item{ID: id, Path: st.oldPath})
+		}
+		item := &Item{ID: id, Path: st.path}
+		switch st.typ {
+		case Created:
+			d.Created = append(d.Created, item)
+		case Modified:
+			d.Modified = append(d.Modified, item)
+		case Deleted:
+			d.Deleted = append(d.Deleted, item)
+		}
+	}
+	return d
+}
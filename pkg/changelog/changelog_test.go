@@ -0,0 +1,114 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+package changelog
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/pkg/events"
+	"github.com/rs/zerolog"
+)
+
+func TestCompact(t *testing.T) {
+	tests := []struct {
+		name    string
+		changes []*Change
+		want    *Delta
+	}{
+		{
+			name:    "created and modified",
+			changes: []*Change{{Type: Created, ResourceID: "a", Path: "/a"}, {Type: Modified, ResourceID: "a", Path: "/a"}},
+			want:    &Delta{Created: []*Item{{ID: "a", Path: "/a"}}, Modified: []*Item{}, Deleted: []*Item{}},
+		},
+		{
+			name:    "created and deleted",
+			changes: []*Change{{Type: Created, ResourceID: "a", Path: "/a"}, {Type: Deleted, ResourceID: "a", Path: "/a"}},
+			want:    &Delta{Created: []*Item{}, Modified: []*Item{}, Deleted: []*Item{}},
+		},
+		{
+			name:    "moved twice",
+			changes: []*Change{{Type: Moved, ResourceID: "a", OldPath: "/a", Path: "/b"}, {Type: Moved, ResourceID: "a", OldPath: "/b", Path: "/c"}},
+			want:    &Delta{Created: []*Item{}, Modified: []*Item{{ID: "a", Path: "/c"}}, Deleted: []*Item{{ID: "a", Path: "/a"}}},
+		},
+		{
+			name:    "moved and deleted",
+			changes: []*Change{{Type: Moved, ResourceID: "a", OldPath: "/a", Path: "/b"}, {Type: Deleted, ResourceID: "a", Path: "/b"}},
+			want:    &Delta{Created: []*Item{}, Modified: []*Item{}, Deleted: []*Item{{ID: "a", Path: "/a"}}},
+		},
+		{
+			name:    "created and moved",
+			changes: []*Change{{Type: Created, ResourceID: "a", Path: "/a"}, {Type: Moved, ResourceID: "a", OldPath: "/a", Path: "/b"}},
+			want:    &Delta{Created: []*Item{{ID: "a", Path: "/b"}}, Modified: []*Item{}, Deleted: []*Item{}},
+		},
+		{
+			name:    "uploads without id",
+			changes: []*Change{{Type: Modified, Path: "/a"}, {Type: Modified, Path: "/b"}, {Type: Modified, Path: "/a"}},
+			want:    &Delta{Created: []*Item{}, Modified: []*Item{{Path: "/a"}, {Path: "/b"}}, Deleted: []*Item{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Compact(tt.changes); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Compact() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+type logs struct {
+	Manager
+	added map[string][]*Change
+}
+
+func (l *logs) Add(ctx context.Context, uid *userpb.UserId, c *Change) error {
+	l.added[uid.OpaqueId] = append(l.added[uid.OpaqueId], c)
+	return nil
+}
+
+func TestMaterializeUpload(t *testing.T) {
+	l := &logs{added: map[string][]*Change{}}
+	log := zerolog.Nop()
+	mt := &Materializer{m: l, log: &log}
+	now := time.Now()
+
+	mt.materialize(context.Background(), events.FileUploaded{
+		Executant: &userpb.UserId{OpaqueId: "marie"},
+		Owner:     &userpb.UserId{OpaqueId: "einstein"},
+		Path:      "/Projects/notes.txt",
+		MountPath: "/users/einstein",
+		Time:      now,
+	})
+	// the storage did not tell the owner
+	mt.materialize(context.Background(), events.FileUploaded{
+		Executant: &userpb.UserId{OpaqueId: "marie"},
+		Path:      "/file.txt",
+		Time:      now,
+	})
+
+	want := map[string][]*Change{
+		"einstein": {{Type: Modified, Path: "/users/einstein/Projects/notes.txt", Time: now}},
+		"marie":    {{Type: Modified, Path: "/file.txt", Time: now}},
+	}
+	if !reflect.DeepEqual(l.added, want) {
+		t.Errorf("got %+v, wanted %+v", l.added, want)
+	}
+}
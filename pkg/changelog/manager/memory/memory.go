@@ -0,0 +1,158 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+package memory
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/pkg/changelog"
+	"github.com/cs3org/reva/pkg/changelog/manager/registry"
+	"github.com/cs3org/reva/pkg/errtypes"
+)
+
+func init() {
+	registry.Register("memory", New)
+}
+
+type entry struct {
+	id     int64
+	owner  *userpb.UserId
+	change *changelog.Change
+}
+
+type manager struct {
+	sync.Mutex
+	// epoch identifies this instance in the cursors, as the logs are lost
+	// on restarts
+	epoch  string
+	lastID int64
+	// logs holds the change logs of the users, oldest first.
+	logs map[string][]*entry
+	// purged holds the id of the last change purged from the log of each
+	// user.
+	purged map[string]int64
+}
+
+// New returns a change log manager keeping the logs in memory.
+func New(m map[string]interface{}) (changelog.Manager, error) {
+	return &manager{
+		epoch:  strconv.FormatInt(time.Now().UnixNano(), 36),
+		logs:   map[string][]*entry{},
+		purged: map[string]int64{},
+	}, nil
+}
+
+func userKey(uid *userpb.UserId) string {
+	return uid.GetIdp() + "!" + uid.GetOpaqueId()
+}
+
+func (m *manager) cursor(id int64) string {
+	return fmt.Sprintf("%s.%d", m.epoch, id)
+}
+
+func (m *manager) Add(ctx context.Context, uid *userpb.UserId, c *changelog.Change) error {
+	m.Lock()
+	defer m.Unlock()
+	m.lastID++
+	key := userKey(uid)
+	m.logs[key] = append(m.logs[key], &entry{id: m.lastID, owner: uid, change: c})
+	return nil
+}
+
+func (m *manager) Since(ctx context.Context, uids []*userpb.UserId, cursor string, limit int) ([]*changelog.Change, string, error) {
+	m.Lock()
+	defer m.Unlock()
+	if cursor == "" {
+		return nil, m.cursor(m.lastID), nil
+	}
+
+	parts := strings.SplitN(cursor, ".", 2)
+	if len(parts) != 2 {
+		return nil, "", errtypes.BadRequest("changelog: invalid cursor " + cursor)
+	}
+	since, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return nil, "", errtypes.BadRequest("changelog: invalid cursor " + cursor)
+	}
+	if parts[0] != m.epoch || since > m.lastID {
+		return nil, "", changelog.ErrCursorExpired
+	}
+
+	// the logs are merged oldest first, by the ids of their entries
+	logs := make([][]*entry, 0, len(uids))
+	for _, uid := range uids {
+		key := userKey(uid)
+		if since < m.purged[key] {
+			return nil, "", changelog.ErrCursorExpired
+		}
+		log := m.logs[key]
+		i := 0
+		for i < len(log) && log[i].id <= since {
+			i++
+		}
+		logs = append(logs, log[i:])
+	}
+
+	changes := []*changelog.Change{}
+	last := m.lastID
+	for limit <= 0 || len(changes) < limit {
+		next := -1
+		for i, log := range logs {
+			if len(log) > 0 && (next == -1 || log[0].id < logs[next][0].id) {
+				next = i
+			}
+		}
+		if next == -1 {
+			break
+		}
+		e := logs[next][0]
+		logs[next] = logs[next][1:]
+		c := *e.change
+		c.Owner = e.owner
+		changes = append(changes, &c)
+		last = e.id
+	}
+	return changes, m.cursor(last), nil
+}
+
+func (m *manager) Purge(ctx context.Context, before time.Time) error {
+	m.Lock()
+	defer m.Unlock()
+	for key, log := range m.logs {
+		i := 0
+		for i < len(log) && log[i].change.Time.Before(before) {
+			i++
+		}
+		if i == 0 {
+			continue
+		}
+		m.purged[key] = log[i-1].id
+		if i == len(log) {
+			delete(m.logs, key)
+		} else {
+			m.logs[key] = log[i:]
+		}
+	}
+	return nil
+}
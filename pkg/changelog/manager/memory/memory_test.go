@@ -0,0 +1,107 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+package memory
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/pkg/changelog"
+)
+
+func TestSinceAndPurge(t *testing.T) {
+	ctx := context.Background()
+	uid := &userpb.UserId{Idp: "idp", OpaqueId: "einstein"}
+	uids := []*userpb.UserId{uid}
+	m, _ := New(nil)
+
+	_, cursor, err := m.Since(ctx, uids, "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		c := &changelog.Change{Type: changelog.Modified, Path: "/file", Time: now.Add(time.Duration(i) * time.Hour)}
+		if err := m.Add(ctx, uid, c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	page, next, _ := m.Since(ctx, uids, cursor, 2)
+	if len(page) != 2 || !page[0].Time.Equal(now) {
+		t.Fatalf("unexpected first page %+v", page)
+	}
+	page, next, _ = m.Since(ctx, uids, next, 10)
+	if len(page) != 3 {
+		t.Fatalf("unexpected second page %+v", page)
+	}
+	if page, _, _ = m.Since(ctx, uids, next, 10); len(page) != 0 {
+		t.Fatalf("unexpected changes after the last cursor %+v", page)
+	}
+
+	if err := m.Purge(ctx, now.Add(90*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := m.Since(ctx, uids, cursor, 10); err != changelog.ErrCursorExpired {
+		t.Fatalf("expected the cursor to expire after the purge, got %v", err)
+	}
+	if _, _, err := m.Since(ctx, uids, next, 10); err != nil {
+		t.Fatalf("expected the last cursor to stay valid, got %v", err)
+	}
+
+	other, _ := New(nil)
+	if _, _, err := other.Since(ctx, uids, next, 10); err != changelog.ErrCursorExpired {
+		t.Fatalf("expected the cursor of another instance to expire, got %v", err)
+	}
+}
+
+func TestSinceMergesLogs(t *testing.T) {
+	ctx := context.Background()
+	einstein := &userpb.UserId{Idp: "idp", OpaqueId: "einstein"}
+	marie := &userpb.UserId{Idp: "idp", OpaqueId: "marie"}
+	m, _ := New(nil)
+
+	_, cursor, _ := m.Since(ctx, []*userpb.UserId{einstein}, "", 0)
+	for i, uid := range []*userpb.UserId{einstein, marie, einstein, marie} {
+		c := &changelog.Change{Type: changelog.Modified, Path: fmt.Sprintf("/file%d", i), Time: time.Now()}
+		if err := m.Add(ctx, uid, c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	page, next, err := m.Since(ctx, []*userpb.UserId{einstein, marie}, cursor, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 3 || page[0].Path != "/file0" || page[1].Path != "/file1" || page[2].Path != "/file2" {
+		t.Fatalf("unexpected first page %+v", page)
+	}
+	if page[1].Owner.OpaqueId != "marie" {
+		t.Fatalf("unexpected owner %v", page[1].Owner)
+	}
+	if page, _, _ = m.Since(ctx, []*userpb.UserId{einstein, marie}, next, 3); len(page) != 1 || page[0].Path != "/file3" {
+		t.Fatalf("unexpected second page %+v", page)
+	}
+	if page, _, _ = m.Since(ctx, []*userpb.UserId{einstein}, cursor, 0); len(page) != 2 {
+		t.Fatalf("unexpected changes of einstein %+v", page)
+	}
+}
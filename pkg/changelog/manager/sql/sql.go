@@ -0,0 +1,197 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/pkg/changelog"
+	"github.com/cs3org/reva/pkg/changelog/manager/registry"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+
+	// Provides mysql drivers
+	_ "github.com/go-sql-driver/mysql"
+)
+
+func init() {
+	registry.Register("sql", New)
+}
+
+// The manager expects the following tables:
+//
+//   changes(id AUTO_INCREMENT PRIMARY KEY, owner_idp, owner_opaque_id, type, resource_id, path, old_path, time)
+//   change_purges(owner_idp, owner_opaque_id, last_id, PRIMARY KEY(owner_idp, owner_opaque_id))
+//
+// where time is in nanoseconds since the epoch, and last_id is the id of the
+// last change purged from the log of the user. The cursors are the ids of the
+// changes, so that they survive the restarts and are shared by all the
+// instances using the same database.
+
+type config struct {
+	DbUsername string `mapstructure:"db_username"`
+	DbPassword string `mapstructure:"db_password"`
+	DbHost     string `mapstructure:"db_host"`
+	DbPort     int    `mapstructure:"db_port"`
+	DbName     string `mapstructure:"db_name"`
+}
+
+func (c *config) init() {
+	if c.DbPort == 0 {
+		c.DbPort = 3306
+	}
+}
+
+type manager struct {
+	c  *config
+	db *sql.DB
+}
+
+func parseConfig(m map[string]interface{}) (*config, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		err = errors.Wrap(err, "error decoding conf")
+		return nil, err
+	}
+	c.init()
+	return c, nil
+}
+
+// New returns a change log manager keeping the logs in a SQL database.
+func New(m map[string]interface{}) (changelog.Manager, error) {
+	c, err := parseConfig(m)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s:%d)/%s", c.DbUsername, c.DbPassword, c.DbHost, c.DbPort, c.DbName))
+	if err != nil {
+		return nil, errors.Wrap(err, "sql: error opening connection to the database")
+	}
+
+	return &manager{c: c, db: db}, nil
+}
+
+func (m *manager) Add(ctx context.Context, uid *userpb.UserId, c *changelog.Change) error {
+	_, err := m.db.ExecContext(ctx, "INSERT INTO changes (owner_idp, owner_opaque_id, type, resource_id, path, old_path, time) VALUES (?, ?, ?, ?, ?, ?, ?)",
+		uid.GetIdp(), uid.GetOpaqueId(), c.Type, c.ResourceID, c.Path, c.OldPath, c.Time.UnixNano())
+	return err
+}
+
+// currentID returns the id of the last change ever added, including the
+// purged ones.
+func (m *manager) currentID(ctx context.Context) (int64, error) {
+	var id int64
+	err := m.db.QueryRowContext(ctx, "SELECT GREATEST(COALESCE((SELECT MAX(id) FROM changes), 0), COALESCE((SELECT MAX(last_id) FROM change_purges), 0))").Scan(&id)
+	return id, err
+}
+
+// owners returns the condition matching the changes of the users, and its
+// parameters.
+func owners(uids []*userpb.UserId) (string, []interface{}) {
+	conds := make([]string, 0, len(uids))
+	params := make([]interface{}, 0, 2*len(uids))
+	for _, uid := range uids {
+		conds = append(conds, "(owner_idp=? AND owner_opaque_id=?)")
+		params = append(params, uid.GetIdp(), uid.GetOpaqueId())
+	}
+	return "(" + strings.Join(conds, " OR ") + ")", params
+}
+
+func (m *manager) Since(ctx context.Context, uids []*userpb.UserId, cursor string, limit int) ([]*changelog.Change, string, error) {
+	current, err := m.currentID(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+	if cursor == "" {
+		return nil, strconv.FormatInt(current, 10), nil
+	}
+
+	since, err := strconv.ParseInt(cursor, 10, 64)
+	if err != nil {
+		return nil, "", errtypes.BadRequest("changelog: invalid cursor " + cursor)
+	}
+	if since > current {
+		return nil, "", changelog.ErrCursorExpired
+	}
+	changes := []*changelog.Change{}
+	if len(uids) == 0 {
+		return changes, cursor, nil
+	}
+
+	cond, params := owners(uids)
+	var purged int64
+	if err := m.db.QueryRowContext(ctx, "SELECT COALESCE(MAX(last_id), 0) FROM change_purges WHERE "+cond, params...).Scan(&purged); err != nil {
+		return nil, "", err
+	}
+	if since < purged {
+		return nil, "", changelog.ErrCursorExpired
+	}
+
+	query := "SELECT id, owner_idp, owner_opaque_id, type, resource_id, path, old_path, time FROM changes WHERE id>? AND " + cond + " ORDER BY id"
+	params = append([]interface{}{since}, params...)
+	if limit > 0 {
+		query += " LIMIT ?"
+		params = append(params, limit)
+	}
+	rows, err := m.db.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+
+	last := current
+	for rows.Next() {
+		c := &changelog.Change{Owner: &userpb.UserId{}}
+		var t int64
+		if err := rows.Scan(&last, &c.Owner.Idp, &c.Owner.OpaqueId, &c.Type, &c.ResourceID, &c.Path, &c.OldPath, &t); err != nil {
+			return nil, "", err
+		}
+		c.Time = time.Unix(0, t)
+		changes = append(changes, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+	return changes, strconv.FormatInt(last, 10), nil
+}
+
+func (m *manager) Purge(ctx context.Context, before time.Time) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if _, err := tx.ExecContext(ctx, "INSERT INTO change_purges (owner_idp, owner_opaque_id, last_id) SELECT owner_idp, owner_opaque_id, MAX(id) FROM changes WHERE time<? GROUP BY owner_idp, owner_opaque_id ON DUPLICATE KEY UPDATE last_id=VALUES(last_id)", before.UnixNano()); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM changes WHERE time<?", before.UnixNano()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
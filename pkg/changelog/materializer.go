@@ -0,0 +1,151 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+package changelog
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"path"
+	"time"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/events"
+	eventsregistry "github.com/cs3org/reva/pkg/events/driver/registry"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+// MaterializerConfig is the configuration of the materializer.
+type MaterializerConfig struct {
+	// Events configures the bus the events are consumed from.
+	Events map[string]interface{} `mapstructure:"events"`
+	// Retention is the number of days the changes are kept for.
+	Retention int `mapstructure:"retention"`
+	// PurgeInterval is the time in seconds between two purges of the
+	// expired changes.
+	PurgeInterval int `mapstructure:"purge_interval"`
+}
+
+// ParseMaterializerConfig decodes the configuration of the materializer from a map.
+func ParseMaterializerConfig(m map[string]interface{}) (*MaterializerConfig, error) {
+	c := &MaterializerConfig{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, errors.Wrap(err, "changelog: error decoding conf")
+	}
+	if c.Retention == 0 {
+		c.Retention = 30
+	}
+	if c.PurgeInterval == 0 {
+		c.PurgeInterval = 3600
+	}
+	return c, nil
+}
+
+// Materializer adds the changes consumed from the bus to the logs of the
+// owners of the changed resources.
+type Materializer struct {
+	c      *MaterializerConfig
+	m      Manager
+	stream events.Consumer
+	log    *zerolog.Logger
+}
+
+// NewMaterializer returns a materializer storing the changes in m.
+func NewMaterializer(c *MaterializerConfig, m Manager, log *zerolog.Logger) (*Materializer, error) {
+	stream, err := eventsregistry.NewStream(c.Events)
+	if err != nil {
+		return nil, errors.Wrap(err, "changelog: error creating events stream")
+	}
+	return &Materializer{c: c, m: m, stream: stream, log: log}, nil
+}
+
+// Run materializes the events consumed from the bus and purges the expired
+// changes until stop is closed.
+func (mt *Materializer) Run(stop <-chan struct{}) {
+	ctx, cancel := context.WithCancel(appctx.WithLogger(context.Background(), mt.log))
+	defer cancel()
+
+	evs, err := events.Consume(ctx, mt.stream, "changelog", events.FileUploaded{}, events.ContainerCreated{}, events.ItemTrashed{}, events.ItemMoved{})
+	if err != nil {
+		mt.log.Error().Err(err).Msg("changelog: error consuming events")
+		return
+	}
+
+	ticker := time.NewTicker(time.Duration(mt.c.PurgeInterval) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			before := time.Now().AddDate(0, 0, -mt.c.Retention)
+			if err := mt.m.Purge(ctx, before); err != nil {
+				mt.log.Error().Err(err).Msg("changelog: error purging changes")
+			}
+		case e, ok := <-evs:
+			if !ok {
+				return
+			}
+			mt.materialize(ctx, e)
+		}
+	}
+}
+
+func (mt *Materializer) materialize(ctx context.Context, e interface{}) {
+	switch e := e.(type) {
+	case events.FileUploaded:
+		mt.add(ctx, owner(e.Owner, e.Executant), &Change{Type: Modified, ResourceID: wrapResourceID(e.ResourceID), Path: path.Join("/", e.MountPath, e.Path), Time: e.Time})
+	case events.ContainerCreated:
+		mt.add(ctx, owner(e.Owner, e.Executant), &Change{Type: Created, ResourceID: wrapResourceID(e.ResourceID), Path: e.Path, Time: e.Time})
+	case events.ItemTrashed:
+		mt.add(ctx, owner(e.Owner, e.Executant), &Change{Type: Deleted, ResourceID: wrapResourceID(e.ResourceID), Path: e.Path, Time: e.Time})
+	case events.ItemMoved:
+		mt.add(ctx, owner(e.Owner, e.Executant), &Change{Type: Moved, ResourceID: wrapResourceID(e.ResourceID), OldPath: e.OldPath, Path: e.Path, Time: e.Time})
+	}
+}
+
+func (mt *Materializer) add(ctx context.Context, uid *userpb.UserId, c *Change) {
+	if uid == nil {
+		return
+	}
+	if err := mt.m.Add(ctx, uid, c); err != nil {
+		mt.log.Error().Err(err).Str("type", c.Type).Msg("changelog: error adding change")
+	}
+}
+
+// owner returns the owner of the resource, or the executant of the change
+// when the storage does not tell it.
+func owner(o, executant *userpb.UserId) *userpb.UserId {
+	if o.GetOpaqueId() != "" {
+		return o
+	}
+	return executant
+}
+
+// wrapResourceID encodes the id of the resource as the file ids of the
+// ownCloud APIs.
+func wrapResourceID(r *provider.ResourceId) string {
+	if r == nil {
+		return ""
+	}
+	return base64.URLEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", r.StorageId, r.OpaqueId)))
+}
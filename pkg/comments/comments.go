@@ -0,0 +1,110 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package comments keeps the comments the users write on the resources they
+// have access to.
+package comments
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+)
+
+// ObjectTypeFiles is the type of the objects of the comments on files and
+// folders.
+const ObjectTypeFiles = "files"
+
+// VerbComment is the verb of the comments written by the users.
+const VerbComment = "comment"
+
+// Comment is a comment on an object.
+type Comment struct {
+	// ID is assigned by the manager, and increases with the time the
+	// comments are added.
+	ID int64 `json:"id"`
+	// ObjectType and ObjectID identify the commented object.
+	ObjectType string         `json:"object_type"`
+	ObjectID   string         `json:"object_id"`
+	Actor      *userpb.UserId `json:"actor"`
+	// ActorUsername is the username of the actor when the comment was
+	// written.
+	ActorUsername string    `json:"actor_username"`
+	Verb          string    `json:"verb"`
+	Message       string    `json:"message"`
+	Mentions      []string  `json:"mentions,omitempty"`
+	CreationTime  time.Time `json:"creation_time"`
+}
+
+// Filter restricts the comments returned by List.
+type Filter struct {
+	ObjectType string
+	ObjectID   string
+	// Before, if set, restricts the comments to the ones written before.
+	Before time.Time
+	Offset int
+	// Limit is the maximum number of comments returned.
+	Limit int
+}
+
+// Matches returns whether the comment satisfies the object and time
+// constraints of the filter.
+func (f *Filter) Matches(c *Comment) bool {
+	if c.ObjectType != f.ObjectType || c.ObjectID != f.ObjectID {
+		return false
+	}
+	return f.Before.IsZero() || c.CreationTime.Before(f.Before)
+}
+
+// Manager is the interface to implement to store the comments.
+type Manager interface {
+	// Add stores a new comment, and sets its id.
+	Add(ctx context.Context, c *Comment) error
+	// Get returns the comment with the given id.
+	Get(ctx context.Context, id int64) (*Comment, error)
+	// List returns the comments matching the filter, most recent first.
+	List(ctx context.Context, f *Filter) ([]*Comment, error)
+	// Update replaces the message and the mentions of the comment.
+	Update(ctx context.Context, id int64, message string, mentions []string) error
+	// Delete removes the comment.
+	Delete(ctx context.Context, id int64) error
+}
+
+// mentionRegex matches the @username and @"user name" mentions.
+var mentionRegex = regexp.MustCompile(`(?:^|\s)@(?:"([^"]+)"|([\w.@-]*[\w]))`)
+
+// ParseMentions returns the usernames mentioned in the message, without
+// duplicates.
+func ParseMentions(message string) []string {
+	mentions := []string{}
+	seen := map[string]bool{}
+	for _, m := range mentionRegex.FindAllStringSubmatch(message, -1) {
+		username := m[1]
+		if username == "" {
+			username = m[2]
+		}
+		if username == "" || seen[username] {
+			continue
+		}
+		seen[username] = true
+		mentions = append(mentions, username)
+	}
+	return mentions
+}
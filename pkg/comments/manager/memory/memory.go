@@ -0,0 +1,120 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package memory
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	"github.com/cs3org/reva/pkg/comments"
+	"github.com/cs3org/reva/pkg/comments/manager/registry"
+	"github.com/cs3org/reva/pkg/errtypes"
+)
+
+func init() {
+	registry.Register("memory", New)
+}
+
+type manager struct {
+	sync.Mutex
+	lastID int64
+	// comments holds all the comments, oldest first.
+	comments []*comments.Comment
+}
+
+// New returns a comments manager keeping the comments in memory.
+func New(m map[string]interface{}) (comments.Manager, error) {
+	return &manager{}, nil
+}
+
+func (m *manager) find(id int64) int {
+	for i, c := range m.comments {
+		if c.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m *manager) Add(ctx context.Context, c *comments.Comment) error {
+	m.Lock()
+	defer m.Unlock()
+	m.lastID++
+	c.ID = m.lastID
+	stored := *c
+	m.comments = append(m.comments, &stored)
+	return nil
+}
+
+func (m *manager) Get(ctx context.Context, id int64) (*comments.Comment, error) {
+	m.Lock()
+	defer m.Unlock()
+	i := m.find(id)
+	if i < 0 {
+		return nil, errtypes.NotFound("comment " + strconv.FormatInt(id, 10))
+	}
+	c := *m.comments[i]
+	return &c, nil
+}
+
+func (m *manager) List(ctx context.Context, f *comments.Filter) ([]*comments.Comment, error) {
+	m.Lock()
+	defer m.Unlock()
+	list := []*comments.Comment{}
+	skipped := 0
+	for i := len(m.comments) - 1; i >= 0; i-- {
+		if f.Limit > 0 && len(list) == f.Limit {
+			break
+		}
+		if !f.Matches(m.comments[i]) {
+			continue
+		}
+		if skipped < f.Offset {
+			skipped++
+			continue
+		}
+		c := *m.comments[i]
+		list = append(list, &c)
+	}
+	return list, nil
+}
+
+func (m *manager) Update(ctx context.Context, id int64, message string, mentions []string) error {
+	m.Lock()
+	defer m.Unlock()
+	i := m.find(id)
+	if i < 0 {
+		return errtypes.NotFound("comment " + strconv.FormatInt(id, 10))
+	}
+	m.comments[i].Message = message
+	m.comments[i].Mentions = mentions
+	return nil
+}
+
+func (m *manager) Delete(ctx context.Context, id int64) error {
+	m.Lock()
+	defer m.Unlock()
+	i := m.find(id)
+	if i < 0 {
+		return errtypes.NotFound("comment " + strconv.FormatInt(id, 10))
+	}
+	m.comments = append(m.comments[:i], m.comments[i+1:]...)
+	return nil
+}
@@ -0,0 +1,65 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cs3org/reva/pkg/comments"
+)
+
+func TestListUpdateAndDelete(t *testing.T) {
+	ctx := context.Background()
+	m, _ := New(nil)
+
+	now := time.Now()
+	for i := 0; i < 4; i++ {
+		c := &comments.Comment{ObjectType: comments.ObjectTypeFiles, ObjectID: "file", Message: "hi", CreationTime: now.Add(time.Duration(i) * time.Hour)}
+		if err := m.Add(ctx, c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_ = m.Add(ctx, &comments.Comment{ObjectType: comments.ObjectTypeFiles, ObjectID: "other", CreationTime: now})
+
+	f := &comments.Filter{ObjectType: comments.ObjectTypeFiles, ObjectID: "file", Limit: 2, Offset: 1}
+	page, _ := m.List(ctx, f)
+	if len(page) != 2 || page[0].ID != 3 || page[1].ID != 2 {
+		t.Fatalf("unexpected page %+v", page)
+	}
+
+	if err := m.Update(ctx, 2, "edited", []string{"marie"}); err != nil {
+		t.Fatal(err)
+	}
+	if c, _ := m.Get(ctx, 2); c.Message != "edited" || len(c.Mentions) != 1 {
+		t.Fatalf("unexpected comment after update %+v", c)
+	}
+
+	if err := m.Delete(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Get(ctx, 2); err == nil {
+		t.Fatal("expected deleted comment to be gone")
+	}
+	all, _ := m.List(ctx, &comments.Filter{ObjectType: comments.ObjectTypeFiles, ObjectID: "file"})
+	if len(all) != 3 {
+		t.Fatalf("expected 3 comments, got %d", len(all))
+	}
+}
@@ -0,0 +1,204 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/pkg/comments"
+	"github.com/cs3org/reva/pkg/comments/manager/registry"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+
+	// Provides mysql drivers
+	_ "github.com/go-sql-driver/mysql"
+)
+
+func init() {
+	registry.Register("sql", New)
+}
+
+// The manager expects the following table:
+//
+//   comments(id, object_type, object_id, actor_idp, actor_id, actor_username, verb, message, mentions, creation_time)
+//
+// where id is auto-incremented, mentions holds the JSON encoded list of
+// the mentioned usernames, and the comments are looked up by object_type,
+// object_id and id.
+
+type config struct {
+	DbUsername string `mapstructure:"db_username"`
+	DbPassword string `mapstructure:"db_password"`
+	DbHost     string `mapstructure:"db_host"`
+	DbPort     int    `mapstructure:"db_port"`
+	DbName     string `mapstructure:"db_name"`
+}
+
+func (c *config) init() {
+	if c.DbPort == 0 {
+		c.DbPort = 3306
+	}
+}
+
+type manager struct {
+	db *sql.DB
+}
+
+func parseConfig(m map[string]interface{}) (*config, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		err = errors.Wrap(err, "error decoding conf")
+		return nil, err
+	}
+	c.init()
+	return c, nil
+}
+
+// New returns a comments manager storing the comments in a SQL database.
+func New(m map[string]interface{}) (comments.Manager, error) {
+	c, err := parseConfig(m)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?parseTime=true", c.DbUsername, c.DbPassword, c.DbHost, c.DbPort, c.DbName))
+	if err != nil {
+		return nil, errors.Wrap(err, "sql: error opening connection to the database")
+	}
+
+	return &manager{db: db}, nil
+}
+
+const selectComments = "SELECT id, object_type, object_id, actor_idp, actor_id, actor_username, verb, message, mentions, creation_time FROM comments"
+
+func scanComment(s interface{ Scan(...interface{}) error }) (*comments.Comment, error) {
+	c := &comments.Comment{}
+	var actorIdp, actorID, mentions string
+	if err := s.Scan(&c.ID, &c.ObjectType, &c.ObjectID, &actorIdp, &actorID, &c.ActorUsername, &c.Verb, &c.Message, &mentions, &c.CreationTime); err != nil {
+		return nil, err
+	}
+	if actorID != "" {
+		c.Actor = &userpb.UserId{Idp: actorIdp, OpaqueId: actorID}
+	}
+	if mentions != "" {
+		if err := json.Unmarshal([]byte(mentions), &c.Mentions); err != nil {
+			return nil, errors.Wrap(err, "sql: error decoding mentions")
+		}
+	}
+	return c, nil
+}
+
+func encodeMentions(mentions []string) (string, error) {
+	if len(mentions) == 0 {
+		return "", nil
+	}
+	b, err := json.Marshal(mentions)
+	return string(b), err
+}
+
+func (m *manager) Add(ctx context.Context, c *comments.Comment) error {
+	mentions, err := encodeMentions(c.Mentions)
+	if err != nil {
+		return err
+	}
+	query := "INSERT INTO comments(object_type, object_id, actor_idp, actor_id, actor_username, verb, message, mentions, creation_time) VALUES(?,?,?,?,?,?,?,?,?)"
+	res, err := m.db.ExecContext(ctx, query, c.ObjectType, c.ObjectID, c.Actor.GetIdp(), c.Actor.GetOpaqueId(), c.ActorUsername, c.Verb, c.Message, mentions, c.CreationTime.UTC())
+	if err != nil {
+		return err
+	}
+	c.ID, err = res.LastInsertId()
+	return err
+}
+
+func (m *manager) Get(ctx context.Context, id int64) (*comments.Comment, error) {
+	c, err := scanComment(m.db.QueryRowContext(ctx, selectComments+" WHERE id=?", id))
+	if err == sql.ErrNoRows {
+		return nil, errtypes.NotFound("comment " + strconv.FormatInt(id, 10))
+	}
+	return c, err
+}
+
+func (m *manager) List(ctx context.Context, f *comments.Filter) ([]*comments.Comment, error) {
+	query := selectComments + " WHERE object_type=? AND object_id=?"
+	params := []interface{}{f.ObjectType, f.ObjectID}
+	if !f.Before.IsZero() {
+		query += " AND creation_time<?"
+		params = append(params, f.Before.UTC())
+	}
+	query += " ORDER BY id DESC"
+	if f.Limit > 0 {
+		query += " LIMIT ? OFFSET ?"
+		params = append(params, f.Limit, f.Offset)
+	} else if f.Offset > 0 {
+		// MySQL only accepts an offset together with a limit.
+		query += " LIMIT 18446744073709551615 OFFSET ?"
+		params = append(params, f.Offset)
+	}
+
+	rows, err := m.db.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := []*comments.Comment{}
+	for rows.Next() {
+		c, err := scanComment(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, c)
+	}
+	return list, rows.Err()
+}
+
+func (m *manager) Update(ctx context.Context, id int64, message string, mentions []string) error {
+	encoded, err := encodeMentions(mentions)
+	if err != nil {
+		return err
+	}
+	// MySQL reports the changed rows only, so a missing comment is
+	// detected with a lookup rather than with the affected rows.
+	if _, err := m.Get(ctx, id); err != nil {
+		return err
+	}
+	_, err = m.db.ExecContext(ctx, "UPDATE comments SET message=?, mentions=? WHERE id=?", message, encoded, id)
+	return err
+}
+
+func (m *manager) Delete(ctx context.Context, id int64) error {
+	res, err := m.db.ExecContext(ctx, "DELETE FROM comments WHERE id=?", id)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errtypes.NotFound("comment " + strconv.FormatInt(id, 10))
+	}
+	return nil
+}
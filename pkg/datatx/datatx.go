@@ -0,0 +1,73 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package datatx transfers data between the storages, possibly of different
+// sites.
+package datatx
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	txv1beta1 "github.com/cs3org/go-cs3apis/cs3/tx/v1beta1"
+	"github.com/cs3org/reva/pkg/errtypes"
+)
+
+// Manager is the interface that data transfer drivers implement.
+type Manager interface {
+	// CreateTransfer starts the transfer of the resource at the source
+	// target URI to the destination target URI, removing the source once it
+	// is transferred if move is set.
+	CreateTransfer(ctx context.Context, srcTargetURI, dstTargetURI string, move bool) (*txv1beta1.TxInfo, error)
+	// GetTransferStatus returns the transfer of the user.
+	GetTransferStatus(ctx context.Context, id string) (*txv1beta1.TxInfo, error)
+	// CancelTransfer cancels the transfer of the user.
+	CancelTransfer(ctx context.Context, id string) (*txv1beta1.TxInfo, error)
+}
+
+// TargetURI returns the target URI of the resource at the path of the WebDAV
+// endpoint, accessed with the token. The token is carried in the user info
+// of the URI, e.g. https://<token>@cernbox.cern.ch/remote.php/webdav/folder.
+func TargetURI(endpoint, fn, token string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + strings.TrimPrefix(fn, "/")
+	u.User = url.User(token)
+	return u.String(), nil
+}
+
+// ParseTargetURI returns the WebDAV URL of the resource and the token of the
+// target URI.
+func ParseTargetURI(uri string) (string, string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", "", errtypes.BadRequest("datatx: invalid target URI: " + err.Error())
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", "", errtypes.BadRequest("datatx: target URI must be an HTTP URL: " + u.Redacted())
+	}
+	var token string
+	if u.User != nil {
+		token = u.User.Username()
+		u.User = nil
+	}
+	return u.String(), token, nil
+}
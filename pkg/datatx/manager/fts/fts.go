@@ -0,0 +1,533 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package fts
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	txv1beta1 "github.com/cs3org/go-cs3apis/cs3/tx/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/datatx"
+	"github.com/cs3org/reva/pkg/datatx/manager/registry"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/token"
+	ctxpkg "github.com/cs3org/reva/pkg/user"
+	"github.com/cs3org/reva/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"github.com/studio-b12/gowebdav"
+)
+
+func init() {
+	registry.Register("fts", New)
+}
+
+type config struct {
+	// Endpoint is the URL of the REST API of FTS3, e.g.
+	// https://fts3.cern.ch:8446.
+	Endpoint string `mapstructure:"endpoint"`
+	// ClientCert and ClientKey are the X.509 credentials reva authenticates
+	// to FTS3 with, CAFile the CA bundle FTS3 is verified with.
+	ClientCert string `mapstructure:"client_cert"`
+	ClientKey  string `mapstructure:"client_key"`
+	CAFile     string `mapstructure:"ca_file"`
+	// File is the file the transfers are stored in.
+	File string `mapstructure:"file"`
+	// VerifyChecksum makes FTS3 compare the checksums of the source and
+	// destination files.
+	VerifyChecksum bool `mapstructure:"verify_checksum"`
+	Overwrite      bool `mapstructure:"overwrite"`
+	// Retry is the number of times FTS3 retries a failed file.
+	Retry int `mapstructure:"retry"`
+	// Priority is the priority of the jobs, from 1 to 5.
+	Priority int `mapstructure:"priority"`
+	// PollInterval is the number of seconds between the checks of the
+	// states of the jobs.
+	PollInterval int  `mapstructure:"poll_interval"`
+	Insecure     bool `mapstructure:"insecure"`
+}
+
+func (c *config) init() {
+	c.Endpoint = strings.TrimSuffix(c.Endpoint, "/")
+	if c.File == "" {
+		c.File = "/var/tmp/reva/datatx-fts-transfers.json"
+	}
+	if c.Retry == 0 {
+		c.Retry = 3
+	}
+	if c.Priority == 0 {
+		c.Priority = 3
+	}
+	if c.PollInterval == 0 {
+		c.PollInterval = 30
+	}
+}
+
+// transfer is a transfer run by an FTS3 job.
+type transfer struct {
+	ID          string           `json:"id"`
+	Owner       *userpb.UserId   `json:"owner"`
+	Src         string           `json:"src"`
+	Dst         string           `json:"dst"`
+	Move        bool             `json:"move"`
+	JobID       string           `json:"job_id"`
+	Status      txv1beta1.TxInfo_Status `json:"status"`
+	Description string           `json:"description,omitempty"`
+	Ctime       time.Time        `json:"ctime"`
+}
+
+func (t *transfer) info() *txv1beta1.TxInfo {
+	return &txv1beta1.TxInfo{
+		Id:          &txv1beta1.TxId{OpaqueId: t.ID},
+		Status:      t.Status,
+		Description: t.Description,
+		Ctime: &types.Timestamp{
+			Seconds: uint64(t.Ctime.Unix()),
+			Nanos:   uint32(t.Ctime.Nanosecond()),
+		},
+	}
+}
+
+func (t *transfer) running() bool {
+	return t.Status == txv1beta1.TxInfo_STATUS_TRANSFER_NEW || t.Status == txv1beta1.TxInfo_STATUS_TRANSFER_IN_PROGRESS
+}
+
+type manager struct {
+	c      *config
+	client *http.Client
+
+	mu        sync.Mutex
+	transfers map[string]*transfer
+}
+
+// New returns a data transfer manager submitting the transfers as jobs to
+// FTS3, see https://fts3-docs.web.cern.ch. The resources are transferred over
+// WebDAV, FTS3 presenting the tokens of the target URIs as bearer tokens. The
+// folders are listed when the transfers are created and transferred as jobs
+// of many files.
+func New(m map[string]interface{}) (datatx.Manager, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, errors.Wrap(err, "error decoding conf")
+	}
+	c.init()
+	if c.Endpoint == "" {
+		return nil, errors.New("fts: endpoint must be set")
+	}
+
+	client, err := newClient(c)
+	if err != nil {
+		return nil, err
+	}
+	mgr := &manager{
+		c:         c,
+		client:    client,
+		transfers: map[string]*transfer{},
+	}
+	if err := mgr.load(); err != nil {
+		return nil, err
+	}
+	go mgr.poll()
+	return mgr, nil
+}
+
+func newClient(c *config) (*http.Client, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: c.Insecure}
+	if c.ClientCert != "" {
+		cert, err := tls.LoadX509KeyPair(c.ClientCert, c.ClientKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "fts: error loading client certificate")
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	if c.CAFile != "" {
+		pem, err := ioutil.ReadFile(c.CAFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "fts: error reading CA file")
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.New("fts: no certificate found in " + c.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: &http.Transport{TLSClientConfig: tlsConfig, Proxy: http.ProxyFromEnvironment},
+	}, nil
+}
+
+func (m *manager) load() error {
+	data, err := ioutil.ReadFile(m.c.File)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &m.transfers); err != nil {
+		return errors.Wrap(err, "fts: error decoding transfers")
+	}
+	return nil
+}
+
+// persist writes the transfers to the file, the lock being held.
+func (m *manager) persist() error {
+	data, err := json.Marshal(m.transfers)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(m.c.File), 0700); err != nil {
+		return err
+	}
+	return errors.Wrap(ioutil.WriteFile(m.c.File, data, 0600), "fts: error writing transfers")
+}
+
+type ftsFile struct {
+	Sources           []string `json:"sources"`
+	Destinations      []string `json:"destinations"`
+	SourceTokens      []string `json:"source_tokens,omitempty"`
+	DestinationTokens []string `json:"destination_tokens,omitempty"`
+}
+
+type ftsJob struct {
+	Files  []*ftsFile             `json:"files"`
+	Params map[string]interface{} `json:"params"`
+}
+
+func (m *manager) CreateTransfer(ctx context.Context, srcTargetURI, dstTargetURI string, move bool) (*txv1beta1.TxInfo, error) {
+	u, ok := ctxpkg.ContextGetUser(ctx)
+	if !ok {
+		return nil, errtypes.UserRequired("fts: user not found in context")
+	}
+	src, srcToken, err := datatx.ParseTargetURI(srcTargetURI)
+	if err != nil {
+		return nil, err
+	}
+	dst, dstToken, err := datatx.ParseTargetURI(dstTargetURI)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := listFiles(src, srcToken)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, errtypes.BadRequest("fts: nothing to transfer")
+	}
+
+	t := &transfer{
+		ID:     uuid.New().String(),
+		Owner:  u.Id,
+		Src:    srcTargetURI,
+		Dst:    dstTargetURI,
+		Move:   move,
+		Status: txv1beta1.TxInfo_STATUS_TRANSFER_NEW,
+		Ctime:  time.Now(),
+	}
+
+	job := &ftsJob{
+		Params: map[string]interface{}{
+			"verify_checksum": m.c.VerifyChecksum,
+			"overwrite":       m.c.Overwrite,
+			"retry":           m.c.Retry,
+			"priority":        m.c.Priority,
+			"job_metadata": map[string]string{
+				"reva_transfer_id": t.ID,
+				"reva_user":        u.Id.OpaqueId + "@" + u.Id.Idp,
+			},
+		},
+	}
+	for _, f := range files {
+		file := &ftsFile{
+			Sources:      []string{davURL(src, f)},
+			Destinations: []string{davURL(dst, f)},
+		}
+		if srcToken != "" {
+			file.SourceTokens = []string{srcToken}
+		}
+		if dstToken != "" {
+			file.DestinationTokens = []string{dstToken}
+		}
+		job.Files = append(job.Files, file)
+	}
+
+	res := &struct {
+		JobID string `json:"job_id"`
+	}{}
+	if err := m.call(ctx, http.MethodPost, "/jobs", job, res); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t.JobID = res.JobID
+	t.Status = txv1beta1.TxInfo_STATUS_TRANSFER_IN_PROGRESS
+	m.transfers[t.ID] = t
+	if err := m.persist(); err != nil {
+		return nil, err
+	}
+	appctx.GetLogger(ctx).Info().Str("transfer", t.ID).Str("job", t.JobID).Int("files", len(files)).Msg("fts: transfer submitted")
+	return t.info(), nil
+}
+
+// listFiles returns the paths of the files below the WebDAV URL, relative to
+// it, or the empty path if the URL is the one of a file.
+func listFiles(endpoint, tkn string) ([]string, error) {
+	c := gowebdav.NewClient(endpoint, "", "")
+	c.SetHeader(token.TokenHeader, tkn)
+
+	info, err := c.Stat("/")
+	if err != nil {
+		return nil, errors.Wrap(err, "fts: error statting the source")
+	}
+	if !info.IsDir() {
+		return []string{""}, nil
+	}
+
+	var files []string
+	var walk func(dir string) error
+	walk = func(dir string) error {
+		infos, err := c.ReadDir(dir)
+		if err != nil {
+			return errors.Wrap(err, "fts: error listing the source")
+		}
+		for _, i := range infos {
+			p := path.Join(dir, i.Name())
+			if i.IsDir() {
+				if err := walk(p); err != nil {
+					return err
+				}
+				continue
+			}
+			files = append(files, strings.TrimPrefix(p, "/"))
+		}
+		return nil
+	}
+	if err := walk("/"); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// davURL returns the URL of the file below the WebDAV URL, with the scheme
+// understood by FTS3.
+func davURL(endpoint, fn string) string {
+	u, _ := url.Parse(endpoint)
+	if fn != "" {
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/" + fn
+	}
+	switch u.Scheme {
+	case "https":
+		u.Scheme = "davs"
+	case "http":
+		u.Scheme = "dav"
+	}
+	return u.String()
+}
+
+func (m *manager) GetTransferStatus(ctx context.Context, id string) (*txv1beta1.TxInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return t.info(), nil
+}
+
+func (m *manager) CancelTransfer(ctx context.Context, id string) (*txv1beta1.TxInfo, error) {
+	m.mu.Lock()
+	t, err := m.get(ctx, id)
+	if err != nil {
+		m.mu.Unlock()
+		return nil, err
+	}
+	if !t.running() {
+		info := t.info()
+		m.mu.Unlock()
+		return info, nil
+	}
+	jobID := t.JobID
+	m.mu.Unlock()
+
+	err = m.call(ctx, http.MethodDelete, "/jobs/"+url.PathEscape(jobID), nil, nil)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err != nil {
+		t.Status = txv1beta1.TxInfo_STATUS_TRANSFER_CANCEL_FAILED
+		t.Description = err.Error()
+	} else {
+		t.Status = txv1beta1.TxInfo_STATUS_TRANSFER_CANCELLED
+	}
+	if err := m.persist(); err != nil {
+		return nil, err
+	}
+	return t.info(), nil
+}
+
+// get returns the transfer of the user, the lock being held.
+func (m *manager) get(ctx context.Context, id string) (*transfer, error) {
+	u, ok := ctxpkg.ContextGetUser(ctx)
+	if !ok {
+		return nil, errtypes.UserRequired("fts: user not found in context")
+	}
+	t, ok := m.transfers[id]
+	if !ok || !utils.UserEqual(t.Owner, u.Id) {
+		return nil, errtypes.NotFound(id)
+	}
+	return t, nil
+}
+
+// poll follows the states of the jobs of the running transfers.
+func (m *manager) poll() {
+	ticker := time.NewTicker(time.Duration(m.c.PollInterval) * time.Second)
+	for range ticker.C {
+		m.check()
+	}
+}
+
+func (m *manager) check() {
+	ctx := context.Background()
+
+	m.mu.Lock()
+	running := map[string]*transfer{}
+	for id, t := range m.transfers {
+		if t.running() {
+			c := *t
+			running[id] = &c
+		}
+	}
+	m.mu.Unlock()
+
+	for id, t := range running {
+		job := &struct {
+			JobState string `json:"job_state"`
+			Reason   string `json:"reason"`
+		}{}
+		if err := m.call(ctx, http.MethodGet, "/jobs/"+url.PathEscape(t.JobID), nil, job); err != nil {
+			log.Error().Err(err).Str("transfer", id).Msg("fts: error getting job state")
+			continue
+		}
+
+		st, description := txv1beta1.TxInfo_STATUS_TRANSFER_IN_PROGRESS, ""
+		switch job.JobState {
+		case "FINISHED":
+			st = txv1beta1.TxInfo_STATUS_TRANSFER_COMPLETE
+			if t.Move {
+				if err := removeSource(t.Src); err != nil {
+					st, description = txv1beta1.TxInfo_STATUS_TRANSFER_FAILED, err.Error()
+				}
+			}
+		case "FAILED", "FINISHEDDIRTY":
+			st, description = txv1beta1.TxInfo_STATUS_TRANSFER_FAILED, job.Reason
+		case "CANCELED":
+			st = txv1beta1.TxInfo_STATUS_TRANSFER_CANCELLED
+		default:
+			continue
+		}
+
+		m.mu.Lock()
+		if cur := m.transfers[id]; cur != nil && cur.running() {
+			cur.Status, cur.Description = st, description
+			if err := m.persist(); err != nil {
+				log.Error().Err(err).Msg("fts: error persisting transfers")
+			}
+		}
+		m.mu.Unlock()
+	}
+}
+
+// removeSource removes the source of a moved resource.
+func removeSource(srcTargetURI string) error {
+	endpoint, tkn, err := datatx.ParseTargetURI(srcTargetURI)
+	if err != nil {
+		return err
+	}
+	c := gowebdav.NewClient(endpoint, "", "")
+	c.SetHeader(token.TokenHeader, tkn)
+	return errors.Wrap(c.RemoveAll("/"), "fts: error removing the source of the move")
+}
+
+// call calls the REST API of FTS3, decoding the result in res if it is not
+// nil.
+func (m *manager) call(ctx context.Context, method, p string, body interface{}, res interface{}) error {
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, m.c.Endpoint+p, r)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	httpRes, err := m.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "fts: error calling "+method+" "+p)
+	}
+	defer httpRes.Body.Close()
+
+	if httpRes.StatusCode < 200 || httpRes.StatusCode >= 300 {
+		e := &struct {
+			Message string `json:"message"`
+		}{}
+		_ = json.NewDecoder(httpRes.Body).Decode(e)
+		msg := "fts: " + method + " " + p + ": " + httpRes.Status + ": " + e.Message
+		switch httpRes.StatusCode {
+		case http.StatusNotFound:
+			return errtypes.NotFound(msg)
+		case http.StatusForbidden, http.StatusUnauthorized:
+			return errtypes.PermissionDenied(msg)
+		default:
+			return errors.New(msg)
+		}
+	}
+	if res == nil {
+		return nil
+	}
+	return errors.Wrap(json.NewDecoder(httpRes.Body).Decode(res), "fts: error decoding response")
+}
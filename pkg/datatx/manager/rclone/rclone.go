@@ -0,0 +1,453 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package rclone
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	txv1beta1 "github.com/cs3org/go-cs3apis/cs3/tx/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/datatx"
+	"github.com/cs3org/reva/pkg/datatx/manager/registry"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/rhttp"
+	"github.com/cs3org/reva/pkg/token"
+	ctxpkg "github.com/cs3org/reva/pkg/user"
+	"github.com/cs3org/reva/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+)
+
+func init() {
+	registry.Register("rclone", New)
+}
+
+type config struct {
+	// Endpoint is the URL of the remote control API of rclone, i.e. of an
+	// rclone rcd daemon, AuthUser and AuthPass its basic auth credentials.
+	Endpoint string `mapstructure:"endpoint"`
+	AuthUser string `mapstructure:"auth_user"`
+	AuthPass string `mapstructure:"auth_pass"`
+	// File is the file the transfers are stored in.
+	File string `mapstructure:"file"`
+	// Transfers is the number of files transferred in parallel and
+	// MultiThreadStreams the number of streams large files are transferred
+	// with.
+	Transfers          int `mapstructure:"transfers"`
+	MultiThreadStreams int `mapstructure:"multi_thread_streams"`
+	// BwLimit caps the bandwidth of every transfer, in the rclone syntax,
+	// e.g. 10M for 10 MiB/s.
+	BwLimit string `mapstructure:"bwlimit"`
+	// Checksum makes rclone compare the checksums of the files rather than
+	// their sizes and modification times.
+	Checksum bool `mapstructure:"checksum"`
+	// MaxRetries is the number of times a failed transfer is restarted. The
+	// files already transferred are not transferred again.
+	MaxRetries int `mapstructure:"max_retries"`
+	// PollInterval is the number of seconds between the checks of the
+	// progress of the transfers.
+	PollInterval int  `mapstructure:"poll_interval"`
+	Insecure     bool `mapstructure:"insecure"`
+}
+
+func (c *config) init() {
+	c.Endpoint = strings.TrimSuffix(c.Endpoint, "/")
+	if c.File == "" {
+		c.File = "/var/tmp/reva/datatx-transfers.json"
+	}
+	if c.Transfers == 0 {
+		c.Transfers = 4
+	}
+	if c.MultiThreadStreams == 0 {
+		c.MultiThreadStreams = 4
+	}
+	if c.MaxRetries == 0 {
+		c.MaxRetries = 3
+	}
+	if c.PollInterval == 0 {
+		c.PollInterval = 5
+	}
+}
+
+// transfer is a transfer run by an rclone job. A transfer restarted after a
+// failure gets a new job.
+type transfer struct {
+	ID          string           `json:"id"`
+	Owner       *userpb.UserId   `json:"owner"`
+	Src         string           `json:"src"`
+	Dst         string           `json:"dst"`
+	Move        bool             `json:"move"`
+	JobID       int64            `json:"job_id"`
+	Status      txv1beta1.TxInfo_Status `json:"status"`
+	Description string           `json:"description,omitempty"`
+	Retries     int              `json:"retries"`
+	Ctime       time.Time        `json:"ctime"`
+}
+
+func (t *transfer) info() *txv1beta1.TxInfo {
+	return &txv1beta1.TxInfo{
+		Id:          &txv1beta1.TxId{OpaqueId: t.ID},
+		Status:      t.Status,
+		Description: t.Description,
+		Ctime: &types.Timestamp{
+			Seconds: uint64(t.Ctime.Unix()),
+			Nanos:   uint32(t.Ctime.Nanosecond()),
+		},
+	}
+}
+
+func (t *transfer) running() bool {
+	return t.Status == txv1beta1.TxInfo_STATUS_TRANSFER_NEW || t.Status == txv1beta1.TxInfo_STATUS_TRANSFER_IN_PROGRESS
+}
+
+type manager struct {
+	c      *config
+	client *http.Client
+
+	mu        sync.Mutex
+	transfers map[string]*transfer
+}
+
+// New returns a data transfer manager running the transfers as jobs of an
+// rclone remote control daemon, see https://rclone.org/rc/. The resources
+// are transferred over WebDAV, from and to the URLs of the target URIs. The
+// transfers are stored in a JSON file and are followed up after a restart.
+func New(m map[string]interface{}) (datatx.Manager, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, errors.Wrap(err, "error decoding conf")
+	}
+	c.init()
+	if c.Endpoint == "" {
+		return nil, errors.New("rclone: endpoint must be set")
+	}
+
+	mgr := &manager{
+		c:         c,
+		client:    rhttp.GetHTTPClient(rhttp.Timeout(30*time.Second), rhttp.Insecure(c.Insecure)),
+		transfers: map[string]*transfer{},
+	}
+	if err := mgr.load(); err != nil {
+		return nil, err
+	}
+	go mgr.poll()
+	return mgr, nil
+}
+
+func (m *manager) load() error {
+	data, err := ioutil.ReadFile(m.c.File)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &m.transfers); err != nil {
+		return errors.Wrap(err, "rclone: error decoding transfers")
+	}
+	return nil
+}
+
+// persist writes the transfers to the file, the lock being held.
+func (m *manager) persist() error {
+	data, err := json.Marshal(m.transfers)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(m.c.File), 0700); err != nil {
+		return err
+	}
+	return errors.Wrap(ioutil.WriteFile(m.c.File, data, 0600), "rclone: error writing transfers")
+}
+
+func (m *manager) CreateTransfer(ctx context.Context, srcTargetURI, dstTargetURI string, move bool) (*txv1beta1.TxInfo, error) {
+	u, ok := ctxpkg.ContextGetUser(ctx)
+	if !ok {
+		return nil, errtypes.UserRequired("rclone: user not found in context")
+	}
+	// validate the URIs before submitting them
+	for _, uri := range []string{srcTargetURI, dstTargetURI} {
+		if _, _, err := datatx.ParseTargetURI(uri); err != nil {
+			return nil, err
+		}
+	}
+
+	t := &transfer{
+		ID:     uuid.New().String(),
+		Owner:  u.Id,
+		Src:    srcTargetURI,
+		Dst:    dstTargetURI,
+		Move:   move,
+		Status: txv1beta1.TxInfo_STATUS_TRANSFER_NEW,
+		Ctime:  time.Now(),
+	}
+	jobID, err := m.submit(ctx, t)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t.JobID = jobID
+	t.Status = txv1beta1.TxInfo_STATUS_TRANSFER_IN_PROGRESS
+	m.transfers[t.ID] = t
+	if err := m.persist(); err != nil {
+		return nil, err
+	}
+	appctx.GetLogger(ctx).Info().Str("transfer", t.ID).Int64("job", jobID).Msg("rclone: transfer started")
+	return t.info(), nil
+}
+
+func (m *manager) GetTransferStatus(ctx context.Context, id string) (*txv1beta1.TxInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t, err := m.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return t.info(), nil
+}
+
+func (m *manager) CancelTransfer(ctx context.Context, id string) (*txv1beta1.TxInfo, error) {
+	m.mu.Lock()
+	t, err := m.get(ctx, id)
+	if err != nil {
+		m.mu.Unlock()
+		return nil, err
+	}
+	if !t.running() {
+		info := t.info()
+		m.mu.Unlock()
+		return info, nil
+	}
+	jobID := t.JobID
+	m.mu.Unlock()
+
+	err = m.call(ctx, "job/stop", map[string]interface{}{"jobid": jobID}, nil)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err != nil {
+		t.Status = txv1beta1.TxInfo_STATUS_TRANSFER_CANCEL_FAILED
+		t.Description = err.Error()
+	} else {
+		t.Status = txv1beta1.TxInfo_STATUS_TRANSFER_CANCELLED
+	}
+	if err := m.persist(); err != nil {
+		return nil, err
+	}
+	return t.info(), nil
+}
+
+// get returns the transfer of the user, the lock being held.
+func (m *manager) get(ctx context.Context, id string) (*transfer, error) {
+	u, ok := ctxpkg.ContextGetUser(ctx)
+	if !ok {
+		return nil, errtypes.UserRequired("rclone: user not found in context")
+	}
+	t, ok := m.transfers[id]
+	if !ok || !utils.UserEqual(t.Owner, u.Id) {
+		return nil, errtypes.NotFound(id)
+	}
+	return t, nil
+}
+
+// fs returns the on the fly rclone remote of the target URI.
+func fs(targetURI string) (string, error) {
+	endpoint, tkn, err := datatx.ParseTargetURI(targetURI)
+	if err != nil {
+		return "", err
+	}
+	return ":webdav,url=" + quote(endpoint) + ",headers=" + quote(token.TokenHeader+","+tkn) + ":", nil
+}
+
+// quote quotes a parameter of an rclone connection string.
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// submit starts an rclone job for the transfer, returning its id.
+func (m *manager) submit(ctx context.Context, t *transfer) (int64, error) {
+	src, err := fs(t.Src)
+	if err != nil {
+		return 0, err
+	}
+	dst, err := fs(t.Dst)
+	if err != nil {
+		return 0, err
+	}
+
+	method := "sync/copy"
+	req := map[string]interface{}{
+		"srcFs":   src,
+		"dstFs":   dst,
+		"_async":  true,
+		"_config": m.jobConfig(),
+	}
+	if t.Move {
+		method = "sync/move"
+		req["deleteEmptySrcDirs"] = true
+	}
+
+	res := &struct {
+		JobID int64 `json:"jobid"`
+	}{}
+	if err := m.call(ctx, method, req, res); err != nil {
+		return 0, err
+	}
+	return res.JobID, nil
+}
+
+// jobConfig returns the options of rclone overridden for the jobs.
+func (m *manager) jobConfig() map[string]interface{} {
+	c := map[string]interface{}{
+		"Transfers":          m.c.Transfers,
+		"MultiThreadStreams": m.c.MultiThreadStreams,
+		"CheckSum":           m.c.Checksum,
+	}
+	if m.c.BwLimit != "" {
+		c["BwLimit"] = m.c.BwLimit
+	}
+	return c
+}
+
+type jobStatus struct {
+	Finished bool   `json:"finished"`
+	Success  bool   `json:"success"`
+	Error    string `json:"error"`
+}
+
+// poll follows the progress of the running transfers, restarting the failed
+// ones.
+func (m *manager) poll() {
+	ticker := time.NewTicker(time.Duration(m.c.PollInterval) * time.Second)
+	for range ticker.C {
+		m.check()
+	}
+}
+
+func (m *manager) check() {
+	ctx := context.Background()
+
+	m.mu.Lock()
+	running := map[string]int64{}
+	for id, t := range m.transfers {
+		if t.running() {
+			running[id] = t.JobID
+		}
+	}
+	m.mu.Unlock()
+
+	for id, jobID := range running {
+		st := &jobStatus{}
+		err := m.call(ctx, "job/status", map[string]interface{}{"jobid": jobID}, st)
+		if err != nil {
+			// the job is unknown when rclone was restarted
+			if _, ok := err.(errtypes.IsNotFound); !ok {
+				log.Error().Err(err).Str("transfer", id).Msg("rclone: error getting job status")
+				continue
+			}
+			st = &jobStatus{Finished: true, Error: "the rclone job was lost"}
+		}
+		if !st.Finished {
+			continue
+		}
+
+		m.mu.Lock()
+		t := m.transfers[id]
+		if t == nil || !t.running() || t.JobID != jobID {
+			// cancelled in the meantime
+			m.mu.Unlock()
+			continue
+		}
+		switch {
+		case st.Success:
+			t.Status = txv1beta1.TxInfo_STATUS_TRANSFER_COMPLETE
+			t.Description = ""
+		case t.Retries < m.c.MaxRetries:
+			t.Retries++
+			t.Description = st.Error
+			if newJob, err := m.submit(ctx, t); err != nil {
+				t.Status = txv1beta1.TxInfo_STATUS_TRANSFER_FAILED
+				t.Description = err.Error()
+			} else {
+				log.Info().Str("transfer", id).Int64("job", newJob).Int("retry", t.Retries).Str("error", st.Error).Msg("rclone: transfer restarted")
+				t.JobID = newJob
+			}
+		default:
+			t.Status = txv1beta1.TxInfo_STATUS_TRANSFER_FAILED
+			t.Description = st.Error
+		}
+		if err := m.persist(); err != nil {
+			log.Error().Err(err).Msg("rclone: error persisting transfers")
+		}
+		m.mu.Unlock()
+	}
+}
+
+// call calls the method of the remote control API of rclone, decoding the
+// result in res if it is not nil.
+func (m *manager) call(ctx context.Context, method string, params interface{}, res interface{}) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.c.Endpoint+"/"+method, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if m.c.AuthUser != "" {
+		req.SetBasicAuth(m.c.AuthUser, m.c.AuthPass)
+	}
+
+	httpRes, err := m.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "rclone: error calling "+method)
+	}
+	defer httpRes.Body.Close()
+
+	if httpRes.StatusCode != http.StatusOK {
+		e := &struct {
+			Error string `json:"error"`
+		}{}
+		_ = json.NewDecoder(httpRes.Body).Decode(e)
+		if httpRes.StatusCode == http.StatusNotFound || strings.Contains(e.Error, "job not found") {
+			return errtypes.NotFound("rclone: " + method + ": " + e.Error)
+		}
+		return errors.New("rclone: error calling " + method + ": " + httpRes.Status + ": " + e.Error)
+	}
+	if res == nil {
+		return nil
+	}
+	return errors.Wrap(json.NewDecoder(httpRes.Body).Decode(res), "rclone: error decoding response of "+method)
+}
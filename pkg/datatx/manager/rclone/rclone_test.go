@@ -0,0 +1,108 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package rclone
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	txv1beta1 "github.com/cs3org/go-cs3apis/cs3/tx/v1beta1"
+	ctxpkg "github.com/cs3org/reva/pkg/user"
+)
+
+func TestTransferRestart(t *testing.T) {
+	var mu sync.Mutex
+	var submitted []map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		req := map[string]interface{}{}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		switch r.URL.Path {
+		case "/sync/copy":
+			submitted = append(submitted, req)
+			_ = json.NewEncoder(w).Encode(map[string]int{"jobid": len(submitted)})
+		case "/job/status":
+			// the first job fails, the second succeeds
+			if req["jobid"].(float64) == 1 {
+				_, _ = w.Write([]byte(`{"finished": true, "success": false, "error": "connection reset"}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"finished": true, "success": true}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	m, err := New(map[string]interface{}{
+		"endpoint": srv.URL,
+		"file":     filepath.Join(t.TempDir(), "transfers.json"),
+		"bwlimit":  "10M",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mgr := m.(*manager)
+
+	ctx := ctxpkg.ContextSetUser(context.Background(), &userpb.User{Id: &userpb.UserId{Idp: "idp", OpaqueId: "einstein"}})
+	info, err := mgr.CreateTransfer(ctx, "https://tkn1@site1.org/webdav/data", "https://tkn2@site2.org/webdav/Data-Transfers/data", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Status != txv1beta1.TxInfo_STATUS_TRANSFER_IN_PROGRESS {
+		t.Fatalf("unexpected status %v", info.Status)
+	}
+
+	mgr.check()
+	mgr.check()
+
+	info, err = mgr.GetTransferStatus(ctx, info.Id.OpaqueId)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Status != txv1beta1.TxInfo_STATUS_TRANSFER_COMPLETE {
+		t.Fatalf("unexpected status %v: %s", info.Status, info.Description)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(submitted) != 2 {
+		t.Fatalf("expected the transfer to be submitted twice, got %d", len(submitted))
+	}
+	src := submitted[0]["srcFs"].(string)
+	if src != ":webdav,url='https://site1.org/webdav/data',headers='x-access-token,tkn1':" {
+		t.Errorf("unexpected source %s", src)
+	}
+	if c := submitted[0]["_config"].(map[string]interface{}); c["BwLimit"] != "10M" || !strings.HasPrefix(submitted[1]["dstFs"].(string), ":webdav,url='https://site2.org/") {
+		t.Errorf("unexpected job %v", submitted[0])
+	}
+
+	other := ctxpkg.ContextSetUser(context.Background(), &userpb.User{Id: &userpb.UserId{Idp: "idp", OpaqueId: "marie"}})
+	if _, err := mgr.GetTransferStatus(other, info.Id.OpaqueId); err == nil {
+		t.Error("the transfer of another user must not be found")
+	}
+}
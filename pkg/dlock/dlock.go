@@ -0,0 +1,67 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package dlock provides locks shared by the replicas of the services, so
+// that running several of them does not cause duplicate work or races.
+package dlock
+
+import (
+	"context"
+	"time"
+
+	"github.com/cs3org/reva/pkg/errtypes"
+)
+
+// Locker hands out named locks.
+type Locker interface {
+	// TryLock acquires the named lock for at most ttl without waiting. It
+	// returns an errtypes.Locked error when the lock is held.
+	TryLock(ctx context.Context, name string, ttl time.Duration) (Lock, error)
+}
+
+// Lock is a held lock, which is released by Unlock or at the end of its ttl.
+type Lock interface {
+	// Refresh extends the lock by its ttl.
+	Refresh(ctx context.Context) error
+	// Unlock releases the lock.
+	Unlock(ctx context.Context) error
+}
+
+const (
+	minRetry = 50 * time.Millisecond
+	maxRetry = time.Second
+)
+
+// Acquire waits until the named lock is acquired or the context is done.
+func Acquire(ctx context.Context, l Locker, name string, ttl time.Duration) (Lock, error) {
+	retry := minRetry
+	for {
+		lock, err := l.TryLock(ctx, name, ttl)
+		if _, ok := err.(errtypes.IsLocked); !ok {
+			return lock, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(retry):
+		}
+		if retry *= 2; retry > maxRetry {
+			retry = maxRetry
+		}
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package etcd
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/cs3org/reva/pkg/dlock"
+	"github.com/cs3org/reva/pkg/dlock/driver/registry"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+func init() {
+	registry.Register("etcd", New)
+}
+
+type config struct {
+	Endpoints []string `mapstructure:"endpoints"`
+	Username  string   `mapstructure:"username"`
+	Password  string   `mapstructure:"password"`
+	// DialTimeout is the timeout in seconds for connecting to etcd.
+	DialTimeout int `mapstructure:"dial_timeout"`
+	// Prefix is prepended to the names of the locks to build their keys.
+	Prefix string `mapstructure:"prefix"`
+}
+
+func (c *config) init() {
+	if len(c.Endpoints) == 0 {
+		c.Endpoints = []string{"localhost:2379"}
+	}
+	if c.DialTimeout == 0 {
+		c.DialTimeout = 5
+	}
+	if c.Prefix == "" {
+		c.Prefix = "/reva/locks/"
+	}
+}
+
+type locker struct {
+	c      *config
+	client *clientv3.Client
+}
+
+// New returns a locker storing the locks in etcd, for the replicas sharing
+// an etcd cluster. The locks are bound to etcd leases, whose ttl is rounded
+// up to the second.
+func New(m map[string]interface{}) (dlock.Locker, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, errors.Wrap(err, "error decoding conf")
+	}
+	c.init()
+
+	client, err := clientv3.New(clientv3.Config{
+		Endpoints:   c.Endpoints,
+		Username:    c.Username,
+		Password:    c.Password,
+		DialTimeout: time.Duration(c.DialTimeout) * time.Second,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "dlock: error connecting to etcd")
+	}
+	return &locker{c: c, client: client}, nil
+}
+
+func (l *locker) TryLock(ctx context.Context, name string, ttl time.Duration) (dlock.Lock, error) {
+	lease, err := l.client.Grant(ctx, int64(math.Ceil(ttl.Seconds())))
+	if err != nil {
+		return nil, errors.Wrap(err, "dlock: error granting lease")
+	}
+
+	key := l.c.Prefix + name
+	res, err := l.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.CreateRevision(key), "=", 0)).
+		Then(clientv3.OpPut(key, "", clientv3.WithLease(lease.ID))).
+		Commit()
+	if err == nil && !res.Succeeded {
+		err = errtypes.Locked(name)
+	}
+	if err != nil {
+		_, _ = l.client.Revoke(context.Background(), lease.ID)
+		if _, ok := err.(errtypes.IsLocked); ok {
+			return nil, err
+		}
+		return nil, errors.Wrap(err, "dlock: error acquiring lock "+name)
+	}
+	return &lock{client: l.client, lease: lease.ID}, nil
+}
+
+type lock struct {
+	client *clientv3.Client
+	lease  clientv3.LeaseID
+}
+
+func (lk *lock) Refresh(ctx context.Context) error {
+	if _, err := lk.client.KeepAliveOnce(ctx, lk.lease); err != nil {
+		return errors.Wrap(err, "dlock: error refreshing lock")
+	}
+	return nil
+}
+
+func (lk *lock) Unlock(ctx context.Context) error {
+	if _, err := lk.client.Revoke(ctx, lk.lease); err != nil {
+		return errors.Wrap(err, "dlock: error releasing lock")
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package memory
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/cs3org/reva/pkg/dlock"
+	"github.com/cs3org/reva/pkg/dlock/driver/registry"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/google/uuid"
+)
+
+func init() {
+	registry.Register("memory", New)
+}
+
+type entry struct {
+	token   string
+	expires time.Time
+}
+
+type locker struct {
+	sync.Mutex
+	locks map[string]entry
+}
+
+var shared = &locker{locks: map[string]entry{}}
+
+// New returns a locker whose locks are shared by the services of the
+// process, for single replica deployments.
+func New(m map[string]interface{}) (dlock.Locker, error) {
+	return shared, nil
+}
+
+func (l *locker) TryLock(ctx context.Context, name string, ttl time.Duration) (dlock.Lock, error) {
+	l.Lock()
+	defer l.Unlock()
+
+	now := time.Now()
+	for n, e := range l.locks {
+		if now.After(e.expires) {
+			delete(l.locks, n)
+		}
+	}
+	if _, ok := l.locks[name]; ok {
+		return nil, errtypes.Locked(name)
+	}
+	lk := &lock{l: l, name: name, token: uuid.New().String(), ttl: ttl}
+	l.locks[name] = entry{token: lk.token, expires: now.Add(ttl)}
+	return lk, nil
+}
+
+type lock struct {
+	l     *locker
+	name  string
+	token string
+	ttl   time.Duration
+}
+
+func (lk *lock) Refresh(ctx context.Context) error {
+	lk.l.Lock()
+	defer lk.l.Unlock()
+
+	e, ok := lk.l.locks[lk.name]
+	if !ok || e.token != lk.token || time.Now().After(e.expires) {
+		return errtypes.NotFound("dlock: lock lost: " + lk.name)
+	}
+	e.expires = time.Now().Add(lk.ttl)
+	lk.l.locks[lk.name] = e
+	return nil
+}
+
+func (lk *lock) Unlock(ctx context.Context) error {
+	lk.l.Lock()
+	defer lk.l.Unlock()
+
+	if e, ok := lk.l.locks[lk.name]; ok && e.token == lk.token {
+		delete(lk.l.locks, lk.name)
+	}
+	return nil
+}
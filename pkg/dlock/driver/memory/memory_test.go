@@ -0,0 +1,64 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cs3org/reva/pkg/dlock"
+	"github.com/cs3org/reva/pkg/errtypes"
+)
+
+func TestLocks(t *testing.T) {
+	ctx := context.Background()
+	l := &locker{locks: map[string]entry{}}
+
+	lk, err := l.TryLock(ctx, "a", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.TryLock(ctx, "a", time.Minute); err == nil {
+		t.Fatal("the lock should be held")
+	} else if _, ok := err.(errtypes.IsLocked); !ok {
+		t.Fatalf("expected a locked error, got %v", err)
+	}
+	if _, err := l.TryLock(ctx, "b", time.Minute); err != nil {
+		t.Fatal("the locks should be independent")
+	}
+
+	if err := lk.Unlock(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := l.TryLock(ctx, "a", 10*time.Millisecond); err != nil {
+		t.Fatal("the lock should have been released")
+	}
+
+	// the expired lock is acquired by the waiting caller
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	lk, err = dlock.Acquire(ctx, l, "a", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := lk.Refresh(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
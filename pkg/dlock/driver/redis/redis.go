@@ -0,0 +1,163 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package redis
+
+import (
+	"context"
+	"time"
+
+	"github.com/cs3org/reva/pkg/dlock"
+	"github.com/cs3org/reva/pkg/dlock/driver/registry"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/gomodule/redigo/redis"
+	"github.com/google/uuid"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	registry.Register("redis", New)
+}
+
+type config struct {
+	Address  string `mapstructure:"address"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	// Prefix is prepended to the names of the locks to build their keys.
+	Prefix string `mapstructure:"prefix"`
+}
+
+func (c *config) init() {
+	if c.Address == "" {
+		c.Address = "localhost:6379"
+	}
+	if c.Prefix == "" {
+		c.Prefix = "reva:lock:"
+	}
+}
+
+// The locks are only released or extended by their holder, identified by
+// the random token stored in the key.
+var (
+	unlockScript = redis.NewScript(1, `
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+	refreshScript = redis.NewScript(1, `
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+)
+
+type locker struct {
+	pool   *redis.Pool
+	prefix string
+}
+
+// New returns a locker storing the locks in redis, for the replicas sharing
+// a redis server.
+func New(m map[string]interface{}) (dlock.Locker, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, errors.Wrap(err, "error decoding conf")
+	}
+	c.init()
+
+	return &locker{pool: newRedisPool(c.Address, c.Username, c.Password), prefix: c.Prefix}, nil
+}
+
+func (l *locker) TryLock(ctx context.Context, name string, ttl time.Duration) (dlock.Lock, error) {
+	conn, err := l.pool.GetContext(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "dlock: error getting redis connection")
+	}
+	defer conn.Close()
+
+	lk := &lock{pool: l.pool, key: l.prefix + name, token: uuid.New().String(), ttl: ttl}
+	if _, err := redis.String(conn.Do("SET", lk.key, lk.token, "NX", "PX", ttl.Milliseconds())); err != nil {
+		if err == redis.ErrNil {
+			return nil, errtypes.Locked(name)
+		}
+		return nil, errors.Wrap(err, "dlock: error acquiring lock "+name)
+	}
+	return lk, nil
+}
+
+type lock struct {
+	pool  *redis.Pool
+	key   string
+	token string
+	ttl   time.Duration
+}
+
+func (lk *lock) Refresh(ctx context.Context) error {
+	conn, err := lk.pool.GetContext(ctx)
+	if err != nil {
+		return errors.Wrap(err, "dlock: error getting redis connection")
+	}
+	defer conn.Close()
+
+	n, err := redis.Int(refreshScript.Do(conn, lk.key, lk.token, lk.ttl.Milliseconds()))
+	if err != nil {
+		return errors.Wrap(err, "dlock: error refreshing lock")
+	}
+	if n == 0 {
+		return errtypes.NotFound("dlock: lock lost: " + lk.key)
+	}
+	return nil
+}
+
+func (lk *lock) Unlock(ctx context.Context) error {
+	conn, err := lk.pool.GetContext(ctx)
+	if err != nil {
+		return errors.Wrap(err, "dlock: error getting redis connection")
+	}
+	defer conn.Close()
+
+	if _, err := unlockScript.Do(conn, lk.key, lk.token); err != nil {
+		return errors.Wrap(err, "dlock: error releasing lock")
+	}
+	return nil
+}
+
+func newRedisPool(address, username, password string) *redis.Pool {
+	return &redis.Pool{
+		MaxIdle:     10,
+		MaxActive:   100,
+		IdleTimeout: 240 * time.Second,
+		Dial: func() (redis.Conn, error) {
+			opts := []redis.DialOption{}
+			if username != "" {
+				opts = append(opts, redis.DialUsername(username))
+			}
+			if password != "" {
+				opts = append(opts, redis.DialPassword(password))
+			}
+			return redis.Dial("tcp", address, opts...)
+		},
+		TestOnBorrow: func(c redis.Conn, t time.Time) error {
+			_, err := c.Do("PING")
+			return err
+		},
+	}
+}
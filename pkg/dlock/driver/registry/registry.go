@@ -0,0 +1,63 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package registry
+
+import (
+	"github.com/cs3org/reva/pkg/dlock"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+// NewFunc is the function that lock drivers
+// should register at init time.
+type NewFunc func(map[string]interface{}) (dlock.Locker, error)
+
+// NewFuncs is a map containing all the registered lock drivers.
+var NewFuncs = map[string]NewFunc{}
+
+// Register registers a new lock driver new function.
+// Not safe for concurrent use. Safe for use from package init.
+func Register(name string, f NewFunc) {
+	NewFuncs[name] = f
+}
+
+type config struct {
+	Driver  string                            `mapstructure:"driver"`
+	Drivers map[string]map[string]interface{} `mapstructure:"drivers"`
+}
+
+// NewLocker returns the locker configured in the locks section of the
+// configuration of a service. Without configuration, the locks are only
+// shared by the services of the process.
+func NewLocker(m map[string]interface{}) (dlock.Locker, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, errors.Wrap(err, "dlock: error decoding conf")
+	}
+	if c.Driver == "" {
+		c.Driver = "memory"
+	}
+
+	f, ok := NewFuncs[c.Driver]
+	if !ok {
+		return nil, errtypes.NotFound("dlock: driver not found: " + c.Driver)
+	}
+	return f(c.Drivers[c.Driver])
+}
@@ -89,6 +89,10 @@ type Options struct {
 	// Requires extra metadata operations if set to true
 	VersionInvariant bool
 
+	// UseUserACLs makes the grants be stored as user ACLs (user.acl, evaluated
+	// through sys.eval.useracl) instead of sys ACLs on directories.
+	UseUserACLs bool
+
 	// SingleUsername is the username to use when connecting to EOS.
 	// Defaults to apache
 	SingleUsername string
@@ -264,55 +268,64 @@ func (c *Client) AddACL(ctx context.Context, uid, gid, rootUID, rootGID, path st
 	if err != nil {
 		return err
 	}
-	sysACL := a.CitrineSerialize()
-	args := []string{"-r", rootUID, rootGID, "acl"}
 
-	if finfo.IsDir {
-		args = append(args, "--sys", "--recursive")
-	} else {
-		args = append(args, "--user")
-		userACLAttr := &eosclient.Attribute{
-			Type: SystemAttr,
-			Key:  "eval.useracl",
-			Val:  "1",
-		}
-		if err = c.SetAttr(ctx, uid, gid, userACLAttr, false, path); err != nil {
-			return err
-		}
+	if finfo.IsDir && !c.opt.UseUserACLs {
+		return c.setACL(ctx, rootUID, rootGID, path, "--sys", true, a)
 	}
-	args = append(args, sysACL, path)
-
-	cmd := exec.CommandContext(ctx, c.opt.EosBinary, args...)
-	_, _, err = c.executeEOS(ctx, cmd)
-	return err
 
+	userACLAttr := &eosclient.Attribute{
+		Type: SystemAttr,
+		Key:  "eval.useracl",
+		Val:  "1",
+	}
+	if err = c.SetAttr(ctx, uid, gid, userACLAttr, false, path); err != nil {
+		return err
+	}
+	return c.setACL(ctx, rootUID, rootGID, path, "--user", finfo.IsDir, a)
 }
 
-// RemoveACL removes the acl from EOS.
+// RemoveACL removes the acl from EOS, from both the sys and the user ACLs.
 func (c *Client) RemoveACL(ctx context.Context, uid, gid, rootUID, rootGID, path string, a *acl.Entry) error {
 	finfo, err := c.GetFileInfoByPath(ctx, uid, gid, path)
 	if err != nil {
 		return err
 	}
 
-	sysACL := a.CitrineSerialize()
-	args := []string{"-r", rootUID, rootGID, "acl"}
-	if finfo.IsDir {
-		args = append(args, "--sys", "--recursive")
-	} else {
-		args = append(args, "--user")
-		userACLAttr := &eosclient.Attribute{
-			Type: SystemAttr,
-			Key:  "eval.useracl",
+	if finfo.SysACL.GetEntry(a.Type, a.Qualifier) != nil {
+		if err = c.setACL(ctx, rootUID, rootGID, path, "--sys", finfo.IsDir, a); err != nil {
+			return err
 		}
-		if err = c.UnsetAttr(ctx, uid, gid, userACLAttr, path); err != nil {
+	}
+
+	if finfo.UserACL.GetEntry(a.Type, a.Qualifier) != nil {
+		if err = c.setACL(ctx, rootUID, rootGID, path, "--user", finfo.IsDir, a); err != nil {
 			return err
 		}
+		if len(finfo.UserACL.Entries) == 1 {
+			userACLAttr := &eosclient.Attribute{
+				Type: SystemAttr,
+				Key:  "eval.useracl",
+			}
+			if err = c.UnsetAttr(ctx, uid, gid, userACLAttr, path); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// setACL runs the acl command for the given entry on either the sys or the
+// user ACLs. An entry without permissions is removed.
+func (c *Client) setACL(ctx context.Context, rootUID, rootGID, path, aclFlag string, recursive bool, a *acl.Entry) error {
+	args := []string{"-r", rootUID, rootGID, "acl", aclFlag}
+	if recursive {
+		args = append(args, "--recursive")
 	}
-	args = append(args, sysACL, path)
+	args = append(args, a.CitrineSerialize(), path)
 
 	cmd := exec.CommandContext(ctx, c.opt.EosBinary, args...)
-	_, _, err = c.executeEOS(ctx, cmd)
+	_, _, err := c.executeEOS(ctx, cmd)
 	return err
 }
 
@@ -337,6 +350,7 @@ func (c *Client) GetACL(ctx context.Context, uid, gid, path, aclType, target str
 }
 
 // ListACLs returns the list of ACLs present under the given path.
+// The sys and the user ACLs are merged, the sys ones taking precedence.
 // EOS returns uids/gid for Citrine version and usernames for older versions.
 // For Citire we need to convert back the uid back to username.
 func (c *Client) ListACLs(ctx context.Context, uid, gid, path string) ([]*acl.Entry, error) {
@@ -357,7 +371,9 @@ func (c *Client) getACLForPath(ctx context.Context, uid, gid, path string) (*acl
 		return nil, err
 	}
 
-	return finfo.SysACL, nil
+	acls := &acl.ACLs{Entries: append([]*acl.Entry{}, finfo.SysACL.Entries...)}
+	acls.Merge(finfo.UserACL)
+	return acls, nil
 }
 
 // GetFileInfoByInode returns the FileInfo by the given inode
@@ -915,6 +931,10 @@ func (c *Client) mapToFileInfo(kv map[string]string) (*eosclient.FileInfo, error
 	if err != nil {
 		return nil, err
 	}
+	userACL, err := acl.Parse(kv["user.acl"], acl.ShortTextForm)
+	if err != nil {
+		return nil, err
+	}
 
 	fi := &eosclient.FileInfo{
 		File:       kv["file"],
@@ -930,6 +950,7 @@ func (c *Client) mapToFileInfo(kv map[string]string) (*eosclient.FileInfo, error
 		IsDir:      isDir,
 		Instance:   c.opt.URL,
 		SysACL:     sysACL,
+		UserACL:    userACL,
 		TreeCount:  treeCount,
 		Attrs:      kv,
 	}
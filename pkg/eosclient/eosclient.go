@@ -83,6 +83,7 @@ type FileInfo struct {
 	ETag       string            `json:"etag"`
 	Instance   string            `json:"instance"`
 	SysACL     *acl.ACLs         `json:"sys_acl"`
+	UserACL    *acl.ACLs         `json:"user_acl"`
 	Attrs      map[string]string `json:"attrs"`
 }
 
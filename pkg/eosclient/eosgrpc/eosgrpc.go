@@ -19,25 +19,20 @@
 package eosgrpc
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"os"
-	"os/exec"
 	"path"
 	"path/filepath"
 	"strconv"
 	"strings"
-	"syscall"
 
 	"github.com/cs3org/reva/pkg/appctx"
 	"github.com/cs3org/reva/pkg/eosclient"
 	erpc "github.com/cs3org/reva/pkg/eosclient/eosgrpc/eos_grpc"
 	"github.com/cs3org/reva/pkg/errtypes"
 	"github.com/cs3org/reva/pkg/storage/utils/acl"
-	"github.com/google/uuid"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog/log"
 	"google.golang.org/grpc"
@@ -63,42 +58,38 @@ type Options struct {
 	// This is the case when access to EOS is done from FUSE under apache or www-data.
 	ForceSingleUserMode bool
 
-	// UseKeyTabAuth changes will authenticate requests by using an EOS keytab.
-	UseKeytab bool
-
 	// Whether to maintain the same inode across various versions of a file.
 	// Requires extra metadata operations if set to true
 	VersionInvariant bool
 
+	// UseUserACLs makes the grants be stored as user ACLs (user.acl, evaluated
+	// through sys.eval.useracl) instead of sys ACLs.
+	UseUserACLs bool
+
 	// SingleUsername is the username to use when connecting to EOS.
 	// Defaults to apache
 	SingleUsername string
 
-	// Location of the xrdcopy binary.
-	// Default is /opt/eos/xrootd/bin/xrdcopy.
-	XrdcopyBinary string
-
-	// URL of the EOS MGM.
-	// Default is root://eos-example.org
-	URL string
-
 	// URI of the EOS MGM grpc server
 	GrpcURI string
 
-	// Location on the local fs where to store reads.
-	// Defaults to os.TempDir()
-	CacheDirectory string
+	// HTTPURL is the URL of the XrdHttp interface of the EOS MGM, through
+	// which the data of the files is transferred.
+	// Default is https://eos-example.org:8443
+	HTTPURL string
+
+	// ClientCertFile and ClientKeyFile are the certificate and the key the
+	// client authenticates with to the XrdHttp interface, if any.
+	ClientCertFile string
+	ClientKeyFile  string
 
-	// Keytab is the location of the EOS keytab file.
-	Keytab string
+	// ClientCAFile holds the certificates of the authorities the XrdHttp
+	// interface is trusted from, in place of the ones of the system.
+	ClientCAFile string
 
 	// Authkey is the key that authorizes this client to connect to the GRPC service
 	// It's unclear whether this will be the final solution
 	Authkey string
-
-	// SecProtocol is the comma separated list of security protocols used by xrootd.
-	// For example: "sss, unix"
-	SecProtocol string
 }
 
 func (opt *Options) init() {
@@ -106,24 +97,18 @@ func (opt *Options) init() {
 		opt.SingleUsername = "apache"
 	}
 
-	if opt.XrdcopyBinary == "" {
-		opt.XrdcopyBinary = "/opt/eos/xrootd/bin/xrdcopy"
-	}
-
-	if opt.URL == "" {
-		opt.URL = "root://eos-example.org"
-	}
-
-	if opt.CacheDirectory == "" {
-		opt.CacheDirectory = os.TempDir()
+	if opt.HTTPURL == "" {
+		opt.HTTPURL = "https://eos-example.org:8443"
 	}
 }
 
 // Client performs actions against a EOS management node (MGM)
-// using the EOS GRPC interface.
+// using the EOS GRPC interface, the data of the files being transferred
+// through the XrdHttp interface.
 type Client struct {
-	opt *Options
-	cl  erpc.EosClient
+	opt  *Options
+	cl   erpc.EosClient
+	http *httpClient
 }
 
 // Create and connect a grpc eos Client
@@ -178,6 +163,13 @@ func New(opt *Options) *Client {
 	}
 	c.cl = ccl
 
+	hcl, err := newHTTPClient(opt)
+	if err != nil {
+		tlog.Error().Err(err).Msg("error creating the http client")
+		return nil
+	}
+	c.http = hcl
+
 	return c
 }
 
@@ -226,9 +218,20 @@ func (c *Client) initMDRequest(uid, gid string) (*erpc.MDRequest, error) {
 
 // AddACL adds an new acl to EOS with the given aclType.
 func (c *Client) AddACL(ctx context.Context, uid, gid, rootUID, rootGID, path string, a *acl.Entry) error {
-	log := appctx.GetLogger(ctx)
+	aclType := erpc.NSRequest_AclRequest_SYS_ACL
+	if c.opt.UseUserACLs {
+		aclType = erpc.NSRequest_AclRequest_USER_ACL
+		userACLAttr := &eosclient.Attribute{
+			Type: SystemAttr,
+			Key:  "eval.useracl",
+			Val:  "1",
+		}
+		if err := c.SetAttr(ctx, uid, gid, userACLAttr, false, path); err != nil {
+			return err
+		}
+	}
 
-	acls, err := c.getACLForPath(ctx, uid, gid, path)
+	acls, err := c.getACLForPath(ctx, uid, gid, path, aclType)
 	if err != nil {
 		return err
 	}
@@ -237,52 +240,73 @@ func (c *Client) AddACL(ctx context.Context, uid, gid, rootUID, rootGID, path st
 	if err != nil {
 		return err
 	}
-	sysACL := acls.Serialize()
 
-	// Init a new NSRequest
-	rq, err := c.initNSRequest(uid, gid)
-	if err != nil {
-		return err
-	}
+	return c.setACLForPath(ctx, uid, gid, path, aclType, acls)
+}
 
-	msg := new(erpc.NSRequest_AclRequest)
-	msg.Cmd = erpc.NSRequest_AclRequest_ACL_COMMAND(erpc.NSRequest_AclRequest_ACL_COMMAND_value["MODIFY"])
-	msg.Type = erpc.NSRequest_AclRequest_ACL_TYPE(erpc.NSRequest_AclRequest_ACL_TYPE_value["SYS_ACL"])
-	msg.Recursive = true
-	msg.Rule = sysACL
+// RemoveACL removes the acl from EOS, from both the sys and the user ACLs.
+func (c *Client) RemoveACL(ctx context.Context, uid, gid, rootUID, rootGID, path string, a *acl.Entry) error {
+	for _, aclType := range []erpc.NSRequest_AclRequest_ACL_TYPE{erpc.NSRequest_AclRequest_SYS_ACL, erpc.NSRequest_AclRequest_USER_ACL} {
+		acls, err := c.getACLForPath(ctx, uid, gid, path, aclType)
+		if err != nil {
+			return err
+		}
+		if acls.GetEntry(a.Type, a.Qualifier) == nil {
+			continue
+		}
 
-	msg.Id = new(erpc.MDId)
-	msg.Id.Path = []byte(path)
+		acls.DeleteEntry(a.Type, a.Qualifier)
+		if err := c.setACLForPath(ctx, uid, gid, path, aclType, acls); err != nil {
+			return err
+		}
+	}
 
-	rq.Command = &erpc.NSRequest_Acl{Acl: msg}
+	return nil
+}
 
-	// Now send the req and see what happens
-	resp, err := c.cl.Exec(context.Background(), rq)
+// UpdateACL updates the EOS acl.
+func (c *Client) UpdateACL(ctx context.Context, uid, gid, rootUID, rootGID, path string, a *acl.Entry) error {
+	return c.AddACL(ctx, uid, gid, rootUID, rootGID, path, a)
+}
+
+// GetACL for a file
+func (c *Client) GetACL(ctx context.Context, uid, gid, path, aclType, target string) (*acl.Entry, error) {
+	acls, err := c.ListACLs(ctx, uid, gid, path)
 	if err != nil {
-		log.Error().Str("Exec ", "'"+path+"' ").Str("err:", err.Error()).Msg("")
-		return err
+		return nil, err
 	}
-
-	log.Debug().Str("Exec ", "'"+path+"' ").Str("resp:", fmt.Sprintf("%#v", resp)).Msg("")
-	if resp == nil {
-		return errtypes.NotFound(fmt.Sprintf("Path: %s", path))
+	for _, a := range acls {
+		if a.Type == aclType && a.Qualifier == target {
+			return a, nil
+		}
 	}
-
-	return err
+	return nil, errtypes.NotFound(fmt.Sprintf("%s:%s", aclType, target))
 
 }
 
-// RemoveACL removes the acl from EOS.
-func (c *Client) RemoveACL(ctx context.Context, uid, gid, rootUID, rootGID, path string, a *acl.Entry) error {
-	log := appctx.GetLogger(ctx)
+// ListACLs returns the list of ACLs present under the given path.
+// The sys and the user ACLs are merged, the sys ones taking precedence.
+// EOS returns uids/gid for Citrine version and usernames for older versions.
+// For Citire we need to convert back the uid back to username.
+func (c *Client) ListACLs(ctx context.Context, uid, gid, path string) ([]*acl.Entry, error) {
+	parsedACLs, err := c.getACLForPath(ctx, uid, gid, path, erpc.NSRequest_AclRequest_SYS_ACL)
+	if err != nil {
+		return nil, err
+	}
 
-	acls, err := c.getACLForPath(ctx, uid, gid, path)
+	userACLs, err := c.getACLForPath(ctx, uid, gid, path, erpc.NSRequest_AclRequest_USER_ACL)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	parsedACLs.Merge(userACLs)
 
-	acls.DeleteEntry(a.Type, a.Qualifier)
-	sysACL := acls.Serialize()
+	// EOS Citrine ACLs are stored with uid. The UID will be resolved to the
+	// user opaque ID at the eosfs level.
+	return parsedACLs.Entries, nil
+}
+
+func (c *Client) setACLForPath(ctx context.Context, uid, gid, path string, aclType erpc.NSRequest_AclRequest_ACL_TYPE, acls *acl.ACLs) error {
+	log := appctx.GetLogger(ctx)
 
 	// Init a new NSRequest
 	rq, err := c.initNSRequest(uid, gid)
@@ -292,9 +316,9 @@ func (c *Client) RemoveACL(ctx context.Context, uid, gid, rootUID, rootGID, path
 
 	msg := new(erpc.NSRequest_AclRequest)
 	msg.Cmd = erpc.NSRequest_AclRequest_ACL_COMMAND(erpc.NSRequest_AclRequest_ACL_COMMAND_value["MODIFY"])
-	msg.Type = erpc.NSRequest_AclRequest_ACL_TYPE(erpc.NSRequest_AclRequest_ACL_TYPE_value["SYS_ACL"])
+	msg.Type = aclType
 	msg.Recursive = true
-	msg.Rule = sysACL
+	msg.Rule = acls.Serialize()
 
 	msg.Id = new(erpc.MDId)
 	msg.Id.Path = []byte(path)
@@ -313,45 +337,10 @@ func (c *Client) RemoveACL(ctx context.Context, uid, gid, rootUID, rootGID, path
 		return errtypes.NotFound(fmt.Sprintf("Path: %s", path))
 	}
 
-	return err
-
+	return nil
 }
 
-// UpdateACL updates the EOS acl.
-func (c *Client) UpdateACL(ctx context.Context, uid, gid, rootUID, rootGID, path string, a *acl.Entry) error {
-	return c.AddACL(ctx, uid, gid, path, rootUID, rootGID, a)
-}
-
-// GetACL for a file
-func (c *Client) GetACL(ctx context.Context, uid, gid, path, aclType, target string) (*acl.Entry, error) {
-	acls, err := c.ListACLs(ctx, uid, gid, path)
-	if err != nil {
-		return nil, err
-	}
-	for _, a := range acls {
-		if a.Type == aclType && a.Qualifier == target {
-			return a, nil
-		}
-	}
-	return nil, errtypes.NotFound(fmt.Sprintf("%s:%s", aclType, target))
-
-}
-
-// ListACLs returns the list of ACLs present under the given path.
-// EOS returns uids/gid for Citrine version and usernames for older versions.
-// For Citire we need to convert back the uid back to username.
-func (c *Client) ListACLs(ctx context.Context, uid, gid, path string) ([]*acl.Entry, error) {
-	parsedACLs, err := c.getACLForPath(ctx, uid, gid, path)
-	if err != nil {
-		return nil, err
-	}
-
-	// EOS Citrine ACLs are stored with uid. The UID will be resolved to the
-	// user opaque ID at the eosfs level.
-	return parsedACLs.Entries, nil
-}
-
-func (c *Client) getACLForPath(ctx context.Context, uid, gid, path string) (*acl.ACLs, error) {
+func (c *Client) getACLForPath(ctx context.Context, uid, gid, path string, aclType erpc.NSRequest_AclRequest_ACL_TYPE) (*acl.ACLs, error) {
 	log := appctx.GetLogger(ctx)
 
 	// Initialize the common fields of the NSReq
@@ -362,7 +351,7 @@ func (c *Client) getACLForPath(ctx context.Context, uid, gid, path string) (*acl
 
 	msg := new(erpc.NSRequest_AclRequest)
 	msg.Cmd = erpc.NSRequest_AclRequest_ACL_COMMAND(erpc.NSRequest_AclRequest_ACL_COMMAND_value["LIST"])
-	msg.Type = erpc.NSRequest_AclRequest_ACL_TYPE(erpc.NSRequest_AclRequest_ACL_TYPE_value["SYS_ACL"])
+	msg.Type = aclType
 	msg.Recursive = true
 
 	msg.Id = new(erpc.MDId)
@@ -833,7 +822,39 @@ func (c *Client) Remove(ctx context.Context, uid, gid, path string) error {
 
 // Rename renames the resource referenced by oldPath to newPath
 func (c *Client) Rename(ctx context.Context, uid, gid, oldPath, newPath string) error {
-	return errtypes.NotSupported("eosgrpc: Rename not implemented")
+	log := appctx.GetLogger(ctx)
+
+	// Initialize the common fields of the NSReq
+	rq, err := c.initNSRequest(uid, gid)
+	if err != nil {
+		return err
+	}
+
+	msg := new(erpc.NSRequest_RenameRequest)
+
+	msg.Id = new(erpc.MDId)
+	msg.Id.Path = []byte(oldPath)
+	msg.Target = []byte(newPath)
+
+	rq.Command = &erpc.NSRequest_Rename{Rename: msg}
+
+	// Now send the req and see what happens
+	resp, err := c.cl.Exec(ctx, rq)
+	if err != nil {
+		log.Warn().Err(err).Str("path", oldPath).Str("err", err.Error())
+		return err
+	}
+
+	if resp == nil {
+		return errtypes.InternalError(fmt.Sprintf("nil response for uid: '%s' path: '%s'", uid, oldPath))
+	}
+
+	log.Info().Str("path", oldPath).Str("target", newPath).Str("resp:", fmt.Sprintf("%#v", resp)).Msg("grpc response")
+
+	if e := resp.GetError(); e != nil && e.Code != 0 {
+		return errtypes.InternalError(fmt.Sprintf("eosgrpc: error renaming '%s' to '%s': %s", oldPath, newPath, e.Msg))
+	}
+	return nil
 }
 
 // List the contents of the directory given by path
@@ -908,43 +929,27 @@ func (c *Client) List(ctx context.Context, uid, gid, dpath string) ([]*eosclient
 
 // Read reads a file from the mgm
 func (c *Client) Read(ctx context.Context, uid, gid, path string) (io.ReadCloser, error) {
-	rand := "eosread-" + uuid.New().String()
-	localTarget := fmt.Sprintf("%s/%s", c.opt.CacheDirectory, rand)
-	defer os.RemoveAll(localTarget)
-
-	xrdPath := fmt.Sprintf("%s//%s", c.opt.URL, path)
-	cmd := exec.CommandContext(ctx, c.opt.XrdcopyBinary, "--nopbar", "--silent", "-f", xrdPath, localTarget, fmt.Sprintf("-OSeos.ruid=%s&eos.rgid=%s", uid, gid))
-	if _, _, err := c.execute(ctx, cmd); err != nil {
-		return nil, err
-	}
-	return os.Open(localTarget)
+	return c.http.get(ctx, uid, gid, path)
 }
 
 // Write writes a file to the mgm
 func (c *Client) Write(ctx context.Context, uid, gid, path string, stream io.ReadCloser) error {
-	fd, err := ioutil.TempFile(c.opt.CacheDirectory, "eoswrite-")
+	defer stream.Close()
+	return c.http.put(ctx, uid, gid, path, stream, -1)
+}
+
+// WriteFile writes an existing file to the mgm
+func (c *Client) WriteFile(ctx context.Context, uid, gid, path, source string) error {
+	fd, err := os.Open(source)
 	if err != nil {
 		return err
 	}
 	defer fd.Close()
-	defer os.RemoveAll(fd.Name())
-
-	// copy stream to local temp file
-	_, err = io.Copy(fd, stream)
+	fi, err := fd.Stat()
 	if err != nil {
 		return err
 	}
-
-	return c.WriteFile(ctx, uid, gid, path, fd.Name())
-}
-
-// WriteFile writes an existing file to the mgm
-func (c *Client) WriteFile(ctx context.Context, uid, gid, path, source string) error {
-	xrdPath := fmt.Sprintf("%s//%s", c.opt.URL, path)
-	cmd := exec.CommandContext(ctx, c.opt.XrdcopyBinary, "--nopbar", "--silent", "-f", source, xrdPath, fmt.Sprintf("-ODeos.ruid=%s&eos.rgid=%s", uid, gid))
-	_, _, err := c.execute(ctx, cmd)
-	return err
-
+	return c.http.put(ctx, uid, gid, path, fd, fi.Size())
 }
 
 // ListDeletedEntries returns a list of the deleted entries.
@@ -1076,15 +1081,15 @@ func (c *Client) ListVersions(ctx context.Context, uid, gid, p string) ([]*eoscl
 	return finfos, nil
 }
 
-// RollbackToVersion rollbacks a file to a previous version.
+// RollbackToVersion rollbacks a file to a previous version. The gRPC
+// interface having no rollback, the version is written over the file, EOS
+// keeping the current content as a new version.
 func (c *Client) RollbackToVersion(ctx context.Context, uid, gid, path, version string) error {
-	// TODO(ffurano):
-	/*
-		cmd := exec.CommandContext(ctx, c.opt.EosBinary, "-r", uid, gid, "file", "versions", path, version)
-		_, _, err = c.executeEOS(ctx, cmd)
+	r, err := c.ReadVersion(ctx, uid, gid, path, version)
+	if err != nil {
 		return err
-	*/
-	return errtypes.NotSupported("TODO")
+	}
+	return c.Write(ctx, uid, gid, path, r)
 }
 
 // ReadVersion reads the version for the given file.
@@ -1188,52 +1193,3 @@ func (c *Client) grpcMDResponseToFileInfo(st *erpc.MDResponse, namepfx string) (
 
 	return fi, nil
 }
-
-// exec executes the command and returns the stdout, stderr and return code
-func (c *Client) execute(ctx context.Context, cmd *exec.Cmd) (string, string, error) {
-	log := appctx.GetLogger(ctx)
-
-	outBuf := &bytes.Buffer{}
-	errBuf := &bytes.Buffer{}
-	cmd.Stdout = outBuf
-	cmd.Stderr = errBuf
-	cmd.Env = []string{
-		"EOS_MGM_URL=" + c.opt.URL,
-	}
-
-	if c.opt.UseKeytab {
-		cmd.Env = append(cmd.Env, "XrdSecPROTOCOL="+c.opt.SecProtocol)
-		cmd.Env = append(cmd.Env, "XrdSecSSSKT="+c.opt.Keytab)
-	}
-
-	err := cmd.Run()
-
-	var exitStatus int
-	if exiterr, ok := err.(*exec.ExitError); ok {
-		// The program has exited with an exit code != 0
-		// This works on both Unix and Windows. Although package
-		// syscall is generally platform dependent, WaitStatus is
-		// defined for both Unix and Windows and in both cases has
-		// an ExitStatus() method with the same signature.
-		if status, ok := exiterr.Sys().(syscall.WaitStatus); ok {
-
-			exitStatus = status.ExitStatus()
-			switch exitStatus {
-			case 0:
-				err = nil
-			case 2:
-				err = errtypes.NotFound(errBuf.String())
-			}
-		}
-	}
-
-	args := fmt.Sprintf("%s", cmd.Args)
-	env := fmt.Sprintf("%s", cmd.Env)
-	log.Info().Str("args", args).Str("env", env).Int("exit", exitStatus).Msg("eos cmd")
-
-	if err != nil && exitStatus != 2 { // don't wrap the errtypes.NotFoundError
-		err = errors.Wrap(err, "eosclient: error while executing command")
-	}
-
-	return outBuf.String(), errBuf.String(), err
-}
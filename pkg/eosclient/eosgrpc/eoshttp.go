@@ -0,0 +1,197 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package eosgrpc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/pkg/errors"
+)
+
+// httpClient transfers the data of the files through the XrdHttp interface
+// of EOS, the MGM redirecting the requests to the FSTs holding the files.
+type httpClient struct {
+	opt *Options
+	cl  *http.Client
+}
+
+func newHTTPClient(opt *Options) (*httpClient, error) {
+	tlsConfig := &tls.Config{}
+	if opt.ClientCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(opt.ClientCertFile, opt.ClientKeyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "eosgrpc: error loading the client certificate")
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	if opt.ClientCAFile != "" {
+		ca, err := ioutil.ReadFile(opt.ClientCAFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "eosgrpc: error reading the CA certificates")
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, errors.New("eosgrpc: no CA certificate in " + opt.ClientCAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	return &httpClient{
+		opt: opt,
+		cl: &http.Client{
+			Transport: &http.Transport{
+				Proxy:               http.ProxyFromEnvironment,
+				TLSClientConfig:     tlsConfig,
+				MaxIdleConnsPerHost: 64,
+				IdleConnTimeout:     90 * time.Second,
+			},
+			// the redirections to the FSTs are followed by hand, so that
+			// the uploads are sent once
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}, nil
+}
+
+// buildURL returns the URL of the file at the MGM, accessed with the role
+// of the user.
+func (c *httpClient) buildURL(p, uid, gid string) (string, error) {
+	u, err := url.Parse(c.opt.HTTPURL)
+	if err != nil {
+		return "", errors.Wrap(err, "eosgrpc: invalid http url "+c.opt.HTTPURL)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/") + "/" + strings.TrimPrefix(p, "/")
+	q := u.Query()
+	q.Set("eos.ruid", uid)
+	q.Set("eos.rgid", gid)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+func (c *httpClient) newRequest(ctx context.Context, method, target string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	if c.opt.Authkey != "" {
+		req.Header.Set("x-gateway-authorization", c.opt.Authkey)
+	}
+	return req, nil
+}
+
+// do sends the request built by newReq to the MGM, then to the FST the MGM
+// redirects it to.
+func (c *httpClient) do(ctx context.Context, target string, newReq func(target string, redirected bool) (*http.Request, error)) (*http.Response, error) {
+	log := appctx.GetLogger(ctx)
+	for i := 0; i < 10; i++ {
+		req, err := newReq(target, i > 0)
+		if err != nil {
+			return nil, err
+		}
+		res, err := c.cl.Do(req)
+		if err != nil {
+			return nil, errors.Wrap(err, "eosgrpc: error sending http request")
+		}
+		switch res.StatusCode {
+		case http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+			res.Body.Close()
+			loc, err := res.Location()
+			if err != nil {
+				return nil, errors.Wrap(err, "eosgrpc: invalid redirection")
+			}
+			log.Debug().Str("location", loc.Redacted()).Msg("eosgrpc: following redirection")
+			target = loc.String()
+			continue
+		}
+		return res, nil
+	}
+	return nil, errtypes.InternalError("eosgrpc: too many redirections")
+}
+
+func httpError(res *http.Response, p string) error {
+	switch res.StatusCode {
+	case http.StatusNotFound:
+		return errtypes.NotFound(p)
+	case http.StatusForbidden, http.StatusUnauthorized:
+		return errtypes.PermissionDenied(p)
+	case http.StatusInsufficientStorage:
+		return errtypes.InsufficientStorage(p)
+	}
+	return errtypes.InternalError(fmt.Sprintf("eosgrpc: http status %d for %s", res.StatusCode, p))
+}
+
+// get streams the content of the file.
+func (c *httpClient) get(ctx context.Context, uid, gid, p string) (io.ReadCloser, error) {
+	target, err := c.buildURL(p, uid, gid)
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.do(ctx, target, func(target string, _ bool) (*http.Request, error) {
+		return c.newRequest(ctx, http.MethodGet, target, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		res.Body.Close()
+		return nil, httpError(res, p)
+	}
+	return res.Body, nil
+}
+
+// put writes the content of the file, of unknown length when negative. The
+// MGM is asked for the FST without the content, which is only sent to the
+// FST.
+func (c *httpClient) put(ctx context.Context, uid, gid, p string, content io.Reader, length int64) error {
+	target, err := c.buildURL(p, uid, gid)
+	if err != nil {
+		return err
+	}
+	res, err := c.do(ctx, target, func(target string, redirected bool) (*http.Request, error) {
+		if !redirected {
+			return c.newRequest(ctx, http.MethodPut, target, nil)
+		}
+		req, err := c.newRequest(ctx, http.MethodPut, target, ioutil.NopCloser(content))
+		if err != nil {
+			return nil, err
+		}
+		req.ContentLength = length
+		return req, nil
+	})
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusCreated && res.StatusCode != http.StatusNoContent {
+		return httpError(res, p)
+	}
+	return nil
+}
@@ -122,6 +122,32 @@ func (e InsufficientStorage) IsInsufficientStorage() {}
 // https://developer.mozilla.org/en-US/docs/Web/HTTP/Status/507
 const StatusInssufficientStorage = 507
 
+// Locked is the error to use when a resource is locked with another lock.
+type Locked string
+
+func (e Locked) Error() string { return "error: locked: " + string(e) }
+
+// IsLocked implements the IsLocked interface.
+func (e Locked) IsLocked() {}
+
+// Unavailable is the error to use when a service temporarily refuses the
+// operation, e.g. during a maintenance.
+type Unavailable string
+
+func (e Unavailable) Error() string { return "error: unavailable: " + string(e) }
+
+// IsUnavailable implements the IsUnavailable interface.
+func (e Unavailable) IsUnavailable() {}
+
+// PreconditionFailed is the error to use when the resource does not match
+// the precondition of the operation, e.g. it changed in the meantime.
+type PreconditionFailed string
+
+func (e PreconditionFailed) Error() string { return "error: precondition failed: " + string(e) }
+
+// IsPreconditionFailed implements the IsPreconditionFailed interface.
+func (e PreconditionFailed) IsPreconditionFailed() {}
+
 // IsNotFound is the interface to implement
 // to specify that an a resource is not found.
 type IsNotFound interface {
@@ -187,3 +213,21 @@ type IsChecksumMismatch interface {
 type IsInsufficientStorage interface {
 	IsInsufficientStorage()
 }
+
+// IsLocked is the interface to implement
+// to specify that a resource is locked.
+type IsLocked interface {
+	IsLocked()
+}
+
+// IsUnavailable is the interface to implement
+// to specify that an operation is temporarily refused.
+type IsUnavailable interface {
+	IsUnavailable()
+}
+
+// IsPreconditionFailed is the interface to implement
+// to specify that the precondition of an operation is not met.
+type IsPreconditionFailed interface {
+	IsPreconditionFailed()
+}
@@ -0,0 +1,124 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/cs3org/reva/pkg/events"
+	"github.com/cs3org/reva/pkg/events/driver/registry"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	registry.Register("memory", New)
+}
+
+type config struct {
+	// Name is the name of the bus. The services of the process configured
+	// with the same name share the bus.
+	Name string `mapstructure:"name"`
+	// BufferSize is the number of messages kept for each consumer group
+	// before new messages are dropped.
+	BufferSize int `mapstructure:"buffer_size"`
+}
+
+func (c *config) init() {
+	if c.Name == "" {
+		c.Name = "default"
+	}
+	if c.BufferSize == 0 {
+		c.BufferSize = 1024
+	}
+}
+
+type bus struct {
+	sync.RWMutex
+	size   int
+	groups map[string]chan *events.Message
+}
+
+var (
+	mu    sync.Mutex
+	buses = map[string]*bus{}
+)
+
+// New returns a bus delivering the events to the consumers of the same
+// process. The events published while a group has no consumer are dropped.
+func New(m map[string]interface{}) (events.Stream, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, errors.Wrap(err, "error decoding conf")
+	}
+	c.init()
+
+	mu.Lock()
+	defer mu.Unlock()
+	b, ok := buses[c.Name]
+	if !ok {
+		b = &bus{size: c.BufferSize, groups: map[string]chan *events.Message{}}
+		buses[c.Name] = b
+	}
+	return b, nil
+}
+
+func (b *bus) Publish(ctx context.Context, msg *events.Message) error {
+	b.RLock()
+	defer b.RUnlock()
+	for _, ch := range b.groups {
+		// never block the publishers on slow consumers
+		select {
+		case ch <- msg:
+		default:
+		}
+	}
+	return nil
+}
+
+func (b *bus) Consume(ctx context.Context, group string) (<-chan *events.Message, error) {
+	b.Lock()
+	ch, ok := b.groups[group]
+	if !ok {
+		ch = make(chan *events.Message, b.size)
+		b.groups[group] = ch
+	}
+	b.Unlock()
+
+	// the consumers of a group share its channel, so that each message is
+	// delivered to only one of them
+	out := make(chan *events.Message)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg := <-ch:
+				select {
+				case out <- msg:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
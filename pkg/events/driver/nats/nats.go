@@ -0,0 +1,146 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package nats
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/cs3org/reva/pkg/events"
+	"github.com/cs3org/reva/pkg/events/driver/registry"
+	"github.com/mitchellh/mapstructure"
+	"github.com/nats-io/nats.go"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	registry.Register("nats", New)
+}
+
+type config struct {
+	Address string `mapstructure:"address"`
+	// Stream is the name of the JetStream stream the events are stored in.
+	Stream string `mapstructure:"stream"`
+	// SubjectPrefix prefixes the subjects of the events, which end with
+	// the type of the event.
+	SubjectPrefix string `mapstructure:"subject_prefix"`
+	Username      string `mapstructure:"username"`
+	Password      string `mapstructure:"password"`
+}
+
+func (c *config) init() {
+	if c.Address == "" {
+		c.Address = nats.DefaultURL
+	}
+	if c.Stream == "" {
+		c.Stream = "reva-events"
+	}
+	if c.SubjectPrefix == "" {
+		c.SubjectPrefix = "reva.events"
+	}
+	c.SubjectPrefix = strings.TrimSuffix(c.SubjectPrefix, ".")
+}
+
+type stream struct {
+	c  *config
+	nc *nats.Conn
+	js nats.JetStreamContext
+}
+
+// New returns a bus backed by a NATS JetStream stream, which persists the
+// events until they have been acknowledged by every consumer group.
+func New(m map[string]interface{}) (events.Stream, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, errors.Wrap(err, "error decoding conf")
+	}
+	c.init()
+
+	opts := []nats.Option{nats.Name("reva")}
+	if c.Username != "" {
+		opts = append(opts, nats.UserInfo(c.Username, c.Password))
+	}
+	nc, err := nats.Connect(c.Address, opts...)
+	if err != nil {
+		return nil, errors.Wrap(err, "nats: error connecting to server")
+	}
+	js, err := nc.JetStream()
+	if err != nil {
+		nc.Close()
+		return nil, errors.Wrap(err, "nats: error getting jetstream context")
+	}
+
+	if _, err := js.StreamInfo(c.Stream); err != nil {
+		_, err = js.AddStream(&nats.StreamConfig{
+			Name:     c.Stream,
+			Subjects: []string{c.SubjectPrefix + ".>"},
+		})
+		if err != nil {
+			nc.Close()
+			return nil, errors.Wrap(err, "nats: error creating stream")
+		}
+	}
+
+	return &stream{c: c, nc: nc, js: js}, nil
+}
+
+func (s *stream) Publish(ctx context.Context, msg *events.Message) error {
+	_, err := s.js.Publish(s.c.SubjectPrefix+"."+msg.Type, msg.Data, nats.Context(ctx))
+	return err
+}
+
+func (s *stream) Consume(ctx context.Context, group string) (<-chan *events.Message, error) {
+	out := make(chan *events.Message)
+
+	// closed guards out against the callbacks still running after the
+	// subscription has been drained
+	var mu sync.Mutex
+	closed := false
+
+	sub, err := s.js.QueueSubscribe(s.c.SubjectPrefix+".>", group, func(m *nats.Msg) {
+		mu.Lock()
+		defer mu.Unlock()
+		if closed {
+			return
+		}
+		msg := &events.Message{
+			Type: strings.TrimPrefix(m.Subject, s.c.SubjectPrefix+"."),
+			Data: m.Data,
+		}
+		select {
+		case out <- msg:
+			_ = m.Ack()
+		case <-ctx.Done():
+		}
+	}, nats.Durable(group), nats.ManualAck())
+	if err != nil {
+		return nil, errors.Wrap(err, "nats: error subscribing")
+	}
+
+	go func() {
+		<-ctx.Done()
+		_ = sub.Drain()
+		mu.Lock()
+		closed = true
+		close(out)
+		mu.Unlock()
+	}()
+	return out, nil
+}
@@ -0,0 +1,63 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package registry
+
+import (
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/events"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+// NewFunc is the function that events drivers
+// should register at init time.
+type NewFunc func(map[string]interface{}) (events.Stream, error)
+
+// NewFuncs is a map containing all the registered events drivers.
+var NewFuncs = map[string]NewFunc{}
+
+// Register registers a new events driver new function.
+// Not safe for concurrent use. Safe for use from package init.
+func Register(name string, f NewFunc) {
+	NewFuncs[name] = f
+}
+
+type config struct {
+	Driver  string                            `mapstructure:"driver"`
+	Drivers map[string]map[string]interface{} `mapstructure:"drivers"`
+}
+
+// NewStream returns the stream configured in the events section of the
+// configuration of a service. Without configuration, the events are
+// published to the in-memory bus of the process.
+func NewStream(m map[string]interface{}) (events.Stream, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, errors.Wrap(err, "events: error decoding conf")
+	}
+	if c.Driver == "" {
+		c.Driver = "memory"
+	}
+
+	f, ok := NewFuncs[c.Driver]
+	if !ok {
+		return nil, errtypes.NotFound("events: driver not found: " + c.Driver)
+	}
+	return f(c.Drivers[c.Driver])
+}
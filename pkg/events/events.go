@@ -0,0 +1,115 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package events defines the events emitted by the services and the
+// interfaces of the buses they are published to, so that other services can
+// react to them without being called directly.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// Message is an event as transported by a bus.
+type Message struct {
+	// Type is the name of the type of the event, e.g. ShareCreated.
+	Type string `json:"type"`
+	// Data is the JSON encoding of the event.
+	Data []byte `json:"data"`
+}
+
+// Publisher is the interface to implement to publish events to a bus.
+type Publisher interface {
+	Publish(ctx context.Context, msg *Message) error
+}
+
+// Consumer is the interface to implement to consume events from a bus.
+type Consumer interface {
+	// Consume returns the messages received by the consumer group, until the
+	// context is done. Each message is delivered to a single consumer of each
+	// group.
+	Consume(ctx context.Context, group string) (<-chan *Message, error)
+}
+
+// Stream is a bus events can be published to and consumed from.
+type Stream interface {
+	Publisher
+	Consumer
+}
+
+func typeName(ev interface{}) string {
+	t := reflect.TypeOf(ev)
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
+
+// Publish publishes a typed event, e.g. a ShareCreated.
+func Publish(ctx context.Context, p Publisher, ev interface{}) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return errors.Wrap(err, "events: error encoding event")
+	}
+	return p.Publish(ctx, &Message{Type: typeName(ev), Data: data})
+}
+
+// Consume returns the events of the given types received by the consumer
+// group, decoded into values of the same types as the given ones, e.g.
+// Consume(ctx, c, "notifications", ShareCreated{}) delivers ShareCreated values.
+// The events of other types are skipped.
+func Consume(ctx context.Context, c Consumer, group string, evs ...interface{}) (<-chan interface{}, error) {
+	types := map[string]reflect.Type{}
+	for _, ev := range evs {
+		t := reflect.TypeOf(ev)
+		if t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		types[t.Name()] = t
+	}
+
+	msgs, err := c.Consume(ctx, group)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan interface{})
+	go func() {
+		defer close(out)
+		for msg := range msgs {
+			t, ok := types[msg.Type]
+			if !ok {
+				continue
+			}
+			v := reflect.New(t)
+			if err := json.Unmarshal(msg.Data, v.Interface()); err != nil {
+				continue
+			}
+			select {
+			case out <- v.Elem().Interface():
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
@@ -0,0 +1,166 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package events
+
+import (
+	"time"
+
+	grouppb "github.com/cs3org/go-cs3apis/cs3/identity/group/v1beta1"
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+)
+
+// FileUploaded is emitted when the upload of a file has completed.
+type FileUploaded struct {
+	Executant *userpb.UserId
+	// Owner is the owner of the uploaded file, if it could be resolved.
+	Owner *userpb.UserId
+	// ResourceID is the id of the uploaded file, if it could be resolved.
+	ResourceID *provider.ResourceId
+	// Path is the path of the file in the storage it was uploaded to.
+	Path string
+	// MountPath is the path the storage is mounted at in the global
+	// namespace, if the data provider is configured with it.
+	MountPath string
+	Time      time.Time
+}
+
+// ContainerCreated is emitted by the storage providers when a folder has been
+// created.
+type ContainerCreated struct {
+	Executant *userpb.UserId
+	// Owner is the owner of the folder, whose namespace changed.
+	Owner      *userpb.UserId
+	ResourceID *provider.ResourceId
+	// Path is the path of the folder in the global namespace.
+	Path string
+	Time time.Time
+}
+
+// ItemTrashed is emitted by the storage providers when a resource has been
+// deleted, or moved to the recycle bin.
+type ItemTrashed struct {
+	Executant  *userpb.UserId
+	Owner      *userpb.UserId
+	ResourceID *provider.ResourceId
+	// Path is the path the resource had in the global namespace before
+	// being deleted.
+	Path string
+	Time time.Time
+}
+
+// ItemMoved is emitted by the storage providers when a resource has been
+// moved or renamed.
+type ItemMoved struct {
+	Executant  *userpb.UserId
+	Owner      *userpb.UserId
+	ResourceID *provider.ResourceId
+	// OldPath and Path are the paths of the resource in the global namespace
+	// before and after the move.
+	OldPath string
+	Path    string
+	Time    time.Time
+}
+
+// ShareCreated is emitted when a resource is shared with a user or a group.
+type ShareCreated struct {
+	ShareID        string
+	Sharer         *userpb.UserId
+	GranteeUserID  *userpb.UserId
+	GranteeGroupID *grouppb.GroupId
+	ResourceID     *provider.ResourceId
+	// ResourceName is the name of the shared resource.
+	ResourceName string
+	Time         time.Time
+}
+
+// LinkExpired is emitted when an expired public link is removed.
+type LinkExpired struct {
+	LinkID  string
+	Creator *userpb.UserId
+	// Name is the display name of the link, or its token if it has none.
+	Name string
+	Time time.Time
+}
+
+// UserLoggedIn is emitted when a user successfully authenticates.
+type UserLoggedIn struct {
+	UserID   *userpb.UserId
+	Username string
+	// AuthType is the type of the credentials the user authenticated with.
+	AuthType string
+	Time     time.Time
+}
+
+// UserProvisioned is emitted when a user has been provisioned, at its first
+// login.
+type UserProvisioned struct {
+	UserID   *userpb.UserId
+	Username string
+	Time     time.Time
+}
+
+// CommentCreated is emitted when a user comments on a resource.
+type CommentCreated struct {
+	CommentID  string
+	Author     *userpb.UserId
+	ResourceID *provider.ResourceId
+	// ResourceName is the name of the commented resource.
+	ResourceName string
+	// Mentioned holds the users mentioned in the comment.
+	Mentioned []*userpb.UserId
+	Time      time.Time
+}
+
+// TransferChecksumMismatch is emitted when the checksum of the bytes
+// transferred by the data gateway does not match the declared one.
+type TransferChecksumMismatch struct {
+	// Direction is either upload or download.
+	Direction string
+	// Target is the data provider URL of the transfer.
+	Target    string
+	Algorithm string
+	Expected  string
+	Computed  string
+	Time      time.Time
+}
+
+// UploadProcessed is emitted by the event step of the post-processing of the
+// uploads, once the steps configured before it succeeded.
+type UploadProcessed struct {
+	Executant  *userpb.UserId
+	ResourceID *provider.ResourceId
+	Path       string
+	Time       time.Time
+}
+
+// OCMShareNotified is emitted when another provider notifies a change of an
+// OCM share between one of its users and one of ours, e.g. that the share has
+// been accepted.
+type OCMShareNotified struct {
+	// NotificationType is the OCM type of the notification, e.g.
+	// SHARE_ACCEPTED.
+	NotificationType string
+	ShareID          string
+	// User is our user, RemoteUser the user of the other provider.
+	User         *userpb.UserId
+	RemoteUser   *userpb.UserId
+	ResourceName string
+	Time         time.Time
+}
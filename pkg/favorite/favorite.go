@@ -0,0 +1,50 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package favorite keeps the resources the users marked as favorites. The
+// favorites are specific to each user, so they are not stored with the
+// resources, where they would be visible to everyone with access to them.
+package favorite
+
+import (
+	"context"
+	"fmt"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+)
+
+// Manager is the interface to implement to store the favorites of the users.
+type Manager interface {
+	// ListFavorites returns the ids of the favorite resources of the user.
+	ListFavorites(ctx context.Context, uid *userpb.UserId) ([]*provider.ResourceId, error)
+	// SetFavorite marks the resource as a favorite of the user.
+	SetFavorite(ctx context.Context, uid *userpb.UserId, rid *provider.ResourceId) error
+	// UnsetFavorite removes the resource from the favorites of the user.
+	UnsetFavorite(ctx context.Context, uid *userpb.UserId, rid *provider.ResourceId) error
+}
+
+// UserKey returns the key identifying the user in the stores.
+func UserKey(uid *userpb.UserId) string {
+	return uid.GetIdp() + "!" + uid.GetOpaqueId()
+}
+
+// ResourceKey returns the key identifying the resource in the stores.
+func ResourceKey(rid *provider.ResourceId) string {
+	return fmt.Sprintf("%s:%s", rid.GetStorageId(), rid.GetOpaqueId())
+}
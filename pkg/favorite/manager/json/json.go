@@ -0,0 +1,137 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package json
+
+import (
+	"context"
+	"sync"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/favorite"
+	"github.com/cs3org/reva/pkg/favorite/manager/registry"
+	"github.com/cs3org/reva/pkg/utils/jsonfile"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	registry.Register("json", New)
+}
+
+type config struct {
+	File string `mapstructure:"file"`
+}
+
+func (c *config) init() {
+	if c.File == "" {
+		c.File = "/var/tmp/reva/favorites.json"
+	}
+}
+
+// db holds the favorite resources of the users, by user key and resource key.
+type db map[string]map[string]*provider.ResourceId
+
+type manager struct {
+	sync.Mutex
+	c    *config
+	file *jsonfile.File
+	db   db
+}
+
+// New returns a favorite manager storing the favorites in a JSON file. The
+// file is read again when it is modified, so that it can be shared by
+// several services.
+func New(m map[string]interface{}) (favorite.Manager, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, errors.Wrap(err, "error decoding conf")
+	}
+	c.init()
+
+	mgr := &manager{c: c, file: jsonfile.New(c.File), db: db{}}
+	if err := mgr.reload(); err != nil {
+		return nil, err
+	}
+	return mgr, nil
+}
+
+func (m *manager) reload() error {
+	d := db{}
+	changed, err := m.file.Reload(&d)
+	if err != nil {
+		return errors.Wrap(err, "favorite: error reading favorites")
+	}
+	if changed {
+		m.db = d
+	}
+	return nil
+}
+
+func (m *manager) persist() error {
+	if err := m.file.Persist(m.db); err != nil {
+		return errors.Wrap(err, "favorite: error writing favorites")
+	}
+	return nil
+}
+
+func (m *manager) ListFavorites(ctx context.Context, uid *userpb.UserId) ([]*provider.ResourceId, error) {
+	m.Lock()
+	defer m.Unlock()
+	if err := m.reload(); err != nil {
+		return nil, err
+	}
+	favs := m.db[favorite.UserKey(uid)]
+	list := make([]*provider.ResourceId, 0, len(favs))
+	for _, rid := range favs {
+		list = append(list, rid)
+	}
+	return list, nil
+}
+
+func (m *manager) SetFavorite(ctx context.Context, uid *userpb.UserId, rid *provider.ResourceId) error {
+	m.Lock()
+	defer m.Unlock()
+	if err := m.reload(); err != nil {
+		return err
+	}
+	key := favorite.UserKey(uid)
+	if m.db[key] == nil {
+		m.db[key] = map[string]*provider.ResourceId{}
+	}
+	m.db[key][favorite.ResourceKey(rid)] = rid
+	return m.persist()
+}
+
+func (m *manager) UnsetFavorite(ctx context.Context, uid *userpb.UserId, rid *provider.ResourceId) error {
+	m.Lock()
+	defer m.Unlock()
+	if err := m.reload(); err != nil {
+		return err
+	}
+	key := favorite.UserKey(uid)
+	if _, ok := m.db[key][favorite.ResourceKey(rid)]; !ok {
+		return nil
+	}
+	delete(m.db[key], favorite.ResourceKey(rid))
+	if len(m.db[key]) == 0 {
+		delete(m.db, key)
+	}
+	return m.persist()
+}
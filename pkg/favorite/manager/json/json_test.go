@@ -0,0 +1,74 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package json
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+)
+
+func TestSharedFile(t *testing.T) {
+	ctx := context.Background()
+	dir, err := ioutil.TempDir("", "favorites")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	conf := map[string]interface{}{"file": filepath.Join(dir, "favorites.json")}
+	m1, err := New(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m2, err := New(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	einstein := &userpb.UserId{Idp: "idp", OpaqueId: "einstein"}
+	marie := &userpb.UserId{Idp: "idp", OpaqueId: "marie"}
+	rid := &provider.ResourceId{StorageId: "storage", OpaqueId: "file"}
+	if err := m1.SetFavorite(ctx, einstein, rid); err != nil {
+		t.Fatal(err)
+	}
+
+	favs, err := m2.ListFavorites(ctx, einstein)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(favs) != 1 || favs[0].OpaqueId != "file" {
+		t.Fatalf("unexpected favorites %+v", favs)
+	}
+	if favs, _ := m2.ListFavorites(ctx, marie); len(favs) != 0 {
+		t.Fatalf("expected no favorites for marie, got %+v", favs)
+	}
+
+	if err := m2.UnsetFavorite(ctx, einstein, rid); err != nil {
+		t.Fatal(err)
+	}
+	if favs, _ := m1.ListFavorites(ctx, einstein); len(favs) != 0 {
+		t.Fatalf("expected no favorites after unset, got %+v", favs)
+	}
+}
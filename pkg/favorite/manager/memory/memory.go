@@ -0,0 +1,73 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package memory
+
+import (
+	"context"
+	"sync"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/favorite"
+	"github.com/cs3org/reva/pkg/favorite/manager/registry"
+)
+
+func init() {
+	registry.Register("memory", New)
+}
+
+type manager struct {
+	sync.RWMutex
+	// favorites holds the favorite resources of the users, by resource key.
+	favorites map[string]map[string]*provider.ResourceId
+}
+
+// New returns a favorite manager keeping the favorites in memory.
+func New(m map[string]interface{}) (favorite.Manager, error) {
+	return &manager{favorites: map[string]map[string]*provider.ResourceId{}}, nil
+}
+
+func (m *manager) ListFavorites(ctx context.Context, uid *userpb.UserId) ([]*provider.ResourceId, error) {
+	m.RLock()
+	defer m.RUnlock()
+	favs := m.favorites[favorite.UserKey(uid)]
+	list := make([]*provider.ResourceId, 0, len(favs))
+	for _, rid := range favs {
+		list = append(list, rid)
+	}
+	return list, nil
+}
+
+func (m *manager) SetFavorite(ctx context.Context, uid *userpb.UserId, rid *provider.ResourceId) error {
+	m.Lock()
+	defer m.Unlock()
+	key := favorite.UserKey(uid)
+	if m.favorites[key] == nil {
+		m.favorites[key] = map[string]*provider.ResourceId{}
+	}
+	m.favorites[key][favorite.ResourceKey(rid)] = rid
+	return nil
+}
+
+func (m *manager) UnsetFavorite(ctx context.Context, uid *userpb.UserId, rid *provider.ResourceId) error {
+	m.Lock()
+	defer m.Unlock()
+	delete(m.favorites[favorite.UserKey(uid)], favorite.ResourceKey(rid))
+	return nil
+}
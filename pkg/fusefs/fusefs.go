@@ -0,0 +1,449 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// +build linux darwin
+
+// Package fusefs exposes a folder of the CS3 namespace as a FUSE filesystem.
+package fusefs
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	"bazil.org/fuse"
+	"bazil.org/fuse/fs"
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+)
+
+// ContextFunc returns the context the requests to the gateway are made with,
+// carrying a valid token of the user the filesystem is mounted for.
+type ContextFunc func() (context.Context, error)
+
+// Transferer moves the content of the files between the storage and local
+// files.
+type Transferer interface {
+	Download(ctx context.Context, info *provider.ResourceInfo, local string) error
+	Upload(ctx context.Context, local, remote string) error
+}
+
+type cacheEntry struct {
+	info    *provider.ResourceInfo
+	expires time.Time
+}
+
+// FS exposes a remote folder as a FUSE filesystem. The metadata of the
+// resources is cached for a short time, by the filesystem and by the kernel,
+// and the files are downloaded to a local temporary file when opened and
+// uploaded back when modified.
+type FS struct {
+	gwc      gateway.GatewayAPIClient
+	root     string
+	ttl      time.Duration
+	ctx      ContextFunc
+	transfer Transferer
+	uid      uint32
+	gid      uint32
+	mu       sync.Mutex
+	cache    map[string]cacheEntry
+	// paths maps the ids of the cached resources to their paths.
+	paths map[string]string
+}
+
+// New returns a filesystem exposing the remote folder root, owned by the user
+// and group of the process.
+func New(gwc gateway.GatewayAPIClient, root string, ctx ContextFunc, transfer Transferer, ttl time.Duration) *FS {
+	return &FS{
+		gwc:      gwc,
+		root:     root,
+		ttl:      ttl,
+		ctx:      ctx,
+		transfer: transfer,
+		uid:      uint32(os.Getuid()),
+		gid:      uint32(os.Getgid()),
+		cache:    map[string]cacheEntry{},
+		paths:    map[string]string{},
+	}
+}
+
+// SetOwner sets the user and group owning the files of the filesystem.
+func (f *FS) SetOwner(uid, gid uint32) {
+	f.uid, f.gid = uid, gid
+}
+
+// Root implements fs.FS.
+func (f *FS) Root() (fs.Node, error) {
+	return &dirNode{fs: f, path: f.root}, nil
+}
+
+// Invalidate drops the cached metadata of the given remote paths.
+func (f *FS) Invalidate(paths ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, p := range paths {
+		if e, ok := f.cache[p]; ok {
+			delete(f.paths, resourceKey(e.info.GetId()))
+		}
+		delete(f.cache, p)
+	}
+}
+
+// InvalidateResource drops the cached metadata of the resource and of its
+// parent folder, if the resource is cached. It returns whether it was.
+func (f *FS) InvalidateResource(id *provider.ResourceId) bool {
+	f.mu.Lock()
+	p, ok := f.paths[resourceKey(id)]
+	f.mu.Unlock()
+	if !ok {
+		return false
+	}
+	f.Invalidate(p, path.Dir(p))
+	return true
+}
+
+func resourceKey(id *provider.ResourceId) string {
+	return id.GetStorageId() + "!" + id.GetOpaqueId()
+}
+
+func ref(p string) *provider.Reference {
+	return &provider.Reference{
+		Spec: &provider.Reference_Path{Path: p},
+	}
+}
+
+func (f *FS) stat(p string) (*provider.ResourceInfo, error) {
+	f.mu.Lock()
+	e, ok := f.cache[p]
+	f.mu.Unlock()
+	if ok && time.Now().Before(e.expires) {
+		return e.info, nil
+	}
+
+	ctx, err := f.ctx()
+	if err != nil {
+		return nil, err
+	}
+	res, err := f.gwc.Stat(ctx, &provider.StatRequest{Ref: ref(p)})
+	if err != nil {
+		return nil, err
+	}
+	if err := fuseError(res.Status); err != nil {
+		return nil, err
+	}
+	f.store(p, res.Info)
+	return res.Info, nil
+}
+
+func (f *FS) store(p string, info *provider.ResourceInfo) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.cache[p] = cacheEntry{info: info, expires: time.Now().Add(f.ttl)}
+	if info.Id != nil {
+		f.paths[resourceKey(info.Id)] = p
+	}
+}
+
+func (f *FS) attr(p string, a *fuse.Attr) error {
+	info, err := f.stat(p)
+	if err != nil {
+		return err
+	}
+	a.Valid = f.ttl
+	a.Size = info.Size
+	a.Mode = 0644
+	if info.Type == provider.ResourceType_RESOURCE_TYPE_CONTAINER {
+		a.Mode = os.ModeDir | 0755
+	}
+	if info.Mtime != nil {
+		a.Mtime = time.Unix(int64(info.Mtime.Seconds), int64(info.Mtime.Nanos))
+		a.Ctime = a.Mtime
+	}
+	a.Uid, a.Gid = f.uid, f.gid
+	return nil
+}
+
+// fuseError maps the status returned by the gateway to an errno.
+func fuseError(s *rpc.Status) error {
+	switch s.Code {
+	case rpc.Code_CODE_OK:
+		return nil
+	case rpc.Code_CODE_NOT_FOUND:
+		return fuse.ENOENT
+	case rpc.Code_CODE_PERMISSION_DENIED, rpc.Code_CODE_UNAUTHENTICATED:
+		return fuse.EPERM
+	case rpc.Code_CODE_ALREADY_EXISTS:
+		return fuse.EEXIST
+	default:
+		return fuse.EIO
+	}
+}
+
+type dirNode struct {
+	fs   *FS
+	path string
+}
+
+func (d *dirNode) Attr(ctx context.Context, a *fuse.Attr) error {
+	return d.fs.attr(d.path, a)
+}
+
+func (d *dirNode) node(info *provider.ResourceInfo, p string) fs.Node {
+	if info.Type == provider.ResourceType_RESOURCE_TYPE_CONTAINER {
+		return &dirNode{fs: d.fs, path: p}
+	}
+	return &fileNode{fs: d.fs, path: p}
+}
+
+func (d *dirNode) Lookup(ctx context.Context, req *fuse.LookupRequest, resp *fuse.LookupResponse) (fs.Node, error) {
+	p := path.Join(d.path, req.Name)
+	info, err := d.fs.stat(p)
+	if err != nil {
+		return nil, err
+	}
+	// the kernel keeps the entries no longer than the filesystem does.
+	resp.EntryValid = d.fs.ttl
+	return d.node(info, p), nil
+}
+
+func (d *dirNode) ReadDirAll(ctx context.Context) ([]fuse.Dirent, error) {
+	rctx, err := d.fs.ctx()
+	if err != nil {
+		return nil, err
+	}
+	res, err := d.fs.gwc.ListContainer(rctx, &provider.ListContainerRequest{Ref: ref(d.path)})
+	if err != nil {
+		return nil, err
+	}
+	if err := fuseError(res.Status); err != nil {
+		return nil, err
+	}
+
+	dirents := make([]fuse.Dirent, 0, len(res.Infos))
+	for _, info := range res.Infos {
+		name := path.Base(info.Path)
+		d.fs.store(path.Join(d.path, name), info)
+		t := fuse.DT_File
+		if info.Type == provider.ResourceType_RESOURCE_TYPE_CONTAINER {
+			t = fuse.DT_Dir
+		}
+		dirents = append(dirents, fuse.Dirent{Name: name, Type: t})
+	}
+	return dirents, nil
+}
+
+func (d *dirNode) Mkdir(ctx context.Context, req *fuse.MkdirRequest) (fs.Node, error) {
+	rctx, err := d.fs.ctx()
+	if err != nil {
+		return nil, err
+	}
+	p := path.Join(d.path, req.Name)
+	res, err := d.fs.gwc.CreateContainer(rctx, &provider.CreateContainerRequest{Ref: ref(p)})
+	if err != nil {
+		return nil, err
+	}
+	if err := fuseError(res.Status); err != nil {
+		return nil, err
+	}
+	d.fs.Invalidate(d.path, p)
+	return &dirNode{fs: d.fs, path: p}, nil
+}
+
+func (d *dirNode) Create(ctx context.Context, req *fuse.CreateRequest, resp *fuse.CreateResponse) (fs.Node, fs.Handle, error) {
+	p := path.Join(d.path, req.Name)
+	tmp, err := ioutil.TempFile("", "reva-mount-")
+	if err != nil {
+		return nil, nil, err
+	}
+	resp.Attr.Mode = 0644
+	resp.Attr.Uid, resp.Attr.Gid = d.fs.uid, d.fs.gid
+	n := &fileNode{fs: d.fs, path: p}
+	// the file is created remotely when the handle is flushed.
+	return n, &fileHandle{node: n, tmp: tmp, dirty: true}, nil
+}
+
+func (d *dirNode) Remove(ctx context.Context, req *fuse.RemoveRequest) error {
+	rctx, err := d.fs.ctx()
+	if err != nil {
+		return err
+	}
+	p := path.Join(d.path, req.Name)
+	res, err := d.fs.gwc.Delete(rctx, &provider.DeleteRequest{Ref: ref(p)})
+	if err != nil {
+		return err
+	}
+	d.fs.Invalidate(d.path, p)
+	return fuseError(res.Status)
+}
+
+func (d *dirNode) Rename(ctx context.Context, req *fuse.RenameRequest, newDir fs.Node) error {
+	target, ok := newDir.(*dirNode)
+	if !ok {
+		return fuse.EIO
+	}
+	rctx, err := d.fs.ctx()
+	if err != nil {
+		return err
+	}
+	src, dst := path.Join(d.path, req.OldName), path.Join(target.path, req.NewName)
+	res, err := d.fs.gwc.Move(rctx, &provider.MoveRequest{Source: ref(src), Destination: ref(dst)})
+	if err != nil {
+		return err
+	}
+	d.fs.Invalidate(d.path, target.path, src, dst)
+	return fuseError(res.Status)
+}
+
+type fileNode struct {
+	fs   *FS
+	path string
+}
+
+func (n *fileNode) Attr(ctx context.Context, a *fuse.Attr) error {
+	return n.fs.attr(n.path, a)
+}
+
+func (n *fileNode) Open(ctx context.Context, req *fuse.OpenRequest, resp *fuse.OpenResponse) (fs.Handle, error) {
+	tmp, err := ioutil.TempFile("", "reva-mount-")
+	if err != nil {
+		return nil, err
+	}
+	h := &fileHandle{node: n, tmp: tmp}
+
+	if req.Flags&fuse.OpenTruncate != 0 {
+		h.dirty = true
+		return h, nil
+	}
+	if err := h.fetch(); err != nil {
+		h.close()
+		return nil, err
+	}
+	return h, nil
+}
+
+func (n *fileNode) Setattr(ctx context.Context, req *fuse.SetattrRequest, resp *fuse.SetattrResponse) error {
+	// only truncation is supported, the other attributes are ignored.
+	if req.Valid.Size() {
+		tmp, err := ioutil.TempFile("", "reva-mount-")
+		if err != nil {
+			return err
+		}
+		h := &fileHandle{node: n, tmp: tmp, dirty: true}
+		defer h.close()
+		if req.Size > 0 {
+			if err := h.fetch(); err != nil {
+				return err
+			}
+		}
+		if err := h.tmp.Truncate(int64(req.Size)); err != nil {
+			return err
+		}
+		if err := h.flush(); err != nil {
+			return err
+		}
+	}
+	return n.Attr(ctx, &resp.Attr)
+}
+
+// fileHandle is an open file, backed by a local temporary copy.
+type fileHandle struct {
+	node  *fileNode
+	mu    sync.Mutex
+	tmp   *os.File
+	dirty bool
+}
+
+func (h *fileHandle) fetch() error {
+	info, err := h.node.fs.stat(h.node.path)
+	if err != nil {
+		return err
+	}
+	ctx, err := h.node.fs.ctx()
+	if err != nil {
+		return err
+	}
+	return h.node.fs.transfer.Download(ctx, info, h.tmp.Name())
+}
+
+func (h *fileHandle) Read(ctx context.Context, req *fuse.ReadRequest, resp *fuse.ReadResponse) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	buf := make([]byte, req.Size)
+	n, err := h.tmp.ReadAt(buf, req.Offset)
+	if err != nil && err != io.EOF {
+		return err
+	}
+	resp.Data = buf[:n]
+	return nil
+}
+
+func (h *fileHandle) Write(ctx context.Context, req *fuse.WriteRequest, resp *fuse.WriteResponse) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	n, err := h.tmp.WriteAt(req.Data, req.Offset)
+	if err != nil {
+		return err
+	}
+	resp.Size = n
+	h.dirty = true
+	return nil
+}
+
+func (h *fileHandle) Flush(ctx context.Context, req *fuse.FlushRequest) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.flush()
+}
+
+// flush uploads the local copy when it was modified.
+func (h *fileHandle) flush() error {
+	if !h.dirty {
+		return nil
+	}
+	if err := h.tmp.Sync(); err != nil {
+		return err
+	}
+	ctx, err := h.node.fs.ctx()
+	if err != nil {
+		return err
+	}
+	if err := h.node.fs.transfer.Upload(ctx, h.tmp.Name(), h.node.path); err != nil {
+		return err
+	}
+	h.node.fs.Invalidate(h.node.path, path.Dir(h.node.path))
+	h.dirty = false
+	return nil
+}
+
+func (h *fileHandle) Release(ctx context.Context, req *fuse.ReleaseRequest) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	err := h.flush()
+	h.close()
+	return err
+}
+
+func (h *fileHandle) close() {
+	h.tmp.Close()
+	os.Remove(h.tmp.Name())
+}
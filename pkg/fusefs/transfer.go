@@ -0,0 +1,141 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package fusefs
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	typespb "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	"github.com/cs3org/reva/internal/http/services/datagateway"
+	"github.com/cs3org/reva/pkg/rhttp"
+	"github.com/pkg/errors"
+)
+
+// SimpleTransferer transfers the files through the data gateway with the
+// simple protocol.
+type SimpleTransferer struct {
+	gwc    gateway.GatewayAPIClient
+	client *http.Client
+}
+
+// NewSimpleTransferer returns a Transferer using the simple protocol.
+func NewSimpleTransferer(gwc gateway.GatewayAPIClient, client *http.Client) *SimpleTransferer {
+	return &SimpleTransferer{gwc: gwc, client: client}
+}
+
+// Download downloads the remote file to the local path.
+func (t *SimpleTransferer) Download(ctx context.Context, info *provider.ResourceInfo, local string) error {
+	res, err := t.gwc.InitiateFileDownload(ctx, &provider.InitiateFileDownloadRequest{
+		Ref: &provider.Reference{Spec: &provider.Reference_Path{Path: info.Path}},
+	})
+	if err != nil {
+		return err
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return errors.New("fusefs: error initiating download: " + res.Status.Message)
+	}
+	var ep, tkn string
+	for _, p := range res.Protocols {
+		if p.Protocol == "simple" {
+			ep, tkn = p.DownloadEndpoint, p.Token
+		}
+	}
+
+	req, err := rhttp.NewRequest(ctx, http.MethodGet, ep, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(datagateway.TokenTransportHeader, tkn)
+	httpRes, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer httpRes.Body.Close()
+	if httpRes.StatusCode != http.StatusOK {
+		return errors.New("fusefs: error downloading file: " + httpRes.Status)
+	}
+
+	f, err := os.OpenFile(local, os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, httpRes.Body); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Upload uploads the local file to the remote path.
+func (t *SimpleTransferer) Upload(ctx context.Context, local, remote string) error {
+	f, err := os.Open(local)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	res, err := t.gwc.InitiateFileUpload(ctx, &provider.InitiateFileUploadRequest{
+		Ref: &provider.Reference{Spec: &provider.Reference_Path{Path: remote}},
+		Opaque: &typespb.Opaque{Map: map[string]*typespb.OpaqueEntry{
+			"Upload-Length": {
+				Decoder: "plain",
+				Value:   []byte(strconv.FormatInt(fi.Size(), 10)),
+			},
+		}},
+	})
+	if err != nil {
+		return err
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return errors.New("fusefs: error initiating upload: " + res.Status.Message)
+	}
+	var ep, tkn string
+	for _, p := range res.Protocols {
+		if p.Protocol == "simple" {
+			ep, tkn = p.UploadEndpoint, p.Token
+		}
+	}
+
+	req, err := rhttp.NewRequest(ctx, http.MethodPut, ep, f)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(datagateway.TokenTransportHeader, tkn)
+	req.ContentLength = fi.Size()
+	httpRes, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer httpRes.Body.Close()
+	if httpRes.StatusCode != http.StatusOK {
+		return errors.New("fusefs: error uploading file: " + httpRes.Status)
+	}
+	return nil
+}
@@ -33,3 +33,14 @@ type Manager interface {
 	GetMembers(ctx context.Context, gid *grouppb.GroupId) ([]*userpb.UserId, error)
 	HasMember(ctx context.Context, gid *grouppb.GroupId, uid *userpb.UserId) (bool, error)
 }
+
+// ProvisioningManager is implemented by the group managers whose backend can be
+// written to, allowing groups to be provisioned from an identity management system.
+type ProvisioningManager interface {
+	Manager
+	CreateGroup(ctx context.Context, g *grouppb.Group) (*grouppb.Group, error)
+	UpdateGroup(ctx context.Context, g *grouppb.Group) (*grouppb.Group, error)
+	DeleteGroup(ctx context.Context, gid *grouppb.GroupId) error
+	AddMembers(ctx context.Context, gid *grouppb.GroupId, members []*userpb.UserId) error
+	RemoveMembers(ctx context.Context, gid *grouppb.GroupId, members []*userpb.UserId) error
+}
@@ -20,16 +20,17 @@ package json
 
 import (
 	"context"
-	"encoding/json"
-	"io/ioutil"
+	"os"
 	"strconv"
 	"strings"
+	"sync"
 
 	grouppb "github.com/cs3org/go-cs3apis/cs3/identity/group/v1beta1"
 	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
 	"github.com/cs3org/reva/pkg/errtypes"
 	"github.com/cs3org/reva/pkg/group"
 	"github.com/cs3org/reva/pkg/group/manager/registry"
+	"github.com/cs3org/reva/pkg/utils/jsonfile"
 	"github.com/mitchellh/mapstructure"
 	"github.com/pkg/errors"
 )
@@ -39,6 +40,8 @@ func init() {
 }
 
 type manager struct {
+	sync.Mutex
+	file   *jsonfile.File
 	groups []*grouppb.Group
 }
 
@@ -70,33 +73,53 @@ func New(m map[string]interface{}) (group.Manager, error) {
 		return nil, err
 	}
 
-	f, err := ioutil.ReadFile(c.Groups)
-	if err != nil {
+	if _, err := os.Stat(c.Groups); err != nil {
+		return nil, err
+	}
+	mgr := &manager{file: jsonfile.New(c.Groups)}
+	if err := mgr.reload(); err != nil {
 		return nil, err
 	}
+	return mgr, nil
+}
 
+func (m *manager) reload() error {
 	groups := []*grouppb.Group{}
+	changed, err := m.file.Reload(&groups)
+	if err != nil || !changed {
+		return err
+	}
+	m.groups = groups
+	return nil
+}
 
-	err = json.Unmarshal(f, &groups)
-	if err != nil {
+func (m *manager) GetGroup(ctx context.Context, gid *grouppb.GroupId) (*grouppb.Group, error) {
+	m.Lock()
+	defer m.Unlock()
+	if err := m.reload(); err != nil {
 		return nil, err
 	}
-
-	return &manager{
-		groups: groups,
-	}, nil
+	if g := m.findGroup(gid); g != nil {
+		return g, nil
+	}
+	return nil, errtypes.NotFound(gid.OpaqueId)
 }
 
-func (m *manager) GetGroup(ctx context.Context, gid *grouppb.GroupId) (*grouppb.Group, error) {
+func (m *manager) findGroup(gid *grouppb.GroupId) *grouppb.Group {
 	for _, g := range m.groups {
 		if g.Id.GetOpaqueId() == gid.OpaqueId || g.GroupName == gid.OpaqueId {
-			return g, nil
+			return g
 		}
 	}
-	return nil, errtypes.NotFound(gid.OpaqueId)
+	return nil
 }
 
 func (m *manager) GetGroupByClaim(ctx context.Context, claim, value string) (*grouppb.Group, error) {
+	m.Lock()
+	defer m.Unlock()
+	if err := m.reload(); err != nil {
+		return nil, err
+	}
 	for _, g := range m.groups {
 		if groupClaim, err := extractClaim(g, claim); err == nil && value == groupClaim {
 			return g, nil
@@ -120,6 +143,11 @@ func extractClaim(g *grouppb.Group, claim string) (string, error) {
 }
 
 func (m *manager) FindGroups(ctx context.Context, query string) ([]*grouppb.Group, error) {
+	m.Lock()
+	defer m.Unlock()
+	if err := m.reload(); err != nil {
+		return nil, err
+	}
 	groups := []*grouppb.Group{}
 	for _, g := range m.groups {
 		if groupContains(g, query) {
@@ -136,10 +164,13 @@ func groupContains(g *grouppb.Group, query string) bool {
 }
 
 func (m *manager) GetMembers(ctx context.Context, gid *grouppb.GroupId) ([]*userpb.UserId, error) {
-	for _, g := range m.groups {
-		if g.Id.GetOpaqueId() == gid.OpaqueId || g.GroupName == gid.OpaqueId {
-			return g.Members, nil
-		}
+	m.Lock()
+	defer m.Unlock()
+	if err := m.reload(); err != nil {
+		return nil, err
+	}
+	if g := m.findGroup(gid); g != nil {
+		return g.Members, nil
 	}
 	return nil, errtypes.NotFound(gid.OpaqueId)
 }
@@ -157,3 +188,119 @@ func (m *manager) HasMember(ctx context.Context, gid *grouppb.GroupId, uid *user
 	}
 	return false, nil
 }
+
+func (m *manager) CreateGroup(ctx context.Context, g *grouppb.Group) (*grouppb.Group, error) {
+	m.Lock()
+	defer m.Unlock()
+	if err := m.reload(); err != nil {
+		return nil, err
+	}
+	for _, o := range m.groups {
+		if o.GroupName == g.GroupName || o.Id.GetOpaqueId() == g.Id.GetOpaqueId() {
+			return nil, errtypes.AlreadyExists(g.GroupName)
+		}
+	}
+	m.groups = append(m.groups, g)
+	if err := m.persist(); err != nil {
+		m.groups = m.groups[:len(m.groups)-1]
+		return nil, err
+	}
+	return g, nil
+}
+
+func (m *manager) UpdateGroup(ctx context.Context, g *grouppb.Group) (*grouppb.Group, error) {
+	m.Lock()
+	defer m.Unlock()
+	if err := m.reload(); err != nil {
+		return nil, err
+	}
+	for i, o := range m.groups {
+		if o.Id.GetOpaqueId() == g.Id.GetOpaqueId() {
+			m.groups[i] = g
+			if err := m.persist(); err != nil {
+				m.groups[i] = o
+				return nil, err
+			}
+			return g, nil
+		}
+	}
+	return nil, errtypes.NotFound(g.Id.GetOpaqueId())
+}
+
+func (m *manager) DeleteGroup(ctx context.Context, gid *grouppb.GroupId) error {
+	m.Lock()
+	defer m.Unlock()
+	if err := m.reload(); err != nil {
+		return err
+	}
+	for i, g := range m.groups {
+		if g.Id.GetOpaqueId() == gid.OpaqueId {
+			old := m.groups
+			m.groups = append(append([]*grouppb.Group{}, m.groups[:i]...), m.groups[i+1:]...)
+			if err := m.persist(); err != nil {
+				m.groups = old
+				return err
+			}
+			return nil
+		}
+	}
+	return errtypes.NotFound(gid.OpaqueId)
+}
+
+func (m *manager) AddMembers(ctx context.Context, gid *grouppb.GroupId, members []*userpb.UserId) error {
+	return m.updateMembers(gid, func(current []*userpb.UserId) []*userpb.UserId {
+		for _, u := range members {
+			if !containsMember(current, u) {
+				current = append(current, u)
+			}
+		}
+		return current
+	})
+}
+
+func (m *manager) RemoveMembers(ctx context.Context, gid *grouppb.GroupId, members []*userpb.UserId) error {
+	return m.updateMembers(gid, func(current []*userpb.UserId) []*userpb.UserId {
+		kept := []*userpb.UserId{}
+		for _, u := range current {
+			if !containsMember(members, u) {
+				kept = append(kept, u)
+			}
+		}
+		return kept
+	})
+}
+
+func (m *manager) updateMembers(gid *grouppb.GroupId, f func([]*userpb.UserId) []*userpb.UserId) error {
+	m.Lock()
+	defer m.Unlock()
+	if err := m.reload(); err != nil {
+		return err
+	}
+	g := m.findGroup(gid)
+	if g == nil {
+		return errtypes.NotFound(gid.OpaqueId)
+	}
+	old := g.Members
+	g.Members = f(append([]*userpb.UserId{}, old...))
+	if err := m.persist(); err != nil {
+		g.Members = old
+		return err
+	}
+	return nil
+}
+
+func containsMember(members []*userpb.UserId, uid *userpb.UserId) bool {
+	for _, u := range members {
+		if u.OpaqueId == uid.OpaqueId && u.Idp == uid.Idp {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *manager) persist() error {
+	if err := m.file.Persist(m.groups); err != nil {
+		return errors.Wrap(err, "json: error writing groups file")
+	}
+	return nil
+}
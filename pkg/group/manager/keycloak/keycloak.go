@@ -0,0 +1,211 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package keycloak
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/ReneKroon/ttlcache/v2"
+	grouppb "github.com/cs3org/go-cs3apis/cs3/identity/group/v1beta1"
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/group"
+	"github.com/cs3org/reva/pkg/group/manager/registry"
+	"github.com/cs3org/reva/pkg/keycloak"
+	"github.com/cs3org/reva/pkg/user"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	registry.Register("keycloak", New)
+}
+
+type config struct {
+	keycloak.Config `mapstructure:",squash"`
+	// Idp is the identity provider of the groups and of their members,
+	// usually the issuer of the realm.
+	Idp string `mapstructure:"idp"`
+	// CacheTTL is the time in seconds for which the groups and their members are cached.
+	CacheTTL int `mapstructure:"cache_ttl"`
+}
+
+func (c *config) init() {
+	c.Config.Init()
+	if c.Idp == "" {
+		c.Idp = c.BaseURL + "/realms/" + c.Realm
+	}
+	if c.CacheTTL == 0 {
+		c.CacheTTL = 300
+	}
+}
+
+func parseConfig(m map[string]interface{}) (*config, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		err = errors.Wrap(err, "error decoding conf")
+		return nil, err
+	}
+	c.init()
+	return c, nil
+}
+
+type manager struct {
+	c      *config
+	client *keycloak.Client
+	cache  *ttlcache.Cache
+}
+
+// New returns a group manager querying the admin REST API of a Keycloak realm.
+// The groups are identified by their name, as in the groups of the users.
+func New(m map[string]interface{}) (group.Manager, error) {
+	c, err := parseConfig(m)
+	if err != nil {
+		return nil, err
+	}
+
+	cache := ttlcache.NewCache()
+	_ = cache.SetTTL(time.Duration(c.CacheTTL) * time.Second)
+	cache.SkipTTLExtensionOnHit(true)
+
+	return &manager{
+		c:      c,
+		client: keycloak.New(&c.Config),
+		cache:  cache,
+	}, nil
+}
+
+func (m *manager) toGroup(g *keycloak.Group) *grouppb.Group {
+	gid, _ := strconv.ParseInt(keycloak.Attribute(g.Attributes, m.c.GIDNumberAttribute), 10, 64)
+	cg := &grouppb.Group{
+		Id: &grouppb.GroupId{
+			Idp:      m.c.Idp,
+			OpaqueId: g.Name,
+		},
+		GroupName:   g.Name,
+		DisplayName: g.Name,
+		GidNumber:   gid,
+	}
+	if q := keycloak.Attribute(g.Attributes, m.c.QuotaAttribute); m.c.QuotaAttribute != "" && q != "" {
+		cg.Opaque = &types.Opaque{Map: map[string]*types.OpaqueEntry{user.QuotaOpaqueKey: user.QuotaEntry(q)}}
+	}
+	return cg
+}
+
+// getKeycloakGroup looks up a group by its name.
+func (m *manager) getKeycloakGroup(ctx context.Context, name string) (*keycloak.Group, error) {
+	if v, err := m.cache.Get("group:" + name); err == nil {
+		return v.(*keycloak.Group), nil
+	}
+
+	groups, err := m.client.FindGroups(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	for _, g := range groups {
+		if g.Name == name {
+			_ = m.cache.Set("group:"+name, g)
+			return g, nil
+		}
+	}
+	return nil, errtypes.NotFound(name)
+}
+
+func (m *manager) GetGroup(ctx context.Context, gid *grouppb.GroupId) (*grouppb.Group, error) {
+	kg, err := m.getKeycloakGroup(ctx, gid.OpaqueId)
+	if err != nil {
+		return nil, err
+	}
+	g := m.toGroup(kg)
+	members, err := m.GetMembers(ctx, gid)
+	if err != nil {
+		return nil, err
+	}
+	g.Members = members
+	return g, nil
+}
+
+func (m *manager) GetGroupByClaim(ctx context.Context, claim, value string) (*grouppb.Group, error) {
+	switch claim {
+	case "group_name", "display_name", "group_id":
+		return m.GetGroup(ctx, &grouppb.GroupId{OpaqueId: value})
+	case "gid_number":
+		groups, err := m.client.FindGroups(ctx, "")
+		if err != nil {
+			return nil, err
+		}
+		for _, kg := range groups {
+			if keycloak.Attribute(kg.Attributes, m.c.GIDNumberAttribute) == value {
+				return m.GetGroup(ctx, &grouppb.GroupId{OpaqueId: kg.Name})
+			}
+		}
+		return nil, errtypes.NotFound(value)
+	}
+	return nil, errors.New("keycloak: invalid field " + claim)
+}
+
+func (m *manager) FindGroups(ctx context.Context, query string) ([]*grouppb.Group, error) {
+	found, err := m.client.FindGroups(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	groups := make([]*grouppb.Group, 0, len(found))
+	for _, g := range found {
+		groups = append(groups, m.toGroup(g))
+	}
+	return groups, nil
+}
+
+func (m *manager) GetMembers(ctx context.Context, gid *grouppb.GroupId) ([]*userpb.UserId, error) {
+	if v, err := m.cache.Get("members:" + gid.OpaqueId); err == nil {
+		return v.([]*userpb.UserId), nil
+	}
+
+	kg, err := m.getKeycloakGroup(ctx, gid.OpaqueId)
+	if err != nil {
+		return nil, err
+	}
+	users, err := m.client.GetGroupMembers(ctx, kg.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	members := make([]*userpb.UserId, 0, len(users))
+	for _, u := range users {
+		members = append(members, &userpb.UserId{Idp: m.c.Idp, OpaqueId: u.ID})
+	}
+	_ = m.cache.Set("members:"+gid.OpaqueId, members)
+	return members, nil
+}
+
+func (m *manager) HasMember(ctx context.Context, gid *grouppb.GroupId, uid *userpb.UserId) (bool, error) {
+	members, err := m.GetMembers(ctx, gid)
+	if err != nil {
+		return false, err
+	}
+	for _, u := range members {
+		if u.OpaqueId == uid.OpaqueId {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -26,14 +26,18 @@ import (
 	"strconv"
 	"strings"
 	"text/template"
+	"time"
 
 	"github.com/Masterminds/sprig"
+	"github.com/ReneKroon/ttlcache/v2"
 	grouppb "github.com/cs3org/go-cs3apis/cs3/identity/group/v1beta1"
 	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
 	"github.com/cs3org/reva/pkg/appctx"
 	"github.com/cs3org/reva/pkg/errtypes"
 	"github.com/cs3org/reva/pkg/group"
 	"github.com/cs3org/reva/pkg/group/manager/registry"
+	"github.com/cs3org/reva/pkg/user"
 	"github.com/go-ldap/ldap/v3"
 	"github.com/mitchellh/mapstructure"
 	"github.com/pkg/errors"
@@ -44,9 +48,10 @@ func init() {
 }
 
 type manager struct {
-	c            *config
-	groupfilter  *template.Template
-	memberfilter *template.Template
+	c              *config
+	groupfilter    *template.Template
+	memberfilter   *template.Template
+	nonMemberCache *ttlcache.Cache
 }
 
 type config struct {
@@ -61,6 +66,13 @@ type config struct {
 	BindPassword    string     `mapstructure:"bind_password"`
 	Idp             string     `mapstructure:"idp"`
 	Schema          attributes `mapstructure:"schema"`
+	// Nested enables the resolution of the members of the groups which are
+	// members of a group, up to MaxNestingDepth levels.
+	Nested          bool `mapstructure:"nested"`
+	MaxNestingDepth int  `mapstructure:"max_nesting_depth"`
+	// NonMemberCacheTTL is the time in seconds for which a negative membership
+	// check is cached. A negative value disables the cache.
+	NonMemberCacheTTL int `mapstructure:"non_member_cache_ttl"`
 }
 
 type attributes struct {
@@ -76,6 +88,10 @@ type attributes struct {
 	DisplayName string `mapstructure:"displayName"`
 	// GIDNumber is a numeric id that maps to a filesystem gid, eg. 654321
 	GIDNumber string `mapstructure:"gidNumber"`
+	// GroupObjectClass is the object class of the groups, used to recognize the nested groups
+	GroupObjectClass string `mapstructure:"groupObjectClass"`
+	// Quota is the quota in bytes of the members of the group, not read when empty
+	Quota string `mapstructure:"quota"`
 }
 
 // Default attributes (Active Directory)
@@ -86,6 +102,8 @@ var ldapDefaults = attributes{
 	Mail:        "mail",
 	DisplayName: "displayName",
 	GIDNumber:   "gidNumber",
+
+	GroupObjectClass: "group",
 }
 
 func parseConfig(m map[string]interface{}) (*config, error) {
@@ -112,10 +130,21 @@ func New(m map[string]interface{}) (group.Manager, error) {
 		c.FindFilter = c.GroupFilter
 	}
 	c.MemberFilter = strings.ReplaceAll(c.MemberFilter, "%s", "{{.OpaqueId}}")
+	if c.MaxNestingDepth == 0 {
+		c.MaxNestingDepth = 10
+	}
+	if c.NonMemberCacheTTL == 0 {
+		c.NonMemberCacheTTL = 60
+	}
 
 	mgr := &manager{
 		c: c,
 	}
+	if c.NonMemberCacheTTL > 0 {
+		mgr.nonMemberCache = ttlcache.NewCache()
+		_ = mgr.nonMemberCache.SetTTL(time.Duration(c.NonMemberCacheTTL) * time.Second)
+		mgr.nonMemberCache.SkipTTLExtensionOnHit(true)
+	}
 
 	mgr.groupfilter, err = template.New("gf").Funcs(sprig.TxtFuncMap()).Parse(c.GroupFilter)
 	if err != nil {
@@ -150,7 +179,7 @@ func (m *manager) GetGroup(ctx context.Context, gid *grouppb.GroupId) (*grouppb.
 		m.c.BaseDN,
 		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
 		m.getGroupFilter(gid),
-		[]string{m.c.Schema.DN, m.c.Schema.GID, m.c.Schema.CN, m.c.Schema.Mail, m.c.Schema.DisplayName, m.c.Schema.GIDNumber},
+		m.searchAttributes(),
 		nil,
 	)
 
@@ -185,6 +214,7 @@ func (m *manager) GetGroup(ctx context.Context, gid *grouppb.GroupId) (*grouppb.
 		Mail:        sr.Entries[0].GetEqualFoldAttributeValue(m.c.Schema.Mail),
 		DisplayName: sr.Entries[0].GetEqualFoldAttributeValue(m.c.Schema.DisplayName),
 		GidNumber:   gidNumber,
+		Opaque:      m.opaque(sr.Entries[0]),
 	}
 
 	return g, nil
@@ -223,7 +253,7 @@ func (m *manager) GetGroupByClaim(ctx context.Context, claim, value string) (*gr
 		m.c.BaseDN,
 		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
 		m.getAttributeFilter(claim, value),
-		[]string{m.c.Schema.DN, m.c.Schema.GID, m.c.Schema.CN, m.c.Schema.Mail, m.c.Schema.DisplayName, m.c.Schema.GIDNumber},
+		m.searchAttributes(),
 		nil,
 	)
 
@@ -258,6 +288,7 @@ func (m *manager) GetGroupByClaim(ctx context.Context, claim, value string) (*gr
 		Mail:        sr.Entries[0].GetEqualFoldAttributeValue(m.c.Schema.Mail),
 		DisplayName: sr.Entries[0].GetEqualFoldAttributeValue(m.c.Schema.DisplayName),
 		GidNumber:   gidNumber,
+		Opaque:      m.opaque(sr.Entries[0]),
 	}
 
 	return g, nil
@@ -281,7 +312,7 @@ func (m *manager) FindGroups(ctx context.Context, query string) ([]*grouppb.Grou
 		m.c.BaseDN,
 		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
 		m.getFindFilter(query),
-		[]string{m.c.Schema.DN, m.c.Schema.GID, m.c.Schema.CN, m.c.Schema.Mail, m.c.Schema.DisplayName, m.c.Schema.GIDNumber},
+		m.searchAttributes(),
 		nil,
 	)
 
@@ -308,6 +339,7 @@ func (m *manager) FindGroups(ctx context.Context, query string) ([]*grouppb.Grou
 			Mail:        entry.GetEqualFoldAttributeValue(m.c.Schema.Mail),
 			DisplayName: entry.GetEqualFoldAttributeValue(m.c.Schema.DisplayName),
 			GidNumber:   gidNumber,
+			Opaque:      m.opaque(entry),
 		}
 		groups = append(groups, g)
 	}
@@ -328,33 +360,90 @@ func (m *manager) GetMembers(ctx context.Context, gid *grouppb.GroupId) ([]*user
 		return nil, err
 	}
 
+	users := []*userpb.UserId{}
+	seen := map[string]bool{}
+	visited := map[string]bool{gid.OpaqueId: true}
+	if err := m.getMembers(ctx, l, gid, 0, visited, seen, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// getMembers appends the members of the group to users. When nested groups are
+// enabled, the members of the groups which are members of the group are added
+// as well; visited protects against cycles in the membership graph.
+func (m *manager) getMembers(ctx context.Context, l *ldap.Conn, gid *grouppb.GroupId, depth int, visited, seen map[string]bool, users *[]*userpb.UserId) error {
+	attrs := []string{m.c.Schema.CN} // TODO use DN to look up user id
+	if m.c.Nested {
+		attrs = append(attrs, m.c.Schema.GID, "objectClass")
+	}
+
 	// Search for the given clientID
 	searchRequest := ldap.NewSearchRequest(
 		m.c.BaseDN,
 		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
 		m.getMemberFilter(gid),
-		[]string{m.c.Schema.CN}, // TODO use DN to look up user id
+		attrs,
 		nil,
 	)
 
 	sr, err := l.Search(searchRequest)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	users := []*userpb.UserId{}
 	for _, entry := range sr.Entries {
+		if m.c.Nested && m.isGroup(entry) {
+			child := &grouppb.GroupId{
+				Idp:      m.c.Idp,
+				OpaqueId: entry.GetEqualFoldAttributeValue(m.c.Schema.GID),
+			}
+			if visited[child.OpaqueId] {
+				continue
+			}
+			if depth+1 >= m.c.MaxNestingDepth {
+				appctx.GetLogger(ctx).Warn().Str("group", gid.OpaqueId).Int("depth", depth+1).Msg("ldap: maximum group nesting depth reached")
+				continue
+			}
+			visited[child.OpaqueId] = true
+			if err := m.getMembers(ctx, l, child, depth+1, visited, seen, users); err != nil {
+				return err
+			}
+			continue
+		}
+
 		// FIXME this makes the group members use the cn, not an immutable id
-		users = append(users, &userpb.UserId{
-			OpaqueId: entry.GetEqualFoldAttributeValue(m.c.Schema.CN),
+		cn := entry.GetEqualFoldAttributeValue(m.c.Schema.CN)
+		if seen[cn] {
+			continue
+		}
+		seen[cn] = true
+		*users = append(*users, &userpb.UserId{
+			OpaqueId: cn,
 			Idp:      m.c.Idp,
 		})
 	}
 
-	return users, nil
+	return nil
+}
+
+func (m *manager) isGroup(entry *ldap.Entry) bool {
+	for _, oc := range entry.GetAttributeValues("objectClass") {
+		if strings.EqualFold(oc, m.c.Schema.GroupObjectClass) {
+			return true
+		}
+	}
+	return false
 }
 
 func (m *manager) HasMember(ctx context.Context, gid *grouppb.GroupId, uid *userpb.UserId) (bool, error) {
+	key := gid.OpaqueId + "!" + uid.Idp + "!" + uid.OpaqueId
+	if m.nonMemberCache != nil {
+		if _, err := m.nonMemberCache.Get(key); err == nil {
+			return false, nil
+		}
+	}
+
 	members, err := m.GetMembers(ctx, gid)
 	if err != nil {
 		return false, err
@@ -365,9 +454,32 @@ func (m *manager) HasMember(ctx context.Context, gid *grouppb.GroupId, uid *user
 			return true, nil
 		}
 	}
+
+	if m.nonMemberCache != nil {
+		_ = m.nonMemberCache.Set(key, true)
+	}
 	return false, nil
 }
 
+func (m *manager) searchAttributes() []string {
+	attrs := []string{m.c.Schema.DN, m.c.Schema.GID, m.c.Schema.CN, m.c.Schema.Mail, m.c.Schema.DisplayName, m.c.Schema.GIDNumber}
+	if m.c.Schema.Quota != "" {
+		attrs = append(attrs, m.c.Schema.Quota)
+	}
+	return attrs
+}
+
+func (m *manager) opaque(entry *ldap.Entry) *types.Opaque {
+	if m.c.Schema.Quota == "" {
+		return nil
+	}
+	q := entry.GetEqualFoldAttributeValue(m.c.Schema.Quota)
+	if q == "" {
+		return nil
+	}
+	return &types.Opaque{Map: map[string]*types.OpaqueEntry{user.QuotaOpaqueKey: user.QuotaEntry(q)}}
+}
+
 func (m *manager) getGroupFilter(gid *grouppb.GroupId) string {
 	b := bytes.Buffer{}
 	if err := m.groupfilter.Execute(&b, gid); err != nil {
@@ -21,6 +21,8 @@ package loader
 import (
 	// Load core group manager drivers.
 	_ "github.com/cs3org/reva/pkg/group/manager/json"
+	_ "github.com/cs3org/reva/pkg/group/manager/keycloak"
 	_ "github.com/cs3org/reva/pkg/group/manager/ldap"
+	_ "github.com/cs3org/reva/pkg/group/manager/sql"
 	// Add your own here
 )
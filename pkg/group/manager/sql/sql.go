@@ -0,0 +1,368 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	grouppb "github.com/cs3org/go-cs3apis/cs3/identity/group/v1beta1"
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/group"
+	"github.com/cs3org/reva/pkg/group/manager/registry"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+
+	// Provides mysql drivers
+	_ "github.com/go-sql-driver/mysql"
+)
+
+func init() {
+	registry.Register("sql", New)
+}
+
+// The manager expects the following tables:
+//
+//   groups(id, idp, group_name, mail, display_name, gid_number)
+//   group_members(group_id, user_id, user_idp)
+
+type config struct {
+	DbUsername string `mapstructure:"db_username"`
+	DbPassword string `mapstructure:"db_password"`
+	DbHost     string `mapstructure:"db_host"`
+	DbPort     int    `mapstructure:"db_port"`
+	DbName     string `mapstructure:"db_name"`
+	// Idp is the identity provider of the groups stored in the database.
+	Idp string `mapstructure:"idp"`
+	// SearchLimit is the maximum number of groups returned by a search.
+	SearchLimit int `mapstructure:"search_limit"`
+}
+
+func (c *config) init() {
+	if c.DbPort == 0 {
+		c.DbPort = 3306
+	}
+	if c.SearchLimit == 0 {
+		c.SearchLimit = 100
+	}
+}
+
+type manager struct {
+	c  *config
+	db *sql.DB
+}
+
+func parseConfig(m map[string]interface{}) (*config, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		err = errors.Wrap(err, "error decoding conf")
+		return nil, err
+	}
+	c.init()
+	return c, nil
+}
+
+// New returns a group manager storing the groups in a SQL database.
+func New(m map[string]interface{}) (group.Manager, error) {
+	c, err := parseConfig(m)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s:%d)/%s", c.DbUsername, c.DbPassword, c.DbHost, c.DbPort, c.DbName))
+	if err != nil {
+		return nil, errors.Wrap(err, "sql: error opening connection to the database")
+	}
+
+	return &manager{c: c, db: db}, nil
+}
+
+const selectGroup = "SELECT id, idp, group_name, mail, display_name, gid_number FROM `groups`"
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanGroup(row scanner) (*grouppb.Group, error) {
+	var id, idp, name, mail, displayName string
+	var gidNumber sql.NullInt64
+	if err := row.Scan(&id, &idp, &name, &mail, &displayName, &gidNumber); err != nil {
+		return nil, err
+	}
+	return &grouppb.Group{
+		Id: &grouppb.GroupId{
+			Idp:      idp,
+			OpaqueId: id,
+		},
+		GroupName:   name,
+		Mail:        mail,
+		DisplayName: displayName,
+		GidNumber:   gidNumber.Int64,
+	}, nil
+}
+
+func (m *manager) queryGroup(ctx context.Context, where string, args ...interface{}) (*grouppb.Group, error) {
+	g, err := scanGroup(m.db.QueryRowContext(ctx, selectGroup+" WHERE "+where, args...))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errtypes.NotFound(fmt.Sprintf("%v", args))
+		}
+		return nil, errors.Wrap(err, "sql: error querying group")
+	}
+	members, err := m.getMembers(ctx, g.Id)
+	if err != nil {
+		return nil, err
+	}
+	g.Members = members
+	return g, nil
+}
+
+func (m *manager) GetGroup(ctx context.Context, gid *grouppb.GroupId) (*grouppb.Group, error) {
+	return m.queryGroup(ctx, "id=?", gid.OpaqueId)
+}
+
+// claimColumns maps the claims to the columns storing them.
+var claimColumns = map[string]string{
+	"group_name":   "group_name",
+	"gid_number":   "gid_number",
+	"display_name": "display_name",
+	"mail":         "mail",
+	"group_id":     "id",
+}
+
+func (m *manager) GetGroupByClaim(ctx context.Context, claim, value string) (*grouppb.Group, error) {
+	col, ok := claimColumns[claim]
+	if !ok {
+		return nil, errors.New("sql: invalid field " + claim)
+	}
+	return m.queryGroup(ctx, col+"=?", value)
+}
+
+// FindGroups searches the groups whose name, mail, display name or id
+// contain the query. A query in the form attribute:value, e.g. group_name:physics,
+// only matches the groups whose attribute is equal to the value.
+// The results are sorted by name and limited to the configured search limit.
+func (m *manager) FindGroups(ctx context.Context, query string) ([]*grouppb.Group, error) {
+	where := "(group_name LIKE ? OR mail LIKE ? OR display_name LIKE ? OR id LIKE ?)"
+	like := "%" + escapeLike(query) + "%"
+	args := []interface{}{like, like, like, like}
+
+	if parts := strings.SplitN(query, ":", 2); len(parts) == 2 {
+		if col, ok := claimColumns[parts[0]]; ok {
+			where = col + "=?"
+			args = []interface{}{parts[1]}
+		}
+	}
+	args = append(args, m.c.SearchLimit)
+
+	rows, err := m.db.QueryContext(ctx, selectGroup+" WHERE "+where+" ORDER BY group_name LIMIT ?", args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "sql: error searching groups")
+	}
+	defer rows.Close()
+
+	groups := []*grouppb.Group{}
+	for rows.Next() {
+		g, err := scanGroup(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "sql: error scanning group")
+		}
+		groups = append(groups, g)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "sql: error searching groups")
+	}
+	return groups, nil
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+func (m *manager) GetMembers(ctx context.Context, gid *grouppb.GroupId) ([]*userpb.UserId, error) {
+	if _, err := m.GetGroup(ctx, gid); err != nil {
+		return nil, err
+	}
+	return m.getMembers(ctx, gid)
+}
+
+func (m *manager) getMembers(ctx context.Context, gid *grouppb.GroupId) ([]*userpb.UserId, error) {
+	rows, err := m.db.QueryContext(ctx, "SELECT user_id, user_idp FROM group_members WHERE group_id=?", gid.OpaqueId)
+	if err != nil {
+		return nil, errors.Wrap(err, "sql: error querying group members")
+	}
+	defer rows.Close()
+
+	members := []*userpb.UserId{}
+	for rows.Next() {
+		uid := &userpb.UserId{}
+		if err := rows.Scan(&uid.OpaqueId, &uid.Idp); err != nil {
+			return nil, errors.Wrap(err, "sql: error scanning group members")
+		}
+		members = append(members, uid)
+	}
+	return members, rows.Err()
+}
+
+func (m *manager) HasMember(ctx context.Context, gid *grouppb.GroupId, uid *userpb.UserId) (bool, error) {
+	var n int
+	if err := m.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM group_members WHERE group_id=? AND user_id=? AND user_idp=?", gid.OpaqueId, uid.OpaqueId, uid.Idp).Scan(&n); err != nil {
+		return false, errors.Wrap(err, "sql: error querying group members")
+	}
+	return n > 0, nil
+}
+
+func (m *manager) CreateGroup(ctx context.Context, g *grouppb.Group) (*grouppb.Group, error) {
+	var exists int
+	if err := m.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM `groups` WHERE group_name=? OR id=?", g.GroupName, g.Id.OpaqueId).Scan(&exists); err != nil {
+		return nil, errors.Wrap(err, "sql: error checking group")
+	}
+	if exists > 0 {
+		return nil, errtypes.AlreadyExists(g.GroupName)
+	}
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "sql: error starting transaction")
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, "INSERT INTO `groups` (id, idp, group_name, mail, display_name, gid_number) VALUES (?, ?, ?, ?, ?, ?)",
+		g.Id.OpaqueId, m.idp(g.Id), g.GroupName, g.Mail, g.DisplayName, nullGid(g.GidNumber)); err != nil {
+		return nil, errors.Wrap(err, "sql: error creating group")
+	}
+	if err := insertMembers(ctx, tx, g.Id, g.Members); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, errors.Wrap(err, "sql: error committing transaction")
+	}
+	return m.GetGroup(ctx, g.Id)
+}
+
+func (m *manager) UpdateGroup(ctx context.Context, g *grouppb.Group) (*grouppb.Group, error) {
+	if _, err := m.GetGroup(ctx, g.Id); err != nil {
+		return nil, err
+	}
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "sql: error starting transaction")
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, "UPDATE `groups` SET group_name=?, mail=?, display_name=?, gid_number=COALESCE(?, gid_number) WHERE id=?",
+		g.GroupName, g.Mail, g.DisplayName, nullGid(g.GidNumber), g.Id.OpaqueId); err != nil {
+		return nil, errors.Wrap(err, "sql: error updating group")
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM group_members WHERE group_id=?", g.Id.OpaqueId); err != nil {
+		return nil, errors.Wrap(err, "sql: error updating group members")
+	}
+	if err := insertMembers(ctx, tx, g.Id, g.Members); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, errors.Wrap(err, "sql: error committing transaction")
+	}
+	return m.GetGroup(ctx, g.Id)
+}
+
+func (m *manager) DeleteGroup(ctx context.Context, gid *grouppb.GroupId) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "sql: error starting transaction")
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	res, err := tx.ExecContext(ctx, "DELETE FROM `groups` WHERE id=?", gid.OpaqueId)
+	if err != nil {
+		return errors.Wrap(err, "sql: error deleting group")
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return errtypes.NotFound(gid.OpaqueId)
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM group_members WHERE group_id=?", gid.OpaqueId); err != nil {
+		return errors.Wrap(err, "sql: error deleting group members")
+	}
+	return tx.Commit()
+}
+
+func (m *manager) AddMembers(ctx context.Context, gid *grouppb.GroupId, members []*userpb.UserId) error {
+	if _, err := m.GetGroup(ctx, gid); err != nil {
+		return err
+	}
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "sql: error starting transaction")
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, u := range members {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM group_members WHERE group_id=? AND user_id=? AND user_idp=?", gid.OpaqueId, u.OpaqueId, u.Idp); err != nil {
+			return errors.Wrap(err, "sql: error adding group member")
+		}
+	}
+	if err := insertMembers(ctx, tx, gid, members); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (m *manager) RemoveMembers(ctx context.Context, gid *grouppb.GroupId, members []*userpb.UserId) error {
+	if _, err := m.GetGroup(ctx, gid); err != nil {
+		return err
+	}
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return errors.Wrap(err, "sql: error starting transaction")
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, u := range members {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM group_members WHERE group_id=? AND user_id=? AND user_idp=?", gid.OpaqueId, u.OpaqueId, u.Idp); err != nil {
+			return errors.Wrap(err, "sql: error removing group member")
+		}
+	}
+	return tx.Commit()
+}
+
+func insertMembers(ctx context.Context, tx *sql.Tx, gid *grouppb.GroupId, members []*userpb.UserId) error {
+	for _, u := range members {
+		if _, err := tx.ExecContext(ctx, "INSERT INTO group_members (group_id, user_id, user_idp) VALUES (?, ?, ?)", gid.OpaqueId, u.OpaqueId, u.Idp); err != nil {
+			return errors.Wrap(err, "sql: error inserting group member")
+		}
+	}
+	return nil
+}
+
+func (m *manager) idp(gid *grouppb.GroupId) string {
+	if gid.Idp != "" {
+		return gid.Idp
+	}
+	return m.c.Idp
+}
+
+func nullGid(gid int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: gid, Valid: gid != 0}
+}
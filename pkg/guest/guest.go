@@ -0,0 +1,61 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package guest
+
+import (
+	"context"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+)
+
+// Guest is a lightweight account created for an external sharee.
+type Guest struct {
+	User *userpb.User `json:"user"`
+	// Inviter is the user who first shared a resource with the guest.
+	Inviter *userpb.UserId `json:"inviter"`
+	// Password is the hash of the password chosen by the guest on activation.
+	Password string `json:"password"`
+	// ActivationToken is the secret sent to the guest to activate the account.
+	// It is empty once the account has been activated.
+	ActivationToken string `json:"activation_token"`
+	// ActivationExpiration is the unix timestamp after which the activation
+	// token is no longer valid.
+	ActivationExpiration int64 `json:"activation_expiration"`
+}
+
+// IsActive returns whether the guest has already activated the account.
+func (g *Guest) IsActive() bool {
+	return g.Password != "" && g.ActivationToken == ""
+}
+
+// Manager is the interface to implement to manage guest accounts.
+type Manager interface {
+	// InviteGuest returns the guest account associated with the given email
+	// address, creating an inactive one on behalf of the context user if it
+	// does not exist yet. The accounts which have not been activated keep
+	// their activation token, so that the invitations sent earlier remain
+	// valid, until it expires and a fresh one is generated.
+	InviteGuest(ctx context.Context, mail string) (*Guest, error)
+	// ActivateGuest sets the password of the guest holding the activation token.
+	ActivateGuest(ctx context.Context, token, password string) (*userpb.User, error)
+	// Authenticate checks the credentials of an active guest.
+	Authenticate(ctx context.Context, mail, password string) (*userpb.User, error)
+	// GetGuest returns the guest account with the given id.
+	GetGuest(ctx context.Context, uid *userpb.UserId) (*userpb.User, error)
+}
@@ -0,0 +1,251 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package json
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/guest"
+	"github.com/cs3org/reva/pkg/guest/manager/registry"
+	"github.com/cs3org/reva/pkg/user"
+	"github.com/cs3org/reva/pkg/utils/jsonfile"
+	"github.com/golang/protobuf/proto"
+	"github.com/google/uuid"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/sethvargo/go-password/password"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func init() {
+	registry.Register("json", New)
+}
+
+type config struct {
+	File string `mapstructure:"file"`
+	// Idp is the identity provider assigned to guest accounts, keeping them in
+	// a namespace separate from the one of regular users.
+	Idp string `mapstructure:"idp"`
+	// ActivationExpiration is the number of hours an activation token is valid.
+	ActivationExpiration int `mapstructure:"activation_expiration"`
+	PasswordHashCost     int `mapstructure:"password_hash_cost"`
+}
+
+func (c *config) init() {
+	if c.File == "" {
+		c.File = "/var/tmp/reva/guests.json"
+	}
+	if c.Idp == "" {
+		c.Idp = "guests"
+	}
+	if c.ActivationExpiration == 0 {
+		c.ActivationExpiration = 72
+	}
+	if c.PasswordHashCost == 0 {
+		c.PasswordHashCost = 11
+	}
+}
+
+type manager struct {
+	sync.Mutex // concurrent access to the file
+	c          *config
+	file       *jsonfile.File
+	guests     map[string]*guest.Guest
+}
+
+func parseConfig(m map[string]interface{}) (*config, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// New returns a guest manager that stores the accounts in a json file.
+// The file is read again when it is modified so that it can be shared between
+// the services creating guests and the auth providers authenticating them.
+func New(m map[string]interface{}) (guest.Manager, error) {
+	c, err := parseConfig(m)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating a new manager")
+	}
+	c.init()
+
+	mgr := &manager{c: c, file: jsonfile.New(c.File), guests: map[string]*guest.Guest{}}
+	if _, err := mgr.load(); err != nil {
+		return nil, errors.Wrap(err, "error loading the file containing the guests")
+	}
+	return mgr, nil
+}
+
+// load returns the guests stored in the file, indexed by mail, reading the
+// file again if it was modified by another process.
+func (m *manager) load() (map[string]*guest.Guest, error) {
+	guests := map[string]*guest.Guest{}
+	changed, err := m.file.Reload(&guests)
+	if err != nil {
+		return nil, errors.Wrap(err, "error reading the guests")
+	}
+	if changed {
+		m.guests = guests
+	}
+	return m.guests, nil
+}
+
+func (m *manager) save() error {
+	if err := m.file.Persist(m.guests); err != nil {
+		// drop the changes made in memory, the file is read again by the
+		// next operation
+		m.file = jsonfile.New(m.c.File)
+		m.guests = map[string]*guest.Guest{}
+		return errors.Wrap(err, "error writing the guests")
+	}
+	return nil
+}
+
+func (m *manager) InviteGuest(ctx context.Context, mail string) (*guest.Guest, error) {
+	mail = strings.ToLower(strings.TrimSpace(mail))
+	if !strings.Contains(mail, "@") {
+		return nil, errtypes.BadRequest("invalid email address: " + mail)
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	guests, err := m.load()
+	if err != nil {
+		return nil, err
+	}
+
+	g, ok := guests[mail]
+	if !ok {
+		inviter := user.ContextMustGetUser(ctx)
+		g = &guest.Guest{
+			User: &userpb.User{
+				Id: &userpb.UserId{
+					Idp:      m.c.Idp,
+					OpaqueId: uuid.New().String(),
+				},
+				Username:    mail,
+				Mail:        mail,
+				DisplayName: mail,
+			},
+			Inviter: inviter.Id,
+		}
+		guests[mail] = g
+	}
+
+	// a pending invitation keeps its token, for the links sent earlier to
+	// keep working until it expires.
+	if !g.IsActive() && (g.ActivationToken == "" || time.Now().Unix() > g.ActivationExpiration) {
+		token, err := password.Generate(32, 10, 0, false, true)
+		if err != nil {
+			return nil, errors.Wrap(err, "error creating activation token")
+		}
+		g.ActivationToken = token
+		g.ActivationExpiration = time.Now().Add(time.Duration(m.c.ActivationExpiration) * time.Hour).Unix()
+	}
+
+	if err := m.save(); err != nil {
+		return nil, err
+	}
+	// the guests are cached, the caller gets a copy
+	cp := *g
+	cp.User = proto.Clone(g.User).(*userpb.User)
+	return &cp, nil
+}
+
+func (m *manager) ActivateGuest(ctx context.Context, token, pwd string) (*userpb.User, error) {
+	if token == "" || pwd == "" {
+		return nil, errtypes.BadRequest("token and password are required")
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	guests, err := m.load()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, g := range guests {
+		if g.ActivationToken != token {
+			continue
+		}
+		if time.Now().Unix() > g.ActivationExpiration {
+			return nil, errtypes.PermissionDenied("activation token expired")
+		}
+
+		h, err := bcrypt.GenerateFromPassword([]byte(pwd), m.c.PasswordHashCost)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not hash password")
+		}
+		g.Password = string(h)
+		g.ActivationToken = ""
+		g.ActivationExpiration = 0
+
+		if err := m.save(); err != nil {
+			return nil, err
+		}
+		return proto.Clone(g.User).(*userpb.User), nil
+	}
+
+	return nil, errtypes.NotFound("activation token not found")
+}
+
+func (m *manager) Authenticate(ctx context.Context, mail, pwd string) (*userpb.User, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	guests, err := m.load()
+	if err != nil {
+		return nil, err
+	}
+
+	g, ok := guests[strings.ToLower(mail)]
+	if !ok || !g.IsActive() {
+		return nil, errtypes.InvalidCredentials(mail)
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(g.Password), []byte(pwd)); err != nil {
+		return nil, errtypes.InvalidCredentials(mail)
+	}
+	return proto.Clone(g.User).(*userpb.User), nil
+}
+
+func (m *manager) GetGuest(ctx context.Context, uid *userpb.UserId) (*userpb.User, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	guests, err := m.load()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, g := range guests {
+		if g.User.Id.Idp == uid.Idp && g.User.Id.OpaqueId == uid.OpaqueId {
+			return proto.Clone(g.User).(*userpb.User), nil
+		}
+	}
+	return nil, errtypes.NotFound(uid.OpaqueId)
+}
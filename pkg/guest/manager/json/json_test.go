@@ -0,0 +1,106 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package json
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/pkg/user"
+)
+
+func TestGuestLifecycle(t *testing.T) {
+	tempDir, err := ioutil.TempDir("", "jsonguest_test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	mgr, err := New(map[string]interface{}{
+		"file":               path.Join(tempDir, "guests.json"),
+		"password_hash_cost": 4,
+	})
+	if err != nil {
+		t.Fatalf("error creating manager: %v", err)
+	}
+
+	inviter := &userpb.User{Id: &userpb.UserId{Idp: "localhost", OpaqueId: "einstein"}, Username: "einstein"}
+	ctx := user.ContextSetUser(context.Background(), inviter)
+
+	g, err := mgr.InviteGuest(ctx, "Marie@Example.org")
+	if err != nil {
+		t.Fatalf("error inviting guest: %v", err)
+	}
+	if g.User.Id.Idp != "guests" || g.User.Mail != "marie@example.org" {
+		t.Fatalf("unexpected guest user: %+v", g.User)
+	}
+	if g.IsActive() || g.ActivationToken == "" {
+		t.Fatalf("new guest must be inactive and have an activation token")
+	}
+
+	if _, err := mgr.Authenticate(ctx, "marie@example.org", "secret"); err == nil {
+		t.Fatalf("inactive guest must not be able to authenticate")
+	}
+
+	// inviting a pending guest again keeps the token sent with the first invitation
+	pending, err := mgr.InviteGuest(ctx, "marie@example.org")
+	if err != nil {
+		t.Fatalf("error inviting guest again: %v", err)
+	}
+	if pending.ActivationToken != g.ActivationToken {
+		t.Fatalf("the activation token of a pending guest must not change")
+	}
+
+	if _, err := mgr.ActivateGuest(ctx, "wrong-token", "secret"); err == nil {
+		t.Fatalf("activation with a wrong token must fail")
+	}
+
+	if _, err := mgr.ActivateGuest(ctx, g.ActivationToken, "secret"); err != nil {
+		t.Fatalf("error activating guest: %v", err)
+	}
+
+	u, err := mgr.Authenticate(ctx, "marie@example.org", "secret")
+	if err != nil {
+		t.Fatalf("error authenticating guest: %v", err)
+	}
+	if u.Id.OpaqueId != g.User.Id.OpaqueId {
+		t.Fatalf("authenticated a different user: %+v", u)
+	}
+
+	if _, err := mgr.Authenticate(ctx, "marie@example.org", "wrong"); err == nil {
+		t.Fatalf("authentication with a wrong password must fail")
+	}
+
+	// inviting an active guest again returns the same account without a new token
+	again, err := mgr.InviteGuest(ctx, "marie@example.org")
+	if err != nil {
+		t.Fatalf("error inviting guest again: %v", err)
+	}
+	if again.User.Id.OpaqueId != g.User.Id.OpaqueId || again.ActivationToken != "" {
+		t.Fatalf("unexpected guest after second invitation: %+v", again)
+	}
+
+	if _, err := mgr.GetGuest(ctx, g.User.Id); err != nil {
+		t.Fatalf("error getting guest: %v", err)
+	}
+}
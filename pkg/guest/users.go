@@ -0,0 +1,58 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+package guest
+
+import (
+	"context"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/user"
+)
+
+type userManager struct {
+	user.Manager
+	guests Manager
+}
+
+// NewUserManager returns a user manager resolving the ids of the guest
+// accounts, which are not known to m, with the guest manager.
+func NewUserManager(m user.Manager, guests Manager) user.Manager {
+	return &userManager{Manager: m, guests: guests}
+}
+
+func (um *userManager) GetUser(ctx context.Context, uid *userpb.UserId) (*userpb.User, error) {
+	g, err := um.guests.GetGuest(ctx, uid)
+	if _, ok := err.(errtypes.IsNotFound); ok {
+		return um.Manager.GetUser(ctx, uid)
+	}
+	return g, err
+}
+
+func (um *userManager) GetUserGroups(ctx context.Context, uid *userpb.UserId) ([]string, error) {
+	_, err := um.guests.GetGuest(ctx, uid)
+	switch err.(type) {
+	case nil:
+		// guests do not belong to any group
+		return []string{}, nil
+	case errtypes.IsNotFound:
+		return um.Manager.GetUserGroups(ctx, uid)
+	default:
+		return nil, err
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+package guest
+
+import (
+	"context"
+	"testing"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/user"
+)
+
+type users struct {
+	user.Manager
+}
+
+func (users) GetUser(ctx context.Context, uid *userpb.UserId) (*userpb.User, error) {
+	if uid.OpaqueId == "einstein" {
+		return &userpb.User{Id: uid, Username: "einstein"}, nil
+	}
+	return nil, errtypes.NotFound(uid.OpaqueId)
+}
+
+func (users) GetUserGroups(ctx context.Context, uid *userpb.UserId) ([]string, error) {
+	if uid.OpaqueId == "einstein" {
+		return []string{"physics-lovers"}, nil
+	}
+	return nil, errtypes.NotFound(uid.OpaqueId)
+}
+
+type guests struct {
+	Manager
+}
+
+func (guests) GetGuest(ctx context.Context, uid *userpb.UserId) (*userpb.User, error) {
+	if uid.Idp == "guests" && uid.OpaqueId == "marie" {
+		return &userpb.User{Id: uid, Username: "marie@example.org"}, nil
+	}
+	return nil, errtypes.NotFound(uid.OpaqueId)
+}
+
+func TestUserManager(t *testing.T) {
+	m := NewUserManager(users{}, guests{})
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		uid      *userpb.UserId
+		username string
+		groups   int
+	}{
+		{"user", &userpb.UserId{Idp: "localhost", OpaqueId: "einstein"}, "einstein", 1},
+		{"guest", &userpb.UserId{Idp: "guests", OpaqueId: "marie"}, "marie@example.org", 0},
+		{"unknown", &userpb.UserId{Idp: "localhost", OpaqueId: "marie"}, "", -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := m.GetUser(ctx, tt.uid)
+			if tt.username == "" {
+				if _, ok := err.(errtypes.IsNotFound); !ok {
+					t.Fatalf("expected not found, got %v, %v", u, err)
+				}
+				return
+			}
+			if err != nil || u.Username != tt.username {
+				t.Fatalf("got %v, %v, expected %s", u, err, tt.username)
+			}
+			groups, err := m.GetUserGroups(ctx, tt.uid)
+			if err != nil || len(groups) != tt.groups {
+				t.Fatalf("got groups %v, %v, expected %d", groups, err, tt.groups)
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package health keeps track of the state of the servers run by the process,
+// as reported by the supervisor restarting them when they fail.
+package health
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// State is the state of a server.
+type State string
+
+const (
+	// Starting is the state of a server which has not been started yet.
+	Starting State = "starting"
+	// Running is the state of a server serving requests.
+	Running State = "running"
+	// Restarting is the state of a server which failed, waiting to be
+	// restarted.
+	Restarting State = "restarting"
+	// Stopped is the state of a server which was shut down.
+	Stopped State = "stopped"
+)
+
+// Status is the status of a server.
+type Status struct {
+	Name      string    `json:"name"`
+	State     State     `json:"state"`
+	Restarts  int       `json:"restarts"`
+	LastError string    `json:"last_error,omitempty"`
+	Since     time.Time `json:"since"`
+}
+
+var statuses = struct {
+	sync.RWMutex
+	m map[string]*Status
+}{m: map[string]*Status{}}
+
+// SetState records the state of the given server, along with the error which
+// made it fail, if any.
+func SetState(name string, state State, err error) {
+	statuses.Lock()
+	defer statuses.Unlock()
+
+	s, ok := statuses.m[name]
+	if !ok {
+		s = &Status{Name: name}
+		statuses.m[name] = s
+	}
+	if state == Restarting {
+		s.Restarts++
+	}
+	if err != nil {
+		s.LastError = err.Error()
+	}
+	s.State = state
+	s.Since = time.Now()
+}
+
+// Statuses returns the status of every server, sorted by name.
+func Statuses() []Status {
+	statuses.RLock()
+	defer statuses.RUnlock()
+
+	list := make([]Status, 0, len(statuses.m))
+	for _, s := range statuses.m {
+		list = append(list, *s)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Healthy tells whether every server is running.
+func Healthy() bool {
+	for _, s := range Statuses() {
+		if s.State != Running {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,117 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package idalloc
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	grouppb "github.com/cs3org/go-cs3apis/cs3/identity/group/v1beta1"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/group"
+	"github.com/golang/protobuf/proto"
+)
+
+// GroupKey returns the key under which the gid of a group is allocated.
+func GroupKey(gid *grouppb.GroupId) string {
+	return gid.GetIdp() + "!" + gid.GetOpaqueId()
+}
+
+type groupManager struct {
+	group.Manager
+	a Allocator
+}
+
+// NewGroupManager returns a group manager allocating the gid numbers of the
+// groups returned by m which do not have any.
+func NewGroupManager(m group.Manager, a Allocator) group.Manager {
+	return &groupManager{Manager: m, a: a}
+}
+
+// complete returns a copy of the group with the missing gid allocated.
+func (gm *groupManager) complete(ctx context.Context, g *grouppb.Group) (*grouppb.Group, error) {
+	if g.GidNumber != 0 {
+		return g, nil
+	}
+	n, err := gm.a.Allocate(ctx, Group, GroupKey(g.Id))
+	if err != nil {
+		return nil, err
+	}
+	c := proto.Clone(g).(*grouppb.Group)
+	c.GidNumber = n
+	return c, nil
+}
+
+func (gm *groupManager) GetGroup(ctx context.Context, gid *grouppb.GroupId) (*grouppb.Group, error) {
+	g, err := gm.Manager.GetGroup(ctx, gid)
+	if err != nil {
+		return nil, err
+	}
+	return gm.complete(ctx, g)
+}
+
+func (gm *groupManager) GetGroupByClaim(ctx context.Context, claim, value string) (*grouppb.Group, error) {
+	g, err := gm.Manager.GetGroupByClaim(ctx, claim, value)
+	if _, ok := err.(errtypes.IsNotFound); ok && claim == "gid_number" {
+		// the gid might have been allocated to a group whose backend does not know it
+		g, err = gm.getGroupByGID(ctx, value)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return gm.complete(ctx, g)
+}
+
+func (gm *groupManager) getGroupByGID(ctx context.Context, value string) (*grouppb.Group, error) {
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil, errtypes.NotFound(value)
+	}
+	key, err := gm.a.Lookup(ctx, Group, n)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(key, userGroupPrefix) {
+		// private groups of the users are not known to the group managers
+		return nil, errtypes.NotFound(value)
+	}
+	parts := strings.SplitN(key, "!", 2)
+	gid := &grouppb.GroupId{OpaqueId: key}
+	if len(parts) == 2 {
+		gid = &grouppb.GroupId{Idp: parts[0], OpaqueId: parts[1]}
+	}
+	return gm.Manager.GetGroup(ctx, gid)
+}
+
+func (gm *groupManager) FindGroups(ctx context.Context, query string) ([]*grouppb.Group, error) {
+	groups, err := gm.Manager.FindGroups(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	completed := make([]*grouppb.Group, 0, len(groups))
+	for _, g := range groups {
+		c, err := gm.complete(ctx, g)
+		if err != nil {
+			return nil, err
+		}
+		completed = append(completed, c)
+	}
+	return completed, nil
+}
@@ -0,0 +1,45 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package idalloc allocates numeric uids and gids to the users and groups whose
+// backend does not provide them, for the storage drivers relying on posix
+// identities. A number, once allocated, is never given to another identity.
+package idalloc
+
+import (
+	"context"
+)
+
+// Kind is the kind of identity numbers are allocated to.
+type Kind string
+
+const (
+	// User is the kind of the uid numbers.
+	User Kind = "user"
+	// Group is the kind of the gid numbers.
+	Group Kind = "group"
+)
+
+// Allocator is the interface to implement to allocate numeric ids.
+type Allocator interface {
+	// Allocate returns the number allocated to the identity with the given key,
+	// allocating a new one the first time the identity is seen.
+	Allocate(ctx context.Context, kind Kind, key string) (int64, error)
+	// Lookup returns the key of the identity the number has been allocated to.
+	Lookup(ctx context.Context, kind Kind, n int64) (string, error)
+}
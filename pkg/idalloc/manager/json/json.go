@@ -0,0 +1,185 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package json
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/idalloc"
+	"github.com/cs3org/reva/pkg/idalloc/manager/registry"
+	"github.com/cs3org/reva/pkg/utils/jsonfile"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	registry.Register("json", New)
+}
+
+type config struct {
+	// File is the file the allocations are persisted to.
+	File string `mapstructure:"file"`
+	// UIDMin and UIDMax delimit the range of the allocated uids.
+	UIDMin int64 `mapstructure:"uid_min"`
+	UIDMax int64 `mapstructure:"uid_max"`
+	// GIDMin and GIDMax delimit the range of the allocated gids.
+	GIDMin int64 `mapstructure:"gid_min"`
+	GIDMax int64 `mapstructure:"gid_max"`
+}
+
+func (c *config) init() {
+	if c.File == "" {
+		c.File = "/var/tmp/reva/idalloc.json"
+	}
+	if c.UIDMin == 0 {
+		c.UIDMin = 100000
+	}
+	if c.UIDMax == 0 {
+		c.UIDMax = 999999
+	}
+	if c.GIDMin == 0 {
+		c.GIDMin = 100000
+	}
+	if c.GIDMax == 0 {
+		c.GIDMax = 999999
+	}
+}
+
+type allocations map[idalloc.Kind]map[string]int64
+
+type manager struct {
+	sync.Mutex
+	c     *config
+	file  *jsonfile.File
+	alloc allocations
+}
+
+func parseConfig(m map[string]interface{}) (*config, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		err = errors.Wrap(err, "error decoding conf")
+		return nil, err
+	}
+	c.init()
+	return c, nil
+}
+
+// New returns an allocator handing out the numbers of a range in sequence,
+// and persisting the allocations in a JSON file.
+func New(m map[string]interface{}) (idalloc.Allocator, error) {
+	c, err := parseConfig(m)
+	if err != nil {
+		return nil, err
+	}
+	if c.UIDMin > c.UIDMax || c.GIDMin > c.GIDMax {
+		return nil, errors.New("idalloc: invalid range")
+	}
+
+	mgr := &manager{c: c, file: jsonfile.New(c.File), alloc: allocations{}}
+	if err := mgr.reload(); err != nil {
+		return nil, err
+	}
+	return mgr, nil
+}
+
+func (m *manager) reload() error {
+	alloc := allocations{}
+	changed, err := m.file.Reload(&alloc)
+	if err != nil {
+		return errors.Wrap(err, "idalloc: error reading allocations")
+	}
+	if changed {
+		m.alloc = alloc
+	}
+	return nil
+}
+
+func (m *manager) persist() error {
+	if err := m.file.Persist(m.alloc); err != nil {
+		return errors.Wrap(err, "idalloc: error writing allocations")
+	}
+	return nil
+}
+
+func (m *manager) bounds(kind idalloc.Kind) (int64, int64, error) {
+	switch kind {
+	case idalloc.User:
+		return m.c.UIDMin, m.c.UIDMax, nil
+	case idalloc.Group:
+		return m.c.GIDMin, m.c.GIDMax, nil
+	}
+	return 0, 0, errtypes.BadRequest(fmt.Sprintf("idalloc: unknown kind %s", kind))
+}
+
+func (m *manager) Allocate(ctx context.Context, kind idalloc.Kind, key string) (int64, error) {
+	min, max, err := m.bounds(kind)
+	if err != nil {
+		return 0, err
+	}
+
+	m.Lock()
+	defer m.Unlock()
+	if err := m.reload(); err != nil {
+		return 0, err
+	}
+
+	if n, ok := m.alloc[kind][key]; ok {
+		return n, nil
+	}
+
+	// the next number is the one following the highest allocated so far, so
+	// that numbers are never reused, even if the range was changed
+	next := min
+	for _, n := range m.alloc[kind] {
+		if n >= next {
+			next = n + 1
+		}
+	}
+	if next > max {
+		return 0, errtypes.InternalError(fmt.Sprintf("idalloc: %s range exhausted", kind))
+	}
+
+	if m.alloc[kind] == nil {
+		m.alloc[kind] = map[string]int64{}
+	}
+	m.alloc[kind][key] = next
+	if err := m.persist(); err != nil {
+		delete(m.alloc[kind], key)
+		return 0, err
+	}
+	return next, nil
+}
+
+func (m *manager) Lookup(ctx context.Context, kind idalloc.Kind, n int64) (string, error) {
+	m.Lock()
+	defer m.Unlock()
+	if err := m.reload(); err != nil {
+		return "", err
+	}
+
+	for key, v := range m.alloc[kind] {
+		if v == n {
+			return key, nil
+		}
+	}
+	return "", errtypes.NotFound(fmt.Sprintf("%s %d", kind, n))
+}
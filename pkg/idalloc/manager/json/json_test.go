@@ -0,0 +1,83 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package json
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cs3org/reva/pkg/idalloc"
+)
+
+func TestAllocate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "idalloc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	conf := map[string]interface{}{
+		"file":    filepath.Join(dir, "idalloc.json"),
+		"uid_min": 1000,
+		"uid_max": 1001,
+	}
+	a, err := New(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	tests := []struct {
+		key  string
+		want int64
+	}{
+		{"einstein", 1000},
+		{"marie", 1001},
+		{"einstein", 1000},
+	}
+	for _, tt := range tests {
+		n, err := a.Allocate(ctx, idalloc.User, tt.key)
+		if err != nil {
+			t.Fatalf("allocating %s: %v", tt.key, err)
+		}
+		if n != tt.want {
+			t.Errorf("allocating %s: got %d, want %d", tt.key, n, tt.want)
+		}
+	}
+
+	if _, err := a.Allocate(ctx, idalloc.User, "richard"); err == nil {
+		t.Error("expected an error when the range is exhausted")
+	}
+
+	// the allocations are shared with other instances through the file
+	b, err := New(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := b.Lookup(ctx, idalloc.User, 1001)
+	if err != nil || key != "marie" {
+		t.Errorf("lookup of 1001: got %q, %v, want marie", key, err)
+	}
+	if _, err := b.Lookup(ctx, idalloc.Group, 1001); err == nil {
+		t.Error("expected uids and gids to be allocated independently")
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/idalloc"
+	"github.com/cs3org/reva/pkg/idalloc/manager/registry"
+	"github.com/go-sql-driver/mysql"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	registry.Register("sql", New)
+}
+
+// The allocator expects the following table:
+//
+//   id_allocations(kind VARCHAR, id_key VARCHAR, number BIGINT,
+//                  PRIMARY KEY (kind, id_key), UNIQUE (kind, number))
+
+// maxRetries is the number of times an allocation is retried when another
+// instance allocated the same number concurrently.
+const maxRetries = 5
+
+type config struct {
+	DbUsername string `mapstructure:"db_username"`
+	DbPassword string `mapstructure:"db_password"`
+	DbHost     string `mapstructure:"db_host"`
+	DbPort     int    `mapstructure:"db_port"`
+	DbName     string `mapstructure:"db_name"`
+	// UIDMin and UIDMax delimit the range of the allocated uids.
+	UIDMin int64 `mapstructure:"uid_min"`
+	UIDMax int64 `mapstructure:"uid_max"`
+	// GIDMin and GIDMax delimit the range of the allocated gids.
+	GIDMin int64 `mapstructure:"gid_min"`
+	GIDMax int64 `mapstructure:"gid_max"`
+}
+
+func (c *config) init() {
+	if c.DbPort == 0 {
+		c.DbPort = 3306
+	}
+	if c.UIDMin == 0 {
+		c.UIDMin = 100000
+	}
+	if c.UIDMax == 0 {
+		c.UIDMax = 999999
+	}
+	if c.GIDMin == 0 {
+		c.GIDMin = 100000
+	}
+	if c.GIDMax == 0 {
+		c.GIDMax = 999999
+	}
+}
+
+type manager struct {
+	c  *config
+	db *sql.DB
+}
+
+func parseConfig(m map[string]interface{}) (*config, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		err = errors.Wrap(err, "error decoding conf")
+		return nil, err
+	}
+	c.init()
+	return c, nil
+}
+
+// New returns an allocator storing the allocations in a SQL database, which
+// can be shared by several instances.
+func New(m map[string]interface{}) (idalloc.Allocator, error) {
+	c, err := parseConfig(m)
+	if err != nil {
+		return nil, err
+	}
+	if c.UIDMin > c.UIDMax || c.GIDMin > c.GIDMax {
+		return nil, errors.New("idalloc: invalid range")
+	}
+
+	db, err := sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s:%d)/%s", c.DbUsername, c.DbPassword, c.DbHost, c.DbPort, c.DbName))
+	if err != nil {
+		return nil, errors.Wrap(err, "sql: error opening connection to the database")
+	}
+
+	return &manager{c: c, db: db}, nil
+}
+
+func (m *manager) bounds(kind idalloc.Kind) (int64, int64, error) {
+	switch kind {
+	case idalloc.User:
+		return m.c.UIDMin, m.c.UIDMax, nil
+	case idalloc.Group:
+		return m.c.GIDMin, m.c.GIDMax, nil
+	}
+	return 0, 0, errtypes.BadRequest(fmt.Sprintf("idalloc: unknown kind %s", kind))
+}
+
+func (m *manager) get(ctx context.Context, kind idalloc.Kind, key string) (int64, error) {
+	var n int64
+	err := m.db.QueryRowContext(ctx, "SELECT number FROM id_allocations WHERE kind=? AND id_key=?", kind, key).Scan(&n)
+	return n, err
+}
+
+func (m *manager) Allocate(ctx context.Context, kind idalloc.Kind, key string) (int64, error) {
+	min, max, err := m.bounds(kind)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := 0; i < maxRetries; i++ {
+		n, err := m.get(ctx, kind, key)
+		if err == nil {
+			return n, nil
+		}
+		if err != sql.ErrNoRows {
+			return 0, err
+		}
+
+		// the next number is the one following the highest allocated so far,
+		// so that numbers are never reused, even if the range was changed
+		var highest sql.NullInt64
+		if err := m.db.QueryRowContext(ctx, "SELECT MAX(number) FROM id_allocations WHERE kind=?", kind).Scan(&highest); err != nil {
+			return 0, err
+		}
+		next := min
+		if highest.Valid && highest.Int64 >= next {
+			next = highest.Int64 + 1
+		}
+		if next > max {
+			return 0, errtypes.InternalError(fmt.Sprintf("idalloc: %s range exhausted", kind))
+		}
+
+		_, err = m.db.ExecContext(ctx, "INSERT INTO id_allocations (kind, id_key, number) VALUES (?, ?, ?)", kind, key, next)
+		if err == nil {
+			return next, nil
+		}
+		// a duplicate entry means that either the key or the number has been
+		// allocated concurrently, in both cases the allocation is tried again
+		if e, ok := err.(*mysql.MySQLError); !ok || e.Number != 1062 {
+			return 0, err
+		}
+	}
+	return 0, errtypes.InternalError("idalloc: too many concurrent allocations")
+}
+
+func (m *manager) Lookup(ctx context.Context, kind idalloc.Kind, n int64) (string, error) {
+	var key string
+	err := m.db.QueryRowContext(ctx, "SELECT id_key FROM id_allocations WHERE kind=? AND number=?", kind, n).Scan(&key)
+	if err == sql.ErrNoRows {
+		return "", errtypes.NotFound(fmt.Sprintf("%s %d", kind, n))
+	}
+	return key, err
+}
@@ -0,0 +1,149 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package idalloc
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/user"
+	"github.com/golang/protobuf/proto"
+)
+
+// userGroupPrefix prefixes the keys of the private groups of the users, whose
+// gid is used as the primary gid of the users lacking one.
+const userGroupPrefix = "user:"
+
+// UserKey returns the key under which the uid of a user is allocated.
+func UserKey(uid *userpb.UserId) string {
+	return uid.GetIdp() + "!" + uid.GetOpaqueId()
+}
+
+func parseUserKey(key string) *userpb.UserId {
+	parts := strings.SplitN(key, "!", 2)
+	if len(parts) != 2 {
+		return &userpb.UserId{OpaqueId: key}
+	}
+	return &userpb.UserId{Idp: parts[0], OpaqueId: parts[1]}
+}
+
+type userManager struct {
+	user.Manager
+	a Allocator
+}
+
+// NewUserManager returns a user manager allocating the uid and gid numbers of
+// the users returned by m which do not have any.
+func NewUserManager(m user.Manager, a Allocator) user.Manager {
+	return &userManager{Manager: m, a: a}
+}
+
+func opaqueValue(u *userpb.User, key string) string {
+	if u.Opaque == nil || u.Opaque.Map == nil {
+		return ""
+	}
+	if e, ok := u.Opaque.Map[key]; ok && e.Decoder == "plain" {
+		return string(e.Value)
+	}
+	return ""
+}
+
+// complete returns a copy of the user with the missing uid and gid allocated.
+func (um *userManager) complete(ctx context.Context, u *userpb.User) (*userpb.User, error) {
+	uid, gid := opaqueValue(u, "uid"), opaqueValue(u, "gid")
+	if uid != "" && gid != "" {
+		return u, nil
+	}
+
+	key := UserKey(u.Id)
+	c := proto.Clone(u).(*userpb.User)
+	if c.Opaque == nil {
+		c.Opaque = &types.Opaque{}
+	}
+	if c.Opaque.Map == nil {
+		c.Opaque.Map = map[string]*types.OpaqueEntry{}
+	}
+	if uid == "" {
+		n, err := um.a.Allocate(ctx, User, key)
+		if err != nil {
+			return nil, err
+		}
+		c.Opaque.Map["uid"] = &types.OpaqueEntry{Decoder: "plain", Value: []byte(strconv.FormatInt(n, 10))}
+	}
+	if gid == "" {
+		n, err := um.a.Allocate(ctx, Group, userGroupPrefix+key)
+		if err != nil {
+			return nil, err
+		}
+		c.Opaque.Map["gid"] = &types.OpaqueEntry{Decoder: "plain", Value: []byte(strconv.FormatInt(n, 10))}
+	}
+	return c, nil
+}
+
+func (um *userManager) GetUser(ctx context.Context, uid *userpb.UserId) (*userpb.User, error) {
+	u, err := um.Manager.GetUser(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	return um.complete(ctx, u)
+}
+
+func (um *userManager) GetUserByClaim(ctx context.Context, claim, value string) (*userpb.User, error) {
+	u, err := um.Manager.GetUserByClaim(ctx, claim, value)
+	if _, ok := err.(errtypes.IsNotFound); ok && claim == "uid" {
+		// the uid might have been allocated to a user whose backend does not know it
+		u, err = um.getUserByUID(ctx, value)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return um.complete(ctx, u)
+}
+
+func (um *userManager) getUserByUID(ctx context.Context, value string) (*userpb.User, error) {
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil, errtypes.NotFound(value)
+	}
+	key, err := um.a.Lookup(ctx, User, n)
+	if err != nil {
+		return nil, err
+	}
+	return um.Manager.GetUser(ctx, parseUserKey(key))
+}
+
+func (um *userManager) FindUsers(ctx context.Context, query string) ([]*userpb.User, error) {
+	users, err := um.Manager.FindUsers(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	completed := make([]*userpb.User, 0, len(users))
+	for _, u := range users {
+		c, err := um.complete(ctx, u)
+		if err != nil {
+			return nil, err
+		}
+		completed = append(completed, c)
+	}
+	return completed, nil
+}
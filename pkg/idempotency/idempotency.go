@@ -0,0 +1,62 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package idempotency carries the idempotency keys set by the clients from
+// their HTTP requests to the gateway, which replays the response of a
+// request already handled instead of running it again, e.g. when the client
+// retries after a timeout.
+package idempotency
+
+import (
+	"context"
+	"net/http"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// Header is the HTTP header holding the idempotency key of a request.
+const Header = "Idempotency-Key"
+
+// MetadataKey is the gRPC metadata the idempotency key is forwarded in.
+const MetadataKey = "idempotency-key"
+
+// maxLength bounds the length of the keys, the longer ones being ignored.
+const maxLength = 255
+
+// ForwardKey returns the request with its idempotency key, if any, added to
+// the metadata of the outgoing gRPC calls.
+func ForwardKey(r *http.Request) *http.Request {
+	key := r.Header.Get(Header)
+	if key == "" || len(key) > maxLength {
+		return r
+	}
+	return r.WithContext(metadata.AppendToOutgoingContext(r.Context(), MetadataKey, key))
+}
+
+// GetKey returns the idempotency key of the incoming gRPC call, if any.
+func GetKey(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	vals := md.Get(MetadataKey)
+	if len(vals) == 0 || len(vals[0]) > maxLength {
+		return ""
+	}
+	return vals[0]
+}
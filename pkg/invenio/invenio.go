@@ -0,0 +1,138 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package invenio implements clients depositing files to the repositories
+// based on Invenio, namely Zenodo and InvenioRDM.
+package invenio
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/pkg/errors"
+)
+
+// The flavors of the APIs of the repositories.
+const (
+	// FlavorZenodo is the deposit API of Zenodo.
+	FlavorZenodo = "zenodo"
+	// FlavorRDM is the records API of InvenioRDM.
+	FlavorRDM = "rdm"
+)
+
+// Deposition is a record being deposited to a repository.
+type Deposition struct {
+	ID string `json:"id"`
+	// DOI is the DOI of the record, known once it is published.
+	DOI string `json:"doi,omitempty"`
+	// URL is the landing page of the record.
+	URL string `json:"url,omitempty"`
+
+	// bucket is the URL the files of Zenodo depositions are uploaded to.
+	bucket string
+}
+
+// Client deposits records to a repository.
+type Client interface {
+	// Create creates a draft record with the metadata, which are passed
+	// through to the repository as they are.
+	Create(ctx context.Context, metadata json.RawMessage) (*Deposition, error)
+	// Upload adds a file to the draft record.
+	Upload(ctx context.Context, d *Deposition, name string, r io.Reader, size int64) error
+	// Publish publishes the draft record, setting its DOI.
+	Publish(ctx context.Context, d *Deposition) error
+}
+
+// New returns a client of the repository at the URL, authenticating the
+// requests with the HTTP client, e.g. one obtained through OAuth.
+func New(flavor, url string, client *http.Client) (Client, error) {
+	url = strings.TrimSuffix(url, "/")
+	switch flavor {
+	case FlavorZenodo:
+		return &zenodo{url: url, client: client}, nil
+	case FlavorRDM:
+		return &rdm{url: url, client: client}, nil
+	default:
+		return nil, errtypes.NotSupported("invenio: unknown flavor " + flavor)
+	}
+}
+
+// do sends a request to the repository, decoding the JSON response into res
+// if it is not nil.
+func do(ctx context.Context, client *http.Client, method, url string, body interface{}, res interface{}) error {
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, r)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return send(client, req, res)
+}
+
+func send(client *http.Client, req *http.Request, res interface{}) error {
+	httpRes, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer httpRes.Body.Close()
+
+	if httpRes.StatusCode < 200 || httpRes.StatusCode >= 300 {
+		b, _ := ioutil.ReadAll(io.LimitReader(httpRes.Body, 4096))
+		msg := req.Method + " " + req.URL.Path + ": " + httpRes.Status + ": " + strings.TrimSpace(string(b))
+		switch httpRes.StatusCode {
+		case http.StatusUnauthorized:
+			return errtypes.InvalidCredentials("invenio: " + msg)
+		case http.StatusForbidden:
+			return errtypes.PermissionDenied("invenio: " + msg)
+		case http.StatusBadRequest:
+			return errtypes.BadRequest("invenio: " + msg)
+		default:
+			return errors.New("invenio: " + msg)
+		}
+	}
+	if res == nil {
+		return nil
+	}
+	return errors.Wrap(json.NewDecoder(httpRes.Body).Decode(res), "invenio: error decoding response")
+}
+
+func upload(ctx context.Context, client *http.Client, method, url string, r io.Reader, size int64) error {
+	req, err := http.NewRequestWithContext(ctx, method, url, r)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.ContentLength = size
+	return send(client, req, nil)
+}
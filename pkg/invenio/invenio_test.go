@@ -0,0 +1,79 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package invenio
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestZenodo(t *testing.T) {
+	var srv *httptest.Server
+	uploaded := map[string]string{}
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/deposit/depositions":
+			body := map[string]json.RawMessage{}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil || string(body["metadata"]) != `{"title":"data"}` {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"id": 42, "links": {"bucket": "` + srv.URL + `/api/files/b1", "html": "https://zenodo.org/deposit/42"}}`))
+		case r.Method == http.MethodPut && strings.HasPrefix(r.URL.Path, "/api/files/b1/"):
+			b, _ := ioutil.ReadAll(r.Body)
+			uploaded[strings.TrimPrefix(r.URL.Path, "/api/files/b1/")] = string(b)
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodPost && r.URL.Path == "/api/deposit/depositions/42/actions/publish":
+			w.WriteHeader(http.StatusAccepted)
+			_, _ = w.Write([]byte(`{"id": 42, "doi": "10.5281/zenodo.42", "links": {"html": "https://zenodo.org/record/42"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	ctx := context.Background()
+	c, err := New(FlavorZenodo, srv.URL+"/", srv.Client())
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := c.Create(ctx, json.RawMessage(`{"title":"data"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Upload(ctx, d, "a b.txt", strings.NewReader("hello"), 5); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Publish(ctx, d); err != nil {
+		t.Fatal(err)
+	}
+
+	if uploaded["a b.txt"] != "hello" {
+		t.Errorf("unexpected uploads %v", uploaded)
+	}
+	if d.ID != "42" || d.DOI != "10.5281/zenodo.42" || d.URL != "https://zenodo.org/record/42" {
+		t.Errorf("unexpected deposition %+v", d)
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package invenio
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// rdm is a client of the records API of InvenioRDM, see
+// https://inveniordm.docs.cern.ch/reference/rest_api_index/.
+type rdm struct {
+	url    string
+	client *http.Client
+}
+
+type rdmRecord struct {
+	ID   string `json:"id"`
+	PIDs struct {
+		DOI struct {
+			Identifier string `json:"identifier"`
+		} `json:"doi"`
+	} `json:"pids"`
+	Links struct {
+		SelfHTML string `json:"self_html"`
+	} `json:"links"`
+}
+
+func (c *rdm) Create(ctx context.Context, metadata json.RawMessage) (*Deposition, error) {
+	res := &rdmRecord{}
+	body := map[string]interface{}{
+		"metadata": metadata,
+		"files":    map[string]bool{"enabled": true},
+		"access":   map[string]string{"record": "public", "files": "public"},
+	}
+	if err := do(ctx, c.client, http.MethodPost, c.url+"/api/records", body, res); err != nil {
+		return nil, err
+	}
+	return &Deposition{ID: res.ID, URL: res.Links.SelfHTML}, nil
+}
+
+func (c *rdm) Upload(ctx context.Context, d *Deposition, name string, r io.Reader, size int64) error {
+	files := c.draftURL(d) + "/files"
+	if err := do(ctx, c.client, http.MethodPost, files, []map[string]string{{"key": name}}, nil); err != nil {
+		return err
+	}
+	file := files + "/" + url.PathEscape(name)
+	if err := upload(ctx, c.client, http.MethodPut, file+"/content", r, size); err != nil {
+		return err
+	}
+	return do(ctx, c.client, http.MethodPost, file+"/commit", nil, nil)
+}
+
+func (c *rdm) Publish(ctx context.Context, d *Deposition) error {
+	res := &rdmRecord{}
+	if err := do(ctx, c.client, http.MethodPost, c.draftURL(d)+"/actions/publish", nil, res); err != nil {
+		return err
+	}
+	d.DOI, d.URL = res.PIDs.DOI.Identifier, res.Links.SelfHTML
+	return nil
+}
+
+func (c *rdm) draftURL(d *Deposition) string {
+	return c.url + "/api/records/" + url.PathEscape(d.ID) + "/draft"
+}
@@ -0,0 +1,78 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package invenio
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// zenodo is a client of the deposit API of Zenodo, see
+// https://developers.zenodo.org.
+type zenodo struct {
+	url    string
+	client *http.Client
+}
+
+type zenodoDeposition struct {
+	ID    int    `json:"id"`
+	DOI   string `json:"doi"`
+	Links struct {
+		Bucket string `json:"bucket"`
+		HTML   string `json:"html"`
+	} `json:"links"`
+}
+
+func (z *zenodo) Create(ctx context.Context, metadata json.RawMessage) (*Deposition, error) {
+	res := &zenodoDeposition{}
+	body := map[string]interface{}{"metadata": metadata}
+	if err := do(ctx, z.client, http.MethodPost, z.url+"/api/deposit/depositions", body, res); err != nil {
+		return nil, err
+	}
+	return &Deposition{ID: strconv.Itoa(res.ID), URL: res.Links.HTML, bucket: res.Links.Bucket}, nil
+}
+
+func (z *zenodo) Upload(ctx context.Context, d *Deposition, name string, r io.Reader, size int64) error {
+	if d.bucket == "" {
+		// the bucket of the deposition is only known from its links
+		res := &zenodoDeposition{}
+		if err := do(ctx, z.client, http.MethodGet, z.depositionURL(d), nil, res); err != nil {
+			return err
+		}
+		d.bucket = res.Links.Bucket
+	}
+	return upload(ctx, z.client, http.MethodPut, d.bucket+"/"+url.PathEscape(name), r, size)
+}
+
+func (z *zenodo) Publish(ctx context.Context, d *Deposition) error {
+	res := &zenodoDeposition{}
+	if err := do(ctx, z.client, http.MethodPost, z.depositionURL(d)+"/actions/publish", nil, res); err != nil {
+		return err
+	}
+	d.DOI, d.URL = res.DOI, res.Links.HTML
+	return nil
+}
+
+func (z *zenodo) depositionURL(d *Deposition) string {
+	return z.url + "/api/deposit/depositions/" + url.PathEscape(d.ID)
+}
@@ -0,0 +1,128 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package ipfilter
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// geoDB maps the ranges of addresses to their countries, the addresses being
+// stored in their 16 bytes form so that the ranges can be sorted and searched.
+type geoDB struct {
+	ranges []geoRange
+}
+
+type geoRange struct {
+	first, last net.IP
+	country     string
+}
+
+func loadGeoDB(file string) (*geoDB, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "ipfilter: error opening geoip database")
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	r.Comment = '#'
+	db := &geoDB{}
+	for line := 1; ; line++ {
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "ipfilter: error reading geoip database")
+		}
+		gr, ok, err := parseGeoRange(rec)
+		if err != nil {
+			return nil, fmt.Errorf("ipfilter: geoip database line %d: %v", line, err)
+		}
+		if ok {
+			db.ranges = append(db.ranges, gr)
+		}
+	}
+	sort.Slice(db.ranges, func(i, j int) bool {
+		return bytes.Compare(db.ranges[i].first, db.ranges[j].first) < 0
+	})
+	return db, nil
+}
+
+// parseGeoRange parses a "network,country" or "first,last,country" record,
+// skipping the header and the records without country.
+func parseGeoRange(rec []string) (geoRange, bool, error) {
+	switch {
+	case len(rec) == 2 && strings.Contains(rec[0], "/"):
+		_, n, err := net.ParseCIDR(strings.TrimSpace(rec[0]))
+		if err != nil {
+			return geoRange{}, false, err
+		}
+		first := n.IP.To16()
+		last := make(net.IP, net.IPv6len)
+		mask := n.Mask
+		if len(mask) == net.IPv4len {
+			mask = append(net.CIDRMask(96, 128)[:12], mask...)
+		}
+		for i := range last {
+			last[i] = first[i] | ^mask[i]
+		}
+		return geoRange{first: first, last: last, country: country(rec[1])}, rec[1] != "", nil
+	case len(rec) == 3:
+		first, last := net.ParseIP(strings.TrimSpace(rec[0])), net.ParseIP(strings.TrimSpace(rec[1]))
+		if first == nil || last == nil {
+			// the header of the file.
+			return geoRange{}, false, nil
+		}
+		return geoRange{first: first.To16(), last: last.To16(), country: country(rec[2])}, rec[2] != "", nil
+	case len(rec) == 2:
+		// the header of the file.
+		return geoRange{}, false, nil
+	}
+	return geoRange{}, false, fmt.Errorf("unexpected %d fields", len(rec))
+}
+
+func country(s string) string {
+	return strings.ToUpper(strings.TrimSpace(s))
+}
+
+// lookup returns the country of an address, or an empty string if unknown.
+func (db *geoDB) lookup(ip net.IP) string {
+	ip = ip.To16()
+	i := sort.Search(len(db.ranges), func(i int) bool {
+		return bytes.Compare(db.ranges[i].first, ip) > 0
+	})
+	if i == 0 {
+		return ""
+	}
+	if r := db.ranges[i-1]; bytes.Compare(ip, r.last) <= 0 {
+		return r.country
+	}
+	return ""
+}
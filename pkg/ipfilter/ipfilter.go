@@ -0,0 +1,205 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package ipfilter decides whether the clients are allowed to reach a service
+// from their addresses, with lists of networks and the countries the
+// addresses are located in.
+package ipfilter
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Config configures the addresses allowed to reach a service.
+type Config struct {
+	// Allow is the list of networks, in CIDR notation, or addresses allowed.
+	// If not empty, the other addresses are denied.
+	Allow []string `mapstructure:"allow"`
+	// Deny is the list of networks or addresses denied, taking precedence
+	// over the allowed ones.
+	Deny []string `mapstructure:"deny"`
+	// GeoIPDatabase is a CSV file mapping the networks, as "network,country"
+	// or "first address,last address,country" lines, to the ISO codes of
+	// their countries, like the DB-IP lite country database.
+	GeoIPDatabase string `mapstructure:"geoip_database"`
+	// AllowCountries and DenyCountries are the ISO codes of the countries
+	// allowed and denied. They need the geoip database and do not apply to
+	// the private and loopback addresses.
+	AllowCountries []string `mapstructure:"allow_countries"`
+	DenyCountries  []string `mapstructure:"deny_countries"`
+}
+
+// Filter checks the addresses of the clients.
+type Filter struct {
+	allow, deny    []*net.IPNet
+	geo            *geoDB
+	allowCountries map[string]bool
+	denyCountries  map[string]bool
+}
+
+var privateNets = mustParseNets("10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "fc00::/7", "127.0.0.0/8", "::1/128", "169.254.0.0/16", "fe80::/10")
+
+// New returns a new Filter.
+func New(c *Config) (*Filter, error) {
+	f := &Filter{
+		allowCountries: countries(c.AllowCountries),
+		denyCountries:  countries(c.DenyCountries),
+	}
+	var err error
+	if f.allow, err = parseNets(c.Allow); err != nil {
+		return nil, err
+	}
+	if f.deny, err = parseNets(c.Deny); err != nil {
+		return nil, err
+	}
+	if len(f.allowCountries) > 0 || len(f.denyCountries) > 0 {
+		if c.GeoIPDatabase == "" {
+			return nil, errors.New("ipfilter: the country rules need a geoip database")
+		}
+		if f.geo, err = loadGeoDB(c.GeoIPDatabase); err != nil {
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// Check returns an error saying why the address is not allowed, or nil.
+func (f *Filter) Check(ip net.IP) error {
+	if ip == nil {
+		return errors.New("ipfilter: unknown address")
+	}
+	if contains(f.deny, ip) {
+		return fmt.Errorf("ipfilter: address %s denied", ip)
+	}
+	if len(f.allow) > 0 {
+		if contains(f.allow, ip) {
+			return nil
+		}
+		return fmt.Errorf("ipfilter: address %s not allowed", ip)
+	}
+	if f.geo == nil || contains(privateNets, ip) {
+		return nil
+	}
+
+	country := f.geo.lookup(ip)
+	if f.denyCountries[country] {
+		return fmt.Errorf("ipfilter: country %s of address %s denied", country, ip)
+	}
+	if len(f.allowCountries) > 0 && !f.allowCountries[country] {
+		return fmt.Errorf("ipfilter: country %q of address %s not allowed", country, ip)
+	}
+	return nil
+}
+
+// ParseHost returns the address of a host:port string, or of a plain host.
+func ParseHost(addr string) net.IP {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		host = addr
+	}
+	return net.ParseIP(strings.TrimSpace(host))
+}
+
+// Proxies are the reverse proxies trusted to report the addresses of the
+// clients in a header like X-Forwarded-For.
+type Proxies struct {
+	nets []*net.IPNet
+}
+
+// NewProxies returns the proxies in the given list of networks or addresses.
+func NewProxies(list []string) (*Proxies, error) {
+	nets, err := parseNets(list)
+	if err != nil {
+		return nil, err
+	}
+	return &Proxies{nets: nets}, nil
+}
+
+// ClientIP returns the address of the client of a request received from
+// remoteAddr, carrying the comma separated list of hops in its header. The
+// header is only honoured when the request comes from a trusted proxy, and the
+// right-most hop which is not a trusted proxy is taken, as the hops on its
+// left can be set by the client.
+func (p *Proxies) ClientIP(remoteAddr, header string) net.IP {
+	ip := ParseHost(remoteAddr)
+	if ip == nil || header == "" || !contains(p.nets, ip) {
+		return ip
+	}
+	hops := strings.Split(header, ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip = ParseHost(hops[i])
+		if ip == nil || !contains(p.nets, ip) {
+			return ip
+		}
+	}
+	// every hop is a trusted proxy
+	return ip
+}
+
+func parseNets(list []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(list))
+	for _, s := range list {
+		if !strings.Contains(s, "/") {
+			ip := net.ParseIP(s)
+			if ip == nil {
+				return nil, fmt.Errorf("ipfilter: invalid address %q", s)
+			}
+			bits := 8 * net.IPv6len
+			if ip4 := ip.To4(); ip4 != nil {
+				ip, bits = ip4, 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, errors.Wrapf(err, "ipfilter: invalid network %q", s)
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+func mustParseNets(list ...string) []*net.IPNet {
+	nets, err := parseNets(list)
+	if err != nil {
+		panic(err)
+	}
+	return nets
+}
+
+func contains(nets []*net.IPNet, ip net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func countries(codes []string) map[string]bool {
+	m := make(map[string]bool, len(codes))
+	for _, c := range codes {
+		m[strings.ToUpper(c)] = true
+	}
+	return m
+}
@@ -0,0 +1,95 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package ipfilter
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	db, err := ioutil.TempFile("", "geoip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(db.Name())
+	_, _ = db.WriteString("ip_start,ip_end,country\n137.138.0.0,137.138.255.255,CH\n2001:1458::,2001:1458:ffff:ffff:ffff:ffff:ffff:ffff,CH\n8.8.8.0/24,US\n")
+	db.Close()
+
+	tests := []struct {
+		name string
+		conf Config
+		ip   string
+		ok   bool
+	}{
+		{"no rules", Config{}, "1.2.3.4", true},
+		{"allowed network", Config{Allow: []string{"137.138.0.0/16"}}, "137.138.1.1", true},
+		{"not allowed network", Config{Allow: []string{"137.138.0.0/16"}}, "8.8.8.8", false},
+		{"denied address", Config{Allow: []string{"137.138.0.0/16"}, Deny: []string{"137.138.1.1"}}, "137.138.1.1", false},
+		{"allowed country", Config{GeoIPDatabase: db.Name(), AllowCountries: []string{"ch"}}, "137.138.4.5", true},
+		{"allowed country ipv6", Config{GeoIPDatabase: db.Name(), AllowCountries: []string{"CH"}}, "2001:1458::1", true},
+		{"not allowed country", Config{GeoIPDatabase: db.Name(), AllowCountries: []string{"CH"}}, "8.8.8.8", false},
+		{"unknown country", Config{GeoIPDatabase: db.Name(), AllowCountries: []string{"CH"}}, "1.1.1.1", false},
+		{"private address", Config{GeoIPDatabase: db.Name(), AllowCountries: []string{"CH"}}, "10.0.0.1", true},
+		{"denied country", Config{GeoIPDatabase: db.Name(), DenyCountries: []string{"US"}}, "8.8.8.200", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := New(&tt.conf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := f.Check(net.ParseIP(tt.ip)); (err == nil) != tt.ok {
+				t.Errorf("Check(%s): got %v, expected allowed %t", tt.ip, err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	p, err := NewProxies([]string{"10.0.0.0/8", "192.168.1.1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		header     string
+		expected   string
+	}{
+		{"no header", "10.0.0.1:1234", "", "10.0.0.1"},
+		{"untrusted remote", "8.8.8.8:1234", "137.138.1.1", "8.8.8.8"},
+		{"trusted remote", "10.0.0.1:1234", "137.138.1.1", "137.138.1.1"},
+		{"spoofed hop", "10.0.0.1:1234", "137.138.1.1, 8.8.8.8", "8.8.8.8"},
+		{"trusted hops", "10.0.0.1:1234", "8.8.8.8, 192.168.1.1, 10.0.0.2", "8.8.8.8"},
+		{"only trusted hops", "10.0.0.1:1234", "10.0.0.3, 10.0.0.2", "10.0.0.3"},
+		{"malformed hop", "10.0.0.1:1234", "137.138.1.1, garbage", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ip := p.ClientIP(tt.remoteAddr, tt.header)
+			if (ip == nil && tt.expected != "") || (ip != nil && ip.String() != tt.expected) {
+				t.Errorf("ClientIP(%s, %s): got %v, expected %s", tt.remoteAddr, tt.header, ip, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cs3org/reva/pkg/dlock"
+	"github.com/cs3org/reva/pkg/dlock/driver/registry"
+	"github.com/cs3org/reva/pkg/errtypes"
+)
+
+// Elector elects the replica running each occurrence of a job when several
+// replicas of a service run the same jobs.
+type Elector interface {
+	// Elect returns whether this replica runs the occurrence of the job
+	// scheduled at the given time.
+	Elect(ctx context.Context, job string, at time.Time) (bool, error)
+}
+
+// NewElector returns the elector of the jobs of a service, scoped to the
+// replicas of that service: through the locks configured in the locks
+// section when set, or through the files of the shared lease directory
+// when set, or else a local elector.
+func NewElector(locks map[string]interface{}, leaseDir, scope string) (Elector, error) {
+	switch {
+	case len(locks) > 0:
+		l, err := registry.NewLocker(locks)
+		if err != nil {
+			return nil, err
+		}
+		return NewLockElector(l, scope), nil
+	case leaseDir != "":
+		return NewFileElector(filepath.Join(leaseDir, scope))
+	default:
+		return NewLocalElector(), nil
+	}
+}
+
+type local struct{}
+
+// NewLocalElector returns an Elector for single replica deployments, which
+// always elects the calling replica.
+func NewLocalElector() Elector {
+	return local{}
+}
+
+func (local) Elect(ctx context.Context, job string, at time.Time) (bool, error) {
+	return true, nil
+}
+
+// claimRetention is how long the claims of the past occurrences are kept.
+const claimRetention = time.Hour
+
+type file struct {
+	dir string
+}
+
+// NewFileElector returns an Elector for the replicas sharing a directory,
+// e.g. on the storage they serve. The first replica creating the claim file
+// of an occurrence runs it.
+func NewFileElector(dir string) (Elector, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &file{dir: dir}, nil
+}
+
+func (e *file) Elect(ctx context.Context, job string, at time.Time) (bool, error) {
+	f, err := os.OpenFile(filepath.Join(e.dir, fmt.Sprintf("%s.%d", job, at.Unix())), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		if os.IsExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	host, _ := os.Hostname()
+	_, err = f.WriteString(host)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	e.removeClaims(job, at.Add(-claimRetention))
+	return err == nil, err
+}
+
+// removeClaims removes the claims of the occurrences of the job scheduled
+// before the given time.
+func (e *file) removeClaims(job string, before time.Time) {
+	matches, _ := filepath.Glob(filepath.Join(e.dir, job+".*"))
+	for _, m := range matches {
+		ts, err := strconv.ParseInt(strings.TrimPrefix(filepath.Base(m), job+"."), 10, 64)
+		if err == nil && ts < before.Unix() {
+			_ = os.Remove(m)
+		}
+	}
+}
+
+type locks struct {
+	l     dlock.Locker
+	scope string
+}
+
+// NewLockElector returns an Elector for the replicas sharing a locker. The
+// first replica acquiring the lock of an occurrence runs it. The lock is
+// not released, so that the replicas waking up later skip the occurrence,
+// but expires after an hour.
+func NewLockElector(l dlock.Locker, scope string) Elector {
+	return &locks{l: l, scope: scope}
+}
+
+func (e *locks) Elect(ctx context.Context, job string, at time.Time) (bool, error) {
+	_, err := e.l.TryLock(ctx, fmt.Sprintf("jobs/%s/%s.%d", e.scope, job, at.Unix()), claimRetention)
+	if err != nil {
+		if _, ok := err.(errtypes.IsLocked); ok {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
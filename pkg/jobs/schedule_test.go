@@ -0,0 +1,64 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSchedules(t *testing.T) {
+	// Friday, 13th of January 2023.
+	now := time.Date(2023, 1, 13, 10, 17, 42, 0, time.UTC)
+	tests := []struct {
+		spec string
+		next time.Time
+	}{
+		{"* * * * *", time.Date(2023, 1, 13, 10, 18, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2023, 1, 13, 10, 30, 0, 0, time.UTC)},
+		{"30 3 * * *", time.Date(2023, 1, 14, 3, 30, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", time.Date(2023, 1, 13, 13, 0, 0, 0, time.UTC)},
+		{"0 0 * * 1", time.Date(2023, 1, 16, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1,15 * *", time.Date(2023, 1, 15, 0, 0, 0, 0, time.UTC)},
+		// Either the day of month or the day of week matches.
+		{"0 0 20 * 6", time.Date(2023, 1, 14, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"@every 1h", time.Date(2023, 1, 13, 11, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		s, err := Parse(tt.spec)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.spec, err)
+		}
+		if next := s.Next(now); !next.Equal(tt.next) {
+			t.Errorf("%s: expected %s, got %s", tt.spec, tt.next, next)
+		}
+	}
+
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "5-1 * * * *", "@every 10ms", "@sometimes"} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("%q should not parse", spec)
+		}
+	}
+
+	if s, _ := Parse("0 0 30 2 *"); !s.Next(now).IsZero() {
+		t.Error("an impossible date should have no occurrence")
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package jobs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/rs/zerolog"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+var (
+	jobKey    = tag.MustNewKey("job")
+	resultKey = tag.MustNewKey("result")
+
+	runs            = stats.Int64("jobs_runs", "Number of runs of the periodic jobs", stats.UnitDimensionless)
+	lastRun         = stats.Int64("jobs_last_run_timestamp_seconds", "Time of the last run of the periodic jobs", stats.UnitSeconds)
+	lastRunDuration = stats.Float64("jobs_last_run_duration_seconds", "Duration of the last run of the periodic jobs", stats.UnitSeconds)
+	lastRunSuccess  = stats.Int64("jobs_last_run_success", "Whether the last run of the periodic jobs succeeded", stats.UnitDimensionless)
+
+	views = []*view.View{
+		{Name: "jobs_runs_total", Measure: runs, Aggregation: view.Count(), TagKeys: []tag.Key{jobKey, resultKey}},
+		{Name: "jobs_last_run_timestamp_seconds", Measure: lastRun, Aggregation: view.LastValue(), TagKeys: []tag.Key{jobKey}},
+		{Name: "jobs_last_run_duration_seconds", Measure: lastRunDuration, Aggregation: view.LastValue(), TagKeys: []tag.Key{jobKey}},
+		{Name: "jobs_last_run_success", Measure: lastRunSuccess, Aggregation: view.LastValue(), TagKeys: []tag.Key{jobKey}},
+	}
+
+	registerViews sync.Once
+	registerErr   error
+)
+
+// Func is the work done by a job.
+type Func func(ctx context.Context) error
+
+type job struct {
+	name     string
+	schedule Schedule
+	fn       Func
+}
+
+// Scheduler runs periodic jobs according to their schedule.
+type Scheduler struct {
+	elector Elector
+	log     *zerolog.Logger
+	jobs    []*job
+}
+
+// NewScheduler returns a scheduler running the occurrences of its jobs
+// the elector elects the replica for. A nil elector runs all of them.
+func NewScheduler(elector Elector, log *zerolog.Logger) (*Scheduler, error) {
+	registerViews.Do(func() {
+		registerErr = view.Register(views...)
+	})
+	if registerErr != nil {
+		return nil, registerErr
+	}
+	if elector == nil {
+		elector = NewLocalElector()
+	}
+	return &Scheduler{elector: elector, log: log}, nil
+}
+
+// Register adds a job to the scheduler. The name has to be unique among the
+// jobs sharing an elector, and the spec is parsed with Parse.
+func (s *Scheduler) Register(name, spec string, fn Func) error {
+	sched, err := Parse(spec)
+	if err != nil {
+		return err
+	}
+	s.jobs = append(s.jobs, &job{name: name, schedule: sched, fn: fn})
+	return nil
+}
+
+// Empty tells whether no job was registered.
+func (s *Scheduler) Empty() bool {
+	return len(s.jobs) == 0
+}
+
+// Run runs the jobs until the stop channel is closed, and returns once the
+// running jobs are done.
+func (s *Scheduler) Run(stop <-chan struct{}) {
+	var wg sync.WaitGroup
+	for _, j := range s.jobs {
+		wg.Add(1)
+		go func(j *job) {
+			defer wg.Done()
+			s.loop(j, stop)
+		}(j)
+	}
+	wg.Wait()
+}
+
+func (s *Scheduler) loop(j *job, stop <-chan struct{}) {
+	for {
+		next := j.schedule.Next(time.Now())
+		if next.IsZero() {
+			s.log.Error().Str("job", j.name).Msg("jobs: the schedule has no next occurrence")
+			return
+		}
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		s.run(j, next)
+	}
+}
+
+func (s *Scheduler) run(j *job, at time.Time) {
+	log := s.log.With().Str("job", j.name).Time("scheduled", at).Logger()
+	ctx := appctx.WithLogger(context.Background(), &log)
+
+	elected, err := s.elector.Elect(ctx, j.name, at)
+	if err != nil {
+		log.Error().Err(err).Msg("jobs: error electing the replica running the job")
+		return
+	}
+	if !elected {
+		log.Debug().Msg("jobs: the job is run by another replica")
+		return
+	}
+
+	start := time.Now()
+	err = j.fn(ctx)
+	duration := time.Since(start)
+
+	result, success := "success", int64(1)
+	if err != nil {
+		result, success = "failure", 0
+		log.Error().Err(err).Dur("duration", duration).Msg("jobs: job failed")
+	} else {
+		log.Debug().Dur("duration", duration).Msg("jobs: job done")
+	}
+	if tctx, terr := tag.New(ctx, tag.Upsert(jobKey, j.name), tag.Upsert(resultKey, result)); terr == nil {
+		stats.Record(tctx, runs.M(1), lastRun.M(start.Unix()), lastRunDuration.M(duration.Seconds()), lastRunSuccess.M(success))
+	}
+}
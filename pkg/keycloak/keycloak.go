@@ -0,0 +1,242 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package keycloak implements a client for the admin REST API of Keycloak,
+// used by the user and group managers backed by a Keycloak realm.
+package keycloak
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/rhttp"
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// Config holds the configuration of the client.
+type Config struct {
+	// BaseURL is the URL of the Keycloak server, e.g. https://keycloak.example.org/auth
+	BaseURL string `mapstructure:"base_url"`
+	// Realm is the realm holding the users and groups.
+	Realm string `mapstructure:"realm"`
+	// ClientID and ClientSecret are the credentials of a confidential client
+	// whose service account has the view-users role of the realm-management client.
+	ClientID     string `mapstructure:"client_id"`
+	ClientSecret string `mapstructure:"client_secret"`
+	Insecure     bool   `mapstructure:"insecure"`
+	// UIDAttribute and GIDAttribute are the user attributes holding the
+	// numeric uid and gid of the users.
+	UIDAttribute string `mapstructure:"uid_attribute"`
+	GIDAttribute string `mapstructure:"gid_attribute"`
+	// GIDNumberAttribute is the group attribute holding the numeric gid of the groups.
+	GIDNumberAttribute string `mapstructure:"gid_number_attribute"`
+	// QuotaAttribute, if set, is the user and group attribute holding their
+	// quota in bytes.
+	QuotaAttribute string `mapstructure:"quota_attribute"`
+}
+
+// Init sets the defaults of the configuration.
+func (c *Config) Init() {
+	c.BaseURL = strings.TrimSuffix(c.BaseURL, "/")
+	if c.UIDAttribute == "" {
+		c.UIDAttribute = "uidNumber"
+	}
+	if c.GIDAttribute == "" {
+		c.GIDAttribute = "gidNumber"
+	}
+	if c.GIDNumberAttribute == "" {
+		c.GIDNumberAttribute = "gidNumber"
+	}
+}
+
+// User is the representation of a user in the admin API.
+type User struct {
+	ID            string              `json:"id"`
+	Username      string              `json:"username"`
+	Email         string              `json:"email"`
+	EmailVerified bool                `json:"emailVerified"`
+	FirstName     string              `json:"firstName"`
+	LastName      string              `json:"lastName"`
+	Enabled       bool                `json:"enabled"`
+	Attributes    map[string][]string `json:"attributes"`
+}
+
+// DisplayName returns the full name of the user.
+func (u *User) DisplayName() string {
+	name := strings.TrimSpace(u.FirstName + " " + u.LastName)
+	if name == "" {
+		return u.Username
+	}
+	return name
+}
+
+// Group is the representation of a group in the admin API.
+type Group struct {
+	ID         string              `json:"id"`
+	Name       string              `json:"name"`
+	Path       string              `json:"path"`
+	Attributes map[string][]string `json:"attributes"`
+	SubGroups  []*Group            `json:"subGroups"`
+}
+
+// Attribute returns the first value of an attribute, if set.
+func Attribute(attrs map[string][]string, name string) string {
+	if v := attrs[name]; len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+// Client queries the admin API of a realm.
+type Client struct {
+	conf   *Config
+	client *http.Client
+}
+
+// New returns a client authenticating with the client credentials flow.
+// The access tokens are cached and renewed when they expire.
+func New(c *Config) *Client {
+	cc := &clientcredentials.Config{
+		ClientID:     c.ClientID,
+		ClientSecret: c.ClientSecret,
+		TokenURL:     fmt.Sprintf("%s/realms/%s/protocol/openid-connect/token", c.BaseURL, c.Realm),
+	}
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, rhttp.GetHTTPClient(
+		rhttp.Timeout(10*time.Second),
+		rhttp.Insecure(c.Insecure),
+	))
+	return &Client{conf: c, client: cc.Client(ctx)}
+}
+
+func (c *Client) get(ctx context.Context, path string, query url.Values, v interface{}) error {
+	u := fmt.Sprintf("%s/admin/realms/%s/%s", c.conf.BaseURL, c.conf.Realm, path)
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	res, err := c.client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "keycloak: error sending request")
+	}
+	defer res.Body.Close()
+
+	switch {
+	case res.StatusCode == http.StatusNotFound:
+		return errtypes.NotFound(path)
+	case res.StatusCode != http.StatusOK:
+		body, _ := ioutil.ReadAll(res.Body)
+		return errors.Errorf("keycloak: unexpected status %d: %s", res.StatusCode, string(body))
+	}
+
+	if err := json.NewDecoder(res.Body).Decode(v); err != nil {
+		return errors.Wrap(err, "keycloak: error decoding response")
+	}
+	return nil
+}
+
+// GetUser returns the user with the given id.
+func (c *Client) GetUser(ctx context.Context, id string) (*User, error) {
+	u := &User{}
+	if err := c.get(ctx, "users/"+url.PathEscape(id), nil, u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// FindUsers returns the users matching the query, e.g. username=einstein&exact=true
+// or search=ein.
+func (c *Client) FindUsers(ctx context.Context, query url.Values) ([]*User, error) {
+	users := []*User{}
+	if err := c.get(ctx, "users", query, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// GetUserGroups returns the groups the user with the given id is member of.
+func (c *Client) GetUserGroups(ctx context.Context, id string) ([]*Group, error) {
+	groups := []*Group{}
+	if err := c.get(ctx, "users/"+url.PathEscape(id)+"/groups", nil, &groups); err != nil {
+		return nil, err
+	}
+	return groups, nil
+}
+
+// GetGroup returns the group with the given id.
+func (c *Client) GetGroup(ctx context.Context, id string) (*Group, error) {
+	g := &Group{}
+	if err := c.get(ctx, "groups/"+url.PathEscape(id), nil, g); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// FindGroups returns the groups, including the subgroups, whose name contains the query.
+func (c *Client) FindGroups(ctx context.Context, search string) ([]*Group, error) {
+	tree := []*Group{}
+	if err := c.get(ctx, "groups", url.Values{"search": {search}, "briefRepresentation": {"false"}}, &tree); err != nil {
+		return nil, err
+	}
+
+	// the search returns the tree of groups leading to the matches
+	groups := []*Group{}
+	var walk func([]*Group)
+	walk = func(gs []*Group) {
+		for _, g := range gs {
+			if strings.Contains(strings.ToLower(g.Name), strings.ToLower(search)) {
+				groups = append(groups, g)
+			}
+			walk(g.SubGroups)
+		}
+	}
+	walk(tree)
+	return groups, nil
+}
+
+// GetGroupMembers returns the members of the group with the given id.
+func (c *Client) GetGroupMembers(ctx context.Context, id string) ([]*User, error) {
+	members := []*User{}
+	first := 0
+	const max = 100
+	for {
+		page := []*User{}
+		q := url.Values{"first": {fmt.Sprint(first)}, "max": {fmt.Sprint(max)}}
+		if err := c.get(ctx, "groups/"+url.PathEscape(id)+"/members", q, &page); err != nil {
+			return nil, err
+		}
+		members = append(members, page...)
+		if len(page) < max {
+			return members, nil
+		}
+		first += max
+	}
+}
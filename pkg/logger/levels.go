@@ -0,0 +1,77 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package logger
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+// serviceLevels holds the log levels overriding the configured one for the
+// requests handled by a given service.
+var serviceLevels = struct {
+	sync.RWMutex
+	m map[string]zerolog.Level
+}{m: map[string]zerolog.Level{}}
+
+// SetServiceLevel overrides the log level of the requests handled by the
+// given service.
+func SetServiceLevel(service, lvl string) error {
+	zlvl, err := zerolog.ParseLevel(lvl)
+	if err != nil || lvl == "" {
+		return errors.Errorf("logger: invalid log level %q", lvl)
+	}
+	serviceLevels.Lock()
+	serviceLevels.m[service] = zlvl
+	serviceLevels.Unlock()
+	return nil
+}
+
+// ResetServiceLevel removes the log level override of the given service.
+func ResetServiceLevel(service string) {
+	serviceLevels.Lock()
+	delete(serviceLevels.m, service)
+	serviceLevels.Unlock()
+}
+
+// ServiceLevels returns the log level overrides, per service.
+func ServiceLevels() map[string]string {
+	serviceLevels.RLock()
+	defer serviceLevels.RUnlock()
+	levels := make(map[string]string, len(serviceLevels.m))
+	for s, l := range serviceLevels.m {
+		levels[s] = l.String()
+	}
+	return levels
+}
+
+// ForService returns the logger to be used for the requests handled by the
+// given service, with the level overridden if an override is set.
+func ForService(l zerolog.Logger, service string) zerolog.Logger {
+	serviceLevels.RLock()
+	lvl, ok := serviceLevels.m[service]
+	serviceLevels.RUnlock()
+	l = l.With().Str("service", service).Logger()
+	if ok {
+		l = l.Level(lvl)
+	}
+	return l
+}
@@ -0,0 +1,78 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package maintenance keeps the maintenance modes of a deployment and of its
+// storage providers, during which the mutating operations are refused while
+// the reads keep working.
+package maintenance
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Global is the key of the maintenance mode of the whole deployment.
+const Global = "*"
+
+// Mode is a maintenance in progress.
+type Mode struct {
+	// Message is shown to the users whose operations are refused.
+	Message string    `json:"message"`
+	Since   time.Time `json:"since"`
+	// Address is the address of the storage provider in maintenance, for
+	// the gateway to recognize it when it was resolved from a path.
+	Address string `json:"address,omitempty"`
+}
+
+// Manager is the interface to implement to store the maintenance modes.
+type Manager interface {
+	// GetModes returns the maintenances in progress, keyed by storage
+	// provider id, the one of the deployment having the Global key.
+	GetModes(ctx context.Context) (map[string]*Mode, error)
+	// SetMode starts the maintenance of the storage provider, or of the
+	// deployment, or ends it if the mode is nil.
+	SetMode(ctx context.Context, key string, mode *Mode) error
+}
+
+// mutatingVerbs prefix the names of the CS3 methods changing the state of
+// the services.
+var mutatingVerbs = []string{
+	"Accept", "Add", "Create", "Delete", "Forward", "Move", "Purge", "Remove",
+	"Restore", "Set", "Touch", "Unset", "Update",
+}
+
+// IsMutating returns whether the gRPC method changes the state of the
+// services, e.g. /cs3.storage.provider.v1beta1.ProviderAPI/Delete. Only the
+// CS3 APIs are considered, so that the internal APIs, among them the one
+// ending the maintenance, are never refused.
+func IsMutating(method string) bool {
+	if !strings.HasPrefix(method, "/cs3.") {
+		return false
+	}
+	name := method[strings.LastIndex(method, "/")+1:]
+	if name == "InitiateFileUpload" {
+		return true
+	}
+	for _, v := range mutatingVerbs {
+		if strings.HasPrefix(name, v) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,39 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package maintenance
+
+import "testing"
+
+func TestIsMutating(t *testing.T) {
+	tests := map[string]bool{
+		"/cs3.gateway.v1beta1.GatewayAPI/Delete":                         true,
+		"/cs3.gateway.v1beta1.GatewayAPI/InitiateFileUpload":             true,
+		"/cs3.gateway.v1beta1.GatewayAPI/CreateShare":                    true,
+		"/cs3.storage.provider.v1beta1.ProviderAPI/SetArbitraryMetadata": true,
+		"/cs3.gateway.v1beta1.GatewayAPI/InitiateFileDownload":           false,
+		"/cs3.gateway.v1beta1.GatewayAPI/Stat":                           false,
+		"/cs3.gateway.v1beta1.GatewayAPI/ListContainer":                  false,
+		"/revad.adminprovider.AdminAPI/SetMaintenance":                   false,
+	}
+	for method, want := range tests {
+		if got := IsMutating(method); got != want {
+			t.Errorf("IsMutating(%q) = %v, want %v", method, got, want)
+		}
+	}
+}
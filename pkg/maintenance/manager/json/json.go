@@ -0,0 +1,116 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package json
+
+import (
+	"context"
+	"sync"
+
+	"github.com/cs3org/reva/pkg/maintenance"
+	"github.com/cs3org/reva/pkg/maintenance/manager/registry"
+	"github.com/cs3org/reva/pkg/utils/jsonfile"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	registry.Register("json", New)
+}
+
+type config struct {
+	File string `mapstructure:"file"`
+}
+
+func (c *config) init() {
+	if c.File == "" {
+		c.File = "/var/tmp/reva/maintenance.json"
+	}
+}
+
+type manager struct {
+	sync.Mutex
+	c     *config
+	file  *jsonfile.File
+	modes map[string]*maintenance.Mode
+}
+
+// New returns a maintenance manager storing the modes in a JSON file. The
+// file is read again when it is modified, so that it can be shared by the
+// services of several processes.
+func New(m map[string]interface{}) (maintenance.Manager, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, errors.Wrap(err, "error decoding conf")
+	}
+	c.init()
+
+	mgr := &manager{c: c, file: jsonfile.New(c.File), modes: map[string]*maintenance.Mode{}}
+	if err := mgr.reload(); err != nil {
+		return nil, err
+	}
+	return mgr, nil
+}
+
+func (m *manager) reload() error {
+	modes := map[string]*maintenance.Mode{}
+	changed, err := m.file.Reload(&modes)
+	if err != nil {
+		return errors.Wrap(err, "maintenance: error reading modes")
+	}
+	if changed {
+		m.modes = modes
+	}
+	return nil
+}
+
+func (m *manager) persist() error {
+	if err := m.file.Persist(m.modes); err != nil {
+		return errors.Wrap(err, "maintenance: error writing modes")
+	}
+	return nil
+}
+
+func (m *manager) GetModes(ctx context.Context) (map[string]*maintenance.Mode, error) {
+	m.Lock()
+	defer m.Unlock()
+	if err := m.reload(); err != nil {
+		return nil, err
+	}
+	modes := make(map[string]*maintenance.Mode, len(m.modes))
+	for k, v := range m.modes {
+		mode := *v
+		modes[k] = &mode
+	}
+	return modes, nil
+}
+
+func (m *manager) SetMode(ctx context.Context, key string, mode *maintenance.Mode) error {
+	m.Lock()
+	defer m.Unlock()
+	if err := m.reload(); err != nil {
+		return err
+	}
+	if mode == nil {
+		delete(m.modes, key)
+	} else {
+		v := *mode
+		m.modes[key] = &v
+	}
+	return m.persist()
+}
@@ -0,0 +1,70 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package memory
+
+import (
+	"context"
+	"sync"
+
+	"github.com/cs3org/reva/pkg/maintenance"
+	"github.com/cs3org/reva/pkg/maintenance/manager/registry"
+)
+
+func init() {
+	registry.Register("memory", New)
+}
+
+type manager struct {
+	sync.RWMutex
+	modes map[string]*maintenance.Mode
+}
+
+// shared is the manager of the process, so that the modes set through the
+// admin provider are seen by the gateway and ocdav services of the same
+// process.
+var shared = &manager{modes: map[string]*maintenance.Mode{}}
+
+// New returns the maintenance manager of the process, which forgets the
+// modes when it stops.
+func New(m map[string]interface{}) (maintenance.Manager, error) {
+	return shared, nil
+}
+
+func (m *manager) GetModes(ctx context.Context) (map[string]*maintenance.Mode, error) {
+	m.RLock()
+	defer m.RUnlock()
+	modes := make(map[string]*maintenance.Mode, len(m.modes))
+	for k, v := range m.modes {
+		mode := *v
+		modes[k] = &mode
+	}
+	return modes, nil
+}
+
+func (m *manager) SetMode(ctx context.Context, key string, mode *maintenance.Mode) error {
+	m.Lock()
+	defer m.Unlock()
+	if mode == nil {
+		delete(m.modes, key)
+		return nil
+	}
+	v := *mode
+	m.modes[key] = &v
+	return nil
+}
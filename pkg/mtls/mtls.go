@@ -0,0 +1,411 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package mtls provides the TLS credentials of the gRPC connections between
+// the services, with the certificates reloaded when their files change and
+// the peers authenticated by their SPIFFE IDs or host names.
+package mtls
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// The modes of the client connections.
+const (
+	ModeInsecure = "insecure"
+	ModeTLS      = "tls"
+	ModeMTLS     = "mtls"
+)
+
+// The client authentications required by the servers.
+const (
+	ClientAuthRequire = "require"
+	ClientAuthVerify  = "verify_if_given"
+	ClientAuthNone    = "none"
+)
+
+// Config configures the certificates of a gRPC server or client.
+type Config struct {
+	CertFile string `mapstructure:"cert_file"`
+	KeyFile  string `mapstructure:"key_file"`
+	// CAFile contains the certificates of the authorities the peers are
+	// verified with. If empty, the system ones are used, which the servers
+	// verifying their clients only accept with AllowedIdentities.
+	CAFile string `mapstructure:"ca_file"`
+	// AllowedIdentities are the identities the peers must present: SPIFFE IDs,
+	// like spiffe://cern.ch/reva/gateway, in the URI SANs of their
+	// certificates, or DNS names. If empty, the clients are only verified
+	// against the CA and the servers against the host name they are dialed at.
+	AllowedIdentities []string `mapstructure:"allowed_identities"`
+	// ReloadInterval is the minimum interval in seconds between two checks
+	// of the files for new certificates.
+	ReloadInterval int `mapstructure:"reload_interval"`
+}
+
+func (c *Config) init() {
+	if c.ReloadInterval == 0 {
+		c.ReloadInterval = 60
+	}
+}
+
+// ServerConfig configures the TLS of a gRPC server.
+type ServerConfig struct {
+	Config `mapstructure:",squash"`
+	// ClientAuth is require to enforce the mutual TLS, verify_if_given to
+	// only verify the clients presenting a certificate, or none.
+	ClientAuth string `mapstructure:"client_auth"`
+}
+
+// Enabled returns whether the server serves TLS.
+func (c *ServerConfig) Enabled() bool {
+	return c.CertFile != ""
+}
+
+// ClientConfig configures the TLS of the gRPC connections to the services.
+type ClientConfig struct {
+	Config `mapstructure:",squash"`
+	// Mode is the default mode of the connections: insecure, tls to only
+	// verify the servers, or mtls to also present the client certificate.
+	Mode string `mapstructure:"mode"`
+	// Endpoints overrides the mode for some addresses, for example to keep
+	// plain connections to the services not migrated yet.
+	Endpoints map[string]string `mapstructure:"endpoints"`
+}
+
+// ModeFor returns the mode of the connections to an endpoint.
+func (c *ClientConfig) ModeFor(endpoint string) string {
+	if m, ok := c.Endpoints[endpoint]; ok {
+		return m
+	}
+	if c.Mode == "" {
+		return ModeInsecure
+	}
+	return c.Mode
+}
+
+// NewServerCredentials returns the credentials of a gRPC server.
+func NewServerCredentials(c *ServerConfig) (credentials.TransportCredentials, error) {
+	conf, err := NewServerTLSConfig(c)
+	if err != nil {
+		return nil, err
+	}
+	return credentials.NewTLS(conf), nil
+}
+
+// NewServerTLSConfig returns the TLS configuration of a server, serving the
+// reloaded certificate and verifying the clients according to ClientAuth.
+func NewServerTLSConfig(c *ServerConfig) (*tls.Config, error) {
+	c.init()
+	if c.ClientAuth == "" {
+		c.ClientAuth = ClientAuthRequire
+	}
+	r, err := newReloader(&c.Config, true)
+	if err != nil {
+		return nil, err
+	}
+
+	conf := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			cert, _ := r.get()
+			return cert, nil
+		},
+	}
+	switch c.ClientAuth {
+	case ClientAuthRequire:
+		// the chain is verified against the reloaded roots below.
+		conf.ClientAuth = tls.RequireAnyClientCert
+	case ClientAuthVerify:
+		conf.ClientAuth = tls.RequestClientCert
+	case ClientAuthNone:
+		conf.ClientAuth = tls.NoClientCert
+		return conf, nil
+	default:
+		return nil, fmt.Errorf("mtls: unknown client auth %q", c.ClientAuth)
+	}
+	if c.CAFile == "" && len(c.AllowedIdentities) == 0 {
+		// any certificate issued by a public authority would be accepted.
+		return nil, fmt.Errorf("mtls: client auth %s requires a ca_file or allowed_identities", c.ClientAuth)
+	}
+	conf.VerifyPeerCertificate = func(raw [][]byte, _ [][]*x509.Certificate) error {
+		if len(raw) == 0 {
+			return nil
+		}
+		return r.verify(raw, x509.ExtKeyUsageClientAuth, "")
+	}
+	return conf, nil
+}
+
+// Client creates the credentials of the connections to the services, sharing
+// the certificates loaded from the configuration.
+type Client struct {
+	conf *ClientConfig
+	r    *reloader
+}
+
+// NewClient returns a new Client.
+func NewClient(c *ClientConfig) (*Client, error) {
+	c.init()
+	modes := values(c.Endpoints)
+	if c.Mode != "" {
+		modes = append(modes, c.Mode)
+	}
+	for _, m := range modes {
+		switch m {
+		case ModeInsecure, ModeTLS:
+		case ModeMTLS:
+			if c.CertFile == "" {
+				return nil, errors.New("mtls: the mtls mode requires a client certificate")
+			}
+		default:
+			return nil, fmt.Errorf("mtls: unknown mode %q", m)
+		}
+	}
+	r, err := newReloader(&c.Config, false)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conf: c, r: r}, nil
+}
+
+// Credentials returns the credentials of the connections to an endpoint, or
+// nil if they are not encrypted.
+func (c *Client) Credentials(endpoint string) credentials.TransportCredentials {
+	mode := c.conf.ModeFor(endpoint)
+	if mode == ModeInsecure {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		host = endpoint
+	}
+
+	conf := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		// the chain and the identity of the server are verified against the
+		// reloaded roots in VerifyPeerCertificate.
+		InsecureSkipVerify: true, // #nosec G402
+		VerifyPeerCertificate: func(raw [][]byte, _ [][]*x509.Certificate) error {
+			return c.r.verify(raw, x509.ExtKeyUsageServerAuth, host)
+		},
+	}
+	if mode == ModeMTLS {
+		conf.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			cert, _ := c.r.get()
+			if cert == nil {
+				return nil, errors.New("mtls: no client certificate configured")
+			}
+			return cert, nil
+		}
+	}
+	return credentials.NewTLS(conf)
+}
+
+// PeerIdentities returns the identities of the peer of a gRPC call: the
+// SPIFFE IDs and DNS names of its verified certificate.
+func PeerIdentities(ctx context.Context) []string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.PeerCertificates) == 0 {
+		return nil
+	}
+	return identities(info.State.PeerCertificates[0])
+}
+
+func identities(cert *x509.Certificate) []string {
+	ids := []string{}
+	for _, u := range cert.URIs {
+		if u.Scheme == "spiffe" {
+			ids = append(ids, u.String())
+		}
+	}
+	return append(ids, cert.DNSNames...)
+}
+
+func values(m map[string]string) []string {
+	v := make([]string, 0, len(m))
+	for _, s := range m {
+		v = append(v, s)
+	}
+	return v
+}
+
+// reloader holds the certificate and the roots loaded from the files of the
+// configuration, reloading them when the files change.
+type reloader struct {
+	conf *Config
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	roots   *x509.CertPool
+	mtimes  []time.Time
+	checked time.Time
+}
+
+func newReloader(c *Config, requireCert bool) (*reloader, error) {
+	if (c.CertFile == "") != (c.KeyFile == "") || (requireCert && c.CertFile == "") {
+		return nil, errors.New("mtls: cert_file and key_file must be configured together")
+	}
+	r := &reloader{conf: c}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *reloader) files() []string {
+	return []string{r.conf.CertFile, r.conf.KeyFile, r.conf.CAFile}
+}
+
+func (r *reloader) load() error {
+	mtimes := make([]time.Time, 0, 3)
+	for _, f := range r.files() {
+		var t time.Time
+		if f != "" {
+			fi, err := os.Stat(f)
+			if err != nil {
+				return errors.Wrap(err, "mtls: error reading certificates")
+			}
+			t = fi.ModTime()
+		}
+		mtimes = append(mtimes, t)
+	}
+
+	var cert *tls.Certificate
+	if r.conf.CertFile != "" {
+		c, err := tls.LoadX509KeyPair(r.conf.CertFile, r.conf.KeyFile)
+		if err != nil {
+			return errors.Wrap(err, "mtls: error loading key pair")
+		}
+		cert = &c
+	}
+
+	var roots *x509.CertPool
+	if r.conf.CAFile != "" {
+		pem, err := ioutil.ReadFile(r.conf.CAFile)
+		if err != nil {
+			return errors.Wrap(err, "mtls: error reading ca file")
+		}
+		roots = x509.NewCertPool()
+		if !roots.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("mtls: no certificate found in %s", r.conf.CAFile)
+		}
+	} else {
+		var err error
+		if roots, err = x509.SystemCertPool(); err != nil {
+			return errors.Wrap(err, "mtls: error loading system roots")
+		}
+	}
+
+	r.cert, r.roots, r.mtimes, r.checked = cert, roots, mtimes, time.Now()
+	return nil
+}
+
+// get returns the current certificate and roots, reloading them first if
+// the files changed since the last check.
+func (r *reloader) get() (*tls.Certificate, *x509.CertPool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if time.Since(r.checked) >= time.Duration(r.conf.ReloadInterval)*time.Second {
+		r.checked = time.Now()
+		if r.changed() {
+			// a failed reload, for example while the files are being
+			// rotated, keeps the previous certificates.
+			if err := r.load(); err != nil {
+				log.Error().Err(err).Msg("mtls: error reloading certificates, keeping the previous ones")
+			} else {
+				log.Info().Msg("mtls: certificates reloaded")
+			}
+		}
+	}
+	return r.cert, r.roots
+}
+
+func (r *reloader) changed() bool {
+	for i, f := range r.files() {
+		if f == "" {
+			continue
+		}
+		fi, err := os.Stat(f)
+		if err != nil || !fi.ModTime().Equal(r.mtimes[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// verify verifies the chain presented by a peer against the current roots,
+// and its identity against the allowed ones or else the host name.
+func (r *reloader) verify(raw [][]byte, usage x509.ExtKeyUsage, host string) error {
+	certs := make([]*x509.Certificate, 0, len(raw))
+	for _, b := range raw {
+		c, err := x509.ParseCertificate(b)
+		if err != nil {
+			return errors.Wrap(err, "mtls: error parsing peer certificate")
+		}
+		certs = append(certs, c)
+	}
+	if len(certs) == 0 {
+		return errors.New("mtls: no peer certificate")
+	}
+
+	_, roots := r.get()
+	opts := x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: x509.NewCertPool(),
+		KeyUsages:     []x509.ExtKeyUsage{usage},
+	}
+	for _, c := range certs[1:] {
+		opts.Intermediates.AddCert(c)
+	}
+	if _, err := certs[0].Verify(opts); err != nil {
+		return errors.Wrap(err, "mtls: error verifying peer certificate")
+	}
+
+	if len(r.conf.AllowedIdentities) == 0 {
+		if host == "" {
+			return nil
+		}
+		return certs[0].VerifyHostname(host)
+	}
+	for _, id := range identities(certs[0]) {
+		for _, allowed := range r.conf.AllowedIdentities {
+			if id == allowed {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("mtls: peer identities %v not allowed", identities(certs[0]))
+}
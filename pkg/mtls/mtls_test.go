@@ -0,0 +1,188 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package mtls
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type issued struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	der  []byte
+}
+
+func issue(t *testing.T, tmpl *x509.Certificate, parent *issued) *issued {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl.SerialNumber = big.NewInt(time.Now().UnixNano())
+	tmpl.NotBefore = time.Now().Add(-time.Hour)
+	tmpl.NotAfter = time.Now().Add(time.Hour)
+	p, signer := tmpl, key
+	if parent != nil {
+		p, signer = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, p, &key.PublicKey, signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &issued{cert: cert, key: key, der: der}
+}
+
+func writePEM(t *testing.T, path, typ string, b []byte) {
+	if err := ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: b}), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mtls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ca := issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "ca"}, IsCA: true, BasicConstraintsValid: true, KeyUsage: x509.KeyUsageCertSign}, nil)
+	id, _ := url.Parse("spiffe://cern.ch/reva/gateway")
+	leaf := issue(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "gateway"},
+		URIs:        []*url.URL{id},
+		DNSNames:    []string{"gateway.cern.ch"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+	}, ca)
+	keyDER, err := x509.MarshalECPrivateKey(leaf.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Config{
+		CertFile:       filepath.Join(dir, "cert.pem"),
+		KeyFile:        filepath.Join(dir, "key.pem"),
+		CAFile:         filepath.Join(dir, "ca.pem"),
+		ReloadInterval: -1,
+	}
+	writePEM(t, c.CertFile, "CERTIFICATE", leaf.der)
+	writePEM(t, c.KeyFile, "EC PRIVATE KEY", keyDER)
+	writePEM(t, c.CAFile, "CERTIFICATE", ca.der)
+
+	r, err := newReloader(c, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.verify([][]byte{leaf.der}, x509.ExtKeyUsageServerAuth, "gateway.cern.ch"); err != nil {
+		t.Errorf("expected the host name to be verified: %v", err)
+	}
+	if err := r.verify([][]byte{leaf.der}, x509.ExtKeyUsageServerAuth, "storage.cern.ch"); err == nil {
+		t.Error("expected an error for another host name")
+	}
+
+	c.AllowedIdentities = []string{"spiffe://cern.ch/reva/gateway"}
+	if err := r.verify([][]byte{leaf.der}, x509.ExtKeyUsageClientAuth, ""); err != nil {
+		t.Errorf("expected the spiffe id to be allowed: %v", err)
+	}
+	c.AllowedIdentities = []string{"spiffe://cern.ch/reva/storage"}
+	if err := r.verify([][]byte{leaf.der}, x509.ExtKeyUsageClientAuth, ""); err == nil {
+		t.Error("expected an error for a spiffe id not allowed")
+	}
+
+	// a certificate of another authority is refused until the ca is rotated.
+	other := issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "other"}, IsCA: true, BasicConstraintsValid: true, KeyUsage: x509.KeyUsageCertSign}, nil)
+	c.AllowedIdentities = nil
+	if err := r.verify([][]byte{other.der}, x509.ExtKeyUsageAny, ""); err == nil {
+		t.Error("expected an error for a certificate of another authority")
+	}
+	writePEM(t, c.CAFile, "CERTIFICATE", other.der)
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(c.CAFile, future, future); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.verify([][]byte{other.der}, x509.ExtKeyUsageAny, ""); err != nil {
+		t.Errorf("expected the reloaded ca to be used: %v", err)
+	}
+}
+
+func TestServerClientAuth(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mtls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ca := issue(t, &x509.Certificate{Subject: pkix.Name{CommonName: "ca"}, IsCA: true, BasicConstraintsValid: true, KeyUsage: x509.KeyUsageCertSign}, nil)
+	leaf := issue(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "gateway"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, ca)
+	keyDER, err := x509.MarshalECPrivateKey(leaf.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile, keyFile, caFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem"), filepath.Join(dir, "ca.pem")
+	writePEM(t, certFile, "CERTIFICATE", leaf.der)
+	writePEM(t, keyFile, "EC PRIVATE KEY", keyDER)
+	writePEM(t, caFile, "CERTIFICATE", ca.der)
+
+	tests := []struct {
+		name       string
+		clientAuth string
+		caFile     string
+		identities []string
+		ok         bool
+	}{
+		{"require with ca", ClientAuthRequire, caFile, nil, true},
+		{"require with identities", ClientAuthRequire, "", []string{"spiffe://cern.ch/reva/gateway"}, true},
+		{"require with system roots", ClientAuthRequire, "", nil, false},
+		{"default with system roots", "", "", nil, false},
+		{"verify with system roots", ClientAuthVerify, "", nil, false},
+		{"none with system roots", ClientAuthNone, "", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &ServerConfig{
+				Config: Config{
+					CertFile:          certFile,
+					KeyFile:           keyFile,
+					CAFile:            tt.caFile,
+					AllowedIdentities: tt.identities,
+				},
+				ClientAuth: tt.clientAuth,
+			}
+			if _, err := NewServerTLSConfig(c); (err == nil) != tt.ok {
+				t.Errorf("got %v, expected success %t", err, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,392 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package notification
+
+import (
+	"bytes"
+	"context"
+	"path"
+	"text/template"
+
+	grouppb "github.com/cs3org/go-cs3apis/cs3/identity/group/v1beta1"
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/auth/scope"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/events"
+	eventsregistry "github.com/cs3org/reva/pkg/events/driver/registry"
+	ocmshare "github.com/cs3org/reva/pkg/ocm/share"
+	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
+	"github.com/cs3org/reva/pkg/sharedconf"
+	"github.com/cs3org/reva/pkg/smtpclient"
+	"github.com/cs3org/reva/pkg/token"
+	tokenregistry "github.com/cs3org/reva/pkg/token/manager/registry"
+	"github.com/google/uuid"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/metadata"
+)
+
+// Template holds the templates of the notifications of a type of event.
+// The templates are evaluated against {{.Actor}} and {{.Recipient}}, the
+// display names of the users, {{.Resource}} and {{.Time}}.
+type Template struct {
+	Subject string `mapstructure:"subject"`
+	Body    string `mapstructure:"body"`
+}
+
+var defaultTemplates = map[string]*Template{
+	ShareReceived: {
+		Subject: "{{.Actor}} shared {{.Resource}} with you",
+		Body:    "Hello {{.Recipient}},\n\n{{.Actor}} shared {{.Resource}} with you.\n",
+	},
+	LinkExpired: {
+		Subject: "Your public link to {{.Resource}} has expired",
+		Body:    "Hello {{.Recipient}},\n\nthe public link to {{.Resource}} has expired and has been removed.\n",
+	},
+	UploadCompleted: {
+		Subject: "{{.Resource}} has been uploaded",
+		Body:    "Hello {{.Recipient}},\n\nthe upload of {{.Resource}} has completed.\n",
+	},
+	CommentMention: {
+		Subject: "{{.Actor}} mentioned you in a comment on {{.Resource}}",
+		Body:    "Hello {{.Recipient}},\n\n{{.Actor}} mentioned you in a comment on {{.Resource}}.\n",
+	},
+	OCMShareAccepted: {
+		Subject: "{{.Actor}} accepted your share of {{.Resource}}",
+		Body:    "Hello {{.Recipient}},\n\n{{.Actor}} accepted your share of {{.Resource}}.\n",
+	},
+	OCMShareDeclined: {
+		Subject: "{{.Actor}} declined your share of {{.Resource}}",
+		Body:    "Hello {{.Recipient}},\n\n{{.Actor}} declined your share of {{.Resource}}.\n",
+	},
+	OCMShareUnshared: {
+		Subject: "{{.Actor}} stopped sharing {{.Resource}} with you",
+		Body:    "Hello {{.Recipient}},\n\n{{.Actor}} stopped sharing {{.Resource}} with you.\n",
+	},
+	OCMSharePermissionsChanged: {
+		Subject: "{{.Actor}} changed your permissions on {{.Resource}}",
+		Body:    "Hello {{.Recipient}},\n\n{{.Actor}} changed your permissions on {{.Resource}}.\n",
+	},
+}
+
+// DispatcherConfig is the configuration of the dispatcher.
+type DispatcherConfig struct {
+	GatewaySvc    string                            `mapstructure:"gatewaysvc"`
+	TokenManager  string                            `mapstructure:"token_manager"`
+	TokenManagers map[string]map[string]interface{} `mapstructure:"token_managers"`
+	// Email enables the delivery of the notifications by email.
+	Email           bool                        `mapstructure:"email"`
+	SMTPCredentials *smtpclient.SMTPCredentials `mapstructure:"smtp_credentials"`
+	// Templates overrides the default templates of the types of events.
+	Templates map[string]*Template `mapstructure:"templates"`
+	// Events configures the bus the events are consumed from.
+	Events map[string]interface{} `mapstructure:"events"`
+}
+
+// ParseDispatcherConfig decodes the configuration of the dispatcher from a map.
+func ParseDispatcherConfig(m map[string]interface{}) (*DispatcherConfig, error) {
+	c := &DispatcherConfig{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, errors.Wrap(err, "notification: error decoding conf")
+	}
+	if c.TokenManager == "" {
+		c.TokenManager = "jwt"
+	}
+	c.GatewaySvc = sharedconf.GetGatewaySVC(c.GatewaySvc)
+	return c, nil
+}
+
+type templates struct {
+	subject, body *template.Template
+}
+
+// Dispatcher turns the events into notifications for the users concerned.
+type Dispatcher struct {
+	c         *DispatcherConfig
+	m         Manager
+	tm        token.Manager
+	stream    events.Consumer
+	smtp      *smtpclient.SMTPCredentials
+	templates map[string]*templates
+	log       *zerolog.Logger
+}
+
+// NewDispatcher returns a dispatcher storing the notifications in m.
+func NewDispatcher(c *DispatcherConfig, m Manager, log *zerolog.Logger) (*Dispatcher, error) {
+	f, ok := tokenregistry.NewFuncs[c.TokenManager]
+	if !ok {
+		return nil, errtypes.NotFound("notification: token manager does not exist: " + c.TokenManager)
+	}
+	tm, err := f(c.TokenManagers[c.TokenManager])
+	if err != nil {
+		return nil, errors.Wrap(err, "notification: error creating token manager")
+	}
+
+	stream, err := eventsregistry.NewStream(c.Events)
+	if err != nil {
+		return nil, errors.Wrap(err, "notification: error creating events stream")
+	}
+
+	d := &Dispatcher{c: c, m: m, tm: tm, stream: stream, templates: map[string]*templates{}, log: log}
+	if c.Email {
+		if c.SMTPCredentials == nil {
+			return nil, errors.New("notification: email enabled without smtp credentials")
+		}
+		d.smtp = smtpclient.NewSMTPCredentials(c.SMTPCredentials)
+	}
+
+	for typ, def := range defaultTemplates {
+		t := def
+		if custom, ok := c.Templates[typ]; ok {
+			t = custom
+		}
+		subject, err := template.New(typ + "_subject").Parse(t.Subject)
+		if err != nil {
+			return nil, errors.Wrapf(err, "notification: error parsing subject template of %s", typ)
+		}
+		body, err := template.New(typ + "_body").Parse(t.Body)
+		if err != nil {
+			return nil, errors.Wrapf(err, "notification: error parsing body template of %s", typ)
+		}
+		d.templates[typ] = &templates{subject: subject, body: body}
+	}
+	return d, nil
+}
+
+// Run dispatches the events consumed from the bus until stop is closed.
+func (d *Dispatcher) Run(stop <-chan struct{}) {
+	ctx, cancel := context.WithCancel(appctx.WithLogger(context.Background(), d.log))
+	defer cancel()
+	go func() {
+		<-stop
+		cancel()
+	}()
+
+	evs, err := events.Consume(ctx, d.stream, "notifications", events.ShareCreated{}, events.FileUploaded{}, events.LinkExpired{}, events.CommentCreated{}, events.OCMShareNotified{})
+	if err != nil {
+		d.log.Error().Err(err).Msg("notification: error consuming events")
+		return
+	}
+	for e := range evs {
+		for _, ev := range toEvents(e) {
+			if err := d.Dispatch(ctx, ev); err != nil {
+				d.log.Error().Err(err).Str("type", ev.Type).Msg("notification: error dispatching event")
+			}
+		}
+	}
+}
+
+func toEvents(e interface{}) []*Event {
+	switch e := e.(type) {
+	case events.ShareCreated:
+		return []*Event{{
+			Type:           ShareReceived,
+			Recipient:      e.GranteeUserID,
+			RecipientGroup: e.GranteeGroupID,
+			Actor:          e.Sharer,
+			Resource:       e.ResourceName,
+			Time:           e.Time,
+		}}
+	case events.FileUploaded:
+		return []*Event{{
+			Type:      UploadCompleted,
+			Recipient: e.Executant,
+			Resource:  path.Base(e.Path),
+			Time:      e.Time,
+		}}
+	case events.LinkExpired:
+		return []*Event{{
+			Type:      LinkExpired,
+			Recipient: e.Creator,
+			Resource:  e.Name,
+			Time:      e.Time,
+		}}
+	case events.CommentCreated:
+		// every mentioned user gets its own notification
+		evs := make([]*Event, 0, len(e.Mentioned))
+		for _, uid := range e.Mentioned {
+			evs = append(evs, &Event{
+				Type:      CommentMention,
+				Recipient: uid,
+				Actor:     e.Author,
+				Resource:  e.ResourceName,
+				Time:      e.Time,
+			})
+		}
+		return evs
+	case events.OCMShareNotified:
+		typ, ok := ocmNotificationTypes[e.NotificationType]
+		if !ok {
+			return nil
+		}
+		return []*Event{{
+			Type:      typ,
+			Recipient: e.User,
+			ActorName: e.RemoteUser.GetOpaqueId() + "@" + e.RemoteUser.GetIdp(),
+			Resource:  e.ResourceName,
+			Time:      e.Time,
+		}}
+	}
+	return nil
+}
+
+var ocmNotificationTypes = map[string]string{
+	ocmshare.NotificationShareAccepted:      OCMShareAccepted,
+	ocmshare.NotificationShareDeclined:      OCMShareDeclined,
+	ocmshare.NotificationShareUnshared:      OCMShareUnshared,
+	ocmshare.NotificationPermissionsChanged: OCMSharePermissionsChanged,
+}
+
+// Dispatch notifies the users concerned by the event.
+func (d *Dispatcher) Dispatch(ctx context.Context, ev *Event) error {
+	t, ok := d.templates[ev.Type]
+	if !ok {
+		return errtypes.NotSupported("notification: unknown event type " + ev.Type)
+	}
+
+	// the lookups are made on behalf of the user who caused the event or,
+	// in its absence, of the user to notify
+	as := ev.Actor
+	if as == nil {
+		as = ev.Recipient
+	}
+	if as == nil {
+		return errtypes.BadRequest("notification: event without actor nor recipient")
+	}
+	ctx, err := d.userContext(ctx, as)
+	if err != nil {
+		return err
+	}
+
+	client, err := pool.GetGatewayServiceClient(d.c.GatewaySvc)
+	if err != nil {
+		return err
+	}
+
+	recipients := []*userpb.UserId{}
+	if ev.Recipient != nil {
+		recipients = append(recipients, ev.Recipient)
+	}
+	if ev.RecipientGroup != nil {
+		res, err := client.GetMembers(ctx, &grouppb.GetMembersRequest{GroupId: ev.RecipientGroup})
+		if err != nil {
+			return err
+		}
+		if res.Status.Code != rpc.Code_CODE_OK {
+			return errtypes.InternalError(res.Status.Message)
+		}
+		recipients = append(recipients, res.Members...)
+	}
+
+	var actor *userpb.User
+	if ev.Actor != nil {
+		if actor, err = d.getUser(ctx, ev.Actor); err != nil {
+			return err
+		}
+	}
+
+	for _, uid := range recipients {
+		if ev.Actor != nil && uid.OpaqueId == ev.Actor.OpaqueId && uid.Idp == ev.Actor.Idp {
+			continue
+		}
+		u, err := d.getUser(ctx, uid)
+		if err != nil {
+			d.log.Warn().Err(err).Interface("user", uid).Msg("notification: error getting recipient, skipping")
+			continue
+		}
+		if err := d.notify(ctx, t, ev, actor, u); err != nil {
+			d.log.Error().Err(err).Str("user", u.Username).Msg("notification: error notifying user")
+		}
+	}
+	return nil
+}
+
+func (d *Dispatcher) notify(ctx context.Context, t *templates, ev *Event, actor, u *userpb.User) error {
+	data := map[string]interface{}{
+		"Recipient": u.DisplayName,
+		"Resource":  ev.Resource,
+		"Time":      ev.Time,
+	}
+	if actor != nil {
+		data["Actor"] = actor.DisplayName
+	} else if ev.ActorName != "" {
+		data["Actor"] = ev.ActorName
+	}
+
+	var subject, body bytes.Buffer
+	if err := t.subject.Execute(&subject, data); err != nil {
+		return err
+	}
+	if err := t.body.Execute(&body, data); err != nil {
+		return err
+	}
+
+	n := &Notification{
+		ID:       uuid.New().String(),
+		Type:     ev.Type,
+		Subject:  subject.String(),
+		Message:  body.String(),
+		Resource: ev.Resource,
+		Time:     ev.Time,
+	}
+	if actor != nil {
+		n.Actor = actor.Username
+	} else {
+		n.Actor = ev.ActorName
+	}
+	if err := d.m.Add(ctx, u.Id, n); err != nil {
+		return err
+	}
+
+	if d.smtp != nil && u.Mail != "" {
+		return d.smtp.SendMail(u.Mail, n.Subject, n.Message)
+	}
+	return nil
+}
+
+func (d *Dispatcher) userContext(ctx context.Context, uid *userpb.UserId) (context.Context, error) {
+	scopes, err := scope.GetOwnerScope()
+	if err != nil {
+		return nil, err
+	}
+	tkn, err := d.tm.MintToken(ctx, &userpb.User{Id: uid}, scopes)
+	if err != nil {
+		return nil, errors.Wrap(err, "notification: error minting token")
+	}
+	ctx = token.ContextSetToken(ctx, tkn)
+	return metadata.AppendToOutgoingContext(ctx, token.TokenHeader, tkn), nil
+}
+
+func (d *Dispatcher) getUser(ctx context.Context, uid *userpb.UserId) (*userpb.User, error) {
+	client, err := pool.GetGatewayServiceClient(d.c.GatewaySvc)
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.GetUser(ctx, &userpb.GetUserRequest{UserId: uid})
+	if err != nil {
+		return nil, err
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return nil, errtypes.NotFound(uid.OpaqueId)
+	}
+	return res.User, nil
+}
@@ -0,0 +1,184 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package json
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/notification"
+	"github.com/cs3org/reva/pkg/notification/manager/registry"
+	"github.com/cs3org/reva/pkg/utils/jsonfile"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	registry.Register("json", New)
+}
+
+type config struct {
+	File string `mapstructure:"file"`
+	// MaxPerUser is the number of notifications kept for each user.
+	MaxPerUser int `mapstructure:"max_per_user"`
+}
+
+func (c *config) init() {
+	if c.File == "" {
+		c.File = "/var/tmp/reva/notifications.json"
+	}
+	if c.MaxPerUser == 0 {
+		c.MaxPerUser = 200
+	}
+}
+
+type manager struct {
+	sync.Mutex
+	c             *config
+	file          *jsonfile.File
+	notifications map[string][]*notification.Notification
+}
+
+// New returns a notification manager storing the notifications in a JSON file.
+func New(m map[string]interface{}) (notification.Manager, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, errors.Wrap(err, "error decoding conf")
+	}
+	c.init()
+
+	mgr := &manager{c: c, file: jsonfile.New(c.File), notifications: map[string][]*notification.Notification{}}
+	if err := mgr.reload(); err != nil {
+		return nil, err
+	}
+	return mgr, nil
+}
+
+func userKey(uid *userpb.UserId) string {
+	return uid.GetIdp() + "!" + uid.GetOpaqueId()
+}
+
+func (m *manager) reload() error {
+	notifications := map[string][]*notification.Notification{}
+	changed, err := m.file.Reload(&notifications)
+	if err != nil {
+		return errors.Wrap(err, "notification: error reading notifications")
+	}
+	if changed {
+		m.notifications = notifications
+	}
+	return nil
+}
+
+func (m *manager) persist() error {
+	if err := m.file.Persist(m.notifications); err != nil {
+		return errors.Wrap(err, "notification: error writing notifications")
+	}
+	return nil
+}
+
+func (m *manager) find(uid *userpb.UserId, id string) (int, error) {
+	for i, n := range m.notifications[userKey(uid)] {
+		if n.ID == id {
+			return i, nil
+		}
+	}
+	return 0, errtypes.NotFound(id)
+}
+
+func (m *manager) Add(ctx context.Context, uid *userpb.UserId, n *notification.Notification) error {
+	m.Lock()
+	defer m.Unlock()
+	if err := m.reload(); err != nil {
+		return err
+	}
+
+	k := userKey(uid)
+	list := append(m.notifications[k], n)
+	sort.SliceStable(list, func(i, j int) bool { return list[i].Time.After(list[j].Time) })
+	if len(list) > m.c.MaxPerUser {
+		list = list[:m.c.MaxPerUser]
+	}
+	m.notifications[k] = list
+	return m.persist()
+}
+
+func (m *manager) List(ctx context.Context, uid *userpb.UserId) ([]*notification.Notification, error) {
+	m.Lock()
+	defer m.Unlock()
+	if err := m.reload(); err != nil {
+		return nil, err
+	}
+
+	list := make([]*notification.Notification, 0, len(m.notifications[userKey(uid)]))
+	for _, n := range m.notifications[userKey(uid)] {
+		c := *n
+		list = append(list, &c)
+	}
+	return list, nil
+}
+
+func (m *manager) Get(ctx context.Context, uid *userpb.UserId, id string) (*notification.Notification, error) {
+	m.Lock()
+	defer m.Unlock()
+	if err := m.reload(); err != nil {
+		return nil, err
+	}
+
+	i, err := m.find(uid, id)
+	if err != nil {
+		return nil, err
+	}
+	c := *m.notifications[userKey(uid)][i]
+	return &c, nil
+}
+
+func (m *manager) SetRead(ctx context.Context, uid *userpb.UserId, id string, read bool) error {
+	m.Lock()
+	defer m.Unlock()
+	if err := m.reload(); err != nil {
+		return err
+	}
+
+	i, err := m.find(uid, id)
+	if err != nil {
+		return err
+	}
+	m.notifications[userKey(uid)][i].Read = read
+	return m.persist()
+}
+
+func (m *manager) Delete(ctx context.Context, uid *userpb.UserId, id string) error {
+	m.Lock()
+	defer m.Unlock()
+	if err := m.reload(); err != nil {
+		return err
+	}
+
+	i, err := m.find(uid, id)
+	if err != nil {
+		return err
+	}
+	k := userKey(uid)
+	m.notifications[k] = append(m.notifications[k][:i], m.notifications[k][i+1:]...)
+	return m.persist()
+}
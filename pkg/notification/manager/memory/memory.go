@@ -0,0 +1,134 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/notification"
+	"github.com/cs3org/reva/pkg/notification/manager/registry"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	registry.Register("memory", New)
+}
+
+type config struct {
+	// MaxPerUser is the number of notifications kept for each user.
+	MaxPerUser int `mapstructure:"max_per_user"`
+}
+
+func (c *config) init() {
+	if c.MaxPerUser == 0 {
+		c.MaxPerUser = 200
+	}
+}
+
+type manager struct {
+	sync.RWMutex
+	c             *config
+	notifications map[string][]*notification.Notification
+}
+
+// New returns a notification manager keeping the notifications in memory.
+func New(m map[string]interface{}) (notification.Manager, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, errors.Wrap(err, "error decoding conf")
+	}
+	c.init()
+	return &manager{c: c, notifications: map[string][]*notification.Notification{}}, nil
+}
+
+func userKey(uid *userpb.UserId) string {
+	return uid.GetIdp() + "!" + uid.GetOpaqueId()
+}
+
+func (m *manager) Add(ctx context.Context, uid *userpb.UserId, n *notification.Notification) error {
+	m.Lock()
+	defer m.Unlock()
+	k := userKey(uid)
+	list := append(m.notifications[k], n)
+	sort.SliceStable(list, func(i, j int) bool { return list[i].Time.After(list[j].Time) })
+	if len(list) > m.c.MaxPerUser {
+		list = list[:m.c.MaxPerUser]
+	}
+	m.notifications[k] = list
+	return nil
+}
+
+func (m *manager) List(ctx context.Context, uid *userpb.UserId) ([]*notification.Notification, error) {
+	m.RLock()
+	defer m.RUnlock()
+	list := make([]*notification.Notification, 0, len(m.notifications[userKey(uid)]))
+	for _, n := range m.notifications[userKey(uid)] {
+		c := *n
+		list = append(list, &c)
+	}
+	return list, nil
+}
+
+func (m *manager) find(uid *userpb.UserId, id string) (int, error) {
+	for i, n := range m.notifications[userKey(uid)] {
+		if n.ID == id {
+			return i, nil
+		}
+	}
+	return 0, errtypes.NotFound(id)
+}
+
+func (m *manager) Get(ctx context.Context, uid *userpb.UserId, id string) (*notification.Notification, error) {
+	m.RLock()
+	defer m.RUnlock()
+	i, err := m.find(uid, id)
+	if err != nil {
+		return nil, err
+	}
+	c := *m.notifications[userKey(uid)][i]
+	return &c, nil
+}
+
+func (m *manager) SetRead(ctx context.Context, uid *userpb.UserId, id string, read bool) error {
+	m.Lock()
+	defer m.Unlock()
+	i, err := m.find(uid, id)
+	if err != nil {
+		return err
+	}
+	m.notifications[userKey(uid)][i].Read = read
+	return nil
+}
+
+func (m *manager) Delete(ctx context.Context, uid *userpb.UserId, id string) error {
+	m.Lock()
+	defer m.Unlock()
+	i, err := m.find(uid, id)
+	if err != nil {
+		return err
+	}
+	k := userKey(uid)
+	m.notifications[k] = append(m.notifications[k][:i], m.notifications[k][i+1:]...)
+	return nil
+}
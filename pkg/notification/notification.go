@@ -0,0 +1,85 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package notification delivers notifications to the users about the events
+// concerning them, by email and in the web interface.
+package notification
+
+import (
+	"context"
+	"time"
+
+	grouppb "github.com/cs3org/go-cs3apis/cs3/identity/group/v1beta1"
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+)
+
+// Types of the events the users are notified about.
+const (
+	ShareReceived   = "share_received"
+	LinkExpired     = "link_expired"
+	UploadCompleted = "upload_completed"
+	CommentMention  = "comment_mention"
+
+	OCMShareAccepted           = "ocm_share_accepted"
+	OCMShareDeclined           = "ocm_share_declined"
+	OCMShareUnshared           = "ocm_share_unshared"
+	OCMSharePermissionsChanged = "ocm_share_permissions_changed"
+)
+
+// Event is an event the users are notified about.
+type Event struct {
+	Type string
+	// Recipient is the user to notify.
+	Recipient *userpb.UserId
+	// RecipientGroup is the group whose members are notified.
+	RecipientGroup *grouppb.GroupId
+	// Actor is the user who caused the event, if any.
+	Actor *userpb.UserId
+	// ActorName names the actor when it is not one of our users, e.g. a user
+	// of another OCM provider.
+	ActorName string
+	// Resource is the path or name of the resource concerned by the event.
+	Resource string
+	Time     time.Time
+}
+
+// Notification is a notification shown to a user in the web interface.
+type Notification struct {
+	ID       string    `json:"id"`
+	Type     string    `json:"type"`
+	Subject  string    `json:"subject"`
+	Message  string    `json:"message"`
+	Resource string    `json:"resource,omitempty"`
+	Actor    string    `json:"actor,omitempty"`
+	Time     time.Time `json:"time"`
+	Read     bool      `json:"read"`
+}
+
+// Manager is the interface to implement to store the notifications of the users.
+type Manager interface {
+	// Add stores a new notification for the user.
+	Add(ctx context.Context, uid *userpb.UserId, n *Notification) error
+	// List returns the notifications of the user, most recent first.
+	List(ctx context.Context, uid *userpb.UserId) ([]*Notification, error)
+	// Get returns the notification of the user with the given id.
+	Get(ctx context.Context, uid *userpb.UserId, id string) (*Notification, error)
+	// SetRead marks the notification of the user as read or unread.
+	SetRead(ctx context.Context, uid *userpb.UserId, id string, read bool) error
+	// Delete removes the notification of the user.
+	Delete(ctx context.Context, uid *userpb.UserId, id string) error
+}
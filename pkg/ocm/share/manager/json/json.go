@@ -223,6 +223,7 @@ func (m *mgr) Share(ctx context.Context, md *provider.ResourceId, g *ocm.ShareGr
 			return nil, errors.New("json: owner of resource not provided")
 		}
 		userID = owner
+		protection := share.Protection(g.Grantee.GetOpaque())
 		g.Grantee.Opaque = &typespb.Opaque{
 			Map: map[string]*typespb.OpaqueEntry{
 				"token": &typespb.OpaqueEntry{
@@ -231,6 +232,7 @@ func (m *mgr) Share(ctx context.Context, md *provider.ResourceId, g *ocm.ShareGr
 				},
 			},
 		}
+		share.SetProtection(g.Grantee, protection)
 	} else {
 		userID = user.ContextMustGetUser(ctx).GetId()
 	}
@@ -273,36 +275,30 @@ func (m *mgr) Share(ctx context.Context, md *provider.ResourceId, g *ocm.ShareGr
 		if !ok {
 			return nil, errors.New("Could not get token from context")
 		}
-		var protocol []byte
+		protocolName := "webdav"
 		if st == ocm.Share_SHARE_TYPE_TRANSFER {
-			protocol, err = json.Marshal(
-				map[string]interface{}{
-					"name": "datatx",
-					"options": map[string]string{
-						"permissions": pm,
-						"token":       token,
-					},
-				},
-			)
-			if err != nil {
-				err = errors.Wrap(err, "error marshalling protocol data")
-				return nil, err
-			}
-
-		} else {
-			protocol, err = json.Marshal(
-				map[string]interface{}{
-					"name": "webdav",
-					"options": map[string]string{
-						"permissions": pm,
-						"token":       tokenpkg.ContextMustGetToken(ctx),
-					},
-				},
-			)
-			if err != nil {
-				err = errors.Wrap(err, "error marshalling protocol data")
-				return nil, err
-			}
+			protocolName = "datatx"
+		}
+		options := map[string]string{
+			"permissions": pm,
+			"token":       token,
+		}
+		protection := share.Protection(g.Grantee.GetOpaque())
+		if e, ok := protection[share.ExpirationOpaqueKey]; ok {
+			options["expiration"] = string(e.Value)
+		}
+		if e, ok := protection[share.SharedSecretOpaqueKey]; ok {
+			options["sharedSecret"] = string(e.Value)
+		}
+		protocol, err := json.Marshal(
+			map[string]interface{}{
+				"name":    protocolName,
+				"options": options,
+			},
+		)
+		if err != nil {
+			err = errors.Wrap(err, "error marshalling protocol data")
+			return nil, err
 		}
 
 		requestBody := url.Values{
@@ -362,10 +358,18 @@ func (m *mgr) Share(ctx context.Context, md *provider.ResourceId, g *ocm.ShareGr
 		}
 		m.model.Shares[s.Id.OpaqueId] = string(encShare)
 	} else {
-		encShare, err := utils.MarshalProtoV1ToJSON(&ocm.ReceivedShare{
+		rs := &ocm.ReceivedShare{
 			Share: s,
 			State: ocm.ShareState_SHARE_STATE_PENDING,
-		})
+		}
+		if existing := m.findReceivedByKey(key); existing != nil {
+			// the owner sent the share again, eg. to rotate its secret
+			existing.Share.Grantee = g.Grantee
+			existing.Share.Permissions = g.Permissions
+			existing.Share.Mtime = ts
+			rs, s = existing, existing.Share
+		}
+		encShare, err := utils.MarshalProtoV1ToJSON(rs)
 		if err != nil {
 			return nil, err
 		}
@@ -401,6 +405,22 @@ func (m *mgr) getByID(ctx context.Context, id *ocm.ShareId) (*ocm.Share, error)
 	return nil, errtypes.NotFound(id.String())
 }
 
+// findReceivedByKey returns the received share matching the key, if any. The
+// model must be locked.
+func (m *mgr) findReceivedByKey(key *ocm.ShareKey) *ocm.ReceivedShare {
+	for _, s := range m.model.ReceivedShares {
+		var rs ocm.ReceivedShare
+		if err := utils.UnmarshalJSONToProtoV1([]byte(s.(string)), &rs); err != nil {
+			continue
+		}
+		if utils.UserEqual(key.Owner, rs.Share.Owner) &&
+			utils.ResourceEqual(key.ResourceId, rs.Share.ResourceId) && utils.GranteeEqual(key.Grantee, rs.Share.Grantee) {
+			return &rs
+		}
+	}
+	return nil
+}
+
 func (m *mgr) getByKey(ctx context.Context, key *ocm.ShareKey) (*ocm.Share, error) {
 	m.Lock()
 	defer m.Unlock()
@@ -673,3 +693,101 @@ func (m *mgr) UpdateReceivedShare(ctx context.Context, ref *ocm.ShareReference,
 
 	return rs, nil
 }
+
+func (m *mgr) SetShareState(ctx context.Context, key *ocm.ShareKey, secret string, state ocm.ShareState) (*ocm.Share, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	if err := m.model.ReadFile(); err != nil {
+		err = errors.Wrap(err, "error reading model")
+		return nil, err
+	}
+
+	ref := &ocm.ShareReference{Spec: &ocm.ShareReference_Key{Key: key}}
+	for id, v := range m.model.Shares {
+		var s ocm.Share
+		if err := utils.UnmarshalJSONToProtoV1([]byte(v.(string)), &s); err != nil {
+			continue
+		}
+		if !sharesEqual(ref, &s) {
+			continue
+		}
+		if err := share.CheckSecret(s.Grantee, secret); err != nil {
+			return nil, err
+		}
+		share.SetProtection(s.Grantee, map[string]*typespb.OpaqueEntry{
+			share.StateOpaqueKey: {Decoder: "plain", Value: []byte(state.String())},
+		})
+		encShare, err := utils.MarshalProtoV1ToJSON(&s)
+		if err != nil {
+			return nil, err
+		}
+		m.model.Shares[id] = string(encShare)
+		if err := m.model.Save(); err != nil {
+			err = errors.Wrap(err, "error saving model")
+			return nil, err
+		}
+		return &s, nil
+	}
+	return nil, errtypes.NotFound(key.String())
+}
+
+func (m *mgr) UpdateReceivedSharePermissions(ctx context.Context, key *ocm.ShareKey, secret string, p *ocm.SharePermissions) (*ocm.ReceivedShare, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	if err := m.model.ReadFile(); err != nil {
+		err = errors.Wrap(err, "error reading model")
+		return nil, err
+	}
+
+	rs := m.findReceivedByKey(key)
+	if rs == nil {
+		return nil, errtypes.NotFound(key.String())
+	}
+	if err := share.CheckSecret(rs.Share.Grantee, secret); err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UnixNano()
+	rs.Share.Permissions = p
+	rs.Share.Mtime = &typespb.Timestamp{
+		Seconds: uint64(now / 1000000000),
+		Nanos:   uint32(now % 1000000000),
+	}
+	encShare, err := utils.MarshalProtoV1ToJSON(rs)
+	if err != nil {
+		return nil, err
+	}
+	m.model.ReceivedShares[rs.Share.Id.GetOpaqueId()] = string(encShare)
+	if err := m.model.Save(); err != nil {
+		err = errors.Wrap(err, "error saving model")
+		return nil, err
+	}
+	return rs, nil
+}
+
+func (m *mgr) RemoveReceivedShare(ctx context.Context, key *ocm.ShareKey, secret string) (*ocm.ReceivedShare, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	if err := m.model.ReadFile(); err != nil {
+		err = errors.Wrap(err, "error reading model")
+		return nil, err
+	}
+
+	rs := m.findReceivedByKey(key)
+	if rs == nil {
+		return nil, errtypes.NotFound(key.String())
+	}
+	if err := share.CheckSecret(rs.Share.Grantee, secret); err != nil {
+		return nil, err
+	}
+
+	delete(m.model.ReceivedShares, rs.Share.Id.GetOpaqueId())
+	if err := m.model.Save(); err != nil {
+		err = errors.Wrap(err, "error saving model")
+		return nil, err
+	}
+	return rs, nil
+}
@@ -0,0 +1,117 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package share
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+
+	ocmprovider "github.com/cs3org/go-cs3apis/cs3/ocm/provider/v1beta1"
+	"github.com/pkg/errors"
+)
+
+// The types of the notifications exchanged by the providers about the shares,
+// as defined by the OCM specification.
+const (
+	// NotificationShareAccepted and NotificationShareDeclined are sent by the
+	// provider of the grantee to the provider of the owner.
+	NotificationShareAccepted = "SHARE_ACCEPTED"
+	NotificationShareDeclined = "SHARE_DECLINED"
+	// NotificationShareUnshared and NotificationPermissionsChanged are sent by
+	// the provider of the owner to the provider of the grantee.
+	NotificationShareUnshared      = "SHARE_UNSHARED"
+	NotificationPermissionsChanged = "RESHARE_CHANGE_PERMISSION"
+)
+
+// StateOpaqueKey holds, in the opaque of the grantee of a share, the state of
+// the share notified by the provider of the grantee.
+const StateOpaqueKey = "state"
+
+const notificationsEndpoint = "notifications"
+
+// Notification is the body of the requests to the /notifications endpoint of
+// the OCM API.
+type Notification struct {
+	NotificationType string `json:"notificationType"`
+	ResourceType     string `json:"resourceType"`
+	// ProviderID is the id of the shared resource at the provider of its
+	// owner, as sent when creating the share.
+	ProviderID   string               `json:"providerId"`
+	Notification *NotificationDetails `json:"notification"`
+}
+
+// NotificationDetails identifies the share a notification is about, along
+// with the type specific details.
+type NotificationDetails struct {
+	// Owner and ShareWith are the opaque ids of the users at their providers.
+	Owner     string `json:"owner"`
+	ShareWith string `json:"shareWith"`
+	// MeshProvider is the domain of the provider sending the notification.
+	MeshProvider string `json:"meshProvider"`
+	SharedSecret string `json:"sharedSecret,omitempty"`
+	// Permissions are the new OCS permissions of the share.
+	Permissions int    `json:"permissions,omitempty"`
+	Message     string `json:"message,omitempty"`
+}
+
+// SendNotification sends the notification to the OCM endpoint of the
+// provider.
+func SendNotification(ctx context.Context, c *http.Client, pi *ocmprovider.ProviderInfo, n *Notification) error {
+	var endpoint string
+	for _, s := range pi.Services {
+		if s.Endpoint.Type.Name == "OCM" {
+			endpoint = s.Endpoint.Path
+		}
+	}
+	if endpoint == "" {
+		return errors.New("share: ocm endpoint not specified for mesh provider " + pi.Domain)
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return err
+	}
+	u.Path = path.Join(u.Path, notificationsEndpoint)
+
+	body, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "share: error framing notification request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := c.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "share: error sending notification")
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusCreated {
+		b, _ := ioutil.ReadAll(res.Body)
+		return fmt.Errorf("share: error sending notification: %s: %s", res.Status, string(b))
+	}
+	return nil
+}
@@ -20,13 +20,60 @@ package share
 
 import (
 	"context"
+	"crypto/subtle"
+	"strconv"
+	"time"
 
 	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
 	ocmprovider "github.com/cs3org/go-cs3apis/cs3/ocm/provider/v1beta1"
 	ocm "github.com/cs3org/go-cs3apis/cs3/sharing/ocm/v1beta1"
 	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	"github.com/cs3org/reva/pkg/errtypes"
 )
 
+// The optional protection of a share is carried in the opaque of its grantee,
+// next to the token giving access to the shared resource.
+const (
+	// ExpirationOpaqueKey holds the unix time after which the share can no
+	// longer be accessed.
+	ExpirationOpaqueKey = "expiration"
+	// SharedSecretOpaqueKey holds the secret sent along every access to the
+	// shared resource. The owner rotates it by sending the share again.
+	SharedSecretOpaqueKey = "shared_secret"
+)
+
+// Protection returns the opaque entries protecting a share found in o.
+func Protection(o *types.Opaque) map[string]*types.OpaqueEntry {
+	p := map[string]*types.OpaqueEntry{}
+	for _, k := range []string{ExpirationOpaqueKey, SharedSecretOpaqueKey} {
+		if e, ok := o.GetMap()[k]; ok {
+			p[k] = e
+		}
+	}
+	return p
+}
+
+// Expired returns whether the expiration found in o has passed.
+func Expired(o *types.Opaque, now time.Time) bool {
+	e, ok := o.GetMap()[ExpirationOpaqueKey]
+	if !ok {
+		return false
+	}
+	exp, err := strconv.ParseInt(string(e.Value), 10, 64)
+	return err == nil && now.Unix() >= exp
+}
+
+// CheckSecret returns an error if the share granted to g is protected by a
+// shared secret other than secret.
+func CheckSecret(g *provider.Grantee, secret string) error {
+	e, ok := g.GetOpaque().GetMap()[SharedSecretOpaqueKey]
+	if ok && subtle.ConstantTimeCompare(e.Value, []byte(secret)) != 1 {
+		return errtypes.PermissionDenied("share: invalid shared secret")
+	}
+	return nil
+}
+
 // Manager is the interface that manipulates the OCM shares.
 type Manager interface {
 	// Create a new share in fn with the given acl.
@@ -54,4 +101,36 @@ type Manager interface {
 
 	// UpdateReceivedShare updates the received share with share state.
 	UpdateReceivedShare(ctx context.Context, ref *ocm.ShareReference, f *ocm.UpdateReceivedOCMShareRequest_UpdateField) (*ocm.ReceivedShare, error)
+
+	// The following methods apply the notifications of the other providers.
+	// They are called without user in the context, and the shared secret of
+	// the share, if it has one, must match the given one.
+
+	// SetShareState records the state of the share notified by the provider
+	// of its grantee.
+	SetShareState(ctx context.Context, key *ocm.ShareKey, secret string, state ocm.ShareState) (*ocm.Share, error)
+
+	// UpdateReceivedSharePermissions updates the permissions of the received
+	// share changed by its owner.
+	UpdateReceivedSharePermissions(ctx context.Context, key *ocm.ShareKey, secret string, p *ocm.SharePermissions) (*ocm.ReceivedShare, error)
+
+	// RemoveReceivedShare removes the received share unshared by its owner.
+	RemoveReceivedShare(ctx context.Context, key *ocm.ShareKey, secret string) (*ocm.ReceivedShare, error)
+}
+
+// SetProtection adds the entries protecting a share to the opaque of its
+// grantee.
+func SetProtection(g *provider.Grantee, p map[string]*types.OpaqueEntry) {
+	if len(p) == 0 {
+		return
+	}
+	if g.Opaque == nil {
+		g.Opaque = &types.Opaque{}
+	}
+	if g.Opaque.Map == nil {
+		g.Opaque.Map = map[string]*types.OpaqueEntry{}
+	}
+	for k, e := range p {
+		g.Opaque.Map[k] = e
+	}
 }
@@ -0,0 +1,49 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package permission
+
+import (
+	"context"
+
+	"github.com/cs3org/reva/pkg/cache"
+)
+
+type cachedManager struct {
+	Manager
+	cache *cache.CapabilityCache
+}
+
+// NewCachedManager returns a Manager caching the capabilities granted to the
+// roles, which change far less often than they are checked. The roles of
+// the users are still resolved by m on every call.
+func NewCachedManager(m Manager, c *cache.CapabilityCache) Manager {
+	return &cachedManager{Manager: m, cache: c}
+}
+
+func (m *cachedManager) GetCapabilities(ctx context.Context, role string) ([]string, error) {
+	if caps, ok := m.cache.Get(ctx, role); ok {
+		return caps, nil
+	}
+	caps, err := m.Manager.GetCapabilities(ctx, role)
+	if err != nil {
+		return nil, err
+	}
+	_ = m.cache.Set(ctx, role, caps)
+	return caps, nil
+}
@@ -0,0 +1,51 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package registry
+
+import (
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/permission"
+)
+
+// NewFunc is the function that permission managers
+// should register at init time.
+type NewFunc func(map[string]interface{}) (permission.Manager, error)
+
+// NewFuncs is a map containing all the registered permission managers.
+var NewFuncs = map[string]NewFunc{}
+
+// Register registers a new permission manager new function.
+// Not safe for concurrent use. Safe for use from package init.
+func Register(name string, f NewFunc) {
+	NewFuncs[name] = f
+}
+
+// New returns the permission manager configured with the permission_driver
+// and permission_drivers options of a service, or nil when no driver is set,
+// in which case the services fall back to their lists of admins.
+func New(driver string, drivers map[string]map[string]interface{}) (permission.Manager, error) {
+	if driver == "" {
+		return nil, nil
+	}
+	f, ok := NewFuncs[driver]
+	if !ok {
+		return nil, errtypes.NotFound("permission driver not found: " + driver)
+	}
+	return f(drivers[driver])
+}
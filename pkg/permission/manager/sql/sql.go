@@ -0,0 +1,146 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/pkg/permission"
+	"github.com/cs3org/reva/pkg/permission/manager/registry"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+
+	// Provides mysql drivers
+	_ "github.com/go-sql-driver/mysql"
+)
+
+func init() {
+	registry.Register("sql", New)
+}
+
+// The manager expects the following tables:
+//
+//   role_capabilities(role, capability)
+//   role_assignments(role, principal_type, principal)
+//
+// where principal_type is one of user, group or idp, and principal is
+// respectively a username, a group name or an identity provider.
+
+type config struct {
+	DbUsername string `mapstructure:"db_username"`
+	DbPassword string `mapstructure:"db_password"`
+	DbHost     string `mapstructure:"db_host"`
+	DbPort     int    `mapstructure:"db_port"`
+	DbName     string `mapstructure:"db_name"`
+	// DefaultRole is the role of the users without any assigned role.
+	DefaultRole string `mapstructure:"default_role"`
+}
+
+func (c *config) init() {
+	if c.DbPort == 0 {
+		c.DbPort = 3306
+	}
+	if c.DefaultRole == "" {
+		c.DefaultRole = permission.RoleUser
+	}
+}
+
+type manager struct {
+	c  *config
+	db *sql.DB
+}
+
+func parseConfig(m map[string]interface{}) (*config, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		err = errors.Wrap(err, "error decoding conf")
+		return nil, err
+	}
+	c.init()
+	return c, nil
+}
+
+// New returns a permission manager reading the roles and their assignments
+// from a SQL database.
+func New(m map[string]interface{}) (permission.Manager, error) {
+	c, err := parseConfig(m)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s:%d)/%s", c.DbUsername, c.DbPassword, c.DbHost, c.DbPort, c.DbName))
+	if err != nil {
+		return nil, errors.Wrap(err, "sql: error opening connection to the database")
+	}
+
+	return &manager{c: c, db: db}, nil
+}
+
+func (m *manager) GetRoles(ctx context.Context, u *userpb.User) ([]string, error) {
+	query := "SELECT DISTINCT role FROM role_assignments WHERE (principal_type='user' AND principal=?) OR (principal_type='idp' AND principal=?)"
+	params := []interface{}{u.Username, u.Id.GetIdp()}
+	for _, g := range u.Groups {
+		query += " OR (principal_type='group' AND principal=?)"
+		params = append(params, g)
+	}
+
+	rows, err := m.db.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roles []string
+	for rows.Next() {
+		var r string
+		if err := rows.Scan(&r); err != nil {
+			return nil, err
+		}
+		roles = append(roles, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(roles) == 0 {
+		return []string{m.c.DefaultRole}, nil
+	}
+	return roles, nil
+}
+
+func (m *manager) GetCapabilities(ctx context.Context, role string) ([]string, error) {
+	rows, err := m.db.QueryContext(ctx, "SELECT capability FROM role_capabilities WHERE role=?", role)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var capabilities []string
+	for rows.Next() {
+		var c string
+		if err := rows.Scan(&c); err != nil {
+			return nil, err
+		}
+		capabilities = append(capabilities, c)
+	}
+	return capabilities, rows.Err()
+}
@@ -0,0 +1,107 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package static
+
+import (
+	"context"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/pkg/permission"
+	"github.com/cs3org/reva/pkg/permission/manager/registry"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	registry.Register("static", New)
+}
+
+type assignments struct {
+	// Users maps usernames to their roles.
+	Users map[string][]string `mapstructure:"users"`
+	// Groups maps groups to the roles of their members.
+	Groups map[string][]string `mapstructure:"groups"`
+	// Idps maps identity providers to the roles of their users, e.g. to
+	// assign the guest role to the accounts of the guest provider.
+	Idps map[string][]string `mapstructure:"idps"`
+}
+
+type config struct {
+	// Roles maps the roles to their capabilities.
+	Roles       map[string][]string `mapstructure:"roles"`
+	Assignments assignments         `mapstructure:"assignments"`
+	// DefaultRole is the role of the users without any assigned role.
+	DefaultRole string `mapstructure:"default_role"`
+}
+
+func (c *config) init() {
+	if c.DefaultRole == "" {
+		c.DefaultRole = permission.RoleUser
+	}
+	if c.Roles == nil {
+		c.Roles = map[string][]string{
+			permission.RoleAdmin:        {permission.All},
+			permission.RoleSpaceManager: {permission.CreateShare, permission.CreatePublicLink, permission.InviteGuests, "spaces.*"},
+			permission.RoleUser:         {permission.CreateShare, permission.CreatePublicLink, permission.InviteGuests},
+			permission.RoleGuest:        {},
+		}
+	}
+}
+
+type manager struct {
+	c *config
+}
+
+func parseConfig(m map[string]interface{}) (*config, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		err = errors.Wrap(err, "error decoding conf")
+		return nil, err
+	}
+	c.init()
+	return c, nil
+}
+
+// New returns a permission manager reading the roles and their assignments
+// from the configuration.
+func New(m map[string]interface{}) (permission.Manager, error) {
+	c, err := parseConfig(m)
+	if err != nil {
+		return nil, err
+	}
+	return &manager{c: c}, nil
+}
+
+func (m *manager) GetRoles(ctx context.Context, u *userpb.User) ([]string, error) {
+	var roles []string
+	roles = append(roles, m.c.Assignments.Users[u.Username]...)
+	for _, g := range u.Groups {
+		roles = append(roles, m.c.Assignments.Groups[g]...)
+	}
+	roles = append(roles, m.c.Assignments.Idps[u.Id.GetIdp()]...)
+
+	if len(roles) == 0 {
+		return []string{m.c.DefaultRole}, nil
+	}
+	return roles, nil
+}
+
+func (m *manager) GetCapabilities(ctx context.Context, role string) ([]string, error) {
+	return m.c.Roles[role], nil
+}
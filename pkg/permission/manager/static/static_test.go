@@ -0,0 +1,69 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package static
+
+import (
+	"context"
+	"testing"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/pkg/permission"
+)
+
+func TestCheckPermission(t *testing.T) {
+	pm, err := New(map[string]interface{}{
+		"assignments": map[string]interface{}{
+			"users":  map[string]interface{}{"einstein": []string{"admin"}},
+			"groups": map[string]interface{}{"physics-lovers": []string{"spacemanager"}},
+			"idps":   map[string]interface{}{"guests": []string{"guest"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	einstein := &userpb.User{Id: &userpb.UserId{Idp: "localhost"}, Username: "einstein"}
+	marie := &userpb.User{Id: &userpb.UserId{Idp: "localhost"}, Username: "marie", Groups: []string{"physics-lovers"}}
+	richard := &userpb.User{Id: &userpb.UserId{Idp: "localhost"}, Username: "richard"}
+	guest := &userpb.User{Id: &userpb.UserId{Idp: "guests"}, Username: "guest@example.org"}
+
+	tests := []struct {
+		u          *userpb.User
+		capability string
+		want       bool
+	}{
+		{einstein, permission.ManageUsers, true},
+		{marie, permission.CreateSpace, true},
+		{marie, permission.ManageUsers, false},
+		{richard, permission.CreateShare, true},
+		{richard, permission.CreateSpace, false},
+		{guest, permission.CreateShare, false},
+	}
+
+	ctx := context.Background()
+	for _, tt := range tests {
+		got, err := permission.CheckPermission(ctx, pm, tt.u, tt.capability)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("%s %s: got %t, want %t", tt.u.Username, tt.capability, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package permission resolves the capabilities of the users from the named
+// roles assigned to them, so that services can check what a user is allowed
+// to do instead of relying on hard-coded lists of administrators.
+package permission
+
+import (
+	"context"
+	"strings"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+)
+
+// Predefined roles. Deployments are free to define others.
+const (
+	RoleAdmin        = "admin"
+	RoleSpaceManager = "spacemanager"
+	RoleUser         = "user"
+	RoleGuest        = "guest"
+)
+
+// Capabilities checked by the services.
+const (
+	// All grants every capability.
+	All = "*"
+
+	CreatePublicLink = "publiclinks.create"
+	CreateShare      = "shares.create"
+	CreateSpace      = "spaces.create"
+	ManageSpaces     = "spaces.manage"
+	InviteGuests     = "guests.invite"
+	ManageUsers      = "users.manage"
+	ImpersonateUsers = "users.impersonate"
+	DeprovisionUsers = "accounts.deprovision"
+	ManageWebhooks   = "webhooks.manage"
+	ManageRetention  = "retention.manage"
+	ManageRevisions  = "revisions.manage"
+	Administer       = "system.admin"
+)
+
+// Manager is the interface to implement to resolve the roles of the users.
+type Manager interface {
+	// GetRoles returns the roles assigned to the user.
+	GetRoles(ctx context.Context, u *userpb.User) ([]string, error)
+	// GetCapabilities returns the capabilities granted to the role.
+	GetCapabilities(ctx context.Context, role string) ([]string, error)
+}
+
+// GetUserCapabilities returns the capabilities granted to the user by all its roles.
+func GetUserCapabilities(ctx context.Context, m Manager, u *userpb.User) ([]string, error) {
+	roles, err := m.GetRoles(ctx, u)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	capabilities := []string{}
+	for _, r := range roles {
+		caps, err := m.GetCapabilities(ctx, r)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range caps {
+			if !seen[c] {
+				seen[c] = true
+				capabilities = append(capabilities, c)
+			}
+		}
+	}
+	return capabilities, nil
+}
+
+// CheckPermission returns whether the user has been granted the capability.
+func CheckPermission(ctx context.Context, m Manager, u *userpb.User, capability string) (bool, error) {
+	caps, err := GetUserCapabilities(ctx, m, u)
+	if err != nil {
+		return false, err
+	}
+	for _, c := range caps {
+		if Matches(c, capability) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// IsAdmin returns whether the user holds the system.admin capability. It is
+// granted by the permission manager when one is configured, and otherwise to
+// the listed admins and to the members of the listed admin groups, so that
+// all the services exposing admin tasks agree on who the admins are.
+func IsAdmin(ctx context.Context, m Manager, u *userpb.User, admins, adminGroups []string) (bool, error) {
+	if m != nil {
+		return CheckPermission(ctx, m, u, Administer)
+	}
+	for _, a := range admins {
+		if a == u.Username {
+			return true, nil
+		}
+	}
+	for _, g := range u.Groups {
+		for _, a := range adminGroups {
+			if a == g {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// Matches returns whether the granted capability covers the requested one.
+// A granted capability ending with .* covers all the capabilities in its
+// namespace, e.g. spaces.* covers spaces.create.
+func Matches(granted, requested string) bool {
+	if granted == All || granted == requested {
+		return true
+	}
+	if strings.HasSuffix(granted, ".*") {
+		return strings.HasPrefix(requested, strings.TrimSuffix(granted, "*"))
+	}
+	return false
+}
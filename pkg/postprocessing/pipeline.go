@@ -0,0 +1,519 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package postprocessing
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/internal/http/services/datagateway"
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/auth/scope"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/events"
+	eventsregistry "github.com/cs3org/reva/pkg/events/driver/registry"
+	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
+	"github.com/cs3org/reva/pkg/rhttp"
+	"github.com/cs3org/reva/pkg/sharedconf"
+	"github.com/cs3org/reva/pkg/token"
+	tokenregistry "github.com/cs3org/reva/pkg/token/manager/registry"
+	"github.com/cs3org/reva/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/metadata"
+)
+
+// PipelineConfig is the configuration of the pipeline.
+type PipelineConfig struct {
+	GatewaySvc    string                            `mapstructure:"gatewaysvc"`
+	TokenManager  string                            `mapstructure:"token_manager"`
+	TokenManagers map[string]map[string]interface{} `mapstructure:"token_managers"`
+	Insecure      bool                              `mapstructure:"insecure"`
+	// Events configures the bus the upload events are consumed from.
+	Events map[string]interface{} `mapstructure:"events"`
+	// Steps are the names of the steps the uploads go through, in order.
+	Steps []string `mapstructure:"steps"`
+	// Drivers holds the configuration of the steps, by name.
+	Drivers map[string]map[string]interface{} `mapstructure:"drivers"`
+	// Workers is the number of uploads processed concurrently.
+	Workers int `mapstructure:"workers"`
+	// MaxRetries is the number of retries of a failing step, before the
+	// post-processing of the file is marked as failed.
+	MaxRetries int `mapstructure:"max_retries"`
+	// RetryInterval is the time in seconds before the first retry, which
+	// doubles after each failure.
+	RetryInterval int `mapstructure:"retry_interval"`
+	// File is the JSON file the jobs are persisted to.
+	File string `mapstructure:"file"`
+}
+
+// ParsePipelineConfig decodes the configuration of the pipeline from a map.
+func ParsePipelineConfig(m map[string]interface{}) (*PipelineConfig, error) {
+	c := &PipelineConfig{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, errors.Wrap(err, "postprocessing: error decoding conf")
+	}
+	if c.TokenManager == "" {
+		c.TokenManager = "jwt"
+	}
+	if c.Workers == 0 {
+		c.Workers = 4
+	}
+	if c.MaxRetries == 0 {
+		c.MaxRetries = 3
+	}
+	if c.RetryInterval == 0 {
+		c.RetryInterval = 10
+	}
+	if c.File == "" {
+		c.File = "/var/tmp/reva/postprocessing.json"
+	}
+	c.GatewaySvc = sharedconf.GetGatewaySVC(c.GatewaySvc)
+	return c, nil
+}
+
+// NewStepFunc creates the step of the given name from its configuration.
+type NewStepFunc func(name string, m map[string]interface{}) (Step, error)
+
+type namedStep struct {
+	name string
+	step Step
+}
+
+// Pipeline runs the uploaded files through the steps.
+type Pipeline struct {
+	c      *PipelineConfig
+	steps  []namedStep
+	tm     token.Manager
+	stream events.Consumer
+	client *http.Client
+	log    *zerolog.Logger
+
+	mu    sync.Mutex
+	jobs  map[string]*Job
+	queue chan *Job
+}
+
+// NewPipeline returns a pipeline whose steps are created with newStep.
+func NewPipeline(c *PipelineConfig, newStep NewStepFunc, log *zerolog.Logger) (*Pipeline, error) {
+	steps := make([]namedStep, 0, len(c.Steps))
+	for _, name := range c.Steps {
+		s, err := newStep(name, c.Drivers[name])
+		if err != nil {
+			return nil, errors.Wrap(err, "postprocessing: error creating step "+name)
+		}
+		steps = append(steps, namedStep{name: name, step: s})
+	}
+
+	f, ok := tokenregistry.NewFuncs[c.TokenManager]
+	if !ok {
+		return nil, errtypes.NotFound("postprocessing: token manager does not exist: " + c.TokenManager)
+	}
+	tm, err := f(c.TokenManagers[c.TokenManager])
+	if err != nil {
+		return nil, errors.Wrap(err, "postprocessing: error creating token manager")
+	}
+
+	stream, err := eventsregistry.NewStream(c.Events)
+	if err != nil {
+		return nil, errors.Wrap(err, "postprocessing: error creating events stream")
+	}
+
+	p := &Pipeline{
+		c:      c,
+		steps:  steps,
+		tm:     tm,
+		stream: stream,
+		client: rhttp.GetHTTPClient(rhttp.Insecure(c.Insecure)),
+		log:    log,
+		jobs:   map[string]*Job{},
+		queue:  make(chan *Job, 1024),
+	}
+	if err := p.load(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Run processes the files uploaded until stop is closed. The jobs which were
+// still processing when the pipeline was stopped are resumed.
+func (p *Pipeline) Run(stop <-chan struct{}) {
+	ctx, cancel := context.WithCancel(appctx.WithLogger(context.Background(), p.log))
+	defer cancel()
+	go func() {
+		<-stop
+		cancel()
+	}()
+
+	for i := 0; i < p.c.Workers; i++ {
+		go p.work(ctx)
+	}
+	p.mu.Lock()
+	for _, j := range p.jobs {
+		if j.Status == StatusProcessing {
+			p.queue <- j
+		}
+	}
+	p.mu.Unlock()
+
+	evs, err := events.Consume(ctx, p.stream, "postprocessing", events.FileUploaded{})
+	if err != nil {
+		p.log.Error().Err(err).Msg("postprocessing: error consuming events")
+		return
+	}
+	for e := range evs {
+		ev, ok := e.(events.FileUploaded)
+		if !ok || ev.Executant == nil {
+			continue
+		}
+		now := time.Now()
+		j := &Job{
+			ID:         uuid.New().String(),
+			ResourceID: ev.ResourceID,
+			Path:       ev.Path,
+			Executant:  ev.Executant,
+			Status:     StatusProcessing,
+			Created:    now,
+			Updated:    now,
+		}
+		if err := p.save(j); err != nil {
+			p.log.Error().Err(err).Str("path", ev.Path).Msg("postprocessing: error storing job")
+			continue
+		}
+		select {
+		case p.queue <- j:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (p *Pipeline) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case j := <-p.queue:
+			p.process(ctx, j)
+		}
+	}
+}
+
+// ListJobs returns the jobs of the uploads of the given user, most recent
+// first.
+func (p *Pipeline) ListJobs(uid *userpb.UserId) []*Job {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var list []*Job
+	for _, j := range p.jobs {
+		if utils.UserEqual(j.Executant, uid) {
+			list = append(list, j.clone())
+		}
+	}
+	sort.Slice(list, func(i, k int) bool { return list[i].Created.After(list[k].Created) })
+	return list
+}
+
+// GetJob returns the job with the given id.
+func (p *Pipeline) GetJob(id string) (*Job, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	j, ok := p.jobs[id]
+	if !ok {
+		return nil, errtypes.NotFound("postprocessing: job not found: " + id)
+	}
+	return j.clone(), nil
+}
+
+// Retry processes again a failed job, starting from the step which failed.
+func (p *Pipeline) Retry(id string) error {
+	p.mu.Lock()
+	j, ok := p.jobs[id]
+	if !ok {
+		p.mu.Unlock()
+		return errtypes.NotFound("postprocessing: job not found: " + id)
+	}
+	if j.Status != StatusFailed {
+		p.mu.Unlock()
+		return errtypes.BadRequest("postprocessing: only failed jobs can be retried")
+	}
+	j.Status = StatusProcessing
+	j.Error = ""
+	err := p.persist()
+	p.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	p.queue <- j
+	return nil
+}
+
+// process runs the job through the steps which did not succeed yet.
+func (p *Pipeline) process(ctx context.Context, j *Job) {
+	log := p.log.With().Str("job", j.ID).Str("path", j.Path).Logger()
+
+	uctx, err := p.userContext(ctx, j.Executant)
+	if err != nil {
+		p.finish(ctx, j, nil, StatusFailed, err)
+		return
+	}
+	ctx = uctx
+	ref := &provider.Reference{Spec: &provider.Reference_Path{Path: j.Path}}
+	if j.ResourceID != nil {
+		ref = &provider.Reference{Spec: &provider.Reference_Id{Id: j.ResourceID}}
+	}
+	info, err := p.stat(ctx, ref)
+	if err != nil {
+		p.finish(ctx, j, nil, StatusFailed, err)
+		return
+	}
+	p.setStatus(ctx, ref, StatusProcessing)
+
+	f := &File{
+		Info:      info,
+		Executant: j.Executant,
+		Open: func() (io.ReadCloser, error) {
+			return p.download(ctx, ref)
+		},
+	}
+	for _, s := range p.steps {
+		var r *StepResult
+		_ = p.update(j, func() {
+			if r = j.Result(s.name); r == nil {
+				r = &StepResult{Name: s.name}
+				j.Steps = append(j.Steps, r)
+			}
+		})
+		if r.Status == StatusDone {
+			continue
+		}
+		attempts, stepErr := p.runStep(ctx, s, f)
+		if ctx.Err() != nil {
+			// the pipeline is stopping, the job is resumed on restart
+			return
+		}
+		status := StatusDone
+		if _, rejected := stepErr.(Rejection); rejected {
+			status = StatusRejected
+			log.Info().Str("step", s.name).Err(stepErr).Msg("postprocessing: file rejected")
+		} else if stepErr != nil {
+			status = StatusFailed
+			log.Error().Str("step", s.name).Err(stepErr).Msg("postprocessing: step failed")
+		}
+		if err := p.update(j, func() {
+			r.Status, r.Attempts, r.Error, r.Time = status, r.Attempts+attempts, "", time.Now()
+			if stepErr != nil {
+				r.Error = stepErr.Error()
+			}
+		}); err != nil {
+			log.Error().Err(err).Msg("postprocessing: error storing job")
+		}
+		if status != StatusDone {
+			p.finish(ctx, j, ref, status, stepErr)
+			return
+		}
+	}
+	p.finish(ctx, j, ref, StatusDone, nil)
+}
+
+// runStep runs the step, retrying with an exponential backoff unless the
+// file was rejected, and returns the number of attempts.
+func (p *Pipeline) runStep(ctx context.Context, s namedStep, f *File) (int, error) {
+	interval := time.Duration(p.c.RetryInterval) * time.Second
+	for attempts := 1; ; attempts++ {
+		err := s.step.Process(ctx, f)
+		if _, rejected := err.(Rejection); err == nil || rejected || attempts > p.c.MaxRetries {
+			return attempts, err
+		}
+		p.log.Warn().Err(err).Str("step", s.name).Str("path", f.Info.Path).Int("attempt", attempts).Msg("postprocessing: step failed, retrying")
+		select {
+		case <-ctx.Done():
+			return attempts, ctx.Err()
+		case <-time.After(interval):
+		}
+		interval *= 2
+	}
+}
+
+// finish stores the outcome of the job and reports it on the file.
+func (p *Pipeline) finish(ctx context.Context, j *Job, ref *provider.Reference, status Status, jobErr error) {
+	if err := p.update(j, func() {
+		j.Status = status
+		if jobErr != nil {
+			j.Error = jobErr.Error()
+		}
+	}); err != nil {
+		p.log.Error().Err(err).Str("job", j.ID).Msg("postprocessing: error storing job")
+	}
+	if ref != nil {
+		p.setStatus(ctx, ref, status)
+	}
+}
+
+func (p *Pipeline) stat(ctx context.Context, ref *provider.Reference) (*provider.ResourceInfo, error) {
+	client, err := pool.GetGatewayServiceClient(p.c.GatewaySvc)
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.Stat(ctx, &provider.StatRequest{Ref: ref})
+	if err != nil {
+		return nil, err
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return nil, errtypes.InternalError("postprocessing: error statting file: " + res.Status.Message)
+	}
+	return res.Info, nil
+}
+
+// setStatus stores the status of the post-processing in the metadata of the
+// file, where the other services look it up.
+func (p *Pipeline) setStatus(ctx context.Context, ref *provider.Reference, status Status) {
+	client, err := pool.GetGatewayServiceClient(p.c.GatewaySvc)
+	if err == nil {
+		var res *provider.SetArbitraryMetadataResponse
+		res, err = client.SetArbitraryMetadata(ctx, &provider.SetArbitraryMetadataRequest{
+			Ref: ref,
+			ArbitraryMetadata: &provider.ArbitraryMetadata{
+				Metadata: map[string]string{StatusKey: string(status)},
+			},
+		})
+		if err == nil && res.Status.Code != rpc.Code_CODE_OK {
+			err = errtypes.InternalError(res.Status.Message)
+		}
+	}
+	if err != nil {
+		p.log.Error().Err(err).Str("status", string(status)).Msg("postprocessing: error setting status")
+	}
+}
+
+func (p *Pipeline) download(ctx context.Context, ref *provider.Reference) (io.ReadCloser, error) {
+	client, err := pool.GetGatewayServiceClient(p.c.GatewaySvc)
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.InitiateFileDownload(ctx, &provider.InitiateFileDownloadRequest{Ref: ref})
+	if err != nil {
+		return nil, err
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return nil, errtypes.InternalError("postprocessing: error initiating download: " + res.Status.Message)
+	}
+
+	var endpoint, tkn string
+	for _, p := range res.Protocols {
+		if p.Protocol == "simple" {
+			endpoint, tkn = p.DownloadEndpoint, p.Token
+		}
+	}
+	if endpoint == "" {
+		return nil, errtypes.NotSupported("postprocessing: no simple download protocol")
+	}
+
+	req, err := rhttp.NewRequest(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set(datagateway.TokenTransportHeader, tkn)
+	httpRes, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if httpRes.StatusCode != http.StatusOK {
+		httpRes.Body.Close()
+		return nil, errtypes.InternalError("postprocessing: unexpected download status " + httpRes.Status)
+	}
+	return httpRes.Body, nil
+}
+
+func (p *Pipeline) userContext(ctx context.Context, uid *userpb.UserId) (context.Context, error) {
+	scopes, err := scope.GetOwnerScope()
+	if err != nil {
+		return nil, err
+	}
+	tkn, err := p.tm.MintToken(ctx, &userpb.User{Id: uid}, scopes)
+	if err != nil {
+		return nil, errors.Wrap(err, "postprocessing: error minting token")
+	}
+	ctx = token.ContextSetToken(ctx, tkn)
+	return metadata.AppendToOutgoingContext(ctx, token.TokenHeader, tkn), nil
+}
+
+func (p *Pipeline) load() error {
+	b, err := ioutil.ReadFile(p.c.File)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "postprocessing: error reading jobs file")
+	}
+	if len(b) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(b, &p.jobs); err != nil {
+		return errors.Wrap(err, "postprocessing: error decoding jobs file")
+	}
+	return nil
+}
+
+// save stores a new job.
+func (p *Pipeline) save(j *Job) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.jobs[j.ID] = j
+	return p.persist()
+}
+
+// update modifies the job with fn while holding the lock, and persists it.
+func (p *Pipeline) update(j *Job, fn func()) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	fn()
+	j.Updated = time.Now()
+	return p.persist()
+}
+
+// persist writes all the jobs to the file. It must be called with the lock
+// held.
+func (p *Pipeline) persist() error {
+	b, err := json.Marshal(p.jobs)
+	if err != nil {
+		return errors.Wrap(err, "postprocessing: error encoding jobs")
+	}
+	if err := os.MkdirAll(filepath.Dir(p.c.File), 0700); err != nil {
+		return err
+	}
+	tmp := p.c.File + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0600); err != nil {
+		return errors.Wrap(err, "postprocessing: error writing jobs file")
+	}
+	return os.Rename(tmp, p.c.File)
+}
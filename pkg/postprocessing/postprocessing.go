@@ -0,0 +1,116 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package postprocessing runs the finalized uploads through a pipeline of
+// configurable steps, e.g. a virus scan, before the files are made available
+// for download.
+package postprocessing
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+)
+
+// StatusKey is the arbitrary metadata key the post-processing status of the
+// files is stored under.
+const StatusKey = "postprocessing"
+
+// Status is the status of the post-processing of a file.
+type Status string
+
+// The statuses of the post-processing. A file is processing until all the
+// steps succeeded; it is rejected if a step refused it, in which case it
+// cannot be downloaded, and failed if a step kept failing after its retries.
+const (
+	StatusProcessing Status = "processing"
+	StatusDone       Status = "done"
+	StatusFailed     Status = "failed"
+	StatusRejected   Status = "rejected"
+)
+
+// File is the uploaded file passed to the steps.
+type File struct {
+	Info      *provider.ResourceInfo
+	Executant *userpb.UserId
+	// Open returns a reader of the content of the file.
+	Open func() (io.ReadCloser, error)
+}
+
+// Step is the interface to implement for a post-processing step.
+type Step interface {
+	// Process processes the file. Returning a Rejection stops the pipeline
+	// and marks the file as rejected, while other errors are retried.
+	Process(ctx context.Context, f *File) error
+}
+
+// Rejection is the error returned by the steps refusing a file.
+type Rejection string
+
+func (r Rejection) Error() string { return "rejected: " + string(r) }
+
+// Rejectf returns a rejection with the formatted reason.
+func Rejectf(format string, a ...interface{}) Rejection {
+	return Rejection(fmt.Sprintf(format, a...))
+}
+
+// StepResult is the outcome of a step of a job.
+type StepResult struct {
+	Name     string    `json:"name"`
+	Status   Status    `json:"status"`
+	Attempts int       `json:"attempts"`
+	Error    string    `json:"error,omitempty"`
+	Time     time.Time `json:"time"`
+}
+
+// Job is the post-processing of an upload.
+type Job struct {
+	ID         string               `json:"id"`
+	ResourceID *provider.ResourceId `json:"resource_id"`
+	Path       string               `json:"path"`
+	Executant  *userpb.UserId       `json:"executant"`
+	Status     Status               `json:"status"`
+	Steps      []*StepResult        `json:"steps"`
+	Error      string               `json:"error,omitempty"`
+	Created    time.Time            `json:"created"`
+	Updated    time.Time            `json:"updated"`
+}
+
+// Result returns the result of the named step, if it already ran.
+func (j *Job) Result(name string) *StepResult {
+	for _, r := range j.Steps {
+		if r.Name == name {
+			return r
+		}
+	}
+	return nil
+}
+
+func (j *Job) clone() *Job {
+	c := *j
+	c.Steps = make([]*StepResult, 0, len(j.Steps))
+	for _, r := range j.Steps {
+		rc := *r
+		c.Steps = append(c.Steps, &rc)
+	}
+	return &c
+}
@@ -0,0 +1,74 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package checksum
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"encoding/hex"
+	"hash"
+	"hash/adler32"
+	"io"
+
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/postprocessing"
+	"github.com/cs3org/reva/pkg/postprocessing/step/registry"
+)
+
+func init() {
+	registry.Register("checksum", New)
+}
+
+type step struct{}
+
+// New returns a step computing the checksum of the files, and rejecting the
+// ones whose checksum does not match the one reported by the storage.
+func New(m map[string]interface{}) (postprocessing.Step, error) {
+	return &step{}, nil
+}
+
+func (s *step) Process(ctx context.Context, f *postprocessing.File) error {
+	xs := f.Info.GetChecksum()
+	var h hash.Hash
+	switch xs.GetType() {
+	case provider.ResourceChecksumType_RESOURCE_CHECKSUM_TYPE_SHA1:
+		h = sha1.New()
+	case provider.ResourceChecksumType_RESOURCE_CHECKSUM_TYPE_MD5:
+		h = md5.New()
+	case provider.ResourceChecksumType_RESOURCE_CHECKSUM_TYPE_ADLER32:
+		h = adler32.New()
+	default:
+		// the storage does not compute checksums
+		return nil
+	}
+
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	if _, err := io.Copy(h, r); err != nil {
+		return err
+	}
+	if computed := hex.EncodeToString(h.Sum(nil)); computed != xs.Sum {
+		return postprocessing.Rejectf("checksum mismatch: expected %s, computed %s", xs.Sum, computed)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package event
+
+import (
+	"context"
+	"time"
+
+	"github.com/cs3org/reva/pkg/events"
+	eventsregistry "github.com/cs3org/reva/pkg/events/driver/registry"
+	"github.com/cs3org/reva/pkg/postprocessing"
+	"github.com/cs3org/reva/pkg/postprocessing/step/registry"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	registry.Register("event", New)
+}
+
+type config struct {
+	// Events configures the bus the events are published to.
+	Events map[string]interface{} `mapstructure:"events"`
+}
+
+type step struct {
+	publisher events.Publisher
+}
+
+// New returns a step publishing an UploadProcessed event.
+func New(m map[string]interface{}) (postprocessing.Step, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, errors.Wrap(err, "event: error decoding conf")
+	}
+	stream, err := eventsregistry.NewStream(c.Events)
+	if err != nil {
+		return nil, errors.Wrap(err, "event: error creating events stream")
+	}
+	return &step{publisher: stream}, nil
+}
+
+func (s *step) Process(ctx context.Context, f *postprocessing.File) error {
+	return events.Publish(ctx, s.publisher, events.UploadProcessed{
+		Executant:  f.Executant,
+		ResourceID: f.Info.Id,
+		Path:       f.Info.Path,
+		Time:       time.Now(),
+	})
+}
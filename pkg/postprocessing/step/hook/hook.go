@@ -0,0 +1,128 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package hook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/postprocessing"
+	"github.com/cs3org/reva/pkg/postprocessing/step/registry"
+	"github.com/cs3org/reva/pkg/rhttp"
+	"github.com/cs3org/reva/pkg/webhook"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	registry.Register("hook", New)
+}
+
+type config struct {
+	URL string `mapstructure:"url"`
+	// Secret, if set, is used to sign the requests as the webhooks are.
+	Secret   string `mapstructure:"secret"`
+	Timeout  int    `mapstructure:"timeout"`
+	Insecure bool   `mapstructure:"insecure"`
+}
+
+func (c *config) init() {
+	if c.Timeout == 0 {
+		c.Timeout = 60
+	}
+}
+
+type step struct {
+	c      *config
+	client *http.Client
+}
+
+// payload is the body of the requests sent to the hook.
+type payload struct {
+	ResourceID *provider.ResourceId `json:"resource_id"`
+	Path       string               `json:"path"`
+	Size       uint64               `json:"size"`
+	MimeType   string               `json:"mime_type"`
+	Owner      string               `json:"owner"`
+}
+
+// New returns a step posting the metadata of the files to an external
+// service. The service can reject a file by answering with 403 Forbidden or
+// 422 Unprocessable Entity, the body of the response being the reason.
+func New(m map[string]interface{}) (postprocessing.Step, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, errors.Wrap(err, "hook: error decoding conf")
+	}
+	c.init()
+	if c.URL == "" {
+		return nil, errors.New("hook: url is required")
+	}
+	return &step{
+		c: c,
+		client: rhttp.GetHTTPClient(
+			rhttp.Timeout(time.Duration(c.Timeout)*time.Second),
+			rhttp.Insecure(c.Insecure),
+		),
+	}, nil
+}
+
+func (s *step) Process(ctx context.Context, f *postprocessing.File) error {
+	body, err := json.Marshal(&payload{
+		ResourceID: f.Info.Id,
+		Path:       f.Info.Path,
+		Size:       f.Info.Size,
+		MimeType:   f.Info.MimeType,
+		Owner:      f.Info.GetOwner().GetOpaqueId(),
+	})
+	if err != nil {
+		return errors.Wrap(err, "hook: error encoding payload")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.c.URL, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "hook: error creating request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.c.Secret != "" {
+		req.Header.Set(webhook.HeaderSignature, webhook.Sign(s.c.Secret, body))
+	}
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	switch {
+	case res.StatusCode >= 200 && res.StatusCode < 300:
+		return nil
+	case res.StatusCode == http.StatusForbidden || res.StatusCode == http.StatusUnprocessableEntity:
+		reason, _ := ioutil.ReadAll(io.LimitReader(res.Body, 1024))
+		return postprocessing.Rejectf("%s", bytes.TrimSpace(reason))
+	default:
+		return fmt.Errorf("hook: unexpected status %s", res.Status)
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package thumbnail
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/postprocessing"
+	"github.com/cs3org/reva/pkg/postprocessing/step/registry"
+	"github.com/cs3org/reva/pkg/rhttp"
+	"github.com/cs3org/reva/pkg/token"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	registry.Register("thumbnail", New)
+}
+
+type config struct {
+	// URL is the template of the URL of the thumbnails, evaluated against
+	// {{.Path}}, {{.ResourceID}} and {{.Size}}, e.g.
+	// https://thumbnails.example.org/{{.ResourceID}}?size={{.Size}}.
+	URL string `mapstructure:"url"`
+	// Sizes are the sizes of the thumbnails to generate, e.g. 32x32.
+	Sizes    []string `mapstructure:"sizes"`
+	Timeout  int      `mapstructure:"timeout"`
+	Insecure bool     `mapstructure:"insecure"`
+}
+
+func (c *config) init() {
+	if len(c.Sizes) == 0 {
+		c.Sizes = []string{"32x32", "256x256"}
+	}
+	if c.Timeout == 0 {
+		c.Timeout = 60
+	}
+}
+
+type step struct {
+	c      *config
+	tpl    *template.Template
+	client *http.Client
+}
+
+// New returns a step requesting the thumbnails of the uploaded images, so
+// that they are generated before the clients ask for them.
+func New(m map[string]interface{}) (postprocessing.Step, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, errors.Wrap(err, "thumbnail: error decoding conf")
+	}
+	c.init()
+	if c.URL == "" {
+		return nil, errors.New("thumbnail: url is required")
+	}
+	tpl, err := template.New("url").Parse(c.URL)
+	if err != nil {
+		return nil, errors.Wrap(err, "thumbnail: error parsing url template")
+	}
+	return &step{
+		c:   c,
+		tpl: tpl,
+		client: rhttp.GetHTTPClient(
+			rhttp.Timeout(time.Duration(c.Timeout)*time.Second),
+			rhttp.Insecure(c.Insecure),
+		),
+	}, nil
+}
+
+func (s *step) Process(ctx context.Context, f *postprocessing.File) error {
+	if !strings.HasPrefix(f.Info.MimeType, "image/") {
+		return nil
+	}
+	for _, size := range s.c.Sizes {
+		if err := s.generate(ctx, f, size); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *step) generate(ctx context.Context, f *postprocessing.File, size string) error {
+	var u strings.Builder
+	err := s.tpl.Execute(&u, map[string]string{
+		"Path":       f.Info.Path,
+		"ResourceID": fileID(f.Info.Id),
+		"Size":       size,
+	})
+	if err != nil {
+		return errors.Wrap(err, "thumbnail: error executing url template")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	if tkn, ok := token.ContextGetToken(ctx); ok {
+		req.Header.Set(token.TokenHeader, tkn)
+	}
+	res, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("thumbnail: unexpected status %s for size %s", res.Status, size)
+	}
+	return nil
+}
+
+// fileID encodes the id of the resource as the file ids of the ownCloud APIs.
+func fileID(r *provider.ResourceId) string {
+	if r == nil {
+		return ""
+	}
+	return base64.URLEncoding.EncodeToString([]byte(fmt.Sprintf("%s:%s", r.StorageId, r.OpaqueId)))
+}
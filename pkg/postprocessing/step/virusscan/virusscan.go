@@ -0,0 +1,143 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package virusscan
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/cs3org/reva/pkg/postprocessing"
+	"github.com/cs3org/reva/pkg/postprocessing/step/registry"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	registry.Register("virusscan", New)
+}
+
+type config struct {
+	// Address is the TCP address of the clamd daemon.
+	Address string `mapstructure:"address"`
+	// Timeout is the timeout in seconds of a scan.
+	Timeout int `mapstructure:"timeout"`
+}
+
+func (c *config) init() {
+	if c.Address == "" {
+		c.Address = "localhost:3310"
+	}
+	if c.Timeout == 0 {
+		c.Timeout = 300
+	}
+}
+
+type step struct {
+	c *config
+}
+
+// New returns a step scanning the files with clamd, rejecting the infected
+// ones.
+func New(m map[string]interface{}) (postprocessing.Step, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, errors.Wrap(err, "virusscan: error decoding conf")
+	}
+	c.init()
+	return &step{c: c}, nil
+}
+
+func (s *step) Process(ctx context.Context, f *postprocessing.File) error {
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	d := net.Dialer{}
+	conn, err := d.DialContext(ctx, "tcp", s.c.Address)
+	if err != nil {
+		return errors.Wrap(err, "virusscan: error connecting to clamd")
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(time.Duration(s.c.Timeout) * time.Second))
+
+	reply, err := instream(conn, r)
+	if err != nil {
+		return err
+	}
+	// the reply is formatted as 'stream: OK' or 'stream: [signature] FOUND'
+	reply = strings.TrimSpace(strings.TrimPrefix(reply, "stream:"))
+	switch {
+	case reply == "OK":
+		return nil
+	case strings.HasSuffix(reply, "FOUND"):
+		return postprocessing.Rejectf("virus found: %s", strings.TrimSpace(strings.TrimSuffix(reply, "FOUND")))
+	default:
+		return errors.New("virusscan: unexpected reply from clamd: " + reply)
+	}
+}
+
+// instream sends the content to clamd with the INSTREAM command, as chunks
+// prefixed with their length and terminated by an empty chunk, and returns
+// the reply.
+func instream(conn net.Conn, r io.Reader) (string, error) {
+	w := bufio.NewWriter(conn)
+	if _, err := w.WriteString("zINSTREAM\x00"); err != nil {
+		return "", err
+	}
+	buf := make([]byte, 32*1024)
+	size := make([]byte, 4)
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size, uint32(n))
+			if _, err := w.Write(size); err != nil {
+				return "", err
+			}
+			if _, err := w.Write(buf[:n]); err != nil {
+				return "", err
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	binary.BigEndian.PutUint32(size, 0)
+	if _, err := w.Write(size); err != nil {
+		return "", err
+	}
+	if err := w.Flush(); err != nil {
+		return "", errors.Wrap(err, "virusscan: error sending the file to clamd")
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString('\x00')
+	if err != nil && err != io.EOF {
+		return "", errors.Wrap(err, "virusscan: error reading the reply of clamd")
+	}
+	return strings.TrimRight(reply, "\x00"), nil
+}
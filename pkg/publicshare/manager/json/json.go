@@ -39,6 +39,8 @@ import (
 	typespb "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
 	"github.com/cs3org/reva/pkg/appctx"
 	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/events"
+	eventsregistry "github.com/cs3org/reva/pkg/events/driver/registry"
 	"github.com/cs3org/reva/pkg/publicshare"
 	"github.com/cs3org/reva/pkg/publicshare/manager/registry"
 	"github.com/cs3org/reva/pkg/utils"
@@ -60,17 +62,22 @@ func New(c map[string]interface{}) (publicshare.Manager, error) {
 
 	conf.init()
 
+	stream, err := eventsregistry.NewStream(conf.Events)
+	if err != nil {
+		return nil, err
+	}
+
 	m := manager{
 		mutex:                      &sync.Mutex{},
 		file:                       conf.File,
 		passwordHashCost:           conf.SharePasswordHashCost,
 		janitorRunInterval:         conf.JanitorRunInterval,
 		enableExpiredSharesCleanup: conf.EnableExpiredSharesCleanup,
+		stream:                     stream,
 	}
 
 	// attempt to create the db file
 	var fi os.FileInfo
-	var err error
 	if fi, err = os.Stat(m.file); os.IsNotExist(err) {
 		folder := filepath.Dir(m.file)
 		if err := os.MkdirAll(folder, 0755); err != nil {
@@ -98,6 +105,8 @@ type config struct {
 	SharePasswordHashCost      int    `mapstructure:"password_hash_cost"`
 	JanitorRunInterval         int    `mapstructure:"janitor_run_interval"`
 	EnableExpiredSharesCleanup bool   `mapstructure:"enable_expired_shares_cleanup"`
+	// Events configures the bus the expiration of the shares is published to.
+	Events map[string]interface{} `mapstructure:"events"`
 }
 
 func (c *config) init() {
@@ -119,6 +128,8 @@ type manager struct {
 	passwordHashCost           int
 	janitorRunInterval         int
 	enableExpiredSharesCleanup bool
+
+	stream events.Publisher
 }
 
 func (m *manager) startJanitorRun() {
@@ -405,23 +416,42 @@ func notExpired(s *link.PublicShare) bool {
 }
 
 func (m *manager) cleanupExpiredShares() {
-	m.mutex.Lock()
-	defer m.mutex.Unlock()
+	if _, err := m.PurgeExpiredShares(context.Background()); err != nil {
+		log.Err(err).Msg("publicShareJSONManager: error purging expired shares")
+	}
+}
 
-	db, _ := m.readDb()
+// PurgeExpiredShares deletes the expired public shares, regardless of
+// enable_expired_shares_cleanup, and returns their number.
+func (m *manager) PurgeExpiredShares(ctx context.Context) (int, error) {
+	m.mutex.Lock()
+	db, err := m.readDb()
+	m.mutex.Unlock()
+	if err != nil {
+		return 0, err
+	}
 
+	var purged int
 	for _, v := range db {
 		d := v.(map[string]interface{})["share"]
 
 		var ps link.PublicShare
-		_ = utils.UnmarshalJSONToProtoV1([]byte(d.(string)), &ps)
+		if err := utils.UnmarshalJSONToProtoV1([]byte(d.(string)), &ps); err != nil {
+			continue
+		}
 
 		if !notExpired(&ps) {
-			_ = m.revokeExpiredPublicShare(context.Background(), &ps, nil)
+			if err := m.expirePublicShare(ctx, &ps, nil); err != nil {
+				return purged, err
+			}
+			purged++
 		}
 	}
+	return purged, nil
 }
 
+// revokeExpiredPublicShare deletes an expired share found while holding
+// the mutex, if enabled.
 func (m *manager) revokeExpiredPublicShare(ctx context.Context, s *link.PublicShare, u *user.User) error {
 	if !m.enableExpiredSharesCleanup {
 		return nil
@@ -430,6 +460,10 @@ func (m *manager) revokeExpiredPublicShare(ctx context.Context, s *link.PublicSh
 	m.mutex.Unlock()
 	defer m.mutex.Lock()
 
+	return m.expirePublicShare(ctx, s, u)
+}
+
+func (m *manager) expirePublicShare(ctx context.Context, s *link.PublicShare, u *user.User) error {
 	span := trace.FromContext(ctx)
 	span.AddAttributes(
 		trace.StringAttribute("operation", "delete expired share"),
@@ -448,6 +482,19 @@ func (m *manager) revokeExpiredPublicShare(ctx context.Context, s *link.PublicSh
 		return err
 	}
 
+	name := s.DisplayName
+	if name == "" {
+		name = s.Token
+	}
+	if err := events.Publish(ctx, m.stream, events.LinkExpired{
+		LinkID:  s.Id.OpaqueId,
+		Creator: s.Creator,
+		Name:    name,
+		Time:    time.Now(),
+	}); err != nil {
+		log.Err(err).Msg("publicShareJSONManager: error publishing event")
+	}
+
 	return nil
 }
 
@@ -42,6 +42,14 @@ type Manager interface {
 	GetPublicShareByToken(ctx context.Context, token string, auth *link.PublicShareAuthentication, sign bool) (*link.PublicShare, error)
 }
 
+// ExpiredSharesPurger is implemented by the managers able to delete the
+// expired public shares on demand.
+type ExpiredSharesPurger interface {
+	// PurgeExpiredShares deletes the expired public shares and returns
+	// their number.
+	PurgeExpiredShares(ctx context.Context) (int, error)
+}
+
 // CreateSignature calculates a signature for a public share.
 func CreateSignature(token, pw string, expiration time.Time) string {
 	h := sha256.New()
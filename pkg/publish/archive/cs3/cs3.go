@@ -0,0 +1,226 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package cs3
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	typespb "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	"github.com/cs3org/reva/internal/http/services/datagateway"
+	"github.com/cs3org/reva/pkg/publish"
+	"github.com/cs3org/reva/pkg/publish/archive/registry"
+	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
+	"github.com/cs3org/reva/pkg/rhttp"
+	"github.com/cs3org/reva/pkg/sharedconf"
+	"github.com/cs3org/reva/pkg/token"
+	ctxpkg "github.com/cs3org/reva/pkg/user"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/metadata"
+)
+
+func init() {
+	registry.Register("cs3", New)
+}
+
+type config struct {
+	GatewaySvc string `mapstructure:"gatewaysvc"`
+	// Path is the folder of the namespace the publications are stored in,
+	// e.g. a project area.
+	Path string `mapstructure:"path"`
+	// Username is the account storing the publications, authenticated
+	// through the machine auth provider.
+	Username          string `mapstructure:"username"`
+	MachineAuthAPIKey string `mapstructure:"machine_auth_apikey"`
+	// TokenRefresh is the number of seconds after which the token of the
+	// account is renewed.
+	TokenRefresh int   `mapstructure:"token_refresh"`
+	Timeout      int64 `mapstructure:"timeout"`
+	Insecure     bool  `mapstructure:"insecure"`
+}
+
+func (c *config) init() {
+	c.GatewaySvc = sharedconf.GetGatewaySVC(c.GatewaySvc)
+	if c.TokenRefresh == 0 {
+		c.TokenRefresh = 900
+	}
+}
+
+type archive struct {
+	c      *config
+	client *http.Client
+
+	mu        sync.Mutex
+	user      *userpb.User
+	token     string
+	refreshed time.Time
+	// folders are the folders known to exist.
+	folders map[string]bool
+}
+
+// New returns an archive storing the publications in a folder of the CS3
+// namespace, e.g. a project area on EOS.
+func New(m map[string]interface{}) (publish.Archive, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, errors.Wrap(err, "error decoding conf")
+	}
+	c.init()
+	if c.Path == "" || c.Username == "" || c.MachineAuthAPIKey == "" {
+		return nil, errors.New("cs3: path, username and machine_auth_apikey must be set")
+	}
+
+	return &archive{
+		c: c,
+		client: rhttp.GetHTTPClient(
+			rhttp.Timeout(time.Duration(c.Timeout*int64(time.Second))),
+			rhttp.Insecure(c.Insecure),
+		),
+		folders: map[string]bool{},
+	}, nil
+}
+
+func (a *archive) Location(publicationID string) string {
+	return path.Join(a.c.Path, publicationID)
+}
+
+func (a *archive) Put(ctx context.Context, publicationID, name string, r io.Reader, size int64) error {
+	client, err := pool.GetGatewayServiceClient(a.c.GatewaySvc)
+	if err != nil {
+		return err
+	}
+	ctx, err = a.context(ctx, client)
+	if err != nil {
+		return err
+	}
+
+	fn := path.Join(a.Location(publicationID), name)
+	if err := a.mkdirAll(ctx, client, path.Dir(fn)); err != nil {
+		return err
+	}
+
+	res, err := client.InitiateFileUpload(ctx, &provider.InitiateFileUploadRequest{
+		Ref: &provider.Reference{Spec: &provider.Reference_Path{Path: fn}},
+		Opaque: &typespb.Opaque{Map: map[string]*typespb.OpaqueEntry{
+			"Upload-Length": {
+				Decoder: "plain",
+				Value:   []byte(strconv.FormatInt(size, 10)),
+			},
+		}},
+	})
+	if err != nil {
+		return err
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return errors.New("cs3: error initiating upload of " + fn + ": " + res.Status.Message)
+	}
+	var ep, tkn string
+	for _, p := range res.Protocols {
+		if p.Protocol == "simple" {
+			ep, tkn = p.UploadEndpoint, p.Token
+		}
+	}
+
+	req, err := rhttp.NewRequest(ctx, http.MethodPut, ep, r)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(datagateway.TokenTransportHeader, tkn)
+	req.ContentLength = size
+	httpRes, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer httpRes.Body.Close()
+	if httpRes.StatusCode != http.StatusOK {
+		return errors.New("cs3: error uploading " + fn + ": " + httpRes.Status)
+	}
+	return nil
+}
+
+// context returns a context authenticated as the account of the archive,
+// keeping the deadline of ctx.
+func (a *archive) context(ctx context.Context, client gateway.GatewayAPIClient) (context.Context, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.token == "" || time.Since(a.refreshed) > time.Duration(a.c.TokenRefresh)*time.Second {
+		res, err := client.Authenticate(context.Background(), &gateway.AuthenticateRequest{
+			Type:         "machine",
+			ClientId:     a.c.Username,
+			ClientSecret: a.c.MachineAuthAPIKey,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if res.Status.Code != rpc.Code_CODE_OK {
+			return nil, errors.New("cs3: error authenticating " + a.c.Username + ": " + res.Status.Message)
+		}
+		a.user, a.token, a.refreshed = res.User, res.Token, time.Now()
+	}
+
+	ctx = ctxpkg.ContextSetUser(ctx, a.user)
+	ctx = token.ContextSetToken(ctx, a.token)
+	return metadata.NewOutgoingContext(ctx, metadata.Pairs(token.TokenHeader, a.token)), nil
+}
+
+// mkdirAll creates the folder and its parents below the path of the archive.
+func (a *archive) mkdirAll(ctx context.Context, client gateway.GatewayAPIClient, dir string) error {
+	rel := strings.TrimPrefix(dir, a.c.Path)
+	current := a.c.Path
+	for _, p := range strings.Split(strings.Trim(rel, "/"), "/") {
+		if p == "" {
+			continue
+		}
+		current = path.Join(current, p)
+
+		a.mu.Lock()
+		exists := a.folders[current]
+		a.mu.Unlock()
+		if exists {
+			continue
+		}
+
+		res, err := client.CreateContainer(ctx, &provider.CreateContainerRequest{
+			Ref: &provider.Reference{Spec: &provider.Reference_Path{Path: current}},
+		})
+		if err != nil {
+			return err
+		}
+		if res.Status.Code != rpc.Code_CODE_OK && res.Status.Code != rpc.Code_CODE_ALREADY_EXISTS {
+			return errors.New("cs3: error creating " + current + ": " + res.Status.Message)
+		}
+
+		a.mu.Lock()
+		a.folders[current] = true
+		a.mu.Unlock()
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package s3
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"path"
+
+	"github.com/cs3org/reva/pkg/publish"
+	"github.com/cs3org/reva/pkg/publish/archive/registry"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	registry.Register("s3", New)
+}
+
+type config struct {
+	Endpoint  string `mapstructure:"endpoint"`
+	Region    string `mapstructure:"region"`
+	Bucket    string `mapstructure:"bucket"`
+	AccessKey string `mapstructure:"access_key"`
+	SecretKey string `mapstructure:"secret_key"`
+	// Prefix is the prefix of the keys of the objects of the publications.
+	Prefix string `mapstructure:"prefix"`
+}
+
+type archive struct {
+	c      *config
+	client *minio.Client
+}
+
+// New returns an archive storing the publications in an S3 bucket.
+func New(m map[string]interface{}) (publish.Archive, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, errors.Wrap(err, "error decoding conf")
+	}
+	if c.Endpoint == "" || c.Bucket == "" {
+		return nil, errors.New("s3: endpoint and bucket must be set")
+	}
+
+	u, err := url.Parse(c.Endpoint)
+	if err != nil {
+		return nil, errors.Wrap(err, "s3: failed to parse endpoint")
+	}
+	client, err := minio.New(u.Host, &minio.Options{
+		Region: c.Region,
+		Creds:  credentials.NewStaticV4(c.AccessKey, c.SecretKey, ""),
+		Secure: u.Scheme != "http",
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "s3: failed to setup client")
+	}
+
+	return &archive{c: c, client: client}, nil
+}
+
+func (a *archive) Location(publicationID string) string {
+	return "s3://" + path.Join(a.c.Bucket, a.c.Prefix, publicationID)
+}
+
+func (a *archive) Put(ctx context.Context, publicationID, name string, r io.Reader, size int64) error {
+	key := path.Join(a.c.Prefix, publicationID, name)
+	_, err := a.client.PutObject(ctx, a.c.Bucket, key, r, size, minio.PutObjectOptions{ContentType: "application/octet-stream"})
+	if err != nil {
+		return errors.Wrapf(err, "s3: could not store object '%s' into bucket '%s'", key, a.c.Bucket)
+	}
+	return nil
+}
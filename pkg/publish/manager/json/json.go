@@ -0,0 +1,128 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package json
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/publish"
+	"github.com/cs3org/reva/pkg/publish/manager/registry"
+	"github.com/cs3org/reva/pkg/utils"
+	"github.com/cs3org/reva/pkg/utils/jsonfile"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	registry.Register("json", New)
+}
+
+type config struct {
+	File string `mapstructure:"file"`
+}
+
+func (c *config) init() {
+	if c.File == "" {
+		c.File = "/var/tmp/reva/publications.json"
+	}
+}
+
+type manager struct {
+	sync.Mutex
+	c            *config
+	file         *jsonfile.File
+	publications map[string]*publish.Publication
+}
+
+// New returns a publication manager storing the publications in a JSON file.
+func New(m map[string]interface{}) (publish.Manager, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, errors.Wrap(err, "error decoding conf")
+	}
+	c.init()
+
+	mgr := &manager{c: c, file: jsonfile.New(c.File), publications: map[string]*publish.Publication{}}
+	if err := mgr.reload(); err != nil {
+		return nil, err
+	}
+	return mgr, nil
+}
+
+func (m *manager) reload() error {
+	publications := map[string]*publish.Publication{}
+	changed, err := m.file.Reload(&publications)
+	if err != nil {
+		return errors.Wrap(err, "publish: error reading publications")
+	}
+	if changed {
+		m.publications = publications
+	}
+	return nil
+}
+
+func (m *manager) persist() error {
+	if err := m.file.Persist(m.publications); err != nil {
+		return errors.Wrap(err, "publish: error writing publications")
+	}
+	return nil
+}
+
+func (m *manager) StorePublication(ctx context.Context, p *publish.Publication) error {
+	m.Lock()
+	defer m.Unlock()
+	if err := m.reload(); err != nil {
+		return err
+	}
+	m.publications[p.ID] = p
+	return m.persist()
+}
+
+func (m *manager) GetPublication(ctx context.Context, id string) (*publish.Publication, error) {
+	m.Lock()
+	defer m.Unlock()
+	if err := m.reload(); err != nil {
+		return nil, err
+	}
+	p, ok := m.publications[id]
+	if !ok {
+		return nil, errtypes.NotFound(id)
+	}
+	return p, nil
+}
+
+func (m *manager) ListPublications(ctx context.Context, owner *userpb.UserId) ([]*publish.Publication, error) {
+	m.Lock()
+	defer m.Unlock()
+	if err := m.reload(); err != nil {
+		return nil, err
+	}
+	publications := []*publish.Publication{}
+	for _, p := range m.publications {
+		if utils.UserEqual(p.Owner, owner) {
+			publications = append(publications, p)
+		}
+	}
+	sort.Slice(publications, func(i, j int) bool { return publications[i].Created.Before(publications[j].Created) })
+	return publications, nil
+}
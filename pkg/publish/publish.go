@@ -0,0 +1,96 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package publish copies snapshots of folders to archival storages, recording
+// their provenance and identifying them with persistent identifiers.
+package publish
+
+import (
+	"context"
+	"io"
+	"time"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+)
+
+// The states of the publications.
+const (
+	StatePending = "pending"
+	StateRunning = "running"
+	StateDone    = "done"
+	StateFailed  = "failed"
+)
+
+// Publication is a snapshot of a folder copied to an archive.
+type Publication struct {
+	ID string `json:"id"`
+	// Identifier is the persistent identifier of the publication.
+	Identifier  string         `json:"identifier"`
+	Title       string         `json:"title"`
+	Description string         `json:"description,omitempty"`
+	Owner       *userpb.UserId `json:"owner"`
+	Source      *Source        `json:"source"`
+	// Archive is the name of the archive the publication is stored in and
+	// Location where in the archive.
+	Archive   string     `json:"archive"`
+	Location  string     `json:"location,omitempty"`
+	Files     []*File    `json:"files,omitempty"`
+	State     string     `json:"state"`
+	Error     string     `json:"error,omitempty"`
+	Created   time.Time  `json:"created"`
+	Published *time.Time `json:"published,omitempty"`
+}
+
+// Source is the published folder, as it was when it was published.
+type Source struct {
+	Path       string               `json:"path"`
+	ResourceID *provider.ResourceId `json:"resource_id"`
+	Etag       string               `json:"etag"`
+}
+
+// File is a file of a publication.
+type File struct {
+	// Path is the path of the file relative to the published folder.
+	Path  string    `json:"path"`
+	Size  uint64    `json:"size"`
+	Mtime time.Time `json:"mtime"`
+	Etag  string    `json:"etag"`
+	// SHA256 is the checksum of the content of the file, computed while it
+	// was archived.
+	SHA256 string `json:"sha256"`
+}
+
+// Manager is the interface to implement to store the publications.
+type Manager interface {
+	// StorePublication adds the publication or updates it.
+	StorePublication(ctx context.Context, p *Publication) error
+	GetPublication(ctx context.Context, id string) (*Publication, error)
+	// ListPublications returns the publications of the owner.
+	ListPublications(ctx context.Context, owner *userpb.UserId) ([]*Publication, error)
+}
+
+// Archive is the interface to implement to store the content of the
+// publications.
+type Archive interface {
+	// Put stores a file of the publication, name being its path relative to
+	// the root of the publication in the archive.
+	Put(ctx context.Context, publicationID, name string, r io.Reader, size int64) error
+	// Location returns where the publication is stored, e.g. its URL.
+	Location(publicationID string) string
+}
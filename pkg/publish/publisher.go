@@ -0,0 +1,259 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package publish
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/internal/http/services/datagateway"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
+	"github.com/cs3org/reva/pkg/rhttp"
+	"github.com/cs3org/reva/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+const (
+	// ManifestName is the name of the file describing the publication,
+	// stored in the archive next to the files.
+	ManifestName = "publication.json"
+	// DataDir is the folder of the archived publications the files are
+	// stored in.
+	DataDir = "data"
+)
+
+// Publisher copies the folders to an archive.
+type Publisher struct {
+	m           Manager
+	archiveName string
+	archive     Archive
+	gatewaySvc  string
+	idPrefix    string
+	client      *http.Client
+}
+
+// NewPublisher returns a publisher copying the folders to the archive and
+// storing the publications with the manager. The identifiers of the
+// publications are their ids prefixed with idPrefix, e.g. urn:uuid:.
+func NewPublisher(m Manager, archiveName string, archive Archive, gatewaySvc, idPrefix string, client *http.Client) *Publisher {
+	return &Publisher{
+		m:           m,
+		archiveName: archiveName,
+		archive:     archive,
+		gatewaySvc:  gatewaySvc,
+		idPrefix:    idPrefix,
+		client:      client,
+	}
+}
+
+// Create stores a pending publication of the folder, to be published with
+// Publish.
+func (p *Publisher) Create(ctx context.Context, owner *userpb.UserId, fn, title, description string) (*Publication, error) {
+	id := uuid.New().String()
+	pub := &Publication{
+		ID:          id,
+		Identifier:  p.idPrefix + id,
+		Title:       title,
+		Description: description,
+		Owner:       owner,
+		Source:      &Source{Path: fn},
+		Archive:     p.archiveName,
+		Location:    p.archive.Location(id),
+		State:       StatePending,
+		Created:     time.Now(),
+	}
+	if err := p.store(ctx, pub); err != nil {
+		return nil, err
+	}
+	return pub, nil
+}
+
+// Publish copies the folder of the publication to the archive on behalf of
+// the user of the context. The publication fails if the folder is modified
+// while it is copied, when the storage propagates the etags.
+func (p *Publisher) Publish(ctx context.Context, pub *Publication) error {
+	err := p.publish(ctx, pub)
+	if err != nil {
+		pub.State = StateFailed
+		pub.Error = err.Error()
+	}
+	if serr := p.store(ctx, pub); serr != nil && err == nil {
+		err = serr
+	}
+	return err
+}
+
+// store stores a copy of the publication, which is still modified while it
+// is published.
+func (p *Publisher) store(ctx context.Context, pub *Publication) error {
+	c, src := *pub, *pub.Source
+	c.Source = &src
+	c.Files = append([]*File(nil), pub.Files...)
+	return p.m.StorePublication(ctx, &c)
+}
+
+func (p *Publisher) publish(ctx context.Context, pub *Publication) error {
+	client, err := pool.GetGatewayServiceClient(p.gatewaySvc)
+	if err != nil {
+		return err
+	}
+
+	pub.State = StateRunning
+	if err := p.store(ctx, pub); err != nil {
+		return err
+	}
+
+	root, err := stat(ctx, client, pub.Source.Path)
+	if err != nil {
+		return err
+	}
+	if root.Type != provider.ResourceType_RESOURCE_TYPE_CONTAINER {
+		return errtypes.BadRequest("publish: only folders can be published")
+	}
+	pub.Source.ResourceID, pub.Source.Etag = root.Id, root.Etag
+
+	if err := p.copyTree(ctx, client, pub, root, ""); err != nil {
+		return err
+	}
+
+	after, err := stat(ctx, client, pub.Source.Path)
+	if err != nil {
+		return err
+	}
+	if after.Etag != root.Etag {
+		return errors.New("publish: the folder was modified while it was published")
+	}
+
+	now := time.Now()
+	pub.State, pub.Published = StateDone, &now
+	manifest, err := json.MarshalIndent(pub, "", "  ")
+	if err != nil {
+		return err
+	}
+	return p.archive.Put(ctx, pub.ID, ManifestName, bytes.NewReader(manifest), int64(len(manifest)))
+}
+
+// copyTree copies the files below the folder, rel being its path relative to
+// the published folder.
+func (p *Publisher) copyTree(ctx context.Context, client gateway.GatewayAPIClient, pub *Publication, folder *provider.ResourceInfo, rel string) error {
+	res, err := client.ListContainer(ctx, &provider.ListContainerRequest{
+		Ref: &provider.Reference{Spec: &provider.Reference_Path{Path: folder.Path}},
+	})
+	if err != nil {
+		return err
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return errors.New("publish: error listing " + folder.Path + ": " + res.Status.Message)
+	}
+	sort.Slice(res.Infos, func(i, j int) bool { return res.Infos[i].Path < res.Infos[j].Path })
+
+	for _, info := range res.Infos {
+		name := path.Join(rel, path.Base(info.Path))
+		if info.Type == provider.ResourceType_RESOURCE_TYPE_CONTAINER {
+			if err := p.copyTree(ctx, client, pub, info, name); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := p.copyFile(ctx, client, pub, info, name); err != nil {
+			return errors.Wrap(err, "publish: error archiving "+info.Path)
+		}
+	}
+	return nil
+}
+
+func (p *Publisher) copyFile(ctx context.Context, client gateway.GatewayAPIClient, pub *Publication, info *provider.ResourceInfo, name string) error {
+	res, err := client.InitiateFileDownload(ctx, &provider.InitiateFileDownloadRequest{
+		Ref: &provider.Reference{Spec: &provider.Reference_Path{Path: info.Path}},
+	})
+	if err != nil {
+		return err
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return errors.New(res.Status.Message)
+	}
+	var ep, tkn string
+	for _, proto := range res.Protocols {
+		if proto.Protocol == "simple" {
+			ep, tkn = proto.DownloadEndpoint, proto.Token
+		}
+	}
+
+	req, err := rhttp.NewRequest(ctx, http.MethodGet, ep, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(datagateway.TokenTransportHeader, tkn)
+	httpRes, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer httpRes.Body.Close()
+	if httpRes.StatusCode != http.StatusOK {
+		return errors.New("error downloading file: " + httpRes.Status)
+	}
+
+	h := sha256.New()
+	if err := p.archive.Put(ctx, pub.ID, path.Join(DataDir, name), io.TeeReader(httpRes.Body, h), int64(info.Size)); err != nil {
+		return err
+	}
+
+	pub.Files = append(pub.Files, &File{
+		Path:   name,
+		Size:   info.Size,
+		Mtime:  utils.TSToTime(info.Mtime).UTC(),
+		Etag:   strings.Trim(info.Etag, "\""),
+		SHA256: hex.EncodeToString(h.Sum(nil)),
+	})
+	return nil
+}
+
+func stat(ctx context.Context, client gateway.GatewayAPIClient, fn string) (*provider.ResourceInfo, error) {
+	res, err := client.Stat(ctx, &provider.StatRequest{
+		Ref: &provider.Reference{Spec: &provider.Reference_Path{Path: fn}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	switch res.Status.Code {
+	case rpc.Code_CODE_OK:
+		return res.Info, nil
+	case rpc.Code_CODE_NOT_FOUND:
+		return nil, errtypes.NotFound(fn)
+	case rpc.Code_CODE_PERMISSION_DENIED:
+		return nil, errtypes.PermissionDenied(fn)
+	default:
+		return nil, errors.New("publish: error statting " + fn + ": " + res.Status.Message)
+	}
+}
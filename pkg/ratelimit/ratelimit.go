@@ -0,0 +1,223 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package ratelimit implements token bucket rate limiting shared by the HTTP
+// middleware and the gRPC interceptors.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/gomodule/redigo/redis"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+// Limiter decides whether a request identified by a key may proceed.
+type Limiter interface {
+	// Allow consumes a token from the bucket identified by key. If the bucket
+	// is empty it returns false and the time after which a token will be
+	// available again.
+	Allow(ctx context.Context, key string) (bool, time.Duration, error)
+}
+
+// Config holds the configuration of a limiter.
+type Config struct {
+	// Rate is the number of requests per second refilled in each bucket.
+	Rate float64 `mapstructure:"rate"`
+	// Burst is the capacity of each bucket.
+	Burst int `mapstructure:"burst"`
+	// PerUser limits authenticated requests by user instead of by client address.
+	PerUser bool `mapstructure:"per_user"`
+	// Redis is the address of a redis server holding the buckets, so that the
+	// limits are enforced across all the nodes of a deployment. If empty, the
+	// buckets are kept in memory.
+	Redis         string `mapstructure:"redis"`
+	RedisUsername string `mapstructure:"redis_username"`
+	RedisPassword string `mapstructure:"redis_password"`
+	RedisPrefix   string `mapstructure:"redis_prefix"`
+}
+
+// Init sets the defaults of the configuration.
+func (c *Config) Init() {
+	if c.Rate == 0 {
+		c.Rate = 10
+	}
+	if c.Burst == 0 {
+		c.Burst = int(math.Ceil(c.Rate)) * 2
+	}
+	if c.RedisPrefix == "" {
+		c.RedisPrefix = "ratelimit:"
+	}
+}
+
+// ParseConfig decodes the limiter configuration from a map.
+func ParseConfig(m map[string]interface{}) (*Config, error) {
+	c := &Config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, errors.Wrap(err, "ratelimit: error decoding conf")
+	}
+	c.Init()
+	return c, nil
+}
+
+// New returns the limiter described by the configuration.
+func New(c *Config) Limiter {
+	if c.Redis != "" {
+		return NewRedis(c.Redis, c.RedisUsername, c.RedisPassword, c.RedisPrefix, c.Rate, c.Burst)
+	}
+	return NewMemory(c.Rate, c.Burst)
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+type memory struct {
+	sync.Mutex
+	rate    float64
+	burst   float64
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+// NewMemory returns a limiter keeping the buckets in memory.
+func NewMemory(rate float64, burst int) Limiter {
+	return &memory{
+		rate:    rate,
+		burst:   float64(burst),
+		buckets: map[string]*bucket{},
+		now:     time.Now,
+	}
+}
+
+func (m *memory) Allow(ctx context.Context, key string) (bool, time.Duration, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	now := m.now()
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: m.burst, last: now}
+		m.buckets[key] = b
+	}
+	b.tokens = math.Min(m.burst, b.tokens+now.Sub(b.last).Seconds()*m.rate)
+	b.last = now
+
+	// drop the buckets that are full, they carry no information
+	if len(m.buckets) > 10000 {
+		for k, o := range m.buckets {
+			if k != key && o.tokens+now.Sub(o.last).Seconds()*m.rate >= m.burst {
+				delete(m.buckets, k)
+			}
+		}
+	}
+
+	if b.tokens < 1 {
+		return false, retryAfter(1-b.tokens, m.rate), nil
+	}
+	b.tokens--
+	return true, 0, nil
+}
+
+func retryAfter(missing, rate float64) time.Duration {
+	return time.Duration(math.Ceil(missing / rate * float64(time.Second)))
+}
+
+// tokenBucketScript atomically refills and consumes a bucket stored as a hash.
+// It returns the number of missing tokens multiplied by 1000 when the request
+// is rejected, or 0 when it is allowed.
+var tokenBucketScript = redis.NewScript(1, `
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local b = redis.call("HMGET", KEYS[1], "tokens", "last")
+local tokens = tonumber(b[1]) or burst
+local last = tonumber(b[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - last) * rate)
+local missing = 0
+if tokens < 1 then
+	missing = math.ceil((1 - tokens) * 1000)
+else
+	tokens = tokens - 1
+end
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "last", tostring(now))
+redis.call("EXPIRE", KEYS[1], math.ceil(burst / rate) + 1)
+return missing
+`)
+
+type redisLimiter struct {
+	pool   *redis.Pool
+	prefix string
+	rate   float64
+	burst  int
+}
+
+// NewRedis returns a limiter keeping the buckets in redis.
+func NewRedis(address, username, password, prefix string, rate float64, burst int) Limiter {
+	return &redisLimiter{
+		pool:   newRedisPool(address, username, password),
+		prefix: prefix,
+		rate:   rate,
+		burst:  burst,
+	}
+}
+
+func (r *redisLimiter) Allow(ctx context.Context, key string) (bool, time.Duration, error) {
+	conn, err := r.pool.GetContext(ctx)
+	if err != nil {
+		return false, 0, errors.Wrap(err, "ratelimit: error getting redis connection")
+	}
+	defer conn.Close()
+
+	now := float64(time.Now().UnixNano()) / float64(time.Second)
+	missing, err := redis.Int64(tokenBucketScript.Do(conn, r.prefix+key, r.rate, r.burst, now))
+	if err != nil {
+		return false, 0, errors.Wrap(err, "ratelimit: error running redis script")
+	}
+	if missing > 0 {
+		return false, retryAfter(float64(missing)/1000, r.rate), nil
+	}
+	return true, 0, nil
+}
+
+func newRedisPool(address, username, password string) *redis.Pool {
+	return &redis.Pool{
+		MaxIdle:     50,
+		MaxActive:   1000,
+		IdleTimeout: 240 * time.Second,
+		Dial: func() (redis.Conn, error) {
+			opts := []redis.DialOption{}
+			if username != "" {
+				opts = append(opts, redis.DialUsername(username))
+			}
+			if password != "" {
+				opts = append(opts, redis.DialPassword(password))
+			}
+			return redis.Dial("tcp", address, opts...)
+		},
+		TestOnBorrow: func(c redis.Conn, t time.Time) error {
+			_, err := c.Do("PING")
+			return err
+		},
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryLimiter(t *testing.T) {
+	now := time.Unix(1000, 0)
+	l := NewMemory(1, 2).(*memory)
+	l.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if ok, _, _ := l.Allow(ctx, "a"); !ok {
+			t.Fatalf("request %d should have been allowed", i)
+		}
+	}
+
+	ok, retry, _ := l.Allow(ctx, "a")
+	if ok {
+		t.Fatal("request should have been rejected")
+	}
+	if retry != time.Second {
+		t.Fatalf("expected retry after 1s, got %s", retry)
+	}
+
+	if ok, _, _ := l.Allow(ctx, "b"); !ok {
+		t.Fatal("buckets should be independent")
+	}
+
+	now = now.Add(time.Second)
+	if ok, _, _ := l.Allow(ctx, "a"); !ok {
+		t.Fatal("bucket should have been refilled")
+	}
+}
@@ -68,6 +68,43 @@ func (r *Registry) GetService(name string) (registry.Service, error) {
 	return nil, fmt.Errorf("service %v not found", name)
 }
 
+// Remove implements the Registry interface.
+func (r *Registry) Remove(svc registry.Service) error {
+	r.Lock()
+	defer r.Unlock()
+
+	registered, ok := r.services[svc.Name()]
+	if !ok {
+		return fmt.Errorf("service %v not found", svc.Name())
+	}
+
+	removed := map[string]bool{}
+	for _, n := range svc.Nodes() {
+		removed[n.ID()] = true
+	}
+
+	s := service{
+		name:  svc.Name(),
+		nodes: make([]node, 0),
+	}
+	for _, n := range registered.Nodes() {
+		if !removed[n.ID()] {
+			s.nodes = append(s.nodes, node{
+				id:       n.ID(),
+				address:  n.Address(),
+				metadata: n.Metadata(),
+			})
+		}
+	}
+
+	if len(s.nodes) == 0 {
+		delete(r.services, svc.Name())
+	} else {
+		r.services[svc.Name()] = s
+	}
+	return nil
+}
+
 // New returns an implementation of the Registry interface.
 func New(m map[string]interface{}) registry.Registry {
 	// c, err := registry.ParseConfig(m)
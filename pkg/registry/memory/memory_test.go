@@ -125,6 +125,28 @@ func TestGetService(t *testing.T) {
 	}
 }
 
+func TestRemove(t *testing.T) {
+	reg = New(in)
+	_ = reg.Add(service{name: "auth-provider", nodes: []node{node1, node2}})
+
+	if err := reg.Remove(service{name: "auth-provider", nodes: []node{node1}}); err != nil {
+		t.Fatal(err)
+	}
+	svc, err := reg.GetService("auth-provider")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, 1, len(svc.Nodes()))
+	assert.Equal(t, node2.ID(), svc.Nodes()[0].ID())
+
+	if err := reg.Remove(service{name: "auth-provider", nodes: []node{node2}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := reg.GetService("auth-provider"); err == nil {
+		t.Error("expected the service without nodes to be removed")
+	}
+}
+
 //	func contains(a []registry.Node, b registry.Node) bool {
 //		for i := range a {
 //			if a[i].Address() == b.Address() {
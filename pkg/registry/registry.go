@@ -26,6 +26,10 @@ type Registry interface {
 	// GetService retrieves a Service and all of its nodes by Service name. It returns []*Service because we can have
 	// multiple versions of the same Service running alongside each others.
 	GetService(string) (Service, error)
+
+	// Remove deregisters the nodes of a Service, e.g. when the process running them is shutting down. The Service is
+	// removed once it has no nodes left.
+	Remove(Service) error
 }
 
 // Service defines a service.
@@ -23,18 +23,21 @@ import (
 	"io"
 	"net"
 	"sort"
+	"sync"
 
 	"github.com/cs3org/reva/internal/grpc/interceptors/appctx"
 	"github.com/cs3org/reva/internal/grpc/interceptors/auth"
 	"github.com/cs3org/reva/internal/grpc/interceptors/log"
 	"github.com/cs3org/reva/internal/grpc/interceptors/recovery"
 	"github.com/cs3org/reva/internal/grpc/interceptors/token"
+	"github.com/cs3org/reva/pkg/mtls"
 	"github.com/cs3org/reva/pkg/sharedconf"
+	rtrace "github.com/cs3org/reva/pkg/trace"
 	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
 	"github.com/mitchellh/mapstructure"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
-	"go.opencensus.io/plugin/ocgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
 )
@@ -99,6 +102,9 @@ type config struct {
 	Services         map[string]map[string]interface{} `mapstructure:"services"`
 	Interceptors     map[string]map[string]interface{} `mapstructure:"interceptors"`
 	EnableReflection bool                              `mapstructure:"enable_reflection"`
+	// TLS serves the services over TLS, requiring by default the clients
+	// to present a certificate.
+	TLS mtls.ServerConfig `mapstructure:"tls"`
 }
 
 func (c *config) init() {
@@ -118,6 +124,10 @@ type Server struct {
 	listener net.Listener
 	log      zerolog.Logger
 	services map[string]Service
+	// serviceNames maps the full names of the gRPC services to the names of
+	// the services registering them.
+	serviceNames map[string]string
+	closeOnce    sync.Once
 }
 
 // NewServer returns a new Server.
@@ -129,7 +139,7 @@ func NewServer(m interface{}, log zerolog.Logger) (*Server, error) {
 
 	conf.init()
 
-	server := &Server{conf: conf, log: log, services: map[string]Service{}}
+	server := &Server{conf: conf, log: log, services: map[string]Service{}, serviceNames: map[string]string{}}
 
 	return server, nil
 }
@@ -195,11 +205,24 @@ func (s *Server) registerServices() error {
 	if err != nil {
 		return err
 	}
-	opts = append(opts, grpc.StatsHandler(&ocgrpc.ServerHandler{}))
+	if s.conf.TLS.Enabled() {
+		creds, err := mtls.NewServerCredentials(&s.conf.TLS)
+		if err != nil {
+			return errors.Wrap(err, "rgrpc: error creating tls credentials")
+		}
+		opts = append(opts, grpc.Creds(creds))
+		s.log.Info().Msgf("rgrpc: grpc server serving tls with client auth %s", s.conf.TLS.ClientAuth)
+	}
 	grpcServer := grpc.NewServer(opts...)
 
-	for _, svc := range s.services {
+	for name, svc := range s.services {
+		registered := grpcServer.GetServiceInfo()
 		svc.Register(grpcServer)
+		for n := range grpcServer.GetServiceInfo() {
+			if _, ok := registered[n]; !ok {
+				s.serviceNames[n] = name
+			}
+		}
 	}
 
 	if s.conf.EnableReflection {
@@ -214,26 +237,36 @@ func (s *Server) registerServices() error {
 
 // TODO(labkode): make closing with deadline.
 func (s *Server) cleanupServices() {
-	for name, svc := range s.services {
-		if err := svc.Close(); err != nil {
-			s.log.Error().Err(err).Msgf("error closing service %q", name)
-		} else {
-			s.log.Info().Msgf("service %q correctly closed", name)
+	// the services are closed once, as a graceful stop can be followed by a
+	// hard one when the deadline is reached.
+	s.closeOnce.Do(func() {
+		for name, svc := range s.services {
+			if err := svc.Close(); err != nil {
+				s.log.Error().Err(err).Msgf("error closing service %q", name)
+			} else {
+				s.log.Info().Msgf("service %q correctly closed", name)
+			}
 		}
-	}
+	})
 }
 
 // Stop stops the server.
 func (s *Server) Stop() error {
 	s.cleanupServices()
-	s.s.Stop()
+	// the grpc server is only created when the services are registered.
+	if s.s != nil {
+		s.s.Stop()
+	}
 	return nil
 }
 
-// GracefulStop gracefully stops the server.
+// GracefulStop gracefully stops the server, waiting for the in-flight calls
+// to complete before closing the services.
 func (s *Server) GracefulStop() error {
+	if s.s != nil {
+		s.s.GracefulStop()
+	}
 	s.cleanupServices()
-	s.s.GracefulStop()
 	return nil
 }
 
@@ -275,14 +308,15 @@ func (s *Server) getInterceptors(unprotected []string) ([]grpc.ServerOption, err
 		return nil, errors.Wrap(err, "rgrpc: error creating unary auth interceptor")
 	}
 
-	unaryInterceptors := []grpc.UnaryServerInterceptor{authUnary}
+	unaryInterceptors := []grpc.UnaryServerInterceptor{authUnary, rtrace.UnaryServerInterceptor()}
 	for _, t := range unaryTriples {
 		unaryInterceptors = append(unaryInterceptors, t.Interceptor)
 		s.log.Info().Msgf("rgrpc: chaining grpc unary interceptor %s with priority %d", t.Name, t.Priority)
 	}
 
 	unaryInterceptors = append([]grpc.UnaryServerInterceptor{
-		appctx.NewUnary(s.log),
+		otelgrpc.UnaryServerInterceptor(),
+		appctx.NewUnary(s.log, s.serviceNames),
 		token.NewUnary(),
 		log.NewUnary(),
 		recovery.NewUnary(),
@@ -322,8 +356,9 @@ func (s *Server) getInterceptors(unprotected []string) ([]grpc.ServerOption, err
 	}
 
 	streamInterceptors = append([]grpc.StreamServerInterceptor{
+		otelgrpc.StreamServerInterceptor(),
 		authStream,
-		appctx.NewStream(s.log),
+		appctx.NewStream(s.log, s.serviceNames),
 		token.NewStream(),
 		log.NewStream(),
 		recovery.NewStream(),
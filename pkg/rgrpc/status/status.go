@@ -113,6 +113,29 @@ func NewInsufficientStorage(ctx context.Context, err error, msg string) *rpc.Sta
 	}
 }
 
+// NewUnavailable returns a Status with CODE_UNAVAILABLE and logs the msg.
+func NewUnavailable(ctx context.Context, err error, msg string) *rpc.Status {
+	log := appctx.GetLogger(ctx).With().CallerWithSkipFrameCount(3).Logger()
+	log.Warn().Err(err).Msg(msg)
+	return &rpc.Status{
+		Code:    rpc.Code_CODE_UNAVAILABLE,
+		Message: msg,
+		Trace:   getTrace(ctx),
+	}
+}
+
+// NewFailedPrecondition returns a Status with CODE_FAILED_PRECONDITION and
+// logs the msg.
+func NewFailedPrecondition(ctx context.Context, err error, msg string) *rpc.Status {
+	log := appctx.GetLogger(ctx).With().CallerWithSkipFrameCount(3).Logger()
+	log.Debug().Err(err).Msg(msg)
+	return &rpc.Status{
+		Code:    rpc.Code_CODE_FAILED_PRECONDITION,
+		Message: msg,
+		Trace:   getTrace(ctx),
+	}
+}
+
 // NewUnimplemented returns a Status with CODE_UNIMPLEMENTED and logs the msg.
 func NewUnimplemented(ctx context.Context, err error, msg string) *rpc.Status {
 	log := appctx.GetLogger(ctx).With().CallerWithSkipFrameCount(3).Logger()
@@ -157,8 +180,14 @@ func NewStatusFromErrType(ctx context.Context, msg string, err error) *rpc.Statu
 		return NewPermissionDenied(ctx, e, "gateway: "+msg+": "+err.Error())
 	case errtypes.IsNotSupported:
 		return NewUnimplemented(ctx, err, "gateway: "+msg+":"+err.Error())
+	case errtypes.IsAlreadyExists:
+		return NewAlreadyExists(ctx, err, "gateway: "+msg+": "+err.Error())
 	case errtypes.BadRequest:
 		return NewInvalidArg(ctx, "gateway: "+msg+":"+err.Error())
+	case errtypes.IsUnavailable:
+		return NewUnavailable(ctx, err, "gateway: "+msg+": "+err.Error())
+	case errtypes.IsPreconditionFailed:
+		return NewFailedPrecondition(ctx, err, "gateway: "+msg+": "+err.Error())
 	}
 	return NewInternal(ctx, err, "gateway: "+msg+":"+err.Error())
 }
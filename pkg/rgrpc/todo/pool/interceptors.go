@@ -0,0 +1,145 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package pool
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/cs3org/reva/pkg/mtls"
+	"github.com/cs3org/reva/pkg/sharedconf"
+	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+)
+
+// UnaryClientInterceptors is a map of registered unary grpc client interceptors.
+var UnaryClientInterceptors = map[string]NewUnaryClientInterceptor{}
+
+// StreamClientInterceptors is a map of registered streaming grpc client interceptors.
+var StreamClientInterceptors = map[string]NewStreamClientInterceptor{}
+
+// NewUnaryClientInterceptor is the type that unary client interceptors need to register.
+type NewUnaryClientInterceptor func(m map[string]interface{}) (grpc.UnaryClientInterceptor, int, error)
+
+// NewStreamClientInterceptor is the type that stream client interceptors need to register.
+type NewStreamClientInterceptor func(m map[string]interface{}) (grpc.StreamClientInterceptor, int, error)
+
+// RegisterUnaryClientInterceptor registers a new unary client interceptor,
+// which is added to the connections created by the pool when it is enabled
+// in the client_interceptors section of the shared configuration.
+func RegisterUnaryClientInterceptor(name string, newFunc NewUnaryClientInterceptor) {
+	UnaryClientInterceptors[name] = newFunc
+}
+
+// RegisterStreamClientInterceptor registers a new stream client interceptor.
+func RegisterStreamClientInterceptor(name string, newFunc NewStreamClientInterceptor) {
+	StreamClientInterceptors[name] = newFunc
+}
+
+var (
+	tlsClientOnce sync.Once
+	tlsClient     *mtls.Client
+	tlsClientErr  error
+)
+
+// getTransportCredentials returns the option securing the connections to an
+// endpoint, the certificates being shared by all the connections.
+func getTransportCredentials(endpoint string) (grpc.DialOption, error) {
+	tlsClientOnce.Do(func() {
+		tlsClient, tlsClientErr = mtls.NewClient(sharedconf.GetClientTLS())
+	})
+	if tlsClientErr != nil {
+		return nil, errors.Wrap(tlsClientErr, "pool: error creating tls credentials")
+	}
+	if creds := tlsClient.Credentials(endpoint); creds != nil {
+		return grpc.WithTransportCredentials(creds), nil
+	}
+	return grpc.WithInsecure(), nil
+}
+
+var (
+	dialOptionsOnce sync.Once
+	dialOptions     []grpc.DialOption
+	dialOptionsErr  error
+)
+
+// getDialOptions returns the interceptors of the client connections, created
+// once from the shared configuration. The interceptors are chained by
+// ascending priority, after the tracing one.
+func getDialOptions() ([]grpc.DialOption, error) {
+	dialOptionsOnce.Do(func() {
+		conf := sharedconf.GetClientInterceptors()
+
+		type unaryTriple struct {
+			name        string
+			priority    int
+			interceptor grpc.UnaryClientInterceptor
+		}
+		unaryTriples := []unaryTriple{}
+		for name, newFunc := range UnaryClientInterceptors {
+			if m, ok := conf[name]; ok {
+				inter, prio, err := newFunc(m)
+				if err != nil {
+					dialOptionsErr = errors.Wrapf(err, "pool: error creating unary client interceptor: %s,", name)
+					return
+				}
+				unaryTriples = append(unaryTriples, unaryTriple{name, prio, inter})
+			}
+		}
+		sort.SliceStable(unaryTriples, func(i, j int) bool {
+			return unaryTriples[i].priority < unaryTriples[j].priority
+		})
+		unary := []grpc.UnaryClientInterceptor{otelgrpc.UnaryClientInterceptor()}
+		for _, t := range unaryTriples {
+			unary = append(unary, t.interceptor)
+		}
+
+		type streamTriple struct {
+			name        string
+			priority    int
+			interceptor grpc.StreamClientInterceptor
+		}
+		streamTriples := []streamTriple{}
+		for name, newFunc := range StreamClientInterceptors {
+			if m, ok := conf[name]; ok {
+				inter, prio, err := newFunc(m)
+				if err != nil {
+					dialOptionsErr = errors.Wrapf(err, "pool: error creating stream client interceptor: %s,", name)
+					return
+				}
+				streamTriples = append(streamTriples, streamTriple{name, prio, inter})
+			}
+		}
+		sort.SliceStable(streamTriples, func(i, j int) bool {
+			return streamTriples[i].priority < streamTriples[j].priority
+		})
+		stream := []grpc.StreamClientInterceptor{otelgrpc.StreamClientInterceptor()}
+		for _, t := range streamTriples {
+			stream = append(stream, t.interceptor)
+		}
+
+		dialOptions = []grpc.DialOption{
+			grpc.WithUnaryInterceptor(grpc_middleware.ChainUnaryClient(unary...)),
+			grpc.WithStreamInterceptor(grpc_middleware.ChainStreamClient(stream...)),
+		}
+	})
+	return dialOptions, dialOptionsErr
+}
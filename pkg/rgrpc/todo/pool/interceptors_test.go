@@ -0,0 +1,133 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package pool
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	"github.com/cs3org/reva/pkg/sharedconf"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+)
+
+// recorder registers interceptors recording the order they ran in, and the
+// configuration they were created with.
+type recorder struct {
+	mu    sync.Mutex
+	calls []string
+	confs map[string]map[string]interface{}
+}
+
+func (rec *recorder) record(name string) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.calls = append(rec.calls, name)
+}
+
+func (rec *recorder) register(name string, priority int) {
+	RegisterUnaryClientInterceptor(name, func(m map[string]interface{}) (grpc.UnaryClientInterceptor, int, error) {
+		if _, ok := m["fail"]; ok {
+			return nil, 0, errors.New("invalid configuration")
+		}
+		rec.confs[name] = m
+		return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			rec.record("unary " + name)
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}, priority, nil
+	})
+	RegisterStreamClientInterceptor(name, func(m map[string]interface{}) (grpc.StreamClientInterceptor, int, error) {
+		return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			rec.record("stream " + name)
+			return streamer(ctx, desc, cc, method, opts...)
+		}, priority, nil
+	})
+}
+
+// configure decodes the shared configuration enabling the interceptors, and
+// forgets the dial options created from the previous one.
+func configure(t *testing.T, interceptors map[string]interface{}) {
+	if err := sharedconf.Decode(map[string]interface{}{"client_interceptors": interceptors}); err != nil {
+		t.Fatal(err)
+	}
+	dialOptionsOnce = sync.Once{}
+	dialOptions, dialOptionsErr = nil, nil
+}
+
+func TestClientInterceptors(t *testing.T) {
+	rec := &recorder{confs: map[string]map[string]interface{}{}}
+	rec.register("test-late", 20)
+	rec.register("test-early", 10)
+	rec.register("test-disabled", 0)
+	configure(t, map[string]interface{}{
+		"test-late":  map[string]interface{}{"key": "late"},
+		"test-early": map[string]interface{}{"key": "early"},
+	})
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	go func() { _ = srv.Serve(l) }()
+	defer srv.Stop()
+
+	conn, err := NewConn(l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// the server has no service, the calls only go through the interceptors.
+	ctx := context.Background()
+	_ = conn.Invoke(ctx, "/test.Service/Unary", &types.Opaque{}, &types.Opaque{})
+	if _, err := conn.NewStream(ctx, &grpc.StreamDesc{ServerStreams: true}, "/test.Service/Stream"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"unary test-early", "unary test-late", "stream test-early", "stream test-late"}
+	if !reflect.DeepEqual(rec.calls, want) {
+		t.Errorf("the interceptors ran as %v, wanted %v", rec.calls, want)
+	}
+	if rec.confs["test-late"]["key"] != "late" || rec.confs["test-early"]["key"] != "early" {
+		t.Errorf("the interceptors got the configurations %v", rec.confs)
+	}
+	if _, ok := rec.confs["test-disabled"]; ok {
+		t.Error("an interceptor missing from the configuration was enabled")
+	}
+}
+
+func TestClientInterceptorError(t *testing.T) {
+	rec := &recorder{confs: map[string]map[string]interface{}{}}
+	rec.register("test-invalid", 0)
+	defer delete(UnaryClientInterceptors, "test-invalid")
+	defer delete(StreamClientInterceptors, "test-invalid")
+	configure(t, map[string]interface{}{
+		"test-invalid": map[string]interface{}{"fail": true},
+	})
+	defer configure(t, map[string]interface{}{})
+
+	if _, err := NewConn("127.0.0.1:0"); err == nil {
+		t.Fatal("a connection was created with an invalid interceptor")
+	}
+}
@@ -39,7 +39,10 @@ import (
 	storageprovider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	storageregistry "github.com/cs3org/go-cs3apis/cs3/storage/registry/v1beta1"
 	datatx "github.com/cs3org/go-cs3apis/cs3/tx/v1beta1"
-	"go.opencensus.io/plugin/ocgrpc"
+	adminpb "github.com/cs3org/reva/internal/grpc/services/adminprovider/proto"
+	ocmcorepb "github.com/cs3org/reva/internal/grpc/services/ocmcore/proto"
+	searchpb "github.com/cs3org/reva/internal/grpc/services/search/proto"
+	lockpb "github.com/cs3org/reva/internal/grpc/services/storageprovider/proto"
 	"google.golang.org/grpc"
 )
 
@@ -76,13 +79,29 @@ var (
 	userProviders          = newProvider()
 	groupProviders         = newProvider()
 	dataTxs                = newProvider()
+	lockProviders          = newProvider()
+	streamProviders        = newProvider()
+	batchProviders         = newProvider()
+	searchProviders        = newProvider()
+	adminProviders         = newProvider()
+	storageAdmins          = newProvider()
+	cacheAdmins            = newProvider()
+	ocmNotifications       = newProvider()
 )
 
 // NewConn creates a new connection to a grpc server
-// with open census tracing support.
-// TODO(labkode): make grpc tls configurable.
+// propagating the trace context and running the configured client interceptors.
+// The connection is encrypted according to the grpc_client_tls shared configuration.
 func NewConn(endpoint string) (*grpc.ClientConn, error) {
-	conn, err := grpc.Dial(endpoint, grpc.WithInsecure(), grpc.WithStatsHandler(&ocgrpc.ClientHandler{}))
+	opts, err := getDialOptions()
+	if err != nil {
+		return nil, err
+	}
+	creds, err := getTransportCredentials(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := grpc.Dial(endpoint, append([]grpc.DialOption{creds}, opts...)...)
 	if err != nil {
 		return nil, err
 	}
@@ -436,6 +455,144 @@ func GetDataTxClient(endpoint string) (datatx.TxAPIClient, error) {
 	return v, nil
 }
 
+// GetLockClient returns a new LockAPIClient, served by the gateway and by
+// the storage providers.
+func GetLockClient(endpoint string) (lockpb.LockAPIClient, error) {
+	lockProviders.m.Lock()
+	defer lockProviders.m.Unlock()
+
+	if c, ok := lockProviders.conn[endpoint]; ok {
+		return c.(lockpb.LockAPIClient), nil
+	}
+
+	conn, err := NewConn(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	v := lockpb.NewLockAPIClient(conn)
+	lockProviders.conn[endpoint] = v
+	return v, nil
+}
+
+// GetStreamClient returns a new StreamAPIClient, served by the gateway and by
+// the storage providers.
+func GetStreamClient(endpoint string) (lockpb.StreamAPIClient, error) {
+	streamProviders.m.Lock()
+	defer streamProviders.m.Unlock()
+
+	if c, ok := streamProviders.conn[endpoint]; ok {
+		return c.(lockpb.StreamAPIClient), nil
+	}
+
+	conn, err := NewConn(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	v := lockpb.NewStreamAPIClient(conn)
+	streamProviders.conn[endpoint] = v
+	return v, nil
+}
+
+// GetBatchClient returns a new BatchAPIClient, served by the gateway and by
+// the storage providers.
+func GetBatchClient(endpoint string) (lockpb.BatchAPIClient, error) {
+	batchProviders.m.Lock()
+	defer batchProviders.m.Unlock()
+
+	if c, ok := batchProviders.conn[endpoint]; ok {
+		return c.(lockpb.BatchAPIClient), nil
+	}
+
+	conn, err := NewConn(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	v := lockpb.NewBatchAPIClient(conn)
+	batchProviders.conn[endpoint] = v
+	return v, nil
+}
+
+// GetSearchClient returns a new SearchServiceClient.
+func GetSearchClient(endpoint string) (searchpb.SearchServiceClient, error) {
+	searchProviders.m.Lock()
+	defer searchProviders.m.Unlock()
+
+	if c, ok := searchProviders.conn[endpoint]; ok {
+		return c.(searchpb.SearchServiceClient), nil
+	}
+
+	conn, err := NewConn(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	v := searchpb.NewSearchServiceClient(conn)
+	searchProviders.conn[endpoint] = v
+	return v, nil
+}
+
+// GetAdminClient returns a new AdminAPIClient.
+func GetAdminClient(endpoint string) (adminpb.AdminAPIClient, error) {
+	adminProviders.m.Lock()
+	defer adminProviders.m.Unlock()
+
+	if c, ok := adminProviders.conn[endpoint]; ok {
+		return c.(adminpb.AdminAPIClient), nil
+	}
+
+	conn, err := NewConn(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	v := adminpb.NewAdminAPIClient(conn)
+	adminProviders.conn[endpoint] = v
+	return v, nil
+}
+
+// GetStorageAdminClient returns a new StorageAdminAPIClient, served by the
+// storage providers.
+func GetStorageAdminClient(endpoint string) (adminpb.StorageAdminAPIClient, error) {
+	storageAdmins.m.Lock()
+	defer storageAdmins.m.Unlock()
+
+	if c, ok := storageAdmins.conn[endpoint]; ok {
+		return c.(adminpb.StorageAdminAPIClient), nil
+	}
+
+	conn, err := NewConn(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	v := adminpb.NewStorageAdminAPIClient(conn)
+	storageAdmins.conn[endpoint] = v
+	return v, nil
+}
+
+// GetCacheAdminClient returns a new CacheAdminAPIClient, served by the
+// gateway.
+func GetCacheAdminClient(endpoint string) (adminpb.CacheAdminAPIClient, error) {
+	cacheAdmins.m.Lock()
+	defer cacheAdmins.m.Unlock()
+
+	if c, ok := cacheAdmins.conn[endpoint]; ok {
+		return c.(adminpb.CacheAdminAPIClient), nil
+	}
+
+	conn, err := NewConn(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	v := adminpb.NewCacheAdminAPIClient(conn)
+	cacheAdmins.conn[endpoint] = v
+	return v, nil
+}
+
 // getEndpointByName resolve service names to ip addresses present on the registry.
 //	func getEndpointByName(name string) (string, error) {
 //		if services, err := utils.GlobalRegistry.GetService(name); err == nil {
@@ -451,3 +608,23 @@ func GetDataTxClient(endpoint string) (datatx.TxAPIClient, error) {
 //
 //		return "", fmt.Errorf("could not get service by name: %v", name)
 //	}
+
+// GetOCMNotificationsClient returns a new OCMNotificationsAPIClient, served by
+// the gateway and by the ocm cores.
+func GetOCMNotificationsClient(endpoint string) (ocmcorepb.OCMNotificationsAPIClient, error) {
+	ocmNotifications.m.Lock()
+	defer ocmNotifications.m.Unlock()
+
+	if c, ok := ocmNotifications.conn[endpoint]; ok {
+		return c.(ocmcorepb.OCMNotificationsAPIClient), nil
+	}
+
+	conn, err := NewConn(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	v := ocmcorepb.NewOCMNotificationsAPIClient(conn)
+	ocmNotifications.conn[endpoint] = v
+	return v, nil
+}
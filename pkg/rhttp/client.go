@@ -24,13 +24,12 @@ import (
 	"io"
 	"net/http"
 
-	"go.opencensus.io/plugin/ochttp"
-
 	"github.com/cs3org/reva/pkg/token"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
-// GetHTTPClient returns an http client with open census tracing support.
+// GetHTTPClient returns an http client propagating the trace context.
 // TODO(labkode): harden it.
 // https://medium.com/@nate510/don-t-use-go-s-default-http-client-4804cb19f779
 func GetHTTPClient(opts ...Option) *http.Client {
@@ -43,10 +42,8 @@ func GetHTTPClient(opts ...Option) *http.Client {
 	}
 
 	httpClient := &http.Client{
-		Timeout: options.Timeout,
-		Transport: &ochttp.Transport{
-			Base: tr,
-		},
+		Timeout:   options.Timeout,
+		Transport: otelhttp.NewTransport(tr),
 	}
 
 	return httpClient
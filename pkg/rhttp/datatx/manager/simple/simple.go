@@ -20,14 +20,17 @@ package simple
 
 import (
 	"net/http"
+	"time"
 
 	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	"github.com/cs3org/reva/pkg/appctx"
 	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/events"
 	"github.com/cs3org/reva/pkg/rhttp/datatx"
 	"github.com/cs3org/reva/pkg/rhttp/datatx/manager/registry"
 	"github.com/cs3org/reva/pkg/rhttp/datatx/utils/download"
 	"github.com/cs3org/reva/pkg/storage"
+	"github.com/cs3org/reva/pkg/user"
 	"github.com/mitchellh/mapstructure"
 	"github.com/pkg/errors"
 )
@@ -36,10 +39,15 @@ func init() {
 	registry.Register("simple", New)
 }
 
-type config struct{}
+type config struct {
+	// MountPath is the path the storage is mounted at in the global
+	// namespace, published along with the paths of the uploaded files.
+	MountPath string `mapstructure:"mount_path"`
+}
 
 type manager struct {
-	conf *config
+	conf      *config
+	publisher events.Publisher
 }
 
 func parseConfig(m map[string]interface{}) (*config, error) {
@@ -52,13 +60,13 @@ func parseConfig(m map[string]interface{}) (*config, error) {
 }
 
 // New returns a datatx manager implementation that relies on HTTP PUT/GET.
-func New(m map[string]interface{}) (datatx.DataTX, error) {
+func New(m map[string]interface{}, publisher events.Publisher) (datatx.DataTX, error) {
 	c, err := parseConfig(m)
 	if err != nil {
 		return nil, err
 	}
 
-	return &manager{conf: c}, nil
+	return &manager{conf: c, publisher: publisher}, nil
 }
 
 func (m *manager) Handler(fs storage.FS) (http.Handler, error) {
@@ -79,6 +87,20 @@ func (m *manager) Handler(fs storage.FS) (http.Handler, error) {
 			switch v := err.(type) {
 			case nil:
 				w.WriteHeader(http.StatusOK)
+				if u, ok := user.ContextGetUser(ctx); ok {
+					ev := events.FileUploaded{
+						Executant: u.Id,
+						Path:      fn,
+						MountPath: m.conf.MountPath,
+						Time:      time.Now(),
+					}
+					if info, err := fs.GetMD(ctx, ref, nil); err == nil {
+						ev.Owner, ev.ResourceID = info.Owner, info.Id
+					}
+					if err := events.Publish(ctx, m.publisher, ev); err != nil {
+						sublog.Error().Err(err).Msg("error publishing event")
+					}
+				}
 			case errtypes.PartialContent:
 				w.WriteHeader(http.StatusPartialContent)
 			case errtypes.ChecksumMismatch:
@@ -20,14 +20,21 @@ package tus
 
 import (
 	"net/http"
+	"path"
+	"strconv"
+	"time"
 
 	"github.com/pkg/errors"
 
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/appctx"
 	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/events"
 	"github.com/cs3org/reva/pkg/rhttp/datatx"
 	"github.com/cs3org/reva/pkg/rhttp/datatx/manager/registry"
 	"github.com/cs3org/reva/pkg/rhttp/datatx/utils/download"
 	"github.com/cs3org/reva/pkg/storage"
+	"github.com/cs3org/reva/pkg/user"
 	"github.com/mitchellh/mapstructure"
 	tusd "github.com/tus/tusd/pkg/handler"
 )
@@ -36,10 +43,15 @@ func init() {
 	registry.Register("tus", New)
 }
 
-type config struct{}
+type config struct {
+	// MountPath is the path the storage is mounted at in the global
+	// namespace, published along with the paths of the uploaded files.
+	MountPath string `mapstructure:"mount_path"`
+}
 
 type manager struct {
-	conf *config
+	conf      *config
+	publisher events.Publisher
 }
 
 func parseConfig(m map[string]interface{}) (*config, error) {
@@ -52,13 +64,13 @@ func parseConfig(m map[string]interface{}) (*config, error) {
 }
 
 // New returns a datatx manager implementation that relies on HTTP PUT/GET.
-func New(m map[string]interface{}) (datatx.DataTX, error) {
+func New(m map[string]interface{}, publisher events.Publisher) (datatx.DataTX, error) {
 	c, err := parseConfig(m)
 	if err != nil {
 		return nil, err
 	}
 
-	return &manager{conf: c}, nil
+	return &manager{conf: c, publisher: publisher}, nil
 }
 
 func (m *manager) Handler(fs storage.FS) (http.Handler, error) {
@@ -99,7 +111,13 @@ func (m *manager) Handler(fs storage.FS) (http.Handler, error) {
 		case "HEAD":
 			handler.HeadFile(w, r)
 		case "PATCH":
+			// the upload info is read beforehand, as the storage might
+			// discard it once the upload is finished
+			info, err := uploadInfo(r, composer)
 			handler.PatchFile(w, r)
+			if err == nil && !info.SizeIsDeferred && w.Header().Get("Upload-Offset") == strconv.FormatInt(info.Size, 10) {
+				m.publishFileUploaded(r, fs, info)
+			}
 		case "DELETE":
 			handler.DelFile(w, r)
 		case "GET":
@@ -117,3 +135,32 @@ func (m *manager) Handler(fs storage.FS) (http.Handler, error) {
 type composable interface {
 	UseIn(composer *tusd.StoreComposer)
 }
+
+func uploadInfo(r *http.Request, composer *tusd.StoreComposer) (tusd.FileInfo, error) {
+	upload, err := composer.Core.GetUpload(r.Context(), path.Base(r.URL.Path))
+	if err != nil {
+		return tusd.FileInfo{}, err
+	}
+	return upload.GetInfo(r.Context())
+}
+
+func (m *manager) publishFileUploaded(r *http.Request, fs storage.FS, info tusd.FileInfo) {
+	ctx := r.Context()
+	u, ok := user.ContextGetUser(ctx)
+	if !ok {
+		return
+	}
+	ev := events.FileUploaded{
+		Executant: u.Id,
+		Path:      path.Join(info.MetaData["dir"], info.MetaData["filename"]),
+		MountPath: m.conf.MountPath,
+		Time:      time.Now(),
+	}
+	ref := &provider.Reference{Spec: &provider.Reference_Path{Path: ev.Path}}
+	if md, err := fs.GetMD(ctx, ref, nil); err == nil {
+		ev.Owner, ev.ResourceID = md.Owner, md.Id
+	}
+	if err := events.Publish(ctx, m.publisher, ev); err != nil {
+		appctx.GetLogger(ctx).Error().Err(err).Msg("error publishing event")
+	}
+}
@@ -25,8 +25,10 @@ import (
 	"mime/multipart"
 	"net/http"
 	"strconv"
+	"strings"
 
 	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/internal/grpc/services/storageprovider"
 	"github.com/cs3org/reva/pkg/appctx"
 	"github.com/cs3org/reva/pkg/errtypes"
 	"github.com/cs3org/reva/pkg/storage"
@@ -163,6 +165,11 @@ func GetOrHeadFile(w http.ResponseWriter, r *http.Request, fs storage.FS) {
 		w.Header().Set("Content-Length", strconv.FormatInt(sendSize, 10))
 	}
 
+	// the checksum lets the data gateway verify the transferred bytes
+	if code == http.StatusOK && md.Checksum != nil && md.Checksum.Type != provider.ResourceChecksumType_RESOURCE_CHECKSUM_TYPE_UNSET {
+		w.Header().Set("OC-Checksum", fmt.Sprintf("%s:%s", strings.ToUpper(string(storageprovider.GRPC2PKGXS(md.Checksum.Type))), md.Checksum.Sum))
+	}
+
 	w.WriteHeader(code)
 
 	if r.Method != "HEAD" {
@@ -187,6 +194,9 @@ func handleError(w http.ResponseWriter, log *zerolog.Logger, err error, action s
 	case errtypes.IsPermissionDenied:
 		log.Debug().Err(err).Str("action", action).Msg("permission denied")
 		w.WriteHeader(http.StatusForbidden)
+	case errtypes.IsLocked:
+		log.Debug().Err(err).Str("action", action).Msg("resource locked")
+		w.WriteHeader(http.StatusLocked)
 	default:
 		log.Error().Err(err).Str("action", action).Msg("unexpected error")
 		w.WriteHeader(http.StatusInternalServerError)
@@ -20,24 +20,31 @@ package rhttp
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"net"
 	"net/http"
 	"path"
 	"sort"
+	"sync"
 	"time"
 
 	"github.com/cs3org/reva/internal/http/interceptors/appctx"
 	"github.com/cs3org/reva/internal/http/interceptors/auth"
 	"github.com/cs3org/reva/internal/http/interceptors/log"
 	"github.com/cs3org/reva/internal/http/interceptors/providerauthorizer"
+	"github.com/cs3org/reva/pkg/mtls"
 	"github.com/cs3org/reva/pkg/rhttp/global"
 	"github.com/cs3org/reva/pkg/rhttp/router"
 	"github.com/mitchellh/mapstructure"
 	"github.com/pkg/errors"
 	"github.com/rs/zerolog"
-	"go.opencensus.io/plugin/ochttp"
 	"go.opencensus.io/trace"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 )
 
 // New returns a new server
@@ -49,13 +56,22 @@ func New(m interface{}, l zerolog.Logger) (*Server, error) {
 
 	conf.init()
 
-	httpServer := &http.Server{}
+	httpServer := &http.Server{
+		ReadHeaderTimeout: time.Duration(conf.ReadHeaderTimeout) * time.Second,
+		ReadTimeout:       time.Duration(conf.ReadTimeout) * time.Second,
+		WriteTimeout:      time.Duration(conf.WriteTimeout) * time.Second,
+		IdleTimeout:       time.Duration(conf.IdleTimeout) * time.Second,
+		MaxHeaderBytes:    conf.MaxHeaderBytes,
+	}
 	s := &Server{
 		httpServer:  httpServer,
 		conf:        conf,
 		svcs:        map[string]global.Service{},
+		svcNames:    map[string]string{},
 		unprotected: []string{},
 		handlers:    map[string]http.Handler{},
+		chains:      map[string][]string{},
+		instances:   map[string]*middlewareTriple{},
 		log:         l,
 	}
 	return s, nil
@@ -64,13 +80,18 @@ func New(m interface{}, l zerolog.Logger) (*Server, error) {
 // Server contains the server info.
 type Server struct {
 	httpServer  *http.Server
+	acmeServer  *http.Server
 	conf        *config
 	listener    net.Listener
 	svcs        map[string]global.Service // map key is svc Prefix
+	svcNames    map[string]string         // map key is svc Prefix
 	unprotected []string
 	handlers    map[string]http.Handler
+	chains      map[string][]string // map key is svc Prefix
 	middlewares []*middlewareTriple
+	instances   map[string]*middlewareTriple // map key is middleware name
 	log         zerolog.Logger
+	closeOnce   sync.Once
 }
 
 type config struct {
@@ -78,6 +99,45 @@ type config struct {
 	Address     string                            `mapstructure:"address"`
 	Services    map[string]map[string]interface{} `mapstructure:"services"`
 	Middlewares map[string]map[string]interface{} `mapstructure:"middlewares"`
+	// TLS serves HTTPS with the certificate files or, when domains are
+	// configured, with the certificates obtained from an ACME authority.
+	TLS tlsConfig `mapstructure:"tls"`
+	// DisableHTTP2 disables HTTP/2, negotiated by default over TLS.
+	DisableHTTP2 bool `mapstructure:"disable_http2"`
+	// H2C serves HTTP/2 without TLS, for the reverse proxies speaking it.
+	H2C bool `mapstructure:"h2c"`
+
+	// ReadHeaderTimeout is the time in seconds allowed to the clients to send
+	// the headers of a request, protecting from the slowloris attacks.
+	ReadHeaderTimeout int `mapstructure:"read_header_timeout"`
+	// ReadTimeout and WriteTimeout are the times in seconds allowed to read
+	// a whole request and to write its response. They bound the duration of
+	// the transfers and are disabled by default.
+	ReadTimeout  int `mapstructure:"read_timeout"`
+	WriteTimeout int `mapstructure:"write_timeout"`
+	// IdleTimeout is the time in seconds the idle keep-alive connections
+	// are kept open.
+	IdleTimeout int `mapstructure:"idle_timeout"`
+	// MaxHeaderBytes is the maximum size of the request headers.
+	MaxHeaderBytes int `mapstructure:"max_header_bytes"`
+}
+
+type tlsConfig struct {
+	mtls.ServerConfig `mapstructure:",squash"`
+	ACME              acmeConfig `mapstructure:"acme"`
+}
+
+type acmeConfig struct {
+	Domains []string `mapstructure:"domains"`
+	Email   string   `mapstructure:"email"`
+	// CacheDir stores the account key and the certificates, which must
+	// survive the restarts not to hit the rate limits of the authority.
+	CacheDir     string `mapstructure:"cache_dir"`
+	DirectoryURL string `mapstructure:"directory_url"`
+	// HTTPAddress is the address serving the HTTP-01 challenges and
+	// redirecting to HTTPS. If empty, the TLS-ALPN-01 challenges are
+	// answered on the HTTPS address.
+	HTTPAddress string `mapstructure:"http_address"`
 }
 
 func (c *config) init() {
@@ -89,6 +149,23 @@ func (c *config) init() {
 	if c.Address == "" {
 		c.Address = "0.0.0.0:19001"
 	}
+
+	if c.ReadHeaderTimeout == 0 {
+		c.ReadHeaderTimeout = 30
+	}
+	if c.IdleTimeout == 0 {
+		c.IdleTimeout = 120
+	}
+	if c.MaxHeaderBytes == 0 {
+		c.MaxHeaderBytes = http.DefaultMaxHeaderBytes
+	}
+
+	if c.TLS.ClientAuth == "" {
+		c.TLS.ClientAuth = mtls.ClientAuthNone
+	}
+	if len(c.TLS.ACME.Domains) > 0 && c.TLS.ACME.CacheDir == "" {
+		c.TLS.ACME.CacheDir = "/var/tmp/reva/acme"
+	}
 }
 
 // Start starts the server
@@ -106,23 +183,95 @@ func (s *Server) Start(ln net.Listener) error {
 		return errors.Wrap(err, "rhttp: error creating http handler")
 	}
 
+	tlsConf, err := s.getTLSConfig()
+	if err != nil {
+		return errors.Wrap(err, "rhttp: error configuring tls")
+	}
+
+	if s.conf.DisableHTTP2 {
+		s.httpServer.TLSNextProto = map[string]func(*http.Server, *tls.Conn, http.Handler){}
+		if tlsConf != nil {
+			tlsConf = withoutHTTP2(tlsConf)
+		}
+	} else if s.conf.H2C && tlsConf == nil {
+		handler = h2c.NewHandler(handler, &http2.Server{})
+	}
 	s.httpServer.Handler = handler
 	s.listener = ln
 
-	s.log.Info().Msgf("http server listening at %s://%s", "http", s.conf.Address)
-	err = s.httpServer.Serve(s.listener)
+	if tlsConf != nil {
+		s.httpServer.TLSConfig = tlsConf
+		s.log.Info().Msgf("http server listening at %s://%s", "https", s.conf.Address)
+		err = s.httpServer.ServeTLS(s.listener, "", "")
+	} else {
+		s.log.Info().Msgf("http server listening at %s://%s", "http", s.conf.Address)
+		err = s.httpServer.Serve(s.listener)
+	}
 	if err == nil || err == http.ErrServerClosed {
 		return nil
 	}
 	return err
 }
 
+// getTLSConfig returns the TLS configuration of the server, or nil if it
+// serves plain HTTP.
+func (s *Server) getTLSConfig() (*tls.Config, error) {
+	acmeConf := s.conf.TLS.ACME
+	if len(acmeConf.Domains) == 0 {
+		if !s.conf.TLS.Enabled() {
+			return nil, nil
+		}
+		return mtls.NewServerTLSConfig(&s.conf.TLS.ServerConfig)
+	}
+	if s.conf.TLS.Enabled() {
+		return nil, errors.New("the certificate files and acme cannot be configured together")
+	}
+
+	m := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(acmeConf.Domains...),
+		Cache:      autocert.DirCache(acmeConf.CacheDir),
+		Email:      acmeConf.Email,
+	}
+	if acmeConf.DirectoryURL != "" {
+		m.Client = &acme.Client{DirectoryURL: acmeConf.DirectoryURL}
+	}
+	if acmeConf.HTTPAddress != "" {
+		s.acmeServer = &http.Server{Addr: acmeConf.HTTPAddress, Handler: m.HTTPHandler(nil)}
+		go func() {
+			s.log.Info().Msgf("http server answering the acme challenges at %s", acmeConf.HTTPAddress)
+			if err := s.acmeServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				s.log.Error().Err(err).Msg("error serving the acme challenges")
+			}
+		}()
+	}
+	s.log.Info().Msgf("obtaining the certificates of %v from acme", acmeConf.Domains)
+	return m.TLSConfig(), nil
+}
+
+// withoutHTTP2 returns a copy of the TLS configuration not offering HTTP/2
+// to the clients, which the acme configuration does by default.
+func withoutHTTP2(c *tls.Config) *tls.Config {
+	c = c.Clone()
+	protos := []string{}
+	for _, p := range c.NextProtos {
+		if p != "h2" {
+			protos = append(protos, p)
+		}
+	}
+	c.NextProtos = protos
+	return c
+}
+
 // Stop stops the server.
 func (s *Server) Stop() error {
 	s.closeServices()
 	// TODO(labkode): set ctx deadline to zero
 	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 	defer cancel()
+	if s.acmeServer != nil {
+		_ = s.acmeServer.Shutdown(ctx)
+	}
 	return s.httpServer.Shutdown(ctx)
 }
 
@@ -130,13 +279,17 @@ func (s *Server) Stop() error {
 // What do we do in case a service cannot be properly closed? Now we just log the error.
 // TODO(labkode): the close should be given a deadline using context.Context.
 func (s *Server) closeServices() {
-	for _, svc := range s.svcs {
-		if err := svc.Close(); err != nil {
-			s.log.Error().Err(err).Msgf("error closing service %q", svc.Prefix())
-		} else {
-			s.log.Info().Msgf("service %q correctly closed", svc.Prefix())
+	// the services are closed once, as a graceful stop can be followed by a
+	// hard one when the deadline is reached.
+	s.closeOnce.Do(func() {
+		for _, svc := range s.svcs {
+			if err := svc.Close(); err != nil {
+				s.log.Error().Err(err).Msgf("error closing service %q", svc.Prefix())
+			} else {
+				s.log.Info().Msgf("service %q correctly closed", svc.Prefix())
+			}
 		}
-	}
+	})
 }
 
 // Network return the network type.
@@ -149,10 +302,15 @@ func (s *Server) Address() string {
 	return s.conf.Address
 }
 
-// GracefulStop gracefully stops the server.
+// GracefulStop gracefully stops the server, waiting for the in-flight
+// requests to complete before closing the services.
 func (s *Server) GracefulStop() error {
+	if s.acmeServer != nil {
+		_ = s.acmeServer.Shutdown(context.Background())
+	}
+	err := s.httpServer.Shutdown(context.Background())
 	s.closeServices()
-	return s.httpServer.Shutdown(context.Background())
+	return err
 }
 
 // middlewareTriple represents a middleware with the
@@ -172,11 +330,13 @@ func (s *Server) registerMiddlewares() error {
 				err = errors.Wrapf(err, "error creating new middleware: %s,", name)
 				return err
 			}
-			middlewares = append(middlewares, &middlewareTriple{
+			triple := &middlewareTriple{
 				Name:       name,
 				Priority:   prio,
 				Middleware: m,
-			})
+			}
+			middlewares = append(middlewares, triple)
+			s.instances[name] = triple
 			s.log.Info().Msgf("http middleware enabled: %s", name)
 		}
 	}
@@ -201,8 +361,17 @@ func (s *Server) registerServices() error {
 
 			// instrument services with opencensus tracing.
 			h := traceHandler(svcName, svc.Handler())
+			chain, err := getChain(s.conf.Services[svcName])
+			if err != nil {
+				_ = svc.Close()
+				return errors.Wrapf(err, "http service %s could not be started,", svcName)
+			}
+			if chain != nil {
+				s.chains[svc.Prefix()] = chain
+			}
 			s.handlers[svc.Prefix()] = h
 			s.svcs[svc.Prefix()] = svc
+			s.svcNames[svc.Prefix()] = svcName
 			s.unprotected = append(s.unprotected, getUnprotected(svc.Prefix(), svc.Unprotected())...)
 			s.log.Info().Msgf("http service enabled: %s@/%s", svcName, svc.Prefix())
 		} else {
@@ -213,6 +382,74 @@ func (s *Server) registerServices() error {
 	return nil
 }
 
+// getChain returns the ordered list of middlewares declared by a service in
+// its configuration, or nil if the service uses the chain of the server.
+func getChain(conf map[string]interface{}) ([]string, error) {
+	c := struct {
+		Middlewares []string `mapstructure:"middlewares"`
+	}{}
+	if err := mapstructure.Decode(conf, &c); err != nil {
+		return nil, errors.Wrap(err, "error decoding middlewares")
+	}
+	if _, ok := conf["middlewares"]; !ok {
+		return nil, nil
+	}
+	for _, name := range c.Middlewares {
+		if _, ok := global.NewMiddlewares[name]; !ok && name != "auth" {
+			return nil, fmt.Errorf("http middleware %s does not exist", name)
+		}
+	}
+	return c.Middlewares, nil
+}
+
+// middleware returns the instance of the middleware shared by the chains of
+// the server and of the services, creating it on first use.
+func (s *Server) middleware(name string) (*middlewareTriple, error) {
+	if triple, ok := s.instances[name]; ok {
+		return triple, nil
+	}
+	m, prio, err := global.NewMiddlewares[name](s.conf.Middlewares[name])
+	if err != nil {
+		return nil, errors.Wrapf(err, "error creating new middleware: %s,", name)
+	}
+	triple := &middlewareTriple{Name: name, Priority: prio, Middleware: m}
+	s.instances[name] = triple
+	return triple, nil
+}
+
+// chainHandler wraps the handler of a service with its own middlewares and
+// the authentication, which always applies. They are sorted by priority like
+// the ones of the server, the first one listed being the outermost of the
+// middlewares of the same priority.
+func (s *Server) chainHandler(prefix string, h http.Handler) (http.Handler, error) {
+	chain := s.chains[prefix]
+	triples := []*middlewareTriple{}
+	seen := map[string]bool{}
+	for i := len(chain) - 1; i >= 0; i-- {
+		if seen[chain[i]] {
+			continue
+		}
+		seen[chain[i]] = true
+		triple, err := s.middleware(chain[i])
+		if err != nil {
+			return nil, err
+		}
+		triples = append(triples, triple)
+	}
+	if !seen["auth"] {
+		triples = append(triples, s.instances["auth"])
+	}
+	sort.SliceStable(triples, func(i, j int) bool {
+		return triples[i].Priority > triples[j].Priority
+	})
+
+	for _, triple := range triples {
+		h = triple.Middleware(traceHandler(triple.Name, h))
+	}
+	s.log.Info().Msgf("chaining http middlewares %v for service %q", chain, s.svcNames[prefix])
+	return h, nil
+}
+
 func (s *Server) isServiceEnabled(svcName string) bool {
 	_, ok := global.Services[svcName]
 	return ok
@@ -228,11 +465,57 @@ func getUnprotected(prefix string, unprotected []string) []string {
 }
 
 func (s *Server) getHandler() (http.Handler, error) {
-	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	for _, v := range s.unprotected {
+		s.log.Info().Msgf("unprotected URL: %s", v)
+	}
+	// the authentication is ordered with the middlewares of the server, for
+	// the ones limiting or filtering the clients to run before it.
+	authMiddle, authPrio, err := auth.New(s.conf.Middlewares["auth"], s.unprotected)
+	if err != nil {
+		return nil, errors.Wrap(err, "rhttp: error creating auth middleware")
+	}
+	authTriple := &middlewareTriple{Name: "auth", Priority: authPrio, Middleware: authMiddle}
+	s.instances["auth"] = authTriple
+	s.middlewares = append(s.middlewares, authTriple)
+
+	// sort middlewares by priority.
+	sort.SliceStable(s.middlewares, func(i, j int) bool {
+		return s.middlewares[i].Priority > s.middlewares[j].Priority
+	})
+	for _, triple := range s.middlewares {
+		s.log.Info().Msgf("chaining http middleware %s with priority  %d", triple.Name, triple.Priority)
+	}
+
+	// the services declaring their own chain of middlewares skip the one of
+	// the server, which is applied to the others after the routing. The
+	// middlewares still see the full path, the prefix being shifted after them.
+	for prefix, svc := range s.handlers {
+		svc := svc
+		h := svc
+		if prefix != "" {
+			h = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, r.URL.Path = router.ShiftPath(r.URL.Path)
+				svc.ServeHTTP(w, r)
+			})
+		}
+		if _, ok := s.chains[prefix]; ok {
+			h, err = s.chainHandler(prefix, h)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			h = s.chain(h)
+		}
+		s.handlers[prefix] = h
+	}
+	notFound := s.chain(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		head, tail := router.ShiftPath(r.URL.Path)
 		if h, ok := s.handlers[head]; ok {
-			r.URL.Path = tail
-			s.log.Debug().Msgf("http routing: head=%s tail=%s svc=%s", head, r.URL.Path, head)
+			s.log.Debug().Msgf("http routing: head=%s tail=%s svc=%s", head, tail, head)
 			h.ServeHTTP(w, r)
 			return
 		}
@@ -246,29 +529,9 @@ func (s *Server) getHandler() (http.Handler, error) {
 		}
 
 		s.log.Debug().Msgf("http routing: head=%s tail=%s svc=not-found", head, tail)
-		w.WriteHeader(http.StatusNotFound)
-	})
-
-	// sort middlewares by priority.
-	sort.SliceStable(s.middlewares, func(i, j int) bool {
-		return s.middlewares[i].Priority > s.middlewares[j].Priority
+		notFound.ServeHTTP(w, r)
 	})
 
-	handler := http.Handler(h)
-
-	for _, triple := range s.middlewares {
-		s.log.Info().Msgf("chaining http middleware %s with priority  %d", triple.Name, triple.Priority)
-		handler = triple.Middleware(traceHandler(triple.Name, handler))
-	}
-
-	for _, v := range s.unprotected {
-		s.log.Info().Msgf("unprotected URL: %s", v)
-	}
-	authMiddle, err := auth.New(s.conf.Middlewares["auth"], s.unprotected)
-	if err != nil {
-		return nil, errors.Wrap(err, "rhttp: error creating auth middleware")
-	}
-
 	// add always the logctx middleware as most priority, this middleware is internal
 	// and cannot be configured from the configuration.
 	coreMiddlewares := []*middlewareTriple{}
@@ -281,22 +544,25 @@ func (s *Server) getHandler() (http.Handler, error) {
 		coreMiddlewares = append(coreMiddlewares, &middlewareTriple{Middleware: providerAuthMiddle, Name: "providerauthorizer"})
 	}
 
-	coreMiddlewares = append(coreMiddlewares, &middlewareTriple{Middleware: authMiddle, Name: "auth"})
 	coreMiddlewares = append(coreMiddlewares, &middlewareTriple{Middleware: log.New(), Name: "log"})
-	coreMiddlewares = append(coreMiddlewares, &middlewareTriple{Middleware: appctx.New(s.log), Name: "appctx"})
+	coreMiddlewares = append(coreMiddlewares, &middlewareTriple{Middleware: appctx.New(s.log, s.svcNames), Name: "appctx"})
 
+	var h http.Handler = handler
 	for _, triple := range coreMiddlewares {
-		handler = triple.Middleware(traceHandler(triple.Name, handler))
+		h = triple.Middleware(traceHandler(triple.Name, h))
 	}
 
-	// use opencensus handler to trace endpoints.
-	// TODO(labkode): enable also opencensus telemetry.
-	handler = &ochttp.Handler{
-		Handler: handler,
-		//IsPublicEndpoint: true,
-	}
+	// trace the endpoints, continuing the traces of the callers.
+	return otelhttp.NewHandler(h, "rhttp"), nil
+}
 
-	return handler, nil
+// chain wraps a handler with the middlewares of the server, sorted by
+// priority.
+func (s *Server) chain(h http.Handler) http.Handler {
+	for _, triple := range s.middlewares {
+		h = triple.Middleware(traceHandler(triple.Name, h))
+	}
+	return h
 }
 
 func traceHandler(name string, h http.Handler) http.Handler {
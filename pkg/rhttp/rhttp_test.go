@@ -0,0 +1,162 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package rhttp
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	_ "github.com/cs3org/reva/internal/http/interceptors/auth/credential/loader"
+	_ "github.com/cs3org/reva/internal/http/interceptors/auth/token/loader"
+	_ "github.com/cs3org/reva/internal/http/interceptors/auth/tokenwriter/loader"
+	"github.com/cs3org/reva/pkg/rhttp/global"
+	_ "github.com/cs3org/reva/pkg/token/manager/loader"
+	"github.com/rs/zerolog"
+)
+
+// tag returns a middleware adding its name to the X-Chain header of the
+// response.
+func tag(name string) global.Middleware {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("X-Chain", name)
+			h.ServeHTTP(w, r)
+		})
+	}
+}
+
+// created counts the instances of the test middlewares.
+var created = map[string]int{}
+
+func init() {
+	for name, prio := range map[string]int{"test-outer": 0, "test-inner": 0, "test-late": 50} {
+		name, prio := name, prio
+		global.NewMiddlewares[name] = func(map[string]interface{}) (global.Middleware, int, error) {
+			created[name]++
+			return tag(name), prio, nil
+		}
+	}
+}
+
+func TestMiddlewareChains(t *testing.T) {
+	s, err := New(map[string]interface{}{
+		"middlewares": map[string]interface{}{
+			"auth": map[string]interface{}{
+				"token_managers": map[string]interface{}{"jwt": map[string]interface{}{"secret": "changemeplease"}},
+			},
+		},
+	}, zerolog.Nop())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	path := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.URL.Path))
+	})
+	s.handlers = map[string]http.Handler{"own": path, "late": path, "shared": path, "bare": path}
+	s.chains = map[string][]string{"own": {"test-outer", "test-inner"}, "late": {"test-late", "auth", "test-inner"}, "bare": {}}
+	s.middlewares = []*middlewareTriple{
+		{Name: "test-server", Priority: 100, Middleware: tag("test-server")},
+		{Name: "test-early", Priority: 10, Middleware: tag("test-early")},
+	}
+	s.unprotected = []string{"/own/public", "/late/public", "/shared/public", "/bare", "/unknown"}
+
+	created = map[string]int{}
+	h, err := s.getHandler()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the services share the instances of the middlewares
+	if created["test-inner"] != 1 {
+		t.Errorf("test-inner was created %d times, wanted once", created["test-inner"])
+	}
+
+	tests := []struct {
+		url   string
+		code  int
+		chain string
+		path  string
+	}{
+		{"/own/public/file", http.StatusOK, "test-outer,test-inner", "/public/file"},
+		{"/shared/public/file", http.StatusOK, "test-early,test-server", "/public/file"},
+		// the chains are sorted by priority
+		{"/late/public/file", http.StatusOK, "test-inner,test-late", "/public/file"},
+		{"/bare/file", http.StatusOK, "", "/file"},
+		{"/unknown/file", http.StatusNotFound, "test-early,test-server", ""},
+		// the services with their own chain are still protected, and the
+		// middlewares with a lower priority than auth run before it
+		{"/own/private", http.StatusUnauthorized, "test-outer,test-inner", ""},
+		{"/late/private", http.StatusUnauthorized, "test-inner", ""},
+		{"/shared/private", http.StatusUnauthorized, "test-early", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.url, nil))
+			if w.Code != tt.code {
+				t.Fatalf("got status %d, wanted %d", w.Code, tt.code)
+			}
+			if chain := strings.Join(w.Header().Values("X-Chain"), ","); chain != tt.chain {
+				t.Errorf("went through %q, wanted %q", chain, tt.chain)
+			}
+			if tt.code == http.StatusOK && w.Body.String() != tt.path {
+				t.Errorf("the service got the path %s, wanted %s", w.Body.String(), tt.path)
+			}
+		})
+	}
+}
+
+func TestWithoutHTTP2(t *testing.T) {
+	c := &tls.Config{NextProtos: []string{"h2", "http/1.1", "acme-tls/1"}}
+	if protos := strings.Join(withoutHTTP2(c).NextProtos, ","); protos != "http/1.1,acme-tls/1" {
+		t.Errorf("got the protocols %s", protos)
+	}
+	if len(c.NextProtos) != 3 {
+		t.Errorf("the original configuration was modified")
+	}
+}
+
+func TestGetChain(t *testing.T) {
+	tests := []struct {
+		name  string
+		conf  map[string]interface{}
+		chain []string
+		err   bool
+	}{
+		{"chain of the server", map[string]interface{}{"prefix": "own"}, nil, false},
+		{"no middleware", map[string]interface{}{"middlewares": []string{}}, []string{}, false},
+		{"own chain", map[string]interface{}{"middlewares": []string{"test-inner", "test-outer"}}, []string{"test-inner", "test-outer"}, false},
+		{"auth by name", map[string]interface{}{"middlewares": []string{"auth", "test-inner"}}, []string{"auth", "test-inner"}, false},
+		{"unknown middleware", map[string]interface{}{"middlewares": []string{"test-inner", "unknown"}}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chain, err := getChain(tt.conf)
+			if (err != nil) != tt.err {
+				t.Fatalf("got error %v, wanted an error: %v", err, tt.err)
+			}
+			if (chain == nil) != (tt.chain == nil) || strings.Join(chain, ",") != strings.Join(tt.chain, ",") {
+				t.Errorf("got %v, wanted %v", chain, tt.chain)
+			}
+		})
+	}
+}
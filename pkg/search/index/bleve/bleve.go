@@ -0,0 +1,199 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package bleve
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/analysis/analyzer/keyword"
+	"github.com/blevesearch/bleve/v2/mapping"
+	"github.com/blevesearch/bleve/v2/search/query"
+	"github.com/cs3org/reva/pkg/search"
+	"github.com/cs3org/reva/pkg/search/index/registry"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	registry.Register("bleve", New)
+}
+
+type config struct {
+	// Root is the directory the index is stored in.
+	Root string `mapstructure:"root"`
+}
+
+func (c *config) init() {
+	if c.Root == "" {
+		c.Root = "/var/tmp/reva/search"
+	}
+}
+
+func parseConfig(m map[string]interface{}) (*config, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		err = errors.Wrap(err, "error decoding conf")
+		return nil, err
+	}
+	c.init()
+	return c, nil
+}
+
+// indexDocument is the document as stored by bleve. The whole document is
+// kept in raw, which is not indexed, to be returned by the searches.
+type indexDocument struct {
+	Name string `json:"name"`
+	// Filename is the name of the file in lower case, for the matches on
+	// parts of names.
+	Filename string            `json:"filename"`
+	MimeType string            `json:"mime_type"`
+	Metadata map[string]string `json:"metadata"`
+	Content  string            `json:"content"`
+	Raw      string            `json:"raw"`
+}
+
+type index struct {
+	idx bleve.Index
+}
+
+// New returns a search index embedded in the process, stored on the local
+// filesystem.
+func New(m map[string]interface{}) (search.Index, error) {
+	c, err := parseConfig(m)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(c.Root, 0700); err != nil {
+		return nil, errors.Wrap(err, "bleve: error creating index directory")
+	}
+
+	p := filepath.Join(c.Root, "index.bleve")
+	idx, err := bleve.Open(p)
+	if err == bleve.ErrorIndexPathDoesNotExist {
+		idx, err = bleve.New(p, indexMapping())
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "bleve: error opening index")
+	}
+	return &index{idx: idx}, nil
+}
+
+// newMemOnly returns a search index kept in memory, for the tests.
+func newMemOnly() (search.Index, error) {
+	idx, err := bleve.NewMemOnly(indexMapping())
+	if err != nil {
+		return nil, err
+	}
+	return &index{idx: idx}, nil
+}
+
+func indexMapping() mapping.IndexMapping {
+	kw := bleve.NewTextFieldMapping()
+	kw.Analyzer = keyword.Name
+
+	content := bleve.NewTextFieldMapping()
+	content.Store = false
+
+	raw := bleve.NewTextFieldMapping()
+	raw.Index = false
+	raw.IncludeInAll = false
+
+	doc := bleve.NewDocumentMapping()
+	doc.AddFieldMappingsAt("name", bleve.NewTextFieldMapping())
+	doc.AddFieldMappingsAt("filename", kw)
+	doc.AddFieldMappingsAt("mime_type", kw)
+	doc.AddFieldMappingsAt("content", content)
+	doc.AddFieldMappingsAt("raw", raw)
+
+	m := bleve.NewIndexMapping()
+	m.DefaultMapping = doc
+	return m
+}
+
+func (i *index) Upsert(ctx context.Context, d *search.Document) error {
+	stored := *d
+	stored.Content = ""
+	raw, err := json.Marshal(&stored)
+	if err != nil {
+		return errors.Wrap(err, "bleve: error encoding document")
+	}
+	err = i.idx.Index(d.ID(), &indexDocument{
+		Name:     d.Name,
+		Filename: strings.ToLower(d.Name),
+		MimeType: d.MimeType,
+		Metadata: d.Metadata,
+		Content:  d.Content,
+		Raw:      string(raw),
+	})
+	return errors.Wrap(err, "bleve: error indexing document")
+}
+
+func (i *index) Delete(ctx context.Context, id string) error {
+	return errors.Wrap(i.idx.Delete(id), "bleve: error deleting document")
+}
+
+func (i *index) Close() error {
+	return i.idx.Close()
+}
+
+func (i *index) Search(ctx context.Context, q *search.Query) ([]*search.Match, error) {
+	req := bleve.NewSearchRequestOptions(buildQuery(q), q.Limit, q.Offset, false)
+	req.Fields = []string{"raw"}
+	res, err := i.idx.SearchInContext(ctx, req)
+	if err != nil {
+		return nil, errors.Wrap(err, "bleve: error searching index")
+	}
+
+	matches := make([]*search.Match, 0, len(res.Hits))
+	for _, hit := range res.Hits {
+		raw, ok := hit.Fields["raw"].(string)
+		if !ok {
+			continue
+		}
+		d := &search.Document{}
+		if err := json.Unmarshal([]byte(raw), d); err != nil {
+			continue
+		}
+		matches = append(matches, &search.Match{Document: d, Score: hit.Score})
+	}
+	return matches, nil
+}
+
+func buildQuery(q *search.Query) query.Query {
+	conjuncts := []query.Query{}
+	if q.Term != "" {
+		wildcard := bleve.NewWildcardQuery("*" + strings.ToLower(q.Term) + "*")
+		wildcard.SetField("filename")
+		conjuncts = append(conjuncts, bleve.NewDisjunctionQuery(bleve.NewMatchQuery(q.Term), wildcard))
+	}
+	for k, v := range q.Metadata {
+		mq := bleve.NewMatchPhraseQuery(v)
+		mq.SetField("metadata." + k)
+		conjuncts = append(conjuncts, mq)
+	}
+	if len(conjuncts) == 0 {
+		return bleve.NewMatchAllQuery()
+	}
+	return bleve.NewConjunctionQuery(conjuncts...)
+}
@@ -0,0 +1,72 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package bleve
+
+import (
+	"context"
+	"testing"
+
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/search"
+)
+
+func TestSearch(t *testing.T) {
+	ctx := context.Background()
+	idx, err := newMemOnly()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	docs := []*search.Document{
+		{Name: "Quarterly-Report.txt", Content: "revenue and costs", Metadata: map[string]string{"project": "apollo"}},
+		{Name: "notes.md", Content: "meeting about the quarterly report", Metadata: map[string]string{"project": "gemini"}},
+		{Name: "holidays.jpg"},
+	}
+	for i, d := range docs {
+		d.ResourceID = &provider.ResourceId{StorageId: "storage", OpaqueId: d.Name}
+		d.Path = "/home/" + d.Name
+		if err := idx.Upsert(ctx, docs[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	matches, err := idx.Search(ctx, &search.Query{Term: "report", Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 2 {
+		t.Fatalf("expected 2 matches, got %d", len(matches))
+	}
+	if matches[0].Content != "" {
+		t.Fatal("expected the content not to be returned")
+	}
+
+	matches, _ = idx.Search(ctx, &search.Query{Term: "report", Metadata: map[string]string{"project": "apollo"}, Limit: 10})
+	if len(matches) != 1 || matches[0].Name != "Quarterly-Report.txt" || matches[0].Path != "/home/Quarterly-Report.txt" {
+		t.Fatalf("unexpected matches %+v", matches)
+	}
+
+	if err := idx.Delete(ctx, docs[0].ID()); err != nil {
+		t.Fatal(err)
+	}
+	matches, _ = idx.Search(ctx, &search.Query{Metadata: map[string]string{"project": "apollo"}, Limit: 10})
+	if len(matches) != 0 {
+		t.Fatalf("expected no match after delete, got %d", len(matches))
+	}
+}
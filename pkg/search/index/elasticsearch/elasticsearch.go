@@ -0,0 +1,221 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/rhttp"
+	"github.com/cs3org/reva/pkg/search"
+	"github.com/cs3org/reva/pkg/search/index/registry"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	registry.Register("elasticsearch", New)
+}
+
+// The documents are stored with the dynamic mapping of Elasticsearch, the
+// metadata values being matched against their keyword sub-fields.
+
+type config struct {
+	// Address is the URL of the Elasticsearch cluster.
+	Address  string `mapstructure:"address"`
+	Index    string `mapstructure:"index"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	Insecure bool   `mapstructure:"insecure"`
+	// Timeout is the timeout in seconds of the requests to the cluster.
+	Timeout int `mapstructure:"timeout"`
+}
+
+func (c *config) init() {
+	if c.Address == "" {
+		c.Address = "http://localhost:9200"
+	}
+	c.Address = strings.TrimSuffix(c.Address, "/")
+	if c.Index == "" {
+		c.Index = "reva"
+	}
+	if c.Timeout == 0 {
+		c.Timeout = 10
+	}
+}
+
+func parseConfig(m map[string]interface{}) (*config, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		err = errors.Wrap(err, "error decoding conf")
+		return nil, err
+	}
+	c.init()
+	return c, nil
+}
+
+type index struct {
+	c      *config
+	client *http.Client
+}
+
+// New returns a search index stored in an Elasticsearch cluster.
+func New(m map[string]interface{}) (search.Index, error) {
+	c, err := parseConfig(m)
+	if err != nil {
+		return nil, err
+	}
+	client := rhttp.GetHTTPClient(
+		rhttp.Insecure(c.Insecure),
+		rhttp.Timeout(time.Duration(c.Timeout)*time.Second),
+	)
+	return &index{c: c, client: client}, nil
+}
+
+func (i *index) docURL(id string) string {
+	return fmt.Sprintf("%s/%s/_doc/%s", i.c.Address, url.PathEscape(i.c.Index), url.PathEscape(id))
+}
+
+func (i *index) do(ctx context.Context, method, u string, body interface{}) (*http.Response, error) {
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return nil, errors.Wrap(err, "elasticsearch: error encoding request")
+		}
+		r = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, r)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if i.c.Username != "" {
+		req.SetBasicAuth(i.c.Username, i.c.Password)
+	}
+	res, err := i.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "elasticsearch: error sending request")
+	}
+	return res, nil
+}
+
+func checkResponse(res *http.Response) error {
+	if res.StatusCode >= 200 && res.StatusCode < 300 {
+		return nil
+	}
+	b, _ := ioutil.ReadAll(io.LimitReader(res.Body, 1024))
+	return errtypes.InternalError(fmt.Sprintf("elasticsearch: unexpected status %d: %s", res.StatusCode, b))
+}
+
+func (i *index) Upsert(ctx context.Context, d *search.Document) error {
+	res, err := i.do(ctx, http.MethodPut, i.docURL(d.ID()), d)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	return checkResponse(res)
+}
+
+func (i *index) Delete(ctx context.Context, id string) error {
+	res, err := i.do(ctx, http.MethodDelete, i.docURL(id), nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	return checkResponse(res)
+}
+
+type searchResponse struct {
+	Hits struct {
+		Hits []struct {
+			Score  float64          `json:"_score"`
+			Source *search.Document `json:"_source"`
+		} `json:"hits"`
+	} `json:"hits"`
+}
+
+func (i *index) Search(ctx context.Context, q *search.Query) ([]*search.Match, error) {
+	u := fmt.Sprintf("%s/%s/_search", i.c.Address, url.PathEscape(i.c.Index))
+	res, err := i.do(ctx, http.MethodPost, u, buildQuery(q))
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		// nothing has been indexed yet
+		return []*search.Match{}, nil
+	}
+	if err := checkResponse(res); err != nil {
+		return nil, err
+	}
+
+	sr := &searchResponse{}
+	if err := json.NewDecoder(res.Body).Decode(sr); err != nil {
+		return nil, errors.Wrap(err, "elasticsearch: error decoding response")
+	}
+	matches := make([]*search.Match, 0, len(sr.Hits.Hits))
+	for _, hit := range sr.Hits.Hits {
+		if hit.Source == nil {
+			continue
+		}
+		matches = append(matches, &search.Match{Document: hit.Source, Score: hit.Score})
+	}
+	return matches, nil
+}
+
+type m map[string]interface{}
+
+func buildQuery(q *search.Query) m {
+	must := []m{}
+	if q.Term != "" {
+		must = append(must, m{"bool": m{
+			"should": []m{
+				{"multi_match": m{"query": q.Term, "fields": []string{"name^2", "metadata.*", "content"}}},
+				{"wildcard": m{"name.keyword": m{"value": "*" + q.Term + "*", "case_insensitive": true}}},
+			},
+			"minimum_should_match": 1,
+		}})
+	}
+	filter := []m{}
+	for k, v := range q.Metadata {
+		filter = append(filter, m{"term": m{"metadata." + k + ".keyword": v}})
+	}
+	if len(must) == 0 {
+		must = append(must, m{"match_all": m{}})
+	}
+	return m{
+		"from":    q.Offset,
+		"size":    q.Limit,
+		"_source": m{"excludes": []string{"content"}},
+		"query":   m{"bool": m{"must": must, "filter": filter}},
+	}
+}
@@ -0,0 +1,321 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package search
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strings"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/internal/http/services/datagateway"
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/auth/scope"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/events"
+	eventsregistry "github.com/cs3org/reva/pkg/events/driver/registry"
+	"github.com/cs3org/reva/pkg/rgrpc/todo/pool"
+	"github.com/cs3org/reva/pkg/rhttp"
+	"github.com/cs3org/reva/pkg/sharedconf"
+	"github.com/cs3org/reva/pkg/token"
+	tokenregistry "github.com/cs3org/reva/pkg/token/manager/registry"
+	"github.com/cs3org/reva/pkg/utils"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+	"google.golang.org/grpc/metadata"
+)
+
+// IndexerConfig is the configuration of the indexer.
+type IndexerConfig struct {
+	GatewaySvc    string                            `mapstructure:"gatewaysvc"`
+	TokenManager  string                            `mapstructure:"token_manager"`
+	TokenManagers map[string]map[string]interface{} `mapstructure:"token_managers"`
+	// ExtractContent enables the indexing of the content of the text files.
+	ExtractContent bool `mapstructure:"extract_content"`
+	// MaxContentSize is the size in bytes above which the content of the
+	// files is not indexed.
+	MaxContentSize uint64 `mapstructure:"max_content_size"`
+	Insecure       bool   `mapstructure:"insecure"`
+	// Events configures the bus the events are consumed from.
+	Events map[string]interface{} `mapstructure:"events"`
+}
+
+// ParseIndexerConfig decodes the configuration of the indexer from a map.
+func ParseIndexerConfig(m map[string]interface{}) (*IndexerConfig, error) {
+	c := &IndexerConfig{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, errors.Wrap(err, "search: error decoding conf")
+	}
+	if c.TokenManager == "" {
+		c.TokenManager = "jwt"
+	}
+	if c.MaxContentSize == 0 {
+		c.MaxContentSize = 1 << 20
+	}
+	c.GatewaySvc = sharedconf.GetGatewaySVC(c.GatewaySvc)
+	return c, nil
+}
+
+// Indexer adds the files uploaded to the storages to an index.
+type Indexer struct {
+	c      *IndexerConfig
+	idx    Index
+	tm     token.Manager
+	stream events.Consumer
+	client *http.Client
+	log    *zerolog.Logger
+}
+
+// NewIndexer returns an indexer storing the documents in idx.
+func NewIndexer(c *IndexerConfig, idx Index, log *zerolog.Logger) (*Indexer, error) {
+	f, ok := tokenregistry.NewFuncs[c.TokenManager]
+	if !ok {
+		return nil, errtypes.NotFound("search: token manager does not exist: " + c.TokenManager)
+	}
+	tm, err := f(c.TokenManagers[c.TokenManager])
+	if err != nil {
+		return nil, errors.Wrap(err, "search: error creating token manager")
+	}
+
+	stream, err := eventsregistry.NewStream(c.Events)
+	if err != nil {
+		return nil, errors.Wrap(err, "search: error creating events stream")
+	}
+
+	return &Indexer{
+		c:      c,
+		idx:    idx,
+		tm:     tm,
+		stream: stream,
+		client: rhttp.GetHTTPClient(rhttp.Insecure(c.Insecure)),
+		log:    log,
+	}, nil
+}
+
+// Run indexes the files uploaded until stop is closed.
+func (i *Indexer) Run(stop <-chan struct{}) {
+	ctx, cancel := context.WithCancel(appctx.WithLogger(context.Background(), i.log))
+	defer cancel()
+	go func() {
+		<-stop
+		cancel()
+	}()
+
+	evs, err := events.Consume(ctx, i.stream, "search", events.FileUploaded{})
+	if err != nil {
+		i.log.Error().Err(err).Msg("search: error consuming events")
+		return
+	}
+	for e := range evs {
+		ev, ok := e.(events.FileUploaded)
+		if !ok {
+			continue
+		}
+		if err := i.IndexUpload(ctx, ev); err != nil {
+			i.log.Error().Err(err).Str("path", ev.Path).Msg("search: error indexing file")
+		}
+	}
+}
+
+// IndexUpload indexes the file of the upload, on behalf of the user who
+// uploaded it.
+func (i *Indexer) IndexUpload(ctx context.Context, ev events.FileUploaded) error {
+	if ev.Executant == nil {
+		return errtypes.BadRequest("search: upload event without executant")
+	}
+	ctx, err := i.userContext(ctx, ev.Executant)
+	if err != nil {
+		return err
+	}
+	client, err := pool.GetGatewayServiceClient(i.c.GatewaySvc)
+	if err != nil {
+		return err
+	}
+
+	ref := &provider.Reference{Spec: &provider.Reference_Path{Path: ev.Path}}
+	if ev.ResourceID != nil {
+		ref = &provider.Reference{Spec: &provider.Reference_Id{Id: ev.ResourceID}}
+	}
+	res, err := client.Stat(ctx, &provider.StatRequest{Ref: ref, ArbitraryMetadataKeys: []string{"*"}})
+	if err != nil {
+		return err
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return errtypes.InternalError("search: error statting file: " + res.Status.Message)
+	}
+	return i.index(ctx, ref, res.Info)
+}
+
+// Reindex indexes again all the files of the home of the user, on their
+// behalf, and returns their number.
+func (i *Indexer) Reindex(ctx context.Context, uid *userpb.UserId) (int, error) {
+	ctx, err := i.userContext(ctx, uid)
+	if err != nil {
+		return 0, err
+	}
+	client, err := pool.GetGatewayServiceClient(i.c.GatewaySvc)
+	if err != nil {
+		return 0, err
+	}
+	res, err := client.GetHome(ctx, &provider.GetHomeRequest{})
+	if err != nil {
+		return 0, err
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return 0, errtypes.InternalError("search: error getting home: " + res.Status.Message)
+	}
+	return i.reindexFolder(ctx, client, res.Path)
+}
+
+func (i *Indexer) reindexFolder(ctx context.Context, client gateway.GatewayAPIClient, p string) (int, error) {
+	res, err := client.ListContainer(ctx, &provider.ListContainerRequest{
+		Ref:                   &provider.Reference{Spec: &provider.Reference_Path{Path: p}},
+		ArbitraryMetadataKeys: []string{"*"},
+	})
+	if err != nil {
+		return 0, err
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return 0, errtypes.InternalError("search: error listing " + p + ": " + res.Status.Message)
+	}
+
+	indexed := 0
+	for _, ri := range res.Infos {
+		if ri.Type == provider.ResourceType_RESOURCE_TYPE_CONTAINER {
+			n, err := i.reindexFolder(ctx, client, ri.Path)
+			indexed += n
+			if err != nil {
+				return indexed, err
+			}
+			continue
+		}
+		ref := &provider.Reference{Spec: &provider.Reference_Id{Id: ri.Id}}
+		if err := i.index(ctx, ref, ri); err != nil {
+			i.log.Error().Err(err).Str("path", ri.Path).Msg("search: error indexing file")
+			continue
+		}
+		indexed++
+	}
+	return indexed, nil
+}
+
+// index indexes the file, whose content is downloaded on behalf of the user
+// of the context.
+func (i *Indexer) index(ctx context.Context, ref *provider.Reference, ri *provider.ResourceInfo) error {
+	d := &Document{
+		ResourceID: ri.Id,
+		Owner:      ri.Owner,
+		Path:       ri.Path,
+		Name:       path.Base(ri.Path),
+		MimeType:   ri.MimeType,
+		Size:       ri.Size,
+		Metadata:   ri.GetArbitraryMetadata().GetMetadata(),
+	}
+	if ri.Mtime != nil {
+		d.Mtime = utils.TSToTime(ri.Mtime)
+	}
+
+	if i.c.ExtractContent && isText(ri.MimeType) && ri.Size <= i.c.MaxContentSize {
+		content, err := i.download(ctx, ref)
+		if err != nil {
+			// the file is still indexed by name and metadata
+			i.log.Warn().Err(err).Str("path", ri.Path).Msg("search: error extracting content")
+		}
+		d.Content = content
+	}
+
+	return i.idx.Upsert(ctx, d)
+}
+
+func (i *Indexer) download(ctx context.Context, ref *provider.Reference) (string, error) {
+	client, err := pool.GetGatewayServiceClient(i.c.GatewaySvc)
+	if err != nil {
+		return "", err
+	}
+	res, err := client.InitiateFileDownload(ctx, &provider.InitiateFileDownloadRequest{Ref: ref})
+	if err != nil {
+		return "", err
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return "", errtypes.InternalError("search: error initiating download: " + res.Status.Message)
+	}
+
+	var endpoint, tkn string
+	for _, p := range res.Protocols {
+		if p.Protocol == "simple" {
+			endpoint, tkn = p.DownloadEndpoint, p.Token
+		}
+	}
+	if endpoint == "" {
+		return "", errtypes.NotSupported("search: no simple download protocol")
+	}
+
+	req, err := rhttp.NewRequest(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set(datagateway.TokenTransportHeader, tkn)
+	httpRes, err := i.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer httpRes.Body.Close()
+	if httpRes.StatusCode != http.StatusOK {
+		return "", errtypes.InternalError("search: unexpected download status " + httpRes.Status)
+	}
+
+	b, err := ioutil.ReadAll(io.LimitReader(httpRes.Body, int64(i.c.MaxContentSize)))
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// isText returns whether the content of the files of the given mime type can
+// be indexed as is.
+func isText(mimeType string) bool {
+	if strings.HasPrefix(mimeType, "text/") {
+		return true
+	}
+	switch mimeType {
+	case "application/json", "application/xml", "application/javascript", "application/x-sh":
+		return true
+	}
+	return false
+}
+
+func (i *Indexer) userContext(ctx context.Context, uid *userpb.UserId) (context.Context, error) {
+	scopes, err := scope.GetOwnerScope()
+	if err != nil {
+		return nil, err
+	}
+	tkn, err := i.tm.MintToken(ctx, &userpb.User{Id: uid}, scopes)
+	if err != nil {
+		return nil, errors.Wrap(err, "search: error minting token")
+	}
+	ctx = token.ContextSetToken(ctx, tkn)
+	return metadata.AppendToOutgoingContext(ctx, token.TokenHeader, tkn), nil
+}
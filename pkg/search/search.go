@@ -0,0 +1,90 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package search indexes the names, metadata and optionally the content of
+// the files as they are uploaded, so that the users can look them up without
+// walking the storages.
+package search
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+)
+
+// Document is the indexed representation of a file.
+type Document struct {
+	ResourceID *provider.ResourceId `json:"resource_id"`
+	Owner      *userpb.UserId       `json:"owner,omitempty"`
+	// Path is the path of the file when it was indexed, as seen by the user
+	// who uploaded it.
+	Path     string            `json:"path"`
+	Name     string            `json:"name"`
+	MimeType string            `json:"mime_type"`
+	Size     uint64            `json:"size"`
+	Mtime    time.Time         `json:"mtime"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// Content is the text extracted from the file, if any. It is indexed
+	// but not returned by the searches.
+	Content string `json:"content,omitempty"`
+}
+
+// ID returns the id of the document in the index.
+func (d *Document) ID() string {
+	return DocumentID(d.ResourceID)
+}
+
+// DocumentID returns the id of the document of the given resource.
+func DocumentID(r *provider.ResourceId) string {
+	return fmt.Sprintf("%s:%s", r.GetStorageId(), r.GetOpaqueId())
+}
+
+// Query describes the documents to look up.
+type Query struct {
+	// Term, if set, is matched against the names, the metadata values and
+	// the content of the documents.
+	Term string
+	// Metadata, if set, restricts the results to the documents having
+	// exactly these metadata values.
+	Metadata map[string]string
+	// Offset is the number of results to skip, to fetch the next page.
+	Offset int
+	// Limit is the maximum number of results.
+	Limit int
+}
+
+// Match is a document matching a query.
+type Match struct {
+	*Document
+	Score float64
+}
+
+// Index is the interface to implement to store the documents.
+type Index interface {
+	// Upsert adds the document to the index, replacing the one of the same
+	// resource if any.
+	Upsert(ctx context.Context, d *Document) error
+	// Delete removes the document of the resource with the given id.
+	Delete(ctx context.Context, id string) error
+	// Search returns the documents matching the query, best matches first,
+	// regardless of who can access them.
+	Search(ctx context.Context, q *Query) ([]*Match, error)
+}
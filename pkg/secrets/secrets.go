@@ -0,0 +1,176 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package secrets resolves the references to secrets held outside of the
+// configuration files. A reference is a string of the form ${kind:ref}, which
+// can appear anywhere in a configuration value:
+//
+//	${env:NAME}             the value of the NAME environment variable
+//	${file:/path}           the content of the file, without trailing newlines
+//	${vault:path#key}       the key of the secret stored at path in Vault
+//
+// Vault is reached at the address set in VAULT_ADDR, with the token set in
+// VAULT_TOKEN. Both version 1 and 2 of the key-value secrets engine are
+// supported, e.g. ${vault:secret/data/reva#jwt_secret} for the latter.
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var reference = regexp.MustCompile(`\$\{(env|file|vault):([^}]+)\}`)
+
+// Resolve replaces in place the references to secrets found in the string
+// values of the configuration, including the nested ones.
+func Resolve(conf map[string]interface{}) error {
+	r := &resolver{vault: map[string]map[string]interface{}{}}
+	_, err := r.resolve(conf)
+	return err
+}
+
+type resolver struct {
+	// vault caches the secrets read from Vault, by path.
+	vault map[string]map[string]interface{}
+}
+
+func (r *resolver) resolve(v interface{}) (interface{}, error) {
+	switch t := v.(type) {
+	case string:
+		return r.resolveString(t)
+	case map[string]interface{}:
+		for k, v := range t {
+			res, err := r.resolve(v)
+			if err != nil {
+				return nil, errors.Wrapf(err, "%s", k)
+			}
+			t[k] = res
+		}
+	case []map[string]interface{}:
+		for i := range t {
+			if _, err := r.resolve(t[i]); err != nil {
+				return nil, err
+			}
+		}
+	case []interface{}:
+		for i, v := range t {
+			res, err := r.resolve(v)
+			if err != nil {
+				return nil, err
+			}
+			t[i] = res
+		}
+	}
+	return v, nil
+}
+
+func (r *resolver) resolveString(s string) (string, error) {
+	var err error
+	res := reference.ReplaceAllStringFunc(s, func(m string) string {
+		if err != nil {
+			return m
+		}
+		sub := reference.FindStringSubmatch(m)
+		var val string
+		switch sub[1] {
+		case "env":
+			var ok bool
+			if val, ok = os.LookupEnv(sub[2]); !ok {
+				err = fmt.Errorf("secrets: environment variable %s is not set", sub[2])
+			}
+		case "file":
+			var data []byte
+			if data, err = ioutil.ReadFile(sub[2]); err == nil {
+				val = strings.TrimRight(string(data), "\r\n")
+			}
+		case "vault":
+			val, err = r.fromVault(sub[2])
+		}
+		return val
+	})
+	return res, err
+}
+
+func (r *resolver) fromVault(ref string) (string, error) {
+	i := strings.LastIndex(ref, "#")
+	if i < 0 {
+		return "", fmt.Errorf("secrets: vault reference %s has no key, expected path#key", ref)
+	}
+	path, key := ref[:i], ref[i+1:]
+
+	data, ok := r.vault[path]
+	if !ok {
+		var err error
+		if data, err = readVault(path); err != nil {
+			return "", err
+		}
+		r.vault[path] = data
+	}
+
+	val, ok := data[key]
+	if !ok {
+		return "", fmt.Errorf("secrets: key %s not found in vault secret %s", key, path)
+	}
+	return fmt.Sprint(val), nil
+}
+
+func readVault(path string) (map[string]interface{}, error) {
+	addr := os.Getenv("VAULT_ADDR")
+	if addr == "" {
+		return nil, errors.New("secrets: VAULT_ADDR is not set")
+	}
+
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(addr, "/")+"/v1/"+strings.TrimPrefix(path, "/"), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "secrets: error creating vault request")
+	}
+	req.Header.Set("X-Vault-Token", os.Getenv("VAULT_TOKEN"))
+
+	c := &http.Client{Timeout: 10 * time.Second}
+	res, err := c.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "secrets: error reading from vault")
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("secrets: error reading %s from vault: %s", path, res.Status)
+	}
+
+	var body struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return nil, errors.Wrap(err, "secrets: error decoding vault response")
+	}
+
+	// the key-value engine version 2 nests the secret, along with its metadata.
+	if nested, ok := body.Data["data"].(map[string]interface{}); ok {
+		if _, ok := body.Data["metadata"]; ok {
+			return nested, nil
+		}
+	}
+	return body.Data, nil
+}
@@ -0,0 +1,87 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package secrets
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	os.Setenv("REVA_TEST_DB_PASSWORD", "dbpass")
+	defer os.Unsetenv("REVA_TEST_DB_PASSWORD")
+
+	dir, err := ioutil.TempDir("", "secrets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "jwt")
+	if err := ioutil.WriteFile(file, []byte("jwtsecret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	vault := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/secret/data/reva" || r.Header.Get("X-Vault-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_, _ = w.Write([]byte(`{"data": {"data": {"bind_password": "ldappass"}, "metadata": {"version": 1}}}`))
+	}))
+	defer vault.Close()
+	os.Setenv("VAULT_ADDR", vault.URL)
+	os.Setenv("VAULT_TOKEN", "token")
+	defer os.Unsetenv("VAULT_ADDR")
+	defer os.Unsetenv("VAULT_TOKEN")
+
+	conf := map[string]interface{}{
+		"shared": map[string]interface{}{
+			"jwt_secret": "${file:" + file + "}",
+		},
+		"drivers": []map[string]interface{}{
+			{
+				"dsn":           "reva:${env:REVA_TEST_DB_PASSWORD}@tcp(localhost:3306)/reva",
+				"bind_password": "${vault:secret/data/reva#bind_password}",
+			},
+		},
+		"port": int64(9000),
+	}
+	if err := Resolve(conf); err != nil {
+		t.Fatal(err)
+	}
+
+	if s := conf["shared"].(map[string]interface{})["jwt_secret"]; s != "jwtsecret" {
+		t.Errorf("file reference resolved to %q", s)
+	}
+	d := conf["drivers"].([]map[string]interface{})[0]
+	if d["dsn"] != "reva:dbpass@tcp(localhost:3306)/reva" {
+		t.Errorf("env reference resolved to %q", d["dsn"])
+	}
+	if d["bind_password"] != "ldappass" {
+		t.Errorf("vault reference resolved to %q", d["bind_password"])
+	}
+
+	if err := Resolve(map[string]interface{}{"secret": "${env:REVA_TEST_UNSET}"}); err == nil {
+		t.Error("expected an error for an unset environment variable")
+	}
+}
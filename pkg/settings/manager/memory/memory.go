@@ -0,0 +1,78 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package memory
+
+import (
+	"context"
+	"sync"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/settings"
+	"github.com/cs3org/reva/pkg/settings/manager/registry"
+)
+
+func init() {
+	registry.Register("memory", New)
+}
+
+type manager struct {
+	sync.RWMutex
+	values map[string]map[string]string
+}
+
+// New returns a settings manager keeping the values in memory,
+// which are lost when the service is restarted.
+func New(m map[string]interface{}) (settings.Manager, error) {
+	return &manager{values: map[string]map[string]string{}}, nil
+}
+
+func userKey(uid *userpb.UserId) string {
+	return uid.GetIdp() + "!" + uid.GetOpaqueId()
+}
+
+func (m *manager) GetValue(ctx context.Context, uid *userpb.UserId, key string) (string, error) {
+	m.RLock()
+	defer m.RUnlock()
+	if v, ok := m.values[userKey(uid)][key]; ok {
+		return v, nil
+	}
+	return "", errtypes.NotFound(key)
+}
+
+func (m *manager) SetValue(ctx context.Context, uid *userpb.UserId, key, value string) error {
+	m.Lock()
+	defer m.Unlock()
+	k := userKey(uid)
+	if m.values[k] == nil {
+		m.values[k] = map[string]string{}
+	}
+	m.values[k][key] = value
+	return nil
+}
+
+func (m *manager) ListValues(ctx context.Context, uid *userpb.UserId) (map[string]string, error) {
+	m.RLock()
+	defer m.RUnlock()
+	values := map[string]string{}
+	for k, v := range m.values[userKey(uid)] {
+		values[k] = v
+	}
+	return values, nil
+}
@@ -0,0 +1,120 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/settings"
+	"github.com/cs3org/reva/pkg/settings/manager/registry"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+
+	// Provides mysql drivers
+	_ "github.com/go-sql-driver/mysql"
+)
+
+func init() {
+	registry.Register("sql", New)
+}
+
+// The manager expects the following table:
+//
+//   settings(user_idp, user_id, setting_key, value,
+//            PRIMARY KEY (user_idp, user_id, setting_key))
+
+type config struct {
+	DbUsername string `mapstructure:"db_username"`
+	DbPassword string `mapstructure:"db_password"`
+	DbHost     string `mapstructure:"db_host"`
+	DbPort     int    `mapstructure:"db_port"`
+	DbName     string `mapstructure:"db_name"`
+}
+
+func (c *config) init() {
+	if c.DbPort == 0 {
+		c.DbPort = 3306
+	}
+}
+
+type manager struct {
+	db *sql.DB
+}
+
+func parseConfig(m map[string]interface{}) (*config, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		err = errors.Wrap(err, "error decoding conf")
+		return nil, err
+	}
+	c.init()
+	return c, nil
+}
+
+// New returns a settings manager storing the values in a SQL database.
+func New(m map[string]interface{}) (settings.Manager, error) {
+	c, err := parseConfig(m)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s:%d)/%s", c.DbUsername, c.DbPassword, c.DbHost, c.DbPort, c.DbName))
+	if err != nil {
+		return nil, errors.Wrap(err, "sql: error opening connection to the database")
+	}
+
+	return &manager{db: db}, nil
+}
+
+func (m *manager) GetValue(ctx context.Context, uid *userpb.UserId, key string) (string, error) {
+	var value string
+	err := m.db.QueryRowContext(ctx, "SELECT value FROM settings WHERE user_idp=? AND user_id=? AND setting_key=?", uid.GetIdp(), uid.GetOpaqueId(), key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", errtypes.NotFound(key)
+	}
+	return value, err
+}
+
+func (m *manager) SetValue(ctx context.Context, uid *userpb.UserId, key, value string) error {
+	_, err := m.db.ExecContext(ctx, "INSERT INTO settings (user_idp, user_id, setting_key, value) VALUES (?, ?, ?, ?) ON DUPLICATE KEY UPDATE value=?",
+		uid.GetIdp(), uid.GetOpaqueId(), key, value, value)
+	return err
+}
+
+func (m *manager) ListValues(ctx context.Context, uid *userpb.UserId) (map[string]string, error) {
+	rows, err := m.db.QueryContext(ctx, "SELECT setting_key, value FROM settings WHERE user_idp=? AND user_id=?", uid.GetIdp(), uid.GetOpaqueId())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	values := map[string]string{}
+	for rows.Next() {
+		var k, v string
+		if err := rows.Scan(&k, &v); err != nil {
+			return nil, err
+		}
+		values[k] = v
+	}
+	return values, rows.Err()
+}
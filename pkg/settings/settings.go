@@ -0,0 +1,154 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package settings defines the typed settings the users can change, grouped in
+// bundles registered by the services, and the interface of the drivers
+// storing the values chosen by the users.
+package settings
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/pkg/errtypes"
+)
+
+// Type is the type of the value of a setting.
+type Type string
+
+// Supported types of settings.
+const (
+	Bool   Type = "bool"
+	Int    Type = "int"
+	Choice Type = "choice"
+)
+
+// Setting is the definition of a setting.
+type Setting struct {
+	Name        string   `mapstructure:"name" json:"name"`
+	Type        Type     `mapstructure:"type" json:"type"`
+	Default     string   `mapstructure:"default" json:"default"`
+	Choices     []string `mapstructure:"choices" json:"choices,omitempty"`
+	Description string   `mapstructure:"description" json:"description,omitempty"`
+}
+
+// Bundle is a group of settings registered by a service. The key of a setting
+// is the name of its bundle and its name separated by a dot, e.g.
+// notifications.email.
+type Bundle struct {
+	Name     string     `mapstructure:"name" json:"name"`
+	Settings []*Setting `mapstructure:"settings" json:"settings"`
+}
+
+// Validate checks that the value is valid for the setting.
+func (s *Setting) Validate(value string) error {
+	switch s.Type {
+	case Bool:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return errtypes.BadRequest(fmt.Sprintf("settings: %s expects a boolean", s.Name))
+		}
+	case Int:
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return errtypes.BadRequest(fmt.Sprintf("settings: %s expects an integer", s.Name))
+		}
+	case Choice:
+		for _, c := range s.Choices {
+			if c == value {
+				return nil
+			}
+		}
+		return errtypes.BadRequest(fmt.Sprintf("settings: %s expects one of %s", s.Name, strings.Join(s.Choices, ", ")))
+	default:
+		return errtypes.BadRequest(fmt.Sprintf("settings: unknown type %s", s.Type))
+	}
+	return nil
+}
+
+var (
+	mu      sync.RWMutex
+	bundles = map[string]*Bundle{}
+)
+
+// RegisterBundle registers a bundle of settings.
+// Safe for use from package init.
+func RegisterBundle(b *Bundle) error {
+	for _, s := range b.Settings {
+		if err := s.Validate(s.Default); err != nil {
+			return err
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	bundles[b.Name] = b
+	return nil
+}
+
+// MustRegisterBundle registers a bundle of settings, and panics if the
+// default values are not valid.
+func MustRegisterBundle(b *Bundle) {
+	if err := RegisterBundle(b); err != nil {
+		panic(err)
+	}
+}
+
+// GetBundles returns the registered bundles sorted by name.
+func GetBundles() []*Bundle {
+	mu.RLock()
+	defer mu.RUnlock()
+	list := make([]*Bundle, 0, len(bundles))
+	for _, b := range bundles {
+		list = append(list, b)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Lookup returns the definition of the setting with the given key.
+func Lookup(key string) (*Setting, bool) {
+	parts := strings.SplitN(key, ".", 2)
+	if len(parts) != 2 {
+		return nil, false
+	}
+	mu.RLock()
+	defer mu.RUnlock()
+	b, ok := bundles[parts[0]]
+	if !ok {
+		return nil, false
+	}
+	for _, s := range b.Settings {
+		if s.Name == parts[1] {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+// Manager is the interface to implement to store the values of the users.
+type Manager interface {
+	// GetValue returns the value set by the user for the key.
+	GetValue(ctx context.Context, uid *userpb.UserId, key string) (string, error)
+	// SetValue stores the value set by the user for the key.
+	SetValue(ctx context.Context, uid *userpb.UserId, key, value string) error
+	// ListValues returns all the values set by the user.
+	ListValues(ctx context.Context, uid *userpb.UserId) (map[string]string, error)
+}
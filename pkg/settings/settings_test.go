@@ -0,0 +1,69 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package settings
+
+import (
+	"testing"
+)
+
+func TestBundles(t *testing.T) {
+	if err := RegisterBundle(&Bundle{
+		Name: "test",
+		Settings: []*Setting{
+			{Name: "enabled", Type: Bool, Default: "maybe"},
+		},
+	}); err == nil {
+		t.Fatal("expected invalid defaults to be rejected")
+	}
+
+	MustRegisterBundle(&Bundle{
+		Name: "test",
+		Settings: []*Setting{
+			{Name: "enabled", Type: Bool, Default: "true"},
+			{Name: "page_size", Type: Int, Default: "50"},
+			{Name: "view", Type: Choice, Default: "list", Choices: []string{"list", "grid"}},
+		},
+	})
+
+	tests := []struct {
+		key   string
+		value string
+		valid bool
+	}{
+		{"test.enabled", "false", true},
+		{"test.enabled", "no", false},
+		{"test.page_size", "100", true},
+		{"test.page_size", "many", false},
+		{"test.view", "grid", true},
+		{"test.view", "tree", false},
+	}
+	for _, tt := range tests {
+		s, ok := Lookup(tt.key)
+		if !ok {
+			t.Fatalf("setting %s not found", tt.key)
+		}
+		if err := s.Validate(tt.value); (err == nil) != tt.valid {
+			t.Errorf("%s=%s: got error %v, want valid %t", tt.key, tt.value, err, tt.valid)
+		}
+	}
+
+	if _, ok := Lookup("test.unknown"); ok {
+		t.Error("expected unknown setting not to be found")
+	}
+}
@@ -22,15 +22,27 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/cs3org/reva/pkg/mtls"
 	"github.com/mitchellh/mapstructure"
 )
 
 var sharedConf = &conf{}
 
+// mainConf is the complete configuration the process was started with.
+var mainConf map[string]interface{}
+
 type conf struct {
 	JWTSecret   string `mapstructure:"jwt_secret"`
 	GatewaySVC  string `mapstructure:"gatewaysvc"`
 	DataGateway string `mapstructure:"datagateway"`
+	// Tenants configures the institutions served by the deployment, see
+	// the tenant package.
+	Tenants []map[string]interface{} `mapstructure:"tenants"`
+	// ClientInterceptors enables the registered gRPC client interceptors
+	// with their configuration, see the pool package.
+	ClientInterceptors map[string]map[string]interface{} `mapstructure:"client_interceptors"`
+	// ClientTLS configures the TLS of the gRPC connections to the services.
+	ClientTLS mtls.ClientConfig `mapstructure:"grpc_client_tls"`
 }
 
 // Decode decodes the configuration.
@@ -86,3 +98,42 @@ func GetDataGateway(val string) string {
 	}
 	return val
 }
+
+// GetTenants returns the configuration of the tenants of the deployment.
+func GetTenants() []map[string]interface{} {
+	return sharedConf.Tenants
+}
+
+// GetClientInterceptors returns the configuration of the enabled gRPC client
+// interceptors.
+func GetClientInterceptors() map[string]map[string]interface{} {
+	return sharedConf.ClientInterceptors
+}
+
+// GetClientTLS returns the configuration of the TLS of the gRPC connections.
+func GetClientTLS() *mtls.ClientConfig {
+	return &sharedConf.ClientTLS
+}
+
+// SetMainConf stores the complete configuration the process was started with.
+func SetMainConf(c map[string]interface{}) {
+	mainConf = c
+}
+
+// GetMainConf returns the complete configuration the process was started with.
+// The returned map must not be modified.
+func GetMainConf() map[string]interface{} {
+	return mainConf
+}
+
+// Check decodes the shared configuration without applying it, and returns
+// the keys which are not known.
+func Check(v interface{}) ([]string, error) {
+	var md mapstructure.Metadata
+	dec, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{Metadata: &md, Result: &conf{}})
+	if err != nil {
+		return nil, err
+	}
+	err = dec.Decode(v)
+	return md.Unused, err
+}
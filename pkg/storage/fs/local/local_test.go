@@ -0,0 +1,51 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package local_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/pkg/storage/fs/local"
+	"github.com/cs3org/reva/pkg/storage/test"
+	ruser "github.com/cs3org/reva/pkg/user"
+	"github.com/cs3org/reva/tests/helpers"
+)
+
+func TestConformance(t *testing.T) {
+	test.Run(t, func(t *testing.T) *test.Env {
+		root, err := helpers.TempDir("reva-unit-tests-*-root")
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { os.RemoveAll(root) })
+
+		fs, err := local.New(map[string]interface{}{"root": root})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ctx := ruser.ContextSetUser(context.Background(), &userpb.User{
+			Id:       &userpb.UserId{Idp: "idp", OpaqueId: "userid"},
+			Username: "username",
+		})
+		return &test.Env{FS: fs, Ctx: ctx, Root: "/"}
+	}, test.Features{Recycle: true, Revisions: true, Grants: true})
+}
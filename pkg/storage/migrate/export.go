@@ -0,0 +1,462 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package migrate
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	collaboration "github.com/cs3org/go-cs3apis/cs3/sharing/collaboration/v1beta1"
+	link "github.com/cs3org/go-cs3apis/cs3/sharing/link/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/internal/http/services/datagateway"
+	"github.com/cs3org/reva/pkg/rhttp"
+	"github.com/pkg/errors"
+)
+
+// userData representation in the export data
+type userData struct {
+	UserID      string   `json:"userId"`
+	Idp         string   `json:"idp"`
+	DisplayName string   `json:"displayName"`
+	Email       string   `json:"email"`
+	Groups      []string `json:"groups"`
+}
+
+// version representation in the export data
+type version struct {
+	Path  string `json:"path"`
+	Key   string `json:"key"`
+	Etag  string `json:"eTag"`
+	Size  uint64 `json:"size"`
+	MTime uint64 `json:"mtime"`
+}
+
+// trashItem representation in the export data
+type trashItem struct {
+	Key          string `json:"key"`
+	Path         string `json:"path"`
+	Type         string `json:"type"`
+	Size         uint64 `json:"size"`
+	DeletionTime uint64 `json:"deletionTime"`
+}
+
+type exporter struct {
+	ctx        context.Context
+	client     gateway.GatewayAPIClient
+	httpClient *http.Client
+	dir        string
+	home       string
+	files      *json.Encoder
+	versions   *json.Encoder
+}
+
+// Export writes the account of the user to a folder named after the user in
+// exportPath, in the layout of the ownCloud data exporter read by ImportUser:
+// the user.json, files.jsonl, versions.jsonl, shares.jsonl and trash.jsonl
+// files, and the contents of the files below files/. The CS3 APIs give no
+// access to the contents of the versions and of the trash, so only their
+// metadata is exported. The context must carry a token of the user.
+func Export(ctx context.Context, client gateway.GatewayAPIClient, httpClient *http.Client, u *userpb.User, exportPath string) (string, error) {
+	dir := path.Join(exportPath, u.Username)
+	if err := os.MkdirAll(path.Join(dir, "files"), 0700); err != nil {
+		return "", err
+	}
+
+	if err := writeJSON(path.Join(dir, "user.json"), &userData{
+		UserID:      u.Id.GetOpaqueId(),
+		Idp:         u.Id.GetIdp(),
+		DisplayName: u.DisplayName,
+		Email:       u.Mail,
+		Groups:      u.Groups,
+	}); err != nil {
+		return "", err
+	}
+
+	home, err := client.GetHome(ctx, &provider.GetHomeRequest{})
+	if err != nil {
+		return "", err
+	}
+	if home.Status.Code != rpc.Code_CODE_OK {
+		return "", statusError("getting home", home.Status)
+	}
+
+	filesJSONL, err := os.Create(path.Join(dir, "files.jsonl"))
+	if err != nil {
+		return "", err
+	}
+	defer filesJSONL.Close()
+	versionsJSONL, err := os.Create(path.Join(dir, "versions.jsonl"))
+	if err != nil {
+		return "", err
+	}
+	defer versionsJSONL.Close()
+
+	e := &exporter{
+		ctx:        ctx,
+		client:     client,
+		httpClient: httpClient,
+		dir:        dir,
+		home:       home.Path,
+		files:      json.NewEncoder(filesJSONL),
+		versions:   json.NewEncoder(versionsJSONL),
+	}
+	if err := e.exportFiles(home.Path); err != nil {
+		return "", err
+	}
+	if err := e.exportShares(); err != nil {
+		return "", err
+	}
+	if err := e.exportTrash(); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// exportFiles exports the files below p recursively. The references to the
+// shares received by the user are skipped.
+func (e *exporter) exportFiles(p string) error {
+	res, err := e.client.ListContainer(e.ctx, &provider.ListContainerRequest{
+		Ref: &provider.Reference{
+			Spec: &provider.Reference_Path{Path: p},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return statusError("listing "+p, res.Status)
+	}
+
+	for _, info := range res.Infos {
+		rel := e.relativePath(info.Path)
+		if rel == "" {
+			continue
+		}
+		md := &metaData{
+			Path:        path.Join("/files", rel),
+			Etag:        info.Etag,
+			Permissions: ocPermissions(info.PermissionSet),
+			MTime:       int(info.GetMtime().GetSeconds()),
+		}
+		switch info.Type {
+		case provider.ResourceType_RESOURCE_TYPE_CONTAINER:
+			md.Type = "folder"
+			if err := e.files.Encode(md); err != nil {
+				return err
+			}
+			if err := os.MkdirAll(filepath.Join(e.dir, "files", filepath.FromSlash(rel)), 0700); err != nil {
+				return err
+			}
+			if err := e.exportFiles(info.Path); err != nil {
+				return err
+			}
+		case provider.ResourceType_RESOURCE_TYPE_FILE:
+			md.Type = "file"
+			if err := e.files.Encode(md); err != nil {
+				return err
+			}
+			if err := e.download(info.Path, filepath.Join(e.dir, "files", filepath.FromSlash(rel))); err != nil {
+				return err
+			}
+			if err := e.exportVersions(info.Path, md.Path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (e *exporter) download(p, target string) error {
+	res, err := e.client.InitiateFileDownload(e.ctx, &provider.InitiateFileDownloadRequest{
+		Ref: &provider.Reference{
+			Spec: &provider.Reference_Path{Path: p},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return statusError("initiating download of "+p, res.Status)
+	}
+
+	var endpoint, tkn string
+	for _, p := range res.Protocols {
+		if p.Protocol == "simple" {
+			endpoint, tkn = p.DownloadEndpoint, p.Token
+		}
+	}
+	if endpoint == "" {
+		return errors.New("migrate: no simple download protocol for " + p)
+	}
+
+	req, err := rhttp.NewRequest(e.ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(datagateway.TokenTransportHeader, tkn)
+	httpRes, err := e.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer httpRes.Body.Close()
+	if httpRes.StatusCode != http.StatusOK {
+		return errors.New("migrate: downloading " + p + " returned " + httpRes.Status)
+	}
+
+	fd, err := os.Create(target)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+	_, err = io.Copy(fd, httpRes.Body)
+	return err
+}
+
+func (e *exporter) exportVersions(p, exportedPath string) error {
+	res, err := e.client.ListFileVersions(e.ctx, &provider.ListFileVersionsRequest{
+		Ref: &provider.Reference{
+			Spec: &provider.Reference_Path{Path: p},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if res.Status.Code == rpc.Code_CODE_UNIMPLEMENTED {
+		// the storage does not keep versions
+		return nil
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return statusError("listing the versions of "+p, res.Status)
+	}
+
+	for _, v := range res.Versions {
+		if err := e.versions.Encode(&version{
+			Path:  exportedPath,
+			Key:   v.Key,
+			Etag:  v.Etag,
+			Size:  v.Size,
+			MTime: v.Mtime,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// exportShares exports the user and group shares and the public links
+// created by the user on the files of the home.
+func (e *exporter) exportShares() error {
+	sharesJSONL, err := os.Create(path.Join(e.dir, "shares.jsonl"))
+	if err != nil {
+		return err
+	}
+	defer sharesJSONL.Close()
+	enc := json.NewEncoder(sharesJSONL)
+
+	res, err := e.client.ListShares(e.ctx, &collaboration.ListSharesRequest{})
+	if err != nil {
+		return err
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return statusError("listing shares", res.Status)
+	}
+	for _, s := range res.Shares {
+		info, err := e.statShared(s.ResourceId)
+		if err != nil || info == nil {
+			continue
+		}
+		sh := &share{
+			Path:        e.relativePath(info.Path),
+			Type:        resourceType(info.Type),
+			Permissions: shareOCPermissions(s.Permissions.GetPermissions()),
+		}
+		switch s.Grantee.Type {
+		case provider.GranteeType_GRANTEE_TYPE_USER:
+			sh.ShareType, sh.SharedWith = "user", s.Grantee.GetUserId().GetOpaqueId()
+		case provider.GranteeType_GRANTEE_TYPE_GROUP:
+			sh.ShareType, sh.SharedWith = "group", s.Grantee.GetGroupId().GetOpaqueId()
+		default:
+			continue
+		}
+		if err := enc.Encode(sh); err != nil {
+			return err
+		}
+	}
+
+	pRes, err := e.client.ListPublicShares(e.ctx, &link.ListPublicSharesRequest{})
+	if err != nil {
+		return err
+	}
+	if pRes.Status.Code != rpc.Code_CODE_OK {
+		return statusError("listing public links", pRes.Status)
+	}
+	for _, s := range pRes.Share {
+		info, err := e.statShared(s.ResourceId)
+		if err != nil || info == nil {
+			continue
+		}
+		sh := &share{
+			Path:        e.relativePath(info.Path),
+			ShareType:   "link",
+			Type:        resourceType(info.Type),
+			Permissions: shareOCPermissions(s.Permissions.GetPermissions()),
+			Name:        s.DisplayName,
+			Token:       s.Token,
+		}
+		if s.Expiration != nil {
+			sh.ExpirationDate = time.Unix(int64(s.Expiration.Seconds), 0).UTC().Format(time.RFC3339)
+		}
+		if err := enc.Encode(sh); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// statShared stats a shared resource, returning nil when it no longer
+// exists or is not in the home of the user.
+func (e *exporter) statShared(id *provider.ResourceId) (*provider.ResourceInfo, error) {
+	res, err := e.client.Stat(e.ctx, &provider.StatRequest{
+		Ref: &provider.Reference{
+			Spec: &provider.Reference_Id{Id: id},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if res.Status.Code != rpc.Code_CODE_OK || e.relativePath(res.Info.Path) == "" {
+		return nil, nil
+	}
+	return res.Info, nil
+}
+
+func (e *exporter) exportTrash() error {
+	trashJSONL, err := os.Create(path.Join(e.dir, "trash.jsonl"))
+	if err != nil {
+		return err
+	}
+	defer trashJSONL.Close()
+	enc := json.NewEncoder(trashJSONL)
+
+	res, err := e.client.ListRecycle(e.ctx, &gateway.ListRecycleRequest{
+		Ref: &provider.Reference{
+			Spec: &provider.Reference_Path{Path: e.home},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if res.Status.Code == rpc.Code_CODE_UNIMPLEMENTED {
+		return nil
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return statusError("listing the trash", res.Status)
+	}
+	for _, item := range res.RecycleItems {
+		if err := enc.Encode(&trashItem{
+			Key:          item.Key,
+			Path:         item.Path,
+			Type:         resourceType(item.Type),
+			Size:         item.Size,
+			DeletionTime: item.GetDeletionTime().GetSeconds(),
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// relativePath returns the path relative to the home of the user, or an
+// empty string for the paths outside of it.
+func (e *exporter) relativePath(p string) string {
+	if !strings.HasPrefix(p, e.home+"/") && !(e.home == "/" && strings.HasPrefix(p, "/")) {
+		return ""
+	}
+	return path.Join("/", strings.TrimPrefix(p, e.home))
+}
+
+func resourceType(t provider.ResourceType) string {
+	if t == provider.ResourceType_RESOURCE_TYPE_CONTAINER {
+		return "folder"
+	}
+	return "file"
+}
+
+// ocPermissions returns the ownCloud permissions bitmask of a resource.
+func ocPermissions(p *provider.ResourcePermissions) int {
+	var perms int
+	if p.GetStat() || p.GetInitiateFileDownload() {
+		perms |= 1
+	}
+	if p.GetInitiateFileUpload() {
+		perms |= 2
+	}
+	if p.GetCreateContainer() {
+		perms |= 4
+	}
+	if p.GetDelete() {
+		perms |= 8
+	}
+	if p.GetAddGrant() {
+		perms |= 16
+	}
+	return perms
+}
+
+// shareOCPermissions maps the permissions of a share to the ownCloud
+// permissions of the role read back by convertPermissions.
+func shareOCPermissions(p *provider.ResourcePermissions) int {
+	role := "viewer"
+	switch {
+	case p.GetAddGrant():
+		role = "co-owner"
+	case p.GetInitiateFileUpload():
+		role = "editor"
+	}
+	for perms, r := range ocPermToRole {
+		if r == role {
+			return perms
+		}
+	}
+	return 1
+}
+
+func writeJSON(file string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, data, 0600)
+}
+
+func statusError(op string, s *rpc.Status) error {
+	return errors.Errorf("migrate: error %s: %s %s", op, s.Code, s.Message)
+}
@@ -0,0 +1,140 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package migrate
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+
+	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	"github.com/cs3org/reva/internal/http/services/datagateway"
+	"github.com/cs3org/reva/pkg/rhttp"
+	"github.com/pkg/errors"
+)
+
+// ImportUser imports the account of a user exported to exportPath by Export
+// or by the ownCloud data exporter below root, usually the home of the user
+// on the target system: the files with their metadata, then the shares. The
+// context must carry a token of the user.
+func ImportUser(ctx context.Context, client gateway.GatewayAPIClient, httpClient *http.Client, exportPath string, root string) error {
+	if err := importFiles(ctx, client, httpClient, exportPath, root); err != nil {
+		return err
+	}
+	if err := importMetadata(ctx, client, exportPath, root); err != nil {
+		return err
+	}
+	return importShares(ctx, client, exportPath, root)
+}
+
+// importFiles uploads the files below files/ in exportPath to root,
+// creating the folders as needed.
+func importFiles(ctx context.Context, client gateway.GatewayAPIClient, httpClient *http.Client, exportPath string, root string) error {
+	filesPath := filepath.Join(exportPath, "files")
+	return filepath.Walk(filesPath, func(p string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(filesPath, p)
+		if err != nil {
+			return err
+		}
+		target := path.Join(root, filepath.ToSlash(rel))
+
+		if fi.IsDir() {
+			return createContainer(ctx, client, target)
+		}
+		return upload(ctx, client, httpClient, p, fi.Size(), target)
+	})
+}
+
+func createContainer(ctx context.Context, client gateway.GatewayAPIClient, target string) error {
+	res, err := client.CreateContainer(ctx, &provider.CreateContainerRequest{
+		Ref: &provider.Reference{
+			Spec: &provider.Reference_Path{Path: target},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if res.Status.Code != rpc.Code_CODE_OK && res.Status.Code != rpc.Code_CODE_ALREADY_EXISTS {
+		return statusError("creating "+target, res.Status)
+	}
+	return nil
+}
+
+func upload(ctx context.Context, client gateway.GatewayAPIClient, httpClient *http.Client, file string, size int64, target string) error {
+	res, err := client.InitiateFileUpload(ctx, &provider.InitiateFileUploadRequest{
+		Ref: &provider.Reference{
+			Spec: &provider.Reference_Path{Path: target},
+		},
+		Opaque: &types.Opaque{
+			Map: map[string]*types.OpaqueEntry{
+				"Upload-Length": {
+					Decoder: "plain",
+					Value:   []byte(strconv.FormatInt(size, 10)),
+				},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if res.Status.Code != rpc.Code_CODE_OK {
+		return statusError("initiating upload of "+target, res.Status)
+	}
+
+	var endpoint, tkn string
+	for _, p := range res.Protocols {
+		if p.Protocol == "simple" {
+			endpoint, tkn = p.UploadEndpoint, p.Token
+		}
+	}
+	if endpoint == "" {
+		return errors.New("migrate: no simple upload protocol for " + target)
+	}
+
+	fd, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer fd.Close()
+
+	req, err := rhttp.NewRequest(ctx, http.MethodPut, endpoint, fd)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(datagateway.TokenTransportHeader, tkn)
+	req.ContentLength = size
+	httpRes, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer httpRes.Body.Close()
+	if httpRes.StatusCode != http.StatusOK {
+		return errors.New("migrate: uploading " + target + " returned " + httpRes.Status)
+	}
+	return nil
+}
@@ -45,13 +45,17 @@ type metaData struct {
 // ImportMetadata from a files.jsonl file in exportPath. The files must already be present on the storage
 // Will set etag and mtime
 func ImportMetadata(ctx context.Context, client gateway.GatewayAPIClient, exportPath string, ns string) error {
+	return importMetadata(ctx, client, exportPath, path.Join(ns, path.Base(exportPath)))
+}
+
+func importMetadata(ctx context.Context, client gateway.GatewayAPIClient, exportPath string, root string) error {
 
 	filesJSONL, err := os.Open(path.Join(exportPath, "files.jsonl"))
 	if err != nil {
 		return err
 	}
+	defer filesJSONL.Close()
 	jsonLines := bufio.NewScanner(filesJSONL)
-	filesJSONL.Close()
 
 	for jsonLines.Scan() {
 		var fileData metaData
@@ -71,7 +75,7 @@ func ImportMetadata(ctx context.Context, client gateway.GatewayAPIClient, export
 		// TODO permissions? is done via share? but this is owner permissions
 
 		if len(m) > 0 {
-			resourcePath := path.Join(ns, path.Base(exportPath), strings.TrimPrefix(fileData.Path, "/files/"))
+			resourcePath := path.Join(root, strings.TrimPrefix(fileData.Path, "/files/"))
 			samReq := &storageprovider.SetArbitraryMetadataRequest{
 				Ref: &storageprovider.Reference{
 					Spec: &storageprovider.Reference_Path{Path: resourcePath},
@@ -27,6 +27,7 @@ import (
 	"path"
 
 	gateway "github.com/cs3org/go-cs3apis/cs3/gateway/v1beta1"
+	group "github.com/cs3org/go-cs3apis/cs3/identity/group/v1beta1"
 	user "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
 	rpc "github.com/cs3org/go-cs3apis/cs3/rpc/v1beta1"
 	collaboration "github.com/cs3org/go-cs3apis/cs3/sharing/collaboration/v1beta1"
@@ -50,13 +51,17 @@ type share struct {
 
 // ImportShares from a shares.jsonl file in exportPath. The files must already be present on the storage
 func ImportShares(ctx context.Context, client gateway.GatewayAPIClient, exportPath string, ns string) error {
+	return importShares(ctx, client, exportPath, path.Join(ns, path.Base(exportPath)))
+}
+
+func importShares(ctx context.Context, client gateway.GatewayAPIClient, exportPath string, root string) error {
 
 	sharesJSONL, err := os.Open(path.Join(exportPath, "shares.jsonl"))
 	if err != nil {
 		return err
 	}
+	defer sharesJSONL.Close()
 	jsonLines := bufio.NewScanner(sharesJSONL)
-	sharesJSONL.Close()
 
 	for jsonLines.Scan() {
 		var shareData share
@@ -65,8 +70,14 @@ func ImportShares(ctx context.Context, client gateway.GatewayAPIClient, exportPa
 			return err
 		}
 
+		if shareData.ShareType != "" && shareData.ShareType != "user" && shareData.ShareType != "group" {
+			// public links cannot be recreated with their tokens
+			log.Print("Unsupported share type, skipping share import: " + shareData.ShareType + " " + shareData.Path)
+			continue
+		}
+
 		// Stat file, skip share creation if it does not exist on the target system
-		resourcePath := path.Join(ns, path.Base(exportPath), shareData.Path)
+		resourcePath := path.Join(root, shareData.Path)
 		statReq := &provider.StatRequest{
 			Ref: &provider.Reference{
 				Spec: &provider.Reference_Path{Path: resourcePath},
@@ -95,12 +106,7 @@ func shareReq(info *provider.ResourceInfo, share *share) *collaboration.CreateSh
 	return &collaboration.CreateShareRequest{
 		ResourceInfo: info,
 		Grant: &collaboration.ShareGrant{
-			Grantee: &provider.Grantee{
-				Type: provider.GranteeType_GRANTEE_TYPE_USER,
-				Id: &provider.Grantee_UserId{UserId: &user.UserId{
-					OpaqueId: share.SharedWith,
-				}},
-			},
+			Grantee: grantee(share),
 			Permissions: &collaboration.SharePermissions{
 				Permissions: convertPermissions(share.Permissions),
 			},
@@ -108,6 +114,23 @@ func shareReq(info *provider.ResourceInfo, share *share) *collaboration.CreateSh
 	}
 }
 
+func grantee(share *share) *provider.Grantee {
+	if share.ShareType == "group" {
+		return &provider.Grantee{
+			Type: provider.GranteeType_GRANTEE_TYPE_GROUP,
+			Id: &provider.Grantee_GroupId{GroupId: &group.GroupId{
+				OpaqueId: share.SharedWith,
+			}},
+		}
+	}
+	return &provider.Grantee{
+		Type: provider.GranteeType_GRANTEE_TYPE_USER,
+		Id: &provider.Grantee_UserId{UserId: &user.UserId{
+			OpaqueId: share.SharedWith,
+		}},
+	}
+}
+
 // Maps oc10 permissions to roles
 var ocPermToRole = map[int]string{
 	1:  "viewer",
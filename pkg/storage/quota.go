@@ -0,0 +1,60 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+package storage
+
+import (
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	"github.com/cs3org/reva/pkg/utils"
+)
+
+// QuotaRefOpaqueKey is the opaque entry of a GetQuota request carrying the
+// resource whose storage space quota is requested, as the request of the
+// storage provider has no reference of its own.
+const QuotaRefOpaqueKey = "ref"
+
+// QuotaRefOpaque adds the reference to the opaque of a GetQuota request.
+func QuotaRefOpaque(o *types.Opaque, ref *provider.Reference) (*types.Opaque, error) {
+	if ref == nil {
+		return o, nil
+	}
+	b, err := utils.MarshalProtoV1ToJSON(ref)
+	if err != nil {
+		return nil, err
+	}
+	res := &types.Opaque{Map: map[string]*types.OpaqueEntry{}}
+	for k, v := range o.GetMap() {
+		res.Map[k] = v
+	}
+	res.Map[QuotaRefOpaqueKey] = &types.OpaqueEntry{Decoder: "json", Value: b}
+	return res, nil
+}
+
+// QuotaRefFromOpaque returns the reference held in the opaque of a GetQuota
+// request, or nil when the request targets the whole storage.
+func QuotaRefFromOpaque(o *types.Opaque) (*provider.Reference, error) {
+	e, ok := o.GetMap()[QuotaRefOpaqueKey]
+	if !ok {
+		return nil, nil
+	}
+	ref := &provider.Reference{}
+	if err := utils.UnmarshalJSONToProtoV1(e.Value, ref); err != nil {
+		return nil, err
+	}
+	return ref, nil
+}
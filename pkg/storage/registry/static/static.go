@@ -31,6 +31,7 @@ import (
 	"github.com/cs3org/reva/pkg/storage"
 	"github.com/cs3org/reva/pkg/storage/registry/registry"
 	"github.com/cs3org/reva/pkg/storage/utils/templates"
+	"github.com/cs3org/reva/pkg/tenant"
 	"github.com/cs3org/reva/pkg/user"
 	"github.com/mitchellh/mapstructure"
 	"github.com/pkg/errors"
@@ -108,9 +109,26 @@ func getProviderAddr(ctx context.Context, r rule) string {
 	return addr
 }
 
+// rules returns the rules of the registry along with the storage rules of
+// the tenant of the context, which take precedence.
+func (b *reg) rules(ctx context.Context) map[string]rule {
+	t, ok := tenant.ContextGetTenant(ctx)
+	if !ok || len(t.StorageRules) == 0 {
+		return b.c.Rules
+	}
+	rules := make(map[string]rule, len(b.c.Rules)+len(t.StorageRules))
+	for k, v := range b.c.Rules {
+		rules[k] = v
+	}
+	for k, addr := range t.StorageRules {
+		rules[k] = rule{Address: addr}
+	}
+	return rules
+}
+
 func (b *reg) ListProviders(ctx context.Context) ([]*registrypb.ProviderInfo, error) {
 	providers := []*registrypb.ProviderInfo{}
-	for k, v := range b.c.Rules {
+	for k, v := range b.rules(ctx) {
 		if addr := getProviderAddr(ctx, v); addr != "" {
 			combs := generateRegexCombinations(k)
 			for _, c := range combs {
@@ -127,7 +145,7 @@ func (b *reg) ListProviders(ctx context.Context) ([]*registrypb.ProviderInfo, er
 // returns the the root path of the first provider in the list.
 func (b *reg) GetHome(ctx context.Context) (*registrypb.ProviderInfo, error) {
 	// Assume that HomeProvider is not a regexp
-	if r, ok := b.c.Rules[b.c.HomeProvider]; ok {
+	if r, ok := b.rules(ctx)[b.c.HomeProvider]; ok {
 		if addr := getProviderAddr(ctx, r); addr != "" {
 			return &registrypb.ProviderInfo{
 				ProviderPath: b.c.HomeProvider,
@@ -143,10 +161,12 @@ func (b *reg) FindProviders(ctx context.Context, ref *provider.Reference) ([]*re
 	var match *registrypb.ProviderInfo
 	var shardedMatches []*registrypb.ProviderInfo
 
+	rules := b.rules(ctx)
+
 	// Try to find by path first as most storage operations will be done using the path.
 	fn := path.Clean(ref.GetPath())
 	if fn != "" {
-		for prefix, rule := range b.c.Rules {
+		for prefix, rule := range rules {
 			addr := getProviderAddr(ctx, rule)
 			r, err := regexp.Compile("^" + prefix)
 			if err != nil {
@@ -185,7 +205,7 @@ func (b *reg) FindProviders(ctx context.Context, ref *provider.Reference) ([]*re
 		return nil, errtypes.NotFound("storage provider not found for ref " + ref.String())
 	}
 
-	for prefix, rule := range b.c.Rules {
+	for prefix, rule := range rules {
 		addr := getProviderAddr(ctx, rule)
 		r, err := regexp.Compile("^" + prefix + "$")
 		if err != nil {
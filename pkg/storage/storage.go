@@ -22,7 +22,10 @@ import (
 	"context"
 	"io"
 	"net/url"
+	"strings"
+	"time"
 
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
 	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	registry "github.com/cs3org/go-cs3apis/cs3/storage/registry/v1beta1"
 )
@@ -58,6 +61,185 @@ type FS interface {
 	UnsetArbitraryMetadata(ctx context.Context, ref *provider.Reference, keys []string) error
 }
 
+// QuotaManager is implemented by the storage drivers managing a quota per
+// storage space.
+type QuotaManager interface {
+	// GetSpaceQuota returns the quota and the usage of the storage space
+	// holding the given resource.
+	GetSpaceQuota(ctx context.Context, ref *provider.Reference) (uint64, uint64, error)
+	// SetSpaceQuota sets the maximum number of bytes of the storage space
+	// rooted at the given resource, 0 meaning unlimited.
+	SetSpaceQuota(ctx context.Context, ref *provider.Reference, maxBytes uint64) error
+}
+
+// SpacesManager is implemented by the storage drivers managing project spaces.
+type SpacesManager interface {
+	// CreateStorageSpace creates a storage space and makes the current user its manager.
+	CreateStorageSpace(ctx context.Context, req *provider.CreateStorageSpaceRequest) (*provider.StorageSpace, error)
+	// ListStorageSpaces lists the storage spaces the current user has access to.
+	ListStorageSpaces(ctx context.Context, filters []*provider.ListStorageSpacesRequest_Filter) ([]*provider.StorageSpace, error)
+	// RenameStorageSpace changes the name of the storage space.
+	RenameStorageSpace(ctx context.Context, id, name string) error
+	// DisableStorageSpace makes the storage space inaccessible until it is restored.
+	DisableStorageSpace(ctx context.Context, id string) error
+	// RestoreStorageSpace makes a disabled storage space accessible again.
+	RestoreStorageSpace(ctx context.Context, id string) error
+	// PurgeStorageSpace deletes a disabled storage space and its content.
+	PurgeStorageSpace(ctx context.Context, id string) error
+}
+
+// RecyclePurger is implemented by the storage drivers able to purge their
+// recycle bins in batches, without acting on behalf of a user.
+type RecyclePurger interface {
+	// ListRecycleBins returns the ids of the recycle bins, one per user or space.
+	ListRecycleBins(ctx context.Context) ([]string, error)
+	// PurgeRecycleBin purges the items of the given recycle bin deleted before
+	// the given time and returns the number of purged items and reclaimed bytes.
+	PurgeRecycleBin(ctx context.Context, id string, before time.Time) (int, uint64, error)
+}
+
+// TreeSizeRecalculator is implemented by the storage drivers accounting the
+// size of the folders, which can drift when the propagation is interrupted.
+type TreeSizeRecalculator interface {
+	// RecalculateTreeSize recalculates the size of the folders of the storage
+	// space from the sizes of its files and returns the size of the space.
+	RecalculateTreeSize(ctx context.Context, id string) (uint64, error)
+}
+
+// UploadPurger is implemented by the storage drivers able to delete the
+// upload sessions abandoned by the clients.
+type UploadPurger interface {
+	// PurgeExpiredUploads deletes the uploads which were not written to
+	// since the given time and returns their number.
+	PurgeExpiredUploads(ctx context.Context, before time.Time) (int, error)
+}
+
+// RevisionRestorer is implemented by the storage drivers able to restore a
+// revision to another location.
+type RevisionRestorer interface {
+	// RestoreRevisionTo restores the revision of the resource as a new file
+	// at the target, leaving the current version of the resource untouched.
+	RestoreRevisionTo(ctx context.Context, ref *provider.Reference, key string, target *provider.Reference) error
+}
+
+// RevisionPruner is implemented by the storage drivers able to delete
+// individual revisions, without acting on behalf of a user.
+type RevisionPruner interface {
+	// WalkRevisions calls fn with the revisions of every file having some,
+	// stopping at the first error returned by fn.
+	WalkRevisions(ctx context.Context, fn func(revisions []*provider.FileVersion) error) error
+	// DeleteRevision deletes the revision with the given key.
+	DeleteRevision(ctx context.Context, key string) error
+}
+
+// BlobDeduplicator is implemented by the storage drivers able to store the
+// identical files uploaded to a storage space only once.
+type BlobDeduplicator interface {
+	// SetSpaceDedup enables or disables the deduplication of the content
+	// uploaded to the storage space.
+	SetSpaceDedup(ctx context.Context, id string, enabled bool) error
+	// CollectBlobs deletes the stored content which is not referenced
+	// anymore and returns the number of deleted blobs.
+	CollectBlobs(ctx context.Context) (int, error)
+}
+
+// BlobTierer is implemented by the storage drivers able to move the content
+// which is not accessed to a cheaper storage, and to recall it on access.
+type BlobTierer interface {
+	// SetSpaceTiering sets the number of days after which the content of the
+	// storage space which was not accessed is moved to the cold storage, 0
+	// meaning never and a negative number falling back to the default.
+	SetSpaceTiering(ctx context.Context, id string, days int) error
+	// TierBlobs moves the content which expired to the cold storage and
+	// returns the number of moved blobs.
+	TierBlobs(ctx context.Context) (int, error)
+}
+
+// SpaceEncrypter is implemented by the storage drivers able to encrypt the
+// content of the storage spaces with keys of their own, which can be wrapped
+// with a passphrase held by the users of the space.
+type SpaceEncrypter interface {
+	// EnableSpaceEncryption encrypts the content of the storage space with
+	// keys wrapped with the passphrase, or with the master key of the storage
+	// if it is empty.
+	EnableSpaceEncryption(ctx context.Context, id, passphrase string) error
+	// UnlockSpace unwraps the keys of the storage space with the passphrase.
+	UnlockSpace(ctx context.Context, id, passphrase string) error
+	// LockSpace forgets the unwrapped keys of the storage space.
+	LockSpace(ctx context.Context, id string) error
+	// RotateSpaceKey encrypts the new content of the storage space with a new
+	// key.
+	RotateSpaceKey(ctx context.Context, id string) error
+	// ChangeSpacePassphrase wraps the keys of the storage space with a new
+	// passphrase, or with the master key of the storage if it is empty.
+	ChangeSpacePassphrase(ctx context.Context, id, oldPassphrase, newPassphrase string) error
+}
+
+// Types of the locks. The locks are advisory: the storage drivers keep them
+// but leave it to the clients to honour them.
+const (
+	LockTypeShared    = "shared"
+	LockTypeWrite     = "write"
+	LockTypeExclusive = "exclusive"
+)
+
+// Lock is a lock on a resource. Whoever knows the lock id can refresh or
+// release it, which lets several users of an application share a lock.
+type Lock struct {
+	LockID string         `json:"lock_id"`
+	Type   string         `json:"type"`
+	User   *userpb.UserId `json:"user,omitempty"`
+	// AppName is the name of the application holding the lock, if any.
+	AppName string `json:"app_name,omitempty"`
+	// Expiration is the time the lock expires at, zero meaning never.
+	Expiration time.Time `json:"expiration,omitempty"`
+}
+
+// Expired tells whether the lock has expired.
+func (l *Lock) Expired() bool {
+	return !l.Expiration.IsZero() && time.Now().After(l.Expiration)
+}
+
+// Locker is implemented by the storage drivers persisting locks on the
+// resources.
+type Locker interface {
+	// GetLock returns the lock of the resource, or nil if it is not locked.
+	GetLock(ctx context.Context, ref *provider.Reference) (*Lock, error)
+	// SetLock locks the resource, failing if it is locked with another lock id.
+	SetLock(ctx context.Context, ref *provider.Reference, lock *Lock) error
+	// RefreshLock replaces the lock of the resource, which must have the
+	// same lock id, eg. to extend its expiration.
+	RefreshLock(ctx context.Context, ref *provider.Reference, lock *Lock) error
+	// Unlock removes the lock of the resource, which must have the same lock id.
+	Unlock(ctx context.Context, ref *provider.Reference, lock *Lock) error
+}
+
+// RecycleFilter restricts the recycle bin items being listed.
+type RecycleFilter struct {
+	// From and To, if not zero, bound the deletion time of the items.
+	From, To time.Time
+	// PathPrefix, if set, only keeps the items whose original path is or is
+	// below it.
+	PathPrefix string
+}
+
+// MatchTime tells whether an item deleted at t passes the date range.
+func (f *RecycleFilter) MatchTime(t time.Time) bool {
+	return (f.From.IsZero() || !t.Before(f.From)) && (f.To.IsZero() || !t.After(f.To))
+}
+
+// MatchPath tells whether an item originally at p passes the path filter.
+func (f *RecycleFilter) MatchPath(p string) bool {
+	prefix := strings.TrimSuffix(f.PathPrefix, "/")
+	return prefix == "" || p == prefix || strings.HasPrefix(p, prefix+"/")
+}
+
+// RecycleFilterer is implemented by the storage drivers able to filter the
+// recycle bin items while listing them, instead of listing them all.
+type RecycleFilterer interface {
+	ListRecycleFiltered(ctx context.Context, filter *RecycleFilter) ([]*provider.RecycleItem, error)
+}
+
 // Registry is the interface that storage registries implement
 // for discovering storage providers
 type Registry interface {
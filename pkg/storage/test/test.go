@@ -0,0 +1,390 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package test provides a conformance test suite for the storage drivers,
+// checking that they implement the semantics of the storage.FS interface
+// the same way. The tests of a driver run it against a fresh instance of the
+// driver:
+//
+//	func TestConformance(t *testing.T) {
+//		test.Run(t, func(t *testing.T) *test.Env {
+//			...
+//		}, test.Features{Recycle: true})
+//	}
+package test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+	"testing"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/storage"
+	"github.com/pkg/errors"
+)
+
+// Env is an instance of the driver under test.
+type Env struct {
+	FS storage.FS
+	// Ctx is the context of a user allowed to write in Root, e.g. the owner
+	// of the home.
+	Ctx context.Context
+	// Root is the existing folder the suite creates its resources in.
+	Root string
+}
+
+// Setup returns a fresh instance of the driver under test. The instance is
+// shut down at the end of the test.
+type Setup func(t *testing.T) *Env
+
+// Features are the optional features of the drivers, the tests of the
+// features a driver does not support being skipped.
+type Features struct {
+	Recycle   bool
+	Revisions bool
+	Grants    bool
+}
+
+// Run runs the conformance suite against the driver returned by setup.
+func Run(t *testing.T, setup Setup, f Features) {
+	tests := []struct {
+		name    string
+		enabled bool
+		run     func(t *testing.T, env *Env)
+	}{
+		{"Stat", true, testStat},
+		{"ListFolder", true, testListFolder},
+		{"Upload", true, testUpload},
+		{"Move", true, testMove},
+		{"Recycle", f.Recycle, testRecycle},
+		{"Revisions", f.Revisions, testRevisions},
+		{"Grants", f.Grants, testGrants},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			if !tt.enabled {
+				t.Skip("not supported by the driver")
+			}
+			env := setup(t)
+			t.Cleanup(func() {
+				if err := env.FS.Shutdown(context.Background()); err != nil {
+					t.Errorf("error shutting down the driver: %v", err)
+				}
+			})
+			dir := path.Join(env.Root, "conformance")
+			if err := env.FS.CreateDir(env.Ctx, dir); err != nil {
+				t.Fatalf("error creating %s: %v", dir, err)
+			}
+			tt.run(t, &Env{FS: env.FS, Ctx: env.Ctx, Root: dir})
+		})
+	}
+}
+
+func testStat(t *testing.T, env *Env) {
+	dir := path.Join(env.Root, "folder")
+	mkdir(t, env, dir)
+	info := stat(t, env, dir)
+	if info.Type != provider.ResourceType_RESOURCE_TYPE_CONTAINER {
+		t.Errorf("%s has type %s, expected a container", dir, info.Type)
+	}
+	if path.Base(info.Path) != "folder" {
+		t.Errorf("%s has path %s", dir, info.Path)
+	}
+
+	fn := path.Join(env.Root, "file.txt")
+	upload(t, env, fn, "hello")
+	info = stat(t, env, fn)
+	if info.Type != provider.ResourceType_RESOURCE_TYPE_FILE {
+		t.Errorf("%s has type %s, expected a file", fn, info.Type)
+	}
+	if info.Size != 5 {
+		t.Errorf("%s has size %d, expected 5", fn, info.Size)
+	}
+	if info.Etag == "" {
+		t.Errorf("%s has no etag", fn)
+	}
+
+	if err := env.FS.CreateDir(env.Ctx, dir); !isAlreadyExists(err) {
+		t.Errorf("creating the existing %s returned %v, expected already exists", dir, err)
+	}
+	missing := path.Join(env.Root, "missing")
+	if _, err := env.FS.GetMD(env.Ctx, ref(missing), nil); !isNotFound(err) {
+		t.Errorf("stating the missing %s returned %v, expected not found", missing, err)
+	}
+}
+
+func testListFolder(t *testing.T, env *Env) {
+	dir := path.Join(env.Root, "folder")
+	mkdir(t, env, dir)
+	mkdir(t, env, path.Join(dir, "sub"))
+	upload(t, env, path.Join(dir, "a.txt"), "a")
+	upload(t, env, path.Join(dir, "b.txt"), "b")
+
+	infos, err := env.FS.ListFolder(env.Ctx, ref(dir), nil)
+	if err != nil {
+		t.Fatalf("error listing %s: %v", dir, err)
+	}
+	names := make([]string, 0, len(infos))
+	for _, info := range infos {
+		names = append(names, path.Base(info.Path))
+	}
+	sort.Strings(names)
+	if got := strings.Join(names, ","); got != "a.txt,b.txt,sub" {
+		t.Errorf("%s lists %s, expected a.txt,b.txt,sub", dir, got)
+	}
+
+	missing := path.Join(env.Root, "missing")
+	if _, err := env.FS.ListFolder(env.Ctx, ref(missing), nil); !isNotFound(err) {
+		t.Errorf("listing the missing %s returned %v, expected not found", missing, err)
+	}
+}
+
+func testUpload(t *testing.T, env *Env) {
+	fn := path.Join(env.Root, "file.txt")
+	upload(t, env, fn, "first")
+	if got := download(t, env, fn); got != "first" {
+		t.Errorf("%s holds %q, expected %q", fn, got, "first")
+	}
+	before := stat(t, env, fn)
+
+	upload(t, env, fn, "second version")
+	if got := download(t, env, fn); got != "second version" {
+		t.Errorf("%s holds %q after the overwrite, expected %q", fn, got, "second version")
+	}
+	after := stat(t, env, fn)
+	if after.Size != uint64(len("second version")) {
+		t.Errorf("%s has size %d after the overwrite", fn, after.Size)
+	}
+	if after.Etag == before.Etag {
+		t.Errorf("the etag of %s did not change with its content", fn)
+	}
+	if after.Id.GetOpaqueId() == "" {
+		t.Errorf("%s has no id", fn)
+	}
+}
+
+func testMove(t *testing.T, env *Env) {
+	src, dst := path.Join(env.Root, "src.txt"), path.Join(env.Root, "dst.txt")
+	upload(t, env, src, "moved")
+	if err := env.FS.Move(env.Ctx, ref(src), ref(dst)); err != nil {
+		t.Fatalf("error moving %s to %s: %v", src, dst, err)
+	}
+	if _, err := env.FS.GetMD(env.Ctx, ref(src), nil); !isNotFound(err) {
+		t.Errorf("stating the moved %s returned %v, expected not found", src, err)
+	}
+	if got := download(t, env, dst); got != "moved" {
+		t.Errorf("%s holds %q, expected %q", dst, got, "moved")
+	}
+}
+
+func testRecycle(t *testing.T, env *Env) {
+	fn := path.Join(env.Root, "deleted.txt")
+	upload(t, env, fn, "deleted")
+	remove(t, env, fn)
+	if _, err := env.FS.GetMD(env.Ctx, ref(fn), nil); !isNotFound(err) {
+		t.Fatalf("stating the deleted %s returned %v, expected not found", fn, err)
+	}
+
+	item := findRecycleItem(t, env, "deleted.txt")
+	if item == nil {
+		t.Fatalf("%s is not in the recycle bin", fn)
+	}
+	if err := env.FS.RestoreRecycleItem(env.Ctx, item.Key, ""); err != nil {
+		t.Fatalf("error restoring %s: %v", fn, err)
+	}
+	if got := download(t, env, fn); got != "deleted" {
+		t.Errorf("the restored %s holds %q, expected %q", fn, got, "deleted")
+	}
+	if findRecycleItem(t, env, "deleted.txt") != nil {
+		t.Errorf("the restored %s is still in the recycle bin", fn)
+	}
+
+	remove(t, env, fn)
+	item = findRecycleItem(t, env, "deleted.txt")
+	if item == nil {
+		t.Fatalf("%s is not in the recycle bin", fn)
+	}
+	if err := env.FS.PurgeRecycleItem(env.Ctx, item.Key); err != nil {
+		t.Fatalf("error purging %s: %v", fn, err)
+	}
+	if findRecycleItem(t, env, "deleted.txt") != nil {
+		t.Errorf("the purged %s is still in the recycle bin", fn)
+	}
+}
+
+func testRevisions(t *testing.T, env *Env) {
+	fn := path.Join(env.Root, "versioned.txt")
+	upload(t, env, fn, "v1")
+	upload(t, env, fn, "v2")
+
+	revisions, err := env.FS.ListRevisions(env.Ctx, ref(fn))
+	if err != nil {
+		t.Fatalf("error listing the revisions of %s: %v", fn, err)
+	}
+	if len(revisions) != 1 {
+		t.Fatalf("%s has %d revisions, expected 1", fn, len(revisions))
+	}
+	key := revisions[0].Key
+
+	r, err := env.FS.DownloadRevision(env.Ctx, ref(fn), key)
+	if err != nil {
+		t.Fatalf("error downloading the revision %s of %s: %v", key, fn, err)
+	}
+	defer r.Close()
+	if b, err := ioutil.ReadAll(r); err != nil || string(b) != "v1" {
+		t.Errorf("the revision of %s holds %q (%v), expected %q", fn, b, err, "v1")
+	}
+
+	if err := env.FS.RestoreRevision(env.Ctx, ref(fn), key); err != nil {
+		t.Fatalf("error restoring the revision %s of %s: %v", key, fn, err)
+	}
+	if got := download(t, env, fn); got != "v1" {
+		t.Errorf("%s holds %q after the restore, expected %q", fn, got, "v1")
+	}
+}
+
+func testGrants(t *testing.T, env *Env) {
+	fn := path.Join(env.Root, "shared.txt")
+	upload(t, env, fn, "shared")
+	grantee := &provider.Grantee{
+		Type: provider.GranteeType_GRANTEE_TYPE_USER,
+		Id:   &provider.Grantee_UserId{UserId: &userpb.UserId{Idp: "idp.example.org", OpaqueId: "conformance-grantee"}},
+	}
+
+	g := &provider.Grant{
+		Grantee:     grantee,
+		Permissions: &provider.ResourcePermissions{Stat: true, InitiateFileDownload: true},
+	}
+	if err := env.FS.AddGrant(env.Ctx, ref(fn), g); err != nil {
+		t.Fatalf("error adding a grant to %s: %v", fn, err)
+	}
+	got := findGrant(t, env, fn, grantee)
+	if got == nil || !got.Permissions.GetStat() || !got.Permissions.GetInitiateFileDownload() {
+		t.Fatalf("%s has the grant %v, expected %v", fn, got, g)
+	}
+
+	g.Permissions.InitiateFileUpload = true
+	if err := env.FS.UpdateGrant(env.Ctx, ref(fn), g); err != nil {
+		t.Fatalf("error updating the grant of %s: %v", fn, err)
+	}
+	if got := findGrant(t, env, fn, grantee); got == nil || !got.Permissions.GetInitiateFileUpload() {
+		t.Errorf("%s has the grant %v after the update, expected %v", fn, got, g)
+	}
+
+	if err := env.FS.RemoveGrant(env.Ctx, ref(fn), g); err != nil {
+		t.Fatalf("error removing the grant of %s: %v", fn, err)
+	}
+	if got := findGrant(t, env, fn, grantee); got != nil {
+		t.Errorf("%s still has the removed grant %v", fn, got)
+	}
+}
+
+func ref(p string) *provider.Reference {
+	return &provider.Reference{Spec: &provider.Reference_Path{Path: p}}
+}
+
+func mkdir(t *testing.T, env *Env, p string) {
+	t.Helper()
+	if err := env.FS.CreateDir(env.Ctx, p); err != nil {
+		t.Fatalf("error creating %s: %v", p, err)
+	}
+}
+
+func stat(t *testing.T, env *Env, p string) *provider.ResourceInfo {
+	t.Helper()
+	info, err := env.FS.GetMD(env.Ctx, ref(p), nil)
+	if err != nil {
+		t.Fatalf("error stating %s: %v", p, err)
+	}
+	return info
+}
+
+func upload(t *testing.T, env *Env, p, content string) {
+	t.Helper()
+	if err := env.FS.Upload(env.Ctx, ref(p), ioutil.NopCloser(bytes.NewBufferString(content))); err != nil {
+		t.Fatalf("error uploading %s: %v", p, err)
+	}
+}
+
+func download(t *testing.T, env *Env, p string) string {
+	t.Helper()
+	r, err := env.FS.Download(env.Ctx, ref(p))
+	if err != nil {
+		t.Fatalf("error downloading %s: %v", p, err)
+	}
+	defer r.Close()
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatalf("error reading %s: %v", p, err)
+	}
+	return string(b)
+}
+
+func remove(t *testing.T, env *Env, p string) {
+	t.Helper()
+	if err := env.FS.Delete(env.Ctx, ref(p)); err != nil {
+		t.Fatalf("error deleting %s: %v", p, err)
+	}
+}
+
+func findRecycleItem(t *testing.T, env *Env, name string) *provider.RecycleItem {
+	t.Helper()
+	items, err := env.FS.ListRecycle(env.Ctx)
+	if err != nil {
+		t.Fatalf("error listing the recycle bin: %v", err)
+	}
+	for _, item := range items {
+		if path.Base(item.Path) == name {
+			return item
+		}
+	}
+	return nil
+}
+
+func findGrant(t *testing.T, env *Env, p string, grantee *provider.Grantee) *provider.Grant {
+	t.Helper()
+	grants, err := env.FS.ListGrants(env.Ctx, ref(p))
+	if err != nil {
+		t.Fatalf("error listing the grants of %s: %v", p, err)
+	}
+	for _, g := range grants {
+		if g.Grantee.GetUserId().GetOpaqueId() == grantee.GetUserId().GetOpaqueId() {
+			return g
+		}
+	}
+	return nil
+}
+
+// isNotFound tells whether the error, possibly wrapped by the driver, is a
+// not found error.
+func isNotFound(err error) bool {
+	_, ok := errors.Cause(err).(errtypes.IsNotFound)
+	return ok
+}
+
+func isAlreadyExists(err error) bool {
+	_, ok := errors.Cause(err).(errtypes.IsAlreadyExists)
+	return ok
+}
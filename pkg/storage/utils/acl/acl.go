@@ -92,6 +92,30 @@ func (m *ACLs) DeleteEntry(aclType string, qualifier string) {
 	}
 }
 
+// GetEntry returns the entry uniquely identified by acl type and qualifier,
+// or nil if there is none.
+func (m *ACLs) GetEntry(aclType string, qualifier string) *Entry {
+	for _, e := range m.Entries {
+		if e.Qualifier == qualifier && e.Type == aclType {
+			return e
+		}
+	}
+	return nil
+}
+
+// Merge appends the entries of other that are not already present in m.
+// On conflicting entries the ones of m are kept.
+func (m *ACLs) Merge(other *ACLs) {
+	if other == nil {
+		return
+	}
+	for _, e := range other.Entries {
+		if m.GetEntry(e.Type, e.Qualifier) == nil {
+			m.Entries = append(m.Entries, e)
+		}
+	}
+}
+
 // SetEntry replaces the permissions of an entry with the given set
 func (m *ACLs) SetEntry(aclType string, qualifier string, permissions string) error {
 	if aclType == "" || permissions == "" {
@@ -29,6 +29,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync"
 
 	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
 	"github.com/cs3org/reva/pkg/appctx"
@@ -38,6 +39,7 @@ import (
 	"github.com/cs3org/reva/pkg/storage/utils/chunking"
 	"github.com/cs3org/reva/pkg/storage/utils/decomposedfs/node"
 	"github.com/cs3org/reva/pkg/storage/utils/decomposedfs/options"
+	"github.com/cs3org/reva/pkg/storage/utils/decomposedfs/tiering"
 	"github.com/cs3org/reva/pkg/storage/utils/decomposedfs/tree"
 	"github.com/cs3org/reva/pkg/storage/utils/decomposedfs/xattrs"
 	"github.com/cs3org/reva/pkg/storage/utils/templates"
@@ -69,6 +71,9 @@ type Tree interface {
 	WriteBlob(key string, reader io.Reader) error
 	ReadBlob(key string) (io.ReadCloser, error)
 	DeleteBlob(key string) error
+	WriteDedupBlob(sum []byte, reader io.Reader) (string, error)
+	RefBlob(key string) error
+	CollectBlobs(ctx context.Context) (int, error)
 
 	Propagate(ctx context.Context, node *node.Node) (err error)
 }
@@ -80,6 +85,11 @@ type Decomposedfs struct {
 	o            *options.Options
 	p            PermissionsChecker
 	chunkHandler *chunking.ChunkHandler
+	// tiers is set when the blobs are tiered
+	tiers *tiering.Blobstore
+	// unlocked holds the keys of the encrypted spaces by space id
+	unlocked   map[string]*unlockedKeys
+	unlockedMu sync.Mutex
 }
 
 // NewDefault returns an instance with default components
@@ -94,8 +104,23 @@ func NewDefault(m map[string]interface{}, bs tree.Blobstore) (storage.FS, error)
 
 	lu.Options = o
 
+	var tiers *tiering.Blobstore
+	if len(o.ColdBlobstore) > 0 {
+		cold, err := tiering.NewColdBlobstore(o.ColdBlobstore)
+		if err != nil {
+			return nil, err
+		}
+		tiers = tiering.New(bs, cold)
+		bs = tiers
+	}
+
 	tp := tree.New(o.Root, o.TreeTimeAccounting, o.TreeSizeAccounting, lu, bs)
-	return New(o, lu, p, tp)
+	fs, err := New(o, lu, p, tp)
+	if err != nil {
+		return nil, err
+	}
+	fs.(*Decomposedfs).tiers = tiers
+	return fs, nil
 }
 
 // New returns an implementation of the storage.FS interface that talks to
@@ -114,6 +139,7 @@ func New(o *options.Options, lu *Lookup, p PermissionsChecker, tp Tree) (storage
 		o:            o,
 		p:            p,
 		chunkHandler: chunking.NewChunkHandler(filepath.Join(o.Root, "uploads")),
+		unlocked:     map[string]*unlockedKeys{},
 	}, nil
 }
 
@@ -330,6 +356,9 @@ func (fs *Decomposedfs) Move(ctx context.Context, oldRef, newRef *provider.Refer
 		err = errtypes.NotFound(filepath.Join(oldNode.ParentID, oldNode.Name))
 		return
 	}
+	if oldNode.SpaceType() != "" {
+		return errtypes.PermissionDenied("Decomposedfs: space roots can only be renamed with UpdateStorageSpace")
+	}
 
 	ok, err := fs.p.HasPermission(ctx, oldNode, func(rp *provider.ResourcePermissions) bool {
 		return rp.Move
@@ -349,11 +378,19 @@ func (fs *Decomposedfs) Move(ctx context.Context, oldRef, newRef *provider.Refer
 		return
 	}
 
+	if err = fs.checkMoveQuota(ctx, oldNode, newNode); err != nil {
+		return
+	}
+
 	return fs.tp.Move(ctx, oldNode, newNode)
 }
 
 // GetMD returns the metadata for the specified resource
 func (fs *Decomposedfs) GetMD(ctx context.Context, ref *provider.Reference, mdKeys []string) (ri *provider.ResourceInfo, err error) {
+	if fs.lu.IsSpacesFolder(ref.GetPath()) {
+		return fs.spacesFolderInfo(ctx)
+	}
+
 	var node *node.Node
 	if node, err = fs.lu.NodeFromResource(ctx, ref); err != nil {
 		return
@@ -377,6 +414,10 @@ func (fs *Decomposedfs) GetMD(ctx context.Context, ref *provider.Reference, mdKe
 
 // ListFolder returns a list of resources in the specified folder
 func (fs *Decomposedfs) ListFolder(ctx context.Context, ref *provider.Reference, mdKeys []string) (finfos []*provider.ResourceInfo, err error) {
+	if fs.lu.IsSpacesFolder(ref.GetPath()) {
+		return fs.listSpacesFolder(ctx, mdKeys)
+	}
+
 	var n *node.Node
 	if n, err = fs.lu.NodeFromResource(ctx, ref); err != nil {
 		return
@@ -422,6 +463,9 @@ func (fs *Decomposedfs) Delete(ctx context.Context, ref *provider.Reference) (er
 		err = errtypes.NotFound(filepath.Join(node.ParentID, node.Name))
 		return
 	}
+	if node.SpaceType() != "" {
+		return errtypes.PermissionDenied("Decomposedfs: space roots can only be deleted with DeleteStorageSpace")
+	}
 
 	ok, err := fs.p.HasPermission(ctx, node, func(rp *provider.ResourcePermissions) bool {
 		return rp.Delete
@@ -458,13 +502,43 @@ func (fs *Decomposedfs) Download(ctx context.Context, ref *provider.Reference) (
 		return nil, errtypes.PermissionDenied(filepath.Join(node.ParentID, node.Name))
 	}
 
-	reader, err := fs.tp.ReadBlob(node.BlobID)
+	reader, err := fs.readContent(node.InternalPath(), node.BlobID)
+	if _, ok := err.(errtypes.IsLocked); ok {
+		// the keys of the encrypted space were not unlocked
+		return nil, err
+	}
 	if err != nil {
 		return nil, errors.Wrap(err, "Decomposedfs: error download blob '"+node.ID+"'")
 	}
 	return reader, nil
 }
 
+// readContent returns a reader for the content of a node or revision, which
+// is read from the node file itself when it was inlined.
+func (fs *Decomposedfs) readContent(nodePath, blobID string) (io.ReadCloser, error) {
+	if blobID == "" {
+		r, err := os.Open(nodePath)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, errtypes.NotFound(filepath.Base(nodePath))
+			}
+			return nil, err
+		}
+		return r, nil
+	}
+	if fs.tiers != nil {
+		fs.accessBlob(nodePath, blobID)
+	}
+	r, err := fs.tp.ReadBlob(blobID)
+	if err != nil {
+		return nil, err
+	}
+	if spaceID, err := xattr.Get(nodePath, xattrs.BlobEncryptionAttr); err == nil {
+		return fs.decryptBlob(string(spaceID), r)
+	}
+	return r, nil
+}
+
 func (fs *Decomposedfs) copyMD(s string, t string) (err error) {
 	var attrs []string
 	if attrs, err = xattr.List(s); err != nil {
@@ -22,6 +22,7 @@ import (
 	"github.com/stretchr/testify/mock"
 
 	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/storage"
 	"github.com/cs3org/reva/pkg/storage/utils/decomposedfs"
 	helpers "github.com/cs3org/reva/pkg/storage/utils/decomposedfs/testhelpers"
 	treemocks "github.com/cs3org/reva/pkg/storage/utils/decomposedfs/tree/mocks"
@@ -91,4 +92,38 @@ var _ = Describe("Decomposed", func() {
 			})
 		})
 	})
+
+	Describe("Move", func() {
+		JustBeforeEach(func() {
+			env.Permissions.On("HasPermission", mock.Anything, mock.Anything, mock.Anything).Return(true, nil)
+		})
+
+		Context("into a space with an insufficient quota", func() {
+			It("returns an error", func() {
+				target := &provider.Reference{Spec: &provider.Reference_Path{Path: "/emptydir"}}
+				Expect(env.Fs.(storage.QuotaManager).SetSpaceQuota(env.Ctx, target, 100)).To(Succeed())
+
+				err := env.Fs.Move(env.Ctx,
+					&provider.Reference{Spec: &provider.Reference_Path{Path: "/dir1/file1"}},
+					&provider.Reference{Spec: &provider.Reference_Path{Path: "/emptydir/file1"}},
+				)
+
+				Expect(err).To(MatchError(ContainSubstring("quota exceeded")))
+			})
+		})
+
+		Context("within the same space", func() {
+			It("works", func() {
+				target := &provider.Reference{Spec: &provider.Reference_Path{Path: "/dir1"}}
+				Expect(env.Fs.(storage.QuotaManager).SetSpaceQuota(env.Ctx, target, 100)).To(Succeed())
+
+				err := env.Fs.Move(env.Ctx,
+					&provider.Reference{Spec: &provider.Reference_Path{Path: "/dir1/file1"}},
+					&provider.Reference{Spec: &provider.Reference_Path{Path: "/dir1/subdir1/file1"}},
+				)
+
+				Expect(err).ToNot(HaveOccurred())
+			})
+		})
+	})
 })
@@ -0,0 +1,60 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package decomposedfs
+
+import (
+	"context"
+
+	"github.com/cs3org/reva/pkg/storage/utils/decomposedfs/node"
+)
+
+// Identical files uploaded to the project spaces with deduplication enabled
+// are stored once: their blobs are named after the sha256 of their content
+// and reference counted by the tree. The reference counts are corrected and
+// the unreferenced blobs deleted by CollectBlobs.
+
+// SetSpaceDedup enables or disables the deduplication of the content
+// uploaded to a project space. The content uploaded before is left as is.
+func (fs *Decomposedfs) SetSpaceDedup(ctx context.Context, id string, enabled bool) error {
+	n, err := fs.managedSpaceRoot(ctx, id)
+	if err != nil {
+		return err
+	}
+	return n.SetDedup(enabled)
+}
+
+// CollectBlobs deletes the deduplicated blobs which are not referenced
+// anymore and returns their number.
+func (fs *Decomposedfs) CollectBlobs(ctx context.Context) (int, error) {
+	return fs.tp.CollectBlobs(ctx)
+}
+
+// dedupEnabled returns whether the content uploaded to the folder is
+// deduplicated, i.e. whether the storage space holding it has deduplication
+// enabled.
+func (fs *Decomposedfs) dedupEnabled(ctx context.Context, folderID string) bool {
+	n, err := node.ReadNode(ctx, fs.lu, folderID)
+	for err == nil && n.Exists {
+		if n.SpaceType() != "" || n.ParentID == "" {
+			return n.Dedup()
+		}
+		n, err = n.Parent()
+	}
+	return false
+}
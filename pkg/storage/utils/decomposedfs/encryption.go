@@ -0,0 +1,326 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package decomposedfs
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+	"syscall"
+	"time"
+
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/storage/utils/decomposedfs/encryption"
+	"github.com/cs3org/reva/pkg/storage/utils/decomposedfs/node"
+	"github.com/cs3org/reva/pkg/storage/utils/decomposedfs/xattrs"
+	"github.com/pkg/errors"
+	"github.com/pkg/xattr"
+)
+
+// The content uploaded to an encrypted project space is encrypted with the
+// data keys of the space, which are wrapped with either the master key of
+// the storage or a passphrase held by the users of the space. The keys of
+// the spaces with a passphrase have to be unlocked by a user knowing it
+// before their content can be read or written, so that it stays unreadable
+// to the storage admins.
+
+// keyringMu serializes the changes to the keyrings
+var keyringMu sync.Mutex
+
+type unlockedKeys struct {
+	keys *encryption.Keys
+	// expires is zero for the keys wrapped with the master key
+	expires time.Time
+}
+
+// EnableSpaceEncryption encrypts the content uploaded to the project space
+// from now on, with keys wrapped with the passphrase or the master key when
+// it is empty. The content uploaded before is left as is.
+func (fs *Decomposedfs) EnableSpaceEncryption(ctx context.Context, id, passphrase string) error {
+	n, err := fs.managedSpaceRoot(ctx, id)
+	if err != nil {
+		return err
+	}
+	if _, err := xattr.Get(n.InternalPath(), xattrs.SpaceKeyringAttr); err == nil {
+		return errtypes.AlreadyExists("Decomposedfs: space " + id + " is already encrypted")
+	}
+	return fs.initSpaceKeyring(n, passphrase)
+}
+
+// initSpaceKeyring creates the keyring of the space rooted at n
+func (fs *Decomposedfs) initSpaceKeyring(n *node.Node, passphrase string) error {
+	secret, err := fs.secret(passphrase)
+	if err != nil {
+		return err
+	}
+	keyring, keys, err := encryption.NewKeyring(secret, passphrase != "")
+	if err != nil {
+		return errors.Wrap(err, "Decomposedfs: could not create keyring")
+	}
+	return fs.writeKeyring(n, keyring, keys)
+}
+
+// UnlockSpace unwraps the keys of the space with the passphrase, making its
+// content readable to its users for the configured time.
+func (fs *Decomposedfs) UnlockSpace(ctx context.Context, id, passphrase string) error {
+	n, err := fs.encryptedSpaceRoot(ctx, id)
+	if err != nil {
+		return err
+	}
+	keyring, err := readKeyring(n)
+	if err != nil {
+		return err
+	}
+	if !keyring.Passphrase {
+		return nil
+	}
+	keys, err := keyring.Unlock(passphrase)
+	if err != nil {
+		return err
+	}
+	fs.cacheKeys(id, keyring, keys)
+	return nil
+}
+
+// LockSpace forgets the unwrapped keys of the space, making its content
+// unreadable until it is unlocked again.
+func (fs *Decomposedfs) LockSpace(ctx context.Context, id string) error {
+	if _, err := fs.encryptedSpaceRoot(ctx, id); err != nil {
+		return err
+	}
+	fs.unlockedMu.Lock()
+	delete(fs.unlocked, id)
+	fs.unlockedMu.Unlock()
+	return nil
+}
+
+// RotateSpaceKey encrypts the content uploaded to the space from now on
+// with a new data key. The content uploaded before stays readable with the
+// previous keys.
+func (fs *Decomposedfs) RotateSpaceKey(ctx context.Context, id string) error {
+	n, err := fs.managedSpaceRoot(ctx, id)
+	if err != nil {
+		return err
+	}
+	keyringMu.Lock()
+	defer keyringMu.Unlock()
+	keyring, err := readKeyring(n)
+	if err != nil {
+		return err
+	}
+	keys, err := fs.spaceKeys(id)
+	if err != nil {
+		return err
+	}
+	if err := keyring.Rotate(keys); err != nil {
+		return errors.Wrap(err, "Decomposedfs: could not rotate key")
+	}
+	return fs.writeKeyring(n, keyring, keys)
+}
+
+// ChangeSpacePassphrase wraps the keys of the space with a new passphrase,
+// or with the master key when it is empty. The old passphrase is ignored
+// when the keys are wrapped with the master key.
+func (fs *Decomposedfs) ChangeSpacePassphrase(ctx context.Context, id, oldPassphrase, newPassphrase string) error {
+	n, err := fs.managedSpaceRoot(ctx, id)
+	if err != nil {
+		return err
+	}
+	keyringMu.Lock()
+	defer keyringMu.Unlock()
+	keyring, err := readKeyring(n)
+	if err != nil {
+		return err
+	}
+	oldSecret := fs.o.EncryptionMasterKey
+	if keyring.Passphrase {
+		oldSecret = oldPassphrase
+	}
+	keys, err := keyring.Unlock(oldSecret)
+	if err != nil {
+		return err
+	}
+	newSecret, err := fs.secret(newPassphrase)
+	if err != nil {
+		return err
+	}
+	if err := keyring.Rewrap(keys, newSecret, newPassphrase != ""); err != nil {
+		return errors.Wrap(err, "Decomposedfs: could not rewrap keys")
+	}
+	return fs.writeKeyring(n, keyring, keys)
+}
+
+// secret returns the passphrase, or the master key if it is empty
+func (fs *Decomposedfs) secret(passphrase string) (string, error) {
+	if passphrase != "" {
+		return passphrase, nil
+	}
+	if fs.o.EncryptionMasterKey == "" {
+		return "", errtypes.NotSupported("Decomposedfs: no encryption master key configured, a passphrase is required")
+	}
+	return fs.o.EncryptionMasterKey, nil
+}
+
+// encryptedSpaceRoot returns the root of the encrypted project space if the
+// user has access to it
+func (fs *Decomposedfs) encryptedSpaceRoot(ctx context.Context, id string) (*node.Node, error) {
+	n, err := node.ReadNode(ctx, fs.lu, id)
+	if err != nil {
+		return nil, err
+	}
+	if !n.Exists || n.SpaceType() != spaceTypeProject {
+		return nil, errtypes.NotFound(id)
+	}
+	ok, err := fs.p.HasPermission(ctx, n, func(rp *provider.ResourcePermissions) bool {
+		return rp.Stat
+	})
+	switch {
+	case err != nil:
+		return nil, errtypes.InternalError(err.Error())
+	case !ok:
+		return nil, errtypes.NotFound(id)
+	}
+	if _, err := xattr.Get(n.InternalPath(), xattrs.SpaceKeyringAttr); err != nil {
+		return nil, errtypes.BadRequest("Decomposedfs: space " + id + " is not encrypted")
+	}
+	return n, nil
+}
+
+// readKeyring reads the keyring of the space rooted at n
+func readKeyring(n *node.Node) (*encryption.Keyring, error) {
+	b, err := xattr.Get(n.InternalPath(), xattrs.SpaceKeyringAttr)
+	if err != nil {
+		if isNoData(err) {
+			return nil, errtypes.BadRequest("Decomposedfs: space " + n.ID + " is not encrypted")
+		}
+		return nil, err
+	}
+	keyring := &encryption.Keyring{}
+	if err := json.Unmarshal(b, keyring); err != nil {
+		return nil, errors.Wrap(err, "Decomposedfs: could not read keyring")
+	}
+	return keyring, nil
+}
+
+// isNoData tells whether the xattr error is about a missing attribute
+func isNoData(err error) bool {
+	if xerr, ok := err.(*xattr.Error); ok {
+		return xerr.Err == syscall.ENODATA
+	}
+	return false
+}
+
+// writeKeyring persists the keyring of the space rooted at n and caches its
+// keys
+func (fs *Decomposedfs) writeKeyring(n *node.Node, keyring *encryption.Keyring, keys *encryption.Keys) error {
+	b, err := json.Marshal(keyring)
+	if err != nil {
+		return err
+	}
+	if err := xattr.Set(n.InternalPath(), xattrs.SpaceKeyringAttr, b); err != nil {
+		return errors.Wrap(err, "Decomposedfs: could not write keyring")
+	}
+	fs.cacheKeys(n.ID, keyring, keys)
+	return nil
+}
+
+func (fs *Decomposedfs) cacheKeys(id string, keyring *encryption.Keyring, keys *encryption.Keys) {
+	u := &unlockedKeys{keys: keys}
+	if keyring.Passphrase {
+		u.expires = time.Now().Add(time.Duration(fs.o.EncryptionUnlockTTL) * time.Second)
+	}
+	fs.unlockedMu.Lock()
+	fs.unlocked[id] = u
+	fs.unlockedMu.Unlock()
+}
+
+// spaceKeys returns the keys of the space, unwrapping them with the master
+// key if needed. It fails with Locked when the keys are wrapped with a
+// passphrase and were not unlocked.
+func (fs *Decomposedfs) spaceKeys(id string) (*encryption.Keys, error) {
+	fs.unlockedMu.Lock()
+	u, ok := fs.unlocked[id]
+	if ok && !u.expires.IsZero() && time.Now().After(u.expires) {
+		delete(fs.unlocked, id)
+		ok = false
+	}
+	fs.unlockedMu.Unlock()
+	if ok {
+		return u.keys, nil
+	}
+
+	keyring, err := readKeyring(node.New(id, "", "", 0, "", nil, fs.lu))
+	if err != nil {
+		return nil, err
+	}
+	if keyring.Passphrase {
+		return nil, errtypes.Locked("Decomposedfs: space " + id + " is locked")
+	}
+	keys, err := keyring.Unlock(fs.o.EncryptionMasterKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "Decomposedfs: could not unwrap keys with the master key")
+	}
+	fs.cacheKeys(id, keyring, keys)
+	return keys, nil
+}
+
+// encryptionSpace returns the id of the encrypted space holding the folder,
+// or an empty string if its content is not encrypted.
+func (fs *Decomposedfs) encryptionSpace(ctx context.Context, folderID string) string {
+	n, err := node.ReadNode(ctx, fs.lu, folderID)
+	for err == nil && n.Exists {
+		if n.SpaceType() != "" || n.ParentID == "" {
+			if _, err := xattr.Get(n.InternalPath(), xattrs.SpaceKeyringAttr); err == nil {
+				return n.ID
+			}
+			return ""
+		}
+		n, err = n.Parent()
+	}
+	return ""
+}
+
+// encryptBlob returns a reader of the content encrypted with the current
+// key of the space
+func (fs *Decomposedfs) encryptBlob(spaceID string, r io.Reader) (io.Reader, error) {
+	keys, err := fs.spaceKeys(spaceID)
+	if err != nil {
+		return nil, err
+	}
+	keyID, key := keys.Current()
+	return encryption.Encrypt(r, keyID, key)
+}
+
+// decryptBlob returns a reader of the content of the blob encrypted with the
+// keys of the space
+func (fs *Decomposedfs) decryptBlob(spaceID string, r io.ReadCloser) (io.ReadCloser, error) {
+	keys, err := fs.spaceKeys(spaceID)
+	if err != nil {
+		r.Close()
+		return nil, err
+	}
+	dr, err := encryption.Decrypt(r, keys.Key)
+	if err != nil {
+		r.Close()
+		return nil, errors.Wrap(err, "Decomposedfs: could not decrypt blob")
+	}
+	return dr, nil
+}
@@ -0,0 +1,120 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package encryption_test
+
+import (
+	"bytes"
+	"io/ioutil"
+
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/storage/utils/decomposedfs/encryption"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Encryption", func() {
+	var (
+		keyring *encryption.Keyring
+		keys    *encryption.Keys
+	)
+
+	BeforeEach(func() {
+		var err error
+		keyring, keys, err = encryption.NewKeyring("secret", true)
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	encrypt := func(keys *encryption.Keys, content []byte) []byte {
+		id, key := keys.Current()
+		r, err := encryption.Encrypt(bytes.NewReader(content), id, key)
+		Expect(err).ToNot(HaveOccurred())
+		b, err := ioutil.ReadAll(r)
+		Expect(err).ToNot(HaveOccurred())
+		return b
+	}
+
+	decrypt := func(keys *encryption.Keys, blob []byte) ([]byte, error) {
+		r, err := encryption.Decrypt(ioutil.NopCloser(bytes.NewReader(blob)), keys.Key)
+		if err != nil {
+			return nil, err
+		}
+		return ioutil.ReadAll(r)
+	}
+
+	Describe("Keyring", func() {
+		It("unlocks with the secret", func() {
+			unlocked, err := keyring.Unlock("secret")
+			Expect(err).ToNot(HaveOccurred())
+			id, key := keys.Current()
+			unlockedID, unlockedKey := unlocked.Current()
+			Expect(unlockedID).To(Equal(id))
+			Expect(unlockedKey).To(Equal(key))
+		})
+
+		It("does not unlock with a wrong secret", func() {
+			_, err := keyring.Unlock("wrong")
+			Expect(err).To(BeAssignableToTypeOf(errtypes.InvalidCredentials("")))
+		})
+
+		It("keeps the previous keys when rotating", func() {
+			blob := encrypt(keys, []byte("before"))
+			Expect(keyring.Rotate(keys)).To(Succeed())
+			Expect(keyring.Keys).To(HaveLen(2))
+
+			unlocked, err := keyring.Unlock("secret")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(decrypt(unlocked, blob)).To(Equal([]byte("before")))
+		})
+
+		It("rewraps the keys with a new secret", func() {
+			blob := encrypt(keys, []byte("content"))
+			Expect(keyring.Rewrap(keys, "new", false)).To(Succeed())
+			Expect(keyring.Passphrase).To(BeFalse())
+
+			_, err := keyring.Unlock("secret")
+			Expect(err).To(HaveOccurred())
+			unlocked, err := keyring.Unlock("new")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(decrypt(unlocked, blob)).To(Equal([]byte("content")))
+		})
+	})
+
+	Describe("Encrypt", func() {
+		It("roundtrips content spanning several chunks", func() {
+			content := bytes.Repeat([]byte("0123456789"), 20000)
+			blob := encrypt(keys, content)
+			Expect(blob).ToNot(ContainSubstring("0123456789"))
+			Expect(decrypt(keys, blob)).To(Equal(content))
+		})
+
+		It("detects tampering", func() {
+			blob := encrypt(keys, []byte("content"))
+			blob[len(blob)-1] ^= 1
+			_, err := decrypt(keys, blob)
+			Expect(err).To(Equal(encryption.ErrCorrupted))
+		})
+
+		It("detects truncation", func() {
+			blob := encrypt(keys, bytes.Repeat([]byte("x"), 200000))
+			_, err := decrypt(keys, blob[:len(blob)-100])
+			Expect(err).To(Equal(encryption.ErrCorrupted))
+		})
+	})
+})
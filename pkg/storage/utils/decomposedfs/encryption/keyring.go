@@ -0,0 +1,192 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package encryption encrypts the blobs of the storage spaces with data keys
+// which are themselves wrapped with a key derived from a secret, either the
+// master key of the storage or a passphrase held by the users of the space.
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	keySize  = 32
+	saltSize = 16
+)
+
+// Keyring holds the data keys of a storage space, wrapped with the key
+// derived from its secret. It is safe to persist as it is.
+type Keyring struct {
+	// Passphrase is set when the secret is a passphrase held by the users
+	// rather than the master key of the storage.
+	Passphrase bool              `json:"passphrase"`
+	Salt       []byte            `json:"salt"`
+	Current    string            `json:"current"`
+	Keys       map[string][]byte `json:"keys"`
+}
+
+// Keys are the unwrapped data keys of a keyring.
+type Keys struct {
+	kek     []byte
+	current string
+	keys    map[string][]byte
+}
+
+// NewKeyring returns a keyring with a single data key, wrapped with the key
+// derived from the secret, and its unwrapped keys.
+func NewKeyring(secret string, passphrase bool) (*Keyring, *Keys, error) {
+	k := &Keyring{Keys: map[string][]byte{}}
+	keys := &Keys{keys: map[string][]byte{}}
+	if err := k.Rewrap(keys, secret, passphrase); err != nil {
+		return nil, nil, err
+	}
+	if err := k.Rotate(keys); err != nil {
+		return nil, nil, err
+	}
+	return k, keys, nil
+}
+
+// Unlock unwraps the data keys with the secret. It fails with
+// InvalidCredentials when the secret is wrong.
+func (k *Keyring) Unlock(secret string) (*Keys, error) {
+	kek, err := deriveKey(secret, k.Salt)
+	if err != nil {
+		return nil, err
+	}
+	keys := &Keys{kek: kek, current: k.Current, keys: map[string][]byte{}}
+	for id, wrapped := range k.Keys {
+		key, err := unwrap(kek, id, wrapped)
+		if err != nil {
+			return nil, errtypes.InvalidCredentials("wrong secret")
+		}
+		keys.keys[id] = key
+	}
+	return keys, nil
+}
+
+// Rotate adds a new data key to the keyring, which is used to encrypt the
+// new content. The previous keys are kept to decrypt the existing content.
+func (k *Keyring) Rotate(keys *Keys) error {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+	id := hex.EncodeToString(b)
+	if _, ok := k.Keys[id]; ok {
+		return k.Rotate(keys)
+	}
+	key := make([]byte, keySize)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+	wrapped, err := wrap(keys.kek, id, key)
+	if err != nil {
+		return err
+	}
+	k.Keys[id] = wrapped
+	k.Current = id
+	keys.keys[id] = key
+	keys.current = id
+	return nil
+}
+
+// Rewrap wraps the data keys with the key derived from a new secret.
+func (k *Keyring) Rewrap(keys *Keys, secret string, passphrase bool) error {
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return err
+	}
+	kek, err := deriveKey(secret, salt)
+	if err != nil {
+		return err
+	}
+	wrappedKeys := make(map[string][]byte, len(keys.keys))
+	for id, key := range keys.keys {
+		if wrappedKeys[id], err = wrap(kek, id, key); err != nil {
+			return err
+		}
+	}
+	k.Passphrase = passphrase
+	k.Salt = salt
+	k.Keys = wrappedKeys
+	keys.kek = kek
+	return nil
+}
+
+// Current returns the id and the value of the key encrypting the new content.
+func (k *Keys) Current() (string, []byte) {
+	return k.current, k.keys[k.current]
+}
+
+// Key returns the key with the given id.
+func (k *Keys) Key(id string) ([]byte, error) {
+	key, ok := k.keys[id]
+	if !ok {
+		return nil, errtypes.NotFound("encryption key " + id)
+	}
+	return key, nil
+}
+
+func deriveKey(secret string, salt []byte) ([]byte, error) {
+	if secret == "" {
+		return nil, errtypes.BadRequest("empty secret")
+	}
+	return scrypt.Key([]byte(secret), salt, 1<<15, 8, 1, keySize)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// wrap seals the key with the kek, binding it to its id
+func wrap(kek []byte, id string, key []byte) ([]byte, error) {
+	aead, err := newAEAD(kek)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, key, []byte(id)), nil
+}
+
+func unwrap(kek []byte, id string, wrapped []byte) ([]byte, error) {
+	aead, err := newAEAD(kek)
+	if err != nil {
+		return nil, err
+	}
+	if len(wrapped) < aead.NonceSize() {
+		return nil, errors.New("wrapped key too short")
+	}
+	nonce, sealed := wrapped[:aead.NonceSize()], wrapped[aead.NonceSize():]
+	return aead.Open(nil, nonce, sealed, []byte(id))
+}
@@ -0,0 +1,179 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package encryption
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// The encrypted blobs start with a header naming the key and holding the
+// nonce prefix, followed by the content sealed in chunks. The nonce of a
+// chunk is the prefix, the chunk counter and a flag marking the last chunk,
+// so that the chunks can neither be reordered nor truncated.
+const (
+	magic       = "RENC\x01"
+	chunkSize   = 64 * 1024
+	prefixSize  = 7
+	maxKeyIDLen = 255
+)
+
+// ErrCorrupted is returned when an encrypted blob can not be decrypted.
+var ErrCorrupted = errors.New("encrypted blob is corrupted")
+
+type encrypter struct {
+	src     io.Reader
+	aead    cipher.AEAD
+	prefix  []byte
+	counter uint32
+	plain   []byte
+	out     []byte
+	done    bool
+}
+
+// Encrypt returns a reader of the content of r encrypted with the key.
+func Encrypt(r io.Reader, keyID string, key []byte) (io.Reader, error) {
+	if len(keyID) > maxKeyIDLen {
+		return nil, errors.New("key id too long")
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	prefix := make([]byte, prefixSize)
+	if _, err := io.ReadFull(rand.Reader, prefix); err != nil {
+		return nil, err
+	}
+	header := append([]byte(magic), byte(len(keyID)))
+	header = append(header, keyID...)
+	header = append(header, prefix...)
+	return &encrypter{
+		src:    r,
+		aead:   aead,
+		prefix: prefix,
+		plain:  make([]byte, chunkSize),
+		out:    header,
+	}, nil
+}
+
+func (e *encrypter) Read(p []byte) (int, error) {
+	for len(e.out) == 0 {
+		if e.done {
+			return 0, io.EOF
+		}
+		// a short chunk is the last one, possibly empty
+		n, err := io.ReadFull(e.src, e.plain)
+		switch err {
+		case nil:
+		case io.EOF, io.ErrUnexpectedEOF:
+			e.done = true
+		default:
+			return 0, err
+		}
+		e.out = e.aead.Seal(e.out[:0], nonce(e.prefix, e.counter, e.done), e.plain[:n], nil)
+		e.counter++
+	}
+	n := copy(p, e.out)
+	e.out = e.out[n:]
+	return n, nil
+}
+
+type decrypter struct {
+	src     io.ReadCloser
+	aead    cipher.AEAD
+	prefix  []byte
+	counter uint32
+	sealed  []byte
+	out     []byte
+	done    bool
+}
+
+// Decrypt returns a reader of the content of the encrypted blob r, looking
+// up the key it was encrypted with by its id.
+func Decrypt(r io.ReadCloser, key func(id string) ([]byte, error)) (io.ReadCloser, error) {
+	header := make([]byte, len(magic)+1)
+	if _, err := io.ReadFull(r, header); err != nil || !bytes.Equal(header[:len(magic)], []byte(magic)) {
+		return nil, ErrCorrupted
+	}
+	rest := make([]byte, int(header[len(magic)])+prefixSize)
+	if _, err := io.ReadFull(r, rest); err != nil {
+		return nil, ErrCorrupted
+	}
+	k, err := key(string(rest[:len(rest)-prefixSize]))
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(k)
+	if err != nil {
+		return nil, err
+	}
+	return &decrypter{
+		src:    r,
+		aead:   aead,
+		prefix: rest[len(rest)-prefixSize:],
+		sealed: make([]byte, chunkSize+aead.Overhead()),
+	}, nil
+}
+
+func (d *decrypter) Read(p []byte) (int, error) {
+	for len(d.out) == 0 {
+		if d.done {
+			return 0, io.EOF
+		}
+		n, err := io.ReadFull(d.src, d.sealed)
+		switch err {
+		case nil:
+		case io.ErrUnexpectedEOF:
+			d.done = true
+		case io.EOF:
+			// the last chunk is missing
+			return 0, ErrCorrupted
+		default:
+			return 0, err
+		}
+		plain, err := d.aead.Open(d.sealed[:0], nonce(d.prefix, d.counter, d.done), d.sealed[:n], nil)
+		if err != nil {
+			return 0, ErrCorrupted
+		}
+		d.out = plain
+		d.counter++
+	}
+	n := copy(p, d.out)
+	d.out = d.out[n:]
+	return n, nil
+}
+
+func (d *decrypter) Close() error {
+	return d.src.Close()
+}
+
+func nonce(prefix []byte, counter uint32, last bool) []byte {
+	n := make([]byte, prefixSize+5)
+	copy(n, prefix)
+	binary.BigEndian.PutUint32(n[prefixSize:], counter)
+	if last {
+		n[prefixSize+4] = 1
+	}
+	return n
+}
@@ -0,0 +1,133 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package decomposedfs
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/storage"
+	"github.com/cs3org/reva/pkg/storage/utils/decomposedfs/node"
+	"github.com/cs3org/reva/pkg/storage/utils/locks"
+	"github.com/cs3org/reva/pkg/user"
+	"github.com/pkg/errors"
+)
+
+// lockMu serializes the changes of the locks, which are checked before
+// being written
+var lockMu sync.Mutex
+
+// GetLock returns the lock of a resource, or nil if it is not locked
+func (fs *Decomposedfs) GetLock(ctx context.Context, ref *provider.Reference) (*storage.Lock, error) {
+	n, err := fs.lockableNode(ctx, ref, func(rp *provider.ResourcePermissions) bool {
+		return rp.Stat
+	})
+	if err != nil {
+		return nil, err
+	}
+	l, err := n.ReadLock()
+	if err != nil || l == nil || l.Expired() {
+		return nil, err
+	}
+	return l, nil
+}
+
+// SetLock locks a resource, unless it is locked with another lock id
+func (fs *Decomposedfs) SetLock(ctx context.Context, ref *provider.Reference, l *storage.Lock) error {
+	return fs.changeLock(ctx, ref, func(current *storage.Lock) (*storage.Lock, error) {
+		if err := locks.CanSet(current, l); err != nil {
+			return nil, err
+		}
+		return l, nil
+	})
+}
+
+// RefreshLock replaces the lock of a resource, which must have the same lock id
+func (fs *Decomposedfs) RefreshLock(ctx context.Context, ref *provider.Reference, l *storage.Lock) error {
+	return fs.changeLock(ctx, ref, func(current *storage.Lock) (*storage.Lock, error) {
+		if err := locks.Validate(l); err != nil {
+			return nil, err
+		}
+		if err := locks.Holds(current, l); err != nil {
+			return nil, err
+		}
+		return l, nil
+	})
+}
+
+// Unlock removes the lock of a resource, which must have the same lock id
+func (fs *Decomposedfs) Unlock(ctx context.Context, ref *provider.Reference, l *storage.Lock) error {
+	return fs.changeLock(ctx, ref, func(current *storage.Lock) (*storage.Lock, error) {
+		if err := locks.Holds(current, l); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	})
+}
+
+// changeLock replaces the lock of the resource with the one returned by
+// change, given the current lock
+func (fs *Decomposedfs) changeLock(ctx context.Context, ref *provider.Reference, change func(current *storage.Lock) (*storage.Lock, error)) error {
+	n, err := fs.lockableNode(ctx, ref, func(rp *provider.ResourcePermissions) bool {
+		return rp.InitiateFileUpload
+	})
+	if err != nil {
+		return err
+	}
+
+	lockMu.Lock()
+	defer lockMu.Unlock()
+
+	current, err := n.ReadLock()
+	if err != nil {
+		return err
+	}
+	l, err := change(current)
+	if err != nil {
+		return err
+	}
+	if l != nil {
+		if u, ok := user.ContextGetUser(ctx); ok {
+			l.User = u.Id
+		}
+	}
+	return n.WriteLock(l)
+}
+
+func (fs *Decomposedfs) lockableNode(ctx context.Context, ref *provider.Reference, check func(*provider.ResourcePermissions) bool) (*node.Node, error) {
+	n, err := fs.lu.NodeFromResource(ctx, ref)
+	if err != nil {
+		return nil, errors.Wrap(err, "Decomposedfs: error resolving ref")
+	}
+	if !n.Exists {
+		return nil, errtypes.NotFound(filepath.Join(n.ParentID, n.Name))
+	}
+
+	ok, err := fs.p.HasPermission(ctx, n, check)
+	switch {
+	case err != nil:
+		return nil, errtypes.InternalError(err.Error())
+	case !ok:
+		return nil, errtypes.PermissionDenied(filepath.Join(n.ParentID, n.Name))
+	}
+	return n, nil
+}
@@ -0,0 +1,99 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package decomposedfs_test
+
+import (
+	"time"
+
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/storage"
+	helpers "github.com/cs3org/reva/pkg/storage/utils/decomposedfs/testhelpers"
+	"github.com/stretchr/testify/mock"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Locks", func() {
+	var (
+		env    *helpers.TestEnv
+		locker storage.Locker
+		ref    = &provider.Reference{Spec: &provider.Reference_Path{Path: "/dir1/file1"}}
+		lock   = func(id string) *storage.Lock {
+			return &storage.Lock{LockID: id, Type: storage.LockTypeExclusive, AppName: "office"}
+		}
+	)
+
+	JustBeforeEach(func() {
+		var err error
+		env, err = helpers.NewTestEnv()
+		Expect(err).ToNot(HaveOccurred())
+		env.Permissions.On("HasPermission", mock.Anything, mock.Anything, mock.Anything).Return(true, nil)
+
+		var ok bool
+		locker, ok = env.Fs.(storage.Locker)
+		Expect(ok).To(BeTrue())
+	})
+
+	AfterEach(func() {
+		if env != nil {
+			env.Cleanup()
+		}
+	})
+
+	It("sets, refreshes and removes a lock", func() {
+		Expect(locker.SetLock(env.Ctx, ref, lock("lock1"))).To(Succeed())
+
+		l, err := locker.GetLock(env.Ctx, ref)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(l.LockID).To(Equal("lock1"))
+		Expect(l.AppName).To(Equal("office"))
+		Expect(l.User.OpaqueId).To(Equal(env.Owner.Id.OpaqueId))
+
+		refreshed := lock("lock1")
+		refreshed.Expiration = time.Now().Add(time.Hour)
+		Expect(locker.RefreshLock(env.Ctx, ref, refreshed)).To(Succeed())
+
+		Expect(locker.Unlock(env.Ctx, ref, lock("lock1"))).To(Succeed())
+		l, err = locker.GetLock(env.Ctx, ref)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(l).To(BeNil())
+	})
+
+	It("refuses another lock id", func() {
+		Expect(locker.SetLock(env.Ctx, ref, lock("lock1"))).To(Succeed())
+
+		err := locker.SetLock(env.Ctx, ref, lock("lock2"))
+		Expect(err).To(BeAssignableToTypeOf(errtypes.Locked("")))
+		err = locker.Unlock(env.Ctx, ref, lock("lock2"))
+		Expect(err).To(BeAssignableToTypeOf(errtypes.Locked("")))
+	})
+
+	It("ignores expired locks", func() {
+		expired := lock("lock1")
+		expired.Expiration = time.Now().Add(-time.Minute)
+		Expect(locker.SetLock(env.Ctx, ref, expired)).To(Succeed())
+
+		l, err := locker.GetLock(env.Ctx, ref)
+		Expect(err).ToNot(HaveOccurred())
+		Expect(l).To(BeNil())
+		Expect(locker.SetLock(env.Ctx, ref, lock("lock2"))).To(Succeed())
+	})
+})
@@ -57,8 +57,15 @@ func (lu *Lookup) NodeFromPath(ctx context.Context, fn string) (*node.Node, erro
 	log := appctx.GetLogger(ctx)
 	log.Debug().Interface("fn", fn).Msg("NodeFromPath()")
 
-	n, err := lu.HomeOrRootNode(ctx)
-	if err != nil {
+	var n *node.Node
+	var err error
+	if name, rest, ok := lu.splitSpacePath(fn); ok {
+		// paths in the spaces folder start at the root of the project space
+		if n, err = lu.SpaceRootByName(ctx, name); err != nil {
+			return nil, err
+		}
+		fn = rest
+	} else if n, err = lu.HomeOrRootNode(ctx); err != nil {
 		return nil, err
 	}
 
@@ -91,6 +98,11 @@ func (lu *Lookup) Path(ctx context.Context, n *node.Node) (p string, err error)
 		return
 	}
 	for n.ID != root.ID {
+		if n.SpaceType() == spaceTypeProject {
+			// project spaces are listed in the spaces folder
+			p = filepath.Join(lu.Options.SpacesFolder, n.Name, p)
+			return
+		}
 		p = filepath.Join(n.Name, p)
 		if n, err = n.Parent(); err != nil {
 			appctx.GetLogger(ctx).
@@ -105,6 +117,47 @@ func (lu *Lookup) Path(ctx context.Context, n *node.Node) (p string, err error)
 	return
 }
 
+// SpaceRootByName returns the root node of the enabled project space with the given name
+func (lu *Lookup) SpaceRootByName(ctx context.Context, name string) (*node.Node, error) {
+	links, err := filepath.Glob(filepath.Join(lu.Options.Root, "spaces", spaceTypeProject, "*"))
+	if err != nil {
+		return nil, err
+	}
+	for _, link := range links {
+		n, err := node.ReadNode(ctx, lu, filepath.Base(link))
+		if err != nil {
+			appctx.GetLogger(ctx).Error().Err(err).Str("link", link).Msg("could not read space root")
+			continue
+		}
+		if n.Exists && n.Name == name && n.DisabledSince() == nil {
+			return n, nil
+		}
+	}
+	return nil, errtypes.NotFound(filepath.Join(lu.Options.SpacesFolder, name))
+}
+
+// IsSpacesFolder returns whether the path is the folder listing the project spaces
+func (lu *Lookup) IsSpacesFolder(fn string) bool {
+	return filepath.Join("/", fn) == lu.Options.SpacesFolder
+}
+
+// splitSpacePath splits a path in the spaces folder into the name of the
+// project space and the path relative to its root
+func (lu *Lookup) splitSpacePath(fn string) (name, rest string, ok bool) {
+	if lu.Options.SpacesFolder == "" {
+		return "", "", false
+	}
+	rel := strings.TrimPrefix(filepath.Join("/", fn), lu.Options.SpacesFolder+"/")
+	if rel == filepath.Join("/", fn) {
+		return "", "", false
+	}
+	parts := strings.SplitN(rel, "/", 2)
+	if len(parts) == 1 {
+		return parts[0], "/", true
+	}
+	return parts[0], "/" + parts[1], true
+}
+
 // RootNode returns the root node of the storage
 func (lu *Lookup) RootNode(ctx context.Context) (*node.Node, error) {
 	return node.New("root", "", "", 0, "", nil, lu), nil
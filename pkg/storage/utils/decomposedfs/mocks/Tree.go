@@ -38,6 +38,27 @@ type Tree struct {
 	mock.Mock
 }
 
+// CollectBlobs provides a mock function with given fields: ctx
+func (_m *Tree) CollectBlobs(ctx context.Context) (int, error) {
+	ret := _m.Called(ctx)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(context.Context) int); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CreateDir provides a mock function with given fields: ctx, _a1
 func (_m *Tree) CreateDir(ctx context.Context, _a1 *node.Node) error {
 	ret := _m.Called(ctx, _a1)
@@ -230,6 +251,20 @@ func (_m *Tree) ReadBlob(key string) (io.ReadCloser, error) {
 	return r0, r1
 }
 
+// RefBlob provides a mock function with given fields: key
+func (_m *Tree) RefBlob(key string) error {
+	ret := _m.Called(key)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(key)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // RestoreRecycleItemFunc provides a mock function with given fields: ctx, key
 func (_m *Tree) RestoreRecycleItemFunc(ctx context.Context, key string) (*node.Node, func() error, error) {
 	ret := _m.Called(ctx, key)
@@ -289,3 +324,24 @@ func (_m *Tree) WriteBlob(key string, reader io.Reader) error {
 
 	return r0
 }
+
+// WriteDedupBlob provides a mock function with given fields: sum, reader
+func (_m *Tree) WriteDedupBlob(sum []byte, reader io.Reader) (string, error) {
+	ret := _m.Called(sum, reader)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func([]byte, io.Reader) string); ok {
+		r0 = rf(sum, reader)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func([]byte, io.Reader) error); ok {
+		r1 = rf(sum, reader)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
@@ -22,6 +22,7 @@ import (
 	"context"
 	"crypto/md5"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"hash"
 	"io"
@@ -44,6 +45,7 @@ import (
 	"github.com/cs3org/reva/pkg/appctx"
 	"github.com/cs3org/reva/pkg/errtypes"
 	"github.com/cs3org/reva/pkg/mime"
+	"github.com/cs3org/reva/pkg/storage"
 	"github.com/cs3org/reva/pkg/storage/utils/ace"
 	"github.com/cs3org/reva/pkg/storage/utils/decomposedfs/xattrs"
 	"github.com/cs3org/reva/pkg/user"
@@ -56,6 +58,9 @@ const (
 	ChecksumsKey  = "http://owncloud.org/ns/checksums"
 	UserShareType = "0"
 	QuotaKey      = "quota"
+	// TierKey is the opaque entry set to "cold" for the files whose blob
+	// was moved to the cold blobstore
+	TierKey = "tier"
 
 	QuotaUncalculated = "-1"
 	QuotaUnknown      = "-2"
@@ -68,7 +73,7 @@ type Node struct {
 	ID       string
 	Name     string
 	Blobsize int64
-	BlobID   string
+	BlobID   string // empty for the files whose content is inlined in the node
 	owner    *userpb.UserId
 	Exists   bool
 
@@ -546,6 +551,20 @@ func (n *Node) AsResourceInfo(ctx context.Context, rp *provider.ResourcePermissi
 		readChecksumIntoOpaque(ctx, nodePath, storageprovider.XSMD5, ri)
 		readChecksumIntoOpaque(ctx, nodePath, storageprovider.XSAdler32, ri)
 	}
+	// storage tier
+	if nodeType == provider.ResourceType_RESOURCE_TYPE_FILE {
+		if tier, err := xattr.Get(nodePath, xattrs.BlobTierAttr); err == nil {
+			if ri.Opaque == nil {
+				ri.Opaque = &types.Opaque{
+					Map: map[string]*types.OpaqueEntry{},
+				}
+			}
+			ri.Opaque.Map[TierKey] = &types.OpaqueEntry{
+				Decoder: "plain",
+				Value:   tier,
+			}
+		}
+	}
 	// quota
 	if _, ok := mdKeysMap[QuotaKey]; (nodeType == provider.ResourceType_RESOURCE_TYPE_CONTAINER) && returnAllKeys || ok {
 		var quotaPath string
@@ -671,6 +690,109 @@ func (n *Node) HasPropagation() (propagation bool) {
 	return false
 }
 
+// SpaceType returns the type of the storage space rooted at the node, or an
+// empty string if the node is not the root of a storage space
+func (n *Node) SpaceType() string {
+	if b, err := xattr.Get(n.lu.InternalPath(n.ID), xattrs.SpaceTypeAttr); err == nil {
+		return string(b)
+	}
+	return ""
+}
+
+// DisabledSince returns when the storage space rooted at the node was disabled
+// or nil if it is enabled
+func (n *Node) DisabledSince() *time.Time {
+	b, err := xattr.Get(n.lu.InternalPath(n.ID), xattrs.SpaceDisabledAttr)
+	if err != nil {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, string(b))
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+// Dedup returns whether the content uploaded to the storage space rooted at
+// the node is deduplicated
+func (n *Node) Dedup() bool {
+	b, err := xattr.Get(n.lu.InternalPath(n.ID), xattrs.SpaceDedupAttr)
+	return err == nil && string(b) == "1"
+}
+
+// SetDedup enables or disables the deduplication of the content uploaded to
+// the storage space rooted at the node
+func (n *Node) SetDedup(enabled bool) error {
+	if enabled {
+		return xattr.Set(n.InternalPath(), xattrs.SpaceDedupAttr, []byte("1"))
+	}
+	err := xattr.Remove(n.InternalPath(), xattrs.SpaceDedupAttr)
+	if isNoData(err) {
+		return nil
+	}
+	return err
+}
+
+// TieringDays returns the number of days after which the blobs of the
+// storage space rooted at the node are moved to the cold blobstore when they
+// are not accessed, and whether the space has a tiering policy
+func (n *Node) TieringDays() (int, bool) {
+	b, err := xattr.Get(n.InternalPath(), xattrs.SpaceTieringAttr)
+	if err != nil {
+		return 0, false
+	}
+	days, err := strconv.Atoi(string(b))
+	if err != nil {
+		return 0, false
+	}
+	return days, true
+}
+
+// SetTieringDays sets the tiering policy of the storage space rooted at the
+// node, a negative number of days removing it
+func (n *Node) SetTieringDays(days int) error {
+	if days >= 0 {
+		return xattr.Set(n.InternalPath(), xattrs.SpaceTieringAttr, []byte(strconv.Itoa(days)))
+	}
+	err := xattr.Remove(n.InternalPath(), xattrs.SpaceTieringAttr)
+	if isNoData(err) {
+		return nil
+	}
+	return err
+}
+
+// ReadLock returns the lock of the node, or nil if it is not locked
+func (n *Node) ReadLock() (*storage.Lock, error) {
+	b, err := xattr.Get(n.InternalPath(), xattrs.LockAttr)
+	switch {
+	case isNoData(err):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+	l := &storage.Lock{}
+	if err := json.Unmarshal(b, l); err != nil {
+		return nil, errors.Wrap(err, "Decomposedfs: could not decode lock")
+	}
+	return l, nil
+}
+
+// WriteLock stores the lock of the node, or removes it if l is nil
+func (n *Node) WriteLock(l *storage.Lock) error {
+	if l == nil {
+		err := xattr.Remove(n.InternalPath(), xattrs.LockAttr)
+		if isNoData(err) {
+			return nil
+		}
+		return err
+	}
+	b, err := json.Marshal(l)
+	if err != nil {
+		return err
+	}
+	return xattr.Set(n.InternalPath(), xattrs.LockAttr, b)
+}
+
 // GetTMTime reads the tmtime from the extended attributes
 func (n *Node) GetTMTime() (tmTime time.Time, err error) {
 	var b []byte
@@ -119,6 +119,11 @@ func (p *Permissions) AssemblePermissions(ctx context.Context, n *Node) (ap *pro
 	// for all segments, starting at the leaf
 	for cn.ID != rn.ID {
 
+		// nothing in a disabled storage space is accessible
+		if cn.DisabledSince() != nil {
+			return NoPermissions, nil
+		}
+
 		if np, err := cn.ReadUserPermissions(ctx, u); err == nil {
 			AddPermissions(ap, np)
 		} else {
@@ -186,6 +191,11 @@ func (p *Permissions) HasPermission(ctx context.Context, n *Node, check func(*pr
 	// for all segments, starting at the leaf
 	for cn.ID != rn.ID {
 
+		// nothing in a disabled storage space is accessible
+		if cn.DisabledSince() != nil {
+			return false, nil
+		}
+
 		var grantees []string
 		if grantees, err = cn.ListGrantees(ctx); err != nil {
 			appctx.GetLogger(ctx).Error().Err(err).Interface("node", cn).Msg("error listing grantees")
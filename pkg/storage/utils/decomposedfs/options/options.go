@@ -40,6 +40,9 @@ type Options struct {
 	// TODO NodeLayout option to save nodes as eg. nodes/1d/d8/1dd84abf-9466-4e14-bb86-02fc4ea3abcf
 	ShareFolder string `mapstructure:"share_folder"`
 
+	// SpacesFolder is the folder listing the project spaces the user is a member of.
+	SpacesFolder string `mapstructure:"spaces_folder"`
+
 	// EnableHome enables the creation of home directories.
 	EnableHome bool `mapstructure:"enable_home"`
 
@@ -51,6 +54,30 @@ type Options struct {
 
 	// set an owner for the root node
 	Owner string `mapstructure:"owner"`
+
+	// InlineThreshold is the size in bytes up to which the content of the files
+	// is kept in their node instead of a blob of the blobstore, saving an inode
+	// per file. 0 disables the inlining.
+	InlineThreshold int64 `mapstructure:"inline_threshold"`
+
+	// ColdBlobstore configures the blobstore the blobs which were not
+	// accessed for a while are moved to, e.g. {"driver": "s3", "s3.bucket": ...}
+	// or {"driver": "local", "root": ...}. Tiering is disabled when it is not set.
+	ColdBlobstore map[string]interface{} `mapstructure:"cold_blobstore"`
+
+	// TieringDays is the number of days after which the blobs which were not
+	// accessed are moved to the cold blobstore, unless the space sets its own
+	// policy. 0 only moves the blobs of the spaces with a policy.
+	TieringDays int `mapstructure:"tiering_days"`
+
+	// EncryptionMasterKey is the secret wrapping the keys of the encrypted
+	// spaces which are not protected by a passphrase of their users. Only
+	// the spaces with a passphrase can be encrypted when it is not set.
+	EncryptionMasterKey string `mapstructure:"encryption_master_key"`
+
+	// EncryptionUnlockTTL is the number of seconds the keys of the spaces
+	// protected by a passphrase stay unlocked, defaults to 3600.
+	EncryptionUnlockTTL int `mapstructure:"encryption_unlock_ttl"`
 }
 
 // New returns a new Options instance for the given configuration
@@ -73,6 +100,15 @@ func New(m map[string]interface{}) (*Options, error) {
 	// ensure share folder always starts with slash
 	o.ShareFolder = filepath.Join("/", o.ShareFolder)
 
+	if o.SpacesFolder == "" {
+		o.SpacesFolder = "/Spaces"
+	}
+	o.SpacesFolder = filepath.Join("/", o.SpacesFolder)
+
+	if o.EncryptionUnlockTTL == 0 {
+		o.EncryptionUnlockTTL = 3600
+	}
+
 	// c.DataDirectory should never end in / unless it is the root
 	o.Root = filepath.Clean(o.Root)
 
@@ -0,0 +1,217 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package decomposedfs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/storage/utils/decomposedfs/node"
+	"github.com/cs3org/reva/pkg/storage/utils/decomposedfs/xattrs"
+	"github.com/pkg/xattr"
+)
+
+// GetSpaceQuota returns the quota and the usage of the storage space holding
+// the resource, i.e. of its closest ancestor with a quota set, or else the
+// project space, home or root node.
+func (fs *Decomposedfs) GetSpaceQuota(ctx context.Context, ref *provider.Reference) (total uint64, used uint64, err error) {
+	n, err := fs.lu.NodeFromResource(ctx, ref)
+	if err != nil {
+		return 0, 0, err
+	}
+	if !n.Exists {
+		return 0, 0, errtypes.NotFound(filepath.Join(n.ParentID, n.Name))
+	}
+
+	root, err := fs.spaceRoot(ctx, n)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	rp, err := fs.p.AssemblePermissions(ctx, root)
+	switch {
+	case err != nil:
+		return 0, 0, errtypes.InternalError(err.Error())
+	case !rp.GetQuota:
+		return 0, 0, errtypes.PermissionDenied(root.ID)
+	}
+
+	return fs.spaceUsage(root)
+}
+
+// spaceUsage returns the quota and the usage of the storage space rooted at
+// the node, without checking the permissions of the user.
+func (fs *Decomposedfs) spaceUsage(root *node.Node) (total uint64, used uint64, err error) {
+	used = nodeSize(root)
+	avail, err := fs.getAvailableSize(root.InternalPath())
+	if err != nil {
+		return 0, 0, err
+	}
+	total = avail + used
+
+	if quota, ok := readQuota(root); ok && quota >= 0 && uint64(quota) < total {
+		total = uint64(quota)
+	}
+	return total, used, nil
+}
+
+// nodeSize returns the size of the tree of a folder, or the size of a file.
+func nodeSize(n *node.Node) uint64 {
+	if ts, err := n.GetTreeSize(); err == nil {
+		return ts
+	}
+	if n.Blobsize > 0 {
+		return uint64(n.Blobsize)
+	}
+	return 0
+}
+
+// checkQuota checks that the given number of bytes can be added to the
+// storage space holding the node, which does not need to exist yet.
+func checkQuota(ctx context.Context, fs *Decomposedfs, n *node.Node, size uint64) (quotaSufficient bool, err error) {
+	if size == 0 {
+		return true, nil
+	}
+	root, err := fs.spaceRoot(ctx, n)
+	if err != nil {
+		switch err.(type) {
+		case errtypes.NotFound:
+			// no quota for this storage (eg. no user context)
+			return true, nil
+		default:
+			return false, err
+		}
+	}
+	total, used, err := fs.spaceUsage(root)
+	if err != nil {
+		return false, err
+	}
+	if total != 0 && (used > total || size > total-used) {
+		return false, errtypes.InsufficientStorage("quota exceeded")
+	}
+	return true, nil
+}
+
+// checkMoveQuota checks the quota of the storage space the node is moved to,
+// when it is not the one holding it already.
+func (fs *Decomposedfs) checkMoveQuota(ctx context.Context, oldNode, newNode *node.Node) error {
+	oldParent, err := oldNode.Parent()
+	if err != nil {
+		return err
+	}
+	oldRoot, err := fs.spaceRoot(ctx, oldParent)
+	if err != nil {
+		return err
+	}
+	newParent, err := newNode.Parent()
+	if err != nil {
+		return err
+	}
+	newRoot, err := fs.spaceRoot(ctx, newParent)
+	if err != nil {
+		return err
+	}
+	if oldRoot.ID == newRoot.ID {
+		return nil
+	}
+	_, err = checkQuota(ctx, fs, newParent, nodeSize(oldNode))
+	return err
+}
+
+// checkRestoreQuota checks the quota of the storage space a trashed node is
+// restored to, either at its origin or at the given path.
+func (fs *Decomposedfs) checkRestoreQuota(ctx context.Context, key string, rn *node.Node, restorePath string) error {
+	kp := strings.SplitN(key, ":", 2)
+	if len(kp) != 2 {
+		return errtypes.BadRequest("malformed key")
+	}
+	link, err := os.Readlink(filepath.Join(fs.lu.InternalRoot(), "trash", kp[0], kp[1]))
+	if err != nil {
+		return err
+	}
+	size := trashItemSize(fs.lu.InternalPath(filepath.Base(link)))
+
+	var parent *node.Node
+	if restorePath == "" {
+		parent, err = node.ReadNode(ctx, fs.lu, rn.ParentID)
+	} else {
+		var n *node.Node
+		if n, err = fs.lu.NodeFromPath(ctx, restorePath); err == nil {
+			parent, err = n.Parent()
+		}
+	}
+	if err != nil {
+		return err
+	}
+	_, err = checkQuota(ctx, fs, parent, size)
+	return err
+}
+
+// SetSpaceQuota sets the quota of the storage space rooted at the resource,
+// 0 meaning unlimited. The caller is responsible for checking that the user
+// is allowed to change it.
+func (fs *Decomposedfs) SetSpaceQuota(ctx context.Context, ref *provider.Reference, maxBytes uint64) error {
+	n, err := fs.lu.NodeFromResource(ctx, ref)
+	if err != nil {
+		return err
+	}
+	if !n.Exists {
+		return errtypes.NotFound(filepath.Join(n.ParentID, n.Name))
+	}
+
+	v := node.QuotaUnlimited
+	if maxBytes > 0 {
+		v = strconv.FormatUint(maxBytes, 10)
+	}
+	return xattr.Set(n.InternalPath(), xattrs.QuotaAttr, []byte(v))
+}
+
+// spaceRoot returns the closest ancestor of the node with a quota set,
+// stopping at the root of a project space or at the home or root node.
+func (fs *Decomposedfs) spaceRoot(ctx context.Context, n *node.Node) (*node.Node, error) {
+	home, err := fs.lu.HomeOrRootNode(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := readQuota(n); ok || n.ID == home.ID || n.ParentID == "" || n.SpaceType() != "" {
+			return n, nil
+		}
+		if n, err = n.Parent(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func readQuota(n *node.Node) (int64, bool) {
+	v, err := xattr.Get(n.InternalPath(), xattrs.QuotaAttr)
+	if err != nil {
+		return 0, false
+	}
+	quota, err := strconv.ParseInt(string(v), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return quota, true
+}
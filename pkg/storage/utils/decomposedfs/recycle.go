@@ -22,6 +22,7 @@ import (
 	"context"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -29,6 +30,7 @@ import (
 	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
 	"github.com/cs3org/reva/pkg/appctx"
 	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/storage"
 	"github.com/cs3org/reva/pkg/storage/utils/decomposedfs/node"
 	"github.com/cs3org/reva/pkg/storage/utils/decomposedfs/xattrs"
 	"github.com/cs3org/reva/pkg/user"
@@ -45,7 +47,17 @@ import (
 // contain a directory with symlinks to trash files for every userid/"root"
 
 // ListRecycle returns the list of available recycle items
-func (fs *Decomposedfs) ListRecycle(ctx context.Context) (items []*provider.RecycleItem, err error) {
+func (fs *Decomposedfs) ListRecycle(ctx context.Context) ([]*provider.RecycleItem, error) {
+	return fs.listRecycle(ctx, &storage.RecycleFilter{})
+}
+
+// ListRecycleFiltered returns the list of recycle items matching the filter.
+// The deletion time is checked before reading the metadata of the items.
+func (fs *Decomposedfs) ListRecycleFiltered(ctx context.Context, filter *storage.RecycleFilter) ([]*provider.RecycleItem, error) {
+	return fs.listRecycle(ctx, filter)
+}
+
+func (fs *Decomposedfs) listRecycle(ctx context.Context, filter *storage.RecycleFilter) (items []*provider.RecycleItem, err error) {
 	log := appctx.GetLogger(ctx)
 
 	trashRoot := fs.getRecycleRoot(ctx)
@@ -93,6 +105,16 @@ func (fs *Decomposedfs) ListRecycle(ctx context.Context) (items []*provider.Recy
 			continue
 		}
 
+		deletionTime, timeErr := time.Parse(time.RFC3339Nano, parts[1])
+		if timeErr != nil {
+			log.Error().Err(timeErr).Str("trashRoot", trashRoot).Str("name", names[i]).Str("link", trashnode).Interface("parts", parts).Msg("could parse time format, ignoring")
+			if !filter.From.IsZero() || !filter.To.IsZero() {
+				continue
+			}
+		} else if !filter.MatchTime(deletionTime) {
+			continue
+		}
+
 		nodePath := fs.lu.InternalPath(filepath.Base(trashnode))
 		md, err := os.Stat(nodePath)
 		if err != nil {
@@ -105,13 +127,11 @@ func (fs *Decomposedfs) ListRecycle(ctx context.Context) (items []*provider.Recy
 			Size: uint64(md.Size()),
 			Key:  filepath.Base(trashRoot) + ":" + parts[0], // glue using :, a / is interpreted as a path and only the node id will reach the other methods
 		}
-		if deletionTime, err := time.Parse(time.RFC3339Nano, parts[1]); err == nil {
+		if timeErr == nil {
 			item.DeletionTime = &types.Timestamp{
 				Seconds: uint64(deletionTime.Unix()),
 				// TODO nanos
 			}
-		} else {
-			log.Error().Err(err).Str("trashRoot", trashRoot).Str("name", names[i]).Str("link", trashnode).Interface("parts", parts).Msg("could parse time format, ignoring")
 		}
 
 		// lookup origin path in extended attributes
@@ -122,6 +142,9 @@ func (fs *Decomposedfs) ListRecycle(ctx context.Context) (items []*provider.Recy
 			log.Error().Err(err).Str("trashRoot", trashRoot).Str("name", names[i]).Str("link", trashnode).Msg("could not read origin path, skipping")
 			continue
 		}
+		if !filter.MatchPath(item.Path) {
+			continue
+		}
 		// TODO filter results by permission ... on the original parent? or the trashed node?
 		// if it were on the original parent it would be possible to see files that were trashed before the current user got access
 		// so -> check the trash node itself
@@ -163,6 +186,10 @@ func (fs *Decomposedfs) RestoreRecycleItem(ctx context.Context, key, restorePath
 		return errtypes.PermissionDenied(key)
 	}
 
+	if err := fs.checkRestoreQuota(ctx, key, rn, restorePath); err != nil {
+		return err
+	}
+
 	// Run the restore func
 	return restoreFunc()
 }
@@ -202,6 +229,91 @@ func (fs *Decomposedfs) EmptyRecycle(ctx context.Context) error {
 	return os.RemoveAll(filepath.Join(fs.o.Root, "trash", u.Id.OpaqueId))
 }
 
+// ListRecycleBins returns the ids of the recycle bins, one per user or project space
+func (fs *Decomposedfs) ListRecycleBins(ctx context.Context) ([]string, error) {
+	// TODO use layout, see Tree.Delete() for problem
+	f, err := os.Open(filepath.Join(fs.o.Root, "trash"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, errors.Wrap(err, "decomposedfs: error listing recycle bins")
+	}
+	defer f.Close()
+
+	return f.Readdirnames(0)
+}
+
+// PurgeRecycleBin purges the items of the recycle bin deleted before the given time.
+// It is meant for system jobs and does not check the permissions of the current user.
+func (fs *Decomposedfs) PurgeRecycleBin(ctx context.Context, id string, before time.Time) (int, uint64, error) {
+	log := appctx.GetLogger(ctx)
+
+	// TODO use layout, see Tree.Delete() for problem
+	trashRoot := filepath.Join(fs.o.Root, "trash", id)
+	f, err := os.Open(trashRoot)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, 0, nil
+		}
+		return 0, 0, errors.Wrap(err, "decomposedfs: error listing "+trashRoot)
+	}
+	names, err := f.Readdirnames(0)
+	f.Close()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var items int
+	var reclaimed uint64
+	for _, name := range names {
+		trashnode, err := os.Readlink(filepath.Join(trashRoot, name))
+		if err != nil {
+			log.Error().Err(err).Str("trashRoot", trashRoot).Str("name", name).Msg("error reading trash link, skipping")
+			continue
+		}
+		parts := strings.SplitN(filepath.Base(trashnode), ".T.", 2)
+		if len(parts) != 2 {
+			log.Error().Str("trashRoot", trashRoot).Str("name", name).Str("trashnode", trashnode).Msg("malformed trash link, skipping")
+			continue
+		}
+		deletionTime, err := time.Parse(time.RFC3339Nano, parts[1])
+		if err != nil {
+			log.Error().Err(err).Str("trashRoot", trashRoot).Str("name", name).Str("trashnode", trashnode).Msg("could not parse deletion time, skipping")
+			continue
+		}
+		if !deletionTime.Before(before) {
+			continue
+		}
+
+		size := trashItemSize(fs.lu.InternalPath(filepath.Base(trashnode)))
+
+		_, purgeFunc, err := fs.tp.PurgeRecycleItemFunc(ctx, id+":"+name)
+		if err != nil {
+			log.Error().Err(err).Str("trashRoot", trashRoot).Str("name", name).Msg("could not read trash item, skipping")
+			continue
+		}
+		if err := purgeFunc(); err != nil {
+			return items, reclaimed, err
+		}
+		items++
+		reclaimed += size
+	}
+	return items, reclaimed, nil
+}
+
+// trashItemSize returns the tree size of a trashed container or the blob size of a trashed file
+func trashItemSize(nodePath string) uint64 {
+	for _, attr := range []string{xattrs.TreesizeAttr, xattrs.BlobsizeAttr} {
+		if b, err := xattr.Get(nodePath, attr); err == nil {
+			if size, err := strconv.ParseUint(string(b), 10, 64); err == nil {
+				return size
+			}
+		}
+	}
+	return 0
+}
+
 func getResourceType(isDir bool) provider.ResourceType {
 	if isDir {
 		return provider.ResourceType_RESOURCE_TYPE_CONTAINER
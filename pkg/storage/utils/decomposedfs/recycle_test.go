@@ -0,0 +1,125 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package decomposedfs_test
+
+import (
+	"time"
+
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/storage"
+	helpers "github.com/cs3org/reva/pkg/storage/utils/decomposedfs/testhelpers"
+	"github.com/stretchr/testify/mock"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Recycle", func() {
+	var (
+		env *helpers.TestEnv
+		rp  storage.RecyclePurger
+	)
+
+	JustBeforeEach(func() {
+		var err error
+		env, err = helpers.NewTestEnv()
+		Expect(err).ToNot(HaveOccurred())
+		env.Permissions.On("HasPermission", mock.Anything, mock.Anything, mock.Anything).Return(true, nil)
+		env.Blobstore.On("Delete", "file1-blobid").Return(nil)
+
+		var ok bool
+		rp, ok = env.Fs.(storage.RecyclePurger)
+		Expect(ok).To(BeTrue())
+
+		err = env.Fs.Delete(env.Ctx, &provider.Reference{
+			Spec: &provider.Reference_Path{Path: "/dir1/file1"},
+		})
+		Expect(err).ToNot(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		if env != nil {
+			env.Cleanup()
+		}
+	})
+
+	Describe("ListRecycleFiltered", func() {
+		var rf storage.RecycleFilterer
+
+		JustBeforeEach(func() {
+			var ok bool
+			rf, ok = env.Fs.(storage.RecycleFilterer)
+			Expect(ok).To(BeTrue())
+		})
+
+		It("filters the items by original path", func() {
+			items, err := rf.ListRecycleFiltered(env.Ctx, &storage.RecycleFilter{PathPrefix: "/dir1"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(items)).To(Equal(1))
+			Expect(items[0].Path).To(Equal("/dir1/file1"))
+
+			items, err = rf.ListRecycleFiltered(env.Ctx, &storage.RecycleFilter{PathPrefix: "/dir"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(items)).To(Equal(0))
+		})
+
+		It("filters the items by deletion time", func() {
+			items, err := rf.ListRecycleFiltered(env.Ctx, &storage.RecycleFilter{From: time.Now().Add(-time.Hour)})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(items)).To(Equal(1))
+
+			items, err = rf.ListRecycleFiltered(env.Ctx, &storage.RecycleFilter{To: time.Now().Add(-time.Hour)})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(items)).To(Equal(0))
+		})
+	})
+
+	Describe("PurgeRecycleBin", func() {
+		It("keeps the items deleted after the given time", func() {
+			bins, err := rp.ListRecycleBins(env.Ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(bins)).To(Equal(1))
+
+			items, bytes, err := rp.PurgeRecycleBin(env.Ctx, bins[0], time.Now().Add(-time.Hour))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(items).To(Equal(0))
+			Expect(bytes).To(Equal(uint64(0)))
+
+			recycled, err := env.Fs.ListRecycle(env.Ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(recycled)).To(Equal(1))
+		})
+
+		It("purges the items deleted before the given time", func() {
+			bins, err := rp.ListRecycleBins(env.Ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(bins)).To(Equal(1))
+
+			items, bytes, err := rp.PurgeRecycleBin(env.Ctx, bins[0], time.Now().Add(time.Hour))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(items).To(Equal(1))
+			Expect(bytes).To(Equal(uint64(1234)))
+
+			recycled, err := env.Fs.ListRecycle(env.Ctx)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(recycled)).To(Equal(0))
+			env.Blobstore.AssertCalled(GinkgoT(), "Delete", "file1-blobid")
+		})
+	})
+})
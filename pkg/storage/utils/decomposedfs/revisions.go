@@ -23,6 +23,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -30,7 +31,11 @@ import (
 	"github.com/cs3org/reva/pkg/appctx"
 	"github.com/cs3org/reva/pkg/errtypes"
 	"github.com/cs3org/reva/pkg/storage/utils/decomposedfs/node"
+	"github.com/cs3org/reva/pkg/storage/utils/decomposedfs/tree"
+	"github.com/cs3org/reva/pkg/storage/utils/decomposedfs/xattrs"
+	"github.com/google/uuid"
 	"github.com/pkg/errors"
+	"github.com/pkg/xattr"
 )
 
 // Revision entries are stored inside the node folder and start with the same uuid as the current version.
@@ -123,12 +128,19 @@ func (fs *Decomposedfs) DownloadRevision(ctx context.Context, ref *provider.Refe
 	}
 
 	contentPath := fs.lu.InternalPath(revisionKey)
-
-	r, err := os.Open(contentPath)
-	if err != nil {
+	if _, err := os.Stat(contentPath); err != nil {
 		if os.IsNotExist(err) {
 			return nil, errtypes.NotFound(contentPath)
 		}
+		return nil, errors.Wrap(err, "Decomposedfs: error reading revision "+revisionKey)
+	}
+	blobID, err := xattr.Get(contentPath, xattrs.BlobIDAttr)
+	if err != nil {
+		return nil, errors.Wrap(err, "Decomposedfs: error reading blobid of revision "+revisionKey)
+	}
+
+	r, err := fs.readContent(contentPath, string(blobID))
+	if err != nil {
 		return nil, errors.Wrap(err, "Decomposedfs: error opening revision "+revisionKey)
 	}
 	return r, nil
@@ -197,9 +209,260 @@ func (fs *Decomposedfs) RestoreRevision(ctx context.Context, ref *provider.Refer
 			return
 		}
 
-		return fs.copyMD(revisionPath, nodePath)
+		if err = fs.copyMD(revisionPath, nodePath); err != nil {
+			return
+		}
+		// the restored node shares the blob with the revision
+		if blobID, err := xattr.Get(revisionPath, xattrs.BlobIDAttr); err == nil && tree.IsDedupBlob(string(blobID)) {
+			return fs.tp.RefBlob(string(blobID))
+		}
+		return nil
 	}
 
 	log.Error().Err(err).Interface("ref", ref).Str("originalnode", kp[0]).Str("revisionKey", revisionKey).Msg("original node does not exist")
 	return
 }
+
+// RestoreRevisionTo restores the specified revision of the resource as a new file at the target,
+// leaving the current version of the resource untouched
+func (fs *Decomposedfs) RestoreRevisionTo(ctx context.Context, ref *provider.Reference, revisionKey string, target *provider.Reference) (err error) {
+	log := appctx.GetLogger(ctx)
+
+	// verify revision key format
+	kp := strings.SplitN(revisionKey, ".REV.", 2)
+	if len(kp) != 2 {
+		log.Error().Str("revisionKey", revisionKey).Msg("malformed revisionKey")
+		return errtypes.NotFound(revisionKey)
+	}
+
+	// check if the node is available and has not been deleted
+	n, err := node.ReadNode(ctx, fs.lu, kp[0])
+	if err != nil {
+		return err
+	}
+	if !n.Exists {
+		return errtypes.NotFound(filepath.Join(n.ParentID, n.Name))
+	}
+
+	ok, err := fs.p.HasPermission(ctx, n, func(rp *provider.ResourcePermissions) bool {
+		return rp.ListFileVersions && rp.RestoreFileVersion && rp.InitiateFileDownload
+	})
+	switch {
+	case err != nil:
+		return errtypes.InternalError(err.Error())
+	case !ok:
+		return errtypes.PermissionDenied(filepath.Join(n.ParentID, n.Name))
+	}
+
+	revisionPath := fs.lu.InternalPath(revisionKey)
+	fi, err := os.Stat(revisionPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return errtypes.NotFound(revisionKey)
+		}
+		return errors.Wrap(err, "Decomposedfs: error reading revision "+revisionKey)
+	}
+	blobID, err := xattr.Get(revisionPath, xattrs.BlobIDAttr)
+	if err != nil {
+		return errors.Wrap(err, "Decomposedfs: error reading blobid of revision "+revisionKey)
+	}
+	blobSize, err := node.ReadBlobSizeAttr(revisionPath)
+	if err != nil {
+		return errors.Wrap(err, "Decomposedfs: error reading blobsize of revision "+revisionKey)
+	}
+
+	// the target must be a new file in an existing folder
+	tn, err := fs.lu.NodeFromResource(ctx, target)
+	if err != nil {
+		return err
+	}
+	if tn.Exists {
+		return errtypes.AlreadyExists(filepath.Join(tn.ParentID, tn.Name))
+	}
+	pn, err := tn.Parent()
+	if err != nil {
+		return errors.Wrap(err, "Decomposedfs: error getting parent "+tn.ParentID)
+	}
+	ok, err = fs.p.HasPermission(ctx, pn, func(rp *provider.ResourcePermissions) bool {
+		return rp.InitiateFileUpload
+	})
+	switch {
+	case err != nil:
+		return errtypes.InternalError(err.Error())
+	case !ok:
+		return errtypes.PermissionDenied(filepath.Join(tn.ParentID, tn.Name))
+	}
+	if _, err = checkQuota(ctx, fs, tn, uint64(blobSize)); err != nil {
+		return err
+	}
+
+	// copy the blob, so that the new file does not depend on the revision,
+	// or take another reference to it if it is deduplicated
+	tn.ID = uuid.New().String()
+	tn.Blobsize = blobSize
+	if tree.IsDedupBlob(string(blobID)) {
+		tn.BlobID = string(blobID)
+		if err = fs.tp.RefBlob(tn.BlobID); err != nil {
+			return err
+		}
+	} else if len(blobID) > 0 {
+		tn.BlobID = uuid.New().String()
+		var r io.ReadCloser
+		if r, err = fs.tp.ReadBlob(string(blobID)); err != nil {
+			return errors.Wrap(err, "Decomposedfs: error reading blob of revision "+revisionKey)
+		}
+		defer r.Close()
+		if err = fs.tp.WriteBlob(tn.BlobID, r); err != nil {
+			return errors.Wrap(err, "Decomposedfs: error writing blob")
+		}
+	}
+
+	nodePath := tn.InternalPath()
+	f, err := os.OpenFile(nodePath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, defaultFilePerm)
+	if err != nil {
+		return errors.Wrap(err, "Decomposedfs: error creating node")
+	}
+	if len(blobID) == 0 {
+		// the content of the revision is inlined, copy it to the new node
+		err = copyFileContent(f, revisionPath)
+	}
+	f.Close()
+	if err != nil {
+		return errors.Wrap(err, "Decomposedfs: error copying content of revision "+revisionKey)
+	}
+
+	// the checksums of the revision still apply to the content, which is
+	// encrypted with the same keys
+	if attrs, err := xattr.List(revisionPath); err == nil {
+		for i := range attrs {
+			if !strings.HasPrefix(attrs[i], xattrs.ChecksumPrefix) && attrs[i] != xattrs.BlobEncryptionAttr {
+				continue
+			}
+			if v, err := xattr.Get(revisionPath, attrs[i]); err == nil {
+				if err := xattr.Set(nodePath, attrs[i], v); err != nil {
+					log.Error().Err(err).Str("attr", attrs[i]).Msg("could not copy attribute of revision")
+				}
+			}
+		}
+	}
+
+	// the owner of the parent becomes the owner, like for uploads
+	owner, err := pn.Owner()
+	if err != nil {
+		return err
+	}
+	if err = tn.WriteMetadata(owner); err != nil {
+		return errors.Wrap(err, "Decomposedfs: could not write metadata")
+	}
+	if err = os.Chtimes(nodePath, fi.ModTime(), fi.ModTime()); err != nil {
+		return errors.Wrap(err, "Decomposedfs: could not set mtime")
+	}
+
+	if err = os.Symlink("../"+tn.ID, filepath.Join(fs.lu.InternalPath(tn.ParentID), tn.Name)); err != nil {
+		return errors.Wrap(err, "Decomposedfs: could not symlink child entry")
+	}
+
+	tn.Exists = true
+	return fs.tp.Propagate(ctx, tn)
+}
+
+// WalkRevisions calls fn with the revisions of every node having some.
+// It is meant for system jobs and does not check the permissions of the current user.
+func (fs *Decomposedfs) WalkRevisions(ctx context.Context, fn func(revisions []*provider.FileVersion) error) error {
+	log := appctx.GetLogger(ctx)
+
+	nodesPath := filepath.Join(fs.o.Root, "nodes")
+	f, err := os.Open(nodesPath)
+	if err != nil {
+		return errors.Wrap(err, "Decomposedfs: error listing "+nodesPath)
+	}
+	names, err := f.Readdirnames(0)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	// revisions of the same node are next to each other once sorted
+	sort.Strings(names)
+
+	var nodeID string
+	var revisions []*provider.FileVersion
+	for _, name := range names {
+		kp := strings.SplitN(name, ".REV.", 2)
+		if len(kp) != 2 {
+			continue
+		}
+		if kp[0] != nodeID && len(revisions) > 0 {
+			if err := fn(revisions); err != nil {
+				return err
+			}
+			revisions = nil
+		}
+		nodeID = kp[0]
+
+		revisionPath := filepath.Join(nodesPath, name)
+		fi, err := os.Stat(revisionPath)
+		if err != nil {
+			log.Error().Err(err).Str("revision", name).Msg("could not stat revision, skipping")
+			continue
+		}
+		blobSize, err := node.ReadBlobSizeAttr(revisionPath)
+		if err != nil {
+			log.Error().Err(err).Str("revision", name).Msg("could not read revision blobsize, skipping")
+			continue
+		}
+		revisions = append(revisions, &provider.FileVersion{
+			Key:   name,
+			Mtime: uint64(fi.ModTime().Unix()),
+			Size:  uint64(blobSize),
+		})
+	}
+	if len(revisions) > 0 {
+		return fn(revisions)
+	}
+	return nil
+}
+
+// DeleteRevision deletes the specified revision and its blob.
+// It is meant for system jobs and does not check the permissions of the current user.
+func (fs *Decomposedfs) DeleteRevision(ctx context.Context, revisionKey string) error {
+	kp := strings.SplitN(revisionKey, ".REV.", 2)
+	if len(kp) != 2 {
+		appctx.GetLogger(ctx).Error().Str("revisionKey", revisionKey).Msg("malformed revisionKey")
+		return errtypes.NotFound(revisionKey)
+	}
+
+	revisionPath := fs.lu.InternalPath(revisionKey)
+	if _, err := os.Stat(revisionPath); err != nil {
+		if os.IsNotExist(err) {
+			return errtypes.NotFound(revisionKey)
+		}
+		return errors.Wrap(err, "Decomposedfs: error reading revision "+revisionKey)
+	}
+	blobID, err := xattr.Get(revisionPath, xattrs.BlobIDAttr)
+	if err != nil {
+		return errors.Wrap(err, "Decomposedfs: error reading blobid of revision "+revisionKey)
+	}
+	if err := os.Remove(revisionPath); err != nil {
+		return errors.Wrap(err, "Decomposedfs: error deleting revision "+revisionKey)
+	}
+
+	// a restored revision shares its blob with the current version of the node,
+	// unless it is deduplicated and thus reference counted
+	if current, err := xattr.Get(fs.lu.InternalPath(kp[0]), xattrs.BlobIDAttr); err == nil && string(current) == string(blobID) && !tree.IsDedupBlob(string(blobID)) {
+		return nil
+	}
+	if len(blobID) > 0 {
+		return fs.tp.DeleteBlob(string(blobID))
+	}
+	return nil
+}
+
+func copyFileContent(dst io.Writer, src string) error {
+	r, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	_, err = io.Copy(dst, r)
+	return err
+}
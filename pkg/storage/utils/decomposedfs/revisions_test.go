@@ -0,0 +1,99 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package decomposedfs_test
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/storage"
+	"github.com/cs3org/reva/pkg/storage/utils/decomposedfs/node"
+	helpers "github.com/cs3org/reva/pkg/storage/utils/decomposedfs/testhelpers"
+	"github.com/stretchr/testify/mock"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Revisions", func() {
+	var (
+		env         *helpers.TestEnv
+		rr          storage.RevisionRestorer
+		revisionKey string
+
+		pathRef = func(p string) *provider.Reference {
+			return &provider.Reference{Spec: &provider.Reference_Path{Path: p}}
+		}
+	)
+
+	JustBeforeEach(func() {
+		var err error
+		env, err = helpers.NewTestEnv()
+		Expect(err).ToNot(HaveOccurred())
+		env.Permissions.On("HasPermission", mock.Anything, mock.Anything, mock.Anything).Return(true, nil)
+		env.Permissions.On("AssemblePermissions", mock.Anything, mock.Anything).Return(&provider.ResourcePermissions{GetQuota: true}, nil)
+
+		var ok bool
+		rr, ok = env.Fs.(storage.RevisionRestorer)
+		Expect(ok).To(BeTrue())
+
+		file, err := env.Lookup.NodeFromPath(env.Ctx, "/dir1/file1")
+		Expect(err).ToNot(HaveOccurred())
+		revisionKey = file.ID + ".REV." + time.Now().UTC().Format(time.RFC3339Nano)
+		rev := node.New(revisionKey, file.ParentID, file.Name, 10, "rev-blobid", nil, env.Lookup)
+		f, err := os.Create(rev.InternalPath())
+		Expect(err).ToNot(HaveOccurred())
+		f.Close()
+		Expect(rev.WriteMetadata(env.Owner.Id)).To(Succeed())
+	})
+
+	AfterEach(func() {
+		if env != nil {
+			env.Cleanup()
+		}
+	})
+
+	Describe("RestoreRevisionTo", func() {
+		It("restores the revision as a new file", func() {
+			env.Blobstore.On("Download", "rev-blobid").Return(ioutil.NopCloser(strings.NewReader("0123456789")), nil)
+			env.Blobstore.On("Upload", mock.Anything, mock.Anything).Return(nil)
+
+			err := rr.RestoreRevisionTo(env.Ctx, pathRef("/dir1/file1"), revisionKey, pathRef("/dir1/restored"))
+			Expect(err).ToNot(HaveOccurred())
+
+			restored, err := env.Lookup.NodeFromPath(env.Ctx, "/dir1/restored")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(restored.Exists).To(BeTrue())
+			Expect(restored.Blobsize).To(Equal(int64(10)))
+			Expect(restored.BlobID).ToNot(Equal("rev-blobid"))
+
+			current, err := env.Lookup.NodeFromPath(env.Ctx, "/dir1/file1")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(current.BlobID).To(Equal("file1-blobid"))
+		})
+
+		It("does not overwrite existing resources", func() {
+			err := rr.RestoreRevisionTo(env.Ctx, pathRef("/dir1/file1"), revisionKey, pathRef("/dir1/subdir1"))
+			Expect(err).To(MatchError(ContainSubstring("already exists")))
+		})
+	})
+})
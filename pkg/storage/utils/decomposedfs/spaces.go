@@ -0,0 +1,441 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package decomposedfs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/storage/utils/ace"
+	"github.com/cs3org/reva/pkg/storage/utils/decomposedfs/node"
+	"github.com/cs3org/reva/pkg/storage/utils/decomposedfs/xattrs"
+	"github.com/cs3org/reva/pkg/user"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/pkg/xattr"
+)
+
+// Project spaces are nodes below the root node that are not linked from it.
+// Instead they are indexed in spaces/project/<spaceid> and listed in the
+// spaces folder of every user that has been granted access to them. A project
+// space owns its nodes, so access is only given by the grants at its root:
+// managers are the users allowed to add and remove grants.
+const spaceTypeProject = "project"
+
+// CreateStorageSpace creates a project space and makes the current user its manager
+func (fs *Decomposedfs) CreateStorageSpace(ctx context.Context, req *provider.CreateStorageSpaceRequest) (*provider.StorageSpace, error) {
+	if req.Type != spaceTypeProject {
+		return nil, errtypes.NotSupported("Decomposedfs: unsupported space type " + req.Type)
+	}
+	if err := fs.checkSpaceName(ctx, "", req.Name); err != nil {
+		return nil, err
+	}
+	u := user.ContextMustGetUser(ctx)
+
+	id := uuid.New().String()
+	n := node.New(id, "root", req.Name, 0, "", nil, fs.lu)
+	nodePath := n.InternalPath()
+	if err := os.MkdirAll(nodePath, 0700); err != nil {
+		return nil, errors.Wrap(err, "Decomposedfs: error creating space root")
+	}
+	if err := n.WriteMetadata(&userpb.UserId{OpaqueId: id}); err != nil {
+		return nil, err
+	}
+	if err := xattr.Set(nodePath, xattrs.SpaceTypeAttr, []byte(spaceTypeProject)); err != nil {
+		return nil, errors.Wrap(err, "Decomposedfs: could not set space type attribute")
+	}
+	if fs.o.TreeTimeAccounting || fs.o.TreeSizeAccounting {
+		// mark the space root as the end of propagation
+		if err := xattr.Set(nodePath, xattrs.PropagationAttr, []byte("1")); err != nil {
+			return nil, errors.Wrap(err, "Decomposedfs: could not mark space root as propagation root")
+		}
+	}
+	if e := req.GetOpaque().GetMap()["dedup"]; e != nil && string(e.Value) == "true" {
+		if err := n.SetDedup(true); err != nil {
+			return nil, errors.Wrap(err, "Decomposedfs: could not enable deduplication")
+		}
+	}
+	if e := req.GetOpaque().GetMap()["encryption"]; e != nil && string(e.Value) == "enable" {
+		var passphrase string
+		if p := req.GetOpaque().GetMap()["passphrase"]; p != nil {
+			passphrase = string(p.Value)
+		}
+		if err := fs.initSpaceKeyring(n, passphrase); err != nil {
+			return nil, err
+		}
+	}
+	if e := req.GetOpaque().GetMap()["tiering_days"]; e != nil && fs.tiers != nil {
+		days, err := strconv.Atoi(string(e.Value))
+		if err != nil {
+			return nil, errtypes.BadRequest("invalid tiering days: " + string(e.Value))
+		}
+		if err := n.SetTieringDays(days); err != nil {
+			return nil, errors.Wrap(err, "Decomposedfs: could not set tiering policy")
+		}
+	}
+	if req.Quota != nil && req.Quota.QuotaMaxBytes > 0 {
+		if err := fs.SetSpaceQuota(ctx, &provider.Reference{Spec: &provider.Reference_Id{Id: &provider.ResourceId{OpaqueId: id}}}, req.Quota.QuotaMaxBytes); err != nil {
+			return nil, err
+		}
+	}
+
+	e := ace.FromGrant(&provider.Grant{
+		Grantee: &provider.Grantee{
+			Type: provider.GranteeType_GRANTEE_TYPE_USER,
+			Id:   &provider.Grantee_UserId{UserId: u.Id},
+		},
+		Permissions: node.OwnerPermissions,
+	})
+	principal, value := e.Marshal()
+	if err := xattr.Set(nodePath, xattrs.GrantPrefix+principal, value); err != nil {
+		return nil, errors.Wrap(err, "Decomposedfs: could not grant access to the space manager")
+	}
+
+	indexPath := filepath.Join(fs.o.Root, "spaces", spaceTypeProject)
+	if err := os.MkdirAll(indexPath, 0700); err != nil {
+		return nil, err
+	}
+	if err := os.Symlink("../../nodes/"+id, filepath.Join(indexPath, id)); err != nil {
+		return nil, errors.Wrap(err, "Decomposedfs: could not index space")
+	}
+
+	return fs.storageSpaceFromNode(n), nil
+}
+
+// ListStorageSpaces lists the project spaces the current user has access to.
+// Disabled spaces are only listed for their managers.
+func (fs *Decomposedfs) ListStorageSpaces(ctx context.Context, filters []*provider.ListStorageSpacesRequest_Filter) ([]*provider.StorageSpace, error) {
+	var spaceID string
+	var owner *userpb.UserId
+	for _, f := range filters {
+		switch f.Type {
+		case provider.ListStorageSpacesRequest_Filter_TYPE_ID:
+			spaceID = f.GetId().GetOpaqueId()
+		case provider.ListStorageSpacesRequest_Filter_TYPE_OWNER:
+			owner = f.GetOwner()
+		case provider.ListStorageSpacesRequest_Filter_TYPE_SPACE_TYPE:
+			if f.GetSpaceType() != spaceTypeProject {
+				return []*provider.StorageSpace{}, nil
+			}
+		}
+	}
+
+	roots, err := fs.listSpaceRoots(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	u := user.ContextMustGetUser(ctx)
+	spaces := []*provider.StorageSpace{}
+	for _, n := range roots {
+		if spaceID != "" && n.ID != spaceID {
+			continue
+		}
+		rp, err := n.ReadUserPermissions(ctx, u)
+		if err != nil || !rp.Stat || (n.DisabledSince() != nil && !rp.RemoveGrant) {
+			continue
+		}
+		if owner != nil && !isSpaceManager(ctx, n, owner) {
+			continue
+		}
+		spaces = append(spaces, fs.storageSpaceFromNode(n))
+	}
+	return spaces, nil
+}
+
+// RenameStorageSpace changes the name of a project space, which is the name it
+// is listed with in the spaces folder
+func (fs *Decomposedfs) RenameStorageSpace(ctx context.Context, id, name string) error {
+	// keeping the name is not a change, which lets the space admins update the quota
+	if n, err := node.ReadNode(ctx, fs.lu, id); err == nil && n.Exists && n.Name == name {
+		return nil
+	}
+	n, err := fs.managedSpaceRoot(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := fs.checkSpaceName(ctx, id, name); err != nil {
+		return err
+	}
+	return xattr.Set(n.InternalPath(), xattrs.NameAttr, []byte(name))
+}
+
+// DisableStorageSpace makes a project space and its content inaccessible until
+// it is restored or purged
+func (fs *Decomposedfs) DisableStorageSpace(ctx context.Context, id string) error {
+	n, err := fs.managedSpaceRoot(ctx, id)
+	if err != nil {
+		return err
+	}
+	if n.DisabledSince() != nil {
+		return nil
+	}
+	return xattr.Set(n.InternalPath(), xattrs.SpaceDisabledAttr, []byte(time.Now().UTC().Format(time.RFC3339Nano)))
+}
+
+// RestoreStorageSpace makes a disabled project space accessible again
+func (fs *Decomposedfs) RestoreStorageSpace(ctx context.Context, id string) error {
+	n, err := fs.managedSpaceRoot(ctx, id)
+	if err != nil {
+		return err
+	}
+	if n.DisabledSince() == nil {
+		return nil
+	}
+	if err := fs.checkSpaceName(ctx, id, n.Name); err != nil {
+		return err
+	}
+	return xattr.Remove(n.InternalPath(), xattrs.SpaceDisabledAttr)
+}
+
+// PurgeStorageSpace irrevocably deletes a disabled project space and its content
+func (fs *Decomposedfs) PurgeStorageSpace(ctx context.Context, id string) error {
+	n, err := fs.managedSpaceRoot(ctx, id)
+	if err != nil {
+		return err
+	}
+	if n.DisabledSince() == nil {
+		return errtypes.BadRequest("Decomposedfs: only disabled spaces can be purged")
+	}
+	if err := fs.purgeNode(ctx, n); err != nil {
+		return err
+	}
+	return os.Remove(filepath.Join(fs.o.Root, "spaces", spaceTypeProject, id))
+}
+
+// listSpaceRoots returns the root nodes of all project spaces
+func (fs *Decomposedfs) listSpaceRoots(ctx context.Context) ([]*node.Node, error) {
+	links, err := filepath.Glob(filepath.Join(fs.o.Root, "spaces", spaceTypeProject, "*"))
+	if err != nil {
+		return nil, err
+	}
+	roots := make([]*node.Node, 0, len(links))
+	for _, link := range links {
+		n, err := node.ReadNode(ctx, fs.lu, filepath.Base(link))
+		if err != nil || !n.Exists {
+			appctx.GetLogger(ctx).Error().Err(err).Str("link", link).Msg("could not read space root")
+			continue
+		}
+		roots = append(roots, n)
+	}
+	return roots, nil
+}
+
+// managedSpaceRoot returns the root node of the project space if the current
+// user is one of its managers. Managers keep their permissions on disabled spaces.
+func (fs *Decomposedfs) managedSpaceRoot(ctx context.Context, id string) (*node.Node, error) {
+	n, err := node.ReadNode(ctx, fs.lu, id)
+	if err != nil {
+		return nil, err
+	}
+	if !n.Exists || n.SpaceType() != spaceTypeProject {
+		return nil, errtypes.NotFound(id)
+	}
+	rp, err := n.ReadUserPermissions(ctx, user.ContextMustGetUser(ctx))
+	switch {
+	case err != nil:
+		return nil, errtypes.InternalError(err.Error())
+	case !rp.AddGrant || !rp.RemoveGrant:
+		return nil, errtypes.PermissionDenied(id)
+	}
+	return n, nil
+}
+
+// checkSpaceName checks that the name can be used for a project space. Names
+// have to be unique as they identify the spaces in the spaces folder.
+func (fs *Decomposedfs) checkSpaceName(ctx context.Context, id, name string) error {
+	if name == "" || name == "." || name == ".." || strings.Contains(name, "/") {
+		return errtypes.BadRequest("Decomposedfs: invalid space name " + name)
+	}
+	roots, err := fs.listSpaceRoots(ctx)
+	if err != nil {
+		return err
+	}
+	for _, n := range roots {
+		if n.ID != id && n.Name == name {
+			return errtypes.AlreadyExists(filepath.Join(fs.o.SpacesFolder, name))
+		}
+	}
+	return nil
+}
+
+// purgeNode deletes the node, its children, revisions and blobs
+func (fs *Decomposedfs) purgeNode(ctx context.Context, n *node.Node) error {
+	nodePath := n.InternalPath()
+	fi, err := os.Lstat(nodePath)
+	if err != nil {
+		return err
+	}
+	if fi.IsDir() {
+		children, err := fs.tp.ListFolder(ctx, n)
+		if err != nil {
+			return err
+		}
+		for _, c := range children {
+			if err := fs.purgeNode(ctx, c); err != nil {
+				return err
+			}
+		}
+	} else if n.BlobID != "" {
+		if err := fs.tp.DeleteBlob(n.BlobID); err != nil {
+			appctx.GetLogger(ctx).Error().Err(err).Interface("node", n).Msg("could not delete blob")
+		}
+	}
+
+	revisions, _ := filepath.Glob(nodePath + ".REV.*")
+	for _, rev := range revisions {
+		if blobID, err := xattr.Get(rev, xattrs.BlobIDAttr); err == nil {
+			if err := fs.tp.DeleteBlob(string(blobID)); err != nil {
+				appctx.GetLogger(ctx).Error().Err(err).Str("revision", rev).Msg("could not delete revision blob")
+			}
+		}
+		if err := os.Remove(rev); err != nil {
+			return err
+		}
+	}
+	return os.RemoveAll(nodePath)
+}
+
+// spacesFolderInfo describes the virtual folder listing the project spaces
+func (fs *Decomposedfs) spacesFolderInfo(ctx context.Context) (*provider.ResourceInfo, error) {
+	infos, err := fs.listSpacesFolder(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	ri := &provider.ResourceInfo{
+		Id:       &provider.ResourceId{OpaqueId: "spaces"},
+		Path:     fs.o.SpacesFolder,
+		Type:     provider.ResourceType_RESOURCE_TYPE_CONTAINER,
+		MimeType: "httpd/unix-directory",
+		PermissionSet: &provider.ResourcePermissions{
+			ListContainer: true,
+			Stat:          true,
+		},
+		Mtime: &types.Timestamp{},
+	}
+	for _, info := range infos {
+		ri.Size += info.Size
+		if info.Mtime != nil && info.Mtime.Seconds > ri.Mtime.Seconds {
+			ri.Mtime = info.Mtime
+		}
+	}
+	mtime := time.Unix(int64(ri.Mtime.Seconds), int64(ri.Mtime.Nanos))
+	if ri.Etag, err = node.CalculateEtag(ri.Id.OpaqueId, mtime); err != nil {
+		return nil, err
+	}
+	return ri, nil
+}
+
+// listSpacesFolder lists the roots of the enabled project spaces the current user has access to
+func (fs *Decomposedfs) listSpacesFolder(ctx context.Context, mdKeys []string) ([]*provider.ResourceInfo, error) {
+	roots, err := fs.listSpaceRoots(ctx)
+	if err != nil {
+		return nil, err
+	}
+	infos := []*provider.ResourceInfo{}
+	for _, n := range roots {
+		if n.DisabledSince() != nil {
+			continue
+		}
+		rp, err := fs.p.AssemblePermissions(ctx, n)
+		if err != nil || !rp.Stat {
+			continue
+		}
+		if ri, err := n.AsResourceInfo(ctx, rp, mdKeys); err == nil {
+			infos = append(infos, ri)
+		}
+	}
+	return infos, nil
+}
+
+func (fs *Decomposedfs) storageSpaceFromNode(n *node.Node) *provider.StorageSpace {
+	space := &provider.StorageSpace{
+		Id:        &provider.StorageSpaceId{OpaqueId: n.ID},
+		Root:      &provider.ResourceId{OpaqueId: n.ID},
+		Name:      n.Name,
+		SpaceType: n.SpaceType(),
+	}
+	if quota, ok := readQuota(n); ok && quota > 0 {
+		space.Quota = &provider.Quota{QuotaMaxBytes: uint64(quota)}
+	}
+	if tmtime, err := n.GetTMTime(); err == nil {
+		space.Mtime = &types.Timestamp{
+			Seconds: uint64(tmtime.Unix()),
+			Nanos:   uint32(tmtime.Nanosecond()),
+		}
+	}
+	opaque := map[string]*types.OpaqueEntry{}
+	if t := n.DisabledSince(); t != nil {
+		opaque["disabled"] = &types.OpaqueEntry{
+			Decoder: "plain",
+			Value:   []byte(t.Format(time.RFC3339)),
+		}
+	}
+	if n.Dedup() {
+		opaque["dedup"] = &types.OpaqueEntry{
+			Decoder: "plain",
+			Value:   []byte("true"),
+		}
+	}
+	if days, ok := n.TieringDays(); ok {
+		opaque["tiering_days"] = &types.OpaqueEntry{
+			Decoder: "plain",
+			Value:   []byte(strconv.Itoa(days)),
+		}
+	}
+	if keyring, err := readKeyring(n); err == nil {
+		mode := "master"
+		if keyring.Passphrase {
+			mode = "passphrase"
+			if _, err := fs.spaceKeys(n.ID); err != nil {
+				opaque["locked"] = &types.OpaqueEntry{
+					Decoder: "plain",
+					Value:   []byte("true"),
+				}
+			}
+		}
+		opaque["encryption"] = &types.OpaqueEntry{
+			Decoder: "plain",
+			Value:   []byte(mode),
+		}
+	}
+	if len(opaque) > 0 {
+		space.Opaque = &types.Opaque{Map: opaque}
+	}
+	return space
+}
+
+// isSpaceManager returns whether the user has been granted the permission to
+// manage the grants at the space root
+func isSpaceManager(ctx context.Context, n *node.Node, u *userpb.UserId) bool {
+	g, err := n.ReadGrant(ctx, xattrs.GrantPrefix+xattrs.UserAcePrefix+u.OpaqueId)
+	if err != nil {
+		return false
+	}
+	return g.Permissions.AddGrant && g.Permissions.RemoveGrant
+}
@@ -0,0 +1,143 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package decomposedfs_test
+
+import (
+	"path"
+
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/storage"
+	helpers "github.com/cs3org/reva/pkg/storage/utils/decomposedfs/testhelpers"
+	"github.com/stretchr/testify/mock"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Spaces", func() {
+	var (
+		env *helpers.TestEnv
+		sm  storage.SpacesManager
+	)
+
+	JustBeforeEach(func() {
+		var err error
+		env, err = helpers.NewTestEnv()
+		Expect(err).ToNot(HaveOccurred())
+		env.Permissions.On("HasPermission", mock.Anything, mock.Anything, mock.Anything).Return(true, nil)
+
+		var ok bool
+		sm, ok = env.Fs.(storage.SpacesManager)
+		Expect(ok).To(BeTrue())
+	})
+
+	AfterEach(func() {
+		if env != nil {
+			env.Cleanup()
+		}
+	})
+
+	Describe("CreateStorageSpace", func() {
+		It("creates a project space managed by the current user", func() {
+			space, err := sm.CreateStorageSpace(env.Ctx, &provider.CreateStorageSpaceRequest{Type: "project", Name: "proj"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(space.Name).To(Equal("proj"))
+			Expect(space.SpaceType).To(Equal("project"))
+
+			spaces, err := sm.ListStorageSpaces(env.Ctx, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(spaces)).To(Equal(1))
+			Expect(spaces[0].Root.OpaqueId).To(Equal(space.Root.OpaqueId))
+		})
+
+		It("rejects duplicate names", func() {
+			_, err := sm.CreateStorageSpace(env.Ctx, &provider.CreateStorageSpaceRequest{Type: "project", Name: "proj"})
+			Expect(err).ToNot(HaveOccurred())
+			_, err = sm.CreateStorageSpace(env.Ctx, &provider.CreateStorageSpaceRequest{Type: "project", Name: "proj"})
+			Expect(err).To(MatchError(ContainSubstring("already exists")))
+		})
+
+		It("rejects unsupported types", func() {
+			_, err := sm.CreateStorageSpace(env.Ctx, &provider.CreateStorageSpaceRequest{Type: "personal", Name: "proj"})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("with a project space", func() {
+		var space *provider.StorageSpace
+
+		JustBeforeEach(func() {
+			var err error
+			space, err = sm.CreateStorageSpace(env.Ctx, &provider.CreateStorageSpaceRequest{Type: "project", Name: "proj"})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("is listed in the spaces folder", func() {
+			err := env.Fs.CreateDir(env.Ctx, "/Spaces/proj/docs")
+			Expect(err).ToNot(HaveOccurred())
+
+			n, err := env.Lookup.NodeFromPath(env.Ctx, "/Spaces/proj/docs")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(n.Exists).To(BeTrue())
+
+			p, err := env.Lookup.Path(env.Ctx, n)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(p).To(Equal("/Spaces/proj/docs"))
+		})
+
+		It("is renamed", func() {
+			err := sm.RenameStorageSpace(env.Ctx, space.Root.OpaqueId, "renamed")
+			Expect(err).ToNot(HaveOccurred())
+
+			n, err := env.Lookup.NodeFromPath(env.Ctx, "/Spaces/renamed")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(n.ID).To(Equal(space.Root.OpaqueId))
+		})
+
+		It("can not be deleted like a folder", func() {
+			err := env.Fs.Delete(env.Ctx, &provider.Reference{Spec: &provider.Reference_Path{Path: "/Spaces/proj"}})
+			Expect(err).To(MatchError(ContainSubstring("permission denied")))
+		})
+
+		It("is disabled, restored and purged", func() {
+			err := sm.PurgeStorageSpace(env.Ctx, space.Root.OpaqueId)
+			Expect(err).To(HaveOccurred())
+
+			err = sm.DisableStorageSpace(env.Ctx, space.Root.OpaqueId)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = env.Lookup.NodeFromPath(env.Ctx, "/Spaces/proj")
+			Expect(err).To(HaveOccurred())
+
+			err = sm.RestoreStorageSpace(env.Ctx, space.Root.OpaqueId)
+			Expect(err).ToNot(HaveOccurred())
+			_, err = env.Lookup.NodeFromPath(env.Ctx, "/Spaces/proj")
+			Expect(err).ToNot(HaveOccurred())
+
+			err = sm.DisableStorageSpace(env.Ctx, space.Root.OpaqueId)
+			Expect(err).ToNot(HaveOccurred())
+			err = sm.PurgeStorageSpace(env.Ctx, space.Root.OpaqueId)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(path.Join(env.Root, "nodes", space.Root.OpaqueId)).ToNot(BeADirectory())
+
+			spaces, err := sm.ListStorageSpaces(env.Ctx, nil)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(len(spaces)).To(Equal(0))
+		})
+	})
+})
@@ -0,0 +1,204 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package decomposedfs
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/storage/utils/decomposedfs/node"
+	"github.com/cs3org/reva/pkg/storage/utils/decomposedfs/tree"
+	"github.com/cs3org/reva/pkg/storage/utils/decomposedfs/xattrs"
+	"github.com/pkg/errors"
+	"github.com/pkg/xattr"
+	"github.com/rs/zerolog/log"
+)
+
+// When a cold blobstore is configured, TierBlobs moves the blobs which were
+// not accessed for longer than the tiering policy of their space to it, and
+// flags their nodes. Reading the content of a flagged node moves its blob back
+// to the hot blobstore. The deduplicated blobs, which are shared by several
+// nodes, stay in the hot blobstore.
+
+const tierCold = "cold"
+
+// accessGranularity is the precision of the recorded access times, which
+// saves a write on every read
+const accessGranularity = time.Hour
+
+// tierMu serializes the recalls of the blobs
+var tierMu sync.Mutex
+
+// SetSpaceTiering sets the number of days after which the content of a
+// project space which was not accessed is moved to the cold blobstore, 0
+// meaning never and a negative number falling back to the default policy.
+func (fs *Decomposedfs) SetSpaceTiering(ctx context.Context, id string, days int) error {
+	if fs.tiers == nil {
+		return errtypes.NotSupported("Decomposedfs: no cold blobstore configured")
+	}
+	n, err := fs.managedSpaceRoot(ctx, id)
+	if err != nil {
+		return err
+	}
+	return n.SetTieringDays(days)
+}
+
+// TierBlobs moves the blobs which were not accessed for longer than the
+// tiering policy of their space to the cold blobstore, and returns their
+// number. The revisions and the trashed nodes are moved as well.
+func (fs *Decomposedfs) TierBlobs(ctx context.Context) (int, error) {
+	if fs.tiers == nil {
+		return 0, errtypes.NotSupported("Decomposedfs: no cold blobstore configured")
+	}
+	log := appctx.GetLogger(ctx)
+
+	nodesPath := filepath.Join(fs.o.Root, "nodes")
+	d, err := os.Open(nodesPath)
+	if err != nil {
+		return 0, err
+	}
+	defer d.Close()
+
+	policies := map[string]int{}
+	now := time.Now()
+	moved := 0
+	for {
+		names, err := d.Readdirnames(1000)
+		for _, name := range names {
+			nodePath := filepath.Join(nodesPath, name)
+			ok, err := fs.tierBlob(ctx, nodePath, now, policies)
+			if err != nil {
+				log.Error().Err(err).Str("node", nodePath).Msg("could not move blob to the cold blobstore")
+				continue
+			}
+			if ok {
+				moved++
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return moved, errors.Wrap(err, "Decomposedfs: error listing nodes")
+		}
+	}
+	return moved, nil
+}
+
+// tierBlob moves the blob of the node to the cold blobstore if it expired
+func (fs *Decomposedfs) tierBlob(ctx context.Context, nodePath string, now time.Time, policies map[string]int) (bool, error) {
+	blobID, err := xattr.Get(nodePath, xattrs.BlobIDAttr)
+	if err != nil || len(blobID) == 0 || tree.IsDedupBlob(string(blobID)) {
+		return false, nil
+	}
+	if tier, err := xattr.Get(nodePath, xattrs.BlobTierAttr); err == nil && string(tier) == tierCold {
+		return false, nil
+	}
+	// the revisions inherit the policy of their node
+	policyPath := strings.SplitN(nodePath, ".REV.", 2)[0]
+	parentID, err := xattr.Get(policyPath, xattrs.ParentidAttr)
+	if err != nil {
+		return false, nil
+	}
+	days := fs.tieringDays(ctx, string(parentID), policies)
+	if days <= 0 || lastAccess(nodePath).After(now.AddDate(0, 0, -days)) {
+		return false, nil
+	}
+
+	if err := fs.tiers.Freeze(string(blobID)); err != nil {
+		return false, err
+	}
+	// the blob is read from the cold blobstore even if the flag is missing
+	return true, xattr.Set(nodePath, xattrs.BlobTierAttr, []byte(tierCold))
+}
+
+// tieringDays returns the tiering policy of the space holding the folder,
+// caching it for all the folders on the way to the space root.
+func (fs *Decomposedfs) tieringDays(ctx context.Context, folderID string, cache map[string]int) int {
+	days := fs.o.TieringDays
+	var visited []string
+	n, err := node.ReadNode(ctx, fs.lu, folderID)
+	for err == nil && n.Exists {
+		if d, ok := cache[n.ID]; ok {
+			days = d
+			break
+		}
+		visited = append(visited, n.ID)
+		if n.SpaceType() != "" || n.ParentID == "" {
+			if d, ok := n.TieringDays(); ok {
+				days = d
+			}
+			break
+		}
+		n, err = n.Parent()
+	}
+	for _, id := range visited {
+		cache[id] = days
+	}
+	return days
+}
+
+// lastAccess returns when the content of the node was last read, or written
+// if it was never read
+func lastAccess(nodePath string) time.Time {
+	var t time.Time
+	if b, err := xattr.Get(nodePath, xattrs.BlobAccessAttr); err == nil {
+		t, _ = time.Parse(time.RFC3339Nano, string(b))
+	}
+	if fi, err := os.Stat(nodePath); err == nil && fi.ModTime().After(t) {
+		t = fi.ModTime()
+	}
+	return t
+}
+
+// accessBlob records the access to the content of the node, and moves its
+// blob back to the hot blobstore if it was moved to the cold one
+func (fs *Decomposedfs) accessBlob(nodePath, blobID string) {
+	now := time.Now()
+	if lastAccess(nodePath).Before(now.Add(-accessGranularity)) {
+		if err := xattr.Set(nodePath, xattrs.BlobAccessAttr, []byte(now.UTC().Format(time.RFC3339Nano))); err != nil {
+			log.Error().Err(err).Str("node", nodePath).Msg("could not record blob access")
+		}
+	}
+
+	if tier, err := xattr.Get(nodePath, xattrs.BlobTierAttr); err != nil || string(tier) != tierCold {
+		return
+	}
+	tierMu.Lock()
+	defer tierMu.Unlock()
+	// the blob might have been recalled while waiting for the lock
+	if tier, err := xattr.Get(nodePath, xattrs.BlobTierAttr); err != nil || string(tier) != tierCold {
+		return
+	}
+	if err := fs.tiers.Thaw(blobID); err != nil {
+		// the blob is read from the cold blobstore
+		log.Error().Err(err).Str("node", nodePath).Str("blob", blobID).Msg("could not recall blob")
+		return
+	}
+	if err := xattr.Remove(nodePath, xattrs.BlobTierAttr); err != nil {
+		log.Error().Err(err).Str("node", nodePath).Msg("could not unflag recalled blob")
+	}
+}
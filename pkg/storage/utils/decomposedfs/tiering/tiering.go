@@ -0,0 +1,127 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package tiering provides a blobstore keeping the blobs in a hot blobstore
+// and moving the ones which are rarely accessed to a cheaper, cold one.
+package tiering
+
+import (
+	"fmt"
+	"io"
+
+	ocisblobstore "github.com/cs3org/reva/pkg/storage/fs/ocis/blobstore"
+	s3blobstore "github.com/cs3org/reva/pkg/storage/fs/s3ng/blobstore"
+	"github.com/cs3org/reva/pkg/storage/utils/decomposedfs/tree"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+// Blobstore stores the new blobs in the hot blobstore. The blobs are only
+// moved between the blobstores by Freeze and Thaw, and are read from the
+// cold blobstore when they are not found in the hot one.
+type Blobstore struct {
+	hot  tree.Blobstore
+	cold tree.Blobstore
+}
+
+// New returns a blobstore moving the blobs between hot and cold.
+func New(hot, cold tree.Blobstore) *Blobstore {
+	return &Blobstore{hot: hot, cold: cold}
+}
+
+type coldConfig struct {
+	// Driver is either local or s3.
+	Driver      string `mapstructure:"driver"`
+	Root        string `mapstructure:"root"`
+	S3Endpoint  string `mapstructure:"s3.endpoint"`
+	S3Region    string `mapstructure:"s3.region"`
+	S3Bucket    string `mapstructure:"s3.bucket"`
+	S3AccessKey string `mapstructure:"s3.access_key"`
+	S3SecretKey string `mapstructure:"s3.secret_key"`
+}
+
+// NewColdBlobstore returns the cold blobstore described by the
+// configuration, either a local directory or an s3 bucket.
+func NewColdBlobstore(m map[string]interface{}) (tree.Blobstore, error) {
+	c := &coldConfig{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, errors.Wrap(err, "error decoding cold blobstore conf")
+	}
+	switch c.Driver {
+	case "local":
+		if c.Root == "" {
+			return nil, fmt.Errorf("cold blobstore: root is required")
+		}
+		return ocisblobstore.New(c.Root)
+	case "s3":
+		return s3blobstore.New(c.S3Endpoint, c.S3Region, c.S3Bucket, c.S3AccessKey, c.S3SecretKey)
+	default:
+		return nil, fmt.Errorf("cold blobstore: unknown driver '%s'", c.Driver)
+	}
+}
+
+// Upload stores a new blob in the hot blobstore
+func (bs *Blobstore) Upload(key string, reader io.Reader) error {
+	return bs.hot.Upload(key, reader)
+}
+
+// Download reads the blob from the hot blobstore, or from the cold one if it
+// was moved there
+func (bs *Blobstore) Download(key string) (io.ReadCloser, error) {
+	r, err := bs.hot.Download(key)
+	if err == nil {
+		return r, nil
+	}
+	if r, cerr := bs.cold.Download(key); cerr == nil {
+		return r, nil
+	}
+	return nil, err
+}
+
+// Delete deletes the blob from both blobstores. It only fails if the blob
+// could not be deleted from either of them.
+func (bs *Blobstore) Delete(key string) error {
+	err := bs.hot.Delete(key)
+	if cerr := bs.cold.Delete(key); cerr == nil {
+		return nil
+	}
+	return err
+}
+
+// Freeze moves the blob to the cold blobstore
+func (bs *Blobstore) Freeze(key string) error {
+	return move(bs.hot, bs.cold, key)
+}
+
+// Thaw moves the blob back to the hot blobstore
+func (bs *Blobstore) Thaw(key string) error {
+	return move(bs.cold, bs.hot, key)
+}
+
+// move copies the blob and only deletes the source once the copy succeeded
+func move(from, to tree.Blobstore, key string) error {
+	r, err := from.Download(key)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	if err := to.Upload(key, r); err != nil {
+		return errors.Wrapf(err, "could not copy blob '%s'", key)
+	}
+	return from.Delete(key)
+}
@@ -0,0 +1,217 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package tree
+
+import (
+	"context"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/storage/utils/decomposedfs/xattrs"
+	"github.com/pkg/errors"
+	"github.com/pkg/xattr"
+)
+
+// DedupPrefix is the prefix of the ids of the deduplicated blobs, which are
+// named after the sha256 of their content and shared by all the nodes with
+// the same content.
+const DedupPrefix = "sha256-"
+
+// blobRefsGracePeriod is the time during which the collection leaves alone
+// the blobs whose reference count changed, as the nodes referencing them
+// might not be written yet.
+const blobRefsGracePeriod = time.Hour
+
+// blobRefsMu serializes the changes of the reference counts
+var blobRefsMu sync.Mutex
+
+// IsDedupBlob returns whether the blob with the given id is deduplicated
+func IsDedupBlob(key string) bool {
+	return strings.HasPrefix(key, DedupPrefix)
+}
+
+// WriteDedupBlob stores the content with the given sha256, unless a blob with
+// the same content is already stored, and returns the id of the blob. Every
+// call takes a reference to the blob, released by DeleteBlob.
+func (t *Tree) WriteDedupBlob(sum []byte, reader io.Reader) (string, error) {
+	key := DedupPrefix + hex.EncodeToString(sum)
+
+	blobRefsMu.Lock()
+	refs, err := t.readBlobRefs(key)
+	if err == nil && refs > 0 {
+		err = t.writeBlobRefs(key, refs+1)
+		blobRefsMu.Unlock()
+		return key, err
+	}
+	blobRefsMu.Unlock()
+	if err != nil {
+		return "", err
+	}
+
+	// the upload is done without holding the lock, concurrent uploads of the
+	// same content write the same blob
+	if err := t.blobstore.Upload(key, reader); err != nil {
+		return "", err
+	}
+	return key, t.RefBlob(key)
+}
+
+// RefBlob takes another reference to a deduplicated blob
+func (t *Tree) RefBlob(key string) error {
+	blobRefsMu.Lock()
+	defer blobRefsMu.Unlock()
+	refs, err := t.readBlobRefs(key)
+	if err != nil {
+		return err
+	}
+	return t.writeBlobRefs(key, refs+1)
+}
+
+// releaseBlob releases a reference to a deduplicated blob, and deletes the
+// blob if it was the last one
+func (t *Tree) releaseBlob(key string) error {
+	blobRefsMu.Lock()
+	defer blobRefsMu.Unlock()
+	refs, err := t.readBlobRefs(key)
+	if err != nil {
+		return err
+	}
+	if refs > 1 {
+		return t.writeBlobRefs(key, refs-1)
+	}
+	if err := t.blobstore.Delete(key); err != nil {
+		return err
+	}
+	return t.writeBlobRefs(key, 0)
+}
+
+// CollectBlobs counts the references to the deduplicated blobs held by the
+// nodes, their revisions and the trashed nodes, corrects the reference counts
+// which drifted, e.g. after a failed upload, and deletes the blobs which are
+// not referenced anymore. It returns the number of deleted blobs.
+func (t *Tree) CollectBlobs(ctx context.Context) (int, error) {
+	log := appctx.GetLogger(ctx)
+
+	nodesPath := filepath.Join(t.root, "nodes")
+	d, err := os.Open(nodesPath)
+	if err != nil {
+		return 0, err
+	}
+	defer d.Close()
+	counted := map[string]int{}
+	for {
+		names, err := d.Readdirnames(1000)
+		for _, name := range names {
+			b, err := xattr.Get(filepath.Join(nodesPath, name), xattrs.BlobIDAttr)
+			if err == nil && IsDedupBlob(string(b)) {
+				counted[string(b)]++
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return 0, errors.Wrap(err, "Decomposedfs: error listing nodes")
+		}
+	}
+
+	refsPath := filepath.Join(t.root, "blobrefs")
+	infos, err := ioutil.ReadDir(refsPath)
+	if err != nil {
+		return 0, errors.Wrap(err, "Decomposedfs: error listing blob references")
+	}
+
+	blobRefsMu.Lock()
+	defer blobRefsMu.Unlock()
+	collected := 0
+	recent := time.Now().Add(-blobRefsGracePeriod)
+	for _, fi := range infos {
+		key := fi.Name()
+		if fi.ModTime().After(recent) {
+			continue
+		}
+		refs, err := t.readBlobRefs(key)
+		if err != nil {
+			log.Error().Err(err).Str("blob", key).Msg("could not read blob references")
+			continue
+		}
+		switch {
+		case counted[key] == 0:
+			if err := t.blobstore.Delete(key); err != nil {
+				log.Error().Err(err).Str("blob", key).Msg("could not delete unreferenced blob")
+				continue
+			}
+			if err := t.writeBlobRefs(key, 0); err != nil {
+				return collected, err
+			}
+			collected++
+		case counted[key] != refs:
+			log.Warn().Str("blob", key).Int("refs", refs).Int("counted", counted[key]).Msg("correcting blob references")
+			if err := t.writeBlobRefs(key, counted[key]); err != nil {
+				return collected, err
+			}
+		}
+	}
+	// restore the counts which got lost
+	for key, refs := range counted {
+		if _, err := os.Stat(t.blobRefsPath(key)); os.IsNotExist(err) {
+			log.Warn().Str("blob", key).Int("counted", refs).Msg("restoring blob references")
+			if err := t.writeBlobRefs(key, refs); err != nil {
+				return collected, err
+			}
+		}
+	}
+	return collected, nil
+}
+
+func (t *Tree) blobRefsPath(key string) string {
+	return filepath.Join(t.root, "blobrefs", key)
+}
+
+// readBlobRefs returns the number of references to the blob, 0 if it is not
+// stored
+func (t *Tree) readBlobRefs(key string) (int, error) {
+	b, err := ioutil.ReadFile(t.blobRefsPath(key))
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, errors.Wrap(err, "Decomposedfs: error reading blob references")
+	}
+	return strconv.Atoi(strings.TrimSpace(string(b)))
+}
+
+func (t *Tree) writeBlobRefs(key string, refs int) error {
+	if refs <= 0 {
+		err := os.Remove(t.blobRefsPath(key))
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	return ioutil.WriteFile(t.blobRefsPath(key), []byte(strconv.Itoa(refs)), 0600)
+}
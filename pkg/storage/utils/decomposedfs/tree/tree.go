@@ -94,6 +94,10 @@ func (t *Tree) Setup(owner string) error {
 		// better to keep uploads on a fast / volatile storage before a workflow finally moves them to the nodes dir
 		filepath.Join(t.root, "uploads"),
 		filepath.Join(t.root, "trash"),
+		// spaces contain symlinks from spaces/<type>/<spaceid> to ../../nodes/<spaceid>
+		filepath.Join(t.root, "spaces"),
+		// blobrefs contain the reference counts of the deduplicated blobs
+		filepath.Join(t.root, "blobrefs"),
 	}
 	for _, v := range dataPaths {
 		err := os.MkdirAll(v, 0700)
@@ -606,11 +610,15 @@ func (t *Tree) ReadBlob(key string) (io.ReadCloser, error) {
 	return t.blobstore.Download(key)
 }
 
-// DeleteBlob deletes a blob from the blobstore
+// DeleteBlob deletes a blob from the blobstore. Deduplicated blobs are only
+// deleted when their last reference is released.
 func (t *Tree) DeleteBlob(key string) error {
 	if key == "" {
 		return fmt.Errorf("could not delete blob, empty key was given")
 	}
+	if IsDedupBlob(key) {
+		return t.releaseBlob(key)
+	}
 
 	return t.blobstore.Delete(key)
 }
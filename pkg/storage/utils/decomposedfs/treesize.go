@@ -0,0 +1,94 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package decomposedfs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/storage/utils/decomposedfs/node"
+	"github.com/cs3org/reva/pkg/storage/utils/decomposedfs/xattrs"
+	"github.com/pkg/errors"
+	"github.com/pkg/xattr"
+)
+
+// RecalculateTreeSize recalculates the tree size of the folders of the space
+// from the blob sizes of their files, and returns the size of the space.
+func (fs *Decomposedfs) RecalculateTreeSize(ctx context.Context, id string) (uint64, error) {
+	if !fs.o.TreeSizeAccounting {
+		return 0, errtypes.NotSupported("Decomposedfs: tree size accounting is disabled")
+	}
+	n, err := node.ReadNode(ctx, fs.lu, id)
+	if err != nil {
+		return 0, err
+	}
+	if !n.Exists {
+		return 0, errtypes.NotFound(id)
+	}
+	if fi, err := os.Stat(n.InternalPath()); err != nil || !fi.IsDir() {
+		return 0, errtypes.BadRequest("Decomposedfs: " + id + " is not a folder")
+	}
+	return recalculateTreeSize(ctx, n.InternalPath())
+}
+
+func recalculateTreeSize(ctx context.Context, nodePath string) (uint64, error) {
+	log := appctx.GetLogger(ctx)
+	f, err := os.Open(nodePath)
+	if err != nil {
+		return 0, err
+	}
+	names, err := f.Readdirnames(0)
+	f.Close()
+	if err != nil {
+		return 0, err
+	}
+
+	var size uint64
+	for _, name := range names {
+		// the children are symlinks to their nodes, which are followed
+		cPath := filepath.Join(nodePath, name)
+		fi, err := os.Stat(cPath)
+		if err != nil {
+			log.Error().Err(err).Str("childpath", cPath).Msg("could not stat child entry")
+			continue
+		}
+		if fi.IsDir() {
+			csize, err := recalculateTreeSize(ctx, cPath)
+			if err != nil {
+				return 0, err
+			}
+			size += csize
+			continue
+		}
+		blobSize, err := node.ReadBlobSizeAttr(cPath)
+		if err != nil {
+			log.Error().Err(err).Str("childpath", cPath).Msg("could not read blobSize xattr")
+			continue
+		}
+		size += uint64(blobSize)
+	}
+	if err := xattr.Set(nodePath, xattrs.TreesizeAttr, []byte(strconv.FormatUint(size, 10))); err != nil {
+		return 0, errors.Wrap(err, "Decomposedfs: could not set treesize of "+nodePath)
+	}
+	return size, nil
+}
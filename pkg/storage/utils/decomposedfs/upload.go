@@ -22,6 +22,7 @@ import (
 	"context"
 	"crypto/md5"
 	"crypto/sha1"
+	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
@@ -41,9 +42,11 @@ import (
 	"github.com/cs3org/reva/pkg/logger"
 	"github.com/cs3org/reva/pkg/storage/utils/chunking"
 	"github.com/cs3org/reva/pkg/storage/utils/decomposedfs/node"
+	"github.com/cs3org/reva/pkg/storage/utils/decomposedfs/xattrs"
 	"github.com/cs3org/reva/pkg/user"
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
+	"github.com/pkg/xattr"
 	"github.com/rs/zerolog"
 	tusd "github.com/tus/tusd/pkg/handler"
 )
@@ -158,11 +161,18 @@ func (fs *Decomposedfs) InitiateUpload(ctx context.Context, ref *provider.Refere
 
 	log.Debug().Interface("info", info).Interface("node", n).Interface("metadata", metadata).Msg("Decomposedfs: resolved filename")
 
-	_, err = checkQuota(ctx, fs, uint64(info.Size))
+	_, err = checkQuota(ctx, fs, n, uint64(info.Size))
 	if err != nil {
 		return nil, err
 	}
 
+	// fail early when the content can not be encrypted
+	if spaceID := fs.encryptionSpace(ctx, n.ParentID); spaceID != "" {
+		if _, err := fs.spaceKeys(spaceID); err != nil {
+			return nil, err
+		}
+	}
+
 	upload, err := fs.NewUpload(ctx, info)
 	if err != nil {
 		return nil, err
@@ -357,6 +367,33 @@ func (fs *Decomposedfs) GetUpload(ctx context.Context, id string) (tusd.Upload,
 	}, nil
 }
 
+// PurgeExpiredUploads deletes the uploads which were not written to since
+// the given time, e.g. because the client gave up, and returns their number.
+func (fs *Decomposedfs) PurgeExpiredUploads(ctx context.Context, before time.Time) (int, error) {
+	infos, err := filepath.Glob(filepath.Join(fs.o.Root, "uploads", "*.info"))
+	if err != nil {
+		return 0, err
+	}
+
+	var purged int
+	for _, infoPath := range infos {
+		fi, err := os.Stat(infoPath)
+		if err != nil || fi.ModTime().After(before) {
+			continue
+		}
+		binPath := strings.TrimSuffix(infoPath, ".info")
+		if fi, err := os.Stat(binPath); err == nil && fi.ModTime().After(before) {
+			continue
+		}
+		upload := &fileUpload{infoPath: infoPath, binPath: binPath, fs: fs, ctx: ctx}
+		if err := upload.Terminate(ctx); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
 type fileUpload struct {
 	// info stores the current information about the upload
 	info tusd.FileInfo
@@ -432,11 +469,6 @@ func (upload *fileUpload) FinishUpload(ctx context.Context) (err error) {
 		return
 	}
 
-	_, err = checkQuota(upload.ctx, upload.fs, uint64(fi.Size()))
-	if err != nil {
-		return err
-	}
-
 	n := node.New(
 		upload.info.Storage["NodeId"],
 		upload.info.Storage["NodeParentId"],
@@ -447,6 +479,13 @@ func (upload *fileUpload) FinishUpload(ctx context.Context) (err error) {
 		upload.fs.lu,
 	)
 
+	// the uploads of deferred length are only checked here, once their
+	// size is known
+	_, err = checkQuota(upload.ctx, upload.fs, n, uint64(fi.Size()))
+	if err != nil {
+		return err
+	}
+
 	if n.ID == "" {
 		n.ID = uuid.New().String()
 	}
@@ -465,6 +504,15 @@ func (upload *fileUpload) FinishUpload(ctx context.Context) (err error) {
 	sha1h := sha1.New()
 	md5h := md5.New()
 	adler32h := adler32.New()
+	// the content of encrypted spaces is neither inlined nor deduplicated
+	encSpaceID := upload.fs.encryptionSpace(upload.ctx, n.ParentID)
+	// small files keep their content in the node, without a blob
+	inline := encSpaceID == "" && upload.fs.o.InlineThreshold > 0 && fi.Size() <= upload.fs.o.InlineThreshold
+	// the blobs of deduplicated spaces are named after their sha256
+	var sha256h hash.Hash
+	if !inline && encSpaceID == "" && upload.fs.dedupEnabled(upload.ctx, n.ParentID) {
+		sha256h = sha256.New()
+	}
 	{
 		f, err := os.Open(upload.binPath)
 		if err != nil {
@@ -475,6 +523,9 @@ func (upload *fileUpload) FinishUpload(ctx context.Context) (err error) {
 
 		r1 := io.TeeReader(f, sha1h)
 		r2 := io.TeeReader(r1, md5h)
+		if sha256h != nil {
+			r2 = io.TeeReader(r2, sha256h)
+		}
 
 		if _, err := io.Copy(adler32h, r2); err != nil {
 			sublog.Err(err).Msg("Decomposedfs: could not copy bytes for checksumming")
@@ -501,7 +552,13 @@ func (upload *fileUpload) FinishUpload(ctx context.Context) (err error) {
 			return err
 		}
 	}
-	n.BlobID = upload.info.ID // This can be changed to a content hash in the future when reference counting for the blobs was added
+	switch {
+	case inline:
+		// node.New made up a blob id
+		n.BlobID = ""
+	case sha256h == nil:
+		n.BlobID = upload.info.ID
+	}
 
 	// defer writing the checksums until the node is in place
 
@@ -519,25 +576,38 @@ func (upload *fileUpload) FinishUpload(ctx context.Context) (err error) {
 		}
 	}
 
-	// upload the data to the blobstore
-	file, err := os.Open(upload.binPath)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-	err = upload.fs.tp.WriteBlob(n.BlobID, file)
-	if err != nil {
-		return errors.Wrap(err, "failed to upload file to blostore")
-	}
+	if !inline {
+		// upload the data to the blobstore
+		var file *os.File
+		if file, err = os.Open(upload.binPath); err != nil {
+			return err
+		}
+		defer file.Close()
+		switch {
+		case encSpaceID != "":
+			var r io.Reader
+			if r, err = upload.fs.encryptBlob(encSpaceID, file); err != nil {
+				return err
+			}
+			err = upload.fs.tp.WriteBlob(n.BlobID, r)
+		case sha256h != nil:
+			n.BlobID, err = upload.fs.tp.WriteDedupBlob(sha256h.Sum(nil), file)
+		default:
+			err = upload.fs.tp.WriteBlob(n.BlobID, file)
+		}
+		if err != nil {
+			return errors.Wrap(err, "failed to upload file to blostore")
+		}
 
-	// now truncate the upload (the payload stays in the blobstore) and move it to the target path
+		// now truncate the upload (the payload stays in the blobstore) and move it to the target path
+		if err = os.Truncate(upload.binPath, 0); err != nil {
+			sublog.Err(err).
+				Msg("Decomposedfs: could not truncate")
+			return
+		}
+	}
 	// TODO put uploads on the same underlying storage as the destination dir?
 	// TODO trigger a workflow as the final rename might eg involve antivirus scanning
-	if err = os.Truncate(upload.binPath, 0); err != nil {
-		sublog.Err(err).
-			Msg("Decomposedfs: could not truncate")
-		return
-	}
 	if err = os.Rename(upload.binPath, targetPath); err != nil {
 		sublog.Err(err).
 			Msg("Decomposedfs: could not rename")
@@ -557,6 +627,11 @@ func (upload *fileUpload) FinishUpload(ctx context.Context) (err error) {
 	if err != nil {
 		return errors.Wrap(err, "Decomposedfs: could not write metadata")
 	}
+	if encSpaceID != "" {
+		if err = xattr.Set(targetPath, xattrs.BlobEncryptionAttr, []byte(encSpaceID)); err != nil {
+			return errors.Wrap(err, "Decomposedfs: could not flag encrypted blob")
+		}
+	}
 
 	// link child name to parent if it is new
 	childNameLink := filepath.Join(upload.fs.lu.InternalPath(n.ParentID), n.Name)
@@ -705,20 +780,3 @@ func (upload *fileUpload) ConcatUploads(ctx context.Context, uploads []tusd.Uplo
 
 	return
 }
-
-func checkQuota(ctx context.Context, fs *Decomposedfs, fileSize uint64) (quotaSufficient bool, err error) {
-	total, inUse, err := fs.GetQuota(ctx)
-	if err != nil {
-		switch err.(type) {
-		case errtypes.NotFound:
-			// no quota for this storage (eg. no user context)
-			return true, nil
-		default:
-			return false, err
-		}
-	}
-	if !(total == 0) && fileSize > total-inUse {
-		return false, errtypes.InsufficientStorage("quota exceeded")
-	}
-	return true, nil
-}
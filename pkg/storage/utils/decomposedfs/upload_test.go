@@ -24,9 +24,11 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 
 	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
 	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/pkg/xattr"
 	"github.com/stretchr/testify/mock"
 
 	"github.com/cs3org/reva/pkg/storage"
@@ -35,6 +37,7 @@ import (
 	"github.com/cs3org/reva/pkg/storage/utils/decomposedfs/options"
 	"github.com/cs3org/reva/pkg/storage/utils/decomposedfs/tree"
 	treemocks "github.com/cs3org/reva/pkg/storage/utils/decomposedfs/tree/mocks"
+	"github.com/cs3org/reva/pkg/storage/utils/decomposedfs/xattrs"
 	ruser "github.com/cs3org/reva/pkg/user"
 	"github.com/cs3org/reva/tests/helpers"
 
@@ -146,6 +149,49 @@ var _ = Describe("File uploads", func() {
 
 				bs.AssertCalled(GinkgoT(), "Upload", mock.Anything, mock.Anything)
 			})
+
+			Context("with an inline threshold", func() {
+				BeforeEach(func() {
+					o.InlineThreshold = 16
+				})
+
+				It("keeps the content of small files in the node", func() {
+					err := fs.Upload(ctx, ref, ioutil.NopCloser(bytes.NewReader(fileContent)))
+					Expect(err).ToNot(HaveOccurred())
+					bs.AssertNotCalled(GinkgoT(), "Upload", mock.Anything, mock.Anything)
+
+					r, err := fs.Download(ctx, ref)
+					Expect(err).ToNot(HaveOccurred())
+					defer r.Close()
+					data, err := ioutil.ReadAll(r)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(data).To(Equal(fileContent))
+				})
+			})
+
+			Context("in a space with deduplication", func() {
+				JustBeforeEach(func() {
+					err := xattr.Set(lookup.InternalPath("root"), xattrs.SpaceDedupAttr, []byte("1"))
+					Expect(err).ToNot(HaveOccurred())
+				})
+
+				It("stores identical files once", func() {
+					bs.On("Upload", mock.AnythingOfType("string"), mock.AnythingOfType("*os.File")).Return(nil)
+
+					err := fs.Upload(ctx, ref, ioutil.NopCloser(bytes.NewReader(fileContent)))
+					Expect(err).ToNot(HaveOccurred())
+					other := &provider.Reference{Spec: &provider.Reference_Path{Path: "/bar"}}
+					err = fs.Upload(ctx, other, ioutil.NopCloser(bytes.NewReader(fileContent)))
+					Expect(err).ToNot(HaveOccurred())
+
+					bs.AssertNumberOfCalls(GinkgoT(), "Upload", 1)
+					key := bs.Calls[0].Arguments.String(0)
+					Expect(key).To(HavePrefix(tree.DedupPrefix))
+					refs, err := ioutil.ReadFile(filepath.Join(o.Root, "blobrefs", key))
+					Expect(err).ToNot(HaveOccurred())
+					Expect(string(refs)).To(Equal("2"))
+				})
+			})
 		})
 	})
 })
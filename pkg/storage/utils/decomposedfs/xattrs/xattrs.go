@@ -70,6 +70,30 @@ const (
 	// the quota for the storage space / tree, regardless who accesses it
 	QuotaAttr string = OcisPrefix + "quota"
 
+	// the type of the storage space rooted at this node, eg. project
+	SpaceTypeAttr string = OcisPrefix + "space.type"
+	// the time the storage space rooted at this node was disabled
+	// stored as a readable time.RFC3339Nano
+	SpaceDisabledAttr string = OcisPrefix + "space.disabled"
+	// set to "1" when the content uploaded to the storage space rooted at
+	// this node is deduplicated
+	SpaceDedupAttr string = OcisPrefix + "space.dedup"
+	// the number of days after which the blobs of the storage space rooted at
+	// this node are moved to the cold blobstore when they are not accessed
+	SpaceTieringAttr string = OcisPrefix + "space.tiering"
+	// set to "cold" when the blob of the node was moved to the cold blobstore
+	BlobTierAttr string = OcisPrefix + "blobtier"
+	// the last time the content of the node was read
+	// stored as a readable time.RFC3339Nano
+	BlobAccessAttr string = OcisPrefix + "blobatime"
+	// the keyring of the storage space rooted at this node, stored as json
+	SpaceKeyringAttr string = OcisPrefix + "space.keyring"
+	// the id of the storage space whose keys encrypt the blob of the node
+	BlobEncryptionAttr string = OcisPrefix + "blobenc"
+
+	// the lock of the node, stored as json
+	LockAttr string = OcisPrefix + "lock"
+
 	UserAcePrefix  string = "u:"
 	GroupAcePrefix string = "g:"
 )
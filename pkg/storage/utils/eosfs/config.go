@@ -98,6 +98,10 @@ type Config struct {
 	// Requires extra metadata operations if set to true
 	VersionInvariant bool `mapstructure:"version_invariant"`
 
+	// UseUserACLs stores the grants as user ACLs, which the owners can manage
+	// themselves, instead of sys ACLs. Both kinds are listed in any case.
+	UseUserACLs bool `mapstructure:"use_user_acls"`
+
 	// UseGRPC controls whether we spawn eosclient processes or use GRPC to connect to EOS.
 	UseGRPC bool `mapstructure:"use_grpc"`
 
@@ -111,4 +115,18 @@ type Config struct {
 	// URI of the EOS MGM grpc server
 	// Default is empty
 	GrpcURI string `mapstructure:"master_grpc_uri"`
+
+	// URL of the XrdHttp interface of the EOS MGM, through which the grpc
+	// client transfers the data of the files.
+	// Default is https://eos-example.org:8443
+	MasterHTTPURL string `mapstructure:"master_http_url"`
+
+	// Certificate and key the grpc client authenticates with to the XrdHttp
+	// interface, if any.
+	HTTPClientCertFile string `mapstructure:"http_client_certfile"`
+	HTTPClientKeyFile  string `mapstructure:"http_client_keyfile"`
+
+	// Certificates of the authorities the XrdHttp interface is trusted from,
+	// in place of the ones of the system.
+	HTTPClientCAFile string `mapstructure:"http_client_cafile"`
 }
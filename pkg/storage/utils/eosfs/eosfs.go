@@ -146,17 +146,16 @@ func NewEOSFS(c *Config) (storage.FS, error) {
 	var eosClient eosclient.EOSClient
 	if c.UseGRPC {
 		eosClientOpts := &eosgrpc.Options{
-			XrdcopyBinary:       c.XrdcopyBinary,
-			URL:                 c.MasterURL,
 			GrpcURI:             c.GrpcURI,
-			CacheDirectory:      c.CacheDirectory,
+			HTTPURL:             c.MasterHTTPURL,
+			ClientCertFile:      c.HTTPClientCertFile,
+			ClientKeyFile:       c.HTTPClientKeyFile,
+			ClientCAFile:        c.HTTPClientCAFile,
 			ForceSingleUserMode: c.ForceSingleUserMode,
 			SingleUsername:      c.SingleUsername,
-			UseKeytab:           c.UseKeytab,
-			Keytab:              c.Keytab,
 			Authkey:             c.GRPCAuthkey,
-			SecProtocol:         c.SecProtocol,
 			VersionInvariant:    c.VersionInvariant,
+			UseUserACLs:         c.UseUserACLs,
 		}
 		eosClient = eosgrpc.New(eosClientOpts)
 	} else {
@@ -171,6 +170,7 @@ func NewEOSFS(c *Config) (storage.FS, error) {
 			Keytab:              c.Keytab,
 			SecProtocol:         c.SecProtocol,
 			VersionInvariant:    c.VersionInvariant,
+			UseUserACLs:         c.UseUserACLs,
 		}
 		eosClient = eosbinary.New(eosClientOpts)
 	}
@@ -520,7 +520,8 @@ func (fs *eosfs) ListGrants(ctx context.Context, ref *provider.Reference) ([]*pr
 	grantList := []*provider.Grant{}
 	for _, a := range acls {
 		var grantee *provider.Grantee
-		if a.Type == acl.TypeUser {
+		switch a.Type {
+		case acl.TypeUser:
 			// EOS Citrine ACLs are stored with uid for users.
 			// This needs to be resolved to the user opaque ID.
 			qualifier, err := fs.getUserIDGateway(ctx, a.Qualifier)
@@ -531,11 +532,14 @@ func (fs *eosfs) ListGrants(ctx context.Context, ref *provider.Reference) ([]*pr
 				Id:   &provider.Grantee_UserId{UserId: qualifier},
 				Type: grants.GetGranteeType(a.Type),
 			}
-		} else {
+		case acl.TypeGroup:
 			grantee = &provider.Grantee{
 				Id:   &provider.Grantee_GroupId{GroupId: &grouppb.GroupId{OpaqueId: a.Qualifier}},
 				Type: grants.GetGranteeType(a.Type),
 			}
+		default:
+			// unix groups, everyone and the like have no CS3 grantee
+			continue
 		}
 		grantList = append(grantList, &provider.Grant{
 			Grantee:     grantee,
@@ -1395,8 +1399,15 @@ func (fs *eosfs) permissionSet(ctx context.Context, eosFileInfo *eosclient.FileI
 		}
 	}
 
+	entries := []*acl.Entry{}
+	for _, a := range []*acl.ACLs{eosFileInfo.SysACL, eosFileInfo.UserACL} {
+		if a != nil {
+			entries = append(entries, a.Entries...)
+		}
+	}
+
 	var perm provider.ResourcePermissions
-	for _, e := range eosFileInfo.SysACL.Entries {
+	for _, e := range entries {
 		var userInGroup bool
 		for _, g := range u.Groups {
 			if e.Qualifier == g {
@@ -72,6 +72,15 @@ func initializeDB(root, dbName string) (*sql.DB, error) {
 		return nil, errors.Wrap(err, "localfs: error executing create statement")
 	}
 
+	stmt, err = db.Prepare("CREATE TABLE IF NOT EXISTS locks (resource TEXT PRIMARY KEY, lock TEXT)")
+	if err != nil {
+		return nil, errors.Wrap(err, "localfs: error preparing statement")
+	}
+	_, err = stmt.Exec()
+	if err != nil {
+		return nil, errors.Wrap(err, "localfs: error executing create statement")
+	}
+
 	return db, nil
 }
 
@@ -121,7 +130,7 @@ func (fs *localfs) addToACLDB(ctx context.Context, resource, grantee, role strin
 }
 
 func (fs *localfs) getACLs(ctx context.Context, resource string) (*sql.Rows, error) {
-	grants, err := fs.db.Query("SELECT grantee, role FROM user_interaction WHERE resource=?", resource)
+	grants, err := fs.db.Query("SELECT grantee, role FROM user_interaction WHERE resource=? AND role != ''", resource)
 	if err != nil {
 		return nil, err
 	}
@@ -244,5 +253,47 @@ func (fs *localfs) copyMD(s string, t string) (err error) {
 	if err != nil {
 		return errors.Wrap(err, "localfs: error executing delete statement")
 	}
+
+	stmt, err = fs.db.Prepare("UPDATE locks SET resource=? WHERE resource=?")
+	if err != nil {
+		return errors.Wrap(err, "localfs: error preparing statement")
+	}
+	_, err = stmt.Exec(t, s)
+	if err != nil {
+		return errors.Wrap(err, "localfs: error executing delete statement")
+	}
+	return nil
+}
+
+func (fs *localfs) getLockEntry(ctx context.Context, resource string) (string, error) {
+	var lock string
+	err := fs.db.QueryRow("SELECT lock FROM locks WHERE resource=?", resource).Scan(&lock)
+	if err != nil {
+		return "", err
+	}
+	return lock, nil
+}
+
+func (fs *localfs) addToLocksDB(ctx context.Context, resource, lock string) error {
+	stmt, err := fs.db.Prepare("INSERT INTO locks (resource, lock) VALUES (?, ?) ON CONFLICT(resource) DO UPDATE SET lock=?")
+	if err != nil {
+		return errors.Wrap(err, "localfs: error preparing statement")
+	}
+	_, err = stmt.Exec(resource, lock, lock)
+	if err != nil {
+		return errors.Wrap(err, "localfs: error executing insert statement")
+	}
+	return nil
+}
+
+func (fs *localfs) removeFromLocksDB(ctx context.Context, resource string) error {
+	stmt, err := fs.db.Prepare("DELETE FROM locks WHERE resource=?")
+	if err != nil {
+		return errors.Wrap(err, "localfs: error preparing statement")
+	}
+	_, err = stmt.Exec(resource)
+	if err != nil {
+		return errors.Wrap(err, "localfs: error executing delete statement")
+	}
 	return nil
 }
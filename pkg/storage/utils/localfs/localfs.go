@@ -1073,7 +1073,7 @@ func (fs *localfs) DownloadRevision(ctx context.Context, ref *provider.Reference
 	}
 
 	versionsDir := fs.wrapVersions(ctx, np)
-	vp := path.Join(versionsDir, revisionKey)
+	vp := path.Join(versionsDir, "v"+revisionKey)
 
 	r, err := os.Open(vp)
 	if err != nil {
@@ -1097,7 +1097,7 @@ func (fs *localfs) RestoreRevision(ctx context.Context, ref *provider.Reference,
 	}
 
 	versionsDir := fs.wrapVersions(ctx, np)
-	vp := path.Join(versionsDir, revisionKey)
+	vp := path.Join(versionsDir, "v"+revisionKey)
 	np = fs.wrap(ctx, np)
 
 	// check revision exists
@@ -1113,12 +1113,19 @@ func (fs *localfs) RestoreRevision(ctx context.Context, ref *provider.Reference,
 		return fmt.Errorf("%s is not a regular file", vp)
 	}
 
+	// move the revision aside first, archiving the current version could
+	// overwrite it when both were made in the same millisecond
+	tp := vp + ".restore"
+	if err := os.Rename(vp, tp); err != nil {
+		return errors.Wrap(err, "localfs: error renaming from "+vp+" to "+tp)
+	}
+
 	if err := fs.archiveRevision(ctx, np); err != nil {
 		return err
 	}
 
-	if err := os.Rename(vp, np); err != nil {
-		return errors.Wrap(err, "localfs: error renaming from "+vp+" to "+np)
+	if err := os.Rename(tp, np); err != nil {
+		return errors.Wrap(err, "localfs: error renaming from "+tp+" to "+np)
 	}
 
 	return fs.propagate(ctx, np)
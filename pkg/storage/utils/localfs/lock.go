@@ -0,0 +1,153 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package localfs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"os"
+	"sync"
+
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/storage"
+	"github.com/cs3org/reva/pkg/storage/utils/locks"
+	"github.com/pkg/errors"
+)
+
+// lockMu serializes the changes of the locks, which are checked before
+// being written.
+var lockMu sync.Mutex
+
+func (fs *localfs) GetLock(ctx context.Context, ref *provider.Reference) (*storage.Lock, error) {
+	np, err := fs.resolveLockable(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+	l, err := fs.readLock(ctx, np)
+	if err != nil || l == nil || l.Expired() {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (fs *localfs) SetLock(ctx context.Context, ref *provider.Reference, l *storage.Lock) error {
+	return fs.changeLock(ctx, ref, func(current *storage.Lock) (*storage.Lock, error) {
+		if err := locks.CanSet(current, l); err != nil {
+			return nil, err
+		}
+		return l, nil
+	})
+}
+
+func (fs *localfs) RefreshLock(ctx context.Context, ref *provider.Reference, l *storage.Lock) error {
+	return fs.changeLock(ctx, ref, func(current *storage.Lock) (*storage.Lock, error) {
+		if err := locks.Validate(l); err != nil {
+			return nil, err
+		}
+		if err := locks.Holds(current, l); err != nil {
+			return nil, err
+		}
+		return l, nil
+	})
+}
+
+func (fs *localfs) Unlock(ctx context.Context, ref *provider.Reference, l *storage.Lock) error {
+	return fs.changeLock(ctx, ref, func(current *storage.Lock) (*storage.Lock, error) {
+		if err := locks.Holds(current, l); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	})
+}
+
+func (fs *localfs) changeLock(ctx context.Context, ref *provider.Reference, change func(current *storage.Lock) (*storage.Lock, error)) error {
+	np, err := fs.resolveLockable(ctx, ref)
+	if err != nil {
+		return err
+	}
+
+	lockMu.Lock()
+	defer lockMu.Unlock()
+
+	current, err := fs.readLock(ctx, np)
+	if err != nil {
+		return err
+	}
+	l, err := change(current)
+	if err != nil {
+		return err
+	}
+	if l == nil {
+		return fs.removeFromLocksDB(ctx, np)
+	}
+
+	u, err := getUser(ctx)
+	if err != nil {
+		return err
+	}
+	l.User = u.Id
+	b, err := json.Marshal(l)
+	if err != nil {
+		return err
+	}
+	return fs.addToLocksDB(ctx, np, string(b))
+}
+
+func (fs *localfs) readLock(ctx context.Context, np string) (*storage.Lock, error) {
+	entry, err := fs.getLockEntry(ctx, np)
+	switch {
+	case err == sql.ErrNoRows:
+		return nil, nil
+	case err != nil:
+		return nil, errors.Wrap(err, "localfs: error reading lock")
+	}
+	l := &storage.Lock{}
+	if err := json.Unmarshal([]byte(entry), l); err != nil {
+		return nil, errors.Wrap(err, "localfs: error decoding lock")
+	}
+	return l, nil
+}
+
+// resolveLockable returns the internal path of the resource to lock.
+func (fs *localfs) resolveLockable(ctx context.Context, ref *provider.Reference) (string, error) {
+	np, err := fs.resolve(ctx, ref)
+	if err != nil {
+		return "", errors.Wrap(err, "localfs: error resolving ref")
+	}
+
+	if fs.isShareFolderRoot(ctx, np) {
+		return "", errtypes.PermissionDenied("localfs: cannot lock the virtual share folder")
+	}
+
+	if fs.isShareFolderChild(ctx, np) {
+		np = fs.wrapReferences(ctx, np)
+	} else {
+		np = fs.wrap(ctx, np)
+	}
+
+	if _, err := os.Stat(np); err != nil {
+		if os.IsNotExist(err) {
+			return "", errtypes.NotFound(fs.unwrap(ctx, np))
+		}
+		return "", errors.Wrap(err, "localfs: error stating "+np)
+	}
+	return np, nil
+}
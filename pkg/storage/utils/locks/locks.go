@@ -0,0 +1,66 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package locks holds the rules the storage drivers apply when locking,
+// refreshing and unlocking the resources.
+package locks
+
+import (
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/storage"
+)
+
+// Validate checks that the lock can be stored.
+func Validate(l *storage.Lock) error {
+	if l == nil || l.LockID == "" {
+		return errtypes.BadRequest("missing lock id")
+	}
+	switch l.Type {
+	case storage.LockTypeShared, storage.LockTypeWrite, storage.LockTypeExclusive:
+		return nil
+	}
+	return errtypes.BadRequest("invalid lock type: " + l.Type)
+}
+
+// CanSet checks that a resource whose lock is current, nil if it is not
+// locked, can be locked with l. Setting the lock a resource already has
+// replaces it.
+func CanSet(current, l *storage.Lock) error {
+	if err := Validate(l); err != nil {
+		return err
+	}
+	if current == nil || current.Expired() || current.LockID == l.LockID {
+		return nil
+	}
+	return errtypes.Locked("the resource is locked with another lock")
+}
+
+// Holds checks that current, the lock of a resource, has the lock id of l
+// and thus can be refreshed or removed with it.
+func Holds(current, l *storage.Lock) error {
+	if l == nil || l.LockID == "" {
+		return errtypes.BadRequest("missing lock id")
+	}
+	if current == nil || current.Expired() {
+		return errtypes.Locked("the resource is not locked")
+	}
+	if current.LockID != l.LockID {
+		return errtypes.Locked("the resource is locked with another lock")
+	}
+	return nil
+}
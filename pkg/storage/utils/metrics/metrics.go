@@ -0,0 +1,645 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package metrics wraps a storage driver to record the latency, the errors
+// and the bytes transferred by its operations, and to log the slow ones.
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"sync"
+	"time"
+
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/storage"
+	tusd "github.com/tus/tusd/pkg/handler"
+	"go.opencensus.io/stats"
+	"go.opencensus.io/stats/view"
+	"go.opencensus.io/tag"
+)
+
+var (
+	driverKey    = tag.MustNewKey("driver")
+	methodKey    = tag.MustNewKey("method")
+	directionKey = tag.MustNewKey("direction")
+
+	latency = stats.Float64("storage_latency", "Time spent in the storage driver operations", stats.UnitMilliseconds)
+	errs    = stats.Int64("storage_errors", "Number of storage driver operations that failed", stats.UnitDimensionless)
+	bytes   = stats.Int64("storage_transferred", "Number of bytes transferred to and from the storage driver", stats.UnitBytes)
+
+	latencyBuckets = []float64{1, 2, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000, 30000}
+
+	views = []*view.View{
+		{Name: "storage_latency_ms", Measure: latency, Aggregation: view.Distribution(latencyBuckets...), TagKeys: []tag.Key{driverKey, methodKey}},
+		{Name: "storage_errors_total", Measure: errs, Aggregation: view.Count(), TagKeys: []tag.Key{driverKey, methodKey}},
+		{Name: "storage_transferred_bytes_total", Measure: bytes, Aggregation: view.Sum(), TagKeys: []tag.Key{driverKey, directionKey}},
+	}
+
+	registerViews sync.Once
+)
+
+type fs struct {
+	driver string
+	next   storage.FS
+	opts   Options
+}
+
+// Options configures the wrapper.
+type Options struct {
+	// SlowThreshold, if set, is the duration above which the operations are
+	// logged, along with the resource they concern and the backend endpoint.
+	SlowThreshold time.Duration
+	// Endpoint is the address of the backend of the driver.
+	Endpoint string
+}
+
+type composable interface {
+	UseIn(composer *tusd.StoreComposer)
+}
+
+// composableFS is used instead of fs for the drivers supporting the tus
+// protocol, so that the wrapper can still be used by the tus data transfer.
+type composableFS struct {
+	*fs
+	composable composable
+}
+
+func (c *composableFS) UseIn(composer *tusd.StoreComposer) {
+	c.composable.UseIn(composer)
+}
+
+// New returns a storage.FS recording the metrics of the operations of the
+// given driver. Uploads handled through the tus protocol bypass the wrapper
+// and are not accounted for in the transferred bytes.
+func New(driver string, next storage.FS, opts Options) (storage.FS, error) {
+	var err error
+	registerViews.Do(func() {
+		err = view.Register(views...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	f := &fs{driver: driver, next: next, opts: opts}
+	if c, ok := next.(composable); ok {
+		return &composableFS{fs: f, composable: c}, nil
+	}
+	return f, nil
+}
+
+// observe records the latency of the call to method on ref started at t, and
+// the failure if err is not nil. The calls slower than the threshold are
+// logged.
+func (f *fs) observe(ctx context.Context, method, ref string, t time.Time, err error) {
+	d := time.Since(t)
+	if f.opts.SlowThreshold > 0 && d > f.opts.SlowThreshold {
+		appctx.GetLogger(ctx).Warn().Err(err).
+			Str("driver", f.driver).
+			Str("endpoint", f.opts.Endpoint).
+			Str("method", method).
+			Str("ref", ref).
+			Dur("duration", d).
+			Msg("slow storage operation")
+	}
+
+	ctx, _ = tag.New(ctx, tag.Upsert(driverKey, f.driver), tag.Upsert(methodKey, method))
+	stats.Record(ctx, latency.M(float64(d)/float64(time.Millisecond)))
+	if err != nil {
+		stats.Record(ctx, errs.M(1))
+	}
+}
+
+func refString(ref *provider.Reference) string {
+	if id := ref.GetId(); id != nil {
+		return id.StorageId + ":" + id.OpaqueId
+	}
+	return ref.GetPath()
+}
+
+// endpointKeys are the keys of the drivers configuration holding the address
+// of their backend, by order of precedence.
+var endpointKeys = []string{"endpoint", "master_url", "url", "endpoint_url", "hostname", "root"}
+
+// Endpoint returns the address of the backend found in the configuration of
+// a driver, if any.
+func Endpoint(m map[string]interface{}) string {
+	for _, k := range endpointKeys {
+		if v, ok := m[k].(string); ok && v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func (f *fs) transferred(ctx context.Context, direction string, n int64) {
+	ctx, _ = tag.New(ctx, tag.Upsert(driverKey, f.driver), tag.Upsert(directionKey, direction))
+	stats.Record(ctx, bytes.M(n))
+}
+
+// countingReader counts the bytes read through it, and reports them once
+// closed.
+type countingReader struct {
+	io.ReadCloser
+	n    int64
+	once sync.Once
+	done func(n int64)
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.n += int64(n)
+	return n, err
+}
+
+func (r *countingReader) Close() error {
+	r.once.Do(func() { r.done(r.n) })
+	return r.ReadCloser.Close()
+}
+
+func (f *fs) GetHome(ctx context.Context) (string, error) {
+	t := time.Now()
+	home, err := f.next.GetHome(ctx)
+	f.observe(ctx, "GetHome", "", t, err)
+	return home, err
+}
+
+func (f *fs) CreateHome(ctx context.Context) error {
+	t := time.Now()
+	err := f.next.CreateHome(ctx)
+	f.observe(ctx, "CreateHome", "", t, err)
+	return err
+}
+
+func (f *fs) CreateDir(ctx context.Context, fn string) error {
+	t := time.Now()
+	err := f.next.CreateDir(ctx, fn)
+	f.observe(ctx, "CreateDir", fn, t, err)
+	return err
+}
+
+func (f *fs) Delete(ctx context.Context, ref *provider.Reference) error {
+	t := time.Now()
+	err := f.next.Delete(ctx, ref)
+	f.observe(ctx, "Delete", refString(ref), t, err)
+	return err
+}
+
+func (f *fs) Move(ctx context.Context, oldRef, newRef *provider.Reference) error {
+	t := time.Now()
+	err := f.next.Move(ctx, oldRef, newRef)
+	f.observe(ctx, "Move", refString(oldRef), t, err)
+	return err
+}
+
+func (f *fs) GetMD(ctx context.Context, ref *provider.Reference, mdKeys []string) (*provider.ResourceInfo, error) {
+	t := time.Now()
+	ri, err := f.next.GetMD(ctx, ref, mdKeys)
+	f.observe(ctx, "GetMD", refString(ref), t, err)
+	return ri, err
+}
+
+func (f *fs) ListFolder(ctx context.Context, ref *provider.Reference, mdKeys []string) ([]*provider.ResourceInfo, error) {
+	t := time.Now()
+	ris, err := f.next.ListFolder(ctx, ref, mdKeys)
+	f.observe(ctx, "ListFolder", refString(ref), t, err)
+	return ris, err
+}
+
+func (f *fs) InitiateUpload(ctx context.Context, ref *provider.Reference, uploadLength int64, metadata map[string]string) (map[string]string, error) {
+	t := time.Now()
+	res, err := f.next.InitiateUpload(ctx, ref, uploadLength, metadata)
+	f.observe(ctx, "InitiateUpload", refString(ref), t, err)
+	return res, err
+}
+
+func (f *fs) Upload(ctx context.Context, ref *provider.Reference, r io.ReadCloser) error {
+	t := time.Now()
+	cr := &countingReader{ReadCloser: r, done: func(int64) {}}
+	err := f.next.Upload(ctx, ref, cr)
+	f.observe(ctx, "Upload", refString(ref), t, err)
+	f.transferred(ctx, "upload", cr.n)
+	return err
+}
+
+func (f *fs) Download(ctx context.Context, ref *provider.Reference) (io.ReadCloser, error) {
+	t := time.Now()
+	r, err := f.next.Download(ctx, ref)
+	f.observe(ctx, "Download", refString(ref), t, err)
+	if err != nil {
+		return nil, err
+	}
+	return &countingReader{ReadCloser: r, done: func(n int64) { f.transferred(ctx, "download", n) }}, nil
+}
+
+func (f *fs) ListRevisions(ctx context.Context, ref *provider.Reference) ([]*provider.FileVersion, error) {
+	t := time.Now()
+	revs, err := f.next.ListRevisions(ctx, ref)
+	f.observe(ctx, "ListRevisions", refString(ref), t, err)
+	return revs, err
+}
+
+func (f *fs) DownloadRevision(ctx context.Context, ref *provider.Reference, key string) (io.ReadCloser, error) {
+	t := time.Now()
+	r, err := f.next.DownloadRevision(ctx, ref, key)
+	f.observe(ctx, "DownloadRevision", refString(ref), t, err)
+	if err != nil {
+		return nil, err
+	}
+	return &countingReader{ReadCloser: r, done: func(n int64) { f.transferred(ctx, "download", n) }}, nil
+}
+
+func (f *fs) RestoreRevision(ctx context.Context, ref *provider.Reference, key string) error {
+	t := time.Now()
+	err := f.next.RestoreRevision(ctx, ref, key)
+	f.observe(ctx, "RestoreRevision", refString(ref), t, err)
+	return err
+}
+
+func (f *fs) ListRecycle(ctx context.Context) ([]*provider.RecycleItem, error) {
+	t := time.Now()
+	items, err := f.next.ListRecycle(ctx)
+	f.observe(ctx, "ListRecycle", "", t, err)
+	return items, err
+}
+
+func (f *fs) RestoreRecycleItem(ctx context.Context, key, restorePath string) error {
+	t := time.Now()
+	err := f.next.RestoreRecycleItem(ctx, key, restorePath)
+	f.observe(ctx, "RestoreRecycleItem", key, t, err)
+	return err
+}
+
+func (f *fs) PurgeRecycleItem(ctx context.Context, key string) error {
+	t := time.Now()
+	err := f.next.PurgeRecycleItem(ctx, key)
+	f.observe(ctx, "PurgeRecycleItem", key, t, err)
+	return err
+}
+
+func (f *fs) EmptyRecycle(ctx context.Context) error {
+	t := time.Now()
+	err := f.next.EmptyRecycle(ctx)
+	f.observe(ctx, "EmptyRecycle", "", t, err)
+	return err
+}
+
+func (f *fs) GetPathByID(ctx context.Context, id *provider.ResourceId) (string, error) {
+	t := time.Now()
+	p, err := f.next.GetPathByID(ctx, id)
+	f.observe(ctx, "GetPathByID", id.GetStorageId()+":"+id.GetOpaqueId(), t, err)
+	return p, err
+}
+
+func (f *fs) AddGrant(ctx context.Context, ref *provider.Reference, g *provider.Grant) error {
+	t := time.Now()
+	err := f.next.AddGrant(ctx, ref, g)
+	f.observe(ctx, "AddGrant", refString(ref), t, err)
+	return err
+}
+
+func (f *fs) RemoveGrant(ctx context.Context, ref *provider.Reference, g *provider.Grant) error {
+	t := time.Now()
+	err := f.next.RemoveGrant(ctx, ref, g)
+	f.observe(ctx, "RemoveGrant", refString(ref), t, err)
+	return err
+}
+
+func (f *fs) UpdateGrant(ctx context.Context, ref *provider.Reference, g *provider.Grant) error {
+	t := time.Now()
+	err := f.next.UpdateGrant(ctx, ref, g)
+	f.observe(ctx, "UpdateGrant", refString(ref), t, err)
+	return err
+}
+
+func (f *fs) ListGrants(ctx context.Context, ref *provider.Reference) ([]*provider.Grant, error) {
+	t := time.Now()
+	grants, err := f.next.ListGrants(ctx, ref)
+	f.observe(ctx, "ListGrants", refString(ref), t, err)
+	return grants, err
+}
+
+func (f *fs) GetQuota(ctx context.Context) (uint64, uint64, error) {
+	t := time.Now()
+	total, used, err := f.next.GetQuota(ctx)
+	f.observe(ctx, "GetQuota", "", t, err)
+	return total, used, err
+}
+
+func (f *fs) GetSpaceQuota(ctx context.Context, ref *provider.Reference) (uint64, uint64, error) {
+	qm, ok := f.next.(storage.QuotaManager)
+	if !ok {
+		return 0, 0, errtypes.NotSupported("GetSpaceQuota")
+	}
+	t := time.Now()
+	total, used, err := qm.GetSpaceQuota(ctx, ref)
+	f.observe(ctx, "GetSpaceQuota", refString(ref), t, err)
+	return total, used, err
+}
+
+func (f *fs) SetSpaceQuota(ctx context.Context, ref *provider.Reference, maxBytes uint64) error {
+	qm, ok := f.next.(storage.QuotaManager)
+	if !ok {
+		return errtypes.NotSupported("SetSpaceQuota")
+	}
+	t := time.Now()
+	err := qm.SetSpaceQuota(ctx, ref, maxBytes)
+	f.observe(ctx, "SetSpaceQuota", refString(ref), t, err)
+	return err
+}
+
+func (f *fs) CreateStorageSpace(ctx context.Context, req *provider.CreateStorageSpaceRequest) (*provider.StorageSpace, error) {
+	sm, ok := f.next.(storage.SpacesManager)
+	if !ok {
+		return nil, errtypes.NotSupported("CreateStorageSpace")
+	}
+	t := time.Now()
+	space, err := sm.CreateStorageSpace(ctx, req)
+	f.observe(ctx, "CreateStorageSpace", req.Name, t, err)
+	return space, err
+}
+
+func (f *fs) ListStorageSpaces(ctx context.Context, filters []*provider.ListStorageSpacesRequest_Filter) ([]*provider.StorageSpace, error) {
+	sm, ok := f.next.(storage.SpacesManager)
+	if !ok {
+		return nil, errtypes.NotSupported("ListStorageSpaces")
+	}
+	t := time.Now()
+	spaces, err := sm.ListStorageSpaces(ctx, filters)
+	f.observe(ctx, "ListStorageSpaces", "", t, err)
+	return spaces, err
+}
+
+func (f *fs) RenameStorageSpace(ctx context.Context, id, name string) error {
+	sm, ok := f.next.(storage.SpacesManager)
+	if !ok {
+		return errtypes.NotSupported("RenameStorageSpace")
+	}
+	t := time.Now()
+	err := sm.RenameStorageSpace(ctx, id, name)
+	f.observe(ctx, "RenameStorageSpace", id, t, err)
+	return err
+}
+
+func (f *fs) DisableStorageSpace(ctx context.Context, id string) error {
+	sm, ok := f.next.(storage.SpacesManager)
+	if !ok {
+		return errtypes.NotSupported("DisableStorageSpace")
+	}
+	t := time.Now()
+	err := sm.DisableStorageSpace(ctx, id)
+	f.observe(ctx, "DisableStorageSpace", id, t, err)
+	return err
+}
+
+func (f *fs) RestoreStorageSpace(ctx context.Context, id string) error {
+	sm, ok := f.next.(storage.SpacesManager)
+	if !ok {
+		return errtypes.NotSupported("RestoreStorageSpace")
+	}
+	t := time.Now()
+	err := sm.RestoreStorageSpace(ctx, id)
+	f.observe(ctx, "RestoreStorageSpace", id, t, err)
+	return err
+}
+
+func (f *fs) PurgeStorageSpace(ctx context.Context, id string) error {
+	sm, ok := f.next.(storage.SpacesManager)
+	if !ok {
+		return errtypes.NotSupported("PurgeStorageSpace")
+	}
+	t := time.Now()
+	err := sm.PurgeStorageSpace(ctx, id)
+	f.observe(ctx, "PurgeStorageSpace", id, t, err)
+	return err
+}
+
+func (f *fs) ListRecycleFiltered(ctx context.Context, filter *storage.RecycleFilter) ([]*provider.RecycleItem, error) {
+	rf, ok := f.next.(storage.RecycleFilterer)
+	if !ok {
+		return nil, errtypes.NotSupported("ListRecycleFiltered")
+	}
+	t := time.Now()
+	items, err := rf.ListRecycleFiltered(ctx, filter)
+	f.observe(ctx, "ListRecycleFiltered", filter.PathPrefix, t, err)
+	return items, err
+}
+
+func (f *fs) ListRecycleBins(ctx context.Context) ([]string, error) {
+	rp, ok := f.next.(storage.RecyclePurger)
+	if !ok {
+		return nil, errtypes.NotSupported("ListRecycleBins")
+	}
+	t := time.Now()
+	bins, err := rp.ListRecycleBins(ctx)
+	f.observe(ctx, "ListRecycleBins", "", t, err)
+	return bins, err
+}
+
+func (f *fs) PurgeRecycleBin(ctx context.Context, id string, before time.Time) (int, uint64, error) {
+	rp, ok := f.next.(storage.RecyclePurger)
+	if !ok {
+		return 0, 0, errtypes.NotSupported("PurgeRecycleBin")
+	}
+	t := time.Now()
+	items, bytes, err := rp.PurgeRecycleBin(ctx, id, before)
+	f.observe(ctx, "PurgeRecycleBin", id, t, err)
+	return items, bytes, err
+}
+
+func (f *fs) RestoreRevisionTo(ctx context.Context, ref *provider.Reference, key string, target *provider.Reference) error {
+	rr, ok := f.next.(storage.RevisionRestorer)
+	if !ok {
+		return errtypes.NotSupported("RestoreRevisionTo")
+	}
+	t := time.Now()
+	err := rr.RestoreRevisionTo(ctx, ref, key, target)
+	f.observe(ctx, "RestoreRevisionTo", refString(ref), t, err)
+	return err
+}
+
+func (f *fs) WalkRevisions(ctx context.Context, fn func(revisions []*provider.FileVersion) error) error {
+	rp, ok := f.next.(storage.RevisionPruner)
+	if !ok {
+		return errtypes.NotSupported("WalkRevisions")
+	}
+	t := time.Now()
+	err := rp.WalkRevisions(ctx, fn)
+	f.observe(ctx, "WalkRevisions", "", t, err)
+	return err
+}
+
+func (f *fs) DeleteRevision(ctx context.Context, key string) error {
+	rp, ok := f.next.(storage.RevisionPruner)
+	if !ok {
+		return errtypes.NotSupported("DeleteRevision")
+	}
+	t := time.Now()
+	err := rp.DeleteRevision(ctx, key)
+	f.observe(ctx, "DeleteRevision", key, t, err)
+	return err
+}
+
+func (f *fs) CreateReference(ctx context.Context, path string, targetURI *url.URL) error {
+	t := time.Now()
+	err := f.next.CreateReference(ctx, path, targetURI)
+	f.observe(ctx, "CreateReference", path, t, err)
+	return err
+}
+
+func (f *fs) Shutdown(ctx context.Context) error {
+	return f.next.Shutdown(ctx)
+}
+
+func (f *fs) SetArbitraryMetadata(ctx context.Context, ref *provider.Reference, md *provider.ArbitraryMetadata) error {
+	t := time.Now()
+	err := f.next.SetArbitraryMetadata(ctx, ref, md)
+	f.observe(ctx, "SetArbitraryMetadata", refString(ref), t, err)
+	return err
+}
+
+func (f *fs) UnsetArbitraryMetadata(ctx context.Context, ref *provider.Reference, keys []string) error {
+	t := time.Now()
+	err := f.next.UnsetArbitraryMetadata(ctx, ref, keys)
+	f.observe(ctx, "UnsetArbitraryMetadata", refString(ref), t, err)
+	return err
+}
+
+func (f *fs) SetSpaceDedup(ctx context.Context, id string, enabled bool) error {
+	bd, ok := f.next.(storage.BlobDeduplicator)
+	if !ok {
+		return errtypes.NotSupported("SetSpaceDedup")
+	}
+	t := time.Now()
+	err := bd.SetSpaceDedup(ctx, id, enabled)
+	f.observe(ctx, "SetSpaceDedup", id, t, err)
+	return err
+}
+
+func (f *fs) CollectBlobs(ctx context.Context) (int, error) {
+	bd, ok := f.next.(storage.BlobDeduplicator)
+	if !ok {
+		return 0, errtypes.NotSupported("CollectBlobs")
+	}
+	t := time.Now()
+	collected, err := bd.CollectBlobs(ctx)
+	f.observe(ctx, "CollectBlobs", "", t, err)
+	return collected, err
+}
+
+func (f *fs) SetSpaceTiering(ctx context.Context, id string, days int) error {
+	bt, ok := f.next.(storage.BlobTierer)
+	if !ok {
+		return errtypes.NotSupported("SetSpaceTiering")
+	}
+	t := time.Now()
+	err := bt.SetSpaceTiering(ctx, id, days)
+	f.observe(ctx, "SetSpaceTiering", id, t, err)
+	return err
+}
+
+func (f *fs) TierBlobs(ctx context.Context) (int, error) {
+	bt, ok := f.next.(storage.BlobTierer)
+	if !ok {
+		return 0, errtypes.NotSupported("TierBlobs")
+	}
+	t := time.Now()
+	moved, err := bt.TierBlobs(ctx)
+	f.observe(ctx, "TierBlobs", "", t, err)
+	return moved, err
+}
+
+func (f *fs) EnableSpaceEncryption(ctx context.Context, id, passphrase string) error {
+	se, ok := f.next.(storage.SpaceEncrypter)
+	if !ok {
+		return errtypes.NotSupported("EnableSpaceEncryption")
+	}
+	t := time.Now()
+	err := se.EnableSpaceEncryption(ctx, id, passphrase)
+	f.observe(ctx, "EnableSpaceEncryption", id, t, err)
+	return err
+}
+
+func (f *fs) UnlockSpace(ctx context.Context, id, passphrase string) error {
+	se, ok := f.next.(storage.SpaceEncrypter)
+	if !ok {
+		return errtypes.NotSupported("UnlockSpace")
+	}
+	t := time.Now()
+	err := se.UnlockSpace(ctx, id, passphrase)
+	f.observe(ctx, "UnlockSpace", id, t, err)
+	return err
+}
+
+func (f *fs) LockSpace(ctx context.Context, id string) error {
+	se, ok := f.next.(storage.SpaceEncrypter)
+	if !ok {
+		return errtypes.NotSupported("LockSpace")
+	}
+	t := time.Now()
+	err := se.LockSpace(ctx, id)
+	f.observe(ctx, "LockSpace", id, t, err)
+	return err
+}
+
+func (f *fs) RotateSpaceKey(ctx context.Context, id string) error {
+	se, ok := f.next.(storage.SpaceEncrypter)
+	if !ok {
+		return errtypes.NotSupported("RotateSpaceKey")
+	}
+	t := time.Now()
+	err := se.RotateSpaceKey(ctx, id)
+	f.observe(ctx, "RotateSpaceKey", id, t, err)
+	return err
+}
+
+func (f *fs) ChangeSpacePassphrase(ctx context.Context, id, oldPassphrase, newPassphrase string) error {
+	se, ok := f.next.(storage.SpaceEncrypter)
+	if !ok {
+		return errtypes.NotSupported("ChangeSpacePassphrase")
+	}
+	t := time.Now()
+	err := se.ChangeSpacePassphrase(ctx, id, oldPassphrase, newPassphrase)
+	f.observe(ctx, "ChangeSpacePassphrase", id, t, err)
+	return err
+}
+
+func (f *fs) RecalculateTreeSize(ctx context.Context, id string) (uint64, error) {
+	tr, ok := f.next.(storage.TreeSizeRecalculator)
+	if !ok {
+		return 0, errtypes.NotSupported("RecalculateTreeSize")
+	}
+	t := time.Now()
+	size, err := tr.RecalculateTreeSize(ctx, id)
+	f.observe(ctx, "RecalculateTreeSize", id, t, err)
+	return size, err
+}
+
+func (f *fs) PurgeExpiredUploads(ctx context.Context, before time.Time) (int, error) {
+	up, ok := f.next.(storage.UploadPurger)
+	if !ok {
+		return 0, errtypes.NotSupported("PurgeExpiredUploads")
+	}
+	t := time.Now()
+	purged, err := up.PurgeExpiredUploads(ctx, before)
+	f.observe(ctx, "PurgeExpiredUploads", "", t, err)
+	return purged, err
+}
@@ -0,0 +1,108 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package tags handles the user-defined tags of the resources, which are
+// stored as a comma separated list in their arbitrary metadata.
+package tags
+
+import (
+	"strings"
+
+	"github.com/cs3org/reva/pkg/errtypes"
+)
+
+const (
+	// MetadataKey is the arbitrary metadata key the tags are stored under.
+	// It is the key of the oc:tags WebDAV property.
+	MetadataKey = "http://owncloud.org/ns/tags"
+	// ListOpaqueKey is the opaque entry of the ListContainer requests which
+	// lists the resources with the given tag in the whole subtree of the
+	// reference, instead of its direct children.
+	ListOpaqueKey = "tag"
+
+	maxLength = 128
+)
+
+// Validate returns an error if the tag cannot be stored.
+func Validate(tag string) error {
+	switch {
+	case strings.TrimSpace(tag) == "":
+		return errtypes.BadRequest("tags: empty tag")
+	case strings.Contains(tag, ","):
+		return errtypes.BadRequest("tags: tags cannot contain commas")
+	case len(tag) > maxLength:
+		return errtypes.BadRequest("tags: tag too long")
+	}
+	return nil
+}
+
+// Parse returns the tags of the metadata value, without duplicates.
+func Parse(v string) []string {
+	list := []string{}
+	seen := map[string]bool{}
+	for _, t := range strings.Split(v, ",") {
+		t = strings.TrimSpace(t)
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		list = append(list, t)
+	}
+	return list
+}
+
+// Format returns the metadata value of the tags.
+func Format(list []string) string {
+	return strings.Join(list, ",")
+}
+
+// Has returns whether the metadata value contains the tag.
+func Has(v, tag string) bool {
+	tag = strings.TrimSpace(tag)
+	for _, t := range Parse(v) {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Add returns the metadata value with the tag added, and whether it was
+// not there yet.
+func Add(v, tag string) (string, bool) {
+	if Has(v, tag) {
+		return Format(Parse(v)), false
+	}
+	return Format(append(Parse(v), strings.TrimSpace(tag))), true
+}
+
+// Remove returns the metadata value without the tag, and whether it was
+// there.
+func Remove(v, tag string) (string, bool) {
+	tag = strings.TrimSpace(tag)
+	list := []string{}
+	found := false
+	for _, t := range Parse(v) {
+		if t == tag {
+			found = true
+			continue
+		}
+		list = append(list, t)
+	}
+	return Format(list), found
+}
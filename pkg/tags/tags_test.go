@@ -0,0 +1,49 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package tags
+
+import "testing"
+
+func TestAddRemove(t *testing.T) {
+	v, added := Add("", "projects")
+	if !added || v != "projects" {
+		t.Fatalf("unexpected value %q", v)
+	}
+	v, added = Add(v, "urgent")
+	if !added || v != "projects,urgent" {
+		t.Fatalf("unexpected value %q", v)
+	}
+	if _, added = Add(v, " urgent "); added {
+		t.Fatal("expected the tag not to be added twice")
+	}
+	if !Has(v, "projects") || Has(v, "project") {
+		t.Fatalf("unexpected tags in %q", v)
+	}
+
+	v, removed := Remove(v, "projects")
+	if !removed || v != "urgent" {
+		t.Fatalf("unexpected value %q", v)
+	}
+	if _, removed = Remove(v, "projects"); removed {
+		t.Fatal("expected the tag not to be removed twice")
+	}
+	if err := Validate("a,b"); err == nil {
+		t.Fatal("expected tags with commas to be rejected")
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package tenant lets a single deployment serve several institutions, the
+// tenants, each with its own identity provider, storages, shares and
+// capabilities. The tenant of a request is selected by the host name it was
+// sent to or, in its absence, by the identity provider of the user, which is
+// carried in the access token.
+package tenant
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/pkg/errtypes"
+	ctxuser "github.com/cs3org/reva/pkg/user"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+// Tenant is an institution served by the deployment.
+type Tenant struct {
+	ID string `mapstructure:"id"`
+	// Hostnames are the host names the tenant is served at.
+	Hostnames []string `mapstructure:"hostnames"`
+	// Idp is the identity provider of the users of the tenant. The users of
+	// other identity providers are refused at the host names of the tenant.
+	Idp string `mapstructure:"idp"`
+	// StorageRules maps the path prefixes or storage ids of the storages of
+	// the tenant to the addresses of their providers, on top of the rules of
+	// the storage registry.
+	StorageRules map[string]string `mapstructure:"storage_rules"`
+	// Shares configures the driver of the user share provider for the
+	// tenant, so that its shares are stored apart from the other ones.
+	Shares map[string]interface{} `mapstructure:"shares"`
+	// Capabilities overrides the OCS capabilities advertised to the clients
+	// of the tenant.
+	Capabilities map[string]interface{} `mapstructure:"capabilities"`
+}
+
+var tenants []*Tenant
+
+// Load configures the tenants of the deployment.
+func Load(m []map[string]interface{}) error {
+	ts := make([]*Tenant, 0, len(m))
+	ids, hosts, idps := map[string]bool{}, map[string]bool{}, map[string]bool{}
+	for _, c := range m {
+		t := &Tenant{}
+		if err := mapstructure.Decode(c, t); err != nil {
+			return errors.Wrap(err, "tenant: error decoding conf")
+		}
+		if t.ID == "" || t.Idp == "" {
+			return errtypes.BadRequest("tenant: the id and the idp of the tenants are required")
+		}
+		if ids[t.ID] || idps[t.Idp] {
+			return errtypes.BadRequest("tenant: duplicated tenant " + t.ID)
+		}
+		ids[t.ID], idps[t.Idp] = true, true
+		for _, h := range t.Hostnames {
+			if hosts[strings.ToLower(h)] {
+				return errtypes.BadRequest("tenant: host name " + h + " is used by several tenants")
+			}
+			hosts[strings.ToLower(h)] = true
+		}
+		ts = append(ts, t)
+	}
+	tenants = ts
+	return nil
+}
+
+// All returns the tenants of the deployment.
+func All() []*Tenant {
+	return tenants
+}
+
+// ByHost returns the tenant served at the host, which may include a port.
+func ByHost(host string) (*Tenant, bool) {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	for _, t := range tenants {
+		for _, h := range t.Hostnames {
+			if strings.EqualFold(h, host) {
+				return t, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// ByIdp returns the tenant of the users of the identity provider.
+func ByIdp(idp string) (*Tenant, bool) {
+	for _, t := range tenants {
+		if t.Idp == idp {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+// CheckUser returns an error if the user does not belong to the tenant.
+func (t *Tenant) CheckUser(u *userpb.UserId) error {
+	if u.GetIdp() != t.Idp {
+		return errtypes.PermissionDenied("tenant: user does not belong to tenant " + t.ID)
+	}
+	return nil
+}
+
+type key int
+
+const tenantKey key = iota
+
+// ContextSetTenant stores the tenant in the context.
+func ContextSetTenant(ctx context.Context, t *Tenant) context.Context {
+	return context.WithValue(ctx, tenantKey, t)
+}
+
+// ContextGetTenant returns the tenant stored in the context or, if there is
+// none, the tenant of the user of the context.
+func ContextGetTenant(ctx context.Context) (*Tenant, bool) {
+	if t, ok := ctx.Value(tenantKey).(*Tenant); ok {
+		return t, true
+	}
+	if u, ok := ctxuser.ContextGetUser(ctx); ok {
+		return ByIdp(u.Id.GetIdp())
+	}
+	return nil, false
+}
@@ -0,0 +1,68 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package tenant
+
+import (
+	"context"
+	"testing"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	ctxuser "github.com/cs3org/reva/pkg/user"
+)
+
+func TestSelection(t *testing.T) {
+	err := Load([]map[string]interface{}{
+		{"id": "a", "idp": "https://idp.a", "hostnames": []string{"cloud.a.org"}},
+		{"id": "b", "idp": "https://idp.b"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { tenants = nil }()
+
+	if tn, ok := ByHost("CLOUD.a.org:443"); !ok || tn.ID != "a" {
+		t.Errorf("ByHost: got %v, %v, expected tenant a", tn, ok)
+	}
+	if _, ok := ByHost("cloud.b.org"); ok {
+		t.Error("ByHost: unexpected tenant for unknown host")
+	}
+
+	ctx := ctxuser.ContextSetUser(context.Background(), &userpb.User{Id: &userpb.UserId{Idp: "https://idp.b", OpaqueId: "einstein"}})
+	tn, ok := ContextGetTenant(ctx)
+	if !ok || tn.ID != "b" {
+		t.Fatalf("ContextGetTenant: got %v, %v, expected tenant b", tn, ok)
+	}
+	a, _ := ByHost("cloud.a.org")
+	if err := a.CheckUser(&userpb.UserId{Idp: "https://idp.b"}); err == nil {
+		t.Error("CheckUser: expected the user of another idp to be refused")
+	}
+	if tn, _ := ContextGetTenant(ContextSetTenant(ctx, a)); tn.ID != "a" {
+		t.Errorf("ContextGetTenant: got %s, expected the tenant set in the context", tn.ID)
+	}
+}
+
+func TestLoadDuplicates(t *testing.T) {
+	err := Load([]map[string]interface{}{
+		{"id": "a", "idp": "https://idp.a", "hostnames": []string{"cloud.org"}},
+		{"id": "b", "idp": "https://idp.b", "hostnames": []string{"cloud.org"}},
+	})
+	if err == nil {
+		t.Error("expected an error for a host name shared by two tenants")
+	}
+}
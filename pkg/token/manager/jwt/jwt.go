@@ -28,6 +28,7 @@ import (
 	"github.com/cs3org/reva/pkg/sharedconf"
 	"github.com/cs3org/reva/pkg/token"
 	"github.com/cs3org/reva/pkg/token/manager/registry"
+	"github.com/cs3org/reva/pkg/token/revocation"
 	"github.com/dgrijalva/jwt-go"
 	"github.com/mitchellh/mapstructure"
 	"github.com/pkg/errors"
@@ -42,10 +43,14 @@ func init() {
 type config struct {
 	Secret  string `mapstructure:"secret"`
 	Expires int64  `mapstructure:"expires"`
+	// RevocationFile is the file holding the list of the users whose tokens
+	// have been revoked. If empty, tokens are valid until they expire.
+	RevocationFile string `mapstructure:"revocation_file"`
 }
 
 type manager struct {
-	conf *config
+	conf    *config
+	revoked *revocation.List
 }
 
 // claims are custom claims for the JWT token.
@@ -82,6 +87,9 @@ func New(value map[string]interface{}) (token.Manager, error) {
 	}
 
 	m := &manager{conf: c}
+	if c.RevocationFile != "" {
+		m.revoked = revocation.New(c.RevocationFile)
+	}
 	return m, nil
 }
 
@@ -118,6 +126,15 @@ func (m *manager) DismantleToken(ctx context.Context, tkn string) (*user.User, m
 	}
 
 	if claims, ok := token.Claims.(*claims); ok && token.Valid {
+		if m.revoked != nil {
+			revoked, err := m.revoked.IsRevoked(claims.User.GetId(), claims.IssuedAt)
+			if err != nil {
+				return nil, nil, errors.Wrap(err, "error checking token revocation")
+			}
+			if revoked {
+				return nil, nil, errtypes.InvalidCredentials("token has been revoked")
+			}
+		}
 		return claims.User, claims.Scope, nil
 	}
 
@@ -0,0 +1,87 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package revocation keeps track of the users whose tokens have been revoked,
+// e.g. because their account was deprovisioned. The list is stored in a file
+// shared by all the services verifying tokens.
+package revocation
+
+import (
+	"sync"
+	"time"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	"github.com/cs3org/reva/pkg/utils/jsonfile"
+	"github.com/pkg/errors"
+)
+
+// List is a file backed list of revocations. The tokens of a user issued
+// before the time of the revocation are no longer valid.
+type List struct {
+	sync.Mutex
+	file    *jsonfile.File
+	revoked map[string]int64
+}
+
+// New returns the revocation list stored in the given file.
+func New(file string) *List {
+	return &List{file: jsonfile.New(file), revoked: map[string]int64{}}
+}
+
+func key(uid *userpb.UserId) string {
+	return uid.GetIdp() + "!" + uid.GetOpaqueId()
+}
+
+func (l *List) reload() error {
+	revoked := map[string]int64{}
+	changed, err := l.file.Reload(&revoked)
+	if err != nil {
+		return errors.Wrap(err, "revocation: error reading revocation list")
+	}
+	if changed {
+		l.revoked = revoked
+	}
+	return nil
+}
+
+// Revoke invalidates the tokens of the user issued until now.
+func (l *List) Revoke(uid *userpb.UserId) error {
+	l.Lock()
+	defer l.Unlock()
+	if err := l.reload(); err != nil {
+		return err
+	}
+
+	l.revoked[key(uid)] = time.Now().Unix()
+	if err := l.file.Persist(l.revoked); err != nil {
+		return errors.Wrap(err, "revocation: error writing revocation list")
+	}
+	return nil
+}
+
+// IsRevoked returns whether a token of the user issued at the given unix time
+// has been revoked.
+func (l *List) IsRevoked(uid *userpb.UserId, issuedAt int64) (bool, error) {
+	l.Lock()
+	defer l.Unlock()
+	if err := l.reload(); err != nil {
+		return false, err
+	}
+	t, ok := l.revoked[key(uid)]
+	return ok && issuedAt <= t, nil
+}
@@ -0,0 +1,86 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package trace
+
+import (
+	"context"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	provider "github.com/cs3org/go-cs3apis/cs3/storage/provider/v1beta1"
+	ctxpkg "github.com/cs3org/reva/pkg/user"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+)
+
+// Keys of the attributes set on the spans.
+const (
+	UserIDKey       = attribute.Key("reva.user.id")
+	UserIdpKey      = attribute.Key("reva.user.idp")
+	UsernameKey     = attribute.Key("reva.user.name")
+	ResourceIDKey   = attribute.Key("reva.resource.id")
+	ResourcePathKey = attribute.Key("reva.resource.path")
+	StorageIDKey    = attribute.Key("reva.storage.id")
+)
+
+// SetUserAttributes annotates the current span with the user.
+func SetUserAttributes(ctx context.Context, u *userpb.User) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() || u == nil {
+		return
+	}
+	span.SetAttributes(
+		UserIDKey.String(u.Id.GetOpaqueId()),
+		UserIdpKey.String(u.Id.GetIdp()),
+		UsernameKey.String(u.Username),
+	)
+}
+
+// SetReferenceAttributes annotates the current span with the resource and
+// the storage the reference points to.
+func SetReferenceAttributes(ctx context.Context, ref *provider.Reference) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() || ref == nil {
+		return
+	}
+	if p := ref.GetPath(); p != "" {
+		span.SetAttributes(ResourcePathKey.String(p))
+	}
+	if id := ref.GetId(); id != nil {
+		span.SetAttributes(
+			ResourceIDKey.String(id.OpaqueId),
+			StorageIDKey.String(id.StorageId),
+		)
+	}
+}
+
+// UnaryServerInterceptor returns a unary interceptor annotating the span of
+// the call with the authenticated user and, for the calls concerning a
+// resource, with the resource and its storage.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if u, ok := ctxpkg.ContextGetUser(ctx); ok {
+			SetUserAttributes(ctx, u)
+		}
+		if r, ok := req.(interface{ GetRef() *provider.Reference }); ok {
+			SetReferenceAttributes(ctx, r.GetRef())
+		}
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package trace configures the OpenTelemetry tracing of the services, and
+// provides the helpers to annotate the spans with the user and the resource
+// concerned by the requests.
+package trace
+
+import (
+	"context"
+	"net"
+
+	"github.com/pkg/errors"
+	octrace "go.opencensus.io/trace"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/bridge/opencensus"
+	"go.opentelemetry.io/otel/exporters/jaeger"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+)
+
+// Exporters supported by Init.
+const (
+	ExporterOTLP   = "otlp"
+	ExporterJaeger = "jaeger"
+)
+
+// instrumentationName is the name of the tracer of the services.
+const instrumentationName = "github.com/cs3org/reva"
+
+// Options configures the tracing.
+type Options struct {
+	Enabled bool
+	// Exporter is either otlp or jaeger.
+	Exporter string
+	// Endpoint is the address of the OTLP receiver, or of the Jaeger agent.
+	Endpoint string
+	// Collector is the URL of the Jaeger collector, used instead of the agent
+	// if set.
+	Collector   string
+	ServiceName string
+	// Insecure disables TLS for the connections to the OTLP receiver.
+	Insecure bool
+	// SampleRatio is the fraction of the traces started by the services that
+	// are sampled. The services follow the sampling decision of the callers.
+	SampleRatio float64
+}
+
+// Init configures the propagation of the trace context over gRPC and HTTP
+// and, if enabled, the export of the spans. The spans created with
+// OpenCensus are exported as well.
+func Init(o *Options) error {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	if !o.Enabled {
+		return nil
+	}
+
+	if o.ServiceName == "" {
+		o.ServiceName = "revad"
+	}
+	if o.SampleRatio == 0 {
+		o.SampleRatio = 1
+	}
+
+	exporter, err := newExporter(o)
+	if err != nil {
+		return err
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(o.SampleRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(
+			semconv.SchemaURL,
+			semconv.ServiceNameKey.String(o.ServiceName),
+		)),
+	)
+	otel.SetTracerProvider(tp)
+	octrace.DefaultTracer = opencensus.NewTracer(tp.Tracer(instrumentationName))
+	return nil
+}
+
+func newExporter(o *Options) (sdktrace.SpanExporter, error) {
+	switch o.Exporter {
+	case ExporterOTLP:
+		if o.Endpoint == "" {
+			o.Endpoint = "localhost:4317"
+		}
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(o.Endpoint)}
+		if o.Insecure {
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+		return otlptracegrpc.New(context.Background(), opts...)
+	case ExporterJaeger, "":
+		if o.Collector != "" {
+			return jaeger.New(jaeger.WithCollectorEndpoint(jaeger.WithEndpoint(o.Collector)))
+		}
+		if o.Endpoint == "" {
+			o.Endpoint = "localhost:6831"
+		}
+		host, port, err := net.SplitHostPort(o.Endpoint)
+		if err != nil {
+			return nil, errors.Wrap(err, "trace: invalid jaeger agent endpoint")
+		}
+		return jaeger.New(jaeger.WithAgentEndpoint(jaeger.WithAgentHost(host), jaeger.WithAgentPort(port)))
+	}
+	return nil, errors.New("trace: unknown exporter " + o.Exporter)
+}
@@ -20,24 +20,31 @@ package json
 
 import (
 	"context"
-	"encoding/json"
-	"io/ioutil"
+	"os"
 	"strings"
+	"sync"
 
 	"github.com/cs3org/reva/pkg/user"
 	"github.com/cs3org/reva/pkg/user/manager/registry"
+	"github.com/cs3org/reva/pkg/utils/jsonfile"
 	"github.com/mitchellh/mapstructure"
 	"github.com/pkg/errors"
 
 	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
 	"github.com/cs3org/reva/pkg/errtypes"
 )
 
+// disabledKey is the opaque entry marking the users which have been deleted.
+const disabledKey = "disabled"
+
 func init() {
 	registry.Register("json", New)
 }
 
 type manager struct {
+	sync.Mutex
+	file  *jsonfile.File
 	users []*userpb.User
 }
 
@@ -69,34 +76,65 @@ func New(m map[string]interface{}) (user.Manager, error) {
 		return nil, err
 	}
 
-	f, err := ioutil.ReadFile(c.Users)
-	if err != nil {
+	if _, err := os.Stat(c.Users); err != nil {
+		return nil, err
+	}
+	mgr := &manager{file: jsonfile.New(c.Users)}
+	if err := mgr.reload(); err != nil {
 		return nil, err
 	}
+	return mgr, nil
+}
 
+func (m *manager) reload() error {
 	users := []*userpb.User{}
+	changed, err := m.file.Reload(&users)
+	if err != nil || !changed {
+		return err
+	}
+	m.users = users
+	return nil
+}
 
-	err = json.Unmarshal(f, &users)
-	if err != nil {
+func (m *manager) GetUser(ctx context.Context, uid *userpb.UserId) (*userpb.User, error) {
+	m.Lock()
+	defer m.Unlock()
+	if err := m.reload(); err != nil {
 		return nil, err
 	}
-
-	return &manager{
-		users: users,
-	}, nil
+	if u := m.findUser(uid); u != nil && !isDisabled(u) {
+		return u, nil
+	}
+	return nil, errtypes.NotFound(uid.OpaqueId)
 }
 
-func (m *manager) GetUser(ctx context.Context, uid *userpb.UserId) (*userpb.User, error) {
+func (m *manager) findUser(uid *userpb.UserId) *userpb.User {
 	for _, u := range m.users {
 		if (u.Id.GetOpaqueId() == uid.OpaqueId || u.Username == uid.OpaqueId) && (uid.Idp == "" || uid.Idp == u.Id.GetIdp()) {
-			return u, nil
+			return u
 		}
 	}
-	return nil, errtypes.NotFound(uid.OpaqueId)
+	return nil
+}
+
+func isDisabled(u *userpb.User) bool {
+	if u.Opaque == nil || u.Opaque.Map == nil {
+		return false
+	}
+	e, ok := u.Opaque.Map[disabledKey]
+	return ok && string(e.Value) == "true"
 }
 
 func (m *manager) GetUserByClaim(ctx context.Context, claim, value string) (*userpb.User, error) {
+	m.Lock()
+	defer m.Unlock()
+	if err := m.reload(); err != nil {
+		return nil, err
+	}
 	for _, u := range m.users {
+		if isDisabled(u) {
+			continue
+		}
 		if userClaim, err := extractClaim(u, claim); err == nil && value == userClaim {
 			return u, nil
 		}
@@ -130,9 +168,14 @@ func userContains(u *userpb.User, query string) bool {
 }
 
 func (m *manager) FindUsers(ctx context.Context, query string) ([]*userpb.User, error) {
+	m.Lock()
+	defer m.Unlock()
+	if err := m.reload(); err != nil {
+		return nil, err
+	}
 	users := []*userpb.User{}
 	for _, u := range m.users {
-		if userContains(u, query) {
+		if !isDisabled(u) && userContains(u, query) {
 			users = append(users, u)
 		}
 	}
@@ -146,3 +189,80 @@ func (m *manager) GetUserGroups(ctx context.Context, uid *userpb.UserId) ([]stri
 	}
 	return user.Groups, nil
 }
+
+func (m *manager) CreateUser(ctx context.Context, u *userpb.User) (*userpb.User, error) {
+	m.Lock()
+	defer m.Unlock()
+	if err := m.reload(); err != nil {
+		return nil, err
+	}
+	// the deleted users with the same username or id are overwritten, so
+	// that a deprovisioned account can be provisioned again.
+	users := make([]*userpb.User, 0, len(m.users)+1)
+	for _, o := range m.users {
+		if o.Username == u.Username || (o.Id.GetOpaqueId() == u.Id.GetOpaqueId() && o.Id.GetIdp() == u.Id.GetIdp()) {
+			if !isDisabled(o) {
+				return nil, errtypes.AlreadyExists(u.Username)
+			}
+			continue
+		}
+		users = append(users, o)
+	}
+	old := m.users
+	m.users = append(users, u)
+	if err := m.persist(); err != nil {
+		m.users = old
+		return nil, err
+	}
+	return u, nil
+}
+
+func (m *manager) UpdateUser(ctx context.Context, u *userpb.User) (*userpb.User, error) {
+	m.Lock()
+	defer m.Unlock()
+	if err := m.reload(); err != nil {
+		return nil, err
+	}
+	for i, o := range m.users {
+		if o.Id.GetOpaqueId() == u.Id.GetOpaqueId() && o.Id.GetIdp() == u.Id.GetIdp() && !isDisabled(o) {
+			m.users[i] = u
+			if err := m.persist(); err != nil {
+				m.users[i] = o
+				return nil, err
+			}
+			return u, nil
+		}
+	}
+	return nil, errtypes.NotFound(u.Id.GetOpaqueId())
+}
+
+func (m *manager) DeleteUser(ctx context.Context, uid *userpb.UserId) error {
+	m.Lock()
+	defer m.Unlock()
+	if err := m.reload(); err != nil {
+		return err
+	}
+	u := m.findUser(uid)
+	if u == nil || isDisabled(u) {
+		return errtypes.NotFound(uid.OpaqueId)
+	}
+	if u.Opaque == nil {
+		u.Opaque = &types.Opaque{}
+	}
+	if u.Opaque.Map == nil {
+		u.Opaque.Map = map[string]*types.OpaqueEntry{}
+	}
+	u.Opaque.Map[disabledKey] = &types.OpaqueEntry{Decoder: "plain", Value: []byte("true")}
+	if err := m.persist(); err != nil {
+		delete(u.Opaque.Map, disabledKey)
+		return err
+	}
+	return nil
+}
+
+func (m *manager) persist() error {
+	if err := m.file.Persist(m.users); err != nil {
+		return errors.Wrap(err, "json: error writing users file")
+	}
+	return nil
+}
@@ -135,3 +135,49 @@ func TestUserManager(t *testing.T) {
 		t.Fatalf("user differ: expected=%v got=%v", "einstein", resUser[0].Username)
 	}
 }
+
+func TestProvisioning(t *testing.T) {
+	file, err := ioutil.TempFile("", "json_test")
+	if err != nil {
+		t.Fatalf("error while open temp file: %v", err)
+	}
+	defer os.Remove(file.Name())
+	_, _ = file.WriteString(`[]`)
+	file.Close()
+
+	m, err := New(map[string]interface{}{"users": file.Name()})
+	if err != nil {
+		t.Fatalf("error while get manager: %v", err)
+	}
+	p := m.(*manager)
+
+	u := &userpb.User{Id: &userpb.UserId{Idp: "localhost", OpaqueId: "marie"}, Username: "marie", DisplayName: "Marie Curie"}
+	if _, err := p.CreateUser(ctx, u); err != nil {
+		t.Fatalf("error creating user: %v", err)
+	}
+	if _, err := p.CreateUser(ctx, u); err == nil {
+		t.Fatal("expected an error creating an existing user")
+	}
+	if err := p.DeleteUser(ctx, u.Id); err != nil {
+		t.Fatalf("error deleting user: %v", err)
+	}
+	if _, err := p.GetUser(ctx, u.Id); err == nil {
+		t.Fatal("expected a deleted user not to be found")
+	}
+
+	// the deleted user is provisioned again with another id
+	again := &userpb.User{Id: &userpb.UserId{Idp: "localhost", OpaqueId: "marie2"}, Username: "marie", DisplayName: "Marie Sklodowska-Curie"}
+	if _, err := p.CreateUser(ctx, again); err != nil {
+		t.Fatalf("error creating a deleted user again: %v", err)
+	}
+	got, err := p.GetUserByClaim(ctx, "username", "marie")
+	if err != nil {
+		t.Fatalf("error getting user: %v", err)
+	}
+	if got.DisplayName != again.DisplayName || got.Id.OpaqueId != "marie2" {
+		t.Fatalf("user not overwritten: %+v", got)
+	}
+	if len(p.users) != 1 {
+		t.Fatalf("expected the deleted user to be replaced, got %d users", len(p.users))
+	}
+}
@@ -0,0 +1,233 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package keycloak
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/ReneKroon/ttlcache/v2"
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/keycloak"
+	"github.com/cs3org/reva/pkg/user"
+	"github.com/cs3org/reva/pkg/user/manager/registry"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	registry.Register("keycloak", New)
+}
+
+type config struct {
+	keycloak.Config `mapstructure:",squash"`
+	// Idp is the identity provider of the users, usually the issuer of the realm.
+	Idp string `mapstructure:"idp"`
+	// CacheTTL is the time in seconds for which the users and their groups are cached.
+	CacheTTL int `mapstructure:"cache_ttl"`
+	// SearchLimit is the maximum number of users returned by a search.
+	SearchLimit int `mapstructure:"search_limit"`
+}
+
+func (c *config) init() {
+	c.Config.Init()
+	if c.Idp == "" {
+		c.Idp = c.BaseURL + "/realms/" + c.Realm
+	}
+	if c.CacheTTL == 0 {
+		c.CacheTTL = 300
+	}
+	if c.SearchLimit == 0 {
+		c.SearchLimit = 100
+	}
+}
+
+func parseConfig(m map[string]interface{}) (*config, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		err = errors.Wrap(err, "error decoding conf")
+		return nil, err
+	}
+	c.init()
+	return c, nil
+}
+
+type manager struct {
+	c      *config
+	client *keycloak.Client
+	cache  *ttlcache.Cache
+}
+
+// New returns a user manager querying the admin REST API of a Keycloak realm.
+func New(m map[string]interface{}) (user.Manager, error) {
+	c, err := parseConfig(m)
+	if err != nil {
+		return nil, err
+	}
+
+	cache := ttlcache.NewCache()
+	_ = cache.SetTTL(time.Duration(c.CacheTTL) * time.Second)
+	cache.SkipTTLExtensionOnHit(true)
+
+	return &manager{
+		c:      c,
+		client: keycloak.New(&c.Config),
+		cache:  cache,
+	}, nil
+}
+
+func (m *manager) toUser(u *keycloak.User) *userpb.User {
+	cu := &userpb.User{
+		Id: &userpb.UserId{
+			Idp:      m.c.Idp,
+			OpaqueId: u.ID,
+		},
+		Username:     u.Username,
+		Mail:         u.Email,
+		MailVerified: u.EmailVerified,
+		DisplayName:  u.DisplayName(),
+	}
+	uid := keycloak.Attribute(u.Attributes, m.c.UIDAttribute)
+	gid := keycloak.Attribute(u.Attributes, m.c.GIDAttribute)
+	if uid != "" || gid != "" {
+		cu.Opaque = &types.Opaque{
+			Map: map[string]*types.OpaqueEntry{
+				"uid": {
+					Decoder: "plain",
+					Value:   []byte(uid),
+				},
+				"gid": {
+					Decoder: "plain",
+					Value:   []byte(gid),
+				},
+			},
+		}
+	}
+	if q := keycloak.Attribute(u.Attributes, m.c.QuotaAttribute); m.c.QuotaAttribute != "" && q != "" {
+		if cu.Opaque == nil {
+			cu.Opaque = &types.Opaque{Map: map[string]*types.OpaqueEntry{}}
+		}
+		cu.Opaque.Map[user.QuotaOpaqueKey] = user.QuotaEntry(q)
+	}
+	return cu
+}
+
+func (m *manager) GetUser(ctx context.Context, uid *userpb.UserId) (*userpb.User, error) {
+	if v, err := m.cache.Get("user:" + uid.OpaqueId); err == nil {
+		return v.(*userpb.User), nil
+	}
+
+	ku, err := m.client.GetUser(ctx, uid.OpaqueId)
+	if err != nil {
+		return nil, err
+	}
+	if !ku.Enabled {
+		return nil, errtypes.NotFound(uid.OpaqueId)
+	}
+	return m.completeUser(ctx, ku)
+}
+
+// completeUser adds the groups to the user and caches it.
+func (m *manager) completeUser(ctx context.Context, ku *keycloak.User) (*userpb.User, error) {
+	u := m.toUser(ku)
+	groups, err := m.GetUserGroups(ctx, u.Id)
+	if err != nil {
+		return nil, err
+	}
+	u.Groups = groups
+	_ = m.cache.Set("user:"+ku.ID, u)
+	return u, nil
+}
+
+func (m *manager) GetUserByClaim(ctx context.Context, claim, value string) (*userpb.User, error) {
+	key := "claim:" + claim + ":" + value
+	if v, err := m.cache.Get(key); err == nil {
+		return m.GetUser(ctx, &userpb.UserId{OpaqueId: v.(string)})
+	}
+
+	var q url.Values
+	switch claim {
+	case "userid":
+		return m.GetUser(ctx, &userpb.UserId{OpaqueId: value})
+	case "username":
+		q = url.Values{"username": {value}, "exact": {"true"}}
+	case "mail":
+		q = url.Values{"email": {value}, "exact": {"true"}}
+	case "uid":
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return nil, errtypes.BadRequest("keycloak: invalid uid " + value)
+		}
+		q = url.Values{"q": {m.c.UIDAttribute + ":" + value}}
+	default:
+		return nil, errors.New("keycloak: invalid field " + claim)
+	}
+
+	users, err := m.client.FindUsers(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	for _, ku := range users {
+		if !ku.Enabled {
+			continue
+		}
+		_ = m.cache.Set(key, ku.ID)
+		return m.completeUser(ctx, ku)
+	}
+	return nil, errtypes.NotFound(value)
+}
+
+func (m *manager) FindUsers(ctx context.Context, query string) ([]*userpb.User, error) {
+	found, err := m.client.FindUsers(ctx, url.Values{
+		"search":              {query},
+		"max":                 {strconv.Itoa(m.c.SearchLimit)},
+		"briefRepresentation": {"false"},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	users := []*userpb.User{}
+	for _, ku := range found {
+		if ku.Enabled {
+			users = append(users, m.toUser(ku))
+		}
+	}
+	return users, nil
+}
+
+func (m *manager) GetUserGroups(ctx context.Context, uid *userpb.UserId) ([]string, error) {
+	if v, err := m.cache.Get("groups:" + uid.OpaqueId); err == nil {
+		return v.([]string), nil
+	}
+
+	kgroups, err := m.client.GetUserGroups(ctx, uid.OpaqueId)
+	if err != nil {
+		return nil, err
+	}
+	groups := make([]string, 0, len(kgroups))
+	for _, g := range kgroups {
+		groups = append(groups, g.Name)
+	}
+	_ = m.cache.Set("groups:"+uid.OpaqueId, groups)
+	return groups, nil
+}
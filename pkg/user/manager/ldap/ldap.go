@@ -77,6 +77,8 @@ type attributes struct {
 	UIDNumber string `mapstructure:"uidNumber"`
 	// GIDNumber is a numeric id that maps to a filesystem gid, eg. 654321
 	GIDNumber string `mapstructure:"gidNumber"`
+	// Quota is the quota in bytes of the user, not read when empty
+	Quota string `mapstructure:"quota"`
 }
 
 // Default attributes (Active Directory)
@@ -153,7 +155,7 @@ func (m *manager) GetUser(ctx context.Context, uid *userpb.UserId) (*userpb.User
 		m.c.BaseDN,
 		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
 		m.getUserFilter(uid),
-		[]string{m.c.Schema.DN, m.c.Schema.UID, m.c.Schema.CN, m.c.Schema.Mail, m.c.Schema.DisplayName, m.c.Schema.UIDNumber, m.c.Schema.GIDNumber},
+		m.searchAttributes(),
 		nil,
 	)
 
@@ -182,18 +184,7 @@ func (m *manager) GetUser(ctx context.Context, uid *userpb.UserId) (*userpb.User
 		Groups:      groups,
 		Mail:        sr.Entries[0].GetEqualFoldAttributeValue(m.c.Schema.Mail),
 		DisplayName: sr.Entries[0].GetEqualFoldAttributeValue(m.c.Schema.DisplayName),
-		Opaque: &types.Opaque{
-			Map: map[string]*types.OpaqueEntry{
-				"uid": {
-					Decoder: "plain",
-					Value:   []byte(sr.Entries[0].GetEqualFoldAttributeValue(m.c.Schema.UIDNumber)),
-				},
-				"gid": {
-					Decoder: "plain",
-					Value:   []byte(sr.Entries[0].GetEqualFoldAttributeValue(m.c.Schema.GIDNumber)),
-				},
-			},
-		},
+		Opaque:      m.opaque(sr.Entries[0]),
 	}
 
 	return u, nil
@@ -234,7 +225,7 @@ func (m *manager) GetUserByClaim(ctx context.Context, claim, value string) (*use
 		m.c.BaseDN,
 		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
 		m.getAttributeFilter(claim, value),
-		[]string{m.c.Schema.DN, m.c.Schema.UID, m.c.Schema.CN, m.c.Schema.Mail, m.c.Schema.DisplayName, m.c.Schema.UIDNumber, m.c.Schema.GIDNumber},
+		m.searchAttributes(),
 		nil,
 	)
 
@@ -263,18 +254,7 @@ func (m *manager) GetUserByClaim(ctx context.Context, claim, value string) (*use
 		Groups:      groups,
 		Mail:        sr.Entries[0].GetEqualFoldAttributeValue(m.c.Schema.Mail),
 		DisplayName: sr.Entries[0].GetEqualFoldAttributeValue(m.c.Schema.DisplayName),
-		Opaque: &types.Opaque{
-			Map: map[string]*types.OpaqueEntry{
-				"uid": {
-					Decoder: "plain",
-					Value:   []byte(sr.Entries[0].GetEqualFoldAttributeValue(m.c.Schema.UIDNumber)),
-				},
-				"gid": {
-					Decoder: "plain",
-					Value:   []byte(sr.Entries[0].GetEqualFoldAttributeValue(m.c.Schema.GIDNumber)),
-				},
-			},
-		},
+		Opaque:      m.opaque(sr.Entries[0]),
 	}
 
 	return u, nil
@@ -299,7 +279,7 @@ func (m *manager) FindUsers(ctx context.Context, query string) ([]*userpb.User,
 		m.c.BaseDN,
 		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
 		m.getFindFilter(query),
-		[]string{m.c.Schema.DN, m.c.Schema.UID, m.c.Schema.CN, m.c.Schema.Mail, m.c.Schema.DisplayName, m.c.Schema.UIDNumber, m.c.Schema.GIDNumber},
+		m.searchAttributes(),
 		nil,
 	)
 
@@ -325,18 +305,7 @@ func (m *manager) FindUsers(ctx context.Context, query string) ([]*userpb.User,
 			Groups:      groups,
 			Mail:        entry.GetEqualFoldAttributeValue(m.c.Schema.Mail),
 			DisplayName: entry.GetEqualFoldAttributeValue(m.c.Schema.DisplayName),
-			Opaque: &types.Opaque{
-				Map: map[string]*types.OpaqueEntry{
-					"uid": {
-						Decoder: "plain",
-						Value:   []byte(entry.GetEqualFoldAttributeValue(m.c.Schema.UIDNumber)),
-					},
-					"gid": {
-						Decoder: "plain",
-						Value:   []byte(entry.GetEqualFoldAttributeValue(m.c.Schema.GIDNumber)),
-					},
-				},
-			},
+			Opaque:      m.opaque(entry),
 		}
 		users = append(users, user)
 	}
@@ -383,6 +352,35 @@ func (m *manager) GetUserGroups(ctx context.Context, uid *userpb.UserId) ([]stri
 	return groups, nil
 }
 
+func (m *manager) searchAttributes() []string {
+	attrs := []string{m.c.Schema.DN, m.c.Schema.UID, m.c.Schema.CN, m.c.Schema.Mail, m.c.Schema.DisplayName, m.c.Schema.UIDNumber, m.c.Schema.GIDNumber}
+	if m.c.Schema.Quota != "" {
+		attrs = append(attrs, m.c.Schema.Quota)
+	}
+	return attrs
+}
+
+func (m *manager) opaque(entry *ldap.Entry) *types.Opaque {
+	o := &types.Opaque{
+		Map: map[string]*types.OpaqueEntry{
+			"uid": {
+				Decoder: "plain",
+				Value:   []byte(entry.GetEqualFoldAttributeValue(m.c.Schema.UIDNumber)),
+			},
+			"gid": {
+				Decoder: "plain",
+				Value:   []byte(entry.GetEqualFoldAttributeValue(m.c.Schema.GIDNumber)),
+			},
+		},
+	}
+	if m.c.Schema.Quota != "" {
+		if q := entry.GetEqualFoldAttributeValue(m.c.Schema.Quota); q != "" {
+			o.Map[user.QuotaOpaqueKey] = user.QuotaEntry(q)
+		}
+	}
+	return o
+}
+
 func (m *manager) getUserFilter(uid *userpb.UserId) string {
 	b := bytes.Buffer{}
 	if err := m.userfilter.Execute(&b, uid); err != nil {
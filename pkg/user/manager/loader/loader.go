@@ -22,6 +22,8 @@ import (
 	// Load core user manager drivers.
 	_ "github.com/cs3org/reva/pkg/user/manager/demo"
 	_ "github.com/cs3org/reva/pkg/user/manager/json"
+	_ "github.com/cs3org/reva/pkg/user/manager/keycloak"
 	_ "github.com/cs3org/reva/pkg/user/manager/ldap"
+	_ "github.com/cs3org/reva/pkg/user/manager/sql"
 	// Add your own here
 )
@@ -0,0 +1,319 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package sql
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/user"
+	"github.com/cs3org/reva/pkg/user/manager/registry"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+
+	// Provides mysql drivers
+	_ "github.com/go-sql-driver/mysql"
+)
+
+func init() {
+	registry.Register("sql", New)
+}
+
+// The manager expects the following tables:
+//
+//   users(id, idp, username, mail, display_name, uid_number, gid_number, disabled)
+//   group_members(group_id, user_id, user_idp)
+//   groups(id, idp, group_name, ...)
+//
+// as created by the group manager of the same package.
+
+type config struct {
+	DbUsername string `mapstructure:"db_username"`
+	DbPassword string `mapstructure:"db_password"`
+	DbHost     string `mapstructure:"db_host"`
+	DbPort     int    `mapstructure:"db_port"`
+	DbName     string `mapstructure:"db_name"`
+	// Idp is the identity provider of the users stored in the database.
+	Idp string `mapstructure:"idp"`
+	// SearchLimit is the maximum number of users returned by a search.
+	SearchLimit int `mapstructure:"search_limit"`
+}
+
+func (c *config) init() {
+	if c.DbPort == 0 {
+		c.DbPort = 3306
+	}
+	if c.SearchLimit == 0 {
+		c.SearchLimit = 100
+	}
+}
+
+type manager struct {
+	c  *config
+	db *sql.DB
+}
+
+func parseConfig(m map[string]interface{}) (*config, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		err = errors.Wrap(err, "error decoding conf")
+		return nil, err
+	}
+	c.init()
+	return c, nil
+}
+
+// New returns a user manager storing the users in a SQL database.
+func New(m map[string]interface{}) (user.Manager, error) {
+	c, err := parseConfig(m)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("mysql", fmt.Sprintf("%s:%s@tcp(%s:%d)/%s", c.DbUsername, c.DbPassword, c.DbHost, c.DbPort, c.DbName))
+	if err != nil {
+		return nil, errors.Wrap(err, "sql: error opening connection to the database")
+	}
+
+	return &manager{c: c, db: db}, nil
+}
+
+const selectUser = "SELECT id, idp, username, mail, display_name, uid_number, gid_number FROM users"
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func (m *manager) scanUser(row scanner) (*userpb.User, error) {
+	var id, idp, username, mail, displayName string
+	var uidNumber, gidNumber sql.NullInt64
+	if err := row.Scan(&id, &idp, &username, &mail, &displayName, &uidNumber, &gidNumber); err != nil {
+		return nil, err
+	}
+
+	u := &userpb.User{
+		Id: &userpb.UserId{
+			Idp:      idp,
+			OpaqueId: id,
+		},
+		Username:    username,
+		Mail:        mail,
+		DisplayName: displayName,
+	}
+	if uidNumber.Valid || gidNumber.Valid {
+		u.Opaque = &types.Opaque{
+			Map: map[string]*types.OpaqueEntry{
+				"uid": {
+					Decoder: "plain",
+					Value:   []byte(strconv.FormatInt(uidNumber.Int64, 10)),
+				},
+				"gid": {
+					Decoder: "plain",
+					Value:   []byte(strconv.FormatInt(gidNumber.Int64, 10)),
+				},
+			},
+		}
+	}
+	return u, nil
+}
+
+func (m *manager) queryUser(ctx context.Context, where string, args ...interface{}) (*userpb.User, error) {
+	row := m.db.QueryRowContext(ctx, selectUser+" WHERE disabled=0 AND "+where, args...)
+	u, err := m.scanUser(row)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, errtypes.NotFound(fmt.Sprintf("%v", args))
+		}
+		return nil, errors.Wrap(err, "sql: error querying user")
+	}
+	groups, err := m.getUserGroups(ctx, u.Id)
+	if err != nil {
+		return nil, err
+	}
+	u.Groups = groups
+	return u, nil
+}
+
+func (m *manager) GetUser(ctx context.Context, uid *userpb.UserId) (*userpb.User, error) {
+	if uid.Idp != "" {
+		return m.queryUser(ctx, "id=? AND idp=?", uid.OpaqueId, uid.Idp)
+	}
+	return m.queryUser(ctx, "id=?", uid.OpaqueId)
+}
+
+// claimColumns maps the claims to the columns storing them.
+var claimColumns = map[string]string{
+	"mail":         "mail",
+	"username":     "username",
+	"userid":       "id",
+	"uid":          "uid_number",
+	"gid":          "gid_number",
+	"display_name": "display_name",
+}
+
+func (m *manager) GetUserByClaim(ctx context.Context, claim, value string) (*userpb.User, error) {
+	col, ok := claimColumns[claim]
+	if !ok {
+		return nil, errors.New("sql: invalid field " + claim)
+	}
+	return m.queryUser(ctx, col+"=?", value)
+}
+
+// FindUsers searches the users whose username, mail, display name or id
+// contain the query. A query in the form attribute:value, e.g. mail:einstein@example.org,
+// only matches the users whose attribute is equal to the value.
+// The results are sorted by username and limited to the configured search limit.
+func (m *manager) FindUsers(ctx context.Context, query string) ([]*userpb.User, error) {
+	where := "(username LIKE ? OR mail LIKE ? OR display_name LIKE ? OR id LIKE ?)"
+	like := "%" + escapeLike(query) + "%"
+	args := []interface{}{like, like, like, like}
+
+	if parts := strings.SplitN(query, ":", 2); len(parts) == 2 {
+		if col, ok := claimColumns[parts[0]]; ok {
+			where = col + "=?"
+			args = []interface{}{parts[1]}
+		}
+	}
+	args = append(args, m.c.SearchLimit)
+
+	rows, err := m.db.QueryContext(ctx, selectUser+" WHERE disabled=0 AND "+where+" ORDER BY username LIMIT ?", args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "sql: error searching users")
+	}
+	defer rows.Close()
+
+	users := []*userpb.User{}
+	for rows.Next() {
+		u, err := m.scanUser(rows)
+		if err != nil {
+			return nil, errors.Wrap(err, "sql: error scanning user")
+		}
+		users = append(users, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "sql: error searching users")
+	}
+	return users, nil
+}
+
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+func (m *manager) GetUserGroups(ctx context.Context, uid *userpb.UserId) ([]string, error) {
+	if _, err := m.GetUser(ctx, uid); err != nil {
+		return nil, err
+	}
+	return m.getUserGroups(ctx, uid)
+}
+
+func (m *manager) getUserGroups(ctx context.Context, uid *userpb.UserId) ([]string, error) {
+	rows, err := m.db.QueryContext(ctx, "SELECT g.group_name FROM `groups` g JOIN group_members gm ON g.id=gm.group_id WHERE gm.user_id=? AND gm.user_idp=?", uid.OpaqueId, uid.Idp)
+	if err != nil {
+		return nil, errors.Wrap(err, "sql: error querying user groups")
+	}
+	defer rows.Close()
+
+	groups := []string{}
+	for rows.Next() {
+		var g string
+		if err := rows.Scan(&g); err != nil {
+			return nil, errors.Wrap(err, "sql: error scanning user groups")
+		}
+		groups = append(groups, g)
+	}
+	return groups, rows.Err()
+}
+
+func (m *manager) CreateUser(ctx context.Context, u *userpb.User) (*userpb.User, error) {
+	var exists int
+	if err := m.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM users WHERE username=? OR (id=? AND idp=?)", u.Username, u.Id.OpaqueId, m.idp(u.Id)).Scan(&exists); err != nil {
+		return nil, errors.Wrap(err, "sql: error checking user")
+	}
+	if exists > 0 {
+		return nil, errtypes.AlreadyExists(u.Username)
+	}
+
+	uidNumber, gidNumber := numericIDs(u)
+	_, err := m.db.ExecContext(ctx, "INSERT INTO users (id, idp, username, mail, display_name, uid_number, gid_number, disabled) VALUES (?, ?, ?, ?, ?, ?, ?, 0)",
+		u.Id.OpaqueId, m.idp(u.Id), u.Username, u.Mail, u.DisplayName, uidNumber, gidNumber)
+	if err != nil {
+		return nil, errors.Wrap(err, "sql: error creating user")
+	}
+	return m.GetUser(ctx, &userpb.UserId{OpaqueId: u.Id.OpaqueId, Idp: m.idp(u.Id)})
+}
+
+func (m *manager) UpdateUser(ctx context.Context, u *userpb.User) (*userpb.User, error) {
+	uidNumber, gidNumber := numericIDs(u)
+	res, err := m.db.ExecContext(ctx, "UPDATE users SET username=?, mail=?, display_name=?, uid_number=COALESCE(?, uid_number), gid_number=COALESCE(?, gid_number) WHERE id=? AND idp=? AND disabled=0",
+		u.Username, u.Mail, u.DisplayName, uidNumber, gidNumber, u.Id.OpaqueId, m.idp(u.Id))
+	if err != nil {
+		return nil, errors.Wrap(err, "sql: error updating user")
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		// MySQL does not count the rows left unchanged, check that the user exists
+		if _, err := m.GetUser(ctx, u.Id); err != nil {
+			return nil, err
+		}
+	}
+	return m.GetUser(ctx, &userpb.UserId{OpaqueId: u.Id.OpaqueId, Idp: m.idp(u.Id)})
+}
+
+func (m *manager) DeleteUser(ctx context.Context, uid *userpb.UserId) error {
+	res, err := m.db.ExecContext(ctx, "UPDATE users SET disabled=1 WHERE id=? AND idp=? AND disabled=0", uid.OpaqueId, m.idp(uid))
+	if err != nil {
+		return errors.Wrap(err, "sql: error disabling user")
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return errtypes.NotFound(uid.OpaqueId)
+	}
+	return nil
+}
+
+func (m *manager) idp(uid *userpb.UserId) string {
+	if uid.Idp != "" {
+		return uid.Idp
+	}
+	return m.c.Idp
+}
+
+// numericIDs returns the uid and gid numbers stored in the opaque map of the user, if any.
+func numericIDs(u *userpb.User) (sql.NullInt64, sql.NullInt64) {
+	var uid, gid sql.NullInt64
+	if u.Opaque == nil || u.Opaque.Map == nil {
+		return uid, gid
+	}
+	if e, ok := u.Opaque.Map["uid"]; ok {
+		if v, err := strconv.ParseInt(string(e.Value), 10, 64); err == nil {
+			uid = sql.NullInt64{Int64: v, Valid: true}
+		}
+	}
+	if e, ok := u.Opaque.Map["gid"]; ok {
+		if v, err := strconv.ParseInt(string(e.Value), 10, 64); err == nil {
+			gid = sql.NullInt64{Int64: v, Valid: true}
+		}
+	}
+	return uid, gid
+}
@@ -0,0 +1,252 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package mapping normalizes the attributes of the users returned by the user
+// managers, so that usernames, emails and uid/gid numbers follow the same
+// conventions regardless of the backend the users are stored in.
+package mapping
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"strings"
+	"text/template"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	"github.com/cs3org/reva/pkg/user"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+// Config holds the templates computing the attributes of the users.
+// The templates are evaluated against the attributes returned by the backend,
+// available as {{.Username}}, {{.Mail}}, {{.DisplayName}}, {{.UID}}, {{.GID}},
+// {{.OpaqueID}} and {{.Idp}}. An empty template leaves the attribute unchanged.
+type Config struct {
+	Username    string `mapstructure:"username"`
+	Mail        string `mapstructure:"mail"`
+	DisplayName string `mapstructure:"display_name"`
+	UID         string `mapstructure:"uid"`
+	GID         string `mapstructure:"gid"`
+	// Lookup holds the templates converting the values of the claims used to
+	// look up a user, e.g. username, into the values known by the backend.
+	// The value is available as {{.Value}}.
+	Lookup map[string]string `mapstructure:"lookup"`
+}
+
+// ParseConfig decodes the mapping configuration from a map.
+func ParseConfig(m map[string]interface{}) (*Config, error) {
+	c := &Config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, errors.Wrap(err, "mapping: error decoding conf")
+	}
+	return c, nil
+}
+
+var funcs = template.FuncMap{
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"trim":  strings.TrimSpace,
+	// stripDomain removes the domain from a username or email, e.g. einstein@example.org becomes einstein
+	"stripDomain": func(s string) string {
+		if i := strings.LastIndex(s, "@"); i >= 0 {
+			return s[:i]
+		}
+		if i := strings.Index(s, "\\"); i >= 0 {
+			return s[i+1:]
+		}
+		return s
+	},
+	"replace": func(old, new, s string) string {
+		return strings.ReplaceAll(s, old, new)
+	},
+	// add adds an offset to a numeric id, e.g. {{.UID | add 100000}}
+	"add": func(n int64, s string) (string, error) {
+		v, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return "", err
+		}
+		return strconv.FormatInt(v+n, 10), nil
+	},
+}
+
+type attributes struct {
+	Username    string
+	Mail        string
+	DisplayName string
+	UID         string
+	GID         string
+	OpaqueID    string
+	Idp         string
+}
+
+type mapper struct {
+	user.Manager
+	username, mail, displayName, uid, gid *template.Template
+	lookup                                map[string]*template.Template
+}
+
+// New returns a user manager applying the mapping to the users returned by m.
+func New(c *Config, m user.Manager) (user.Manager, error) {
+	var err error
+	parse := func(name, text string) *template.Template {
+		if text == "" || err != nil {
+			return nil
+		}
+		var t *template.Template
+		t, err = template.New(name).Funcs(funcs).Option("missingkey=error").Parse(text)
+		if err != nil {
+			err = errors.Wrapf(err, "mapping: error parsing template for %s", name)
+		}
+		return t
+	}
+
+	mp := &mapper{
+		Manager:     m,
+		username:    parse("username", c.Username),
+		mail:        parse("mail", c.Mail),
+		displayName: parse("display_name", c.DisplayName),
+		uid:         parse("uid", c.UID),
+		gid:         parse("gid", c.GID),
+		lookup:      map[string]*template.Template{},
+	}
+	for claim, text := range c.Lookup {
+		mp.lookup[claim] = parse(claim, text)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return mp, nil
+}
+
+func execute(t *template.Template, data interface{}, value string) (string, error) {
+	if t == nil {
+		return value, nil
+	}
+	var b bytes.Buffer
+	if err := t.Execute(&b, data); err != nil {
+		return "", errors.Wrapf(err, "mapping: error applying template %s", t.Name())
+	}
+	return b.String(), nil
+}
+
+func opaqueValue(u *userpb.User, key string) string {
+	if u.Opaque == nil || u.Opaque.Map == nil {
+		return ""
+	}
+	if e, ok := u.Opaque.Map[key]; ok && e.Decoder == "plain" {
+		return string(e.Value)
+	}
+	return ""
+}
+
+// apply returns a copy of the user with the mapped attributes.
+func (mp *mapper) apply(u *userpb.User) (*userpb.User, error) {
+	attrs := &attributes{
+		Username:    u.Username,
+		Mail:        u.Mail,
+		DisplayName: u.DisplayName,
+		UID:         opaqueValue(u, "uid"),
+		GID:         opaqueValue(u, "gid"),
+		OpaqueID:    u.Id.GetOpaqueId(),
+		Idp:         u.Id.GetIdp(),
+	}
+
+	mapped := &userpb.User{
+		Id:           u.Id,
+		Groups:       u.Groups,
+		MailVerified: u.MailVerified,
+	}
+	var err error
+	if mapped.Username, err = execute(mp.username, attrs, attrs.Username); err != nil {
+		return nil, err
+	}
+	if mapped.Mail, err = execute(mp.mail, attrs, attrs.Mail); err != nil {
+		return nil, err
+	}
+	if mapped.DisplayName, err = execute(mp.displayName, attrs, attrs.DisplayName); err != nil {
+		return nil, err
+	}
+	uid, err := execute(mp.uid, attrs, attrs.UID)
+	if err != nil {
+		return nil, err
+	}
+	gid, err := execute(mp.gid, attrs, attrs.GID)
+	if err != nil {
+		return nil, err
+	}
+
+	opaque := map[string]*types.OpaqueEntry{}
+	if u.Opaque != nil {
+		for k, v := range u.Opaque.Map {
+			opaque[k] = v
+		}
+	}
+	if uid != "" {
+		opaque["uid"] = &types.OpaqueEntry{Decoder: "plain", Value: []byte(uid)}
+	}
+	if gid != "" {
+		opaque["gid"] = &types.OpaqueEntry{Decoder: "plain", Value: []byte(gid)}
+	}
+	if len(opaque) > 0 {
+		mapped.Opaque = &types.Opaque{Map: opaque}
+	}
+	return mapped, nil
+}
+
+func (mp *mapper) applyAll(users []*userpb.User) ([]*userpb.User, error) {
+	mapped := make([]*userpb.User, 0, len(users))
+	for _, u := range users {
+		m, err := mp.apply(u)
+		if err != nil {
+			return nil, err
+		}
+		mapped = append(mapped, m)
+	}
+	return mapped, nil
+}
+
+func (mp *mapper) GetUser(ctx context.Context, uid *userpb.UserId) (*userpb.User, error) {
+	u, err := mp.Manager.GetUser(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	return mp.apply(u)
+}
+
+func (mp *mapper) GetUserByClaim(ctx context.Context, claim, value string) (*userpb.User, error) {
+	value, err := execute(mp.lookup[claim], struct{ Value string }{value}, value)
+	if err != nil {
+		return nil, err
+	}
+	u, err := mp.Manager.GetUserByClaim(ctx, claim, value)
+	if err != nil {
+		return nil, err
+	}
+	return mp.apply(u)
+}
+
+func (mp *mapper) FindUsers(ctx context.Context, query string) ([]*userpb.User, error) {
+	users, err := mp.Manager.FindUsers(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return mp.applyAll(users)
+}
@@ -0,0 +1,97 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package mapping
+
+import (
+	"context"
+	"testing"
+
+	userpb "github.com/cs3org/go-cs3apis/cs3/identity/user/v1beta1"
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+	"github.com/cs3org/reva/pkg/errtypes"
+)
+
+type backend struct {
+	u *userpb.User
+}
+
+func (b *backend) GetUser(ctx context.Context, uid *userpb.UserId) (*userpb.User, error) {
+	return b.u, nil
+}
+
+func (b *backend) GetUserByClaim(ctx context.Context, claim, value string) (*userpb.User, error) {
+	if claim == "username" && value == b.u.Username {
+		return b.u, nil
+	}
+	return nil, errtypes.NotFound(value)
+}
+
+func (b *backend) GetUserGroups(ctx context.Context, uid *userpb.UserId) ([]string, error) {
+	return b.u.Groups, nil
+}
+
+func (b *backend) FindUsers(ctx context.Context, query string) ([]*userpb.User, error) {
+	return []*userpb.User{b.u}, nil
+}
+
+func TestMapping(t *testing.T) {
+	b := &backend{u: &userpb.User{
+		Id:          &userpb.UserId{Idp: "https://idp.example.org", OpaqueId: "4c510ada"},
+		Username:    "EINSTEIN@EXAMPLE.ORG",
+		Mail:        "Albert.Einstein@Example.org",
+		DisplayName: "Albert Einstein",
+		Opaque: &types.Opaque{Map: map[string]*types.OpaqueEntry{
+			"uid": {Decoder: "plain", Value: []byte("123")},
+			"gid": {Decoder: "plain", Value: []byte("456")},
+		}},
+	}}
+
+	m, err := New(&Config{
+		Username: "{{.Username | stripDomain | lower}}",
+		Mail:     "{{.Mail | lower}}",
+		UID:      "{{.UID | add 100000}}",
+		Lookup:   map[string]string{"username": "{{.Value | upper}}@EXAMPLE.ORG"},
+	}, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	u, err := m.GetUserByClaim(context.Background(), "username", "einstein")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.Username != "einstein" {
+		t.Errorf("unexpected username %s", u.Username)
+	}
+	if u.Mail != "albert.einstein@example.org" {
+		t.Errorf("unexpected mail %s", u.Mail)
+	}
+	if u.DisplayName != "Albert Einstein" {
+		t.Errorf("unexpected display name %s", u.DisplayName)
+	}
+	if uid := string(u.Opaque.Map["uid"].Value); uid != "100123" {
+		t.Errorf("unexpected uid %s", uid)
+	}
+	if gid := string(u.Opaque.Map["gid"].Value); gid != "456" {
+		t.Errorf("unexpected gid %s", gid)
+	}
+	if b.u.Username != "EINSTEIN@EXAMPLE.ORG" {
+		t.Error("the user of the backend must not be modified")
+	}
+}
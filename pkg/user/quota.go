@@ -0,0 +1,48 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package user
+
+import (
+	"strconv"
+
+	types "github.com/cs3org/go-cs3apis/cs3/types/v1beta1"
+)
+
+// QuotaOpaqueKey is the opaque entry in which the user and group providers
+// expose the quota in bytes set in the identity management system.
+const QuotaOpaqueKey = "quota"
+
+// QuotaEntry returns the opaque entry holding the given quota.
+func QuotaEntry(quota string) *types.OpaqueEntry {
+	return &types.OpaqueEntry{Decoder: "plain", Value: []byte(quota)}
+}
+
+// GetQuota returns the quota held in the opaque entries of a user or a
+// group, if any.
+func GetQuota(o *types.Opaque) (uint64, bool) {
+	e, ok := o.GetMap()[QuotaOpaqueKey]
+	if !ok {
+		return 0, false
+	}
+	q, err := strconv.ParseUint(string(e.Value), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return q, true
+}
@@ -29,6 +29,7 @@ type key int
 const (
 	userKey key = iota
 	idKey
+	impersonatorKey
 )
 
 // ContextGetUser returns the user if set in the given context.
@@ -62,6 +63,19 @@ func ContextSetUserID(ctx context.Context, id *userpb.UserId) context.Context {
 	return context.WithValue(ctx, idKey, id)
 }
 
+// ContextGetImpersonator returns the id of the user acting on behalf of the
+// context user, if the request was made with an impersonation token.
+func ContextGetImpersonator(ctx context.Context) (*userpb.UserId, bool) {
+	u, ok := ctx.Value(impersonatorKey).(*userpb.UserId)
+	return u, ok
+}
+
+// ContextSetImpersonator stores the id of the user acting on behalf of the
+// context user.
+func ContextSetImpersonator(ctx context.Context, id *userpb.UserId) context.Context {
+	return context.WithValue(ctx, impersonatorKey, id)
+}
+
 // Manager is the interface to implement to manipulate users.
 type Manager interface {
 	GetUser(ctx context.Context, uid *userpb.UserId) (*userpb.User, error)
@@ -69,3 +83,15 @@ type Manager interface {
 	GetUserGroups(ctx context.Context, uid *userpb.UserId) ([]string, error)
 	FindUsers(ctx context.Context, query string) ([]*userpb.User, error)
 }
+
+// ProvisioningManager is implemented by the user managers whose backend can be
+// written to, allowing users to be provisioned from an identity management system.
+type ProvisioningManager interface {
+	Manager
+	CreateUser(ctx context.Context, u *userpb.User) (*userpb.User, error)
+	UpdateUser(ctx context.Context, u *userpb.User) (*userpb.User, error)
+	// DeleteUser disables the user, which is then no longer returned by the
+	// manager. The record is kept so that the ownership of its resources can
+	// still be resolved.
+	DeleteUser(ctx context.Context, uid *userpb.UserId) error
+}
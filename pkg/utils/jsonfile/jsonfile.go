@@ -0,0 +1,116 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+// Package jsonfile keeps a value in a json file which can be shared by the
+// services of several processes.
+package jsonfile
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// File is a json file read again when it changes on disk and replaced at once
+// when written, for the other processes never to read it half written.
+// It is not safe for concurrent use: the callers hold their own lock while
+// reloading, updating and persisting the value.
+type File struct {
+	path    string
+	modTime time.Time
+	size    int64
+}
+
+// New returns the json file at the given path.
+func New(path string) *File {
+	return &File{path: path}
+}
+
+// Path returns the path of the file.
+func (f *File) Path() string {
+	return f.path
+}
+
+// Reload decodes the file into v if it changed since it was last read or
+// written, and returns whether it did. If the file was removed, v is left
+// untouched and true is returned, for the caller to start from an empty value.
+// An empty or missing file is not an error.
+func (f *File) Reload(v interface{}) (bool, error) {
+	info, err := os.Stat(f.path)
+	if os.IsNotExist(err) {
+		changed := !f.modTime.IsZero()
+		f.modTime, f.size = time.Time{}, 0
+		return changed, nil
+	}
+	if err != nil {
+		return false, errors.Wrapf(err, "jsonfile: error reading %s", f.path)
+	}
+	if info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		return false, nil
+	}
+
+	data, err := ioutil.ReadFile(f.path)
+	if err != nil {
+		return false, errors.Wrapf(err, "jsonfile: error reading %s", f.path)
+	}
+	if len(bytes.TrimSpace(data)) > 0 {
+		if err := json.Unmarshal(data, v); err != nil {
+			return false, errors.Wrapf(err, "jsonfile: error decoding %s", f.path)
+		}
+	}
+	f.modTime, f.size = info.ModTime(), info.Size()
+	return true, nil
+}
+
+// Persist encodes v into the file. It is written to a temporary file in the
+// same directory first, which is then renamed over the file.
+func (f *File) Persist(v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return errors.Wrapf(err, "jsonfile: error encoding %s", f.path)
+	}
+	dir := filepath.Dir(f.path)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return errors.Wrapf(err, "jsonfile: error creating %s", dir)
+	}
+
+	tmp, err := ioutil.TempFile(dir, "."+filepath.Base(f.path)+".*")
+	if err != nil {
+		return errors.Wrapf(err, "jsonfile: error writing %s", f.path)
+	}
+	_, err = tmp.Write(data)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), f.path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return errors.Wrapf(err, "jsonfile: error writing %s", f.path)
+	}
+
+	if info, err := os.Stat(f.path); err == nil {
+		f.modTime, f.size = info.ModTime(), info.Size()
+	}
+	return nil
+}
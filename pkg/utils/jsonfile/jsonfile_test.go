@@ -0,0 +1,84 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+package jsonfile
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestReloadAndPersist(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jsonfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "sub", "values.json")
+
+	f := New(path)
+	v := map[string]int{}
+	if changed, err := f.Reload(&v); err != nil || changed {
+		t.Fatalf("missing file: got %t, %v", changed, err)
+	}
+
+	if err := f.Persist(map[string]int{"a": 1}); err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := f.Reload(&v); err != nil || changed {
+		t.Fatalf("persisted file: got %t, %v", changed, err)
+	}
+	if files, _ := ioutil.ReadDir(filepath.Dir(path)); len(files) != 1 {
+		t.Fatalf("expected the temporary file to be renamed, got %d files", len(files))
+	}
+
+	// another process writes the file
+	other := New(path)
+	if err := other.Persist(map[string]int{"a": 1, "b": 2}); err != nil {
+		t.Fatal(err)
+	}
+	future := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, future, future); err != nil {
+		t.Fatal(err)
+	}
+	v = map[string]int{}
+	if changed, err := f.Reload(&v); err != nil || !changed {
+		t.Fatalf("modified file: got %t, %v", changed, err)
+	}
+	if !reflect.DeepEqual(v, map[string]int{"a": 1, "b": 2}) {
+		t.Fatalf("got %v", v)
+	}
+
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	v = map[string]int{}
+	if changed, err := f.Reload(&v); err != nil || !changed || len(v) != 0 {
+		t.Fatalf("removed file: got %t, %v, %v", changed, v, err)
+	}
+
+	if err := ioutil.WriteFile(path, []byte("{"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Reload(&v); err == nil {
+		t.Fatal("expected an error for a corrupted file")
+	}
+}
@@ -0,0 +1,215 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cs3org/reva/pkg/appctx"
+	"github.com/cs3org/reva/pkg/events"
+	eventsregistry "github.com/cs3org/reva/pkg/events/driver/registry"
+	"github.com/cs3org/reva/pkg/rhttp"
+	"github.com/google/uuid"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+	"github.com/rs/zerolog"
+)
+
+// Headers of the requests sent to the webhooks.
+const (
+	HeaderEvent     = "X-Reva-Event"
+	HeaderDelivery  = "X-Reva-Delivery"
+	HeaderSignature = "X-Reva-Signature"
+)
+
+// DispatcherConfig is the configuration of the dispatcher.
+type DispatcherConfig struct {
+	// Events configures the bus the events are consumed from.
+	Events map[string]interface{} `mapstructure:"events"`
+	// Timeout is the timeout in seconds of the requests to the webhooks.
+	Timeout  int  `mapstructure:"timeout"`
+	Insecure bool `mapstructure:"insecure"`
+	// MaxRetries is the number of retries of the failed deliveries, before
+	// they are moved to the dead-letter queue.
+	MaxRetries int `mapstructure:"max_retries"`
+	// RetryInterval is the time in seconds before the first retry, which
+	// doubles after each failure.
+	RetryInterval int `mapstructure:"retry_interval"`
+}
+
+// ParseDispatcherConfig decodes the configuration of the dispatcher from a map.
+func ParseDispatcherConfig(m map[string]interface{}) (*DispatcherConfig, error) {
+	c := &DispatcherConfig{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, errors.Wrap(err, "webhook: error decoding conf")
+	}
+	if c.Timeout == 0 {
+		c.Timeout = 10
+	}
+	if c.MaxRetries == 0 {
+		c.MaxRetries = 5
+	}
+	if c.RetryInterval == 0 {
+		c.RetryInterval = 10
+	}
+	return c, nil
+}
+
+// Dispatcher delivers the events to the webhooks.
+type Dispatcher struct {
+	c      *DispatcherConfig
+	m      Manager
+	stream events.Consumer
+	client *http.Client
+	log    *zerolog.Logger
+}
+
+// NewDispatcher returns a dispatcher delivering the events to the webhooks
+// stored in m.
+func NewDispatcher(c *DispatcherConfig, m Manager, log *zerolog.Logger) (*Dispatcher, error) {
+	stream, err := eventsregistry.NewStream(c.Events)
+	if err != nil {
+		return nil, errors.Wrap(err, "webhook: error creating events stream")
+	}
+	return &Dispatcher{
+		c:      c,
+		m:      m,
+		stream: stream,
+		client: rhttp.GetHTTPClient(
+			rhttp.Timeout(time.Duration(c.Timeout)*time.Second),
+			rhttp.Insecure(c.Insecure),
+		),
+		log: log,
+	}, nil
+}
+
+// Run delivers the events consumed from the bus until stop is closed.
+func (d *Dispatcher) Run(stop <-chan struct{}) {
+	ctx, cancel := context.WithCancel(appctx.WithLogger(context.Background(), d.log))
+	defer cancel()
+	go func() {
+		<-stop
+		cancel()
+	}()
+
+	msgs, err := d.stream.Consume(ctx, "webhooks")
+	if err != nil {
+		d.log.Error().Err(err).Msg("webhook: error consuming events")
+		return
+	}
+	for msg := range msgs {
+		webhooks, err := d.m.ListWebhooks(ctx)
+		if err != nil {
+			d.log.Error().Err(err).Str("type", msg.Type).Msg("webhook: error listing webhooks")
+			continue
+		}
+		for _, w := range webhooks {
+			if !w.Matches(msg.Type) {
+				continue
+			}
+			p := &Payload{
+				ID:   uuid.New().String(),
+				Type: msg.Type,
+				Time: time.Now(),
+				Data: json.RawMessage(msg.Data),
+			}
+			go d.deliverWithRetries(ctx, w, p)
+		}
+	}
+}
+
+// deliverWithRetries delivers the payload, retrying with an exponential
+// backoff, and moves it to the dead-letter queue if all the attempts failed.
+func (d *Dispatcher) deliverWithRetries(ctx context.Context, w *Webhook, p *Payload) {
+	interval := time.Duration(d.c.RetryInterval) * time.Second
+	var err error
+	attempts := 1
+	for ; ; attempts++ {
+		if err = d.Deliver(ctx, w, p); err == nil {
+			return
+		}
+		d.log.Warn().Err(err).Str("webhook", w.ID).Str("delivery", p.ID).Int("attempt", attempts).Msg("webhook: delivery failed")
+		if attempts > d.c.MaxRetries {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(interval):
+		}
+		interval *= 2
+	}
+
+	dl := &DeadLetter{
+		WebhookID: w.ID,
+		Payload:   p,
+		Attempts:  attempts,
+		LastError: err.Error(),
+		Time:      time.Now(),
+	}
+	if err := d.m.AddDeadLetter(ctx, dl); err != nil {
+		d.log.Error().Err(err).Str("webhook", w.ID).Str("delivery", p.ID).Msg("webhook: error storing dead letter")
+	}
+}
+
+// Deliver sends the payload to the webhook once.
+func (d *Dispatcher) Deliver(ctx context.Context, w *Webhook, p *Payload) error {
+	body, err := json.Marshal(p)
+	if err != nil {
+		return errors.Wrap(err, "webhook: error encoding payload")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return errors.Wrap(err, "webhook: error creating request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, p.Type)
+	req.Header.Set(HeaderDelivery, p.ID)
+	if w.Secret != "" {
+		req.Header.Set(HeaderSignature, Sign(w.Secret, body))
+	}
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("webhook: unexpected status %s", res.Status)
+	}
+	return nil
+}
+
+// Sign returns the signature of the body sent in the X-Reva-Signature
+// header, i.e. the hex encoded HMAC-SHA256 of the body keyed with the secret
+// of the webhook, prefixed with sha256=.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
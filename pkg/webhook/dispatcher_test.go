@@ -0,0 +1,48 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package webhook
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDeliver(t *testing.T) {
+	var signature, body string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := ioutil.ReadAll(r.Body)
+		body = string(data)
+		signature = r.Header.Get(HeaderSignature)
+	}))
+	defer srv.Close()
+
+	d := &Dispatcher{client: srv.Client()}
+	w := &Webhook{ID: "1", URL: srv.URL, Secret: "secret"}
+	p := &Payload{ID: "delivery", Type: "FileUploaded", Data: []byte(`{"Path":"/file"}`)}
+	if err := d.Deliver(context.Background(), w, p); err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := Sign("secret", []byte(body)); signature != expected {
+		t.Fatalf("got signature %q, expected %q", signature, expected)
+	}
+}
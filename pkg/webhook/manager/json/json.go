@@ -0,0 +1,200 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package json
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/utils/jsonfile"
+	"github.com/cs3org/reva/pkg/webhook"
+	"github.com/cs3org/reva/pkg/webhook/manager/registry"
+	"github.com/mitchellh/mapstructure"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	registry.Register("json", New)
+}
+
+type config struct {
+	File string `mapstructure:"file"`
+}
+
+func (c *config) init() {
+	if c.File == "" {
+		c.File = "/var/tmp/reva/webhooks.json"
+	}
+}
+
+type db struct {
+	Webhooks    map[string]*webhook.Webhook    `json:"webhooks"`
+	DeadLetters map[string]*webhook.DeadLetter `json:"dead_letters"`
+}
+
+type manager struct {
+	sync.Mutex
+	c    *config
+	file *jsonfile.File
+	db   *db
+}
+
+// New returns a webhook manager storing the webhooks and the dead letters in
+// a JSON file.
+func New(m map[string]interface{}) (webhook.Manager, error) {
+	c := &config{}
+	if err := mapstructure.Decode(m, c); err != nil {
+		return nil, errors.Wrap(err, "error decoding conf")
+	}
+	c.init()
+
+	mgr := &manager{c: c, file: jsonfile.New(c.File), db: newDB()}
+	if err := mgr.reload(); err != nil {
+		return nil, err
+	}
+	return mgr, nil
+}
+
+func newDB() *db {
+	return &db{
+		Webhooks:    map[string]*webhook.Webhook{},
+		DeadLetters: map[string]*webhook.DeadLetter{},
+	}
+}
+
+func (m *manager) reload() error {
+	d := newDB()
+	changed, err := m.file.Reload(d)
+	if err != nil {
+		return errors.Wrap(err, "webhook: error reading webhooks")
+	}
+	if changed {
+		m.db = d
+	}
+	return nil
+}
+
+func (m *manager) persist() error {
+	if err := m.file.Persist(m.db); err != nil {
+		return errors.Wrap(err, "webhook: error writing webhooks")
+	}
+	return nil
+}
+
+func (m *manager) AddWebhook(ctx context.Context, w *webhook.Webhook) error {
+	m.Lock()
+	defer m.Unlock()
+	if err := m.reload(); err != nil {
+		return err
+	}
+	m.db.Webhooks[w.ID] = w
+	return m.persist()
+}
+
+func (m *manager) GetWebhook(ctx context.Context, id string) (*webhook.Webhook, error) {
+	m.Lock()
+	defer m.Unlock()
+	if err := m.reload(); err != nil {
+		return nil, err
+	}
+	w, ok := m.db.Webhooks[id]
+	if !ok {
+		return nil, errtypes.NotFound(id)
+	}
+	return w, nil
+}
+
+func (m *manager) ListWebhooks(ctx context.Context) ([]*webhook.Webhook, error) {
+	m.Lock()
+	defer m.Unlock()
+	if err := m.reload(); err != nil {
+		return nil, err
+	}
+	webhooks := make([]*webhook.Webhook, 0, len(m.db.Webhooks))
+	for _, w := range m.db.Webhooks {
+		webhooks = append(webhooks, w)
+	}
+	sort.Slice(webhooks, func(i, j int) bool { return webhooks[i].Created.Before(webhooks[j].Created) })
+	return webhooks, nil
+}
+
+func (m *manager) DeleteWebhook(ctx context.Context, id string) error {
+	m.Lock()
+	defer m.Unlock()
+	if err := m.reload(); err != nil {
+		return err
+	}
+	if _, ok := m.db.Webhooks[id]; !ok {
+		return errtypes.NotFound(id)
+	}
+	delete(m.db.Webhooks, id)
+	return m.persist()
+}
+
+func (m *manager) AddDeadLetter(ctx context.Context, d *webhook.DeadLetter) error {
+	m.Lock()
+	defer m.Unlock()
+	if err := m.reload(); err != nil {
+		return err
+	}
+	m.db.DeadLetters[d.Payload.ID] = d
+	return m.persist()
+}
+
+func (m *manager) GetDeadLetter(ctx context.Context, id string) (*webhook.DeadLetter, error) {
+	m.Lock()
+	defer m.Unlock()
+	if err := m.reload(); err != nil {
+		return nil, err
+	}
+	d, ok := m.db.DeadLetters[id]
+	if !ok {
+		return nil, errtypes.NotFound(id)
+	}
+	return d, nil
+}
+
+func (m *manager) ListDeadLetters(ctx context.Context) ([]*webhook.DeadLetter, error) {
+	m.Lock()
+	defer m.Unlock()
+	if err := m.reload(); err != nil {
+		return nil, err
+	}
+	deadLetters := make([]*webhook.DeadLetter, 0, len(m.db.DeadLetters))
+	for _, d := range m.db.DeadLetters {
+		deadLetters = append(deadLetters, d)
+	}
+	sort.Slice(deadLetters, func(i, j int) bool { return deadLetters[i].Time.Before(deadLetters[j].Time) })
+	return deadLetters, nil
+}
+
+func (m *manager) DeleteDeadLetter(ctx context.Context, id string) error {
+	m.Lock()
+	defer m.Unlock()
+	if err := m.reload(); err != nil {
+		return err
+	}
+	if _, ok := m.db.DeadLetters[id]; !ok {
+		return errtypes.NotFound(id)
+	}
+	delete(m.db.DeadLetters, id)
+	return m.persist()
+}
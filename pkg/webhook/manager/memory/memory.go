@@ -0,0 +1,123 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+package memory
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/cs3org/reva/pkg/errtypes"
+	"github.com/cs3org/reva/pkg/webhook"
+	"github.com/cs3org/reva/pkg/webhook/manager/registry"
+)
+
+func init() {
+	registry.Register("memory", New)
+}
+
+type manager struct {
+	sync.Mutex
+	webhooks    map[string]*webhook.Webhook
+	deadLetters map[string]*webhook.DeadLetter
+}
+
+// New returns a webhook manager keeping the webhooks in memory.
+func New(m map[string]interface{}) (webhook.Manager, error) {
+	return &manager{
+		webhooks:    map[string]*webhook.Webhook{},
+		deadLetters: map[string]*webhook.DeadLetter{},
+	}, nil
+}
+
+func (m *manager) AddWebhook(ctx context.Context, w *webhook.Webhook) error {
+	m.Lock()
+	defer m.Unlock()
+	m.webhooks[w.ID] = w
+	return nil
+}
+
+func (m *manager) GetWebhook(ctx context.Context, id string) (*webhook.Webhook, error) {
+	m.Lock()
+	defer m.Unlock()
+	w, ok := m.webhooks[id]
+	if !ok {
+		return nil, errtypes.NotFound(id)
+	}
+	return w, nil
+}
+
+func (m *manager) ListWebhooks(ctx context.Context) ([]*webhook.Webhook, error) {
+	m.Lock()
+	defer m.Unlock()
+	webhooks := make([]*webhook.Webhook, 0, len(m.webhooks))
+	for _, w := range m.webhooks {
+		webhooks = append(webhooks, w)
+	}
+	sort.Slice(webhooks, func(i, j int) bool { return webhooks[i].Created.Before(webhooks[j].Created) })
+	return webhooks, nil
+}
+
+func (m *manager) DeleteWebhook(ctx context.Context, id string) error {
+	m.Lock()
+	defer m.Unlock()
+	if _, ok := m.webhooks[id]; !ok {
+		return errtypes.NotFound(id)
+	}
+	delete(m.webhooks, id)
+	return nil
+}
+
+func (m *manager) AddDeadLetter(ctx context.Context, d *webhook.DeadLetter) error {
+	m.Lock()
+	defer m.Unlock()
+	m.deadLetters[d.Payload.ID] = d
+	return nil
+}
+
+func (m *manager) GetDeadLetter(ctx context.Context, id string) (*webhook.DeadLetter, error) {
+	m.Lock()
+	defer m.Unlock()
+	d, ok := m.deadLetters[id]
+	if !ok {
+		return nil, errtypes.NotFound(id)
+	}
+	return d, nil
+}
+
+func (m *manager) ListDeadLetters(ctx context.Context) ([]*webhook.DeadLetter, error) {
+	m.Lock()
+	defer m.Unlock()
+	deadLetters := make([]*webhook.DeadLetter, 0, len(m.deadLetters))
+	for _, d := range m.deadLetters {
+		deadLetters = append(deadLetters, d)
+	}
+	sort.Slice(deadLetters, func(i, j int) bool { return deadLetters[i].Time.Before(deadLetters[j].Time) })
+	return deadLetters, nil
+}
+
+func (m *manager) DeleteDeadLetter(ctx context.Context, id string) error {
+	m.Lock()
+	defer m.Unlock()
+	if _, ok := m.deadLetters[id]; !ok {
+		return errtypes.NotFound(id)
+	}
+	delete(m.deadLetters, id)
+	return nil
+}
@@ -0,0 +1,87 @@
+// Copyright 2018-2021 CERN
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+// In applying this license, CERN does not waive the privileges and immunities
+// granted to it by virtue of its status as an Intergovernmental Organization
+// or submit itself to any jurisdiction.
+
+// Package webhook delivers the events published to the events bus to the URLs
+// registered by the administrators, so that external systems can react to
+// them.
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// Webhook is a URL the events are delivered to.
+type Webhook struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+	// Events is the list of the types of the delivered events, e.g.
+	// FileUploaded. If empty, every event is delivered.
+	Events []string `json:"events,omitempty"`
+	// Secret is the key the payloads are signed with.
+	Secret  string    `json:"secret,omitempty"`
+	Created time.Time `json:"created"`
+}
+
+// Matches returns whether the events of the given type are delivered to the
+// webhook.
+func (w *Webhook) Matches(typ string) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == "*" || e == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// Payload is the body of the requests sent to the webhooks.
+type Payload struct {
+	// ID is the id of the delivery, which is kept across the retries.
+	ID   string          `json:"id"`
+	Type string          `json:"type"`
+	Time time.Time       `json:"time"`
+	Data json.RawMessage `json:"data"`
+}
+
+// DeadLetter is a delivery that failed after all the retries.
+type DeadLetter struct {
+	WebhookID string    `json:"webhook_id"`
+	Payload   *Payload  `json:"payload"`
+	Attempts  int       `json:"attempts"`
+	LastError string    `json:"last_error"`
+	Time      time.Time `json:"time"`
+}
+
+// Manager is the interface to implement to store the webhooks and the failed
+// deliveries.
+type Manager interface {
+	AddWebhook(ctx context.Context, w *Webhook) error
+	GetWebhook(ctx context.Context, id string) (*Webhook, error)
+	ListWebhooks(ctx context.Context) ([]*Webhook, error)
+	DeleteWebhook(ctx context.Context, id string) error
+
+	// AddDeadLetter stores a failed delivery, identified by the id of its payload.
+	AddDeadLetter(ctx context.Context, d *DeadLetter) error
+	GetDeadLetter(ctx context.Context, id string) (*DeadLetter, error)
+	ListDeadLetters(ctx context.Context) ([]*DeadLetter, error)
+	DeleteDeadLetter(ctx context.Context, id string) error
+}